@@ -0,0 +1,105 @@
+// Package alert records the acknowledgements of the alerts of checks in the
+// monitoring bucket, where the flux of notification rules reads them.
+package alert
+
+import (
+	"context"
+
+	influxdb "github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage"
+)
+
+// names of the measurement, tag and fields of acknowledgements. They are
+// queried by the flux generated for notification rules.
+const (
+	ackMeasurement = "acks"
+	checkIDTag     = "_check_id"
+	ackedByField   = "_acked_by"
+	messageField   = "_message"
+	expiresAtField = "_expires"
+)
+
+var _ influxdb.AlertAckService = (*AckService)(nil)
+
+// AckService records acknowledgements with a points writer.
+type AckService struct {
+	checks  influxdb.CheckService
+	buckets influxdb.BucketService
+	pw      storage.PointsWriter
+
+	TimeGenerator influxdb.TimeGenerator
+}
+
+// NewAckService constructs a new acknowledgement service. The checks service is
+// used to find the organization of a check, and the bucket service its
+// monitoring bucket.
+func NewAckService(checks influxdb.CheckService, buckets influxdb.BucketService, pw storage.PointsWriter) *AckService {
+	return &AckService{
+		checks:        checks,
+		buckets:       buckets,
+		pw:            pw,
+		TimeGenerator: influxdb.RealTimeGenerator{},
+	}
+}
+
+// AckAlert acknowledges the alert of a check for ack.Duration, on behalf of
+// the user on the context.
+func (s *AckService) AckAlert(ctx context.Context, ack *influxdb.AlertAck) error {
+	if err := ack.Valid(); err != nil {
+		return err
+	}
+	if a, err := icontext.GetAuthorizer(ctx); err == nil {
+		ack.AckedBy = a.GetUserID()
+	}
+	d := ack.Duration
+	if d == 0 {
+		d = influxdb.DefaultAlertAckDuration
+	}
+	ack.AckedAt = s.TimeGenerator.Now().UTC()
+	ack.ExpiresAt = ack.AckedAt.Add(d)
+	return s.write(ctx, ack)
+}
+
+// UnackAlert expires the acknowledgement of the alert of a check.
+func (s *AckService) UnackAlert(ctx context.Context, checkID platform.ID) error {
+	now := s.TimeGenerator.Now().UTC()
+	ack := &influxdb.AlertAck{
+		CheckID:   checkID,
+		AckedAt:   now,
+		ExpiresAt: now,
+	}
+	if a, err := icontext.GetAuthorizer(ctx); err == nil {
+		ack.AckedBy = a.GetUserID()
+	}
+	return s.write(ctx, ack)
+}
+
+func (s *AckService) write(ctx context.Context, ack *influxdb.AlertAck) error {
+	c, err := s.checks.FindCheckByID(ctx, ack.CheckID)
+	if err != nil {
+		return err
+	}
+	ack.OrgID = c.GetOrgID()
+
+	b, err := s.buckets.FindBucketByName(ctx, ack.OrgID, influxdb.MonitoringSystemBucketName)
+	if err != nil {
+		return err
+	}
+
+	tags := models.NewTags(map[string]string{
+		checkIDTag: ack.CheckID.String(),
+	})
+	fields := map[string]interface{}{
+		ackedByField:   ack.AckedBy.String(),
+		messageField:   ack.Message,
+		expiresAtField: ack.ExpiresAt.UnixNano(),
+	}
+	p, err := models.NewPoint(ackMeasurement, tags, fields, ack.AckedAt)
+	if err != nil {
+		return err
+	}
+	return s.pw.WritePoints(ctx, ack.OrgID, b.ID, models.Points{p})
+}
@@ -0,0 +1,64 @@
+package alert
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/notification/check"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(pw *mock.PointsWriter) *AckService {
+	checks := mock.NewCheckService()
+	checks.FindCheckByIDFn = func(_ context.Context, id platform.ID) (influxdb.Check, error) {
+		return &check.Deadman{Base: check.Base{ID: id, OrgID: 2}}, nil
+	}
+	buckets := mock.NewBucketService()
+	buckets.FindBucketByNameFn = func(_ context.Context, orgID platform.ID, name string) (*influxdb.Bucket, error) {
+		return &influxdb.Bucket{ID: 3, OrgID: orgID, Name: name}, nil
+	}
+	s := NewAckService(checks, buckets, pw)
+	s.TimeGenerator = mock.TimeGenerator{FakeValue: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	return s
+}
+
+func TestAckService(t *testing.T) {
+	pw := &mock.PointsWriter{}
+	s := newTestService(pw)
+	ctx := icontext.SetAuthorizer(context.Background(), &influxdb.Authorization{UserID: 4})
+
+	ack := &influxdb.AlertAck{CheckID: 1, Message: "on it"}
+	require.NoError(t, s.AckAlert(ctx, ack))
+	require.Equal(t, platform.ID(2), ack.OrgID)
+	require.Equal(t, platform.ID(4), ack.AckedBy)
+	require.Equal(t, time.Date(2021, 1, 1, 4, 0, 0, 0, time.UTC), ack.ExpiresAt)
+
+	require.NoError(t, s.UnackAlert(ctx, 1))
+
+	require.Len(t, pw.Points, 2)
+	p := pw.Points[0]
+	require.Equal(t, "acks", string(p.Name()))
+	require.Equal(t, "0000000000000001", p.Tags().GetString(checkIDTag))
+	fields, err := p.Fields()
+	require.NoError(t, err)
+	require.Equal(t, ack.ExpiresAt.UnixNano(), fields[expiresAtField])
+	require.Equal(t, "on it", fields[messageField])
+
+	fields, err = pw.Points[1].Fields()
+	require.NoError(t, err)
+	require.Equal(t, pw.Points[1].Time().UnixNano(), fields[expiresAtField])
+}
+
+func TestAckService_Invalid(t *testing.T) {
+	pw := &mock.PointsWriter{}
+	s := newTestService(pw)
+
+	err := s.AckAlert(context.Background(), &influxdb.AlertAck{CheckID: 1, Duration: 48 * time.Hour})
+	require.Error(t, err)
+	require.Empty(t, pw.Points)
+}
@@ -0,0 +1,139 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const prefixAlerts = "/api/v2/alerts"
+
+// AlertHandler is the handler for the alert acknowledgement service.
+type AlertHandler struct {
+	chi.Router
+
+	api *kithttp.API
+	log *zap.Logger
+
+	ackService influxdb.AlertAckService
+}
+
+// NewAlertHandler returns a new instance of AlertHandler.
+func NewAlertHandler(log *zap.Logger, ackService influxdb.AlertAckService) *AlertHandler {
+	h := &AlertHandler{
+		log:        log,
+		api:        kithttp.NewAPI(kithttp.WithLog(log)),
+		ackService: ackService,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+	r.Route("/{id}/ack", func(r chi.Router) {
+		r.Post("/", h.handlePostAck)
+		r.Delete("/", h.handleDeleteAck)
+	})
+	h.Router = r
+	return h
+}
+
+// Prefix returns the mounting prefix for the handler.
+func (h *AlertHandler) Prefix() string {
+	return prefixAlerts
+}
+
+type ackRequest struct {
+	Message string `json:"message"`
+	// Duration is a duration such as 30m, or empty for the default duration.
+	Duration string `json:"duration"`
+}
+
+type ackResponse struct {
+	*influxdb.AlertAck
+	Links map[string]string `json:"links"`
+}
+
+func decodeAlertID(r *http.Request) (platform.ID, error) {
+	var id platform.ID
+	if err := id.DecodeFromString(chi.URLParam(r, "id")); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// handlePostAck acknowledges the alert of a check.
+func (h *AlertHandler) handlePostAck(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := decodeAlertID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var req ackRequest
+	if r.ContentLength != 0 {
+		if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+	}
+	ack := &influxdb.AlertAck{
+		CheckID: id,
+		Message: req.Message,
+	}
+	if req.Duration != "" {
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil {
+			h.api.Err(w, r, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "invalid alert acknowledgement duration",
+				Err:  err,
+			})
+			return
+		}
+		ack.Duration = d
+	}
+
+	if err := h.ackService.AckAlert(ctx, ack); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Alert acknowledged", zap.String("checkID", id.String()), zap.Time("expiresAt", ack.ExpiresAt))
+
+	h.api.Respond(w, r, http.StatusCreated, ackResponse{
+		AlertAck: ack,
+		Links: map[string]string{
+			"self":  fmt.Sprintf("%s/%s/ack", prefixAlerts, id),
+			"check": fmt.Sprintf("/api/v2/checks/%s", id),
+		},
+	})
+}
+
+// handleDeleteAck removes the acknowledgement of the alert of a check.
+func (h *AlertHandler) handleDeleteAck(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := decodeAlertID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.ackService.UnackAlert(ctx, id); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Alert acknowledgement removed", zap.String("checkID", id.String()))
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,77 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type fakeAckService struct {
+	acked   *influxdb.AlertAck
+	unacked platform.ID
+}
+
+func (s *fakeAckService) AckAlert(ctx context.Context, ack *influxdb.AlertAck) error {
+	if err := ack.Valid(); err != nil {
+		return err
+	}
+	ack.OrgID = 1
+	ack.AckedAt = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	ack.ExpiresAt = ack.AckedAt.Add(ack.Duration)
+	s.acked = ack
+	return nil
+}
+
+func (s *fakeAckService) UnackAlert(ctx context.Context, checkID platform.ID) error {
+	s.unacked = checkID
+	return nil
+}
+
+func TestAlertHandler(t *testing.T) {
+	svc := &fakeAckService{}
+	h := NewAlertHandler(zaptest.NewLogger(t), svc)
+	r := chi.NewRouter()
+	r.Mount(h.Prefix(), h)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, "/api/v2/alerts"+path, strings.NewReader(body)))
+		return w
+	}
+
+	w := do(http.MethodPost, "/000000000000000a/ack", `{"message": "on it", "duration": "30m"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	require.Equal(t, platform.ID(10), svc.acked.CheckID)
+	require.Equal(t, "on it", svc.acked.Message)
+	require.Equal(t, 30*time.Minute, svc.acked.Duration)
+	var res struct {
+		ExpiresAt time.Time         `json:"expiresAt"`
+		Links     map[string]string `json:"links"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Equal(t, time.Date(2021, 1, 1, 0, 30, 0, 0, time.UTC), res.ExpiresAt)
+	require.Equal(t, "/api/v2/checks/000000000000000a", res.Links["check"])
+
+	w = do(http.MethodPost, "/000000000000000a/ack", "")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = do(http.MethodPost, "/000000000000000a/ack", `{"duration": "48h"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = do(http.MethodPost, "/000000000000000a/ack", `{"duration": "soon"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = do(http.MethodDelete, "/000000000000000a/ack", "")
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	require.Equal(t, platform.ID(10), svc.unacked)
+}
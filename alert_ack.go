@@ -0,0 +1,68 @@
+package influxdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+const (
+	// DefaultAlertAckDuration is how long an alert is acknowledged for, unless
+	// a duration is given.
+	DefaultAlertAckDuration = 4 * time.Hour
+
+	// MaxAlertAckDuration is the longest an alert can be acknowledged for.
+	MaxAlertAckDuration = 24 * time.Hour
+)
+
+// ops for alert acknowledgement service.
+const (
+	OpAckAlert   = "AckAlert"
+	OpUnackAlert = "UnackAlert"
+)
+
+// AlertAckService acknowledges the alerts of checks. The ID of an alert is the
+// ID of its check. Acknowledgements are recorded in the monitoring bucket of
+// the organization of the check, and the statuses of an acknowledged check are
+// neither notified again nor escalated by notification rules until it expires.
+type AlertAckService interface {
+	// AckAlert acknowledges the alert of ack.CheckID, and sets the
+	// organization, time and expiry of ack.
+	AckAlert(ctx context.Context, ack *AlertAck) error
+
+	// UnackAlert removes the acknowledgement of the alert of a check.
+	UnackAlert(ctx context.Context, checkID platform.ID) error
+}
+
+// AlertAck is the acknowledgement of the alert of a check.
+type AlertAck struct {
+	CheckID platform.ID `json:"checkID"`
+	OrgID   platform.ID `json:"orgID"`
+	// AckedBy is the user who acknowledged the alert.
+	AckedBy platform.ID `json:"ackedBy,omitempty"`
+	Message string      `json:"message,omitempty"`
+	// Duration is how long the alert is acknowledged for. It defaults to
+	// DefaultAlertAckDuration.
+	Duration  time.Duration `json:"-"`
+	AckedAt   time.Time     `json:"ackedAt"`
+	ExpiresAt time.Time     `json:"expiresAt"`
+}
+
+// Valid returns an error if the acknowledgement is invalid.
+func (a *AlertAck) Valid() error {
+	if !a.CheckID.Valid() {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "alert acknowledgement checkID is invalid",
+		}
+	}
+	if a.Duration < 0 || a.Duration > MaxAlertAckDuration {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "alert acknowledgement duration must be between 0 and " + MaxAlertAckDuration.String(),
+		}
+	}
+	return nil
+}
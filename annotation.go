@@ -63,6 +63,10 @@ var (
 		Code: errors.EInvalid,
 		Msg:  "start time must be set",
 	}
+	errNegativeStreamRetention = &errors.Error{
+		Code: errors.EInvalid,
+		Msg:  "stream retention cannot be negative",
+	}
 )
 
 func invalidStickerError(s string) error {
@@ -96,6 +100,8 @@ type AnnotationService interface {
 	GetAnnotation(ctx context.Context, id platform.ID) (*StoredAnnotation, error)
 	// DeleteAnnotations deletes annotations matching the filter.
 	DeleteAnnotations(ctx context.Context, orgID platform.ID, delete AnnotationDeleteFilter) error
+	// DeleteAnnotationsByID deletes the annotations of the org with the provided ids.
+	DeleteAnnotationsByID(ctx context.Context, orgID platform.ID, ids []platform.ID) error
 	// DeleteAnnotation deletes an annotation by id.
 	DeleteAnnotation(ctx context.Context, id platform.ID) error
 	// UpdateAnnotation updates an annotation.
@@ -358,8 +364,9 @@ type ReadAnnotation struct {
 
 // AnnotationListFilter is a selection filter for listing annotations.
 type AnnotationListFilter struct {
-	StickerIncludes AnnotationStickers `json:"stickerIncludes,omitempty"` // StickerIncludes allows the user to filter annotated events based on it's sticker.
-	StreamIncludes  []string           `json:"streamIncludes,omitempty"`  // StreamIncludes allows the user to filter annotated events by stream.
+	StickerIncludes  AnnotationStickers `json:"stickerIncludes,omitempty"`  // StickerIncludes allows the user to filter annotated events based on it's sticker.
+	StickerPredicate string             `json:"stickerPredicate,omitempty"` // StickerPredicate allows the user to filter annotated events with a predicate on their stickers, like `env="prod" AND team!="db"`.
+	StreamIncludes   []string           `json:"streamIncludes,omitempty"`   // StreamIncludes allows the user to filter annotated events by stream.
 	BasicFilter
 }
 
@@ -396,17 +403,19 @@ func (f *StreamListFilter) Validate(nowFunc func() time.Time) error {
 
 // Stream defines the stream metadata. Used in create and update requests/responses. Delete requests will only require stream name.
 type Stream struct {
-	Name        string `json:"stream"`                // Name is the name of a stream.
-	Description string `json:"description,omitempty"` // Description is more information about a stream.
+	Name             string `json:"stream"`                     // Name is the name of a stream.
+	Description      string `json:"description,omitempty"`      // Description is more information about a stream.
+	RetentionSeconds int64  `json:"retentionSeconds,omitempty"` // RetentionSeconds is how long the annotated events of a stream are kept after they end. Zero keeps them forever.
 }
 
 // ReadStream defines the returned stream.
 type ReadStream struct {
-	ID          platform.ID `json:"id" db:"id"`                             // ID is the id of a stream.
-	Name        string      `json:"stream" db:"name"`                       // Name is the name of a stream.
-	Description string      `json:"description,omitempty" db:"description"` // Description is more information about a stream.
-	CreatedAt   time.Time   `json:"createdAt" db:"created_at"`              // CreatedAt is a timestamp.
-	UpdatedAt   time.Time   `json:"updatedAt" db:"updated_at"`              // UpdatedAt is a timestamp.
+	ID               platform.ID `json:"id" db:"id"`                                        // ID is the id of a stream.
+	Name             string      `json:"stream" db:"name"`                                  // Name is the name of a stream.
+	Description      string      `json:"description,omitempty" db:"description"`            // Description is more information about a stream.
+	RetentionSeconds int64       `json:"retentionSeconds,omitempty" db:"retention_seconds"` // RetentionSeconds is how long the annotated events of a stream are kept after they end.
+	CreatedAt        time.Time   `json:"createdAt" db:"created_at"`                         // CreatedAt is a timestamp.
+	UpdatedAt        time.Time   `json:"updatedAt" db:"updated_at"`                         // UpdatedAt is a timestamp.
 }
 
 // IsValid validates the stream.
@@ -425,17 +434,22 @@ func (s *Stream) Validate(strict bool) error {
 		return errStreamDescTooLong
 	}
 
+	if s.RetentionSeconds < 0 {
+		return errNegativeStreamRetention
+	}
+
 	return nil
 }
 
 // StoredStream represents stream data to be stored in the metadata database.
 type StoredStream struct {
-	ID          platform.ID `db:"id"`          // ID is the stream's id.
-	OrgID       platform.ID `db:"org_id"`      // OrgID is the stream's owning organization.
-	Name        string      `db:"name"`        // Name is the name of a stream.
-	Description string      `db:"description"` // Description is more information about a stream.
-	CreatedAt   time.Time   `db:"created_at"`  // CreatedAt is a timestamp.
-	UpdatedAt   time.Time   `db:"updated_at"`  // UpdatedAt is a timestamp.
+	ID               platform.ID `db:"id"`                // ID is the stream's id.
+	OrgID            platform.ID `db:"org_id"`            // OrgID is the stream's owning organization.
+	Name             string      `db:"name"`              // Name is the name of a stream.
+	Description      string      `db:"description"`       // Description is more information about a stream.
+	RetentionSeconds int64       `db:"retention_seconds"` // RetentionSeconds is how long the annotated events of a stream are kept after they end.
+	CreatedAt        time.Time   `db:"created_at"`        // CreatedAt is a timestamp.
+	UpdatedAt        time.Time   `db:"updated_at"`        // UpdatedAt is a timestamp.
 }
 
 // BasicStream defines a stream by name. Used for stream deletes.
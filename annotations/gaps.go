@@ -0,0 +1,231 @@
+package annotations
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+	"go.uber.org/zap"
+)
+
+// GapStream is the stream of the annotations of gaps in the writes to buckets.
+const GapStream = "gaps"
+
+// GapConfig configures a GapDetector.
+type GapConfig struct {
+	// Enabled enables the detection of gaps.
+	Enabled bool
+	// Window is how long a measurement must receive no writes for before the
+	// time without writes is annotated as a gap.
+	Window time.Duration
+	// Measurements, when set, is a regular expression matching the names of the
+	// only measurements whose gaps are annotated.
+	Measurements string
+}
+
+// PointsWriter writes points to storage.
+type PointsWriter interface {
+	WritePoints(ctx context.Context, orgID platform.ID, bucketID platform.ID, points []models.Point) error
+}
+
+// GapDetector annotates the gaps in the writes to buckets, so that dashboards
+// can tell that no data was collected from a value being zero. The writes are
+// recorded by the points writer it wraps, and gaps are looked for by Run.
+//
+// A measurement of a bucket which received no writes for the window is in a
+// gap, annotated in the GapStream from its last write to when it is found. When
+// the measurement is written to again, the annotation is ended at that write.
+// Only the measurements written to since the detector started are tracked.
+type GapDetector struct {
+	log          *zap.Logger
+	annotations  influxdb.AnnotationService
+	window       time.Duration
+	measurements *regexp.Regexp
+
+	mu     sync.Mutex
+	series map[gapSeries]*gapState
+
+	now func() time.Time
+}
+
+// gapSeries identifies a measurement of a bucket.
+type gapSeries struct {
+	orgID       platform.ID
+	bucketID    platform.ID
+	measurement string
+}
+
+type gapState struct {
+	lastWrite time.Time
+	// inGap is set once the gap after lastWrite has been found, gap being the
+	// annotation of the gap and gapID its ID, which is invalid if it could not
+	// be created.
+	inGap bool
+	gap   influxdb.AnnotationCreate
+	gapID platform.ID
+	// resumedAt is the time of the first write after the gap.
+	resumedAt time.Time
+}
+
+// NewGapDetector constructs a GapDetector writing the annotations of gaps to annotations.
+func NewGapDetector(log *zap.Logger, cfg GapConfig, annotations influxdb.AnnotationService) (*GapDetector, error) {
+	if cfg.Window <= 0 {
+		return nil, fmt.Errorf("gap annotations window must be positive")
+	}
+	d := &GapDetector{
+		log:         log,
+		annotations: annotations,
+		window:      cfg.Window,
+		series:      make(map[gapSeries]*gapState),
+		now:         time.Now,
+	}
+	if cfg.Measurements != "" {
+		re, err := regexp.Compile(cfg.Measurements)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gap annotations measurements: %w", err)
+		}
+		d.measurements = re
+	}
+	return d, nil
+}
+
+// PointsWriter returns pw recording the writes of the measurements of buckets.
+func (d *GapDetector) PointsWriter(pw PointsWriter) PointsWriter {
+	return &gapPointsWriter{detector: d, next: pw}
+}
+
+type gapPointsWriter struct {
+	detector *GapDetector
+	next     PointsWriter
+}
+
+func (w *gapPointsWriter) WritePoints(ctx context.Context, orgID platform.ID, bucketID platform.ID, points []models.Point) error {
+	if err := w.next.WritePoints(ctx, orgID, bucketID, points); err != nil {
+		return err
+	}
+	w.detector.record(orgID, bucketID, points)
+	return nil
+}
+
+func (d *GapDetector) record(orgID, bucketID platform.ID, points []models.Point) {
+	now := d.now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var last string
+	for _, p := range points {
+		name := string(p.Name())
+		// The points of a write are usually of a few measurements, in a row.
+		if name == last {
+			continue
+		}
+		last = name
+		if d.measurements != nil && !d.measurements.MatchString(name) {
+			continue
+		}
+
+		key := gapSeries{orgID: orgID, bucketID: bucketID, measurement: name}
+		s, ok := d.series[key]
+		if !ok {
+			s = &gapState{}
+			d.series[key] = s
+		}
+		if s.inGap && s.resumedAt.IsZero() {
+			s.resumedAt = now
+		}
+		s.lastWrite = now
+	}
+}
+
+// Run looks for gaps a few times every window, until ctx is done.
+func (d *GapDetector) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.window / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.detect(ctx)
+		}
+	}
+}
+
+// detect ends the annotations of the gaps which were written to, and annotates
+// the new gaps.
+func (d *GapDetector) detect(ctx context.Context) {
+	now := d.now()
+
+	var (
+		ended []*gapState
+		found = make(map[platform.ID][]*gapState)
+	)
+	d.mu.Lock()
+	for key, s := range d.series {
+		switch {
+		case s.inGap && !s.resumedAt.IsZero():
+			ended = append(ended, s)
+		case !s.inGap && now.Sub(s.lastWrite) >= d.window:
+			start, end := s.lastWrite, now
+			s.inGap = true
+			s.gap = influxdb.AnnotationCreate{
+				StreamTag: GapStream,
+				Summary:   fmt.Sprintf("No writes to %s", key.measurement),
+				Message:   fmt.Sprintf("Measurement %q of bucket %s received no writes for longer than %s.", key.measurement, key.bucketID, d.window),
+				Stickers: influxdb.AnnotationStickers{
+					"bucket":      key.bucketID.String(),
+					"measurement": key.measurement,
+				},
+				StartTime: &start,
+				EndTime:   &end,
+			}
+			found[key.orgID] = append(found[key.orgID], s)
+		}
+	}
+	d.mu.Unlock()
+
+	// The states are only changed by detect past this point, but for resumedAt,
+	// which is not set again until the state is reset.
+	for _, s := range ended {
+		if s.gapID.Valid() {
+			end := s.resumedAt
+			s.gap.EndTime = &end
+			if _, err := d.annotations.UpdateAnnotation(ctx, s.gapID, s.gap); err != nil {
+				d.log.Error("Failed to end the annotation of a gap", zap.Stringer("annotation_id", s.gapID), zap.Error(err))
+			}
+		}
+		d.mu.Lock()
+		s.inGap, s.gap, s.gapID, s.resumedAt = false, influxdb.AnnotationCreate{}, 0, time.Time{}
+		d.mu.Unlock()
+	}
+
+	for orgID, states := range found {
+		creates := make([]influxdb.AnnotationCreate, 0, len(states))
+		for _, s := range states {
+			creates = append(creates, s.gap)
+		}
+		events, err := d.annotations.CreateAnnotations(ctx, orgID, creates)
+		if err != nil {
+			d.log.Error("Failed to annotate gaps", zap.Stringer("org_id", orgID), zap.Error(err))
+			continue
+		}
+		// A bucket and a measurement identify the gap of the org.
+		byStickers := make(map[[2]string]*gapState, len(states))
+		for _, s := range states {
+			byStickers[[2]string{s.gap.Stickers["bucket"], s.gap.Stickers["measurement"]}] = s
+		}
+		for _, e := range events {
+			if s, ok := byStickers[[2]string{e.Stickers["bucket"], e.Stickers["measurement"]}]; ok {
+				s.gapID = e.ID
+			}
+		}
+		d.log.Debug("Annotated gaps", zap.Stringer("org_id", orgID), zap.Int("count", len(events)))
+	}
+}
@@ -0,0 +1,81 @@
+//go:build sqlite_json && sqlite_foreign_keys
+
+package annotations
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+	influxdbtesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type noopPointsWriter struct{}
+
+func (noopPointsWriter) WritePoints(context.Context, platform.ID, platform.ID, []models.Point) error {
+	return nil
+}
+
+func TestGapDetector(t *testing.T) {
+	t.Parallel()
+
+	svc := newTestService(t)
+	ctx := context.Background()
+	orgID, bucketID := *influxdbtesting.IDPtr(1), *influxdbtesting.IDPtr(10)
+
+	d, err := NewGapDetector(zaptest.NewLogger(t), GapConfig{Window: 10 * time.Minute, Measurements: "^cpu$"}, svc)
+	require.NoError(t, err)
+	start := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	now := start
+	d.now = func() time.Time { return now }
+	pw := d.PointsWriter(noopPointsWriter{})
+
+	write := func() {
+		points := []models.Point{
+			models.MustNewPoint("cpu", nil, models.Fields{"usage": 1.0}, now),
+			models.MustNewPoint("mem", nil, models.Fields{"used": 1.0}, now),
+		}
+		require.NoError(t, pw.WritePoints(ctx, orgID, bucketID, points))
+	}
+	gaps := func() []influxdb.StoredAnnotation {
+		f := influxdb.AnnotationListFilter{StreamIncludes: []string{GapStream}}
+		require.NoError(t, f.Validate(time.Now))
+		got, err := svc.ListAnnotations(ctx, orgID, f)
+		require.NoError(t, err)
+		return got
+	}
+
+	write()
+	now = now.Add(5 * time.Minute)
+	d.detect(ctx)
+	require.Empty(t, gaps())
+
+	// Only cpu matches the measurements of the detector.
+	now = now.Add(6 * time.Minute)
+	d.detect(ctx)
+	got := gaps()
+	require.Len(t, got, 1)
+	require.Equal(t, "No writes to cpu", got[0].Summary)
+	require.Equal(t, influxdb.AnnotationStickers{"bucket": bucketID.String(), "measurement": "cpu"}, got[0].Stickers)
+	require.Equal(t, start.Format(time.RFC3339Nano), got[0].Lower)
+	require.Equal(t, now.Format(time.RFC3339Nano), got[0].Upper)
+
+	// The gap ends at the first write after it.
+	now = now.Add(time.Minute)
+	resumed := now
+	write()
+	now = now.Add(time.Minute)
+	write()
+	d.detect(ctx)
+	got = gaps()
+	require.Len(t, got, 1)
+	require.Equal(t, resumed.Format(time.RFC3339Nano), got[0].Upper)
+
+	d.detect(ctx)
+	require.Len(t, gaps(), 1)
+}
@@ -71,6 +71,18 @@ func (l loggingService) DeleteAnnotations(ctx context.Context, orgID platform.ID
 	return l.underlying.DeleteAnnotations(ctx, orgID, delete)
 }
 
+func (l loggingService) DeleteAnnotationsByID(ctx context.Context, orgID platform.ID, ids []platform.ID) (err error) {
+	defer func(start time.Time) {
+		dur := zap.Duration("took", time.Since(start))
+		if err != nil {
+			l.logger.Debug("failed to delete annotations by ID", zap.Error(err), dur)
+			return
+		}
+		l.logger.Debug("annotations delete by ID", zap.Int("count", len(ids)), dur)
+	}(time.Now())
+	return l.underlying.DeleteAnnotationsByID(ctx, orgID, ids)
+}
+
 func (l loggingService) DeleteAnnotation(ctx context.Context, id platform.ID) (err error) {
 	defer func(start time.Time) {
 		dur := zap.Duration("took", time.Since(start))
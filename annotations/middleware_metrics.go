@@ -48,6 +48,11 @@ func (m metricsService) DeleteAnnotations(ctx context.Context, orgID platform.ID
 	return rec(m.underlying.DeleteAnnotations(ctx, orgID, delete))
 }
 
+func (m metricsService) DeleteAnnotationsByID(ctx context.Context, orgID platform.ID, ids []platform.ID) error {
+	rec := m.rec.Record("delete_annotations_by_id")
+	return rec(m.underlying.DeleteAnnotationsByID(ctx, orgID, ids))
+}
+
 func (m metricsService) DeleteAnnotation(ctx context.Context, id platform.ID) error {
 	rec := m.rec.Record("delete_annotation")
 	return rec(m.underlying.DeleteAnnotation(ctx, id))
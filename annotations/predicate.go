@@ -0,0 +1,52 @@
+package annotations
+
+import (
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/influxdata/influxdb/v2"
+	ierrors "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/predicate"
+)
+
+// stickerPredicateExpr converts a predicate on stickers, using the syntax of delete predicates,
+// into a condition on the annotations table. Stickers are stored as a JSON array of "key=val"
+// strings, so each tag rule checks the existence of a matching element with the json_each TVF.
+// A sticker key which is absent from an annotation matches a != rule.
+func stickerPredicateExpr(pred string) (sq.Sqlizer, error) {
+	node, err := predicate.Parse(pred)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil {
+		return sq.And{}, nil
+	}
+
+	return stickerNodeExpr(node)
+}
+
+func stickerNodeExpr(node predicate.Node) (sq.Sqlizer, error) {
+	switch n := node.(type) {
+	case predicate.LogicalNode:
+		and := sq.And{}
+		for _, child := range n.Children {
+			expr, err := stickerNodeExpr(child)
+			if err != nil {
+				return nil, err
+			}
+			and = append(and, expr)
+		}
+		return and, nil
+	case predicate.TagRuleNode:
+		exists := "EXISTS (SELECT 1 FROM json_each(annotations.stickers) WHERE json_each.value = ?)"
+		if n.Operator == influxdb.NotEqual {
+			exists = "NOT " + exists
+		}
+		return sq.Expr(exists, fmt.Sprintf("%s=%s", n.Key, n.Value)), nil
+	default:
+		return nil, &ierrors.Error{
+			Code: ierrors.EInvalid,
+			Msg:  fmt.Sprintf("unsupported sticker predicate %T", node),
+		}
+	}
+}
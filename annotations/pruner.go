@@ -0,0 +1,49 @@
+package annotations
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultPruneInterval is the default interval at which a Pruner enforces the retention of streams.
+const DefaultPruneInterval = 10 * time.Minute
+
+// Pruner periodically deletes the annotations which are past the retention of their stream.
+type Pruner struct {
+	log      *zap.Logger
+	svc      *Service
+	interval time.Duration
+}
+
+// NewPruner constructs a Pruner enforcing the retention of the streams of svc every interval.
+func NewPruner(log *zap.Logger, svc *Service, interval time.Duration) *Pruner {
+	return &Pruner{
+		log:      log,
+		svc:      svc,
+		interval: interval,
+	}
+}
+
+// Run prunes annotations every interval until ctx is done.
+func (p *Pruner) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			n, err := p.svc.PruneAnnotations(ctx, now.UTC())
+			if err != nil {
+				p.log.Error("Failed to prune annotations", zap.Error(err))
+				continue
+			}
+			if n > 0 {
+				p.log.Debug("Pruned annotations", zap.Int64("count", n))
+			}
+		}
+	}
+}
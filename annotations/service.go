@@ -26,6 +26,10 @@ var (
 	}
 )
 
+// createBatchSize is the number of annotations inserted per query by CreateAnnotations, which
+// keeps the number of query parameters below the limit of sqlite for bulk creates.
+const createBatchSize = 500
+
 var _ influxdb.AnnotationService = (*Service)(nil)
 
 type Service struct {
@@ -91,43 +95,53 @@ func (s *Service) CreateAnnotations(ctx context.Context, orgID platform.ID, crea
 		streamIDsNames[streamID] = name
 	}
 
-	// bulk insert for the creates. this also is unlikely to offer much performance benefit, but since the query
-	// is only used here it is easy enough to form to bulk query.
-	q := sq.Insert("annotations").
-		Columns("id", "org_id", "stream_id", "summary", "message", "stickers", "duration", "lower", "upper").
-		Suffix("RETURNING *")
-
-	for _, create := range creates {
-		// double check that we have a valid name for this stream tag - error if we don't. this should never be an error.
-		streamID, ok := streamNamesIDs[create.StreamTag]
-		if !ok {
-			tx.Rollback()
-			return nil, &ierrors.Error{
-				Code: ierrors.EInternal,
-				Msg:  fmt.Sprintf("unable to find id for stream %q", create.StreamTag),
-			}
+	// bulk insert for the creates, in batches of createBatchSize annotations so that large numbers of
+	// annotations can be created in a single transaction.
+	var res []*influxdb.StoredAnnotation
+	for len(creates) > 0 {
+		batch := creates
+		if len(batch) > createBatchSize {
+			batch = batch[:createBatchSize]
 		}
+		creates = creates[len(batch):]
+
+		q := sq.Insert("annotations").
+			Columns("id", "org_id", "stream_id", "summary", "message", "stickers", "duration", "lower", "upper").
+			Suffix("RETURNING *")
+
+		for _, create := range batch {
+			// double check that we have a valid name for this stream tag - error if we don't. this should never be an error.
+			streamID, ok := streamNamesIDs[create.StreamTag]
+			if !ok {
+				tx.Rollback()
+				return nil, &ierrors.Error{
+					Code: ierrors.EInternal,
+					Msg:  fmt.Sprintf("unable to find id for stream %q", create.StreamTag),
+				}
+			}
 
-		// add the row to the query
-		newID := s.idGenerator.ID()
-		lower := create.StartTime.Format(time.RFC3339Nano)
-		upper := create.EndTime.Format(time.RFC3339Nano)
-		duration := timesToDuration(*create.StartTime, *create.EndTime)
-		q = q.Values(newID, orgID, streamID, create.Summary, create.Message, create.Stickers, duration, lower, upper)
-	}
+			// add the row to the query
+			newID := s.idGenerator.ID()
+			lower := create.StartTime.Format(time.RFC3339Nano)
+			upper := create.EndTime.Format(time.RFC3339Nano)
+			duration := timesToDuration(*create.StartTime, *create.EndTime)
+			q = q.Values(newID, orgID, streamID, create.Summary, create.Message, create.Stickers, duration, lower, upper)
+		}
 
-	// get the query string and args list for the bulk insert
-	query, args, err := q.ToSql()
-	if err != nil {
-		tx.Rollback()
-		return nil, err
-	}
+		// get the query string and args list for the bulk insert
+		query, args, err := q.ToSql()
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
 
-	// run the bulk insert and store the result
-	var res []*influxdb.StoredAnnotation
-	if err := tx.SelectContext(ctx, &res, query, args...); err != nil {
-		tx.Rollback()
-		return nil, err
+		// run the bulk insert and store the result
+		var batchRes []*influxdb.StoredAnnotation
+		if err := tx.SelectContext(ctx, &batchRes, query, args...); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		res = append(res, batchRes...)
 	}
 
 	if err = tx.Commit(); err != nil {
@@ -174,6 +188,15 @@ func (s *Service) ListAnnotations(ctx context.Context, orgID platform.ID, filter
 		q = q.From("annotations")
 	}
 
+	// Add the sticker predicate to the query
+	if filter.StickerPredicate != "" {
+		expr, err := stickerPredicateExpr(filter.StickerPredicate)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Where(expr)
+	}
+
 	// Add stream name filters to the query
 	if len(filter.StreamIncludes) > 0 {
 		q = q.Where(sq.Eq{"stream": filter.StreamIncludes})
@@ -288,6 +311,96 @@ func (s *Service) DeleteAnnotations(ctx context.Context, orgID platform.ID, dele
 	return nil
 }
 
+// DeleteAnnotationsByID deletes the annotations of the org with the provided IDs. IDs of annotations
+// which do not exist, or belong to another org, are ignored.
+func (s *Service) DeleteAnnotationsByID(ctx context.Context, orgID platform.ID, ids []platform.ID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	tx, err := s.store.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	// delete in batches to keep the number of query parameters below the limit of sqlite
+	for len(ids) > 0 {
+		batch := ids
+		if len(batch) > createBatchSize {
+			batch = batch[:createBatchSize]
+		}
+		ids = ids[len(batch):]
+
+		q := sq.Delete("annotations").
+			Where(sq.Eq{"org_id": orgID}).
+			Where(sq.Eq{"id": batch})
+
+		query, args, err := q.ToSql()
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// PruneAnnotations deletes the annotations which ended longer ago than the retention of their
+// stream, as of now. Annotations of streams without a retention are kept. It returns the number
+// of annotations deleted.
+func (s *Service) PruneAnnotations(ctx context.Context, now time.Time) (int64, error) {
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	q := sq.Select("id", "org_id", "name", "description", "retention_seconds", "created_at", "updated_at").
+		From("streams").
+		Where(sq.Gt{"retention_seconds": 0})
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var sts []influxdb.StoredStream
+	if err := s.store.DB.SelectContext(ctx, &sts, query, args...); err != nil {
+		return 0, err
+	}
+
+	var pruned int64
+	for _, st := range sts {
+		// the cutoff is formatted like the stored times so that they compare consistently
+		cutoff := now.Add(-time.Duration(st.RetentionSeconds) * time.Second).Format(time.RFC3339Nano)
+		q := sq.Delete("annotations").
+			Where(sq.Eq{"stream_id": st.ID}).
+			Where(sq.Lt{"upper": cutoff})
+
+		query, args, err := q.ToSql()
+		if err != nil {
+			return pruned, err
+		}
+
+		res, err := s.store.DB.ExecContext(ctx, query, args...)
+		if err != nil {
+			return pruned, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return pruned, err
+		}
+		pruned += n
+	}
+
+	return pruned, nil
+}
+
 // DeleteAnnoation deletes a single annotation by ID
 func (s *Service) DeleteAnnotation(ctx context.Context, id platform.ID) error {
 	s.store.Mu.Lock()
@@ -388,7 +501,7 @@ func (s *Service) UpdateAnnotation(ctx context.Context, id platform.ID, update i
 
 // ListStreams returns a list of streams matching the filter for the provided orgID.
 func (s *Service) ListStreams(ctx context.Context, orgID platform.ID, filter influxdb.StreamListFilter) ([]influxdb.StoredStream, error) {
-	q := sq.Select("id", "org_id", "name", "description", "created_at", "updated_at").
+	q := sq.Select("id", "org_id", "name", "description", "retention_seconds", "created_at", "updated_at").
 		From("streams").
 		Where(sq.Eq{"org_id": orgID})
 
@@ -413,7 +526,7 @@ func (s *Service) ListStreams(ctx context.Context, orgID platform.ID, filter inf
 
 // GetStream gets a single stream by ID
 func (s *Service) GetStream(ctx context.Context, id platform.ID) (*influxdb.StoredStream, error) {
-	q := sq.Select("id", "org_id", "name", "description", "created_at", "updated_at").
+	q := sq.Select("id", "org_id", "name", "description", "retention_seconds", "created_at", "updated_at").
 		From("streams").
 		Where(sq.Eq{"id": id})
 
@@ -434,8 +547,8 @@ func (s *Service) GetStream(ctx context.Context, id platform.ID) (*influxdb.Stor
 	return &st, nil
 }
 
-// CreateOrUpdateStream creates a new stream, or updates the description of an existing stream.
-// Doesn't support updating a stream desctription to "". For that use the UpdateStream method.
+// CreateOrUpdateStream creates a new stream, or updates the description and retention of an existing stream.
+// Doesn't support updating a stream desctription to "", or removing its retention. For that use the UpdateStream method.
 func (s *Service) CreateOrUpdateStream(ctx context.Context, orgID platform.ID, stream influxdb.Stream) (*influxdb.ReadStream, error) {
 	s.store.Mu.Lock()
 	defer s.store.Mu.Unlock()
@@ -458,16 +571,17 @@ func (s *Service) CreateOrUpdateStream(ctx context.Context, orgID platform.ID, s
 	return s.getReadStream(ctx, id)
 }
 
-// UpdateStream updates a stream name and/or a description. It is strictly used for updating an existing stream.
+// UpdateStream updates a stream name, description and retention. It is strictly used for updating an existing stream.
 func (s *Service) UpdateStream(ctx context.Context, id platform.ID, stream influxdb.Stream) (*influxdb.ReadStream, error) {
 	s.store.Mu.Lock()
 	defer s.store.Mu.Unlock()
 
 	q := sq.Update("streams").
 		SetMap(sq.Eq{
-			"name":        stream.Name,
-			"description": stream.Description,
-			"updated_at":  sq.Expr(`datetime('now')`),
+			"name":              stream.Name,
+			"description":       stream.Description,
+			"retention_seconds": stream.RetentionSeconds,
+			"updated_at":        sq.Expr(`datetime('now')`),
 		}).
 		Where(sq.Eq{"id": id}).
 		Suffix(`RETURNING id`)
@@ -543,12 +657,13 @@ func (s *Service) DeleteStreamByID(ctx context.Context, id platform.ID) error {
 
 func newUpsertStreamQuery(orgID, newID platform.ID, t time.Time, stream influxdb.Stream) (string, []interface{}, error) {
 	q := sq.Insert("streams").
-		Columns("id", "org_id", "name", "description", "created_at", "updated_at").
-		Values(newID, orgID, stream.Name, stream.Description, t, t).
+		Columns("id", "org_id", "name", "description", "retention_seconds", "created_at", "updated_at").
+		Values(newID, orgID, stream.Name, stream.Description, stream.RetentionSeconds, t, t).
 		Suffix(`ON CONFLICT(org_id, name) DO UPDATE
 		SET 
 			updated_at = excluded.updated_at,
-			description = IIF(length(excluded.description) = 0, description, excluded.description)`).
+			description = IIF(length(excluded.description) = 0, description, excluded.description),
+			retention_seconds = IIF(excluded.retention_seconds = 0, retention_seconds, excluded.retention_seconds)`).
 		Suffix("RETURNING id")
 
 	return q.ToSql()
@@ -557,7 +672,7 @@ func newUpsertStreamQuery(orgID, newID platform.ID, t time.Time, stream influxdb
 // getReadStream is a helper which should only be called when the stream has been verified to exist
 // via an update or insert.
 func (s *Service) getReadStream(ctx context.Context, id platform.ID) (*influxdb.ReadStream, error) {
-	q := sq.Select("id", "name", "description", "created_at", "updated_at").
+	q := sq.Select("id", "name", "description", "retention_seconds", "created_at", "updated_at").
 		From("streams").
 		Where(sq.Eq{"id": id})
 
@@ -974,6 +974,131 @@ func TestStreamsCRUDMany(t *testing.T) {
 	})
 }
 
+func TestAnnotationsBulk(t *testing.T) {
+	t.Parallel()
+
+	svc := newTestService(t)
+
+	ctx := context.Background()
+	orgID := *influxdbtesting.IDPtr(1)
+	otherOrgID := *influxdbtesting.IDPtr(2)
+	et := time.Now().UTC().Add(-time.Minute)
+	st := et.Add(-time.Minute)
+
+	// more annotations than are inserted in a single query
+	creates := make([]influxdb.AnnotationCreate, 2*createBatchSize+1)
+	for i := range creates {
+		env := "dev"
+		if i%2 == 0 {
+			env = "prod"
+		}
+		creates[i] = influxdb.AnnotationCreate{
+			StreamTag: "deploys",
+			Summary:   "deploy",
+			Stickers:  map[string]string{"env": env, "team": "db"},
+			StartTime: &st,
+			EndTime:   &et,
+		}
+	}
+	creates[0].Stickers = map[string]string{"env": "prod"}
+
+	events, err := svc.CreateAnnotations(ctx, orgID, creates)
+	require.NoError(t, err)
+	require.Len(t, events, len(creates))
+
+	list := func(t *testing.T, pred string) []influxdb.StoredAnnotation {
+		t.Helper()
+
+		f := influxdb.AnnotationListFilter{StickerPredicate: pred}
+		require.NoError(t, f.Validate(time.Now))
+		got, err := svc.ListAnnotations(ctx, orgID, f)
+		require.NoError(t, err)
+		return got
+	}
+
+	t.Run("sticker predicates filter annotations", func(t *testing.T) {
+		require.Len(t, list(t, `env="prod"`), createBatchSize+1)
+		require.Len(t, list(t, `env="prod" AND team="db"`), createBatchSize)
+		require.Len(t, list(t, `env="prod" AND team!="db"`), 1)
+		require.Len(t, list(t, `env!="prod" AND env!="dev"`), 0)
+	})
+
+	t.Run("invalid sticker predicates return an error", func(t *testing.T) {
+		_, err := svc.ListAnnotations(ctx, orgID, influxdb.AnnotationListFilter{StickerPredicate: `env=`})
+		require.Error(t, err)
+	})
+
+	t.Run("annotations can be deleted by id, only for their org", func(t *testing.T) {
+		ids := make([]platform.ID, 0, len(events))
+		for _, e := range events[1:] {
+			ids = append(ids, e.ID)
+		}
+
+		require.NoError(t, svc.DeleteAnnotationsByID(ctx, otherOrgID, ids))
+		require.Len(t, list(t, ""), len(events))
+
+		require.NoError(t, svc.DeleteAnnotationsByID(ctx, orgID, ids))
+		got := list(t, "")
+		require.Len(t, got, 1)
+		require.Equal(t, events[0].ID, got[0].ID)
+	})
+}
+
+func TestStreamsRetention(t *testing.T) {
+	t.Parallel()
+
+	svc := newTestService(t)
+
+	ctx := context.Background()
+	orgID := *influxdbtesting.IDPtr(1)
+	now := time.Now().UTC()
+
+	s, err := svc.CreateOrUpdateStream(ctx, orgID, influxdb.Stream{Name: "incidents", RetentionSeconds: 3600})
+	require.NoError(t, err)
+	require.Equal(t, int64(3600), s.RetentionSeconds)
+
+	annotate := func(stream string, end time.Time) {
+		_, err := svc.CreateAnnotations(ctx, orgID, []influxdb.AnnotationCreate{{
+			StreamTag: stream,
+			Summary:   "summary",
+			StartTime: &end,
+			EndTime:   &end,
+		}})
+		require.NoError(t, err)
+	}
+	annotate("incidents", now.Add(-2*time.Hour))
+	annotate("incidents", now.Add(-time.Minute))
+	annotate("deploys", now.Add(-2*time.Hour))
+
+	t.Run("creating annotations keeps the retention of their stream", func(t *testing.T) {
+		got, err := svc.GetStream(ctx, s.ID)
+		require.NoError(t, err)
+		require.Equal(t, int64(3600), got.RetentionSeconds)
+	})
+
+	t.Run("annotations past the retention of their stream are pruned", func(t *testing.T) {
+		n, err := svc.PruneAnnotations(ctx, now)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), n)
+
+		f := influxdb.AnnotationListFilter{}
+		require.NoError(t, f.Validate(time.Now))
+		got, err := svc.ListAnnotations(ctx, orgID, f)
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+	})
+
+	t.Run("the retention of a stream can be removed", func(t *testing.T) {
+		got, err := svc.UpdateStream(ctx, s.ID, influxdb.Stream{Name: "incidents"})
+		require.NoError(t, err)
+		require.Zero(t, got.RetentionSeconds)
+
+		n, err := svc.PruneAnnotations(ctx, now.Add(24*time.Hour))
+		require.NoError(t, err)
+		require.Zero(t, n)
+	})
+}
+
 func assertAnnotationEvents(t *testing.T, got, want []influxdb.AnnotationEvent) {
 	t.Helper()
 
@@ -8,6 +8,8 @@ import (
 	"github.com/go-chi/chi"
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/predicate"
 )
 
 func (h *AnnotationHandler) annotationsRouter() http.Handler {
@@ -17,6 +19,9 @@ func (h *AnnotationHandler) annotationsRouter() http.Handler {
 	r.Get("/", h.handleGetAnnotations)
 	r.Delete("/", h.handleDeleteAnnotations)
 
+	r.Post("/bulk", h.handleBulkCreateAnnotations)
+	r.Post("/bulk/delete", h.handleBulkDeleteAnnotations)
+
 	r.Route("/{id}", func(r chi.Router) {
 		r.Get("/", h.handleGetAnnotation)
 		r.Delete("/", h.handleDeleteAnnotation)
@@ -103,6 +108,64 @@ func (h *AnnotationHandler) handleDeleteAnnotations(w http.ResponseWriter, r *ht
 	h.api.Respond(w, r, http.StatusNoContent, nil)
 }
 
+// bulkCreateAnnotationsResponse is the response to a bulk create, which only counts the annotations
+// created rather than returning them.
+type bulkCreateAnnotationsResponse struct {
+	Created int `json:"created"`
+}
+
+func (h *AnnotationHandler) handleBulkCreateAnnotations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	o, err := platform.IDFromString(r.URL.Query().Get("orgID"))
+	if err != nil {
+		h.api.Err(w, r, errBadOrg)
+		return
+	}
+
+	c, err := decodeBulkCreateAnnotationsRequest(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	l, err := h.annotationService.CreateAnnotations(ctx, *o, c)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusOK, bulkCreateAnnotationsResponse{Created: len(l)})
+}
+
+// bulkDeleteAnnotationsRequest lists the ids of the annotations to delete.
+type bulkDeleteAnnotationsRequest struct {
+	IDs []platform.ID `json:"ids"`
+}
+
+func (h *AnnotationHandler) handleBulkDeleteAnnotations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	o, err := platform.IDFromString(r.URL.Query().Get("orgID"))
+	if err != nil {
+		h.api.Err(w, r, errBadOrg)
+		return
+	}
+
+	d, err := decodeBulkDeleteAnnotationsRequest(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.annotationService.DeleteAnnotationsByID(ctx, *o, d.IDs); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusNoContent, nil)
+}
+
 func (h *AnnotationHandler) handleGetAnnotation(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -183,6 +246,38 @@ func decodeCreateAnnotationsRequest(r *http.Request) ([]influxdb.AnnotationCreat
 	return cs, nil
 }
 
+func decodeBulkCreateAnnotationsRequest(r *http.Request) ([]influxdb.AnnotationCreate, error) {
+	cs := []influxdb.AnnotationCreate{}
+	if err := json.NewDecoder(r.Body).Decode(&cs); err != nil {
+		return nil, err
+	}
+
+	if len(cs) > maxBulkAnnotations {
+		return nil, errTooManyAnnotations
+	}
+
+	for i := range cs {
+		if err := cs[i].Validate(time.Now); err != nil {
+			return nil, err
+		}
+	}
+
+	return cs, nil
+}
+
+func decodeBulkDeleteAnnotationsRequest(r *http.Request) (*bulkDeleteAnnotationsRequest, error) {
+	d := &bulkDeleteAnnotationsRequest{}
+	if err := json.NewDecoder(r.Body).Decode(d); err != nil {
+		return nil, err
+	}
+
+	if len(d.IDs) > maxBulkAnnotations {
+		return nil, errTooManyAnnotations
+	}
+
+	return d, nil
+}
+
 func decodeListAnnotationsRequest(r *http.Request) (*influxdb.AnnotationListFilter, error) {
 	startTime, endTime, err := tFromReq(r)
 	if err != nil {
@@ -190,7 +285,8 @@ func decodeListAnnotationsRequest(r *http.Request) (*influxdb.AnnotationListFilt
 	}
 
 	f := &influxdb.AnnotationListFilter{
-		StreamIncludes: r.URL.Query()["streamIncludes"],
+		StickerPredicate: r.URL.Query().Get("stickerPredicate"),
+		StreamIncludes:   r.URL.Query()["streamIncludes"],
 		BasicFilter: influxdb.BasicFilter{
 			EndTime:   endTime,
 			StartTime: startTime,
@@ -201,6 +297,14 @@ func decodeListAnnotationsRequest(r *http.Request) (*influxdb.AnnotationListFilt
 		return nil, err
 	}
 
+	if _, err := predicate.Parse(f.StickerPredicate); err != nil {
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "invalid sticker predicate",
+			Err:  err,
+		}
+	}
+
 	return f, nil
 }
 
@@ -8,6 +8,7 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
 	influxdbtesting "github.com/influxdata/influxdb/v2/testing"
 	"github.com/stretchr/testify/require"
 )
@@ -168,6 +169,97 @@ func TestAnnotationRouter(t *testing.T) {
 		doTestRequest(t, req, http.StatusNoContent, false)
 	})
 
+	t.Run("get annotations with a sticker predicate", func(t *testing.T) {
+		ts, svc := newTestServer(t)
+		defer ts.Close()
+
+		req := newTestRequest(t, "GET", ts.URL+"/annotations", nil)
+		q := req.URL.Query()
+		q.Add("orgID", orgStr)
+		q.Add("endTime", now.Format(time.RFC3339))
+		q.Add("stickerPredicate", `env="prod" AND team!="db"`)
+		req.URL.RawQuery = q.Encode()
+
+		svc.EXPECT().
+			ListAnnotations(gomock.Any(), *orgID, influxdb.AnnotationListFilter{
+				StickerIncludes:  map[string]string{},
+				StickerPredicate: `env="prod" AND team!="db"`,
+				BasicFilter: influxdb.BasicFilter{
+					StartTime: &time.Time{},
+					EndTime:   &now,
+				},
+			}).
+			Return([]influxdb.StoredAnnotation{}, nil)
+
+		doTestRequest(t, req, http.StatusOK, true)
+	})
+
+	t.Run("invalid sticker predicates return 400", func(t *testing.T) {
+		ts, _ := newTestServer(t)
+		defer ts.Close()
+
+		req := newTestRequest(t, "GET", ts.URL+"/annotations", nil)
+		q := req.URL.Query()
+		q.Add("orgID", orgStr)
+		q.Add("stickerPredicate", `env=`)
+		req.URL.RawQuery = q.Encode()
+
+		doTestRequest(t, req, http.StatusBadRequest, false)
+	})
+
+	t.Run("bulk create annotations happy path", func(t *testing.T) {
+		ts, svc := newTestServer(t)
+		defer ts.Close()
+
+		createAnnotations := []influxdb.AnnotationCreate{testCreateAnnotation, testCreateAnnotation}
+
+		req := newTestRequest(t, "POST", ts.URL+"/annotations/bulk", createAnnotations)
+		q := req.URL.Query()
+		q.Add("orgID", orgStr)
+		req.URL.RawQuery = q.Encode()
+
+		svc.EXPECT().
+			CreateAnnotations(gomock.Any(), *orgID, createAnnotations).
+			Return([]influxdb.AnnotationEvent{testEvent, testEvent}, nil)
+
+		res := doTestRequest(t, req, http.StatusOK, true)
+
+		got := bulkCreateAnnotationsResponse{}
+		err := json.NewDecoder(res.Body).Decode(&got)
+		require.NoError(t, err)
+		require.Equal(t, bulkCreateAnnotationsResponse{Created: 2}, got)
+	})
+
+	t.Run("bulk create over the limit returns 400", func(t *testing.T) {
+		ts, _ := newTestServer(t)
+		defer ts.Close()
+
+		createAnnotations := make([]influxdb.AnnotationCreate, maxBulkAnnotations+1)
+		req := newTestRequest(t, "POST", ts.URL+"/annotations/bulk", createAnnotations)
+		q := req.URL.Query()
+		q.Add("orgID", orgStr)
+		req.URL.RawQuery = q.Encode()
+
+		doTestRequest(t, req, http.StatusBadRequest, false)
+	})
+
+	t.Run("bulk delete annotations happy path", func(t *testing.T) {
+		ts, svc := newTestServer(t)
+		defer ts.Close()
+
+		ids := []platform.ID{*id, *influxdbtesting.IDPtr(1)}
+		req := newTestRequest(t, "POST", ts.URL+"/annotations/bulk/delete", bulkDeleteAnnotationsRequest{IDs: ids})
+		q := req.URL.Query()
+		q.Add("orgID", orgStr)
+		req.URL.RawQuery = q.Encode()
+
+		svc.EXPECT().
+			DeleteAnnotationsByID(gomock.Any(), *orgID, ids).
+			Return(nil)
+
+		doTestRequest(t, req, http.StatusNoContent, false)
+	})
+
 	t.Run("get annotation happy path", func(t *testing.T) {
 		ts, svc := newTestServer(t)
 		defer ts.Close()
@@ -1,6 +1,7 @@
 package transport
 
 import (
+	"fmt"
 	"net/http"
 	"time"
 
@@ -16,6 +17,9 @@ const (
 	// this is the base api prefix, since the annotations system mounts handlers at
 	// both the ../annotations and ../streams paths.
 	prefixAnnotations = "/api/v2private"
+
+	// maxBulkAnnotations is the maximum number of annotations in a bulk create or delete request.
+	maxBulkAnnotations = 10000
 )
 
 var (
@@ -38,6 +42,11 @@ var (
 		Code: errors.EInvalid,
 		Msg:  "invalid stream name",
 	}
+
+	errTooManyAnnotations = &errors.Error{
+		Code: errors.EInvalid,
+		Msg:  fmt.Sprintf("bulk requests are limited to %d annotations", maxBulkAnnotations),
+	}
 )
 
 // AnnotationsHandler is the handler for the annotation service
@@ -194,11 +194,12 @@ func storedStreamsToReadStreams(stored []influxdb.StoredStream) []influxdb.ReadS
 
 	for _, s := range stored {
 		r = append(r, influxdb.ReadStream{
-			ID:          s.ID,
-			Name:        s.Name,
-			Description: s.Description,
-			CreatedAt:   s.CreatedAt,
-			UpdatedAt:   s.UpdatedAt,
+			ID:               s.ID,
+			Name:             s.Name,
+			Description:      s.Description,
+			RetentionSeconds: s.RetentionSeconds,
+			CreatedAt:        s.CreatedAt,
+			UpdatedAt:        s.UpdatedAt,
 		})
 	}
 
@@ -3,6 +3,7 @@ package influxdb
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
@@ -26,6 +27,14 @@ type Authorization struct {
 	OrgID       platform.ID  `json:"orgID"`
 	UserID      platform.ID  `json:"userID,omitempty"`
 	Permissions []Permission `json:"permissions"`
+	// ExpiresAt is when the authorization expires, if it does.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// LastUsedAt is when the token of the authorization was last found,
+	// if it was.
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	// Labels are key/value pairs set at creation to group authorizations,
+	// such as team=ingest.
+	Labels map[string]string `json:"labels,omitempty"`
 	CRUDLog
 }
 
@@ -71,6 +80,21 @@ func (a *Authorization) IsActive() bool {
 	return a.Status == Active
 }
 
+// IsExpired returns true if the authorization has expired by now.
+func (a *Authorization) IsExpired(now time.Time) bool {
+	return a.ExpiresAt != nil && !now.Before(*a.ExpiresAt)
+}
+
+// HasLabels returns true if the authorization has all of labels.
+func (a *Authorization) HasLabels(labels map[string]string) bool {
+	for k, v := range labels {
+		if got, ok := a.Labels[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
 // GetUserID returns the user id.
 func (a *Authorization) GetUserID() platform.ID {
 	return a.UserID
@@ -124,4 +148,10 @@ type AuthorizationFilter struct {
 
 	OrgID *platform.ID
 	Org   *string
+
+	// UsedBefore matches the authorizations not used since then.
+	UsedBefore *time.Time
+
+	// Labels matches the authorizations having all of these labels.
+	Labels map[string]string
 }
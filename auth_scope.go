@@ -0,0 +1,115 @@
+package influxdb
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// AuthorizationTemplate names a set of permissions an authorization can be
+// scoped to.
+type AuthorizationTemplate string
+
+const (
+	// ReadBucketTemplate allows reading a bucket.
+	ReadBucketTemplate AuthorizationTemplate = "read-bucket"
+	// WriteBucketTemplate allows writing a bucket, without reading it.
+	WriteBucketTemplate AuthorizationTemplate = "write-bucket"
+	// AdminOrgTemplate allows the permissions of the owners of an organization.
+	AdminOrgTemplate AuthorizationTemplate = "admin-org"
+)
+
+// AuthorizationScope applies a template to an organization, or to one of its
+// buckets for the bucket templates.
+type AuthorizationScope struct {
+	Template AuthorizationTemplate `json:"template"`
+	Bucket   string                `json:"bucket,omitempty"`
+}
+
+// ParseAuthorizationScope parses a scope written as read:<bucket>,
+// write:<bucket> or admin, or as a template name followed by :<bucket> for
+// the bucket templates.
+func ParseAuthorizationScope(s string) (AuthorizationScope, error) {
+	name, bucket, _ := strings.Cut(s, ":")
+	scope := AuthorizationScope{Template: AuthorizationTemplate(name), Bucket: bucket}
+	switch name {
+	case "read":
+		scope.Template = ReadBucketTemplate
+	case "write":
+		scope.Template = WriteBucketTemplate
+	case "admin":
+		scope.Template = AdminOrgTemplate
+	}
+	return scope, scope.Valid()
+}
+
+// Valid returns an error if the scope names no template, or if it names a
+// bucket for a template of buckets only.
+func (s AuthorizationScope) Valid() error {
+	switch s.Template {
+	case ReadBucketTemplate, WriteBucketTemplate:
+		if s.Bucket == "" {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("authorization template %s requires a bucket", s.Template),
+			}
+		}
+	case AdminOrgTemplate:
+		if s.Bucket != "" {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("authorization template %s does not apply to a bucket", s.Template),
+			}
+		}
+	default:
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("unknown authorization template %q", s.Template),
+		}
+	}
+	return nil
+}
+
+// ReadBucketPermissions are the permissions of the read-bucket template.
+func ReadBucketPermissions(orgID, bucketID platform.ID) []Permission {
+	return []Permission{{Action: ReadAction, Resource: Resource{Type: BucketsResourceType, OrgID: &orgID, ID: &bucketID}}}
+}
+
+// WriteBucketPermissions are the permissions of the write-bucket template.
+func WriteBucketPermissions(orgID, bucketID platform.ID) []Permission {
+	return []Permission{{Action: WriteAction, Resource: Resource{Type: BucketsResourceType, OrgID: &orgID, ID: &bucketID}}}
+}
+
+// AdminOrgPermissions are the permissions of the admin-org template.
+func AdminOrgPermissions(orgID platform.ID) []Permission {
+	return OwnerPermissions(orgID)
+}
+
+// ScopePermissions returns the permissions of scopes applied to an
+// organization. bucketID returns the ID of a bucket of the organization by
+// its name.
+func ScopePermissions(orgID platform.ID, scopes []AuthorizationScope, bucketID func(name string) (platform.ID, error)) ([]Permission, error) {
+	var ps []Permission
+	for _, s := range scopes {
+		if err := s.Valid(); err != nil {
+			return nil, err
+		}
+		if s.Template == AdminOrgTemplate {
+			ps = append(ps, AdminOrgPermissions(orgID)...)
+			continue
+		}
+
+		id, err := bucketID(s.Bucket)
+		if err != nil {
+			return nil, err
+		}
+		if s.Template == ReadBucketTemplate {
+			ps = append(ps, ReadBucketPermissions(orgID, id)...)
+		} else {
+			ps = append(ps, WriteBucketPermissions(orgID, id)...)
+		}
+	}
+	return ps, nil
+}
@@ -0,0 +1,58 @@
+package influxdb_test
+
+import (
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAuthorizationScope(t *testing.T) {
+	tests := []struct {
+		scope   string
+		want    influxdb.AuthorizationScope
+		wantErr bool
+	}{
+		{scope: "read:b", want: influxdb.AuthorizationScope{Template: influxdb.ReadBucketTemplate, Bucket: "b"}},
+		{scope: "write:b", want: influxdb.AuthorizationScope{Template: influxdb.WriteBucketTemplate, Bucket: "b"}},
+		{scope: "write-bucket:b", want: influxdb.AuthorizationScope{Template: influxdb.WriteBucketTemplate, Bucket: "b"}},
+		{scope: "admin", want: influxdb.AuthorizationScope{Template: influxdb.AdminOrgTemplate}},
+		{scope: "admin-org", want: influxdb.AuthorizationScope{Template: influxdb.AdminOrgTemplate}},
+		{scope: "read", wantErr: true},
+		{scope: "admin:b", wantErr: true},
+		{scope: "delete:b", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scope, func(t *testing.T) {
+			got, err := influxdb.ParseAuthorizationScope(tt.scope)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestScopePermissions(t *testing.T) {
+	orgID, bucketID := platform.ID(1), platform.ID(2)
+	scopes := []influxdb.AuthorizationScope{
+		{Template: influxdb.ReadBucketTemplate, Bucket: "b"},
+		{Template: influxdb.WriteBucketTemplate, Bucket: "b"},
+	}
+	ps, err := influxdb.ScopePermissions(orgID, scopes, func(name string) (platform.ID, error) {
+		require.Equal(t, "b", name)
+		return bucketID, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, append(influxdb.ReadBucketPermissions(orgID, bucketID), influxdb.WriteBucketPermissions(orgID, bucketID)...), ps)
+	for _, p := range ps {
+		require.NoError(t, p.Valid())
+	}
+
+	ps, err = influxdb.ScopePermissions(orgID, []influxdb.AuthorizationScope{{Template: influxdb.AdminOrgTemplate}}, nil)
+	require.NoError(t, err)
+	require.Equal(t, influxdb.OwnerPermissions(orgID), ps)
+}
@@ -0,0 +1,50 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+var _ influxdb.AlertAckService = (*AlertAckService)(nil)
+
+// AlertAckService wraps a influxdb.AlertAckService and authorizes actions
+// against it. Acknowledging the alert of a check requires write access to the check.
+type AlertAckService struct {
+	s      influxdb.AlertAckService
+	checks influxdb.CheckService
+}
+
+// NewAlertAckService constructs an instance of an authorizing alert acknowledgement service.
+func NewAlertAckService(s influxdb.AlertAckService, checks influxdb.CheckService) *AlertAckService {
+	return &AlertAckService{
+		s:      s,
+		checks: checks,
+	}
+}
+
+// AckAlert checks to see if the authorizer on context has write access to the check of the alert.
+func (s *AlertAckService) AckAlert(ctx context.Context, ack *influxdb.AlertAck) error {
+	if err := s.authorizeCheck(ctx, ack.CheckID); err != nil {
+		return err
+	}
+	return s.s.AckAlert(ctx, ack)
+}
+
+// UnackAlert checks to see if the authorizer on context has write access to the check of the alert.
+func (s *AlertAckService) UnackAlert(ctx context.Context, checkID platform.ID) error {
+	if err := s.authorizeCheck(ctx, checkID); err != nil {
+		return err
+	}
+	return s.s.UnackAlert(ctx, checkID)
+}
+
+func (s *AlertAckService) authorizeCheck(ctx context.Context, id platform.ID) error {
+	c, err := s.checks.FindCheckByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	_, _, err = AuthorizeWrite(ctx, influxdb.ChecksResourceType, c.GetID(), c.GetOrgID())
+	return err
+}
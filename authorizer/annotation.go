@@ -69,6 +69,14 @@ func (s *AnnotationService) DeleteAnnotations(ctx context.Context, orgID platfor
 	return s.s.DeleteAnnotations(ctx, orgID, delete)
 }
 
+// DeleteAnnotationsByID checks to see if the authorizer on context has write access to the provided orgID
+func (s *AnnotationService) DeleteAnnotationsByID(ctx context.Context, orgID platform.ID, ids []platform.ID) error {
+	if _, _, err := AuthorizeOrgWriteResource(ctx, influxdb.AnnotationsResourceType, orgID); err != nil {
+		return err
+	}
+	return s.s.DeleteAnnotationsByID(ctx, orgID, ids)
+}
+
 // DeleteAnnotation checks to see if the authorizer on context has write access to the requested annotation
 func (s *AnnotationService) DeleteAnnotation(ctx context.Context, id platform.ID) error {
 	a, err := s.s.GetAnnotation(ctx, id)
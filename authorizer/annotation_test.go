@@ -210,6 +210,51 @@ func Test_DeleteAnnotations(t *testing.T) {
 	}
 }
 
+func Test_DeleteAnnotationsByID(t *testing.T) {
+	t.Parallel()
+
+	ids := []platform.ID{1, 2}
+
+	tests := []struct {
+		name    string
+		wantErr error
+	}{
+		{
+			"authorized to delete annotations by id with the specified org",
+			nil,
+		},
+		{
+			"not authorized to delete annotations by id with the specified org",
+			&errors.Error{
+				Msg:  fmt.Sprintf("write:orgs/%s/annotations is unauthorized", annOrgID1),
+				Code: errors.EUnauthorized,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrlr := gomock.NewController(t)
+			svc := mock.NewMockAnnotationService(ctrlr)
+			s := authorizer.NewAnnotationService(svc)
+
+			var perm influxdb.Permission
+			if tt.wantErr == nil {
+				perm = newTestAnnotationsPermission(influxdb.WriteAction, annOrgID1)
+				svc.EXPECT().
+					DeleteAnnotationsByID(gomock.Any(), *annOrgID1, ids).
+					Return(nil)
+			} else {
+				perm = newTestAnnotationsPermission(influxdb.ReadAction, annOrgID1)
+			}
+
+			ctx := influxdbcontext.SetAuthorizer(context.Background(), mock.NewMockAuthorizer(false, []influxdb.Permission{perm}))
+			err := s.DeleteAnnotationsByID(ctx, *annOrgID1, ids)
+			require.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
 func Test_DeleteAnnotation(t *testing.T) {
 	t.Parallel()
 
@@ -88,7 +88,82 @@ func authorize(ctx context.Context, a influxdb.Action, rt influxdb.ResourceType,
 	if err != nil {
 		return nil, influxdb.Permission{}, err
 	}
-	return auth, *p, isAllowed(auth, *p)
+	err = isAllowed(auth, *p)
+	if err != nil && rid != nil {
+		allowed, lerr := isAllowedByLabel(ctx, auth, *p)
+		if lerr != nil {
+			return auth, *p, lerr
+		}
+		if allowed {
+			err = nil
+		}
+	}
+	return auth, *p, err
+}
+
+// isAllowedByLabel reports whether the permissions of the authorizer targeting
+// resources by label allow p, the resource of p having one of their labels or a
+// label descending from one. The labels are looked up with the label resolver on
+// the context; without one, label permissions allow nothing.
+func isAllowedByLabel(ctx context.Context, a influxdb.Authorizer, p influxdb.Permission) (bool, error) {
+	pset, err := a.PermissionSet()
+	if err != nil {
+		return false, err
+	}
+
+	labelIDs := make(map[platform.ID]bool)
+	for _, perm := range pset {
+		r := perm.Resource
+		if r.LabelID == nil || perm.Action != p.Action || r.Type != p.Resource.Type {
+			continue
+		}
+		if r.OrgID != nil && p.Resource.OrgID != nil && *r.OrgID != *p.Resource.OrgID {
+			continue
+		}
+		labelIDs[*r.LabelID] = true
+	}
+	if len(labelIDs) == 0 {
+		return false, nil
+	}
+
+	resolver, ok := icontext.GetLabelResolver(ctx)
+	if !ok {
+		return false, nil
+	}
+	labels, err := resolver.FindResourceLabels(ctx, influxdb.LabelMappingFilter{
+		ResourceID:   *p.Resource.ID,
+		ResourceType: p.Resource.Type,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	// Walk up from the labels of the resource to the roots of their trees.
+	seen := make(map[platform.ID]bool)
+	for len(labels) > 0 {
+		l := labels[len(labels)-1]
+		labels = labels[:len(labels)-1]
+		if seen[l.ID] {
+			continue
+		}
+		seen[l.ID] = true
+		if labelIDs[l.ID] {
+			return true, nil
+		}
+		if l.ParentID == nil || seen[*l.ParentID] {
+			continue
+		}
+
+		parent, err := resolver.FindLabelByID(ctx, *l.ParentID)
+		if err != nil {
+			if errors.ErrorCode(err) == errors.ENotFound {
+				continue
+			}
+			return false, err
+		}
+		labels = append(labels, parent)
+	}
+	return false, nil
 }
 
 func authorizeReadSystemBucket(ctx context.Context, bid, oid platform.ID) (influxdb.Authorizer, influxdb.Permission, error) {
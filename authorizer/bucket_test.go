@@ -117,6 +117,63 @@ func TestBucketService_FindBucketByID(t *testing.T) {
 	}
 }
 
+func TestBucketService_FindBucketByID_Label(t *testing.T) {
+	// Bucket 1 is labeled "payments", a child of "team", and bucket 2 is not labeled.
+	labels := map[platform.ID]*influxdb.Label{
+		3: {ID: 3, OrgID: 10, Name: "team"},
+		4: {ID: 4, OrgID: 10, Name: "payments", ParentID: influxdbtesting.IDPtr(3)},
+	}
+	resolver := &mock.LabelService{
+		FindLabelByIDFn: func(ctx context.Context, id platform.ID) (*influxdb.Label, error) {
+			return labels[id], nil
+		},
+		FindResourceLabelsFn: func(ctx context.Context, f influxdb.LabelMappingFilter) ([]*influxdb.Label, error) {
+			if f.ResourceID == 1 {
+				return []*influxdb.Label{labels[4]}, nil
+			}
+			return nil, nil
+		},
+	}
+	s := authorizer.NewBucketService(&mock.BucketService{
+		FindBucketByIDFn: func(ctx context.Context, id platform.ID) (*influxdb.Bucket, error) {
+			return &influxdb.Bucket{ID: id, OrgID: 10}, nil
+		},
+	})
+
+	tests := []struct {
+		name     string
+		labelID  platform.ID
+		bucketID platform.ID
+		resolver bool
+		allowed  bool
+	}{
+		{name: "labeled bucket", labelID: 4, bucketID: 1, resolver: true, allowed: true},
+		{name: "bucket labeled with descendant", labelID: 3, bucketID: 1, resolver: true, allowed: true},
+		{name: "unlabeled bucket", labelID: 3, bucketID: 2, resolver: true},
+		{name: "no resolver", labelID: 4, bucketID: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := influxdb.NewLabelPermission(influxdb.ReadAction, influxdb.BucketsResourceType, 10, tt.labelID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := influxdbcontext.SetAuthorizer(context.Background(), mock.NewMockAuthorizer(false, []influxdb.Permission{*p}))
+			if tt.resolver {
+				ctx = influxdbcontext.SetLabelResolver(ctx, resolver)
+			}
+
+			_, err = s.FindBucketByID(ctx, tt.bucketID)
+			if tt.allowed && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.allowed && errors.ErrorCode(err) != errors.EUnauthorized {
+				t.Fatalf("got error %v, want unauthorized", err)
+			}
+		})
+	}
+}
+
 func TestBucketService_FindBucket(t *testing.T) {
 	type fields struct {
 		BucketService influxdb.BucketService
@@ -0,0 +1,116 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+var _ influxdb.CellTemplateService = (*CellTemplateService)(nil)
+
+// CellTemplateService wraps a influxdb.CellTemplateService and authorizes actions
+// against it. Cell templates are authorized with the organization's dashboard permissions.
+type CellTemplateService struct {
+	s          influxdb.CellTemplateService
+	dashboards influxdb.DashboardService
+}
+
+// NewCellTemplateService constructs an instance of an authorizing cell template service.
+// The unauthorized dashboards service is used to look up the organization of each dashboard.
+func NewCellTemplateService(s influxdb.CellTemplateService, dashboards influxdb.DashboardService) *CellTemplateService {
+	return &CellTemplateService{
+		s:          s,
+		dashboards: dashboards,
+	}
+}
+
+// FindCellTemplateByID checks to see if the authorizer on context has read access to the dashboards of the template's organization.
+func (s *CellTemplateService) FindCellTemplateByID(ctx context.Context, id platform.ID) (*influxdb.CellTemplate, error) {
+	t, err := s.s.FindCellTemplateByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := AuthorizeOrgReadResource(ctx, influxdb.DashboardsResourceType, t.OrganizationID); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// FindCellTemplates retrieves all cell templates that match the provided filter and then filters the list down to only the resources that are authorized.
+func (s *CellTemplateService) FindCellTemplates(ctx context.Context, filter influxdb.CellTemplateFilter, opts influxdb.FindOptions) ([]*influxdb.CellTemplate, int, error) {
+	ts, _, err := s.s.FindCellTemplates(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tts := ts[:0]
+	for _, t := range ts {
+		_, _, err := AuthorizeOrgReadResource(ctx, influxdb.DashboardsResourceType, t.OrganizationID)
+		if err != nil && errors.ErrorCode(err) != errors.EUnauthorized {
+			return nil, 0, err
+		}
+		if errors.ErrorCode(err) == errors.EUnauthorized {
+			continue
+		}
+		tts = append(tts, t)
+	}
+	return tts, len(tts), nil
+}
+
+// CreateCellTemplate checks to see if the authorizer on context has write access to the dashboards of the organization provided.
+func (s *CellTemplateService) CreateCellTemplate(ctx context.Context, t *influxdb.CellTemplate) error {
+	if _, _, err := AuthorizeCreate(ctx, influxdb.DashboardsResourceType, t.OrganizationID); err != nil {
+		return err
+	}
+	return s.s.CreateCellTemplate(ctx, t)
+}
+
+// UpdateCellTemplate checks to see if the authorizer on context has write access to the dashboards of the template's organization.
+func (s *CellTemplateService) UpdateCellTemplate(ctx context.Context, id platform.ID, upd influxdb.CellTemplateUpdate) (*influxdb.CellTemplate, error) {
+	if err := s.authorizeWrite(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.s.UpdateCellTemplate(ctx, id, upd)
+}
+
+// DeleteCellTemplate checks to see if the authorizer on context has write access to the dashboards of the template's organization.
+func (s *CellTemplateService) DeleteCellTemplate(ctx context.Context, id platform.ID) error {
+	if err := s.authorizeWrite(ctx, id); err != nil {
+		return err
+	}
+	return s.s.DeleteCellTemplate(ctx, id)
+}
+
+// AddCellFromTemplate checks to see if the authorizer on context has read access to the template and write access to the dashboard provided.
+func (s *CellTemplateService) AddCellFromTemplate(ctx context.Context, dashboardID, templateID platform.ID, c *influxdb.Cell, params map[string]string) error {
+	if _, err := s.FindCellTemplateByID(ctx, templateID); err != nil {
+		return err
+	}
+	d, err := s.dashboards.FindDashboardByID(ctx, dashboardID)
+	if err != nil {
+		return err
+	}
+	if _, _, err := AuthorizeWrite(ctx, influxdb.DashboardsResourceType, d.ID, d.OrganizationID); err != nil {
+		return err
+	}
+	return s.s.AddCellFromTemplate(ctx, dashboardID, templateID, c, params)
+}
+
+// FindCellTemplateLinks checks to see if the authorizer on context has read access to the dashboards of the template's organization.
+func (s *CellTemplateService) FindCellTemplateLinks(ctx context.Context, templateID platform.ID) ([]*influxdb.CellTemplateLink, error) {
+	if _, err := s.FindCellTemplateByID(ctx, templateID); err != nil {
+		return nil, err
+	}
+	return s.s.FindCellTemplateLinks(ctx, templateID)
+}
+
+func (s *CellTemplateService) authorizeWrite(ctx context.Context, id platform.ID) error {
+	t, err := s.s.FindCellTemplateByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	_, _, err = AuthorizeOrgWriteResource(ctx, influxdb.DashboardsResourceType, t.OrganizationID)
+	return err
+}
@@ -0,0 +1,114 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+var _ influxdb.CheckTemplateService = (*CheckTemplateService)(nil)
+
+// CheckTemplateService wraps a influxdb.CheckTemplateService and authorizes actions
+// against it. Check templates are authorized with the organization's check permissions.
+type CheckTemplateService struct {
+	s influxdb.CheckTemplateService
+}
+
+// NewCheckTemplateService constructs an instance of an authorizing check template service.
+func NewCheckTemplateService(s influxdb.CheckTemplateService) *CheckTemplateService {
+	return &CheckTemplateService{
+		s: s,
+	}
+}
+
+// FindCheckTemplateByID checks to see if the authorizer on context has read access to the checks of the template's organization.
+func (s *CheckTemplateService) FindCheckTemplateByID(ctx context.Context, id platform.ID) (*influxdb.CheckTemplate, error) {
+	t, err := s.s.FindCheckTemplateByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := AuthorizeOrgReadResource(ctx, influxdb.ChecksResourceType, t.OrganizationID); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// FindCheckTemplates retrieves all check templates that match the provided filter and then filters the list down to only the resources that are authorized.
+func (s *CheckTemplateService) FindCheckTemplates(ctx context.Context, filter influxdb.CheckTemplateFilter, opts influxdb.FindOptions) ([]*influxdb.CheckTemplate, int, error) {
+	ts, _, err := s.s.FindCheckTemplates(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tts := ts[:0]
+	for _, t := range ts {
+		_, _, err := AuthorizeOrgReadResource(ctx, influxdb.ChecksResourceType, t.OrganizationID)
+		if err != nil && errors.ErrorCode(err) != errors.EUnauthorized {
+			return nil, 0, err
+		}
+		if errors.ErrorCode(err) == errors.EUnauthorized {
+			continue
+		}
+		tts = append(tts, t)
+	}
+	return tts, len(tts), nil
+}
+
+// CreateCheckTemplate checks to see if the authorizer on context has write access to the checks of the organization provided.
+func (s *CheckTemplateService) CreateCheckTemplate(ctx context.Context, t *influxdb.CheckTemplate) error {
+	if _, _, err := AuthorizeCreate(ctx, influxdb.ChecksResourceType, t.OrganizationID); err != nil {
+		return err
+	}
+	return s.s.CreateCheckTemplate(ctx, t)
+}
+
+// UpdateCheckTemplate checks to see if the authorizer on context has write access to the checks of the template's organization.
+func (s *CheckTemplateService) UpdateCheckTemplate(ctx context.Context, id platform.ID, upd influxdb.CheckTemplateUpdate) (*influxdb.CheckTemplate, error) {
+	if err := s.authorizeWrite(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.s.UpdateCheckTemplate(ctx, id, upd)
+}
+
+// DeleteCheckTemplate checks to see if the authorizer on context has write access to the checks of the template's organization.
+func (s *CheckTemplateService) DeleteCheckTemplate(ctx context.Context, id platform.ID) error {
+	if err := s.authorizeWrite(ctx, id); err != nil {
+		return err
+	}
+	return s.s.DeleteCheckTemplate(ctx, id)
+}
+
+// InstantiateCheckTemplate checks to see if the authorizer on context has write access to the checks of the template's organization.
+func (s *CheckTemplateService) InstantiateCheckTemplate(ctx context.Context, id platform.ID, targets []map[string]string, userID platform.ID) ([]*influxdb.CheckTemplateInstance, error) {
+	if err := s.authorizeWrite(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.s.InstantiateCheckTemplate(ctx, id, targets, userID)
+}
+
+// FindCheckTemplateInstances checks to see if the authorizer on context has read access to the checks of the template's organization.
+func (s *CheckTemplateService) FindCheckTemplateInstances(ctx context.Context, templateID platform.ID) ([]*influxdb.CheckTemplateInstance, error) {
+	if _, err := s.FindCheckTemplateByID(ctx, templateID); err != nil {
+		return nil, err
+	}
+	return s.s.FindCheckTemplateInstances(ctx, templateID)
+}
+
+// DeleteCheckTemplateInstance checks to see if the authorizer on context has write access to the checks of the template's organization.
+func (s *CheckTemplateService) DeleteCheckTemplateInstance(ctx context.Context, templateID, checkID platform.ID) error {
+	if err := s.authorizeWrite(ctx, templateID); err != nil {
+		return err
+	}
+	return s.s.DeleteCheckTemplateInstance(ctx, templateID, checkID)
+}
+
+func (s *CheckTemplateService) authorizeWrite(ctx context.Context, id platform.ID) error {
+	t, err := s.s.FindCheckTemplateByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	_, _, err = AuthorizeOrgWriteResource(ctx, influxdb.ChecksResourceType, t.OrganizationID)
+	return err
+}
@@ -2,6 +2,7 @@ package authorizer
 
 import (
 	"context"
+	"time"
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/platform"
@@ -142,3 +143,160 @@ func (s *DashboardService) ReplaceDashboardCells(ctx context.Context, id platfor
 	}
 	return s.s.ReplaceDashboardCells(ctx, id, c)
 }
+
+var _ influxdb.DashboardRevisionService = (*DashboardRevisionService)(nil)
+
+// DashboardRevisionService wraps a influxdb.DashboardRevisionService and authorizes
+// actions against it with the permissions of the dashboard each revision belongs to.
+type DashboardRevisionService struct {
+	s          influxdb.DashboardRevisionService
+	dashboards influxdb.DashboardService
+}
+
+// NewDashboardRevisionService constructs an instance of an authorizing dashboard revision service.
+// The unauthorized dashboards service is used to look up the organization of each dashboard.
+func NewDashboardRevisionService(s influxdb.DashboardRevisionService, dashboards influxdb.DashboardService) *DashboardRevisionService {
+	return &DashboardRevisionService{
+		s:          s,
+		dashboards: dashboards,
+	}
+}
+
+// FindDashboardRevisions checks to see if the authorizer on context has read access to the dashboard provided.
+func (s *DashboardRevisionService) FindDashboardRevisions(ctx context.Context, dashboardID platform.ID, opts influxdb.FindOptions) ([]*influxdb.DashboardRevision, int, error) {
+	b, err := s.dashboards.FindDashboardByID(ctx, dashboardID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, _, err := AuthorizeRead(ctx, influxdb.DashboardsResourceType, dashboardID, b.OrganizationID); err != nil {
+		return nil, 0, err
+	}
+	return s.s.FindDashboardRevisions(ctx, dashboardID, opts)
+}
+
+// FindDashboardRevision checks to see if the authorizer on context has read access to the dashboard provided.
+func (s *DashboardRevisionService) FindDashboardRevision(ctx context.Context, dashboardID platform.ID, version int) (*influxdb.DashboardRevision, error) {
+	b, err := s.dashboards.FindDashboardByID(ctx, dashboardID)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := AuthorizeRead(ctx, influxdb.DashboardsResourceType, dashboardID, b.OrganizationID); err != nil {
+		return nil, err
+	}
+	return s.s.FindDashboardRevision(ctx, dashboardID, version)
+}
+
+// RestoreDashboardRevision checks to see if the authorizer on context has write access to the dashboard provided.
+func (s *DashboardRevisionService) RestoreDashboardRevision(ctx context.Context, dashboardID platform.ID, version int) (*influxdb.Dashboard, error) {
+	b, err := s.dashboards.FindDashboardByID(ctx, dashboardID)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := AuthorizeWrite(ctx, influxdb.DashboardsResourceType, dashboardID, b.OrganizationID); err != nil {
+		return nil, err
+	}
+	return s.s.RestoreDashboardRevision(ctx, dashboardID, version)
+}
+
+var _ influxdb.DashboardShareService = (*DashboardShareService)(nil)
+
+// DashboardShareService wraps a influxdb.DashboardShareService and authorizes
+// actions against it with the permissions of the dashboard each share belongs to.
+type DashboardShareService struct {
+	s          influxdb.DashboardShareService
+	dashboards influxdb.DashboardService
+}
+
+// NewDashboardShareService constructs an instance of an authorizing dashboard share service.
+// The unauthorized dashboards service is used to look up the organization of each dashboard.
+func NewDashboardShareService(s influxdb.DashboardShareService, dashboards influxdb.DashboardService) *DashboardShareService {
+	return &DashboardShareService{
+		s:          s,
+		dashboards: dashboards,
+	}
+}
+
+// CreateDashboardShare checks to see if the authorizer on context has write access to the dashboard provided.
+func (s *DashboardShareService) CreateDashboardShare(ctx context.Context, dashboardID platform.ID, expiresAt time.Time) (*influxdb.DashboardShare, error) {
+	if err := s.authorizeWrite(ctx, dashboardID); err != nil {
+		return nil, err
+	}
+	return s.s.CreateDashboardShare(ctx, dashboardID, expiresAt)
+}
+
+// FindDashboardShares checks to see if the authorizer on context has write access to the dashboard provided.
+func (s *DashboardShareService) FindDashboardShares(ctx context.Context, dashboardID platform.ID) ([]*influxdb.DashboardShare, error) {
+	if err := s.authorizeWrite(ctx, dashboardID); err != nil {
+		return nil, err
+	}
+	return s.s.FindDashboardShares(ctx, dashboardID)
+}
+
+// FindDashboardShareByToken requires no permissions, since holding a valid token
+// is what grants access to the shared dashboard.
+func (s *DashboardShareService) FindDashboardShareByToken(ctx context.Context, token string) (*influxdb.DashboardShare, error) {
+	return s.s.FindDashboardShareByToken(ctx, token)
+}
+
+// DeleteDashboardShare checks to see if the authorizer on context has write access to the dashboard provided.
+func (s *DashboardShareService) DeleteDashboardShare(ctx context.Context, dashboardID, shareID platform.ID) error {
+	if err := s.authorizeWrite(ctx, dashboardID); err != nil {
+		return err
+	}
+	return s.s.DeleteDashboardShare(ctx, dashboardID, shareID)
+}
+
+func (s *DashboardShareService) authorizeWrite(ctx context.Context, dashboardID platform.ID) error {
+	b, err := s.dashboards.FindDashboardByID(ctx, dashboardID)
+	if err != nil {
+		return err
+	}
+	_, _, err = AuthorizeWrite(ctx, influxdb.DashboardsResourceType, dashboardID, b.OrganizationID)
+	return err
+}
+
+var _ influxdb.DashboardUsageService = (*DashboardUsageService)(nil)
+
+// DashboardUsageService wraps a influxdb.DashboardUsageService and authorizes
+// reading the usage of a dashboard with the permissions of the dashboard.
+type DashboardUsageService struct {
+	s          influxdb.DashboardUsageService
+	dashboards influxdb.DashboardService
+}
+
+// NewDashboardUsageService constructs an instance of an authorizing dashboard usage service.
+// The unauthorized dashboards service is used to look up the organization of each dashboard.
+func NewDashboardUsageService(s influxdb.DashboardUsageService, dashboards influxdb.DashboardService) *DashboardUsageService {
+	return &DashboardUsageService{
+		s:          s,
+		dashboards: dashboards,
+	}
+}
+
+// RecordDashboardView requires no permissions, since callers record views of dashboards they have already read.
+func (s *DashboardUsageService) RecordDashboardView(ctx context.Context, dashboardID platform.ID) {
+	s.s.RecordDashboardView(ctx, dashboardID)
+}
+
+// RecordCellView requires no permissions, since callers record views of cells they have already read.
+func (s *DashboardUsageService) RecordCellView(ctx context.Context, dashboardID, cellID platform.ID) {
+	s.s.RecordCellView(ctx, dashboardID, cellID)
+}
+
+// RecordDashboardQuery requires no permissions, since queries are only counted
+// for dashboards of the organization they were run by.
+func (s *DashboardUsageService) RecordDashboardQuery(ctx context.Context, orgID, dashboardID, cellID platform.ID) {
+	s.s.RecordDashboardQuery(ctx, orgID, dashboardID, cellID)
+}
+
+// FindDashboardUsage checks to see if the authorizer on context has read access to the dashboard provided.
+func (s *DashboardUsageService) FindDashboardUsage(ctx context.Context, dashboardID platform.ID) (*influxdb.DashboardUsage, error) {
+	d, err := s.dashboards.FindDashboardByID(ctx, dashboardID)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := AuthorizeRead(ctx, influxdb.DashboardsResourceType, d.ID, d.OrganizationID); err != nil {
+		return nil, err
+	}
+	return s.s.FindDashboardUsage(ctx, dashboardID)
+}
@@ -0,0 +1,78 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+var _ influxdb.DeleteJobService = (*DeleteJobService)(nil)
+
+// DeleteJobService wraps a influxdb.DeleteJobService and authorizes actions
+// against it. Delete jobs are authorized with the permissions of their bucket.
+type DeleteJobService struct {
+	s influxdb.DeleteJobService
+}
+
+// NewDeleteJobService constructs an instance of an authorizing delete job service.
+func NewDeleteJobService(s influxdb.DeleteJobService) *DeleteJobService {
+	return &DeleteJobService{
+		s: s,
+	}
+}
+
+// CreateDeleteJob checks to see if the authorizer on context has write access to the bucket.
+func (s *DeleteJobService) CreateDeleteJob(ctx context.Context, req influxdb.DeleteJobRequest) (*influxdb.DeleteJob, error) {
+	if _, _, err := AuthorizeWrite(ctx, influxdb.BucketsResourceType, req.BucketID, req.OrgID); err != nil {
+		return nil, err
+	}
+	return s.s.CreateDeleteJob(ctx, req)
+}
+
+// FindDeleteJobByID checks to see if the authorizer on context has read access to the job's bucket.
+func (s *DeleteJobService) FindDeleteJobByID(ctx context.Context, id platform.ID) (*influxdb.DeleteJob, error) {
+	j, err := s.s.FindDeleteJobByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := AuthorizeRead(ctx, influxdb.BucketsResourceType, j.BucketID, j.OrgID); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// FindDeleteJobs retrieves the delete jobs of an organization and then filters the list down to
+// the jobs of the buckets that are authorized.
+func (s *DeleteJobService) FindDeleteJobs(ctx context.Context, orgID platform.ID) ([]*influxdb.DeleteJob, error) {
+	js, err := s.s.FindDeleteJobs(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	rjs := js[:0]
+	for _, j := range js {
+		_, _, err := AuthorizeRead(ctx, influxdb.BucketsResourceType, j.BucketID, j.OrgID)
+		if err != nil && errors.ErrorCode(err) != errors.EUnauthorized {
+			return nil, err
+		}
+		if errors.ErrorCode(err) == errors.EUnauthorized {
+			continue
+		}
+		rjs = append(rjs, j)
+	}
+	return rjs, nil
+}
+
+// CancelDeleteJob checks to see if the authorizer on context has write access to the job's bucket.
+func (s *DeleteJobService) CancelDeleteJob(ctx context.Context, id platform.ID) (*influxdb.DeleteJob, error) {
+	j, err := s.s.FindDeleteJobByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := AuthorizeWrite(ctx, influxdb.BucketsResourceType, j.BucketID, j.OrgID); err != nil {
+		return nil, err
+	}
+	return s.s.CancelDeleteJob(ctx, id)
+}
@@ -0,0 +1,92 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+var _ influxdb.DeletePolicyService = (*DeletePolicyService)(nil)
+
+// DeletePolicyService wraps a influxdb.DeletePolicyService and authorizes actions
+// against it. Delete policies are authorized with the permissions of their bucket,
+// on top of those of their task.
+type DeletePolicyService struct {
+	s influxdb.DeletePolicyService
+}
+
+// NewDeletePolicyService constructs an instance of an authorizing delete policy service.
+func NewDeletePolicyService(s influxdb.DeletePolicyService) *DeletePolicyService {
+	return &DeletePolicyService{
+		s: s,
+	}
+}
+
+// FindDeletePolicyByID checks to see if the authorizer on context has read access to the policy's bucket.
+func (s *DeletePolicyService) FindDeletePolicyByID(ctx context.Context, id platform.ID) (*influxdb.DeletePolicy, error) {
+	p, err := s.s.FindDeletePolicyByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := AuthorizeRead(ctx, influxdb.BucketsResourceType, p.BucketID, p.OrganizationID); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// FindDeletePolicies retrieves all delete policies that match the provided filter and then filters
+// the list down to the policies of the buckets that are authorized.
+func (s *DeletePolicyService) FindDeletePolicies(ctx context.Context, filter influxdb.DeletePolicyFilter) ([]*influxdb.DeletePolicy, error) {
+	ps, err := s.s.FindDeletePolicies(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	rps := ps[:0]
+	for _, p := range ps {
+		_, _, err := AuthorizeRead(ctx, influxdb.BucketsResourceType, p.BucketID, p.OrganizationID)
+		if err != nil && errors.ErrorCode(err) != errors.EUnauthorized {
+			return nil, err
+		}
+		if errors.ErrorCode(err) == errors.EUnauthorized {
+			continue
+		}
+		rps = append(rps, p)
+	}
+	return rps, nil
+}
+
+// CreateDeletePolicy checks to see if the authorizer on context has write access to the bucket.
+func (s *DeletePolicyService) CreateDeletePolicy(ctx context.Context, p *influxdb.DeletePolicy) error {
+	if _, _, err := AuthorizeWrite(ctx, influxdb.BucketsResourceType, p.BucketID, p.OrganizationID); err != nil {
+		return err
+	}
+	return s.s.CreateDeletePolicy(ctx, p)
+}
+
+// UpdateDeletePolicy checks to see if the authorizer on context has write access to the policy's bucket.
+func (s *DeletePolicyService) UpdateDeletePolicy(ctx context.Context, id platform.ID, upd influxdb.DeletePolicyUpdate) (*influxdb.DeletePolicy, error) {
+	if err := s.authorizeWrite(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.s.UpdateDeletePolicy(ctx, id, upd)
+}
+
+// DeleteDeletePolicy checks to see if the authorizer on context has write access to the policy's bucket.
+func (s *DeletePolicyService) DeleteDeletePolicy(ctx context.Context, id platform.ID) error {
+	if err := s.authorizeWrite(ctx, id); err != nil {
+		return err
+	}
+	return s.s.DeleteDeletePolicy(ctx, id)
+}
+
+func (s *DeletePolicyService) authorizeWrite(ctx context.Context, id platform.ID) error {
+	p, err := s.s.FindDeletePolicyByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	_, _, err = AuthorizeWrite(ctx, influxdb.BucketsResourceType, p.BucketID, p.OrganizationID)
+	return err
+}
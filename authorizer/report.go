@@ -0,0 +1,163 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+var _ influxdb.ReportService = (*ReportService)(nil)
+var _ influxdb.ReportRunService = (*ReportRunService)(nil)
+
+// ReportService wraps a influxdb.ReportService and authorizes actions against
+// it. Reports are authorized with the permissions of their dashboard.
+type ReportService struct {
+	s          influxdb.ReportService
+	dashboards influxdb.DashboardService
+}
+
+// NewReportService constructs an instance of an authorizing report service.
+// The unauthorized dashboards service is used to look up the organization of each dashboard.
+func NewReportService(s influxdb.ReportService, dashboards influxdb.DashboardService) *ReportService {
+	return &ReportService{
+		s:          s,
+		dashboards: dashboards,
+	}
+}
+
+// FindReportByID checks to see if the authorizer on context has read access to the report's dashboard.
+func (s *ReportService) FindReportByID(ctx context.Context, id platform.ID) (*influxdb.Report, error) {
+	r, err := s.s.FindReportByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := AuthorizeRead(ctx, influxdb.DashboardsResourceType, r.DashboardID, r.OrganizationID); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// FindReports retrieves all reports that match the provided filter and then filters the list down to only the resources that are authorized.
+func (s *ReportService) FindReports(ctx context.Context, filter influxdb.ReportFilter, opts influxdb.FindOptions) ([]*influxdb.Report, int, error) {
+	rs, _, err := s.s.FindReports(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rrs := rs[:0]
+	for _, r := range rs {
+		_, _, err := AuthorizeRead(ctx, influxdb.DashboardsResourceType, r.DashboardID, r.OrganizationID)
+		if err != nil && errors.ErrorCode(err) != errors.EUnauthorized {
+			return nil, 0, err
+		}
+		if errors.ErrorCode(err) == errors.EUnauthorized {
+			continue
+		}
+		rrs = append(rrs, r)
+	}
+	return rrs, len(rrs), nil
+}
+
+// CreateReport checks to see if the authorizer on context has write access to the dashboard
+// and read access to the notification endpoint provided.
+func (s *ReportService) CreateReport(ctx context.Context, r *influxdb.Report) error {
+	if err := s.authorizeDashboard(ctx, r.DashboardID, r.OrganizationID); err != nil {
+		return err
+	}
+	if _, _, err := AuthorizeRead(ctx, influxdb.NotificationEndpointResourceType, r.EndpointID, r.OrganizationID); err != nil {
+		return err
+	}
+	return s.s.CreateReport(ctx, r)
+}
+
+// UpdateReport checks to see if the authorizer on context has write access to the report's dashboard,
+// and read access to the notification endpoint it is updated with.
+func (s *ReportService) UpdateReport(ctx context.Context, id platform.ID, upd influxdb.ReportUpdate) (*influxdb.Report, error) {
+	r, err := s.authorizeWrite(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if upd.EndpointID != nil {
+		if _, _, err := AuthorizeRead(ctx, influxdb.NotificationEndpointResourceType, *upd.EndpointID, r.OrganizationID); err != nil {
+			return nil, err
+		}
+	}
+	return s.s.UpdateReport(ctx, id, upd)
+}
+
+// DeleteReport checks to see if the authorizer on context has write access to the report's dashboard.
+func (s *ReportService) DeleteReport(ctx context.Context, id platform.ID) error {
+	if _, err := s.authorizeWrite(ctx, id); err != nil {
+		return err
+	}
+	return s.s.DeleteReport(ctx, id)
+}
+
+func (s *ReportService) authorizeWrite(ctx context.Context, id platform.ID) (*influxdb.Report, error) {
+	r, err := s.s.FindReportByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := AuthorizeWrite(ctx, influxdb.DashboardsResourceType, r.DashboardID, r.OrganizationID); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// authorizeDashboard checks that the dashboard belongs to the organization and
+// that the authorizer on context has write access to it.
+func (s *ReportService) authorizeDashboard(ctx context.Context, dashboardID, orgID platform.ID) error {
+	d, err := s.dashboards.FindDashboardByID(ctx, dashboardID)
+	if err != nil {
+		return err
+	}
+	if d.OrganizationID != orgID {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "report dashboard belongs to a different organization than the report",
+		}
+	}
+	_, _, err = AuthorizeWrite(ctx, influxdb.DashboardsResourceType, d.ID, d.OrganizationID)
+	return err
+}
+
+// ReportRunService wraps a influxdb.ReportRunService and authorizes actions against it.
+type ReportRunService struct {
+	s       influxdb.ReportRunService
+	reports influxdb.ReportService
+}
+
+// NewReportRunService constructs an instance of an authorizing report run service.
+// The unauthorized reports service is used to look up the dashboard of each report.
+func NewReportRunService(s influxdb.ReportRunService, reports influxdb.ReportService) *ReportRunService {
+	return &ReportRunService{
+		s:       s,
+		reports: reports,
+	}
+}
+
+// RenderReport checks to see if the authorizer on context has read access to the report's dashboard.
+func (s *ReportRunService) RenderReport(ctx context.Context, id platform.ID, format string) (*influxdb.RenderedReport, error) {
+	r, err := s.reports.FindReportByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := AuthorizeRead(ctx, influxdb.DashboardsResourceType, r.DashboardID, r.OrganizationID); err != nil {
+		return nil, err
+	}
+	return s.s.RenderReport(ctx, id, format)
+}
+
+// RunReport checks to see if the authorizer on context has write access to the report's dashboard.
+func (s *ReportRunService) RunReport(ctx context.Context, id platform.ID) (*influxdb.Report, error) {
+	r, err := s.reports.FindReportByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := AuthorizeWrite(ctx, influxdb.DashboardsResourceType, r.DashboardID, r.OrganizationID); err != nil {
+		return nil, err
+	}
+	return s.s.RunReport(ctx, id)
+}
@@ -0,0 +1,91 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+var _ influxdb.SilenceService = (*SilenceService)(nil)
+
+// SilenceService wraps a influxdb.SilenceService and authorizes actions against
+// it. Silences mute the notification rules of their organization, they are
+// authorized with the permissions on the notification rules of the organization.
+type SilenceService struct {
+	s influxdb.SilenceService
+}
+
+// NewSilenceService constructs an instance of an authorizing silence service.
+func NewSilenceService(s influxdb.SilenceService) *SilenceService {
+	return &SilenceService{
+		s: s,
+	}
+}
+
+// FindSilenceByID checks to see if the authorizer on context has read access to the notification rules of the silence's organization.
+func (s *SilenceService) FindSilenceByID(ctx context.Context, id platform.ID) (*influxdb.Silence, error) {
+	sil, err := s.s.FindSilenceByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := AuthorizeOrgReadResource(ctx, influxdb.NotificationRuleResourceType, sil.OrganizationID); err != nil {
+		return nil, err
+	}
+	return sil, nil
+}
+
+// FindSilences retrieves all silences that match the provided filter and then filters the list down to only the resources that are authorized.
+func (s *SilenceService) FindSilences(ctx context.Context, filter influxdb.SilenceFilter, opts influxdb.FindOptions) ([]*influxdb.Silence, int, error) {
+	ss, _, err := s.s.FindSilences(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rss := ss[:0]
+	for _, sil := range ss {
+		_, _, err := AuthorizeOrgReadResource(ctx, influxdb.NotificationRuleResourceType, sil.OrganizationID)
+		if err != nil && errors.ErrorCode(err) != errors.EUnauthorized {
+			return nil, 0, err
+		}
+		if errors.ErrorCode(err) == errors.EUnauthorized {
+			continue
+		}
+		rss = append(rss, sil)
+	}
+	return rss, len(rss), nil
+}
+
+// CreateSilence checks to see if the authorizer on context has write access to the notification rules of the organization.
+func (s *SilenceService) CreateSilence(ctx context.Context, sil *influxdb.Silence) error {
+	if _, _, err := AuthorizeOrgWriteResource(ctx, influxdb.NotificationRuleResourceType, sil.OrganizationID); err != nil {
+		return err
+	}
+	return s.s.CreateSilence(ctx, sil)
+}
+
+// UpdateSilence checks to see if the authorizer on context has write access to the notification rules of the silence's organization.
+func (s *SilenceService) UpdateSilence(ctx context.Context, id platform.ID, upd influxdb.SilenceUpdate) (*influxdb.Silence, error) {
+	if err := s.authorizeWrite(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.s.UpdateSilence(ctx, id, upd)
+}
+
+// DeleteSilence checks to see if the authorizer on context has write access to the notification rules of the silence's organization.
+func (s *SilenceService) DeleteSilence(ctx context.Context, id platform.ID) error {
+	if err := s.authorizeWrite(ctx, id); err != nil {
+		return err
+	}
+	return s.s.DeleteSilence(ctx, id)
+}
+
+func (s *SilenceService) authorizeWrite(ctx context.Context, id platform.ID) error {
+	sil, err := s.s.FindSilenceByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	_, _, err = AuthorizeOrgWriteResource(ctx, influxdb.NotificationRuleResourceType, sil.OrganizationID)
+	return err
+}
@@ -73,15 +73,25 @@ func (a Action) Valid() (err error) {
 // ResourceType is an enum defining all resource types that have a permission model in platform
 type ResourceType string
 
-// Resource is an authorizable resource.
+// Resource is an authorizable resource. A resource with a label ID is any
+// resource of its type having the label, or a label descending from it.
 type Resource struct {
-	Type  ResourceType `json:"type"`
-	ID    *platform.ID `json:"id,omitempty"`
-	OrgID *platform.ID `json:"orgID,omitempty"`
+	Type    ResourceType `json:"type"`
+	ID      *platform.ID `json:"id,omitempty"`
+	OrgID   *platform.ID `json:"orgID,omitempty"`
+	LabelID *platform.ID `json:"labelID,omitempty"`
 }
 
 // String stringifies a resource
 func (r Resource) String() string {
+	if r.LabelID != nil {
+		s := path.Join(string(r.Type), string(LabelsResourceType), r.LabelID.String())
+		if r.OrgID != nil {
+			s = path.Join(string(OrgsResourceType), r.OrgID.String(), s)
+		}
+		return s
+	}
+
 	if r.OrgID != nil && r.ID != nil {
 		return path.Join(string(OrgsResourceType), r.OrgID.String(), string(r.Type), r.ID.String())
 	}
@@ -242,6 +252,12 @@ func (p Permission) matchesV1(perm Permission) bool {
 		return false
 	}
 
+	// The resources of a label are resolved by the authorizer, from the labels
+	// of the resource, so a label permission only matches permissions of its label.
+	if p.Resource.LabelID != nil {
+		return perm.Resource.LabelID != nil && *p.Resource.LabelID == *perm.Resource.LabelID
+	}
+
 	if p.Resource.OrgID == nil && p.Resource.ID == nil {
 		return true
 	}
@@ -314,6 +330,22 @@ func (p *Permission) Valid() error {
 		}
 	}
 
+	if p.Resource.LabelID != nil {
+		if !p.Resource.LabelID.Valid() {
+			return &errors2.Error{
+				Code: errors2.EInvalid,
+				Err:  platform.ErrInvalidID,
+				Msg:  "invalid label id for permission",
+			}
+		}
+		if p.Resource.ID != nil {
+			return &errors2.Error{
+				Code: errors2.EInvalid,
+				Msg:  "permission cannot target both a resource id and a label id",
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -343,6 +375,21 @@ func NewResourcePermission(a Action, rt ResourceType, rid platform.ID) (*Permiss
 	return p, p.Valid()
 }
 
+// NewLabelPermission returns a permission for the resources of type rt in an
+// organization having a label.
+func NewLabelPermission(a Action, rt ResourceType, orgID, labelID platform.ID) (*Permission, error) {
+	p := &Permission{
+		Action: a,
+		Resource: Resource{
+			Type:    rt,
+			OrgID:   &orgID,
+			LabelID: &labelID,
+		},
+	}
+
+	return p, p.Valid()
+}
+
 // NewGlobalPermission constructs a global permission capable of accessing any resource of type rt.
 func NewGlobalPermission(a Action, rt ResourceType) (*Permission, error) {
 	p := &Permission{
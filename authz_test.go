@@ -35,6 +35,71 @@ func TestAuthorizer_PermissionAllowed(t *testing.T) {
 			},
 			allowed: true,
 		},
+		{
+			name: "label permission does not match resources",
+			permission: platform.Permission{
+				Action: platform.WriteAction,
+				Resource: platform.Resource{
+					Type:  platform.BucketsResourceType,
+					OrgID: influxdbtesting.IDPtr(1),
+					ID:    influxdbtesting.IDPtr(1),
+				},
+			},
+			permissions: []platform.Permission{
+				{
+					Action: platform.WriteAction,
+					Resource: platform.Resource{
+						Type:    platform.BucketsResourceType,
+						OrgID:   influxdbtesting.IDPtr(1),
+						LabelID: influxdbtesting.IDPtr(2),
+					},
+				},
+			},
+			allowed: false,
+		},
+		{
+			name: "label permission matches its label",
+			permission: platform.Permission{
+				Action: platform.WriteAction,
+				Resource: platform.Resource{
+					Type:    platform.BucketsResourceType,
+					OrgID:   influxdbtesting.IDPtr(1),
+					LabelID: influxdbtesting.IDPtr(2),
+				},
+			},
+			permissions: []platform.Permission{
+				{
+					Action: platform.WriteAction,
+					Resource: platform.Resource{
+						Type:    platform.BucketsResourceType,
+						OrgID:   influxdbtesting.IDPtr(1),
+						LabelID: influxdbtesting.IDPtr(2),
+					},
+				},
+			},
+			allowed: true,
+		},
+		{
+			name: "org permission allows label permission",
+			permission: platform.Permission{
+				Action: platform.WriteAction,
+				Resource: platform.Resource{
+					Type:    platform.BucketsResourceType,
+					OrgID:   influxdbtesting.IDPtr(1),
+					LabelID: influxdbtesting.IDPtr(2),
+				},
+			},
+			permissions: []platform.Permission{
+				{
+					Action: platform.WriteAction,
+					Resource: platform.Resource{
+						Type:  platform.BucketsResourceType,
+						OrgID: influxdbtesting.IDPtr(1),
+					},
+				},
+			},
+			allowed: true,
+		},
 		{
 			name: "bad org id in permission",
 			permission: platform.Permission{
@@ -286,6 +351,30 @@ func TestPermission_Valid(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid bucket permission with label ID",
+			fields: fields{
+				Action: platform.ReadAction,
+				Resource: platform.Resource{
+					Type:    platform.BucketsResourceType,
+					OrgID:   influxdbtesting.IDPtr(1),
+					LabelID: influxdbtesting.IDPtr(2),
+				},
+			},
+		},
+		{
+			name: "invalid bucket permission with ID and label ID",
+			fields: fields{
+				Action: platform.ReadAction,
+				Resource: platform.Resource{
+					Type:    platform.BucketsResourceType,
+					ID:      validID(),
+					OrgID:   influxdbtesting.IDPtr(1),
+					LabelID: influxdbtesting.IDPtr(2),
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalid permission without an action",
 			fields: fields{
@@ -409,6 +498,18 @@ func TestPermission_String(t *testing.T) {
 			},
 			want: `write:buckets`,
 		},
+		{
+			name: "valid permission with a label id",
+			fields: fields{
+				Action: platform.ReadAction,
+				Resource: platform.Resource{
+					Type:    platform.BucketsResourceType,
+					OrgID:   influxdbtesting.IDPtr(1),
+					LabelID: influxdbtesting.IDPtr(2),
+				},
+			},
+			want: `read:orgs/0000000000000001/buckets/labels/0000000000000002`,
+		},
 		{
 			name: "valid permission with no org id",
 			fields: fields{
@@ -72,6 +72,15 @@ type BucketMetadataManifest struct {
 	Description            *string                   `json:"description,omitempty"`
 	DefaultRetentionPolicy string                    `json:"defaultRetentionPolicy"`
 	RetentionPolicies      []RetentionPolicyManifest `json:"retentionPolicies"`
+	DBRPs                  []DBRPManifest            `json:"dbrps,omitempty"`
+}
+
+// DBRPManifest is a database and retention policy mapped to the bucket of a
+// BucketMetadataManifest.
+type DBRPManifest struct {
+	Database        string `json:"database"`
+	RetentionPolicy string `json:"retentionPolicy"`
+	Default         bool   `json:"default"`
 }
 
 type RetentionPolicyManifest struct {
@@ -11,14 +11,16 @@ import (
 )
 
 type BucketManifestWriter struct {
-	ts *tenant.Service
-	mc *meta.Client
+	ts    *tenant.Service
+	mc    *meta.Client
+	dbrps influxdb.DBRPMappingService
 }
 
-func NewBucketManifestWriter(ts *tenant.Service, mc *meta.Client) BucketManifestWriter {
+func NewBucketManifestWriter(ts *tenant.Service, mc *meta.Client, dbrps influxdb.DBRPMappingService) BucketManifestWriter {
 	return BucketManifestWriter{
-		ts: ts,
-		mc: mc,
+		ts:    ts,
+		mc:    mc,
+		dbrps: dbrps,
 	}
 }
 
@@ -41,6 +43,12 @@ func (b BucketManifestWriter) WriteManifest(ctx context.Context, w io.Writer) er
 
 		dbInfo := b.mc.Database(bkt.ID.String())
 
+		virtual := false
+		dbrps, _, err := b.dbrps.FindMany(ctx, influxdb.DBRPMappingFilter{OrgID: &bkt.OrgID, BucketID: &bkt.ID, Virtual: &virtual})
+		if err != nil {
+			return err
+		}
+
 		var description *string
 		if bkt.Description != "" {
 			description = &bkt.Description
@@ -54,6 +62,7 @@ func (b BucketManifestWriter) WriteManifest(ctx context.Context, w io.Writer) er
 			Description:            description,
 			DefaultRetentionPolicy: dbInfo.DefaultRetentionPolicy,
 			RetentionPolicies:      retentionPolicyToManifest(dbInfo.RetentionPolicies),
+			DBRPs:                  dbrpsToManifest(dbrps),
 		})
 	}
 
@@ -79,6 +88,20 @@ func retentionPolicyToManifest(meta []meta.RetentionPolicyInfo) []influxdb.Reten
 	return r
 }
 
+func dbrpsToManifest(dbrps []*influxdb.DBRPMapping) []influxdb.DBRPManifest {
+	r := make([]influxdb.DBRPManifest, 0, len(dbrps))
+
+	for _, d := range dbrps {
+		r = append(r, influxdb.DBRPManifest{
+			Database:        d.Database,
+			RetentionPolicy: d.RetentionPolicy,
+			Default:         d.Default,
+		})
+	}
+
+	return r
+}
+
 func subscriptionInfosToManifest(subInfos []meta.SubscriptionInfo) []influxdb.SubscriptionManifest {
 	r := make([]influxdb.SubscriptionManifest, 0, len(subInfos))
 
@@ -31,7 +31,8 @@ type KVStore struct {
 	db   *bolt.DB
 	log  *zap.Logger
 
-	noSync bool
+	noSync   bool
+	readOnly bool
 }
 
 type KVOption func(*KVStore)
@@ -44,6 +45,12 @@ func WithNoSync(s *KVStore) {
 	s.noSync = true
 }
 
+// WithReadOnly opens the boltdb file read-only, so that every write
+// transaction fails. The file must exist.
+func WithReadOnly(s *KVStore) {
+	s.readOnly = true
+}
+
 // NewKVStore returns an instance of KVStore with the file at
 // the provided path.
 func NewKVStore(log *zap.Logger, path string, opts ...KVOption) *KVStore {
@@ -69,6 +76,14 @@ func (s *KVStore) Open(ctx context.Context) error {
 	span, _ := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
 
+	if s.readOnly {
+		if err := s.openDB(); err != nil {
+			return fmt.Errorf("unable to open boltdb file %v", err)
+		}
+		s.log.Info("Resources opened read-only", zap.String("path", s.path))
+		return nil
+	}
+
 	// Ensure the required directory structure exists.
 	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
 		return fmt.Errorf("unable to create directory %s: %v", s.path, err)
@@ -93,7 +108,7 @@ func (s *KVStore) Open(ctx context.Context) error {
 }
 
 func (s *KVStore) openDB() (err error) {
-	if s.db, err = bolt.Open(s.path, 0600, &bolt.Options{Timeout: 1 * time.Second}); err != nil {
+	if s.db, err = bolt.Open(s.path, 0600, &bolt.Options{Timeout: 1 * time.Second, ReadOnly: s.readOnly}); err != nil {
 		return fmt.Errorf("unable to open boltdb file %v", err)
 	}
 	s.db.NoSync = s.noSync
@@ -17,12 +17,16 @@ const (
 	MonitoringSystemBucketRetention = time.Hour * 24 * 7
 	// TasksSystemBucketRetention is the time we should retain task system bucket information
 	TasksSystemBucketRetention = time.Hour * 24 * 3
+	// InternalSystemBucketRetention is the default time we should retain the statistics
+	// the server writes about itself
+	InternalSystemBucketRetention = time.Hour * 24 * 7
 )
 
 // Bucket names constants
 const (
 	TasksSystemBucketName      = "_tasks"
 	MonitoringSystemBucketName = "_monitoring"
+	InternalSystemBucketName   = "_internal"
 )
 
 // InfiniteRetention is default infinite retention period.
@@ -0,0 +1,137 @@
+package bucketstats
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+type handler struct {
+	log       *zap.Logger
+	api       *kithttp.API
+	bucketSvc influxdb.BucketService
+	tracker   *Tracker
+
+	idLookupKey string
+}
+
+// NewHandler creates the handler of the /api/v2/buckets/:id/stats route, to
+// mount under the bucket with the URL parameter idLookupKey. The stats are
+// those of the buckets bucketSvc finds, so it is expected to authorize the
+// read of the bucket.
+func NewHandler(log *zap.Logger, idLookupKey string, bucketSvc influxdb.BucketService, tracker *Tracker) http.Handler {
+	h := &handler{
+		log:       log,
+		api:       kithttp.NewAPI(kithttp.WithLog(log)),
+		bucketSvc: bucketSvc,
+		tracker:   tracker,
+
+		idLookupKey: idLookupKey,
+	}
+
+	r := chi.NewRouter()
+	r.Get("/", h.handleGetStats)
+	return r
+}
+
+// handleGetStats is the HTTP handler for the GET /api/v2/buckets/:id/stats route.
+func (h *handler) handleGetStats(w http.ResponseWriter, r *http.Request) {
+	id, err := platform.IDFromString(chi.URLParam(r, h.idLookupKey))
+	if err != nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "invalid bucket ID",
+			Err:  err,
+		})
+		return
+	}
+
+	b, err := h.bucketSvc.FindBucketByID(r.Context(), *id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusOK, newStatsResponse(b, h.tracker.Stats(b.ID)))
+}
+
+type statsResponse struct {
+	Links    map[string]string `json:"links"`
+	BucketID platform.ID       `json:"bucketID"`
+	OrgID    platform.ID       `json:"orgID"`
+	Windows  []windowResponse  `json:"windows"`
+}
+
+type windowResponse struct {
+	Window  string          `json:"window"`
+	Seconds int64           `json:"seconds"`
+	Writes  writesResponse  `json:"writes"`
+	Queries queriesResponse `json:"queries"`
+}
+
+type writesResponse struct {
+	Requests        int64   `json:"requests"`
+	Errors          int64   `json:"errors"`
+	ErrorRate       float64 `json:"errorRate"`
+	Points          int64   `json:"points"`
+	Bytes           int64   `json:"bytes"`
+	PointsPerSecond float64 `json:"pointsPerSecond"`
+	BytesPerSecond  float64 `json:"bytesPerSecond"`
+}
+
+type queriesResponse struct {
+	Requests      int64   `json:"requests"`
+	Errors        int64   `json:"errors"`
+	ErrorRate     float64 `json:"errorRate"`
+	ScannedValues int64   `json:"scannedValues"`
+	ScannedBytes  int64   `json:"scannedBytes"`
+}
+
+func newStatsResponse(b *influxdb.Bucket, windows []Window) *statsResponse {
+	res := &statsResponse{
+		Links: map[string]string{
+			"bucket": fmt.Sprintf("/api/v2/buckets/%s", b.ID),
+			"self":   fmt.Sprintf("/api/v2/buckets/%s/stats", b.ID),
+		},
+		BucketID: b.ID,
+		OrgID:    b.OrgID,
+		Windows:  make([]windowResponse, 0, len(windows)),
+	}
+	for _, w := range windows {
+		seconds := w.Duration.Seconds()
+		res.Windows = append(res.Windows, windowResponse{
+			Window:  w.Duration.String(),
+			Seconds: int64(seconds),
+			Writes: writesResponse{
+				Requests:        w.Writes,
+				Errors:          w.WriteErrors,
+				ErrorRate:       rate(w.WriteErrors, w.Writes),
+				Points:          w.PointsWritten,
+				Bytes:           w.BytesWritten,
+				PointsPerSecond: float64(w.PointsWritten) / seconds,
+				BytesPerSecond:  float64(w.BytesWritten) / seconds,
+			},
+			Queries: queriesResponse{
+				Requests:      w.Queries,
+				Errors:        w.QueryErrors,
+				ErrorRate:     rate(w.QueryErrors, w.Queries),
+				ScannedValues: w.ScannedValues,
+				ScannedBytes:  w.ScannedBytes,
+			},
+		})
+	}
+	return res
+}
+
+func rate(n, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
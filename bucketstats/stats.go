@@ -0,0 +1,241 @@
+// Package bucketstats tracks the recent load of each bucket, the writes and the
+// storage reads of queries, so bucket owners can see the load of their own
+// buckets without access to the metrics of the server.
+package bucketstats
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/query"
+)
+
+const (
+	// slotDuration is the resolution of the statistics.
+	slotDuration = time.Minute
+	// numSlots is the number of slots kept, so the statistics cover the last
+	// hour.
+	numSlots = 60
+)
+
+// Windows are the durations of the recent windows the statistics are reported for.
+var Windows = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute, time.Hour}
+
+// PointsWriter writes points to storage.
+type PointsWriter interface {
+	WritePoints(ctx context.Context, orgID platform.ID, bucketID platform.ID, points []models.Point) error
+}
+
+// Counts are the writes and reads of a bucket over some time.
+type Counts struct {
+	// Writes is the number of write requests.
+	Writes int64
+	// WriteErrors is the number of write requests which failed.
+	WriteErrors int64
+	// PointsWritten is the number of points written.
+	PointsWritten int64
+	// BytesWritten is the number of bytes of line protocol written.
+	BytesWritten int64
+	// Queries is the number of storage reads of queries.
+	Queries int64
+	// QueryErrors is the number of storage reads which failed.
+	QueryErrors int64
+	// ScannedValues is the number of values scanned by the reads.
+	ScannedValues int64
+	// ScannedBytes is the number of bytes scanned by the reads.
+	ScannedBytes int64
+}
+
+func (c *Counts) add(o Counts) {
+	c.Writes += o.Writes
+	c.WriteErrors += o.WriteErrors
+	c.PointsWritten += o.PointsWritten
+	c.BytesWritten += o.BytesWritten
+	c.Queries += o.Queries
+	c.QueryErrors += o.QueryErrors
+	c.ScannedValues += o.ScannedValues
+	c.ScannedBytes += o.ScannedBytes
+}
+
+// Window are the counts of a bucket over the recent window of Duration.
+type Window struct {
+	Duration time.Duration
+	Counts
+}
+
+// Tracker tracks the counts of each bucket over the last hour, in slots of a
+// minute.
+type Tracker struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	buckets map[platform.ID]*bucketSlots
+	pruned  int64 // the slot the idle buckets were last pruned at.
+}
+
+type bucketSlots struct {
+	slots [numSlots]Counts
+	last  int64 // the slot counts were last added to.
+}
+
+// NewTracker constructs a tracker of the counts of buckets.
+func NewTracker() *Tracker {
+	return &Tracker{
+		now:     time.Now,
+		buckets: map[platform.ID]*bucketSlots{},
+	}
+}
+
+func (t *Tracker) slot() int64 {
+	return t.now().UnixNano() / int64(slotDuration)
+}
+
+// record adds c to the counts of the current slot of the bucket.
+func (t *Tracker) record(bucketID platform.ID, c Counts) {
+	slot := t.slot()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if slot != t.pruned {
+		t.prune(slot)
+	}
+
+	b, ok := t.buckets[bucketID]
+	if !ok {
+		b = &bucketSlots{last: slot}
+		t.buckets[bucketID] = b
+	}
+	// clear the slots which were not written to since the last record.
+	for s := b.last + 1; s <= slot && s <= b.last+numSlots; s++ {
+		b.slots[s%numSlots] = Counts{}
+	}
+	if slot > b.last {
+		b.last = slot
+	}
+	b.slots[slot%numSlots].add(c)
+}
+
+// prune forgets the buckets which were not recorded for all the slots.
+func (t *Tracker) prune(slot int64) {
+	for id, b := range t.buckets {
+		if slot-b.last >= numSlots {
+			delete(t.buckets, id)
+		}
+	}
+	t.pruned = slot
+}
+
+// Stats returns the counts of the bucket over each of the Windows, the
+// current slot included.
+func (t *Tracker) Stats(bucketID platform.ID) []Window {
+	slot := t.slot()
+
+	windows := make([]Window, len(Windows))
+	for i, d := range Windows {
+		windows[i].Duration = d
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b, ok := t.buckets[bucketID]
+	if !ok {
+		return windows
+	}
+	for age := int64(0); age < numSlots; age++ {
+		s := slot - age
+		if s > b.last || b.last-s >= numSlots {
+			continue
+		}
+		for i, d := range Windows {
+			if age < int64(d/slotDuration) {
+				windows[i].add(b.slots[s%numSlots])
+			}
+		}
+	}
+	return windows
+}
+
+// PointsWriter returns pw recording the writes of buckets.
+func (t *Tracker) PointsWriter(pw PointsWriter) PointsWriter {
+	return &pointsWriter{tracker: t, next: pw}
+}
+
+// StorageReader returns r recording the reads of buckets.
+func (t *Tracker) StorageReader(r query.StorageReader) query.StorageReader {
+	return &storageReader{StorageReader: r, tracker: t}
+}
+
+type pointsWriter struct {
+	tracker *Tracker
+	next    PointsWriter
+}
+
+func (w *pointsWriter) WritePoints(ctx context.Context, orgID platform.ID, bucketID platform.ID, points []models.Point) error {
+	if err := w.next.WritePoints(ctx, orgID, bucketID, points); err != nil {
+		w.tracker.record(bucketID, Counts{Writes: 1, WriteErrors: 1})
+		return err
+	}
+
+	c := Counts{Writes: 1, PointsWritten: int64(len(points))}
+	for _, p := range points {
+		c.BytesWritten += int64(p.StringSize())
+	}
+	w.tracker.record(bucketID, c)
+	return nil
+}
+
+// storageReader records the reads of the series of buckets, the reads of tag
+// keys, tag values and series cardinality are not recorded.
+type storageReader struct {
+	query.StorageReader
+	tracker *Tracker
+}
+
+func (r *storageReader) ReadFilter(ctx context.Context, spec query.ReadFilterSpec, alloc memory.Allocator) (query.TableIterator, error) {
+	ti, err := r.StorageReader.ReadFilter(ctx, spec, alloc)
+	return r.iterator(spec.BucketID, ti, err)
+}
+
+func (r *storageReader) ReadGroup(ctx context.Context, spec query.ReadGroupSpec, alloc memory.Allocator) (query.TableIterator, error) {
+	ti, err := r.StorageReader.ReadGroup(ctx, spec, alloc)
+	return r.iterator(spec.BucketID, ti, err)
+}
+
+func (r *storageReader) ReadWindowAggregate(ctx context.Context, spec query.ReadWindowAggregateSpec, alloc memory.Allocator) (query.TableIterator, error) {
+	ti, err := r.StorageReader.ReadWindowAggregate(ctx, spec, alloc)
+	return r.iterator(spec.BucketID, ti, err)
+}
+
+func (r *storageReader) iterator(bucketID platform.ID, ti query.TableIterator, err error) (query.TableIterator, error) {
+	if err != nil {
+		r.tracker.record(bucketID, Counts{Queries: 1, QueryErrors: 1})
+		return nil, err
+	}
+	return &tableIterator{TableIterator: ti, tracker: r.tracker, bucketID: bucketID}, nil
+}
+
+type tableIterator struct {
+	query.TableIterator
+	tracker  *Tracker
+	bucketID platform.ID
+}
+
+func (ti *tableIterator) Do(f func(flux.Table) error) error {
+	err := ti.TableIterator.Do(f)
+	stats := ti.TableIterator.Statistics()
+	c := Counts{
+		Queries:       1,
+		ScannedValues: int64(stats.ScannedValues),
+		ScannedBytes:  int64(stats.ScannedBytes),
+	}
+	if err != nil {
+		c.QueryErrors = 1
+	}
+	ti.tracker.record(ti.bucketID, c)
+	return err
+}
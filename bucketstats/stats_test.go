@@ -0,0 +1,199 @@
+package bucketstats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	kerrors "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/query"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+const (
+	bucket1 platform.ID = 10
+	bucket2 platform.ID = 20
+)
+
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time      { return c.t }
+func (c *clock) add(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestTracker() (*Tracker, *clock) {
+	c := &clock{t: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	tr := NewTracker()
+	tr.now = c.now
+	return tr, c
+}
+
+type writerFunc func(platform.ID) error
+
+func (f writerFunc) WritePoints(_ context.Context, _, bucketID platform.ID, _ []models.Point) error {
+	return f(bucketID)
+}
+
+type fakeIterator struct {
+	stats cursors.CursorStats
+	err   error
+}
+
+func (i *fakeIterator) Do(func(flux.Table) error) error { return i.err }
+func (i *fakeIterator) Statistics() cursors.CursorStats { return i.stats }
+
+type fakeReader struct {
+	query.StorageReader
+	it *fakeIterator
+}
+
+func (r *fakeReader) ReadFilter(context.Context, query.ReadFilterSpec, memory.Allocator) (query.TableIterator, error) {
+	return r.it, nil
+}
+
+func (r *fakeReader) ReadGroup(context.Context, query.ReadGroupSpec, memory.Allocator) (query.TableIterator, error) {
+	return nil, errors.New("unsupported group")
+}
+
+func TestTracker_Windows(t *testing.T) {
+	tr, c := newTestTracker()
+	pw := tr.PointsWriter(writerFunc(func(platform.ID) error { return nil }))
+	points, err := models.ParsePointsString("cpu value=1 1\ncpu value=2 2")
+	require.NoError(t, err)
+
+	write := func() { require.NoError(t, pw.WritePoints(context.Background(), 1, bucket1, points)) }
+
+	write() // 30 minutes ago
+	c.add(20 * time.Minute)
+	write() // 10 minutes ago
+	write()
+	c.add(7 * time.Minute)
+	write() // 3 minutes ago
+	c.add(3 * time.Minute)
+	write() // now
+
+	got := map[time.Duration]int64{}
+	for _, w := range tr.Stats(bucket1) {
+		got[w.Duration] = w.Writes
+		assert.Equal(t, 2*w.Writes, w.PointsWritten)
+	}
+	assert.Equal(t, map[time.Duration]int64{
+		time.Minute:      1,
+		5 * time.Minute:  2,
+		15 * time.Minute: 4,
+		time.Hour:        5,
+	}, got)
+
+	// the writes of other buckets are not reported.
+	for _, w := range tr.Stats(bucket2) {
+		assert.Zero(t, w.Writes)
+	}
+
+	// the writes are forgotten after an hour.
+	c.add(time.Hour)
+	for _, w := range tr.Stats(bucket1) {
+		assert.Zero(t, w.Writes)
+	}
+
+	// slots of the previous hour are not reported again.
+	write()
+	c.add(30 * time.Minute)
+	write()
+	for _, w := range tr.Stats(bucket1) {
+		if w.Duration == time.Hour {
+			assert.Equal(t, int64(2), w.Writes)
+		}
+	}
+
+	// idle buckets are forgotten.
+	c.add(2 * time.Hour)
+	require.NoError(t, tr.PointsWriter(writerFunc(func(platform.ID) error { return nil })).WritePoints(context.Background(), 1, bucket2, nil))
+	assert.NotContains(t, tr.buckets, bucket1)
+}
+
+func TestTracker_WriteErrors(t *testing.T) {
+	tr, _ := newTestTracker()
+	pw := tr.PointsWriter(writerFunc(func(platform.ID) error { return errors.New("shard is closed") }))
+	assert.Error(t, pw.WritePoints(context.Background(), 1, bucket1, nil))
+
+	w := tr.Stats(bucket1)[0]
+	assert.Equal(t, int64(1), w.Writes)
+	assert.Equal(t, int64(1), w.WriteErrors)
+}
+
+func TestTracker_Reads(t *testing.T) {
+	tr, _ := newTestTracker()
+	it := &fakeIterator{stats: cursors.CursorStats{ScannedValues: 3, ScannedBytes: 24}}
+	r := tr.StorageReader(&fakeReader{it: it})
+
+	ti, err := r.ReadFilter(context.Background(), query.ReadFilterSpec{BucketID: bucket1}, nil)
+	require.NoError(t, err)
+	require.NoError(t, ti.Do(func(flux.Table) error { return nil }))
+
+	it.err = errors.New("canceled")
+	ti, err = r.ReadFilter(context.Background(), query.ReadFilterSpec{BucketID: bucket1}, nil)
+	require.NoError(t, err)
+	require.Error(t, ti.Do(func(flux.Table) error { return nil }))
+
+	_, err = r.ReadGroup(context.Background(), query.ReadGroupSpec{ReadFilterSpec: query.ReadFilterSpec{BucketID: bucket1}}, nil)
+	require.Error(t, err)
+
+	w := tr.Stats(bucket1)[0]
+	assert.Equal(t, int64(3), w.Queries)
+	assert.Equal(t, int64(2), w.QueryErrors)
+	assert.Equal(t, int64(6), w.ScannedValues)
+	assert.Equal(t, int64(48), w.ScannedBytes)
+}
+
+type fakeBuckets struct {
+	influxdb.BucketService
+}
+
+func (fakeBuckets) FindBucketByID(_ context.Context, id platform.ID) (*influxdb.Bucket, error) {
+	if id != bucket1 {
+		return nil, &kerrors.Error{Code: kerrors.ENotFound, Msg: "bucket not found"}
+	}
+	return &influxdb.Bucket{ID: id, OrgID: 1}, nil
+}
+
+func TestHandler_GetStats(t *testing.T) {
+	tr, _ := newTestTracker()
+	pw := tr.PointsWriter(writerFunc(func(platform.ID) error { return nil }))
+	points, err := models.ParsePointsString("cpu value=1 1")
+	require.NoError(t, err)
+	require.NoError(t, pw.WritePoints(context.Background(), 1, bucket1, points))
+
+	r := chi.NewRouter()
+	r.Mount("/api/v2/buckets/{id}/stats", NewHandler(zaptest.NewLogger(t), "id", fakeBuckets{}, tr))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v2/buckets/"+bucket1.String()+"/stats", nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var res statsResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&res))
+	assert.Equal(t, bucket1, res.BucketID)
+	require.Len(t, res.Windows, len(Windows))
+	assert.Equal(t, "1m0s", res.Windows[0].Window)
+	assert.Equal(t, int64(1), res.Windows[0].Writes.Requests)
+	assert.Equal(t, int64(points[0].StringSize()), res.Windows[0].Writes.Bytes)
+	assert.InDelta(t, 1.0/60, res.Windows[0].Writes.PointsPerSecond, 1e-9)
+
+	for id, code := range map[string]int{bucket2.String(): http.StatusNotFound, "nope": http.StatusBadRequest} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v2/buckets/"+id+"/stats", nil))
+		assert.Equal(t, code, rec.Code)
+	}
+}
@@ -0,0 +1,115 @@
+package cdc
+
+import (
+	"fmt"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/predicate"
+)
+
+// filter matches the points of a write to a predicate, using the syntax of
+// delete predicates. The _measurement key is the name of a point, the _field
+// key the keys of its fields, and the other keys are tags, a tag missing from a
+// point matching a != rule.
+type filter struct {
+	node predicate.Node
+	// fields is set when the predicate has a rule on _field, in which case only
+	// the fields of a point matching the predicate are kept.
+	fields bool
+}
+
+// newFilter returns the filter of the predicate pred, nil if pred is empty.
+func newFilter(pred string) (*filter, error) {
+	node, err := predicate.Parse(pred)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil {
+		return nil, nil
+	}
+	f := &filter{node: node}
+	if err := f.check(node); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *filter) check(node predicate.Node) error {
+	switch n := node.(type) {
+	case predicate.LogicalNode:
+		for _, child := range n.Children {
+			if err := f.check(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	case predicate.TagRuleNode:
+		if n.Operator != influxdb.Equal && n.Operator != influxdb.NotEqual {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("unsupported operator %s in subscription predicate", n.Operator),
+			}
+		}
+		if n.Key == "_field" {
+			f.fields = true
+		}
+		return nil
+	default:
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("unsupported subscription predicate %T", node),
+		}
+	}
+}
+
+// match returns p, or a point with the fields of p matching the predicate, or
+// nil if p does not match it.
+func (f *filter) match(p models.Point) (models.Point, error) {
+	if f == nil {
+		return p, nil
+	}
+	if !f.fields {
+		if !f.matches(f.node, p, "") {
+			return nil, nil
+		}
+		return p, nil
+	}
+
+	fields, err := p.Fields()
+	if err != nil {
+		return nil, err
+	}
+	matched := 0
+	for k := range fields {
+		if f.matches(f.node, p, k) {
+			matched++
+			continue
+		}
+		delete(fields, k)
+	}
+	if matched == 0 {
+		return nil, nil
+	}
+	return models.NewPoint(string(p.Name()), p.Tags(), fields, p.Time())
+}
+
+func (f *filter) matches(node predicate.Node, p models.Point, field string) bool {
+	switch n := node.(type) {
+	case predicate.LogicalNode:
+		return f.matches(n.Children[0], p, field) && f.matches(n.Children[1], p, field)
+	case predicate.TagRuleNode:
+		var v string
+		switch n.Key {
+		case "_measurement":
+			v = string(p.Name())
+		case "_field":
+			v = field
+		default:
+			v = string(p.Tags().Get([]byte(n.Key)))
+		}
+		return (v == n.Value) == (n.Operator == influxdb.Equal)
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package cdc
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const prefixSubscribe = "/api/v2/subscribe"
+
+// Handler streams the writes to a bucket to its subscribers.
+type Handler struct {
+	chi.Router
+
+	api *kithttp.API
+	log *zap.Logger
+
+	hub           *Hub
+	bucketService influxdb.BucketService
+}
+
+// NewHandler returns a new instance of Handler.
+func NewHandler(log *zap.Logger, hub *Hub, bucketService influxdb.BucketService) *Handler {
+	h := &Handler{
+		api:           kithttp.NewAPI(kithttp.WithLog(log)),
+		log:           log,
+		hub:           hub,
+		bucketService: bucketService,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+	r.Get("/", h.handleSubscribe)
+	h.Router = r
+	return h
+}
+
+// Prefix returns the mounting prefix for the handler.
+func (h *Handler) Prefix() string {
+	return prefixSubscribe
+}
+
+// handleSubscribe subscribes to the writes to the bucket bucketID of the points
+// matching the optional predicate, and streams their line protocol until the
+// request ends or the subscriber falls too far behind.
+func (h *Handler) handleSubscribe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	q := r.URL.Query()
+	bucketID, err := platform.IDFromString(q.Get("bucketID"))
+	if err != nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "bucketID is required",
+			Err:  err,
+		})
+		return
+	}
+	b, err := h.bucketService.FindBucketByID(ctx, *bucketID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if _, _, err := authorizer.AuthorizeReadBucket(ctx, b.Type, b.ID, b.OrgID); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	sub, err := h.hub.Subscribe(b.ID, q.Get("predicate"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	defer sub.Close()
+
+	// The stream outlives the write timeout of the server.
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		h.log.Debug("Subscription stream cannot be flushed", zap.Error(err))
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case write, ok := <-sub.Writes():
+			if !ok {
+				h.log.Debug("Subscription ended", zap.Stringer("bucket_id", b.ID), zap.Error(sub.Err()))
+				return
+			}
+			if _, err := w.Write(write); err != nil {
+				return
+			}
+			_ = rc.Flush()
+		}
+	}
+}
@@ -0,0 +1,255 @@
+// Package cdc streams the writes to buckets to the clients subscribed to them,
+// so that the data can be processed downstream as it is written, rather than
+// by polling queries.
+package cdc
+
+import (
+	"context"
+	"sync"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// Config configures the subscriptions to the writes to buckets.
+type Config struct {
+	// Enabled enables the subscriptions.
+	Enabled bool
+	// Buffer is the number of writes buffered for a subscriber. A subscriber
+	// which falls further behind is disconnected.
+	Buffer int
+	// MaxSubscriptions is the maximum number of subscriptions of the server, 0
+	// being unlimited.
+	MaxSubscriptions int
+}
+
+// ErrSlowSubscriber ends the subscriptions whose subscriber did not keep up
+// with the writes.
+var ErrSlowSubscriber = &errors.Error{
+	Code: errors.ETooManyRequests,
+	Msg:  "the subscriber fell too far behind the writes and was disconnected",
+}
+
+// PointsWriter writes points to storage.
+type PointsWriter interface {
+	WritePoints(ctx context.Context, orgID platform.ID, bucketID platform.ID, points []models.Point) error
+}
+
+// Hub publishes the writes of the points writer it wraps to the subscriptions
+// to their bucket. A write is published once it is written, like the writes
+// queued by the replications of the bucket, as the line protocol of its points
+// matching the predicate of each subscription.
+//
+// Subscriptions are not durable: a subscriber only receives the writes made
+// while it is subscribed, and is disconnected when it falls more than the
+// buffer behind, rather than slowing down the writes.
+type Hub struct {
+	log    *zap.Logger
+	buffer int
+	max    int
+
+	mu   sync.RWMutex
+	subs map[platform.ID]map[*Subscription]struct{}
+	n    int
+
+	subscriptions prometheus.Gauge
+	published     prometheus.Counter
+	dropped       prometheus.Counter
+}
+
+// NewHub returns a Hub of subscriptions configured by cfg.
+func NewHub(log *zap.Logger, cfg Config) *Hub {
+	buffer := cfg.Buffer
+	if buffer <= 0 {
+		buffer = 1
+	}
+	return &Hub{
+		log:    log,
+		buffer: buffer,
+		max:    cfg.MaxSubscriptions,
+		subs:   make(map[platform.ID]map[*Subscription]struct{}),
+		subscriptions: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "cdc",
+			Name:      "subscriptions",
+			Help:      "Number of subscriptions to the writes to buckets",
+		}),
+		published: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "cdc",
+			Name:      "published_points_total",
+			Help:      "Number of points published to subscriptions",
+		}),
+		dropped: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "cdc",
+			Name:      "slow_subscribers_total",
+			Help:      "Number of subscriptions ended because their subscriber fell too far behind the writes",
+		}),
+	}
+}
+
+// PrometheusCollectors returns the metrics of the subscriptions.
+func (h *Hub) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{h.subscriptions, h.published, h.dropped}
+}
+
+// Subscription is a subscription to the writes to a bucket.
+type Subscription struct {
+	hub      *Hub
+	bucketID platform.ID
+	filter   *filter
+
+	writes chan []byte
+	once   sync.Once
+	err    error
+}
+
+// Subscribe subscribes to the writes to the bucket bucketID of the points
+// matching the predicate pred, in the syntax of delete predicates. The
+// subscription must be closed once done with.
+func (h *Hub) Subscribe(bucketID platform.ID, pred string) (*Subscription, error) {
+	f, err := newFilter(pred)
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.max > 0 && h.n >= h.max {
+		return nil, &errors.Error{
+			Code: errors.ETooManyRequests,
+			Msg:  "the server has too many subscriptions",
+		}
+	}
+	s := &Subscription{
+		hub:      h,
+		bucketID: bucketID,
+		filter:   f,
+		writes:   make(chan []byte, h.buffer),
+	}
+	subs, ok := h.subs[bucketID]
+	if !ok {
+		subs = make(map[*Subscription]struct{})
+		h.subs[bucketID] = subs
+	}
+	subs[s] = struct{}{}
+	h.n++
+	h.subscriptions.Inc()
+	return s, nil
+}
+
+// Writes returns the line protocol of the writes the subscription received. It
+// is closed when the subscription ends.
+func (s *Subscription) Writes() <-chan []byte {
+	return s.writes
+}
+
+// Err returns why the subscription ended, once Writes is closed: nil when it
+// was closed, or ErrSlowSubscriber.
+func (s *Subscription) Err() error {
+	return s.err
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.end(nil)
+}
+
+func (s *Subscription) end(err error) {
+	s.once.Do(func() {
+		h := s.hub
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		subs := h.subs[s.bucketID]
+		delete(subs, s)
+		if len(subs) == 0 {
+			delete(h.subs, s.bucketID)
+		}
+		h.n--
+		h.subscriptions.Dec()
+
+		// The writes are only published with the read lock held, so none is
+		// being sent once removed.
+		s.err = err
+		close(s.writes)
+	})
+}
+
+// PointsWriter returns pw publishing its writes to the subscriptions.
+func (h *Hub) PointsWriter(pw PointsWriter) PointsWriter {
+	return &pointsWriter{hub: h, next: pw}
+}
+
+type pointsWriter struct {
+	hub  *Hub
+	next PointsWriter
+}
+
+func (w *pointsWriter) WritePoints(ctx context.Context, orgID platform.ID, bucketID platform.ID, points []models.Point) error {
+	if err := w.next.WritePoints(ctx, orgID, bucketID, points); err != nil {
+		return err
+	}
+	w.hub.publish(bucketID, points)
+	return nil
+}
+
+func (h *Hub) publish(bucketID platform.ID, points []models.Point) {
+	var slow []*Subscription
+
+	h.mu.RLock()
+	// The points may be reused once written, so each subscription is sent a
+	// copy of them as line protocol. The copy of the points is shared by the
+	// subscriptions without predicate.
+	var (
+		all  []byte
+		allN int
+	)
+	for s := range h.subs[bucketID] {
+		var (
+			write []byte
+			n     int
+		)
+		if s.filter == nil {
+			if all == nil {
+				all, allN = appendPoints(nil, points, nil)
+			}
+			write, n = all, allN
+		} else {
+			write, n = appendPoints(nil, points, s.filter)
+		}
+		if n == 0 {
+			continue
+		}
+		select {
+		case s.writes <- write:
+			h.published.Add(float64(n))
+		default:
+			slow = append(slow, s)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, s := range slow {
+		h.dropped.Inc()
+		h.log.Info("Disconnecting a slow subscriber", zap.Stringer("bucket_id", bucketID))
+		s.end(ErrSlowSubscriber)
+	}
+}
+
+// appendPoints appends the line protocol of the points matching f to buf, and
+// returns the number of points appended.
+func appendPoints(buf []byte, points []models.Point, f *filter) ([]byte, int) {
+	n := 0
+	for _, p := range points {
+		m, err := f.match(p)
+		if err != nil || m == nil {
+			continue
+		}
+		buf = m.AppendString(buf)
+		buf = append(buf, '\n')
+		n++
+	}
+	return buf, n
+}
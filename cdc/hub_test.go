@@ -0,0 +1,94 @@
+package cdc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/cdc"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type nopWriter struct{}
+
+func (nopWriter) WritePoints(ctx context.Context, orgID platform.ID, bucketID platform.ID, points []models.Point) error {
+	return nil
+}
+
+func write(t *testing.T, pw cdc.PointsWriter, bucketID platform.ID, lp string) {
+	t.Helper()
+	points, err := models.ParsePointsString(lp)
+	require.NoError(t, err)
+	require.NoError(t, pw.WritePoints(context.Background(), 1, bucketID, points))
+}
+
+func TestHub(t *testing.T) {
+	h := cdc.NewHub(zaptest.NewLogger(t), cdc.Config{Buffer: 10})
+	pw := h.PointsWriter(nopWriter{})
+
+	all, err := h.Subscribe(2, "")
+	require.NoError(t, err)
+	defer all.Close()
+	cpu, err := h.Subscribe(2, `_measurement="cpu" AND host!="b"`)
+	require.NoError(t, err)
+	defer cpu.Close()
+	idle, err := h.Subscribe(2, `_field="idle"`)
+	require.NoError(t, err)
+	defer idle.Close()
+
+	write(t, pw, 2, "cpu,host=a idle=1,user=2 1\ncpu,host=b idle=3 2\nmem free=4 3")
+	// The writes to other buckets are not published.
+	write(t, pw, 3, "cpu,host=a idle=5 4")
+
+	require.Equal(t, "cpu,host=a idle=1,user=2 1\ncpu,host=b idle=3 2\nmem free=4 3\n", string(<-all.Writes()))
+	require.Equal(t, "cpu,host=a idle=1,user=2 1\n", string(<-cpu.Writes()))
+	require.Equal(t, "cpu,host=a idle=1 1\ncpu,host=b idle=3 2\n", string(<-idle.Writes()))
+	require.Empty(t, all.Writes())
+
+	// The writes not matching a predicate are not published to it.
+	write(t, pw, 2, "mem free=5 5")
+	require.Len(t, all.Writes(), 1)
+	require.Empty(t, cpu.Writes())
+	require.Empty(t, idle.Writes())
+
+	all.Close()
+	_, ok := <-all.Writes()
+	require.False(t, ok)
+	require.NoError(t, all.Err())
+}
+
+func TestHub_SlowSubscriber(t *testing.T) {
+	h := cdc.NewHub(zaptest.NewLogger(t), cdc.Config{Buffer: 1})
+	pw := h.PointsWriter(nopWriter{})
+
+	s, err := h.Subscribe(2, "")
+	require.NoError(t, err)
+	write(t, pw, 2, "cpu idle=1 1")
+	write(t, pw, 2, "cpu idle=2 2")
+
+	require.Equal(t, "cpu idle=1 1\n", string(<-s.Writes()))
+	_, ok := <-s.Writes()
+	require.False(t, ok)
+	require.Equal(t, cdc.ErrSlowSubscriber, s.Err())
+	s.Close()
+}
+
+func TestHub_Subscribe(t *testing.T) {
+	h := cdc.NewHub(zaptest.NewLogger(t), cdc.Config{Buffer: 1, MaxSubscriptions: 1})
+
+	_, err := h.Subscribe(2, `host=~/a/`)
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+
+	s, err := h.Subscribe(2, "")
+	require.NoError(t, err)
+	_, err = h.Subscribe(3, "")
+	require.Equal(t, errors.ETooManyRequests, errors.ErrorCode(err))
+
+	s.Close()
+	s, err = h.Subscribe(3, "")
+	require.NoError(t, err)
+	s.Close()
+}
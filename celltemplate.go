@@ -0,0 +1,277 @@
+package influxdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// ErrCellTemplateNotFound is the error msg for a missing cell template.
+const ErrCellTemplateNotFound = "cell template not found"
+
+// ops for cell template service.
+const (
+	OpFindCellTemplateByID  = "FindCellTemplateByID"
+	OpFindCellTemplates     = "FindCellTemplates"
+	OpCreateCellTemplate    = "CreateCellTemplate"
+	OpUpdateCellTemplate    = "UpdateCellTemplate"
+	OpDeleteCellTemplate    = "DeleteCellTemplate"
+	OpAddCellFromTemplate   = "AddCellFromTemplate"
+	OpFindCellTemplateLinks = "FindCellTemplateLinks"
+)
+
+// CellTemplateService manages a library of reusable cells. Dashboard cells added
+// from a template stay linked to it, so an update to the template can be
+// propagated to every cell using it.
+type CellTemplateService interface {
+	// FindCellTemplateByID returns a single cell template by ID.
+	FindCellTemplateByID(ctx context.Context, id platform.ID) (*CellTemplate, error)
+
+	// FindCellTemplates returns the cell templates matching filter and the total count of matching templates.
+	FindCellTemplates(ctx context.Context, filter CellTemplateFilter, opts FindOptions) ([]*CellTemplate, int, error)
+
+	// CreateCellTemplate creates a new cell template and sets t.ID with the new identifier.
+	CreateCellTemplate(ctx context.Context, t *CellTemplate) error
+
+	// UpdateCellTemplate updates a single cell template with changeset. If upd.Propagate
+	// is set, the views of every cell linked to the template are rendered again.
+	UpdateCellTemplate(ctx context.Context, id platform.ID, upd CellTemplateUpdate) (*CellTemplate, error)
+
+	// DeleteCellTemplate removes a cell template. Cells linked to it keep their views.
+	DeleteCellTemplate(ctx context.Context, id platform.ID) error
+
+	// AddCellFromTemplate adds a cell to a dashboard with the view rendered from
+	// the template and params, and links the cell to the template.
+	AddCellFromTemplate(ctx context.Context, dashboardID, templateID platform.ID, c *Cell, params map[string]string) error
+
+	// FindCellTemplateLinks returns the cells linked to a cell template.
+	FindCellTemplateLinks(ctx context.Context, templateID platform.ID) ([]*CellTemplateLink, error)
+}
+
+// CellTemplate is a named, parameterized cell. References to a parameter in the
+// text of its queries are written as ${name}.
+type CellTemplate struct {
+	ID             platform.ID             `json:"id,omitempty"`
+	OrganizationID platform.ID             `json:"orgID"`
+	Name           string                  `json:"name"`
+	Description    string                  `json:"description"`
+	Parameters     []CellTemplateParameter `json:"parameters"`
+	Properties     ViewProperties          `json:"-"`
+	CreatedAt      time.Time               `json:"createdAt"`
+	UpdatedAt      time.Time               `json:"updatedAt"`
+}
+
+// CellTemplateParameter is a parameter of a cell template. A parameter without a
+// default must be given a value whenever the template is rendered.
+type CellTemplateParameter struct {
+	Name    string  `json:"name"`
+	Default *string `json:"default,omitempty"`
+}
+
+// CellTemplateLink links a dashboard cell to the template it was added from, along
+// with the params its view was rendered with.
+type CellTemplateLink struct {
+	TemplateID  platform.ID       `json:"templateID"`
+	DashboardID platform.ID       `json:"dashboardID"`
+	CellID      platform.ID       `json:"cellID"`
+	Params      map[string]string `json:"params,omitempty"`
+}
+
+// CellTemplateFilter represents a set of filters that restrict the returned cell templates.
+type CellTemplateFilter struct {
+	OrganizationID *platform.ID
+}
+
+var cellTemplateParamPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// MarshalJSON encodes a cell template to JSON bytes.
+func (t CellTemplate) MarshalJSON() ([]byte, error) {
+	props, err := MarshalViewPropertiesJSON(t.Properties)
+	if err != nil {
+		return nil, err
+	}
+
+	type cellTemplate CellTemplate
+	return json.Marshal(struct {
+		cellTemplate
+		Properties json.RawMessage `json:"properties"`
+	}{
+		cellTemplate: cellTemplate(t),
+		Properties:   props,
+	})
+}
+
+// UnmarshalJSON decodes JSON bytes into a cell template.
+func (t *CellTemplate) UnmarshalJSON(b []byte) error {
+	type cellTemplate CellTemplate
+	var ct cellTemplate
+	if err := json.Unmarshal(b, &ct); err != nil {
+		return err
+	}
+
+	props, err := UnmarshalViewPropertiesJSON(b)
+	if err != nil {
+		return err
+	}
+	*t = CellTemplate(ct)
+	t.Properties = props
+	return nil
+}
+
+// Valid returns an error if the cell template is invalid.
+func (t *CellTemplate) Valid() error {
+	if t.Name == "" {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "cell template name is required",
+		}
+	}
+	if t.Properties == nil {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "cell template properties are required",
+		}
+	}
+	if _, ok := t.Properties.(EmptyViewProperties); ok {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "cell template properties are required",
+		}
+	}
+
+	seen := make(map[string]bool, len(t.Parameters))
+	for _, p := range t.Parameters {
+		if !cellTemplateParamPattern.MatchString(p.Name) {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("invalid cell template parameter name %q", p.Name),
+			}
+		}
+		if seen[p.Name] {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("duplicate cell template parameter %q", p.Name),
+			}
+		}
+		seen[p.Name] = true
+	}
+	return nil
+}
+
+// Render returns the view of a cell using the template, with every parameter
+// referenced in its queries replaced by its value in params, or its default.
+func (t *CellTemplate) Render(params map[string]string) (*View, error) {
+	values := make(map[string]string, len(t.Parameters))
+	for _, p := range t.Parameters {
+		if v, ok := params[p.Name]; ok {
+			values[p.Name] = v
+			continue
+		}
+		if p.Default == nil {
+			return nil, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("missing value for cell template parameter %q", p.Name),
+			}
+		}
+		values[p.Name] = *p.Default
+	}
+	for name := range params {
+		if _, ok := values[name]; !ok {
+			return nil, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("unknown cell template parameter %q", name),
+			}
+		}
+	}
+
+	raw, err := MarshalViewPropertiesJSON(t.Properties)
+	if err != nil {
+		return nil, err
+	}
+	var props map[string]interface{}
+	if err := json.Unmarshal(raw, &props); err != nil {
+		return nil, err
+	}
+
+	replacements := make([]string, 0, 2*len(values))
+	for name, v := range values {
+		replacements = append(replacements, "${"+name+"}", v)
+	}
+	replacer := strings.NewReplacer(replacements...)
+	if queries, ok := props["queries"].([]interface{}); ok {
+		for _, q := range queries {
+			query, ok := q.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if text, ok := query["text"].(string); ok {
+				query["text"] = replacer.Replace(text)
+			}
+		}
+	}
+
+	b, err := json.Marshal(map[string]interface{}{"properties": props})
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := UnmarshalViewPropertiesJSON(b)
+	if err != nil {
+		return nil, err
+	}
+	return &View{
+		ViewContents: ViewContents{Name: t.Name},
+		Properties:   rendered,
+	}, nil
+}
+
+// CellTemplateUpdate is the patch structure for a cell template.
+type CellTemplateUpdate struct {
+	Name        *string                  `json:"name"`
+	Description *string                  `json:"description"`
+	Parameters  *[]CellTemplateParameter `json:"parameters"`
+	Properties  ViewProperties           `json:"-"`
+	// Propagate renders the views of the cells linked to the template again.
+	Propagate bool `json:"propagate"`
+}
+
+// UnmarshalJSON decodes JSON bytes into a cell template update.
+func (u *CellTemplateUpdate) UnmarshalJSON(b []byte) error {
+	type cellTemplateUpdate CellTemplateUpdate
+	var upd cellTemplateUpdate
+	if err := json.Unmarshal(b, &upd); err != nil {
+		return err
+	}
+
+	props, err := UnmarshalViewPropertiesJSON(b)
+	if err != nil {
+		return err
+	}
+	*u = CellTemplateUpdate(upd)
+	u.Properties = props
+	return nil
+}
+
+// Apply applies an update to a cell template.
+func (u CellTemplateUpdate) Apply(t *CellTemplate) error {
+	if u.Name != nil {
+		t.Name = *u.Name
+	}
+	if u.Description != nil {
+		t.Description = *u.Description
+	}
+	if u.Parameters != nil {
+		t.Parameters = *u.Parameters
+	}
+	if u.Properties != nil {
+		if _, ok := u.Properties.(EmptyViewProperties); !ok {
+			t.Properties = u.Properties
+		}
+	}
+	return t.Valid()
+}
@@ -0,0 +1,65 @@
+package influxdb_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCellTemplate_Render(t *testing.T) {
+	host := "server01"
+	tmpl := &influxdb.CellTemplate{
+		Name: "cpu",
+		Parameters: []influxdb.CellTemplateParameter{
+			{Name: "bucket"},
+			{Name: "host", Default: &host},
+		},
+		Properties: influxdb.SingleStatViewProperties{
+			Type: influxdb.ViewPropertyTypeSingleStat,
+			Queries: []influxdb.DashboardQuery{{
+				Text: `from(bucket: "${bucket}") |> filter(fn: (r) => r.host == "${host}")`,
+			}},
+			Suffix: "${host}",
+		},
+	}
+
+	view, err := tmpl.Render(map[string]string{"bucket": "telegraf"})
+	require.NoError(t, err)
+	require.Equal(t, "cpu", view.Name)
+	props := view.Properties.(influxdb.SingleStatViewProperties)
+	require.Equal(t, `from(bucket: "telegraf") |> filter(fn: (r) => r.host == "server01")`, props.Queries[0].Text)
+	// Only query text is substituted.
+	require.Equal(t, "${host}", props.Suffix)
+
+	_, err = tmpl.Render(nil)
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+
+	_, err = tmpl.Render(map[string]string{"bucket": "telegraf", "region": "west"})
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+}
+
+func TestCellTemplate_JSON(t *testing.T) {
+	tmpl := influxdb.CellTemplate{
+		ID:             1,
+		OrganizationID: 2,
+		Name:           "cpu",
+		Properties:     influxdb.MarkdownViewProperties{Type: influxdb.ViewPropertyTypeMarkdown, Note: "# cpu"},
+	}
+	b, err := json.Marshal(tmpl)
+	require.NoError(t, err)
+
+	var got influxdb.CellTemplate
+	require.NoError(t, json.Unmarshal(b, &got))
+	require.Equal(t, tmpl, got)
+
+	require.Error(t, (&influxdb.CellTemplate{Name: "cpu"}).Valid())
+	require.Error(t, (&influxdb.CellTemplate{
+		Name:       "cpu",
+		Properties: tmpl.Properties,
+		Parameters: []influxdb.CellTemplateParameter{{Name: "a"}, {Name: "a"}},
+	}).Valid())
+	require.NoError(t, got.Valid())
+}
@@ -0,0 +1,276 @@
+package influxdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// ErrCheckTemplateNotFound is the error msg for a missing check template.
+const ErrCheckTemplateNotFound = "check template not found"
+
+// ops for check template service.
+const (
+	OpFindCheckTemplateByID       = "FindCheckTemplateByID"
+	OpFindCheckTemplates          = "FindCheckTemplates"
+	OpCreateCheckTemplate         = "CreateCheckTemplate"
+	OpUpdateCheckTemplate         = "UpdateCheckTemplate"
+	OpDeleteCheckTemplate         = "DeleteCheckTemplate"
+	OpInstantiateCheckTemplate    = "InstantiateCheckTemplate"
+	OpFindCheckTemplateInstances  = "FindCheckTemplateInstances"
+	OpDeleteCheckTemplateInstance = "DeleteCheckTemplateInstance"
+)
+
+// CheckTemplateService manages parameterized checks. The checks instantiated
+// from a template stay linked to it, and are updated whenever it is.
+type CheckTemplateService interface {
+	// FindCheckTemplateByID returns a single check template by ID.
+	FindCheckTemplateByID(ctx context.Context, id platform.ID) (*CheckTemplate, error)
+
+	// FindCheckTemplates returns the check templates matching filter and the total count of matching templates.
+	FindCheckTemplates(ctx context.Context, filter CheckTemplateFilter, opts FindOptions) ([]*CheckTemplate, int, error)
+
+	// CreateCheckTemplate creates a new check template and sets t.ID with the new identifier.
+	CreateCheckTemplate(ctx context.Context, t *CheckTemplate) error
+
+	// UpdateCheckTemplate updates a single check template with changeset, and
+	// every check instantiated from it with the params it was instantiated with.
+	UpdateCheckTemplate(ctx context.Context, id platform.ID, upd CheckTemplateUpdate) (*CheckTemplate, error)
+
+	// DeleteCheckTemplate removes a check template. Checks instantiated from it
+	// are kept, and no longer updated with it.
+	DeleteCheckTemplate(ctx context.Context, id platform.ID) error
+
+	// InstantiateCheckTemplate creates a check from the template for each of
+	// the params of targets, such as one per host, owned by userID.
+	InstantiateCheckTemplate(ctx context.Context, id platform.ID, targets []map[string]string, userID platform.ID) ([]*CheckTemplateInstance, error)
+
+	// FindCheckTemplateInstances returns the checks instantiated from a check template.
+	FindCheckTemplateInstances(ctx context.Context, templateID platform.ID) ([]*CheckTemplateInstance, error)
+
+	// DeleteCheckTemplateInstance deletes a check instantiated from a check template.
+	DeleteCheckTemplateInstance(ctx context.Context, templateID, checkID platform.ID) error
+}
+
+// CheckTemplate is a named, parameterized check. Check is the definition of the
+// checks instantiated from the template, as sent to the checks API. References
+// to a parameter in its strings, such as its name, query, tags and message
+// template, are written as ${name}. A string which is only a reference to a
+// number parameter is replaced by the number, so that the thresholds of a
+// check can be parameterized as well.
+type CheckTemplate struct {
+	ID             platform.ID              `json:"id,omitempty"`
+	OrganizationID platform.ID              `json:"orgID"`
+	Name           string                   `json:"name"`
+	Description    string                   `json:"description"`
+	Parameters     []CheckTemplateParameter `json:"parameters"`
+	Check          json.RawMessage          `json:"check"`
+	CreatedAt      time.Time                `json:"createdAt"`
+	UpdatedAt      time.Time                `json:"updatedAt"`
+}
+
+// types of check template parameters.
+const (
+	CheckTemplateParameterString = "string"
+	CheckTemplateParameterNumber = "number"
+)
+
+// CheckTemplateParameter is a parameter of a check template. A parameter without
+// a default must be given a value whenever the template is instantiated. The
+// type of a parameter is a string unless it is set.
+type CheckTemplateParameter struct {
+	Name    string  `json:"name"`
+	Type    string  `json:"type,omitempty"`
+	Default *string `json:"default,omitempty"`
+}
+
+func (p CheckTemplateParameter) validValue(v string) error {
+	if p.Type != CheckTemplateParameterNumber {
+		return nil
+	}
+	if _, err := strconv.ParseFloat(v, 64); err != nil {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("check template parameter %q must be a number", p.Name),
+		}
+	}
+	return nil
+}
+
+// CheckTemplateInstance links a check to the template it was instantiated from,
+// along with the params it was rendered with.
+type CheckTemplateInstance struct {
+	TemplateID platform.ID       `json:"templateID"`
+	CheckID    platform.ID       `json:"checkID"`
+	Params     map[string]string `json:"params,omitempty"`
+}
+
+// CheckTemplateFilter represents a set of filters that restrict the returned check templates.
+type CheckTemplateFilter struct {
+	OrganizationID *platform.ID
+}
+
+// Valid returns an error if the check template is invalid.
+func (t *CheckTemplate) Valid() error {
+	if t.Name == "" {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "check template name is required",
+		}
+	}
+	var check map[string]interface{}
+	if err := json.Unmarshal(t.Check, &check); err != nil || check == nil {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "check template check must be a check definition",
+		}
+	}
+
+	seen := make(map[string]bool, len(t.Parameters))
+	for _, p := range t.Parameters {
+		if !cellTemplateParamPattern.MatchString(p.Name) {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("invalid check template parameter name %q", p.Name),
+			}
+		}
+		if seen[p.Name] {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("duplicate check template parameter %q", p.Name),
+			}
+		}
+		seen[p.Name] = true
+
+		switch p.Type {
+		case "", CheckTemplateParameterString, CheckTemplateParameterNumber:
+		default:
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("invalid type %q of check template parameter %q", p.Type, p.Name),
+			}
+		}
+		if p.Default != nil {
+			if err := p.validValue(*p.Default); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Render returns the definition of a check instantiated from the template, with
+// every parameter it references replaced by its value in params, or its default.
+func (t *CheckTemplate) Render(params map[string]string) (json.RawMessage, error) {
+	values := make(map[string]string, len(t.Parameters))
+	numbers := make(map[string]bool)
+	for _, p := range t.Parameters {
+		numbers[p.Name] = p.Type == CheckTemplateParameterNumber
+		if v, ok := params[p.Name]; ok {
+			if err := p.validValue(v); err != nil {
+				return nil, err
+			}
+			values[p.Name] = v
+			continue
+		}
+		if p.Default == nil {
+			return nil, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("missing value for check template parameter %q", p.Name),
+			}
+		}
+		values[p.Name] = *p.Default
+	}
+	for name := range params {
+		if _, ok := values[name]; !ok {
+			return nil, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("unknown check template parameter %q", name),
+			}
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(t.Check))
+	dec.UseNumber()
+	var check interface{}
+	if err := dec.Decode(&check); err != nil {
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "check template check must be a check definition",
+			Err:  err,
+		}
+	}
+
+	replacements := make([]string, 0, 2*len(values))
+	for name, v := range values {
+		replacements = append(replacements, "${"+name+"}", v)
+	}
+	r := checkTemplateRenderer{
+		values:   values,
+		numbers:  numbers,
+		replacer: strings.NewReplacer(replacements...),
+	}
+	return json.Marshal(r.render(check))
+}
+
+type checkTemplateRenderer struct {
+	values   map[string]string
+	numbers  map[string]bool
+	replacer *strings.Replacer
+}
+
+func (r checkTemplateRenderer) render(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = r.render(e)
+		}
+		return v
+	case []interface{}:
+		for i, e := range v {
+			v[i] = r.render(e)
+		}
+		return v
+	case string:
+		if strings.HasPrefix(v, "${") && strings.HasSuffix(v, "}") {
+			if name := v[2 : len(v)-1]; r.numbers[name] {
+				return json.Number(r.values[name])
+			}
+		}
+		return r.replacer.Replace(v)
+	default:
+		return v
+	}
+}
+
+// CheckTemplateUpdate is the patch structure for a check template.
+type CheckTemplateUpdate struct {
+	Name        *string                   `json:"name"`
+	Description *string                   `json:"description"`
+	Parameters  *[]CheckTemplateParameter `json:"parameters"`
+	Check       json.RawMessage           `json:"check,omitempty"`
+}
+
+// Apply applies an update to a check template.
+func (u CheckTemplateUpdate) Apply(t *CheckTemplate) error {
+	if u.Name != nil {
+		t.Name = *u.Name
+	}
+	if u.Description != nil {
+		t.Description = *u.Description
+	}
+	if u.Parameters != nil {
+		t.Parameters = *u.Parameters
+	}
+	if len(u.Check) > 0 {
+		t.Check = u.Check
+	}
+	return t.Valid()
+}
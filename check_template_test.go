@@ -0,0 +1,91 @@
+package influxdb_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckTemplate_Render(t *testing.T) {
+	crit := "90"
+	tmpl := &influxdb.CheckTemplate{
+		Name: "cpu",
+		Parameters: []influxdb.CheckTemplateParameter{
+			{Name: "host"},
+			{Name: "crit", Type: influxdb.CheckTemplateParameterNumber, Default: &crit},
+		},
+		Check: json.RawMessage(`{
+			"type": "threshold",
+			"name": "cpu ${host}",
+			"every": "1m",
+			"query": {"text": "from(bucket: \"telegraf\") |> filter(fn: (r) => r.host == \"${host}\")"},
+			"tags": [{"key": "host", "value": "${host}"}],
+			"statusMessageTemplate": "${host} is ${ r._level }",
+			"thresholds": [{"type": "greater", "level": "CRIT", "value": "${crit}"}, {"type": "greater", "level": "WARN", "value": 80.5}]
+		}`),
+	}
+
+	b, err := tmpl.Render(map[string]string{"host": "server01"})
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"type": "threshold",
+		"name": "cpu server01",
+		"every": "1m",
+		"query": {"text": "from(bucket: \"telegraf\") |> filter(fn: (r) => r.host == \"server01\")"},
+		"tags": [{"key": "host", "value": "server01"}],
+		"statusMessageTemplate": "server01 is ${ r._level }",
+		"thresholds": [{"type": "greater", "level": "CRIT", "value": 90}, {"type": "greater", "level": "WARN", "value": 80.5}]
+	}`, string(b))
+
+	b, err = tmpl.Render(map[string]string{"host": "42", "crit": "95.5"})
+	require.NoError(t, err)
+	var rendered struct {
+		Name       string `json:"name"`
+		Tags       []influxdb.Tag
+		Thresholds []struct {
+			Value float64 `json:"value"`
+		} `json:"thresholds"`
+	}
+	require.NoError(t, json.Unmarshal(b, &rendered))
+	require.Equal(t, "cpu 42", rendered.Name)
+	require.Equal(t, "42", rendered.Tags[0].Value, "only number parameters are replaced by numbers")
+	require.Equal(t, 95.5, rendered.Thresholds[0].Value)
+
+	_, err = tmpl.Render(nil)
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+
+	_, err = tmpl.Render(map[string]string{"host": "server01", "region": "west"})
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+
+	_, err = tmpl.Render(map[string]string{"host": "server01", "crit": "high"})
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+}
+
+func TestCheckTemplate_Valid(t *testing.T) {
+	require.Error(t, (&influxdb.CheckTemplate{Name: "cpu"}).Valid())
+	require.Error(t, (&influxdb.CheckTemplate{Name: "cpu", Check: json.RawMessage(`[]`)}).Valid())
+	require.Error(t, (&influxdb.CheckTemplate{
+		Name:       "cpu",
+		Check:      json.RawMessage(`{"type": "deadman"}`),
+		Parameters: []influxdb.CheckTemplateParameter{{Name: "a"}, {Name: "a"}},
+	}).Valid())
+	require.Error(t, (&influxdb.CheckTemplate{
+		Name:       "cpu",
+		Check:      json.RawMessage(`{"type": "deadman"}`),
+		Parameters: []influxdb.CheckTemplateParameter{{Name: "a-b"}},
+	}).Valid())
+	high := "high"
+	require.Error(t, (&influxdb.CheckTemplate{
+		Name:       "cpu",
+		Check:      json.RawMessage(`{"type": "deadman"}`),
+		Parameters: []influxdb.CheckTemplateParameter{{Name: "crit", Type: influxdb.CheckTemplateParameterNumber, Default: &high}},
+	}).Valid())
+	require.NoError(t, (&influxdb.CheckTemplate{
+		Name:       "cpu",
+		Check:      json.RawMessage(`{"type": "deadman"}`),
+		Parameters: []influxdb.CheckTemplateParameter{{Name: "host"}},
+	}).Valid())
+}
@@ -0,0 +1,543 @@
+package checks
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/notification/check"
+	"github.com/influxdata/influxdb/v2/snowflake"
+	"github.com/influxdata/influxdb/v2/task/taskmodel"
+)
+
+var (
+	checkTemplateBucket         = []byte("checktemplatesv1")
+	orgCheckTemplateIndex       = []byte("orgschecktemplatesv1")
+	checkTemplateInstanceBucket = []byte("checktemplateinstancesv1")
+)
+
+var _ influxdb.CheckTemplateService = (*TemplateService)(nil)
+
+// TemplateService is a kv backed check template service. The checks of the
+// templates are managed with a check service, so that their tasks are
+// scheduled as the tasks of any other check are.
+type TemplateService struct {
+	kv     kv.Store
+	checks influxdb.CheckService
+	tasks  taskmodel.TaskService
+
+	IDGenerator   platform.IDGenerator
+	TimeGenerator influxdb.TimeGenerator
+}
+
+// NewTemplateService constructs and configures a new check template service.
+// The tasks service is used to look up the status of the checks of the templates.
+func NewTemplateService(store kv.Store, checks influxdb.CheckService, tasks taskmodel.TaskService) *TemplateService {
+	return &TemplateService{
+		kv:            store,
+		checks:        checks,
+		tasks:         tasks,
+		IDGenerator:   snowflake.NewIDGenerator(),
+		TimeGenerator: influxdb.RealTimeGenerator{},
+	}
+}
+
+// FindCheckTemplateByID returns a single check template by ID.
+func (s *TemplateService) FindCheckTemplateByID(ctx context.Context, id platform.ID) (*influxdb.CheckTemplate, error) {
+	var t *influxdb.CheckTemplate
+	err := s.kv.View(ctx, func(tx kv.Tx) error {
+		ct, err := findCheckTemplateByID(tx, id)
+		if err != nil {
+			return err
+		}
+		t = ct
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+		}
+	}
+	return t, nil
+}
+
+// FindCheckTemplates returns the check templates matching filter, sorted by ID.
+func (s *TemplateService) FindCheckTemplates(ctx context.Context, filter influxdb.CheckTemplateFilter, opts influxdb.FindOptions) ([]*influxdb.CheckTemplate, int, error) {
+	var ts []*influxdb.CheckTemplate
+	err := s.kv.View(ctx, func(tx kv.Tx) error {
+		ids, err := checkTemplateIDs(tx, filter)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			t, err := findCheckTemplateByID(tx, id)
+			if err != nil {
+				return err
+			}
+			ts = append(ts, t)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, &errors.Error{
+			Err: err,
+		}
+	}
+
+	total := len(ts)
+	if opts.Descending {
+		for i, j := 0, len(ts)-1; i < j; i, j = i+1, j-1 {
+			ts[i], ts[j] = ts[j], ts[i]
+		}
+	}
+	if opts.Offset > 0 {
+		if opts.Offset >= len(ts) {
+			ts = nil
+		} else {
+			ts = ts[opts.Offset:]
+		}
+	}
+	if opts.Limit > 0 && opts.Limit < len(ts) {
+		ts = ts[:opts.Limit]
+	}
+	return ts, total, nil
+}
+
+// CreateCheckTemplate creates a new check template and sets t.ID with the new identifier.
+func (s *TemplateService) CreateCheckTemplate(ctx context.Context, t *influxdb.CheckTemplate) error {
+	if err := t.Valid(); err != nil {
+		return err
+	}
+	err := s.kv.Update(ctx, func(tx kv.Tx) error {
+		t.ID = s.IDGenerator.ID()
+		t.CreatedAt = s.TimeGenerator.Now()
+		t.UpdatedAt = t.CreatedAt
+		if err := putCheckTemplate(tx, t); err != nil {
+			return err
+		}
+		k, err := joinIDs(t.OrganizationID, t.ID)
+		if err != nil {
+			return err
+		}
+		idx, err := tx.Bucket(orgCheckTemplateIndex)
+		if err != nil {
+			return err
+		}
+		return idx.Put(k, nil)
+	})
+	if err != nil {
+		return &errors.Error{
+			Err: err,
+		}
+	}
+	return nil
+}
+
+// UpdateCheckTemplate updates a check template, then every check instantiated
+// from it is updated with the params it was instantiated with. The checks keep
+// their status. Instances of checks which no longer exist are removed.
+func (s *TemplateService) UpdateCheckTemplate(ctx context.Context, id platform.ID, upd influxdb.CheckTemplateUpdate) (*influxdb.CheckTemplate, error) {
+	var (
+		t         *influxdb.CheckTemplate
+		instances []*influxdb.CheckTemplateInstance
+	)
+	err := s.kv.Update(ctx, func(tx kv.Tx) error {
+		ct, err := findCheckTemplateByID(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := upd.Apply(ct); err != nil {
+			return err
+		}
+		is, err := findCheckTemplateInstances(tx, id)
+		if err != nil {
+			return err
+		}
+		// render every check first, so that the template isn't updated with
+		// params its checks can't be rendered with anymore.
+		for _, i := range is {
+			if _, _, err := renderCheck(ct, i.Params); err != nil {
+				return err
+			}
+		}
+		ct.UpdatedAt = s.TimeGenerator.Now()
+		if err := putCheckTemplate(tx, ct); err != nil {
+			return err
+		}
+		t, instances = ct, is
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+		}
+	}
+
+	for _, i := range instances {
+		if err := s.syncCheck(ctx, t, i); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// syncCheck updates the check of an instance with the template.
+func (s *TemplateService) syncCheck(ctx context.Context, t *influxdb.CheckTemplate, i *influxdb.CheckTemplateInstance) error {
+	current, err := s.checks.FindCheckByID(ctx, i.CheckID)
+	if errors.ErrorCode(err) == errors.ENotFound {
+		return s.kv.Update(ctx, func(tx kv.Tx) error {
+			return deleteCheckTemplateInstance(tx, i)
+		})
+	}
+	if err != nil {
+		return err
+	}
+	task, err := s.tasks.FindTaskByID(ctx, current.GetTaskID())
+	if err != nil {
+		return err
+	}
+
+	c, _, err := renderCheck(t, i.Params)
+	if err != nil {
+		return err
+	}
+	_, err = s.checks.UpdateCheck(ctx, i.CheckID, influxdb.CheckCreate{
+		Check:  c,
+		Status: influxdb.Status(task.Status),
+	})
+	return err
+}
+
+// DeleteCheckTemplate removes a check template and its instances. The checks
+// which were instantiated from it are left as they are.
+func (s *TemplateService) DeleteCheckTemplate(ctx context.Context, id platform.ID) error {
+	err := s.kv.Update(ctx, func(tx kv.Tx) error {
+		t, err := findCheckTemplateByID(tx, id)
+		if err != nil {
+			return err
+		}
+		instances, err := findCheckTemplateInstances(tx, id)
+		if err != nil {
+			return err
+		}
+		for _, i := range instances {
+			if err := deleteCheckTemplateInstance(tx, i); err != nil {
+				return err
+			}
+		}
+
+		k, err := joinIDs(t.OrganizationID, t.ID)
+		if err != nil {
+			return err
+		}
+		idx, err := tx.Bucket(orgCheckTemplateIndex)
+		if err != nil {
+			return err
+		}
+		if err := idx.Delete(k); err != nil {
+			return err
+		}
+
+		encodedID, err := id.Encode()
+		if err != nil {
+			return err
+		}
+		b, err := tx.Bucket(checkTemplateBucket)
+		if err != nil {
+			return err
+		}
+		return b.Delete(encodedID)
+	})
+	if err != nil {
+		return &errors.Error{
+			Err: err,
+		}
+	}
+	return nil
+}
+
+// InstantiateCheckTemplate creates a check from the template for each of the
+// params of targets. Every check is rendered before any is created; if the
+// creation of a check fails, the checks created before it are kept.
+func (s *TemplateService) InstantiateCheckTemplate(ctx context.Context, id platform.ID, targets []map[string]string, userID platform.ID) ([]*influxdb.CheckTemplateInstance, error) {
+	t, err := s.FindCheckTemplateByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	creates := make([]influxdb.CheckCreate, 0, len(targets))
+	for _, params := range targets {
+		c, status, err := renderCheck(t, params)
+		if err != nil {
+			return nil, err
+		}
+		c.SetOrgID(t.OrganizationID)
+		creates = append(creates, influxdb.CheckCreate{Check: c, Status: status})
+	}
+
+	instances := make([]*influxdb.CheckTemplateInstance, 0, len(creates))
+	for i, cc := range creates {
+		if err := s.checks.CreateCheck(ctx, cc, userID); err != nil {
+			return instances, err
+		}
+		instance := &influxdb.CheckTemplateInstance{
+			TemplateID: t.ID,
+			CheckID:    cc.GetID(),
+			Params:     targets[i],
+		}
+		if err := s.kv.Update(ctx, func(tx kv.Tx) error {
+			return putCheckTemplateInstance(tx, instance)
+		}); err != nil {
+			return instances, &errors.Error{
+				Err: err,
+			}
+		}
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+// FindCheckTemplateInstances returns the checks instantiated from a check template.
+func (s *TemplateService) FindCheckTemplateInstances(ctx context.Context, templateID platform.ID) ([]*influxdb.CheckTemplateInstance, error) {
+	var instances []*influxdb.CheckTemplateInstance
+	err := s.kv.View(ctx, func(tx kv.Tx) error {
+		if _, err := findCheckTemplateByID(tx, templateID); err != nil {
+			return err
+		}
+		is, err := findCheckTemplateInstances(tx, templateID)
+		if err != nil {
+			return err
+		}
+		instances = is
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+		}
+	}
+	return instances, nil
+}
+
+// DeleteCheckTemplateInstance deletes a check instantiated from a check template.
+func (s *TemplateService) DeleteCheckTemplateInstance(ctx context.Context, templateID, checkID platform.ID) error {
+	instance := &influxdb.CheckTemplateInstance{TemplateID: templateID, CheckID: checkID}
+	err := s.kv.View(ctx, func(tx kv.Tx) error {
+		k, err := joinIDs(templateID, checkID)
+		if err != nil {
+			return err
+		}
+		b, err := tx.Bucket(checkTemplateInstanceBucket)
+		if err != nil {
+			return err
+		}
+		if _, err := b.Get(k); kv.IsNotFound(err) {
+			return &errors.Error{
+				Code: errors.ENotFound,
+				Msg:  "check is not an instance of the check template",
+			}
+		} else if err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return &errors.Error{
+			Err: err,
+		}
+	}
+
+	if err := s.checks.DeleteCheck(ctx, checkID); err != nil && errors.ErrorCode(err) != errors.ENotFound {
+		return err
+	}
+	err = s.kv.Update(ctx, func(tx kv.Tx) error {
+		return deleteCheckTemplateInstance(tx, instance)
+	})
+	if err != nil {
+		return &errors.Error{
+			Err: err,
+		}
+	}
+	return nil
+}
+
+// renderCheck returns the check rendered from t with params, and the status
+// of its definition, which defaults to active.
+func renderCheck(t *influxdb.CheckTemplate, params map[string]string) (influxdb.Check, influxdb.Status, error) {
+	b, err := t.Render(params)
+	if err != nil {
+		return nil, "", err
+	}
+	c, err := check.UnmarshalJSON(b)
+	if err != nil {
+		return nil, "", &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "check template check must be a check definition",
+			Err:  err,
+		}
+	}
+
+	var def struct {
+		Status influxdb.Status `json:"status"`
+	}
+	if err := json.Unmarshal(b, &def); err != nil {
+		return nil, "", err
+	}
+	if def.Status == "" {
+		def.Status = influxdb.Active
+	}
+	return c, def.Status, nil
+}
+
+// joinIDs returns the concatenation of the encoded ids, which is used for index keys.
+func joinIDs(ids ...platform.ID) ([]byte, error) {
+	key := make([]byte, 0, len(ids)*platform.IDLength)
+	for _, id := range ids {
+		b, err := id.Encode()
+		if err != nil {
+			return nil, err
+		}
+		key = append(key, b...)
+	}
+	return key, nil
+}
+
+func findCheckTemplateByID(tx kv.Tx, id platform.ID) (*influxdb.CheckTemplate, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Err:  err,
+		}
+	}
+	b, err := tx.Bucket(checkTemplateBucket)
+	if err != nil {
+		return nil, err
+	}
+	v, err := b.Get(encodedID)
+	if kv.IsNotFound(err) {
+		return nil, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  influxdb.ErrCheckTemplateNotFound,
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t := &influxdb.CheckTemplate{}
+	if err := json.Unmarshal(v, t); err != nil {
+		return nil, errors.NewError(errors.WithErrorErr(err))
+	}
+	return t, nil
+}
+
+func putCheckTemplate(tx kv.Tx, t *influxdb.CheckTemplate) error {
+	v, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	encodedID, err := t.ID.Encode()
+	if err != nil {
+		return err
+	}
+	b, err := tx.Bucket(checkTemplateBucket)
+	if err != nil {
+		return err
+	}
+	return b.Put(encodedID, v)
+}
+
+// checkTemplateIDs returns the IDs of the check templates matching filter, sorted by ID.
+func checkTemplateIDs(tx kv.Tx, filter influxdb.CheckTemplateFilter) ([]platform.ID, error) {
+	bucket, prefix := checkTemplateBucket, []byte(nil)
+	if filter.OrganizationID != nil {
+		p, err := filter.OrganizationID.Encode()
+		if err != nil {
+			return nil, err
+		}
+		bucket, prefix = orgCheckTemplateIndex, p
+	}
+
+	b, err := tx.Bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+	var opts []kv.CursorOption
+	if prefix != nil {
+		opts = append(opts, kv.WithCursorPrefix(prefix))
+	}
+	cur, err := b.ForwardCursor(prefix, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close()
+
+	var ids []platform.ID
+	for k, _ := cur.Next(); k != nil; k, _ = cur.Next() {
+		var id platform.ID
+		if err := id.Decode(k[len(prefix):]); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, cur.Err()
+}
+
+// findCheckTemplateInstances returns the instances of a template, sorted by check.
+func findCheckTemplateInstances(tx kv.Tx, templateID platform.ID) ([]*influxdb.CheckTemplateInstance, error) {
+	prefix, err := templateID.Encode()
+	if err != nil {
+		return nil, err
+	}
+	b, err := tx.Bucket(checkTemplateInstanceBucket)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := b.ForwardCursor(prefix, kv.WithCursorPrefix(prefix))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close()
+
+	var instances []*influxdb.CheckTemplateInstance
+	for k, v := cur.Next(); k != nil; k, v = cur.Next() {
+		i := &influxdb.CheckTemplateInstance{}
+		if err := json.Unmarshal(v, i); err != nil {
+			return nil, err
+		}
+		instances = append(instances, i)
+	}
+	return instances, cur.Err()
+}
+
+func putCheckTemplateInstance(tx kv.Tx, i *influxdb.CheckTemplateInstance) error {
+	v, err := json.Marshal(i)
+	if err != nil {
+		return err
+	}
+	k, err := joinIDs(i.TemplateID, i.CheckID)
+	if err != nil {
+		return err
+	}
+	b, err := tx.Bucket(checkTemplateInstanceBucket)
+	if err != nil {
+		return err
+	}
+	return b.Put(k, v)
+}
+
+func deleteCheckTemplateInstance(tx kv.Tx, i *influxdb.CheckTemplateInstance) error {
+	k, err := joinIDs(i.TemplateID, i.CheckID)
+	if err != nil {
+		return err
+	}
+	b, err := tx.Bucket(checkTemplateInstanceBucket)
+	if err != nil {
+		return err
+	}
+	return b.Delete(k)
+}
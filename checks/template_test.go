@@ -0,0 +1,118 @@
+package checks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/notification/check"
+	"github.com/influxdata/influxdb/v2/query/fluxlang"
+	"github.com/influxdata/influxdb/v2/task/taskmodel"
+	"github.com/influxdata/influxdb/v2/tenant"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestTemplateService(t *testing.T) {
+	ctx := context.Background()
+	store, closeKVStore := NewKVTestStore(t)
+	defer closeKVStore()
+	logger := zaptest.NewLogger(t)
+
+	tenantSvc := tenant.NewService(tenant.NewStore(store))
+	tasks := kv.NewService(logger, store, tenantSvc, kv.ServiceConfig{
+		FluxLanguageService: fluxlang.DefaultService,
+	})
+	checkSvc := NewService(logger, store, tenantSvc, tasks)
+	svc := NewTemplateService(store, checkSvc, tasks)
+
+	user := &influxdb.User{Name: "user"}
+	require.NoError(t, tenantSvc.CreateUser(ctx, user))
+	org := &influxdb.Organization{Name: "org"}
+	require.NoError(t, tenantSvc.CreateOrganization(ctx, org))
+
+	tmpl := &influxdb.CheckTemplate{
+		OrganizationID: org.ID,
+		Name:           "cpu",
+		Parameters: []influxdb.CheckTemplateParameter{
+			{Name: "host"},
+		},
+		Check: json.RawMessage(`{
+			"type": "deadman",
+			"name": "cpu ${host}",
+			"every": "1m",
+			"timeSince": "90s",
+			"staleTime": "10m",
+			"level": "CRIT",
+			"statusMessageTemplate": "${host} is down",
+			"query": {"text": "from(bucket: \"telegraf\") |> range(start: -1m) |> filter(fn: (r) => r.host == \"${host}\")"},
+			"tags": [{"key": "host", "value": "${host}"}]
+		}`),
+	}
+	require.NoError(t, svc.CreateCheckTemplate(ctx, tmpl))
+
+	instances, err := svc.InstantiateCheckTemplate(ctx, tmpl.ID, []map[string]string{{"host": "a"}, {"host": "b"}}, user.ID)
+	require.NoError(t, err)
+	require.Len(t, instances, 2)
+
+	c, err := checkSvc.FindCheckByID(ctx, instances[1].CheckID)
+	require.NoError(t, err)
+	require.Equal(t, "cpu b", c.GetName())
+	require.Equal(t, org.ID, c.GetOrgID())
+	require.Equal(t, "b is down", c.(*check.Deadman).StatusMessageTemplate)
+	task, err := tasks.FindTaskByID(ctx, c.GetTaskID())
+	require.NoError(t, err)
+	require.Equal(t, string(taskmodel.TaskActive), task.Status)
+
+	_, err = svc.InstantiateCheckTemplate(ctx, tmpl.ID, []map[string]string{{}}, user.ID)
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err), "the host parameter has no default")
+
+	// the checks of the template keep their status when it is updated.
+	inactive := influxdb.Inactive
+	_, err = checkSvc.PatchCheck(ctx, instances[0].CheckID, influxdb.CheckUpdate{Status: &inactive})
+	require.NoError(t, err)
+
+	def := json.RawMessage(`{
+		"type": "deadman",
+		"name": "cpu ${host}",
+		"every": "5m",
+		"timeSince": "90s",
+		"staleTime": "10m",
+		"level": "WARN",
+		"statusMessageTemplate": "${host} is not reporting",
+		"query": {"text": "from(bucket: \"telegraf\") |> range(start: -5m) |> filter(fn: (r) => r.host == \"${host}\")"},
+		"tags": [{"key": "host", "value": "${host}"}]
+	}`)
+	_, err = svc.UpdateCheckTemplate(ctx, tmpl.ID, influxdb.CheckTemplateUpdate{Check: def})
+	require.NoError(t, err)
+
+	for i, host := range []string{"a", "b"} {
+		c, err := checkSvc.FindCheckByID(ctx, instances[i].CheckID)
+		require.NoError(t, err)
+		d := c.(*check.Deadman)
+		require.Equal(t, host+" is not reporting", d.StatusMessageTemplate)
+		require.Equal(t, 5*time.Minute, d.Every.TimeDuration())
+	}
+	c, err = checkSvc.FindCheckByID(ctx, instances[0].CheckID)
+	require.NoError(t, err)
+	task, err = tasks.FindTaskByID(ctx, c.GetTaskID())
+	require.NoError(t, err)
+	require.Equal(t, string(taskmodel.TaskInactive), task.Status)
+
+	require.NoError(t, svc.DeleteCheckTemplateInstance(ctx, tmpl.ID, instances[0].CheckID))
+	_, err = checkSvc.FindCheckByID(ctx, instances[0].CheckID)
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+	found, err := svc.FindCheckTemplateInstances(ctx, tmpl.ID)
+	require.NoError(t, err)
+	require.Equal(t, instances[1:], found)
+
+	require.NoError(t, svc.DeleteCheckTemplate(ctx, tmpl.ID))
+	_, err = svc.FindCheckTemplateByID(ctx, tmpl.ID)
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+	_, err = checkSvc.FindCheckByID(ctx, instances[1].CheckID)
+	require.NoError(t, err, "the checks of a deleted template are kept")
+}
@@ -0,0 +1,326 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const prefixCheckTemplates = "/api/v2/checkTemplates"
+
+// CheckTemplateHandler is the handler for the check template service.
+type CheckTemplateHandler struct {
+	chi.Router
+
+	api *kithttp.API
+	log *zap.Logger
+
+	checkTemplateService influxdb.CheckTemplateService
+}
+
+// NewCheckTemplateHandler returns a new instance of CheckTemplateHandler.
+func NewCheckTemplateHandler(log *zap.Logger, checkTemplateService influxdb.CheckTemplateService) *CheckTemplateHandler {
+	h := &CheckTemplateHandler{
+		log:                  log,
+		api:                  kithttp.NewAPI(kithttp.WithLog(log)),
+		checkTemplateService: checkTemplateService,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+	r.Route("/", func(r chi.Router) {
+		r.Get("/", h.handleGetCheckTemplates)
+		r.Post("/", h.handlePostCheckTemplate)
+
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", h.handleGetCheckTemplate)
+			r.Patch("/", h.handlePatchCheckTemplate)
+			r.Delete("/", h.handleDeleteCheckTemplate)
+			r.Get("/checks", h.handleGetCheckTemplateInstances)
+			r.Post("/checks", h.handlePostCheckTemplateInstances)
+			r.Delete("/checks/{checkID}", h.handleDeleteCheckTemplateInstance)
+		})
+	})
+	h.Router = r
+	return h
+}
+
+// Prefix returns the mounting prefix for the handler.
+func (h *CheckTemplateHandler) Prefix() string {
+	return prefixCheckTemplates
+}
+
+type checkTemplateResponse struct {
+	*influxdb.CheckTemplate
+	Links map[string]string `json:"links"`
+}
+
+func newCheckTemplateResponse(t *influxdb.CheckTemplate) checkTemplateResponse {
+	return checkTemplateResponse{
+		CheckTemplate: t,
+		Links: map[string]string{
+			"self":   fmt.Sprintf("%s/%s", prefixCheckTemplates, t.ID),
+			"checks": fmt.Sprintf("%s/%s/checks", prefixCheckTemplates, t.ID),
+			"org":    fmt.Sprintf("/api/v2/orgs/%s", t.OrganizationID),
+		},
+	}
+}
+
+type checkTemplatesResponse struct {
+	CheckTemplates []checkTemplateResponse `json:"checkTemplates"`
+	Total          int                     `json:"total"`
+	Links          map[string]string       `json:"links"`
+}
+
+type checkTemplateInstanceResponse struct {
+	*influxdb.CheckTemplateInstance
+	Links map[string]string `json:"links"`
+}
+
+type checkTemplateInstancesResponse struct {
+	Checks []checkTemplateInstanceResponse `json:"checks"`
+}
+
+func newCheckTemplateInstancesResponse(is []*influxdb.CheckTemplateInstance) checkTemplateInstancesResponse {
+	res := checkTemplateInstancesResponse{
+		Checks: make([]checkTemplateInstanceResponse, 0, len(is)),
+	}
+	for _, i := range is {
+		res.Checks = append(res.Checks, checkTemplateInstanceResponse{
+			CheckTemplateInstance: i,
+			Links: map[string]string{
+				"self":  fmt.Sprintf("%s/%s/checks/%s", prefixCheckTemplates, i.TemplateID, i.CheckID),
+				"check": fmt.Sprintf("/api/v2/checks/%s", i.CheckID),
+			},
+		})
+	}
+	return res
+}
+
+func decodeID(r *http.Request, param string) (platform.ID, error) {
+	var id platform.ID
+	if err := id.DecodeFromString(chi.URLParam(r, param)); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// handleGetCheckTemplates lists the check templates of an organization.
+func (h *CheckTemplateHandler) handleGetCheckTemplates(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	opts, err := influxdb.DecodeFindOptions(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var filter influxdb.CheckTemplateFilter
+	if orgID := r.URL.Query().Get("orgID"); orgID != "" {
+		id, err := platform.IDFromString(orgID)
+		if err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+		filter.OrganizationID = id
+	}
+
+	ts, total, err := h.checkTemplateService.FindCheckTemplates(ctx, filter, *opts)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Check templates retrieved", zap.Int("count", len(ts)))
+
+	res := checkTemplatesResponse{
+		CheckTemplates: make([]checkTemplateResponse, 0, len(ts)),
+		Total:          total,
+		Links: map[string]string{
+			"self": prefixCheckTemplates,
+		},
+	}
+	for _, t := range ts {
+		res.CheckTemplates = append(res.CheckTemplates, newCheckTemplateResponse(t))
+	}
+	h.api.Respond(w, r, http.StatusOK, res)
+}
+
+// handlePostCheckTemplate creates a check template.
+func (h *CheckTemplateHandler) handlePostCheckTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var t influxdb.CheckTemplate
+	if err := h.api.DecodeJSON(r.Body, &t); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if !t.OrganizationID.Valid() {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "orgID is required",
+		})
+		return
+	}
+
+	if err := h.checkTemplateService.CreateCheckTemplate(ctx, &t); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Check template created", zap.String("checkTemplateID", t.ID.String()))
+
+	h.api.Respond(w, r, http.StatusCreated, newCheckTemplateResponse(&t))
+}
+
+// handleGetCheckTemplate retrieves a check template by ID.
+func (h *CheckTemplateHandler) handleGetCheckTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := decodeID(r, "id")
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	t, err := h.checkTemplateService.FindCheckTemplateByID(ctx, id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Check template retrieved", zap.String("checkTemplateID", id.String()))
+
+	h.api.Respond(w, r, http.StatusOK, newCheckTemplateResponse(t))
+}
+
+// handlePatchCheckTemplate updates a check template, and the checks instantiated from it.
+func (h *CheckTemplateHandler) handlePatchCheckTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := decodeID(r, "id")
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var upd influxdb.CheckTemplateUpdate
+	if err := h.api.DecodeJSON(r.Body, &upd); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	t, err := h.checkTemplateService.UpdateCheckTemplate(ctx, id, upd)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Check template updated", zap.String("checkTemplateID", id.String()))
+
+	h.api.Respond(w, r, http.StatusOK, newCheckTemplateResponse(t))
+}
+
+// handleDeleteCheckTemplate deletes a check template.
+func (h *CheckTemplateHandler) handleDeleteCheckTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := decodeID(r, "id")
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.checkTemplateService.DeleteCheckTemplate(ctx, id); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Check template deleted", zap.String("checkTemplateID", id.String()))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetCheckTemplateInstances lists the checks instantiated from a check template.
+func (h *CheckTemplateHandler) handleGetCheckTemplateInstances(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := decodeID(r, "id")
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	is, err := h.checkTemplateService.FindCheckTemplateInstances(ctx, id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, newCheckTemplateInstancesResponse(is))
+}
+
+type postCheckTemplateInstancesRequest struct {
+	Targets []map[string]string `json:"targets"`
+}
+
+// handlePostCheckTemplateInstances instantiates a check template once for each target.
+func (h *CheckTemplateHandler) handlePostCheckTemplateInstances(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := decodeID(r, "id")
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var req postCheckTemplateInstancesRequest
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if len(req.Targets) == 0 {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "at least one target is required",
+		})
+		return
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	is, err := h.checkTemplateService.InstantiateCheckTemplate(ctx, id, req.Targets, auth.GetUserID())
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Check template instantiated", zap.String("checkTemplateID", id.String()), zap.Int("count", len(is)))
+
+	h.api.Respond(w, r, http.StatusCreated, newCheckTemplateInstancesResponse(is))
+}
+
+// handleDeleteCheckTemplateInstance deletes a check instantiated from a check template.
+func (h *CheckTemplateHandler) handleDeleteCheckTemplateInstance(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := decodeID(r, "id")
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	checkID, err := decodeID(r, "checkID")
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.checkTemplateService.DeleteCheckTemplateInstance(ctx, id, checkID); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Check template instance deleted", zap.String("checkTemplateID", id.String()), zap.String("checkID", checkID.String()))
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,139 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type fakeCheckTemplateService struct {
+	influxdb.CheckTemplateService
+	templates map[platform.ID]*influxdb.CheckTemplate
+	instances []*influxdb.CheckTemplateInstance
+	userID    platform.ID
+}
+
+func (s *fakeCheckTemplateService) FindCheckTemplateByID(ctx context.Context, id platform.ID) (*influxdb.CheckTemplate, error) {
+	t, ok := s.templates[id]
+	if !ok {
+		return nil, &errors.Error{Code: errors.ENotFound, Msg: influxdb.ErrCheckTemplateNotFound}
+	}
+	return t, nil
+}
+
+func (s *fakeCheckTemplateService) CreateCheckTemplate(ctx context.Context, t *influxdb.CheckTemplate) error {
+	if err := t.Valid(); err != nil {
+		return err
+	}
+	t.ID = platform.ID(len(s.templates) + 1)
+	s.templates[t.ID] = t
+	return nil
+}
+
+func (s *fakeCheckTemplateService) InstantiateCheckTemplate(ctx context.Context, id platform.ID, targets []map[string]string, userID platform.ID) ([]*influxdb.CheckTemplateInstance, error) {
+	t, err := s.FindCheckTemplateByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.userID = userID
+	var is []*influxdb.CheckTemplateInstance
+	for _, params := range targets {
+		if _, err := t.Render(params); err != nil {
+			return nil, err
+		}
+		i := &influxdb.CheckTemplateInstance{
+			TemplateID: id,
+			CheckID:    platform.ID(len(s.instances) + 100),
+			Params:     params,
+		}
+		s.instances = append(s.instances, i)
+		is = append(is, i)
+	}
+	return is, nil
+}
+
+func (s *fakeCheckTemplateService) FindCheckTemplateInstances(ctx context.Context, templateID platform.ID) ([]*influxdb.CheckTemplateInstance, error) {
+	return s.instances, nil
+}
+
+func (s *fakeCheckTemplateService) DeleteCheckTemplateInstance(ctx context.Context, templateID, checkID platform.ID) error {
+	for i, inst := range s.instances {
+		if inst.TemplateID == templateID && inst.CheckID == checkID {
+			s.instances = append(s.instances[:i], s.instances[i+1:]...)
+			return nil
+		}
+	}
+	return &errors.Error{Code: errors.ENotFound, Msg: "check template instance not found"}
+}
+
+func TestCheckTemplateHandler(t *testing.T) {
+	svc := &fakeCheckTemplateService{templates: map[platform.ID]*influxdb.CheckTemplate{}}
+	h := NewCheckTemplateHandler(zaptest.NewLogger(t), svc)
+	r := chi.NewRouter()
+	r.Mount(h.Prefix(), h)
+
+	ctx := icontext.SetAuthorizer(context.Background(), &influxdb.Authorization{UserID: 7})
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/api/v2/checkTemplates"+path, bytes.NewBufferString(body))
+		r.ServeHTTP(w, req.WithContext(ctx))
+		return w
+	}
+
+	w := do(http.MethodPost, "", `{
+		"orgID": "0000000000000001",
+		"name": "cpu",
+		"parameters": [{"name": "host"}],
+		"check": {"type": "deadman", "name": "cpu ${host}"}
+	}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		ID    string            `json:"id"`
+		Links map[string]string `json:"links"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	require.Equal(t, "/api/v2/checkTemplates/"+created.ID+"/checks", created.Links["checks"])
+
+	w = do(http.MethodPost, "/"+created.ID+"/checks", `{"targets": []}`)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = do(http.MethodPost, "/"+created.ID+"/checks", `{"targets": [{"host": "a"}, {"host": "b"}]}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	require.Equal(t, platform.ID(7), svc.userID)
+	var instances struct {
+		Checks []struct {
+			CheckID string            `json:"checkID"`
+			Params  map[string]string `json:"params"`
+			Links   map[string]string `json:"links"`
+		} `json:"checks"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &instances))
+	require.Len(t, instances.Checks, 2)
+	require.Equal(t, "b", instances.Checks[1].Params["host"])
+	require.Equal(t, "/api/v2/checks/"+instances.Checks[1].CheckID, instances.Checks[1].Links["check"])
+
+	w = do(http.MethodPost, "/"+created.ID+"/checks", `{"targets": [{"region": "west"}]}`)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = do(http.MethodDelete, fmt.Sprintf("/%s/checks/%s", created.ID, instances.Checks[0].CheckID), "")
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	w = do(http.MethodGet, "/"+created.ID+"/checks", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &instances))
+	require.Len(t, instances.Checks, 1)
+
+	w = do(http.MethodGet, "/0000000000000009", "")
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,171 @@
+package debug
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/cli"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const bundlePath = "/api/v2/debug/bundle"
+
+// NewCommand creates the debug command.
+func NewCommand(ctx context.Context, v *viper.Viper) (*cobra.Command, error) {
+	base := &cobra.Command{
+		Use:   "debug",
+		Short: "Commands for collecting diagnostics from a running influxd",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.PrintErrf("See '%s -h' for help\n", cmd.CommandPath())
+		},
+	}
+
+	bundleCmd, err := newBundleCommand(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	base.AddCommand(bundleCmd)
+
+	return base, nil
+}
+
+type bundleFlags struct {
+	host       string
+	token      string
+	output     string
+	cpu        time.Duration
+	trace      time.Duration
+	skipVerify bool
+}
+
+func newBundleCommand(ctx context.Context, v *viper.Viper) (*cobra.Command, error) {
+	var flags bundleFlags
+
+	cmd := &cobra.Command{
+		Use:   "bundle",
+		Short: "Download a debug bundle from a running influxd",
+		Long: `Download a debug bundle from a running influxd, for attaching to support requests.
+
+The bundle is a gzipped tarball containing build information, profiles, goroutine
+stacks, metrics and recent log entries. Collecting it requires an operator token.
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if flags.token == "" {
+				flags.token = os.Getenv("INFLUX_TOKEN")
+			}
+			if flags.output == "" {
+				flags.output = fmt.Sprintf("influxd-debug-bundle-%s.tar.gz", time.Now().UTC().Format("20060102T150405Z"))
+			}
+			if err := downloadBundle(ctx, flags); err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "debug bundle written to %s\n", flags.output)
+			return nil
+		},
+	}
+
+	opts := []cli.Opt{
+		{
+			DestP:   &flags.host,
+			Flag:    "host",
+			Default: "http://localhost:8086",
+			Desc:    "URL of the influxd server",
+		},
+		{
+			DestP: &flags.token,
+			Flag:  "token",
+			Desc:  "operator token used to authenticate; defaults to the INFLUX_TOKEN environment variable",
+			Short: 't',
+		},
+		{
+			DestP: &flags.output,
+			Flag:  "output",
+			Desc:  "path to write the bundle to; defaults to influxd-debug-bundle-<timestamp>.tar.gz",
+			Short: 'o',
+		},
+		{
+			DestP:   &flags.cpu,
+			Flag:    "cpu",
+			Default: 10 * time.Second,
+			Desc:    "duration of the CPU profile to collect; 0 skips it",
+		},
+		{
+			DestP:   &flags.trace,
+			Flag:    "trace",
+			Default: time.Duration(0),
+			Desc:    "duration of the execution trace to collect; 0 skips it",
+		},
+		{
+			DestP:   &flags.skipVerify,
+			Flag:    "skip-verify",
+			Default: false,
+			Desc:    "skip TLS certificate verification",
+		},
+	}
+	if err := cli.BindOptions(v, cmd, opts); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// downloadBundle fetches a debug bundle and writes it to flags.output. Nothing
+// is written unless the server returns a bundle.
+func downloadBundle(ctx context.Context, flags bundleFlags) error {
+	if flags.token == "" {
+		return errors.New("an operator token is required; set --token or INFLUX_TOKEN")
+	}
+
+	u, err := url.Parse(strings.TrimSuffix(flags.host, "/") + bundlePath)
+	if err != nil {
+		return fmt.Errorf("invalid host %q: %w", flags.host, err)
+	}
+	q := u.Query()
+	q.Set("cpu", flags.cpu.String())
+	q.Set("trace", flags.trace.String())
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Token "+flags.token)
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: flags.skipVerify},
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to request debug bundle: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("failed to collect debug bundle: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	f, err := os.OpenFile(flags.output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		_ = f.Close()
+		_ = os.Remove(flags.output)
+		return fmt.Errorf("failed to write debug bundle: %w", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,50 @@
+package debug
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadBundle(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, bundlePath, r.URL.Path)
+		require.Equal(t, "5s", r.URL.Query().Get("cpu"))
+		if r.Header.Get("Authorization") != "Token operator" {
+			http.Error(w, `{"code":"unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("bundle"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	flags := bundleFlags{
+		host:   srv.URL,
+		token:  "operator",
+		output: filepath.Join(dir, "bundle.tar.gz"),
+		cpu:    5 * time.Second,
+	}
+	require.NoError(t, downloadBundle(context.Background(), flags))
+	b, err := os.ReadFile(flags.output)
+	require.NoError(t, err)
+	require.Equal(t, "bundle", string(b))
+
+	// An existing file is never overwritten.
+	require.Error(t, downloadBundle(context.Background(), flags))
+
+	flags.token = "reader"
+	flags.output = filepath.Join(dir, "unauthorized.tar.gz")
+	err = downloadBundle(context.Background(), flags)
+	require.ErrorContains(t, err, "401")
+	require.NoFileExists(t, flags.output)
+
+	flags.token = ""
+	require.Error(t, downloadBundle(context.Background(), flags))
+}
@@ -2,9 +2,11 @@ package downgrade
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -50,6 +52,7 @@ func NewCommand(ctx context.Context, v *viper.Viper) (*cobra.Command, error) {
 	for k := range downgradeMigrationTargets {
 		validDowngradeTargets = append(validDowngradeTargets, k)
 	}
+	sort.Strings(validDowngradeTargets)
 	var validTargetsHelp string
 	if len(validDowngradeTargets) == 1 {
 		validTargetsHelp = validDowngradeTargets[0]
@@ -59,10 +62,11 @@ func NewCommand(ctx context.Context, v *viper.Viper) (*cobra.Command, error) {
 
 	var sqlitePath string
 	var boltPath string
+	var target string
 	var logLevel zapcore.Level
 
 	cmd := &cobra.Command{
-		Use:   fmt.Sprintf("downgrade [flags] %s", validTargetsHelp),
+		Use:   fmt.Sprintf("downgrade [flags] [%s]", validTargetsHelp),
 		Short: "Downgrade metadata schema used by influxd to match the expectations of an older release",
 		Long: `Run this command prior to downgrading the influxd binary.
 
@@ -72,11 +76,25 @@ been migrated to a newer schema will result in a startup error. This command dow
 those metadata schemas to match the expectations of an older release, allowing the older
 influxd binary to boot successfully.
 
-The target version of the downgrade must be specified, i.e. "influxd downgrade 2.0".
+The target version of the downgrade must be specified, either with the --target flag
+or as an argument, i.e. "influxd downgrade --target 2.0" or "influxd downgrade 2.0".
+
+Both metadata stores are checked for compatibility with the target version before
+either is modified, so a failed validation leaves the metadata untouched.
 `,
 		ValidArgs: validDowngradeTargets,
-		Args:      cobra.ExactValidArgs(1),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.MaximumNArgs(1)(cmd, args); err != nil {
+				return err
+			}
+			return cobra.OnlyValidArgs(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			targetVersion, err := resolveTarget(target, args)
+			if err != nil {
+				return err
+			}
+
 			logconf := &influxlogger.Config{
 				Format: "auto",
 				Level:  logLevel,
@@ -86,7 +104,7 @@ The target version of the downgrade must be specified, i.e. "influxd downgrade 2
 				return err
 			}
 
-			return downgrade(ctx, boltPath, sqlitePath, args[0], logger)
+			return downgrade(ctx, boltPath, sqlitePath, targetVersion, logger)
 		},
 	}
 
@@ -102,6 +120,11 @@ The target version of the downgrade must be specified, i.e. "influxd downgrade 2
 			Flag:  "sqlite-path",
 			Desc:  fmt.Sprintf("path to sqlite database. if not set, the database is assumed to be in the bolt-path directory as %q", sqlite.DefaultFilename),
 		},
+		{
+			DestP: &target,
+			Flag:  "target",
+			Desc:  fmt.Sprintf("version to downgrade metadata to, one of %s", validTargetsHelp),
+		},
 		{
 			DestP:   &logLevel,
 			Flag:    "log-level",
@@ -120,6 +143,25 @@ The target version of the downgrade must be specified, i.e. "influxd downgrade 2
 	return cmd, nil
 }
 
+// resolveTarget returns the downgrade target given via the --target flag or as a
+// positional argument, erroring if neither or conflicting targets are provided.
+func resolveTarget(flagTarget string, args []string) (string, error) {
+	target := flagTarget
+	if len(args) > 0 {
+		if target != "" && target != args[0] {
+			return "", fmt.Errorf("conflicting downgrade targets %q and %q", target, args[0])
+		}
+		target = args[0]
+	}
+	if target == "" {
+		return "", errors.New("a downgrade target version must be specified")
+	}
+	if _, ok := downgradeMigrationTargets[target]; !ok {
+		return "", fmt.Errorf("unsupported downgrade target %q", target)
+	}
+	return target, nil
+}
+
 func downgrade(ctx context.Context, boltPath, sqlitePath, targetVersion string, log *zap.Logger) error {
 	info := influxdb.GetBuildInfo()
 
@@ -169,6 +211,15 @@ func downgrade(ctx context.Context, boltPath, sqlitePath, targetVersion string,
 	sqlMigrator := sqlite.NewMigrator(sqlStore, log.With(zap.String("service", "sql-migrator")))
 	sqlMigrator.SetBackupPath(fmt.Sprintf(backupPathFormat, sqlitePath, info.Version, targetVersion))
 
+	// Validate both stores before mutating either, so incompatible metadata is never partially downgraded.
+	log.Info("Validating metadata compatibility with target version", zap.String("version", targetVersion))
+	if err := kvMigrator.Validate(ctx); err != nil {
+		return fmt.Errorf("KV metadata is not compatible with this downgrade: %w", err)
+	}
+	if err := sqlMigrator.Validate(ctx, sqliteMigrations.AllDown); err != nil {
+		return fmt.Errorf("SQL metadata is not compatible with this downgrade: %w", err)
+	}
+
 	log.Info("Downgrading KV metadata to target version", zap.String("version", targetVersion))
 	if err := kvMigrator.Down(ctx, downgradeMigrationTargets[targetVersion].kvMigration); err != nil {
 		return fmt.Errorf("failed to tear down KV migrations: %w", err)
@@ -0,0 +1,36 @@
+package downgrade
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		flag    string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{name: "flag", flag: "2.1", want: "2.1"},
+		{name: "arg", args: []string{"2.0"}, want: "2.0"},
+		{name: "matching flag and arg", flag: "2.3", args: []string{"2.3"}, want: "2.3"},
+		{name: "conflicting flag and arg", flag: "2.3", args: []string{"2.0"}, wantErr: true},
+		{name: "missing", wantErr: true},
+		{name: "unsupported", flag: "1.8", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveTarget(tt.flag, tt.args)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	nethttp "net/http"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ACMEConfig configures automatic TLS certificate issuance and renewal using
+// the ACME protocol.
+type ACMEConfig struct {
+	Domains              []string
+	Email                string
+	AcceptTOS            bool
+	CacheDir             string
+	DirectoryURL         string
+	HTTPChallengeAddress string
+}
+
+// Enabled reports whether certificates should be obtained using ACME.
+func (c ACMEConfig) Enabled() bool {
+	return len(c.Domains) > 0
+}
+
+// Validate returns an error if the config cannot be used to obtain certificates.
+func (c ACMEConfig) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if !c.AcceptTOS {
+		return errors.New("tls-acme-accept-tos must be set to obtain certificates using ACME")
+	}
+	if c.CacheDir == "" {
+		return errors.New("tls-acme-cache-dir must be set to obtain certificates using ACME")
+	}
+	return nil
+}
+
+// newACMEManager returns a certificate manager which obtains and renews
+// certificates for the configured domains, caching them on disk.
+//
+// The manager answers TLS-ALPN-01 challenges through its GetCertificate
+// callback, so the certificate authority must be able to reach the TLS
+// listener on port 443.
+func newACMEManager(c ACMEConfig) (*autocert.Manager, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(c.CacheDir),
+		HostPolicy: autocert.HostWhitelist(c.Domains...),
+		Email:      c.Email,
+	}
+	if c.DirectoryURL != "" {
+		m.Client = &acme.Client{DirectoryURL: c.DirectoryURL}
+	}
+	return m, nil
+}
+
+// runACMEChallengeHTTP launches a plain HTTP listener answering ACME HTTP-01
+// challenges. Every other request is redirected to HTTPS.
+// The listener is run in a separate goroutine. If it fails to start up, it
+// will cancel the launcher.
+func (m *Launcher) runACMEChallengeHTTP(opts *InfluxdOpts, manager *autocert.Manager, httpLogger *zap.Logger) error {
+	log := m.log.With(zap.String("service", "acme-challenge-listener"))
+
+	challengeServer := &nethttp.Server{
+		Handler:           manager.HTTPHandler(nil),
+		ReadHeaderTimeout: opts.HttpReadHeaderTimeout,
+		ReadTimeout:       opts.HttpReadTimeout,
+		WriteTimeout:      opts.HttpWriteTimeout,
+		IdleTimeout:       opts.HttpIdleTimeout,
+		ErrorLog:          zap.NewStdLog(httpLogger),
+	}
+	m.closers = append(m.closers, labeledCloser{
+		label:   "ACME challenge HTTP server",
+		timeout: opts.HttpShutdownTimeout,
+		closer:  challengeServer.Shutdown,
+	})
+
+	addr := opts.HttpTLSACME.HTTPChallengeAddress
+	ln, err := listen(addr)
+	if err != nil {
+		log.Error("Failed to set up ACME challenge listener", zap.String("addr", addr), zap.Error(err))
+		return fmt.Errorf("failed to listen for ACME challenges: %w", err)
+	}
+
+	m.wg.Add(1)
+	go func(log *zap.Logger) {
+		defer m.wg.Done()
+		log.Info("Listening", zap.String("transport", ln.Addr().Network()), zap.String("addr", ln.Addr().String()))
+
+		if err := challengeServer.Serve(ln); err != nethttp.ErrServerClosed {
+			log.Error("Failed to serve ACME challenges", zap.Error(err))
+			m.cancel()
+		}
+		log.Info("Stopping")
+	}(log)
+
+	return nil
+}
@@ -5,22 +5,42 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/annotations"
 	"github.com/influxdata/influxdb/v2/bolt"
+	"github.com/influxdata/influxdb/v2/cdc"
+	"github.com/influxdata/influxdb/v2/dbrp"
 	"github.com/influxdata/influxdb/v2/fluxinit"
 	"github.com/influxdata/influxdb/v2/internal/fs"
 	"github.com/influxdata/influxdb/v2/kit/cli"
+	"github.com/influxdata/influxdb/v2/kit/prom"
 	"github.com/influxdata/influxdb/v2/kit/signals"
+	"github.com/influxdata/influxdb/v2/kit/systemd"
+	"github.com/influxdata/influxdb/v2/kit/tracing/otlp"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	influxlogger "github.com/influxdata/influxdb/v2/logger"
+	"github.com/influxdata/influxdb/v2/membudget"
 	"github.com/influxdata/influxdb/v2/pprof"
+	"github.com/influxdata/influxdb/v2/replica"
+	"github.com/influxdata/influxdb/v2/secret/cloud"
+	"github.com/influxdata/influxdb/v2/secret/kms"
+	"github.com/influxdata/influxdb/v2/selfmonitor"
+	"github.com/influxdata/influxdb/v2/session/saml"
 	"github.com/influxdata/influxdb/v2/sqlite"
 	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/influxdata/influxdb/v2/telemetry"
+	"github.com/influxdata/influxdb/v2/tenant"
+	"github.com/influxdata/influxdb/v2/usage"
+	authv1 "github.com/influxdata/influxdb/v2/v1/authorization"
 	"github.com/influxdata/influxdb/v2/v1/coordinator"
 	"github.com/influxdata/influxdb/v2/vault"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
@@ -59,6 +79,9 @@ func NewInfluxdCommand(ctx context.Context, v *viper.Viper) (*cobra.Command, err
 	}
 	for _, c := range []*cobra.Command{cmd, runCmd} {
 		setCmdDescriptions(c)
+		c.PreRun = func(c *cobra.Command, _ []string) {
+			o.cliFlags = changedFlags(c)
+		}
 		if err := cli.BindOptions(o.Viper, c, cliOpts); err != nil {
 			return nil, err
 		}
@@ -69,6 +92,11 @@ func NewInfluxdCommand(ctx context.Context, v *viper.Viper) (*cobra.Command, err
 		return nil, err
 	}
 	cmd.AddCommand(printCmd)
+	validateCmd, err := NewInfluxdValidateConfigCommand(v, o)
+	if err != nil {
+		return nil, err
+	}
+	cmd.AddCommand(validateCmd)
 
 	return cmd, nil
 }
@@ -110,11 +138,8 @@ func cmdRunE(ctx context.Context, o *InfluxdOpts) func() error {
 		l := NewLauncher()
 
 		// Create top level logger
-		logconf := &influxlogger.Config{
-			Format: "auto",
-			Level:  o.LogLevel,
-		}
-		logger, err := logconf.New(os.Stdout)
+		l.logLevel.SetLevel(o.LogLevel)
+		logger, err := newLogger(l, o)
 		if err != nil {
 			return err
 		}
@@ -124,14 +149,65 @@ func cmdRunE(ctx context.Context, o *InfluxdOpts) func() error {
 		if err := l.run(signals.WithStandardSignals(ctx), o); err != nil {
 			return err
 		}
+		notifySystemd(l.log, systemd.Ready)
 		<-l.Done()
 
-		// Tear down the launcher, allowing it a few seconds to finish any
-		// in-progress requests.
-		shutdownCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
-		defer cancel()
-		return l.Shutdown(shutdownCtx)
+		// Tear down the launcher, allowing each subsystem its configured
+		// timeout to finish in-progress work. When run by systemd, extend its
+		// stop timeout so the process isn't killed while shutting down.
+		states := []string{systemd.Stopping}
+		if timeout, ok := l.ShutdownTimeout(); ok {
+			states = append(states, systemd.ExtendTimeout(timeout))
+		}
+		notifySystemd(l.log, states...)
+		return l.Shutdown(ctx)
+	}
+}
+
+// notifySystemd reports the server's state to systemd, if it is managing the process.
+func notifySystemd(log *zap.Logger, states ...string) {
+	if _, err := systemd.Notify(states...); err != nil {
+		log.Warn("Failed to notify systemd", zap.Strings("states", states), zap.Error(err))
+	}
+}
+
+// newLogger creates the top-level logger for l, writing to stdout, the optional
+// log file, and l's recent entries for debug bundles. Its cores enable every level
+// used by any subsystem, and l.levels filters each logger to the appropriate level.
+func newLogger(l *Launcher, o *InfluxdOpts) (*zap.Logger, error) {
+	for subsystem, val := range o.LogSubsystemLevels {
+		var lvl zapcore.Level
+		if err := lvl.UnmarshalText([]byte(val)); err != nil {
+			return nil, fmt.Errorf("invalid log level for subsystem %q: %w", subsystem, err)
+		}
+		if err := l.levels.SetLevel(subsystem, &lvl); err != nil {
+			return nil, err
+		}
+	}
+
+	level := l.levels.Enabler()
+	stdoutConf := &influxlogger.Config{Format: o.LogFormat, Level: level}
+	stdout, err := stdoutConf.NewCore(os.Stdout)
+	if err != nil {
+		return nil, err
+	}
+	cores := []zapcore.Core{stdout, l.recentLogs.Core(level)}
+
+	if o.LogFile != "" {
+		f, err := os.OpenFile(o.LogFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		fileConf := &influxlogger.Config{Format: o.LogFileFormat, Level: level}
+		file, err := fileConf.NewCore(f)
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		cores = append(cores, file)
 	}
+
+	return l.levels.Filter(influxlogger.NewWithCores(cores...)), nil
 }
 
 // InfluxdOpts captures all arguments for running the InfluxDB server.
@@ -141,8 +217,17 @@ type InfluxdOpts struct {
 
 	LogLevel          zapcore.Level
 	FluxLogEnabled    bool
+	FluxPackagesPath  string
 	TracingType       string
 	ReportingDisabled bool
+	Telemetry         telemetry.Config
+
+	TracingOTLP otlp.Config
+
+	LogFormat          string
+	LogFile            string
+	LogFileFormat      string
+	LogSubsystemLevels map[string]string
 
 	AssetsPath string
 	BoltPath   string
@@ -152,25 +237,64 @@ type InfluxdOpts struct {
 	StoreType   string
 	SecretStore string
 	VaultConfig vault.Config
+	AWSSecrets  cloud.AWSConfig
+	GCPSecrets  cloud.GCPConfig
+
+	SecretEncryption kms.Config
+
+	SecretRotationWebhooks []string
 
 	InstanceID string
 
+	DashboardRevisionRetention int
+
 	HttpBindAddress       string
+	AdminBindAddress      string
+	HttpUnixSocket        string
+	HttpUnixSocketUIDs    []string
 	HttpReadHeaderTimeout time.Duration
 	HttpReadTimeout       time.Duration
 	HttpWriteTimeout      time.Duration
 	HttpIdleTimeout       time.Duration
+	HttpShutdownTimeout   time.Duration
 	HttpTLSCert           string
 	HttpTLSKey            string
 	HttpTLSMinVersion     string
 	HttpTLSStrictCiphers  bool
+	HttpTLSReloadInterval time.Duration
+	HttpTLSACME           ACMEConfig
 	SessionLength         int // in minutes
 	SessionRenewDisabled  bool
+	SessionIdleTimeout    time.Duration
+	SessionMaxLifetime    time.Duration
+	SessionMaxPerUser     int
+	SessionRefreshLength  time.Duration
+
+	SAML saml.Config
+
+	ShutdownTimeout        time.Duration
+	TaskShutdownTimeout    time.Duration
+	StorageShutdownTimeout time.Duration
 
 	ProfilingDisabled bool
 	MetricsDisabled   bool
+	MetricsFilter     prom.Filter
 	UIDisabled        bool
 
+	OrgMetrics     usage.Config
+	SelfMonitoring selfmonitor.Config
+	SLO            kithttp.SLOConfig
+
+	EventLogRetention time.Duration
+
+	GapAnnotations annotations.GapConfig
+
+	Replica replica.Config
+
+	CDC cdc.Config
+
+	DBRPAutoCreate dbrp.AutoCreateSettings
+
 	NatsPort            int
 	NatsMaxPayloadBytes int
 
@@ -185,6 +309,9 @@ type InfluxdOpts struct {
 	QueueSize                       int32
 	CoordinatorConfig               coordinator.Config
 
+	// OrgMemory budgets the memory of the queries and writes of each org.
+	OrgMemory membudget.Config
+
 	// Storage options.
 	StorageConfig storage.Config
 
@@ -192,6 +319,20 @@ type InfluxdOpts struct {
 
 	HardeningEnabled bool
 	StrongPasswords  bool
+	PasswordPolicy   tenant.PasswordPolicy
+	V1TokenHashing   bool
+
+	V1PasswordLockoutThreshold int
+	V1PasswordLockoutWindow    time.Duration
+
+	V1AuthCacheSize int
+	V1AuthCacheTTL  time.Duration
+
+	V1OrgTokenQuota int
+
+	// cliFlags holds the names of options set explicitly on the command line.
+	// These are never overridden by a configuration reload.
+	cliFlags map[string]struct{}
 }
 
 // NewOpts constructs options with default values.
@@ -209,23 +350,83 @@ func NewOpts(viper *viper.Viper) *InfluxdOpts {
 		LogLevel:          zapcore.InfoLevel,
 		FluxLogEnabled:    false,
 		ReportingDisabled: false,
+		Telemetry: telemetry.Config{
+			URL: telemetry.DefaultURL,
+		},
+
+		TracingOTLP: otlp.Config{
+			Endpoint:    otlp.DefaultEndpoint,
+			ServiceName: otlp.DefaultServiceName,
+			SampleRatio: 1,
+		},
+
+		LogFormat:     "auto",
+		LogFileFormat: "json",
+
+		DashboardRevisionRetention: 50,
 
 		BoltPath:   filepath.Join(dir, bolt.DefaultFilename),
 		SqLitePath: filepath.Join(dir, sqlite.DefaultFilename),
 		EnginePath: filepath.Join(dir, "engine"),
 
 		HttpBindAddress:       ":8086",
+		HttpUnixSocketUIDs:    []string{strconv.Itoa(os.Getuid())},
 		HttpReadHeaderTimeout: 10 * time.Second,
 		HttpIdleTimeout:       3 * time.Minute,
+		HttpShutdownTimeout:   2 * time.Second,
 		HttpTLSMinVersion:     "1.2",
 		HttpTLSStrictCiphers:  false,
+		HttpTLSReloadInterval: time.Minute,
 		SessionLength:         60, // 60 minutes
 		SessionRenewDisabled:  false,
+		SessionIdleTimeout:    influxdb.RenewSessionTime,
+		SessionMaxLifetime:    0,
+		SessionMaxPerUser:     0,
+		SessionRefreshLength:  0,
+
+		HttpTLSACME: ACMEConfig{
+			CacheDir: filepath.Join(dir, "acme"),
+		},
+
+		ShutdownTimeout:        2 * time.Second,
+		TaskShutdownTimeout:    0,
+		StorageShutdownTimeout: 0,
 
 		ProfilingDisabled: false,
 		MetricsDisabled:   false,
 		UIDisabled:        false,
 
+		OrgMetrics: usage.Config{
+			MaxOrgs:  100,
+			Interval: time.Minute,
+		},
+		GapAnnotations: annotations.GapConfig{
+			Window: 10 * time.Minute,
+		},
+		Replica: replica.Config{
+			SyncInterval: time.Minute,
+		},
+		CDC: cdc.Config{
+			Buffer: 1000,
+		},
+		SelfMonitoring: selfmonitor.Config{
+			Interval:  10 * time.Second,
+			Retention: influxdb.InternalSystemBucketRetention,
+			Cardinality: selfmonitor.CardinalityConfig{
+				Every: 10 * time.Minute,
+				Warn:  10000,
+				Crit:  100000,
+			},
+		},
+
+		SLO: kithttp.SLOConfig{
+			Write:   kithttp.LatencyObjective{Latency: time.Second, Target: 0.999},
+			Query:   kithttp.LatencyObjective{Latency: 5 * time.Second, Target: 0.99},
+			MaxOrgs: 100,
+		},
+
+		EventLogRetention: 30 * 24 * time.Hour,
+
 		StoreType:   DiskStore,
 		SecretStore: BoltStore,
 
@@ -240,11 +441,25 @@ func NewOpts(viper *viper.Viper) *InfluxdOpts {
 		MaxMemoryBytes:                  0,
 		QueueSize:                       1024,
 
+		OrgMemory: membudget.Config{
+			QueueTimeout: 10 * time.Second,
+		},
+
 		Testing:                 false,
 		TestingAlwaysAllowSetup: false,
 
 		HardeningEnabled: false,
 		StrongPasswords:  false,
+		PasswordPolicy:   tenant.DefaultPasswordPolicy(),
+		V1TokenHashing:   false,
+
+		V1PasswordLockoutThreshold: 0,
+		V1PasswordLockoutWindow:    authv1.DefaultLockoutWindow,
+
+		V1AuthCacheSize: 0,
+		V1AuthCacheTTL:  authv1.DefaultAuthCacheTTL,
+
+		V1OrgTokenQuota: 0,
 	}
 }
 
@@ -258,16 +473,67 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Default: o.LogLevel,
 			Desc:    "supported log levels are debug, info, and error",
 		},
+		{
+			DestP:   &o.LogSubsystemLevels,
+			Flag:    "log-subsystem-levels",
+			Default: o.LogSubsystemLevels,
+			Desc:    fmt.Sprintf("log levels overriding log-level for individual subsystems, i.e. storage=debug,http=warn. Subsystems are %s", strings.Join(influxlogger.Subsystems, ", ")),
+		},
+		{
+			DestP:   &o.LogFormat,
+			Flag:    "log-format",
+			Default: o.LogFormat,
+			Desc:    "format of logs written to stdout: auto, logfmt or json. auto uses console formatting when stdout is a terminal, and logfmt otherwise",
+		},
+		{
+			DestP: &o.LogFile,
+			Flag:  "log-file",
+			Desc:  "path to a file to also write logs to",
+		},
+		{
+			DestP:   &o.LogFileFormat,
+			Flag:    "log-file-format",
+			Default: o.LogFileFormat,
+			Desc:    "format of logs written to log-file: logfmt or json",
+		},
 		{
 			DestP:   &o.FluxLogEnabled,
 			Flag:    "flux-log-enabled",
 			Default: o.FluxLogEnabled,
 			Desc:    "enables detailed logging for flux queries",
 		},
+		{
+			DestP: &o.FluxPackagesPath,
+			Flag:  "flux-packages-path",
+			Desc:  "directory of flux packages installed for organizations at startup, laid out as <orgID>/<name>/<version>.flux",
+		},
 		{
 			DestP: &o.TracingType,
 			Flag:  "tracing-type",
-			Desc:  fmt.Sprintf("supported tracing types are %s, %s", LogTracing, JaegerTracing),
+			Desc:  fmt.Sprintf("supported tracing types are %s, %s and %s. %s exports spans to an OpenTelemetry collector and supersedes %s", LogTracing, OTLPTracing, JaegerTracing, OTLPTracing, JaegerTracing),
+		},
+		{
+			DestP:   &o.TracingOTLP.Endpoint,
+			Flag:    "tracing-otlp-endpoint",
+			Default: o.TracingOTLP.Endpoint,
+			Desc:    "OTLP/HTTP endpoint of the OpenTelemetry collector spans are exported to when tracing-type is otlp. Spans are posted to /v1/traces unless the endpoint has a path",
+		},
+		{
+			DestP: &o.TracingOTLP.Headers,
+			Flag:  "tracing-otlp-headers",
+			Desc:  "headers of the requests exporting spans, such as authorization=Bearer <token>",
+		},
+		{
+			DestP:   &o.TracingOTLP.ServiceName,
+			Flag:    "tracing-otlp-service-name",
+			Default: o.TracingOTLP.ServiceName,
+			Desc:    "service.name of the exported spans",
+		},
+		{
+			DestP:   &o.TracingOTLP.SampleRatio,
+			Flag:    "tracing-otlp-sample-ratio",
+			Default: o.TracingOTLP.SampleRatio,
+			Desc:    "ratio, between 0 and 1, of the traces started by influxd which are sampled. Requests with a traceparent header follow the sampling of their caller",
 		},
 		{
 			DestP:   &o.BoltPath,
@@ -313,7 +579,7 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			DestP:   &o.SecretStore,
 			Flag:    "secret-store",
 			Default: o.SecretStore,
-			Desc:    "data store for secrets (bolt or vault)",
+			Desc:    "data store for secrets (bolt, vault, aws or gcp)",
 		},
 		{
 			DestP:   &o.ReportingDisabled,
@@ -321,6 +587,17 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Default: o.ReportingDisabled,
 			Desc:    "disable sending telemetry data to https://telemetry.influxdata.com every 8 hours",
 		},
+		{
+			DestP: &o.Telemetry.Categories,
+			Flag:  "telemetry-categories",
+			Desc:  "categories of the telemetry data sent: runtime, resources, requests, queries, writes, cardinality and disk. All are sent by default. The payload can be previewed at /api/v2/debug/telemetry",
+		},
+		{
+			DestP:   &o.Telemetry.URL,
+			Flag:    "telemetry-url",
+			Default: o.Telemetry.URL,
+			Desc:    "prometheus push gateway the telemetry data is sent to, such as an internal gateway",
+		},
 		{
 			DestP:   &o.SessionLength,
 			Flag:    "session-length",
@@ -333,6 +610,70 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Default: o.SessionRenewDisabled,
 			Desc:    "disables automatically extending session ttl on request",
 		},
+		{
+			DestP:   &o.SessionIdleTimeout,
+			Flag:    "session-idle-timeout",
+			Default: o.SessionIdleTimeout,
+			Desc:    "how far each request extends the ttl of its session, sessions unused for longer expire",
+		},
+		{
+			DestP:   &o.SessionMaxLifetime,
+			Flag:    "session-max-lifetime",
+			Default: o.SessionMaxLifetime,
+			Desc:    "how long sessions are valid for since they were created, however often they are extended. Sessions are extended indefinitely by default",
+		},
+		{
+			DestP:   &o.SessionMaxPerUser,
+			Flag:    "session-max-per-user",
+			Default: o.SessionMaxPerUser,
+			Desc:    "how many sessions a user has at once, the oldest sessions of the user are removed as it signs in again. Sessions are not limited by default, operators override the limit of users at /api/v2/session-policies/users/:id",
+		},
+		{
+			DestP:   &o.SessionRefreshLength,
+			Flag:    "session-refresh-length",
+			Default: o.SessionRefreshLength,
+			Desc:    "how long the refresh tokens issued at sign in are valid for, they are exchanged for new sessions at /api/v2/signin/refresh. Refresh tokens are disabled by default",
+		},
+		{
+			DestP: &o.SAML.IDPMetadataFile,
+			Flag:  "saml-idp-metadata-file",
+			Desc:  "path to the SAML metadata of the identity provider users sign in with. Enables SAML single sign-on at /api/v2/saml/login",
+		},
+		{
+			DestP: &o.SAML.RootURL,
+			Flag:  "saml-root-url",
+			Desc:  "external URL of influxd, such as https://influxdb.example.com, the identity provider posts its SAML assertions back to",
+		},
+		{
+			DestP: &o.SAML.EntityID,
+			Flag:  "saml-entity-id",
+			Desc:  "SAML entity ID of influxd, it defaults to the URL of its metadata at /api/v2/saml/metadata",
+		},
+		{
+			DestP: &o.SAML.UsernameAttribute,
+			Flag:  "saml-username-attribute",
+			Desc:  "SAML attribute users are named with, the NameID of the subject by default",
+		},
+		{
+			DestP: &o.SAML.OrgAttribute,
+			Flag:  "saml-org-attribute",
+			Desc:  "SAML attribute, such as groups, the organizations of users are mapped from with saml-org-mappings",
+		},
+		{
+			DestP: &o.SAML.OrgMappings,
+			Flag:  "saml-org-mappings",
+			Desc:  "mappings of the values of saml-org-attribute to organizations, as value=org or value=org:owner. The memberships of users in the mapped organizations follow their assertions",
+		},
+		{
+			DestP: &o.SAML.AllowIDPInitiated,
+			Flag:  "saml-allow-idp-initiated",
+			Desc:  "accept SAML assertions influxd did not request, as when users sign in from the portal of the identity provider",
+		},
+		{
+			DestP: &o.SAML.LinkExistingUsers,
+			Flag:  "saml-link-existing-users",
+			Desc:  "sign the users of SAML assertions in as the local users of the same name which were not created with SAML, such as the ones created before SAML was enabled. Operator users are never signed in with SAML",
+		},
 		{
 			DestP: &o.VaultConfig.Address,
 			Flag:  "vault-addr",
@@ -383,6 +724,111 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Flag:  "vault-token",
 			Desc:  "vault authentication token",
 		},
+		{
+			DestP: &o.VaultConfig.MountPath,
+			Flag:  "vault-mount-path",
+			Desc:  "path of the KV v2 secrets engine secrets are stored in. The default is secret.",
+		},
+		{
+			DestP: &o.VaultConfig.RoleID,
+			Flag:  "vault-role-id",
+			Desc:  "role ID to login to Vault with the AppRole auth method, instead of using the vault token.",
+		},
+		{
+			DestP: &o.VaultConfig.SecretID,
+			Flag:  "vault-secret-id",
+			Desc:  "secret ID of the AppRole to login to Vault with.",
+		},
+		{
+			DestP: &o.VaultConfig.AppRoleMountPath,
+			Flag:  "vault-approle-mount-path",
+			Desc:  "path of the AppRole auth method. The default is approle.",
+		},
+		{
+			DestP: &o.SecretRotationWebhooks,
+			Flag:  "secret-rotation-webhook-urls",
+			Desc:  "URLs the rotations of secrets are posted to, with the tasks and notification endpoints which use the secrets.",
+		},
+		{
+			DestP: &o.AWSSecrets.Region,
+			Flag:  "aws-secrets-region",
+			Desc:  "region of the AWS Secrets Manager secrets are stored in. The default is the AWS_REGION environment variable. The credentials are read from the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables.",
+		},
+		{
+			DestP: &o.AWSSecrets.Prefix,
+			Flag:  "aws-secrets-prefix",
+			Desc:  "prefix of the names of the AWS secrets of organizations. The default is influxdb-.",
+		},
+		{
+			DestP: &o.AWSSecrets.Endpoint,
+			Flag:  "aws-secrets-endpoint",
+			Desc:  "URL of the AWS Secrets Manager API, for VPC endpoints. The default is the endpoint of the region.",
+		},
+		{
+			DestP: &o.AWSSecrets.CacheTTL,
+			Flag:  "aws-secrets-cache-ttl",
+			Desc:  "how long the secrets of an organization are cached for. The default is 1m.",
+		},
+		{
+			DestP: &o.GCPSecrets.Project,
+			Flag:  "gcp-secrets-project",
+			Desc:  "GCP project of the Secret Manager secrets are stored in.",
+		},
+		{
+			DestP: &o.GCPSecrets.Prefix,
+			Flag:  "gcp-secrets-prefix",
+			Desc:  "prefix of the IDs of the GCP secrets of organizations. The default is influxdb-.",
+		},
+		{
+			DestP: &o.GCPSecrets.CredentialsFile,
+			Flag:  "gcp-secrets-credentials-file",
+			Desc:  "path to a service account key file. The default is the application default credentials.",
+		},
+		{
+			DestP: &o.GCPSecrets.CacheTTL,
+			Flag:  "gcp-secrets-cache-ttl",
+			Desc:  "how long the secrets of an organization are cached for. The default is 1m.",
+		},
+		{
+			DestP: &o.SecretEncryption.Provider,
+			Flag:  "secret-encryption",
+			Desc:  "encrypts the secrets of the bolt secret store with data keys wrapped by a local keyfile or a KMS key (local, aws or gcp). Secrets are not encrypted by default.",
+		},
+		{
+			DestP: &o.SecretEncryption.KeyFile,
+			Flag:  "secret-encryption-keyfile",
+			Desc:  "path to the keyfile of local secret encryption, with a base64 encoded 32 byte key per line. The last key is the current key, append a key to rotate it.",
+		},
+		{
+			DestP: &o.SecretEncryption.AWS.KeyID,
+			Flag:  "secret-encryption-aws-kms-key-id",
+			Desc:  "ID, ARN or alias of the AWS KMS key of aws secret encryption. The credentials are read from the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables.",
+		},
+		{
+			DestP: &o.SecretEncryption.AWS.Region,
+			Flag:  "secret-encryption-aws-kms-region",
+			Desc:  "region of the AWS KMS key. The default is the AWS_REGION environment variable.",
+		},
+		{
+			DestP: &o.SecretEncryption.AWS.Endpoint,
+			Flag:  "secret-encryption-aws-kms-endpoint",
+			Desc:  "URL of the AWS KMS API, for VPC endpoints. The default is the endpoint of the region.",
+		},
+		{
+			DestP: &o.SecretEncryption.GCP.KeyName,
+			Flag:  "secret-encryption-gcp-kms-key",
+			Desc:  "name of the GCP Cloud KMS crypto key of gcp secret encryption, such as projects/p/locations/l/keyRings/r/cryptoKeys/k.",
+		},
+		{
+			DestP: &o.SecretEncryption.GCP.CredentialsFile,
+			Flag:  "secret-encryption-gcp-credentials-file",
+			Desc:  "path to a service account key file. The default is the application default credentials.",
+		},
+		{
+			DestP: &o.SecretEncryption.DataKeyMaxAge,
+			Flag:  "secret-encryption-data-key-max-age",
+			Desc:  "age of the data key secrets are encrypted with after which it is rotated as influxd starts, and the secrets are encrypted again. The data key is not rotated by default.",
+		},
 
 		// HTTP options
 		{
@@ -391,6 +837,22 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Default: o.HttpBindAddress,
 			Desc:    "bind address for the REST HTTP API",
 		},
+		{
+			DestP: &o.AdminBindAddress,
+			Flag:  "admin-bind-address",
+			Desc:  "bind address for administrative endpoints (/metrics, /debug/pprof, /health, /ready), either host:port or unix:///path/to/socket. If set, /metrics and /debug are no longer served on http-bind-address",
+		},
+		{
+			DestP: &o.HttpUnixSocket,
+			Flag:  "http-unix-socket",
+			Desc:  "path to a unix domain socket on which to serve the full API, in addition to http-bind-address",
+		},
+		{
+			DestP:   &o.HttpUnixSocketUIDs,
+			Flag:    "http-unix-socket-uids",
+			Default: o.HttpUnixSocketUIDs,
+			Desc:    "uids of local processes granted operator access over http-unix-socket without a token (Linux only). Defaults to the uid running influxd; requests from other uids must authenticate with a token",
+		},
 		{
 			DestP:   &o.HttpReadHeaderTimeout,
 			Flag:    "http-read-header-timeout",
@@ -415,6 +877,30 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Default: o.HttpIdleTimeout,
 			Desc:    "max duration the server should keep established connections alive while waiting for new requests. Set to 0 for no timeout",
 		},
+		{
+			DestP:   &o.HttpShutdownTimeout,
+			Flag:    "http-shutdown-timeout",
+			Default: o.HttpShutdownTimeout,
+			Desc:    "max duration to wait for in-flight HTTP requests to complete during shutdown. Set to 0 to wait indefinitely",
+		},
+		{
+			DestP:   &o.TaskShutdownTimeout,
+			Flag:    "task-shutdown-timeout",
+			Default: o.TaskShutdownTimeout,
+			Desc:    "max duration to wait for the task scheduler to stop during shutdown. Set to 0 to wait indefinitely",
+		},
+		{
+			DestP:   &o.StorageShutdownTimeout,
+			Flag:    "storage-shutdown-timeout",
+			Default: o.StorageShutdownTimeout,
+			Desc:    "max duration to wait for the storage engine to flush and close during shutdown. Set to 0 to wait indefinitely, which avoids interrupting in-progress compactions",
+		},
+		{
+			DestP:   &o.ShutdownTimeout,
+			Flag:    "shutdown-timeout",
+			Default: o.ShutdownTimeout,
+			Desc:    "max duration to wait for each remaining subsystem to stop during shutdown. Set to 0 to wait indefinitely",
+		},
 		{
 			DestP: &o.HttpTLSCert,
 			Flag:  "tls-cert",
@@ -437,6 +923,44 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Default: o.HttpTLSStrictCiphers,
 			Desc:    "Restrict accept ciphers to: ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, ECDHE_RSA_WITH_AES_128_GCM_SHA256, ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, ECDHE_RSA_WITH_AES_256_GCM_SHA384, ECDHE_ECDSA_WITH_CHACHA20_POLY1305, ECDHE_RSA_WITH_CHACHA20_POLY1305",
 		},
+		{
+			DestP:   &o.HttpTLSReloadInterval,
+			Flag:    "tls-reload-interval",
+			Default: o.HttpTLSReloadInterval,
+			Desc:    "how often to check tls-cert and tls-key for changes, reloading them without a restart when they are replaced. Set to 0 to disable",
+		},
+		{
+			DestP: &o.HttpTLSACME.Domains,
+			Flag:  "tls-acme-domains",
+			Desc:  "domains to obtain and renew TLS certificates for using ACME (e.g. Let's Encrypt). Cannot be combined with tls-cert and tls-key",
+		},
+		{
+			DestP: &o.HttpTLSACME.Email,
+			Flag:  "tls-acme-email",
+			Desc:  "contact email registered with the ACME account, used to notify about problems with issued certificates",
+		},
+		{
+			DestP:   &o.HttpTLSACME.AcceptTOS,
+			Flag:    "tls-acme-accept-tos",
+			Default: o.HttpTLSACME.AcceptTOS,
+			Desc:    "accept the terms of service of the ACME certificate authority. Required when tls-acme-domains is set",
+		},
+		{
+			DestP:   &o.HttpTLSACME.CacheDir,
+			Flag:    "tls-acme-cache-dir",
+			Default: o.HttpTLSACME.CacheDir,
+			Desc:    "directory in which ACME account keys and issued certificates are stored",
+		},
+		{
+			DestP: &o.HttpTLSACME.DirectoryURL,
+			Flag:  "tls-acme-directory-url",
+			Desc:  "ACME directory endpoint of the certificate authority. Defaults to Let's Encrypt production",
+		},
+		{
+			DestP: &o.HttpTLSACME.HTTPChallengeAddress,
+			Flag:  "tls-acme-http-challenge-address",
+			Desc:  "bind address (e.g. :80) for answering ACME HTTP-01 challenges. If unset, only the TLS-ALPN-01 challenge is answered, on http-bind-address",
+		},
 
 		{
 			DestP:   &o.NoTasks,
@@ -474,6 +998,18 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Default: o.QueueSize,
 			Desc:    "the number of queries that are allowed to be awaiting execution before new queries are rejected. Must be > 0 if query-concurrency is not unlimited",
 		},
+		{
+			DestP:   &o.OrgMemory.OrgBytes,
+			Flag:    "org-memory-budget-bytes",
+			Default: o.OrgMemory.OrgBytes,
+			Desc:    "the number of bytes the queries and writes of each organization may use at once. Set to 0 to not budget the memory of organizations",
+		},
+		{
+			DestP:   &o.OrgMemory.QueueTimeout,
+			Flag:    "org-memory-budget-queue-timeout",
+			Default: o.OrgMemory.QueueTimeout,
+			Desc:    "how long queries and writes wait for the memory budget of their organization before they are rejected",
+		},
 		{
 			DestP: &o.FeatureFlags,
 			Flag:  "feature-flags",
@@ -485,6 +1021,12 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Default: "",
 			Desc:    "add an instance id for replications to prevent collisions and allow querying by edge node",
 		},
+		{
+			DestP:   &o.DashboardRevisionRetention,
+			Flag:    "dashboard-revision-retention",
+			Default: o.DashboardRevisionRetention,
+			Desc:    "number of revisions kept in each dashboard's history. 0 keeps every revision",
+		},
 
 		// storage configuration
 		{
@@ -634,6 +1176,208 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Desc:    "Don't expose metrics over HTTP at /metrics",
 			Default: o.MetricsDisabled,
 		},
+		{
+			DestP: &o.MetricsFilter.EnabledSubsystems,
+			Flag:  "metrics-enabled-subsystems",
+			Desc:  "subsystems of the only metric families exposed at /metrics, such as storage, go or http_api. The subsystem of a family is a prefix of its name ending before an underscore",
+		},
+		{
+			DestP: &o.MetricsFilter.DisabledSubsystems,
+			Flag:  "metrics-disabled-subsystems",
+			Desc:  "subsystems of the metric families not exposed at /metrics, such as storage_tsm or boltdb",
+		},
+		{
+			DestP:   &o.MetricsFilter.ReducedCardinality,
+			Flag:    "metrics-reduced-cardinality",
+			Default: o.MetricsFilter.ReducedCardinality,
+			Desc:    "sum the metrics exposed at /metrics over the bucket, engine, id, path, walPath, partition and shard labels, and only expose the count and sum of histograms and summaries",
+		},
+		{
+			DestP:   &o.SLO.Enabled,
+			Flag:    "slo-metrics-enabled",
+			Default: o.SLO.Enabled,
+			Desc:    "expose the requests to the write and query endpoints which met their latency objectives, and the burn rates of the error budgets of the objectives by organization, at /metrics",
+		},
+		{
+			DestP:   &o.SLO.Write.Latency,
+			Flag:    "slo-write-latency",
+			Default: o.SLO.Write.Latency,
+			Desc:    "latency within which a write must be served to meet its objective",
+		},
+		{
+			DestP:   &o.SLO.Write.Target,
+			Flag:    "slo-write-target",
+			Default: o.SLO.Write.Target,
+			Desc:    "ratio of writes which must meet the write latency objective",
+		},
+		{
+			DestP:   &o.SLO.Query.Latency,
+			Flag:    "slo-query-latency",
+			Default: o.SLO.Query.Latency,
+			Desc:    "latency within which a query must be served to meet its objective",
+		},
+		{
+			DestP:   &o.SLO.Query.Target,
+			Flag:    "slo-query-target",
+			Default: o.SLO.Query.Target,
+			Desc:    "ratio of queries which must meet the query latency objective",
+		},
+		{
+			DestP:   &o.SLO.MaxOrgs,
+			Flag:    "slo-metrics-max-orgs",
+			Default: o.SLO.MaxOrgs,
+			Desc:    "maximum number of organizations with service level metrics of their own, the requests of the others are labeled other. 0 is no maximum",
+		},
+		{
+			DestP:   &o.OrgMetrics.Enabled,
+			Flag:    "org-metrics-enabled",
+			Default: o.OrgMetrics.Enabled,
+			Desc:    "expose the bytes and points written, queries, storage bytes and series cardinality of each organization at /metrics",
+		},
+		{
+			DestP:   &o.OrgMetrics.MaxOrgs,
+			Flag:    "org-metrics-max-orgs",
+			Default: o.OrgMetrics.MaxOrgs,
+			Desc:    "number of organizations with metrics of their own, the usage of further organizations is reported with org_id=\"other\". 0 is unbounded",
+		},
+		{
+			DestP: &o.OrgMetrics.Orgs,
+			Flag:  "org-metrics-orgs",
+			Desc:  "IDs of the only organizations with metrics of their own, overriding org-metrics-max-orgs",
+		},
+		{
+			DestP:   &o.OrgMetrics.Interval,
+			Flag:    "org-metrics-interval",
+			Default: o.OrgMetrics.Interval,
+			Desc:    "how often the storage bytes and series cardinality of organizations are measured",
+		},
+		{
+			DestP:   &o.GapAnnotations.Enabled,
+			Flag:    "gap-annotations-enabled",
+			Default: o.GapAnnotations.Enabled,
+			Desc:    "annotate the times the measurements of buckets received no writes for longer than gap-annotations-window, in the gaps annotation stream",
+		},
+		{
+			DestP:   &o.GapAnnotations.Window,
+			Flag:    "gap-annotations-window",
+			Default: o.GapAnnotations.Window,
+			Desc:    "how long a measurement must receive no writes for before the time without writes is annotated",
+		},
+		{
+			DestP: &o.GapAnnotations.Measurements,
+			Flag:  "gap-annotations-measurements",
+			Desc:  "regular expression matching the names of the only measurements whose gaps are annotated",
+		},
+		{
+			DestP: &o.Replica.PrimaryURL,
+			Flag:  "replica-primary-url",
+			Desc:  "URL of the primary server this server is a read-only replica of. The data is written by the replication streams of the primary, and its organizations and buckets are synced every replica-sync-interval",
+		},
+		{
+			DestP: &o.Replica.PrimaryToken,
+			Flag:  "replica-primary-token",
+			Desc:  "token the organizations and buckets of the primary are read with",
+		},
+		{
+			DestP:   &o.Replica.InsecureSkipVerify,
+			Flag:    "replica-primary-skip-verify",
+			Default: o.Replica.InsecureSkipVerify,
+			Desc:    "skip the verification of the TLS certificate of the primary",
+		},
+		{
+			DestP:   &o.Replica.SyncInterval,
+			Flag:    "replica-sync-interval",
+			Default: o.Replica.SyncInterval,
+			Desc:    "how often the organizations and buckets of the primary are synced",
+		},
+		{
+			DestP: &o.Replica.WriterUser,
+			Flag:  "replica-writer-user",
+			Desc:  "name of the user of the replica whose tokens the replication streams of the primary write with. The writes of every other user are rejected",
+		},
+		{
+			DestP:   &o.CDC.Enabled,
+			Flag:    "cdc-enabled",
+			Default: o.CDC.Enabled,
+			Desc:    "allow the clients allowed to read a bucket to subscribe to its writes at /api/v2/subscribe",
+		},
+		{
+			DestP:   &o.CDC.Buffer,
+			Flag:    "cdc-buffer",
+			Default: o.CDC.Buffer,
+			Desc:    "number of writes buffered for a subscriber, which is disconnected when it falls further behind",
+		},
+		{
+			DestP:   &o.CDC.MaxSubscriptions,
+			Flag:    "cdc-max-subscriptions",
+			Default: o.CDC.MaxSubscriptions,
+			Desc:    "maximum number of subscriptions to the writes to buckets. 0 is unlimited",
+		},
+		{
+			DestP:   &o.SelfMonitoring.Enabled,
+			Flag:    "self-monitoring-enabled",
+			Default: o.SelfMonitoring.Enabled,
+			Desc:    "periodically write the engine, kv, task and HTTP statistics exposed at /metrics into the _internal system bucket",
+		},
+		{
+			DestP:   &o.SelfMonitoring.Interval,
+			Flag:    "self-monitoring-interval",
+			Default: o.SelfMonitoring.Interval,
+			Desc:    "how often the statistics are written into the _internal system bucket",
+		},
+		{
+			DestP:   &o.SelfMonitoring.Retention,
+			Flag:    "self-monitoring-retention",
+			Default: o.SelfMonitoring.Retention,
+			Desc:    "retention period of the _internal system bucket. 0 is infinite",
+		},
+		{
+			DestP: &o.SelfMonitoring.Org,
+			Flag:  "self-monitoring-org",
+			Desc:  "name of the organization of the _internal system bucket, defaults to the first organization",
+		},
+		{
+			DestP:   &o.SelfMonitoring.Cardinality.Enabled,
+			Flag:    "cardinality-check-enabled",
+			Default: o.SelfMonitoring.Cardinality.Enabled,
+			Desc:    "measure the series of every bucket into the _internal system bucket and manage a check alerting on buckets whose series grow too fast. Requires self-monitoring-enabled",
+		},
+		{
+			DestP:   &o.SelfMonitoring.Cardinality.Every,
+			Flag:    "cardinality-check-interval",
+			Default: o.SelfMonitoring.Cardinality.Every,
+			Desc:    "how often the series of the buckets are measured and checked",
+		},
+		{
+			DestP:   &o.SelfMonitoring.Cardinality.Warn,
+			Flag:    "cardinality-check-warn-growth",
+			Default: o.SelfMonitoring.Cardinality.Warn,
+			Desc:    "new series per hour of a bucket above which the cardinality check is warn. 0 disables the level",
+		},
+		{
+			DestP:   &o.SelfMonitoring.Cardinality.Crit,
+			Flag:    "cardinality-check-crit-growth",
+			Default: o.SelfMonitoring.Cardinality.Crit,
+			Desc:    "new series per hour of a bucket above which the cardinality check is crit. 0 disables the level",
+		},
+		{
+			DestP:   &o.EventLogRetention,
+			Flag:    "event-log-retention",
+			Default: o.EventLogRetention,
+			Desc:    "how long the startups, configuration changes, compaction failures and migrations of the server are kept in the event log served at /api/v2/debug/events. 0 keeps them forever",
+		},
+		{
+			DestP:   &o.DBRPAutoCreate.Enabled,
+			Flag:    "v1-dbrp-auto-create",
+			Default: o.DBRPAutoCreate.Enabled,
+			Desc:    "auto-create the bucket and DBRP mapping of the unmapped databases v1 writes are to, for writers with write access to all the buckets of their organization. Overridden by the settings of organizations at /api/v2/dbrp-auto-create",
+		},
+		{
+			DestP:   &o.DBRPAutoCreate.RetentionPeriod,
+			Flag:    "v1-dbrp-auto-create-retention",
+			Default: o.DBRPAutoCreate.RetentionPeriod,
+			Desc:    "retention period of the buckets auto-created by v1 writes. 0 is infinite",
+		},
 		// UI Config
 		{
 			DestP:   &o.UIDisabled,
@@ -665,6 +1409,65 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Default: o.StrongPasswords,
 			Desc:    "enable password strength enforcement",
 		},
+		{
+			DestP:   &o.PasswordPolicy.MinLength,
+			Flag:    "password-min-length",
+			Default: o.PasswordPolicy.MinLength,
+			Desc:    "minimum length of the passwords of users and v1 authorizations",
+		},
+		{
+			DestP:   &o.PasswordPolicy.MinCharClasses,
+			Flag:    "password-min-char-classes",
+			Default: o.PasswordPolicy.MinCharClasses,
+			Desc:    "minimum number of uppercase, lowercase, number and special character classes in passwords. strong-passwords raises it to 3",
+		},
+		{
+			DestP: &o.PasswordPolicy.Banned,
+			Flag:  "password-banned",
+			Desc:  "passwords which may not be used, compared case-insensitively",
+		},
+		{
+			DestP:   &o.PasswordPolicy.MaxAge,
+			Flag:    "password-max-age",
+			Default: o.PasswordPolicy.MaxAge,
+			Desc:    "how long a password may be used before it must be changed. 0 never expires passwords",
+		},
+		{
+			DestP:   &o.V1TokenHashing,
+			Flag:    "v1-token-hashing",
+			Default: o.V1TokenHashing,
+			Desc:    "store only the SHA-256 hash of the tokens of new v1 authorizations; existing tokens are hashed by 'influxd recovery auth hash-v1-tokens'",
+		},
+		{
+			DestP:   &o.V1PasswordLockoutThreshold,
+			Flag:    "v1-password-lockout-threshold",
+			Default: o.V1PasswordLockoutThreshold,
+			Desc:    "number of consecutive failed password attempts after which a v1 authorization is locked. 0 never locks authorizations",
+		},
+		{
+			DestP:   &o.V1PasswordLockoutWindow,
+			Flag:    "v1-password-lockout-window",
+			Default: o.V1PasswordLockoutWindow,
+			Desc:    "how long a v1 authorization stays locked after too many failed password attempts; 'influxd recovery user unlock' unlocks it sooner",
+		},
+		{
+			DestP:   &o.V1AuthCacheSize,
+			Flag:    "v1-auth-cache-size",
+			Default: o.V1AuthCacheSize,
+			Desc:    "maximum number of v1 authorizations cached by token. 0 disables the cache",
+		},
+		{
+			DestP:   &o.V1AuthCacheTTL,
+			Flag:    "v1-auth-cache-ttl",
+			Default: o.V1AuthCacheTTL,
+			Desc:    "how long a v1 authorization stays cached by token",
+		},
+		{
+			DestP:   &o.V1OrgTokenQuota,
+			Flag:    "v1-org-token-quota",
+			Default: o.V1OrgTokenQuota,
+			Desc:    "maximum number of v1 authorizations of an org without a quota of its own, set by 'influxd recovery auth v1-token-quota'. 0 is unlimited",
+		},
 	}
 }
 
@@ -711,3 +1514,13 @@ func inOneDotExFlagsList(key string) bool {
 
 	return false
 }
+
+// passwordPolicy returns the policy of the passwords of users and v1
+// authorizations, with the character classes of strong-passwords.
+func (o *InfluxdOpts) passwordPolicy() tenant.PasswordPolicy {
+	policy := o.PasswordPolicy
+	if strong := tenant.StrongPasswordPolicy(); o.StrongPasswords && policy.MinCharClasses < strong.MinCharClasses {
+		policy.MinCharClasses = strong.MinCharClasses
+	}
+	return policy
+}
@@ -32,6 +32,7 @@ type Engine interface {
 	influxdb.RestoreService
 
 	SeriesCardinality(ctx context.Context, bucketID platform.ID) int64
+	BucketDiskSize(ctx context.Context, bucketID platform.ID) int64
 
 	TSDBStore() storage.TSDBStore
 	MetaClient() storage.MetaClient
@@ -120,6 +121,11 @@ func (t *TemporaryEngine) SeriesCardinality(ctx context.Context, bucketID platfo
 	return t.engine.SeriesCardinality(ctx, bucketID)
 }
 
+// BucketDiskSize returns the number of bytes the shards of a bucket use on disk.
+func (t *TemporaryEngine) BucketDiskSize(ctx context.Context, bucketID platform.ID) int64 {
+	return t.engine.BucketDiskSize(ctx, bucketID)
+}
+
 // DeleteBucketRangePredicate will delete a bucket from the range and predicate.
 func (t *TemporaryEngine) DeleteBucketRangePredicate(ctx context.Context, orgID, bucketID platform.ID, min, max int64, pred influxdb.Predicate, measurement influxql.Expr) error {
 	return t.engine.DeleteBucketRangePredicate(ctx, orgID, bucketID, min, max, pred, measurement)
@@ -9,6 +9,7 @@ import (
 	nethttp "net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -18,18 +19,32 @@ import (
 	"github.com/influxdata/flux/dependencies/url"
 	"github.com/influxdata/flux/execute/executetest"
 	platform "github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/alert"
+	alertTransport "github.com/influxdata/influxdb/v2/alert/transport"
 	"github.com/influxdata/influxdb/v2/annotations"
 	annotationTransport "github.com/influxdata/influxdb/v2/annotations/transport"
 	"github.com/influxdata/influxdb/v2/authorization"
 	"github.com/influxdata/influxdb/v2/authorizer"
 	"github.com/influxdata/influxdb/v2/backup"
 	"github.com/influxdata/influxdb/v2/bolt"
+	"github.com/influxdata/influxdb/v2/bucketstats"
+	"github.com/influxdata/influxdb/v2/cdc"
 	"github.com/influxdata/influxdb/v2/checks"
+	checkTransport "github.com/influxdata/influxdb/v2/checks/transport"
+	platcontext "github.com/influxdata/influxdb/v2/context"
 	"github.com/influxdata/influxdb/v2/dashboards"
 	dashboardTransport "github.com/influxdata/influxdb/v2/dashboards/transport"
 	"github.com/influxdata/influxdb/v2/dbrp"
+	"github.com/influxdata/influxdb/v2/deletejob"
+	"github.com/influxdata/influxdb/v2/deletepolicy"
+	deletePolicyTransport "github.com/influxdata/influxdb/v2/deletepolicy/transport"
+	"github.com/influxdata/influxdb/v2/eventlog"
+	"github.com/influxdata/influxdb/v2/featureflag"
+	"github.com/influxdata/influxdb/v2/fluxpkg"
+	fluxPackageTransport "github.com/influxdata/influxdb/v2/fluxpkg/transport"
 	"github.com/influxdata/influxdb/v2/gather"
 	"github.com/influxdata/influxdb/v2/http"
+	httpmetric "github.com/influxdata/influxdb/v2/http/metric"
 	iqlcontrol "github.com/influxdata/influxdb/v2/influxql/control"
 	iqlquery "github.com/influxdata/influxdb/v2/influxql/query"
 	"github.com/influxdata/influxdb/v2/inmem"
@@ -40,16 +55,22 @@ import (
 	platform2 "github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/prom"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/kit/tracing/otlp"
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"github.com/influxdata/influxdb/v2/kv"
 	"github.com/influxdata/influxdb/v2/kv/migration"
 	"github.com/influxdata/influxdb/v2/kv/migration/all"
 	"github.com/influxdata/influxdb/v2/label"
+	influxlogger "github.com/influxdata/influxdb/v2/logger"
+	"github.com/influxdata/influxdb/v2/membudget"
 	"github.com/influxdata/influxdb/v2/notebooks"
 	notebookTransport "github.com/influxdata/influxdb/v2/notebooks/transport"
+	"github.com/influxdata/influxdb/v2/notification/delivery"
+	awsendpoint "github.com/influxdata/influxdb/v2/notification/endpoint/aws"
 	endpointservice "github.com/influxdata/influxdb/v2/notification/endpoint/service"
 	ruleservice "github.com/influxdata/influxdb/v2/notification/rule/service"
 	"github.com/influxdata/influxdb/v2/pkger"
+	"github.com/influxdata/influxdb/v2/pprof"
 	infprom "github.com/influxdata/influxdb/v2/prometheus"
 	"github.com/influxdata/influxdb/v2/query"
 	"github.com/influxdata/influxdb/v2/query/control"
@@ -57,10 +78,19 @@ import (
 	"github.com/influxdata/influxdb/v2/query/stdlib/influxdata/influxdb"
 	"github.com/influxdata/influxdb/v2/remotes"
 	remotesTransport "github.com/influxdata/influxdb/v2/remotes/transport"
+	"github.com/influxdata/influxdb/v2/replica"
 	"github.com/influxdata/influxdb/v2/replications"
 	replicationTransport "github.com/influxdata/influxdb/v2/replications/transport"
+	"github.com/influxdata/influxdb/v2/report"
+	reportTransport "github.com/influxdata/influxdb/v2/report/transport"
 	"github.com/influxdata/influxdb/v2/secret"
+	"github.com/influxdata/influxdb/v2/secret/cloud"
+	"github.com/influxdata/influxdb/v2/secret/kms"
+	"github.com/influxdata/influxdb/v2/selfmonitor"
 	"github.com/influxdata/influxdb/v2/session"
+	"github.com/influxdata/influxdb/v2/session/saml"
+	"github.com/influxdata/influxdb/v2/silence"
+	silenceTransport "github.com/influxdata/influxdb/v2/silence/transport"
 	"github.com/influxdata/influxdb/v2/snowflake"
 	"github.com/influxdata/influxdb/v2/source"
 	"github.com/influxdata/influxdb/v2/sqlite"
@@ -77,6 +107,10 @@ import (
 	telegrafservice "github.com/influxdata/influxdb/v2/telegraf/service"
 	"github.com/influxdata/influxdb/v2/telemetry"
 	"github.com/influxdata/influxdb/v2/tenant"
+	"github.com/influxdata/influxdb/v2/transferjob"
+	transferJobTransport "github.com/influxdata/influxdb/v2/transferjob/transport"
+	"github.com/influxdata/influxdb/v2/usage"
+	"github.com/influxdata/influxdb/v2/variable"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 
 	// needed for tsm1
@@ -86,13 +120,18 @@ import (
 	_ "github.com/influxdata/influxdb/v2/tsdb/index/tsi1"
 	authv1 "github.com/influxdata/influxdb/v2/v1/authorization"
 	iqlcoordinator "github.com/influxdata/influxdb/v2/v1/coordinator"
+	"github.com/influxdata/influxdb/v2/v1/services/continuousquery"
 	"github.com/influxdata/influxdb/v2/v1/services/meta"
 	storage2 "github.com/influxdata/influxdb/v2/v1/services/storage"
+	"github.com/influxdata/influxdb/v2/v1/services/subscription"
+	"github.com/influxdata/influxdb/v2/v1/services/user"
 	"github.com/influxdata/influxdb/v2/vault"
 	pzap "github.com/influxdata/influxdb/v2/zap"
 	"github.com/opentracing/opentracing-go"
 	jaegerconfig "github.com/uber/jaeger-client-go/config"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
 )
 
 const (
@@ -107,11 +146,38 @@ const (
 	LogTracing = "log"
 	// JaegerTracing enables tracing via the Jaeger client library
 	JaegerTracing = "jaeger"
+	// OTLPTracing enables tracing via export to an OpenTelemetry collector
+	OTLPTracing = "otlp"
 )
 
+// recentLogEntries is the number of log entries retained for debug bundles.
+const recentLogEntries = 1000
+
 type labeledCloser struct {
 	label  string
 	closer func(context.Context) error
+	// timeout bounds how long shutdown waits for closer to return.
+	// A zero timeout waits indefinitely.
+	timeout time.Duration
+}
+
+// close runs the closer, giving up once its timeout has elapsed. Closers which
+// ignore their context are left to finish in the background.
+func (lc labeledCloser) close(ctx context.Context) error {
+	if lc.timeout <= 0 {
+		return lc.closer(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, lc.timeout)
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() { errCh <- lc.closer(ctx) }()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("%s did not stop within %s", lc.label, lc.timeout)
+	}
 }
 
 // Launcher represents the main program execution.
@@ -127,12 +193,16 @@ type Launcher struct {
 	kvStore   kv.Store
 	kvService *kv.Service
 	sqlStore  *sqlite.SqlStore
+	eventLog  *eventlog.Service
 
 	// storage engine
 	engine Engine
 
 	// InfluxQL query engine
 	queryController *control.Controller
+	// orgMemory budgets the memory of the queries and writes of each org, if
+	// enabled.
+	orgMemory *membudget.Accountant
 
 	httpPort   int
 	tlsEnabled bool
@@ -140,8 +210,20 @@ type Launcher struct {
 	scheduler stoppingScheduler
 	executor  *executor.Executor
 
-	log *zap.Logger
-	reg *prom.Registry
+	log      *zap.Logger
+	logLevel zap.AtomicLevel
+	reg      *prom.Registry
+	// levels controls the log level of each subsystem, defaulting to logLevel.
+	levels *influxlogger.Levels
+	// recentLogs retains recent log entries for inclusion in debug bundles.
+	recentLogs *influxlogger.RecentLogs
+
+	// opts are the options the launcher is running with, updated in place by
+	// configuration reloads.
+	opts     *InfluxdOpts
+	reloadMu sync.Mutex
+	certs    *certLoader
+	acme     *autocert.Manager
 
 	apibackend *http.APIBackend
 }
@@ -153,8 +235,12 @@ type stoppingScheduler interface {
 
 // NewLauncher returns a new instance of Launcher with a no-op logger.
 func NewLauncher() *Launcher {
+	logLevel := zap.NewAtomicLevel()
 	return &Launcher{
-		log: zap.NewNop(),
+		log:        zap.NewNop(),
+		logLevel:   logLevel,
+		levels:     influxlogger.NewLevels(logLevel, influxlogger.Subsystems...),
+		recentLogs: influxlogger.NewRecentLogs(recentLogEntries),
 	}
 }
 
@@ -163,6 +249,12 @@ func (m *Launcher) Registry() *prom.Registry {
 	return m.reg
 }
 
+// subsystemLogger returns a logger whose level can be changed independently
+// of the rest of the server.
+func (m *Launcher) subsystemLogger(subsystem string) *zap.Logger {
+	return m.levels.Logger(m.log, subsystem)
+}
+
 // Engine returns a reference to the storage engine. It should only be called
 // for end-to-end testing purposes.
 func (m *Launcher) Engine() Engine {
@@ -176,8 +268,8 @@ func (m *Launcher) Shutdown(ctx context.Context) error {
 	// Shut down subsystems in the reverse order of their registration.
 	for i := len(m.closers); i > 0; i-- {
 		lc := m.closers[i-1]
-		m.log.Info("Stopping subsystem", zap.String("subsystem", lc.label))
-		if err := lc.closer(ctx); err != nil {
+		m.log.Info("Stopping subsystem", zap.String("subsystem", lc.label), zap.Duration("timeout", lc.timeout))
+		if err := lc.close(ctx); err != nil {
 			m.log.Error("Failed to stop subsystem", zap.String("subsystem", lc.label), zap.Error(err))
 			errs = append(errs, err.Error())
 		}
@@ -198,6 +290,19 @@ func (m *Launcher) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// ShutdownTimeout returns the longest time Shutdown can take, and false if
+// any subsystem is allowed to take as long as it needs to stop.
+func (m *Launcher) ShutdownTimeout() (time.Duration, bool) {
+	var total time.Duration
+	for _, lc := range m.closers {
+		if lc.timeout <= 0 {
+			return 0, false
+		}
+		total += lc.timeout
+	}
+	return total, true
+}
+
 func (m *Launcher) Done() <-chan struct{} {
 	return m.doneChan
 }
@@ -208,6 +313,7 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 
 	ctx, m.cancel = context.WithCancel(ctx)
 	m.doneChan = ctx.Done()
+	m.opts = opts
 
 	info := platform.GetBuildInfo()
 	m.log.Info("Welcome to InfluxDB",
@@ -258,8 +364,50 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 	}
 	m.reg.MustRegister(infprom.NewInfluxCollector(procID, info))
 
+	if err := m.eventLog.RecordEvent(ctx, eventlog.Event{
+		Type:    eventlog.TypeStartup,
+		Message: "Server started",
+		Fields: map[string]string{
+			"version":    info.Version,
+			"commit":     info.Commit,
+			"build_date": info.Date,
+		},
+	}); err != nil {
+		m.log.Warn("Failed to record the startup in the event log", zap.Error(err))
+	}
+	if values, err := optValues(opts); err != nil {
+		m.log.Warn("Failed to encode the configuration", zap.Error(err))
+	} else if err := m.eventLog.RecordConfigChanges(ctx, values); err != nil {
+		m.log.Warn("Failed to record the configuration changes in the event log", zap.Error(err))
+	}
+	eventLogCtx, stopEventLog := context.WithCancel(ctx)
+	go m.eventLog.Run(eventLogCtx, time.Hour)
+	m.closers = append(m.closers, labeledCloser{
+		label:   "event log",
+		timeout: opts.ShutdownTimeout,
+		closer: func(ctx context.Context) error {
+			stopEventLog()
+			return m.eventLog.RecordEvent(ctx, eventlog.Event{
+				Type:    eventlog.TypeShutdown,
+				Message: "Server stopped",
+			})
+		},
+	})
+
+	// Apply feature flag overrides set at runtime on top of any set at startup.
+	runtimeFlagger, err := featureflag.NewFlagger(ctx, m.log.With(zap.String("service", "feature_flags")), featureflag.NewStore(m.kvStore), m.flagger)
+	if err != nil {
+		m.log.Error("Failed to load feature flag overrides", zap.Error(err))
+		return err
+	}
+	m.flagger = runtimeFlagger
+
+	if err := opts.PasswordPolicy.Valid(); err != nil {
+		m.log.Error("Invalid password policy", zap.Error(err))
+		return err
+	}
 	tenantStore := tenant.NewStore(m.kvStore)
-	ts := tenant.NewSystem(tenantStore, m.log.With(zap.String("store", "new")), m.reg, opts.StrongPasswords, metric.WithSuffix("new"))
+	ts := tenant.NewSystem(tenantStore, m.log.With(zap.String("store", "new")), m.reg, opts.passwordPolicy(), metric.WithSuffix("new"))
 
 	serviceConfig := kv.ServiceConfig{
 		FluxLanguageService: fluxlang.DefaultService,
@@ -289,10 +437,24 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		authSvc = authorization.NewService(authStore, ts)
 	}
 
-	secretStore, err := secret.NewStore(m.kvStore)
-	if err != nil {
-		m.log.Error("Failed creating new secret store", zap.Error(err))
-		return err
+	var secretStore *secret.Storage
+	if opts.SecretEncryption.Enabled() {
+		wrapper, err := kms.New(ctx, opts.SecretEncryption)
+		if err != nil {
+			m.log.Error("Failed initializing secret encryption", zap.Error(err))
+			return err
+		}
+		secretStore, err = secret.NewEncryptedStore(ctx, m.kvStore, wrapper, opts.SecretEncryption.DataKeyMaxAge)
+		if err != nil {
+			m.log.Error("Failed creating new encrypted secret store", zap.Error(err))
+			return err
+		}
+	} else {
+		secretStore, err = secret.NewStore(m.kvStore)
+		if err != nil {
+			m.log.Error("Failed creating new secret store", zap.Error(err))
+			return err
+		}
 	}
 
 	var secretSvc platform.SecretService = secret.NewMetricService(m.reg, secret.NewLogger(m.log.With(zap.String("service", "secret")), secret.NewService(secretStore)))
@@ -308,13 +470,83 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 			m.log.Error("Failed initializing vault secret service", zap.Error(err))
 			return err
 		}
+		renewCtx, stopRenewal := context.WithCancel(ctx)
+		go func() {
+			if err := svc.RenewToken(renewCtx); err != nil {
+				m.log.Error("Failed renewing vault token", zap.Error(err))
+			}
+		}()
+		m.closers = append(m.closers, labeledCloser{
+			label:   "vault token renewal",
+			timeout: opts.ShutdownTimeout,
+			closer: func(context.Context) error {
+				stopRenewal()
+				return nil
+			},
+		})
+		secretSvc = svc
+	case "aws":
+		svc, err := cloud.NewAWSSecretService(opts.AWSSecrets)
+		if err != nil {
+			m.log.Error("Failed initializing aws secret service", zap.Error(err))
+			return err
+		}
+		secretSvc = svc
+	case "gcp":
+		svc, err := cloud.NewGCPSecretService(ctx, opts.GCPSecrets)
+		if err != nil {
+			m.log.Error("Failed initializing gcp secret service", zap.Error(err))
+			return err
+		}
 		secretSvc = svc
 	default:
-		err := fmt.Errorf("unknown secret service %q, expected \"bolt\" or \"vault\"", opts.SecretStore)
+		err := fmt.Errorf("unknown secret service %q, expected \"bolt\", \"vault\", \"aws\" or \"gcp\"", opts.SecretStore)
 		m.log.Error("Failed setting secret service", zap.Error(err))
 		return err
 	}
 
+	// the resources which use secrets are registered once they are built.
+	secretDependents := &secret.Dependents{}
+
+	// the resolutions of secrets are tracked for every secret store.
+	secretUsageTracker := secret.NewUsageTracker(
+		m.log.With(zap.String("service", "secret_usage")),
+		secretSvc,
+		m.kvStore,
+		secretDependents,
+	)
+	secretSvc = secretUsageTracker
+	{
+		usageCtx, stopUsage := context.WithCancel(ctx)
+		usageDone := make(chan struct{})
+		go func() {
+			defer close(usageDone)
+			secretUsageTracker.Run(usageCtx, secret.DefaultUsageFlushInterval)
+		}()
+		m.closers = append(m.closers, labeledCloser{
+			label:   "secret usage",
+			timeout: opts.ShutdownTimeout,
+			closer: func(ctx context.Context) error {
+				stopUsage()
+				select {
+				case <-usageDone:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			},
+		})
+	}
+
+	// the versions of rotated secrets are kept by every secret store.
+	secretSvc = secret.NewRotationService(
+		m.log.With(zap.String("service", "secret_rotation")),
+		secretSvc,
+		m.kvStore,
+		secretDependents,
+		opts.SecretRotationWebhooks...,
+	)
+
 	metaClient := meta.NewClient(meta.NewConfig(), m.kvStore)
 	if err := metaClient.Open(); err != nil {
 		m.log.Error("Failed to open meta client", zap.Error(err))
@@ -342,13 +574,14 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 			storage.WithMetaClient(metaClient),
 		)
 	}
-	m.engine.WithLogger(m.log)
+	m.engine.WithLogger(m.subsystemLogger(influxlogger.SubsystemStorage))
 	if err := m.engine.Open(ctx); err != nil {
 		m.log.Error("Failed to open engine", zap.Error(err))
 		return err
 	}
 	m.closers = append(m.closers, labeledCloser{
-		label: "engine",
+		label:   "engine",
+		timeout: opts.StorageShutdownTimeout,
 		closer: func(context.Context) error {
 			return m.engine.Close()
 		},
@@ -356,6 +589,18 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 	// The Engine's metrics must be registered after it opens.
 	m.reg.MustRegister(m.engine.PrometheusCollectors()...)
 
+	compactionWatcher := eventlog.NewCompactionWatcher(m.log.With(zap.String("service", "compaction_events")), m.reg, m.eventLog)
+	compactionWatcherCtx, stopCompactionWatcher := context.WithCancel(ctx)
+	go compactionWatcher.Run(compactionWatcherCtx, time.Minute)
+	m.closers = append(m.closers, labeledCloser{
+		label:   "compaction events",
+		timeout: opts.ShutdownTimeout,
+		closer: func(context.Context) error {
+			stopCompactionWatcher()
+			return nil
+		},
+	})
+
 	var (
 		deleteService  platform.DeleteService  = m.engine
 		pointsWriter   storage.PointsWriter    = m.engine
@@ -363,15 +608,34 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		restoreService platform.RestoreService = m.engine
 	)
 
+	deleteCounter := deletejob.NewStoreCounter(storage2.NewStore(m.engine.TSDBStore(), m.engine.MetaClient()))
+	deleteJobSvc := deletejob.NewService(
+		m.log.With(zap.String("service", "delete_jobs")),
+		deleteService,
+		deleteCounter,
+	)
+	{
+		deleteJobsCtx, stopDeleteJobs := context.WithCancel(ctx)
+		go deleteJobSvc.Run(deleteJobsCtx)
+		m.closers = append(m.closers, labeledCloser{
+			label:   "delete jobs",
+			timeout: opts.ShutdownTimeout,
+			closer: func(context.Context) error {
+				stopDeleteJobs()
+				return nil
+			},
+		})
+	}
+
 	remotesSvc := remotes.NewService(m.sqlStore)
 	remotesServer := remotesTransport.NewInstrumentedRemotesHandler(
 		m.log.With(zap.String("handler", "remotes")), m.reg, m.kvStore, remotesSvc)
 
-	replicationSvc, replicationsMetrics := replications.NewService(m.sqlStore, ts, pointsWriter, m.log.With(zap.String("service", "replications")), opts.EnginePath, opts.InstanceID)
+	replicationSvc, replicationsMetrics := replications.NewService(m.sqlStore, ts, pointsWriter, m.subsystemLogger(influxlogger.SubsystemReplications).With(zap.String("service", "replications")), opts.EnginePath, opts.InstanceID)
 	replicationServer := replicationTransport.NewInstrumentedReplicationHandler(
 		m.log.With(zap.String("handler", "replications")), m.reg, m.kvStore, replicationSvc)
 	ts.BucketService = replications.NewBucketService(
-		m.log.With(zap.String("service", "replication_buckets")), ts.BucketService, replicationSvc)
+		m.subsystemLogger(influxlogger.SubsystemReplications).With(zap.String("service", "replication_buckets")), ts.BucketService, replicationSvc)
 
 	m.reg.MustRegister(replicationsMetrics.PrometheusCollectors()...)
 
@@ -381,7 +645,8 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 	}
 
 	m.closers = append(m.closers, labeledCloser{
-		label: "replications",
+		label:   "replications",
+		timeout: opts.ShutdownTimeout,
 		closer: func(context.Context) error {
 			return replicationSvc.Close()
 		},
@@ -389,6 +654,85 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 
 	pointsWriter = replicationSvc
 
+	bucketStats := bucketstats.NewTracker()
+	pointsWriter = bucketStats.PointsWriter(pointsWriter)
+
+	var orgMetrics *usage.OrgMetrics
+	if opts.OrgMetrics.Enabled {
+		orgMetrics, err = usage.NewOrgMetrics(m.log.With(zap.String("service", "org_metrics")), opts.OrgMetrics, ts.BucketService, m.engine)
+		if err != nil {
+			m.log.Error("Failed to create org usage metrics", zap.Error(err))
+			return err
+		}
+		m.reg.MustRegister(orgMetrics.PrometheusCollectors()...)
+		pointsWriter = orgMetrics.PointsWriter(pointsWriter)
+
+		orgMetricsCtx, stopOrgMetrics := context.WithCancel(ctx)
+		go orgMetrics.Run(orgMetricsCtx, opts.OrgMetrics.Interval)
+		m.closers = append(m.closers, labeledCloser{
+			label:   "org metrics",
+			timeout: opts.ShutdownTimeout,
+			closer: func(context.Context) error {
+				stopOrgMetrics()
+				return nil
+			},
+		})
+	}
+
+	if opts.GapAnnotations.Enabled {
+		gapDetector, err := annotations.NewGapDetector(m.log.With(zap.String("service", "gap_annotations")), opts.GapAnnotations, annotations.NewService(m.sqlStore))
+		if err != nil {
+			m.log.Error("Failed to create gap annotations detector", zap.Error(err))
+			return err
+		}
+		pointsWriter = gapDetector.PointsWriter(pointsWriter)
+
+		gapDetectorCtx, stopGapDetector := context.WithCancel(ctx)
+		go gapDetector.Run(gapDetectorCtx)
+		m.closers = append(m.closers, labeledCloser{
+			label:   "gap annotations",
+			timeout: opts.ShutdownTimeout,
+			closer: func(context.Context) error {
+				stopGapDetector()
+				return nil
+			},
+		})
+	}
+
+	var rep *replica.Replica
+	if opts.Replica.Enabled() {
+		primary, err := http.NewHTTPClient(opts.Replica.PrimaryURL, opts.Replica.PrimaryToken, opts.Replica.InsecureSkipVerify)
+		if err != nil {
+			m.log.Error("Failed to create the client of the primary", zap.String("primary", opts.Replica.PrimaryURL), zap.Error(err))
+			return err
+		}
+		rep = replica.New(m.log.With(zap.String("service", "replica")), opts.Replica,
+			&tenant.OrgClientService{Client: primary}, &tenant.BucketClientService{Client: primary}, m.kvStore, m.engine)
+		m.reg.MustRegister(rep.PrometheusCollectors()...)
+		if opts.Replica.WriterUser == "" {
+			m.log.Warn("No replica-writer-user is set, the replica rejects every write, including the ones of the replication streams of the primary")
+		}
+		pointsWriter = rep.PointsWriter(pointsWriter)
+
+		replicaCtx, stopReplica := context.WithCancel(ctx)
+		go rep.Run(replicaCtx)
+		m.closers = append(m.closers, labeledCloser{
+			label:   "replica",
+			timeout: opts.ShutdownTimeout,
+			closer: func(context.Context) error {
+				stopReplica()
+				return nil
+			},
+		})
+	}
+
+	var cdcHub *cdc.Hub
+	if opts.CDC.Enabled {
+		cdcHub = cdc.NewHub(m.log.With(zap.String("service", "cdc")), opts.CDC)
+		m.reg.MustRegister(cdcHub.PrometheusCollectors()...)
+		pointsWriter = cdcHub.PointsWriter(pointsWriter)
+	}
+
 	// When --hardening-enabled, use an HTTP IP validator that restricts
 	// flux and pkger HTTP requests to private addressess.
 	var urlValidator url.Validator
@@ -398,8 +742,32 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		urlValidator = url.PassValidator{}
 	}
 
+	transferJobSvc := transferjob.NewService(
+		m.log.With(zap.String("service", "transfer_jobs")),
+		filepath.Join(opts.EnginePath, "exports"),
+		transferjob.NewStoreExporter(storage2.NewStore(m.engine.TSDBStore(), m.engine.MetaClient())),
+		pointsWriter,
+		urlValidator,
+	)
+	if err := transferJobSvc.Open(ctx); err != nil {
+		m.log.Error("Failed to open the transfer jobs service", zap.Error(err))
+		return err
+	}
+	{
+		transferJobsCtx, stopTransferJobs := context.WithCancel(ctx)
+		go transferJobSvc.Run(transferJobsCtx)
+		m.closers = append(m.closers, labeledCloser{
+			label:   "transfer jobs",
+			timeout: opts.ShutdownTimeout,
+			closer: func(context.Context) error {
+				stopTransferJobs()
+				return nil
+			},
+		})
+	}
+
 	deps, err := influxdb.NewDependencies(
-		storageflux.NewReader(storage2.NewStore(m.engine.TSDBStore(), m.engine.MetaClient())),
+		bucketStats.StorageReader(storageflux.NewReader(storage2.NewStore(m.engine.TSDBStore(), m.engine.MetaClient()))),
 		pointsWriter,
 		authorizer.NewBucketService(ts.BucketService),
 		authorizer.NewOrgService(ts.OrganizationService),
@@ -418,6 +786,19 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		dependencyList = append(dependencyList, testing.FrameworkConfig{})
 	}
 
+	fluxPackageSvc := fluxpkg.NewService(m.sqlStore)
+	if opts.FluxPackagesPath != "" {
+		if err := fluxPackageSvc.LoadDir(ctx, m.log.With(zap.String("service", "flux_packages")), opts.FluxPackagesPath); err != nil {
+			m.log.Error("Failed to load flux packages", zap.Error(err))
+			return err
+		}
+	}
+
+	// The memory of the queries and writes of each org is charged to its budget.
+	orgMemory := membudget.NewAccountant(opts.OrgMemory)
+	m.reg.MustRegister(orgMemory.PrometheusCollectors()...)
+	m.orgMemory = orgMemory
+
 	m.queryController, err = control.New(control.Config{
 		ConcurrencyQuota:                opts.ConcurrencyQuota,
 		InitialMemoryBytesQuotaPerQuery: opts.InitialMemoryBytesQuotaPerQuery,
@@ -425,14 +806,17 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		MaxMemoryBytes:                  opts.MaxMemoryBytes,
 		QueueSize:                       opts.QueueSize,
 		ExecutorDependencies:            dependencyList,
+		OrgMemory:                       orgMemory,
+		Packages:                        fluxPackageSvc,
 		FluxLogEnabled:                  opts.FluxLogEnabled,
-	}, m.log.With(zap.String("service", "storage-reads")))
+	}, m.subsystemLogger(influxlogger.SubsystemStorage).With(zap.String("service", "storage-reads")))
 	if err != nil {
 		m.log.Error("Failed to create query controller", zap.Error(err))
 		return err
 	}
 	m.closers = append(m.closers, labeledCloser{
-		label: "query",
+		label:   "query",
+		timeout: opts.ShutdownTimeout,
 		closer: func(ctx context.Context) error {
 			return m.queryController.Shutdown(ctx)
 		},
@@ -445,7 +829,7 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 	{
 		// create the task stack
 		combinedTaskService := taskbackend.NewAnalyticalStorage(
-			m.log.With(zap.String("service", "task-analytical-store")),
+			m.subsystemLogger(influxlogger.SubsystemTasks).With(zap.String("service", "task-analytical-store")),
 			m.kvService,
 			ts.BucketService,
 			m.kvService,
@@ -454,12 +838,16 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		)
 
 		executor, executorMetrics := executor.NewExecutor(
-			m.log.With(zap.String("service", "task-executor")),
+			m.subsystemLogger(influxlogger.SubsystemTasks).With(zap.String("service", "task-executor")),
 			query.QueryServiceBridge{AsyncQueryService: m.queryController},
 			ts.UserService,
 			combinedTaskService,
 			combinedTaskService,
 			executor.WithFlagger(m.flagger),
+			executor.WithRunner(platform.DeletePolicyTaskType, deletepolicy.NewRunner(
+				m.subsystemLogger(influxlogger.SubsystemTasks).With(zap.String("service", "delete-policy-runner")),
+				deleteService,
+			)),
 		)
 		err = executor.LoadExistingScheduleRuns(ctx)
 		if err != nil {
@@ -467,7 +855,7 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		}
 		m.executor = executor
 		m.reg.MustRegister(executorMetrics.PrometheusCollectors()...)
-		schLogger := m.log.With(zap.String("service", "task-scheduler"))
+		schLogger := m.subsystemLogger(influxlogger.SubsystemTasks).With(zap.String("service", "task-scheduler"))
 
 		var sch stoppingScheduler = &scheduler.NoopScheduler{}
 		if !opts.NoTasks {
@@ -490,7 +878,8 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 				m.log.Fatal("could not start task scheduler", zap.Error(err))
 			}
 			m.closers = append(m.closers, labeledCloser{
-				label: "task",
+				label:   "task",
+				timeout: opts.TaskShutdownTimeout,
 				closer: func(context.Context) error {
 					sch.Stop()
 					return nil
@@ -501,7 +890,7 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 
 		m.scheduler = sch
 
-		coordLogger := m.log.With(zap.String("service", "task-coordinator"))
+		coordLogger := m.subsystemLogger(influxlogger.SubsystemTasks).With(zap.String("service", "task-coordinator"))
 		taskCoord := coordinator.NewCoordinator(
 			coordLogger,
 			sch,
@@ -540,10 +929,21 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 
 	qe := iqlquery.NewExecutor(m.log, cm)
 	se := &iqlcoordinator.StatementExecutor{
-		MetaClient:        metaClient,
-		TSDBStore:         m.engine.TSDBStore(),
-		ShardMapper:       mapper,
-		DBRP:              dbrpSvc,
+		MetaClient:  metaClient,
+		TSDBStore:   m.engine.TSDBStore(),
+		ShardMapper: mapper,
+		DBRP:        dbrpSvc,
+		ContinuousQueries: continuousquery.NewService(
+			m.log.With(zap.String("service", "continuous_queries")),
+			authorizer.NewTaskService(m.log.With(zap.String("service", "continuous_query_tasks")), taskSvc),
+			dbrpSvc,
+		),
+		Subscriptions: subscription.NewService(
+			m.log.With(zap.String("service", "subscriptions")),
+			remotesTransport.NewAuthCheckingService(remotesSvc),
+			replicationTransport.NewAuthCheckingService(replicationSvc),
+			dbrpSvc,
+		),
 		MaxSelectPointN:   opts.CoordinatorConfig.MaxSelectPointN,
 		MaxSelectSeriesN:  opts.CoordinatorConfig.MaxSelectSeriesN,
 		MaxSelectBucketsN: opts.CoordinatorConfig.MaxSelectBucketsN,
@@ -557,23 +957,38 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		checkSvc = checks.NewService(m.log.With(zap.String("svc", "checks")), m.kvStore, ts.OrganizationService, m.kvService)
 		checkSvc = middleware.NewCheckService(checkSvc, m.kvService, coordinator)
 	}
+	checkTemplateSvc := checks.NewTemplateService(m.kvStore, checkSvc, m.kvService)
+	alertAckSvc := alert.NewAckService(checkSvc, ts.BucketService, pointsWriter)
 
 	var notificationEndpointSvc platform.NotificationEndpointService
 	{
 		notificationEndpointSvc = endpointservice.New(endpointservice.NewStore(m.kvStore), secretSvc)
 	}
+	secretDependents.Register(
+		secret.TaskDependents{Tasks: taskSvc},
+		secret.EndpointDependents{Endpoints: notificationEndpointSvc},
+		secret.CheckDependents{Checks: checkSvc, Tasks: taskSvc},
+	)
 
-	var notificationRuleSvc platform.NotificationRuleStore
+	var (
+		notificationRuleSvc platform.NotificationRuleStore
+		silenceSvc          *silence.Service
+	)
 	{
 		coordinator := coordinator.NewCoordinator(m.log, m.scheduler, m.executor)
-		notificationRuleSvc, err = ruleservice.New(m.log, m.kvStore, m.kvService, ts.OrganizationService, notificationEndpointSvc)
+		ruleSvc, err := ruleservice.New(m.log, m.kvStore, m.kvService, ts.OrganizationService, notificationEndpointSvc)
 		if err != nil {
 			return err
 		}
 
+		// the tasks of the notification rules are regenerated when the
+		// silences of their organization change.
+		silenceSvc = silence.NewService(m.kvStore, ruleSvc)
+		ruleSvc.Silences = silenceSvc
+
 		// tasks service notification middleware which keeps task service up to date
 		// with persisted changes to notification rules.
-		notificationRuleSvc = middleware.NewNotificationRuleStore(notificationRuleSvc, m.kvService, coordinator)
+		notificationRuleSvc = middleware.NewNotificationRuleStore(ruleSvc, m.kvService, coordinator)
 	}
 
 	var telegrafSvc platform.TelegrafConfigStore
@@ -587,7 +1002,8 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		return err
 	}
 	m.closers = append(m.closers, labeledCloser{
-		label: "scraper",
+		label:   "scraper",
+		timeout: opts.ShutdownTimeout,
 		closer: func(ctx context.Context) error {
 			scraperScheduler.Close()
 			return nil
@@ -595,15 +1011,31 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 	})
 
 	var sessionSvc platform.SessionService
+	var sessionRefreshSvc session.RefreshTokenService
+	var userSessionSvc session.UserSessionService
+	sessionPolicySvc := session.NewPolicyStore(m.kvStore)
 	{
-		sessionSvc = session.NewService(
+		baseSessionSvc := session.NewService(
 			session.NewStorage(inmem.NewSessionStore()),
 			ts.UserService,
 			ts.UserResourceMappingService,
 			authSvc,
-			session.WithSessionLength(time.Duration(opts.SessionLength)*time.Minute),
+			session.WithPolicy(session.Policy{
+				Length:        time.Duration(opts.SessionLength) * time.Minute,
+				IdleTimeout:   opts.SessionIdleTimeout,
+				MaxLifetime:   opts.SessionMaxLifetime,
+				RenewDisabled: opts.SessionRenewDisabled,
+				MaxSessions:   opts.SessionMaxPerUser,
+			}),
+			session.WithOrgPolicies(sessionPolicySvc),
+			session.WithUserLimits(sessionPolicySvc),
+			session.WithRefreshTokenLength(opts.SessionRefreshLength),
 		)
-		sessionSvc = session.NewSessionMetrics(m.reg, sessionSvc)
+		userSessionSvc = baseSessionSvc
+		if opts.SessionRefreshLength > 0 {
+			sessionRefreshSvc = baseSessionSvc
+		}
+		sessionSvc = session.NewSessionMetrics(m.reg, baseSessionSvc)
 		sessionSvc = session.NewSessionLogger(m.log.With(zap.String("service", "session")), sessionSvc)
 	}
 
@@ -619,8 +1051,51 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 
 	ts.BucketService = storage.NewBucketService(m.log, ts.BucketService, m.engine)
 	ts.BucketService = dbrp.NewBucketService(m.log, ts.BucketService, dbrpSvc)
+	// The retention policies of the v1 query API are managed as buckets.
+	se.BucketService = authorizer.NewBucketService(ts.BucketService)
+
+	dbrpAutoCreateSvc := dbrp.NewAutoCreateSettingsStore(m.kvStore)
+	dbrpAutoCreator := dbrp.NewAutoCreator(m.log.With(zap.String("service", "dbrp_auto_create")), opts.DBRPAutoCreate, dbrpAutoCreateSvc, ts.BucketService, dbrpSvc)
+
+	if opts.SelfMonitoring.Enabled {
+		selfMonitor, err := selfmonitor.New(m.log.With(zap.String("service", "self_monitoring")), opts.SelfMonitoring, m.reg, ts.OrganizationService, ts.BucketService, pointsWriter)
+		if err != nil {
+			m.log.Error("Failed to create self-monitoring", zap.Error(err))
+			return err
+		}
+
+		selfMonitorCtx, stopSelfMonitor := context.WithCancel(ctx)
+		go selfMonitor.Run(selfMonitorCtx, opts.SelfMonitoring.Interval)
+		m.closers = append(m.closers, labeledCloser{
+			label:   "self-monitoring",
+			timeout: opts.ShutdownTimeout,
+			closer: func(context.Context) error {
+				stopSelfMonitor()
+				return nil
+			},
+		})
+
+		if opts.SelfMonitoring.Cardinality.Enabled {
+			cardinalityCheck, err := selfmonitor.NewCardinalityCheck(m.log.With(zap.String("service", "cardinality_check")), opts.SelfMonitoring.Cardinality, selfMonitor, m.engine, checkSvc, m.kvService, ts.UserResourceMappingService)
+			if err != nil {
+				m.log.Error("Failed to create the cardinality check", zap.Error(err))
+				return err
+			}
 
-	bucketManifestWriter := backup.NewBucketManifestWriter(ts, metaClient)
+			cardinalityCtx, stopCardinality := context.WithCancel(ctx)
+			go cardinalityCheck.Run(cardinalityCtx)
+			m.closers = append(m.closers, labeledCloser{
+				label:   "cardinality check",
+				timeout: opts.ShutdownTimeout,
+				closer: func(context.Context) error {
+					stopCardinality()
+					return nil
+				},
+			})
+		}
+	}
+
+	bucketManifestWriter := backup.NewBucketManifestWriter(ts, metaClient, dbrpSvc)
 
 	onboardingLogger := m.log.With(zap.String("handler", "onboard"))
 	onboardOpts := []tenant.OnboardServiceOptionFn{tenant.WithOnboardingLogger(onboardingLogger)}
@@ -634,8 +1109,9 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 	onboardSvc = tenant.NewOnboardingLogger(onboardingLogger, onboardSvc)                 // with logging
 
 	var (
-		passwordV1 platform.PasswordsService
-		authSvcV1  *authv1.Service
+		passwordV1    platform.PasswordsService
+		authSvcV1     *authv1.Service
+		authMetricsV1 platform.AuthorizationService
 	)
 	{
 		authStore, err := authv1.NewStore(m.kvStore)
@@ -644,18 +1120,73 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 			return err
 		}
 
-		authSvcV1 = authv1.NewService(authStore, ts, authv1.WithPasswordChecking(opts.StrongPasswords))
-		passwordV1 = authv1.NewCachingPasswordsService(authSvcV1)
+		authSvcV1 = authv1.NewService(authStore, ts,
+			authv1.WithPasswordPolicy(opts.passwordPolicy()),
+			authv1.WithTokenHashing(opts.V1TokenHashing),
+			authv1.WithLockout(opts.V1PasswordLockoutThreshold, opts.V1PasswordLockoutWindow),
+			authv1.WithAuthCache(opts.V1AuthCacheSize, opts.V1AuthCacheTTL),
+			authv1.WithTokenQuota(opts.V1OrgTokenQuota),
+		)
+		m.reg.MustRegister(authSvcV1.PrometheusCollectors()...)
+		authMetricsV1 = authv1.NewAuthMetrics(m.reg, authSvcV1)
+		cachingPasswordV1 := authv1.NewCachingPasswordsService(authSvcV1)
+		authSvcV1.OnRevoke(cachingPasswordV1.Invalidate)
+		passwordV1 = cachingPasswordV1
+		se.Users = user.NewService(authSvcV1, passwordV1, dbrpSvc)
+
+		sweeper := authv1.NewExpirationSweeper(m.log.With(zap.String("service", "v1_authorization_sweeper")), authSvcV1, authv1.DefaultSweepInterval)
+		sweeperCtx, stopSweeper := context.WithCancel(ctx)
+		go sweeper.Run(sweeperCtx)
+		m.closers = append(m.closers, labeledCloser{
+			label:   "v1 authorization sweeper",
+			timeout: opts.ShutdownTimeout,
+			closer: func(context.Context) error {
+				stopSweeper()
+				return nil
+			},
+		})
+
+		flusher := authv1.NewLastUsedFlusher(m.log.With(zap.String("service", "v1_authorization_last_used")), authSvcV1, authv1.DefaultLastUsedFlushInterval)
+		flusherCtx, stopFlusher := context.WithCancel(ctx)
+		go flusher.Run(flusherCtx)
+		m.closers = append(m.closers, labeledCloser{
+			label:   "v1 authorization last use flusher",
+			timeout: opts.ShutdownTimeout,
+			closer: func(ctx context.Context) error {
+				stopFlusher()
+				return authSvcV1.FlushLastUsed(ctx)
+			},
+		})
 	}
 
 	var (
-		dashboardSvc    platform.DashboardService
-		dashboardLogSvc platform.DashboardOperationLogService
+		dashboardSvc         platform.DashboardService
+		dashboardLogSvc      platform.DashboardOperationLogService
+		dashboardRevisionSvc platform.DashboardRevisionService
+		dashboardShareSvc    platform.DashboardShareService
+		cellTemplateSvc      platform.CellTemplateService
+		dashboardUsageSvc    platform.DashboardUsageService
 	)
 	{
 		dashboardService := dashboards.NewService(m.kvStore, m.kvService)
+		dashboardService.MaxRevisions = opts.DashboardRevisionRetention
 		dashboardSvc = dashboardService
 		dashboardLogSvc = dashboardService
+		dashboardRevisionSvc = dashboardService
+		dashboardShareSvc = dashboardService
+		cellTemplateSvc = dashboardService
+		dashboardUsageSvc = dashboardService
+
+		usageCtx, stopUsageFlush := context.WithCancel(ctx)
+		go dashboardService.FlushDashboardUsageEvery(usageCtx, m.log.With(zap.String("service", "dashboard_usage")), dashboards.DefaultUsageFlushInterval)
+		m.closers = append(m.closers, labeledCloser{
+			label:   "dashboard usage",
+			timeout: opts.ShutdownTimeout,
+			closer: func(ctx context.Context) error {
+				stopUsageFlush()
+				return dashboardService.FlushDashboardUsage(ctx)
+			},
+		})
 	}
 
 	// resourceResolver is a deprecated type which combines the lookups
@@ -677,6 +1208,19 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		NotificationRuleFinder:     notificationRuleSvc,
 	}
 
+	var trustPeer func(platcontext.PeerCredentials) bool
+	if opts.HttpUnixSocket != "" {
+		if trustPeer, err = trustedPeers(opts.HttpUnixSocketUIDs); err != nil {
+			m.log.Error("Invalid http-unix-socket-uids", zap.Error(err))
+			return err
+		}
+	}
+
+	var queryEventRecorder httpmetric.EventRecorder = infprom.NewEventRecorder("query")
+	if orgMetrics != nil {
+		queryEventRecorder = orgMetrics.QueryEventRecorder(queryEventRecorder)
+	}
+
 	errorHandler := kithttp.NewErrorHandler(m.log.With(zap.String("handler", "error_logger")))
 	m.apibackend = &http.APIBackend{
 		AssetsPath:           opts.AssetsPath,
@@ -685,6 +1229,9 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		Logger:               m.log,
 		FluxLogEnabled:       opts.FluxLogEnabled,
 		SessionRenewDisabled: opts.SessionRenewDisabled,
+		SessionIdleTimeout:   opts.SessionIdleTimeout,
+		TrustPeer:            trustPeer,
+		OrgMemory:            orgMemory,
 		NewQueryService:      source.NewQueryService,
 		PointsWriter: &storage.LoggingPointsWriter{
 			Underlying:    pointsWriter,
@@ -692,15 +1239,17 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 			LogBucketName: platform.MonitoringSystemBucketName,
 		},
 		DeleteService:           deleteService,
+		DeleteJobService:        authorizer.NewDeleteJobService(deleteJobSvc),
+		DeleteCounter:           deleteCounter,
 		BackupService:           backupService,
 		SqlBackupRestoreService: m.sqlStore,
 		BucketManifestWriter:    bucketManifestWriter,
 		RestoreService:          restoreService,
 		AuthorizationService:    authSvc,
-		AuthorizationV1Service:  authSvcV1,
+		AuthorizationV1Service:  authMetricsV1,
 		PasswordV1Service:       passwordV1,
 		AuthorizerV1: &authv1.Authorizer{
-			AuthV1:   authSvcV1,
+			AuthV1:   authMetricsV1,
 			AuthV2:   authSvc,
 			Comparer: passwordV1,
 			User:     ts,
@@ -712,40 +1261,54 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		UserService:                     ts.UserService,
 		OnboardingService:               onboardSvc,
 		DBRPService:                     dbrpSvc,
+		DBRPAutoCreator:                 dbrpAutoCreator,
 		OrganizationService:             ts.OrganizationService,
 		UserResourceMappingService:      ts.UserResourceMappingService,
 		LabelService:                    labelSvc,
 		DashboardService:                dashboardSvc,
 		DashboardOperationLogService:    dashboardLogSvc,
+		DashboardUsageService:           dashboardUsageSvc,
 		BucketOperationLogService:       bucketLogSvc,
 		UserOperationLogService:         userLogSvc,
 		OrganizationOperationLogService: orgLogSvc,
 		SourceService:                   sourceSvc,
 		VariableService:                 variableSvc,
-		PasswordsService:                ts.PasswordsService,
-		InfluxqldService:                iqlquery.NewProxyExecutor(m.log, qe),
-		FluxService:                     storageQueryService,
-		FluxLanguageService:             fluxlang.DefaultService,
-		TaskService:                     taskSvc,
-		TelegrafService:                 telegrafSvc,
-		NotificationRuleStore:           notificationRuleSvc,
-		NotificationEndpointService:     notificationEndpointSvc,
-		CheckService:                    checkSvc,
-		ScraperTargetStoreService:       scraperTargetSvc,
-		SecretService:                   secretSvc,
-		LookupService:                   resourceResolver,
-		DocumentService:                 m.kvService,
-		OrgLookupService:                resourceResolver,
-		WriteEventRecorder:              infprom.NewEventRecorder("write"),
-		QueryEventRecorder:              infprom.NewEventRecorder("query"),
-		Flagger:                         m.flagger,
-		FlagsHandler:                    feature.NewFlagsHandler(errorHandler, feature.ByKey),
+		VariableValuesService: variable.NewValuesService(
+			m.log.With(zap.String("service", "variable_values")),
+			authorizer.NewVariableService(variableSvc),
+			query.QueryServiceBridge{AsyncQueryService: m.queryController},
+			variable.DefaultRefreshInterval,
+		),
+		PasswordsService:            ts.PasswordsService,
+		InfluxqldService:            iqlquery.NewProxyExecutor(m.log, qe),
+		FluxService:                 storageQueryService,
+		FluxLanguageService:         fluxlang.DefaultService,
+		TaskService:                 taskSvc,
+		TelegrafService:             telegrafSvc,
+		NotificationRuleStore:       notificationRuleSvc,
+		NotificationEndpointService: notificationEndpointSvc,
+		CheckService:                checkSvc,
+		ScraperTargetStoreService:   scraperTargetSvc,
+		SecretService:               secretSvc,
+		LookupService:               resourceResolver,
+		DocumentService:             m.kvService,
+		OrgLookupService:            resourceResolver,
+		WriteEventRecorder:          infprom.NewEventRecorder("write"),
+		QueryEventRecorder:          queryEventRecorder,
+		Flagger:                     m.flagger,
+		FlagsHandler: featureflag.NewHTTPHandler(
+			m.log.With(zap.String("handler", "feature_flags")),
+			runtimeFlagger,
+			feature.NewFlagsHandler(errorHandler, feature.ByKey),
+		),
 	}
 
 	m.reg.MustRegister(m.apibackend.PrometheusCollectors()...)
 
 	authAgent := new(authorizer.AuthAgent)
 
+	notebookSvc := notebooks.NewService(m.sqlStore)
+
 	var pkgSVC pkger.SVC
 	{
 		b := m.apibackend
@@ -756,13 +1319,18 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 			pkger.WithHTTPClient(pkger.NewDefaultHTTPClient(urlValidator)),
 			pkger.WithLogger(pkgerLogger),
 			pkger.WithStore(pkger.NewStoreKV(m.kvStore)),
+			pkger.WithAuthorizationSVC(authorizer.NewAuthorizationService(authSvc)),
 			pkger.WithBucketSVC(authorizer.NewBucketService(b.BucketService)),
 			pkger.WithCheckSVC(authorizer.NewCheckService(b.CheckService, authedUrmSVC, authedOrgSVC)),
 			pkger.WithDashboardSVC(authorizer.NewDashboardService(b.DashboardService)),
+			pkger.WithDBRPSVC(dbrpSvc),
 			pkger.WithLabelSVC(label.NewAuthedLabelService(labelSvc, b.OrgLookupService)),
 			pkger.WithNotificationEndpointSVC(authorizer.NewNotificationEndpointService(b.NotificationEndpointService, authedUrmSVC, authedOrgSVC)),
 			pkger.WithNotificationRuleSVC(authorizer.NewNotificationRuleStore(b.NotificationRuleStore, authedUrmSVC, authedOrgSVC)),
+			pkger.WithNotebookSVC(authorizer.NewNotebookService(notebookSvc)),
 			pkger.WithOrganizationService(authorizer.NewOrgService(b.OrganizationService)),
+			pkger.WithRemoteConnectionSVC(remotesTransport.NewAuthCheckingService(remotesSvc)),
+			pkger.WithReplicationSVC(replicationTransport.NewAuthCheckingService(replicationSvc)),
 			pkger.WithSecretSVC(authorizer.NewSecretService(b.SecretService)),
 			pkger.WithTaskSVC(authorizer.NewTaskService(pkgerLogger, b.TaskService)),
 			pkger.WithTelegrafSVC(authorizer.NewTelegrafConfigService(b.TelegrafService, b.UserResourceMappingService)),
@@ -774,10 +1342,34 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		pkgSVC = pkger.MWAuth(authAgent)(pkgSVC)
 	}
 
+	// the syncer reads the stacks and their webhook secrets without an
+	// authorizer, and syncs the stacks with the permissions of their owners.
+	stackSyncer := pkger.NewSyncer(
+		m.log.With(zap.String("service", "stack_sync")),
+		pkgSVC,
+		pkger.NewStoreKV(m.kvStore),
+		ts.OrganizationService,
+		ts.UserService,
+		secretSvc,
+		pkger.NewDefaultHTTPClient(urlValidator),
+	)
+	{
+		syncCtx, stopSyncs := context.WithCancel(ctx)
+		go stackSyncer.Run(syncCtx, pkger.DefaultSyncCheckInterval)
+		m.closers = append(m.closers, labeledCloser{
+			label:   "stack sync",
+			timeout: opts.ShutdownTimeout,
+			closer: func(context.Context) error {
+				stopSyncs()
+				return nil
+			},
+		})
+	}
+
 	var stacksHTTPServer *pkger.HTTPServerStacks
 	{
 		tLogger := m.log.With(zap.String("handler", "stacks"))
-		stacksHTTPServer = pkger.NewHTTPServerStacks(tLogger, pkgSVC)
+		stacksHTTPServer = pkger.NewHTTPServerStacks(tLogger, pkgSVC, pkger.WithStackSyncer(stackSyncer))
 	}
 
 	var templatesHTTPServer *pkger.HTTPServerTemplates
@@ -819,21 +1411,43 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		authLogger := m.log.With(zap.String("handler", "v1_authorization"))
 
 		var authService platform.AuthorizationService
-		authService = authorization.NewAuthedAuthorizationService(authSvcV1, ts)
+		authService = authorization.NewAuthedAuthorizationService(authMetricsV1, ts)
 		authService = authorization.NewAuthLogger(authLogger, authService)
 
 		passService := authv1.NewAuthedPasswordService(authv1.AuthFinder(authSvcV1), passwordV1)
-		v1AuthHTTPServer = authv1.NewHTTPAuthHandler(m.log, authService, passService, ts)
+		tokenRotator := authv1.NewAuthedTokenRotator(authv1.AuthFinder(authSvcV1), authSvcV1)
+		auditLog := authv1.NewAuthedAuditLog(authSvcV1)
+		v1AuthHTTPServer = authv1.NewHTTPAuthHandler(m.log, authService, passService, tokenRotator, auditLog, ts)
 	}
 
+	authedSessionPolicySvc := session.NewAuthedPolicyService(sessionPolicySvc, sessionPolicySvc)
+	sessionPolicyServer := session.NewPolicyHandler(
+		m.log.With(zap.String("handler", "session_policies")),
+		authedSessionPolicySvc,
+		authedSessionPolicySvc,
+	)
+
+	dbrpAutoCreateServer := dbrp.NewAutoCreateHandler(
+		m.log.With(zap.String("handler", "dbrp_auto_create")),
+		dbrp.NewAuthorizedAutoCreateSettingsService(dbrpAutoCreateSvc),
+	)
+
+	authedUserSessionSvc := session.NewAuthedUserSessionService(userSessionSvc)
+	meSessionServer := session.NewMeSessionHandler(m.log.With(zap.String("handler", "me_sessions")), authedUserSessionSvc)
+	userSessionServer := session.NewUserSessionHandler(m.log.With(zap.String("handler", "user_sessions")), authedUserSessionSvc)
+
 	var sessionHTTPServer *session.SessionHandler
 	{
 		sessionHTTPServer = session.NewSessionHandler(m.log.With(zap.String("handler", "session")), sessionSvc, ts.UserService, ts.PasswordsService)
+		if sessionRefreshSvc != nil {
+			sessionHTTPServer.WithRefreshTokens(sessionRefreshSvc)
+		}
 	}
 
 	orgHTTPServer := ts.NewOrgHTTPHandler(m.log, secret.NewAuthedService(secretSvc))
 
-	bucketHTTPServer := ts.NewBucketHTTPHandler(m.log, labelSvc)
+	bucketStatsHandler := bucketstats.NewHandler(m.log.With(zap.String("handler", "bucket_stats")), "id", authorizer.NewBucketService(ts.BucketService), bucketStats)
+	bucketHTTPServer := ts.NewBucketHTTPHandler(m.log, labelSvc, bucketStatsHandler)
 
 	var dashboardServer *dashboardTransport.DashboardHandler
 	{
@@ -859,10 +1473,124 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 			ts.OrganizationService,
 			urmHandler,
 			labelHandler,
+			dashboardTransport.WithDashboardRevisionService(authorizer.NewDashboardRevisionService(dashboardRevisionSvc, dashboardSvc)),
+			dashboardTransport.WithDashboardShareService(authorizer.NewDashboardShareService(dashboardShareSvc, dashboardSvc)),
+			dashboardTransport.WithDashboardUsageService(authorizer.NewDashboardUsageService(dashboardUsageSvc, dashboardSvc)),
 		)
 	}
 
-	notebookSvc := notebooks.NewService(m.sqlStore)
+	cellTemplateServer := dashboardTransport.NewCellTemplateHandler(
+		m.log.With(zap.String("handler", "cell_templates")),
+		authorizer.NewCellTemplateService(cellTemplateSvc, dashboardSvc),
+	)
+
+	sharedDashboardServer := dashboardTransport.NewSharedDashboardHandler(
+		m.log.With(zap.String("handler", "shared_dashboards")),
+		dashboardShareSvc,
+		dashboardSvc,
+		storageQueryService,
+		ts.UserService,
+		dashboardTransport.WithSharedDashboardUsageService(dashboardUsageSvc),
+	)
+
+	reportSvc := report.NewService(m.kvStore)
+	reportRunner := report.NewRunner(
+		m.log.With(zap.String("service", "report_runner")),
+		reportSvc,
+		dashboardSvc,
+		storageQueryService,
+		notificationEndpointSvc,
+		secretSvc,
+		ts.UserService,
+	)
+	{
+		reportCtx, stopReports := context.WithCancel(ctx)
+		go reportRunner.Run(reportCtx, report.DefaultSchedulerInterval)
+		m.closers = append(m.closers, labeledCloser{
+			label:   "report runner",
+			timeout: opts.ShutdownTimeout,
+			closer: func(context.Context) error {
+				stopReports()
+				return nil
+			},
+		})
+	}
+
+	transferJobServer := transferJobTransport.NewTransferJobHandler(
+		m.log.With(zap.String("handler", "transfer_jobs")),
+		transferJobSvc,
+		ts.BucketService,
+	)
+
+	fluxPackageServer := fluxPackageTransport.NewFluxPackageHandler(
+		m.log.With(zap.String("handler", "flux_packages")),
+		fluxPackageSvc,
+	)
+
+	deletePolicyServer := deletePolicyTransport.NewDeletePolicyHandler(
+		m.log.With(zap.String("handler", "delete_policies")),
+		authorizer.NewDeletePolicyService(deletepolicy.NewService(
+			authorizer.NewTaskService(m.log.With(zap.String("service", "delete_policy_tasks")), taskSvc),
+		)),
+	)
+
+	reportServer := reportTransport.NewReportHandler(
+		m.log.With(zap.String("handler", "reports")),
+		authorizer.NewReportService(reportSvc, dashboardSvc),
+		authorizer.NewReportRunService(reportRunner, reportSvc),
+	)
+
+	checkTemplateServer := checkTransport.NewCheckTemplateHandler(
+		m.log.With(zap.String("handler", "check_templates")),
+		authorizer.NewCheckTemplateService(checkTemplateSvc),
+	)
+
+	alertServer := alertTransport.NewAlertHandler(
+		m.log.With(zap.String("handler", "alerts")),
+		authorizer.NewAlertAckService(alertAckSvc, checkSvc),
+	)
+
+	silenceServer := silenceTransport.NewSilenceHandler(
+		m.log.With(zap.String("handler", "silences")),
+		authorizer.NewSilenceService(silenceSvc),
+	)
+
+	secretUsageServer := secret.NewUsageHandler(
+		m.log.With(zap.String("handler", "secret_usage")),
+		secret.NewAuthedUsageService(secretUsageTracker),
+	)
+
+	deliverer := delivery.NewDeliverer(
+		m.log.With(zap.String("service", "notification_delivery")),
+		delivery.NewAttemptLog(ts.BucketService, pointsWriter),
+	)
+	{
+		deliveryCtx, stopDeliveries := context.WithCancel(ctx)
+		go deliverer.Run(deliveryCtx, delivery.DefaultRetryInterval)
+		m.closers = append(m.closers, labeledCloser{
+			label:   "notification delivery",
+			timeout: opts.ShutdownTimeout,
+			closer: func(context.Context) error {
+				stopDeliveries()
+				return nil
+			},
+		})
+	}
+
+	deliveryRelayServer := delivery.NewRelayHandler(
+		m.log.With(zap.String("handler", "http_notification_relay")),
+		notificationEndpointSvc,
+		secretSvc,
+		deliverer,
+	)
+
+	awsRelayServer := awsendpoint.NewRelayHandler(
+		m.log.With(zap.String("handler", "aws_notification_relay")),
+		notificationEndpointSvc,
+		secretSvc,
+		awsendpoint.NewPublisher(),
+	)
+
 	notebookServer := notebookTransport.NewNotebookHandler(
 		m.log.With(zap.String("handler", "notebooks")),
 		authorizer.NewNotebookService(
@@ -874,6 +1602,19 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 	)
 
 	annotationSvc := annotations.NewService(m.sqlStore)
+	{
+		pruner := annotations.NewPruner(m.log.With(zap.String("service", "annotations_pruner")), annotationSvc, annotations.DefaultPruneInterval)
+		prunerCtx, stopPruner := context.WithCancel(ctx)
+		go pruner.Run(prunerCtx)
+		m.closers = append(m.closers, labeledCloser{
+			label:   "annotations pruner",
+			timeout: opts.ShutdownTimeout,
+			closer: func(context.Context) error {
+				stopPruner()
+				return nil
+			},
+		})
+	}
 	annotationServer := annotationTransport.NewAnnotationHandler(
 		m.log.With(zap.String("handler", "annotations")),
 		authorizer.NewAnnotationService(
@@ -884,13 +1625,36 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		),
 	)
 
-	configHandler, err := http.NewConfigHandler(m.log.With(zap.String("handler", "config")), opts.BindCliOpts())
+	configHandler, err := http.NewConfigHandler(m.log.With(zap.String("handler", "config")), opts.BindCliOpts(), http.WithConfigReloader(m))
+	if err != nil {
+		return err
+	}
+
+	logLevelsHandler := http.NewLogLevelsHandler(m.log.With(zap.String("handler", "log_levels")), m.levels)
+	eventLogHandler := eventlog.NewEventHandler(m.log.With(zap.String("handler", "event_log")), m.eventLog)
+
+	telemetryPusher, telemetryCategories, err := opts.Telemetry.Pusher(m.reg)
 	if err != nil {
+		m.log.Error("Failed to configure telemetry", zap.Error(err))
 		return err
 	}
+	telemetryPreviewHandler := telemetry.NewPreviewHandler(m.log.With(zap.String("handler", "telemetry_preview")), telemetryPusher, telemetryCategories, !opts.ReportingDisabled)
+
+	bundleHandler := pprof.NewBundleHandler(m.log.With(zap.String("handler", "debug_bundle")), pprof.BundleSources{
+		ProfilingEnabled: !opts.ProfilingDisabled,
+		Gatherer:         m.reg,
+		Logs:             m.recentLogs,
+		Info: map[string]interface{}{
+			"version":     info.Version,
+			"commit":      info.Commit,
+			"build_date":  info.Date,
+			"instance_id": opts.InstanceID,
+			"store":       opts.StoreType,
+			"log_level":   opts.LogLevel.String(),
+		},
+	})
 
-	platformHandler := http.NewPlatformHandler(
-		m.apibackend,
+	apiHandlerOpts := []http.APIHandlerOptFn{
 		http.WithResourceHandler(stacksHTTPServer),
 		http.WithResourceHandler(templatesHTTPServer),
 		http.WithResourceHandler(onboardHTTPServer),
@@ -898,27 +1662,79 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		http.WithResourceHandler(labelHandler),
 		http.WithResourceHandler(sessionHTTPServer.SignInResourceHandler()),
 		http.WithResourceHandler(sessionHTTPServer.SignOutResourceHandler()),
+		http.WithResourceHandler(sessionPolicyServer),
+		http.WithResourceHandler(dbrpAutoCreateServer),
+		http.WithResourceHandler(meSessionServer),
+		http.WithResourceHandler(userSessionServer),
 		http.WithResourceHandler(userHTTPServer),
 		http.WithResourceHandler(meHTTPServer),
 		http.WithResourceHandler(orgHTTPServer),
 		http.WithResourceHandler(bucketHTTPServer),
 		http.WithResourceHandler(v1AuthHTTPServer),
 		http.WithResourceHandler(dashboardServer),
+		http.WithResourceHandler(sharedDashboardServer),
+		http.WithResourceHandler(cellTemplateServer),
+		http.WithResourceHandler(reportServer),
+		http.WithResourceHandler(deletePolicyServer),
+		http.WithResourceHandler(transferJobServer),
+		http.WithResourceHandler(fluxPackageServer),
+		http.WithResourceHandler(awsRelayServer),
+		http.WithResourceHandler(deliveryRelayServer),
+		http.WithResourceHandler(silenceServer),
+		http.WithResourceHandler(secretUsageServer),
+		http.WithResourceHandler(checkTemplateServer),
+		http.WithResourceHandler(alertServer),
 		http.WithResourceHandler(notebookServer),
 		http.WithResourceHandler(annotationServer),
 		http.WithResourceHandler(remotesServer),
 		http.WithResourceHandler(replicationServer),
 		http.WithResourceHandler(configHandler),
-	)
+		http.WithResourceHandler(bundleHandler),
+		http.WithResourceHandler(logLevelsHandler),
+		http.WithResourceHandler(eventLogHandler),
+		http.WithResourceHandler(telemetryPreviewHandler),
+	}
+	if opts.SAML.Enabled() {
+		sp, err := saml.NewServiceProvider(opts.SAML)
+		if err != nil {
+			m.log.Error("Failed to configure SAML single sign-on", zap.Error(err))
+			return err
+		}
+		provisioner, err := saml.NewProvisioner(m.log.With(zap.String("service", "saml")), opts.SAML, ts.UserService, ts.OrganizationService, ts.UserResourceMappingService)
+		if err != nil {
+			m.log.Error("Failed to configure SAML single sign-on", zap.Error(err))
+			return err
+		}
+		samlServer := saml.NewHandler(m.log.With(zap.String("handler", "saml")), sp, provisioner, sessionSvc)
+		apiHandlerOpts = append(apiHandlerOpts, http.WithResourceHandler(samlServer))
+	}
+	if cdcHub != nil {
+		apiHandlerOpts = append(apiHandlerOpts, http.WithResourceHandler(cdc.NewHandler(m.log.With(zap.String("handler", "cdc")), cdcHub, ts.BucketService)))
+	}
+	if rep != nil {
+		apiHandlerOpts = append(apiHandlerOpts, http.WithResourceHandler(replica.NewHandler(m.log.With(zap.String("handler", "replica")), rep)))
+	}
+	platformHandler := http.NewPlatformHandler(m.apibackend, apiHandlerOpts...)
 
-	httpLogger := m.log.With(zap.String("service", "http"))
-	var httpHandler nethttp.Handler = http.NewRootHandler(
-		"platform",
+	httpLogger := m.subsystemLogger(influxlogger.SubsystemHTTP).With(zap.String("service", "http"))
+	rootHandlerOpts := []http.HandlerOptFn{
 		http.WithLog(httpLogger),
 		http.WithAPIHandler(platformHandler),
 		http.WithPprofEnabled(!opts.ProfilingDisabled),
 		http.WithMetrics(m.reg, !opts.MetricsDisabled),
-	)
+		http.WithMetricsFilter(opts.MetricsFilter),
+		http.WithAdminRoutesExposed(opts.AdminBindAddress == ""),
+	}
+	if opts.SLO.Enabled {
+		sloMetrics, err := kithttp.NewSLOMetrics(opts.SLO)
+		if err != nil {
+			m.log.Error("Failed to configure the service level objectives", zap.Error(err))
+			return err
+		}
+		rootHandlerOpts = append(rootHandlerOpts, http.WithSLOMetrics(sloMetrics))
+	}
+	rootHandler := http.NewRootHandler("platform", rootHandlerOpts...)
+	var httpHandler nethttp.Handler = rootHandler
 
 	if opts.LogLevel == zap.DebugLevel {
 		httpHandler = http.LoggingMW(httpLogger)(httpHandler)
@@ -928,12 +1744,31 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		httpHandler = http.Debug(ctx, httpHandler, m.flushers, onboardSvc)
 	}
 
+	// A replica rejects the requests which would change it.
+	if rep != nil {
+		httpHandler = replica.ReadOnly(httpHandler)
+	}
+
 	if !opts.ReportingDisabled {
-		m.runReporter(ctx)
+		m.runReporter(ctx, telemetryPusher)
 	}
 	if err := m.runHTTP(opts, httpHandler, httpLogger); err != nil {
 		return err
 	}
+	if opts.AdminBindAddress != "" {
+		if err := m.runAdminHTTP(opts, rootHandler.AdminHandler(), httpLogger); err != nil {
+			return err
+		}
+	}
+	if opts.HttpUnixSocket != "" {
+		if err := m.runUnixSocketHTTP(opts, httpHandler, httpLogger); err != nil {
+			return err
+		}
+	}
+	m.reloadOnSignal(ctx)
+	if m.certs != nil && opts.HttpTLSReloadInterval > 0 {
+		m.watchCerts(ctx, opts.HttpTLSReloadInterval)
+	}
 
 	return nil
 }
@@ -959,12 +1794,31 @@ func (m *Launcher) initTracing(opts *InfluxdOpts) {
 			return
 		}
 		m.closers = append(m.closers, labeledCloser{
-			label: "Jaeger tracer",
+			label:   "Jaeger tracer",
+			timeout: opts.ShutdownTimeout,
 			closer: func(context.Context) error {
 				return closer.Close()
 			},
 		})
 		opentracing.SetGlobalTracer(tracer)
+
+	case OTLPTracing:
+		m.log.Info("Tracing via OTLP", zap.String("endpoint", opts.TracingOTLP.Endpoint))
+		cfg := opts.TracingOTLP
+		cfg.ServiceVersion = platform.GetBuildInfo().Version
+		tracer, err := otlp.NewTracer(m.log.With(zap.String("service", "tracing")), cfg)
+		if err != nil {
+			m.log.Error("Failed to instantiate OTLP tracer", zap.Error(err))
+			return
+		}
+		m.closers = append(m.closers, labeledCloser{
+			label:   "OTLP tracer",
+			timeout: opts.ShutdownTimeout,
+			closer: func(context.Context) error {
+				return tracer.Close()
+			},
+		})
+		opentracing.SetGlobalTracer(tracer)
 	}
 }
 
@@ -994,7 +1848,8 @@ func (m *Launcher) openMetaStores(ctx context.Context, opts *InfluxdOpts) (strin
 			return "", err
 		}
 		m.closers = append(m.closers, labeledCloser{
-			label: "bolt",
+			label:   "bolt",
+			timeout: opts.ShutdownTimeout,
 			closer: func(context.Context) error {
 				return boltClient.Close()
 			},
@@ -1031,7 +1886,8 @@ func (m *Launcher) openMetaStores(ctx context.Context, opts *InfluxdOpts) (strin
 	}
 
 	m.closers = append(m.closers, labeledCloser{
-		label: "sqlite",
+		label:   "sqlite",
+		timeout: opts.ShutdownTimeout,
 		closer: func(context.Context) error {
 			return sqlStore.Close()
 		},
@@ -1059,6 +1915,11 @@ func (m *Launcher) openMetaStores(ctx context.Context, opts *InfluxdOpts) (strin
 		kvMigrator.SetBackupPath(fmt.Sprintf(backupPattern, opts.BoltPath, info.Version))
 		sqlMigrator.SetBackupPath(fmt.Sprintf(backupPattern, opts.SqLitePath, info.Version))
 	}
+	kvMigrations, err := kvMigrator.List(ctx)
+	if err != nil {
+		m.log.Error("Failed to list KV migrations", zap.Error(err))
+		return "", err
+	}
 	if err := kvMigrator.Up(ctx); err != nil {
 		m.log.Error("Failed to apply KV migrations", zap.Error(err))
 		return "", err
@@ -1070,6 +1931,26 @@ func (m *Launcher) openMetaStores(ctx context.Context, opts *InfluxdOpts) (strin
 
 	m.kvStore = kvStore
 	m.sqlStore = sqlStore
+
+	// The event log is stored by the KV store, so the migrations can only be
+	// recorded once they are applied.
+	m.eventLog = eventlog.NewService(m.log.With(zap.String("service", "event_log")), kvStore, opts.EventLogRetention)
+	for _, mig := range kvMigrations {
+		if mig.State == migration.UpMigrationState {
+			continue
+		}
+		if err := m.eventLog.RecordEvent(ctx, eventlog.Event{
+			Type:    eventlog.TypeMigration,
+			Message: "Applied metadata migration",
+			Fields: map[string]string{
+				"store":     "kv",
+				"id":        strconv.Itoa(int(mig.ID)),
+				"migration": mig.Name,
+			},
+		}); err != nil {
+			m.log.Warn("Failed to record the migration in the event log", zap.Error(err))
+		}
+	}
 	return procID, nil
 }
 
@@ -1089,8 +1970,9 @@ func (m *Launcher) runHTTP(opts *InfluxdOpts, handler nethttp.Handler, httpLogge
 		ErrorLog:          zap.NewStdLog(httpLogger),
 	}
 	m.closers = append(m.closers, labeledCloser{
-		label:  "HTTP server",
-		closer: httpServer.Shutdown,
+		label:   "HTTP server",
+		timeout: opts.HttpShutdownTimeout,
+		closer:  httpServer.Shutdown,
 	})
 
 	ln, err := net.Listen("tcp", opts.HttpBindAddress)
@@ -1103,7 +1985,7 @@ func (m *Launcher) runHTTP(opts *InfluxdOpts, handler nethttp.Handler, httpLogge
 	}
 	m.wg.Add(1)
 
-	m.tlsEnabled = opts.HttpTLSCert != "" && opts.HttpTLSKey != ""
+	m.tlsEnabled = (opts.HttpTLSCert != "" && opts.HttpTLSKey != "") || opts.HttpTLSACME.Enabled()
 	if !m.tlsEnabled {
 		if opts.HttpTLSCert != "" || opts.HttpTLSKey != "" {
 			log.Warn("TLS requires specifying both cert and key, falling back to HTTP")
@@ -1123,30 +2005,47 @@ func (m *Launcher) runHTTP(opts *InfluxdOpts, handler nethttp.Handler, httpLogge
 		return nil
 	}
 
-	if _, err = tls.LoadX509KeyPair(opts.HttpTLSCert, opts.HttpTLSKey); err != nil {
-		log.Error("Failed to load x509 key pair", zap.String("cert-path", opts.HttpTLSCert), zap.String("key-path", opts.HttpTLSKey))
-		return err
+	var getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)
+	var nextProtos []string
+	if opts.HttpTLSACME.Enabled() {
+		if opts.HttpTLSCert != "" || opts.HttpTLSKey != "" {
+			return errors.New("tls-acme-domains cannot be combined with tls-cert and tls-key")
+		}
+		if m.acme, err = newACMEManager(opts.HttpTLSACME); err != nil {
+			log.Error("Failed to configure ACME", zap.Error(err))
+			return err
+		}
+		log.Info("Obtaining TLS certificates using ACME", zap.Strings("domains", opts.HttpTLSACME.Domains))
+		getCertificate = m.acme.GetCertificate
+		// Advertising the ACME protocol allows TLS-ALPN-01 challenges to be answered.
+		nextProtos = []string{"h2", "http/1.1", acme.ALPNProto}
+
+		if opts.HttpTLSACME.HTTPChallengeAddress != "" {
+			if err := m.runACMEChallengeHTTP(opts, m.acme, httpLogger); err != nil {
+				return err
+			}
+		}
+	} else {
+		if m.certs, err = newCertLoader(opts.HttpTLSCert, opts.HttpTLSKey); err != nil {
+			log.Error("Failed to load x509 key pair", zap.String("cert-path", opts.HttpTLSCert), zap.String("key-path", opts.HttpTLSKey))
+			return err
+		}
+		getCertificate = m.certs.GetCertificate
 	}
 
-	var tlsMinVersion uint16
 	var useStrictCiphers = opts.HttpTLSStrictCiphers
-	switch opts.HttpTLSMinVersion {
-	case "1.0":
-		log.Warn("Setting the minimum version of TLS to 1.0 - this is discouraged. Please use 1.2 or 1.3")
-		tlsMinVersion = tls.VersionTLS10
-	case "1.1":
-		log.Warn("Setting the minimum version of TLS to 1.1 - this is discouraged. Please use 1.2 or 1.3")
-		tlsMinVersion = tls.VersionTLS11
-	case "1.2":
-		tlsMinVersion = tls.VersionTLS12
-	case "1.3":
+	tlsMinVersion, err := parseTLSMinVersion(opts.HttpTLSMinVersion)
+	if err != nil {
+		return err
+	}
+	switch tlsMinVersion {
+	case tls.VersionTLS10, tls.VersionTLS11:
+		log.Warn(fmt.Sprintf("Setting the minimum version of TLS to %s - this is discouraged. Please use 1.2 or 1.3", opts.HttpTLSMinVersion))
+	case tls.VersionTLS13:
 		if useStrictCiphers {
 			log.Warn("TLS version 1.3 does not support configuring strict ciphers")
 			useStrictCiphers = false
 		}
-		tlsMinVersion = tls.VersionTLS13
-	default:
-		return fmt.Errorf("unsupported TLS version: %s", opts.HttpTLSMinVersion)
 	}
 
 	// nil uses the default cipher suite
@@ -1168,13 +2067,16 @@ func (m *Launcher) runHTTP(opts *InfluxdOpts, handler nethttp.Handler, httpLogge
 		PreferServerCipherSuites: !useStrictCiphers,
 		MinVersion:               tlsMinVersion,
 		CipherSuites:             cipherConfig,
+		GetCertificate:           getCertificate,
+		NextProtos:               nextProtos,
 	}
 
 	go func(log *zap.Logger) {
 		defer m.wg.Done()
 		log.Info("Listening", zap.String("transport", "https"), zap.String("addr", opts.HttpBindAddress), zap.Int("port", m.httpPort))
 
-		if err := httpServer.ServeTLS(ln, opts.HttpTLSCert, opts.HttpTLSKey); err != nethttp.ErrServerClosed {
+		// The certificate is served by GetCertificate so it can be swapped without a restart.
+		if err := httpServer.ServeTLS(ln, "", ""); err != nethttp.ErrServerClosed {
 			log.Error("Failed to serve HTTPS", zap.Error(err))
 			m.cancel()
 		}
@@ -1184,9 +2086,156 @@ func (m *Launcher) runHTTP(opts *InfluxdOpts, handler nethttp.Handler, httpLogge
 	return nil
 }
 
-// runReporter configures and launches a periodic telemetry report for the server.
-func (m *Launcher) runReporter(ctx context.Context) {
+// unixSocketPrefix marks a bind address as the path to a unix domain socket.
+const unixSocketPrefix = "unix://"
+
+// listen opens a listener on addr, which is either a TCP host:port or a unix
+// socket path prefixed with unix://. Stale socket files are removed before binding.
+func listen(addr string) (net.Listener, error) {
+	if !strings.HasPrefix(addr, unixSocketPrefix) {
+		return net.Listen("tcp", addr)
+	}
+
+	path := strings.TrimPrefix(addr, unixSocketPrefix)
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale unix socket %q: %w", path, err)
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// runAdminHTTP launches a plain HTTP listener serving only administrative endpoints,
+// so they can be firewalled separately from the public API.
+// The listener is run in a separate goroutine. If it fails to start up, it
+// will cancel the launcher.
+func (m *Launcher) runAdminHTTP(opts *InfluxdOpts, handler nethttp.Handler, httpLogger *zap.Logger) error {
+	log := m.log.With(zap.String("service", "admin-listener"))
+
+	// WriteTimeout is left unset, since profiles are captured for a caller-specified duration.
+	adminServer := &nethttp.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: opts.HttpReadHeaderTimeout,
+		ReadTimeout:       opts.HttpReadTimeout,
+		IdleTimeout:       opts.HttpIdleTimeout,
+		ErrorLog:          zap.NewStdLog(httpLogger),
+	}
+	m.closers = append(m.closers, labeledCloser{
+		label:   "admin HTTP server",
+		timeout: opts.HttpShutdownTimeout,
+		closer:  adminServer.Shutdown,
+	})
+
+	ln, err := listen(opts.AdminBindAddress)
+	if err != nil {
+		log.Error("Failed to set up admin listener", zap.String("addr", opts.AdminBindAddress), zap.Error(err))
+		return err
+	}
+
+	m.wg.Add(1)
+	go func(log *zap.Logger) {
+		defer m.wg.Done()
+		log.Info("Listening", zap.String("transport", ln.Addr().Network()), zap.String("addr", ln.Addr().String()))
+
+		if err := adminServer.Serve(ln); err != nethttp.ErrServerClosed {
+			log.Error("Failed to serve admin HTTP", zap.Error(err))
+			m.cancel()
+		}
+		log.Info("Stopping")
+	}(log)
+
+	return nil
+}
+
+// trustedPeers parses a list of uids into a func reporting whether a unix socket
+// peer is granted operator access without a token.
+func trustedPeers(uids []string) (func(platcontext.PeerCredentials) bool, error) {
+	trusted := make(map[uint32]struct{}, len(uids))
+	for _, s := range uids {
+		uid, err := strconv.ParseUint(strings.TrimSpace(s), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid uid %q: %w", s, err)
+		}
+		trusted[uint32(uid)] = struct{}{}
+	}
+	return func(creds platcontext.PeerCredentials) bool {
+		_, ok := trusted[creds.UID]
+		return ok
+	}, nil
+}
+
+// runUnixSocketHTTP launches a plain HTTP listener serving the full API on a unix
+// domain socket. The credentials of each connecting process are attached to its
+// requests, allowing trusted local processes to be authenticated without a token.
+// The listener is run in a separate goroutine. If it fails to start up, it
+// will cancel the launcher.
+func (m *Launcher) runUnixSocketHTTP(opts *InfluxdOpts, handler nethttp.Handler, httpLogger *zap.Logger) error {
+	log := m.log.With(zap.String("service", "unix-socket-listener"))
+
+	unixServer := &nethttp.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: opts.HttpReadHeaderTimeout,
+		ReadTimeout:       opts.HttpReadTimeout,
+		WriteTimeout:      opts.HttpWriteTimeout,
+		IdleTimeout:       opts.HttpIdleTimeout,
+		ErrorLog:          zap.NewStdLog(httpLogger),
+		ConnContext: func(ctx context.Context, c net.Conn) context.Context {
+			creds, err := peerCredentials(c)
+			if err != nil {
+				log.Debug("Failed to read peer credentials; requests must authenticate with a token", zap.Error(err))
+				return ctx
+			}
+			return platcontext.SetPeerCredentials(ctx, creds)
+		},
+	}
+	m.closers = append(m.closers, labeledCloser{
+		label:   "unix socket HTTP server",
+		timeout: opts.HttpShutdownTimeout,
+		closer:  unixServer.Shutdown,
+	})
+
+	ln, err := listen(unixSocketPrefix + opts.HttpUnixSocket)
+	if err != nil {
+		log.Error("Failed to set up unix socket listener", zap.String("path", opts.HttpUnixSocket), zap.Error(err))
+		return err
+	}
+
+	m.wg.Add(1)
+	go func(log *zap.Logger) {
+		defer m.wg.Done()
+		log.Info("Listening", zap.String("transport", ln.Addr().Network()), zap.String("addr", ln.Addr().String()))
+
+		if err := unixServer.Serve(ln); err != nethttp.ErrServerClosed {
+			log.Error("Failed to serve HTTP on unix socket", zap.Error(err))
+			m.cancel()
+		}
+		log.Info("Stopping")
+	}(log)
+
+	return nil
+}
+
+// parseTLSMinVersion converts a tls-min-version option into its crypto/tls constant.
+func parseTLSMinVersion(v string) (uint16, error) {
+	switch v {
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS version: %s", v)
+	}
+}
+
+// runReporter configures and launches a periodic telemetry report for the server,
+// pushed by p.
+func (m *Launcher) runReporter(ctx context.Context, p *telemetry.Pusher) {
 	reporter := telemetry.NewReporter(m.log, m.reg)
+	reporter.Pusher = p
 	reporter.Interval = 8 * time.Hour
 	m.wg.Add(1)
 	go func() {
@@ -0,0 +1,44 @@
+//go:build !windows
+
+package launcher
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_listen(t *testing.T) {
+	t.Run("tcp", func(t *testing.T) {
+		ln, err := listen("127.0.0.1:0")
+		require.NoError(t, err)
+		defer ln.Close()
+		require.Equal(t, "tcp", ln.Addr().Network())
+	})
+
+	t.Run("unix socket replaces stale socket", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "influxd.sock")
+
+		// Leave a stale socket file behind, as a crashed process would.
+		stale, err := net.Listen("unix", path)
+		require.NoError(t, err)
+		stale.(*net.UnixListener).SetUnlinkOnClose(false)
+		require.NoError(t, stale.Close())
+
+		ln, err := listen(unixSocketPrefix + path)
+		require.NoError(t, err)
+		defer ln.Close()
+		require.Equal(t, "unix", ln.Addr().Network())
+	})
+
+	t.Run("unix socket path is not a socket", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "influxd.sock")
+		require.NoError(t, os.WriteFile(path, nil, 0600))
+
+		_, err := listen(unixSocketPrefix + path)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,34 @@
+package launcher
+
+import (
+	"errors"
+	"net"
+
+	platcontext "github.com/influxdata/influxdb/v2/context"
+	"golang.org/x/sys/unix"
+)
+
+// peerCredentials returns the credentials of the process on the other end of a
+// unix socket connection, as recorded by the kernel when it connected.
+func peerCredentials(conn net.Conn) (platcontext.PeerCredentials, error) {
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return platcontext.PeerCredentials{}, errors.New("peer credentials are only available for unix socket connections")
+	}
+	raw, err := uc.SyscallConn()
+	if err != nil {
+		return platcontext.PeerCredentials{}, err
+	}
+
+	var ucred *unix.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		ucred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	}); err != nil {
+		return platcontext.PeerCredentials{}, err
+	}
+	if credErr != nil {
+		return platcontext.PeerCredentials{}, credErr
+	}
+	return platcontext.PeerCredentials{PID: ucred.Pid, UID: ucred.Uid, GID: ucred.Gid}, nil
+}
@@ -0,0 +1,54 @@
+package launcher
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	platcontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_peerCredentials(t *testing.T) {
+	ln, err := listen(unixSocketPrefix + filepath.Join(t.TempDir(), "influxd.sock"))
+	require.NoError(t, err)
+	defer ln.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			close(accepted)
+			return
+		}
+		accepted <- conn
+	}()
+
+	client, err := net.Dial("unix", ln.Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+
+	server, ok := <-accepted
+	require.True(t, ok)
+	defer server.Close()
+
+	creds, err := peerCredentials(server)
+	require.NoError(t, err)
+	require.Equal(t, platcontext.PeerCredentials{
+		PID: int32(os.Getpid()),
+		UID: uint32(os.Getuid()),
+		GID: uint32(os.Getgid()),
+	}, creds)
+}
+
+func Test_trustedPeers(t *testing.T) {
+	trusted, err := trustedPeers([]string{"0", " 1000"})
+	require.NoError(t, err)
+	require.True(t, trusted(platcontext.PeerCredentials{UID: 0}))
+	require.True(t, trusted(platcontext.PeerCredentials{UID: 1000}))
+	require.False(t, trusted(platcontext.PeerCredentials{UID: 1001}))
+
+	_, err = trustedPeers([]string{"influxdb"})
+	require.Error(t, err)
+}
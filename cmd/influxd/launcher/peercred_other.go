@@ -0,0 +1,16 @@
+//go:build !linux
+
+package launcher
+
+import (
+	"errors"
+	"net"
+
+	platcontext "github.com/influxdata/influxdb/v2/context"
+)
+
+// peerCredentials is only supported on Linux. Elsewhere, requests received over
+// a unix socket must authenticate with a token like any other request.
+func peerCredentials(net.Conn) (platcontext.PeerCredentials, error) {
+	return platcontext.PeerCredentials{}, errors.New("peer credentials are not supported on this platform")
+}
@@ -0,0 +1,439 @@
+package launcher
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/eventlog"
+	"github.com/influxdata/influxdb/v2/http"
+	"github.com/influxdata/influxdb/v2/kit/cli"
+	"github.com/influxdata/influxdb/v2/query/control"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// reloadableOpts lists the options which can be applied to a running server.
+// Every other option reports that a restart is required when its value changes.
+var reloadableOpts = map[string]struct{}{
+	"log-level":                       {},
+	"tls-cert":                        {},
+	"tls-key":                         {},
+	"query-concurrency":               {},
+	"query-initial-memory-bytes":      {},
+	"query-memory-bytes":              {},
+	"query-max-memory-bytes":          {},
+	"query-queue-size":                {},
+	"org-memory-budget-bytes":         {},
+	"org-memory-budget-queue-timeout": {},
+}
+
+// queryLimitOpts are the options of the limits of the query controller. They
+// are checked against each other, so they are applied, or skipped, together.
+var queryLimitOpts = []string{
+	"query-concurrency",
+	"query-initial-memory-bytes",
+	"query-memory-bytes",
+	"query-max-memory-bytes",
+	"query-queue-size",
+}
+
+// orgMemoryOpts are the options of the memory budgets of organizations.
+var orgMemoryOpts = []string{
+	"org-memory-budget-bytes",
+	"org-memory-budget-queue-timeout",
+}
+
+// certLoader serves the TLS certificate used by the HTTP listener, allowing it to
+// be swapped without restarting the listener.
+type certLoader struct {
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	certFile string
+	keyFile  string
+	// stamp identifies the versions of certFile and keyFile that cert was loaded from.
+	stamp string
+}
+
+func newCertLoader(certFile, keyFile string) (*certLoader, error) {
+	c := &certLoader{}
+	if err := c.load(certFile, keyFile); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// load reads a key pair from disk and, if valid, replaces the served certificate.
+func (c *certLoader) load(certFile, keyFile string) error {
+	stamp, err := fileStamp(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.cert = &cert
+	c.certFile, c.keyFile = certFile, keyFile
+	c.stamp = stamp
+	c.mu.Unlock()
+	return nil
+}
+
+// reloadIfChanged reloads the key pair if either file has been modified since it
+// was last loaded. It reports whether a new certificate is being served.
+func (c *certLoader) reloadIfChanged() (bool, error) {
+	c.mu.RLock()
+	certFile, keyFile, prev := c.certFile, c.keyFile, c.stamp
+	c.mu.RUnlock()
+
+	stamp, err := fileStamp(certFile, keyFile)
+	if err != nil {
+		return false, err
+	}
+	if stamp == prev {
+		return false, nil
+	}
+	if err := c.load(certFile, keyFile); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// fileStamp summarizes the size and modification time of each file, so that
+// replacing a file in place or via a symlink swap is detected.
+func fileStamp(paths ...string) (string, error) {
+	var sb strings.Builder
+	for _, p := range paths {
+		fi, err := os.Stat(p)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&sb, "%s:%d:%d;", p, fi.Size(), fi.ModTime().UnixNano())
+	}
+	return sb.String(), nil
+}
+
+// GetCertificate satisfies the tls.Config GetCertificate callback.
+func (c *certLoader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cert, nil
+}
+
+// changedFlags returns the names of all flags explicitly set on the command line.
+func changedFlags(cmd *cobra.Command) map[string]struct{} {
+	changed := make(map[string]struct{})
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		changed[f.Name] = struct{}{}
+	})
+	return changed
+}
+
+// loadOpts builds a fresh set of options from the config file and environment
+// used to start the server with cur.
+func loadOpts(cur *InfluxdOpts) (*InfluxdOpts, error) {
+	v := viper.New()
+	v.SetEnvPrefix("INFLUXD")
+	v.AutomaticEnv()
+	v.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	if p := cur.Viper.ConfigFileUsed(); p != "" {
+		v.SetConfigFile(p)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to read config file %s: %w", p, err)
+		}
+	}
+	if invalid := invalidFlags(v); len(invalid) > 0 {
+		return nil, errInvalidFlags(invalid, v.ConfigFileUsed())
+	}
+
+	next := NewOpts(v)
+	if err := cli.BindOptions(v, &cobra.Command{}, next.BindCliOpts()); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// optValues returns the JSON encoding of the value of each flag of o.
+func optValues(o *InfluxdOpts) (map[string][]byte, error) {
+	vals := make(map[string][]byte)
+	for _, opt := range o.BindCliOpts() {
+		b, err := json.Marshal(opt.DestP)
+		if err != nil {
+			return nil, err
+		}
+		vals[opt.Flag] = b
+	}
+	return vals, nil
+}
+
+// diffOpts returns the flags whose values differ between cur and next, sorted by name.
+func diffOpts(cur, next *InfluxdOpts) ([]string, error) {
+	curVals, err := optValues(cur)
+	if err != nil {
+		return nil, err
+	}
+	nextVals, err := optValues(next)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for flag, b := range curVals {
+		if !bytes.Equal(b, nextVals[flag]) {
+			changed = append(changed, flag)
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// queryLimits returns the limits of the query controller set by o.
+func queryLimits(o *InfluxdOpts) control.Config {
+	return control.Config{
+		ConcurrencyQuota:                o.ConcurrencyQuota,
+		InitialMemoryBytesQuotaPerQuery: o.InitialMemoryBytesQuotaPerQuery,
+		MemoryBytesQuotaPerQuery:        o.MemoryBytesQuotaPerQuery,
+		MaxMemoryBytes:                  o.MaxMemoryBytes,
+		QueueSize:                       o.QueueSize,
+	}
+}
+
+// appliedOf returns the flags of group which are to be applied.
+func appliedOf(apply map[string]struct{}, group []string) []string {
+	var flags []string
+	for _, flag := range group {
+		if _, ok := apply[flag]; ok {
+			flags = append(flags, flag)
+		}
+	}
+	return flags
+}
+
+// reportGroup records the flags of a group as applied, or as skipped for the
+// reason err when applying them failed.
+func reportGroup(res *http.ConfigReloadResult, flags []string, err error) {
+	for _, flag := range flags {
+		if err != nil {
+			res.Skipped[flag] = err.Error()
+			continue
+		}
+		res.Applied = append(res.Applied, flag)
+	}
+}
+
+// ReloadConfig re-reads the config file and environment, applying every changed
+// setting that can take effect without a restart. Options set on the command line
+// always take precedence and are never changed by a reload.
+func (m *Launcher) ReloadConfig(ctx context.Context) (*http.ConfigReloadResult, error) {
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+
+	next, err := loadOpts(m.opts)
+	if err != nil {
+		m.log.Error("Failed to reload configuration", zap.Error(err))
+		return nil, err
+	}
+	changed, err := diffOpts(m.opts, next)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &http.ConfigReloadResult{
+		Applied: []string{},
+		Skipped: map[string]string{},
+	}
+	apply := make(map[string]struct{})
+	for _, flag := range changed {
+		if _, ok := m.opts.cliFlags[flag]; ok {
+			res.Skipped[flag] = "set on the command line"
+			continue
+		}
+		if _, ok := reloadableOpts[flag]; !ok {
+			res.Skipped[flag] = "requires restart"
+			continue
+		}
+		apply[flag] = struct{}{}
+	}
+
+	if _, ok := apply["log-level"]; ok {
+		m.logLevel.SetLevel(next.LogLevel)
+		m.opts.LogLevel = next.LogLevel
+		res.Applied = append(res.Applied, "log-level")
+	}
+
+	_, certChanged := apply["tls-cert"]
+	_, keyChanged := apply["tls-key"]
+	if certChanged || keyChanged {
+		certFile, keyFile := m.opts.HttpTLSCert, m.opts.HttpTLSKey
+		if certChanged {
+			certFile = next.HttpTLSCert
+		}
+		if keyChanged {
+			keyFile = next.HttpTLSKey
+		}
+		var reason string
+		if m.acme != nil {
+			reason = "certificates are managed by ACME"
+		} else if m.certs == nil {
+			reason = "TLS was not enabled at startup; requires restart"
+		} else if err := m.certs.load(certFile, keyFile); err != nil {
+			m.log.Error("Failed to reload x509 key pair", zap.String("cert-path", certFile), zap.String("key-path", keyFile), zap.Error(err))
+			reason = fmt.Sprintf("failed to load x509 key pair: %v", err)
+		}
+		for flag, ok := range map[string]bool{"tls-cert": certChanged, "tls-key": keyChanged} {
+			if !ok {
+				continue
+			}
+			if reason != "" {
+				res.Skipped[flag] = reason
+				continue
+			}
+			res.Applied = append(res.Applied, flag)
+		}
+		if reason == "" {
+			m.opts.HttpTLSCert, m.opts.HttpTLSKey = certFile, keyFile
+		}
+	}
+
+	if flags := appliedOf(apply, queryLimitOpts); len(flags) > 0 {
+		// The limits which are not applied keep their current value.
+		limits, cur := queryLimits(next), queryLimits(m.opts)
+		if _, ok := apply["query-concurrency"]; !ok {
+			limits.ConcurrencyQuota = cur.ConcurrencyQuota
+		}
+		if _, ok := apply["query-initial-memory-bytes"]; !ok {
+			limits.InitialMemoryBytesQuotaPerQuery = cur.InitialMemoryBytesQuotaPerQuery
+		}
+		if _, ok := apply["query-memory-bytes"]; !ok {
+			limits.MemoryBytesQuotaPerQuery = cur.MemoryBytesQuotaPerQuery
+		}
+		if _, ok := apply["query-max-memory-bytes"]; !ok {
+			limits.MaxMemoryBytes = cur.MaxMemoryBytes
+		}
+		if _, ok := apply["query-queue-size"]; !ok {
+			limits.QueueSize = cur.QueueSize
+		}
+
+		err := errors.New("the query controller is not running")
+		if m.queryController != nil {
+			err = m.queryController.UpdateLimits(limits)
+		}
+		if err != nil {
+			m.log.Error("Failed to update the query controller limits", zap.Error(err))
+		} else {
+			m.opts.ConcurrencyQuota = limits.ConcurrencyQuota
+			m.opts.InitialMemoryBytesQuotaPerQuery = limits.InitialMemoryBytesQuotaPerQuery
+			m.opts.MemoryBytesQuotaPerQuery = limits.MemoryBytesQuotaPerQuery
+			m.opts.MaxMemoryBytes = limits.MaxMemoryBytes
+			m.opts.QueueSize = limits.QueueSize
+		}
+		reportGroup(res, flags, err)
+	}
+
+	if flags := appliedOf(apply, orgMemoryOpts); len(flags) > 0 {
+		budget := m.opts.OrgMemory
+		if _, ok := apply["org-memory-budget-bytes"]; ok {
+			budget.OrgBytes = next.OrgMemory.OrgBytes
+		}
+		if _, ok := apply["org-memory-budget-queue-timeout"]; ok {
+			budget.QueueTimeout = next.OrgMemory.QueueTimeout
+		}
+
+		err := m.orgMemory.Update(budget)
+		if err != nil {
+			m.log.Error("Failed to update the memory budgets of organizations", zap.Error(err))
+		} else {
+			m.opts.OrgMemory = budget
+		}
+		reportGroup(res, flags, err)
+	}
+	sort.Strings(res.Applied)
+
+	for _, flag := range res.Applied {
+		m.log.Info("Applied reloaded configuration option", zap.String("option", flag))
+	}
+	for flag, reason := range res.Skipped {
+		m.log.Warn("Skipped reloaded configuration option", zap.String("option", flag), zap.String("reason", reason))
+	}
+	if len(changed) > 0 && m.eventLog != nil {
+		skipped := make([]string, 0, len(res.Skipped))
+		for flag := range res.Skipped {
+			skipped = append(skipped, flag)
+		}
+		sort.Strings(skipped)
+		if err := m.eventLog.RecordEvent(ctx, eventlog.Event{
+			Type:    eventlog.TypeConfig,
+			Message: "Reloaded configuration",
+			Fields: map[string]string{
+				"applied": strings.Join(res.Applied, ","),
+				"skipped": strings.Join(skipped, ","),
+			},
+		}); err != nil {
+			m.log.Warn("Failed to record the configuration reload in the event log", zap.Error(err))
+		}
+	}
+	return res, nil
+}
+
+// reloadOnSignal reloads the server configuration each time the process receives SIGHUP.
+func (m *Launcher) reloadOnSignal(ctx context.Context) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer signal.Stop(sigCh)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sigCh:
+				m.log.Info("Received SIGHUP, reloading configuration")
+				_, _ = m.ReloadConfig(ctx)
+			}
+		}
+	}()
+}
+
+// watchCerts polls the TLS certificate and key files, serving the new key pair
+// whenever they are replaced on disk. If the new files cannot be loaded, the
+// previous certificate continues to be served.
+func (m *Launcher) watchCerts(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				reloaded, err := m.certs.reloadIfChanged()
+				if err != nil {
+					m.log.Error("Failed to reload x509 key pair", zap.Error(err))
+					continue
+				}
+				if reloaded {
+					m.log.Info("Reloaded x509 key pair")
+				}
+			}
+		}
+	}()
+}
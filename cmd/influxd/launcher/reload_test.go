@@ -0,0 +1,156 @@
+package launcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/membudget"
+	"github.com/influxdata/influxdb/v2/query/control"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest"
+)
+
+func newReloadTestLauncher(t *testing.T, config string) (*Launcher, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(config), 0600))
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	opts, err := loadOpts(&InfluxdOpts{Viper: v})
+	require.NoError(t, err)
+
+	l := NewLauncher()
+	l.log = zaptest.NewLogger(t)
+	l.logLevel.SetLevel(opts.LogLevel)
+	l.opts = opts
+	return l, path
+}
+
+func TestLauncher_ReloadConfig(t *testing.T) {
+	l, path := newReloadTestLauncher(t, "log-level = \"info\"\nhttp-bind-address = \":8086\"\n")
+
+	require.NoError(t, os.WriteFile(path, []byte("log-level = \"debug\"\nhttp-bind-address = \":9999\"\n"), 0600))
+	res, err := l.ReloadConfig(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"log-level"}, res.Applied)
+	require.Equal(t, map[string]string{"http-bind-address": "requires restart"}, res.Skipped)
+	require.Equal(t, zapcore.DebugLevel, l.logLevel.Level())
+	require.Equal(t, zapcore.DebugLevel, l.opts.LogLevel)
+	require.Equal(t, ":8086", l.opts.HttpBindAddress)
+}
+
+func TestLauncher_ReloadConfig_CLIFlagsTakePrecedence(t *testing.T) {
+	l, path := newReloadTestLauncher(t, "log-level = \"info\"\n")
+	l.opts.cliFlags = map[string]struct{}{"log-level": {}}
+
+	require.NoError(t, os.WriteFile(path, []byte("log-level = \"error\"\n"), 0600))
+	res, err := l.ReloadConfig(context.Background())
+	require.NoError(t, err)
+
+	require.Empty(t, res.Applied)
+	require.Equal(t, map[string]string{"log-level": "set on the command line"}, res.Skipped)
+	require.Equal(t, zapcore.InfoLevel, l.logLevel.Level())
+}
+
+func TestLauncher_ReloadConfig_TLSNotEnabled(t *testing.T) {
+	l, path := newReloadTestLauncher(t, "")
+
+	require.NoError(t, os.WriteFile(path, []byte("tls-cert = \"/tmp/cert.pem\"\n"), 0600))
+	res, err := l.ReloadConfig(context.Background())
+	require.NoError(t, err)
+
+	require.Empty(t, res.Applied)
+	require.Contains(t, res.Skipped, "tls-cert")
+	require.Empty(t, l.opts.HttpTLSCert)
+}
+
+func TestLauncher_ReloadConfig_InvalidConfig(t *testing.T) {
+	l, path := newReloadTestLauncher(t, "")
+
+	require.NoError(t, os.WriteFile(path, []byte("[http]\nbind-address = \":8086\"\n"), 0600))
+	_, err := l.ReloadConfig(context.Background())
+	require.Error(t, err)
+}
+
+func TestLauncher_ReloadConfig_Limits(t *testing.T) {
+	l, path := newReloadTestLauncher(t, "query-concurrency = 2\nquery-queue-size = 2\norg-memory-budget-bytes = 1024\n")
+	ctrl, err := control.New(queryLimits(l.opts), zaptest.NewLogger(t))
+	require.NoError(t, err)
+	defer ctrl.Shutdown(context.Background())
+	l.queryController = ctrl
+	l.orgMemory = membudget.NewAccountant(l.opts.OrgMemory)
+
+	require.NoError(t, os.WriteFile(path, []byte("query-concurrency = 4\nquery-queue-size = 8\norg-memory-budget-bytes = 2048\norg-memory-budget-queue-timeout = \"1s\"\n"), 0600))
+	res, err := l.ReloadConfig(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"org-memory-budget-bytes", "org-memory-budget-queue-timeout", "query-concurrency", "query-queue-size"}, res.Applied)
+	require.Empty(t, res.Skipped)
+	require.Equal(t, int32(4), l.opts.ConcurrencyQuota)
+	require.Equal(t, int32(8), l.opts.QueueSize)
+	require.Equal(t, int64(2048), l.opts.OrgMemory.OrgBytes)
+	require.Equal(t, time.Second, l.opts.OrgMemory.QueueTimeout)
+
+	// The budget holds requests as large as the reloaded budget.
+	r, err := l.orgMemory.Reserve(context.Background(), platform.ID(1), 2048)
+	require.NoError(t, err)
+	r.Release()
+
+	// Limits the controller rejects are skipped together, keeping their values.
+	require.NoError(t, os.WriteFile(path, []byte("query-concurrency = 0\nquery-queue-size = 8\norg-memory-budget-bytes = 0\norg-memory-budget-queue-timeout = \"1s\"\n"), 0600))
+	res, err = l.ReloadConfig(context.Background())
+	require.NoError(t, err)
+
+	require.Empty(t, res.Applied)
+	require.Contains(t, res.Skipped, "query-concurrency")
+	require.Contains(t, res.Skipped, "org-memory-budget-bytes")
+	require.Equal(t, int32(4), l.opts.ConcurrencyQuota)
+	require.Equal(t, int64(2048), l.opts.OrgMemory.OrgBytes)
+}
+
+func TestCertLoader_ReloadIfChanged(t *testing.T) {
+	now := time.Now()
+	certFile, keyFile := writeTestKeyPair(t, now.Add(-time.Hour), now.Add(time.Hour))
+	c, err := newCertLoader(certFile, keyFile)
+	require.NoError(t, err)
+	first, err := c.GetCertificate(nil)
+	require.NoError(t, err)
+
+	reloaded, err := c.reloadIfChanged()
+	require.NoError(t, err)
+	require.False(t, reloaded)
+
+	// Replace the key pair in place, as a certificate renewal job would.
+	nextCert, nextKey := writeTestKeyPair(t, now.Add(-time.Hour), now.Add(2*time.Hour))
+	for src, dst := range map[string]string{nextCert: certFile, nextKey: keyFile} {
+		b, err := os.ReadFile(src)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(dst, b, 0600))
+		future := now.Add(time.Minute)
+		require.NoError(t, os.Chtimes(dst, future, future))
+	}
+
+	reloaded, err = c.reloadIfChanged()
+	require.NoError(t, err)
+	require.True(t, reloaded)
+	second, err := c.GetCertificate(nil)
+	require.NoError(t, err)
+	require.NotEqual(t, first.Certificate[0], second.Certificate[0])
+
+	// A broken replacement leaves the previous certificate in place.
+	require.NoError(t, os.WriteFile(certFile, []byte("not a certificate"), 0600))
+	_, err = c.reloadIfChanged()
+	require.Error(t, err)
+	current, err := c.GetCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, second, current)
+}
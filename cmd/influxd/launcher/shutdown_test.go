@@ -0,0 +1,68 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestLauncher_ShutdownTimeouts(t *testing.T) {
+	var stopped []string
+	block := make(chan struct{})
+	defer close(block)
+
+	l := NewLauncher()
+	l.log = zaptest.NewLogger(t)
+	l.closers = []labeledCloser{
+		{
+			label: "storage",
+			closer: func(context.Context) error {
+				stopped = append(stopped, "storage")
+				return nil
+			},
+		},
+		{
+			label:   "task",
+			timeout: 10 * time.Millisecond,
+			closer: func(context.Context) error {
+				// Ignores its context, as the task scheduler does.
+				<-block
+				return nil
+			},
+		},
+		{
+			label:   "HTTP server",
+			timeout: time.Second,
+			closer: func(ctx context.Context) error {
+				_, ok := ctx.Deadline()
+				require.True(t, ok)
+				stopped = append(stopped, "HTTP server")
+				return nil
+			},
+		},
+	}
+
+	_, bounded := l.ShutdownTimeout()
+	require.False(t, bounded)
+
+	err := l.Shutdown(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "task did not stop within 10ms")
+	require.Equal(t, []string{"HTTP server", "storage"}, stopped)
+}
+
+func TestLauncher_ShutdownTimeout(t *testing.T) {
+	l := NewLauncher()
+	l.closers = []labeledCloser{
+		{label: "a", timeout: time.Second, closer: func(context.Context) error { return nil }},
+		{label: "b", timeout: 2 * time.Second, closer: func(context.Context) error { return errors.New("unused") }},
+	}
+
+	timeout, bounded := l.ShutdownTimeout()
+	require.True(t, bounded)
+	require.Equal(t, 3*time.Second, timeout)
+}
@@ -0,0 +1,368 @@
+package launcher
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/cli"
+	influxlogger "github.com/influxdata/influxdb/v2/logger"
+	"github.com/influxdata/influxdb/v2/query/control"
+	"github.com/influxdata/influxdb/v2/secret/kms"
+	"github.com/influxdata/influxdb/v2/sqlite"
+	"github.com/influxdata/influxdb/v2/telemetry"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+)
+
+// redactedOpts are never printed in the resolved config, since CI logs are often public.
+var redactedOpts = map[string]struct{}{
+	"vault-token":           {},
+	"vault-secret-id":       {},
+	"replica-primary-token": {},
+	"tracing-otlp-headers":  {},
+}
+
+func NewInfluxdValidateConfigCommand(v *viper.Viper, o *InfluxdOpts) (*cobra.Command, error) {
+	influxdOpts := o.BindCliOpts()
+	validateOpts := make([]cli.Opt, len(influxdOpts))
+	for i, opt := range influxdOpts {
+		validateOpts[i] = cli.Opt{
+			DestP:  opt.DestP,
+			Flag:   opt.Flag,
+			Hidden: true,
+		}
+	}
+
+	cmd := &cobra.Command{
+		Use:   "validate-config",
+		Short: "Validate the influxd config resolved from the current environment",
+		Long: `
+Load the config that the influxd server would use if run with the current flags/env vars/config file,
+check it for problems, and print the fully resolved config (in YAML) without starting the server.
+
+The following checks are performed:
+	- every key in the config file is a known option
+	- enumerated options (store, secret-store, tracing-type, tls-min-version, log formats) have supported values
+	- query controller limits are consistent with each other
+	- bolt, sqlite and engine paths are writable by the current user
+	- TLS certificate and key can be loaded and the certificate has not expired
+	- ACME options are complete and the certificate cache is writable
+	- a replica has a writer user for the replication streams of its primary
+
+The command exits with a non-zero status if any problem is found, making it suitable
+for validating config changes in CI.
+
+See 'influxd print-config -h' for the order of precedence of config options.
+`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := printResolvedConfig(validateOpts, cmd.OutOrStdout()); err != nil {
+				return fmt.Errorf("failed to print config: %w", err)
+			}
+
+			problems := validateConfig(v, o)
+			for _, p := range problems {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "error: %v\n", p)
+			}
+			if len(problems) > 0 {
+				return fmt.Errorf("config validation failed with %d problem(s)", len(problems))
+			}
+			_, _ = fmt.Fprintln(cmd.ErrOrStderr(), "config is valid")
+			return nil
+		},
+		Args: cobra.NoArgs,
+	}
+	if err := cli.BindOptions(v, cmd, validateOpts); err != nil {
+		return nil, err
+	}
+
+	return cmd, nil
+}
+
+func printResolvedConfig(configOpts []cli.Opt, out io.Writer) error {
+	configMap := make(map[string]interface{}, len(configOpts))
+	for _, o := range configOpts {
+		if _, ok := redactedOpts[o.Flag]; ok {
+			configMap[o.Flag] = "<redacted>"
+			continue
+		}
+		configMap[o.Flag] = o.DestP
+	}
+
+	return yaml.NewEncoder(out).Encode(configMap)
+}
+
+// validateConfig returns every problem found with the resolved options.
+func validateConfig(v *viper.Viper, o *InfluxdOpts) []error {
+	var problems []error
+
+	if p := v.ConfigFileUsed(); p != "" {
+		keys, err := configFileKeys(p)
+		if err != nil {
+			problems = append(problems, err)
+		}
+		known := make(map[string]struct{})
+		for _, opt := range o.BindCliOpts() {
+			known[opt.Flag] = struct{}{}
+		}
+		for _, k := range keys {
+			if _, ok := known[k]; !ok {
+				problems = append(problems, fmt.Errorf("unknown key %q in config file %s", k, p))
+			}
+		}
+	}
+
+	switch o.StoreType {
+	case DiskStore, BoltStore, MemoryStore:
+	default:
+		problems = append(problems, fmt.Errorf("unknown store type %q; expected disk or memory", o.StoreType))
+	}
+	switch o.SecretStore {
+	case BoltStore, "vault", "aws", "gcp":
+	default:
+		problems = append(problems, fmt.Errorf("unknown secret store %q; expected bolt, vault, aws or gcp", o.SecretStore))
+	}
+	switch o.SecretEncryption.Provider {
+	case "":
+	case kms.LocalProvider, kms.AWSProvider, kms.GCPProvider:
+		if o.SecretStore != BoltStore {
+			problems = append(problems, fmt.Errorf("secret encryption %q only encrypts the bolt secret store, not %q", o.SecretEncryption.Provider, o.SecretStore))
+		}
+	default:
+		problems = append(problems, fmt.Errorf("unknown secret encryption %q; expected local, aws or gcp", o.SecretEncryption.Provider))
+	}
+	if o.SAML.Enabled() && o.SAML.RootURL == "" {
+		problems = append(problems, fmt.Errorf("SAML single sign-on requires the external URL of influxd with saml-root-url"))
+	}
+	if o.OrgMetrics.Enabled && o.OrgMetrics.Interval <= 0 {
+		problems = append(problems, fmt.Errorf("org-metrics-interval must be positive"))
+	}
+	if o.SelfMonitoring.Enabled && o.SelfMonitoring.Interval <= 0 {
+		problems = append(problems, fmt.Errorf("self-monitoring-interval must be positive"))
+	}
+	if o.SelfMonitoring.Cardinality.Enabled {
+		if !o.SelfMonitoring.Enabled {
+			problems = append(problems, fmt.Errorf("cardinality-check-enabled requires self-monitoring-enabled"))
+		}
+		if err := o.SelfMonitoring.Cardinality.Valid(); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	if o.SLO.Enabled {
+		if err := o.SLO.Write.Valid(); err != nil {
+			problems = append(problems, fmt.Errorf("slo-write: %w", err))
+		}
+		if err := o.SLO.Query.Valid(); err != nil {
+			problems = append(problems, fmt.Errorf("slo-query: %w", err))
+		}
+		if o.SLO.MaxOrgs < 0 {
+			problems = append(problems, fmt.Errorf("slo-metrics-max-orgs must not be negative"))
+		}
+	}
+	if _, err := telemetry.ParseCategories(o.Telemetry.Categories); err != nil {
+		problems = append(problems, fmt.Errorf("telemetry-categories: %w", err))
+	}
+	if !o.ReportingDisabled && o.Telemetry.URL == "" {
+		problems = append(problems, fmt.Errorf("telemetry-url must not be empty"))
+	}
+	if o.DBRPAutoCreate.RetentionPeriod < 0 {
+		problems = append(problems, fmt.Errorf("v1-dbrp-auto-create-retention must not be negative"))
+	}
+	if o.EventLogRetention < 0 {
+		problems = append(problems, fmt.Errorf("event-log-retention must not be negative"))
+	}
+	if err := o.PasswordPolicy.Valid(); err != nil {
+		problems = append(problems, fmt.Errorf("password policy: %w", err))
+	}
+	if o.V1PasswordLockoutThreshold < 0 {
+		problems = append(problems, fmt.Errorf("v1-password-lockout-threshold must not be negative"))
+	}
+	if o.V1PasswordLockoutThreshold > 0 && o.V1PasswordLockoutWindow <= 0 {
+		problems = append(problems, fmt.Errorf("v1-password-lockout-window must be positive"))
+	}
+	if o.V1AuthCacheSize < 0 {
+		problems = append(problems, fmt.Errorf("v1-auth-cache-size must not be negative"))
+	}
+	if o.V1AuthCacheSize > 0 && o.V1AuthCacheTTL <= 0 {
+		problems = append(problems, fmt.Errorf("v1-auth-cache-ttl must be positive"))
+	}
+	if o.V1OrgTokenQuota < 0 {
+		problems = append(problems, fmt.Errorf("v1-org-token-quota must not be negative"))
+	}
+	if o.SelfMonitoring.Enabled && o.SelfMonitoring.Retention < 0 {
+		problems = append(problems, fmt.Errorf("self-monitoring-retention must not be negative"))
+	}
+	switch o.TracingType {
+	case "", LogTracing, JaegerTracing:
+	case OTLPTracing:
+		if o.TracingOTLP.SampleRatio < 0 || o.TracingOTLP.SampleRatio > 1 {
+			problems = append(problems, fmt.Errorf("tracing-otlp-sample-ratio must be between 0 and 1"))
+		}
+	default:
+		problems = append(problems, fmt.Errorf("unknown tracing type %q; expected %s, %s or %s", o.TracingType, LogTracing, OTLPTracing, JaegerTracing))
+	}
+
+	switch o.LogFormat {
+	case "", "auto", "logfmt", "json":
+	default:
+		problems = append(problems, fmt.Errorf("unknown log format %q; expected auto, logfmt or json", o.LogFormat))
+	}
+	if o.LogFile != "" {
+		switch o.LogFileFormat {
+		case "logfmt", "json":
+		default:
+			problems = append(problems, fmt.Errorf("unknown log file format %q; expected logfmt or json", o.LogFileFormat))
+		}
+	}
+	levels := influxlogger.NewLevels(zap.NewAtomicLevel(), influxlogger.Subsystems...)
+	for subsystem, val := range o.LogSubsystemLevels {
+		var lvl zapcore.Level
+		if err := lvl.UnmarshalText([]byte(val)); err != nil {
+			problems = append(problems, fmt.Errorf("invalid log level %q for subsystem %q", val, subsystem))
+		} else if err := levels.SetLevel(subsystem, &lvl); err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	if o.Replica.Enabled() && o.Replica.WriterUser == "" {
+		problems = append(problems, errors.New("replica-primary-url requires replica-writer-user, the user the replication streams of the primary write with"))
+	}
+
+	queryConfig := control.Config{
+		ConcurrencyQuota:                o.ConcurrencyQuota,
+		InitialMemoryBytesQuotaPerQuery: o.InitialMemoryBytesQuotaPerQuery,
+		MemoryBytesQuotaPerQuery:        o.MemoryBytesQuotaPerQuery,
+		MaxMemoryBytes:                  o.MaxMemoryBytes,
+		QueueSize:                       o.QueueSize,
+	}
+	if err := queryConfig.Validate(zap.NewNop()); err != nil {
+		problems = append(problems, fmt.Errorf("invalid query config: %w", err))
+	}
+
+	if o.StoreType != MemoryStore {
+		sqlitePath := o.SqLitePath
+		if sqlitePath == "" {
+			sqlitePath = filepath.Join(filepath.Dir(o.BoltPath), sqlite.DefaultFilename)
+		}
+		if err := checkWritable(filepath.Dir(o.BoltPath)); err != nil {
+			problems = append(problems, fmt.Errorf("bolt-path %q: %w", o.BoltPath, err))
+		}
+		if err := checkWritable(filepath.Dir(sqlitePath)); err != nil {
+			problems = append(problems, fmt.Errorf("sqlite-path %q: %w", sqlitePath, err))
+		}
+	}
+	if !o.Testing {
+		if err := checkWritable(o.EnginePath); err != nil {
+			problems = append(problems, fmt.Errorf("engine-path %q: %w", o.EnginePath, err))
+		}
+	}
+	if o.AssetsPath != "" {
+		if fi, err := os.Stat(o.AssetsPath); err != nil {
+			problems = append(problems, fmt.Errorf("assets-path %q: %w", o.AssetsPath, err))
+		} else if !fi.IsDir() {
+			problems = append(problems, fmt.Errorf("assets-path %q: not a directory", o.AssetsPath))
+		}
+	}
+
+	problems = append(problems, validateTLS(o, time.Now())...)
+	return problems
+}
+
+// configFileKeys returns the keys set in the config file at path, excluding
+// flags and environment variables.
+func configFileKeys(path string) ([]string, error) {
+	fv := viper.New()
+	fv.SetConfigFile(path)
+	if err := fv.ReadInConfig(); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	return fv.AllKeys(), nil
+}
+
+// checkWritable reports whether dir, or the closest ancestor of dir which exists,
+// is a directory the current user can create files in.
+func checkWritable(dir string) error {
+	for {
+		fi, err := os.Stat(dir)
+		if err == nil {
+			if !fi.IsDir() {
+				return fmt.Errorf("%s is not a directory", dir)
+			}
+			break
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return err
+		}
+		dir = parent
+	}
+
+	f, err := os.CreateTemp(dir, ".influxd-validate-")
+	if err != nil {
+		return fmt.Errorf("directory %s is not writable: %w", dir, err)
+	}
+	_ = f.Close()
+	return os.Remove(f.Name())
+}
+
+// validateTLS returns any problems with the TLS options, checking certificate
+// validity as of now.
+func validateTLS(o *InfluxdOpts, now time.Time) []error {
+	if _, err := parseTLSMinVersion(o.HttpTLSMinVersion); err != nil {
+		return []error{err}
+	}
+	if o.HttpTLSACME.Enabled() {
+		var problems []error
+		if o.HttpTLSCert != "" || o.HttpTLSKey != "" {
+			problems = append(problems, errors.New("tls-acme-domains cannot be combined with tls-cert and tls-key"))
+		}
+		if err := o.HttpTLSACME.Validate(); err != nil {
+			problems = append(problems, err)
+		} else if err := checkWritable(o.HttpTLSACME.CacheDir); err != nil {
+			problems = append(problems, fmt.Errorf("tls-acme-cache-dir %q: %w", o.HttpTLSACME.CacheDir, err))
+		}
+		return problems
+	}
+	if o.HttpTLSCert == "" && o.HttpTLSKey == "" {
+		return nil
+	}
+	if o.HttpTLSCert == "" || o.HttpTLSKey == "" {
+		return []error{errors.New("TLS requires specifying both tls-cert and tls-key")}
+	}
+
+	pair, err := tls.LoadX509KeyPair(o.HttpTLSCert, o.HttpTLSKey)
+	if err != nil {
+		return []error{fmt.Errorf("failed to load x509 key pair: %w", err)}
+	}
+
+	var problems []error
+	for _, der := range pair.Certificate {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("failed to parse certificate in %s: %w", o.HttpTLSCert, err))
+			continue
+		}
+		name := strings.TrimSpace(cert.Subject.String())
+		if now.After(cert.NotAfter) {
+			problems = append(problems, fmt.Errorf("certificate %q in %s expired at %s", name, o.HttpTLSCert, cert.NotAfter.Format(time.RFC3339)))
+		} else if now.Before(cert.NotBefore) {
+			problems = append(problems, fmt.Errorf("certificate %q in %s is not valid until %s", name, o.HttpTLSCert, cert.NotBefore.Format(time.RFC3339)))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,164 @@
+package launcher
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func newValidateTestOpts(t *testing.T) (*viper.Viper, *InfluxdOpts) {
+	t.Helper()
+
+	dir := t.TempDir()
+	v := viper.New()
+	o := NewOpts(v)
+	o.BoltPath = filepath.Join(dir, "influxd.bolt")
+	o.SqLitePath = filepath.Join(dir, "influxd.sqlite")
+	o.EnginePath = filepath.Join(dir, "engine")
+	return v, o
+}
+
+func writeTestKeyPair(t *testing.T, notBefore, notAfter time.Time) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "influxd-test"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return certPath, keyPath
+}
+
+func Test_validateConfig(t *testing.T) {
+	t.Run("defaults are valid", func(t *testing.T) {
+		v, o := newValidateTestOpts(t)
+		require.Empty(t, validateConfig(v, o))
+	})
+
+	t.Run("unknown config file key", func(t *testing.T) {
+		v, o := newValidateTestOpts(t)
+		path := filepath.Join(t.TempDir(), "config.toml")
+		require.NoError(t, os.WriteFile(path, []byte("log-level = \"debug\"\nlog-levle = \"info\"\n"), 0600))
+		v.SetConfigFile(path)
+
+		problems := validateConfig(v, o)
+		require.Len(t, problems, 1)
+		require.Contains(t, problems[0].Error(), `"log-levle"`)
+	})
+
+	t.Run("bad enumerated values", func(t *testing.T) {
+		v, o := newValidateTestOpts(t)
+		o.StoreType = "tape"
+		o.SecretStore = "safe"
+		o.TracingType = "smoke-signals"
+		o.HttpTLSMinVersion = "0.9"
+		require.Len(t, validateConfig(v, o), 4)
+	})
+
+	t.Run("bad logging options", func(t *testing.T) {
+		v, o := newValidateTestOpts(t)
+		o.LogFormat = "xml"
+		o.LogFile = filepath.Join(t.TempDir(), "influxd.log")
+		o.LogFileFormat = "console"
+		o.LogSubsystemLevels = map[string]string{"storage": "loud", "gpu": "debug"}
+		require.Len(t, validateConfig(v, o), 4)
+	})
+
+	t.Run("inconsistent query limits", func(t *testing.T) {
+		v, o := newValidateTestOpts(t)
+		o.ConcurrencyQuota = 10
+		o.QueueSize = 0
+		problems := validateConfig(v, o)
+		require.Len(t, problems, 1)
+		require.Contains(t, problems[0].Error(), "invalid query config")
+	})
+
+	t.Run("engine path is a file", func(t *testing.T) {
+		v, o := newValidateTestOpts(t)
+		require.NoError(t, os.WriteFile(o.EnginePath, nil, 0600))
+		problems := validateConfig(v, o)
+		require.Len(t, problems, 1)
+		require.Contains(t, problems[0].Error(), "engine-path")
+	})
+}
+
+func Test_validateTLS(t *testing.T) {
+	now := time.Now()
+
+	t.Run("valid key pair", func(t *testing.T) {
+		_, o := newValidateTestOpts(t)
+		o.HttpTLSCert, o.HttpTLSKey = writeTestKeyPair(t, now.Add(-time.Hour), now.Add(time.Hour))
+		require.Empty(t, validateTLS(o, now))
+	})
+
+	t.Run("expired certificate", func(t *testing.T) {
+		_, o := newValidateTestOpts(t)
+		o.HttpTLSCert, o.HttpTLSKey = writeTestKeyPair(t, now.Add(-2*time.Hour), now.Add(-time.Hour))
+		problems := validateTLS(o, now)
+		require.Len(t, problems, 1)
+		require.Contains(t, problems[0].Error(), "expired")
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		_, o := newValidateTestOpts(t)
+		o.HttpTLSCert, _ = writeTestKeyPair(t, now.Add(-time.Hour), now.Add(time.Hour))
+		require.Len(t, validateTLS(o, now), 1)
+	})
+
+	t.Run("acme", func(t *testing.T) {
+		_, o := newValidateTestOpts(t)
+		o.HttpTLSACME = ACMEConfig{
+			Domains:   []string{"influxdb.example.com"},
+			AcceptTOS: true,
+			CacheDir:  filepath.Join(t.TempDir(), "acme"),
+		}
+		require.Empty(t, validateTLS(o, now))
+	})
+
+	t.Run("acme without accepting terms of service", func(t *testing.T) {
+		_, o := newValidateTestOpts(t)
+		o.HttpTLSACME = ACMEConfig{
+			Domains:  []string{"influxdb.example.com"},
+			CacheDir: t.TempDir(),
+		}
+		problems := validateTLS(o, now)
+		require.Len(t, problems, 1)
+		require.Contains(t, problems[0].Error(), "tls-acme-accept-tos")
+	})
+
+	t.Run("acme with key pair", func(t *testing.T) {
+		_, o := newValidateTestOpts(t)
+		o.HttpTLSCert, o.HttpTLSKey = writeTestKeyPair(t, now.Add(-time.Hour), now.Add(time.Hour))
+		o.HttpTLSACME = ACMEConfig{
+			Domains:   []string{"influxdb.example.com"},
+			AcceptTOS: true,
+			CacheDir:  t.TempDir(),
+		}
+		problems := validateTLS(o, now)
+		require.Len(t, problems, 1)
+		require.Contains(t, problems[0].Error(), "cannot be combined")
+	})
+}
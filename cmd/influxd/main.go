@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/cmd/influxd/debug"
 	"github.com/influxdata/influxdb/v2/cmd/influxd/downgrade"
 	"github.com/influxdata/influxdb/v2/cmd/influxd/inspect"
 	"github.com/influxdata/influxdb/v2/cmd/influxd/launcher"
@@ -56,6 +57,11 @@ func main() {
 		handleErr(err.Error())
 	}
 	rootCmd.AddCommand(downgradeCmd)
+	debugCmd, err := debug.NewCommand(ctx, v)
+	if err != nil {
+		handleErr(err.Error())
+	}
+	rootCmd.AddCommand(debugCmd)
 
 	rootCmd.SilenceUsage = true
 	if err := rootCmd.Execute(); err != nil {
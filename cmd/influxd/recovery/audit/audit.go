@@ -0,0 +1,136 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/cmd/influxd/recovery/testhelper"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/logger"
+	"github.com/influxdata/influxdb/v2/tenant"
+	authv1 "github.com/influxdata/influxdb/v2/v1/authorization"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func NewAuditCommand() *cobra.Command {
+	base := &cobra.Command{
+		Use:   "audit",
+		Short: "On-disk authorization audit log commands, for recovery",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.PrintErrf("See '%s -h' for help\n", cmd.CommandPath())
+		},
+	}
+
+	base.AddCommand(NewAuditListCommand())
+
+	return base
+}
+
+type auditListCommand struct {
+	logger     *zap.Logger
+	storeFlags testhelper.StoreFlags
+	out        io.Writer
+	format     string
+	authID     string
+}
+
+func NewAuditListCommand() *cobra.Command {
+	var auditCmd auditListCommand
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the changes of v1 authorizations, and verify that none was tampered with",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config := logger.NewConfig()
+			config.Level = zapcore.InfoLevel
+
+			newLogger, err := config.New(cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			auditCmd.logger = newLogger
+			auditCmd.out = cmd.OutOrStdout()
+			return auditCmd.run()
+		},
+	}
+
+	auditCmd.storeFlags.Register(cmd)
+	testhelper.AddFormatFlag(cmd, &auditCmd.format)
+	cmd.Flags().StringVar(&auditCmd.authID, "auth-id", "", "Only list the changes of this authorization")
+
+	return cmd
+}
+
+func (cmd *auditListCommand) run() error {
+	ctx := context.Background()
+	stores, err := cmd.storeFlags.Open(ctx, cmd.logger, false)
+	if err != nil {
+		return err
+	}
+	defer stores.Close()
+	store := stores.KV
+	tenantService := tenant.NewService(tenant.NewStore(store))
+	authStore, err := authv1.NewStore(store)
+	if err != nil {
+		return err
+	}
+	auth := authv1.NewService(authStore, tenantService)
+
+	var filter authv1.AuditFilter
+	if cmd.authID != "" {
+		if filter.AuthID, err = platform.IDFromString(cmd.authID); err != nil {
+			return fmt.Errorf("invalid --auth-id %q: %w", cmd.authID, err)
+		}
+	}
+	records, err := auth.FindAuditRecords(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if err := PrintAuditRecords(cmd.out, records, cmd.format); err != nil {
+		return err
+	}
+
+	if err := auth.VerifyAuditLog(ctx); err != nil {
+		return fmt.Errorf("audit log verification failed: %w", err)
+	}
+	return nil
+}
+
+func PrintAuditRecords(w io.Writer, v []*authv1.AuditRecord, format string) error {
+	headers := []string{
+		"Seq",
+		"Time",
+		"Action",
+		"Auth ID",
+		"Actor ID",
+		"Status",
+		"Permissions",
+	}
+
+	var rows []map[string]interface{}
+	for _, r := range v {
+		row := map[string]interface{}{
+			"Seq":         r.Seq,
+			"Time":        r.Time.Format(time.RFC3339),
+			"Action":      string(r.Action),
+			"Auth ID":     r.AuthID,
+			"Actor ID":    "",
+			"Status":      "",
+			"Permissions": "",
+		}
+		if r.ActorID != nil {
+			row["Actor ID"] = *r.ActorID
+		}
+		if a := r.After; a != nil {
+			row["Status"] = string(a.Status)
+			row["Permissions"] = a.Permissions
+		}
+		rows = append(rows, row)
+	}
+
+	return testhelper.Render(w, format, headers, rows)
+}
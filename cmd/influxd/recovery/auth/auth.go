@@ -4,15 +4,14 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"os"
-	"path/filepath"
 
-	"github.com/influxdata/influx-cli/v2/pkg/tabwriter"
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/authorization"
-	"github.com/influxdata/influxdb/v2/bolt"
+	"github.com/influxdata/influxdb/v2/cmd/influxd/recovery/testhelper"
+	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/logger"
 	"github.com/influxdata/influxdb/v2/tenant"
+	authv1 "github.com/influxdata/influxdb/v2/v1/authorization"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -30,14 +29,18 @@ func NewAuthCommand() *cobra.Command {
 
 	base.AddCommand(NewAuthListCommand())
 	base.AddCommand(NewAuthCreateCommand())
+	base.AddCommand(NewAuthCreateScopedCommand())
+	base.AddCommand(NewAuthHashV1TokensCommand())
+	base.AddCommand(NewAuthV1TokenQuotaCommand())
 
 	return base
 }
 
 type authListCommand struct {
-	logger   *zap.Logger
-	boltPath string
-	out      io.Writer
+	logger     *zap.Logger
+	storeFlags testhelper.StoreFlags
+	out        io.Writer
+	format     string
 }
 
 func NewAuthListCommand() *cobra.Command {
@@ -59,20 +62,20 @@ func NewAuthListCommand() *cobra.Command {
 		},
 	}
 
-	defaultPath := filepath.Join(os.Getenv("HOME"), ".influxdbv2", "influxd.bolt")
-
-	cmd.Flags().StringVar(&authCmd.boltPath, "bolt-path", defaultPath, "Path to the BoltDB file.")
+	authCmd.storeFlags.Register(cmd)
+	testhelper.AddFormatFlag(cmd, &authCmd.format)
 
 	return cmd
 }
 
 func (cmd *authListCommand) run() error {
 	ctx := context.Background()
-	store := bolt.NewKVStore(cmd.logger.With(zap.String("system", "bolt-kvstore")), cmd.boltPath)
-	if err := store.Open(ctx); err != nil {
+	stores, err := cmd.storeFlags.Open(ctx, cmd.logger, false)
+	if err != nil {
 		return err
 	}
-	defer store.Close()
+	defer stores.Close()
+	store := stores.KV
 	tenantStore := tenant.NewStore(store)
 	tenantService := tenant.NewService(tenantStore)
 	authStore, err := authorization.NewStore(store)
@@ -86,15 +89,17 @@ func (cmd *authListCommand) run() error {
 		return err
 	}
 
-	return PrintAuth(ctx, cmd.out, auths, tenantService)
+	return PrintAuth(ctx, cmd.out, auths, tenantService, cmd.format)
 }
 
 type authCreateCommand struct {
-	logger   *zap.Logger
-	boltPath string
-	out      io.Writer
-	username string
-	org      string
+	logger     *zap.Logger
+	storeFlags testhelper.StoreFlags
+	out        io.Writer
+	format     string
+	username   string
+	org        string
+	dryRun     bool
 }
 
 func NewAuthCreateCommand() *cobra.Command {
@@ -116,28 +121,25 @@ func NewAuthCreateCommand() *cobra.Command {
 		},
 	}
 
-	defaultPath := filepath.Join(os.Getenv("HOME"), ".influxdbv2", "influxd.bolt")
-	cmd.Flags().StringVar(&authCmd.boltPath, "bolt-path", defaultPath, "Path to the BoltDB file")
+	authCmd.storeFlags.Register(cmd)
+	testhelper.AddFormatFlag(cmd, &authCmd.format)
 	cmd.Flags().StringVar(&authCmd.username, "username", "", "Name of the user")
 	cmd.Flags().StringVar(&authCmd.org, "org", "", "Name of the org")
+	testhelper.AddDryRunFlag(cmd, &authCmd.dryRun)
 
 	return cmd
 }
 
 func (cmd *authCreateCommand) run() error {
 	ctx := context.Background()
-	store := bolt.NewKVStore(cmd.logger.With(zap.String("system", "bolt-kvstore")), cmd.boltPath)
-	if err := store.Open(ctx); err != nil {
+	stores, err := cmd.storeFlags.Open(ctx, cmd.logger, cmd.dryRun)
+	if err != nil {
 		return err
 	}
-	defer store.Close()
+	defer stores.Close()
+	store := stores.KV
 	tenantStore := tenant.NewStore(store)
 	tenantService := tenant.NewService(tenantStore)
-	authStore, err := authorization.NewStore(store)
-	if err != nil {
-		return err
-	}
-	auth := authorization.NewService(authStore, tenantService)
 
 	if cmd.username == "" {
 		return fmt.Errorf("must provide --username")
@@ -167,6 +169,15 @@ func (cmd *authCreateCommand) run() error {
 		UserID:      user.ID,
 		OrgID:       org.ID,
 	}
+	if cmd.dryRun {
+		return printDryRunAuth(cmd.out, authToCreate, user, org)
+	}
+
+	authStore, err := authorization.NewStore(store)
+	if err != nil {
+		return err
+	}
+	auth := authorization.NewService(authStore, tenantService)
 	if err := auth.CreateAuthorization(ctx, authToCreate); err != nil {
 		return fmt.Errorf("could not create recovery token: %w", err)
 	}
@@ -177,10 +188,277 @@ func (cmd *authCreateCommand) run() error {
 	if err != nil {
 		return err
 	}
-	return PrintAuth(ctx, cmd.out, auths, tenantService)
+	return PrintAuth(ctx, cmd.out, auths, tenantService, cmd.format)
+}
+
+type authCreateScopedCommand struct {
+	logger      *zap.Logger
+	storeFlags  testhelper.StoreFlags
+	out         io.Writer
+	format      string
+	username    string
+	org         string
+	description string
+	templates   []string
+	dryRun      bool
+}
+
+func NewAuthCreateScopedCommand() *cobra.Command {
+	var authCmd authCreateScopedCommand
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create new token for a user, scoped by templates",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config := logger.NewConfig()
+			config.Level = zapcore.InfoLevel
+
+			newLogger, err := config.New(cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			authCmd.logger = newLogger
+			authCmd.out = cmd.OutOrStdout()
+			return authCmd.run()
+		},
+	}
+
+	authCmd.storeFlags.Register(cmd)
+	testhelper.AddFormatFlag(cmd, &authCmd.format)
+	cmd.Flags().StringVar(&authCmd.username, "username", "", "Name of the user")
+	cmd.Flags().StringVar(&authCmd.org, "org", "", "Name of the org")
+	cmd.Flags().StringVar(&authCmd.description, "description", "", "Description of the token")
+	cmd.Flags().StringArrayVar(&authCmd.templates, "template", nil, "Scope of the token: read:<bucket>, write:<bucket> or admin. May be repeated")
+	testhelper.AddDryRunFlag(cmd, &authCmd.dryRun)
+
+	return cmd
+}
+
+func (cmd *authCreateScopedCommand) run() error {
+	ctx := context.Background()
+	stores, err := cmd.storeFlags.Open(ctx, cmd.logger, cmd.dryRun)
+	if err != nil {
+		return err
+	}
+	defer stores.Close()
+	store := stores.KV
+	tenantStore := tenant.NewStore(store)
+	tenantService := tenant.NewService(tenantStore)
+
+	if cmd.username == "" {
+		return fmt.Errorf("must provide --username")
+	}
+	if cmd.org == "" {
+		return fmt.Errorf("must provide --org")
+	}
+	if len(cmd.templates) == 0 {
+		return fmt.Errorf("must provide --template")
+	}
+
+	scopes := make([]influxdb.AuthorizationScope, 0, len(cmd.templates))
+	for _, t := range cmd.templates {
+		scope, err := influxdb.ParseAuthorizationScope(t)
+		if err != nil {
+			return fmt.Errorf("invalid --template %q: %w", t, err)
+		}
+		scopes = append(scopes, scope)
+	}
+
+	user, err := tenantService.FindUser(ctx, influxdb.UserFilter{Name: &cmd.username})
+	if err != nil {
+		return fmt.Errorf("could not find user %q: %w", cmd.username, err)
+	}
+	org, err := tenantService.FindOrganization(ctx, influxdb.OrganizationFilter{Name: &cmd.org})
+	if err != nil {
+		return fmt.Errorf("could not find org %q: %w", cmd.org, err)
+	}
+
+	perms, err := influxdb.ScopePermissions(org.ID, scopes, func(name string) (platform.ID, error) {
+		b, err := tenantService.FindBucket(ctx, influxdb.BucketFilter{OrganizationID: &org.ID, Name: &name})
+		if err != nil {
+			return 0, fmt.Errorf("could not find bucket %q: %w", name, err)
+		}
+		return b.ID, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	description := cmd.description
+	if description == "" {
+		description = fmt.Sprintf("%s's Scoped Token", cmd.username)
+	}
+	authToCreate := &influxdb.Authorization{
+		Description: description,
+		Permissions: perms,
+		UserID:      user.ID,
+		OrgID:       org.ID,
+	}
+	if cmd.dryRun {
+		return printDryRunAuth(cmd.out, authToCreate, user, org)
+	}
+
+	authStore, err := authorization.NewStore(store)
+	if err != nil {
+		return err
+	}
+	auth := authorization.NewService(authStore, tenantService)
+	if err := auth.CreateAuthorization(ctx, authToCreate); err != nil {
+		return fmt.Errorf("could not create token: %w", err)
+	}
+
+	return PrintAuth(ctx, cmd.out, []*influxdb.Authorization{authToCreate}, tenantService, cmd.format)
+}
+
+// printDryRunAuth prints the authorization a dry run would have created.
+func printDryRunAuth(w io.Writer, a *influxdb.Authorization, user *influxdb.User, org *influxdb.Organization) error {
+	_, err := fmt.Fprintf(w, "Dry run: would create token %q for user %q in org %q with permissions %v\n",
+		a.Description, user.Name, org.Name, a.Permissions)
+	return err
+}
+
+type authHashV1TokensCommand struct {
+	logger     *zap.Logger
+	storeFlags testhelper.StoreFlags
+	out        io.Writer
+}
+
+func NewAuthHashV1TokensCommand() *cobra.Command {
+	var authCmd authHashV1TokensCommand
+	cmd := &cobra.Command{
+		Use:   "hash-v1-tokens",
+		Short: "Replace the v1 authorization tokens stored in plain text with their hash",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config := logger.NewConfig()
+			config.Level = zapcore.InfoLevel
+
+			newLogger, err := config.New(cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			authCmd.logger = newLogger
+			authCmd.out = cmd.OutOrStdout()
+			return authCmd.run()
+		},
+	}
+
+	authCmd.storeFlags.Register(cmd)
+
+	return cmd
+}
+
+func (cmd *authHashV1TokensCommand) run() error {
+	ctx := context.Background()
+	stores, err := cmd.storeFlags.Open(ctx, cmd.logger, false)
+	if err != nil {
+		return err
+	}
+	defer stores.Close()
+	store := stores.KV
+	tenantService := tenant.NewService(tenant.NewStore(store))
+	authStore, err := authv1.NewStore(store)
+	if err != nil {
+		return err
+	}
+	auth := authv1.NewService(authStore, tenantService)
+
+	n, err := auth.HashTokens(ctx)
+	if err != nil {
+		return fmt.Errorf("could not hash v1 tokens: %w", err)
+	}
+	_, err = fmt.Fprintf(cmd.out, "Hashed %d v1 token(s)\n", n)
+	return err
+}
+
+type authV1TokenQuotaCommand struct {
+	logger     *zap.Logger
+	storeFlags testhelper.StoreFlags
+	out        io.Writer
+	org        string
+	max        int
+	reset      bool
 }
 
-func PrintAuth(ctx context.Context, w io.Writer, v []*influxdb.Authorization, userSvc influxdb.UserService) error {
+func NewAuthV1TokenQuotaCommand() *cobra.Command {
+	var authCmd authV1TokenQuotaCommand
+	cmd := &cobra.Command{
+		Use:   "v1-token-quota",
+		Short: "Show or override the maximum number of v1 authorizations of an org",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config := logger.NewConfig()
+			config.Level = zapcore.InfoLevel
+
+			newLogger, err := config.New(cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			authCmd.logger = newLogger
+			authCmd.out = cmd.OutOrStdout()
+			return authCmd.run(cmd.Flags().Changed("max"))
+		},
+	}
+
+	authCmd.storeFlags.Register(cmd)
+	cmd.Flags().StringVar(&authCmd.org, "org", "", "Name of the org")
+	cmd.Flags().IntVar(&authCmd.max, "max", 0, "Maximum number of v1 authorizations of the org. 0 is unlimited")
+	cmd.Flags().BoolVar(&authCmd.reset, "reset", false, "Remove the quota of the org, so that the default quota of influxd applies")
+
+	return cmd
+}
+
+func (cmd *authV1TokenQuotaCommand) run(setMax bool) error {
+	ctx := context.Background()
+	if cmd.org == "" {
+		return fmt.Errorf("must provide --org")
+	}
+	if setMax && cmd.reset {
+		return fmt.Errorf("--max and --reset are mutually exclusive")
+	}
+	stores, err := cmd.storeFlags.Open(ctx, cmd.logger, false)
+	if err != nil {
+		return err
+	}
+	defer stores.Close()
+	store := stores.KV
+	tenantService := tenant.NewService(tenant.NewStore(store))
+
+	org, err := tenantService.FindOrganization(ctx, influxdb.OrganizationFilter{Name: &cmd.org})
+	if err != nil {
+		return fmt.Errorf("could not find org %q: %w", cmd.org, err)
+	}
+
+	authStore, err := authv1.NewStore(store)
+	if err != nil {
+		return err
+	}
+	auth := authv1.NewService(authStore, tenantService)
+
+	switch {
+	case setMax:
+		if err := auth.PutOrgTokenQuota(ctx, org.ID, authv1.TokenQuota{MaxTokens: cmd.max}); err != nil {
+			return fmt.Errorf("could not set the v1 token quota: %w", err)
+		}
+	case cmd.reset:
+		if err := auth.DeleteOrgTokenQuota(ctx, org.ID); err != nil {
+			return fmt.Errorf("could not remove the v1 token quota: %w", err)
+		}
+	}
+
+	q, err := auth.FindOrgTokenQuota(ctx, org.ID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case q == nil:
+		_, err = fmt.Fprintf(cmd.out, "Org %q has no v1 token quota of its own\n", org.Name)
+	case q.MaxTokens == 0:
+		_, err = fmt.Fprintf(cmd.out, "Org %q has no limit of v1 tokens\n", org.Name)
+	default:
+		_, err = fmt.Fprintf(cmd.out, "Org %q has a quota of %d v1 tokens\n", org.Name, q.MaxTokens)
+	}
+	return err
+}
+
+func PrintAuth(ctx context.Context, w io.Writer, v []*influxdb.Authorization, userSvc influxdb.UserService, format string) error {
 	headers := []string{
 		"ID",
 		"User Name",
@@ -208,15 +486,5 @@ func PrintAuth(ctx context.Context, w io.Writer, v []*influxdb.Authorization, us
 		rows = append(rows, row)
 	}
 
-	writer := tabwriter.NewTabWriter(w, false)
-	defer writer.Flush()
-	if err := writer.WriteHeaders(headers...); err != nil {
-		return err
-	}
-	for _, row := range rows {
-		if err := writer.Write(row); err != nil {
-			return err
-		}
-	}
-	return nil
+	return testhelper.Render(w, format, headers, rows)
 }
@@ -32,3 +32,31 @@ func Test_Auth_Basic(t *testing.T) {
 		`[^\t]*	testuser	[^\t]*	testuser's Recovery Token	[^\t]*	\[read:authorizations write:authorizations read:buckets write:buckets read:dashboards write:dashboards read:orgs write:orgs read:sources write:sources read:tasks write:tasks read:telegrafs write:telegrafs read:users write:users read:variables write:variables read:scrapers write:scrapers read:secrets write:secrets read:labels write:labels read:views write:views read:documents write:documents read:notificationRules write:notificationRules read:notificationEndpoints write:notificationEndpoints read:checks write:checks read:dbrp write:dbrp read:notebooks write:notebooks read:annotations write:annotations read:remotes write:remotes read:replications write:replications\]`+"\n",
 		testhelper.MustRunCommand(t, NewAuthCommand(), "list", "--bolt-path", db.Name()))
 }
+
+func Test_Auth_DryRun(t *testing.T) {
+	db := testhelper.NewTestBoltDb(t)
+	defer db.Close()
+	before := testhelper.MustRunCommand(t, NewAuthCommand(), "list", "--bolt-path", db.Name())
+
+	// validation still fails
+	assert.EqualError(t, testhelper.RunCommand(t, NewAuthCommand(), "create-operator", "--bolt-path", db.Name(), "--org", "myorg", "--username", "testuser2", "--dry-run"), "could not find user \"testuser2\": user not found")
+
+	assert.Regexp(t, "^Dry run: would create token \"testuser's Recovery Token\" for user \"testuser\" in org \"myorg\"",
+		testhelper.MustRunCommand(t, NewAuthCommand(), "create-operator", "--bolt-path", db.Name(), "--username", "testuser", "--org", "myorg", "--dry-run"))
+	assert.Equal(t, "Dry run: would create token \"testuser's Scoped Token\" for user \"testuser\" in org \"myorg\" with permissions [read:orgs/dd7cd2292f6e974a/buckets/7451ab8b9b7df592]\n",
+		testhelper.MustRunCommand(t, NewAuthCommand(), "create", "--bolt-path", db.Name(), "--username", "testuser", "--org", "myorg", "--template", "read:mybucket", "--dry-run"))
+
+	// nothing was written
+	assert.Equal(t, before, testhelper.MustRunCommand(t, NewAuthCommand(), "list", "--bolt-path", db.Name()))
+}
+
+func Test_Auth_V1TokenQuota(t *testing.T) {
+	db := testhelper.NewTestBoltDb(t)
+	defer db.Close()
+
+	assert.EqualError(t, testhelper.RunCommand(t, NewAuthCommand(), "v1-token-quota", "--bolt-path", db.Name()), "must provide --org")
+	assert.EqualError(t, testhelper.RunCommand(t, NewAuthCommand(), "v1-token-quota", "--bolt-path", db.Name(), "--org", "myorg", "--max", "5", "--reset"),
+		"--max and --reset are mutually exclusive")
+	assert.Equal(t, "Org \"myorg\" has no v1 token quota of its own\n",
+		testhelper.MustRunCommand(t, NewAuthCommand(), "v1-token-quota", "--bolt-path", db.Name(), "--org", "myorg"))
+}
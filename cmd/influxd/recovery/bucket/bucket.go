@@ -0,0 +1,461 @@
+package bucket
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/cmd/influxd/recovery/testhelper"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/logger"
+	"github.com/influxdata/influxdb/v2/tenant"
+	"github.com/influxdata/influxdb/v2/v1/services/meta"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func NewBucketCommand() *cobra.Command {
+	base := &cobra.Command{
+		Use:   "bucket",
+		Short: "On-disk bucket management commands, for recovery",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.PrintErrf("See '%s -h' for help\n", cmd.CommandPath())
+		},
+	}
+
+	base.AddCommand(NewBucketListCommand())
+	base.AddCommand(NewBucketCreateCommand())
+	base.AddCommand(NewBucketRenameCommand())
+	base.AddCommand(NewBucketRetentionCommand())
+
+	return base
+}
+
+// bucketFlags identify a bucket by its ID, or by its name in an org.
+type bucketFlags struct {
+	id   string
+	org  string
+	name string
+}
+
+func (f *bucketFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.id, "id", "", "ID of the bucket")
+	cmd.Flags().StringVar(&f.org, "org", "", "Name of the org of the bucket")
+	cmd.Flags().StringVar(&f.name, "name", "", "Name of the bucket")
+}
+
+func (f *bucketFlags) find(ctx context.Context, tenantService *tenant.Service) (*influxdb.Bucket, error) {
+	if f.id != "" {
+		id, err := platform.IDFromString(f.id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid id %q: %w", f.id, err)
+		}
+		return tenantService.FindBucketByID(ctx, *id)
+	}
+	if f.org == "" || f.name == "" {
+		return nil, fmt.Errorf("must provide --id, or --org and --name")
+	}
+	b, err := tenantService.FindBucket(ctx, influxdb.BucketFilter{Org: &f.org, Name: &f.name})
+	if err != nil {
+		return nil, fmt.Errorf("could not find bucket %q in org %q: %w", f.name, f.org, err)
+	}
+	return b, nil
+}
+
+// bucketNameTaken returns true if an org has a bucket named name.
+func bucketNameTaken(ctx context.Context, tenantService *tenant.Service, orgID platform.ID, name string) (bool, error) {
+	_, err := tenantService.FindBucket(ctx, influxdb.BucketFilter{OrganizationID: &orgID, Name: &name})
+	if errors.ErrorCode(err) == errors.ENotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// openMetaClient opens the v1 metadata of store. In a dry run the metadata
+// is only loaded, as opening it writes the metadata of a new instance.
+func openMetaClient(store kv.Store, dryRun bool) (*meta.Client, error) {
+	metaClient := meta.NewClient(meta.NewConfig(), store)
+	if dryRun {
+		return metaClient, metaClient.Load()
+	}
+	return metaClient, metaClient.Open()
+}
+
+type bucketListCommand struct {
+	logger     *zap.Logger
+	storeFlags testhelper.StoreFlags
+	out        io.Writer
+	format     string
+	org        string
+}
+
+func NewBucketListCommand() *cobra.Command {
+	var bucketCmd bucketListCommand
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List buckets",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config := logger.NewConfig()
+			config.Level = zapcore.InfoLevel
+
+			newLogger, err := config.New(cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			bucketCmd.logger = newLogger
+			bucketCmd.out = cmd.OutOrStdout()
+			return bucketCmd.run()
+		},
+	}
+
+	bucketCmd.storeFlags.Register(cmd)
+	testhelper.AddFormatFlag(cmd, &bucketCmd.format)
+	cmd.Flags().StringVar(&bucketCmd.org, "org", "", "Only list the buckets of this org")
+
+	return cmd
+}
+
+func (cmd *bucketListCommand) run() error {
+	ctx := context.Background()
+	stores, err := cmd.storeFlags.Open(ctx, cmd.logger, false)
+	if err != nil {
+		return err
+	}
+	defer stores.Close()
+	store := stores.KV
+	tenantService := tenant.NewService(tenant.NewStore(store))
+
+	filter := influxdb.BucketFilter{}
+	if cmd.org != "" {
+		filter.Org = &cmd.org
+	}
+	buckets, _, err := tenantService.FindBuckets(ctx, filter)
+	if err != nil {
+		return err
+	}
+	return PrintBuckets(ctx, cmd.out, buckets, cmd.format)
+}
+
+type bucketCreateCommand struct {
+	logger             *zap.Logger
+	storeFlags         testhelper.StoreFlags
+	out                io.Writer
+	format             string
+	org                string
+	name               string
+	retention          time.Duration
+	shardGroupDuration time.Duration
+	dryRun             bool
+}
+
+func NewBucketCreateCommand() *cobra.Command {
+	var bucketCmd bucketCreateCommand
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create new bucket",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config := logger.NewConfig()
+			config.Level = zapcore.InfoLevel
+
+			newLogger, err := config.New(cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			bucketCmd.logger = newLogger
+			bucketCmd.out = cmd.OutOrStdout()
+			return bucketCmd.run()
+		},
+	}
+
+	bucketCmd.storeFlags.Register(cmd)
+	testhelper.AddFormatFlag(cmd, &bucketCmd.format)
+	cmd.Flags().StringVar(&bucketCmd.org, "org", "", "Name of the org of the bucket")
+	cmd.Flags().StringVar(&bucketCmd.name, "name", "", "Name of the bucket to create")
+	cmd.Flags().DurationVar(&bucketCmd.retention, "retention", 0, "Retention period of the bucket, 0 to keep data forever")
+	cmd.Flags().DurationVar(&bucketCmd.shardGroupDuration, "shard-group-duration", 0, "Shard group duration of the bucket, 0 for the default of its retention")
+	testhelper.AddDryRunFlag(cmd, &bucketCmd.dryRun)
+
+	return cmd
+}
+
+func (cmd *bucketCreateCommand) run() error {
+	ctx := context.Background()
+	stores, err := cmd.storeFlags.Open(ctx, cmd.logger, cmd.dryRun)
+	if err != nil {
+		return err
+	}
+	defer stores.Close()
+	store := stores.KV
+	tenantService := tenant.NewService(tenant.NewStore(store))
+	metaClient, err := openMetaClient(store, cmd.dryRun)
+	if err != nil {
+		return err
+	}
+	defer metaClient.Close()
+
+	if cmd.org == "" {
+		return fmt.Errorf("must provide --org")
+	}
+	if cmd.name == "" {
+		return fmt.Errorf("must provide --name")
+	}
+
+	org, err := tenantService.FindOrganization(ctx, influxdb.OrganizationFilter{Name: &cmd.org})
+	if err != nil {
+		return fmt.Errorf("could not find org %q: %w", cmd.org, err)
+	}
+
+	b := &influxdb.Bucket{
+		OrgID:              org.ID,
+		Name:               cmd.name,
+		RetentionPeriod:    cmd.retention,
+		ShardGroupDuration: meta.NormalisedShardDuration(cmd.shardGroupDuration, cmd.retention),
+	}
+
+	if cmd.dryRun {
+		if taken, err := bucketNameTaken(ctx, tenantService, org.ID, b.Name); err != nil {
+			return err
+		} else if taken {
+			return tenant.BucketAlreadyExistsError(b.Name)
+		}
+		// the checks of the retention policy metaClient would create
+		if b.RetentionPeriod != 0 && b.RetentionPeriod < meta.MinRetentionPolicyDuration {
+			return meta.ErrRetentionPolicyDurationTooLow
+		}
+		if b.RetentionPeriod > 0 && b.RetentionPeriod < b.ShardGroupDuration {
+			return meta.ErrIncompatibleDurations
+		}
+		_, err := fmt.Fprintf(cmd.out, "Dry run: would create bucket %q in org %q (%s)\n", b.Name, org.Name, org.ID)
+		return err
+	}
+
+	if err := tenantService.CreateBucket(ctx, b); err != nil {
+		return err
+	}
+
+	// the storage engine finds the retention of the bucket in the metadata
+	spec := meta.RetentionPolicySpec{
+		Name:               meta.DefaultRetentionPolicyName,
+		Duration:           &b.RetentionPeriod,
+		ShardGroupDuration: b.ShardGroupDuration,
+	}
+	if _, err := metaClient.CreateDatabaseWithRetentionPolicy(b.ID.String(), &spec); err != nil {
+		if err := tenantService.DeleteBucket(ctx, b.ID); err != nil {
+			cmd.logger.Error("Unable to cleanup bucket after create failed", zap.Error(err))
+		}
+		return err
+	}
+
+	return PrintBuckets(ctx, cmd.out, []*influxdb.Bucket{b}, cmd.format)
+}
+
+type bucketRenameCommand struct {
+	logger     *zap.Logger
+	storeFlags testhelper.StoreFlags
+	out        io.Writer
+	format     string
+	bucket     bucketFlags
+	newName    string
+	dryRun     bool
+}
+
+func NewBucketRenameCommand() *cobra.Command {
+	var bucketCmd bucketRenameCommand
+	cmd := &cobra.Command{
+		Use:   "rename",
+		Short: "Rename a bucket",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config := logger.NewConfig()
+			config.Level = zapcore.InfoLevel
+
+			newLogger, err := config.New(cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			bucketCmd.logger = newLogger
+			bucketCmd.out = cmd.OutOrStdout()
+			return bucketCmd.run()
+		},
+	}
+
+	bucketCmd.storeFlags.Register(cmd)
+	testhelper.AddFormatFlag(cmd, &bucketCmd.format)
+	bucketCmd.bucket.register(cmd)
+	cmd.Flags().StringVar(&bucketCmd.newName, "new-name", "", "New name of the bucket")
+	testhelper.AddDryRunFlag(cmd, &bucketCmd.dryRun)
+
+	return cmd
+}
+
+func (cmd *bucketRenameCommand) run() error {
+	ctx := context.Background()
+	stores, err := cmd.storeFlags.Open(ctx, cmd.logger, cmd.dryRun)
+	if err != nil {
+		return err
+	}
+	defer stores.Close()
+	store := stores.KV
+	tenantService := tenant.NewService(tenant.NewStore(store))
+
+	if cmd.newName == "" {
+		return fmt.Errorf("must provide --new-name")
+	}
+	b, err := cmd.bucket.find(ctx, tenantService)
+	if err != nil {
+		return err
+	}
+
+	if cmd.dryRun {
+		if b.Name != cmd.newName {
+			if b.Type == influxdb.BucketTypeSystem {
+				return fmt.Errorf("system buckets cannot be renamed")
+			}
+			if taken, err := bucketNameTaken(ctx, tenantService, b.OrgID, cmd.newName); err != nil {
+				return err
+			} else if taken {
+				return tenant.ErrBucketNameNotUnique
+			}
+		}
+		_, err := fmt.Fprintf(cmd.out, "Dry run: would rename bucket %q (%s) to %q\n", b.Name, b.ID, cmd.newName)
+		return err
+	}
+
+	b, err = tenantService.UpdateBucket(ctx, b.ID, influxdb.BucketUpdate{Name: &cmd.newName})
+	if err != nil {
+		return err
+	}
+	return PrintBuckets(ctx, cmd.out, []*influxdb.Bucket{b}, cmd.format)
+}
+
+type bucketRetentionCommand struct {
+	logger             *zap.Logger
+	storeFlags         testhelper.StoreFlags
+	out                io.Writer
+	format             string
+	bucket             bucketFlags
+	retention          time.Duration
+	shardGroupDuration time.Duration
+	dryRun             bool
+}
+
+func NewBucketRetentionCommand() *cobra.Command {
+	var bucketCmd bucketRetentionCommand
+	cmd := &cobra.Command{
+		Use:   "retention",
+		Short: "Change the retention period of a bucket",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config := logger.NewConfig()
+			config.Level = zapcore.InfoLevel
+
+			newLogger, err := config.New(cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			bucketCmd.logger = newLogger
+			bucketCmd.out = cmd.OutOrStdout()
+			if !cmd.Flags().Changed("retention") {
+				return fmt.Errorf("must provide --retention")
+			}
+			return bucketCmd.run()
+		},
+	}
+
+	bucketCmd.storeFlags.Register(cmd)
+	testhelper.AddFormatFlag(cmd, &bucketCmd.format)
+	bucketCmd.bucket.register(cmd)
+	cmd.Flags().DurationVar(&bucketCmd.retention, "retention", 0, "New retention period of the bucket, 0 to keep data forever")
+	cmd.Flags().DurationVar(&bucketCmd.shardGroupDuration, "shard-group-duration", 0, "New shard group duration of the bucket, 0 to keep it")
+	testhelper.AddDryRunFlag(cmd, &bucketCmd.dryRun)
+
+	return cmd
+}
+
+func (cmd *bucketRetentionCommand) run() error {
+	ctx := context.Background()
+	stores, err := cmd.storeFlags.Open(ctx, cmd.logger, cmd.dryRun)
+	if err != nil {
+		return err
+	}
+	defer stores.Close()
+	store := stores.KV
+	tenantService := tenant.NewService(tenant.NewStore(store))
+	metaClient, err := openMetaClient(store, cmd.dryRun)
+	if err != nil {
+		return err
+	}
+	defer metaClient.Close()
+
+	b, err := cmd.bucket.find(ctx, tenantService)
+	if err != nil {
+		return err
+	}
+
+	upd := influxdb.BucketUpdate{RetentionPeriod: &cmd.retention}
+	if cmd.shardGroupDuration != 0 {
+		upd.ShardGroupDuration = &cmd.shardGroupDuration
+	}
+
+	// the storage engine finds the retention of the bucket in the metadata
+	rpu := meta.RetentionPolicyUpdate{
+		Duration:           upd.RetentionPeriod,
+		ShardGroupDuration: upd.ShardGroupDuration,
+	}
+	if cmd.dryRun {
+		// the update is checked against a copy of the metadata
+		data := metaClient.Data()
+		err = data.UpdateRetentionPolicy(b.ID.String(), meta.DefaultRetentionPolicyName, &rpu, true)
+	} else {
+		err = metaClient.UpdateRetentionPolicy(b.ID.String(), meta.DefaultRetentionPolicyName, &rpu, true)
+	}
+	if err != nil {
+		if err == meta.ErrIncompatibleDurations {
+			return fmt.Errorf("shard-group duration must also be updated to be smaller than new retention duration")
+		}
+		return err
+	}
+
+	if cmd.dryRun {
+		_, err := fmt.Fprintf(cmd.out, "Dry run: would set the retention of bucket %q (%s) to %s\n", b.Name, b.ID, cmd.retention)
+		return err
+	}
+
+	b, err = tenantService.UpdateBucket(ctx, b.ID, upd)
+	if err != nil {
+		return err
+	}
+	return PrintBuckets(ctx, cmd.out, []*influxdb.Bucket{b}, cmd.format)
+}
+
+func PrintBuckets(ctx context.Context, w io.Writer, v []*influxdb.Bucket, format string) error {
+	headers := []string{
+		"ID",
+		"Name",
+		"Organization ID",
+		"Retention",
+		"Shard Group Duration",
+	}
+
+	var rows []map[string]interface{}
+	for _, b := range v {
+		retention := "infinite"
+		if b.RetentionPeriod > 0 {
+			retention = b.RetentionPeriod.String()
+		}
+		row := map[string]interface{}{
+			"ID":                   b.ID,
+			"Name":                 b.Name,
+			"Organization ID":      b.OrgID,
+			"Retention":            retention,
+			"Shard Group Duration": b.ShardGroupDuration.String(),
+		}
+		rows = append(rows, row)
+	}
+
+	return testhelper.Render(w, format, headers, rows)
+}
@@ -0,0 +1,65 @@
+package bucket
+
+import (
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/cmd/influxd/recovery/testhelper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Bucket_Basic(t *testing.T) {
+	db := testhelper.NewTestBoltDb(t)
+	defer db.Close()
+	assert.Regexp(t, "\tmybucket\t+dd7cd2292f6e974a\t+infinite\t",
+		testhelper.MustRunCommand(t, NewBucketCommand(), "list", "--bolt-path", db.Name()))
+
+	// org must exist
+	assert.EqualError(t, testhelper.RunCommand(t, NewBucketCommand(), "create", "--bolt-path", db.Name(), "--org", "not-exist", "--name", "newbucket"), "could not find org \"not-exist\": organization name \"not-exist\" not found")
+
+	// bucket creation works
+	assert.NoError(t, testhelper.RunCommand(t, NewBucketCommand(), "create", "--bolt-path", db.Name(), "--org", "myorg", "--name", "newbucket", "--retention", "24h"))
+	assert.Regexp(t, "\tnewbucket\t+dd7cd2292f6e974a\t+24h0m0s\t+1h0m0s\n",
+		testhelper.MustRunCommand(t, NewBucketCommand(), "list", "--bolt-path", db.Name(), "--org", "myorg"))
+
+	// bucket creation only works for new names
+	assert.Error(t, testhelper.RunCommand(t, NewBucketCommand(), "create", "--bolt-path", db.Name(), "--org", "myorg", "--name", "newbucket"))
+
+	// bucket rename works
+	assert.NoError(t, testhelper.RunCommand(t, NewBucketCommand(), "rename", "--bolt-path", db.Name(), "--org", "myorg", "--name", "newbucket", "--new-name", "renamed"))
+	assert.Error(t, testhelper.RunCommand(t, NewBucketCommand(), "rename", "--bolt-path", db.Name(), "--org", "myorg", "--name", "newbucket", "--new-name", "renamed"))
+
+	// bucket retention change works
+	assert.EqualError(t, testhelper.RunCommand(t, NewBucketCommand(), "retention", "--bolt-path", db.Name(), "--org", "myorg", "--name", "renamed"), "must provide --retention")
+	assert.NoError(t, testhelper.RunCommand(t, NewBucketCommand(), "retention", "--bolt-path", db.Name(), "--org", "myorg", "--name", "renamed", "--retention", "48h"))
+	assert.Regexp(t, "\trenamed\t+dd7cd2292f6e974a\t+48h0m0s\t",
+		testhelper.MustRunCommand(t, NewBucketCommand(), "list", "--bolt-path", db.Name()))
+}
+
+func Test_Bucket_DryRun(t *testing.T) {
+	db := testhelper.NewTestBoltDb(t)
+	defer db.Close()
+	assert.NoError(t, testhelper.RunCommand(t, NewBucketCommand(), "create", "--bolt-path", db.Name(), "--org", "myorg", "--name", "newbucket", "--retention", "24h"))
+	before := testhelper.MustRunCommand(t, NewBucketCommand(), "list", "--bolt-path", db.Name())
+
+	assert.EqualError(t, testhelper.RunCommand(t, NewBucketCommand(), "create", "--bolt-path", db.Name(), "--org", "myorg", "--name", "mybucket", "--dry-run"),
+		"bucket with name mybucket already exists")
+	assert.EqualError(t, testhelper.RunCommand(t, NewBucketCommand(), "create", "--bolt-path", db.Name(), "--org", "myorg", "--name", "otherbucket", "--retention", "24h", "--shard-group-duration", "48h", "--dry-run"),
+		"retention policy duration must be greater than the shard duration")
+	assert.Equal(t, "Dry run: would create bucket \"otherbucket\" in org \"myorg\" (dd7cd2292f6e974a)\n",
+		testhelper.MustRunCommand(t, NewBucketCommand(), "create", "--bolt-path", db.Name(), "--org", "myorg", "--name", "otherbucket", "--dry-run"))
+
+	assert.EqualError(t, testhelper.RunCommand(t, NewBucketCommand(), "rename", "--bolt-path", db.Name(), "--org", "myorg", "--name", "_tasks", "--new-name", "tasks", "--dry-run"),
+		"system buckets cannot be renamed")
+	assert.EqualError(t, testhelper.RunCommand(t, NewBucketCommand(), "rename", "--bolt-path", db.Name(), "--org", "myorg", "--name", "newbucket", "--new-name", "mybucket", "--dry-run"),
+		"bucket name is not unique")
+	assert.Regexp(t, `^Dry run: would rename bucket "newbucket" \([0-9a-f]{16}\) to "renamed"\n$`,
+		testhelper.MustRunCommand(t, NewBucketCommand(), "rename", "--bolt-path", db.Name(), "--org", "myorg", "--name", "newbucket", "--new-name", "renamed", "--dry-run"))
+
+	assert.EqualError(t, testhelper.RunCommand(t, NewBucketCommand(), "retention", "--bolt-path", db.Name(), "--org", "myorg", "--name", "newbucket", "--retention", "30m", "--dry-run"),
+		"retention policy duration must be at least 1h0m0s")
+	assert.Regexp(t, `^Dry run: would set the retention of bucket "newbucket" \([0-9a-f]{16}\) to 48h0m0s\n$`,
+		testhelper.MustRunCommand(t, NewBucketCommand(), "retention", "--bolt-path", db.Name(), "--org", "myorg", "--name", "newbucket", "--retention", "48h", "--dry-run"))
+
+	// nothing was written
+	assert.Equal(t, before, testhelper.MustRunCommand(t, NewBucketCommand(), "list", "--bolt-path", db.Name()))
+}
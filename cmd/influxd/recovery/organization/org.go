@@ -4,13 +4,12 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"os"
-	"path/filepath"
 
-	"github.com/influxdata/influx-cli/v2/pkg/tabwriter"
 	"github.com/influxdata/influxdb/v2"
-	"github.com/influxdata/influxdb/v2/bolt"
+	"github.com/influxdata/influxdb/v2/cmd/influxd/recovery/testhelper"
+	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/logger"
+	"github.com/influxdata/influxdb/v2/sqlite"
 	"github.com/influxdata/influxdb/v2/tenant"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
@@ -29,14 +28,16 @@ func NewOrgCommand() *cobra.Command {
 
 	base.AddCommand(NewOrgListCommand())
 	base.AddCommand(NewOrgCreateCommand())
+	base.AddCommand(NewOrgDeleteCommand())
 
 	return base
 }
 
 type orgListCommand struct {
-	logger   *zap.Logger
-	boltPath string
-	out      io.Writer
+	logger     *zap.Logger
+	storeFlags testhelper.StoreFlags
+	out        io.Writer
+	format     string
 }
 
 func NewOrgListCommand() *cobra.Command {
@@ -58,20 +59,20 @@ func NewOrgListCommand() *cobra.Command {
 		},
 	}
 
-	defaultPath := filepath.Join(os.Getenv("HOME"), ".influxdbv2", "influxd.bolt")
-
-	cmd.Flags().StringVar(&orgCmd.boltPath, "bolt-path", defaultPath, "Path to the BoltDB file")
+	orgCmd.storeFlags.Register(cmd)
+	testhelper.AddFormatFlag(cmd, &orgCmd.format)
 
 	return cmd
 }
 
 func (cmd *orgListCommand) run() error {
 	ctx := context.Background()
-	store := bolt.NewKVStore(cmd.logger.With(zap.String("system", "bolt-kvstore")), cmd.boltPath)
-	if err := store.Open(ctx); err != nil {
+	stores, err := cmd.storeFlags.Open(ctx, cmd.logger, false)
+	if err != nil {
 		return err
 	}
-	defer store.Close()
+	defer stores.Close()
+	store := stores.KV
 	tenantStore := tenant.NewStore(store)
 	tenantService := tenant.NewService(tenantStore)
 	orgs, _, err := tenantService.FindOrganizations(ctx, influxdb.OrganizationFilter{})
@@ -79,14 +80,15 @@ func (cmd *orgListCommand) run() error {
 		return err
 	}
 
-	return PrintOrgs(ctx, cmd.out, orgs)
+	return PrintOrgs(ctx, cmd.out, orgs, cmd.format)
 }
 
 type orgCreateCommand struct {
-	logger   *zap.Logger
-	boltPath string
-	out      io.Writer
-	org      string
+	logger     *zap.Logger
+	storeFlags testhelper.StoreFlags
+	out        io.Writer
+	format     string
+	org        string
 }
 
 func NewOrgCreateCommand() *cobra.Command {
@@ -108,8 +110,8 @@ func NewOrgCreateCommand() *cobra.Command {
 		},
 	}
 
-	defaultPath := filepath.Join(os.Getenv("HOME"), ".influxdbv2", "influxd.bolt")
-	cmd.Flags().StringVar(&orgCmd.boltPath, "bolt-path", defaultPath, "Path to the BoltDB file")
+	orgCmd.storeFlags.Register(cmd)
+	testhelper.AddFormatFlag(cmd, &orgCmd.format)
 	cmd.Flags().StringVar(&orgCmd.org, "org", "", "Name of the org to create")
 
 	return cmd
@@ -117,11 +119,12 @@ func NewOrgCreateCommand() *cobra.Command {
 
 func (cmd *orgCreateCommand) run() error {
 	ctx := context.Background()
-	store := bolt.NewKVStore(cmd.logger.With(zap.String("system", "bolt-kvstore")), cmd.boltPath)
-	if err := store.Open(ctx); err != nil {
+	stores, err := cmd.storeFlags.Open(ctx, cmd.logger, false)
+	if err != nil {
 		return err
 	}
-	defer store.Close()
+	defer stores.Close()
+	store := stores.KV
 	tenantStore := tenant.NewStore(store)
 	tenantService := tenant.NewService(tenantStore)
 	if cmd.org == "" {
@@ -138,10 +141,130 @@ func (cmd *orgCreateCommand) run() error {
 	if err != nil {
 		return err
 	}
-	return PrintOrgs(ctx, cmd.out, orgs)
+	return PrintOrgs(ctx, cmd.out, orgs, cmd.format)
+}
+
+type orgDeleteCommand struct {
+	logger     *zap.Logger
+	storeFlags testhelper.StoreFlags
+	out        io.Writer
+	format     string
+	org        string
+	dryRun     bool
+}
+
+func NewOrgDeleteCommand() *cobra.Command {
+	var orgCmd orgDeleteCommand
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete an org and its buckets, leaving their data on disk",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config := logger.NewConfig()
+			config.Level = zapcore.InfoLevel
+
+			newLogger, err := config.New(cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			orgCmd.logger = newLogger
+			orgCmd.out = cmd.OutOrStdout()
+			return orgCmd.run()
+		},
+	}
+
+	orgCmd.storeFlags.Register(cmd)
+	testhelper.AddFormatFlag(cmd, &orgCmd.format)
+	cmd.Flags().StringVar(&orgCmd.org, "org", "", "Name of the org to delete")
+	testhelper.AddDryRunFlag(cmd, &orgCmd.dryRun)
+
+	return cmd
+}
+
+func (cmd *orgDeleteCommand) run() error {
+	ctx := context.Background()
+	stores, err := cmd.storeFlags.Open(ctx, cmd.logger, cmd.dryRun)
+	if err != nil {
+		return err
+	}
+	defer stores.Close()
+	store := stores.KV
+	tenantStore := tenant.NewStore(store)
+	tenantService := tenant.NewService(tenantStore)
+	if cmd.org == "" {
+		return fmt.Errorf("must provide --org")
+	}
+
+	org, err := tenantService.FindOrganization(ctx, influxdb.OrganizationFilter{Name: &cmd.org})
+	if err != nil {
+		return fmt.Errorf("could not find org %q: %w", cmd.org, err)
+	}
+
+	if cmd.dryRun {
+		buckets, _, err := tenantService.FindBuckets(ctx, influxdb.BucketFilter{OrganizationID: &org.ID})
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(cmd.out, "Dry run: would delete org %q (%s) and its %d buckets\n", org.Name, org.ID, len(buckets))
+		return err
+	}
+
+	// Delete the SQLite resources first, so that a failure leaves the org
+	// in place to retry.
+	if stores.SQL != nil {
+		if err := deleteOrgSQLResources(ctx, stores.SQL, org.ID); err != nil {
+			return fmt.Errorf("could not delete the sqlite resources of org %q: %w", cmd.org, err)
+		}
+	}
+	if err := tenantService.DeleteOrganization(ctx, org.ID); err != nil {
+		return err
+	}
+
+	orgs, _, err := tenantService.FindOrganizations(ctx, influxdb.OrganizationFilter{})
+	if err != nil {
+		return err
+	}
+	return PrintOrgs(ctx, cmd.out, orgs, cmd.format)
+}
+
+// orgSQLTables are the SQLite tables holding resources of an org, ordered so
+// that rows are deleted before the rows they reference.
+var orgSQLTables = []string{
+	"replications",
+	"remotes",
+	"annotations",
+	"streams",
+	"notebooks",
+	"flux_packages",
+}
+
+// deleteOrgSQLResources deletes the resources of an org stored in SQLite.
+// Tables missing from older metadata layouts are skipped.
+func deleteOrgSQLResources(ctx context.Context, store *sqlite.SqlStore, orgID platform.ID) error {
+	store.Mu.Lock()
+	defer store.Mu.Unlock()
+
+	tx, err := store.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, table := range orgSQLTables {
+		var n int
+		if err := tx.GetContext(ctx, &n, `SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name=?`, table); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if n == 0 {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE org_id = ?", table), orgID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
-func PrintOrgs(ctx context.Context, w io.Writer, v []*influxdb.Organization) error {
+func PrintOrgs(ctx context.Context, w io.Writer, v []*influxdb.Organization, format string) error {
 	headers := []string{
 		"ID",
 		"Name",
@@ -156,15 +279,5 @@ func PrintOrgs(ctx context.Context, w io.Writer, v []*influxdb.Organization) err
 		rows = append(rows, row)
 	}
 
-	writer := tabwriter.NewTabWriter(w, false)
-	defer writer.Flush()
-	if err := writer.WriteHeaders(headers...); err != nil {
-		return err
-	}
-	for _, row := range rows {
-		if err := writer.Write(row); err != nil {
-			return err
-		}
-	}
-	return nil
+	return testhelper.Render(w, format, headers, rows)
 }
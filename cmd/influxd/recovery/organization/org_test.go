@@ -1,10 +1,16 @@
 package organization
 
 import (
+	"context"
+	"path/filepath"
 	"testing"
 
 	"github.com/influxdata/influxdb/v2/cmd/influxd/recovery/testhelper"
+	"github.com/influxdata/influxdb/v2/sqlite"
+	"github.com/influxdata/influxdb/v2/sqlite/migrations"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 )
 
 func Test_Org_Basic(t *testing.T) {
@@ -23,4 +29,74 @@ dd7cd2292f6e974a	myorg
 
 	// neworg shows up in list of orgs
 	assert.Regexp(t, "\tneworg\n", testhelper.MustRunCommand(t, NewOrgCommand(), "list", "--bolt-path", db.Name()))
+
+	// org deletion only works for existing names
+	assert.EqualError(t, testhelper.RunCommand(t, NewOrgCommand(), "delete", "--bolt-path", db.Name(), "--org", "not-exist"), "could not find org \"not-exist\": organization name \"not-exist\" not found")
+
+	// org deletion works
+	assert.NoError(t, testhelper.RunCommand(t, NewOrgCommand(), "delete", "--bolt-path", db.Name(), "--org", "neworg"))
+	assert.NotRegexp(t, "\tneworg\n", testhelper.MustRunCommand(t, NewOrgCommand(), "list", "--bolt-path", db.Name()))
+}
+
+func Test_Org_DryRun(t *testing.T) {
+	db := testhelper.NewTestBoltDb(t)
+	defer db.Close()
+
+	assert.EqualError(t, testhelper.RunCommand(t, NewOrgCommand(), "delete", "--bolt-path", db.Name(), "--org", "not-exist", "--dry-run"), "could not find org \"not-exist\": organization name \"not-exist\" not found")
+	assert.Equal(t, "Dry run: would delete org \"myorg\" (dd7cd2292f6e974a) and its 3 buckets\n",
+		testhelper.MustRunCommand(t, NewOrgCommand(), "delete", "--bolt-path", db.Name(), "--org", "myorg", "--dry-run"))
+
+	// nothing was written
+	assert.Equal(t, `ID			Name
+dd7cd2292f6e974a	myorg
+`,
+		testhelper.MustRunCommand(t, NewOrgCommand(), "list", "--bolt-path", db.Name()))
+}
+
+func Test_Org_Format(t *testing.T) {
+	db := testhelper.NewTestBoltDb(t)
+	defer db.Close()
+	assert.Equal(t, `[
+  {
+    "ID": "dd7cd2292f6e974a",
+    "Name": "myorg"
+  }
+]
+`,
+		testhelper.MustRunCommand(t, NewOrgCommand(), "list", "--bolt-path", db.Name(), "--format", "json"))
+	assert.Equal(t, `ID,Name
+dd7cd2292f6e974a,myorg
+`,
+		testhelper.MustRunCommand(t, NewOrgCommand(), "list", "--bolt-path", db.Name(), "--format", "csv"))
+
+	assert.EqualError(t, testhelper.RunCommand(t, NewOrgCommand(), "list", "--bolt-path", db.Name(), "--format", "xml"),
+		`unsupported format "xml", must be one of table, json or csv`)
+}
+
+func Test_Org_DeleteSQLite(t *testing.T) {
+	ctx := context.Background()
+	db := testhelper.NewTestBoltDb(t)
+	defer db.Close()
+
+	sqlitePath := filepath.Join(t.TempDir(), sqlite.DefaultFilename)
+	store, err := sqlite.NewSqlStore(sqlitePath, zap.NewNop())
+	require.NoError(t, err)
+	require.NoError(t, sqlite.NewMigrator(store, zap.NewNop()).Up(ctx, migrations.AllUp))
+	for _, orgID := range []string{"dd7cd2292f6e974a", "0000000000000001"} {
+		_, err := store.DB.Exec(`INSERT INTO notebooks (id, org_id, name, spec) VALUES (?, ?, 'nb', '{}')`, orgID+"-nb", orgID)
+		require.NoError(t, err)
+	}
+	require.NoError(t, store.Close())
+
+	// a missing sqlite file is an error
+	assert.Error(t, testhelper.RunCommand(t, NewOrgCommand(), "list", "--bolt-path", db.Name(), "--sqlite-path", sqlitePath+".missing"))
+
+	assert.NoError(t, testhelper.RunCommand(t, NewOrgCommand(), "delete", "--bolt-path", db.Name(), "--sqlite-path", sqlitePath, "--org", "myorg"))
+
+	store, err = sqlite.NewSqlStore(sqlitePath, zap.NewNop())
+	require.NoError(t, err)
+	defer store.Close()
+	var orgIDs []string
+	require.NoError(t, store.DB.Select(&orgIDs, `SELECT org_id FROM notebooks`))
+	assert.Equal(t, []string{"0000000000000001"}, orgIDs)
 }
@@ -1,7 +1,9 @@
 package recovery
 
 import (
+	"github.com/influxdata/influxdb/v2/cmd/influxd/recovery/audit"
 	"github.com/influxdata/influxdb/v2/cmd/influxd/recovery/auth"
+	"github.com/influxdata/influxdb/v2/cmd/influxd/recovery/bucket"
 	"github.com/influxdata/influxdb/v2/cmd/influxd/recovery/organization"
 	"github.com/influxdata/influxdb/v2/cmd/influxd/recovery/user"
 	"github.com/spf13/cobra"
@@ -21,6 +23,8 @@ func NewCommand() *cobra.Command {
 	base.AddCommand(auth.NewAuthCommand())
 	base.AddCommand(user.NewUserCommand())
 	base.AddCommand(organization.NewOrgCommand())
+	base.AddCommand(audit.NewAuditCommand())
+	base.AddCommand(bucket.NewBucketCommand())
 
 	return base
 }
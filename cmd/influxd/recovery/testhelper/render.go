@@ -0,0 +1,89 @@
+package testhelper
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/influxdata/influx-cli/v2/pkg/tabwriter"
+	"github.com/spf13/cobra"
+)
+
+// Output formats of the recovery commands.
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+)
+
+// AddFormatFlag adds the --format flag choosing the output format of a command.
+func AddFormatFlag(cmd *cobra.Command, format *string) {
+	cmd.Flags().StringVar(format, "format", FormatTable, "Output format: table, json or csv")
+}
+
+// AddDryRunFlag adds the --dry-run flag of a command changing the store.
+func AddDryRunFlag(cmd *cobra.Command, dryRun *bool) {
+	cmd.Flags().BoolVar(dryRun, "dry-run", false, "Open the store read-only, validate the operation and print what would change without writing")
+}
+
+// Render writes rows, holding the value of each header, in a format. Tables
+// and CSV have a line of headers; JSON is an array of objects keyed by header.
+func Render(w io.Writer, format string, headers []string, rows []map[string]interface{}) error {
+	switch format {
+	case FormatTable, "":
+		return renderTable(w, headers, rows)
+	case FormatJSON:
+		return renderJSON(w, headers, rows)
+	case FormatCSV:
+		return renderCSV(w, headers, rows)
+	default:
+		return fmt.Errorf("unsupported format %q, must be one of %s, %s or %s", format, FormatTable, FormatJSON, FormatCSV)
+	}
+}
+
+func renderTable(w io.Writer, headers []string, rows []map[string]interface{}) error {
+	writer := tabwriter.NewTabWriter(w, false)
+	defer writer.Flush()
+	if err := writer.WriteHeaders(headers...); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renderJSON(w io.Writer, headers []string, rows []map[string]interface{}) error {
+	objects := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		obj := make(map[string]interface{}, len(headers))
+		for _, h := range headers {
+			obj[h] = row[h]
+		}
+		objects = append(objects, obj)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(objects)
+}
+
+func renderCSV(w io.Writer, headers []string, rows []map[string]interface{}) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(headers); err != nil {
+		return err
+	}
+	record := make([]string, len(headers))
+	for _, row := range rows {
+		for i, h := range headers {
+			record[i] = fmt.Sprint(row[h])
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,82 @@
+package testhelper
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/influxdata/influxdb/v2/bolt"
+	"github.com/influxdata/influxdb/v2/sqlite"
+	sqliteMigrations "github.com/influxdata/influxdb/v2/sqlite/migrations"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// StoreFlags locate the metadata stores a recovery command opens.
+type StoreFlags struct {
+	BoltPath   string
+	SqlitePath string
+}
+
+// Register adds the --bolt-path and --sqlite-path flags to a command.
+func (f *StoreFlags) Register(cmd *cobra.Command) {
+	defaultPath := filepath.Join(os.Getenv("HOME"), ".influxdbv2", "influxd.bolt")
+	cmd.Flags().StringVar(&f.BoltPath, "bolt-path", defaultPath, "Path to the BoltDB file")
+	cmd.Flags().StringVar(&f.SqlitePath, "sqlite-path", "", "Path to the SQLite metadata file. If set, the resources stored there are repaired too")
+}
+
+// Stores are the opened metadata stores of an instance. SQL is nil unless
+// a --sqlite-path was given.
+type Stores struct {
+	KV  *bolt.KVStore
+	SQL *sqlite.SqlStore
+}
+
+// Open opens the stores, read-only if readOnly is set. The SQLite file must
+// exist and hold no migration unknown to this influxd.
+func (f *StoreFlags) Open(ctx context.Context, log *zap.Logger, readOnly bool) (*Stores, error) {
+	var opts []bolt.KVOption
+	if readOnly {
+		opts = append(opts, bolt.WithReadOnly)
+	}
+	kvStore := bolt.NewKVStore(log.With(zap.String("system", "bolt-kvstore")), f.BoltPath, opts...)
+	if err := kvStore.Open(ctx); err != nil {
+		return nil, err
+	}
+	stores := &Stores{KV: kvStore}
+	if f.SqlitePath == "" {
+		return stores, nil
+	}
+
+	if _, err := os.Stat(f.SqlitePath); err != nil {
+		stores.Close()
+		return nil, fmt.Errorf("unable to open sqlite file: %w", err)
+	}
+	path := f.SqlitePath
+	if readOnly {
+		path = "file:" + path + "?mode=ro"
+	}
+	sqlStore, err := sqlite.NewSqlStore(path, log.With(zap.String("system", "sqlite")))
+	if err != nil {
+		stores.Close()
+		return nil, err
+	}
+	stores.SQL = sqlStore
+	if err := sqlite.NewMigrator(sqlStore, log).Validate(ctx, sqliteMigrations.AllUp); err != nil {
+		stores.Close()
+		return nil, fmt.Errorf("SQL metadata is not compatible with this influxd: %w", err)
+	}
+	return stores, nil
+}
+
+// Close closes the opened stores.
+func (s *Stores) Close() error {
+	if s.SQL != nil {
+		if err := s.SQL.Close(); err != nil {
+			s.KV.Close()
+			return err
+		}
+	}
+	return s.KV.Close()
+}
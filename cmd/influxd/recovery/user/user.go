@@ -4,15 +4,14 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"os"
-	"path/filepath"
 
-	"github.com/influxdata/influx-cli/v2/pkg/tabwriter"
 	"github.com/influxdata/influxdb/v2"
-	"github.com/influxdata/influxdb/v2/bolt"
+	"github.com/influxdata/influxdb/v2/cmd/influxd/recovery/testhelper"
 	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/logger"
 	"github.com/influxdata/influxdb/v2/tenant"
+	authv1 "github.com/influxdata/influxdb/v2/v1/authorization"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -31,14 +30,16 @@ func NewUserCommand() *cobra.Command {
 	base.AddCommand(NewUserListCommand())
 	base.AddCommand(NewUserCreateCommand())
 	base.AddCommand(NewUserUpdateCommand())
+	base.AddCommand(NewUserUnlockCommand())
 
 	return base
 }
 
 type userListCommand struct {
-	logger   *zap.Logger
-	boltPath string
-	out      io.Writer
+	logger     *zap.Logger
+	storeFlags testhelper.StoreFlags
+	out        io.Writer
+	format     string
 }
 
 func NewUserListCommand() *cobra.Command {
@@ -60,20 +61,20 @@ func NewUserListCommand() *cobra.Command {
 		},
 	}
 
-	defaultPath := filepath.Join(os.Getenv("HOME"), ".influxdbv2", "influxd.bolt")
-
-	cmd.Flags().StringVar(&userCmd.boltPath, "bolt-path", defaultPath, "Path to the BoltDB file.")
+	userCmd.storeFlags.Register(cmd)
+	testhelper.AddFormatFlag(cmd, &userCmd.format)
 
 	return cmd
 }
 
 func (cmd *userListCommand) run() error {
 	ctx := context.Background()
-	store := bolt.NewKVStore(cmd.logger.With(zap.String("system", "bolt-kvstore")), cmd.boltPath)
-	if err := store.Open(ctx); err != nil {
+	stores, err := cmd.storeFlags.Open(ctx, cmd.logger, false)
+	if err != nil {
 		return err
 	}
-	defer store.Close()
+	defer stores.Close()
+	store := stores.KV
 	tenantStore := tenant.NewStore(store)
 	tenantService := tenant.NewService(tenantStore)
 	filter := influxdb.UserFilter{}
@@ -82,15 +83,18 @@ func (cmd *userListCommand) run() error {
 		return err
 	}
 
-	return PrintUsers(ctx, cmd.out, users)
+	return PrintUsers(ctx, cmd.out, users, cmd.format)
 }
 
 type userCreateCommand struct {
-	logger   *zap.Logger
-	boltPath string
-	out      io.Writer
-	username string
-	password string
+	logger     *zap.Logger
+	storeFlags testhelper.StoreFlags
+	out        io.Writer
+	format     string
+	username   string
+	password   string
+	dryRun     bool
+	policy     tenant.PasswordPolicy
 }
 
 func NewUserCreateCommand() *cobra.Command {
@@ -112,23 +116,29 @@ func NewUserCreateCommand() *cobra.Command {
 		},
 	}
 
-	defaultPath := filepath.Join(os.Getenv("HOME"), ".influxdbv2", "influxd.bolt")
-	cmd.Flags().StringVar(&userCmd.boltPath, "bolt-path", defaultPath, "Path to the BoltDB file")
+	userCmd.storeFlags.Register(cmd)
+	testhelper.AddFormatFlag(cmd, &userCmd.format)
 	cmd.Flags().StringVar(&userCmd.username, "username", "", "Name of the user")
 	cmd.Flags().StringVar(&userCmd.password, "password", "", "Password for new user")
+	testhelper.AddDryRunFlag(cmd, &userCmd.dryRun)
+	registerPasswordPolicyFlags(cmd, &userCmd.policy)
 
 	return cmd
 }
 
 func (cmd *userCreateCommand) run() error {
 	ctx := context.Background()
-	store := bolt.NewKVStore(cmd.logger.With(zap.String("system", "bolt-kvstore")), cmd.boltPath)
-	if err := store.Open(ctx); err != nil {
+	if err := cmd.policy.Valid(); err != nil {
+		return fmt.Errorf("invalid password policy: %w", err)
+	}
+	stores, err := cmd.storeFlags.Open(ctx, cmd.logger, cmd.dryRun)
+	if err != nil {
 		return err
 	}
-	defer store.Close()
+	defer stores.Close()
+	store := stores.KV
 	tenantStore := tenant.NewStore(store)
-	tenantService := tenant.NewService(tenantStore)
+	tenantService := tenant.NewService(tenantStore, tenant.WithPasswordPolicy(cmd.policy))
 
 	if cmd.username == "" {
 		return fmt.Errorf("must provide --username")
@@ -137,6 +147,19 @@ func (cmd *userCreateCommand) run() error {
 		return fmt.Errorf("must provide --password")
 	}
 
+	if cmd.dryRun {
+		if err := cmd.policy.Check(cmd.password); err != nil {
+			return err
+		}
+		if _, err := tenantService.FindUser(ctx, influxdb.UserFilter{Name: &cmd.username}); err == nil {
+			return errors.UserAlreadyExistsError(cmd.username)
+		} else if errors.ErrorCode(err) != errors.ENotFound {
+			return err
+		}
+		_, err := fmt.Fprintf(cmd.out, "Dry run: would create user %q\n", cmd.username)
+		return err
+	}
+
 	user := influxdb.User{
 		Name: cmd.username,
 	}
@@ -159,10 +182,19 @@ func (cmd *userCreateCommand) run() error {
 	if err != nil {
 		return err
 	}
-	return PrintUsers(ctx, cmd.out, users)
+	return PrintUsers(ctx, cmd.out, users, cmd.format)
+}
+
+// registerPasswordPolicyFlags adds the flags of the policy the passwords set
+// by a command follow, which should match the one of the server.
+func registerPasswordPolicyFlags(cmd *cobra.Command, policy *tenant.PasswordPolicy) {
+	*policy = tenant.DefaultPasswordPolicy()
+	cmd.Flags().IntVar(&policy.MinLength, "password-min-length", policy.MinLength, "Minimum length of the password")
+	cmd.Flags().IntVar(&policy.MinCharClasses, "password-min-char-classes", policy.MinCharClasses, "Minimum number of uppercase, lowercase, number and special character classes in the password")
+	cmd.Flags().StringSliceVar(&policy.Banned, "password-banned", nil, "Passwords which may not be used, compared case-insensitively")
 }
 
-func PrintUsers(ctx context.Context, w io.Writer, v []*influxdb.User) error {
+func PrintUsers(ctx context.Context, w io.Writer, v []*influxdb.User, format string) error {
 	headers := []string{"ID", "Name"}
 
 	var rows []map[string]interface{}
@@ -174,26 +206,19 @@ func PrintUsers(ctx context.Context, w io.Writer, v []*influxdb.User) error {
 		rows = append(rows, row)
 	}
 
-	writer := tabwriter.NewTabWriter(w, false)
-	defer writer.Flush()
-	if err := writer.WriteHeaders(headers...); err != nil {
-		return err
-	}
-	for _, row := range rows {
-		if err := writer.Write(row); err != nil {
-			return err
-		}
-	}
-	return nil
+	return testhelper.Render(w, format, headers, rows)
 }
 
 type userUpdateCommand struct {
-	logger   *zap.Logger
-	boltPath string
-	out      io.Writer
-	username string
-	id       string
-	password string
+	logger     *zap.Logger
+	storeFlags testhelper.StoreFlags
+	out        io.Writer
+	format     string
+	username   string
+	id         string
+	password   string
+	dryRun     bool
+	policy     tenant.PasswordPolicy
 }
 
 func NewUserUpdateCommand() *cobra.Command {
@@ -215,24 +240,30 @@ func NewUserUpdateCommand() *cobra.Command {
 		},
 	}
 
-	defaultPath := filepath.Join(os.Getenv("HOME"), ".influxdbv2", "influxd.bolt")
-	cmd.Flags().StringVar(&userCmd.boltPath, "bolt-path", defaultPath, "Path to the BoltDB file")
+	userCmd.storeFlags.Register(cmd)
+	testhelper.AddFormatFlag(cmd, &userCmd.format)
 	cmd.Flags().StringVar(&userCmd.username, "username", "", "Name of the user")
 	cmd.Flags().StringVar(&userCmd.id, "id", "", "ID of the user")
 	cmd.Flags().StringVar(&userCmd.password, "password", "", "New password for new user")
+	testhelper.AddDryRunFlag(cmd, &userCmd.dryRun)
+	registerPasswordPolicyFlags(cmd, &userCmd.policy)
 
 	return cmd
 }
 
 func (cmd *userUpdateCommand) run() error {
 	ctx := context.Background()
-	store := bolt.NewKVStore(cmd.logger.With(zap.String("system", "bolt-kvstore")), cmd.boltPath)
-	if err := store.Open(ctx); err != nil {
+	if err := cmd.policy.Valid(); err != nil {
+		return fmt.Errorf("invalid password policy: %w", err)
+	}
+	stores, err := cmd.storeFlags.Open(ctx, cmd.logger, cmd.dryRun)
+	if err != nil {
 		return err
 	}
-	defer store.Close()
+	defer stores.Close()
+	store := stores.KV
 	tenantStore := tenant.NewStore(store)
-	tenantService := tenant.NewService(tenantStore)
+	tenantService := tenant.NewService(tenantStore, tenant.WithPasswordPolicy(cmd.policy))
 
 	if cmd.password == "" {
 		return fmt.Errorf("must provide a new password to set, with --password")
@@ -257,6 +288,14 @@ func (cmd *userUpdateCommand) run() error {
 		return fmt.Errorf("expected 1 user, found %d", len(users))
 	}
 
+	if cmd.dryRun {
+		if err := cmd.policy.Check(cmd.password); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(cmd.out, "Dry run: would set the password of user %q (%s)\n", users[0].Name, users[0].ID)
+		return err
+	}
+
 	if err := tenantService.SetPassword(ctx, users[0].ID, cmd.password); err != nil {
 		return err
 	}
@@ -266,5 +305,79 @@ func (cmd *userUpdateCommand) run() error {
 	if err != nil {
 		return err
 	}
-	return PrintUsers(ctx, cmd.out, users)
+	return PrintUsers(ctx, cmd.out, users, cmd.format)
+}
+
+type userUnlockCommand struct {
+	logger     *zap.Logger
+	storeFlags testhelper.StoreFlags
+	out        io.Writer
+	username   string
+	id         string
+}
+
+func NewUserUnlockCommand() *cobra.Command {
+	var userCmd userUnlockCommand
+	cmd := &cobra.Command{
+		Use:   "unlock",
+		Short: "Unlock a v1 user locked after too many failed password attempts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config := logger.NewConfig()
+			config.Level = zapcore.InfoLevel
+
+			newLogger, err := config.New(cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			userCmd.logger = newLogger
+			userCmd.out = cmd.OutOrStdout()
+			return userCmd.run()
+		},
+	}
+
+	userCmd.storeFlags.Register(cmd)
+	cmd.Flags().StringVar(&userCmd.username, "username", "", "Name of the v1 user")
+	cmd.Flags().StringVar(&userCmd.id, "id", "", "ID of the v1 authorization")
+
+	return cmd
+}
+
+func (cmd *userUnlockCommand) run() error {
+	ctx := context.Background()
+	if (cmd.id == "") == (cmd.username == "") {
+		return fmt.Errorf("must provide exactly one of --id or --username")
+	}
+	stores, err := cmd.storeFlags.Open(ctx, cmd.logger, false)
+	if err != nil {
+		return err
+	}
+	defer stores.Close()
+	store := stores.KV
+	tenantService := tenant.NewService(tenant.NewStore(store))
+	authStore, err := authv1.NewStore(store)
+	if err != nil {
+		return err
+	}
+	auth := authv1.NewService(authStore, tenantService)
+
+	var authID platform.ID
+	if cmd.id != "" {
+		id, err := platform.IDFromString(cmd.id)
+		if err != nil {
+			return fmt.Errorf("invalid id %q: %w", cmd.id, err)
+		}
+		authID = *id
+	} else {
+		a, err := auth.FindAuthorizationByToken(ctx, cmd.username)
+		if err != nil {
+			return err
+		}
+		authID = a.ID
+	}
+
+	if err := auth.UnlockPassword(ctx, authID); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(cmd.out, "Unlocked v1 authorization %s\n", authID)
+	return err
 }
@@ -29,3 +29,34 @@ func Test_User_Basic(t *testing.T) {
 	assert.Regexp(t, "\ttestuser2\n",
 		testhelper.MustRunCommand(t, NewUserCommand(), "list", "--bolt-path", db.Name()))
 }
+
+func Test_User_DryRun(t *testing.T) {
+	db := testhelper.NewTestBoltDb(t)
+	defer db.Close()
+
+	assert.EqualError(t, testhelper.RunCommand(t, NewUserCommand(), "create", "--bolt-path", db.Name(), "--username", "testuser", "--password", "my_password", "--dry-run"),
+		"user with name testuser already exists")
+	assert.EqualError(t, testhelper.RunCommand(t, NewUserCommand(), "create", "--bolt-path", db.Name(), "--username", "testuser2", "--password", "foo", "--dry-run"), errors.EPasswordLength.Error())
+	assert.Equal(t, "Dry run: would create user \"testuser2\"\n",
+		testhelper.MustRunCommand(t, NewUserCommand(), "create", "--bolt-path", db.Name(), "--username", "testuser2", "--password", "my_password", "--dry-run"))
+
+	assert.EqualError(t, testhelper.RunCommand(t, NewUserCommand(), "update", "--bolt-path", db.Name(), "--username", "testuser2", "--password", "my_password", "--dry-run"), "user not found")
+	assert.Equal(t, "Dry run: would set the password of user \"testuser\" (08371db1dd8c8000)\n",
+		testhelper.MustRunCommand(t, NewUserCommand(), "update", "--bolt-path", db.Name(), "--username", "testuser", "--password", "my_password", "--dry-run"))
+
+	// nothing was written
+	assert.Equal(t, `ID			Name
+08371db1dd8c8000	testuser
+`,
+		testhelper.MustRunCommand(t, NewUserCommand(), "list", "--bolt-path", db.Name()))
+}
+
+func Test_User_Unlock(t *testing.T) {
+	db := testhelper.NewTestBoltDb(t)
+	defer db.Close()
+
+	assert.EqualError(t, testhelper.RunCommand(t, NewUserCommand(), "unlock", "--bolt-path", db.Name()),
+		"must provide exactly one of --id or --username")
+	assert.EqualError(t, testhelper.RunCommand(t, NewUserCommand(), "unlock", "--bolt-path", db.Name(), "--id", "08371db1dd8c8000"),
+		"authorization not found")
+}
@@ -0,0 +1,22 @@
+package context
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+const labelResolverCtxKey contextKey = "influx/label-resolver/v1"
+
+// SetLabelResolver sets the resolver of the labels of resources on context, with
+// which the permissions of the authorizer targeting resources by label are resolved.
+func SetLabelResolver(ctx context.Context, r influxdb.LabelResolver) context.Context {
+	return context.WithValue(ctx, labelResolverCtxKey, r)
+}
+
+// GetLabelResolver retrieves the label resolver from context. The second return
+// value is false if there is none, in which case label permissions match no resource.
+func GetLabelResolver(ctx context.Context) (influxdb.LabelResolver, bool) {
+	r, ok := ctx.Value(labelResolverCtxKey).(influxdb.LabelResolver)
+	return r, ok && r != nil
+}
@@ -0,0 +1,27 @@
+package context
+
+import (
+	"context"
+)
+
+const peerCredentialsCtxKey contextKey = "influx/peer-credentials/v1"
+
+// PeerCredentials identifies the local process on the other end of a
+// unix socket connection, as reported by the kernel.
+type PeerCredentials struct {
+	PID int32
+	UID uint32
+	GID uint32
+}
+
+// SetPeerCredentials sets the credentials of the connected peer on context.
+func SetPeerCredentials(ctx context.Context, creds PeerCredentials) context.Context {
+	return context.WithValue(ctx, peerCredentialsCtxKey, creds)
+}
+
+// GetPeerCredentials retrieves the credentials of the connected peer from context.
+// The second return value is false if the request was not received over a unix socket.
+func GetPeerCredentials(ctx context.Context) (PeerCredentials, bool) {
+	creds, ok := ctx.Value(peerCredentialsCtxKey).(PeerCredentials)
+	return creds, ok
+}
@@ -21,6 +21,16 @@ const ErrCellNotFound = "cell not found"
 // ErrViewNotFound is the error msg for a missing View.
 const ErrViewNotFound = "view not found"
 
+// ErrDashboardRevisionNotFound is the error msg for a missing dashboard revision.
+const ErrDashboardRevisionNotFound = "dashboard revision not found"
+
+// ErrDashboardShareNotFound is the error msg for a missing dashboard share.
+const ErrDashboardShareNotFound = "dashboard share not found"
+
+// ErrInvalidDashboardShareToken is the error msg for a share token which is
+// malformed, has been tampered with, has expired or has been revoked.
+const ErrInvalidDashboardShareToken = "dashboard share token is invalid or expired"
+
 // ops for dashboard service.
 const (
 	OpFindDashboardByID       = "FindDashboardByID"
@@ -34,6 +44,17 @@ const (
 	OpUpdateDashboardCellView = "UpdateDashboardCellView"
 	OpDeleteDashboard         = "DeleteDashboard"
 	OpReplaceDashboardCells   = "ReplaceDashboardCells"
+
+	OpFindDashboardRevisions   = "FindDashboardRevisions"
+	OpFindDashboardRevision    = "FindDashboardRevision"
+	OpRestoreDashboardRevision = "RestoreDashboardRevision"
+
+	OpCreateDashboardShare      = "CreateDashboardShare"
+	OpFindDashboardShares       = "FindDashboardShares"
+	OpFindDashboardShareByToken = "FindDashboardShareByToken"
+	OpDeleteDashboardShare      = "DeleteDashboardShare"
+
+	OpFindDashboardUsage = "FindDashboardUsage"
 )
 
 // DashboardService represents a service for managing dashboard data.
@@ -74,6 +95,107 @@ type DashboardService interface {
 	ReplaceDashboardCells(ctx context.Context, id platform.ID, c []*Cell) error
 }
 
+// DashboardRevisionService records the history of changes to dashboards and
+// allows a dashboard to be restored to an earlier revision.
+type DashboardRevisionService interface {
+	// FindDashboardRevisions returns the revisions of a dashboard, newest first, and
+	// the total count of revisions. Additional options provide pagination.
+	FindDashboardRevisions(ctx context.Context, dashboardID platform.ID, opts FindOptions) ([]*DashboardRevision, int, error)
+
+	// FindDashboardRevision returns a single revision of a dashboard.
+	FindDashboardRevision(ctx context.Context, dashboardID platform.ID, version int) (*DashboardRevision, error)
+
+	// RestoreDashboardRevision replaces the dashboard's name, description, cells and
+	// views with those of the revision. The restore is itself recorded as a new revision.
+	RestoreDashboardRevision(ctx context.Context, dashboardID platform.ID, version int) (*Dashboard, error)
+}
+
+// DashboardRevision is a snapshot of a dashboard, including the views of its
+// cells, taken after a change was made to it.
+type DashboardRevision struct {
+	DashboardID platform.ID `json:"dashboardID"`
+	// Version increases by one with every change to the dashboard.
+	Version int `json:"version"`
+	// Description describes the change which produced the revision.
+	Description string      `json:"description"`
+	UserID      platform.ID `json:"userID,omitempty"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	Dashboard   *Dashboard  `json:"dashboard"`
+}
+
+// DefaultDashboardShareTTL is how long a share is valid for when no expiry is requested.
+const DefaultDashboardShareTTL = 7 * 24 * time.Hour
+
+// DashboardShareService manages signed, expiring links granting read-only access
+// to a single dashboard to anyone holding the link.
+type DashboardShareService interface {
+	// CreateDashboardShare creates a share of a dashboard valid until expiresAt,
+	// and returns it with its token. The token is only returned on creation.
+	CreateDashboardShare(ctx context.Context, dashboardID platform.ID, expiresAt time.Time) (*DashboardShare, error)
+
+	// FindDashboardShares returns the unexpired shares of a dashboard.
+	FindDashboardShares(ctx context.Context, dashboardID platform.ID) ([]*DashboardShare, error)
+
+	// FindDashboardShareByToken verifies a share token and returns the share it grants.
+	FindDashboardShareByToken(ctx context.Context, token string) (*DashboardShare, error)
+
+	// DeleteDashboardShare revokes a share, invalidating its token.
+	DeleteDashboardShare(ctx context.Context, dashboardID, shareID platform.ID) error
+}
+
+// DashboardShare grants read-only access to a dashboard, and the queries of its
+// cells, until it expires or is revoked.
+type DashboardShare struct {
+	ID             platform.ID `json:"id"`
+	DashboardID    platform.ID `json:"dashboardID"`
+	OrganizationID platform.ID `json:"orgID"`
+	CreatedBy      platform.ID `json:"createdBy,omitempty"`
+	CreatedAt      time.Time   `json:"createdAt"`
+	ExpiresAt      time.Time   `json:"expiresAt"`
+	// Token is the signed token identifying the share. It is only set when the share is created.
+	Token string `json:"token,omitempty"`
+}
+
+// DashboardUsageService records how often dashboards and their cells are viewed
+// and queried, so that unused dashboards which still generate query load can be found.
+type DashboardUsageService interface {
+	// RecordDashboardView records that a dashboard was viewed.
+	RecordDashboardView(ctx context.Context, dashboardID platform.ID)
+
+	// RecordCellView records that the view of a dashboard cell was read.
+	RecordCellView(ctx context.Context, dashboardID, cellID platform.ID)
+
+	// RecordDashboardQuery records a query run on behalf of a dashboard by orgID.
+	// The cell is optional, and the query is ignored if the dashboard does not
+	// belong to orgID.
+	RecordDashboardQuery(ctx context.Context, orgID, dashboardID, cellID platform.ID)
+
+	// FindDashboardUsage returns the usage of a dashboard and each of its cells.
+	FindDashboardUsage(ctx context.Context, dashboardID platform.ID) (*DashboardUsage, error)
+}
+
+// DashboardUsageCounts counts the views and queries of a dashboard or cell.
+type DashboardUsageCounts struct {
+	Views         int64      `json:"views"`
+	Queries       int64      `json:"queries"`
+	LastViewedAt  *time.Time `json:"lastViewedAt,omitempty"`
+	LastQueriedAt *time.Time `json:"lastQueriedAt,omitempty"`
+}
+
+// DashboardUsage is the usage of a dashboard. The dashboard's query count includes
+// the queries of its cells, and queries which named no cell.
+type DashboardUsage struct {
+	DashboardID platform.ID `json:"dashboardID"`
+	DashboardUsageCounts
+	Cells []*CellUsage `json:"cells"`
+}
+
+// CellUsage is the usage of a dashboard cell.
+type CellUsage struct {
+	CellID platform.ID `json:"cellID"`
+	DashboardUsageCounts
+}
+
 // Dashboard represents all visual and query data for a dashboard.
 type Dashboard struct {
 	ID             platform.ID   `json:"id,omitempty"`
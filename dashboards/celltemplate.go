@@ -0,0 +1,528 @@
+package dashboards
+
+import (
+	"context"
+	"encoding/json"
+
+	influxdb "github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+var (
+	cellTemplateBucket      = []byte("celltemplatesv1")
+	orgCellTemplateIndex    = []byte("orgscelltemplatesv1")
+	cellTemplateLinkBucket  = []byte("celltemplatelinksv1")
+	dashboardCellLinkBucket = []byte("dashboardcelltemplatelinksv1")
+)
+
+const dashboardCellTemplatePropagatedEvent = "Dashboard Cell Template Propagated"
+
+var _ influxdb.CellTemplateService = (*Service)(nil)
+
+// joinIDs returns the concatenation of the encoded ids, which is used for index keys.
+func joinIDs(ids ...platform.ID) ([]byte, error) {
+	key := make([]byte, 0, len(ids)*platform.IDLength)
+	for _, id := range ids {
+		b, err := id.Encode()
+		if err != nil {
+			return nil, err
+		}
+		key = append(key, b...)
+	}
+	return key, nil
+}
+
+// FindCellTemplateByID returns a single cell template by ID.
+func (s *Service) FindCellTemplateByID(ctx context.Context, id platform.ID) (*influxdb.CellTemplate, error) {
+	var t *influxdb.CellTemplate
+	err := s.kv.View(ctx, func(tx kv.Tx) error {
+		ct, err := s.findCellTemplateByID(tx, id)
+		if err != nil {
+			return err
+		}
+		t = ct
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+		}
+	}
+	return t, nil
+}
+
+// FindCellTemplates returns the cell templates matching filter, sorted by ID.
+func (s *Service) FindCellTemplates(ctx context.Context, filter influxdb.CellTemplateFilter, opts influxdb.FindOptions) ([]*influxdb.CellTemplate, int, error) {
+	var ts []*influxdb.CellTemplate
+	err := s.kv.View(ctx, func(tx kv.Tx) error {
+		ids, err := s.cellTemplateIDs(tx, filter)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			t, err := s.findCellTemplateByID(tx, id)
+			if err != nil {
+				return err
+			}
+			ts = append(ts, t)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, &errors.Error{
+			Err: err,
+		}
+	}
+
+	total := len(ts)
+	if opts.Descending {
+		for i, j := 0, len(ts)-1; i < j; i, j = i+1, j-1 {
+			ts[i], ts[j] = ts[j], ts[i]
+		}
+	}
+	if opts.Offset > 0 {
+		if opts.Offset >= len(ts) {
+			ts = nil
+		} else {
+			ts = ts[opts.Offset:]
+		}
+	}
+	if opts.Limit > 0 && opts.Limit < len(ts) {
+		ts = ts[:opts.Limit]
+	}
+	return ts, total, nil
+}
+
+// CreateCellTemplate creates a new cell template and sets t.ID with the new identifier.
+func (s *Service) CreateCellTemplate(ctx context.Context, t *influxdb.CellTemplate) error {
+	if err := t.Valid(); err != nil {
+		return err
+	}
+	err := s.kv.Update(ctx, func(tx kv.Tx) error {
+		t.ID = s.IDGenerator.ID()
+		t.CreatedAt = s.TimeGenerator.Now()
+		t.UpdatedAt = t.CreatedAt
+		if err := s.putCellTemplate(tx, t); err != nil {
+			return err
+		}
+		k, err := joinIDs(t.OrganizationID, t.ID)
+		if err != nil {
+			return err
+		}
+		idx, err := tx.Bucket(orgCellTemplateIndex)
+		if err != nil {
+			return err
+		}
+		return idx.Put(k, nil)
+	})
+	if err != nil {
+		return &errors.Error{
+			Err: err,
+		}
+	}
+	return nil
+}
+
+// UpdateCellTemplate updates a cell template. If upd.Propagate is set, the view of
+// every cell linked to the template is rendered again with the params it was added
+// with; the update fails if any of them can no longer be rendered.
+func (s *Service) UpdateCellTemplate(ctx context.Context, id platform.ID, upd influxdb.CellTemplateUpdate) (*influxdb.CellTemplate, error) {
+	var t *influxdb.CellTemplate
+	err := s.kv.Update(ctx, func(tx kv.Tx) error {
+		ct, err := s.findCellTemplateByID(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := upd.Apply(ct); err != nil {
+			return err
+		}
+		ct.UpdatedAt = s.TimeGenerator.Now()
+		if err := s.putCellTemplate(tx, ct); err != nil {
+			return err
+		}
+		if upd.Propagate {
+			if err := s.propagateCellTemplate(ctx, tx, ct); err != nil {
+				return err
+			}
+		}
+		t = ct
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+		}
+	}
+	return t, nil
+}
+
+// DeleteCellTemplate removes a cell template and its links. The views of the
+// cells which were linked to it are left as they are.
+func (s *Service) DeleteCellTemplate(ctx context.Context, id platform.ID) error {
+	err := s.kv.Update(ctx, func(tx kv.Tx) error {
+		t, err := s.findCellTemplateByID(tx, id)
+		if err != nil {
+			return err
+		}
+		links, err := s.findCellTemplateLinks(tx, id)
+		if err != nil {
+			return err
+		}
+		for _, l := range links {
+			if err := s.deleteCellTemplateLink(tx, l); err != nil {
+				return err
+			}
+		}
+
+		k, err := joinIDs(t.OrganizationID, t.ID)
+		if err != nil {
+			return err
+		}
+		idx, err := tx.Bucket(orgCellTemplateIndex)
+		if err != nil {
+			return err
+		}
+		if err := idx.Delete(k); err != nil {
+			return err
+		}
+
+		encodedID, err := id.Encode()
+		if err != nil {
+			return err
+		}
+		b, err := tx.Bucket(cellTemplateBucket)
+		if err != nil {
+			return err
+		}
+		return b.Delete(encodedID)
+	})
+	if err != nil {
+		return &errors.Error{
+			Err: err,
+		}
+	}
+	return nil
+}
+
+// AddCellFromTemplate adds a cell to a dashboard with the view rendered from the
+// template, and links the cell to the template.
+func (s *Service) AddCellFromTemplate(ctx context.Context, dashboardID, templateID platform.ID, c *influxdb.Cell, params map[string]string) error {
+	err := s.kv.Update(ctx, func(tx kv.Tx) error {
+		t, err := s.findCellTemplateByID(tx, templateID)
+		if err != nil {
+			return err
+		}
+		d, err := s.findDashboardByID(ctx, tx, dashboardID)
+		if err != nil {
+			return err
+		}
+		if d.OrganizationID != t.OrganizationID {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "cell template belongs to a different organization than the dashboard",
+			}
+		}
+
+		view, err := t.Render(params)
+		if err != nil {
+			return err
+		}
+		if err := s.addDashboardCell(ctx, tx, d.ID, c, influxdb.AddDashboardCellOptions{View: view}); err != nil {
+			return err
+		}
+		return s.putCellTemplateLink(tx, &influxdb.CellTemplateLink{
+			TemplateID:  t.ID,
+			DashboardID: d.ID,
+			CellID:      c.ID,
+			Params:      params,
+		})
+	})
+	if err != nil {
+		return &errors.Error{
+			Err: err,
+		}
+	}
+	return nil
+}
+
+// FindCellTemplateLinks returns the cells linked to a cell template.
+func (s *Service) FindCellTemplateLinks(ctx context.Context, templateID platform.ID) ([]*influxdb.CellTemplateLink, error) {
+	var links []*influxdb.CellTemplateLink
+	err := s.kv.View(ctx, func(tx kv.Tx) error {
+		if _, err := s.findCellTemplateByID(tx, templateID); err != nil {
+			return err
+		}
+		ls, err := s.findCellTemplateLinks(tx, templateID)
+		if err != nil {
+			return err
+		}
+		links = ls
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+		}
+	}
+	return links, nil
+}
+
+// propagateCellTemplate renders the views of the cells linked to t again. Links to
+// cells which no longer exist, for instance after a revision was restored, are removed.
+func (s *Service) propagateCellTemplate(ctx context.Context, tx kv.Tx, t *influxdb.CellTemplate) error {
+	links, err := s.findCellTemplateLinks(tx, t.ID)
+	if err != nil {
+		return err
+	}
+
+	// Links are sorted by dashboard, so each dashboard's cells are consecutive.
+	var updated []*influxdb.Dashboard
+	var d *influxdb.Dashboard
+	for _, l := range links {
+		if d == nil || d.ID != l.DashboardID {
+			d, err = s.findDashboardByID(ctx, tx, l.DashboardID)
+			if errors.ErrorCode(err) == errors.ENotFound {
+				if err := s.deleteCellTemplateLink(tx, l); err != nil {
+					return err
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			updated = append(updated, d)
+		}
+		if !hasCell(d, l.CellID) {
+			if err := s.deleteCellTemplateLink(tx, l); err != nil {
+				return err
+			}
+			continue
+		}
+
+		view, err := t.Render(l.Params)
+		if err != nil {
+			return err
+		}
+		// The name of a cell can be changed without detaching it from its template.
+		if cur, err := s.findDashboardCellView(ctx, tx, l.DashboardID, l.CellID); err == nil {
+			view.Name = cur.Name
+		}
+		if err := s.createCellView(ctx, tx, l.DashboardID, l.CellID, view); err != nil {
+			return err
+		}
+	}
+
+	for _, d := range updated {
+		if err := s.recordRevision(ctx, tx, d, dashboardCellTemplatePropagatedEvent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hasCell(d *influxdb.Dashboard, cellID platform.ID) bool {
+	for _, c := range d.Cells {
+		if c.ID == cellID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) findCellTemplateByID(tx kv.Tx, id platform.ID) (*influxdb.CellTemplate, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Err:  err,
+		}
+	}
+	b, err := tx.Bucket(cellTemplateBucket)
+	if err != nil {
+		return nil, err
+	}
+	v, err := b.Get(encodedID)
+	if kv.IsNotFound(err) {
+		return nil, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  influxdb.ErrCellTemplateNotFound,
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t := &influxdb.CellTemplate{}
+	if err := json.Unmarshal(v, t); err != nil {
+		return nil, errors.NewError(errors.WithErrorErr(err))
+	}
+	return t, nil
+}
+
+func (s *Service) putCellTemplate(tx kv.Tx, t *influxdb.CellTemplate) error {
+	v, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	encodedID, err := t.ID.Encode()
+	if err != nil {
+		return err
+	}
+	b, err := tx.Bucket(cellTemplateBucket)
+	if err != nil {
+		return err
+	}
+	return b.Put(encodedID, v)
+}
+
+// cellTemplateIDs returns the IDs of the cell templates matching filter, sorted by ID.
+func (s *Service) cellTemplateIDs(tx kv.Tx, filter influxdb.CellTemplateFilter) ([]platform.ID, error) {
+	bucket, prefix := cellTemplateBucket, []byte(nil)
+	if filter.OrganizationID != nil {
+		p, err := filter.OrganizationID.Encode()
+		if err != nil {
+			return nil, err
+		}
+		bucket, prefix = orgCellTemplateIndex, p
+	}
+
+	b, err := tx.Bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+	var opts []kv.CursorOption
+	if prefix != nil {
+		opts = append(opts, kv.WithCursorPrefix(prefix))
+	}
+	cur, err := b.ForwardCursor(prefix, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close()
+
+	var ids []platform.ID
+	for k, _ := cur.Next(); k != nil; k, _ = cur.Next() {
+		var id platform.ID
+		if err := id.Decode(k[len(prefix):]); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, cur.Err()
+}
+
+// findCellTemplateLinks returns the links of a template, sorted by dashboard and cell.
+func (s *Service) findCellTemplateLinks(tx kv.Tx, templateID platform.ID) ([]*influxdb.CellTemplateLink, error) {
+	prefix, err := templateID.Encode()
+	if err != nil {
+		return nil, err
+	}
+	b, err := tx.Bucket(cellTemplateLinkBucket)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := b.ForwardCursor(prefix, kv.WithCursorPrefix(prefix))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close()
+
+	var links []*influxdb.CellTemplateLink
+	for k, v := cur.Next(); k != nil; k, v = cur.Next() {
+		l := &influxdb.CellTemplateLink{}
+		if err := json.Unmarshal(v, l); err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+	return links, cur.Err()
+}
+
+// putCellTemplateLink stores a link keyed by its template, and the template of
+// the cell keyed by the cell, so that either can be found from the other.
+func (s *Service) putCellTemplateLink(tx kv.Tx, l *influxdb.CellTemplateLink) error {
+	v, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	k, err := joinIDs(l.TemplateID, l.DashboardID, l.CellID)
+	if err != nil {
+		return err
+	}
+	b, err := tx.Bucket(cellTemplateLinkBucket)
+	if err != nil {
+		return err
+	}
+	if err := b.Put(k, v); err != nil {
+		return err
+	}
+
+	ck, err := joinIDs(l.DashboardID, l.CellID)
+	if err != nil {
+		return err
+	}
+	tid, err := l.TemplateID.Encode()
+	if err != nil {
+		return err
+	}
+	cb, err := tx.Bucket(dashboardCellLinkBucket)
+	if err != nil {
+		return err
+	}
+	return cb.Put(ck, tid)
+}
+
+func (s *Service) deleteCellTemplateLink(tx kv.Tx, l *influxdb.CellTemplateLink) error {
+	k, err := joinIDs(l.TemplateID, l.DashboardID, l.CellID)
+	if err != nil {
+		return err
+	}
+	b, err := tx.Bucket(cellTemplateLinkBucket)
+	if err != nil {
+		return err
+	}
+	if err := b.Delete(k); err != nil {
+		return err
+	}
+
+	ck, err := joinIDs(l.DashboardID, l.CellID)
+	if err != nil {
+		return err
+	}
+	cb, err := tx.Bucket(dashboardCellLinkBucket)
+	if err != nil {
+		return err
+	}
+	return cb.Delete(ck)
+}
+
+// unlinkCell removes the link of a cell to its template, if it has one.
+func (s *Service) unlinkCell(tx kv.Tx, dashboardID, cellID platform.ID) error {
+	ck, err := joinIDs(dashboardID, cellID)
+	if err != nil {
+		return err
+	}
+	cb, err := tx.Bucket(dashboardCellLinkBucket)
+	if err != nil {
+		return err
+	}
+	v, err := cb.Get(ck)
+	if kv.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var templateID platform.ID
+	if err := templateID.Decode(v); err != nil {
+		return err
+	}
+	return s.deleteCellTemplateLink(tx, &influxdb.CellTemplateLink{
+		TemplateID:  templateID,
+		DashboardID: dashboardID,
+		CellID:      cellID,
+	})
+}
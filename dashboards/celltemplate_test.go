@@ -0,0 +1,113 @@
+package dashboards
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCellTemplate(text string) *influxdb.CellTemplate {
+	return &influxdb.CellTemplate{
+		Name:       "cpu",
+		Parameters: []influxdb.CellTemplateParameter{{Name: "host"}},
+		Properties: influxdb.SingleStatViewProperties{
+			Type:    influxdb.ViewPropertyTypeSingleStat,
+			Queries: []influxdb.DashboardQuery{{Text: text}},
+		},
+	}
+}
+
+func cellQuery(t *testing.T, svc *Service, d *influxdb.Dashboard, c *influxdb.Cell) string {
+	t.Helper()
+	view, err := svc.GetDashboardCellView(context.Background(), d.ID, c.ID)
+	require.NoError(t, err)
+	return view.Properties.(influxdb.SingleStatViewProperties).Queries[0].Text
+}
+
+func TestService_CellTemplates(t *testing.T) {
+	ctx := context.Background()
+	svc := newRevisionTestService(t)
+
+	tmpl := newTestCellTemplate(`from(bucket: "a") |> filter(fn: (r) => r.host == "${host}")`)
+	tmpl.OrganizationID = 1
+	require.NoError(t, svc.CreateCellTemplate(ctx, tmpl))
+
+	d1 := &influxdb.Dashboard{OrganizationID: 1, Name: "one"}
+	require.NoError(t, svc.CreateDashboard(ctx, d1))
+	d2 := &influxdb.Dashboard{OrganizationID: 1, Name: "two"}
+	require.NoError(t, svc.CreateDashboard(ctx, d2))
+
+	c1 := &influxdb.Cell{CellProperty: influxdb.CellProperty{W: 4, H: 4}}
+	require.NoError(t, svc.AddCellFromTemplate(ctx, d1.ID, tmpl.ID, c1, map[string]string{"host": "h1"}))
+	c2 := &influxdb.Cell{}
+	require.NoError(t, svc.AddCellFromTemplate(ctx, d2.ID, tmpl.ID, c2, map[string]string{"host": "h2"}))
+	require.Equal(t, `from(bucket: "a") |> filter(fn: (r) => r.host == "h1")`, cellQuery(t, svc, d1, c1))
+
+	err := svc.AddCellFromTemplate(ctx, d1.ID, tmpl.ID, &influxdb.Cell{}, nil)
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err), "the host parameter has no default")
+
+	other := &influxdb.Dashboard{OrganizationID: 2, Name: "other"}
+	require.NoError(t, svc.CreateDashboard(ctx, other))
+	err = svc.AddCellFromTemplate(ctx, other.ID, tmpl.ID, &influxdb.Cell{}, map[string]string{"host": "h1"})
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+
+	links, err := svc.FindCellTemplateLinks(ctx, tmpl.ID)
+	require.NoError(t, err)
+	require.Len(t, links, 2)
+
+	// An update without propagation leaves the cells as they are.
+	props := newTestCellTemplate(`from(bucket: "b") |> filter(fn: (r) => r.host == "${host}")`).Properties
+	_, err = svc.UpdateCellTemplate(ctx, tmpl.ID, influxdb.CellTemplateUpdate{Properties: props})
+	require.NoError(t, err)
+	require.Equal(t, `from(bucket: "a") |> filter(fn: (r) => r.host == "h1")`, cellQuery(t, svc, d1, c1))
+
+	// Editing a cell's properties detaches it from the template.
+	_, err = svc.UpdateDashboardCellView(ctx, d2.ID, c2.ID, influxdb.ViewUpdate{
+		Properties: newTestCellTemplate("custom").Properties,
+	})
+	require.NoError(t, err)
+
+	_, err = svc.UpdateCellTemplate(ctx, tmpl.ID, influxdb.CellTemplateUpdate{Propagate: true})
+	require.NoError(t, err)
+	require.Equal(t, `from(bucket: "b") |> filter(fn: (r) => r.host == "h1")`, cellQuery(t, svc, d1, c1))
+	require.Equal(t, "custom", cellQuery(t, svc, d2, c2))
+
+	revs, _, err := svc.FindDashboardRevisions(ctx, d1.ID, influxdb.FindOptions{Limit: 1})
+	require.NoError(t, err)
+	require.Equal(t, dashboardCellTemplatePropagatedEvent, revs[0].Description)
+
+	// A propagated update which leaves a linked cell unrenderable is rejected.
+	_, err = svc.UpdateCellTemplate(ctx, tmpl.ID, influxdb.CellTemplateUpdate{
+		Parameters: &[]influxdb.CellTemplateParameter{{Name: "host"}, {Name: "region"}},
+		Propagate:  true,
+	})
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+	got, err := svc.FindCellTemplateByID(ctx, tmpl.ID)
+	require.NoError(t, err)
+	require.Len(t, got.Parameters, 1)
+
+	ts, total, err := svc.FindCellTemplates(ctx, influxdb.CellTemplateFilter{OrganizationID: &d1.OrganizationID}, influxdb.FindOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	require.Equal(t, tmpl.ID, ts[0].ID)
+
+	// Deleting a dashboard removes the links of its cells, and deleting the
+	// template keeps the views of the cells linked to it.
+	require.NoError(t, svc.AddCellFromTemplate(ctx, d2.ID, tmpl.ID, &influxdb.Cell{}, map[string]string{"host": "h3"}))
+	require.NoError(t, svc.DeleteDashboard(ctx, d2.ID))
+	links, err = svc.FindCellTemplateLinks(ctx, tmpl.ID)
+	require.NoError(t, err)
+	require.Len(t, links, 1)
+
+	require.NoError(t, svc.DeleteCellTemplate(ctx, tmpl.ID))
+	_, err = svc.FindCellTemplateByID(ctx, tmpl.ID)
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+	require.Equal(t, `from(bucket: "b") |> filter(fn: (r) => r.host == "h1")`, cellQuery(t, svc, d1, c1))
+	ts, total, err = svc.FindCellTemplates(ctx, influxdb.CellTemplateFilter{}, influxdb.FindOptions{})
+	require.NoError(t, err)
+	require.Zero(t, total)
+	require.Empty(t, ts)
+}
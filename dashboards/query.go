@@ -0,0 +1,112 @@
+package dashboards
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/influxdata/flux/ast"
+	influxdb "github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// PermissionService finds the permissions a user currently holds.
+type PermissionService interface {
+	FindPermissionForUser(ctx context.Context, userID platform.ID) (influxdb.PermissionSet, error)
+}
+
+// ViewQueries returns the text of the queries of a view, in order.
+func ViewQueries(view *influxdb.View) ([]string, error) {
+	props, err := influxdb.MarshalViewPropertiesJSON(view.Properties)
+	if err != nil {
+		return nil, err
+	}
+	// Every view type with queries stores them under the same field.
+	var v struct {
+		Queries []influxdb.DashboardQuery `json:"queries"`
+	}
+	if err := json.Unmarshal(props, &v); err != nil {
+		return nil, err
+	}
+
+	queries := make([]string, 0, len(v.Queries))
+	for _, q := range v.Queries {
+		queries = append(queries, q.Text)
+	}
+	return queries, nil
+}
+
+// QueryExtern returns the extern defining the dashboard time range variables
+// the cell queries refer to: v.timeRangeStart, v.timeRangeStop and v.windowPeriod.
+func QueryExtern(start, stop time.Time, window time.Duration) ([]byte, error) {
+	if window < time.Millisecond {
+		window = time.Millisecond
+	}
+
+	extern := &ast.File{Body: []ast.Statement{
+		&ast.OptionStatement{
+			Assignment: &ast.VariableAssignment{
+				ID: &ast.Identifier{Name: "v"},
+				Init: &ast.ObjectExpression{Properties: []*ast.Property{
+					{Key: &ast.Identifier{Name: "timeRangeStart"}, Value: &ast.DateTimeLiteral{Value: start}},
+					{Key: &ast.Identifier{Name: "timeRangeStop"}, Value: &ast.DateTimeLiteral{Value: stop}},
+					{Key: &ast.Identifier{Name: "windowPeriod"}, Value: &ast.DurationLiteral{Values: []ast.Duration{
+						{Magnitude: window.Milliseconds(), Unit: ast.MillisecondUnit},
+					}}},
+				}},
+			},
+		},
+	}}
+	return json.Marshal(extern)
+}
+
+// ReadAuthorization returns the authorization the cell queries of a dashboard of
+// orgID are run with on behalf of userID, when no caller is there to authorize
+// them. It holds only the bucket read permissions the user currently has in
+// orgID, so it never reads more than the user could; if the user cannot read any
+// bucket of orgID anymore, it fails.
+func ReadAuthorization(ctx context.Context, ps PermissionService, id, orgID, userID platform.ID) (*influxdb.Authorization, error) {
+	if !userID.Valid() {
+		return nil, &errors.Error{
+			Code: errors.EForbidden,
+			Msg:  "dashboard queries have no user to run on behalf of",
+		}
+	}
+	perms, err := ps.FindPermissionForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var read []influxdb.Permission
+	for _, p := range perms {
+		if p.Action != influxdb.ReadAction {
+			continue
+		}
+		switch {
+		case p.Resource.Type == influxdb.InstanceResourceType,
+			p.Resource.Type == influxdb.BucketsResourceType && p.Resource.OrgID == nil && p.Resource.ID == nil:
+			// Access to every bucket is narrowed to the buckets of the organization.
+			read = append(read, influxdb.Permission{
+				Action:   influxdb.ReadAction,
+				Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, OrgID: &orgID},
+			})
+		case p.Resource.Type == influxdb.BucketsResourceType && (p.Resource.OrgID == nil || *p.Resource.OrgID == orgID):
+			read = append(read, p)
+		}
+	}
+	if len(read) == 0 {
+		return nil, &errors.Error{
+			Code: errors.EForbidden,
+			Msg:  "user can no longer read the buckets of the organization",
+		}
+	}
+
+	return &influxdb.Authorization{
+		ID:          id,
+		OrgID:       orgID,
+		UserID:      userID,
+		Status:      influxdb.Active,
+		Permissions: read,
+	}, nil
+}
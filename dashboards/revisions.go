@@ -0,0 +1,289 @@
+package dashboards
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	influxdb "github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+var dashboardRevisionBucket = []byte("dashboardrevisionsv1")
+
+const dashboardRestoredEvent = "Dashboard Restored"
+
+var _ influxdb.DashboardRevisionService = (*Service)(nil)
+
+// revisionRecord is the stored form of a revision. Views are stored separately
+// from the cells, since a cell's JSON encoding does not round-trip its view properties.
+type revisionRecord struct {
+	*influxdb.DashboardRevision
+	Views []*influxdb.View `json:"views"`
+}
+
+// revisionPrefix returns the prefix of the keys of every revision of a dashboard.
+func revisionPrefix(dashboardID platform.ID) ([]byte, error) {
+	return dashboardID.Encode()
+}
+
+// encodeRevisionKey returns the key of a revision. Versions are encoded big-endian,
+// so a dashboard's revisions are sorted by version.
+func encodeRevisionKey(dashboardID platform.ID, version int) ([]byte, error) {
+	prefix, err := revisionPrefix(dashboardID)
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, len(prefix)+8)
+	copy(key, prefix)
+	binary.BigEndian.PutUint64(key[len(prefix):], uint64(version))
+	return key, nil
+}
+
+// FindDashboardRevisions returns the revisions of a dashboard, newest first.
+func (s *Service) FindDashboardRevisions(ctx context.Context, dashboardID platform.ID, opts influxdb.FindOptions) ([]*influxdb.DashboardRevision, int, error) {
+	var (
+		revs  []*influxdb.DashboardRevision
+		total int
+	)
+	err := s.kv.View(ctx, func(tx kv.Tx) error {
+		if _, err := s.findDashboardByID(ctx, tx, dashboardID); err != nil {
+			return err
+		}
+		versions, err := s.revisionVersions(ctx, tx, dashboardID)
+		if err != nil {
+			return err
+		}
+		total = len(versions)
+
+		for i := total - 1 - opts.Offset; i >= 0; i-- {
+			if opts.Limit > 0 && len(revs) >= opts.Limit {
+				break
+			}
+			rev, err := s.findRevision(ctx, tx, dashboardID, versions[i])
+			if err != nil {
+				return err
+			}
+			revs = append(revs, rev)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, &errors.Error{
+			Err: err,
+		}
+	}
+	return revs, total, nil
+}
+
+// FindDashboardRevision returns a single revision of a dashboard.
+func (s *Service) FindDashboardRevision(ctx context.Context, dashboardID platform.ID, version int) (*influxdb.DashboardRevision, error) {
+	var rev *influxdb.DashboardRevision
+	err := s.kv.View(ctx, func(tx kv.Tx) error {
+		r, err := s.findRevision(ctx, tx, dashboardID, version)
+		if err != nil {
+			return err
+		}
+		rev = r
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+		}
+	}
+	return rev, nil
+}
+
+// RestoreDashboardRevision replaces the dashboard's name, description, cells and
+// views with those of the revision.
+func (s *Service) RestoreDashboardRevision(ctx context.Context, dashboardID platform.ID, version int) (*influxdb.Dashboard, error) {
+	var d *influxdb.Dashboard
+	err := s.kv.Update(ctx, func(tx kv.Tx) error {
+		rev, err := s.findRevision(ctx, tx, dashboardID, version)
+		if err != nil {
+			return err
+		}
+		cur, err := s.findDashboardByID(ctx, tx, dashboardID)
+		if err != nil {
+			return err
+		}
+
+		for _, c := range cur.Cells {
+			if err := s.deleteDashboardCellView(ctx, tx, cur.ID, c.ID); err != nil {
+				return err
+			}
+		}
+		for _, c := range rev.Dashboard.Cells {
+			if err := s.createCellView(ctx, tx, cur.ID, c.ID, c.View); err != nil {
+				return err
+			}
+		}
+
+		cur.Name = rev.Dashboard.Name
+		cur.Description = rev.Dashboard.Description
+		cur.Cells = rev.Dashboard.Cells
+
+		if err := s.appendDashboardEventToLog(ctx, tx, cur.ID, dashboardRestoredEvent); err != nil {
+			return err
+		}
+		if err := s.putDashboardWithMeta(ctx, tx, cur); err != nil {
+			return err
+		}
+		if err := s.recordRevision(ctx, tx, cur, fmt.Sprintf("%s from Revision %d", dashboardRestoredEvent, version)); err != nil {
+			return err
+		}
+		d = cur
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+		}
+	}
+	return d, nil
+}
+
+func (s *Service) findRevision(ctx context.Context, tx kv.Tx, dashboardID platform.ID, version int) (*influxdb.DashboardRevision, error) {
+	k, err := encodeRevisionKey(dashboardID, version)
+	if err != nil {
+		return nil, errors.NewError(errors.WithErrorErr(err))
+	}
+	b, err := tx.Bucket(dashboardRevisionBucket)
+	if err != nil {
+		return nil, err
+	}
+	v, err := b.Get(k)
+	if kv.IsNotFound(err) {
+		return nil, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  influxdb.ErrDashboardRevisionNotFound,
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rec := revisionRecord{DashboardRevision: &influxdb.DashboardRevision{}}
+	if err := json.Unmarshal(v, &rec); err != nil {
+		return nil, errors.NewError(errors.WithErrorErr(err))
+	}
+
+	views := make(map[platform.ID]*influxdb.View, len(rec.Views))
+	for _, view := range rec.Views {
+		views[view.ID] = view
+	}
+	for _, c := range rec.Dashboard.Cells {
+		c.View = views[c.ID]
+	}
+	return rec.DashboardRevision, nil
+}
+
+// revisionVersions returns the versions of every revision of a dashboard, oldest first.
+func (s *Service) revisionVersions(ctx context.Context, tx kv.Tx, dashboardID platform.ID) ([]int, error) {
+	prefix, err := revisionPrefix(dashboardID)
+	if err != nil {
+		return nil, err
+	}
+	b, err := tx.Bucket(dashboardRevisionBucket)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := b.ForwardCursor(prefix, kv.WithCursorPrefix(prefix))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close()
+
+	var versions []int
+	for k, _ := cur.Next(); k != nil; k, _ = cur.Next() {
+		versions = append(versions, int(binary.BigEndian.Uint64(k[len(prefix):])))
+	}
+	return versions, cur.Err()
+}
+
+// recordRevision stores a snapshot of d, including the views of its cells, as
+// its next revision, then prunes revisions beyond s.MaxRevisions.
+func (s *Service) recordRevision(ctx context.Context, tx kv.Tx, d *influxdb.Dashboard, description string) error {
+	versions, err := s.revisionVersions(ctx, tx, d.ID)
+	if err != nil {
+		return err
+	}
+
+	snapshot := *d
+	snapshot.Cells = make([]*influxdb.Cell, 0, len(d.Cells))
+	var views []*influxdb.View
+	for _, c := range d.Cells {
+		cell := *c
+		cell.View = nil
+		snapshot.Cells = append(snapshot.Cells, &cell)
+
+		view, err := s.findDashboardCellView(ctx, tx, d.ID, c.ID)
+		if errors.ErrorCode(err) == errors.ENotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		views = append(views, view)
+	}
+
+	rev := &influxdb.DashboardRevision{
+		DashboardID: d.ID,
+		Version:     1,
+		Description: description,
+		CreatedAt:   s.TimeGenerator.Now(),
+		Dashboard:   &snapshot,
+	}
+	if n := len(versions); n > 0 {
+		rev.Version = versions[n-1] + 1
+	}
+	if a, err := icontext.GetAuthorizer(ctx); err == nil {
+		// Add the user to the revision if you can, but don't error if its not there.
+		rev.UserID = a.GetUserID()
+	}
+
+	v, err := json.Marshal(revisionRecord{DashboardRevision: rev, Views: views})
+	if err != nil {
+		return err
+	}
+	k, err := encodeRevisionKey(d.ID, rev.Version)
+	if err != nil {
+		return err
+	}
+	b, err := tx.Bucket(dashboardRevisionBucket)
+	if err != nil {
+		return err
+	}
+	if err := b.Put(k, v); err != nil {
+		return err
+	}
+
+	if s.MaxRevisions <= 0 || len(versions) < s.MaxRevisions {
+		return nil
+	}
+	// Keep the new revision and the MaxRevisions-1 revisions before it.
+	return s.deleteRevisions(ctx, tx, d.ID, versions[:len(versions)-s.MaxRevisions+1])
+}
+
+// deleteRevisions deletes the given revisions of a dashboard.
+func (s *Service) deleteRevisions(ctx context.Context, tx kv.Tx, dashboardID platform.ID, versions []int) error {
+	b, err := tx.Bucket(dashboardRevisionBucket)
+	if err != nil {
+		return err
+	}
+	for _, v := range versions {
+		k, err := encodeRevisionKey(dashboardID, v)
+		if err != nil {
+			return err
+		}
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package dashboards
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/mock"
+	itesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func newRevisionTestService(t *testing.T) *Service {
+	s, closeBolt := itesting.NewTestBoltStore(t)
+	t.Cleanup(closeBolt)
+	return NewService(s, kv.NewService(zaptest.NewLogger(t), s, &mock.OrganizationService{}))
+}
+
+func TestService_DashboardRevisions(t *testing.T) {
+	ctx := context.Background()
+	svc := newRevisionTestService(t)
+
+	d := &influxdb.Dashboard{OrganizationID: 1, Name: "dash"}
+	require.NoError(t, svc.CreateDashboard(ctx, d))
+
+	cell := &influxdb.Cell{CellProperty: influxdb.CellProperty{W: 4, H: 4}}
+	require.NoError(t, svc.AddDashboardCell(ctx, d.ID, cell, influxdb.AddDashboardCellOptions{
+		View: &influxdb.View{
+			ViewContents: influxdb.ViewContents{Name: "cpu"},
+			Properties:   influxdb.SingleStatViewProperties{Type: influxdb.ViewPropertyTypeSingleStat, Prefix: "%"},
+		},
+	}))
+	require.NoError(t, svc.RemoveDashboardCell(ctx, d.ID, cell.ID))
+
+	revs, total, err := svc.FindDashboardRevisions(ctx, d.ID, influxdb.FindOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 3, total)
+	require.Equal(t, []int{3, 2, 1}, []int{revs[0].Version, revs[1].Version, revs[2].Version})
+	require.Equal(t, dashboardCellRemovedEvent, revs[0].Description)
+	require.Empty(t, revs[0].Dashboard.Cells)
+	require.Len(t, revs[1].Dashboard.Cells, 1)
+
+	// Restoring the revision before the cell was removed brings back the cell and its view.
+	restored, err := svc.RestoreDashboardRevision(ctx, d.ID, 2)
+	require.NoError(t, err)
+	require.Len(t, restored.Cells, 1)
+	require.Equal(t, cell.ID, restored.Cells[0].ID)
+	view, err := svc.GetDashboardCellView(ctx, d.ID, cell.ID)
+	require.NoError(t, err)
+	require.Equal(t, "cpu", view.Name)
+	require.Equal(t, "%", view.Properties.(influxdb.SingleStatViewProperties).Prefix)
+
+	rev, err := svc.FindDashboardRevision(ctx, d.ID, 4)
+	require.NoError(t, err)
+	require.Equal(t, "Dashboard Restored from Revision 2", rev.Description)
+
+	_, err = svc.FindDashboardRevision(ctx, d.ID, 10)
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+
+	revs, total, err = svc.FindDashboardRevisions(ctx, d.ID, influxdb.FindOptions{Offset: 1, Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, 4, total)
+	require.Equal(t, []int{3, 2}, []int{revs[0].Version, revs[1].Version})
+
+	require.NoError(t, svc.DeleteDashboard(ctx, d.ID))
+	err = svc.kv.View(ctx, func(tx kv.Tx) error {
+		versions, err := svc.revisionVersions(ctx, tx, d.ID)
+		require.Empty(t, versions)
+		return err
+	})
+	require.NoError(t, err)
+}
+
+func TestService_DashboardRevisionsPruned(t *testing.T) {
+	ctx := context.Background()
+	svc := newRevisionTestService(t)
+	svc.MaxRevisions = 2
+
+	d := &influxdb.Dashboard{OrganizationID: 1, Name: "dash"}
+	require.NoError(t, svc.CreateDashboard(ctx, d))
+	for _, name := range []string{"a", "b", "c"} {
+		name := name
+		_, err := svc.UpdateDashboard(ctx, d.ID, influxdb.DashboardUpdate{Name: &name})
+		require.NoError(t, err)
+	}
+
+	revs, total, err := svc.FindDashboardRevisions(ctx, d.ID, influxdb.FindOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 2, total)
+	require.Equal(t, 4, revs[0].Version)
+	require.Equal(t, "c", revs[0].Dashboard.Name)
+	require.Equal(t, 3, revs[1].Version)
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"sync"
 	"time"
 
 	influxdb "github.com/influxdata/influxdb/v2"
@@ -31,6 +32,8 @@ const (
 	dashboardCellAddedEvent     = "Dashboard Cell Added"
 	dashboardCellRemovedEvent   = "Dashboard Cell Removed"
 	dashboardCellUpdatedEvent   = "Dashboard Cell Updated"
+
+	dashboardCellViewUpdatedEvent = "Dashboard Cell View Updated"
 )
 
 // OpLogStore is a type which persists and reports operation log entries on a backing
@@ -50,6 +53,14 @@ type Service struct {
 
 	IDGenerator   platform.IDGenerator
 	TimeGenerator influxdb.TimeGenerator
+
+	// MaxRevisions is the number of revisions retained for each dashboard.
+	// Older revisions are pruned as new ones are recorded. Zero retains every revision.
+	MaxRevisions int
+
+	usageMu sync.Mutex
+	// usage holds the usage recorded since it was last flushed to the store.
+	usage map[usageKey]*influxdb.DashboardUsageCounts
 }
 
 // NewService constructs and configures a new dashboard service.
@@ -348,7 +359,7 @@ func (s *Service) CreateDashboard(ctx context.Context, d *influxdb.Dashboard) er
 			return err
 		}
 
-		return nil
+		return s.recordRevision(ctx, tx, d, dashboardCreatedEvent)
 	})
 	if err != nil {
 		return &errors.Error{
@@ -402,7 +413,10 @@ func (s *Service) ReplaceDashboardCells(ctx context.Context, id platform.ID, cs
 			return err
 		}
 
-		return s.putDashboardWithMeta(ctx, tx, d)
+		if err := s.putDashboardWithMeta(ctx, tx, d); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, tx, d, dashboardCellsReplacedEvent)
 	})
 	if err != nil {
 		return &errors.Error{
@@ -428,7 +442,10 @@ func (s *Service) addDashboardCell(ctx context.Context, tx kv.Tx, id platform.ID
 		return err
 	}
 
-	return s.putDashboardWithMeta(ctx, tx, d)
+	if err := s.putDashboardWithMeta(ctx, tx, d); err != nil {
+		return err
+	}
+	return s.recordRevision(ctx, tx, d, dashboardCellAddedEvent)
 }
 
 // AddDashboardCell adds a cell to a dashboard and sets the cells ID.
@@ -473,6 +490,16 @@ func (s *Service) RemoveDashboardCell(ctx context.Context, dashboardID, cellID p
 				Err: err,
 			}
 		}
+		if err := s.unlinkCell(tx, d.ID, d.Cells[idx].ID); err != nil {
+			return &errors.Error{
+				Err: err,
+			}
+		}
+		if err := s.deleteUsage(tx, d.ID, d.Cells[idx].ID); err != nil {
+			return &errors.Error{
+				Err: err,
+			}
+		}
 
 		d.Cells = append(d.Cells[:idx], d.Cells[idx+1:]...)
 
@@ -487,6 +514,12 @@ func (s *Service) RemoveDashboardCell(ctx context.Context, dashboardID, cellID p
 				Err: err,
 			}
 		}
+
+		if err := s.recordRevision(ctx, tx, d, dashboardCellRemovedEvent); err != nil {
+			return &errors.Error{
+				Err: err,
+			}
+		}
 		return nil
 	})
 }
@@ -623,6 +656,22 @@ func (s *Service) UpdateDashboardCellView(ctx context.Context, dashboardID, cell
 			return err
 		}
 
+		// Editing the properties of a cell added from a template detaches it, so
+		// they are not overwritten when the template is updated.
+		if _, ok := upd.Properties.(influxdb.EmptyViewProperties); !ok && upd.Properties != nil {
+			if err := s.unlinkCell(tx, dashboardID, cellID); err != nil {
+				return err
+			}
+		}
+
+		d, err := s.findDashboardByID(ctx, tx, dashboardID)
+		if err != nil {
+			return err
+		}
+		if err := s.recordRevision(ctx, tx, d, dashboardCellViewUpdatedEvent); err != nil {
+			return err
+		}
+
 		v = view
 		return nil
 	})
@@ -675,7 +724,10 @@ func (s *Service) UpdateDashboardCell(ctx context.Context, dashboardID, cellID p
 			return err
 		}
 
-		return s.putDashboardWithMeta(ctx, tx, d)
+		if err := s.putDashboardWithMeta(ctx, tx, d); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, tx, d, dashboardCellUpdatedEvent)
 	})
 
 	if err != nil {
@@ -885,6 +937,10 @@ func (s *Service) updateDashboard(ctx context.Context, tx kv.Tx, id platform.ID,
 		}
 	}
 
+	if err := s.recordRevision(ctx, tx, d, dashboardUpdatedEvent); err != nil {
+		return nil, err
+	}
+
 	return d, nil
 }
 
@@ -912,6 +968,11 @@ func (s *Service) deleteDashboard(ctx context.Context, tx kv.Tx, id platform.ID)
 				Err: err,
 			}
 		}
+		if err := s.unlinkCell(tx, d.ID, cell.ID); err != nil {
+			return &errors.Error{
+				Err: err,
+			}
+		}
 	}
 
 	encodedID, err := id.Encode()
@@ -936,6 +997,26 @@ func (s *Service) deleteDashboard(ctx context.Context, tx kv.Tx, id platform.ID)
 		}
 	}
 
+	versions, err := s.revisionVersions(ctx, tx, d.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.deleteRevisions(ctx, tx, d.ID, versions); err != nil {
+		return err
+	}
+
+	shares, err := s.shareIDs(tx, d.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.deleteShares(tx, d.ID, shares); err != nil {
+		return err
+	}
+
+	if err := s.deleteUsage(tx, d.ID, 0); err != nil {
+		return err
+	}
+
 	if err := s.appendDashboardEventToLog(ctx, tx, d.ID, dashboardRemovedEvent); err != nil {
 		return &errors.Error{
 			Err: err,
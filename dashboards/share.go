@@ -0,0 +1,344 @@
+package dashboards
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	influxdb "github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+var (
+	dashboardShareBucket    = []byte("dashboardsharesv1")
+	dashboardShareKeyBucket = []byte("dashboardsharekeysv1")
+
+	// shareSigningKeyID is the key under which the secret used to sign share tokens is stored.
+	shareSigningKeyID = []byte("signing")
+)
+
+const shareSigningKeyLen = 32
+
+var _ influxdb.DashboardShareService = (*Service)(nil)
+
+// encodeShareKey returns the key of a share. Keys are prefixed by the dashboard,
+// so the shares of a dashboard can be listed together.
+func encodeShareKey(dashboardID, shareID platform.ID) ([]byte, error) {
+	prefix, err := dashboardID.Encode()
+	if err != nil {
+		return nil, err
+	}
+	id, err := shareID.Encode()
+	if err != nil {
+		return nil, err
+	}
+	return append(prefix, id...), nil
+}
+
+// CreateDashboardShare creates a share of a dashboard valid until expiresAt.
+func (s *Service) CreateDashboardShare(ctx context.Context, dashboardID platform.ID, expiresAt time.Time) (*influxdb.DashboardShare, error) {
+	now := s.TimeGenerator.Now()
+	if expiresAt.IsZero() {
+		expiresAt = now.Add(influxdb.DefaultDashboardShareTTL)
+	}
+	if !expiresAt.After(now) {
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "dashboard share must expire in the future",
+		}
+	}
+
+	var share *influxdb.DashboardShare
+	err := s.kv.Update(ctx, func(tx kv.Tx) error {
+		d, err := s.findDashboardByID(ctx, tx, dashboardID)
+		if err != nil {
+			return err
+		}
+		key, err := s.shareSigningKey(tx, true)
+		if err != nil {
+			return err
+		}
+
+		share = &influxdb.DashboardShare{
+			ID:             s.IDGenerator.ID(),
+			DashboardID:    d.ID,
+			OrganizationID: d.OrganizationID,
+			CreatedAt:      now,
+			// Tokens carry the expiry in seconds, so store it at the same precision.
+			ExpiresAt: expiresAt.Truncate(time.Second),
+		}
+		if a, err := icontext.GetAuthorizer(ctx); err == nil {
+			share.CreatedBy = a.GetUserID()
+		}
+
+		if err := s.putShare(tx, share); err != nil {
+			return err
+		}
+		share.Token = signShareToken(key, share)
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+		}
+	}
+	return share, nil
+}
+
+// FindDashboardShares returns the unexpired shares of a dashboard.
+func (s *Service) FindDashboardShares(ctx context.Context, dashboardID platform.ID) ([]*influxdb.DashboardShare, error) {
+	var shares []*influxdb.DashboardShare
+	err := s.kv.View(ctx, func(tx kv.Tx) error {
+		if _, err := s.findDashboardByID(ctx, tx, dashboardID); err != nil {
+			return err
+		}
+		prefix, err := dashboardID.Encode()
+		if err != nil {
+			return err
+		}
+		b, err := tx.Bucket(dashboardShareBucket)
+		if err != nil {
+			return err
+		}
+		cur, err := b.ForwardCursor(prefix, kv.WithCursorPrefix(prefix))
+		if err != nil {
+			return err
+		}
+		defer cur.Close()
+
+		now := s.TimeGenerator.Now()
+		for k, v := cur.Next(); k != nil; k, v = cur.Next() {
+			share := &influxdb.DashboardShare{}
+			if err := json.Unmarshal(v, share); err != nil {
+				return err
+			}
+			if share.ExpiresAt.After(now) {
+				shares = append(shares, share)
+			}
+		}
+		return cur.Err()
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+		}
+	}
+	return shares, nil
+}
+
+// FindDashboardShareByToken verifies the signature and expiry of a share token,
+// and that the share has not been revoked.
+func (s *Service) FindDashboardShareByToken(ctx context.Context, token string) (*influxdb.DashboardShare, error) {
+	invalid := &errors.Error{
+		Code: errors.EUnauthorized,
+		Msg:  influxdb.ErrInvalidDashboardShareToken,
+	}
+
+	claims, err := parseShareToken(token)
+	if err != nil {
+		return nil, invalid
+	}
+	if !claims.ExpiresAt.After(s.TimeGenerator.Now()) {
+		return nil, invalid
+	}
+
+	var share *influxdb.DashboardShare
+	err = s.kv.View(ctx, func(tx kv.Tx) error {
+		key, err := s.shareSigningKey(tx, false)
+		if err != nil {
+			return err
+		}
+		if key == nil || !hmac.Equal([]byte(signShareToken(key, claims)), []byte(token)) {
+			return invalid
+		}
+
+		sh, err := s.findShare(tx, claims.DashboardID, claims.ID)
+		if errors.ErrorCode(err) == errors.ENotFound {
+			return invalid
+		}
+		if err != nil {
+			return err
+		}
+		if !sh.ExpiresAt.Equal(claims.ExpiresAt) {
+			return invalid
+		}
+		share = sh
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+// DeleteDashboardShare revokes a share.
+func (s *Service) DeleteDashboardShare(ctx context.Context, dashboardID, shareID platform.ID) error {
+	err := s.kv.Update(ctx, func(tx kv.Tx) error {
+		if _, err := s.findShare(tx, dashboardID, shareID); err != nil {
+			return err
+		}
+		return s.deleteShares(tx, dashboardID, []platform.ID{shareID})
+	})
+	if err != nil {
+		return &errors.Error{
+			Err: err,
+		}
+	}
+	return nil
+}
+
+func (s *Service) findShare(tx kv.Tx, dashboardID, shareID platform.ID) (*influxdb.DashboardShare, error) {
+	k, err := encodeShareKey(dashboardID, shareID)
+	if err != nil {
+		return nil, errors.NewError(errors.WithErrorErr(err))
+	}
+	b, err := tx.Bucket(dashboardShareBucket)
+	if err != nil {
+		return nil, err
+	}
+	v, err := b.Get(k)
+	if kv.IsNotFound(err) {
+		return nil, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  influxdb.ErrDashboardShareNotFound,
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	share := &influxdb.DashboardShare{}
+	if err := json.Unmarshal(v, share); err != nil {
+		return nil, errors.NewError(errors.WithErrorErr(err))
+	}
+	return share, nil
+}
+
+func (s *Service) putShare(tx kv.Tx, share *influxdb.DashboardShare) error {
+	v, err := json.Marshal(share)
+	if err != nil {
+		return err
+	}
+	k, err := encodeShareKey(share.DashboardID, share.ID)
+	if err != nil {
+		return err
+	}
+	b, err := tx.Bucket(dashboardShareBucket)
+	if err != nil {
+		return err
+	}
+	return b.Put(k, v)
+}
+
+// shareIDs returns the IDs of every share of a dashboard, including expired ones.
+func (s *Service) shareIDs(tx kv.Tx, dashboardID platform.ID) ([]platform.ID, error) {
+	prefix, err := dashboardID.Encode()
+	if err != nil {
+		return nil, err
+	}
+	b, err := tx.Bucket(dashboardShareBucket)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := b.ForwardCursor(prefix, kv.WithCursorPrefix(prefix))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close()
+
+	var ids []platform.ID
+	for k, _ := cur.Next(); k != nil; k, _ = cur.Next() {
+		var id platform.ID
+		if err := id.Decode(k[len(prefix):]); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, cur.Err()
+}
+
+// deleteShares deletes the given shares of a dashboard.
+func (s *Service) deleteShares(tx kv.Tx, dashboardID platform.ID, shareIDs []platform.ID) error {
+	b, err := tx.Bucket(dashboardShareBucket)
+	if err != nil {
+		return err
+	}
+	for _, id := range shareIDs {
+		k, err := encodeShareKey(dashboardID, id)
+		if err != nil {
+			return err
+		}
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shareSigningKey returns the secret used to sign share tokens. If create is true
+// and no secret exists yet, one is generated; otherwise a missing secret is nil.
+func (s *Service) shareSigningKey(tx kv.Tx, create bool) ([]byte, error) {
+	b, err := tx.Bucket(dashboardShareKeyBucket)
+	if err != nil {
+		return nil, err
+	}
+	key, err := b.Get(shareSigningKeyID)
+	if err == nil {
+		return key, nil
+	}
+	if !kv.IsNotFound(err) {
+		return nil, err
+	}
+	if !create {
+		return nil, nil
+	}
+
+	key = make([]byte, shareSigningKeyLen)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := b.Put(shareSigningKeyID, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// signShareToken returns the token of a share, which is its dashboard, ID and
+// expiry followed by an HMAC-SHA256 signature of them.
+func signShareToken(key []byte, share *influxdb.DashboardShare) string {
+	payload := fmt.Sprintf("%s.%s.%d", share.DashboardID, share.ID, share.ExpiresAt.Unix())
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// parseShareToken returns the claims of a token. The signature is not verified.
+func parseShareToken(token string) (*influxdb.DashboardShare, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("malformed share token")
+	}
+	share := &influxdb.DashboardShare{}
+	if err := share.DashboardID.DecodeFromString(parts[0]); err != nil {
+		return nil, err
+	}
+	if err := share.ID.DecodeFromString(parts[1]); err != nil {
+		return nil, err
+	}
+	exp, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	share.ExpiresAt = time.Unix(exp, 0)
+	return share, nil
+}
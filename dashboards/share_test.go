@@ -0,0 +1,73 @@
+package dashboards
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_DashboardShares(t *testing.T) {
+	ctx := context.Background()
+	svc := newRevisionTestService(t)
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	svc.TimeGenerator = mock.TimeGenerator{FakeValue: now}
+
+	d := &influxdb.Dashboard{OrganizationID: 1, Name: "dash"}
+	require.NoError(t, svc.CreateDashboard(ctx, d))
+
+	share, err := svc.CreateDashboardShare(ctx, d.ID, time.Time{})
+	require.NoError(t, err)
+	require.Equal(t, now.Add(influxdb.DefaultDashboardShareTTL), share.ExpiresAt)
+	require.NotEmpty(t, share.Token)
+
+	found, err := svc.FindDashboardShareByToken(ctx, share.Token)
+	require.NoError(t, err)
+	require.Equal(t, share.ID, found.ID)
+	require.Equal(t, d.ID, found.DashboardID)
+	require.Equal(t, d.OrganizationID, found.OrganizationID)
+	require.Empty(t, found.Token)
+
+	shares, err := svc.FindDashboardShares(ctx, d.ID)
+	require.NoError(t, err)
+	require.Len(t, shares, 1)
+
+	// Tampering with any part of the token invalidates it.
+	parts := strings.Split(share.Token, ".")
+	parts[2] = "4102444800"
+	_, err = svc.FindDashboardShareByToken(ctx, strings.Join(parts, "."))
+	require.Equal(t, errors.EUnauthorized, errors.ErrorCode(err))
+	_, err = svc.FindDashboardShareByToken(ctx, "garbage")
+	require.Equal(t, errors.EUnauthorized, errors.ErrorCode(err))
+
+	_, err = svc.CreateDashboardShare(ctx, d.ID, now.Add(-time.Minute))
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+
+	// Expired shares are neither listed nor accepted.
+	short, err := svc.CreateDashboardShare(ctx, d.ID, now.Add(time.Hour))
+	require.NoError(t, err)
+	svc.TimeGenerator = mock.TimeGenerator{FakeValue: now.Add(2 * time.Hour)}
+	shares, err = svc.FindDashboardShares(ctx, d.ID)
+	require.NoError(t, err)
+	require.Len(t, shares, 1)
+	require.Equal(t, share.ID, shares[0].ID)
+	_, err = svc.FindDashboardShareByToken(ctx, short.Token)
+	require.Equal(t, errors.EUnauthorized, errors.ErrorCode(err))
+
+	// Revoked shares are no longer accepted.
+	require.NoError(t, svc.DeleteDashboardShare(ctx, d.ID, share.ID))
+	_, err = svc.FindDashboardShareByToken(ctx, share.Token)
+	require.Equal(t, errors.EUnauthorized, errors.ErrorCode(err))
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(svc.DeleteDashboardShare(ctx, d.ID, share.ID)))
+
+	another, err := svc.CreateDashboardShare(ctx, d.ID, time.Time{})
+	require.NoError(t, err)
+	require.NoError(t, svc.DeleteDashboard(ctx, d.ID))
+	_, err = svc.FindDashboardShareByToken(ctx, another.Token)
+	require.Equal(t, errors.EUnauthorized, errors.ErrorCode(err))
+}
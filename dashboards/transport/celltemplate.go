@@ -0,0 +1,301 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const prefixCellTemplates = "/api/v2/cellTemplates"
+
+// CellTemplateHandler is the handler for the cell template service.
+type CellTemplateHandler struct {
+	chi.Router
+
+	api *kithttp.API
+	log *zap.Logger
+
+	cellTemplateService influxdb.CellTemplateService
+}
+
+// NewCellTemplateHandler returns a new instance of CellTemplateHandler.
+func NewCellTemplateHandler(log *zap.Logger, cellTemplateService influxdb.CellTemplateService) *CellTemplateHandler {
+	h := &CellTemplateHandler{
+		log:                 log,
+		api:                 kithttp.NewAPI(kithttp.WithLog(log)),
+		cellTemplateService: cellTemplateService,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+	r.Route("/", func(r chi.Router) {
+		r.Get("/", h.handleGetCellTemplates)
+		r.Post("/", h.handlePostCellTemplate)
+
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", h.handleGetCellTemplate)
+			r.Patch("/", h.handlePatchCellTemplate)
+			r.Delete("/", h.handleDeleteCellTemplate)
+			r.Get("/cells", h.handleGetCellTemplateLinks)
+			r.Post("/cells", h.handlePostCellFromTemplate)
+		})
+	})
+	h.Router = r
+	return h
+}
+
+// Prefix returns the mounting prefix for the handler.
+func (h *CellTemplateHandler) Prefix() string {
+	return prefixCellTemplates
+}
+
+type cellTemplateResponse struct {
+	*influxdb.CellTemplate
+	Links map[string]string `json:"links"`
+}
+
+// MarshalJSON encodes the template with its links. The embedded template's own
+// MarshalJSON would otherwise be promoted and drop the links.
+func (r cellTemplateResponse) MarshalJSON() ([]byte, error) {
+	props, err := influxdb.MarshalViewPropertiesJSON(r.Properties)
+	if err != nil {
+		return nil, err
+	}
+
+	type cellTemplate influxdb.CellTemplate
+	return json.Marshal(struct {
+		cellTemplate
+		Properties json.RawMessage   `json:"properties"`
+		Links      map[string]string `json:"links"`
+	}{
+		cellTemplate: cellTemplate(*r.CellTemplate),
+		Properties:   props,
+		Links:        r.Links,
+	})
+}
+
+func newCellTemplateResponse(t *influxdb.CellTemplate) cellTemplateResponse {
+	return cellTemplateResponse{
+		CellTemplate: t,
+		Links: map[string]string{
+			"self":  fmt.Sprintf("%s/%s", prefixCellTemplates, t.ID),
+			"cells": fmt.Sprintf("%s/%s/cells", prefixCellTemplates, t.ID),
+			"org":   fmt.Sprintf("/api/v2/orgs/%s", t.OrganizationID),
+		},
+	}
+}
+
+type cellTemplatesResponse struct {
+	CellTemplates []cellTemplateResponse `json:"cellTemplates"`
+	Total         int                    `json:"total"`
+	Links         map[string]string      `json:"links"`
+}
+
+type cellTemplateLinksResponse struct {
+	Cells []*influxdb.CellTemplateLink `json:"cells"`
+}
+
+func decodeCellTemplateID(r *http.Request) (platform.ID, error) {
+	var id platform.ID
+	if err := id.DecodeFromString(chi.URLParam(r, "id")); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// handleGetCellTemplates lists the cell templates of an organization.
+func (h *CellTemplateHandler) handleGetCellTemplates(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	opts, err := influxdb.DecodeFindOptions(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var filter influxdb.CellTemplateFilter
+	if orgID := r.URL.Query().Get("orgID"); orgID != "" {
+		id, err := platform.IDFromString(orgID)
+		if err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+		filter.OrganizationID = id
+	}
+
+	ts, total, err := h.cellTemplateService.FindCellTemplates(ctx, filter, *opts)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Cell templates retrieved", zap.Int("count", len(ts)))
+
+	res := cellTemplatesResponse{
+		CellTemplates: make([]cellTemplateResponse, 0, len(ts)),
+		Total:         total,
+		Links: map[string]string{
+			"self": prefixCellTemplates,
+		},
+	}
+	for _, t := range ts {
+		res.CellTemplates = append(res.CellTemplates, newCellTemplateResponse(t))
+	}
+	h.api.Respond(w, r, http.StatusOK, res)
+}
+
+// handlePostCellTemplate creates a cell template.
+func (h *CellTemplateHandler) handlePostCellTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var t influxdb.CellTemplate
+	if err := h.api.DecodeJSON(r.Body, &t); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if !t.OrganizationID.Valid() {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "orgID is required",
+		})
+		return
+	}
+
+	if err := h.cellTemplateService.CreateCellTemplate(ctx, &t); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Cell template created", zap.String("cellTemplate", fmt.Sprint(t)))
+
+	h.api.Respond(w, r, http.StatusCreated, newCellTemplateResponse(&t))
+}
+
+// handleGetCellTemplate retrieves a cell template by ID.
+func (h *CellTemplateHandler) handleGetCellTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := decodeCellTemplateID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	t, err := h.cellTemplateService.FindCellTemplateByID(ctx, id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Cell template retrieved", zap.String("cellTemplate", fmt.Sprint(t)))
+
+	h.api.Respond(w, r, http.StatusOK, newCellTemplateResponse(t))
+}
+
+// handlePatchCellTemplate updates a cell template, propagating the change to the
+// cells linked to it if the update requests it.
+func (h *CellTemplateHandler) handlePatchCellTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := decodeCellTemplateID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var upd influxdb.CellTemplateUpdate
+	if err := h.api.DecodeJSON(r.Body, &upd); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	t, err := h.cellTemplateService.UpdateCellTemplate(ctx, id, upd)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Cell template updated", zap.String("cellTemplate", fmt.Sprint(t)), zap.Bool("propagate", upd.Propagate))
+
+	h.api.Respond(w, r, http.StatusOK, newCellTemplateResponse(t))
+}
+
+// handleDeleteCellTemplate deletes a cell template.
+func (h *CellTemplateHandler) handleDeleteCellTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := decodeCellTemplateID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.cellTemplateService.DeleteCellTemplate(ctx, id); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Cell template deleted", zap.String("cellTemplateID", id.String()))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetCellTemplateLinks lists the dashboard cells linked to a cell template.
+func (h *CellTemplateHandler) handleGetCellTemplateLinks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := decodeCellTemplateID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	links, err := h.cellTemplateService.FindCellTemplateLinks(ctx, id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if links == nil {
+		links = []*influxdb.CellTemplateLink{}
+	}
+	h.api.Respond(w, r, http.StatusOK, cellTemplateLinksResponse{Cells: links})
+}
+
+type postCellFromTemplateRequest struct {
+	DashboardID platform.ID `json:"dashboardID"`
+	influxdb.CellProperty
+	Params map[string]string `json:"params"`
+}
+
+// handlePostCellFromTemplate adds a cell rendered from a cell template to a dashboard.
+func (h *CellTemplateHandler) handlePostCellFromTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := decodeCellTemplateID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var req postCellFromTemplateRequest
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if !req.DashboardID.Valid() {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "dashboardID is required",
+		})
+		return
+	}
+
+	cell := &influxdb.Cell{CellProperty: req.CellProperty}
+	if err := h.cellTemplateService.AddCellFromTemplate(ctx, req.DashboardID, id, cell, req.Params); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Dashboard cell created from template", zap.String("dashboardID", req.DashboardID.String()), zap.String("cellTemplateID", id.String()))
+
+	h.api.Respond(w, r, http.StatusCreated, newDashboardCellResponse(req.DashboardID, cell))
+}
@@ -0,0 +1,85 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/dashboards"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/mock"
+	itesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestCellTemplateHandler(t *testing.T) {
+	log := zaptest.NewLogger(t)
+	store := itesting.NewTestInmemStore(t)
+	svc := dashboards.NewService(store, kv.NewService(log, store, &mock.OrganizationService{}))
+
+	ctx := context.Background()
+	d := &influxdb.Dashboard{OrganizationID: 1, Name: "dash"}
+	require.NoError(t, svc.CreateDashboard(ctx, d))
+
+	h := NewCellTemplateHandler(log, svc)
+	r := chi.NewRouter()
+	r.Mount(h.Prefix(), h)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, "/api/v2/cellTemplates"+path, bytes.NewBufferString(body)))
+		return w
+	}
+
+	w := do(http.MethodPost, "", fmt.Sprintf(`{
+		"orgID": %q,
+		"name": "cpu",
+		"parameters": [{"name": "host", "default": "server01"}],
+		"properties": {"shape": "chronograf-v2", "type": "single-stat", "queries": [{"text": "host == \"${host}\""}]}
+	}`, d.OrganizationID))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		ID    string            `json:"id"`
+		Links map[string]string `json:"links"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	require.Equal(t, "/api/v2/cellTemplates/"+created.ID, created.Links["self"])
+
+	w = do(http.MethodPost, "/"+created.ID+"/cells", fmt.Sprintf(`{"dashboardID": %q, "w": 4, "h": 2}`, d.ID))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = do(http.MethodPatch, "/"+created.ID, `{
+		"properties": {"shape": "chronograf-v2", "type": "single-stat", "queries": [{"text": "host != \"${host}\""}]},
+		"propagate": true
+	}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = do(http.MethodGet, "/"+created.ID+"/cells", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var links cellTemplateLinksResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &links))
+	require.Len(t, links.Cells, 1)
+	view, err := svc.GetDashboardCellView(ctx, d.ID, links.Cells[0].CellID)
+	require.NoError(t, err)
+	require.Equal(t, `host != "server01"`, view.Properties.(influxdb.SingleStatViewProperties).Queries[0].Text)
+
+	w = do(http.MethodGet, fmt.Sprintf("?orgID=%s", d.OrganizationID), "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var list struct {
+		Total int `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Equal(t, 1, list.Total)
+
+	w = do(http.MethodDelete, "/"+created.ID, "")
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	w = do(http.MethodGet, "/"+created.ID, "")
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
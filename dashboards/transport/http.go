@@ -28,6 +28,9 @@ type DashboardHandler struct {
 	labelService     influxdb.LabelService
 	userService      influxdb.UserService
 	orgService       influxdb.OrganizationService
+	revisionService  influxdb.DashboardRevisionService
+	shareService     influxdb.DashboardShareService
+	usageService     influxdb.DashboardUsageService
 }
 
 const (
@@ -42,6 +45,7 @@ func NewDashboardHandler(
 	userService influxdb.UserService,
 	orgService influxdb.OrganizationService,
 	urmHandler, labelHandler http.Handler,
+	opts ...DashboardHandlerOption,
 ) *DashboardHandler {
 	h := &DashboardHandler{
 		log:              log,
@@ -51,6 +55,9 @@ func NewDashboardHandler(
 		userService:      userService,
 		orgService:       orgService,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
 
 	// setup routing
 	{
@@ -85,6 +92,26 @@ func NewDashboardHandler(
 					})
 				})
 
+				if h.usageService != nil {
+					r.Get("/usage", h.handleGetDashboardUsage)
+				}
+
+				if h.revisionService != nil {
+					r.Route("/revisions", func(r chi.Router) {
+						r.Get("/", h.handleGetDashboardRevisions)
+						r.Get("/{version}", h.handleGetDashboardRevision)
+						r.Post("/{version}/restore", h.handlePostDashboardRevisionRestore)
+					})
+				}
+
+				if h.shareService != nil {
+					r.Route("/shares", func(r chi.Router) {
+						r.Post("/", h.handlePostDashboardShare)
+						r.Get("/", h.handleGetDashboardShares)
+						r.Delete("/{shareID}", h.handleDeleteDashboardShare)
+					})
+				}
+
 				// mount embedded resources
 				mountableRouter := r.With(kithttp.ValidResource(h.api, h.lookupOrgByDashboardID))
 				mountableRouter.Mount("/members", urmHandler)
@@ -426,8 +453,14 @@ func (h *DashboardHandler) handleGetDashboard(w http.ResponseWriter, r *http.Req
 			if view != nil {
 				c.View = view
 			}
+			if h.usageService != nil {
+				h.usageService.RecordCellView(ctx, dashboard.ID, c.ID)
+			}
 		}
 	}
+	if h.usageService != nil {
+		h.usageService.RecordDashboardView(ctx, dashboard.ID)
+	}
 
 	labels, err := h.labelService.FindResourceLabels(ctx, influxdb.LabelMappingFilter{ResourceID: dashboard.ID, ResourceType: influxdb.DashboardsResourceType})
 	if err != nil {
@@ -782,6 +815,9 @@ func (h *DashboardHandler) handleGetDashboardCellView(w http.ResponseWriter, r *
 		h.api.Err(w, r, err)
 		return
 	}
+	if h.usageService != nil {
+		h.usageService.RecordCellView(ctx, req.dashboardID, req.cellID)
+	}
 
 	h.log.Debug("Dashboard cell view retrieved", zap.String("dashboardID", req.dashboardID.String()), zap.String("cellID", req.cellID.String()), zap.String("view", fmt.Sprint(view)))
 
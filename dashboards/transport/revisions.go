@@ -0,0 +1,157 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"go.uber.org/zap"
+)
+
+// DashboardHandlerOption configures optional behavior of a DashboardHandler.
+type DashboardHandlerOption func(h *DashboardHandler)
+
+// WithDashboardRevisionService enables the revision history endpoints, served by svc.
+func WithDashboardRevisionService(svc influxdb.DashboardRevisionService) DashboardHandlerOption {
+	return func(h *DashboardHandler) {
+		h.revisionService = svc
+	}
+}
+
+type dashboardRevisionResponse struct {
+	Version     int               `json:"version"`
+	Description string            `json:"description"`
+	UserID      platform.ID       `json:"userID,omitempty"`
+	CreatedAt   time.Time         `json:"createdAt"`
+	Dashboard   dashboardResponse `json:"dashboard"`
+	Links       map[string]string `json:"links"`
+}
+
+func newDashboardRevisionResponse(rev *influxdb.DashboardRevision) dashboardRevisionResponse {
+	self := fmt.Sprintf("/api/v2/dashboards/%s/revisions/%d", rev.DashboardID, rev.Version)
+	return dashboardRevisionResponse{
+		Version:     rev.Version,
+		Description: rev.Description,
+		UserID:      rev.UserID,
+		CreatedAt:   rev.CreatedAt,
+		Dashboard:   newDashboardResponse(rev.Dashboard, nil),
+		Links: map[string]string{
+			"self":    self,
+			"restore": self + "/restore",
+		},
+	}
+}
+
+type dashboardRevisionsResponse struct {
+	Revisions []dashboardRevisionResponse `json:"revisions"`
+	// Total is the number of revisions retained for the dashboard.
+	Total int               `json:"total"`
+	Links map[string]string `json:"links"`
+}
+
+// handleGetDashboardRevisions lists the revisions of a dashboard, newest first.
+func (h *DashboardHandler) handleGetDashboardRevisions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	req, err := decodeGetDashboardRequest(ctx, r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	opts, err := influxdb.DecodeFindOptions(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	revs, total, err := h.revisionService.FindDashboardRevisions(ctx, req.DashboardID, *opts)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	res := dashboardRevisionsResponse{
+		Revisions: make([]dashboardRevisionResponse, 0, len(revs)),
+		Total:     total,
+		Links: map[string]string{
+			"self":      fmt.Sprintf("/api/v2/dashboards/%s/revisions", req.DashboardID),
+			"dashboard": fmt.Sprintf("/api/v2/dashboards/%s", req.DashboardID),
+		},
+	}
+	for _, rev := range revs {
+		res.Revisions = append(res.Revisions, newDashboardRevisionResponse(rev))
+	}
+	h.api.Respond(w, r, http.StatusOK, res)
+}
+
+// handleGetDashboardRevision retrieves a single revision of a dashboard.
+func (h *DashboardHandler) handleGetDashboardRevision(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	req, err := decodeDashboardRevisionRequest(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	rev, err := h.revisionService.FindDashboardRevision(ctx, req.DashboardID, req.Version)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, newDashboardRevisionResponse(rev))
+}
+
+// handlePostDashboardRevisionRestore restores a dashboard to a revision.
+func (h *DashboardHandler) handlePostDashboardRevisionRestore(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	req, err := decodeDashboardRevisionRequest(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	dashboard, err := h.revisionService.RestoreDashboardRevision(ctx, req.DashboardID, req.Version)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	labels, err := h.labelService.FindResourceLabels(ctx, influxdb.LabelMappingFilter{ResourceID: dashboard.ID, ResourceType: influxdb.DashboardsResourceType})
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	h.log.Debug("Dashboard restored", zap.String("dashboardID", dashboard.ID.String()), zap.Int("version", req.Version))
+
+	h.api.Respond(w, r, http.StatusOK, newDashboardResponse(dashboard, labels))
+}
+
+type dashboardRevisionRequest struct {
+	DashboardID platform.ID
+	Version     int
+}
+
+func decodeDashboardRevisionRequest(r *http.Request) (*dashboardRevisionRequest, error) {
+	dash, err := decodeGetDashboardRequest(r.Context(), r)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil || version < 1 {
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "revision version must be a positive integer",
+		}
+	}
+
+	return &dashboardRevisionRequest{
+		DashboardID: dash.DashboardID,
+		Version:     version,
+	}, nil
+}
@@ -0,0 +1,78 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/dashboards"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/label"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/tenant"
+	itesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestDashboardHandler_Revisions(t *testing.T) {
+	log := zaptest.NewLogger(t)
+	store := itesting.NewTestInmemStore(t)
+	svc := dashboards.NewService(store, kv.NewService(log, store, &mock.OrganizationService{}))
+
+	ctx := context.Background()
+	d := &influxdb.Dashboard{OrganizationID: 1, Name: "before"}
+	require.NoError(t, svc.CreateDashboard(ctx, d))
+	name := "after"
+	_, err := svc.UpdateDashboard(ctx, d.ID, influxdb.DashboardUpdate{Name: &name})
+	require.NoError(t, err)
+
+	labelSvc := mock.NewLabelService()
+	h := NewDashboardHandler(
+		log,
+		svc,
+		labelSvc,
+		mock.NewUserService(),
+		mock.NewOrganizationService(),
+		tenant.NewURMHandler(log.With(zap.String("handler", "urm")), influxdb.DashboardsResourceType, "id", mock.NewUserService(), mock.NewUserResourceMappingService()),
+		label.NewHTTPEmbeddedHandler(log.With(zap.String("handler", "label")), influxdb.DashboardsResourceType, labelSvc),
+		WithDashboardRevisionService(svc),
+	)
+	r := chi.NewRouter()
+	r.Mount(h.Prefix(), h)
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, fmt.Sprintf("/api/v2/dashboards/%s%s", d.ID, path), nil))
+		return w
+	}
+
+	w := do(http.MethodGet, "/revisions")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var list dashboardRevisionsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Equal(t, 2, list.Total)
+	require.Equal(t, 2, list.Revisions[0].Version)
+	require.Equal(t, "after", list.Revisions[0].Dashboard.Name)
+
+	w = do(http.MethodPost, "/revisions/1/restore")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var restored dashboardResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &restored))
+	require.Equal(t, "before", restored.Name)
+
+	w = do(http.MethodGet, "/revisions/3")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = do(http.MethodGet, "/revisions/9")
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	w = do(http.MethodGet, "/revisions/latest")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,422 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/flux/csv"
+	"github.com/influxdata/flux/iocounter"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/dashboards"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/query"
+	"go.uber.org/zap"
+)
+
+const (
+	prefixDashboardShares = "/api/v2/share"
+
+	// defaultShareQueryRange is the time range queried when a request does not provide one.
+	defaultShareQueryRange = time.Hour
+	// shareQueryPoints is the number of windows the queried time range is divided
+	// into when a request does not provide a window period.
+	shareQueryPoints = 360
+)
+
+// WithDashboardShareService enables the endpoints managing the share links of
+// a dashboard, served by svc.
+func WithDashboardShareService(svc influxdb.DashboardShareService) DashboardHandlerOption {
+	return func(h *DashboardHandler) {
+		h.shareService = svc
+	}
+}
+
+type dashboardShareResponse struct {
+	*influxdb.DashboardShare
+	Links map[string]string `json:"links"`
+}
+
+func newDashboardShareResponse(s *influxdb.DashboardShare) dashboardShareResponse {
+	res := dashboardShareResponse{
+		DashboardShare: s,
+		Links: map[string]string{
+			"self":      fmt.Sprintf("/api/v2/dashboards/%s/shares/%s", s.DashboardID, s.ID),
+			"dashboard": fmt.Sprintf("/api/v2/dashboards/%s", s.DashboardID),
+		},
+	}
+	if s.Token != "" {
+		res.Links["share"] = fmt.Sprintf("%s/%s", prefixDashboardShares, s.Token)
+	}
+	return res
+}
+
+type dashboardSharesResponse struct {
+	Shares []dashboardShareResponse `json:"shares"`
+	Links  map[string]string        `json:"links"`
+}
+
+type postDashboardShareRequest struct {
+	// ExpiresAt defaults to influxdb.DefaultDashboardShareTTL from now.
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// handlePostDashboardShare creates a share link for a dashboard.
+func (h *DashboardHandler) handlePostDashboardShare(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	dash, err := decodeGetDashboardRequest(ctx, r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var req postDashboardShareRequest
+	if r.ContentLength != 0 {
+		if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+	}
+
+	share, err := h.shareService.CreateDashboardShare(ctx, dash.DashboardID, req.ExpiresAt)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Dashboard share created", zap.String("dashboardID", share.DashboardID.String()), zap.String("shareID", share.ID.String()))
+
+	h.api.Respond(w, r, http.StatusCreated, newDashboardShareResponse(share))
+}
+
+// handleGetDashboardShares lists the unexpired share links of a dashboard.
+func (h *DashboardHandler) handleGetDashboardShares(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	dash, err := decodeGetDashboardRequest(ctx, r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	shares, err := h.shareService.FindDashboardShares(ctx, dash.DashboardID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	res := dashboardSharesResponse{
+		Shares: make([]dashboardShareResponse, 0, len(shares)),
+		Links: map[string]string{
+			"self":      fmt.Sprintf("/api/v2/dashboards/%s/shares", dash.DashboardID),
+			"dashboard": fmt.Sprintf("/api/v2/dashboards/%s", dash.DashboardID),
+		},
+	}
+	for _, s := range shares {
+		res.Shares = append(res.Shares, newDashboardShareResponse(s))
+	}
+	h.api.Respond(w, r, http.StatusOK, res)
+}
+
+// handleDeleteDashboardShare revokes a share link of a dashboard.
+func (h *DashboardHandler) handleDeleteDashboardShare(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	dash, err := decodeGetDashboardRequest(ctx, r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	var shareID platform.ID
+	if err := shareID.DecodeFromString(chi.URLParam(r, "shareID")); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.shareService.DeleteDashboardShare(ctx, dash.DashboardID, shareID); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Dashboard share revoked", zap.String("dashboardID", dash.DashboardID.String()), zap.String("shareID", shareID.String()))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SharedDashboardHandler serves dashboards to holders of a share token, without
+// any other authentication. Only the dashboard the token was issued for can be
+// read, and only the queries stored in its cells can be run, with the bucket
+// read access the creator of the share holds in the dashboard's organization.
+type SharedDashboardHandler struct {
+	chi.Router
+
+	api *kithttp.API
+	log *zap.Logger
+
+	shareService      influxdb.DashboardShareService
+	dashboardService  influxdb.DashboardService
+	queryService      query.ProxyQueryService
+	permissionService dashboards.PermissionService
+	usageService      influxdb.DashboardUsageService
+}
+
+// SharedDashboardHandlerOption configures optional behavior of a SharedDashboardHandler.
+type SharedDashboardHandlerOption func(h *SharedDashboardHandler)
+
+// WithSharedDashboardUsageService records the views and queries of shared dashboards to svc.
+func WithSharedDashboardUsageService(svc influxdb.DashboardUsageService) SharedDashboardHandlerOption {
+	return func(h *SharedDashboardHandler) {
+		h.usageService = svc
+	}
+}
+
+// NewSharedDashboardHandler returns a new instance of SharedDashboardHandler. The
+// services must not be authorizing, since requests carry no authorization.
+func NewSharedDashboardHandler(
+	log *zap.Logger,
+	shareService influxdb.DashboardShareService,
+	dashboardService influxdb.DashboardService,
+	queryService query.ProxyQueryService,
+	permissionService dashboards.PermissionService,
+	opts ...SharedDashboardHandlerOption,
+) *SharedDashboardHandler {
+	h := &SharedDashboardHandler{
+		log:               log,
+		api:               kithttp.NewAPI(kithttp.WithLog(log)),
+		shareService:      shareService,
+		dashboardService:  dashboardService,
+		queryService:      queryService,
+		permissionService: permissionService,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+	r.Route("/{token}", func(r chi.Router) {
+		r.Get("/", h.handleGetSharedDashboard)
+		r.Post("/query", h.handlePostSharedQuery)
+	})
+	h.Router = r
+	return h
+}
+
+// Prefix returns the path prefix of the public share endpoints.
+func (h *SharedDashboardHandler) Prefix() string {
+	return prefixDashboardShares
+}
+
+type sharedDashboardResponse struct {
+	ID          platform.ID            `json:"id"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Meta        influxdb.DashboardMeta `json:"meta"`
+	Cells       []*influxdb.Cell       `json:"cells"`
+	ExpiresAt   time.Time              `json:"expiresAt"`
+	Links       map[string]string      `json:"links"`
+}
+
+// handleGetSharedDashboard returns the shared dashboard, with the views of its cells.
+func (h *SharedDashboardHandler) handleGetSharedDashboard(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	token := chi.URLParam(r, "token")
+	share, d, err := h.findSharedDashboard(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	for _, c := range d.Cells {
+		view, err := h.dashboardService.GetDashboardCellView(ctx, d.ID, c.ID)
+		if errors.ErrorCode(err) == errors.ENotFound {
+			continue
+		}
+		if err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+		c.View = view
+		if h.usageService != nil {
+			h.usageService.RecordCellView(ctx, d.ID, c.ID)
+		}
+	}
+	if h.usageService != nil {
+		h.usageService.RecordDashboardView(ctx, d.ID)
+	}
+
+	h.api.Respond(w, r, http.StatusOK, sharedDashboardResponse{
+		ID:          d.ID,
+		Name:        d.Name,
+		Description: d.Description,
+		Meta:        d.Meta,
+		Cells:       d.Cells,
+		ExpiresAt:   share.ExpiresAt,
+		Links: map[string]string{
+			"self":  fmt.Sprintf("%s/%s", prefixDashboardShares, token),
+			"query": fmt.Sprintf("%s/%s/query", prefixDashboardShares, token),
+		},
+	})
+}
+
+type sharedQueryRequest struct {
+	CellID platform.ID `json:"cellID"`
+	// Query is the index of the query within the cell's view.
+	Query        int       `json:"query"`
+	Start        time.Time `json:"start"`
+	Stop         time.Time `json:"stop"`
+	WindowPeriod string    `json:"windowPeriod"`
+}
+
+// handlePostSharedQuery runs a query of a cell of the shared dashboard, over the
+// requested time range, and responds with annotated CSV.
+func (h *SharedDashboardHandler) handlePostSharedQuery(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	share, d, err := h.findSharedDashboard(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var req sharedQueryRequest
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if !hasCell(d, req.CellID) {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  influxdb.ErrCellNotFound,
+		})
+		return
+	}
+	view, err := h.dashboardService.GetDashboardCellView(ctx, d.ID, req.CellID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	text, err := viewQuery(view, req.Query)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	extern, err := sharedQueryExtern(req, time.Now())
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	// The query runs on behalf of the creator of the share, and only reads the
+	// buckets of the dashboard's organization they can still read.
+	auth, err := dashboards.ReadAuthorization(ctx, h.permissionService, share.ID, share.OrganizationID, share.CreatedBy)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	ctx = icontext.SetAuthorizer(ctx, auth)
+
+	dialect := &csv.Dialect{ResultEncoderConfig: csv.DefaultEncoderConfig()}
+	pr := &query.ProxyRequest{
+		Request: query.Request{
+			Authorization:  auth,
+			OrganizationID: share.OrganizationID,
+			Compiler: lang.FluxCompiler{
+				Now:    time.Now(),
+				Extern: extern,
+				Query:  text,
+			},
+			Source: r.Header.Get("User-Agent"),
+		},
+		Dialect: dialect,
+	}
+
+	if h.usageService != nil {
+		h.usageService.RecordDashboardQuery(ctx, share.OrganizationID, d.ID, req.CellID)
+	}
+
+	dialect.SetHeaders(w)
+	cw := iocounter.Writer{Writer: w}
+	if _, err := h.queryService.Query(ctx, &cw, pr); err != nil {
+		if cw.Count() == 0 {
+			// Only respond with an error if nothing has been written yet.
+			h.api.Err(w, r, err)
+			return
+		}
+		h.log.Info("Error writing shared query response to client", zap.String("shareID", share.ID.String()), zap.Error(err))
+	}
+}
+
+// findSharedDashboard verifies the token of the request and returns its share and dashboard.
+func (h *SharedDashboardHandler) findSharedDashboard(r *http.Request) (*influxdb.DashboardShare, *influxdb.Dashboard, error) {
+	share, err := h.shareService.FindDashboardShareByToken(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		return nil, nil, err
+	}
+	d, err := h.dashboardService.FindDashboardByID(r.Context(), share.DashboardID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return share, d, nil
+}
+
+// viewQuery returns the text of the i-th query of a view.
+func viewQuery(view *influxdb.View, i int) (string, error) {
+	queries, err := dashboards.ViewQueries(view)
+	if err != nil {
+		return "", err
+	}
+	if i < 0 || i >= len(queries) || queries[i] == "" {
+		return "", &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  fmt.Sprintf("cell has no query %d", i),
+		}
+	}
+	return queries[i], nil
+}
+
+// sharedQueryExtern returns the extern defining the dashboard time range variables
+// the cell queries refer to: v.timeRangeStart, v.timeRangeStop and v.windowPeriod.
+func sharedQueryExtern(req sharedQueryRequest, now time.Time) ([]byte, error) {
+	stop := req.Stop
+	if stop.IsZero() {
+		stop = now
+	}
+	start := req.Start
+	if start.IsZero() {
+		start = stop.Add(-defaultShareQueryRange)
+	}
+	if !start.Before(stop) {
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "start must be before stop",
+		}
+	}
+
+	window := stop.Sub(start) / shareQueryPoints
+	if req.WindowPeriod != "" {
+		d, err := time.ParseDuration(req.WindowPeriod)
+		if err != nil || d <= 0 {
+			return nil, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("invalid window period %q", req.WindowPeriod),
+			}
+		}
+		window = d
+	}
+	return dashboards.QueryExtern(start, stop, window)
+}
+
+func hasCell(d *influxdb.Dashboard, cellID platform.ID) bool {
+	for _, c := range d.Cells {
+		if c.ID == cellID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/dashboards"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/query"
+	querymock "github.com/influxdata/influxdb/v2/query/mock"
+	itesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestSharedDashboardHandler(t *testing.T) {
+	log := zaptest.NewLogger(t)
+	store := itesting.NewTestInmemStore(t)
+	svc := dashboards.NewService(store, kv.NewService(log, store, &mock.OrganizationService{}))
+
+	ctx := context.Background()
+	d := &influxdb.Dashboard{OrganizationID: 1, Name: "shared"}
+	require.NoError(t, svc.CreateDashboard(ctx, d))
+	cell := &influxdb.Cell{CellProperty: influxdb.CellProperty{W: 4, H: 4}}
+	require.NoError(t, svc.AddDashboardCell(ctx, d.ID, cell, influxdb.AddDashboardCellOptions{
+		View: &influxdb.View{
+			ViewContents: influxdb.ViewContents{Name: "cpu"},
+			Properties: influxdb.SingleStatViewProperties{
+				Type:    influxdb.ViewPropertyTypeSingleStat,
+				Queries: []influxdb.DashboardQuery{{Text: `from(bucket: "b") |> range(start: v.timeRangeStart)`}},
+			},
+		},
+	}))
+	creatorID := platform.ID(2)
+	share, err := svc.CreateDashboardShare(icontext.SetAuthorizer(ctx, &influxdb.Authorization{UserID: creatorID}), d.ID, time.Time{})
+	require.NoError(t, err)
+
+	otherOrgID := platform.ID(3)
+	creatorPerms := append(influxdb.MemberPermissions(d.OrganizationID), influxdb.Permission{
+		Action:   influxdb.ReadAction,
+		Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, OrgID: &otherOrgID},
+	})
+	us := mock.NewUserService()
+	us.FindPermissionForUserFn = func(_ context.Context, id platform.ID) (influxdb.PermissionSet, error) {
+		require.Equal(t, creatorID, id)
+		return creatorPerms, nil
+	}
+
+	var got *query.ProxyRequest
+	qs := &querymock.ProxyQueryService{
+		QueryF: func(ctx context.Context, w io.Writer, req *query.ProxyRequest) (flux.Statistics, error) {
+			a, err := icontext.GetAuthorizer(ctx)
+			require.NoError(t, err)
+			require.Equal(t, req.Request.Authorization, a)
+			got = req
+			_, err = w.Write([]byte("result"))
+			return flux.Statistics{}, err
+		},
+	}
+	h := NewSharedDashboardHandler(log, svc, svc, qs, us)
+	r := chi.NewRouter()
+	r.Mount(h.Prefix(), h)
+
+	do := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		var b bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&b).Encode(body))
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, "/api/v2/share/"+path, &b))
+		return w
+	}
+
+	w := do(http.MethodGet, share.Token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var res struct {
+		Name  string `json:"name"`
+		Cells []struct {
+			Name       string          `json:"name"`
+			Properties json.RawMessage `json:"properties"`
+		} `json:"cells"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Equal(t, "shared", res.Name)
+	require.Len(t, res.Cells, 1)
+	require.Equal(t, "cpu", res.Cells[0].Name)
+	require.Contains(t, string(res.Cells[0].Properties), "timeRangeStart")
+
+	w = do(http.MethodPost, share.Token+"/query", map[string]interface{}{"cellID": cell.ID.String(), "windowPeriod": "1m"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, "result", w.Body.String())
+	require.Equal(t, d.OrganizationID, got.Request.OrganizationID)
+	compiler := got.Request.Compiler.(lang.FluxCompiler)
+	require.Equal(t, `from(bucket: "b") |> range(start: v.timeRangeStart)`, compiler.Query)
+	require.Contains(t, string(compiler.Extern), "timeRangeStop")
+	require.Equal(t, creatorID, got.Request.Authorization.UserID)
+	perms := got.Request.Authorization.Permissions
+	require.Len(t, perms, 1)
+	require.Equal(t, influxdb.ReadAction, perms[0].Action)
+	require.Equal(t, influxdb.BucketsResourceType, perms[0].Resource.Type)
+	require.Equal(t, d.OrganizationID, *perms[0].Resource.OrgID)
+
+	// Once the creator cannot read the buckets anymore, neither can the share.
+	creatorPerms = influxdb.MePermissions(creatorID)
+	w = do(http.MethodPost, share.Token+"/query", map[string]interface{}{"cellID": cell.ID.String()})
+	require.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+	creatorPerms = influxdb.MemberPermissions(d.OrganizationID)
+
+	w = do(http.MethodPost, share.Token+"/query", map[string]interface{}{"cellID": cell.ID.String(), "query": 1})
+	require.Equal(t, http.StatusNotFound, w.Code)
+	w = do(http.MethodPost, share.Token+"/query", map[string]interface{}{"cellID": fmt.Sprintf("%016x", 99)})
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	require.NoError(t, svc.DeleteDashboardShare(ctx, d.ID, share.ID))
+	w = do(http.MethodGet, share.Token, nil)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestDashboardHandler_Shares(t *testing.T) {
+	log := zaptest.NewLogger(t)
+	store := itesting.NewTestInmemStore(t)
+	svc := dashboards.NewService(store, kv.NewService(log, store, &mock.OrganizationService{}))
+
+	ctx := context.Background()
+	d := &influxdb.Dashboard{OrganizationID: 1, Name: "shared"}
+	require.NoError(t, svc.CreateDashboard(ctx, d))
+
+	h := NewDashboardHandler(
+		log,
+		svc,
+		mock.NewLabelService(),
+		mock.NewUserService(),
+		mock.NewOrganizationService(),
+		http.NotFoundHandler(),
+		http.NotFoundHandler(),
+		WithDashboardShareService(svc),
+	)
+	r := chi.NewRouter()
+	r.Mount(h.Prefix(), h)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, fmt.Sprintf("/api/v2/dashboards/%s/shares%s", d.ID, path), bytes.NewBufferString(body)))
+		return w
+	}
+
+	w := do(http.MethodPost, "", `{}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created dashboardShareResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	require.NotEmpty(t, created.Token)
+
+	w = do(http.MethodGet, "", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var list dashboardSharesResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Shares, 1)
+
+	w = do(http.MethodDelete, "/"+created.ID.String(), "")
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	_, err := svc.FindDashboardShareByToken(ctx, created.Token)
+	require.Error(t, err)
+}
@@ -0,0 +1,45 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+// WithDashboardUsageService records the views of dashboards and cells to svc, and
+// enables the endpoint reporting the usage of a dashboard.
+func WithDashboardUsageService(svc influxdb.DashboardUsageService) DashboardHandlerOption {
+	return func(h *DashboardHandler) {
+		h.usageService = svc
+	}
+}
+
+type dashboardUsageResponse struct {
+	*influxdb.DashboardUsage
+	Links map[string]string `json:"links"`
+}
+
+// handleGetDashboardUsage reports how often a dashboard and its cells have been viewed and queried.
+func (h *DashboardHandler) handleGetDashboardUsage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	req, err := decodeGetDashboardRequest(ctx, r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	usage, err := h.usageService.FindDashboardUsage(ctx, req.DashboardID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusOK, dashboardUsageResponse{
+		DashboardUsage: usage,
+		Links: map[string]string{
+			"self":      fmt.Sprintf("/api/v2/dashboards/%s/usage", req.DashboardID),
+			"dashboard": fmt.Sprintf("/api/v2/dashboards/%s", req.DashboardID),
+		},
+	})
+}
@@ -0,0 +1,62 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/dashboards"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/mock"
+	itesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestDashboardHandler_Usage(t *testing.T) {
+	log := zaptest.NewLogger(t)
+	store := itesting.NewTestInmemStore(t)
+	svc := dashboards.NewService(store, kv.NewService(log, store, &mock.OrganizationService{}))
+
+	ctx := context.Background()
+	d := &influxdb.Dashboard{OrganizationID: 1, Name: "dash"}
+	require.NoError(t, svc.CreateDashboard(ctx, d))
+	cell := &influxdb.Cell{}
+	require.NoError(t, svc.AddDashboardCell(ctx, d.ID, cell, influxdb.AddDashboardCellOptions{}))
+
+	h := NewDashboardHandler(
+		log,
+		svc,
+		mock.NewLabelService(),
+		mock.NewUserService(),
+		mock.NewOrganizationService(),
+		http.NotFoundHandler(),
+		http.NotFoundHandler(),
+		WithDashboardUsageService(svc),
+	)
+	r := chi.NewRouter()
+	r.Mount(h.Prefix(), h)
+
+	do := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v2/dashboards/%s%s", d.ID, path), nil))
+		return w
+	}
+
+	require.Equal(t, http.StatusOK, do("?include=properties").Code)
+	require.Equal(t, http.StatusOK, do(fmt.Sprintf("/cells/%s/view", cell.ID)).Code)
+
+	w := do("/usage")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var usage influxdb.DashboardUsage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &usage))
+	require.Equal(t, int64(1), usage.Views)
+	require.NotNil(t, usage.LastViewedAt)
+	require.Len(t, usage.Cells, 1)
+	require.Equal(t, int64(2), usage.Cells[0].Views)
+}
@@ -0,0 +1,333 @@
+package dashboards
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	influxdb "github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+	"go.uber.org/zap"
+)
+
+var dashboardUsageBucket = []byte("dashboardusagev1")
+
+// DefaultUsageFlushInterval is how often recorded usage is written to the store.
+const DefaultUsageFlushInterval = 10 * time.Second
+
+var _ influxdb.DashboardUsageService = (*Service)(nil)
+
+// usageKey identifies the usage recorded for a dashboard, or one of its cells if
+// cellID is set. orgID is set for queries, which are only counted if the dashboard
+// belongs to the organization they were run by.
+type usageKey struct {
+	dashboardID platform.ID
+	cellID      platform.ID
+	orgID       platform.ID
+}
+
+// RecordDashboardView records that a dashboard was viewed. Usage is counted in
+// memory and written to the store by FlushDashboardUsage.
+func (s *Service) RecordDashboardView(ctx context.Context, dashboardID platform.ID) {
+	s.recordUsage(usageKey{dashboardID: dashboardID}, func(c *influxdb.DashboardUsageCounts, now time.Time) {
+		c.Views++
+		c.LastViewedAt = &now
+	})
+}
+
+// RecordCellView records that the view of a dashboard cell was read.
+func (s *Service) RecordCellView(ctx context.Context, dashboardID, cellID platform.ID) {
+	s.recordUsage(usageKey{dashboardID: dashboardID, cellID: cellID}, func(c *influxdb.DashboardUsageCounts, now time.Time) {
+		c.Views++
+		c.LastViewedAt = &now
+	})
+}
+
+// RecordDashboardQuery records a query run by orgID on behalf of a dashboard, and
+// optionally one of its cells.
+func (s *Service) RecordDashboardQuery(ctx context.Context, orgID, dashboardID, cellID platform.ID) {
+	s.recordUsage(usageKey{dashboardID: dashboardID, cellID: cellID, orgID: orgID}, func(c *influxdb.DashboardUsageCounts, now time.Time) {
+		c.Queries++
+		c.LastQueriedAt = &now
+	})
+}
+
+func (s *Service) recordUsage(k usageKey, fn func(c *influxdb.DashboardUsageCounts, now time.Time)) {
+	now := s.TimeGenerator.Now()
+
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+	if s.usage == nil {
+		s.usage = make(map[usageKey]*influxdb.DashboardUsageCounts)
+	}
+	c, ok := s.usage[k]
+	if !ok {
+		c = &influxdb.DashboardUsageCounts{}
+		s.usage[k] = c
+	}
+	fn(c, now)
+}
+
+// FindDashboardUsage returns the usage of a dashboard and each of its cells,
+// including usage which has not yet been flushed to the store.
+func (s *Service) FindDashboardUsage(ctx context.Context, dashboardID platform.ID) (*influxdb.DashboardUsage, error) {
+	var (
+		d       *influxdb.Dashboard
+		records map[platform.ID]*influxdb.DashboardUsageCounts
+	)
+	err := s.kv.View(ctx, func(tx kv.Tx) error {
+		dash, err := s.findDashboardByID(ctx, tx, dashboardID)
+		if err != nil {
+			return err
+		}
+		rs, err := s.findUsageRecords(tx, dashboardID)
+		if err != nil {
+			return err
+		}
+		d, records = dash, rs
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+		}
+	}
+
+	s.usageMu.Lock()
+	for k, c := range s.usage {
+		if k.dashboardID == dashboardID && usageApplies(d, k) {
+			mergeUsage(records, k.cellID, c)
+		}
+	}
+	s.usageMu.Unlock()
+
+	usage := &influxdb.DashboardUsage{
+		DashboardID: d.ID,
+		Cells:       make([]*influxdb.CellUsage, 0, len(d.Cells)),
+	}
+	if c, ok := records[0]; ok {
+		usage.DashboardUsageCounts = *c
+	}
+	for _, cell := range d.Cells {
+		cu := &influxdb.CellUsage{CellID: cell.ID}
+		if c, ok := records[cell.ID]; ok {
+			cu.DashboardUsageCounts = *c
+		}
+		usage.Cells = append(usage.Cells, cu)
+	}
+	return usage, nil
+}
+
+// FlushDashboardUsage adds the usage recorded since the last flush to the store.
+// Usage of dashboards or cells which no longer exist is discarded.
+func (s *Service) FlushDashboardUsage(ctx context.Context) error {
+	s.usageMu.Lock()
+	pending := s.usage
+	s.usage = nil
+	s.usageMu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	byDashboard := make(map[platform.ID][]usageKey)
+	for k := range pending {
+		byDashboard[k.dashboardID] = append(byDashboard[k.dashboardID], k)
+	}
+
+	err := s.kv.Update(ctx, func(tx kv.Tx) error {
+		for id, keys := range byDashboard {
+			d, err := s.findDashboardByID(ctx, tx, id)
+			if errors.ErrorCode(err) == errors.ENotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			records, err := s.findUsageRecords(tx, id)
+			if err != nil {
+				return err
+			}
+			for _, k := range keys {
+				if usageApplies(d, k) {
+					mergeUsage(records, k.cellID, pending[k])
+				}
+			}
+			if err := s.putUsageRecords(tx, id, records); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// Keep the usage so that it is written by the next flush.
+		s.usageMu.Lock()
+		for k, c := range pending {
+			if s.usage == nil {
+				s.usage = make(map[usageKey]*influxdb.DashboardUsageCounts)
+			}
+			if cur, ok := s.usage[k]; ok {
+				addUsageCounts(c, cur)
+			}
+			s.usage[k] = c
+		}
+		s.usageMu.Unlock()
+		return &errors.Error{
+			Err: err,
+		}
+	}
+	return nil
+}
+
+// FlushDashboardUsageEvery flushes recorded usage every interval until ctx is done.
+func (s *Service) FlushDashboardUsageEvery(ctx context.Context, log *zap.Logger, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.FlushDashboardUsage(ctx); err != nil {
+				log.Warn("Failed to flush dashboard usage", zap.Error(err))
+			}
+		}
+	}
+}
+
+// usageApplies reports whether the usage recorded under k should be counted for d.
+func usageApplies(d *influxdb.Dashboard, k usageKey) bool {
+	if k.orgID.Valid() && k.orgID != d.OrganizationID {
+		return false
+	}
+	return !k.cellID.Valid() || hasCell(d, k.cellID)
+}
+
+// mergeUsage adds the usage c of a cell, or of the dashboard if cellID is zero,
+// to records. The queries of a cell are also counted for the dashboard.
+func mergeUsage(records map[platform.ID]*influxdb.DashboardUsageCounts, cellID platform.ID, c *influxdb.DashboardUsageCounts) {
+	get := func(id platform.ID) *influxdb.DashboardUsageCounts {
+		r, ok := records[id]
+		if !ok {
+			r = &influxdb.DashboardUsageCounts{}
+			records[id] = r
+		}
+		return r
+	}
+
+	addUsageCounts(get(cellID), c)
+	if cellID.Valid() {
+		addUsageCounts(get(0), &influxdb.DashboardUsageCounts{
+			Queries:       c.Queries,
+			LastQueriedAt: c.LastQueriedAt,
+		})
+	}
+}
+
+// addUsageCounts adds the counts of src to dst, keeping the latest timestamps.
+func addUsageCounts(dst, src *influxdb.DashboardUsageCounts) {
+	dst.Views += src.Views
+	dst.Queries += src.Queries
+	if src.LastViewedAt != nil && (dst.LastViewedAt == nil || src.LastViewedAt.After(*dst.LastViewedAt)) {
+		dst.LastViewedAt = src.LastViewedAt
+	}
+	if src.LastQueriedAt != nil && (dst.LastQueriedAt == nil || src.LastQueriedAt.After(*dst.LastQueriedAt)) {
+		dst.LastQueriedAt = src.LastQueriedAt
+	}
+}
+
+// findUsageRecords returns the stored usage of a dashboard, keyed by cell. The
+// usage of the dashboard itself is keyed by the zero ID.
+func (s *Service) findUsageRecords(tx kv.Tx, dashboardID platform.ID) (map[platform.ID]*influxdb.DashboardUsageCounts, error) {
+	prefix, err := dashboardID.Encode()
+	if err != nil {
+		return nil, err
+	}
+	b, err := tx.Bucket(dashboardUsageBucket)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := b.ForwardCursor(prefix, kv.WithCursorPrefix(prefix))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close()
+
+	records := make(map[platform.ID]*influxdb.DashboardUsageCounts)
+	for k, v := cur.Next(); k != nil; k, v = cur.Next() {
+		var cellID platform.ID
+		if len(k) > len(prefix) {
+			if err := cellID.Decode(k[len(prefix):]); err != nil {
+				return nil, err
+			}
+		}
+		c := &influxdb.DashboardUsageCounts{}
+		if err := json.Unmarshal(v, c); err != nil {
+			return nil, err
+		}
+		records[cellID] = c
+	}
+	return records, cur.Err()
+}
+
+func (s *Service) putUsageRecords(tx kv.Tx, dashboardID platform.ID, records map[platform.ID]*influxdb.DashboardUsageCounts) error {
+	b, err := tx.Bucket(dashboardUsageBucket)
+	if err != nil {
+		return err
+	}
+	for cellID, c := range records {
+		k, err := encodeUsageKey(dashboardID, cellID)
+		if err != nil {
+			return err
+		}
+		v, err := json.Marshal(c)
+		if err != nil {
+			return err
+		}
+		if err := b.Put(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteUsage deletes the stored usage of a dashboard cell, or of the whole
+// dashboard and its cells if cellID is zero.
+func (s *Service) deleteUsage(tx kv.Tx, dashboardID, cellID platform.ID) error {
+	b, err := tx.Bucket(dashboardUsageBucket)
+	if err != nil {
+		return err
+	}
+	if cellID.Valid() {
+		k, err := encodeUsageKey(dashboardID, cellID)
+		if err != nil {
+			return err
+		}
+		return b.Delete(k)
+	}
+
+	records, err := s.findUsageRecords(tx, dashboardID)
+	if err != nil {
+		return err
+	}
+	for id := range records {
+		k, err := encodeUsageKey(dashboardID, id)
+		if err != nil {
+			return err
+		}
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeUsageKey returns the key of the usage of a cell, or of the dashboard
+// itself if cellID is zero. Keys are prefixed by the dashboard.
+func encodeUsageKey(dashboardID, cellID platform.ID) ([]byte, error) {
+	if !cellID.Valid() {
+		return dashboardID.Encode()
+	}
+	return joinIDs(dashboardID, cellID)
+}
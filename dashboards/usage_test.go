@@ -0,0 +1,78 @@
+package dashboards
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_DashboardUsage(t *testing.T) {
+	ctx := context.Background()
+	svc := newRevisionTestService(t)
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	svc.TimeGenerator = mock.TimeGenerator{FakeValue: now}
+
+	d := &influxdb.Dashboard{OrganizationID: 1, Name: "dash"}
+	require.NoError(t, svc.CreateDashboard(ctx, d))
+	c1, c2 := &influxdb.Cell{}, &influxdb.Cell{}
+	require.NoError(t, svc.AddDashboardCell(ctx, d.ID, c1, influxdb.AddDashboardCellOptions{}))
+	require.NoError(t, svc.AddDashboardCell(ctx, d.ID, c2, influxdb.AddDashboardCellOptions{}))
+
+	svc.RecordDashboardView(ctx, d.ID)
+	svc.RecordCellView(ctx, d.ID, c1.ID)
+	svc.RecordDashboardQuery(ctx, d.OrganizationID, d.ID, c1.ID)
+	svc.RecordDashboardQuery(ctx, d.OrganizationID, d.ID, 0)
+	// Queries run by another organization are not counted.
+	svc.RecordDashboardQuery(ctx, 2, d.ID, c1.ID)
+
+	check := func() {
+		t.Helper()
+		usage, err := svc.FindDashboardUsage(ctx, d.ID)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), usage.Views)
+		require.Equal(t, int64(2), usage.Queries)
+		require.Equal(t, now, *usage.LastQueriedAt)
+		require.Len(t, usage.Cells, 2)
+		require.Equal(t, c1.ID, usage.Cells[0].CellID)
+		require.Equal(t, int64(1), usage.Cells[0].Views)
+		require.Equal(t, int64(1), usage.Cells[0].Queries)
+		require.Zero(t, usage.Cells[1].Views)
+		require.Nil(t, usage.Cells[1].LastViewedAt)
+	}
+	// Unflushed usage is reported, and flushing does not change it.
+	check()
+	require.NoError(t, svc.FlushDashboardUsage(ctx))
+	check()
+
+	svc.RecordDashboardView(ctx, d.ID)
+	require.NoError(t, svc.FlushDashboardUsage(ctx))
+	usage, err := svc.FindDashboardUsage(ctx, d.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), usage.Views)
+
+	require.NoError(t, svc.RemoveDashboardCell(ctx, d.ID, c1.ID))
+	svc.RecordCellView(ctx, d.ID, c1.ID)
+	require.NoError(t, svc.FlushDashboardUsage(ctx))
+	usage, err = svc.FindDashboardUsage(ctx, d.ID)
+	require.NoError(t, err)
+	require.Len(t, usage.Cells, 1)
+	require.Equal(t, c2.ID, usage.Cells[0].CellID)
+
+	require.NoError(t, svc.DeleteDashboard(ctx, d.ID))
+	svc.RecordDashboardView(ctx, d.ID)
+	require.NoError(t, svc.FlushDashboardUsage(ctx))
+	_, err = svc.FindDashboardUsage(ctx, d.ID)
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+	err = svc.kv.View(ctx, func(tx kv.Tx) error {
+		records, err := svc.findUsageRecords(tx, d.ID)
+		require.Empty(t, records)
+		return err
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,250 @@
+package dbrp
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+	"go.uber.org/zap"
+)
+
+var autoCreateBucket = []byte("dbrpautocreatev1")
+
+// DefaultAutoCreateRetentionPolicy is the retention policy of the mappings
+// auto-created for writes without one.
+const DefaultAutoCreateRetentionPolicy = "autogen"
+
+// AutoCreateSettings configures the auto-creation of the bucket and mapping of
+// the unmapped databases v1 writes are to.
+type AutoCreateSettings struct {
+	// Enabled enables the auto-creation.
+	Enabled bool `json:"enabled"`
+	// RetentionPeriod is the retention period of the buckets created. 0 is
+	// infinite.
+	RetentionPeriod time.Duration `json:"retentionPeriod"`
+}
+
+// Valid returns an error if the settings are invalid.
+func (s AutoCreateSettings) Valid() error {
+	if s.RetentionPeriod < 0 {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "auto-create retention period must not be negative",
+		}
+	}
+	return nil
+}
+
+// AutoCreateSettingsService manages the auto-create settings of organizations,
+// which override the settings of the instance.
+type AutoCreateSettingsService interface {
+	// FindAutoCreateSettings returns the settings of an organization, and nil
+	// when the organization has none.
+	FindAutoCreateSettings(ctx context.Context, orgID platform.ID) (*AutoCreateSettings, error)
+	PutAutoCreateSettings(ctx context.Context, orgID platform.ID, s AutoCreateSettings) error
+	DeleteAutoCreateSettings(ctx context.Context, orgID platform.ID) error
+}
+
+var _ AutoCreateSettingsService = (*AutoCreateSettingsStore)(nil)
+
+// AutoCreateSettingsStore stores the auto-create settings of organizations.
+type AutoCreateSettingsStore struct {
+	kv kv.Store
+}
+
+// NewAutoCreateSettingsStore creates a new store of the auto-create settings of organizations.
+func NewAutoCreateSettingsStore(s kv.Store) *AutoCreateSettingsStore {
+	return &AutoCreateSettingsStore{kv: s}
+}
+
+// FindAutoCreateSettings returns the auto-create settings of an organization.
+func (s *AutoCreateSettingsStore) FindAutoCreateSettings(ctx context.Context, orgID platform.ID) (*AutoCreateSettings, error) {
+	key, err := orgID.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	var settings *AutoCreateSettings
+	err = s.kv.View(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(autoCreateBucket)
+		if err != nil {
+			return err
+		}
+		v, err := b.Get(key)
+		if kv.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		settings = new(AutoCreateSettings)
+		return json.Unmarshal(v, settings)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// PutAutoCreateSettings sets the auto-create settings of an organization.
+func (s *AutoCreateSettingsStore) PutAutoCreateSettings(ctx context.Context, orgID platform.ID, settings AutoCreateSettings) error {
+	if err := settings.Valid(); err != nil {
+		return err
+	}
+	key, err := orgID.Encode()
+	if err != nil {
+		return err
+	}
+	v, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	return s.kv.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(autoCreateBucket)
+		if err != nil {
+			return err
+		}
+		return b.Put(key, v)
+	})
+}
+
+// DeleteAutoCreateSettings removes the auto-create settings of an organization.
+func (s *AutoCreateSettingsStore) DeleteAutoCreateSettings(ctx context.Context, orgID platform.ID) error {
+	key, err := orgID.Encode()
+	if err != nil {
+		return err
+	}
+	return s.kv.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(autoCreateBucket)
+		if err != nil {
+			return err
+		}
+		if err := b.Delete(key); err != nil && !kv.IsNotFound(err) {
+			return err
+		}
+		return nil
+	})
+}
+
+// AutoCreator creates the bucket and mapping of the unmapped databases v1
+// writes are to, as 1.x created the databases written to. The bucket of a
+// database and retention policy is named db/rp, like the buckets of upgraded
+// databases.
+//
+// A database is only created when auto-creation is enabled for the
+// organization, by its settings or else by the settings of the instance, and
+// the writer may create buckets and mappings in the organization.
+type AutoCreator struct {
+	log      *zap.Logger
+	defaults AutoCreateSettings
+
+	settings AutoCreateSettingsService
+	buckets  influxdb.BucketService
+	dbrps    influxdb.DBRPMappingService
+}
+
+// NewAutoCreator constructs an AutoCreator with the settings of the instance,
+// which apply to the organizations without settings.
+func NewAutoCreator(log *zap.Logger, defaults AutoCreateSettings, settings AutoCreateSettingsService, buckets influxdb.BucketService, dbrps influxdb.DBRPMappingService) *AutoCreator {
+	return &AutoCreator{
+		log:      log,
+		defaults: defaults,
+		settings: settings,
+		buckets:  buckets,
+		dbrps:    dbrps,
+	}
+}
+
+// orgSettings returns the auto-create settings which apply to an organization.
+func (c *AutoCreator) orgSettings(ctx context.Context, orgID platform.ID) (AutoCreateSettings, error) {
+	s, err := c.settings.FindAutoCreateSettings(ctx, orgID)
+	if err != nil {
+		return AutoCreateSettings{}, err
+	}
+	if s == nil {
+		return c.defaults, nil
+	}
+	return *s, nil
+}
+
+// AutoCreate creates the bucket and mapping of the database and retention
+// policy a write by auth is to. An ENotFound error is returned when
+// auto-creation is disabled for the organization, as it is for writes to
+// unmapped databases.
+func (c *AutoCreator) AutoCreate(ctx context.Context, auth influxdb.Authorizer, orgID platform.ID, db, rp string) (*influxdb.Bucket, error) {
+	settings, err := c.orgSettings(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.Enabled {
+		return nil, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  "no dbrp mapping found",
+		}
+	}
+	if err := authorizeAutoCreate(auth, orgID); err != nil {
+		return nil, err
+	}
+
+	if rp == "" {
+		rp = DefaultAutoCreateRetentionPolicy
+	}
+	name := db + "/" + rp
+	b, err := c.buckets.FindBucketByName(ctx, orgID, name)
+	if errors.ErrorCode(err) == errors.ENotFound {
+		b = &influxdb.Bucket{
+			OrgID:               orgID,
+			Type:                influxdb.BucketTypeUser,
+			Name:                name,
+			RetentionPolicyName: rp,
+			RetentionPeriod:     settings.RetentionPeriod,
+			Description:         "Created by a v1 write to database " + db,
+		}
+		err = c.buckets.CreateBucket(ctx, b)
+		if errors.ErrorCode(err) == errors.EConflict {
+			// a concurrent write created the bucket.
+			b, err = c.buckets.FindBucketByName(ctx, orgID, name)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m := &influxdb.DBRPMapping{
+		Database:        db,
+		RetentionPolicy: rp,
+		OrganizationID:  orgID,
+		BucketID:        b.ID,
+	}
+	// the first mapping of the database is its default.
+	if err := c.dbrps.Create(ctx, m); err != nil && errors.ErrorCode(err) != errors.EConflict {
+		return nil, err
+	}
+	c.log.Info("Auto-created database",
+		zap.Stringer("org_id", orgID),
+		zap.String("database", db),
+		zap.String("retention_policy", rp),
+		zap.Stringer("bucket_id", b.ID))
+	return b, nil
+}
+
+// authorizeAutoCreate checks that auth may create the buckets and mappings
+// of the organization.
+func authorizeAutoCreate(auth influxdb.Authorizer, orgID platform.ID) error {
+	p, err := influxdb.NewPermission(influxdb.WriteAction, influxdb.BucketsResourceType, orgID)
+	if err != nil {
+		return err
+	}
+	if ps, err := auth.PermissionSet(); err != nil || !ps.Allowed(*p) {
+		return &errors.Error{
+			Code: errors.EForbidden,
+			Msg:  "insufficient permissions to auto-create database; a write permission on all the buckets of the organization is required",
+			Err:  err,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package dbrp_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/dbrp"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mock"
+	itesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestAutoCreateSettingsStore(t *testing.T) {
+	ctx := context.Background()
+	s := dbrp.NewAutoCreateSettingsStore(itesting.NewTestInmemStore(t))
+	orgID := platform.ID(1)
+
+	settings, err := s.FindAutoCreateSettings(ctx, orgID)
+	require.NoError(t, err)
+	assert.Nil(t, settings)
+
+	want := dbrp.AutoCreateSettings{Enabled: true, RetentionPeriod: 72 * time.Hour}
+	require.NoError(t, s.PutAutoCreateSettings(ctx, orgID, want))
+	settings, err = s.FindAutoCreateSettings(ctx, orgID)
+	require.NoError(t, err)
+	assert.Equal(t, &want, settings)
+
+	err = s.PutAutoCreateSettings(ctx, orgID, dbrp.AutoCreateSettings{RetentionPeriod: -time.Hour})
+	assert.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+
+	require.NoError(t, s.DeleteAutoCreateSettings(ctx, orgID))
+	settings, err = s.FindAutoCreateSettings(ctx, orgID)
+	require.NoError(t, err)
+	assert.Nil(t, settings)
+}
+
+func TestAutoCreator_AutoCreate(t *testing.T) {
+	ctx := context.Background()
+	orgID := platform.ID(1)
+	store := itesting.NewTestInmemStore(t)
+
+	buckets := map[string]*influxdb.Bucket{}
+	bucketSvc := mock.NewBucketService()
+	bucketSvc.FindBucketByNameFn = func(ctx context.Context, orgID platform.ID, name string) (*influxdb.Bucket, error) {
+		if b, ok := buckets[name]; ok {
+			return b, nil
+		}
+		return nil, &errors.Error{Code: errors.ENotFound, Msg: "bucket not found"}
+	}
+	bucketSvc.FindBucketByIDFn = func(ctx context.Context, id platform.ID) (*influxdb.Bucket, error) {
+		for _, b := range buckets {
+			if b.ID == id {
+				return b, nil
+			}
+		}
+		return nil, &errors.Error{Code: errors.ENotFound, Msg: "bucket not found"}
+	}
+	bucketSvc.CreateBucketFn = func(ctx context.Context, b *influxdb.Bucket) error {
+		b.ID = platform.ID(100 + len(buckets))
+		buckets[b.Name] = b
+		return nil
+	}
+	dbrpSvc := dbrp.NewService(ctx, bucketSvc, store)
+
+	settings := dbrp.NewAutoCreateSettingsStore(store)
+	c := dbrp.NewAutoCreator(zaptest.NewLogger(t), dbrp.AutoCreateSettings{}, settings, bucketSvc, dbrpSvc)
+
+	writer := mock.NewMockAuthorizer(false, []influxdb.Permission{{
+		Action:   influxdb.WriteAction,
+		Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, OrgID: &orgID},
+	}})
+
+	// auto-creation is disabled by default.
+	_, err := c.AutoCreate(ctx, writer, orgID, "telegraf", "")
+	assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+
+	require.NoError(t, settings.PutAutoCreateSettings(ctx, orgID, dbrp.AutoCreateSettings{Enabled: true, RetentionPeriod: time.Hour}))
+
+	// writers which may only write to a bucket may not create databases.
+	bucketID := platform.ID(10)
+	bucketWriter := mock.NewMockAuthorizer(false, []influxdb.Permission{{
+		Action:   influxdb.WriteAction,
+		Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, OrgID: &orgID, ID: &bucketID},
+	}})
+	_, err = c.AutoCreate(ctx, bucketWriter, orgID, "telegraf", "")
+	assert.Equal(t, errors.EForbidden, errors.ErrorCode(err))
+
+	b, err := c.AutoCreate(ctx, writer, orgID, "telegraf", "")
+	require.NoError(t, err)
+	assert.Equal(t, "telegraf/autogen", b.Name)
+	assert.Equal(t, "autogen", b.RetentionPolicyName)
+	assert.Equal(t, time.Hour, b.RetentionPeriod)
+
+	b2, err := c.AutoCreate(ctx, writer, orgID, "telegraf", "short")
+	require.NoError(t, err)
+	assert.Equal(t, "telegraf/short", b2.Name)
+
+	db := "telegraf"
+	mappings, _, err := dbrpSvc.FindMany(ctx, influxdb.DBRPMappingFilter{OrgID: &orgID, Database: &db})
+	require.NoError(t, err)
+	require.Len(t, mappings, 2)
+	for _, m := range mappings {
+		switch m.RetentionPolicy {
+		case "autogen":
+			assert.Equal(t, b.ID, m.BucketID)
+			assert.True(t, m.Default, "the first mapping of a database is its default")
+		case "short":
+			assert.Equal(t, b2.ID, m.BucketID)
+			assert.False(t, m.Default)
+		default:
+			t.Errorf("unexpected mapping of retention policy %q", m.RetentionPolicy)
+		}
+	}
+
+	// auto-creating an existing database is a no-op.
+	b3, err := c.AutoCreate(ctx, writer, orgID, "telegraf", "autogen")
+	require.NoError(t, err)
+	assert.Equal(t, b.ID, b3.ID)
+	assert.Len(t, buckets, 2)
+}
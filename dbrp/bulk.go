@@ -0,0 +1,108 @@
+package dbrp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// ConflictPolicy is how an import handles the mappings whose database and
+// retention policy are already mapped in the organization.
+type ConflictPolicy string
+
+const (
+	// ConflictSkip keeps the existing mappings.
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictOverwrite replaces the existing mappings.
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictFail imports nothing if any mapping conflicts.
+	ConflictFail ConflictPolicy = "fail"
+)
+
+// Valid returns an error if the policy is unknown.
+func (p ConflictPolicy) Valid() error {
+	switch p {
+	case ConflictSkip, ConflictOverwrite, ConflictFail:
+		return nil
+	}
+	return &errors.Error{
+		Code: errors.EInvalid,
+		Msg:  fmt.Sprintf("invalid conflict policy %q, must be one of skip, overwrite or fail", p),
+	}
+}
+
+// ImportResult counts the mappings of an import by what was done to them.
+type ImportResult struct {
+	Created     int `json:"created"`
+	Overwritten int `json:"overwritten"`
+	Skipped     int `json:"skipped"`
+}
+
+// Export returns all the mappings of an organization, except the virtual
+// ones, which are derived from the names of the buckets.
+func Export(ctx context.Context, svc influxdb.DBRPMappingService, orgID platform.ID) ([]*influxdb.DBRPMapping, error) {
+	virtual := false
+	mappings, _, err := svc.FindMany(ctx, influxdb.DBRPMappingFilter{OrgID: &orgID, Virtual: &virtual})
+	return mappings, err
+}
+
+// Import creates mappings in an organization, ignoring their IDs and
+// organizations. The mappings are validated before any is created, so an
+// invalid mapping, or a conflicting one with ConflictFail, imports nothing.
+func Import(ctx context.Context, svc influxdb.DBRPMappingService, orgID platform.ID, mappings []*influxdb.DBRPMapping, policy ConflictPolicy) (ImportResult, error) {
+	var res ImportResult
+	if err := policy.Valid(); err != nil {
+		return res, err
+	}
+
+	existing, err := Export(ctx, svc, orgID)
+	if err != nil {
+		return res, err
+	}
+	byName := make(map[string]*influxdb.DBRPMapping, len(existing))
+	for _, m := range existing {
+		byName[m.Database+"/"+m.RetentionPolicy] = m
+	}
+
+	imported := make(map[string]bool, len(mappings))
+	for _, m := range mappings {
+		m.ID = 0
+		m.OrganizationID = orgID
+		if err := m.Validate(); err != nil {
+			return res, ErrInvalidDBRP(err)
+		}
+		key := m.Database + "/" + m.RetentionPolicy
+		if imported[key] {
+			return res, ErrDBRPAlreadyExists(fmt.Sprintf("database %q and retention policy %q are imported more than once", m.Database, m.RetentionPolicy))
+		}
+		imported[key] = true
+		if _, ok := byName[key]; ok && policy == ConflictFail {
+			return res, ErrDBRPAlreadyExists(fmt.Sprintf("database %q and retention policy %q are already mapped", m.Database, m.RetentionPolicy))
+		}
+	}
+
+	for _, m := range mappings {
+		old, ok := byName[m.Database+"/"+m.RetentionPolicy]
+		if ok && policy == ConflictSkip {
+			res.Skipped++
+			continue
+		}
+		if ok {
+			if err := svc.Delete(ctx, orgID, old.ID); err != nil {
+				return res, err
+			}
+		}
+		if err := svc.Create(ctx, m); err != nil {
+			return res, err
+		}
+		if ok {
+			res.Overwritten++
+		} else {
+			res.Created++
+		}
+	}
+	return res, nil
+}
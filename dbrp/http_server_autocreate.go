@@ -0,0 +1,160 @@
+package dbrp
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const PrefixDBRPAutoCreate = "/api/v2/dbrp-auto-create"
+
+// AutoCreateHandler is the handler for the auto-create settings of organizations.
+type AutoCreateHandler struct {
+	chi.Router
+
+	api *kithttp.API
+	log *zap.Logger
+
+	settingsSvc AutoCreateSettingsService
+}
+
+// NewAutoCreateHandler returns a new instance of AutoCreateHandler.
+func NewAutoCreateHandler(log *zap.Logger, settingsSvc AutoCreateSettingsService) *AutoCreateHandler {
+	h := &AutoCreateHandler{
+		log:         log,
+		api:         kithttp.NewAPI(kithttp.WithLog(log)),
+		settingsSvc: settingsSvc,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+	r.Route("/{orgID}", func(r chi.Router) {
+		r.Get("/", h.handleGetSettings)
+		r.Put("/", h.handlePutSettings)
+		r.Delete("/", h.handleDeleteSettings)
+	})
+	h.Router = r
+	return h
+}
+
+// Prefix returns the mounting prefix for the handler.
+func (h *AutoCreateHandler) Prefix() string {
+	return PrefixDBRPAutoCreate
+}
+
+// autoCreateBody is the auto-create settings with the retention period as a
+// string, such as 72h.
+type autoCreateBody struct {
+	Enabled         bool   `json:"enabled"`
+	RetentionPeriod string `json:"retentionPeriod,omitempty"`
+}
+
+func newAutoCreateBody(s AutoCreateSettings) autoCreateBody {
+	b := autoCreateBody{Enabled: s.Enabled}
+	if s.RetentionPeriod != 0 {
+		b.RetentionPeriod = s.RetentionPeriod.String()
+	}
+	return b
+}
+
+func (b autoCreateBody) toSettings() (AutoCreateSettings, error) {
+	s := AutoCreateSettings{Enabled: b.Enabled}
+	if b.RetentionPeriod != "" {
+		d, err := time.ParseDuration(b.RetentionPeriod)
+		if err != nil {
+			return AutoCreateSettings{}, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "invalid retentionPeriod",
+				Err:  err,
+			}
+		}
+		s.RetentionPeriod = d
+	}
+	return s, s.Valid()
+}
+
+func decodeOrgIDParam(r *http.Request) (platform.ID, error) {
+	var id platform.ID
+	if err := id.DecodeFromString(chi.URLParam(r, "orgID")); err != nil {
+		return 0, ErrInvalidOrgID(chi.URLParam(r, "orgID"), err)
+	}
+	return id, nil
+}
+
+// handleGetSettings is the HTTP handler for the GET /api/v2/dbrp-auto-create/:orgID route.
+func (h *AutoCreateHandler) handleGetSettings(w http.ResponseWriter, r *http.Request) {
+	orgID, err := decodeOrgIDParam(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	s, err := h.settingsSvc.FindAutoCreateSettings(r.Context(), orgID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if s == nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  "organization has no dbrp auto-create settings",
+		})
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusOK, newAutoCreateBody(*s))
+}
+
+// handlePutSettings is the HTTP handler for the PUT /api/v2/dbrp-auto-create/:orgID route.
+func (h *AutoCreateHandler) handlePutSettings(w http.ResponseWriter, r *http.Request) {
+	orgID, err := decodeOrgIDParam(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var body autoCreateBody
+	if err := h.api.DecodeJSON(r.Body, &body); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	s, err := body.toSettings()
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.settingsSvc.PutAutoCreateSettings(r.Context(), orgID, s); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("DBRP auto-create settings updated", zap.String("orgID", orgID.String()))
+
+	h.api.Respond(w, r, http.StatusOK, newAutoCreateBody(s))
+}
+
+// handleDeleteSettings is the HTTP handler for the DELETE /api/v2/dbrp-auto-create/:orgID route.
+func (h *AutoCreateHandler) handleDeleteSettings(w http.ResponseWriter, r *http.Request) {
+	orgID, err := decodeOrgIDParam(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.settingsSvc.DeleteAutoCreateSettings(r.Context(), orgID); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusNoContent, nil)
+}
@@ -45,6 +45,8 @@ func NewHTTPHandler(log *zap.Logger, dbrpSvc influxdb.DBRPMappingService, orgSvc
 	r.Route("/", func(r chi.Router) {
 		r.Post("/", h.handlePostDBRP)
 		r.Get("/", h.handleGetDBRPs)
+		r.Get("/export", h.handleExportDBRPs)
+		r.Post("/import", h.handleImportDBRPs)
 
 		r.Route("/{id}", func(r chi.Router) {
 			r.Get("/", h.handleGetDBRP)
@@ -148,6 +150,56 @@ func (h *Handler) handleGetDBRPs(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleExportDBRPs responds with all the mappings of an organization, in the
+// body the import endpoint accepts.
+func (h *Handler) handleExportDBRPs(w http.ResponseWriter, r *http.Request) {
+	orgID, err := h.mustGetOrgIDFromHTTPRequest(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	dbrps, err := Export(r.Context(), h.dbrpSvc, *orgID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusOK, getDBRPsResponse{
+		Content: dbrps,
+	})
+}
+
+// handleImportDBRPs creates the mappings of the body in an organization. The
+// onConflict parameter is the ConflictPolicy, fail by default.
+func (h *Handler) handleImportDBRPs(w http.ResponseWriter, r *http.Request) {
+	orgID, err := h.mustGetOrgIDFromHTTPRequest(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	policy := ConflictFail
+	if raw := r.URL.Query().Get("onConflict"); raw != "" {
+		policy = ConflictPolicy(raw)
+	}
+
+	var req getDBRPsResponse
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "invalid json structure",
+			Err:  err,
+		})
+		return
+	}
+
+	res, err := Import(r.Context(), h.dbrpSvc, *orgID, req.Content, policy)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, res)
+}
+
 type getDBRPResponse struct {
 	Content *influxdb.DBRPMapping `json:"content"`
 }
@@ -540,3 +540,75 @@ func Test_handleDeleteDBRP(t *testing.T) {
 		})
 	}
 }
+
+func Test_handleImportExportDBRPs(t *testing.T) {
+	ctx := context.Background()
+	svc, server, shutdown := initHttpService(t)
+	defer shutdown()
+	client := server.Client()
+	orgID := influxdbtesting.MustIDBase16("059af7ed2a034000")
+
+	existing := &influxdb.DBRPMapping{
+		BucketID:        influxdbtesting.MustIDBase16("5555f7ed2a035555"),
+		OrganizationID:  orgID,
+		Database:        "mydb",
+		RetentionPolicy: "autogen",
+		Default:         true,
+	}
+	if err := svc.Create(ctx, existing); err != nil {
+		t.Fatal(err)
+	}
+
+	importDBRPs := func(policy string) (*http.Response, dbrp.ImportResult) {
+		t.Helper()
+		body := `{"content": [
+	{"database": "mydb", "retention_policy": "autogen", "default": true, "bucketID": "6666f7ed2a036666"},
+	{"database": "mydb", "retention_policy": "downsampled", "bucketID": "7777f7ed2a037777"}
+]}`
+		resp, err := client.Post(server.URL+"/import?org=org&onConflict="+policy, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var res dbrp.ImportResult
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return resp, res
+	}
+
+	resp, _ := importDBRPs("fail")
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp, _ = importDBRPs("invalid")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, res := importDBRPs("skip")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, dbrp.ImportResult{Created: 1, Skipped: 1}, res)
+
+	resp, res = importDBRPs("overwrite")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, dbrp.ImportResult{Overwritten: 2}, res)
+
+	resp, err := client.Get(server.URL + "/export?orgID=059af7ed2a034000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var exported struct {
+		Content []*influxdb.DBRPMapping `json:"content"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&exported); err != nil {
+		t.Fatal(err)
+	}
+	buckets := map[string]platform.ID{}
+	for _, m := range exported.Content {
+		buckets[m.RetentionPolicy] = m.BucketID
+	}
+	assert.Equal(t, map[string]platform.ID{
+		"autogen":     influxdbtesting.MustIDBase16("6666f7ed2a036666"),
+		"downsampled": influxdbtesting.MustIDBase16("7777f7ed2a037777"),
+	}, buckets)
+}
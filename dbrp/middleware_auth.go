@@ -61,3 +61,40 @@ func (svc AuthorizedService) Delete(ctx context.Context, orgID, id platform.ID)
 	}
 	return svc.DBRPMappingService.Delete(ctx, orgID, id)
 }
+
+var _ AutoCreateSettingsService = (*AuthorizedAutoCreateSettingsService)(nil)
+
+// AuthorizedAutoCreateSettingsService wraps an AutoCreateSettingsService and
+// authorizes actions against it appropriately.
+type AuthorizedAutoCreateSettingsService struct {
+	s AutoCreateSettingsService
+}
+
+// NewAuthorizedAutoCreateSettingsService constructs an instance of an authorizing auto-create settings service.
+func NewAuthorizedAutoCreateSettingsService(s AutoCreateSettingsService) *AuthorizedAutoCreateSettingsService {
+	return &AuthorizedAutoCreateSettingsService{s: s}
+}
+
+// FindAutoCreateSettings checks to see if the authorizer on context has read access to the organization.
+func (svc *AuthorizedAutoCreateSettingsService) FindAutoCreateSettings(ctx context.Context, orgID platform.ID) (*AutoCreateSettings, error) {
+	if _, _, err := authorizer.AuthorizeReadOrg(ctx, orgID); err != nil {
+		return nil, err
+	}
+	return svc.s.FindAutoCreateSettings(ctx, orgID)
+}
+
+// PutAutoCreateSettings checks to see if the authorizer on context has write access to the organization.
+func (svc *AuthorizedAutoCreateSettingsService) PutAutoCreateSettings(ctx context.Context, orgID platform.ID, s AutoCreateSettings) error {
+	if _, _, err := authorizer.AuthorizeWriteOrg(ctx, orgID); err != nil {
+		return err
+	}
+	return svc.s.PutAutoCreateSettings(ctx, orgID, s)
+}
+
+// DeleteAutoCreateSettings checks to see if the authorizer on context has write access to the organization.
+func (svc *AuthorizedAutoCreateSettingsService) DeleteAutoCreateSettings(ctx context.Context, orgID platform.ID) error {
+	if _, _, err := authorizer.AuthorizeWriteOrg(ctx, orgID); err != nil {
+		return err
+	}
+	return svc.s.DeleteAutoCreateSettings(ctx, orgID)
+}
@@ -2,6 +2,7 @@ package influxdb
 
 import (
 	"context"
+	"time"
 
 	"github.com/influxdata/influxql"
 
@@ -19,3 +20,66 @@ type Predicate interface {
 type DeleteService interface {
 	DeleteBucketRangePredicate(ctx context.Context, orgID, bucketID platform.ID, min, max int64, pred Predicate, measurement influxql.Expr) error
 }
+
+// DeleteJobStatus is the status of a delete job.
+type DeleteJobStatus string
+
+const (
+	DeleteJobQueued    DeleteJobStatus = "queued"
+	DeleteJobRunning   DeleteJobStatus = "running"
+	DeleteJobSucceeded DeleteJobStatus = "succeeded"
+	DeleteJobFailed    DeleteJobStatus = "failed"
+	DeleteJobCanceled  DeleteJobStatus = "canceled"
+)
+
+// Done returns whether a job with the status has finished running.
+func (s DeleteJobStatus) Done() bool {
+	return s == DeleteJobSucceeded || s == DeleteJobFailed || s == DeleteJobCanceled
+}
+
+// DeleteJob is a delete of the points of a bucket in a time range matching a
+// predicate, run in the background.
+type DeleteJob struct {
+	ID        platform.ID     `json:"id"`
+	OrgID     platform.ID     `json:"orgID"`
+	BucketID  platform.ID     `json:"bucketID"`
+	Start     time.Time       `json:"start"`
+	Stop      time.Time       `json:"stop"`
+	Predicate string          `json:"predicate,omitempty"`
+	Status    DeleteJobStatus `json:"status"`
+	// PercentComplete is the percentage of the time range deleted so far.
+	PercentComplete float64 `json:"percentComplete"`
+	PointsRemoved   int64   `json:"pointsRemoved"`
+	Error           string  `json:"error,omitempty"`
+
+	CreatedAt  time.Time  `json:"createdAt"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// DeleteJobRequest is the delete a delete job is created to run.
+type DeleteJobRequest struct {
+	OrgID    platform.ID
+	BucketID platform.ID
+	// Start and Stop are the inclusive time range of the delete, in
+	// nanoseconds.
+	Start int64
+	Stop  int64
+	// Predicate is the delete predicate, from which Pred and Measurement are
+	// parsed.
+	Predicate   string
+	Pred        Predicate
+	Measurement influxql.Expr
+}
+
+// DeleteJobService runs delete jobs.
+type DeleteJobService interface {
+	// CreateDeleteJob queues a delete job.
+	CreateDeleteJob(ctx context.Context, req DeleteJobRequest) (*DeleteJob, error)
+	FindDeleteJobByID(ctx context.Context, id platform.ID) (*DeleteJob, error)
+	// FindDeleteJobs returns the delete jobs of an organization, most recent first.
+	FindDeleteJobs(ctx context.Context, orgID platform.ID) ([]*DeleteJob, error)
+	// CancelDeleteJob cancels a queued or running delete job. The points a
+	// running job has already deleted are not restored.
+	CancelDeleteJob(ctx context.Context, id platform.ID) (*DeleteJob, error)
+}
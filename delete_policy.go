@@ -0,0 +1,147 @@
+package influxdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// DeletePolicyTaskType is the type of the tasks which run delete policies.
+const DeletePolicyTaskType = "deletePolicy"
+
+// ErrDeletePolicyNotFound is the error msg for a missing delete policy.
+const ErrDeletePolicyNotFound = "delete policy not found"
+
+// DeletePolicyService manages the delete policies of buckets.
+type DeletePolicyService interface {
+	// FindDeletePolicyByID returns a single delete policy by ID.
+	FindDeletePolicyByID(ctx context.Context, id platform.ID) (*DeletePolicy, error)
+
+	// FindDeletePolicies returns the delete policies matching filter.
+	FindDeletePolicies(ctx context.Context, filter DeletePolicyFilter) ([]*DeletePolicy, error)
+
+	// CreateDeletePolicy creates a new delete policy and sets p.ID with the new identifier.
+	CreateDeletePolicy(ctx context.Context, p *DeletePolicy) error
+
+	// UpdateDeletePolicy updates a single delete policy with changeset.
+	UpdateDeletePolicy(ctx context.Context, id platform.ID, upd DeletePolicyUpdate) (*DeletePolicy, error)
+
+	// DeleteDeletePolicy removes a delete policy by ID.
+	DeleteDeletePolicy(ctx context.Context, id platform.ID) error
+}
+
+// DeletePolicy is a delete of the points of a bucket matching a predicate,
+// run on a schedule by a task. The ID of a delete policy is that of its task,
+// whose runs are the history of the policy.
+type DeletePolicy struct {
+	ID             platform.ID `json:"id,omitempty"`
+	OrganizationID platform.ID `json:"orgID"`
+	BucketID       platform.ID `json:"bucketID"`
+	OwnerID        platform.ID `json:"ownerID,omitempty"`
+	Name           string      `json:"name"`
+	Description    string      `json:"description"`
+	Predicate      string      `json:"predicate"`
+	Status         Status      `json:"status"`
+	// Every or Cron is the schedule of the policy.
+	Every Duration `json:"every"`
+	Cron  string   `json:"cron,omitempty"`
+	// OlderThan is the age, as of a run, of the points the run deletes. All
+	// the points matching the predicate are deleted if it is zero.
+	OlderThan Duration `json:"olderThan"`
+
+	LatestCompleted time.Time `json:"latestCompleted,omitempty"`
+	LastRunStatus   string    `json:"lastRunStatus,omitempty"`
+	LastRunError    string    `json:"lastRunError,omitempty"`
+}
+
+// Valid returns an error if the delete policy is invalid. The predicate is
+// not parsed.
+func (p *DeletePolicy) Valid() error {
+	if !p.OrganizationID.Valid() {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "delete policy orgID is required",
+		}
+	}
+	if !p.BucketID.Valid() {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "delete policy bucketID is required",
+		}
+	}
+	if p.Name == "" {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "delete policy name is required",
+		}
+	}
+	if err := p.Status.Valid(); err != nil {
+		return err
+	}
+	if (p.Every.Duration == 0) == (p.Cron == "") {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "delete policy must have exactly one of every or cron",
+		}
+	}
+	if p.Every.Duration < 0 || p.Every.Duration%time.Second != 0 {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "delete policy every must be a positive number of seconds",
+		}
+	}
+	if p.OlderThan.Duration < 0 {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "delete policy olderThan must not be negative",
+		}
+	}
+	return nil
+}
+
+// DeletePolicyFilter represents a set of filters that restrict the returned delete policies.
+type DeletePolicyFilter struct {
+	OrganizationID platform.ID
+	BucketID       *platform.ID
+}
+
+// DeletePolicyUpdate is the patch structure for a delete policy.
+type DeletePolicyUpdate struct {
+	Name        *string   `json:"name"`
+	Description *string   `json:"description"`
+	Predicate   *string   `json:"predicate"`
+	Status      *Status   `json:"status"`
+	Every       *Duration `json:"every"`
+	Cron        *string   `json:"cron"`
+	OlderThan   *Duration `json:"olderThan"`
+}
+
+// Apply applies an update to a delete policy. Setting either of every and
+// cron clears the other.
+func (u DeletePolicyUpdate) Apply(p *DeletePolicy) {
+	if u.Name != nil {
+		p.Name = *u.Name
+	}
+	if u.Description != nil {
+		p.Description = *u.Description
+	}
+	if u.Predicate != nil {
+		p.Predicate = *u.Predicate
+	}
+	if u.Status != nil {
+		p.Status = *u.Status
+	}
+	if u.Every != nil {
+		p.Every = *u.Every
+		p.Cron = ""
+	}
+	if u.Cron != nil {
+		p.Cron = *u.Cron
+		p.Every = Duration{}
+	}
+	if u.OlderThan != nil {
+		p.OlderThan = *u.OlderThan
+	}
+}
@@ -0,0 +1,66 @@
+package deletejob
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/predicate"
+	"github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// Counter counts the series and points of a bucket in a time range matching
+// a delete predicate.
+type Counter interface {
+	// Count counts the series with points in the inclusive time range
+	// [start, stop], and their points.
+	Count(ctx context.Context, orgID, bucketID platform.ID, start, stop int64, pred string) (series, points int64, err error)
+}
+
+var _ Counter = (*StoreCounter)(nil)
+
+// StoreCounter counts the series and points read from a storage store.
+type StoreCounter struct {
+	store reads.Store
+}
+
+// NewStoreCounter constructs a StoreCounter.
+func NewStoreCounter(store reads.Store) *StoreCounter {
+	return &StoreCounter{store: store}
+}
+
+// Count reads the series and points matching the predicate and counts them.
+func (c *StoreCounter) Count(ctx context.Context, orgID, bucketID platform.ID, start, stop int64, pred string) (int64, int64, error) {
+	src, err := anypb.New(c.store.GetSource(uint64(orgID), uint64(bucketID)))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	req := datatypes.ReadFilterRequest{
+		ReadSource: src,
+		// The range of a read is [start, end), while that of a delete is
+		// [start, stop].
+		Range: &datatypes.TimestampRange{Start: start, End: stop + 1},
+	}
+	node, err := predicate.Parse(pred)
+	if err != nil {
+		return 0, 0, err
+	}
+	if node != nil {
+		root, err := node.ToDataType()
+		if err != nil {
+			return 0, 0, err
+		}
+		req.Predicate = &datatypes.Predicate{Root: root}
+	}
+
+	rs, err := c.store.ReadFilter(ctx, &req)
+	if err != nil {
+		return 0, 0, err
+	}
+	if rs == nil {
+		return 0, 0, nil
+	}
+	return reads.CountResultSet(rs)
+}
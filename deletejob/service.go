@@ -0,0 +1,278 @@
+// Package deletejob runs the deletes of the delete API in the background, as
+// jobs whose progress is tracked and which may be canceled.
+package deletejob
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/snowflake"
+	"go.uber.org/zap"
+)
+
+const (
+	// slices is the number of slices the time range of a job is deleted in.
+	// The progress of a job is the slices deleted, and a job is canceled
+	// between slices.
+	slices = 100
+
+	// jobRetention is how long finished jobs are kept.
+	jobRetention = 24 * time.Hour
+)
+
+// ErrDeleteJobNotFound is returned when a delete job does not exist.
+var ErrDeleteJobNotFound = &errors.Error{
+	Code: errors.ENotFound,
+	Msg:  "delete job not found",
+}
+
+var _ influxdb.DeleteJobService = (*Service)(nil)
+
+type job struct {
+	influxdb.DeleteJob
+	req influxdb.DeleteJobRequest
+	// cancel cancels the job while it runs.
+	cancel context.CancelFunc
+}
+
+// Service runs delete jobs one at a time. The jobs are kept in memory, so the
+// jobs queued or running when the process stops are lost, and the deletes of
+// the running ones are left partially done.
+type Service struct {
+	log     *zap.Logger
+	deletes influxdb.DeleteService
+	counter Counter
+
+	IDGenerator   platform.IDGenerator
+	TimeGenerator influxdb.TimeGenerator
+
+	mu     sync.Mutex
+	jobs   map[platform.ID]*job
+	queue  []*job
+	notify chan struct{}
+}
+
+// NewService constructs a Service. The points of each slice of a job are
+// counted with counter before the slice is deleted.
+func NewService(log *zap.Logger, deletes influxdb.DeleteService, counter Counter) *Service {
+	return &Service{
+		log:           log,
+		deletes:       deletes,
+		counter:       counter,
+		IDGenerator:   snowflake.NewIDGenerator(),
+		TimeGenerator: influxdb.RealTimeGenerator{},
+		jobs:          make(map[platform.ID]*job),
+		notify:        make(chan struct{}, 1),
+	}
+}
+
+// CreateDeleteJob queues a delete job.
+func (s *Service) CreateDeleteJob(ctx context.Context, req influxdb.DeleteJobRequest) (*influxdb.DeleteJob, error) {
+	if req.Start > req.Stop {
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "invalid time range, start time must not be after stop time",
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.TimeGenerator.Now()
+	s.prune(now)
+
+	j := &job{
+		DeleteJob: influxdb.DeleteJob{
+			ID:        s.IDGenerator.ID(),
+			OrgID:     req.OrgID,
+			BucketID:  req.BucketID,
+			Start:     time.Unix(0, req.Start).UTC(),
+			Stop:      time.Unix(0, req.Stop).UTC(),
+			Predicate: req.Predicate,
+			Status:    influxdb.DeleteJobQueued,
+			CreatedAt: now,
+		},
+		req: req,
+	}
+	s.jobs[j.ID] = j
+	s.queue = append(s.queue, j)
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+
+	res := j.DeleteJob
+	return &res, nil
+}
+
+// FindDeleteJobByID returns a delete job.
+func (s *Service) FindDeleteJobByID(ctx context.Context, id platform.ID) (*influxdb.DeleteJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrDeleteJobNotFound
+	}
+	res := j.DeleteJob
+	return &res, nil
+}
+
+// FindDeleteJobs returns the delete jobs of an organization, most recent first.
+func (s *Service) FindDeleteJobs(ctx context.Context, orgID platform.ID) ([]*influxdb.DeleteJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := []*influxdb.DeleteJob{}
+	for _, j := range s.jobs {
+		if j.OrgID == orgID {
+			res := j.DeleteJob
+			jobs = append(jobs, &res)
+		}
+	}
+	sort.Slice(jobs, func(i, k int) bool {
+		return jobs[i].CreatedAt.After(jobs[k].CreatedAt)
+	})
+	return jobs, nil
+}
+
+// CancelDeleteJob cancels a queued or running delete job. A running job stops
+// once the slice being deleted is.
+func (s *Service) CancelDeleteJob(ctx context.Context, id platform.ID) (*influxdb.DeleteJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrDeleteJobNotFound
+	}
+	if j.Status.Done() {
+		return nil, &errors.Error{
+			Code: errors.EConflict,
+			Msg:  "delete job has already finished",
+		}
+	}
+
+	if j.Status == influxdb.DeleteJobQueued {
+		now := s.TimeGenerator.Now()
+		j.FinishedAt = &now
+	} else {
+		j.cancel()
+	}
+	j.Status = influxdb.DeleteJobCanceled
+
+	res := j.DeleteJob
+	return &res, nil
+}
+
+// Run runs the queued jobs until ctx is done.
+func (s *Service) Run(ctx context.Context) {
+	for {
+		s.mu.Lock()
+		var next *job
+		if len(s.queue) > 0 {
+			next, s.queue = s.queue[0], s.queue[1:]
+		}
+		s.mu.Unlock()
+
+		if next != nil {
+			s.run(ctx, next)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.notify:
+		}
+	}
+}
+
+func (s *Service) run(ctx context.Context, j *job) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s.mu.Lock()
+	if j.Status != influxdb.DeleteJobQueued {
+		// The job was canceled while queued.
+		s.mu.Unlock()
+		return
+	}
+	now := s.TimeGenerator.Now()
+	j.Status = influxdb.DeleteJobRunning
+	j.StartedAt = &now
+	j.cancel = cancel
+	s.mu.Unlock()
+
+	err := s.delete(ctx, j)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now = s.TimeGenerator.Now()
+	j.FinishedAt = &now
+	switch {
+	case j.Status == influxdb.DeleteJobCanceled:
+	case err != nil:
+		j.Status = influxdb.DeleteJobFailed
+		j.Error = err.Error()
+	default:
+		j.Status = influxdb.DeleteJobSucceeded
+	}
+	s.log.Info("Delete job finished",
+		zap.Stringer("job_id", j.ID),
+		zap.Stringer("org_id", j.OrgID),
+		zap.Stringer("bucket_id", j.BucketID),
+		zap.String("status", string(j.Status)),
+		zap.Int64("points_removed", j.PointsRemoved),
+		zap.Error(err))
+}
+
+// delete deletes the time range of a job slice by slice, counting the points
+// of each before deleting it.
+func (s *Service) delete(ctx context.Context, j *job) error {
+	// The offsets are unsigned as the time range may be wider than an int64.
+	span := uint64(j.req.Stop) - uint64(j.req.Start)
+	step := span/slices + 1
+	for off := uint64(0); ; off += step {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		end := off + step - 1
+		if end > span || end < off {
+			end = span
+		}
+		start, stop := int64(uint64(j.req.Start)+off), int64(uint64(j.req.Start)+end)
+
+		_, points, err := s.counter.Count(ctx, j.OrgID, j.BucketID, start, stop, j.req.Predicate)
+		if err != nil {
+			return err
+		}
+		if err := s.deletes.DeleteBucketRangePredicate(ctx, j.OrgID, j.BucketID, start, stop, j.req.Pred, j.req.Measurement); err != nil {
+			return err
+		}
+
+		s.mu.Lock()
+		j.PointsRemoved += points
+		j.PercentComplete = 100 * (float64(end) + 1) / (float64(span) + 1)
+		s.mu.Unlock()
+
+		if end == span {
+			return nil
+		}
+	}
+}
+
+// prune removes the jobs which finished longer ago than the retention.
+func (s *Service) prune(now time.Time) {
+	for id, j := range s.jobs {
+		if j.FinishedAt != nil && now.Sub(*j.FinishedAt) > jobRetention {
+			delete(s.jobs, id)
+		}
+	}
+}
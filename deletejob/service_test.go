@@ -0,0 +1,175 @@
+package deletejob
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxql"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type counterFunc func(start, stop int64) (int64, int64, error)
+
+func (f counterFunc) Count(ctx context.Context, orgID, bucketID platform.ID, start, stop int64, pred string) (int64, int64, error) {
+	return f(start, stop)
+}
+
+// ranges records the ranges deleted.
+type ranges struct {
+	mu sync.Mutex
+	rs [][2]int64
+}
+
+func (r *ranges) deleter(err error) mock.DeleteService {
+	return mock.DeleteService{
+		DeleteBucketRangePredicateF: func(ctx context.Context, orgID, bucketID platform.ID, min, max int64, pred influxdb.Predicate, measurement influxql.Expr) error {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.rs = append(r.rs, [2]int64{min, max})
+			return err
+		},
+	}
+}
+
+func newTestService(t *testing.T, deletes influxdb.DeleteService, counter Counter) *Service {
+	svc := NewService(zaptest.NewLogger(t), deletes, counter)
+	svc.IDGenerator = mock.NewIncrementingIDGenerator(1)
+	svc.TimeGenerator = mock.TimeGenerator{FakeValue: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	return svc
+}
+
+func waitDone(t *testing.T, svc *Service, id platform.ID) *influxdb.DeleteJob {
+	t.Helper()
+	var job *influxdb.DeleteJob
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = svc.FindDeleteJobByID(context.Background(), id)
+		require.NoError(t, err)
+		return job.Status.Done()
+	}, 5*time.Second, time.Millisecond)
+	return job
+}
+
+func TestService_Run(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		start, stop int64
+	}{
+		{name: "narrow range", start: 10, stop: 20},
+		{name: "range", start: 1000, stop: 1000000},
+		{name: "full range", start: math.MinInt64, stop: math.MaxInt64},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var deleted ranges
+			svc := newTestService(t, deleted.deleter(nil), counterFunc(func(start, stop int64) (int64, int64, error) {
+				return 1, 2, nil
+			}))
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go svc.Run(ctx)
+
+			job, err := svc.CreateDeleteJob(ctx, influxdb.DeleteJobRequest{
+				OrgID:     1,
+				BucketID:  2,
+				Start:     tc.start,
+				Stop:      tc.stop,
+				Predicate: `_measurement="cpu"`,
+			})
+			require.NoError(t, err)
+			require.Equal(t, influxdb.DeleteJobQueued, job.Status)
+
+			job = waitDone(t, svc, job.ID)
+			require.Equal(t, influxdb.DeleteJobSucceeded, job.Status)
+			require.Equal(t, float64(100), job.PercentComplete)
+			require.NotNil(t, job.StartedAt)
+			require.NotNil(t, job.FinishedAt)
+
+			// The slices cover the range without gaps or overlaps.
+			require.LessOrEqual(t, len(deleted.rs), slices+1)
+			require.Equal(t, tc.start, deleted.rs[0][0])
+			for i := 1; i < len(deleted.rs); i++ {
+				require.Equal(t, deleted.rs[i-1][1]+1, deleted.rs[i][0])
+			}
+			require.Equal(t, tc.stop, deleted.rs[len(deleted.rs)-1][1])
+			require.Equal(t, int64(2*len(deleted.rs)), job.PointsRemoved)
+		})
+	}
+}
+
+func TestService_Failed(t *testing.T) {
+	var deleted ranges
+	svc := newTestService(t, deleted.deleter(errors.New("disk full")), counterFunc(func(start, stop int64) (int64, int64, error) {
+		return 0, 0, nil
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go svc.Run(ctx)
+
+	job, err := svc.CreateDeleteJob(ctx, influxdb.DeleteJobRequest{OrgID: 1, BucketID: 2, Start: 0, Stop: 1000})
+	require.NoError(t, err)
+
+	job = waitDone(t, svc, job.ID)
+	require.Equal(t, influxdb.DeleteJobFailed, job.Status)
+	require.Equal(t, "disk full", job.Error)
+	require.Len(t, deleted.rs, 1)
+}
+
+func TestService_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	var deleted ranges
+	svc := newTestService(t, deleted.deleter(nil), counterFunc(func(start, stop int64) (int64, int64, error) {
+		once.Do(func() { close(started) })
+		<-release
+		return 0, 1, nil
+	}))
+
+	running, err := svc.CreateDeleteJob(ctx, influxdb.DeleteJobRequest{OrgID: 1, BucketID: 2, Start: 0, Stop: 1000})
+	require.NoError(t, err)
+	queued, err := svc.CreateDeleteJob(ctx, influxdb.DeleteJobRequest{OrgID: 1, BucketID: 2, Start: 0, Stop: 1000})
+	require.NoError(t, err)
+
+	go svc.Run(ctx)
+	<-started
+
+	job, err := svc.CancelDeleteJob(ctx, queued.ID)
+	require.NoError(t, err)
+	require.Equal(t, influxdb.DeleteJobCanceled, job.Status)
+	require.NotNil(t, job.FinishedAt)
+
+	job, err = svc.CancelDeleteJob(ctx, running.ID)
+	require.NoError(t, err)
+	require.Equal(t, influxdb.DeleteJobCanceled, job.Status)
+	close(release)
+
+	job = waitDone(t, svc, running.ID)
+	require.Equal(t, influxdb.DeleteJobCanceled, job.Status)
+	require.NotNil(t, job.FinishedAt)
+	// The slice being deleted when canceled is the last one.
+	require.Len(t, deleted.rs, 1)
+	require.Equal(t, int64(1), job.PointsRemoved)
+
+	_, err = svc.CancelDeleteJob(ctx, running.ID)
+	require.Error(t, err)
+
+	jobs, err := svc.FindDeleteJobs(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	jobs, err = svc.FindDeleteJobs(ctx, 3)
+	require.NoError(t, err)
+	require.Empty(t, jobs)
+
+	_, err = svc.FindDeleteJobByID(ctx, 100)
+	require.Equal(t, ErrDeleteJobNotFound, err)
+}
@@ -0,0 +1,68 @@
+package deletepolicy
+
+import (
+	"context"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/predicate"
+	"github.com/influxdata/influxdb/v2/task/taskmodel"
+	"go.uber.org/zap"
+)
+
+// Runner runs the tasks of delete policies, deleting the points of their
+// bucket matching their predicate.
+type Runner struct {
+	log     *zap.Logger
+	deletes influxdb.DeleteService
+}
+
+// NewRunner constructs a Runner.
+func NewRunner(log *zap.Logger, deletes influxdb.DeleteService) *Runner {
+	return &Runner{log: log, deletes: deletes}
+}
+
+// Run runs the delete policy of a task for the time it is scheduled for. The
+// owner of the task, whose authorization is on the context, must still be
+// allowed to write to the bucket.
+func (r *Runner) Run(ctx context.Context, t *taskmodel.Task, scheduledFor time.Time) error {
+	p, err := FromTask(t)
+	if err != nil {
+		return err
+	}
+	if _, _, err := authorizer.AuthorizeWrite(ctx, influxdb.BucketsResourceType, p.BucketID, p.OrganizationID); err != nil {
+		return err
+	}
+
+	node, err := predicate.Parse(p.Predicate)
+	if err != nil {
+		return err
+	}
+	pred, err := predicate.New(node)
+	if err != nil {
+		return err
+	}
+	measurement, err := predicate.MeasurementExpr(p.Predicate)
+	if err != nil {
+		return err
+	}
+
+	stop := scheduledFor.Add(-p.OlderThan.Duration).UnixNano()
+	if p.OlderThan.Duration == 0 || stop > models.MaxNanoTime {
+		stop = models.MaxNanoTime
+	}
+	if stop < models.MinNanoTime {
+		return nil
+	}
+
+	if err := r.deletes.DeleteBucketRangePredicate(ctx, p.OrganizationID, p.BucketID, models.MinNanoTime, stop, pred, measurement); err != nil {
+		return err
+	}
+	r.log.Debug("Delete policy run",
+		zap.Stringer("policy_id", p.ID),
+		zap.Stringer("bucket_id", p.BucketID),
+		zap.Time("scheduled_for", scheduledFor))
+	return nil
+}
@@ -0,0 +1,254 @@
+// Package deletepolicy manages the delete policies of buckets, which run as
+// tasks of the task scheduler.
+package deletepolicy
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/predicate"
+	"github.com/influxdata/influxdb/v2/task/taskmodel"
+)
+
+// The metadata of the tasks of delete policies.
+const (
+	MetadataBucketID  = "deletePolicyBucketID"
+	MetadataPredicate = "deletePolicyPredicate"
+	MetadataOlderThan = "deletePolicyOlderThan"
+)
+
+var _ influxdb.DeletePolicyService = (*Service)(nil)
+
+// Service manages delete policies as tasks of type
+// influxdb.DeletePolicyTaskType, whose metadata holds the bucket and the
+// predicate of the policy, and whose options its schedule. The tasks are run
+// by a Runner rather than by querying their Flux.
+type Service struct {
+	tasks taskmodel.TaskService
+}
+
+// NewService constructs a Service. The tasks are created as the user
+// creating the delete policy, so their service should authorize the context.
+func NewService(tasks taskmodel.TaskService) *Service {
+	return &Service{tasks: tasks}
+}
+
+// FindDeletePolicyByID returns a single delete policy by ID.
+func (s *Service) FindDeletePolicyByID(ctx context.Context, id platform.ID) (*influxdb.DeletePolicy, error) {
+	t, err := s.tasks.FindTaskByID(ctx, id)
+	if err != nil {
+		if errors.ErrorCode(err) == errors.ENotFound {
+			return nil, errNotFound()
+		}
+		return nil, err
+	}
+	if t.Type != influxdb.DeletePolicyTaskType {
+		return nil, errNotFound()
+	}
+	return FromTask(t)
+}
+
+// FindDeletePolicies returns the delete policies of an organization, and of a
+// bucket if the filter sets one.
+func (s *Service) FindDeletePolicies(ctx context.Context, filter influxdb.DeletePolicyFilter) ([]*influxdb.DeletePolicy, error) {
+	taskType := influxdb.DeletePolicyTaskType
+	tf := taskmodel.TaskFilter{
+		Type:           &taskType,
+		OrganizationID: &filter.OrganizationID,
+		Limit:          taskmodel.TaskMaxPageSize,
+	}
+
+	ps := []*influxdb.DeletePolicy{}
+	for {
+		tasks, _, err := s.tasks.FindTasks(ctx, tf)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tasks {
+			p, err := FromTask(t)
+			if err != nil {
+				return nil, err
+			}
+			if filter.BucketID != nil && p.BucketID != *filter.BucketID {
+				continue
+			}
+			ps = append(ps, p)
+		}
+		if len(tasks) < tf.Limit {
+			break
+		}
+		tf.After = &tasks[len(tasks)-1].ID
+	}
+	return ps, nil
+}
+
+// CreateDeletePolicy creates the task of a delete policy, owned by the user
+// creating it.
+func (s *Service) CreateDeletePolicy(ctx context.Context, p *influxdb.DeletePolicy) error {
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		return err
+	}
+	if p.Status == "" {
+		p.Status = influxdb.Active
+	}
+	if err := valid(p); err != nil {
+		return err
+	}
+
+	t, err := s.tasks.CreateTask(ctx, taskmodel.TaskCreate{
+		Type:           influxdb.DeletePolicyTaskType,
+		Flux:           script(p),
+		Description:    p.Description,
+		Status:         string(p.Status),
+		OrganizationID: p.OrganizationID,
+		OwnerID:        auth.GetUserID(),
+		Metadata:       metadata(p),
+	})
+	if err != nil {
+		return err
+	}
+
+	created, err := FromTask(t)
+	if err != nil {
+		return err
+	}
+	*p = *created
+	return nil
+}
+
+// UpdateDeletePolicy updates the task of a delete policy.
+func (s *Service) UpdateDeletePolicy(ctx context.Context, id platform.ID, upd influxdb.DeletePolicyUpdate) (*influxdb.DeletePolicy, error) {
+	p, err := s.FindDeletePolicyByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	upd.Apply(p)
+	if err := valid(p); err != nil {
+		return nil, err
+	}
+
+	flux, status := script(p), string(p.Status)
+	t, err := s.tasks.UpdateTask(ctx, id, taskmodel.TaskUpdate{
+		Flux:        &flux,
+		Status:      &status,
+		Description: &p.Description,
+		Metadata:    metadata(p),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return FromTask(t)
+}
+
+// DeleteDeletePolicy deletes the task of a delete policy, and so its history.
+func (s *Service) DeleteDeletePolicy(ctx context.Context, id platform.ID) error {
+	if _, err := s.FindDeletePolicyByID(ctx, id); err != nil {
+		return err
+	}
+	return s.tasks.DeleteTask(ctx, id)
+}
+
+// FromTask returns the delete policy a task runs.
+func FromTask(t *taskmodel.Task) (*influxdb.DeletePolicy, error) {
+	bucket, _ := t.Metadata[MetadataBucketID].(string)
+	bucketID, err := platform.IDFromString(bucket)
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.EInternal,
+			Msg:  fmt.Sprintf("task %s is not a delete policy: invalid bucket ID %q", t.ID, bucket),
+			Err:  err,
+		}
+	}
+	pred, _ := t.Metadata[MetadataPredicate].(string)
+	olderThan, _ := t.Metadata[MetadataOlderThan].(string)
+
+	p := &influxdb.DeletePolicy{
+		ID:              t.ID,
+		OrganizationID:  t.OrganizationID,
+		BucketID:        *bucketID,
+		OwnerID:         t.OwnerID,
+		Name:            t.Name,
+		Description:     t.Description,
+		Predicate:       pred,
+		Status:          influxdb.Status(t.Status),
+		Cron:            t.Cron,
+		LatestCompleted: t.LatestCompleted,
+		LastRunStatus:   t.LastRunStatus,
+		LastRunError:    t.LastRunError,
+	}
+	if t.Every != "" {
+		// The schedule may have been changed to one Go cannot parse through
+		// the task, in which case the policy has no every.
+		p.Every.Duration, _ = time.ParseDuration(t.Every)
+	}
+	if olderThan != "" {
+		if p.OlderThan.Duration, err = time.ParseDuration(olderThan); err != nil {
+			return nil, &errors.Error{
+				Code: errors.EInternal,
+				Msg:  fmt.Sprintf("task %s is not a delete policy: invalid olderThan %q", t.ID, olderThan),
+				Err:  err,
+			}
+		}
+	}
+	return p, nil
+}
+
+func valid(p *influxdb.DeletePolicy) error {
+	if err := p.Valid(); err != nil {
+		return err
+	}
+	if _, err := predicate.Parse(p.Predicate); err != nil {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "invalid delete policy predicate",
+			Err:  err,
+		}
+	}
+	_, err := predicate.MeasurementExpr(p.Predicate)
+	return err
+}
+
+func metadata(p *influxdb.DeletePolicy) map[string]interface{} {
+	return map[string]interface{}{
+		MetadataBucketID:  p.BucketID.String(),
+		MetadataPredicate: p.Predicate,
+		MetadataOlderThan: p.OlderThan.String(),
+	}
+}
+
+// script returns the Flux of the task of a delete policy. Only its options are
+// used, the task being run natively.
+func script(p *influxdb.DeletePolicy) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Delete policy of bucket %s, deleting the points", p.BucketID)
+	if p.Predicate != "" {
+		fmt.Fprintf(&b, " matching %s", strings.ReplaceAll(p.Predicate, "\n", " "))
+	}
+	if p.OlderThan.Duration > 0 {
+		fmt.Fprintf(&b, " older than %s", p.OlderThan)
+	}
+	b.WriteString(".\n")
+
+	fmt.Fprintf(&b, "option task = {name: %s, ", strconv.Quote(p.Name))
+	if p.Cron != "" {
+		fmt.Fprintf(&b, "cron: %s}\n", strconv.Quote(p.Cron))
+	} else {
+		fmt.Fprintf(&b, "every: %s}\n", p.Every)
+	}
+	return b.String()
+}
+
+func errNotFound() error {
+	return &errors.Error{
+		Code: errors.ENotFound,
+		Msg:  influxdb.ErrDeletePolicyNotFound,
+	}
+}
@@ -0,0 +1,210 @@
+package deletepolicy_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/deletepolicy"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/query/fluxlang"
+	"github.com/influxdata/influxdb/v2/task/options"
+	"github.com/influxdata/influxdb/v2/task/taskmodel"
+	"github.com/influxdata/influxql"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// newTaskService returns a task service keeping its tasks in memory, taking
+// their options from their Flux.
+func newTaskService(t *testing.T) *mock.TaskService {
+	tasks := map[platform.ID]*taskmodel.Task{}
+	applyOptions := func(task *taskmodel.Task) {
+		opts, err := options.FromScriptAST(fluxlang.DefaultService, task.Flux)
+		require.NoError(t, err)
+		task.Name, task.Cron, task.Every = opts.Name, opts.Cron, ""
+		if !opts.Every.IsZero() {
+			task.Every = opts.Every.String()
+		}
+	}
+
+	ts := mock.NewTaskService()
+	ts.CreateTaskFn = func(ctx context.Context, tc taskmodel.TaskCreate) (*taskmodel.Task, error) {
+		task := &taskmodel.Task{
+			ID:             platform.ID(len(tasks) + 1),
+			Type:           tc.Type,
+			OrganizationID: tc.OrganizationID,
+			OwnerID:        tc.OwnerID,
+			Description:    tc.Description,
+			Flux:           tc.Flux,
+			Status:         tc.Status,
+			Metadata:       tc.Metadata,
+		}
+		applyOptions(task)
+		tasks[task.ID] = task
+		return task, nil
+	}
+	ts.FindTaskByIDFn = func(ctx context.Context, id platform.ID) (*taskmodel.Task, error) {
+		task, ok := tasks[id]
+		if !ok {
+			return nil, taskmodel.ErrTaskNotFound
+		}
+		return task, nil
+	}
+	ts.FindTasksFn = func(ctx context.Context, f taskmodel.TaskFilter) ([]*taskmodel.Task, int, error) {
+		var res []*taskmodel.Task
+		for id := platform.ID(1); int(id) <= len(tasks); id++ {
+			task, ok := tasks[id]
+			if ok && task.OrganizationID == *f.OrganizationID && task.Type == *f.Type {
+				res = append(res, task)
+			}
+		}
+		return res, len(res), nil
+	}
+	ts.UpdateTaskFn = func(ctx context.Context, id platform.ID, upd taskmodel.TaskUpdate) (*taskmodel.Task, error) {
+		task := tasks[id]
+		task.Flux, task.Status, task.Description, task.Metadata = *upd.Flux, *upd.Status, *upd.Description, upd.Metadata
+		applyOptions(task)
+		return task, nil
+	}
+	ts.DeleteTaskFn = func(ctx context.Context, id platform.ID) error {
+		delete(tasks, id)
+		return nil
+	}
+	return ts
+}
+
+func TestService(t *testing.T) {
+	orgID, bucketID, userID := platform.ID(1), platform.ID(10), platform.ID(2)
+	ctx := icontext.SetAuthorizer(context.Background(), &mock.Authorizer{AllowAll: true, UserID: userID})
+	tasks := newTaskService(t)
+	svc := deletepolicy.NewService(tasks)
+
+	// A task which is not a delete policy.
+	_, err := tasks.CreateTask(ctx, taskmodel.TaskCreate{
+		OrganizationID: orgID,
+		Flux:           `option task = {name: "other", every: 1h}`,
+		Status:         "active",
+	})
+	require.NoError(t, err)
+
+	p := &influxdb.DeletePolicy{
+		OrganizationID: orgID,
+		BucketID:       bucketID,
+		Name:           "purge tmp",
+		Predicate:      `tmp="true"`,
+		Every:          influxdb.Duration{Duration: 24 * time.Hour},
+		OlderThan:      influxdb.Duration{Duration: time.Hour},
+	}
+	require.NoError(t, svc.CreateDeletePolicy(ctx, p))
+	require.Equal(t, platform.ID(2), p.ID)
+	require.Equal(t, userID, p.OwnerID)
+	require.Equal(t, influxdb.Active, p.Status)
+	require.Equal(t, "purge tmp", p.Name)
+	require.Equal(t, 24*time.Hour, p.Every.Duration)
+	require.Equal(t, time.Hour, p.OlderThan.Duration)
+	require.Equal(t, `tmp="true"`, p.Predicate)
+
+	found, err := svc.FindDeletePolicyByID(ctx, p.ID)
+	require.NoError(t, err)
+	require.Equal(t, p, found)
+
+	_, err = svc.FindDeletePolicyByID(ctx, 1)
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+
+	ps, err := svc.FindDeletePolicies(ctx, influxdb.DeletePolicyFilter{OrganizationID: orgID})
+	require.NoError(t, err)
+	require.Equal(t, []*influxdb.DeletePolicy{p}, ps)
+	otherBucket := platform.ID(20)
+	ps, err = svc.FindDeletePolicies(ctx, influxdb.DeletePolicyFilter{OrganizationID: orgID, BucketID: &otherBucket})
+	require.NoError(t, err)
+	require.Empty(t, ps)
+
+	cron, inactive := "0 3 * * *", influxdb.Inactive
+	updated, err := svc.UpdateDeletePolicy(ctx, p.ID, influxdb.DeletePolicyUpdate{Cron: &cron, Status: &inactive})
+	require.NoError(t, err)
+	require.Equal(t, cron, updated.Cron)
+	require.Zero(t, updated.Every.Duration)
+	require.Equal(t, influxdb.Inactive, updated.Status)
+	require.Equal(t, `tmp="true"`, updated.Predicate)
+
+	require.NoError(t, svc.DeleteDeletePolicy(ctx, p.ID))
+	_, err = svc.FindDeletePolicyByID(ctx, p.ID)
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(svc.DeleteDeletePolicy(ctx, 1)))
+}
+
+func TestService_Invalid(t *testing.T) {
+	ctx := icontext.SetAuthorizer(context.Background(), &mock.Authorizer{AllowAll: true, UserID: 2})
+	svc := deletepolicy.NewService(newTaskService(t))
+
+	for _, tc := range []struct {
+		name string
+		p    influxdb.DeletePolicy
+	}{
+		{name: "no bucket", p: influxdb.DeletePolicy{OrganizationID: 1, Name: "p", Every: influxdb.Duration{Duration: time.Hour}}},
+		{name: "no schedule", p: influxdb.DeletePolicy{OrganizationID: 1, BucketID: 10, Name: "p"}},
+		{name: "every and cron", p: influxdb.DeletePolicy{OrganizationID: 1, BucketID: 10, Name: "p", Every: influxdb.Duration{Duration: time.Hour}, Cron: "0 * * * *"}},
+		{name: "invalid predicate", p: influxdb.DeletePolicy{OrganizationID: 1, BucketID: 10, Name: "p", Every: influxdb.Duration{Duration: time.Hour}, Predicate: `tmp=`}},
+		{name: "field predicate", p: influxdb.DeletePolicy{OrganizationID: 1, BucketID: 10, Name: "p", Every: influxdb.Duration{Duration: time.Hour}, Predicate: `_field="f"`}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Error(t, svc.CreateDeletePolicy(ctx, &tc.p))
+		})
+	}
+}
+
+func TestRunner(t *testing.T) {
+	orgID, bucketID := platform.ID(1), platform.ID(10)
+	scheduledFor := time.Date(2021, 1, 2, 3, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name      string
+		olderThan time.Duration
+		stop      int64
+	}{
+		{name: "older than", olderThan: time.Hour, stop: scheduledFor.Add(-time.Hour).UnixNano()},
+		{name: "all", stop: models.MaxNanoTime},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tasks := newTaskService(t)
+			ctx := icontext.SetAuthorizer(context.Background(), &mock.Authorizer{AllowAll: true, UserID: 2})
+			p := &influxdb.DeletePolicy{
+				OrganizationID: orgID,
+				BucketID:       bucketID,
+				Name:           "purge tmp",
+				Predicate:      `_measurement="cpu" and tmp="true"`,
+				Every:          influxdb.Duration{Duration: 24 * time.Hour},
+				OlderThan:      influxdb.Duration{Duration: tc.olderThan},
+			}
+			require.NoError(t, deletepolicy.NewService(tasks).CreateDeletePolicy(ctx, p))
+			task, err := tasks.FindTaskByID(ctx, p.ID)
+			require.NoError(t, err)
+
+			var deleted bool
+			runner := deletepolicy.NewRunner(zaptest.NewLogger(t), mock.DeleteService{
+				DeleteBucketRangePredicateF: func(ctx context.Context, oid, bid platform.ID, min, max int64, pred influxdb.Predicate, measurement influxql.Expr) error {
+					deleted = true
+					require.Equal(t, orgID, oid)
+					require.Equal(t, bucketID, bid)
+					require.Equal(t, models.MinNanoTime, min)
+					require.Equal(t, tc.stop, max)
+					require.NotNil(t, pred)
+					require.Equal(t, `_measurement = 'cpu'`, measurement.String())
+					return nil
+				},
+			})
+			require.NoError(t, runner.Run(ctx, task, scheduledFor))
+			require.True(t, deleted)
+
+			// The owner of the task may no longer write to the bucket.
+			ctx = icontext.SetAuthorizer(context.Background(), &mock.Authorizer{UserID: 2})
+			require.Error(t, runner.Run(ctx, task, scheduledFor))
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const prefixDeletePolicies = "/api/v2/deletePolicies"
+
+// DeletePolicyHandler is the handler for the delete policy service.
+type DeletePolicyHandler struct {
+	chi.Router
+
+	api *kithttp.API
+	log *zap.Logger
+
+	deletePolicyService influxdb.DeletePolicyService
+}
+
+// NewDeletePolicyHandler returns a new instance of DeletePolicyHandler.
+func NewDeletePolicyHandler(log *zap.Logger, deletePolicyService influxdb.DeletePolicyService) *DeletePolicyHandler {
+	h := &DeletePolicyHandler{
+		log:                 log,
+		api:                 kithttp.NewAPI(kithttp.WithLog(log)),
+		deletePolicyService: deletePolicyService,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+	r.Route("/", func(r chi.Router) {
+		r.Get("/", h.handleGetDeletePolicies)
+		r.Post("/", h.handlePostDeletePolicy)
+
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", h.handleGetDeletePolicy)
+			r.Patch("/", h.handlePatchDeletePolicy)
+			r.Delete("/", h.handleDeleteDeletePolicy)
+		})
+	})
+	h.Router = r
+	return h
+}
+
+// Prefix returns the mounting prefix for the handler.
+func (h *DeletePolicyHandler) Prefix() string {
+	return prefixDeletePolicies
+}
+
+type deletePolicyResponse struct {
+	*influxdb.DeletePolicy
+	Links map[string]string `json:"links"`
+}
+
+func newDeletePolicyResponse(p *influxdb.DeletePolicy) deletePolicyResponse {
+	return deletePolicyResponse{
+		DeletePolicy: p,
+		Links: map[string]string{
+			"self":   fmt.Sprintf("%s/%s", prefixDeletePolicies, p.ID),
+			"runs":   fmt.Sprintf("/api/v2/tasks/%s/runs", p.ID),
+			"bucket": fmt.Sprintf("/api/v2/buckets/%s", p.BucketID),
+			"org":    fmt.Sprintf("/api/v2/orgs/%s", p.OrganizationID),
+		},
+	}
+}
+
+type deletePoliciesResponse struct {
+	DeletePolicies []deletePolicyResponse `json:"deletePolicies"`
+	Links          map[string]string      `json:"links"`
+}
+
+func decodeDeletePolicyID(r *http.Request) (platform.ID, error) {
+	var id platform.ID
+	if err := id.DecodeFromString(chi.URLParam(r, "id")); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// handleGetDeletePolicies lists the delete policies of an organization or a bucket.
+func (h *DeletePolicyHandler) handleGetDeletePolicies(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var filter influxdb.DeletePolicyFilter
+	q := r.URL.Query()
+	orgID, err := platform.IDFromString(q.Get("orgID"))
+	if err != nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "orgID is required",
+			Err:  err,
+		})
+		return
+	}
+	filter.OrganizationID = *orgID
+	if bucketID := q.Get("bucketID"); bucketID != "" {
+		id, err := platform.IDFromString(bucketID)
+		if err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+		filter.BucketID = id
+	}
+
+	ps, err := h.deletePolicyService.FindDeletePolicies(ctx, filter)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Delete policies retrieved", zap.Int("count", len(ps)))
+
+	res := deletePoliciesResponse{
+		DeletePolicies: make([]deletePolicyResponse, 0, len(ps)),
+		Links: map[string]string{
+			"self": prefixDeletePolicies,
+		},
+	}
+	for _, p := range ps {
+		res.DeletePolicies = append(res.DeletePolicies, newDeletePolicyResponse(p))
+	}
+	h.api.Respond(w, r, http.StatusOK, res)
+}
+
+// handlePostDeletePolicy creates a delete policy.
+func (h *DeletePolicyHandler) handlePostDeletePolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var p influxdb.DeletePolicy
+	if err := h.api.DecodeJSON(r.Body, &p); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.deletePolicyService.CreateDeletePolicy(ctx, &p); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Delete policy created", zap.String("deletePolicy", fmt.Sprint(p)))
+
+	h.api.Respond(w, r, http.StatusCreated, newDeletePolicyResponse(&p))
+}
+
+// handleGetDeletePolicy retrieves a delete policy by ID.
+func (h *DeletePolicyHandler) handleGetDeletePolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := decodeDeletePolicyID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	p, err := h.deletePolicyService.FindDeletePolicyByID(ctx, id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Delete policy retrieved", zap.String("deletePolicy", fmt.Sprint(p)))
+
+	h.api.Respond(w, r, http.StatusOK, newDeletePolicyResponse(p))
+}
+
+// handlePatchDeletePolicy updates a delete policy.
+func (h *DeletePolicyHandler) handlePatchDeletePolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := decodeDeletePolicyID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var upd influxdb.DeletePolicyUpdate
+	if err := h.api.DecodeJSON(r.Body, &upd); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	p, err := h.deletePolicyService.UpdateDeletePolicy(ctx, id, upd)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Delete policy updated", zap.String("deletePolicy", fmt.Sprint(p)))
+
+	h.api.Respond(w, r, http.StatusOK, newDeletePolicyResponse(p))
+}
+
+// handleDeleteDeletePolicy deletes a delete policy.
+func (h *DeletePolicyHandler) handleDeleteDeletePolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := decodeDeletePolicyID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.deletePolicyService.DeleteDeletePolicy(ctx, id); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Delete policy deleted", zap.String("deletePolicyID", id.String()))
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,115 @@
+package eventlog
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+)
+
+// compactionsFailedMetric counts the compactions of the storage engine which
+// failed.
+const compactionsFailedMetric = "storage_compactions_failed"
+
+// CompactionWatcher records an event when compactions of the storage engine
+// fail. The failures are observed from the metrics of the engine, which
+// counts them by engine and level.
+type CompactionWatcher struct {
+	log      *zap.Logger
+	gatherer prometheus.Gatherer
+	rec      Recorder
+
+	failed map[string]float64 // the failures observed, by labels.
+}
+
+// NewCompactionWatcher creates a watcher of the compactions of the engine
+// whose metrics g gathers.
+func NewCompactionWatcher(log *zap.Logger, g prometheus.Gatherer, rec Recorder) *CompactionWatcher {
+	return &CompactionWatcher{
+		log:      log,
+		gatherer: g,
+		rec:      rec,
+		failed:   map[string]float64{},
+	}
+}
+
+// Run checks for failed compactions every interval until ctx is done.
+func (w *CompactionWatcher) Run(ctx context.Context, interval time.Duration) {
+	w.Check(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.Check(ctx)
+		}
+	}
+}
+
+// Check records an event for each engine and level with compactions which
+// failed since the last check. The counts of the engine start at zero with the
+// server, so the first check records the failures since the start.
+func (w *CompactionWatcher) Check(ctx context.Context) {
+	mfs, err := w.gatherer.Gather()
+	if err != nil {
+		w.log.Debug("Failed to gather some metrics", zap.Error(err))
+	}
+
+	failed := map[string]float64{}
+	labels := map[string][]*dto.LabelPair{}
+	for _, mf := range mfs {
+		if mf.GetName() != compactionsFailedMetric {
+			continue
+		}
+		for _, m := range mf.Metric {
+			k := labelsKey(m.Label)
+			failed[k] = m.GetCounter().GetValue()
+			labels[k] = m.Label
+		}
+	}
+
+	prev := w.failed
+	w.failed = failed
+
+	keys := make([]string, 0, len(failed))
+	for k := range failed {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		n := failed[k] - prev[k]
+		if n <= 0 {
+			continue
+		}
+		fields := map[string]string{"failures": strconv.FormatFloat(n, 'f', -1, 64)}
+		for _, lp := range labels[k] {
+			fields[lp.GetName()] = lp.GetValue()
+		}
+		if err := w.rec.RecordEvent(ctx, Event{
+			Type:    TypeCompaction,
+			Message: "Compactions failed",
+			Fields:  fields,
+		}); err != nil {
+			w.log.Warn("Failed to record compaction failures", zap.Error(err))
+		}
+	}
+}
+
+func labelsKey(lps []*dto.LabelPair) string {
+	var sb strings.Builder
+	for _, lp := range lps {
+		sb.WriteString(lp.GetName())
+		sb.WriteByte(0)
+		sb.WriteString(lp.GetValue())
+		sb.WriteByte(0)
+	}
+	return sb.String()
+}
@@ -0,0 +1,54 @@
+// Package eventlog records the notable events of the server, such as its
+// startups, configuration changes, compaction failures and migrations, so
+// operators can reconstruct what changed without searching through logs.
+package eventlog
+
+import (
+	"context"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+// Type is the kind of an event.
+type Type string
+
+// The types of events.
+const (
+	TypeStartup    Type = "startup"
+	TypeShutdown   Type = "shutdown"
+	TypeConfig     Type = "config"
+	TypeCompaction Type = "compaction"
+	TypeMigration  Type = "migration"
+	TypeAuth       Type = "auth"
+)
+
+// Event is a notable event of the server.
+type Event struct {
+	ID      platform.ID       `json:"id"`
+	Time    time.Time         `json:"time"`
+	Type    Type              `json:"type"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// Filter selects events. The zero values select every event.
+type Filter struct {
+	Type  Type
+	Since time.Time // events at or after Since.
+	Until time.Time // events before Until.
+	// Limit is the maximum number of events returned.
+	Limit int
+}
+
+// Recorder records events.
+type Recorder interface {
+	// RecordEvent records e, setting its ID, and its time when it is zero.
+	RecordEvent(ctx context.Context, e Event) error
+}
+
+// EventService finds the recorded events.
+type EventService interface {
+	// FindEvents returns the events selected by the filter, newest first.
+	FindEvents(ctx context.Context, f Filter) ([]Event, error)
+}
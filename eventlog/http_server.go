@@ -0,0 +1,120 @@
+package eventlog
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const prefixEvents = "/api/v2/debug/events"
+
+// EventHandler serves the event log to operators.
+type EventHandler struct {
+	chi.Router
+
+	api *kithttp.API
+	log *zap.Logger
+
+	eventSvc EventService
+}
+
+// NewEventHandler returns a new instance of EventHandler.
+func NewEventHandler(log *zap.Logger, eventSvc EventService) *EventHandler {
+	h := &EventHandler{
+		log:      log,
+		api:      kithttp.NewAPI(kithttp.WithLog(log)),
+		eventSvc: eventSvc,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+		h.mwAuthorize,
+	)
+	r.Get("/", h.handleGetEvents)
+	h.Router = r
+	return h
+}
+
+// Prefix returns the mounting prefix for the handler.
+func (h *EventHandler) Prefix() string {
+	return prefixEvents
+}
+
+type eventsResponse struct {
+	Events []Event `json:"events"`
+}
+
+// handleGetEvents is the HTTP handler for the GET /api/v2/debug/events route.
+func (h *EventHandler) handleGetEvents(w http.ResponseWriter, r *http.Request) {
+	f, err := decodeFilter(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	events, err := h.eventSvc.FindEvents(r.Context(), f)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, eventsResponse{Events: events})
+}
+
+func decodeFilter(r *http.Request) (Filter, error) {
+	q := r.URL.Query()
+	f := Filter{Type: Type(q.Get("type"))}
+
+	for param, t := range map[string]*time.Time{"since": &f.Since, "until": &f.Until} {
+		v := q.Get(param)
+		if v == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return Filter{}, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("%s must be an RFC3339 time", param),
+				Err:  err,
+			}
+		}
+		*t = parsed
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return Filter{}, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "limit must be a positive integer",
+			}
+		}
+		f.Limit = limit
+	}
+	return f, nil
+}
+
+func (h *EventHandler) mwAuthorize(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if err := authorizer.IsAllowedAll(r.Context(), influxdb.OperPermissions()); err != nil {
+			h.api.Err(w, r, &errors.Error{
+				Code: errors.EUnauthorized,
+				Msg:  fmt.Sprintf("access to %s requires operator permissions", h.Prefix()),
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
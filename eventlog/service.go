@@ -0,0 +1,271 @@
+package eventlog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/snowflake"
+	"go.uber.org/zap"
+)
+
+var (
+	eventBucket  = []byte("eventlogv1")
+	configBucket = []byte("eventlogconfigv1")
+)
+
+const (
+	// DefaultLimit is the number of events found when the filter has no limit.
+	DefaultLimit = 100
+	// MaxLimit is the maximum number of events found at once.
+	MaxLimit = 1000
+)
+
+var (
+	_ Recorder     = (*Service)(nil)
+	_ EventService = (*Service)(nil)
+)
+
+// Service stores the events in a kv store, keyed by time. The events older
+// than the retention are removed by Run.
+type Service struct {
+	log   *zap.Logger
+	kv    kv.Store
+	idGen platform.IDGenerator
+	now   func() time.Time
+
+	retention time.Duration
+}
+
+// NewService creates a new event log, retaining the events for retention. The
+// events are retained forever when retention is zero.
+func NewService(log *zap.Logger, s kv.Store, retention time.Duration) *Service {
+	return &Service{
+		log:       log,
+		kv:        s,
+		idGen:     snowflake.NewDefaultIDGenerator(),
+		now:       time.Now,
+		retention: retention,
+	}
+}
+
+// eventKey orders the events by time, and then by ID.
+func eventKey(t time.Time, id platform.ID) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	binary.BigEndian.PutUint64(key[8:], uint64(id))
+	return key
+}
+
+func keyTime(key []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(key)))
+}
+
+// RecordEvent records e.
+func (s *Service) RecordEvent(ctx context.Context, e Event) error {
+	e.ID = s.idGen.ID()
+	if e.Time.IsZero() {
+		e.Time = s.now()
+	}
+	e.Time = e.Time.UTC()
+
+	v, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return s.kv.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(eventBucket)
+		if err != nil {
+			return err
+		}
+		return b.Put(eventKey(e.Time, e.ID), v)
+	})
+}
+
+// FindEvents returns the events selected by the filter, newest first.
+func (s *Service) FindEvents(ctx context.Context, f Filter) ([]Event, error) {
+	limit := f.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "limit must not exceed 1000",
+		}
+	}
+
+	events := []Event{}
+	err := s.kv.View(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(eventBucket)
+		if err != nil {
+			return err
+		}
+		c, err := b.ForwardCursor(nil, kv.WithCursorDirection(kv.CursorDescending))
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		for k, v := c.Next(); k != nil && len(events) < limit; k, v = c.Next() {
+			t := keyTime(k)
+			if !f.Until.IsZero() && !t.Before(f.Until) {
+				continue
+			}
+			if !f.Since.IsZero() && t.Before(f.Since) {
+				break
+			}
+
+			var e Event
+			if err := json.Unmarshal(v, &e); err != nil {
+				return &errors.Error{
+					Code: errors.EInternal,
+					Msg:  "failed to decode event",
+					Err:  err,
+				}
+			}
+			if f.Type != "" && e.Type != f.Type {
+				continue
+			}
+			events = append(events, e)
+		}
+		return c.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Prune removes the events before the time, returning the number of events
+// removed.
+func (s *Service) Prune(ctx context.Context, before time.Time) (int, error) {
+	var n int
+	err := s.kv.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(eventBucket)
+		if err != nil {
+			return err
+		}
+		c, err := b.ForwardCursor(nil)
+		if err != nil {
+			return err
+		}
+
+		var keys [][]byte
+		for k, _ := c.Next(); k != nil && keyTime(k).Before(before); k, _ = c.Next() {
+			keys = append(keys, k)
+		}
+		if err := c.Err(); err != nil {
+			return err
+		}
+		if err := c.Close(); err != nil {
+			return err
+		}
+
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		n = len(keys)
+		return nil
+	})
+	return n, err
+}
+
+// Run removes the events older than the retention every interval until ctx is
+// done.
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	if s.retention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := s.Prune(ctx, s.now().Add(-s.retention))
+		if err != nil && ctx.Err() == nil {
+			s.log.Warn("Failed to remove expired events", zap.Error(err))
+		} else if n > 0 {
+			s.log.Debug("Removed expired events", zap.Int("events", n))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RecordConfigChanges records an event listing the options whose values
+// differ from the values the server was last started with. Only a digest of
+// each value is stored, so the values of sensitive options are not kept.
+func (s *Service) RecordConfigChanges(ctx context.Context, values map[string][]byte) error {
+	digests := make(map[string][]byte, len(values))
+	for k, v := range values {
+		d := sha256.Sum256(v)
+		digests[k] = d[:]
+	}
+
+	var changed []string
+	err := s.kv.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(configBucket)
+		if err != nil {
+			return err
+		}
+
+		var prev map[string][]byte
+		c, err := b.ForwardCursor(nil)
+		if err != nil {
+			return err
+		}
+		for k, v := c.Next(); k != nil; k, v = c.Next() {
+			if prev == nil {
+				prev = map[string][]byte{}
+			}
+			prev[string(k)] = v
+		}
+		if err := c.Err(); err != nil {
+			return err
+		}
+		if err := c.Close(); err != nil {
+			return err
+		}
+
+		for k, d := range digests {
+			if p, ok := prev[k]; prev != nil && (!ok || string(p) != string(d)) {
+				changed = append(changed, k)
+			}
+			if err := b.Put([]byte(k), d); err != nil {
+				return err
+			}
+		}
+		for k := range prev {
+			if _, ok := digests[k]; !ok {
+				changed = append(changed, k)
+				if err := b.Delete([]byte(k)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil || len(changed) == 0 {
+		return err
+	}
+
+	sort.Strings(changed)
+	return s.RecordEvent(ctx, Event{
+		Type:    TypeConfig,
+		Message: "Configuration changed since the last start",
+		Fields:  map[string]string{"options": strings.Join(changed, ",")},
+	})
+}
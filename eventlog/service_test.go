@@ -0,0 +1,139 @@
+package eventlog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/inmem"
+	"github.com/influxdata/influxdb/v2/kv/migration/all"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+var t0 = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	store := inmem.NewKVStore()
+	require.NoError(t, all.Up(context.Background(), zaptest.NewLogger(t), store))
+	return NewService(zaptest.NewLogger(t), store, time.Hour)
+}
+
+func messages(events []Event) []string {
+	ms := []string{}
+	for _, e := range events {
+		ms = append(ms, e.Message)
+	}
+	return ms
+}
+
+func TestService_FindEvents(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	for i, e := range []Event{
+		{Type: TypeStartup, Message: "started"},
+		{Type: TypeMigration, Message: "migrated"},
+		{Type: TypeConfig, Message: "reloaded"},
+		{Type: TypeShutdown, Message: "stopped"},
+	} {
+		e.Time = t0.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, s.RecordEvent(ctx, e))
+	}
+
+	for _, tt := range []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{name: "all", want: []string{"stopped", "reloaded", "migrated", "started"}},
+		{name: "type", filter: Filter{Type: TypeMigration}, want: []string{"migrated"}},
+		{name: "since", filter: Filter{Since: t0.Add(2 * time.Minute)}, want: []string{"stopped", "reloaded"}},
+		{name: "until", filter: Filter{Until: t0.Add(2 * time.Minute)}, want: []string{"migrated", "started"}},
+		{name: "limit", filter: Filter{Limit: 1}, want: []string{"stopped"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := s.FindEvents(ctx, tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, messages(events))
+		})
+	}
+
+	_, err := s.FindEvents(ctx, Filter{Limit: MaxLimit + 1})
+	assert.Error(t, err)
+}
+
+func TestService_Prune(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, s.RecordEvent(ctx, Event{Type: TypeStartup, Message: "started", Time: t0.Add(time.Duration(i) * time.Hour)}))
+	}
+
+	n, err := s.Prune(ctx, t0.Add(90*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	events, err := s.FindEvents(ctx, Filter{})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, t0.Add(2*time.Hour), events[0].Time)
+}
+
+func TestService_RecordConfigChanges(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+
+	// the first start has no changes.
+	require.NoError(t, s.RecordConfigChanges(ctx, map[string][]byte{"log-level": []byte(`"info"`), "secret": []byte(`"hunter2"`)}))
+	require.NoError(t, s.RecordConfigChanges(ctx, map[string][]byte{"log-level": []byte(`"info"`), "secret": []byte(`"hunter2"`)}))
+	events, err := s.FindEvents(ctx, Filter{})
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	require.NoError(t, s.RecordConfigChanges(ctx, map[string][]byte{"log-level": []byte(`"debug"`), "secret": []byte(`"hunter3"`), "http-bind-address": []byte(`":8086"`)}))
+	events, err = s.FindEvents(ctx, Filter{Type: TypeConfig})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, map[string]string{"options": "http-bind-address,log-level,secret"}, events[0].Fields)
+
+	require.NoError(t, s.RecordConfigChanges(ctx, map[string][]byte{"log-level": []byte(`"debug"`), "secret": []byte(`"hunter3"`)}))
+	events, err = s.FindEvents(ctx, Filter{Type: TypeConfig, Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"options": "http-bind-address"}, events[0].Fields)
+}
+
+func TestCompactionWatcher(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+
+	reg := prometheus.NewRegistry()
+	failed := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: compactionsFailedMetric,
+		Help: "Failed compactions",
+	}, []string{"level"})
+	reg.MustRegister(failed)
+
+	w := NewCompactionWatcher(zaptest.NewLogger(t), reg, s)
+	failed.WithLabelValues("1").Add(2)
+	w.Check(ctx)
+	w.Check(ctx)
+	failed.WithLabelValues("1").Inc()
+	failed.WithLabelValues("full").Inc()
+	w.Check(ctx)
+
+	events, err := s.FindEvents(ctx, Filter{Type: TypeCompaction})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	var fields []map[string]string
+	for _, e := range events {
+		fields = append(fields, e.Fields)
+	}
+	assert.ElementsMatch(t, []map[string]string{
+		{"level": "1", "failures": "2"},
+		{"level": "1", "failures": "1"},
+		{"level": "full", "failures": "1"},
+	}, fields)
+}
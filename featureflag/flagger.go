@@ -0,0 +1,205 @@
+package featureflag
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/feature"
+	"github.com/influxdata/influxdb/v2/kit/feature/override"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+	"go.uber.org/zap"
+)
+
+// Flagger applies overrides set at runtime on top of the values computed by
+// a base Flagger. Overrides scoped to the organization of the authorization on
+// the context take precedence over global overrides, which take precedence
+// over the base values.
+//
+// All overrides are held in memory, since flags are computed for every request.
+type Flagger struct {
+	log   *zap.Logger
+	store *Store
+	base  feature.Flagger
+	byKey feature.ByKeyFn
+
+	mu     sync.RWMutex
+	global map[string]string
+	byOrg  map[platform.ID]map[string]string
+}
+
+var _ feature.Flagger = (*Flagger)(nil)
+
+// NewFlagger loads the persisted overrides and returns a Flagger applying them on top of base.
+func NewFlagger(ctx context.Context, log *zap.Logger, store *Store, base feature.Flagger) (*Flagger, error) {
+	f := &Flagger{
+		log:    log,
+		store:  store,
+		base:   base,
+		byKey:  feature.ByKey,
+		global: map[string]string{},
+		byOrg:  map[platform.ID]map[string]string{},
+	}
+
+	var overrides []Override
+	err := store.View(ctx, func(tx kv.Tx) error {
+		var err error
+		overrides, err = store.ListOverrides(ctx, tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range overrides {
+		// Flags are removed from the codebase over time; ignore overrides for them
+		// rather than failing to start.
+		if _, found := f.byKey(o.Key); !found {
+			log.Warn("Ignoring override for non-existent feature flag", zap.String("flag", o.Key))
+			continue
+		}
+		f.set(o.OrgID, o.Key, &o.Value)
+	}
+	return f, nil
+}
+
+// Flags computes the base flag values and applies any runtime overrides.
+func (f *Flagger) Flags(ctx context.Context, flags ...feature.Flag) (map[string]interface{}, error) {
+	computed, err := f.base.Flags(ctx, flags...)
+	if err != nil {
+		return nil, err
+	}
+
+	var orgOverrides map[string]string
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if auth, err := icontext.GetAuthorizer(ctx); err == nil {
+		if a, ok := auth.(*influxdb.Authorization); ok {
+			orgOverrides = f.byOrg[a.OrgID]
+		}
+	}
+
+	for k := range computed {
+		s, ok := orgOverrides[k]
+		if !ok {
+			s, ok = f.global[k]
+		}
+		if !ok {
+			continue
+		}
+		flag, found := f.byKey(k)
+		if !found {
+			continue
+		}
+		v, err := override.Coerce(s, flag, f.byKey)
+		if err != nil {
+			// Values are validated when set, so this only happens if a flag changes type.
+			f.log.Warn("Ignoring invalid feature flag override", zap.String("flag", k), zap.Error(err))
+			continue
+		}
+		computed[k] = v
+	}
+	return computed, nil
+}
+
+// Overrides returns the overrides in the given scope, sorted by flag key.
+// A nil orgID returns the global overrides.
+func (f *Flagger) Overrides(orgID *platform.ID) []Override {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.overrides(orgID)
+}
+
+func (f *Flagger) overrides(orgID *platform.ID) []Override {
+	scope := f.global
+	if orgID != nil {
+		scope = f.byOrg[*orgID]
+	}
+	overrides := make([]Override, 0, len(scope))
+	for k, v := range scope {
+		overrides = append(overrides, Override{OrgID: orgID, Key: k, Value: v})
+	}
+	sort.Slice(overrides, func(i, j int) bool {
+		return overrides[i].Key < overrides[j].Key
+	})
+	return overrides
+}
+
+// SetOverrides persists overrides in the given scope. A nil value removes the
+// override for that flag. A nil orgID sets global overrides.
+func (f *Flagger) SetOverrides(ctx context.Context, orgID *platform.ID, values map[string]*string) ([]Override, error) {
+	for k, v := range values {
+		flag, found := f.byKey(k)
+		if !found {
+			return nil, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("feature flag %q does not exist", k),
+			}
+		}
+		if v == nil {
+			continue
+		}
+		if _, err := override.Coerce(*v, flag, f.byKey); err != nil {
+			return nil, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("invalid value for feature flag %q", k),
+				Err:  err,
+			}
+		}
+	}
+
+	// Hold the lock across the update so the cache and store are changed together.
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	err := f.store.Update(ctx, func(tx kv.Tx) error {
+		for k, v := range values {
+			if v == nil {
+				if err := f.store.DeleteOverride(ctx, tx, orgID, k); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := f.store.PutOverride(ctx, tx, Override{OrgID: orgID, Key: k, Value: *v}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range values {
+		f.set(orgID, k, v)
+		f.log.Info("Set feature flag override", zap.String("flag", k), zap.Stringp("value", v), zap.String("scope", scopeName(orgID)))
+	}
+	return f.overrides(orgID), nil
+}
+
+// set updates the in-memory overrides. Callers must hold f.mu or have exclusive access to f.
+func (f *Flagger) set(orgID *platform.ID, key string, value *string) {
+	scope := f.global
+	if orgID != nil {
+		if f.byOrg[*orgID] == nil {
+			f.byOrg[*orgID] = map[string]string{}
+		}
+		scope = f.byOrg[*orgID]
+	}
+	if value == nil {
+		delete(scope, key)
+		return
+	}
+	scope[key] = *value
+}
+
+func scopeName(orgID *platform.ID) string {
+	if orgID == nil {
+		return "global"
+	}
+	return orgID.String()
+}
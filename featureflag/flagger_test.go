@@ -0,0 +1,81 @@
+package featureflag
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/inmem"
+	"github.com/influxdata/influxdb/v2/kit/feature"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	kvStore := inmem.NewKVStore()
+	require.NoError(t, kvStore.CreateBucket(context.Background(), overridesBucket))
+	return NewStore(kvStore)
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestFlagger(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	orgID := platform.ID(1)
+	otherOrgID := platform.ID(2)
+
+	f, err := NewFlagger(ctx, zaptest.NewLogger(t), store, feature.DefaultFlagger())
+	require.NoError(t, err)
+
+	queryTracing := feature.QueryTracing()
+	flags, err := f.Flags(ctx, queryTracing)
+	require.NoError(t, err)
+	require.Equal(t, false, flags[queryTracing.Key()])
+
+	_, err = f.SetOverrides(ctx, nil, map[string]*string{queryTracing.Key(): strPtr("true")})
+	require.NoError(t, err)
+	overrides, err := f.SetOverrides(ctx, &orgID, map[string]*string{queryTracing.Key(): strPtr("false")})
+	require.NoError(t, err)
+	require.Equal(t, []Override{{OrgID: &orgID, Key: queryTracing.Key(), Value: "false"}}, overrides)
+
+	flagsFor := func(orgID platform.ID) map[string]interface{} {
+		ctx := icontext.SetAuthorizer(ctx, &influxdb.Authorization{OrgID: orgID})
+		flags, err := f.Flags(ctx, queryTracing)
+		require.NoError(t, err)
+		return flags
+	}
+	require.Equal(t, false, flagsFor(orgID)[queryTracing.Key()])
+	require.Equal(t, true, flagsFor(otherOrgID)[queryTracing.Key()])
+
+	// Overrides are persisted, and loaded by a new Flagger.
+	reloaded, err := NewFlagger(ctx, zaptest.NewLogger(t), store, feature.DefaultFlagger())
+	require.NoError(t, err)
+	require.Equal(t, f.Overrides(nil), reloaded.Overrides(nil))
+	require.Equal(t, f.Overrides(&orgID), reloaded.Overrides(&orgID))
+
+	// A nil value removes the override.
+	overrides, err = f.SetOverrides(ctx, &orgID, map[string]*string{queryTracing.Key(): nil})
+	require.NoError(t, err)
+	require.Empty(t, overrides)
+	require.Equal(t, true, flagsFor(orgID)[queryTracing.Key()])
+}
+
+func TestFlagger_SetOverrides_Invalid(t *testing.T) {
+	ctx := context.Background()
+	f, err := NewFlagger(ctx, zaptest.NewLogger(t), newTestStore(t), feature.DefaultFlagger())
+	require.NoError(t, err)
+
+	_, err = f.SetOverrides(ctx, nil, map[string]*string{"doesNotExist": strPtr("true")})
+	require.Error(t, err)
+
+	_, err = f.SetOverrides(ctx, nil, map[string]*string{feature.QueryTracing().Key(): strPtr("sometimes")})
+	require.Error(t, err)
+	require.Empty(t, f.Overrides(nil))
+}
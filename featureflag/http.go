@@ -0,0 +1,163 @@
+package featureflag
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const prefixFlags = "/api/v2/flags"
+
+// HTTPHandler serves the computed feature flags for the current request, and
+// lets operators manage runtime overrides.
+type HTTPHandler struct {
+	chi.Router
+
+	log     *zap.Logger
+	api     *kithttp.API
+	flagger *Flagger
+}
+
+// NewHTTPHandler returns a handler for /api/v2/flags. GET requests to the root
+// are served by flagsHandler, which reports the flags computed for the caller.
+func NewHTTPHandler(log *zap.Logger, flagger *Flagger, flagsHandler http.Handler) *HTTPHandler {
+	h := &HTTPHandler{
+		log:     log,
+		api:     kithttp.NewAPI(kithttp.WithLog(log)),
+		flagger: flagger,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+
+	r.Get("/", flagsHandler.ServeHTTP)
+	r.Group(func(r chi.Router) {
+		r.Use(h.mwAuthorize)
+		r.Put("/", h.handlePutOverrides)
+		r.Get("/overrides", h.handleGetOverrides)
+	})
+	h.Router = r
+	return h
+}
+
+func (h *HTTPHandler) Prefix() string {
+	return prefixFlags
+}
+
+// overridesRequest sets flag values in a scope. A null value removes the override.
+type overridesRequest struct {
+	OrgID *platform.ID               `json:"orgID,omitempty"`
+	Flags map[string]json.RawMessage `json:"flags"`
+}
+
+type overridesResponse struct {
+	Overrides []Override `json:"overrides"`
+}
+
+func (h *HTTPHandler) handleGetOverrides(w http.ResponseWriter, r *http.Request) {
+	orgID, err := decodeOrgID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, overridesResponse{Overrides: h.flagger.Overrides(orgID)})
+}
+
+func (h *HTTPHandler) handlePutOverrides(w http.ResponseWriter, r *http.Request) {
+	var req overridesRequest
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if len(req.Flags) == 0 {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "at least one flag must be provided",
+		})
+		return
+	}
+
+	values := make(map[string]*string, len(req.Flags))
+	for k, raw := range req.Flags {
+		v, err := decodeFlagValue(raw)
+		if err != nil {
+			h.api.Err(w, r, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("invalid value for feature flag %q", k),
+				Err:  err,
+			})
+			return
+		}
+		values[k] = v
+	}
+
+	overrides, err := h.flagger.SetOverrides(r.Context(), req.OrgID, values)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, overridesResponse{Overrides: overrides})
+}
+
+// decodeFlagValue converts a JSON scalar to the string form used by overrides.
+// It returns nil for a JSON null.
+func decodeFlagValue(raw json.RawMessage) (*string, error) {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return &v, nil
+	case bool, float64:
+		s := string(raw)
+		return &s, nil
+	default:
+		return nil, fmt.Errorf("expected a string, number or boolean but got %T", v)
+	}
+}
+
+func decodeOrgID(r *http.Request) (*platform.ID, error) {
+	s := r.URL.Query().Get("orgID")
+	if s == "" {
+		return nil, nil
+	}
+	id, err := platform.IDFromString(s)
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "invalid org ID",
+			Err:  err,
+		}
+	}
+	return id, nil
+}
+
+func (h *HTTPHandler) mwAuthorize(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if err := authorizer.IsAllowedAll(r.Context(), influxdb.OperPermissions()); err != nil {
+			h.api.Err(w, r, &errors.Error{
+				Code: errors.EUnauthorized,
+				Msg:  fmt.Sprintf("managing feature flags at %s requires operator permissions", h.Prefix()),
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,57 @@
+package featureflag
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/feature"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	f, err := NewFlagger(context.Background(), zaptest.NewLogger(t), newTestStore(t), feature.DefaultFlagger())
+	require.NoError(t, err)
+	flagsHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	h := NewHTTPHandler(zaptest.NewLogger(t), f, flagsHandler)
+
+	do := func(method, path, body string, auth *influxdb.Authorization) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		if auth != nil {
+			r = r.WithContext(icontext.SetAuthorizer(r.Context(), auth))
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	operator := &influxdb.Authorization{Status: influxdb.Active, Permissions: influxdb.OperPermissions()}
+	member := &influxdb.Authorization{Status: influxdb.Active}
+
+	// Computed flags remain available to every caller.
+	require.Equal(t, http.StatusTeapot, do(http.MethodGet, "/", "", member).Code)
+
+	body := `{"orgID": "0000000000000001", "flags": {"queryTracing": true}}`
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodPut, "/", body, member).Code)
+
+	w := do(http.MethodPut, "/", body, operator)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = do(http.MethodGet, "/overrides?orgID=0000000000000001", "", operator)
+	require.Equal(t, http.StatusOK, w.Code)
+	var res overridesResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&res))
+	require.Len(t, res.Overrides, 1)
+	require.Equal(t, "queryTracing", res.Overrides[0].Key)
+	require.Equal(t, "true", res.Overrides[0].Value)
+
+	require.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/", `{"flags": {"queryTracing": [1]}}`, operator).Code)
+	require.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/", `{"flags": {}}`, operator).Code)
+}
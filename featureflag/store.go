@@ -0,0 +1,114 @@
+package featureflag
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+var overridesBucket = []byte("featureflagoverridesv1")
+
+// globalScope prefixes the keys of overrides which apply to every organization.
+var globalScope = []byte("0000000000000000")
+
+// Override is a feature flag value set at runtime.
+type Override struct {
+	// OrgID is the organization the override applies to, or nil if it applies to all organizations.
+	OrgID *platform.ID `json:"orgID,omitempty"`
+	Key   string       `json:"key"`
+	Value string       `json:"value"`
+}
+
+// Store persists feature flag overrides in a kv.Store.
+type Store struct {
+	kvStore kv.Store
+}
+
+// NewStore creates a new Store.
+func NewStore(kvStore kv.Store) *Store {
+	return &Store{kvStore: kvStore}
+}
+
+func (s *Store) View(ctx context.Context, fn func(kv.Tx) error) error {
+	return s.kvStore.View(ctx, fn)
+}
+
+func (s *Store) Update(ctx context.Context, fn func(kv.Tx) error) error {
+	return s.kvStore.Update(ctx, fn)
+}
+
+// ListOverrides returns every persisted override.
+func (s *Store) ListOverrides(ctx context.Context, tx kv.Tx) ([]Override, error) {
+	b, err := tx.Bucket(overridesBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	cur, err := b.ForwardCursor(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var overrides []Override
+	err = kv.WalkCursor(ctx, cur, func(k, v []byte) (bool, error) {
+		var o Override
+		if err := json.Unmarshal(v, &o); err != nil {
+			return false, err
+		}
+		overrides = append(overrides, o)
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+// PutOverride creates or replaces an override.
+func (s *Store) PutOverride(ctx context.Context, tx kv.Tx, o Override) error {
+	key, err := encodeOverrideKey(o.OrgID, o.Key)
+	if err != nil {
+		return err
+	}
+	val, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+
+	b, err := tx.Bucket(overridesBucket)
+	if err != nil {
+		return err
+	}
+	return b.Put(key, val)
+}
+
+// DeleteOverride removes an override, if it exists.
+func (s *Store) DeleteOverride(ctx context.Context, tx kv.Tx, orgID *platform.ID, flagKey string) error {
+	key, err := encodeOverrideKey(orgID, flagKey)
+	if err != nil {
+		return err
+	}
+
+	b, err := tx.Bucket(overridesBucket)
+	if err != nil {
+		return err
+	}
+	return b.Delete(key)
+}
+
+func encodeOverrideKey(orgID *platform.ID, flagKey string) ([]byte, error) {
+	scope := globalScope
+	if orgID != nil {
+		var err error
+		if scope, err = orgID.Encode(); err != nil {
+			return nil, err
+		}
+	}
+
+	key := make([]byte, 0, len(scope)+len(flagKey))
+	key = append(key, scope...)
+	key = append(key, flagKey...)
+	return key, nil
+}
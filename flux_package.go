@@ -0,0 +1,97 @@
+package influxdb
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// FluxPackageImportPrefix is the prefix of the import paths of the Flux
+// packages of organizations. A query imports the enabled version of a package
+// with "org/<name>", or a specific version with "org/<name>@<version>".
+const FluxPackageImportPrefix = "org/"
+
+// FluxPackage is a version of a Flux package installed for an organization.
+// The variables of its source not starting with an underscore are exported to
+// the queries of the organization importing it.
+type FluxPackage struct {
+	ID      platform.ID `json:"id" db:"id"`
+	OrgID   platform.ID `json:"orgID" db:"org_id"`
+	Name    string      `json:"name" db:"name"`
+	Version string      `json:"version" db:"version"`
+	Source  string      `json:"source,omitempty" db:"source"`
+	// Enabled is whether the version is the one imported without a version.
+	// At most one version of a package is enabled, and the versions of a
+	// package none of whose versions is enabled cannot be imported.
+	Enabled   bool      `json:"enabled" db:"enabled"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+var (
+	fluxPackageNamePattern    = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	fluxPackageVersionPattern = regexp.MustCompile(`^[0-9A-Za-z][0-9A-Za-z._-]*$`)
+)
+
+// Valid returns an error if the name or version of the package is invalid. The
+// source is not parsed.
+func (p *FluxPackage) Valid() error {
+	if !p.OrgID.Valid() {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "flux package orgID is required",
+		}
+	}
+	if !fluxPackageNamePattern.MatchString(p.Name) {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "flux package name must be a lowercase identifier",
+		}
+	}
+	if !fluxPackageVersionPattern.MatchString(p.Version) {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "flux package version must contain only letters, digits, '.', '_' and '-'",
+		}
+	}
+	if p.Source == "" {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "flux package source is required",
+		}
+	}
+	return nil
+}
+
+// FluxPackageFilter represents a set of filters that restrict the returned
+// Flux packages.
+type FluxPackageFilter struct {
+	OrgID platform.ID
+	Name  *string
+}
+
+// FluxPackageService manages the Flux packages of organizations.
+type FluxPackageService interface {
+	// InstallFluxPackage installs a version of a package and sets p.ID with
+	// the new identifier. A version cannot be installed twice.
+	InstallFluxPackage(ctx context.Context, p *FluxPackage) error
+
+	// FindFluxPackages returns the versions of the packages matching filter,
+	// without their source.
+	FindFluxPackages(ctx context.Context, filter FluxPackageFilter) ([]*FluxPackage, error)
+
+	// FindFluxPackage returns a version of a package of an organization.
+	FindFluxPackage(ctx context.Context, orgID platform.ID, name, version string) (*FluxPackage, error)
+
+	// EnableFluxPackage enables a version of a package, disabling its other
+	// versions.
+	EnableFluxPackage(ctx context.Context, orgID platform.ID, name, version string) error
+
+	// DisableFluxPackage disables all the versions of a package.
+	DisableFluxPackage(ctx context.Context, orgID platform.ID, name string) error
+
+	// DeleteFluxPackage removes a version of a package.
+	DeleteFluxPackage(ctx context.Context, orgID platform.ID, name, version string) error
+}
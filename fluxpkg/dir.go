@@ -0,0 +1,91 @@
+package fluxpkg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	ierrors "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"go.uber.org/zap"
+)
+
+// fluxExt is the extension of the files of package versions.
+const fluxExt = ".flux"
+
+// LoadDir installs the packages of a directory laid out as
+// <orgID>/<name>/<version>.flux. The versions already installed are kept, so
+// the source of an installed version is not changed by editing its file.
+// Loaded versions are not enabled.
+func (s *Service) LoadDir(ctx context.Context, log *zap.Logger, dir string) error {
+	orgs, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, org := range orgs {
+		if !org.IsDir() {
+			continue
+		}
+		orgID, err := platform.IDFromString(org.Name())
+		if err != nil {
+			return fmt.Errorf("flux package directory %s is not named after an org ID: %w", filepath.Join(dir, org.Name()), err)
+		}
+		names, err := os.ReadDir(filepath.Join(dir, org.Name()))
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if !name.IsDir() {
+				continue
+			}
+			pkgDir := filepath.Join(dir, org.Name(), name.Name())
+			versions, err := os.ReadDir(pkgDir)
+			if err != nil {
+				return err
+			}
+			for _, v := range versions {
+				if v.IsDir() || filepath.Ext(v.Name()) != fluxExt {
+					continue
+				}
+				if err := s.loadFile(ctx, log, *orgID, name.Name(), filepath.Join(pkgDir, v.Name())); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Service) loadFile(ctx context.Context, log *zap.Logger, orgID platform.ID, name, file string) error {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	p := &influxdb.FluxPackage{
+		OrgID:   orgID,
+		Name:    name,
+		Version: strings.TrimSuffix(filepath.Base(file), fluxExt),
+		Source:  string(src),
+	}
+
+	installed, err := s.FindFluxPackage(ctx, p.OrgID, p.Name, p.Version)
+	if err == nil {
+		if installed.Source != p.Source {
+			log.Warn("Flux package version already installed with another source, keeping it",
+				zap.Stringer("orgID", orgID), zap.String("name", p.Name), zap.String("version", p.Version))
+		}
+		return nil
+	}
+	if ierrors.ErrorCode(err) != ierrors.ENotFound {
+		return err
+	}
+
+	if err := s.InstallFluxPackage(ctx, p); err != nil {
+		return fmt.Errorf("installing flux package %s: %w", file, err)
+	}
+	log.Info("Installed flux package", zap.Stringer("orgID", orgID), zap.String("name", p.Name), zap.String("version", p.Version))
+	return nil
+}
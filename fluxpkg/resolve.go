@@ -0,0 +1,233 @@
+package fluxpkg
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/ast/astutil"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/flux/parser"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	ierrors "github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// ResolvePackages returns the compiler of a Flux query of an organization with
+// the imports of the packages of the organization replaced by their
+// definitions. Other compilers are returned as is.
+func (s *Service) ResolvePackages(ctx context.Context, orgID platform.ID, compiler flux.Compiler) (flux.Compiler, error) {
+	switch c := compiler.(type) {
+	case lang.FluxCompiler:
+		q, err := s.resolve(ctx, orgID, c.Query)
+		if err != nil {
+			return nil, err
+		}
+		c.Query = q
+		return c, nil
+	case *lang.FluxCompiler:
+		q, err := s.resolve(ctx, orgID, c.Query)
+		if err != nil {
+			return nil, err
+		}
+		cc := *c
+		cc.Query = q
+		return &cc, nil
+	}
+	return compiler, nil
+}
+
+// resolve rewrites a query importing packages of the organization. Each
+// import of a package becomes a function returning a record of the exports
+// of the package, and a variable named as the import set to its result:
+//
+//	_org_<name> = () => { <package body>; return {<exports>} }
+//	<name> = _org_<name>()
+//
+// The imports of the packages are added to those of the query.
+func (s *Service) resolve(ctx context.Context, orgID platform.ID, query string) (string, error) {
+	if !strings.Contains(query, `"`+influxdb.FluxPackageImportPrefix) {
+		return query, nil
+	}
+	pkg := parser.ParseSource(query)
+	if ast.Check(pkg) > 0 || len(pkg.Files) != 1 {
+		// The compiler reports the errors of the query.
+		return query, nil
+	}
+	file := pkg.Files[0]
+
+	imports := newImportSet()
+	for _, d := range file.Imports {
+		if !strings.HasPrefix(d.Path.Value, influxdb.FluxPackageImportPrefix) {
+			if err := imports.add(d); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	var prelude []ast.Statement
+	for _, d := range file.Imports {
+		ref := strings.TrimPrefix(d.Path.Value, influxdb.FluxPackageImportPrefix)
+		if ref == d.Path.Value {
+			continue
+		}
+		p, err := s.findImport(ctx, orgID, ref)
+		if err != nil {
+			return "", err
+		}
+		f, err := parsePackage(p.Source)
+		if err != nil {
+			return "", err
+		}
+		for _, pd := range f.Imports {
+			if err := imports.add(pd); err != nil {
+				return "", &ierrors.Error{
+					Code: ierrors.EInvalid,
+					Msg:  fmt.Sprintf("flux package %s@%s conflicts with the imports of the query", p.Name, p.Version),
+					Err:  err,
+				}
+			}
+		}
+		name := p.Name
+		if d.As != nil {
+			name = d.As.Name
+		}
+		prelude = append(prelude, packageStatements(name, f)...)
+	}
+
+	resolved := *file
+	resolved.Imports = imports.decls
+	resolved.Body = append(prelude, file.Body...)
+	return astutil.Format(&resolved)
+}
+
+// findImport returns the package imported as ref, either <name> for the
+// enabled version of the package or <name>@<version>.
+func (s *Service) findImport(ctx context.Context, orgID platform.ID, ref string) (*influxdb.FluxPackage, error) {
+	name, version, versioned := strings.Cut(ref, "@")
+	enabled, err := s.findEnabledFluxPackage(ctx, orgID, name)
+	if err != nil {
+		if ierrors.ErrorCode(err) == ierrors.ENotFound {
+			return nil, &ierrors.Error{
+				Code: ierrors.ENotFound,
+				Msg:  fmt.Sprintf("flux package %s is not installed or not enabled", name),
+			}
+		}
+		return nil, err
+	}
+	if !versioned || version == enabled.Version {
+		return enabled, nil
+	}
+	p, err := s.FindFluxPackage(ctx, orgID, name, version)
+	if err != nil {
+		if ierrors.ErrorCode(err) == ierrors.ENotFound {
+			return nil, &ierrors.Error{
+				Code: ierrors.ENotFound,
+				Msg:  fmt.Sprintf("version %s of flux package %s is not installed", version, name),
+			}
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// parsePackage parses the source of a package. A package may import the
+// packages of Flux, but not those of organizations, and its body may only
+// assign variables.
+func parsePackage(source string) (*ast.File, error) {
+	pkg := parser.ParseSource(source)
+	if ast.Check(pkg) > 0 {
+		return nil, &ierrors.Error{
+			Code: ierrors.EInvalid,
+			Msg:  "invalid flux package source",
+			Err:  ast.GetError(pkg),
+		}
+	}
+	f := pkg.Files[0]
+	for _, d := range f.Imports {
+		if strings.HasPrefix(d.Path.Value, influxdb.FluxPackageImportPrefix) {
+			return nil, &ierrors.Error{
+				Code: ierrors.EInvalid,
+				Msg:  fmt.Sprintf("flux packages cannot import other flux packages: %q", d.Path.Value),
+			}
+		}
+	}
+	exports := 0
+	for _, stmt := range f.Body {
+		a, ok := stmt.(*ast.VariableAssignment)
+		if !ok {
+			return nil, &ierrors.Error{
+				Code: ierrors.EInvalid,
+				Msg:  fmt.Sprintf("flux packages may only assign variables, found a %s", stmt.Type()),
+			}
+		}
+		if !strings.HasPrefix(a.ID.Name, "_") {
+			exports++
+		}
+	}
+	if exports == 0 {
+		return nil, &ierrors.Error{
+			Code: ierrors.EInvalid,
+			Msg:  "flux packages must export a variable not starting with an underscore",
+		}
+	}
+	return f, nil
+}
+
+// packageStatements returns the statements defining the package f as the
+// variable name.
+func packageStatements(name string, f *ast.File) []ast.Statement {
+	exports := &ast.ObjectExpression{}
+	for _, stmt := range f.Body {
+		id := stmt.(*ast.VariableAssignment).ID
+		if !strings.HasPrefix(id.Name, "_") {
+			exports.Properties = append(exports.Properties, &ast.Property{
+				Key:   &ast.Identifier{Name: id.Name},
+				Value: &ast.Identifier{Name: id.Name},
+			})
+		}
+	}
+	body := append(append([]ast.Statement(nil), f.Body...), &ast.ReturnStatement{Argument: exports})
+
+	fn := "_org_" + name
+	return []ast.Statement{
+		&ast.VariableAssignment{
+			ID:   &ast.Identifier{Name: fn},
+			Init: &ast.FunctionExpression{Body: &ast.Block{Body: body}},
+		},
+		&ast.VariableAssignment{
+			ID:   &ast.Identifier{Name: name},
+			Init: &ast.CallExpression{Callee: &ast.Identifier{Name: fn}},
+		},
+	}
+}
+
+// importSet is the imports of a resolved query, each imported once.
+type importSet struct {
+	paths map[string]string // by name
+	decls []*ast.ImportDeclaration
+}
+
+func newImportSet() *importSet {
+	return &importSet{paths: make(map[string]string)}
+}
+
+// add adds an import unless it is already imported under the same name.
+func (s *importSet) add(d *ast.ImportDeclaration) error {
+	name := path.Base(d.Path.Value)
+	if d.As != nil {
+		name = d.As.Name
+	}
+	if p, ok := s.paths[name]; ok {
+		if p != d.Path.Value {
+			return fmt.Errorf("%s is imported from both %q and %q", name, p, d.Path.Value)
+		}
+		return nil
+	}
+	s.paths[name] = d.Path.Value
+	s.decls = append(s.decls, d)
+	return nil
+}
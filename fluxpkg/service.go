@@ -0,0 +1,194 @@
+// Package fluxpkg manages the Flux packages installed for organizations, and
+// resolves their imports in the Flux queries of the organizations.
+package fluxpkg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	ierrors "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/snowflake"
+	"github.com/influxdata/influxdb/v2/sqlite"
+	"github.com/mattn/go-sqlite3"
+)
+
+var (
+	errFluxPackageNotFound = &ierrors.Error{
+		Code: ierrors.ENotFound,
+		Msg:  "flux package not found",
+	}
+
+	errFluxPackageExists = &ierrors.Error{
+		Code: ierrors.EConflict,
+		Msg:  "flux package version is already installed",
+	}
+)
+
+var _ influxdb.FluxPackageService = (*Service)(nil)
+
+// Service stores the Flux packages of organizations in SQLite.
+type Service struct {
+	store       *sqlite.SqlStore
+	idGenerator platform.IDGenerator
+}
+
+// NewService constructs a Service.
+func NewService(store *sqlite.SqlStore) *Service {
+	return &Service{
+		store:       store,
+		idGenerator: snowflake.NewIDGenerator(),
+	}
+}
+
+// InstallFluxPackage installs a version of a package, disabled. Its source
+// must be a valid package.
+func (s *Service) InstallFluxPackage(ctx context.Context, p *influxdb.FluxPackage) error {
+	if err := p.Valid(); err != nil {
+		return err
+	}
+	if _, err := parsePackage(p.Source); err != nil {
+		return err
+	}
+
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	p.ID = s.idGenerator.ID()
+	p.Enabled = false
+	p.CreatedAt = time.Now().UTC()
+
+	query := `
+		INSERT INTO flux_packages (id, org_id, name, version, source, enabled, created_at)
+		VALUES (:id, :org_id, :name, :version, :source, :enabled, :created_at)`
+
+	if _, err := s.store.DB.NamedExecContext(ctx, query, p); err != nil {
+		if sqlErr, ok := err.(sqlite3.Error); ok && sqlErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return errFluxPackageExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *Service) FindFluxPackages(ctx context.Context, filter influxdb.FluxPackageFilter) ([]*influxdb.FluxPackage, error) {
+	q := sq.Select("id", "org_id", "name", "version", "enabled", "created_at").
+		From("flux_packages").
+		Where(sq.Eq{"org_id": filter.OrgID}).
+		OrderBy("name", "created_at")
+
+	if filter.Name != nil {
+		q = q.Where(sq.Eq{"name": *filter.Name})
+	}
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	ps := []*influxdb.FluxPackage{}
+	if err := s.store.DB.SelectContext(ctx, &ps, query, args...); err != nil {
+		return nil, err
+	}
+	return ps, nil
+}
+
+func (s *Service) FindFluxPackage(ctx context.Context, orgID platform.ID, name, version string) (*influxdb.FluxPackage, error) {
+	return s.findFluxPackage(ctx, sq.Eq{"org_id": orgID, "name": name, "version": version})
+}
+
+// findEnabledFluxPackage returns the enabled version of a package.
+func (s *Service) findEnabledFluxPackage(ctx context.Context, orgID platform.ID, name string) (*influxdb.FluxPackage, error) {
+	return s.findFluxPackage(ctx, sq.Eq{"org_id": orgID, "name": name, "enabled": true})
+}
+
+func (s *Service) findFluxPackage(ctx context.Context, where sq.Eq) (*influxdb.FluxPackage, error) {
+	q := sq.Select("id", "org_id", "name", "version", "source", "enabled", "created_at").
+		From("flux_packages").
+		Where(where)
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var p influxdb.FluxPackage
+	if err := s.store.DB.GetContext(ctx, &p, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errFluxPackageNotFound
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (s *Service) EnableFluxPackage(ctx context.Context, orgID platform.ID, name, version string) error {
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	if _, err := s.FindFluxPackage(ctx, orgID, name, version); err != nil {
+		return err
+	}
+
+	q := sq.Update("flux_packages").
+		Set("enabled", sq.Expr("version = ?", version)).
+		Where(sq.Eq{"org_id": orgID, "name": name})
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = s.store.DB.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (s *Service) DisableFluxPackage(ctx context.Context, orgID platform.ID, name string) error {
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	q := sq.Update("flux_packages").
+		Set("enabled", false).
+		Where(sq.Eq{"org_id": orgID, "name": name})
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return err
+	}
+	res, err := s.store.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return errFluxPackageNotFound
+	}
+	return nil
+}
+
+func (s *Service) DeleteFluxPackage(ctx context.Context, orgID platform.ID, name, version string) error {
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	q := sq.Delete("flux_packages").
+		Where(sq.Eq{"org_id": orgID, "name": name, "version": version}).
+		Suffix("RETURNING id")
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return err
+	}
+
+	var d platform.ID
+	if err := s.store.DB.GetContext(ctx, &d, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errFluxPackageNotFound
+		}
+		return err
+	}
+	return nil
+}
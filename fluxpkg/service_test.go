@@ -0,0 +1,141 @@
+package fluxpkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/flux/parser"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	ierrors "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/sqlite"
+	"github.com/influxdata/influxdb/v2/sqlite/migrations"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+var (
+	ctx   = context.Background()
+	orgID = platform.ID(10)
+)
+
+const mathSource = `
+import "strings"
+
+_scale = 10.0
+scaled = (v) => v * _scale
+upper = (s) => strings.toUpper(v: s)
+`
+
+func newTestService(t *testing.T) *Service {
+	store := sqlite.NewTestStore(t)
+	require.NoError(t, sqlite.NewMigrator(store, zaptest.NewLogger(t)).Up(ctx, migrations.AllUp))
+
+	return &Service{
+		store:       store,
+		idGenerator: mock.NewIncrementingIDGenerator(1),
+	}
+}
+
+func install(t *testing.T, svc *Service, name, version, source string) {
+	t.Helper()
+	require.NoError(t, svc.InstallFluxPackage(ctx, &influxdb.FluxPackage{
+		OrgID:   orgID,
+		Name:    name,
+		Version: version,
+		Source:  source,
+	}))
+}
+
+func TestService_Install(t *testing.T) {
+	svc := newTestService(t)
+	install(t, svc, "mathx", "1.0.0", mathSource)
+
+	err := svc.InstallFluxPackage(ctx, &influxdb.FluxPackage{OrgID: orgID, Name: "mathx", Version: "1.0.0", Source: mathSource})
+	require.Equal(t, errFluxPackageExists, err)
+
+	for _, source := range []string{
+		`x = (`,
+		`import "org/other"
+x = 1`,
+		`from(bucket: "b")`,
+		`_x = 1`,
+	} {
+		err := svc.InstallFluxPackage(ctx, &influxdb.FluxPackage{OrgID: orgID, Name: "bad", Version: "1", Source: source})
+		require.Equal(t, ierrors.EInvalid, ierrors.ErrorCode(err), source)
+	}
+
+	ps, err := svc.FindFluxPackages(ctx, influxdb.FluxPackageFilter{OrgID: orgID})
+	require.NoError(t, err)
+	require.Len(t, ps, 1)
+	require.False(t, ps[0].Enabled)
+	require.Empty(t, ps[0].Source)
+}
+
+func TestService_Enable(t *testing.T) {
+	svc := newTestService(t)
+	install(t, svc, "mathx", "1.0.0", mathSource)
+	install(t, svc, "mathx", "2.0.0", mathSource)
+
+	require.NoError(t, svc.EnableFluxPackage(ctx, orgID, "mathx", "1.0.0"))
+	require.NoError(t, svc.EnableFluxPackage(ctx, orgID, "mathx", "2.0.0"))
+	require.Equal(t, errFluxPackageNotFound, svc.EnableFluxPackage(ctx, orgID, "mathx", "3.0.0"))
+
+	p, err := svc.findEnabledFluxPackage(ctx, orgID, "mathx")
+	require.NoError(t, err)
+	require.Equal(t, "2.0.0", p.Version)
+
+	require.NoError(t, svc.DisableFluxPackage(ctx, orgID, "mathx"))
+	_, err = svc.findEnabledFluxPackage(ctx, orgID, "mathx")
+	require.Equal(t, errFluxPackageNotFound, err)
+
+	require.NoError(t, svc.DeleteFluxPackage(ctx, orgID, "mathx", "1.0.0"))
+	require.Equal(t, errFluxPackageNotFound, svc.DeleteFluxPackage(ctx, orgID, "mathx", "1.0.0"))
+}
+
+func TestService_ResolvePackages(t *testing.T) {
+	svc := newTestService(t)
+	install(t, svc, "mathx", "1.0.0", mathSource)
+	install(t, svc, "mathx", "2.0.0", `double = (v) => v * 2.0`)
+
+	query := `import "strings"
+import "org/mathx"
+import m1 "org/mathx@1.0.0"
+
+from(bucket: "b") |> map(fn: (r) => ({r with _value: mathx.double(v: m1.scaled(v: r._value))}))`
+
+	// The packages of an org must be enabled to be imported.
+	_, err := svc.ResolvePackages(ctx, orgID, lang.FluxCompiler{Query: query})
+	require.Equal(t, ierrors.ENotFound, ierrors.ErrorCode(err))
+
+	require.NoError(t, svc.EnableFluxPackage(ctx, orgID, "mathx", "2.0.0"))
+	c, err := svc.ResolvePackages(ctx, orgID, lang.FluxCompiler{Query: query})
+	require.NoError(t, err)
+
+	pkg := parser.ParseSource(c.(lang.FluxCompiler).Query)
+	require.Zero(t, ast.Check(pkg))
+	f := pkg.Files[0]
+	require.Len(t, f.Imports, 1)
+	require.Equal(t, "strings", f.Imports[0].Path.Value)
+
+	var names []string
+	for _, stmt := range f.Body {
+		if a, ok := stmt.(*ast.VariableAssignment); ok {
+			names = append(names, a.ID.Name)
+		}
+	}
+	require.Equal(t, []string{"_org_mathx", "mathx", "_org_m1", "m1"}, names)
+
+	// Queries importing no packages are not rewritten.
+	plain := `from(bucket: "b") |> range(start: -1h)`
+	c, err = svc.ResolvePackages(ctx, orgID, lang.FluxCompiler{Query: plain})
+	require.NoError(t, err)
+	require.Equal(t, plain, c.(lang.FluxCompiler).Query)
+
+	// The packages of an org cannot be imported by other orgs.
+	_, err = svc.ResolvePackages(ctx, orgID+1, lang.FluxCompiler{Query: query})
+	require.Equal(t, ierrors.ENotFound, ierrors.ErrorCode(err))
+}
@@ -0,0 +1,203 @@
+package transport
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const prefixFluxPackages = "/api/v2/fluxpackages"
+
+// FluxPackageHandler is the handler for the flux package service. Reading the
+// packages of an organization requires reading the organization, and changing
+// them writing it.
+type FluxPackageHandler struct {
+	chi.Router
+
+	api *kithttp.API
+	log *zap.Logger
+
+	fluxPackageService influxdb.FluxPackageService
+}
+
+// NewFluxPackageHandler returns a new instance of FluxPackageHandler.
+func NewFluxPackageHandler(log *zap.Logger, fluxPackageService influxdb.FluxPackageService) *FluxPackageHandler {
+	h := &FluxPackageHandler{
+		log:                log,
+		api:                kithttp.NewAPI(kithttp.WithLog(log)),
+		fluxPackageService: fluxPackageService,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+	r.Route("/", func(r chi.Router) {
+		r.Get("/", h.handleGetFluxPackages)
+		r.Post("/", h.handlePostFluxPackage)
+
+		r.Route("/{name}", func(r chi.Router) {
+			r.Post("/disable", h.handleDisableFluxPackage)
+			r.Route("/{version}", func(r chi.Router) {
+				r.Get("/", h.handleGetFluxPackage)
+				r.Delete("/", h.handleDeleteFluxPackage)
+				r.Post("/enable", h.handleEnableFluxPackage)
+			})
+		})
+	})
+	h.Router = r
+	return h
+}
+
+// Prefix returns the mounting prefix for the handler.
+func (h *FluxPackageHandler) Prefix() string {
+	return prefixFluxPackages
+}
+
+type fluxPackagesResponse struct {
+	Packages []*influxdb.FluxPackage `json:"packages"`
+}
+
+type postFluxPackageRequest struct {
+	OrgID   platform.ID `json:"orgID"`
+	Name    string      `json:"name"`
+	Version string      `json:"version"`
+	Source  string      `json:"source"`
+}
+
+// decodeOrgID returns the orgID parameter of a request, if the caller may
+// read the organization, or write it when write is set.
+func (h *FluxPackageHandler) decodeOrgID(w http.ResponseWriter, r *http.Request, write bool) (platform.ID, bool) {
+	orgID, err := platform.IDFromString(r.URL.Query().Get("orgID"))
+	if err != nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "orgID is required",
+			Err:  err,
+		})
+		return 0, false
+	}
+	if err := authorizeOrg(r, *orgID, write); err != nil {
+		h.api.Err(w, r, err)
+		return 0, false
+	}
+	return *orgID, true
+}
+
+func authorizeOrg(r *http.Request, orgID platform.ID, write bool) error {
+	var err error
+	if write {
+		_, _, err = authorizer.AuthorizeWriteOrg(r.Context(), orgID)
+	} else {
+		_, _, err = authorizer.AuthorizeReadOrg(r.Context(), orgID)
+	}
+	return err
+}
+
+// handleGetFluxPackages lists the package versions of an organization.
+func (h *FluxPackageHandler) handleGetFluxPackages(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := h.decodeOrgID(w, r, false)
+	if !ok {
+		return
+	}
+	filter := influxdb.FluxPackageFilter{OrgID: orgID}
+	if name := r.URL.Query().Get("name"); name != "" {
+		filter.Name = &name
+	}
+
+	ps, err := h.fluxPackageService.FindFluxPackages(r.Context(), filter)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, fluxPackagesResponse{Packages: ps})
+}
+
+// handlePostFluxPackage installs a package version.
+func (h *FluxPackageHandler) handlePostFluxPackage(w http.ResponseWriter, r *http.Request) {
+	var req postFluxPackageRequest
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if err := authorizeOrg(r, req.OrgID, true); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	p := &influxdb.FluxPackage{
+		OrgID:   req.OrgID,
+		Name:    req.Name,
+		Version: req.Version,
+		Source:  req.Source,
+	}
+	if err := h.fluxPackageService.InstallFluxPackage(r.Context(), p); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Flux package installed", zap.Stringer("orgID", p.OrgID), zap.String("name", p.Name), zap.String("version", p.Version))
+	h.api.Respond(w, r, http.StatusCreated, p)
+}
+
+// handleGetFluxPackage returns a package version with its source.
+func (h *FluxPackageHandler) handleGetFluxPackage(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := h.decodeOrgID(w, r, false)
+	if !ok {
+		return
+	}
+	p, err := h.fluxPackageService.FindFluxPackage(r.Context(), orgID, chi.URLParam(r, "name"), chi.URLParam(r, "version"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, p)
+}
+
+// handleEnableFluxPackage makes a version the one imported without a version.
+func (h *FluxPackageHandler) handleEnableFluxPackage(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := h.decodeOrgID(w, r, true)
+	if !ok {
+		return
+	}
+	if err := h.fluxPackageService.EnableFluxPackage(r.Context(), orgID, chi.URLParam(r, "name"), chi.URLParam(r, "version")); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusNoContent, nil)
+}
+
+// handleDisableFluxPackage disables a package, which its queries can no
+// longer import.
+func (h *FluxPackageHandler) handleDisableFluxPackage(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := h.decodeOrgID(w, r, true)
+	if !ok {
+		return
+	}
+	if err := h.fluxPackageService.DisableFluxPackage(r.Context(), orgID, chi.URLParam(r, "name")); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusNoContent, nil)
+}
+
+// handleDeleteFluxPackage uninstalls a package version.
+func (h *FluxPackageHandler) handleDeleteFluxPackage(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := h.decodeOrgID(w, r, true)
+	if !ok {
+		return
+	}
+	if err := h.fluxPackageService.DeleteFluxPackage(r.Context(), orgID, chi.URLParam(r, "name"), chi.URLParam(r, "version")); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusNoContent, nil)
+}
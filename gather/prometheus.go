@@ -82,54 +82,8 @@ func (p *prometheusScraper) parse(r io.Reader, header http.Header, target influx
 		}
 	}
 	ms := make([]Metrics, 0)
-
-	// read metrics
 	for name, family := range metricFamilies {
-		for _, m := range family.Metric {
-			// reading tags
-			tags := makeLabels(m)
-			// reading fields
-			var fields map[string]interface{}
-			switch family.GetType() {
-			case dto.MetricType_SUMMARY:
-				// summary metric
-				fields = makeQuantiles(m)
-				fields["count"] = float64(m.GetSummary().GetSampleCount())
-
-				ss := float64(m.GetSummary().GetSampleSum())
-				if !math.IsNaN(ss) {
-					fields["sum"] = ss
-				}
-			case dto.MetricType_HISTOGRAM:
-				// histogram metric
-				fields = makeBuckets(m)
-				fields["count"] = float64(m.GetHistogram().GetSampleCount())
-
-				ss := float64(m.GetHistogram().GetSampleSum())
-				if !math.IsNaN(ss) {
-					fields["sum"] = ss
-				}
-			default:
-				// standard metric
-				fields = getNameAndValue(m)
-			}
-			if len(fields) == 0 {
-				continue
-			}
-			tm := now
-			if m.TimestampMs != nil && *m.TimestampMs > 0 {
-				tm = time.Unix(0, *m.TimestampMs*1000000)
-			}
-			me := Metrics{
-				Timestamp: tm,
-				Tags:      tags,
-				Fields:    fields,
-				Name:      name,
-				Type:      family.GetType(),
-			}
-			ms = append(ms, me)
-		}
-
+		ms = appendFamily(ms, name, family, now)
 	}
 
 	collected = MetricsCollection{
@@ -141,6 +95,63 @@ func (p *prometheusScraper) parse(r io.Reader, header http.Header, target influx
 	return collected, nil
 }
 
+// FamilyMetrics converts gathered metric families to metrics, timestamped now
+// unless the metric has a timestamp of its own.
+func FamilyMetrics(families []*dto.MetricFamily, now time.Time) MetricsSlice {
+	ms := make(MetricsSlice, 0, len(families))
+	for _, family := range families {
+		ms = appendFamily(ms, family.GetName(), family, now)
+	}
+	return ms
+}
+
+func appendFamily(ms []Metrics, name string, family *dto.MetricFamily, now time.Time) []Metrics {
+	for _, m := range family.Metric {
+		// reading tags
+		tags := makeLabels(m)
+		// reading fields
+		var fields map[string]interface{}
+		switch family.GetType() {
+		case dto.MetricType_SUMMARY:
+			// summary metric
+			fields = makeQuantiles(m)
+			fields["count"] = float64(m.GetSummary().GetSampleCount())
+
+			ss := float64(m.GetSummary().GetSampleSum())
+			if !math.IsNaN(ss) {
+				fields["sum"] = ss
+			}
+		case dto.MetricType_HISTOGRAM:
+			// histogram metric
+			fields = makeBuckets(m)
+			fields["count"] = float64(m.GetHistogram().GetSampleCount())
+
+			ss := float64(m.GetHistogram().GetSampleSum())
+			if !math.IsNaN(ss) {
+				fields["sum"] = ss
+			}
+		default:
+			// standard metric
+			fields = getNameAndValue(m)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		tm := now
+		if m.TimestampMs != nil && *m.TimestampMs > 0 {
+			tm = time.Unix(0, *m.TimestampMs*1000000)
+		}
+		ms = append(ms, Metrics{
+			Timestamp: tm,
+			Tags:      tags,
+			Fields:    fields,
+			Name:      name,
+			Type:      family.GetType(),
+		})
+	}
+	return ms
+}
+
 // Get labels from metric
 func makeLabels(m *dto.Metric) map[string]string {
 	result := map[string]string{}
@@ -3,19 +3,25 @@ package http
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/influxdata/httprouter"
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/authorizer"
+	platcontext "github.com/influxdata/influxdb/v2/context"
 	"github.com/influxdata/influxdb/v2/dbrp"
+	"github.com/influxdata/influxdb/v2/deletejob"
+	"github.com/influxdata/influxdb/v2/http/legacy"
 	"github.com/influxdata/influxdb/v2/http/metric"
+	"github.com/influxdata/influxdb/v2/http/points"
 	"github.com/influxdata/influxdb/v2/influxql"
 	"github.com/influxdata/influxdb/v2/kit/feature"
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kit/prom"
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/membudget"
 	"github.com/influxdata/influxdb/v2/query"
 	"github.com/influxdata/influxdb/v2/query/fluxlang"
 	"github.com/influxdata/influxdb/v2/static"
@@ -39,10 +45,20 @@ type APIBackend struct {
 	FluxLogEnabled bool
 	errors.HTTPErrorHandler
 	SessionRenewDisabled bool
+	// SessionIdleTimeout is how far each request extends its session. It
+	// defaults to platform.RenewSessionTime.
+	SessionIdleTimeout time.Duration
+	// TrustPeer, if set, grants operator access to tokenless requests from
+	// local processes connected over a unix socket whose credentials it accepts.
+	TrustPeer func(platcontext.PeerCredentials) bool
 	// MaxBatchSizeBytes is the maximum number of bytes which can be written
 	// in a single points batch
 	MaxBatchSizeBytes int64
 
+	// OrgMemory, if set, is charged the memory of the points batches written
+	// against the budgets of their organizations.
+	OrgMemory *membudget.Accountant
+
 	// WriteParserMaxBytes specifies the maximum number of bytes that may be allocated when processing a single
 	// write request. A value of zero specifies there is no limit.
 	WriteParserMaxBytes int
@@ -64,6 +80,8 @@ type APIBackend struct {
 
 	PointsWriter                    storage.PointsWriter
 	DeleteService                   influxdb.DeleteService
+	DeleteJobService                influxdb.DeleteJobService
+	DeleteCounter                   deletejob.Counter
 	BackupService                   influxdb.BackupService
 	SqlBackupRestoreService         influxdb.SqlBackupRestoreService
 	BucketManifestWriter            influxdb.BucketManifestWriter
@@ -74,6 +92,7 @@ type APIBackend struct {
 	AuthorizerV1                    influxdb.AuthorizerV1
 	OnboardingService               influxdb.OnboardingService
 	DBRPService                     influxdb.DBRPMappingService
+	DBRPAutoCreator                 legacy.DBRPAutoCreator
 	BucketService                   influxdb.BucketService
 	SessionService                  influxdb.SessionService
 	UserService                     influxdb.UserService
@@ -82,11 +101,13 @@ type APIBackend struct {
 	LabelService                    influxdb.LabelService
 	DashboardService                influxdb.DashboardService
 	DashboardOperationLogService    influxdb.DashboardOperationLogService
+	DashboardUsageService           influxdb.DashboardUsageService
 	BucketOperationLogService       influxdb.BucketOperationLogService
 	UserOperationLogService         influxdb.UserOperationLogService
 	OrganizationOperationLogService influxdb.OrganizationOperationLogService
 	SourceService                   influxdb.SourceService
 	VariableService                 influxdb.VariableService
+	VariableValuesService           influxdb.VariableValuesService
 	PasswordsService                influxdb.PasswordsService
 	InfluxqldService                influxql.ProxyQueryService
 	FluxService                     query.ProxyQueryService
@@ -117,6 +138,8 @@ func (b *APIBackend) PrometheusCollectors() []prometheus.Collector {
 		cs = append(cs, pc.PrometheusCollectors()...)
 	}
 
+	cs = append(cs, points.PrometheusCollectors()...)
+
 	return cs
 }
 
@@ -213,6 +236,7 @@ func NewAPIHandler(b *APIBackend, opts ...APIHandlerOptFn) *APIHandler {
 	writeBackend := NewWriteBackend(b.Logger.With(zap.String("handler", "write")), b)
 	h.Mount(prefixWrite, NewWriteHandler(b.Logger, writeBackend,
 		WithMaxBatchSizeBytes(b.MaxBatchSizeBytes),
+		WithOrgMemory(b.OrgMemory),
 		// WithParserOptions(
 		//	models.WithParserMaxBytes(b.WriteParserMaxBytes),
 		//	models.WithParserMaxLines(b.WriteParserMaxLines),
@@ -27,6 +27,18 @@ type AuthenticationHandler struct {
 	UserService          platform.UserService
 	TokenParser          *jsonweb.TokenParser
 	SessionRenewDisabled bool
+	// SessionIdleTimeout is how far each request extends its session. It
+	// defaults to platform.RenewSessionTime.
+	SessionIdleTimeout time.Duration
+
+	// TrustPeer reports whether a local process connected over a unix socket
+	// is granted operator access without presenting a token. Requests carrying
+	// a token or session are always authenticated with those instead.
+	TrustPeer func(platcontext.PeerCredentials) bool
+
+	// LabelResolver is set on the context of requests, to resolve the
+	// permissions targeting resources by label.
+	LabelResolver platform.LabelResolver
 
 	// This is only really used for it's lookup method the specific http
 	// handler used to register routes does not matter.
@@ -55,6 +67,7 @@ func (h *AuthenticationHandler) RegisterNoAuthRoute(method, path string) {
 const (
 	tokenAuthScheme   = "token"
 	sessionAuthScheme = "session"
+	peerAuthScheme    = "peer"
 )
 
 // ProbeAuthScheme probes the http request for the requests for token or cookie session.
@@ -87,6 +100,9 @@ func (h *AuthenticationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 
 	ctx := r.Context()
 	scheme, err := ProbeAuthScheme(r)
+	if err != nil && h.isTrustedPeer(ctx) {
+		scheme, err = peerAuthScheme, nil
+	}
 	if err != nil {
 		h.unauthorized(ctx, w, err)
 		return
@@ -94,6 +110,9 @@ func (h *AuthenticationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 
 	var auth platform.Authorizer
 	switch scheme {
+	case peerAuthScheme:
+		creds, _ := platcontext.GetPeerCredentials(ctx)
+		auth = &PeerAuthorizer{Credentials: creds}
 	case tokenAuthScheme:
 		auth, err = h.extractAuthorization(ctx, r)
 	case sessionAuthScheme:
@@ -122,6 +141,9 @@ func (h *AuthenticationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 	}
 
 	ctx = platcontext.SetAuthorizer(ctx, auth)
+	if h.LabelResolver != nil {
+		ctx = platcontext.SetLabelResolver(ctx, h.LabelResolver)
+	}
 
 	if span := opentracing.SpanFromContext(ctx); span != nil {
 		span.SetTag("user_id", auth.GetUserID().String())
@@ -130,6 +152,16 @@ func (h *AuthenticationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 	h.Handler.ServeHTTP(w, r.WithContext(ctx))
 }
 
+// isTrustedPeer reports whether the request was received over a unix socket
+// from a process that is granted access by its credentials alone.
+func (h *AuthenticationHandler) isTrustedPeer(ctx context.Context) bool {
+	if h.TrustPeer == nil {
+		return false
+	}
+	creds, ok := platcontext.GetPeerCredentials(ctx)
+	return ok && h.TrustPeer(creds)
+}
+
 func (h *AuthenticationHandler) isUserActive(ctx context.Context, auth platform.Authorizer) error {
 	u, err := h.UserService.FindUserByID(ctx, auth.GetUserID())
 	if err != nil {
@@ -178,7 +210,11 @@ func (h *AuthenticationHandler) extractSession(ctx context.Context, r *http.Requ
 
 	if !h.SessionRenewDisabled {
 		// if the session is not expired, renew the session
-		err = h.SessionService.RenewSession(ctx, s, time.Now().Add(platform.RenewSessionTime))
+		idle := h.SessionIdleTimeout
+		if idle <= 0 {
+			idle = platform.RenewSessionTime
+		}
+		err = h.SessionService.RenewSession(ctx, s, time.Now().Add(idle))
 		if err != nil {
 			return nil, err
 		}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/influxdata/influxdb/v2"
+	platcontext "github.com/influxdata/influxdb/v2/context"
 	platformhttp "github.com/influxdata/influxdb/v2/http"
 	"github.com/influxdata/influxdb/v2/jsonweb"
 	"github.com/influxdata/influxdb/v2/kit/platform"
@@ -398,3 +399,97 @@ func TestAuthenticationHandler_NoAuthRoutes(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthenticationHandler_TrustedPeer(t *testing.T) {
+	trustUID := func(uid uint32) func(platcontext.PeerCredentials) bool {
+		return func(creds platcontext.PeerCredentials) bool {
+			return creds.UID == uid
+		}
+	}
+
+	tests := []struct {
+		name      string
+		trustPeer func(platcontext.PeerCredentials) bool
+		creds     *platcontext.PeerCredentials
+		token     string
+		wantCode  int
+		wantKind  string
+	}{
+		{
+			name:      "trusted peer without token",
+			trustPeer: trustUID(1000),
+			creds:     &platcontext.PeerCredentials{PID: 42, UID: 1000, GID: 1000},
+			wantCode:  http.StatusOK,
+			wantKind:  "peer",
+		},
+		{
+			name:      "untrusted peer without token",
+			trustPeer: trustUID(1000),
+			creds:     &platcontext.PeerCredentials{PID: 42, UID: 1001, GID: 1001},
+			wantCode:  http.StatusUnauthorized,
+		},
+		{
+			name:      "trusted peer with token uses token",
+			trustPeer: trustUID(1000),
+			creds:     &platcontext.PeerCredentials{PID: 42, UID: 1000, GID: 1000},
+			token:     "abc123",
+			wantCode:  http.StatusOK,
+			wantKind:  "authorization",
+		},
+		{
+			name:      "no peer credentials",
+			trustPeer: trustUID(1000),
+			wantCode:  http.StatusUnauthorized,
+		},
+		{
+			name:     "peer trust disabled",
+			creds:    &platcontext.PeerCredentials{PID: 42, UID: 1000, GID: 1000},
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotKind string
+			h := platformhttp.NewAuthenticationHandler(zaptest.NewLogger(t), kithttp.NewErrorHandler(zaptest.NewLogger(t)))
+			h.AuthorizationService = &mock.AuthorizationService{
+				FindAuthorizationByTokenFn: func(ctx context.Context, token string) (*influxdb.Authorization, error) {
+					return &influxdb.Authorization{}, nil
+				},
+			}
+			h.SessionService = mock.NewSessionService()
+			h.UserService = &mock.UserService{
+				FindUserByIDFn: func(ctx context.Context, id platform.ID) (*influxdb.User, error) {
+					panic("user service should only be called with valid user ID")
+				},
+			}
+			h.TrustPeer = tt.trustPeer
+			h.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				auth, err := platcontext.GetAuthorizer(r.Context())
+				if err != nil {
+					t.Fatal(err)
+				}
+				gotKind = auth.Kind()
+				w.WriteHeader(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("POST", "http://any.url", nil)
+			if tt.creds != nil {
+				r = r.WithContext(platcontext.SetPeerCredentials(r.Context(), *tt.creds))
+			}
+			if tt.token != "" {
+				platformhttp.SetToken(tt.token, r)
+			}
+
+			h.ServeHTTP(w, r)
+
+			if got, want := w.Code, tt.wantCode; got != want {
+				t.Errorf("expected status code to be %d got %d", want, got)
+			}
+			if got, want := gotKind, tt.wantKind; got != want {
+				t.Errorf("expected authorizer kind to be %q got %q", want, got)
+			}
+		})
+	}
+}
@@ -1,9 +1,11 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/go-chi/chi"
@@ -34,22 +36,55 @@ type optValue []byte
 
 func (o optValue) MarshalJSON() ([]byte, error) { return o, nil }
 
+// ConfigReloadResult reports the outcome of re-reading the server configuration.
+type ConfigReloadResult struct {
+	// Applied lists the options whose new values took effect without a restart.
+	Applied []string `json:"applied"`
+	// Skipped lists the options which changed but require a restart to take effect,
+	// mapped to the reason they were not applied.
+	Skipped map[string]string `json:"skipped"`
+}
+
+// ConfigReloader re-reads the server configuration and applies any settings
+// which are safe to change at runtime.
+type ConfigReloader interface {
+	ReloadConfig(ctx context.Context) (*ConfigReloadResult, error)
+}
+
 type ConfigHandler struct {
 	chi.Router
 
 	log *zap.Logger
 	api *kithttp.API
 
+	opts     []cli.Opt
+	reloader ConfigReloader
+
+	mu     sync.RWMutex
 	config parsedOpt
 }
 
+// ConfigHandlerOptFn configures optional behavior of a ConfigHandler.
+type ConfigHandlerOptFn func(h *ConfigHandler)
+
+// WithConfigReloader enables the reload endpoint, delegating to r.
+func WithConfigReloader(r ConfigReloader) ConfigHandlerOptFn {
+	return func(h *ConfigHandler) {
+		h.reloader = r
+	}
+}
+
 // NewConfigHandler creates a handler that will return a JSON object with key/value pairs for the configuration values
 // used during the launcher startup. The opts slice provides a list of options names along with a pointer to their
 // value.
-func NewConfigHandler(log *zap.Logger, opts []cli.Opt) (*ConfigHandler, error) {
+func NewConfigHandler(log *zap.Logger, opts []cli.Opt, optFns ...ConfigHandlerOptFn) (*ConfigHandler, error) {
 	h := &ConfigHandler{
-		log: log,
-		api: kithttp.NewAPI(kithttp.WithLog(log)),
+		log:  log,
+		api:  kithttp.NewAPI(kithttp.WithLog(log)),
+		opts: opts,
+	}
+	for _, fn := range optFns {
+		fn(h)
 	}
 
 	if err := h.parseOptions(opts); err != nil {
@@ -65,6 +100,7 @@ func NewConfigHandler(log *zap.Logger, opts []cli.Opt) (*ConfigHandler, error) {
 	)
 
 	r.Get("/", h.handleGetConfig)
+	r.Post("/reload", h.handlePostReload)
 	h.Router = r
 	return h, nil
 }
@@ -74,7 +110,7 @@ func (h *ConfigHandler) Prefix() string {
 }
 
 func (h *ConfigHandler) parseOptions(opts []cli.Opt) error {
-	h.config = make(parsedOpt)
+	config := make(parsedOpt)
 
 	for _, o := range opts {
 		var b []byte
@@ -91,14 +127,44 @@ func (h *ConfigHandler) parseOptions(opts []cli.Opt) error {
 			return errInvalidType(o.DestP, o.Flag)
 		}
 
-		h.config[o.Flag] = b
+		config[o.Flag] = b
 	}
 
+	h.mu.Lock()
+	h.config = config
+	h.mu.Unlock()
 	return nil
 }
 
 func (h *ConfigHandler) handleGetConfig(w http.ResponseWriter, r *http.Request) {
-	h.api.Respond(w, r, http.StatusOK, map[string]parsedOpt{"config": h.config})
+	h.mu.RLock()
+	config := h.config
+	h.mu.RUnlock()
+	h.api.Respond(w, r, http.StatusOK, map[string]parsedOpt{"config": config})
+}
+
+func (h *ConfigHandler) handlePostReload(w http.ResponseWriter, r *http.Request) {
+	if h.reloader == nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.ENotImplemented,
+			Msg:  "configuration reload is not supported by this server",
+		})
+		return
+	}
+
+	res, err := h.reloader.ReloadConfig(r.Context())
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	// Refresh the cached values so subsequent reads reflect the reloaded configuration.
+	if err := h.parseOptions(h.opts); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusOK, res)
 }
 
 func (h *ConfigHandler) mwAuthorize(next http.Handler) http.Handler {
@@ -125,3 +125,68 @@ func TestConfigHandler_Authorization(t *testing.T) {
 		})
 	}
 }
+
+type fakeConfigReloader struct {
+	reload func(ctx context.Context) (*ConfigReloadResult, error)
+}
+
+func (f *fakeConfigReloader) ReloadConfig(ctx context.Context) (*ConfigReloadResult, error) {
+	return f.reload(ctx)
+}
+
+func TestConfigHandler_Reload(t *testing.T) {
+	t.Run("applies and refreshes config", func(t *testing.T) {
+		stringFlag := "before"
+		opts := []cli.Opt{
+			{
+				DestP: &stringFlag,
+				Flag:  "string-flag",
+			},
+		}
+		reloader := &fakeConfigReloader{
+			reload: func(ctx context.Context) (*ConfigReloadResult, error) {
+				stringFlag = "after"
+				return &ConfigReloadResult{
+					Applied: []string{"string-flag"},
+					Skipped: map[string]string{"other-flag": "requires restart"},
+				}, nil
+			},
+		}
+
+		h, err := NewConfigHandler(zaptest.NewLogger(t), opts, WithConfigReloader(reloader))
+		require.NoError(t, err)
+		ctx := influxdbcontext.SetAuthorizer(context.Background(), mock.NewMockAuthorizer(false, influxdb.OperPermissions()))
+
+		rr := httptest.NewRecorder()
+		r, err := http.NewRequest(http.MethodPost, "/reload", nil)
+		require.NoError(t, err)
+		h.ServeHTTP(rr, r.WithContext(ctx))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var res ConfigReloadResult
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&res))
+		require.Equal(t, []string{"string-flag"}, res.Applied)
+		require.Equal(t, map[string]string{"other-flag": "requires restart"}, res.Skipped)
+
+		rr = httptest.NewRecorder()
+		r, err = http.NewRequest(http.MethodGet, "/", nil)
+		require.NoError(t, err)
+		h.ServeHTTP(rr, r.WithContext(ctx))
+
+		var got map[string]map[string]string
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+		require.Equal(t, "after", got["config"]["string-flag"])
+	})
+
+	t.Run("not supported without a reloader", func(t *testing.T) {
+		h, err := NewConfigHandler(zaptest.NewLogger(t), nil)
+		require.NoError(t, err)
+		ctx := influxdbcontext.SetAuthorizer(context.Background(), mock.NewMockAuthorizer(false, influxdb.OperPermissions()))
+
+		rr := httptest.NewRecorder()
+		r, err := http.NewRequest(http.MethodPost, "/reload", nil)
+		require.NoError(t, err)
+		h.ServeHTTP(rr, r.WithContext(ctx))
+		require.Equal(t, http.StatusNotImplemented, rr.Code)
+	})
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/influxdata/httprouter"
 	"github.com/influxdata/influxdb/v2"
 	pcontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/deletejob"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
 	"github.com/influxdata/influxdb/v2/models"
@@ -28,6 +29,8 @@ type DeleteBackend struct {
 	errors.HTTPErrorHandler
 
 	DeleteService       influxdb.DeleteService
+	DeleteJobService    influxdb.DeleteJobService
+	DeleteCounter       deletejob.Counter
 	BucketService       influxdb.BucketService
 	OrganizationService influxdb.OrganizationService
 }
@@ -39,6 +42,8 @@ func NewDeleteBackend(log *zap.Logger, b *APIBackend) *DeleteBackend {
 
 		HTTPErrorHandler:    b.HTTPErrorHandler,
 		DeleteService:       b.DeleteService,
+		DeleteJobService:    b.DeleteJobService,
+		DeleteCounter:       b.DeleteCounter,
 		BucketService:       b.BucketService,
 		OrganizationService: b.OrganizationService,
 	}
@@ -52,12 +57,17 @@ type DeleteHandler struct {
 	log *zap.Logger
 
 	DeleteService       influxdb.DeleteService
+	DeleteJobService    influxdb.DeleteJobService
+	DeleteCounter       deletejob.Counter
 	BucketService       influxdb.BucketService
 	OrganizationService influxdb.OrganizationService
 }
 
 const (
-	prefixDelete = "/api/v2/delete"
+	prefixDelete           = "/api/v2/delete"
+	deleteJobsPath         = "/api/v2/delete/jobs"
+	deleteJobsIDPath       = "/api/v2/delete/jobs/:id"
+	deleteJobsIDCancelPath = "/api/v2/delete/jobs/:id/cancel"
 )
 
 var (
@@ -74,10 +84,15 @@ func NewDeleteHandler(log *zap.Logger, b *DeleteBackend) *DeleteHandler {
 
 		BucketService:       b.BucketService,
 		DeleteService:       b.DeleteService,
+		DeleteJobService:    b.DeleteJobService,
+		DeleteCounter:       b.DeleteCounter,
 		OrganizationService: b.OrganizationService,
 	}
 
 	h.HandlerFunc("POST", prefixDelete, h.handleDelete)
+	h.HandlerFunc("GET", deleteJobsPath, h.handleGetDeleteJobs)
+	h.HandlerFunc("GET", deleteJobsIDPath, h.handleGetDeleteJob)
+	h.HandlerFunc("POST", deleteJobsIDCancelPath, h.handleCancelDeleteJob)
 	return h
 }
 
@@ -124,6 +139,16 @@ func (h *DeleteHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r.URL.Query().Get("dryRun") == "true" {
+		h.handleDeleteDryRun(w, r, dr)
+		return
+	}
+
+	if r.URL.Query().Get("async") == "true" {
+		h.handleDeleteAsync(w, r, dr, measurement)
+		return
+	}
+
 	if err := h.DeleteService.DeleteBucketRangePredicate(r.Context(), dr.Org.ID, dr.Bucket.ID, dr.Start, dr.Stop, dr.Predicate, measurement); err != nil {
 		h.HandleHTTPError(ctx, &errors.Error{
 			Code: errors.EInternal,
@@ -142,6 +167,168 @@ func (h *DeleteHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// handleDeleteAsync queues the delete as a job, whose status is returned.
+func (h *DeleteHandler) handleDeleteAsync(w http.ResponseWriter, r *http.Request, dr *deleteRequest, measurement influxql.Expr) {
+	ctx := r.Context()
+	if h.DeleteJobService == nil {
+		h.HandleHTTPError(ctx, &errors.Error{
+			Code: errors.ENotImplemented,
+			Op:   "http/handleDelete",
+			Msg:  "asynchronous deletes are not supported",
+		}, w)
+		return
+	}
+
+	job, err := h.DeleteJobService.CreateDeleteJob(ctx, influxdb.DeleteJobRequest{
+		OrgID:       dr.Org.ID,
+		BucketID:    dr.Bucket.ID,
+		Start:       dr.Start,
+		Stop:        dr.Stop,
+		Predicate:   dr.RawPredicate,
+		Pred:        dr.Predicate,
+		Measurement: measurement,
+	})
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	h.log.Debug("Delete job queued",
+		zap.String("jobID", job.ID.String()),
+		zap.String("orgID", dr.Org.ID.String()),
+		zap.String("bucketID", dr.Bucket.ID.String()),
+	)
+
+	if err := encodeResponse(ctx, w, http.StatusAccepted, job); err != nil {
+		logEncodingError(h.log, r, err)
+	}
+}
+
+type deleteDryRunResponse struct {
+	EstimatedSeries int64 `json:"estimatedSeries"`
+	EstimatedPoints int64 `json:"estimatedPoints"`
+}
+
+// handleDeleteDryRun counts the series and points the delete would remove,
+// without deleting them. The counts are estimates as points may be written
+// or deleted before the delete is run.
+func (h *DeleteHandler) handleDeleteDryRun(w http.ResponseWriter, r *http.Request, dr *deleteRequest) {
+	ctx := r.Context()
+	if h.DeleteCounter == nil {
+		h.HandleHTTPError(ctx, &errors.Error{
+			Code: errors.ENotImplemented,
+			Op:   "http/handleDelete",
+			Msg:  "delete dry runs are not supported",
+		}, w)
+		return
+	}
+
+	series, points, err := h.DeleteCounter.Count(ctx, dr.Org.ID, dr.Bucket.ID, dr.Start, dr.Stop, dr.RawPredicate)
+	if err != nil {
+		h.HandleHTTPError(ctx, &errors.Error{
+			Code: errors.EInternal,
+			Op:   "http/handleDelete",
+			Msg:  fmt.Sprintf("unable to count the points to delete: %v", err),
+			Err:  err,
+		}, w)
+		return
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, deleteDryRunResponse{
+		EstimatedSeries: series,
+		EstimatedPoints: points,
+	}); err != nil {
+		logEncodingError(h.log, r, err)
+	}
+}
+
+type deleteJobsResponse struct {
+	Jobs []*influxdb.DeleteJob `json:"jobs"`
+}
+
+// handleGetDeleteJobs is the HTTP handler for the GET /api/v2/delete/jobs route.
+func (h *DeleteHandler) handleGetDeleteJobs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !h.deleteJobsEnabled(w, r) {
+		return
+	}
+
+	org, err := queryOrganization(ctx, r, h.OrganizationService)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	jobs, err := h.DeleteJobService.FindDeleteJobs(ctx, org.ID)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, deleteJobsResponse{Jobs: jobs}); err != nil {
+		logEncodingError(h.log, r, err)
+	}
+}
+
+// handleGetDeleteJob is the HTTP handler for the GET /api/v2/delete/jobs/:id route.
+func (h *DeleteHandler) handleGetDeleteJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !h.deleteJobsEnabled(w, r) {
+		return
+	}
+
+	id, err := decodeIDFromCtx(ctx, "id")
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	job, err := h.DeleteJobService.FindDeleteJobByID(ctx, id)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, job); err != nil {
+		logEncodingError(h.log, r, err)
+	}
+}
+
+// handleCancelDeleteJob is the HTTP handler for the POST /api/v2/delete/jobs/:id/cancel route.
+func (h *DeleteHandler) handleCancelDeleteJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !h.deleteJobsEnabled(w, r) {
+		return
+	}
+
+	id, err := decodeIDFromCtx(ctx, "id")
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	job, err := h.DeleteJobService.CancelDeleteJob(ctx, id)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, job); err != nil {
+		logEncodingError(h.log, r, err)
+	}
+}
+
+func (h *DeleteHandler) deleteJobsEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if h.DeleteJobService != nil {
+		return true
+	}
+	h.HandleHTTPError(r.Context(), &errors.Error{
+		Code: errors.ENotImplemented,
+		Msg:  "delete jobs are not supported",
+	}, w)
+	return false
+}
+
 func decodeDeleteRequest(ctx context.Context, r *http.Request, orgSvc influxdb.OrganizationService, bucketSvc influxdb.BucketService) (*deleteRequest, influxql.Expr, error) {
 	dr := new(deleteRequest)
 	buf, err := io.ReadAll(r.Body)
@@ -174,54 +361,9 @@ func decodeDeleteRequest(ctx context.Context, r *http.Request, orgSvc influxdb.O
 		}
 		return nil, nil, je
 	}
-	var measurementExpr influxql.Expr
-	if drd.Predicate != "" {
-		expr, err := influxql.ParseExpr(drd.Predicate)
-		if err != nil {
-			return nil, nil, &errors.Error{
-				Code: errors.EInvalid,
-				Msg:  "invalid request; error parsing predicate",
-				Err:  err,
-			}
-		}
-		measurementExpr, _, err = influxql.PartitionExpr(influxql.CloneExpr(expr), func(e influxql.Expr) (bool, error) {
-			switch e := e.(type) {
-			case *influxql.BinaryExpr:
-				switch e.Op {
-				case influxql.EQ, influxql.NEQ, influxql.EQREGEX, influxql.NEQREGEX:
-					tag, ok := e.LHS.(*influxql.VarRef)
-					if ok && tag.Val == "_measurement" {
-						return true, nil
-					}
-				}
-			}
-			return false, nil
-		})
-
-		var walkError error
-		influxql.WalkFunc(expr, func(e influxql.Node) {
-			if v, ok := e.(*influxql.BinaryExpr); ok {
-				if vv, ok := v.LHS.(*influxql.VarRef); ok && v.Op == influxql.EQ {
-					if vv.Val == "_field" {
-						walkError = &errors.Error{
-							Code: errors.ENotImplemented,
-							Msg:  "",
-							Err:  fmt.Errorf("delete by field is not supported"),
-						}
-					}
-				}
-			}
-		})
-		if walkError != nil {
-			return nil, nil, walkError
-		}
-		if err != nil {
-			return nil, nil, &errors.Error{
-				Code: errors.EInvalid,
-				Msg:  "invalid request; error partitioning predicate",
-				Err:  err,
-			}
-		}
+	measurementExpr, err := predicate.MeasurementExpr(drd.Predicate)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	if dr.Org, err = queryOrganization(ctx, r, orgSvc); err != nil {
@@ -240,6 +382,8 @@ type deleteRequest struct {
 	Start     int64
 	Stop      int64
 	Predicate influxdb.Predicate
+	// RawPredicate is the predicate as sent.
+	RawPredicate string
 }
 
 type deleteRequestDecode struct {
@@ -302,6 +446,7 @@ func (dr *deleteRequest) UnmarshalJSON(b []byte) error {
 		}
 	}
 	dr.Stop = stop.UnixNano()
+	dr.RawPredicate = drd.Predicate
 	node, err := predicate.Parse(drd.Predicate)
 	if err != nil {
 		return err
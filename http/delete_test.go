@@ -18,6 +18,8 @@ import (
 	"github.com/influxdata/influxdb/v2/mock"
 	"github.com/influxdata/influxdb/v2/models"
 	influxtesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/influxdata/influxql"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap/zaptest"
 )
 
@@ -529,3 +531,142 @@ func TestDelete(t *testing.T) {
 		})
 	}
 }
+
+type deleteJobService struct {
+	created influxdb.DeleteJobRequest
+}
+
+func (s *deleteJobService) CreateDeleteJob(ctx context.Context, req influxdb.DeleteJobRequest) (*influxdb.DeleteJob, error) {
+	s.created = req
+	return &influxdb.DeleteJob{ID: 3, OrgID: req.OrgID, BucketID: req.BucketID, Predicate: req.Predicate, Status: influxdb.DeleteJobQueued}, nil
+}
+
+func (s *deleteJobService) FindDeleteJobByID(ctx context.Context, id platform.ID) (*influxdb.DeleteJob, error) {
+	return &influxdb.DeleteJob{ID: id, OrgID: 1, BucketID: 2, Status: influxdb.DeleteJobRunning, PercentComplete: 50, PointsRemoved: 10}, nil
+}
+
+func (s *deleteJobService) FindDeleteJobs(ctx context.Context, orgID platform.ID) ([]*influxdb.DeleteJob, error) {
+	return []*influxdb.DeleteJob{{ID: 3, OrgID: orgID, BucketID: 2, Status: influxdb.DeleteJobQueued}}, nil
+}
+
+func (s *deleteJobService) CancelDeleteJob(ctx context.Context, id platform.ID) (*influxdb.DeleteJob, error) {
+	return &influxdb.DeleteJob{ID: id, OrgID: 1, BucketID: 2, Status: influxdb.DeleteJobCanceled}, nil
+}
+
+func TestDeleteJobs(t *testing.T) {
+	jobs := &deleteJobService{}
+	deleteBackend := NewMockDeleteBackend(t)
+	deleteBackend.HTTPErrorHandler = kithttp.NewErrorHandler(zaptest.NewLogger(t))
+	deleteBackend.DeleteService = mock.DeleteService{
+		DeleteBucketRangePredicateF: func(ctx context.Context, orgID, bucketID platform.ID, min, max int64, pred influxdb.Predicate, measurement influxql.Expr) error {
+			t.Fatal("an asynchronous delete must not delete")
+			return nil
+		},
+	}
+	deleteBackend.DeleteJobService = jobs
+	deleteBackend.BucketService = &mock.BucketService{
+		FindBucketFn: func(ctx context.Context, f influxdb.BucketFilter) (*influxdb.Bucket, error) {
+			return &influxdb.Bucket{ID: 2, Name: "bucket1"}, nil
+		},
+	}
+	deleteBackend.OrganizationService = &mock.OrganizationService{
+		FindOrganizationF: func(ctx context.Context, f influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+			return &influxdb.Organization{ID: 1, Name: "org1"}, nil
+		},
+	}
+	h := NewDeleteHandler(zaptest.NewLogger(t), deleteBackend)
+
+	do := func(method, target, body string) (int, string) {
+		r := httptest.NewRequest(method, target, bytes.NewReader([]byte(body)))
+		r = r.WithContext(pcontext.SetAuthorizer(r.Context(), &influxdb.Authorization{
+			UserID: user1ID,
+			Status: influxdb.Active,
+			Permissions: []influxdb.Permission{{
+				Action:   influxdb.WriteAction,
+				Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, ID: influxtesting.IDPtr(2), OrgID: influxtesting.IDPtr(1)},
+			}},
+		}))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		body2, _ := io.ReadAll(w.Result().Body)
+		return w.Result().StatusCode, string(body2)
+	}
+
+	code, body := do("POST", "/api/v2/delete?org=org1&bucket=bucket1&async=true",
+		`{"start":"2009-01-01T23:00:00Z","stop":"2009-11-10T01:00:00Z","predicate":"_measurement=\"cpu\""}`)
+	require.Equal(t, http.StatusAccepted, code, body)
+	require.Contains(t, body, `"status":"queued"`)
+	require.Equal(t, platform.ID(1), jobs.created.OrgID)
+	require.Equal(t, platform.ID(2), jobs.created.BucketID)
+	require.Equal(t, `_measurement="cpu"`, jobs.created.Predicate)
+	require.NotNil(t, jobs.created.Pred)
+	require.NotNil(t, jobs.created.Measurement)
+
+	code, body = do("GET", "/api/v2/delete/jobs?org=org1", "")
+	require.Equal(t, http.StatusOK, code, body)
+	require.Contains(t, body, `"jobs"`)
+
+	code, body = do("GET", "/api/v2/delete/jobs/0000000000000003", "")
+	require.Equal(t, http.StatusOK, code, body)
+	require.Contains(t, body, `"percentComplete":50`)
+	require.Contains(t, body, `"pointsRemoved":10`)
+
+	code, body = do("POST", "/api/v2/delete/jobs/0000000000000003/cancel", "")
+	require.Equal(t, http.StatusOK, code, body)
+	require.Contains(t, body, `"status":"canceled"`)
+}
+
+type deleteCounterFunc func(orgID, bucketID platform.ID, start, stop int64, pred string) (int64, int64, error)
+
+func (f deleteCounterFunc) Count(ctx context.Context, orgID, bucketID platform.ID, start, stop int64, pred string) (int64, int64, error) {
+	return f(orgID, bucketID, start, stop, pred)
+}
+
+func TestDeleteDryRun(t *testing.T) {
+	deleteBackend := NewMockDeleteBackend(t)
+	deleteBackend.HTTPErrorHandler = kithttp.NewErrorHandler(zaptest.NewLogger(t))
+	deleteBackend.DeleteService = mock.DeleteService{
+		DeleteBucketRangePredicateF: func(ctx context.Context, orgID, bucketID platform.ID, min, max int64, pred influxdb.Predicate, measurement influxql.Expr) error {
+			t.Fatal("a dry run must not delete")
+			return nil
+		},
+	}
+	deleteBackend.DeleteCounter = deleteCounterFunc(func(orgID, bucketID platform.ID, start, stop int64, pred string) (int64, int64, error) {
+		require.Equal(t, platform.ID(1), orgID)
+		require.Equal(t, platform.ID(2), bucketID)
+		require.Equal(t, time.Date(2009, 1, 1, 23, 0, 0, 0, time.UTC).UnixNano(), start)
+		require.Equal(t, time.Date(2009, 11, 10, 1, 0, 0, 0, time.UTC).UnixNano(), stop)
+		require.Equal(t, `_measurement="cpu"`, pred)
+		return 3, 42, nil
+	})
+	deleteBackend.BucketService = &mock.BucketService{
+		FindBucketFn: func(ctx context.Context, f influxdb.BucketFilter) (*influxdb.Bucket, error) {
+			return &influxdb.Bucket{ID: 2, Name: "bucket1"}, nil
+		},
+	}
+	deleteBackend.OrganizationService = &mock.OrganizationService{
+		FindOrganizationF: func(ctx context.Context, f influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+			return &influxdb.Organization{ID: 1, Name: "org1"}, nil
+		},
+	}
+	h := NewDeleteHandler(zaptest.NewLogger(t), deleteBackend)
+
+	r := httptest.NewRequest("POST", "/api/v2/delete?org=org1&bucket=bucket1&dryRun=true",
+		bytes.NewReader([]byte(`{"start":"2009-01-01T23:00:00Z","stop":"2009-11-10T01:00:00Z","predicate":"_measurement=\"cpu\""}`)))
+	r = r.WithContext(pcontext.SetAuthorizer(r.Context(), &influxdb.Authorization{
+		UserID: user1ID,
+		Status: influxdb.Active,
+		Permissions: []influxdb.Permission{{
+			Action:   influxdb.WriteAction,
+			Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, ID: influxtesting.IDPtr(2), OrgID: influxtesting.IDPtr(1)},
+		}},
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	body, _ := io.ReadAll(w.Result().Body)
+	require.Equal(t, http.StatusOK, w.Result().StatusCode, string(body))
+	eq, diff, err := jsonEqual(string(body), `{"estimatedSeries": 3, "estimatedPoints": 42}`)
+	require.NoError(t, err)
+	require.True(t, eq, diff)
+}
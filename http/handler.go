@@ -29,8 +29,9 @@ const (
 // Handler provides basic handling of metrics, health and debug endpoints.
 // All other requests are passed down to the sub handler.
 type Handler struct {
-	name string
-	r    chi.Router
+	name  string
+	r     chi.Router
+	admin chi.Router
 
 	requests   *prometheus.CounterVec
 	requestDur *prometheus.HistogramVec
@@ -47,10 +48,17 @@ type (
 		readyHandler  http.Handler
 		pprofEnabled  bool
 
+		// adminRoutesExposed controls whether metrics and debug routes are served
+		// alongside the API, or only by Handler.AdminHandler.
+		adminRoutesExposed bool
+
 		// NOTE: Track the registry even if metricsExposed = false
 		// so we can report HTTP metrics via telemetry.
 		metricsRegistry *prom.Registry
 		metricsExposed  bool
+		metricsFilter   prom.Filter
+
+		sloMetrics *kithttp.SLOMetrics
 	}
 
 	HandlerOptFn func(opts *handlerOpts)
@@ -58,7 +66,7 @@ type (
 
 func (o *handlerOpts) metricsHTTPHandler() http.Handler {
 	if o.metricsRegistry != nil && o.metricsExposed {
-		return o.metricsRegistry.HTTPHandler()
+		return o.metricsRegistry.FilteredHTTPHandler(o.metricsFilter)
 	}
 	handlerFunc := func(rw http.ResponseWriter, r *http.Request) {
 		kithttp.WriteErrorResponse(r.Context(), rw, errors.EForbidden, "metrics disabled")
@@ -84,6 +92,15 @@ func WithPprofEnabled(enabled bool) HandlerOptFn {
 	}
 }
 
+// WithAdminRoutesExposed serves the metrics and debug routes alongside the API when
+// exposed is true. Otherwise they are only available from Handler.AdminHandler, so
+// they can be bound to a separate listener.
+func WithAdminRoutesExposed(exposed bool) HandlerOptFn {
+	return func(opts *handlerOpts) {
+		opts.adminRoutesExposed = exposed
+	}
+}
+
 func WithMetrics(reg *prom.Registry, exposed bool) HandlerOptFn {
 	return func(opts *handlerOpts) {
 		opts.metricsRegistry = reg
@@ -91,6 +108,21 @@ func WithMetrics(reg *prom.Registry, exposed bool) HandlerOptFn {
 	}
 }
 
+// WithMetricsFilter filters the metrics served over /metrics.
+func WithMetricsFilter(f prom.Filter) HandlerOptFn {
+	return func(opts *handlerOpts) {
+		opts.metricsFilter = f
+	}
+}
+
+// WithSLOMetrics records the requests to the write and query endpoints against
+// the service level objectives of m.
+func WithSLOMetrics(m *kithttp.SLOMetrics) HandlerOptFn {
+	return func(opts *handlerOpts) {
+		opts.sloMetrics = m
+	}
+}
+
 type AddHeader struct {
 	WriteHeader func(header http.Header)
 }
@@ -114,6 +146,8 @@ func NewRootHandler(name string, opts ...HandlerOptFn) *Handler {
 		pprofEnabled:    false,
 		metricsRegistry: nil,
 		metricsExposed:  false,
+
+		adminRoutesExposed: true,
 	}
 	for _, o := range opts {
 		o(&opt)
@@ -125,24 +159,35 @@ func NewRootHandler(name string, opts ...HandlerOptFn) *Handler {
 	}
 	h.initMetrics()
 
-	r := chi.NewRouter()
 	buildHeader := &AddHeader{
 		WriteHeader: func(header http.Header) {
 			header.Add("X-Influxdb-Build", "OSS")
 			header.Add("X-Influxdb-Version", influxdb.GetBuildInfo().Version)
 		},
 	}
+	// mountSystemRoutes registers the non-API routes, for which we only gather metrics.
+	mountSystemRoutes := func(r chi.Router, admin bool) {
+		r.Group(func(r chi.Router) {
+			r.Use(
+				kithttp.Metrics(name, h.requests, h.requestDur),
+			)
+			r.Mount(ReadyPath, opt.readyHandler)
+			r.Mount(HealthPath, opt.healthHandler)
+			if admin {
+				r.Mount(MetricsPath, opt.metricsHTTPHandler())
+				r.Mount(DebugPath, pprof.NewHTTPHandler(opt.pprofEnabled))
+			}
+		})
+	}
+
+	admin := chi.NewRouter()
+	admin.Use(buildHeader.Middleware)
+	mountSystemRoutes(admin, true)
+	h.admin = admin
+
+	r := chi.NewRouter()
 	r.Use(buildHeader.Middleware)
-	// only gather metrics for system handlers
-	r.Group(func(r chi.Router) {
-		r.Use(
-			kithttp.Metrics(name, h.requests, h.requestDur),
-		)
-		r.Mount(MetricsPath, opt.metricsHTTPHandler())
-		r.Mount(ReadyPath, opt.readyHandler)
-		r.Mount(HealthPath, opt.healthHandler)
-		r.Mount(DebugPath, pprof.NewHTTPHandler(opt.pprofEnabled))
-	})
+	mountSystemRoutes(r, opt.adminRoutesExposed)
 
 	// gather metrics and traces for everything else
 	r.Group(func(r chi.Router) {
@@ -150,6 +195,9 @@ func NewRootHandler(name string, opts ...HandlerOptFn) *Handler {
 			kithttp.Trace(name),
 			kithttp.Metrics(name, h.requests, h.requestDur),
 		)
+		if opt.sloMetrics != nil {
+			r.Use(opt.sloMetrics.Middleware)
+		}
 		r.Mount("/", opt.apiHandler)
 	})
 
@@ -157,6 +205,9 @@ func NewRootHandler(name string, opts ...HandlerOptFn) *Handler {
 
 	if opt.metricsRegistry != nil {
 		opt.metricsRegistry.MustRegister(h.PrometheusCollectors()...)
+		if opt.sloMetrics != nil {
+			opt.metricsRegistry.MustRegister(opt.sloMetrics.PrometheusCollectors()...)
+		}
 	}
 	return h
 }
@@ -166,6 +217,12 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.r.ServeHTTP(w, r)
 }
 
+// AdminHandler returns a handler serving only the administrative routes: metrics,
+// readiness, health and debug.
+func (h *Handler) AdminHandler() http.Handler {
+	return h.admin
+}
+
 // PrometheusCollectors satisfies prom.PrometheusCollector.
 func (h *Handler) PrometheusCollectors() []prometheus.Collector {
 	return []prometheus.Collector{
@@ -90,3 +90,35 @@ func TestHandler_ServeHTTP(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_AdminRoutes(t *testing.T) {
+	for _, exposed := range []bool{true, false} {
+		reg := prom.NewRegistry(zaptest.NewLogger(t))
+		h := NewRootHandler(
+			"test",
+			WithLog(zaptest.NewLogger(t)),
+			WithAPIHandler(http.NotFoundHandler()),
+			WithMetrics(reg, true),
+			WithAdminRoutesExposed(exposed),
+		)
+
+		for _, path := range []string{MetricsPath, HealthPath} {
+			recorder := httptest.NewRecorder()
+			h.AdminHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+			require.Equal(t, http.StatusOK, recorder.Code, "admin handler path %s", path)
+		}
+
+		recorder := httptest.NewRecorder()
+		h.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, MetricsPath, nil))
+		if exposed {
+			require.Equal(t, http.StatusOK, recorder.Code)
+		} else {
+			require.Equal(t, http.StatusNotFound, recorder.Code)
+		}
+
+		// Health checks are always served alongside the API for load balancers.
+		recorder = httptest.NewRecorder()
+		h.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, HealthPath, nil))
+		require.Equal(t, http.StatusOK, recorder.Code)
+	}
+}
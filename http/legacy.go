@@ -11,11 +11,13 @@ func newLegacyBackend(b *APIBackend) *legacy.Backend {
 		Logger:           b.Logger,
 		// TODO(sgc): /write support
 		// MaxBatchSizeBytes:     b.APIBackend.MaxBatchSizeBytes,
+		OrgMemory:             b.OrgMemory,
 		AuthorizationService:  b.AuthorizationService,
 		OrganizationService:   b.OrganizationService,
 		BucketService:         b.BucketService,
 		PointsWriter:          b.PointsWriter,
 		DBRPMappingService:    b.DBRPService,
+		DBRPAutoCreator:       b.DBRPAutoCreator,
 		InfluxqldQueryService: b.InfluxqldService,
 		WriteEventRecorder:    b.WriteEventRecorder,
 	}
@@ -28,7 +30,10 @@ func newLegacyHandler(b *legacy.Backend, config legacy.HandlerConfig) *legacy.Ha
 	}
 
 	pointsWriterBackend := legacy.NewPointsWriterBackend(b)
-	h.PointsWriterHandler = legacy.NewWriterHandler(pointsWriterBackend, legacy.WithMaxBatchSizeBytes(b.MaxBatchSizeBytes))
+	h.PointsWriterHandler = legacy.NewWriterHandler(pointsWriterBackend,
+		legacy.WithMaxBatchSizeBytes(b.MaxBatchSizeBytes),
+		legacy.WithOrgMemory(b.OrgMemory),
+	)
 
 	influxqlBackend := legacy.NewInfluxQLBackend(b)
 	h.InfluxQLHandler = legacy.NewInfluxQLHandler(influxqlBackend, config)
@@ -8,6 +8,7 @@ import (
 	"github.com/influxdata/influxdb/v2/influxql"
 	"github.com/influxdata/influxdb/v2/kit/cli"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/membudget"
 	"github.com/influxdata/influxdb/v2/storage"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
@@ -25,6 +26,7 @@ type Backend struct {
 	errors.HTTPErrorHandler
 	Logger            *zap.Logger
 	MaxBatchSizeBytes int64
+	OrgMemory         *membudget.Accountant
 
 	WriteEventRecorder    metric.EventRecorder
 	AuthorizationService  influxdb.AuthorizationService
@@ -32,6 +34,7 @@ type Backend struct {
 	BucketService         influxdb.BucketService
 	PointsWriter          storage.PointsWriter
 	DBRPMappingService    influxdb.DBRPMappingService
+	DBRPAutoCreator       DBRPAutoCreator
 	InfluxqldQueryService influxql.ProxyQueryService
 }
 
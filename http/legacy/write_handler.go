@@ -14,6 +14,7 @@ import (
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/membudget"
 	"github.com/influxdata/influxdb/v2/storage"
 	"github.com/influxdata/influxdb/v2/tsdb"
 	"go.uber.org/zap"
@@ -34,6 +35,16 @@ type PointsWriterBackend struct {
 	BucketService      influxdb.BucketService
 	PointsWriter       storage.PointsWriter
 	DBRPMappingService influxdb.DBRPMappingService
+	DBRPAutoCreator    DBRPAutoCreator
+}
+
+// DBRPAutoCreator creates the bucket and mapping of the unmapped databases
+// v1 writes are to.
+type DBRPAutoCreator interface {
+	// AutoCreate creates the bucket and mapping of the database and retention
+	// policy a write by auth is to, returning an ENotFound error when the
+	// database may not be auto-created.
+	AutoCreate(ctx context.Context, auth influxdb.Authorizer, orgID platform.ID, db, rp string) (*influxdb.Bucket, error)
 }
 
 // NewPointsWriterBackend creates a new backend for legacy work.
@@ -45,6 +56,7 @@ func NewPointsWriterBackend(b *Backend) *PointsWriterBackend {
 		BucketService:      b.BucketService,
 		PointsWriter:       b.PointsWriter,
 		DBRPMappingService: b.DBRPMappingService,
+		DBRPAutoCreator:    b.DBRPAutoCreator,
 	}
 }
 
@@ -55,10 +67,12 @@ type WriteHandler struct {
 	BucketService      influxdb.BucketService
 	PointsWriter       storage.PointsWriter
 	DBRPMappingService influxdb.DBRPMappingService
+	DBRPAutoCreator    DBRPAutoCreator
 
 	router            *httprouter.Router
 	logger            *zap.Logger
 	maxBatchSizeBytes int64
+	orgMemory         *membudget.Accountant
 }
 
 // NewWriterHandler returns a new instance of PointsWriterHandler.
@@ -69,6 +83,7 @@ func NewWriterHandler(b *PointsWriterBackend, opts ...WriteHandlerOption) *Write
 		BucketService:      b.BucketService,
 		PointsWriter:       b.PointsWriter,
 		DBRPMappingService: b.DBRPMappingService,
+		DBRPAutoCreator:    b.DBRPAutoCreator,
 
 		router: NewRouter(b.HTTPErrorHandler),
 		logger: b.Logger.With(zap.String("handler", "points_writer")),
@@ -94,6 +109,14 @@ func WithMaxBatchSizeBytes(n int64) WriteHandlerOption {
 	}
 }
 
+// WithOrgMemory configures the write handler to charge the memory
+// of the points batches to the budgets of their organizations
+func WithOrgMemory(a *membudget.Accountant) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.orgMemory = a
+	}
+}
+
 // ServeHTTP implements http.Handler
 func (h *WriteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.router.ServeHTTP(w, r)
@@ -138,7 +161,7 @@ func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	bucket, err := h.findBucket(ctx, auth.OrgID, req.Database, req.RetentionPolicy)
+	bucket, err := h.findBucket(ctx, auth, auth.OrgID, req.Database, req.RetentionPolicy)
 	if err != nil {
 		h.HandleHTTPError(ctx, err, sw)
 		return
@@ -150,13 +173,16 @@ func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	parsed, err := points.NewParser(req.Precision).Parse(ctx, auth.OrgID, bucket.ID, req.Body)
+	parser := points.NewParser(req.Precision)
+	parser.OrgMemory = h.orgMemory
+	parsed, err := parser.Parse(ctx, auth.OrgID, bucket.ID, req.Body)
 	if err != nil {
 		h.HandleHTTPError(ctx, err, sw)
 		return
 	}
 
 	if err := h.PointsWriter.WritePoints(ctx, auth.OrgID, bucket.ID, parsed.Points); err != nil {
+		parsed.Discard()
 		if partialErr, ok := err.(tsdb.PartialWriteError); ok {
 			h.HandleHTTPError(ctx, &errors.Error{
 				Code: errors.EUnprocessableEntity,
@@ -176,13 +202,21 @@ func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The points are only released once written, as writes which failed may
+	// still be in progress.
+	parsed.Release()
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
 // findBucket finds a bucket for the specified database and
-// retention policy combination.
-func (h *WriteHandler) findBucket(ctx context.Context, orgID platform.ID, db, rp string) (*influxdb.Bucket, error) {
+// retention policy combination, auto-creating it for auth when it is unmapped
+// and a DBRPAutoCreator is configured.
+func (h *WriteHandler) findBucket(ctx context.Context, auth influxdb.Authorizer, orgID platform.ID, db, rp string) (*influxdb.Bucket, error) {
 	mapping, err := h.findMapping(ctx, orgID, db, rp)
+	if errors.ErrorCode(err) == errors.ENotFound && h.DBRPAutoCreator != nil {
+		return h.DBRPAutoCreator.AutoCreate(ctx, auth, orgID, db, rp)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,176 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	influxlogger "github.com/influxdata/influxdb/v2/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const prefixLogLevels = "/api/v2/debug/log-levels"
+
+// LogLevels is the response body of the log levels API.
+type LogLevels struct {
+	// Global is the level used outside of subsystems, and by every subsystem
+	// without an override.
+	Global string `json:"global"`
+	// Subsystems maps each subsystem to its effective level.
+	Subsystems map[string]SubsystemLogLevel `json:"subsystems"`
+}
+
+// SubsystemLogLevel is the effective log level of a subsystem.
+type SubsystemLogLevel struct {
+	Level string `json:"level"`
+	// Override is true if the level was set for the subsystem, rather than
+	// following the global level.
+	Override bool `json:"override"`
+}
+
+// LogLevelsUpdate is the request body for changing log levels. Omitted fields
+// are left unchanged, and a null subsystem level clears its override.
+type LogLevelsUpdate struct {
+	Global     *string            `json:"global,omitempty"`
+	Subsystems map[string]*string `json:"subsystems,omitempty"`
+}
+
+// LogLevelsHandler allows operators to change the log level of the server, or
+// of individual subsystems, at runtime. Changes are not persisted across restarts.
+type LogLevelsHandler struct {
+	chi.Router
+
+	log    *zap.Logger
+	api    *kithttp.API
+	levels *influxlogger.Levels
+}
+
+// NewLogLevelsHandler returns a handler controlling levels.
+func NewLogLevelsHandler(log *zap.Logger, levels *influxlogger.Levels) *LogLevelsHandler {
+	h := &LogLevelsHandler{
+		log:    log,
+		api:    kithttp.NewAPI(kithttp.WithLog(log)),
+		levels: levels,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+		h.mwAuthorize,
+	)
+
+	r.Get("/", h.handleGetLogLevels)
+	r.Patch("/", h.handlePatchLogLevels)
+	h.Router = r
+	return h
+}
+
+func (h *LogLevelsHandler) Prefix() string {
+	return prefixLogLevels
+}
+
+func (h *LogLevelsHandler) handleGetLogLevels(w http.ResponseWriter, r *http.Request) {
+	h.api.Respond(w, r, http.StatusOK, h.current())
+}
+
+func (h *LogLevelsHandler) handlePatchLogLevels(w http.ResponseWriter, r *http.Request) {
+	var upd LogLevelsUpdate
+	if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "failed to decode request body",
+			Err:  err,
+		})
+		return
+	}
+
+	// Validate the whole update before applying any of it.
+	var global *zapcore.Level
+	if upd.Global != nil {
+		lvl, err := parseLogLevel("global", *upd.Global)
+		if err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+		global = &lvl
+	}
+	subsystems := make(map[string]*zapcore.Level, len(upd.Subsystems))
+	for name, val := range upd.Subsystems {
+		if _, _, err := h.levels.Level(name); err != nil {
+			h.api.Err(w, r, &errors.Error{Code: errors.EInvalid, Msg: err.Error()})
+			return
+		}
+		if val == nil {
+			subsystems[name] = nil
+			continue
+		}
+		lvl, err := parseLogLevel(name, *val)
+		if err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+		subsystems[name] = &lvl
+	}
+
+	if global != nil {
+		h.levels.SetGlobal(*global)
+		h.log.Info("Changed global log level", zap.Stringer("level", *global))
+	}
+	for name, lvl := range subsystems {
+		// The subsystem is known to exist, so this cannot fail.
+		_ = h.levels.SetLevel(name, lvl)
+		if lvl == nil {
+			h.log.Info("Cleared subsystem log level", zap.String("subsystem", name))
+			continue
+		}
+		h.log.Info("Changed subsystem log level", zap.String("subsystem", name), zap.Stringer("level", *lvl))
+	}
+
+	h.api.Respond(w, r, http.StatusOK, h.current())
+}
+
+func (h *LogLevelsHandler) current() LogLevels {
+	res := LogLevels{
+		Global:     h.levels.Global().String(),
+		Subsystems: make(map[string]SubsystemLogLevel),
+	}
+	for _, name := range h.levels.Subsystems() {
+		lvl, override, _ := h.levels.Level(name)
+		res.Subsystems[name] = SubsystemLogLevel{Level: lvl.String(), Override: override}
+	}
+	return res
+}
+
+func parseLogLevel(name, val string) (zapcore.Level, error) {
+	var lvl zapcore.Level
+	if err := lvl.UnmarshalText([]byte(val)); err != nil {
+		return 0, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("invalid log level %q for %s", val, name),
+		}
+	}
+	return lvl, nil
+}
+
+func (h *LogLevelsHandler) mwAuthorize(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if err := authorizer.IsAllowedAll(r.Context(), influxdb.OperPermissions()); err != nil {
+			h.api.Err(w, r, &errors.Error{
+				Code: errors.EUnauthorized,
+				Msg:  fmt.Sprintf("access to %s requires operator permissions", h.Prefix()),
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
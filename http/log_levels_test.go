@@ -0,0 +1,74 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	influxdbcontext "github.com/influxdata/influxdb/v2/context"
+	influxlogger "github.com/influxdata/influxdb/v2/logger"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestLogLevelsHandler(t *testing.T) {
+	global := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	levels := influxlogger.NewLevels(global, influxlogger.SubsystemStorage, influxlogger.SubsystemHTTP)
+	h := NewLogLevelsHandler(zaptest.NewLogger(t), levels)
+
+	do := func(t *testing.T, method, body string, perms []influxdb.Permission) *httptest.ResponseRecorder {
+		t.Helper()
+		r := httptest.NewRequest(method, "/", bytes.NewBufferString(body))
+		ctx := influxdbcontext.SetAuthorizer(context.Background(), mock.NewMockAuthorizer(false, perms))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, r.WithContext(ctx))
+		return rr
+	}
+
+	t.Run("requires operator", func(t *testing.T) {
+		rr := do(t, http.MethodGet, "", nil)
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("override and clear subsystem", func(t *testing.T) {
+		rr := do(t, http.MethodPatch, `{"subsystems":{"storage":"debug"}}`, influxdb.OperPermissions())
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		var got LogLevels
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+		require.Equal(t, LogLevels{
+			Global: "info",
+			Subsystems: map[string]SubsystemLogLevel{
+				"storage": {Level: "debug", Override: true},
+				"http":    {Level: "info"},
+			},
+		}, got)
+
+		rr = do(t, http.MethodPatch, `{"global":"warn","subsystems":{"storage":null}}`, influxdb.OperPermissions())
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		lvl, override, err := levels.Level(influxlogger.SubsystemStorage)
+		require.NoError(t, err)
+		require.False(t, override)
+		require.Equal(t, zapcore.WarnLevel, lvl)
+		require.Equal(t, zapcore.WarnLevel, global.Level())
+	})
+
+	t.Run("invalid update is not applied", func(t *testing.T) {
+		for _, body := range []string{
+			`{"global":"debug","subsystems":{"unknown":"debug"}}`,
+			`{"global":"debug","subsystems":{"http":"loud"}}`,
+			`{"global":"loud"}`,
+		} {
+			rr := do(t, http.MethodPatch, body, influxdb.OperPermissions())
+			require.Equal(t, http.StatusBadRequest, rr.Code, body)
+		}
+		require.Equal(t, zapcore.WarnLevel, global.Level())
+	})
+}
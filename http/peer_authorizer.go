@@ -0,0 +1,42 @@
+package http
+
+import (
+	"fmt"
+
+	"github.com/influxdata/influxdb/v2"
+	platcontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+// PeerAuthorizer grants operator permissions to a trusted local process
+// connected over a unix socket. Like a jwt, it is permission based and has
+// no associated user.
+type PeerAuthorizer struct {
+	Credentials platcontext.PeerCredentials
+}
+
+var _ influxdb.Authorizer = (*PeerAuthorizer)(nil)
+
+// PermissionSet returns operator permissions.
+func (a *PeerAuthorizer) PermissionSet() (influxdb.PermissionSet, error) {
+	return influxdb.OperPermissions(), nil
+}
+
+// Identifier returns the peer's uid, which is used for auditing.
+func (a *PeerAuthorizer) Identifier() platform.ID {
+	return platform.ID(a.Credentials.UID)
+}
+
+// GetUserID returns an invalid id as the peer is not an influxdb user.
+func (a *PeerAuthorizer) GetUserID() platform.ID {
+	return platform.InvalidID()
+}
+
+// Kind returns the string "peer" which is used for auditing.
+func (a *PeerAuthorizer) Kind() string {
+	return peerAuthScheme
+}
+
+func (a *PeerAuthorizer) String() string {
+	return fmt.Sprintf("peer(pid=%d uid=%d gid=%d)", a.Credentials.PID, a.Credentials.UID, a.Credentials.GID)
+}
@@ -26,14 +26,33 @@ func NewPlatformHandler(b *APIBackend, opts ...APIHandlerOptFn) *PlatformHandler
 	h.AuthorizationService = b.AuthorizationService
 	h.SessionService = b.SessionService
 	h.SessionRenewDisabled = b.SessionRenewDisabled
+	h.SessionIdleTimeout = b.SessionIdleTimeout
+	h.TrustPeer = b.TrustPeer
 	h.UserService = b.UserService
+	h.LabelResolver = b.LabelService
 
 	h.RegisterNoAuthRoute("GET", "/api/v2")
 	h.RegisterNoAuthRoute("POST", "/api/v2/signin")
 	h.RegisterNoAuthRoute("POST", "/api/v2/signout")
+	// sessions are refreshed by the refresh token cookie, once they expired.
+	h.RegisterNoAuthRoute("POST", "/api/v2/signin/refresh")
+	h.RegisterNoAuthRoute("DELETE", "/api/v2/signin/refresh")
+	// users are signed in with the assertions of the SAML identity provider.
+	h.RegisterNoAuthRoute("GET", "/api/v2/saml/metadata")
+	h.RegisterNoAuthRoute("GET", "/api/v2/saml/login")
+	h.RegisterNoAuthRoute("POST", "/api/v2/saml/acs")
 	h.RegisterNoAuthRoute("POST", "/api/v2/setup")
 	h.RegisterNoAuthRoute("GET", "/api/v2/setup")
 	h.RegisterNoAuthRoute("GET", "/api/v2/swagger.json")
+	// shared dashboards are authorized by the share token in their path.
+	h.RegisterNoAuthRoute("GET", "/api/v2/share/:token")
+	h.RegisterNoAuthRoute("POST", "/api/v2/share/:token/query")
+	// the messages of aws notification rules are authorized by the secret of their endpoint.
+	h.RegisterNoAuthRoute("POST", "/api/v2/notificationEndpoints/aws/:id")
+	// the notifications relayed to http endpoints are authorized by the relay secret of their endpoint.
+	h.RegisterNoAuthRoute("POST", "/api/v2/notificationEndpoints/http/:id")
+	// the webhook pings of the repositories stacks sync from are authorized by the webhook secret of the stack.
+	h.RegisterNoAuthRoute("POST", "/api/v2/stacks/:id/sync/webhook")
 
 	assetHandler := static.NewAssetHandler(b.AssetsPath)
 	if b.UIDisabled {
@@ -0,0 +1,99 @@
+package points
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+const (
+	// ParallelParseThreshold is the size of the batches, in bytes, from which
+	// their points are parsed in parallel.
+	ParallelParseThreshold = 1 << 20
+
+	// minBlockSize is the minimum size of the blocks of lines a batch parsed in
+	// parallel is split into, so each block is worth handing to a worker.
+	minBlockSize = 256 << 10
+)
+
+// parseJob parses the points of a block of lines.
+type parseJob struct {
+	block       []byte
+	defaultTime time.Time
+	precision   string
+
+	points *[]models.Point
+	err    error
+	done   *sync.WaitGroup
+}
+
+func (j *parseJob) run() {
+	defer j.done.Done()
+	j.points = getPoints(0)
+	*j.points, j.err = models.AppendPointsWithPrecision(*j.points, j.block, j.defaultTime, j.precision)
+}
+
+// The workers parsing blocks, shared by all the batches, so that parsing uses
+// at most all the processors however many batches are written at once.
+var (
+	startWorkers sync.Once
+	parseJobs    chan *parseJob
+)
+
+func submit(j *parseJob) {
+	startWorkers.Do(func() {
+		parseJobs = make(chan *parseJob)
+		for i := 0; i < runtime.GOMAXPROCS(0); i++ {
+			go func() {
+				for j := range parseJobs {
+					j.run()
+				}
+			}()
+		}
+	})
+	parseJobs <- j
+}
+
+// parseParallel parses the points of data like models.ParsePointsWithPrecision,
+// splitting it into blocks of lines parsed by the workers. The points are in a
+// pooled buffer, to be released with releasePoints.
+func parseParallel(data []byte, defaultTime time.Time, precision string) (*[]models.Point, error) {
+	n := len(data) / minBlockSize
+	if procs := runtime.GOMAXPROCS(0); n > procs {
+		n = procs
+	}
+	blocks := models.SplitLines(data, n)
+
+	var done sync.WaitGroup
+	done.Add(len(blocks))
+	jobs := make([]parseJob, len(blocks))
+	for i, block := range blocks {
+		jobs[i] = parseJob{block: block, defaultTime: defaultTime, precision: precision, done: &done}
+		submit(&jobs[i])
+	}
+	done.Wait()
+
+	var (
+		total  int
+		failed []string
+	)
+	for _, j := range jobs {
+		total += len(*j.points)
+		if j.err != nil {
+			failed = append(failed, j.err.Error())
+		}
+	}
+	points := getPoints(total)
+	for _, j := range jobs {
+		*points = append(*points, *j.points...)
+		releasePoints(j.points)
+	}
+	if len(failed) > 0 {
+		return points, fmt.Errorf("%s", strings.Join(failed, "\n"))
+	}
+	return points, nil
+}
@@ -1,6 +1,7 @@
 package points
 
 import (
+	"bytes"
 	"compress/gzip"
 	"context"
 	"errors"
@@ -12,8 +13,10 @@ import (
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/membudget"
 	"github.com/influxdata/influxdb/v2/models"
 	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
@@ -28,16 +31,65 @@ const (
 	msgUnableToReadData = "unable to read data"
 )
 
+var parseDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "http",
+	Subsystem: "write",
+	Name:      "parse_duration_seconds",
+	Help:      "Time taken to parse the points of write requests, by whether they were parsed in parallel",
+	Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 10),
+}, []string{"mode"})
+
+// PrometheusCollectors returns the metrics of the parsing of write requests.
+func PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{parseDuration, poolGets, poolAllocs}
+}
+
 // ParsedPoints contains the points parsed as well as the total number of bytes
 // after decompression.
 type ParsedPoints struct {
 	Points  models.Points
 	RawSize int
+
+	// body and points are the pooled buffers the batch was read and parsed
+	// into, the points referring to the body.
+	body   *bytes.Buffer
+	points *[]models.Point
+
+	// memory is the memory of the points charged to their organization.
+	memory *membudget.Reservation
+}
+
+// Release returns the buffers of the points to be reused by the parsing of
+// later batches. Neither the points nor any of their keys, tags or fields may
+// be used once released, so it must only be called once the points have been
+// written and no writer holds on to them, which is not the case of a write
+// which failed or timed out.
+func (p *ParsedPoints) Release() {
+	p.Discard()
+	if p.points != nil {
+		releasePoints(p.points)
+		p.points = nil
+	}
+	if p.body != nil {
+		releaseBody(p.body)
+		p.body = nil
+	}
+	p.Points = nil
+}
+
+// Discard releases the memory of the points charged to their organization,
+// leaving their buffers to the garbage collector. It is called in place of
+// Release when the points may still be used, as by a write which failed.
+func (p *ParsedPoints) Discard() {
+	p.memory.Release()
 }
 
 // Parser parses batches of Points.
 type Parser struct {
 	Precision string
+	// OrgMemory, if set, is charged the memory of the points parsed against
+	// the budget of their organization.
+	OrgMemory *membudget.Accountant
 	//ParserOptions []models.ParserOption
 }
 
@@ -49,7 +101,7 @@ func (pw *Parser) Parse(ctx context.Context, orgID, bucketID platform.ID, rc io.
 }
 
 func (pw *Parser) parsePoints(ctx context.Context, orgID, bucketID platform.ID, rc io.ReadCloser) (*ParsedPoints, error) {
-	data, err := readAll(ctx, rc)
+	body, err := readAll(ctx, rc)
 	if err != nil {
 		code := errors2.EInternal
 		if errors.Is(err, ErrMaxBatchSizeExceeded) {
@@ -65,12 +117,45 @@ func (pw *Parser) parsePoints(ctx context.Context, orgID, bucketID platform.ID,
 		}
 	}
 
+	data := body.Bytes()
+
+	// The points take about as much memory as the line protocol they are
+	// parsed from, so both are charged before the points are parsed.
+	reservation, err := pw.OrgMemory.Reserve(ctx, orgID, 2*int64(len(data)))
+	if err != nil {
+		releaseBody(body)
+		return nil, &errors2.Error{
+			Code: errors2.ErrorCode(err),
+			Op:   opPointsWriter,
+			Msg:  "unable to reserve memory for the points",
+			Err:  err,
+		}
+	}
+
 	span, _ := tracing.StartSpanFromContextWithOperationName(ctx, "encoding and parsing")
 
-	points, err := models.ParsePointsWithPrecision(data, time.Now().UTC(), pw.Precision)
-	span.LogKV("values_total", len(points))
+	// Large batches are split and parsed by several workers, as parsing is
+	// usually what bounds the throughput of their writes.
+	var (
+		points *[]models.Point
+		mode   = "serial"
+		start  = time.Now()
+	)
+	if len(data) >= ParallelParseThreshold {
+		mode = "parallel"
+		points, err = parseParallel(data, start.UTC(), pw.Precision)
+	} else {
+		points = getPoints(0)
+		*points, err = models.AppendPointsWithPrecision(*points, data, start.UTC(), pw.Precision)
+	}
+	parseDuration.WithLabelValues(mode).Observe(time.Since(start).Seconds())
+	span.LogKV("values_total", len(*points), "parse_mode", mode)
 	span.Finish()
 	if err != nil {
+		releasePoints(points)
+		releaseBody(body)
+		reservation.Release()
+
 		tracing.LogError(span, fmt.Errorf("error parsing points: %v", err))
 
 		code := errors2.EInvalid
@@ -90,12 +175,16 @@ func (pw *Parser) parsePoints(ctx context.Context, orgID, bucketID platform.ID,
 	}
 
 	return &ParsedPoints{
-		Points:  points,
+		Points:  *points,
 		RawSize: len(data),
+		body:    body,
+		points:  points,
+		memory:  reservation,
 	}, nil
 }
 
-func readAll(ctx context.Context, rc io.ReadCloser) (data []byte, err error) {
+// readAll reads rc into a pooled buffer.
+func readAll(ctx context.Context, rc io.ReadCloser) (buf *bytes.Buffer, err error) {
 	defer func() {
 		if cerr := rc.Close(); cerr != nil && err == nil {
 			if errors.Is(cerr, io2.ErrReadLimitExceeded) {
@@ -107,17 +196,17 @@ func readAll(ctx context.Context, rc io.ReadCloser) (data []byte, err error) {
 
 	span, _ := tracing.StartSpanFromContextWithOperationName(ctx, "read request body")
 
+	b := getBody()
 	defer func() {
-		span.LogKV("request_bytes", len(data))
+		span.LogKV("request_bytes", b.Len())
 		span.Finish()
 	}()
 
-	data, err = io.ReadAll(rc)
-	if err != nil {
+	if _, err := b.ReadFrom(rc); err != nil {
+		releaseBody(b)
 		return nil, err
-
 	}
-	return data, nil
+	return b, nil
 }
 
 // NewParser returns a new Parser
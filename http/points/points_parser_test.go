@@ -0,0 +1,163 @@
+package points
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/membudget"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/stretchr/testify/require"
+)
+
+// batch returns line protocol of n lines, some of which have a string field
+// with a quoted newline.
+func batch(n int) []byte {
+	var b bytes.Buffer
+	for i := 0; i < n; i++ {
+		if i%10 == 0 {
+			fmt.Fprintf(&b, "logs,host=server%02d msg=\"line %d\nwrapped\" %d\n", i%20, i, 1600000000000000000+i)
+			continue
+		}
+		fmt.Fprintf(&b, "cpu,host=server%02d,region=us-west usage_user=%d.5,usage_system=%di %d\n", i%20, i, i, 1600000000000000000+i)
+	}
+	return b.Bytes()
+}
+
+func TestParseParallel(t *testing.T) {
+	data := batch(50000)
+	require.GreaterOrEqual(t, len(data), ParallelParseThreshold)
+	now := time.Now().UTC()
+
+	want, err := models.ParsePointsWithPrecision(data, now, "ns")
+	require.NoError(t, err)
+	got, err := parseParallel(data, now, "ns")
+	require.NoError(t, err)
+	require.Equal(t, len(want), len(*got))
+	for i := range want {
+		require.Equal(t, want[i].String(), (*got)[i].String())
+	}
+	releasePoints(got)
+
+	// The errors of all the blocks are reported, in order.
+	bad := append(append([]byte("bad\n"), data...), "worse\n"...)
+	_, wantErr := models.ParsePointsWithPrecision(bad, now, "ns")
+	require.Error(t, wantErr)
+	_, err = parseParallel(bad, now, "ns")
+	require.EqualError(t, err, wantErr.Error())
+}
+
+func TestParser_Parse(t *testing.T) {
+	for _, n := range []int{10, 50000} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			data := batch(n)
+			parsed, err := NewParser("ns").Parse(context.Background(), 1, 2, io.NopCloser(bytes.NewReader(data)))
+			require.NoError(t, err)
+			require.Len(t, parsed.Points, n)
+			require.Equal(t, len(data), parsed.RawSize)
+			parsed.Release()
+			require.Nil(t, parsed.Points)
+		})
+	}
+}
+
+func TestParser_ParseGzip(t *testing.T) {
+	data := batch(100)
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, err := zw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	// The gzip readers and buffers released by a batch are reused by the next.
+	for i := 0; i < 3; i++ {
+		rc, err := BatchReadCloser(io.NopCloser(bytes.NewReader(gz.Bytes())), "gzip", 0)
+		require.NoError(t, err)
+		parsed, err := NewParser("ns").Parse(context.Background(), 1, 2, rc)
+		require.NoError(t, err)
+		require.Len(t, parsed.Points, 100)
+		require.Equal(t, len(data), parsed.RawSize)
+		require.Equal(t, "cpu,host=server01,region=us-west usage_user=1.5,usage_system=1i 1600000000000000001", parsed.Points[1].String())
+		parsed.Release()
+	}
+
+	_, err = BatchReadCloser(io.NopCloser(bytes.NewReader(data)), "gzip", 0)
+	require.Error(t, err)
+}
+
+func BenchmarkParse(b *testing.B) {
+	for _, n := range []int{1000, 100000, 500000} {
+		data := batch(n)
+		b.Run(fmt.Sprintf("serial/%d", n), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := models.ParsePointsWithPrecision(data, time.Now(), "ns"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		if len(data) < minBlockSize*2 {
+			continue
+		}
+		b.Run(fmt.Sprintf("parallel/%d", n), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				points, err := parseParallel(data, time.Now(), "ns")
+				if err != nil {
+					b.Fatal(err)
+				}
+				releasePoints(points)
+			}
+		})
+	}
+}
+
+// BenchmarkParser_Parse compares the allocations of reading and parsing
+// batches whose buffers are released to those of batches left to the GC.
+func BenchmarkParser_Parse(b *testing.B) {
+	for _, n := range []int{1000, 10000} {
+		data := batch(n)
+		for _, release := range []bool{false, true} {
+			b.Run(fmt.Sprintf("%d/release=%t", n, release), func(b *testing.B) {
+				b.SetBytes(int64(len(data)))
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					parsed, err := NewParser("ns").Parse(context.Background(), 1, 2, io.NopCloser(bytes.NewReader(data)))
+					if err != nil {
+						b.Fatal(err)
+					}
+					if release {
+						parsed.Release()
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestParser_ParseOrgMemory(t *testing.T) {
+	data := batch(10)
+	budget := membudget.NewAccountant(membudget.Config{OrgBytes: 3 * int64(len(data))})
+	parser := NewParser("ns")
+	parser.OrgMemory = budget
+
+	// The points are charged to their org until released or discarded.
+	parsed, err := parser.Parse(context.Background(), 1, 2, io.NopCloser(bytes.NewReader(data)))
+	require.NoError(t, err)
+	_, err = parser.Parse(context.Background(), 1, 2, io.NopCloser(bytes.NewReader(data)))
+	require.Equal(t, errors2.ETooManyRequests, errors2.ErrorCode(err))
+	_, err = parser.Parse(context.Background(), 3, 2, io.NopCloser(bytes.NewReader(data)))
+	require.NoError(t, err)
+
+	parsed.Discard()
+	parsed, err = parser.Parse(context.Background(), 1, 2, io.NopCloser(bytes.NewReader(data)))
+	require.NoError(t, err)
+	parsed.Release()
+}
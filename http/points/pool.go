@@ -0,0 +1,136 @@
+package points
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// maxPooledBodySize is the capacity of the largest buffers of request
+	// bodies kept in the pool, so a few huge batches do not pin their memory.
+	maxPooledBodySize = 16 << 20
+
+	// maxPooledPoints is the capacity of the largest buffers of points kept in
+	// the pool, for the same reason.
+	maxPooledPoints = 1 << 16
+)
+
+// The names of the pools, as the pool label of their metrics.
+const (
+	poolBody   = "body"
+	poolGzip   = "gzip"
+	poolPoints = "points"
+)
+
+var (
+	poolGets = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "http",
+		Subsystem: "write",
+		Name:      "pool_gets_total",
+		Help:      "Number of buffers taken from the pools of the write path, by pool",
+	}, []string{"pool"})
+
+	poolAllocs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "http",
+		Subsystem: "write",
+		Name:      "pool_allocations_total",
+		Help:      "Number of buffers of the write path allocated because their pool had none to reuse, by pool",
+	}, []string{"pool"})
+)
+
+// The buffers are pooled from reading the body of write requests to writing
+// their points: the body is read into a pooled buffer, decompressed by a pooled
+// gzip reader, and parsed into a pooled slice of points which refer to the body.
+// Both buffers are released together once the points are written.
+var (
+	bodyPool = sync.Pool{
+		New: func() interface{} {
+			poolAllocs.WithLabelValues(poolBody).Inc()
+			return new(bytes.Buffer)
+		},
+	}
+
+	gzipPool sync.Pool
+
+	pointsPool = sync.Pool{
+		New: func() interface{} {
+			poolAllocs.WithLabelValues(poolPoints).Inc()
+			points := make([]models.Point, 0, 1024)
+			return &points
+		},
+	}
+)
+
+func getBody() *bytes.Buffer {
+	poolGets.WithLabelValues(poolBody).Inc()
+	return bodyPool.Get().(*bytes.Buffer)
+}
+
+func releaseBody(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBodySize {
+		return
+	}
+	buf.Reset()
+	bodyPool.Put(buf)
+}
+
+// getPoints returns an empty pooled buffer of points, of at least capacity n.
+func getPoints(n int) *[]models.Point {
+	poolGets.WithLabelValues(poolPoints).Inc()
+	points := pointsPool.Get().(*[]models.Point)
+	if cap(*points) < n {
+		poolAllocs.WithLabelValues(poolPoints).Inc()
+		*points = make([]models.Point, 0, n)
+	}
+	return points
+}
+
+func releasePoints(points *[]models.Point) {
+	if cap(*points) > maxPooledPoints {
+		return
+	}
+	// The points refer to the body, which must not be kept alive by the pool.
+	for i := range *points {
+		(*points)[i] = nil
+	}
+	*points = (*points)[:0]
+	pointsPool.Put(points)
+}
+
+// gzipReadCloser is a pooled gzip reader, returned to the pool once closed.
+type gzipReadCloser struct {
+	*gzip.Reader
+}
+
+func newGzipReadCloser(r io.Reader) (io.ReadCloser, error) {
+	poolGets.WithLabelValues(poolGzip).Inc()
+	if zr, ok := gzipPool.Get().(*gzip.Reader); ok {
+		if err := zr.Reset(r); err != nil {
+			gzipPool.Put(zr)
+			return nil, err
+		}
+		return &gzipReadCloser{Reader: zr}, nil
+	}
+
+	poolAllocs.WithLabelValues(poolGzip).Inc()
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return &gzipReadCloser{Reader: zr}, nil
+}
+
+func (rc *gzipReadCloser) Close() error {
+	if rc.Reader == nil {
+		return nil
+	}
+	err := rc.Reader.Close()
+	gzipPool.Put(rc.Reader)
+	rc.Reader = nil
+	return err
+}
@@ -52,6 +52,8 @@ type FluxBackend struct {
 	ProxyQueryService   query.ProxyQueryService
 	FluxLanguageService fluxlang.FluxLanguageService
 	Flagger             feature.Flagger
+
+	DashboardUsageService influxdb.DashboardUsageService
 }
 
 // NewFluxBackend returns a new instance of FluxBackend.
@@ -66,6 +68,8 @@ func NewFluxBackend(log *zap.Logger, b *APIBackend) *FluxBackend {
 		OrganizationService: b.OrganizationService,
 		FluxLanguageService: b.FluxLanguageService,
 		Flagger:             b.Flagger,
+
+		DashboardUsageService: b.DashboardUsageService,
 	}
 }
 
@@ -89,6 +93,10 @@ type FluxHandler struct {
 	EventRecorder metric.EventRecorder
 
 	Flagger feature.Flagger
+
+	// DashboardUsageService, if set, counts the queries of requests which name
+	// the dashboard, and optionally the cell, they are run for.
+	DashboardUsageService influxdb.DashboardUsageService
 }
 
 // Prefix provides the route prefix.
@@ -110,6 +118,8 @@ func NewFluxHandler(log *zap.Logger, b *FluxBackend) *FluxHandler {
 		EventRecorder:       b.QueryEventRecorder,
 		FluxLanguageService: b.FluxLanguageService,
 		Flagger:             b.Flagger,
+
+		DashboardUsageService: b.DashboardUsageService,
 	}
 
 	// query reponses can optionally be gzip encoded
@@ -193,6 +203,7 @@ func (h *FluxHandler) handleQuery(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	hd.SetHeaders(w)
+	h.recordDashboardQuery(ctx, r, orgID)
 
 	cw := iocounter.Writer{Writer: w}
 	stats, err := h.ProxyQueryService.Query(ctx, &cw, req)
@@ -216,6 +227,25 @@ func (h *FluxHandler) handleQuery(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// recordDashboardQuery counts the query towards the usage of the dashboard and cell
+// named by the dashboardID and cellID parameters of the request, if any. Malformed
+// IDs are ignored, since they do not affect the query itself.
+func (h *FluxHandler) recordDashboardQuery(ctx context.Context, r *http.Request, orgID platform.ID) {
+	if h.DashboardUsageService == nil {
+		return
+	}
+	qp := r.URL.Query()
+	dashboardID, err := platform.IDFromString(qp.Get("dashboardID"))
+	if err != nil {
+		return
+	}
+	var cellID platform.ID
+	if id, err := platform.IDFromString(qp.Get("cellID")); err == nil {
+		cellID = *id
+	}
+	h.DashboardUsageService.RecordDashboardQuery(ctx, orgID, *dashboardID, cellID)
+}
+
 func (h *FluxHandler) logFluxQuery(n int64, stats flux.Statistics, compiler flux.Compiler, err error) {
 	var q string
 	c, ok := compiler.(lang.FluxCompiler)
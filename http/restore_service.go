@@ -14,6 +14,7 @@ import (
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/authorizer"
 	context2 "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/dbrp"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
@@ -30,6 +31,7 @@ type RestoreBackend struct {
 	SqlBackupRestoreService influxdb.SqlBackupRestoreService
 	BucketService           influxdb.BucketService
 	AuthorizationService    influxdb.AuthorizationService
+	DBRPService             influxdb.DBRPMappingService
 }
 
 // NewRestoreBackend returns a new instance of RestoreBackend.
@@ -42,6 +44,7 @@ func NewRestoreBackend(b *APIBackend) *RestoreBackend {
 		SqlBackupRestoreService: b.SqlBackupRestoreService,
 		BucketService:           b.BucketService,
 		AuthorizationService:    b.AuthorizationService,
+		DBRPService:             b.DBRPService,
 	}
 }
 
@@ -56,6 +59,7 @@ type RestoreHandler struct {
 	SqlBackupRestoreService influxdb.SqlBackupRestoreService
 	BucketService           influxdb.BucketService
 	AuthorizationService    influxdb.AuthorizationService
+	DBRPService             influxdb.DBRPMappingService
 }
 
 const (
@@ -79,6 +83,7 @@ func NewRestoreHandler(b *RestoreBackend) *RestoreHandler {
 		SqlBackupRestoreService: b.SqlBackupRestoreService,
 		BucketService:           b.BucketService,
 		AuthorizationService:    b.AuthorizationService,
+		DBRPService:             b.DBRPService,
 		api:                     kithttp.NewAPI(kithttp.WithLog(b.Logger)),
 	}
 
@@ -264,6 +269,9 @@ func (h *RestoreHandler) handleRestoreBucketMetadata(w http.ResponseWriter, r *h
 		return
 	}
 	shardIDMap, err := h.RestoreService.RestoreBucket(ctx, bkt.ID, rawDbi)
+	if err == nil {
+		err = h.restoreDBRPs(ctx, bkt, b.DBRPs)
+	}
 	if err != nil {
 		h.Logger.Warn("Cleaning up after failed bucket-restore", zap.String("bucket_id", bkt.ID.String()))
 		if err2 := h.BucketService.DeleteBucket(ctx, bkt.ID); err2 != nil {
@@ -287,6 +295,35 @@ func (h *RestoreHandler) handleRestoreBucketMetadata(w http.ResponseWriter, r *h
 	h.api.Respond(w, r, http.StatusCreated, res)
 }
 
+// restoreDBRPs maps the databases and retention policies of a manifest to a
+// restored bucket. Those which are already mapped in the organization, such
+// as when a bucket is restored next to itself under another name, are kept
+// mapped to their bucket.
+func (h *RestoreHandler) restoreDBRPs(ctx context.Context, bkt influxdb.Bucket, ms []influxdb.DBRPManifest) error {
+	if len(ms) == 0 || h.DBRPService == nil {
+		return nil
+	}
+
+	mappings := make([]*influxdb.DBRPMapping, 0, len(ms))
+	for _, m := range ms {
+		mappings = append(mappings, &influxdb.DBRPMapping{
+			Database:        m.Database,
+			RetentionPolicy: m.RetentionPolicy,
+			Default:         m.Default,
+			BucketID:        bkt.ID,
+		})
+	}
+	res, err := dbrp.Import(ctx, h.DBRPService, bkt.OrgID, mappings, dbrp.ConflictSkip)
+	if err != nil {
+		return err
+	}
+	if res.Skipped > 0 {
+		h.Logger.Info("Kept existing DBRP mappings of restored bucket",
+			zap.String("bucket_id", bkt.ID.String()), zap.Int("skipped", res.Skipped))
+	}
+	return nil
+}
+
 func manifestToDbInfo(m influxdb.BucketMetadataManifest) meta.DatabaseInfo {
 	dbi := meta.DatabaseInfo{
 		Name:                   m.BucketName,
@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
+	"github.com/influxdata/flux/ast"
 	"github.com/influxdata/httprouter"
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/platform"
@@ -23,18 +25,20 @@ const (
 // the VariableHandler.
 type VariableBackend struct {
 	errors.HTTPErrorHandler
-	log             *zap.Logger
-	VariableService influxdb.VariableService
-	LabelService    influxdb.LabelService
+	log                   *zap.Logger
+	VariableService       influxdb.VariableService
+	VariableValuesService influxdb.VariableValuesService
+	LabelService          influxdb.LabelService
 }
 
 // NewVariableBackend creates a backend used by the variable handler.
 func NewVariableBackend(log *zap.Logger, b *APIBackend) *VariableBackend {
 	return &VariableBackend{
-		HTTPErrorHandler: b.HTTPErrorHandler,
-		log:              log,
-		VariableService:  b.VariableService,
-		LabelService:     b.LabelService,
+		HTTPErrorHandler:      b.HTTPErrorHandler,
+		log:                   log,
+		VariableService:       b.VariableService,
+		VariableValuesService: b.VariableValuesService,
+		LabelService:          b.LabelService,
 	}
 }
 
@@ -45,8 +49,9 @@ type VariableHandler struct {
 	errors.HTTPErrorHandler
 	log *zap.Logger
 
-	VariableService influxdb.VariableService
-	LabelService    influxdb.LabelService
+	VariableService       influxdb.VariableService
+	VariableValuesService influxdb.VariableValuesService
+	LabelService          influxdb.LabelService
 }
 
 // NewVariableHandler creates a new VariableHandler
@@ -56,8 +61,9 @@ func NewVariableHandler(log *zap.Logger, b *VariableBackend) *VariableHandler {
 		HTTPErrorHandler: b.HTTPErrorHandler,
 		log:              log,
 
-		VariableService: b.VariableService,
-		LabelService:    b.LabelService,
+		VariableService:       b.VariableService,
+		VariableValuesService: b.VariableValuesService,
+		LabelService:          b.LabelService,
 	}
 
 	entityPath := fmt.Sprintf("%s/:id", prefixVariables)
@@ -70,6 +76,8 @@ func NewVariableHandler(log *zap.Logger, b *VariableBackend) *VariableHandler {
 	h.HandlerFunc("PATCH", entityPath, h.handlePatchVariable)
 	h.HandlerFunc("PUT", entityPath, h.handlePutVariable)
 	h.HandlerFunc("DELETE", entityPath, h.handleDeleteVariable)
+	h.HandlerFunc("POST", entityPath+"/resolve", h.handlePostVariableResolve)
+	h.HandlerFunc("GET", entityPath+"/values", h.handleGetVariableValues)
 
 	labelBackend := &LabelBackend{
 		HTTPErrorHandler: b.HTTPErrorHandler,
@@ -448,6 +456,146 @@ func (h *VariableHandler) handleDeleteVariable(w http.ResponseWriter, r *http.Re
 	w.WriteHeader(http.StatusNoContent)
 }
 
+type postVariableResolveRequest struct {
+	// Selected overrides the current selection of variables by name, such as the
+	// choices made in a picker which have not been saved.
+	Selected map[string]string `json:"selected"`
+}
+
+type variableDependency struct {
+	ID    platform.ID `json:"id"`
+	Name  string      `json:"name"`
+	Value string      `json:"value"`
+}
+
+type variableResolveResponse struct {
+	// Dependencies are ordered so every variable comes after those it depends on.
+	Dependencies []variableDependency `json:"dependencies"`
+	// Extern defines the values of the dependencies in the v record, and is to be
+	// passed as the extern of the variable's query.
+	Extern *ast.File `json:"extern"`
+}
+
+// handlePostVariableResolve resolves the variables a query variable depends on,
+// with their selected values, so the variable's own values can be queried.
+func (h *VariableHandler) handlePostVariableResolve(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := requestVariableID(ctx)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	var req postVariableResolveRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.HandleHTTPError(ctx, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  err.Error(),
+			}, w)
+			return
+		}
+	}
+
+	variable, err := h.VariableService.FindVariableByID(ctx, id)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+	orgID := variable.OrganizationID
+	vars, err := h.VariableService.FindVariables(ctx, influxdb.VariableFilter{OrganizationID: &orgID})
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+	deps, err := influxdb.ResolveVariableDependencies(variable, vars)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	res := variableResolveResponse{
+		Dependencies: make([]variableDependency, 0, len(deps)),
+		Extern:       &ast.File{},
+	}
+	props := make([]*ast.Property, 0, len(deps))
+	for _, dep := range deps {
+		value, ok := req.Selected[dep.Name]
+		if !ok {
+			value, ok = dep.SelectedValue()
+		}
+		if !ok {
+			h.HandleHTTPError(ctx, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("variable %q has no selected value", dep.Name),
+			}, w)
+			return
+		}
+		res.Dependencies = append(res.Dependencies, variableDependency{ID: dep.ID, Name: dep.Name, Value: value})
+		props = append(props, &ast.Property{
+			Key:   &ast.Identifier{Name: dep.Name},
+			Value: &ast.StringLiteral{Value: value},
+		})
+	}
+	if len(props) > 0 {
+		res.Extern.Body = []ast.Statement{&ast.OptionStatement{
+			Assignment: &ast.VariableAssignment{
+				ID:   &ast.Identifier{Name: "v"},
+				Init: &ast.ObjectExpression{Properties: props},
+			},
+		}}
+	}
+
+	h.log.Debug("Variable dependencies resolved", zap.String("variableID", id.String()), zap.Int("dependencies", len(deps)))
+	if err := encodeResponse(ctx, w, http.StatusOK, res); err != nil {
+		logEncodingError(h.log, r, err)
+		return
+	}
+}
+
+// handleGetVariableValues returns the values of a variable, as evaluated by the server.
+// The cached values of query variables are queried again if refresh is set.
+func (h *VariableHandler) handleGetVariableValues(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if h.VariableValuesService == nil {
+		h.HandleHTTPError(ctx, &errors.Error{
+			Code: errors.ENotImplemented,
+			Msg:  "variable values are not supported",
+		}, w)
+		return
+	}
+
+	id, err := requestVariableID(ctx)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	var refresh bool
+	if v := r.URL.Query().Get("refresh"); v != "" {
+		if refresh, err = strconv.ParseBool(v); err != nil {
+			h.HandleHTTPError(ctx, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "refresh must be a boolean",
+				Err:  err,
+			}, w)
+			return
+		}
+	}
+
+	values, err := h.VariableValuesService.FindVariableValues(ctx, id, refresh)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+	h.log.Debug("Variable values retrieved", zap.String("variableID", id.String()), zap.Int("values", len(values.Values)))
+
+	if err := encodeResponse(ctx, w, http.StatusOK, values); err != nil {
+		logEncodingError(h.log, r, err)
+		return
+	}
+}
+
 // VariableService is a variable service over HTTP to the influxdb server
 type VariableService struct {
 	Client *httpc.Client
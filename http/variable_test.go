@@ -12,6 +12,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/influxdata/httprouter"
 	platform "github.com/influxdata/influxdb/v2"
 	platform2 "github.com/influxdata/influxdb/v2/kit/platform"
@@ -470,6 +471,121 @@ func TestVariableService_handleGetVariable(t *testing.T) {
 	}
 }
 
+func TestVariableService_handlePostVariableResolve(t *testing.T) {
+	query := func(id, name, q string) *platform.Variable {
+		return &platform.Variable{
+			ID:             itesting.MustIDBase16(id),
+			OrganizationID: platform2.ID(1),
+			Name:           name,
+			Arguments: &platform.VariableArguments{
+				Type:   "query",
+				Values: platform.VariableQueryValues{Query: q, Language: "flux"},
+			},
+		}
+	}
+	vars := []*platform.Variable{
+		{
+			ID:             itesting.MustIDBase16("0000000000000001"),
+			OrganizationID: platform2.ID(1),
+			Name:           "region",
+			Selected:       []string{"west"},
+			Arguments: &platform.VariableArguments{
+				Type:   "map",
+				Values: platform.VariableMapValues{"east": "us-east-1", "west": "us-west-2"},
+			},
+		},
+		query("0000000000000002", "host", `from(bucket: "b") |> filter(fn: (r) => r.region == v.region)`),
+		query("0000000000000003", "disk", `from(bucket: "b") |> filter(fn: (r) => r.host == v.host)`),
+	}
+
+	variableBackend := NewMockVariableBackend(t)
+	variableBackend.VariableService = &mock.VariableService{
+		FindVariableByIDF: func(ctx context.Context, id platform2.ID) (*platform.Variable, error) {
+			for _, v := range vars {
+				if v.ID == id {
+					return v, nil
+				}
+			}
+			return nil, &errors.Error{Code: errors.ENotFound, Msg: platform.ErrVariableNotFound}
+		},
+		FindVariablesF: func(ctx context.Context, f platform.VariableFilter, opts ...platform.FindOptions) ([]*platform.Variable, error) {
+			return vars, nil
+		},
+	}
+	h := NewVariableHandler(zaptest.NewLogger(t), variableBackend)
+
+	resolve := func(id, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("POST", "/api/v2/variables/"+id+"/resolve", bytes.NewBufferString(body)))
+		return w
+	}
+
+	w := resolve("0000000000000003", `{"selected": {"host": "server01"}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", w.Code, w.Body.String())
+	}
+	var res struct {
+		Dependencies []variableDependency `json:"dependencies"`
+		Extern       json.RawMessage      `json:"extern"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	want := []variableDependency{
+		{ID: vars[0].ID, Name: "region", Value: "us-west-2"},
+		{ID: vars[1].ID, Name: "host", Value: "server01"},
+	}
+	if diff := cmp.Diff(want, res.Dependencies); diff != "" {
+		t.Fatalf("unexpected dependencies -want/+got:\n%s", diff)
+	}
+	if !bytes.Contains(res.Extern, []byte(`"us-west-2"`)) || !bytes.Contains(res.Extern, []byte(`"server01"`)) {
+		t.Fatalf("extern is missing dependency values: %s", res.Extern)
+	}
+
+	// host has not been selected, and query variables have no default.
+	if w := resolve("0000000000000003", ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestVariableService_handleGetVariableValues(t *testing.T) {
+	variableBackend := NewMockVariableBackend(t)
+	h := NewVariableHandler(zaptest.NewLogger(t), variableBackend)
+
+	get := func(h http.Handler, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		return w
+	}
+
+	if w := get(h, "/api/v2/variables/0000000000000001/values"); w.Code != http.StatusNotImplemented {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusNotImplemented)
+	}
+
+	variableBackend.VariableValuesService = &mock.VariableValuesService{
+		FindVariableValuesF: func(ctx context.Context, id platform2.ID, refresh bool) (*platform.VariableValues, error) {
+			if !refresh {
+				t.Fatal("values were not refreshed")
+			}
+			return &platform.VariableValues{VariableID: id, Values: []string{"a", "b"}, RefreshedAt: faketime}, nil
+		},
+	}
+	h = NewVariableHandler(zaptest.NewLogger(t), variableBackend)
+
+	w := get(h, "/api/v2/variables/0000000000000001/values?refresh=true")
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", w.Code, w.Body.String())
+	}
+	want := `{"variableID":"0000000000000001","values":["a","b"],"refreshedAt":"2006-05-04T01:02:03Z"}`
+	if eq, diff, _ := jsonEqual(w.Body.String(), want); !eq {
+		t.Fatalf("unexpected body -got/+want:\n%s", diff)
+	}
+
+	if w := get(h, "/api/v2/variables/0000000000000001/values?refresh=maybe"); w.Code != http.StatusBadRequest {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
 func TestVariableService_handlePostVariable(t *testing.T) {
 	type fields struct {
 		VariableService platform.VariableService
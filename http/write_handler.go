@@ -16,6 +16,7 @@ import (
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/membudget"
 	"github.com/influxdata/influxdb/v2/models"
 	"github.com/influxdata/influxdb/v2/storage"
 	"github.com/influxdata/influxdb/v2/tsdb"
@@ -58,6 +59,7 @@ type WriteHandler struct {
 	router            *httprouter.Router
 	log               *zap.Logger
 	maxBatchSizeBytes int64
+	orgMemory         *membudget.Accountant
 	// parserOptions     []models.ParserOption
 }
 
@@ -72,6 +74,14 @@ func WithMaxBatchSizeBytes(n int64) WriteHandlerOption {
 	}
 }
 
+// WithOrgMemory configures the write handler to charge the memory
+// of the points batches to the budgets of their organizations
+func WithOrgMemory(a *membudget.Accountant) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.orgMemory = a
+	}
+}
+
 //func WithParserOptions(opts ...models.ParserOption) WriteHandlerOption {
 //	return func(w *WriteHandler) {
 //		w.parserOptions = opts
@@ -182,7 +192,9 @@ func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
 	// TODO: Backport?
 	//opts := append([]models.ParserOption{}, h.parserOptions...)
 	//opts = append(opts, models.WithParserPrecision(req.Precision))
-	parsed, err := points.NewParser(req.Precision).Parse(ctx, org.ID, bucket.ID, req.Body)
+	parser := points.NewParser(req.Precision)
+	parser.OrgMemory = h.orgMemory
+	parsed, err := parser.Parse(ctx, org.ID, bucket.ID, req.Body)
 	if err != nil {
 		h.HandleHTTPError(ctx, err, sw)
 		return
@@ -190,6 +202,7 @@ func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
 	requestBytes = parsed.RawSize
 
 	if err := h.PointsWriter.WritePoints(ctx, org.ID, bucket.ID, parsed.Points); err != nil {
+		parsed.Discard()
 		if partialErr, ok := err.(tsdb.PartialWriteError); ok {
 			h.HandleHTTPError(ctx, &errors.Error{
 				Code: errors.EUnprocessableEntity,
@@ -209,6 +222,10 @@ func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The points are only released once written, as writes which failed may
+	// still be in progress.
+	parsed.Release()
+
 	sw.WriteHeader(http.StatusNoContent)
 }
 
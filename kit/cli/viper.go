@@ -232,6 +232,25 @@ func BindOptions(v *viper.Viper, cmd *cobra.Command, opts []Opt) error {
 				}
 			}
 
+		case *float64:
+			var d float64
+			if o.Default != nil {
+				d = o.Default.(float64)
+			}
+			if hasShort {
+				flagset.Float64VarP(destP, o.Flag, string(o.Short), d, o.Desc)
+			} else {
+				flagset.Float64Var(destP, o.Flag, d, o.Desc)
+			}
+			if err := v.BindPFlag(o.Flag, flagset.Lookup(o.Flag)); err != nil {
+				return fmt.Errorf("failed to bind flag %q: %w", o.Flag, err)
+			}
+			if envVal != nil {
+				if f, err := cast.ToFloat64E(envVal); err == nil {
+					*destP = f
+				}
+			}
+
 		case *time.Duration:
 			var d time.Duration
 			if o.Default != nil {
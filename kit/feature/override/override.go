@@ -61,8 +61,17 @@ func (f Flagger) Flags(_ context.Context, flags ...feature.Flag) (map[string]int
 }
 
 func (f Flagger) coerce(s string, flag feature.Flag) (iface interface{}, err error) {
+	return Coerce(s, flag, f.byKey)
+}
+
+// Coerce parses the string representation of an override into a value of the same
+// type as the flag's default.
+func Coerce(s string, flag feature.Flag, byKey feature.ByKeyFn) (iface interface{}, err error) {
+	if byKey == nil {
+		byKey = feature.ByKey
+	}
 	if base, ok := flag.(feature.Base); ok {
-		flag, _ = f.byKey(base.Key())
+		flag, _ = byKey(base.Key())
 	}
 
 	switch flag.Default().(type) {
@@ -47,6 +47,20 @@ var (
 		Code: EForbidden,
 		Msg:  "password change required",
 	}
+
+	// EPasswordBanned is used when a password is on the banned list of the
+	// password policy.
+	EPasswordBanned = &Error{
+		Code: EInvalid,
+		Msg:  "password is too common, choose another one",
+	}
+
+	// EPasswordExpired is used when a password is older than the maximum age
+	// of the password policy.
+	EPasswordExpired = &Error{
+		Code: EForbidden,
+		Msg:  "password has expired",
+	}
 )
 
 // UserAlreadyExistsError is used when attempting to create a user with a name
@@ -0,0 +1,146 @@
+package prom
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// ReducedLabels are the labels of high cardinality which are aggregated away
+// by the reduced cardinality mode of a Filter.
+var ReducedLabels = []string{"bucket", "engine", "id", "path", "walPath", "partition", "shard"}
+
+// Filter reduces the metrics gathered for /metrics. The subsystem of a metric
+// family is a prefix of its name, ending before an underscore, such as go,
+// storage or storage_tsm for storage_tsm_files_total.
+type Filter struct {
+	// EnabledSubsystems, when not empty, are the only subsystems gathered.
+	EnabledSubsystems []string
+	// DisabledSubsystems are the subsystems not gathered.
+	DisabledSubsystems []string
+	// ReducedCardinality sums the metrics over the ReducedLabels, and only keeps
+	// the count and sum of histograms and summaries.
+	ReducedCardinality bool
+}
+
+// Enabled reports whether the filter filters any metric.
+func (f Filter) Enabled() bool {
+	return len(f.EnabledSubsystems) > 0 || len(f.DisabledSubsystems) > 0 || f.ReducedCardinality
+}
+
+// Gatherer returns g gathering the metrics which pass the filter.
+func (f Filter) Gatherer(g prometheus.Gatherer) prometheus.Gatherer {
+	if !f.Enabled() {
+		return g
+	}
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		mfs, err := g.Gather()
+		filtered := make([]*dto.MetricFamily, 0, len(mfs))
+		for _, mf := range mfs {
+			if !f.gathers(mf.GetName()) {
+				continue
+			}
+			if f.ReducedCardinality {
+				mf = reduce(mf)
+			}
+			filtered = append(filtered, mf)
+		}
+		return filtered, err
+	})
+}
+
+func (f Filter) gathers(name string) bool {
+	if len(f.EnabledSubsystems) > 0 && !inSubsystems(name, f.EnabledSubsystems) {
+		return false
+	}
+	return !inSubsystems(name, f.DisabledSubsystems)
+}
+
+func inSubsystems(name string, subsystems []string) bool {
+	for _, s := range subsystems {
+		if name == s || strings.HasPrefix(name, s+"_") {
+			return true
+		}
+	}
+	return false
+}
+
+// reduce sums the metrics of the family which only differ by the
+// ReducedLabels, dropping the buckets of histograms and quantiles of summaries.
+func reduce(mf *dto.MetricFamily) *dto.MetricFamily {
+	reduced := &dto.MetricFamily{
+		Name: mf.Name,
+		Help: mf.Help,
+		Type: mf.Type,
+	}
+	index := map[string]*dto.Metric{}
+	for _, m := range mf.Metric {
+		var (
+			key    strings.Builder
+			labels []*dto.LabelPair
+		)
+		for _, lp := range m.Label {
+			if isReducedLabel(lp.GetName()) {
+				continue
+			}
+			labels = append(labels, lp)
+			key.WriteString(lp.GetName())
+			key.WriteByte(0)
+			key.WriteString(lp.GetValue())
+			key.WriteByte(0)
+		}
+
+		sum, ok := index[key.String()]
+		if !ok {
+			sum = newReducedMetric(mf.GetType(), labels)
+			index[key.String()] = sum
+			reduced.Metric = append(reduced.Metric, sum)
+		}
+		addMetric(sum, m)
+	}
+	return reduced
+}
+
+func isReducedLabel(name string) bool {
+	for _, l := range ReducedLabels {
+		if l == name {
+			return true
+		}
+	}
+	return false
+}
+
+func newReducedMetric(typ dto.MetricType, labels []*dto.LabelPair) *dto.Metric {
+	m := &dto.Metric{Label: labels}
+	switch typ {
+	case dto.MetricType_COUNTER:
+		m.Counter = &dto.Counter{Value: new(float64)}
+	case dto.MetricType_GAUGE:
+		m.Gauge = &dto.Gauge{Value: new(float64)}
+	case dto.MetricType_SUMMARY:
+		m.Summary = &dto.Summary{SampleCount: new(uint64), SampleSum: new(float64)}
+	case dto.MetricType_HISTOGRAM:
+		m.Histogram = &dto.Histogram{SampleCount: new(uint64), SampleSum: new(float64)}
+	default:
+		m.Untyped = &dto.Untyped{Value: new(float64)}
+	}
+	return m
+}
+
+func addMetric(sum, m *dto.Metric) {
+	switch {
+	case sum.Counter != nil:
+		*sum.Counter.Value += m.GetCounter().GetValue()
+	case sum.Gauge != nil:
+		*sum.Gauge.Value += m.GetGauge().GetValue()
+	case sum.Summary != nil:
+		*sum.Summary.SampleCount += m.GetSummary().GetSampleCount()
+		*sum.Summary.SampleSum += m.GetSummary().GetSampleSum()
+	case sum.Histogram != nil:
+		*sum.Histogram.SampleCount += m.GetHistogram().GetSampleCount()
+		*sum.Histogram.SampleSum += m.GetHistogram().GetSampleSum()
+	case sum.Untyped != nil:
+		*sum.Untyped.Value += m.GetUntyped().GetValue()
+	}
+}
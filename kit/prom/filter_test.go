@@ -0,0 +1,88 @@
+package prom_test
+
+import (
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/kit/prom"
+	"github.com/influxdata/influxdb/v2/kit/prom/promtest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func newFilterTestRegistry(t *testing.T) *prom.Registry {
+	t.Helper()
+	reg := prom.NewRegistry(zaptest.NewLogger(t))
+
+	files := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "storage_tsm_files_total",
+		Help: "Files of the engine",
+	}, []string{"bucket", "engine", "id", "path", "walPath"})
+	files.WithLabelValues("b1", "tsm1", "1", "/data/1", "/wal/1").Set(2)
+	files.WithLabelValues("b1", "tsm1", "2", "/data/2", "/wal/2").Set(3)
+	files.WithLabelValues("b2", "tsm1", "3", "/data/3", "/wal/3").Set(4)
+
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_api_requests_total",
+		Help: "Requests of the API",
+	}, []string{"path", "status"})
+	requests.WithLabelValues("/api/v2/write", "2XX").Add(5)
+	requests.WithLabelValues("/api/v2/query", "2XX").Add(6)
+	requests.WithLabelValues("/api/v2/query", "5XX").Add(1)
+
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "http_api_request_duration_seconds",
+		Help: "Durations of the requests of the API",
+	}, []string{"path"})
+	duration.WithLabelValues("/api/v2/write").Observe(0.1)
+	duration.WithLabelValues("/api/v2/query").Observe(0.3)
+
+	reg.MustRegister(files, requests, duration, prometheus.NewGoCollector())
+	return reg
+}
+
+func names(t *testing.T, g prometheus.Gatherer) []string {
+	t.Helper()
+	var ns []string
+	for _, mf := range promtest.MustGather(t, g) {
+		ns = append(ns, mf.GetName())
+	}
+	return ns
+}
+
+func TestFilter_Subsystems(t *testing.T) {
+	reg := newFilterTestRegistry(t)
+
+	assert.Same(t, reg.Registry, prom.Filter{}.Gatherer(reg.Registry))
+
+	ns := names(t, prom.Filter{EnabledSubsystems: []string{"storage", "http_api"}}.Gatherer(reg))
+	assert.Equal(t, []string{"http_api_request_duration_seconds", "http_api_requests_total", "storage_tsm_files_total"}, ns)
+
+	ns = names(t, prom.Filter{DisabledSubsystems: []string{"go", "storage_tsm"}}.Gatherer(reg))
+	assert.Equal(t, []string{"http_api_request_duration_seconds", "http_api_requests_total"}, ns)
+
+	// subsystems are whole words of the names.
+	ns = names(t, prom.Filter{EnabledSubsystems: []string{"http_api", "stor"}, DisabledSubsystems: []string{"http_api_requests_total"}}.Gatherer(reg))
+	assert.Equal(t, []string{"http_api_request_duration_seconds"}, ns)
+}
+
+func TestFilter_ReducedCardinality(t *testing.T) {
+	reg := newFilterTestRegistry(t)
+	mfs := promtest.MustGather(t, prom.Filter{ReducedCardinality: true, EnabledSubsystems: []string{"storage", "http"}}.Gatherer(reg))
+
+	files := promtest.MustFindMetric(t, mfs, "storage_tsm_files_total", nil)
+	assert.Equal(t, float64(9), files.GetGauge().GetValue())
+	assert.Empty(t, files.Label)
+
+	// path is a reduced label.
+	requests := promtest.MustFindMetric(t, mfs, "http_api_requests_total", map[string]string{"status": "2XX"})
+	assert.Equal(t, float64(11), requests.GetCounter().GetValue())
+	requests = promtest.MustFindMetric(t, mfs, "http_api_requests_total", map[string]string{"status": "5XX"})
+	assert.Equal(t, float64(1), requests.GetCounter().GetValue())
+
+	duration := promtest.MustFindMetric(t, mfs, "http_api_request_duration_seconds", nil)
+	assert.Equal(t, uint64(2), duration.GetHistogram().GetSampleCount())
+	assert.InDelta(t, 0.4, duration.GetHistogram().GetSampleSum(), 1e-9)
+	require.Empty(t, duration.GetHistogram().GetBucket())
+}
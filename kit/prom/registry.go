@@ -37,11 +37,20 @@ func NewRegistry(log *zap.Logger) *Registry {
 // HTTPHandler returns an http.Handler for the registry,
 // so that the /metrics HTTP handler is uniformly configured across all apps in the platform.
 func (r *Registry) HTTPHandler() http.Handler {
-	opts := promhttp.HandlerOpts{
+	return promhttp.HandlerFor(r.Registry, r.handlerOpts())
+}
+
+// FilteredHTTPHandler returns an http.Handler for the metrics of the registry
+// which pass the filter.
+func (r *Registry) FilteredHTTPHandler(f Filter) http.Handler {
+	return promhttp.HandlerFor(f.Gatherer(r.Registry), r.handlerOpts())
+}
+
+func (r *Registry) handlerOpts() promhttp.HandlerOpts {
+	return promhttp.HandlerOpts{
 		ErrorLog: promLogger{r: r},
 		// TODO(mr): decide if we want to set MaxRequestsInFlight or Timeout.
 	}
-	return promhttp.HandlerFor(r.Registry, opts)
 }
 
 // promLogger satisfies the promhttp.logger interface with the registry.
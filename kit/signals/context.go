@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"os/signal"
+	"syscall"
 )
 
 // WithSignals returns a context that is canceled with any signal in sigs.
@@ -24,7 +25,8 @@ func WithSignals(ctx context.Context, sigs ...os.Signal) context.Context {
 	return ctx
 }
 
-// WithStandardSignals cancels the context on os.Interrupt, os.Kill.
+// WithStandardSignals cancels the context on os.Interrupt, syscall.SIGTERM.
+// os.Kill cannot be caught, so it is not included.
 func WithStandardSignals(ctx context.Context) context.Context {
-	return WithSignals(ctx, os.Interrupt, os.Kill)
+	return WithSignals(ctx, os.Interrupt, syscall.SIGTERM)
 }
@@ -0,0 +1,55 @@
+// Package systemd implements the sd_notify protocol, which lets a service
+// managed by systemd with Type=notify report its state to the service manager.
+package systemd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+const (
+	// Ready tells the service manager that startup is complete.
+	Ready = "READY=1"
+	// Stopping tells the service manager that the service is shutting down.
+	Stopping = "STOPPING=1"
+)
+
+// ExtendTimeout asks the service manager to extend the current start or stop
+// timeout so that it expires no sooner than d from now.
+func ExtendTimeout(d time.Duration) string {
+	return fmt.Sprintf("EXTEND_TIMEOUT_USEC=%d", d.Microseconds())
+}
+
+// Notify sends one or more newline separated states to the service manager.
+// It returns false without error if the process was not started by systemd
+// with a notification socket.
+func Notify(states ...string) (bool, error) {
+	addr := os.Getenv("NOTIFY_SOCKET")
+	if addr == "" {
+		return false, nil
+	}
+	// A leading @ denotes a socket in the abstract namespace.
+	if addr[0] == '@' {
+		addr = "\x00" + addr[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	var msg []byte
+	for i, s := range states {
+		if i > 0 {
+			msg = append(msg, '\n')
+		}
+		msg = append(msg, s...)
+	}
+	if _, err := conn.Write(msg); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,38 @@
+//go:build !windows
+
+package systemd
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotify(t *testing.T) {
+	t.Run("no socket", func(t *testing.T) {
+		t.Setenv("NOTIFY_SOCKET", "")
+		sent, err := Notify(Ready)
+		require.NoError(t, err)
+		require.False(t, sent)
+	})
+
+	t.Run("sends states", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "notify.sock")
+		conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+		require.NoError(t, err)
+		defer conn.Close()
+		t.Setenv("NOTIFY_SOCKET", path)
+
+		sent, err := Notify(Stopping, ExtendTimeout(3*time.Second))
+		require.NoError(t, err)
+		require.True(t, sent)
+
+		buf := make([]byte, 128)
+		n, err := conn.Read(buf)
+		require.NoError(t, err)
+		require.Equal(t, "STOPPING=1\nEXTEND_TIMEOUT_USEC=3000000", string(buf[:n]))
+	})
+}
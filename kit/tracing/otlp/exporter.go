@@ -0,0 +1,366 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	tracesPath = "/v1/traces"
+	scopeName  = "github.com/influxdata/influxdb/v2/kit/tracing"
+
+	// maxQueueSize is the number of finished spans which wait to be exported,
+	// further spans are dropped.
+	maxQueueSize = 4096
+	// maxBatchSize is the number of spans exported at once.
+	maxBatchSize = 512
+	// exportInterval is how often the spans which finished are exported.
+	exportInterval = 5 * time.Second
+	// exportTimeout bounds the export of a batch of spans.
+	exportTimeout = 10 * time.Second
+)
+
+// The OTLP span kinds.
+const (
+	spanKindInternal = 1
+	spanKindServer   = 2
+	spanKindClient   = 3
+	spanKindProducer = 4
+	spanKindConsumer = 5
+)
+
+// statusCodeError is the OTLP status code of failed spans.
+const statusCodeError = 2
+
+// exporter exports finished spans in batches with the OTLP/HTTP protocol,
+// encoded as JSON.
+type exporter struct {
+	log     *zap.Logger
+	client  *http.Client
+	url     string
+	headers map[string]string
+
+	resource otlpResource
+
+	queue chan *Span
+	flush chan chan struct{} // exports the queued spans, and closes the channel once they are.
+	done  chan struct{}
+	wg    sync.WaitGroup
+
+	mu      sync.Mutex
+	dropped int
+}
+
+func newExporter(log *zap.Logger, cfg Config) (*exporter, error) {
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q: expected an http or https URL", cfg.Endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = tracesPath
+	}
+
+	e := &exporter{
+		log:     log,
+		client:  &http.Client{Timeout: exportTimeout},
+		url:     u.String(),
+		headers: cfg.Headers,
+		resource: otlpResource{Attributes: []otlpAttribute{
+			stringAttribute("service.name", cfg.ServiceName),
+		}},
+		queue: make(chan *Span, maxQueueSize),
+		flush: make(chan chan struct{}),
+		done:  make(chan struct{}),
+	}
+	if cfg.ServiceVersion != "" {
+		e.resource.Attributes = append(e.resource.Attributes, stringAttribute("service.version", cfg.ServiceVersion))
+	}
+	e.wg.Add(1)
+	go e.run()
+	return e, nil
+}
+
+// add queues a finished span to be exported, the span is dropped when the
+// queue is full.
+func (e *exporter) add(s *Span) {
+	select {
+	case e.queue <- s:
+	default:
+		e.mu.Lock()
+		e.dropped++
+		e.mu.Unlock()
+	}
+}
+
+// close exports the queued spans and stops the exporter.
+func (e *exporter) close() error {
+	close(e.done)
+	e.wg.Wait()
+	return nil
+}
+
+func (e *exporter) run() {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, maxBatchSize)
+	export := func() {
+		if len(batch) > 0 {
+			e.export(batch)
+			batch = batch[:0]
+		}
+	}
+	drain := func() {
+		for {
+			select {
+			case s := <-e.queue:
+				batch = append(batch, s)
+				if len(batch) == maxBatchSize {
+					export()
+				}
+			default:
+				export()
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case s := <-e.queue:
+			batch = append(batch, s)
+			if len(batch) == maxBatchSize {
+				export()
+			}
+		case <-ticker.C:
+			export()
+		case c := <-e.flush:
+			drain()
+			close(c)
+		case <-e.done:
+			drain()
+			return
+		}
+	}
+}
+
+func (e *exporter) export(spans []*Span) {
+	e.mu.Lock()
+	if e.dropped > 0 {
+		e.log.Warn("Dropped spans, the export of spans is falling behind", zap.Int("spans", e.dropped))
+		e.dropped = 0
+	}
+	e.mu.Unlock()
+
+	req := otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: e.resource,
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: scopeName},
+			Spans: make([]otlpSpan, 0, len(spans)),
+		}},
+	}}}
+	for _, s := range spans {
+		req.ResourceSpans[0].ScopeSpans[0].Spans = append(req.ResourceSpans[0].ScopeSpans[0].Spans, encodeSpan(s))
+	}
+
+	if err := e.post(req); err != nil {
+		e.log.Warn("Failed to export spans", zap.Int("spans", len(spans)), zap.Error(err))
+	}
+}
+
+func (e *exporter) post(body otlpRequest) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("collector responded with %s", resp.Status)
+	}
+	return nil
+}
+
+func encodeSpan(s *Span) otlpSpan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	span := otlpSpan{
+		TraceID:           hex.EncodeToString(s.ctx.traceID[:]),
+		SpanID:            hex.EncodeToString(s.ctx.spanID[:]),
+		TraceState:        s.ctx.state,
+		Name:              s.name,
+		Kind:              s.kind(),
+		StartTimeUnixNano: unixNano(s.start),
+		EndTimeUnixNano:   unixNano(s.end),
+	}
+	if s.parentID != [8]byte{} {
+		span.ParentSpanID = hex.EncodeToString(s.parentID[:])
+	}
+
+	for k, v := range s.tags {
+		if k == "error" {
+			if failed, ok := v.(bool); ok && failed {
+				span.Status = &otlpStatus{Code: statusCodeError}
+			}
+		}
+		span.Attributes = append(span.Attributes, otlpAttribute{Key: k, Value: attributeValue(v)})
+	}
+
+	for _, r := range s.logs {
+		event := otlpEvent{Name: "log", TimeUnixNano: unixNano(r.Timestamp)}
+		for _, f := range r.Fields {
+			switch f.Key() {
+			case "event":
+				event.Name = fmt.Sprint(f.Value())
+				continue
+			case "error", "error.object":
+				if span.Status == nil {
+					span.Status = &otlpStatus{Code: statusCodeError}
+				}
+				if span.Status.Message == "" {
+					span.Status.Message = fmt.Sprint(f.Value())
+				}
+			}
+			event.Attributes = append(event.Attributes, otlpAttribute{Key: f.Key(), Value: attributeValue(f.Value())})
+		}
+		span.Events = append(span.Events, event)
+	}
+	return span
+}
+
+func attributeValue(v interface{}) otlpValue {
+	switch v := v.(type) {
+	case string:
+		return otlpValue{StringValue: &v}
+	case bool:
+		return otlpValue{BoolValue: &v}
+	case int:
+		return intValue(int64(v))
+	case int8:
+		return intValue(int64(v))
+	case int16:
+		return intValue(int64(v))
+	case int32:
+		return intValue(int64(v))
+	case int64:
+		return intValue(v)
+	case uint8:
+		return intValue(int64(v))
+	case uint16:
+		return intValue(int64(v))
+	case uint32:
+		return intValue(int64(v))
+	case float32:
+		f := float64(v)
+		return otlpValue{DoubleValue: &f}
+	case float64:
+		return otlpValue{DoubleValue: &v}
+	case error:
+		return otlpValue{StringValue: strPtr(v.Error())}
+	case fmt.Stringer:
+		return otlpValue{StringValue: strPtr(v.String())}
+	default:
+		return otlpValue{StringValue: strPtr(fmt.Sprint(v))}
+	}
+}
+
+func intValue(n int64) otlpValue {
+	// 64 bit integers are encoded as strings in OTLP JSON.
+	s := strconv.FormatInt(n, 10)
+	return otlpValue{IntValue: &s}
+}
+
+func stringAttribute(k, v string) otlpAttribute {
+	return otlpAttribute{Key: k, Value: otlpValue{StringValue: &v}}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// The JSON encoding of an OTLP ExportTraceServiceRequest.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		TraceState        string          `json:"traceState,omitempty"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		Name              string          `json:"name"`
+		Kind              int             `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Events            []otlpEvent     `json:"events,omitempty"`
+		Status            *otlpStatus     `json:"status,omitempty"`
+	}
+	otlpEvent struct {
+		TimeUnixNano string          `json:"timeUnixNano"`
+		Name         string          `json:"name"`
+		Attributes   []otlpAttribute `json:"attributes,omitempty"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+	}
+)
@@ -0,0 +1,461 @@
+// Package otlp implements an opentracing tracer which exports its spans to an
+// OpenTelemetry collector with the OTLP/HTTP protocol, and propagates them
+// with W3C trace context headers.
+package otlp
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"go.uber.org/zap"
+)
+
+const (
+	traceparentHeader = "traceparent"
+	tracestateHeader  = "tracestate"
+	baggageHeader     = "baggage"
+
+	// DefaultEndpoint is the OTLP/HTTP endpoint of a local collector.
+	DefaultEndpoint = "http://localhost:4318"
+	// DefaultServiceName is the service.name of the spans of influxd.
+	DefaultServiceName = "influxd"
+)
+
+// Config configures the tracer and the export of its spans.
+type Config struct {
+	// Endpoint is the URL of the collector. Spans are posted to /v1/traces of
+	// it, unless it has a path of its own.
+	Endpoint string
+	// Headers are added to the export requests, as for authentication.
+	Headers map[string]string
+	// ServiceName is the service.name resource attribute of the spans.
+	ServiceName string
+	// ServiceVersion is the service.version resource attribute of the spans.
+	ServiceVersion string
+	// SampleRatio is the ratio of the traces started by influxd which are
+	// sampled. The traces of requests follow the sampling of their caller.
+	SampleRatio float64
+}
+
+// Tracer implements opentracing.Tracer, the spans it samples are exported
+// when they finish.
+type Tracer struct {
+	exporter  *exporter
+	threshold uint64 // root traces whose ID is below threshold are sampled.
+}
+
+var _ opentracing.Tracer = (*Tracer)(nil)
+
+// NewTracer returns a tracer exporting its spans as configured by cfg. The
+// tracer must be closed to export its last spans.
+func NewTracer(log *zap.Logger, cfg Config) (*Tracer, error) {
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return nil, fmt.Errorf("trace sample ratio must be between 0 and 1, got %v", cfg.SampleRatio)
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = DefaultEndpoint
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = DefaultServiceName
+	}
+	exp, err := newExporter(log, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	threshold := uint64(math.MaxUint64)
+	if cfg.SampleRatio < 1 {
+		threshold = uint64(cfg.SampleRatio * math.MaxUint64)
+	}
+	return &Tracer{exporter: exp, threshold: threshold}, nil
+}
+
+// Close exports the spans which are not exported yet.
+func (t *Tracer) Close() error {
+	return t.exporter.close()
+}
+
+// StartSpan starts a span. The first ChildOf reference is the parent of the
+// span, or else the first FollowsFrom reference.
+func (t *Tracer) StartSpan(operationName string, opts ...opentracing.StartSpanOption) opentracing.Span {
+	so := opentracing.StartSpanOptions{}
+	for _, opt := range opts {
+		opt.Apply(&so)
+	}
+	if so.StartTime.IsZero() {
+		so.StartTime = time.Now()
+	}
+
+	var parent *SpanContext
+	for _, ref := range so.References {
+		sc, ok := ref.ReferencedContext.(SpanContext)
+		if !ok || !sc.valid() {
+			continue
+		}
+		if parent == nil || ref.Type == opentracing.ChildOfRef {
+			sc := sc
+			parent = &sc
+		}
+		if ref.Type == opentracing.ChildOfRef {
+			break
+		}
+	}
+
+	s := &Span{
+		tracer: t,
+		name:   operationName,
+		start:  so.StartTime,
+	}
+	if parent != nil {
+		s.ctx = SpanContext{
+			traceID: parent.traceID,
+			sampled: parent.sampled,
+			state:   parent.state,
+			baggage: copyBaggage(parent.baggage),
+		}
+		s.parentID = parent.spanID
+	} else {
+		s.ctx.traceID = newTraceID()
+		s.ctx.sampled = binary.BigEndian.Uint64(s.ctx.traceID[8:]) < t.threshold
+	}
+	s.ctx.spanID = newSpanID()
+
+	for k, v := range so.Tags {
+		s.SetTag(k, v)
+	}
+	return s
+}
+
+// Inject writes the W3C trace context of sc to the TextMap or HTTPHeaders
+// carrier.
+func (t *Tracer) Inject(sm opentracing.SpanContext, format interface{}, carrier interface{}) error {
+	sc, ok := sm.(SpanContext)
+	if !ok {
+		return opentracing.ErrInvalidSpanContext
+	}
+	if format != opentracing.TextMap && format != opentracing.HTTPHeaders {
+		return opentracing.ErrUnsupportedFormat
+	}
+	w, ok := carrier.(opentracing.TextMapWriter)
+	if !ok {
+		return opentracing.ErrInvalidCarrier
+	}
+
+	flags := "00"
+	if sc.sampled {
+		flags = "01"
+	}
+	w.Set(traceparentHeader, "00-"+hex.EncodeToString(sc.traceID[:])+"-"+hex.EncodeToString(sc.spanID[:])+"-"+flags)
+	if sc.state != "" {
+		w.Set(tracestateHeader, sc.state)
+	}
+	if len(sc.baggage) > 0 {
+		items := make([]string, 0, len(sc.baggage))
+		for k, v := range sc.baggage {
+			items = append(items, url.QueryEscape(k)+"="+url.QueryEscape(v))
+		}
+		w.Set(baggageHeader, strings.Join(items, ","))
+	}
+	return nil
+}
+
+// Extract reads a W3C trace context from the TextMap or HTTPHeaders carrier.
+func (t *Tracer) Extract(format interface{}, carrier interface{}) (opentracing.SpanContext, error) {
+	if format != opentracing.TextMap && format != opentracing.HTTPHeaders {
+		return nil, opentracing.ErrUnsupportedFormat
+	}
+	r, ok := carrier.(opentracing.TextMapReader)
+	if !ok {
+		return nil, opentracing.ErrInvalidCarrier
+	}
+
+	var parent, state, baggage string
+	_ = r.ForeachKey(func(k, v string) error {
+		switch strings.ToLower(k) {
+		case traceparentHeader:
+			parent = v
+		case tracestateHeader:
+			state = v
+		case baggageHeader:
+			baggage = v
+		}
+		return nil
+	})
+	if parent == "" {
+		return nil, opentracing.ErrSpanContextNotFound
+	}
+
+	sc, err := parseTraceparent(parent)
+	if err != nil {
+		return nil, err
+	}
+	sc.state = state
+	if baggage != "" {
+		sc.baggage = map[string]string{}
+		for _, item := range strings.Split(baggage, ",") {
+			// properties of the items are not kept.
+			item, _, _ = strings.Cut(item, ";")
+			k, v, ok := strings.Cut(strings.TrimSpace(item), "=")
+			if !ok {
+				continue
+			}
+			k, kerr := url.QueryUnescape(k)
+			v, verr := url.QueryUnescape(v)
+			if kerr == nil && verr == nil {
+				sc.baggage[k] = v
+			}
+		}
+	}
+	return sc, nil
+}
+
+// parseTraceparent parses a version 00 traceparent header, and the headers of
+// later versions as far as version 00 defines them.
+func parseTraceparent(v string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return sc, opentracing.ErrSpanContextCorrupted
+	}
+	if len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, opentracing.ErrSpanContextCorrupted
+	}
+	if _, err := hex.Decode(sc.traceID[:], []byte(parts[1])); err != nil {
+		return sc, opentracing.ErrSpanContextCorrupted
+	}
+	if _, err := hex.Decode(sc.spanID[:], []byte(parts[2])); err != nil {
+		return sc, opentracing.ErrSpanContextCorrupted
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return sc, opentracing.ErrSpanContextCorrupted
+	}
+	if !sc.valid() {
+		return sc, opentracing.ErrSpanContextCorrupted
+	}
+	sc.sampled = flags[0]&1 == 1
+	return sc, nil
+}
+
+// SpanContext implements opentracing.SpanContext.
+type SpanContext struct {
+	traceID [16]byte
+	spanID  [8]byte
+	sampled bool
+	state   string
+	baggage map[string]string
+}
+
+var _ opentracing.SpanContext = SpanContext{}
+
+func (c SpanContext) valid() bool {
+	return c.traceID != [16]byte{} && c.spanID != [8]byte{}
+}
+
+// TraceID returns the hex encoded trace ID of the span.
+func (c SpanContext) TraceID() string {
+	return hex.EncodeToString(c.traceID[:])
+}
+
+// IsSampled returns whether the trace of the span is sampled.
+func (c SpanContext) IsSampled() bool {
+	return c.sampled
+}
+
+// ForeachBaggageItem implements opentracing.SpanContext.
+func (c SpanContext) ForeachBaggageItem(handler func(k, v string) bool) {
+	for k, v := range c.baggage {
+		if !handler(k, v) {
+			return
+		}
+	}
+}
+
+func copyBaggage(b map[string]string) map[string]string {
+	if len(b) == 0 {
+		return nil
+	}
+	c := make(map[string]string, len(b))
+	for k, v := range b {
+		c[k] = v
+	}
+	return c
+}
+
+// Span implements opentracing.Span. The tags and logs of spans which are not
+// sampled are dropped.
+type Span struct {
+	tracer   *Tracer
+	parentID [8]byte
+
+	mu       sync.Mutex
+	ctx      SpanContext
+	name     string
+	start    time.Time
+	end      time.Time
+	tags     map[string]interface{}
+	logs     []opentracing.LogRecord
+	finished bool
+}
+
+var _ opentracing.Span = (*Span)(nil)
+
+// Finish finishes the span, and exports it when it is sampled.
+func (s *Span) Finish() {
+	s.FinishWithOptions(opentracing.FinishOptions{})
+}
+
+// FinishWithOptions implements opentracing.Span.
+func (s *Span) FinishWithOptions(opts opentracing.FinishOptions) {
+	if opts.FinishTime.IsZero() {
+		opts.FinishTime = time.Now()
+	}
+
+	s.mu.Lock()
+	if s.finished {
+		s.mu.Unlock()
+		return
+	}
+	s.finished = true
+	s.end = opts.FinishTime
+	if s.ctx.sampled {
+		s.logs = append(s.logs, opts.LogRecords...)
+	}
+	s.mu.Unlock()
+
+	if s.ctx.sampled {
+		s.tracer.exporter.add(s)
+	}
+}
+
+// Context implements opentracing.Span.
+func (s *Span) Context() opentracing.SpanContext {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ctx
+}
+
+// SetOperationName implements opentracing.Span.
+func (s *Span) SetOperationName(operationName string) opentracing.Span {
+	s.mu.Lock()
+	s.name = operationName
+	s.mu.Unlock()
+	return s
+}
+
+// SetTag implements opentracing.Span.
+func (s *Span) SetTag(key string, value interface{}) opentracing.Span {
+	if !s.ctx.sampled {
+		return s
+	}
+	s.mu.Lock()
+	if s.tags == nil {
+		s.tags = map[string]interface{}{}
+	}
+	s.tags[key] = value
+	s.mu.Unlock()
+	return s
+}
+
+// LogFields implements opentracing.Span.
+func (s *Span) LogFields(fields ...log.Field) {
+	if !s.ctx.sampled {
+		return
+	}
+	s.mu.Lock()
+	s.logs = append(s.logs, opentracing.LogRecord{Timestamp: time.Now(), Fields: fields})
+	s.mu.Unlock()
+}
+
+// LogKV implements opentracing.Span.
+func (s *Span) LogKV(alternatingKeyValues ...interface{}) {
+	if !s.ctx.sampled {
+		return
+	}
+	fields, err := log.InterleavedKVToFields(alternatingKeyValues...)
+	if err != nil {
+		s.LogFields(log.Error(err), log.String("function", "LogKV"))
+		return
+	}
+	s.LogFields(fields...)
+}
+
+// SetBaggageItem implements opentracing.Span.
+func (s *Span) SetBaggageItem(restrictedKey, value string) opentracing.Span {
+	s.mu.Lock()
+	b := copyBaggage(s.ctx.baggage)
+	if b == nil {
+		b = map[string]string{}
+	}
+	b[restrictedKey] = value
+	s.ctx.baggage = b
+	s.mu.Unlock()
+	return s
+}
+
+// BaggageItem implements opentracing.Span.
+func (s *Span) BaggageItem(restrictedKey string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ctx.baggage[restrictedKey]
+}
+
+// Tracer implements opentracing.Span.
+func (s *Span) Tracer() opentracing.Tracer {
+	return s.tracer
+}
+
+// LogEvent is deprecated, as such it is not implemented.
+func (s *Span) LogEvent(event string) {
+	panic("use of deprecated LogEvent: not implemented")
+}
+
+// LogEventWithPayload is deprecated, as such it is not implemented.
+func (s *Span) LogEventWithPayload(event string, payload interface{}) {
+	panic("use of deprecated LogEventWithPayload: not implemented")
+}
+
+// Log is deprecated, as such it is not implemented.
+func (s *Span) Log(data opentracing.LogData) {
+	panic("use of deprecated Log: not implemented")
+}
+
+// kind returns the OTLP span kind of the span.kind tag of the span.
+func (s *Span) kind() int {
+	switch fmt.Sprint(s.tags[string(ext.SpanKind)]) {
+	case string(ext.SpanKindRPCServerEnum):
+		return spanKindServer
+	case string(ext.SpanKindRPCClientEnum):
+		return spanKindClient
+	case string(ext.SpanKindProducerEnum):
+		return spanKindProducer
+	case string(ext.SpanKindConsumerEnum):
+		return spanKindConsumer
+	default:
+		return spanKindInternal
+	}
+}
+
+func newTraceID() (id [16]byte) {
+	for id == [16]byte{} {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() (id [8]byte) {
+	for id == [8]byte{} {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
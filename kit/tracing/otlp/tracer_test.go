@@ -0,0 +1,183 @@
+package otlp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// sync exports the queued spans.
+func (e *exporter) sync() {
+	c := make(chan struct{})
+	e.flush <- c
+	<-c
+}
+
+type collector struct {
+	mu       sync.Mutex
+	requests []otlpRequest
+	headers  []http.Header
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req otlpRequest
+	if r.URL.Path != tracesPath || json.NewDecoder(r.Body).Decode(&req) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	c.requests = append(c.requests, req)
+	c.headers = append(c.headers, r.Header)
+	c.mu.Unlock()
+}
+
+func (c *collector) spans() []otlpSpan {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var spans []otlpSpan
+	for _, r := range c.requests {
+		for _, rs := range r.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}
+	return spans
+}
+
+func newTestTracer(t *testing.T, cfg Config) (*Tracer, *collector) {
+	t.Helper()
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	t.Cleanup(srv.Close)
+
+	cfg.Endpoint = srv.URL
+	tracer, err := NewTracer(zaptest.NewLogger(t), cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = tracer.Close() })
+	return tracer, c
+}
+
+func attribute(attrs []otlpAttribute, key string) *otlpValue {
+	for _, a := range attrs {
+		if a.Key == key {
+			return &a.Value
+		}
+	}
+	return nil
+}
+
+func TestTracer_Export(t *testing.T) {
+	tracer, c := newTestTracer(t, Config{
+		Headers:     map[string]string{"Authorization": "Bearer secret"},
+		ServiceName: "influxd",
+		SampleRatio: 1,
+	})
+
+	parent := tracer.StartSpan("request", ext.SpanKindRPCServer)
+	parent.SetTag("route", "/api/v2/query")
+	child := tracer.StartSpan("storage.ReadFilter", opentracing.ChildOf(parent.Context()))
+	child.SetTag("scanned_values", 42)
+	child.LogFields(log.Error(errors.New("shard is closed")))
+	child.Finish()
+	parent.Finish()
+	tracer.exporter.sync()
+
+	spans := c.spans()
+	require.Len(t, spans, 2)
+	readSpan, requestSpan := spans[0], spans[1]
+
+	assert.Equal(t, "request", requestSpan.Name)
+	assert.Equal(t, spanKindServer, requestSpan.Kind)
+	assert.Empty(t, requestSpan.ParentSpanID)
+	assert.Equal(t, "/api/v2/query", *attribute(requestSpan.Attributes, "route").StringValue)
+	assert.Nil(t, requestSpan.Status)
+
+	assert.Equal(t, "storage.ReadFilter", readSpan.Name)
+	assert.Equal(t, spanKindInternal, readSpan.Kind)
+	assert.Equal(t, requestSpan.TraceID, readSpan.TraceID)
+	assert.Equal(t, requestSpan.SpanID, readSpan.ParentSpanID)
+	assert.Equal(t, "42", *attribute(readSpan.Attributes, "scanned_values").IntValue)
+	require.NotNil(t, readSpan.Status)
+	assert.Equal(t, statusCodeError, readSpan.Status.Code)
+	assert.Equal(t, "shard is closed", readSpan.Status.Message)
+	require.Len(t, readSpan.Events, 1)
+
+	assert.Equal(t, "Bearer secret", c.headers[0].Get("Authorization"))
+	assert.Equal(t, "influxd", *attribute(c.requests[0].ResourceSpans[0].Resource.Attributes, "service.name").StringValue)
+}
+
+func TestTracer_Sampling(t *testing.T) {
+	tracer, c := newTestTracer(t, Config{SampleRatio: 0})
+
+	root := tracer.StartSpan("unsampled")
+	assert.False(t, root.Context().(SpanContext).IsSampled())
+	child := tracer.StartSpan("child", opentracing.ChildOf(root.Context()))
+	assert.False(t, child.Context().(SpanContext).IsSampled())
+	child.Finish()
+	root.Finish()
+
+	// the sampling of callers is followed.
+	remote, err := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(http.Header{
+		"Traceparent": []string{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+	}))
+	require.NoError(t, err)
+	sampled := tracer.StartSpan("sampled", ext.RPCServerOption(remote))
+	sampled.Finish()
+	tracer.exporter.sync()
+
+	spans := c.spans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "sampled", spans[0].Name)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].ParentSpanID)
+}
+
+func TestTracer_Propagation(t *testing.T) {
+	tracer, _ := newTestTracer(t, Config{SampleRatio: 1})
+
+	span := tracer.StartSpan("client")
+	span.SetBaggageItem("org id", "0000000000000001")
+	h := http.Header{}
+	require.NoError(t, tracer.Inject(span.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(h)))
+
+	sc := span.Context().(SpanContext)
+	assert.Equal(t, "00-"+sc.TraceID()+"-"+h.Get("traceparent")[36:52]+"-01", h.Get("traceparent"))
+
+	got, err := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(h))
+	require.NoError(t, err)
+	assert.Equal(t, sc.traceID, got.(SpanContext).traceID)
+	assert.Equal(t, sc.spanID, got.(SpanContext).spanID)
+	assert.True(t, got.(SpanContext).IsSampled())
+	assert.Equal(t, "0000000000000001", got.(SpanContext).baggage["org id"])
+
+	for _, v := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01",
+	} {
+		_, err := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(http.Header{"Traceparent": []string{v}}))
+		assert.Error(t, err, v)
+	}
+}
+
+func TestNewTracer_Invalid(t *testing.T) {
+	_, err := NewTracer(zaptest.NewLogger(t), Config{SampleRatio: 2})
+	assert.Error(t, err)
+	_, err = NewTracer(zaptest.NewLogger(t), Config{Endpoint: "localhost:4318", SampleRatio: 1})
+	assert.Error(t, err)
+}
@@ -205,18 +205,26 @@ func StartSpanFromContextWithOperationName(ctx context.Context, operationName st
 	return span, ctx
 }
 
-// InfoFromSpan returns the traceID and if it was sampled from the span, given it is a jaeger span.
-// It returns whether a span associated to the context has been found.
+// traceInfo is implemented by the span contexts of tracers other than jaeger
+// which have a trace ID, such as the OTLP tracer.
+type traceInfo interface {
+	TraceID() string
+	IsSampled() bool
+}
+
+// InfoFromSpan returns the traceID and if it was sampled from the span, given it is a jaeger
+// or OTLP span. It returns whether a span associated to the context has been found.
 func InfoFromSpan(span opentracing.Span) (traceID string, sampled bool, found bool) {
-	if spanContext, ok := span.Context().(jaeger.SpanContext); ok {
-		traceID = spanContext.TraceID().String()
-		sampled = spanContext.IsSampled()
-		return traceID, sampled, true
+	switch spanContext := span.Context().(type) {
+	case jaeger.SpanContext:
+		return spanContext.TraceID().String(), spanContext.IsSampled(), true
+	case traceInfo:
+		return spanContext.TraceID(), spanContext.IsSampled(), true
 	}
 	return "", false, false
 }
 
-// InfoFromContext returns the traceID and if it was sampled from the Jaeger span
+// InfoFromContext returns the traceID and if it was sampled from the Jaeger or OTLP span
 // found in the given context. It returns whether a span associated to the context has been found.
 func InfoFromContext(ctx context.Context) (traceID string, sampled bool, found bool) {
 	if span := opentracing.SpanFromContext(ctx); span != nil {
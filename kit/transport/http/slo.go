@@ -0,0 +1,318 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	sloNamespace = "http"
+	sloSubsystem = "slo"
+
+	// OtherOrgs is the org label of the requests of the organizations which do
+	// not have a label of their own.
+	OtherOrgs = "other"
+
+	// sloSlotDuration is the resolution of the burn rates.
+	sloSlotDuration = time.Minute
+	// sloSlots is the number of slots kept, so that the longest window is
+	// covered.
+	sloSlots = 60
+)
+
+// SLOWindows are the windows the burn rates of the objectives are computed
+// over. The short window detects fast burns, the long window slow burns.
+var SLOWindows = []time.Duration{5 * time.Minute, time.Hour}
+
+// LatencyObjective is a service level objective on the latency of requests:
+// a request is good when it is served within Latency without a server error,
+// and Target is the ratio of requests that must be good.
+type LatencyObjective struct {
+	Latency time.Duration
+	Target  float64
+}
+
+// Valid returns an error if the objective is invalid.
+func (o LatencyObjective) Valid() error {
+	if o.Latency <= 0 {
+		return fmt.Errorf("latency objective must be positive")
+	}
+	if o.Target <= 0 || o.Target >= 1 {
+		return fmt.Errorf("latency objective target must be between 0 and 1")
+	}
+	return nil
+}
+
+// SLOConfig configures the service level objectives of the writes and queries.
+type SLOConfig struct {
+	// Enabled enables the metrics of the objectives.
+	Enabled bool
+	// Write is the objective of the write endpoints.
+	Write LatencyObjective
+	// Query is the objective of the query endpoints.
+	Query LatencyObjective
+	// MaxOrgs bounds the number of organizations with a label of their own,
+	// which are the first organizations to be seen. Zero is no bound.
+	MaxOrgs int
+}
+
+type sloObjective struct {
+	LatencyObjective
+	name  string
+	paths []string
+}
+
+type sloKey struct {
+	objective string
+	org       string
+}
+
+type sloCounts struct {
+	requests int64
+	bad      int64
+}
+
+type sloWindow struct {
+	allowed float64 // the ratio of bad requests the objective allows.
+	slots   [sloSlots]sloCounts
+	last    int64 // the slot counts were last added to.
+}
+
+var _ prometheus.Collector = (*SLOMetrics)(nil)
+
+// SLOMetrics counts the requests to the write and query endpoints, and the
+// requests which met their latency objective, by organization. The burn rate
+// of the error budget of each objective and organization, which is the ratio
+// of bad requests over the ratio of bad requests the objective allows, is
+// computed over each of the SLOWindows, so burn rates can be alerted on
+// without post-processing the latency histograms.
+//
+// The organization of a request is its orgID parameter, or its org parameter,
+// and is empty for requests without them such as the v1 endpoints.
+type SLOMetrics struct {
+	objectives []sloObjective
+	maxOrgs    int
+	now        func() time.Time
+
+	requests *prometheus.CounterVec
+	good     *prometheus.CounterVec
+	target   *prometheus.GaugeVec
+
+	burnRate        *prometheus.Desc
+	budgetRemaining *prometheus.Desc
+
+	mu      sync.Mutex
+	orgs    map[string]bool // the organizations with a label of their own.
+	windows map[sloKey]*sloWindow
+	pruned  int64 // the slot the idle windows were last pruned at.
+}
+
+// NewSLOMetrics constructs the metrics of the objectives of cfg.
+func NewSLOMetrics(cfg SLOConfig) (*SLOMetrics, error) {
+	if err := cfg.Write.Valid(); err != nil {
+		return nil, fmt.Errorf("write %w", err)
+	}
+	if err := cfg.Query.Valid(); err != nil {
+		return nil, fmt.Errorf("query %w", err)
+	}
+	if cfg.MaxOrgs < 0 {
+		return nil, fmt.Errorf("max orgs must not be negative")
+	}
+
+	labels := []string{"objective", "org"}
+	m := &SLOMetrics{
+		objectives: []sloObjective{
+			{LatencyObjective: cfg.Write, name: "write", paths: []string{"/api/v2/write", "/write"}},
+			{LatencyObjective: cfg.Query, name: "query", paths: []string{"/api/v2/query", "/query"}},
+		},
+		maxOrgs: cfg.MaxOrgs,
+		now:     time.Now,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: sloNamespace,
+			Subsystem: sloSubsystem,
+			Name:      "requests_total",
+			Help:      "Number of requests counted against a service level objective",
+		}, labels),
+		good: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: sloNamespace,
+			Subsystem: sloSubsystem,
+			Name:      "good_requests_total",
+			Help:      "Number of requests served within the latency of a service level objective without a server error",
+		}, labels),
+		target: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: sloNamespace,
+			Subsystem: sloSubsystem,
+			Name:      "target_ratio",
+			Help:      "Ratio of requests which must be good to meet a service level objective",
+		}, []string{"objective"}),
+		burnRate: prometheus.NewDesc(
+			prometheus.BuildFQName(sloNamespace, sloSubsystem, "burn_rate"),
+			"Rate the error budget of a service level objective is consumed at over the window, 1 consuming it exactly",
+			[]string{"objective", "org", "window"}, nil,
+		),
+		budgetRemaining: prometheus.NewDesc(
+			prometheus.BuildFQName(sloNamespace, sloSubsystem, "error_budget_remaining_ratio"),
+			"Ratio of the error budget of a service level objective remaining over the window",
+			[]string{"objective", "org", "window"}, nil,
+		),
+		orgs:    map[string]bool{},
+		windows: map[sloKey]*sloWindow{},
+	}
+	for _, o := range m.objectives {
+		m.target.WithLabelValues(o.name).Set(o.Target)
+	}
+	return m, nil
+}
+
+// PrometheusCollectors satisfies the prom.PrometheusCollector interface.
+func (m *SLOMetrics) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{m.requests, m.good, m.target, m}
+}
+
+// Middleware records the requests to the endpoints of the objectives. Client
+// errors are not counted against the objectives.
+func (m *SLOMetrics) Middleware(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		o := m.objective(r.URL.Path)
+		if o == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		statusW := NewStatusResponseWriter(w)
+		start := m.now()
+		next.ServeHTTP(statusW, r)
+
+		code := statusW.Code()
+		if code >= 400 && code < 500 {
+			return
+		}
+		good := code < 500 && m.now().Sub(start) <= o.Latency
+		m.record(o, m.orgLabel(r), good)
+	}
+	return http.HandlerFunc(fn)
+}
+
+func (m *SLOMetrics) objective(path string) *sloObjective {
+	for i := range m.objectives {
+		for _, p := range m.objectives[i].paths {
+			if p == path {
+				return &m.objectives[i]
+			}
+		}
+	}
+	return nil
+}
+
+func (m *SLOMetrics) orgLabel(r *http.Request) string {
+	q := r.URL.Query()
+	org := q.Get("orgID")
+	if org == "" {
+		org = q.Get("org")
+	}
+	if org == "" {
+		return ""
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.orgs[org] {
+		return org
+	}
+	if m.maxOrgs > 0 && len(m.orgs) >= m.maxOrgs {
+		return OtherOrgs
+	}
+	m.orgs[org] = true
+	return org
+}
+
+func (m *SLOMetrics) slot() int64 {
+	return m.now().UnixNano() / int64(sloSlotDuration)
+}
+
+func (m *SLOMetrics) record(o *sloObjective, org string, good bool) {
+	m.requests.WithLabelValues(o.name, org).Inc()
+	var bad int64 = 1
+	if good {
+		m.good.WithLabelValues(o.name, org).Inc()
+		bad = 0
+	}
+
+	slot := m.slot()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if slot != m.pruned {
+		for k, w := range m.windows {
+			if slot-w.last >= sloSlots {
+				delete(m.windows, k)
+			}
+		}
+		m.pruned = slot
+	}
+
+	k := sloKey{objective: o.name, org: org}
+	w, ok := m.windows[k]
+	if !ok {
+		w = &sloWindow{allowed: 1 - o.Target, last: slot}
+		m.windows[k] = w
+	}
+	// clear the slots which were not recorded to since the last request.
+	for s := w.last + 1; s <= slot && s <= w.last+sloSlots; s++ {
+		w.slots[s%sloSlots] = sloCounts{}
+	}
+	if slot > w.last {
+		w.last = slot
+	}
+	w.slots[slot%sloSlots].requests++
+	w.slots[slot%sloSlots].bad += bad
+}
+
+// Describe satisfies the prometheus.Collector interface.
+func (m *SLOMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.burnRate
+	ch <- m.budgetRemaining
+}
+
+// Collect satisfies the prometheus.Collector interface. The burn rates are
+// only collected for the windows with requests.
+func (m *SLOMetrics) Collect(ch chan<- prometheus.Metric) {
+	slot := m.slot()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k, w := range m.windows {
+		for _, d := range SLOWindows {
+			var c sloCounts
+			n := int64(d / sloSlotDuration)
+			for s := slot - n + 1; s <= slot; s++ {
+				if s > w.last || w.last-s >= sloSlots {
+					continue
+				}
+				c.requests += w.slots[s%sloSlots].requests
+				c.bad += w.slots[s%sloSlots].bad
+			}
+			if c.requests == 0 {
+				continue
+			}
+
+			burn := float64(c.bad) / float64(c.requests) / w.allowed
+			window := windowLabel(d)
+			ch <- prometheus.MustNewConstMetric(m.burnRate, prometheus.GaugeValue, burn, k.objective, k.org, window)
+			ch <- prometheus.MustNewConstMetric(m.budgetRemaining, prometheus.GaugeValue, 1-burn, k.objective, k.org, window)
+		}
+	}
+}
+
+// windowLabel formats d without its zero units, such as 5m rather than 5m0s.
+func windowLabel(d time.Duration) string {
+	s := strings.TrimSuffix(d.String(), "0s")
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/prom"
+	"github.com/influxdata/influxdb/v2/kit/prom/promtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestSLOMetrics(t *testing.T) {
+	m, err := NewSLOMetrics(SLOConfig{
+		Write:   LatencyObjective{Latency: time.Second, Target: 0.9},
+		Query:   LatencyObjective{Latency: 5 * time.Second, Target: 0.99},
+		MaxOrgs: 1,
+	})
+	require.NoError(t, err)
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	reg := prom.NewRegistry(zaptest.NewLogger(t))
+	reg.MustRegister(m.PrometheusCollectors()...)
+
+	// the handler takes the latency of the d parameter to respond with the
+	// status of the code parameter.
+	h := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d, err := time.ParseDuration(r.URL.Query().Get("d"))
+		require.NoError(t, err)
+		now = now.Add(d)
+		switch r.URL.Query().Get("code") {
+		case "500":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "400":
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	for _, target := range []string{
+		"/api/v2/write?org=a&d=10ms",
+		"/api/v2/write?org=a&d=10ms",
+		"/api/v2/write?org=a&d=2s",            // too slow.
+		"/api/v2/write?org=a&d=10ms&code=500", // server error.
+		"/api/v2/write?org=a&d=10ms&code=400", // client errors are not counted.
+		"/api/v2/write?org=b&d=10ms",          // beyond MaxOrgs.
+		"/api/v2/buckets?org=a&d=10ms",        // no objective.
+		"/query?d=1s",
+	} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", target, nil))
+	}
+
+	mfs := promtest.MustGather(t, reg)
+	for _, tt := range []struct {
+		labels         map[string]string
+		requests, good float64
+	}{
+		{labels: map[string]string{"objective": "write", "org": "a"}, requests: 4, good: 2},
+		{labels: map[string]string{"objective": "write", "org": OtherOrgs}, requests: 1, good: 1},
+		{labels: map[string]string{"objective": "query", "org": ""}, requests: 1, good: 1},
+	} {
+		requests := promtest.MustFindMetric(t, mfs, "http_slo_requests_total", tt.labels)
+		assert.Equal(t, tt.requests, requests.GetCounter().GetValue(), tt.labels)
+		good := promtest.MustFindMetric(t, mfs, "http_slo_good_requests_total", tt.labels)
+		assert.Equal(t, tt.good, good.GetCounter().GetValue(), tt.labels)
+	}
+	assert.Nil(t, promtest.FindMetric(mfs, "http_slo_requests_total", map[string]string{"objective": "write", "org": "b"}))
+
+	target := promtest.MustFindMetric(t, mfs, "http_slo_target_ratio", map[string]string{"objective": "write"})
+	assert.Equal(t, 0.9, target.GetGauge().GetValue())
+
+	// half the writes of org a were bad, which burns the budget of the 10%
+	// allowed five times as fast as it should.
+	for _, window := range []string{"5m", "1h"} {
+		labels := map[string]string{"objective": "write", "org": "a", "window": window}
+		burn := promtest.MustFindMetric(t, mfs, "http_slo_burn_rate", labels)
+		assert.InDelta(t, 5, burn.GetGauge().GetValue(), 1e-9)
+		remaining := promtest.MustFindMetric(t, mfs, "http_slo_error_budget_remaining_ratio", labels)
+		assert.InDelta(t, -4, remaining.GetGauge().GetValue(), 1e-9)
+	}
+
+	// the requests leave the short window first.
+	now = now.Add(10 * time.Minute)
+	mfs = promtest.MustGather(t, reg)
+	assert.Nil(t, promtest.FindMetric(mfs, "http_slo_burn_rate", map[string]string{"objective": "write", "org": "a", "window": "5m"}))
+	promtest.MustFindMetric(t, mfs, "http_slo_burn_rate", map[string]string{"objective": "write", "org": "a", "window": "1h"})
+}
+
+func TestNewSLOMetrics_Invalid(t *testing.T) {
+	valid := LatencyObjective{Latency: time.Second, Target: 0.99}
+	for _, cfg := range []SLOConfig{
+		{Write: LatencyObjective{Target: 0.99}, Query: valid},
+		{Write: valid, Query: LatencyObjective{Latency: time.Second, Target: 1}},
+		{Write: valid, Query: valid, MaxOrgs: -1},
+	} {
+		_, err := NewSLOMetrics(cfg)
+		assert.Error(t, err)
+	}
+}
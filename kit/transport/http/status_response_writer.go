@@ -26,6 +26,12 @@ func (w *StatusResponseWriter) WriteHeader(statusCode int) {
 	w.ResponseWriter.WriteHeader(statusCode)
 }
 
+// Unwrap returns the wrapped ResponseWriter, so that an http.ResponseController
+// can flush it.
+func (w *StatusResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 func (w *StatusResponseWriter) Code() int {
 	code := w.statusCode
 	if code == 0 {
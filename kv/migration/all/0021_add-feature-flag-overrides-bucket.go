@@ -0,0 +1,10 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+var featureFlagOverridesBucket = []byte("featureflagoverridesv1")
+
+var Migration0021_AddFeatureFlagOverridesBucket = migration.CreateBuckets(
+	"create feature flag overrides bucket",
+	featureFlagOverridesBucket,
+)
@@ -0,0 +1,10 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+var dashboardRevisionsBucket = []byte("dashboardrevisionsv1")
+
+var Migration0022_AddDashboardRevisionsBucket = migration.CreateBuckets(
+	"create dashboard revisions bucket",
+	dashboardRevisionsBucket,
+)
@@ -0,0 +1,14 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+var (
+	dashboardSharesBucket    = []byte("dashboardsharesv1")
+	dashboardShareKeysBucket = []byte("dashboardsharekeysv1")
+)
+
+var Migration0023_AddDashboardSharesBuckets = migration.CreateBuckets(
+	"create dashboard shares buckets",
+	dashboardSharesBucket,
+	dashboardShareKeysBucket,
+)
@@ -0,0 +1,18 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+var (
+	cellTemplatesBucket         = []byte("celltemplatesv1")
+	orgCellTemplatesIndexBucket = []byte("orgscelltemplatesv1")
+	cellTemplateLinksBucket     = []byte("celltemplatelinksv1")
+	dashboardCellLinksBucket    = []byte("dashboardcelltemplatelinksv1")
+)
+
+var Migration0024_AddCellTemplatesBuckets = migration.CreateBuckets(
+	"create cell templates buckets",
+	cellTemplatesBucket,
+	orgCellTemplatesIndexBucket,
+	cellTemplateLinksBucket,
+	dashboardCellLinksBucket,
+)
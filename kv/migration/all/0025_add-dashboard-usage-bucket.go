@@ -0,0 +1,10 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+var dashboardUsageBucket = []byte("dashboardusagev1")
+
+var Migration0025_AddDashboardUsageBucket = migration.CreateBuckets(
+	"create dashboard usage bucket",
+	dashboardUsageBucket,
+)
@@ -0,0 +1,14 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+var (
+	reportBucket   = []byte("reportsv1")
+	orgReportIndex = []byte("orgsreportsv1")
+)
+
+var Migration0026_AddReportsBuckets = migration.CreateBuckets(
+	"create reports buckets",
+	reportBucket,
+	orgReportIndex,
+)
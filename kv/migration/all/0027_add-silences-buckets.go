@@ -0,0 +1,14 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+var (
+	silenceBucket   = []byte("silencesv1")
+	orgSilenceIndex = []byte("orgsilencesv1")
+)
+
+var Migration0027_AddSilencesBuckets = migration.CreateBuckets(
+	"create silences buckets",
+	silenceBucket,
+	orgSilenceIndex,
+)
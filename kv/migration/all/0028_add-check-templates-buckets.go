@@ -0,0 +1,16 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+var (
+	checkTemplatesBucket         = []byte("checktemplatesv1")
+	orgCheckTemplatesIndexBucket = []byte("orgschecktemplatesv1")
+	checkTemplateInstancesBucket = []byte("checktemplateinstancesv1")
+)
+
+var Migration0028_AddCheckTemplatesBuckets = migration.CreateBuckets(
+	"create check templates buckets",
+	checkTemplatesBucket,
+	orgCheckTemplatesIndexBucket,
+	checkTemplateInstancesBucket,
+)
@@ -0,0 +1,10 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+var secretRotationBucket = []byte("secretrotationsv1")
+
+var Migration0029_AddSecretRotationsBucket = migration.CreateBuckets(
+	"create secret rotations bucket",
+	secretRotationBucket,
+)
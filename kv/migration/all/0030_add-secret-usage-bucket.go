@@ -0,0 +1,10 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+var secretUsageBucket = []byte("secretusagev1")
+
+var Migration0030_AddSecretUsageBucket = migration.CreateBuckets(
+	"create secret usage bucket",
+	secretUsageBucket,
+)
@@ -0,0 +1,10 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+var secretDataKeyBucket = []byte("secretdatakeysv1")
+
+var Migration0031_AddSecretDataKeysBucket = migration.CreateBuckets(
+	"create secret data keys bucket",
+	secretDataKeyBucket,
+)
@@ -0,0 +1,10 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+var sessionPolicyBucket = []byte("sessionpoliciesv1")
+
+var Migration0032_AddSessionPoliciesBucket = migration.CreateBuckets(
+	"create session policies bucket",
+	sessionPolicyBucket,
+)
@@ -0,0 +1,14 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+var (
+	eventLogBucket       = []byte("eventlogv1")
+	eventLogConfigBucket = []byte("eventlogconfigv1")
+)
+
+var Migration0033_AddEventLogBuckets = migration.CreateBuckets(
+	"create event log buckets",
+	eventLogBucket,
+	eventLogConfigBucket,
+)
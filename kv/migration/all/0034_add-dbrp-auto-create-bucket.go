@@ -0,0 +1,10 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+var dbrpAutoCreateBucket = []byte("dbrpautocreatev1")
+
+var Migration0034_AddDBRPAutoCreateBucket = migration.CreateBuckets(
+	"create dbrp auto-create bucket",
+	dbrpAutoCreateBucket,
+)
@@ -0,0 +1,9 @@
+package all
+
+import (
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/v1/authorization/index"
+)
+
+// Migration0035_AddIndexLegacyAuthsByUser adds the index of v1 authorizations by user ID
+var Migration0035_AddIndexLegacyAuthsByUser = kv.NewIndexMigration(index.ByUserIDIndexMapping, kv.WithIndexMigrationCleanup)
@@ -0,0 +1,9 @@
+package all
+
+import (
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/v1/authorization/index"
+)
+
+// Migration0036_AddIndexLegacyAuthsByOrg adds the index of v1 authorizations by organization ID
+var Migration0036_AddIndexLegacyAuthsByOrg = kv.NewIndexMigration(index.ByOrgIDIndexMapping, kv.WithIndexMigrationCleanup)
@@ -0,0 +1,8 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+var Migration0037_AddLegacyAuthAuditBucket = migration.CreateBuckets(
+	"create legacy auth audit bucket",
+	[]byte("legacy/authorizationauditv1"),
+)
@@ -0,0 +1,11 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+// Migration0038_AddPasswordTimeBuckets adds the buckets recording when the
+// passwords of users and v1 authorizations were set
+var Migration0038_AddPasswordTimeBuckets = migration.CreateBuckets(
+	"create password time buckets",
+	[]byte("userspasswordtimev1"),
+	[]byte("legacy/authorizationPasswordTimev1"),
+)
@@ -0,0 +1,10 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+// Migration0039_AddLegacyAuthLockoutBucket adds the bucket counting the failed
+// password attempts of v1 authorizations
+var Migration0039_AddLegacyAuthLockoutBucket = migration.CreateBuckets(
+	"create legacy auth lockout bucket",
+	[]byte("legacy/authorizationlockoutv1"),
+)
@@ -0,0 +1,10 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+// Migration0040_AddLegacyAuthRevocationBucket adds the bucket holding the
+// tombstones of revoked v1 authorizations
+var Migration0040_AddLegacyAuthRevocationBucket = migration.CreateBuckets(
+	"create legacy auth revocation bucket",
+	[]byte("legacy/authorizationrevocationv1"),
+)
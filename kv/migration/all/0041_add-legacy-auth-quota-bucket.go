@@ -0,0 +1,10 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+// Migration0041_AddLegacyAuthQuotaBucket adds the bucket holding the token
+// quotas of organizations for v1 authorizations
+var Migration0041_AddLegacyAuthQuotaBucket = migration.CreateBuckets(
+	"create legacy auth quota bucket",
+	[]byte("legacy/authorizationquotav1"),
+)
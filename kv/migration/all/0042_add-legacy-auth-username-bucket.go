@@ -0,0 +1,10 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+// Migration0042_AddLegacyAuthUsernameBucket adds the bucket holding the
+// usernames of v1 authorizations whose tokens are stored hashed
+var Migration0042_AddLegacyAuthUsernameBucket = migration.CreateBuckets(
+	"create legacy auth username bucket",
+	[]byte("legacy/authorizationusernamev1"),
+)
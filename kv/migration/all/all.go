@@ -47,5 +47,49 @@ var Migrations = [...]migration.Spec{
 	Migration0019_AddRemotesReplicationsToTokens,
 	// add_remotes_replications_metrics_buckets
 	Migration0020_Add_remotes_replications_metrics_buckets,
+	// add feature flag overrides bucket
+	Migration0021_AddFeatureFlagOverridesBucket,
+	// add dashboard revisions bucket
+	Migration0022_AddDashboardRevisionsBucket,
+	// add dashboard shares buckets
+	Migration0023_AddDashboardSharesBuckets,
+	// add cell templates buckets
+	Migration0024_AddCellTemplatesBuckets,
+	// add dashboard usage bucket
+	Migration0025_AddDashboardUsageBucket,
+	// add reports buckets
+	Migration0026_AddReportsBuckets,
+	// add silences buckets
+	Migration0027_AddSilencesBuckets,
+	// add check templates buckets
+	Migration0028_AddCheckTemplatesBuckets,
+	// add secret rotations bucket
+	Migration0029_AddSecretRotationsBucket,
+	// add secret usage bucket
+	Migration0030_AddSecretUsageBucket,
+	// add secret data keys bucket
+	Migration0031_AddSecretDataKeysBucket,
+	// add session policies bucket
+	Migration0032_AddSessionPoliciesBucket,
+	// add event log buckets
+	Migration0033_AddEventLogBuckets,
+	// add dbrp auto-create bucket
+	Migration0034_AddDBRPAutoCreateBucket,
+	// add index v1 authorizations by user id
+	Migration0035_AddIndexLegacyAuthsByUser,
+	// add index v1 authorizations by org id
+	Migration0036_AddIndexLegacyAuthsByOrg,
+	// add legacy auth audit bucket
+	Migration0037_AddLegacyAuthAuditBucket,
+	// add password time buckets
+	Migration0038_AddPasswordTimeBuckets,
+	// add legacy auth lockout bucket
+	Migration0039_AddLegacyAuthLockoutBucket,
+	// add legacy auth revocation bucket
+	Migration0040_AddLegacyAuthRevocationBucket,
+	// add legacy auth quota bucket
+	Migration0041_AddLegacyAuthQuotaBucket,
+	// add legacy auth username bucket
+	Migration0042_AddLegacyAuthUsernameBucket,
 	// {{ do_not_edit . }}
 }
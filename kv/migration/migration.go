@@ -121,6 +121,25 @@ func (m *Migrator) List(ctx context.Context) (migrations []Migration, _ error) {
 	return
 }
 
+// Validate checks that every migration recorded in the store is known to the
+// Migrator and ran to completion. It does not modify the store, and should be
+// used to verify compatibility prior to calling Up or Down.
+func (m *Migrator) Validate(ctx context.Context) error {
+	var incomplete []string
+	if err := m.walk(ctx, m.store, func(id platform.ID, mig Migration) {
+		if mig.State != UpMigrationState {
+			incomplete = append(incomplete, mig.Name)
+		}
+	}); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	if len(incomplete) > 0 {
+		return fmt.Errorf("validate: migrations did not complete: %q", incomplete)
+	}
+	return nil
+}
+
 // Up applies each outstanding migration in order.
 // Migrations are applied in order from the lowest indexed migration in a down state.
 //
@@ -101,6 +101,23 @@ func Test_Bolt_MigratorWithBackup(t *testing.T) {
 	require.Equal(t, 5, len(backupMs))
 }
 
+func Test_Migrator_Validate(t *testing.T) {
+	ctx := context.Background()
+	store := inmem.NewKVStore()
+
+	migrator := newMigrator(t, zaptest.NewLogger(t), store, time.Now)
+	migrator.AddMigrations(all.Migrations[0:3]...)
+	require.NoError(t, migrator.Validate(ctx))
+	require.NoError(t, migrator.Up(ctx))
+	require.NoError(t, migrator.Validate(ctx))
+
+	// A migrator which doesn't know about every applied migration, such as one from
+	// an older release, must fail validation.
+	older := newMigrator(t, zaptest.NewLogger(t), store, time.Now)
+	older.AddMigrations(all.Migrations[0:2]...)
+	require.Error(t, older.Validate(ctx))
+}
+
 func newTestBoltStoreWithoutMigrations(t *testing.T) (*bolt.KVStore, func(), error) {
 	f, err := os.CreateTemp("", "influxdata-bolt-")
 	if err != nil {
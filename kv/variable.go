@@ -211,6 +211,10 @@ func (s *Service) CreateVariable(ctx context.Context, v *influxdb.Variable) erro
 			}
 		}
 
+		if err := s.validateVariableDependencies(ctx, tx, v); err != nil {
+			return err
+		}
+
 		v.ID = s.IDGenerator.ID()
 		now := s.Now()
 		v.CreatedAt = now
@@ -222,6 +226,9 @@ func (s *Service) CreateVariable(ctx context.Context, v *influxdb.Variable) erro
 // ReplaceVariable replaces a variable that exists in the store or creates it if it does not
 func (s *Service) ReplaceVariable(ctx context.Context, v *influxdb.Variable) error {
 	return s.kv.Update(ctx, func(tx Tx) error {
+		if err := s.validateVariableDependencies(ctx, tx, v); err != nil {
+			return err
+		}
 		if found, _ := s.findVariableByID(ctx, tx, v.ID); found != nil {
 			return s.putVariable(ctx, tx, v, PutUpdate())
 		}
@@ -242,6 +249,28 @@ func (s *Service) putVariable(ctx context.Context, tx Tx, v *influxdb.Variable,
 	return s.variableStore.Put(ctx, tx, ent, putOpts...)
 }
 
+// validateVariableDependencies returns an error if writing v would make the
+// queries of some of its organization's variables depend on each other.
+func (s *Service) validateVariableDependencies(ctx context.Context, tx Tx, v *influxdb.Variable) error {
+	if len(v.References()) == 0 {
+		return nil
+	}
+	stored, err := s.findOrganizationVariables(ctx, tx, v.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	vars := make([]*influxdb.Variable, 0, len(stored))
+	for _, sv := range stored {
+		// v replaces the stored copy of itself, which may have another name.
+		if sv.ID != v.ID {
+			vars = append(vars, sv)
+		}
+	}
+	_, err = influxdb.ResolveVariableDependencies(v, vars)
+	return err
+}
+
 // UpdateVariable updates a single variable in the store with a changeset
 func (s *Service) UpdateVariable(ctx context.Context, id platform.ID, update *influxdb.VariableUpdate) (*influxdb.Variable, error) {
 	var v *influxdb.Variable
@@ -256,6 +285,9 @@ func (s *Service) UpdateVariable(ctx context.Context, id platform.ID, update *in
 		// TODO: should be moved to service layer
 		update.Name = strings.TrimSpace(update.Name)
 		update.Apply(m)
+		if err := s.validateVariableDependencies(ctx, tx, m); err != nil {
+			return err
+		}
 
 		return s.putVariable(ctx, tx, v, PutUpdate())
 	})
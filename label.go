@@ -64,10 +64,25 @@ type LabelService interface {
 	DeleteLabelMapping(ctx context.Context, m *LabelMapping) error
 }
 
+// LabelResolver finds the labels of resources, and their parents, so that
+// permissions targeting resources by label can be resolved. It must not
+// authorize the lookups, as it is used while authorizing.
+type LabelResolver interface {
+	// FindLabelByID a single label by ID.
+	FindLabelByID(ctx context.Context, id platform.ID) (*Label, error)
+
+	// FindResourceLabels returns a list of labels that belong to a resource
+	FindResourceLabels(ctx context.Context, filter LabelMappingFilter) ([]*Label, error)
+}
+
 // Label is a tag set on a resource, typically used for filtering on a UI.
+// Labels may have a parent label of the same organization, so they form
+// trees. Filtering by a label matches the resources with any label
+// descending from it.
 type Label struct {
 	ID         platform.ID       `json:"id,omitempty"`
 	OrgID      platform.ID       `json:"orgID,omitempty"`
+	ParentID   *platform.ID      `json:"parentID,omitempty"`
 	Name       string            `json:"name"`
 	Properties map[string]string `json:"properties,omitempty"`
 }
@@ -128,12 +143,40 @@ func (l *LabelMapping) Validate() error {
 type LabelUpdate struct {
 	Name       string            `json:"name,omitempty"`
 	Properties map[string]string `json:"properties,omitempty"`
+	ParentID   *platform.ID      `json:"parentID,omitempty"`
+	// RemoveParent makes the label a root label.
+	RemoveParent bool `json:"removeParent,omitempty"`
 }
 
 // LabelFilter represents a set of filters that restrict the returned results.
 type LabelFilter struct {
 	Name  string
 	OrgID *platform.ID
+	// ParentID restricts the labels to the children of a label.
+	ParentID *platform.ID
+}
+
+// LabelDescendants returns the IDs of the labels of ids and of the labels
+// descending from them, among labels.
+func LabelDescendants(labels []*Label, ids ...platform.ID) map[platform.ID]bool {
+	children := make(map[platform.ID][]platform.ID)
+	for _, l := range labels {
+		if l.ParentID != nil {
+			children[*l.ParentID] = append(children[*l.ParentID], l.ID)
+		}
+	}
+
+	descendants := make(map[platform.ID]bool)
+	for len(ids) > 0 {
+		id := ids[len(ids)-1]
+		ids = ids[:len(ids)-1]
+		if descendants[id] {
+			continue
+		}
+		descendants[id] = true
+		ids = append(ids, children[id]...)
+	}
+	return descendants
 }
 
 // LabelMappingFilter represents a set of filters that restrict the returned results.
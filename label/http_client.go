@@ -64,6 +64,9 @@ func (s *LabelClientService) FindLabels(ctx context.Context, filter influxdb.Lab
 	if filter.Name != "" {
 		params = append(params, [2]string{"name", filter.Name})
 	}
+	if filter.ParentID != nil {
+		params = append(params, [2]string{"parentID", filter.ParentID.String()})
+	}
 
 	var lr labelsResponse
 	err := s.Client.
@@ -141,6 +141,15 @@ func (h *LabelHandler) handleGetLabels(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if parentID := qp.Get("parentID"); parentID != "" {
+		i, err := platform.IDFromString(parentID)
+		if err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+		filter.ParentID = i
+	}
+
 	labels, err := h.labelSvc.FindLabels(r.Context(), filter)
 	if err != nil {
 		h.api.Err(w, r, err)
@@ -36,6 +36,10 @@ func (s *Service) CreateLabel(ctx context.Context, l *influxdb.Label) error {
 			return err
 		}
 
+		if err := s.store.validParent(ctx, tx, l); err != nil {
+			return err
+		}
+
 		if err := s.store.CreateLabel(ctx, tx, l); err != nil {
 			return err
 		}
@@ -119,7 +123,7 @@ func (s *Service) UpdateLabel(ctx context.Context, id platform.ID, upd influxdb.
 	return label, err
 }
 
-// DeleteLabel deletes a label.
+// DeleteLabel deletes a label. Its children are moved to its parent.
 func (s *Service) DeleteLabel(ctx context.Context, id platform.ID) error {
 	err := s.store.Update(ctx, func(tx kv.Tx) error {
 		return s.store.DeleteLabel(ctx, tx, id)
@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kv"
 	"github.com/influxdata/influxdb/v2/label"
 	"github.com/influxdata/influxdb/v2/mock"
@@ -59,3 +61,68 @@ func initLabelService(s kv.Store, f influxdbtesting.LabelFields, t *testing.T) (
 		}
 	}
 }
+
+func TestLabelHierarchy(t *testing.T) {
+	st, err := label.NewStore(influxdbtesting.NewTestInmemStore(t))
+	if err != nil {
+		t.Fatalf("failed to create label store: %v", err)
+	}
+	svc := label.NewService(st)
+	ctx := context.Background()
+
+	orgID := platform.ID(1)
+	team := &influxdb.Label{OrgID: orgID, Name: "team"}
+	if err := svc.CreateLabel(ctx, team); err != nil {
+		t.Fatal(err)
+	}
+	service := &influxdb.Label{OrgID: orgID, Name: "service", ParentID: &team.ID}
+	if err := svc.CreateLabel(ctx, service); err != nil {
+		t.Fatal(err)
+	}
+	component := &influxdb.Label{OrgID: orgID, Name: "component", ParentID: &service.ID}
+	if err := svc.CreateLabel(ctx, component); err != nil {
+		t.Fatal(err)
+	}
+
+	children, err := svc.FindLabels(ctx, influxdb.LabelFilter{ParentID: &team.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(children) != 1 || children[0].ID != service.ID {
+		t.Fatalf("expected the children of team to be service, got: %v", children)
+	}
+
+	// A label of another organization cannot be a parent.
+	otherOrg := &influxdb.Label{OrgID: platform.ID(2), Name: "other", ParentID: &team.ID}
+	if err := svc.CreateLabel(ctx, otherOrg); errors.ErrorCode(err) != errors.EInvalid {
+		t.Fatalf("expected invalid error for a parent of another organization, got: %v", err)
+	}
+
+	// A label cannot descend from itself.
+	if _, err := svc.UpdateLabel(ctx, team.ID, influxdb.LabelUpdate{ParentID: &component.ID}); errors.ErrorCode(err) != errors.EInvalid {
+		t.Fatalf("expected invalid error for a cycle, got: %v", err)
+	}
+	if _, err := svc.UpdateLabel(ctx, team.ID, influxdb.LabelUpdate{ParentID: &team.ID}); errors.ErrorCode(err) != errors.EInvalid {
+		t.Fatalf("expected invalid error for a label being its own parent, got: %v", err)
+	}
+
+	// Deleting a label moves its children to its parent.
+	if err := svc.DeleteLabel(ctx, service.ID); err != nil {
+		t.Fatal(err)
+	}
+	l, err := svc.FindLabelByID(ctx, component.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.ParentID == nil || *l.ParentID != team.ID {
+		t.Fatalf("expected component to be moved to team, got parent: %v", l.ParentID)
+	}
+
+	l, err = svc.UpdateLabel(ctx, component.ID, influxdb.LabelUpdate{RemoveParent: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.ParentID != nil {
+		t.Fatalf("expected component to have no parent, got: %v", l.ParentID)
+	}
+}
@@ -171,6 +171,15 @@ func (s *Store) UpdateLabel(ctx context.Context, tx kv.Tx, id platform.ID, upd i
 		}
 	}
 
+	if upd.RemoveParent {
+		label.ParentID = nil
+	} else if upd.ParentID != nil {
+		label.ParentID = upd.ParentID
+	}
+	if err := s.validParent(ctx, tx, label); err != nil {
+		return nil, err
+	}
+
 	if err := label.Validate(); err != nil {
 		return nil, &errors.Error{
 			Code: errors.EInvalid,
@@ -269,6 +278,93 @@ func (s *Store) DeleteLabel(ctx context.Context, tx kv.Tx, id platform.ID) error
 		}
 	}
 
+	// the children of the label are moved to its parent
+	var children []*influxdb.Label
+	err = forEachLabel(ctx, tx, func(l *influxdb.Label) bool {
+		if l.ParentID != nil && *l.ParentID == id {
+			children = append(children, l)
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		child.ParentID = label.ParentID
+		if err := putLabel(tx, child); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validParent checks the parent of a label is a label of the same organization
+// which does not descend from it.
+func (s *Store) validParent(ctx context.Context, tx kv.Tx, l *influxdb.Label) error {
+	if l.ParentID == nil {
+		return nil
+	}
+
+	parent, err := s.GetLabel(ctx, tx, *l.ParentID)
+	if err != nil {
+		if errors.ErrorCode(err) == errors.ENotFound {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("parent label %s not found", l.ParentID),
+			}
+		}
+		return err
+	}
+	if parent.OrgID != l.OrgID {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "parent label must belong to the same organization",
+		}
+	}
+
+	for {
+		if l.ID.Valid() && parent.ID == l.ID {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "label cannot descend from itself",
+			}
+		}
+		if parent.ParentID == nil {
+			return nil
+		}
+		if parent, err = s.GetLabel(ctx, tx, *parent.ParentID); err != nil {
+			return err
+		}
+	}
+}
+
+func putLabel(tx kv.Tx, l *influxdb.Label) error {
+	v, err := json.Marshal(l)
+	if err != nil {
+		return &errors.Error{
+			Err: err,
+		}
+	}
+
+	encodedID, err := l.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Err: err,
+		}
+	}
+
+	b, err := tx.Bucket(labelBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(encodedID, v); err != nil {
+		return &errors.Error{
+			Err: err,
+		}
+	}
+
 	return nil
 }
 
@@ -420,7 +516,8 @@ func labelIndexKey(l *influxdb.Label) ([]byte, error) {
 func filterLabelsFn(filter influxdb.LabelFilter) func(l *influxdb.Label) bool {
 	return func(label *influxdb.Label) bool {
 		return (filter.Name == "" || (strings.EqualFold(filter.Name, label.Name))) &&
-			((filter.OrgID == nil) || (filter.OrgID != nil && *filter.OrgID == label.OrgID))
+			((filter.OrgID == nil) || (filter.OrgID != nil && *filter.OrgID == label.OrgID)) &&
+			(filter.ParentID == nil || (label.ParentID != nil && *filter.ParentID == *label.ParentID))
 	}
 }
 
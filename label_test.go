@@ -1,6 +1,7 @@
 package influxdb_test
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/influxdata/influxdb/v2"
@@ -56,3 +57,43 @@ func TestLabelValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestLabelDescendants(t *testing.T) {
+	parent := func(id platform.ID) *platform.ID { return &id }
+	labels := []*influxdb.Label{
+		{ID: 1, Name: "team"},
+		{ID: 2, Name: "service", ParentID: parent(1)},
+		{ID: 3, Name: "component", ParentID: parent(2)},
+		{ID: 4, Name: "other service", ParentID: parent(1)},
+		{ID: 5, Name: "other team"},
+	}
+
+	tests := []struct {
+		name string
+		ids  []platform.ID
+		want map[platform.ID]bool
+	}{
+		{
+			name: "root",
+			ids:  []platform.ID{1},
+			want: map[platform.ID]bool{1: true, 2: true, 3: true, 4: true},
+		},
+		{
+			name: "inner",
+			ids:  []platform.ID{2},
+			want: map[platform.ID]bool{2: true, 3: true},
+		},
+		{
+			name: "several",
+			ids:  []platform.ID{3, 5},
+			want: map[platform.ID]bool{3: true, 5: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := influxdb.LabelDescendants(labels, tt.ids...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LabelDescendants() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
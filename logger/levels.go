@@ -0,0 +1,170 @@
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Subsystems whose log level can be changed independently of the rest of the server.
+const (
+	SubsystemStorage      = "storage"
+	SubsystemHTTP         = "http"
+	SubsystemTasks        = "tasks"
+	SubsystemReplications = "replications"
+)
+
+// Subsystems lists every subsystem with its own log level.
+var Subsystems = []string{SubsystemStorage, SubsystemHTTP, SubsystemTasks, SubsystemReplications}
+
+// subsystemLevel is a log level which follows the global level until it is overridden.
+type subsystemLevel struct {
+	global     zap.AtomicLevel
+	level      zap.AtomicLevel
+	overridden atomic.Bool
+}
+
+func (s *subsystemLevel) Level() zapcore.Level {
+	if s.overridden.Load() {
+		return s.level.Level()
+	}
+	return s.global.Level()
+}
+
+func (s *subsystemLevel) Enabled(lvl zapcore.Level) bool {
+	return s.Level().Enabled(lvl)
+}
+
+// Levels controls the global log level and the level of each subsystem at runtime.
+//
+// Loggers built from a core using Enabler are filtered by the global level;
+// loggers returned by Logger are filtered by their subsystem's level instead,
+// so a single subsystem can log at debug without enabling debug everywhere.
+type Levels struct {
+	global     zap.AtomicLevel
+	subsystems map[string]*subsystemLevel
+}
+
+// NewLevels returns Levels following global, with a level for each of subsystems.
+func NewLevels(global zap.AtomicLevel, subsystems ...string) *Levels {
+	l := &Levels{
+		global:     global,
+		subsystems: make(map[string]*subsystemLevel, len(subsystems)),
+	}
+	for _, s := range subsystems {
+		l.subsystems[s] = &subsystemLevel{global: global, level: zap.NewAtomicLevel()}
+	}
+	return l
+}
+
+// Global returns the level used by everything outside a subsystem.
+func (l *Levels) Global() zapcore.Level {
+	return l.global.Level()
+}
+
+// SetGlobal sets the level used by everything outside a subsystem, and by
+// every subsystem without an override.
+func (l *Levels) SetGlobal(lvl zapcore.Level) {
+	l.global.SetLevel(lvl)
+}
+
+// Subsystems returns the names of all subsystems, sorted.
+func (l *Levels) Subsystems() []string {
+	names := make([]string, 0, len(l.subsystems))
+	for name := range l.subsystems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Level returns the effective level of subsystem, and whether it overrides the global level.
+func (l *Levels) Level(subsystem string) (zapcore.Level, bool, error) {
+	s, ok := l.subsystems[subsystem]
+	if !ok {
+		return 0, false, fmt.Errorf("unknown log subsystem %q", subsystem)
+	}
+	return s.Level(), s.overridden.Load(), nil
+}
+
+// SetLevel overrides the level of subsystem. A nil level clears the override,
+// so the subsystem follows the global level again.
+func (l *Levels) SetLevel(subsystem string, lvl *zapcore.Level) error {
+	s, ok := l.subsystems[subsystem]
+	if !ok {
+		return fmt.Errorf("unknown log subsystem %q", subsystem)
+	}
+	if lvl == nil {
+		s.overridden.Store(false)
+		return nil
+	}
+	s.level.SetLevel(*lvl)
+	s.overridden.Store(true)
+	return nil
+}
+
+// Enabler returns the level for the server's log cores. It enables every level
+// enabled globally or by any subsystem; loggers must be wrapped with Filter or
+// Logger to apply the appropriate level.
+func (l *Levels) Enabler() zapcore.LevelEnabler {
+	return zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+		if l.global.Enabled(lvl) {
+			return true
+		}
+		for _, s := range l.subsystems {
+			if s.Enabled(lvl) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Filter returns log filtered by the global level.
+func (l *Levels) Filter(log *zap.Logger) *zap.Logger {
+	return withLevel(log, l.global)
+}
+
+// Logger returns log filtered by the level of subsystem, named after it.
+// Unknown subsystems are filtered by the global level.
+func (l *Levels) Logger(log *zap.Logger, subsystem string) *zap.Logger {
+	var level zapcore.LevelEnabler = l.global
+	if s, ok := l.subsystems[subsystem]; ok {
+		level = s
+	}
+	return withLevel(log, level).With(zap.String("subsystem", subsystem))
+}
+
+// withLevel replaces any level filter already applied to log with level.
+func withLevel(log *zap.Logger, level zapcore.LevelEnabler) *zap.Logger {
+	return log.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		if f, ok := c.(*levelFilterCore); ok {
+			c = f.Core
+		}
+		return &levelFilterCore{Core: c, level: level}
+	}))
+}
+
+// levelFilterCore drops entries not enabled by level before they reach Core.
+type levelFilterCore struct {
+	zapcore.Core
+	level zapcore.LevelEnabler
+}
+
+func (c *levelFilterCore) Enabled(lvl zapcore.Level) bool {
+	return c.level.Enabled(lvl) && c.Core.Enabled(lvl)
+}
+
+func (c *levelFilterCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelFilterCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *levelFilterCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.level.Enabled(ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLevels_Logger(t *testing.T) {
+	levels := NewLevels(zap.NewAtomicLevelAt(zapcore.InfoLevel), SubsystemStorage, SubsystemHTTP)
+	core, logs := observer.New(levels.Enabler())
+	root := levels.Filter(zap.New(core))
+	storage := levels.Logger(root.With(zap.String("service", "engine")), SubsystemStorage)
+	httpLog := levels.Logger(root, SubsystemHTTP)
+
+	root.Debug("root")
+	storage.Debug("storage")
+	require.Zero(t, logs.Len())
+
+	debug := zapcore.DebugLevel
+	require.NoError(t, levels.SetLevel(SubsystemStorage, &debug))
+	root.Debug("root")
+	httpLog.Debug("http")
+	storage.Debug("storage")
+	storage.With(zap.Int("n", 1)).Debug("storage with fields")
+	entries := logs.TakeAll()
+	require.Len(t, entries, 2)
+	require.Equal(t, "storage", entries[0].Message)
+	require.Equal(t, map[string]interface{}{"service": "engine", "subsystem": "storage"}, entries[0].ContextMap())
+	require.Equal(t, "storage with fields", entries[1].Message)
+
+	// Once cleared, the subsystem follows the global level again.
+	require.NoError(t, levels.SetLevel(SubsystemStorage, nil))
+	levels.SetGlobal(zapcore.WarnLevel)
+	storage.Info("storage")
+	root.Info("root")
+	httpLog.Warn("http")
+	entries = logs.TakeAll()
+	require.Len(t, entries, 1)
+	require.Equal(t, "http", entries[0].Message)
+
+	require.Error(t, levels.SetLevel("unknown", &debug))
+}
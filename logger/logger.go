@@ -14,7 +14,21 @@ import (
 const TimeFormat = "2006-01-02T15:04:05.000000Z07:00"
 
 func (c *Config) New(defaultOutput io.Writer) (*zap.Logger, error) {
-	w := defaultOutput
+	core, err := c.NewCore(defaultOutput)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithCores(core), nil
+}
+
+// NewWithCores returns a logger writing every entry to all of cores, for
+// example to write both to standard output and to a file.
+func NewWithCores(cores ...zapcore.Core) *zap.Logger {
+	return zap.New(zapcore.NewTee(cores...), zap.Fields(zap.String("log_id", nextID())))
+}
+
+// NewCore returns a core writing entries enabled by c.Level to w in c.Format.
+func (c *Config) NewCore(w io.Writer) (zapcore.Core, error) {
 	format := c.Format
 	if format == "console" {
 		// Disallow the console logger if the output is not a terminal.
@@ -35,11 +49,11 @@ func (c *Config) New(defaultOutput io.Writer) (*zap.Logger, error) {
 	if err != nil {
 		return nil, err
 	}
-	return zap.New(zapcore.NewCore(
+	return zapcore.NewCore(
 		encoder,
 		zapcore.Lock(zapcore.AddSync(w)),
 		c.Level,
-	), zap.Fields(zap.String("log_id", nextID()))), nil
+	), nil
 }
 
 func newEncoder(format string) (zapcore.Encoder, error) {
@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"io"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// RecentLogs retains the most recent log entries in memory, so they can be
+// included in diagnostic bundles without access to the server's log output.
+type RecentLogs struct {
+	mu      sync.Mutex
+	entries [][]byte
+	next    int
+	full    bool
+}
+
+// NewRecentLogs returns a RecentLogs retaining up to n entries.
+func NewRecentLogs(n int) *RecentLogs {
+	return &RecentLogs{entries: make([][]byte, n)}
+}
+
+// Write records p as a single entry, evicting the oldest entry if full.
+// zapcore writes exactly one encoded entry per call.
+func (r *RecentLogs) Write(p []byte) (int, error) {
+	if len(r.entries) == 0 {
+		return len(p), nil
+	}
+
+	entry := make([]byte, len(p))
+	copy(entry, p)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+	return len(p), nil
+}
+
+// WriteTo writes the retained entries to w, oldest first.
+func (r *RecentLogs) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	entries := make([][]byte, 0, len(r.entries))
+	if r.full {
+		entries = append(entries, r.entries[r.next:]...)
+	}
+	entries = append(entries, r.entries[:r.next]...)
+	r.mu.Unlock()
+
+	var total int64
+	for _, e := range entries {
+		n, err := w.Write(e)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// Core returns a core writing logfmt encoded entries enabled by level to r.
+// It is intended to be teed with the core writing to the server's log output.
+func (r *RecentLogs) Core(level zapcore.LevelEnabler) zapcore.Core {
+	encoder, _ := newEncoder("logfmt")
+	return zapcore.NewCore(encoder, zapcore.AddSync(r), level)
+}
@@ -0,0 +1,30 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRecentLogs(t *testing.T) {
+	recent := NewRecentLogs(2)
+	log := zap.New(recent.Core(zapcore.InfoLevel))
+
+	log.Debug("not retained")
+	log.Info("first")
+	log.Info("second")
+	log.Warn("third")
+
+	var buf bytes.Buffer
+	_, err := recent.WriteTo(&buf)
+	require.NoError(t, err)
+	require.NotContains(t, buf.String(), "first")
+	require.NotContains(t, buf.String(), "not retained")
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	require.Contains(t, string(lines[0]), "msg=second")
+	require.Contains(t, string(lines[1]), "msg=third")
+}
@@ -0,0 +1,249 @@
+// Package membudget accounts for the memory used by the requests of
+// organizations against per-org budgets.
+//
+// Both the query engine and the write path charge the memory of their requests
+// to the Accountant, so that a single organization cannot use all the memory of
+// the process: work which would take an organization over its budget waits for
+// the organization's other requests to release their memory, and is rejected
+// when it cannot fit in time.
+package membudget
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Config configures an Accountant.
+type Config struct {
+	// OrgBytes is the number of bytes the requests of each organization may
+	// use at once. Zero disables the accounting.
+	OrgBytes int64
+	// QueueTimeout is how long work waits for the budget of its organization
+	// before it is rejected. Zero rejects the work which does not fit at once.
+	QueueTimeout time.Duration
+}
+
+// Accountant charges the memory of requests to the budgets of their
+// organizations. A nil Accountant accounts for nothing.
+type Accountant struct {
+	// mu protects the budget and queue timeout, which Update changes, and
+	// the accounts of the orgs.
+	mu           sync.Mutex
+	budget       int64
+	queueTimeout time.Duration
+	orgs         map[platform.ID]*account
+
+	used       prometheus.Gauge
+	waits      prometheus.Counter
+	rejections *prometheus.CounterVec
+}
+
+// account is the memory used by an organization. released is closed, and
+// replaced, whenever memory is released, to wake the work waiting for it.
+type account struct {
+	used     int64
+	released chan struct{}
+}
+
+// NewAccountant returns an Accountant of the memory budgets configured by cfg,
+// or nil if the accounting is disabled.
+func NewAccountant(cfg Config) *Accountant {
+	if cfg.OrgBytes <= 0 {
+		return nil
+	}
+	return &Accountant{
+		budget:       cfg.OrgBytes,
+		queueTimeout: cfg.QueueTimeout,
+		orgs:         make(map[platform.ID]*account),
+		used: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "memory",
+			Subsystem: "budget",
+			Name:      "used_bytes",
+			Help:      "Number of bytes charged to the memory budgets of organizations",
+		}),
+		waits: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "memory",
+			Subsystem: "budget",
+			Name:      "waits_total",
+			Help:      "Number of requests which waited for the memory budget of their organization",
+		}),
+		rejections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "memory",
+			Subsystem: "budget",
+			Name:      "rejections_total",
+			Help:      "Number of requests rejected for exceeding the memory budget of their organization, by reason",
+		}, []string{"reason"}),
+	}
+}
+
+// Update applies the budget and queue timeout of cfg to the work charged from
+// now on. Memory already charged stays charged, even beyond a smaller budget.
+// The accounting cannot be enabled or disabled by an update.
+func (a *Accountant) Update(cfg Config) error {
+	if a == nil || cfg.OrgBytes <= 0 {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "the memory budget of organizations cannot be enabled or disabled while running",
+		}
+	}
+	a.mu.Lock()
+	a.budget, a.queueTimeout = cfg.OrgBytes, cfg.QueueTimeout
+	// wake the work waiting for the budget, which may fit now.
+	for _, acc := range a.orgs {
+		close(acc.released)
+		acc.released = make(chan struct{})
+	}
+	a.mu.Unlock()
+	return nil
+}
+
+// PrometheusCollectors returns the metrics of the memory budgets.
+func (a *Accountant) PrometheusCollectors() []prometheus.Collector {
+	if a == nil {
+		return nil
+	}
+	return []prometheus.Collector{a.used, a.waits, a.rejections}
+}
+
+// Reserve charges n bytes to the budget of the org, waiting for the memory to
+// be released by the other requests of the org when it does not fit, until the
+// queue timeout or ctx is done. The memory is charged until the returned
+// Reservation is released.
+func (a *Accountant) Reserve(ctx context.Context, orgID platform.ID, n int64) (*Reservation, error) {
+	if a == nil {
+		return nil, nil
+	}
+	a.mu.Lock()
+	budget, queueTimeout := a.budget, a.queueTimeout
+	a.mu.Unlock()
+	if n > budget {
+		a.rejections.WithLabelValues("too_large").Inc()
+		return nil, &errors.Error{
+			Code: errors.ETooLarge,
+			Msg:  fmt.Sprintf("request needs %d bytes, more than the memory budget of the organization of %d bytes", n, budget),
+		}
+	}
+
+	var timeout <-chan time.Time
+	for waited := false; ; waited = true {
+		released, ok := a.charge(orgID, n)
+		if ok {
+			return &Reservation{a: a, orgID: orgID, n: n}, nil
+		}
+		if queueTimeout <= 0 {
+			return nil, a.exhausted()
+		}
+		if !waited {
+			a.waits.Inc()
+			t := time.NewTimer(queueTimeout)
+			defer t.Stop()
+			timeout = t.C
+		}
+
+		select {
+		case <-released:
+		case <-timeout:
+			return nil, a.exhausted()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (a *Accountant) exhausted() error {
+	a.rejections.WithLabelValues("exhausted").Inc()
+	return &errors.Error{
+		Code: errors.ETooManyRequests,
+		Msg:  "the memory budget of the organization is exhausted",
+	}
+}
+
+// charge charges n bytes to the org if they fit in its budget, returning the
+// channel closed the next time the org releases memory otherwise.
+func (a *Accountant) charge(orgID platform.ID, n int64) (<-chan struct{}, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	acc, ok := a.orgs[orgID]
+	if !ok {
+		acc = &account{released: make(chan struct{})}
+		a.orgs[orgID] = acc
+	}
+	if acc.used+n > a.budget {
+		return acc.released, false
+	}
+	acc.used += n
+	a.used.Add(float64(n))
+	return nil, true
+}
+
+func (a *Accountant) release(orgID platform.ID, n int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	acc := a.orgs[orgID]
+	acc.used -= n
+	a.used.Sub(float64(n))
+	close(acc.released)
+	if acc.used == 0 {
+		delete(a.orgs, orgID)
+		return
+	}
+	acc.released = make(chan struct{})
+}
+
+// Reservation is memory charged to the budget of an organization. A nil
+// Reservation charges nothing.
+type Reservation struct {
+	a     *Accountant
+	orgID platform.ID
+
+	mu sync.Mutex
+	n  int64
+}
+
+// Grow charges n more bytes to the reservation, failing rather than waiting
+// when they do not fit in the budget of the org.
+func (r *Reservation) Grow(n int64) error {
+	if r == nil {
+		return nil
+	}
+	if _, ok := r.a.charge(r.orgID, n); !ok {
+		return r.a.exhausted()
+	}
+	r.mu.Lock()
+	r.n += n
+	r.mu.Unlock()
+	return nil
+}
+
+// Bytes returns the number of bytes charged to the reservation.
+func (r *Reservation) Bytes() int64 {
+	if r == nil {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.n
+}
+
+// Release releases all the memory of the reservation. It may be called more
+// than once.
+func (r *Reservation) Release() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	n := r.n
+	r.n = 0
+	r.mu.Unlock()
+	if n > 0 {
+		r.a.release(r.orgID, n)
+	}
+}
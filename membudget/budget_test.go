@@ -0,0 +1,119 @@
+package membudget_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/membudget"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountant(t *testing.T) {
+	ctx := context.Background()
+	a := membudget.NewAccountant(membudget.Config{OrgBytes: 100})
+	org1, org2 := platform.ID(1), platform.ID(2)
+
+	r1, err := a.Reserve(ctx, org1, 60)
+	require.NoError(t, err)
+	require.Equal(t, int64(60), r1.Bytes())
+
+	// The budgets of orgs are separate.
+	r2, err := a.Reserve(ctx, org2, 100)
+	require.NoError(t, err)
+	r2.Release()
+
+	_, err = a.Reserve(ctx, org1, 50)
+	require.Equal(t, errors.ETooManyRequests, errors.ErrorCode(err))
+	require.Equal(t, errors.ETooManyRequests, errors.ErrorCode(r1.Grow(50)))
+	require.NoError(t, r1.Grow(40))
+	require.Equal(t, int64(100), r1.Bytes())
+
+	_, err = a.Reserve(ctx, org2, 101)
+	require.Equal(t, errors.ETooLarge, errors.ErrorCode(err))
+
+	r1.Release()
+	r1.Release()
+	require.Zero(t, r1.Bytes())
+	r1, err = a.Reserve(ctx, org1, 100)
+	require.NoError(t, err)
+	r1.Release()
+}
+
+func TestAccountant_Queue(t *testing.T) {
+	ctx := context.Background()
+	a := membudget.NewAccountant(membudget.Config{OrgBytes: 100, QueueTimeout: time.Minute})
+
+	r1, err := a.Reserve(ctx, 1, 80)
+	require.NoError(t, err)
+
+	reserved := make(chan error)
+	go func() {
+		r, err := a.Reserve(ctx, 1, 50)
+		r.Release()
+		reserved <- err
+	}()
+	select {
+	case <-reserved:
+		t.Fatal("reserved memory over the budget")
+	case <-time.After(10 * time.Millisecond):
+	}
+	r1.Release()
+	require.NoError(t, <-reserved)
+
+	// The work waiting for memory gives up with its context.
+	r1, err = a.Reserve(ctx, 1, 80)
+	require.NoError(t, err)
+	defer r1.Release()
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = a.Reserve(cctx, 1, 50)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	a = membudget.NewAccountant(membudget.Config{OrgBytes: 100, QueueTimeout: 10 * time.Millisecond})
+	r1, err = a.Reserve(ctx, 1, 80)
+	require.NoError(t, err)
+	defer r1.Release()
+	_, err = a.Reserve(ctx, 1, 50)
+	require.Equal(t, errors.ETooManyRequests, errors.ErrorCode(err))
+}
+
+func TestAccountant_Disabled(t *testing.T) {
+	a := membudget.NewAccountant(membudget.Config{})
+	require.Nil(t, a)
+	require.Empty(t, a.PrometheusCollectors())
+
+	r, err := a.Reserve(context.Background(), 1, 1<<40)
+	require.NoError(t, err)
+	require.NoError(t, r.Grow(1<<40))
+	require.Zero(t, r.Bytes())
+	r.Release()
+}
+
+func TestAccountant_Update(t *testing.T) {
+	ctx := context.Background()
+	a := membudget.NewAccountant(membudget.Config{OrgBytes: 100, QueueTimeout: time.Minute})
+
+	r1, err := a.Reserve(ctx, 1, 80)
+	require.NoError(t, err)
+	defer r1.Release()
+
+	// Work waiting for the budget fits once it grows.
+	reserved := make(chan error)
+	go func() {
+		r, err := a.Reserve(ctx, 1, 50)
+		r.Release()
+		reserved <- err
+	}()
+	require.NoError(t, a.Update(membudget.Config{OrgBytes: 200, QueueTimeout: time.Minute}))
+	require.NoError(t, <-reserved)
+
+	_, err = a.Reserve(ctx, 1, 250)
+	require.Equal(t, errors.ETooLarge, errors.ErrorCode(err))
+
+	require.Error(t, a.Update(membudget.Config{}))
+	var disabled *membudget.Accountant
+	require.Error(t, disabled.Update(membudget.Config{OrgBytes: 100}))
+}
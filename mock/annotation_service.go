@@ -95,6 +95,20 @@ func (mr *MockAnnotationServiceMockRecorder) DeleteAnnotations(ctx, orgID, delet
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAnnotations", reflect.TypeOf((*MockAnnotationService)(nil).DeleteAnnotations), ctx, orgID, delete)
 }
 
+// DeleteAnnotationsByID mocks base method
+func (m *MockAnnotationService) DeleteAnnotationsByID(ctx context.Context, orgID platform.ID, ids []platform.ID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAnnotationsByID", ctx, orgID, ids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAnnotationsByID indicates an expected call of DeleteAnnotationsByID
+func (mr *MockAnnotationServiceMockRecorder) DeleteAnnotationsByID(ctx, orgID, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAnnotationsByID", reflect.TypeOf((*MockAnnotationService)(nil).DeleteAnnotationsByID), ctx, orgID, ids)
+}
+
 // DeleteAnnotation mocks base method
 func (m *MockAnnotationService) DeleteAnnotation(ctx context.Context, id platform.ID) error {
 	m.ctrl.T.Helper()
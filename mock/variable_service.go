@@ -69,3 +69,15 @@ func (s *VariableService) UpdateVariable(ctx context.Context, id platform.ID, up
 	defer s.UpdateVariableCalls.IncrFn()()
 	return s.UpdateVariableF(ctx, id, update)
 }
+
+var _ influxdb.VariableValuesService = &VariableValuesService{}
+
+// VariableValuesService is a mock implementation of influxdb.VariableValuesService.
+type VariableValuesService struct {
+	FindVariableValuesF func(ctx context.Context, id platform.ID, refresh bool) (*influxdb.VariableValues, error)
+}
+
+// FindVariableValues calls FindVariableValuesF.
+func (s *VariableValuesService) FindVariableValues(ctx context.Context, id platform.ID, refresh bool) (*influxdb.VariableValues, error) {
+	return s.FindVariableValuesF(ctx, id, refresh)
+}
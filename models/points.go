@@ -357,6 +357,13 @@ func ValidPrecision(precision string) bool {
 // This can have the unintended effect preventing buf from being garbage collected.
 func ParsePointsWithPrecision(buf []byte, defaultTime time.Time, precision string) ([]Point, error) {
 	points := make([]Point, 0, bytes.Count(buf, []byte{'\n'})+1)
+	return AppendPointsWithPrecision(points, buf, defaultTime, precision)
+}
+
+// AppendPointsWithPrecision is similar to ParsePointsWithPrecision, but appends
+// the points to dst, so that the caller can reuse its buffers of points.
+func AppendPointsWithPrecision(dst []Point, buf []byte, defaultTime time.Time, precision string) ([]Point, error) {
+	points := dst
 	var (
 		pos    int
 		block  []byte
@@ -1177,6 +1184,34 @@ func scanLine(buf []byte, i int) (int, []byte) {
 	return i, buf[start:i]
 }
 
+// SplitLines splits buf into at most n blocks of whole lines, of about the same
+// size, so that the points of the blocks can be parsed independently. The lines
+// are split like ParsePoints does, so quoted newlines stay in their line.
+func SplitLines(buf []byte, n int) [][]byte {
+	if n <= 1 || len(buf) == 0 {
+		return [][]byte{buf}
+	}
+
+	size := len(buf) / n
+	blocks := make([][]byte, 0, n)
+	start, pos := 0, 0
+	for pos < len(buf) && len(blocks) < n-1 {
+		pos, _ = scanLine(buf, pos)
+		pos++
+		if pos-start >= size {
+			if pos > len(buf) {
+				pos = len(buf)
+			}
+			blocks = append(blocks, buf[start:pos])
+			start = pos
+		}
+	}
+	if start < len(buf) {
+		blocks = append(blocks, buf[start:])
+	}
+	return blocks
+}
+
 // scanTo returns the end position in buf and the next consecutive block
 // of bytes, starting from i and ending with stop byte, where stop byte
 // has not been escaped.
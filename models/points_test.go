@@ -2607,3 +2607,28 @@ func BenchmarkNewTagsKeyValues(b *testing.B) {
 		})
 	})
 }
+
+func TestSplitLines(t *testing.T) {
+	buf := []byte("cpu value=1 1\nlogs msg=\"a\nb\" 2\ncpu value=3 3\n\ncpu value=4 4")
+	for n := 0; n <= 6; n++ {
+		blocks := models.SplitLines(buf, n)
+		if n > 1 && len(blocks) > n {
+			t.Fatalf("SplitLines(%d) returned %d blocks", n, len(blocks))
+		}
+		if got := bytes.Join(blocks, nil); !bytes.Equal(got, buf) {
+			t.Fatalf("SplitLines(%d) blocks join to %q", n, got)
+		}
+
+		var points int
+		for _, block := range blocks {
+			pts, err := models.ParsePoints(block)
+			if err != nil {
+				t.Fatalf("SplitLines(%d) split a line: %v", n, err)
+			}
+			points += len(pts)
+		}
+		if points != 4 {
+			t.Fatalf("SplitLines(%d) blocks have %d points, want 4", n, points)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package check
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/ast/astutil"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/notification"
+	"github.com/influxdata/influxdb/v2/notification/flux"
+	"github.com/influxdata/influxdb/v2/query"
+	"github.com/influxdata/influxdb/v2/query/fluxlang"
+)
+
+const (
+	// DefaultAnomalySensitivity is the sensitivity of anomaly checks which
+	// don't set one.
+	DefaultAnomalySensitivity = 3.0
+
+	// madScale scales the median absolute deviation of a baseline to the
+	// standard deviation of a normally distributed series.
+	madScale = 1.4826
+)
+
+var _ influxdb.Check = (*Anomaly)(nil)
+
+// Anomaly is the anomaly detection check. Each run learns the baseline of
+// every series of the query from its values over the baseline window, and the
+// latest value of a series is anomalous when it deviates from the median of its
+// baseline by more than Sensitivity times its scaled median absolute deviation.
+//
+// The level function is given the latest value in _value, the median of the
+// baseline in _median, and the deviation of the value in _deviation.
+type Anomaly struct {
+	Base
+	// BaselineWindow is how far back the values of the baseline are queried.
+	BaselineWindow *notification.Duration `json:"baselineWindow,omitempty"`
+	// Season is the period of series which follow a pattern, such as 1d for a
+	// daily pattern. When it is set, the baseline of a value only has the
+	// values at the same time of the previous seasons.
+	Season *notification.Duration `json:"season,omitempty"`
+	// Sensitivity is the number of deviations beyond which a value is
+	// anomalous. It defaults to DefaultAnomalySensitivity.
+	Sensitivity float64                 `json:"sensitivity,omitempty"`
+	Level       notification.CheckLevel `json:"level"`
+}
+
+// Type returns the type of the check.
+func (c Anomaly) Type() string {
+	return "anomaly"
+}
+
+// Valid returns err if the check is invalid.
+func (c Anomaly) Valid(lang fluxlang.FluxLanguageService) error {
+	if err := c.Base.Valid(lang); err != nil {
+		return err
+	}
+	if c.BaselineWindow == nil || len(c.BaselineWindow.Values) == 0 {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "anomaly check baselineWindow must exist",
+		}
+	}
+	if c.BaselineWindow.TimeDuration() < 2*c.Every.TimeDuration() {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "anomaly check baselineWindow must be at least twice every",
+		}
+	}
+	if c.Season != nil {
+		if c.Season.TimeDuration() < c.Every.TimeDuration() {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "anomaly check season can't be shorter than every",
+			}
+		}
+		if c.BaselineWindow.TimeDuration() <= c.Season.TimeDuration() {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "anomaly check baselineWindow must be longer than season",
+			}
+		}
+	}
+	if c.Sensitivity < 0 {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "anomaly check sensitivity can't be negative",
+		}
+	}
+	return nil
+}
+
+func (c Anomaly) sensitivity() float64 {
+	if c.Sensitivity == 0 {
+		return DefaultAnomalySensitivity
+	}
+	return c.Sensitivity
+}
+
+// GenerateFlux returns a flux script for the Anomaly provided.
+func (c Anomaly) GenerateFlux(lang fluxlang.FluxLanguageService) (string, error) {
+	f, err := c.GenerateFluxAST(lang)
+	if err != nil {
+		return "", err
+	}
+
+	return astutil.Format(f)
+}
+
+// GenerateFluxAST returns a flux AST for the anomaly provided. The query of
+// the check is run over the baseline window, aggregated every interval of the
+// check.
+func (c Anomaly) GenerateFluxAST(lang fluxlang.FluxLanguageService) (*ast.File, error) {
+	p, err := query.Parse(lang, c.Query.Text)
+	if p == nil {
+		return nil, err
+	}
+	replaceDurationsWithEvery(p, c.Every)
+	replaceRangeStart(p, c.BaselineWindow)
+	removeStopFromRange(p)
+	addCreateEmptyFalseToAggregateWindow(p)
+
+	if errs := ast.GetErrors(p); len(errs) != 0 {
+		return nil, multiError(errs)
+	}
+
+	if len(p.Files) != 1 {
+		return nil, fmt.Errorf("expect a single file to be returned from query parsing got %d", len(p.Files))
+	}
+
+	f := p.Files[0]
+	assignPipelineToData(f)
+
+	f.Imports = append(f.Imports, flux.Imports("influxdata/influxdb/monitor", "experimental", "math")...)
+	f.Body = append(f.Body, c.generateFluxASTBody()...)
+
+	return f, nil
+}
+
+func (c Anomaly) generateFluxASTBody() []ast.Statement {
+	var statements []ast.Statement
+	statements = append(statements, c.generateTaskOption())
+	statements = append(statements, c.generateFluxASTCheckDefinition("anomaly"))
+	statements = append(statements, c.generateLevelFn())
+	statements = append(statements, c.generateFluxASTMessageFunction())
+	statements = append(statements, c.generateFluxASTBaseline()...)
+	return append(statements, c.generateFluxASTChecksFunction())
+}
+
+func (c Anomaly) generateLevelFn() ast.Statement {
+	fn := flux.Function(flux.FunctionParams("r"), flux.GreaterThan(flux.Member("r", "_deviation"), flux.Float(c.sensitivity())))
+
+	lvl := strings.ToLower(c.Level.String())
+
+	return flux.DefineVariable(lvl, fn)
+}
+
+// generateFluxASTBaseline generates the median and the median absolute
+// deviation of the values of each series before the last interval. The rows
+// of a series are all given the stop of the range as their time, so that they
+// can be joined with the median of the series.
+func (c Anomaly) generateFluxASTBaseline() []ast.Statement {
+	var before ast.Expression = flux.LessThan(flux.Member("r", "_time"), c.lastInterval())
+	if c.Season != nil {
+		// the values queried a whole number of seasons ago, within an interval.
+		lag := flux.Subtract(
+			flux.Call(flux.Identifier("int"), flux.Object(flux.Property("v", flux.Call(flux.Identifier("now"), flux.Object())))),
+			flux.Call(flux.Identifier("int"), flux.Object(flux.Property("v", flux.Member("r", "_time")))),
+		)
+		phase := &ast.BinaryExpression{
+			Operator: ast.ModuloOperator,
+			Left:     lag,
+			Right:    flux.Call(flux.Identifier("int"), flux.Object(flux.Property("v", (*ast.DurationLiteral)(c.Season)))),
+		}
+		before = flux.And(before, flux.LessThan(
+			phase,
+			flux.Call(flux.Identifier("int"), flux.Object(flux.Property("v", (*ast.DurationLiteral)(c.Every)))),
+		))
+	}
+
+	atStop := flux.Call(flux.Identifier("map"), flux.Object(flux.Property("fn", flux.Function(
+		flux.FunctionParams("r"),
+		flux.ObjectWith("r", flux.Property("_time", flux.Member("r", "_stop"))),
+	))))
+	history := flux.Pipe(
+		flux.Identifier("data"),
+		flux.Call(flux.Identifier("filter"), flux.Object(flux.Property("fn", flux.Function(flux.FunctionParams("r"), before)))),
+		flux.Call(flux.Identifier("map"), flux.Object(flux.Property("fn", flux.Function(
+			flux.FunctionParams("r"),
+			flux.ObjectWith("r",
+				flux.Property("_value", flux.Call(flux.Identifier("float"), flux.Object(flux.Property("v", flux.Member("r", "_value"))))),
+				flux.Property("_time", flux.Member("r", "_stop")),
+			),
+		)))),
+	)
+	median := flux.Pipe(
+		flux.Identifier("history"),
+		flux.Call(flux.Identifier("median"), flux.Object()),
+		atStop,
+	)
+	mad := flux.Pipe(
+		flux.Call(flux.Member("experimental", "join"), flux.Object(
+			flux.Property("left", flux.Identifier("history")),
+			flux.Property("right", flux.Identifier("median")),
+			flux.Property("fn", flux.Function(flux.FunctionParams("left", "right"), flux.ObjectWith("left",
+				flux.Property("_value", flux.Call(flux.Member("math", "abs"), flux.Object(flux.Property("x", flux.Subtract(
+					flux.Member("left", "_value"),
+					flux.Member("right", "_value"),
+				))))),
+			))),
+		)),
+		flux.Call(flux.Identifier("median"), flux.Object()),
+		atStop,
+	)
+	baseline := flux.Call(flux.Member("experimental", "join"), flux.Object(
+		flux.Property("left", flux.Identifier("median")),
+		flux.Property("right", flux.Identifier("mad")),
+		flux.Property("fn", flux.Function(flux.FunctionParams("left", "right"), flux.ObjectWith("left",
+			flux.Property("_median", flux.Member("left", "_value")),
+			flux.Property("_mad", flux.Member("right", "_value")),
+		))),
+	))
+
+	return []ast.Statement{
+		flux.DefineVariable("history", history),
+		flux.DefineVariable("median", median),
+		flux.DefineVariable("mad", mad),
+		flux.DefineVariable("baseline", baseline),
+	}
+}
+
+func (c Anomaly) lastInterval() ast.Expression {
+	now := flux.Call(flux.Identifier("now"), flux.Object())
+	return flux.Call(flux.Member("experimental", "subDuration"), flux.Object(
+		flux.Property("from", now),
+		flux.Property("d", (*ast.DurationLiteral)(c.Every)),
+	))
+}
+
+// generateFluxASTChecksFunction joins the latest value of each series with
+// its baseline, and checks the deviation of the value. Series whose baseline
+// has no deviation are never anomalous.
+func (c Anomaly) generateFluxASTChecksFunction() ast.Statement {
+	latest := flux.Pipe(
+		flux.Identifier("data"),
+		flux.Call(flux.Identifier("filter"), flux.Object(flux.Property("fn", flux.Function(
+			flux.FunctionParams("r"),
+			flux.GreaterThanEqual(flux.Member("r", "_time"), c.lastInterval()),
+		)))),
+		flux.Call(flux.Identifier("last"), flux.Object()),
+		flux.Call(flux.Identifier("map"), flux.Object(flux.Property("fn", flux.Function(
+			flux.FunctionParams("r"),
+			flux.ObjectWith("r",
+				flux.Property("_value", flux.Call(flux.Identifier("float"), flux.Object(flux.Property("v", flux.Member("r", "_value"))))),
+				flux.Property("_value_time", flux.Member("r", "_time")),
+				flux.Property("_time", flux.Member("r", "_stop")),
+			),
+		)))),
+	)
+
+	deviation := flux.If(
+		flux.Equal(flux.Member("r", "_mad"), flux.Float(0)),
+		flux.Float(0),
+		&ast.BinaryExpression{
+			Operator: ast.DivisionOperator,
+			Left: flux.Call(flux.Member("math", "abs"), flux.Object(flux.Property("x", flux.Subtract(
+				flux.Member("r", "_value"),
+				flux.Member("r", "_median"),
+			)))),
+			Right: &ast.BinaryExpression{
+				Operator: ast.MultiplicationOperator,
+				Left:     flux.Float(madScale),
+				Right:    flux.Member("r", "_mad"),
+			},
+		},
+	)
+
+	return flux.ExpressionStatement(flux.Pipe(
+		flux.Call(flux.Member("experimental", "join"), flux.Object(
+			flux.Property("left", latest),
+			flux.Property("right", flux.Identifier("baseline")),
+			flux.Property("fn", flux.Function(flux.FunctionParams("left", "right"), flux.ObjectWith("left",
+				flux.Property("_median", flux.Member("right", "_median")),
+				flux.Property("_mad", flux.Member("right", "_mad")),
+			))),
+		)),
+		flux.Call(flux.Identifier("map"), flux.Object(flux.Property("fn", flux.Function(
+			flux.FunctionParams("r"),
+			flux.ObjectWith("r",
+				flux.Property("_deviation", deviation),
+				flux.Property("_time", flux.Member("r", "_value_time")),
+			),
+		)))),
+		flux.Call(flux.Identifier("drop"), flux.Object(flux.Property("columns", flux.Array(
+			flux.String("_mad"),
+			flux.String("_value_time"),
+		)))),
+		c.generateFluxASTChecksCall(),
+	))
+}
+
+func (c Anomaly) generateFluxASTChecksCall() *ast.CallExpression {
+	lvl := strings.ToLower(c.Level.String())
+	return flux.Call(flux.Member("monitor", "check"), flux.Object(
+		flux.Property("data", flux.Identifier("check")),
+		flux.Property("messageFn", flux.Identifier("messageFn")),
+		flux.Property(lvl, flux.Identifier(lvl)),
+	))
+}
+
+type anomalyAlias Anomaly
+
+// MarshalJSON implement json.Marshaler interface.
+func (c Anomaly) MarshalJSON() ([]byte, error) {
+	return json.Marshal(
+		struct {
+			anomalyAlias
+			Type string `json:"type"`
+		}{
+			anomalyAlias: anomalyAlias(c),
+			Type:         c.Type(),
+		})
+}
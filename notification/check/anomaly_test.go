@@ -0,0 +1,152 @@
+package check_test
+
+import (
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/notification"
+	"github.com/influxdata/influxdb/v2/notification/check"
+	"github.com/influxdata/influxdb/v2/query/fluxlang"
+	itesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnomaly_GenerateFlux(t *testing.T) {
+	type args struct {
+		anomaly check.Anomaly
+	}
+	type wants struct {
+		script string
+	}
+
+	base := check.Base{
+		ID:                    10,
+		Name:                  "moo",
+		Tags:                  []influxdb.Tag{{Key: "aaa", Value: "vaaa"}},
+		Every:                 mustDuration("1m"),
+		StatusMessageTemplate: "whoa! {r[\"_deviation\"]}",
+		Query: influxdb.DashboardQuery{
+			Text: `from(bucket: "foo") |> range(start: -1d, stop: now()) |> filter(fn: (r) => r._field == "usage_user") |> aggregateWindow(every: 1m, fn: mean) |> yield()`,
+		},
+	}
+
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "basic",
+			args: args{
+				anomaly: check.Anomaly{
+					Base:           base,
+					BaselineWindow: mustDuration("1h"),
+					Level:          notification.Warn,
+				},
+			},
+			wants: wants{
+				script: `import "influxdata/influxdb/monitor"
+import "experimental"
+import "math"
+
+data =
+    from(bucket: "foo")
+        |> range(start: -1h)
+        |> filter(fn: (r) => r._field == "usage_user")
+        |> aggregateWindow(every: 1m, fn: mean, createEmpty: false)
+
+option task = {name: "moo", every: 1m}
+
+check = {_check_id: "000000000000000a", _check_name: "moo", _type: "anomaly", tags: {aaa: "vaaa"}}
+warn = (r) => r["_deviation"] > 3.0
+messageFn = (r) => "whoa! {r[\"_deviation\"]}"
+history =
+    data
+        |> filter(fn: (r) => r["_time"] < experimental["subDuration"](from: now(), d: 1m))
+        |> map(fn: (r) => ({r with _value: float(v: r["_value"]), _time: r["_stop"]}))
+median = history |> median() |> map(fn: (r) => ({r with _time: r["_stop"]}))
+mad =
+    experimental["join"](left: history, right: median, fn: (left, right) => ({left with _value: math["abs"](x: left["_value"] - right["_value"])}))
+        |> median()
+        |> map(fn: (r) => ({r with _time: r["_stop"]}))
+baseline = experimental["join"](left: median, right: mad, fn: (left, right) => ({left with _median: left["_value"], _mad: right["_value"]}))
+
+experimental["join"](
+    left:
+        data
+            |> filter(fn: (r) => r["_time"] >= experimental["subDuration"](from: now(), d: 1m))
+            |> last()
+            |> map(fn: (r) => ({r with _value: float(v: r["_value"]), _value_time: r["_time"], _time: r["_stop"]})),
+    right: baseline,
+    fn: (left, right) => ({left with _median: right["_median"], _mad: right["_mad"]}),
+)
+    |> map(fn: (r) => ({r with _deviation: if r["_mad"] == 0.0 then 0.0 else math["abs"](x: r["_value"] - r["_median"]) / (1.4826 * r["_mad"]), _time: r["_value_time"]}))
+    |> drop(columns: ["_mad", "_value_time"])
+    |> monitor["check"](data: check, messageFn: messageFn, warn: warn)
+`,
+			},
+		},
+		{
+			name: "seasonal",
+			args: args{
+				anomaly: check.Anomaly{
+					Base:           base,
+					BaselineWindow: mustDuration("7d"),
+					Season:         mustDuration("1d"),
+					Sensitivity:    5,
+					Level:          notification.Critical,
+				},
+			},
+			wants: wants{
+				script: `import "influxdata/influxdb/monitor"
+import "experimental"
+import "math"
+
+data =
+    from(bucket: "foo")
+        |> range(start: -7d)
+        |> filter(fn: (r) => r._field == "usage_user")
+        |> aggregateWindow(every: 1m, fn: mean, createEmpty: false)
+
+option task = {name: "moo", every: 1m}
+
+check = {_check_id: "000000000000000a", _check_name: "moo", _type: "anomaly", tags: {aaa: "vaaa"}}
+crit = (r) => r["_deviation"] > 5.0
+messageFn = (r) => "whoa! {r[\"_deviation\"]}"
+history =
+    data
+        |> filter(fn: (r) => r["_time"] < experimental["subDuration"](from: now(), d: 1m) and (int(v: now()) - int(v: r["_time"])) % int(v: 1d) < int(v: 1m))
+        |> map(fn: (r) => ({r with _value: float(v: r["_value"]), _time: r["_stop"]}))
+median = history |> median() |> map(fn: (r) => ({r with _time: r["_stop"]}))
+mad =
+    experimental["join"](left: history, right: median, fn: (left, right) => ({left with _value: math["abs"](x: left["_value"] - right["_value"])}))
+        |> median()
+        |> map(fn: (r) => ({r with _time: r["_stop"]}))
+baseline = experimental["join"](left: median, right: mad, fn: (left, right) => ({left with _median: left["_value"], _mad: right["_value"]}))
+
+experimental["join"](
+    left:
+        data
+            |> filter(fn: (r) => r["_time"] >= experimental["subDuration"](from: now(), d: 1m))
+            |> last()
+            |> map(fn: (r) => ({r with _value: float(v: r["_value"]), _value_time: r["_time"], _time: r["_stop"]})),
+    right: baseline,
+    fn: (left, right) => ({left with _median: right["_median"], _mad: right["_mad"]}),
+)
+    |> map(fn: (r) => ({r with _deviation: if r["_mad"] == 0.0 then 0.0 else math["abs"](x: r["_value"] - r["_median"]) / (1.4826 * r["_mad"]), _time: r["_value_time"]}))
+    |> drop(columns: ["_mad", "_value_time"])
+    |> monitor["check"](data: check, messageFn: messageFn, crit: crit)
+`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := tt.args.anomaly.GenerateFlux(fluxlang.DefaultService)
+			require.NoError(t, err)
+			assert.Equal(t, itesting.FormatFluxString(t, tt.wants.script), s)
+		})
+	}
+}
@@ -144,6 +144,41 @@ func TestValidCheck(t *testing.T) {
 				Msg:  "range threshold min can't be larger than max",
 			},
 		},
+		{
+			name: "bad threshold recovery",
+			src: &check.Threshold{
+				Base: goodBase,
+				Thresholds: []check.ThresholdConfig{
+					&check.Greater{Value: 80, Recovery: func(f float64) *float64 { return &f }(90)},
+				},
+			},
+			err: &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "greater threshold recovery can't be greater than value",
+			},
+		},
+		{
+			name: "anomaly without baseline window",
+			src: &check.Anomaly{
+				Base: goodBase,
+			},
+			err: &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "anomaly check baselineWindow must exist",
+			},
+		},
+		{
+			name: "anomaly season longer than baseline window",
+			src: &check.Anomaly{
+				Base:           goodBase,
+				BaselineWindow: mustDuration("1d"),
+				Season:         mustDuration("1d"),
+			},
+			err: &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "anomaly check baselineWindow must be longer than season",
+			},
+		},
 	}
 	for _, c := range cases {
 		got := c.src.Valid(fluxlang.DefaultService)
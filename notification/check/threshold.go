@@ -17,10 +17,18 @@ import (
 
 var _ influxdb.Check = (*Threshold)(nil)
 
+// defaultRecoveryIntervals is the number of check intervals a threshold check
+// looks back for the values of thresholds with a recovery bound by default.
+const defaultRecoveryIntervals = 10
+
 // Threshold is the threshold check.
 type Threshold struct {
 	Base
 	Thresholds []ThresholdConfig `json:"thresholds"`
+	// RecoveryWindow is how far back the check looks for the value that
+	// triggered a threshold with a recovery bound. It defaults to ten times
+	// the every of the check.
+	RecoveryWindow *notification.Duration `json:"recoveryWindow,omitempty"`
 }
 
 // Type returns the type of the check.
@@ -38,21 +46,50 @@ func (t Threshold) Valid(lang fluxlang.FluxLanguageService) error {
 			return err
 		}
 	}
+	if t.RecoveryWindow != nil && t.RecoveryWindow.TimeDuration() < t.Every.TimeDuration() {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "recovery window can't be shorter than every",
+		}
+	}
 	return nil
 }
 
+func (t Threshold) hasRecovery() bool {
+	for _, c := range t.Thresholds {
+		if len(c.generateFluxASTRecoveryCalls("")) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (t Threshold) recoveryWindow() *notification.Duration {
+	if t.RecoveryWindow != nil {
+		return t.RecoveryWindow
+	}
+	w := &notification.Duration{}
+	for _, v := range t.Every.Values {
+		v.Magnitude *= defaultRecoveryIntervals
+		w.Values = append(w.Values, v)
+	}
+	return w
+}
+
 type thresholdDecode struct {
 	Base
-	Thresholds []thresholdConfigDecode `json:"thresholds"`
+	Thresholds     []thresholdConfigDecode `json:"thresholds"`
+	RecoveryWindow *notification.Duration  `json:"recoveryWindow,omitempty"`
 }
 
 type thresholdConfigDecode struct {
 	ThresholdConfigBase
-	Type   string  `json:"type"`
-	Value  float64 `json:"value"`
-	Min    float64 `json:"min"`
-	Max    float64 `json:"max"`
-	Within bool    `json:"within"`
+	Type     string   `json:"type"`
+	Value    float64  `json:"value"`
+	Recovery *float64 `json:"recovery"`
+	Min      float64  `json:"min"`
+	Max      float64  `json:"max"`
+	Within   bool     `json:"within"`
 }
 
 // UnmarshalJSON implement json.Unmarshaler interface.
@@ -62,18 +99,21 @@ func (t *Threshold) UnmarshalJSON(b []byte) error {
 		return err
 	}
 	t.Base = tdRaws.Base
+	t.RecoveryWindow = tdRaws.RecoveryWindow
 	for _, tdRaw := range tdRaws.Thresholds {
 		switch tdRaw.Type {
 		case "lesser":
 			td := &Lesser{
 				ThresholdConfigBase: tdRaw.ThresholdConfigBase,
 				Value:               tdRaw.Value,
+				Recovery:            tdRaw.Recovery,
 			}
 			t.Thresholds = append(t.Thresholds, td)
 		case "greater":
 			td := &Greater{
 				ThresholdConfigBase: tdRaw.ThresholdConfigBase,
 				Value:               tdRaw.Value,
+				Recovery:            tdRaw.Recovery,
 			}
 			t.Thresholds = append(t.Thresholds, td)
 		case "range":
@@ -125,6 +165,9 @@ func (t Threshold) GenerateFluxAST(lang fluxlang.FluxLanguageService) (*ast.File
 		return nil, err
 	}
 	replaceDurationsWithEvery(p, t.Every)
+	if t.hasRecovery() {
+		replaceRangeStart(p, t.recoveryWindow())
+	}
 	removeStopFromRange(p)
 	addCreateEmptyFalseToAggregateWindow(p)
 
@@ -193,6 +236,26 @@ func replaceDurationsWithEvery(pkg *ast.Package, every *notification.Duration) {
 	})
 }
 
+// replaceRangeStart queries the data from start, so that the values which
+// triggered a threshold with a recovery bound before the last run are found.
+func replaceRangeStart(pkg *ast.Package, start *notification.Duration) {
+	ast.Visit(pkg, func(n ast.Node) {
+		if call, ok := n.(*ast.CallExpression); ok {
+			if id, ok := call.Callee.(*ast.Identifier); ok && id.Name == "range" {
+				for _, args := range call.Arguments {
+					if obj, ok := args.(*ast.ObjectExpression); ok {
+						for _, prop := range obj.Properties {
+							if prop.Key.Key() == "start" {
+								prop.Value = flux.Negative((*ast.DurationLiteral)(start))
+							}
+						}
+					}
+				}
+			}
+		}
+	})
+}
+
 // TODO(desa): we'll likely want to remove all other arguments to range that are provided, but for now this should work.
 // When we decide to implement the full feature we'll have to do something more sophisticated.
 func removeStopFromRange(pkg *ast.Package) {
@@ -278,16 +341,26 @@ func (t Threshold) generateFluxASTBody(field string) []ast.Statement {
 	statements = append(statements, t.generateFluxASTCheckDefinition("threshold"))
 	statements = append(statements, t.generateFluxASTThresholdFunctions(field)...)
 	statements = append(statements, t.generateFluxASTMessageFunction())
-	statements = append(statements, t.generateFluxASTChecksFunction())
+	statements = append(statements, t.generateFluxASTChecksFunction(field))
 	return statements
 }
 
-func (t Threshold) generateFluxASTChecksFunction() ast.Statement {
-	return flux.ExpressionStatement(flux.Pipe(
-		flux.Identifier("data"),
-		flux.Call(flux.Member("v1", "fieldsAsCols"), flux.Object()),
-		t.generateFluxASTChecksCall(),
-	))
+// generateFluxASTChecksFunction pipes the data to the check. The levels of
+// thresholds with a recovery bound are found from the state of the values
+// over the recovery window, and only the latest value of each series is checked.
+func (t Threshold) generateFluxASTChecksFunction(field string) ast.Statement {
+	calls := []*ast.CallExpression{flux.Call(flux.Member("v1", "fieldsAsCols"), flux.Object())}
+	for _, c := range t.Thresholds {
+		calls = append(calls, c.generateFluxASTRecoveryCalls(field)...)
+	}
+	if len(calls) > 1 {
+		calls = append(calls, flux.Call(
+			flux.Identifier("last"),
+			flux.Object(flux.Property("column", flux.String("_time"))),
+		))
+	}
+	calls = append(calls, t.generateFluxASTChecksCall())
+	return flux.ExpressionStatement(flux.Pipe(flux.Identifier("data"), calls...))
 }
 
 func (t Threshold) generateFluxASTChecksCall() *ast.CallExpression {
@@ -314,23 +387,77 @@ func (t Threshold) generateFluxASTThresholdFunctions(field string) []ast.Stateme
 }
 
 func (td Greater) generateFluxASTThresholdFunction(field string) ast.Statement {
+	lvl := strings.ToLower(td.Level.String())
+	if td.Recovery != nil {
+		return generateFluxASTRecoveryFunction(lvl)
+	}
+
 	fnBody := flux.GreaterThan(flux.Member("r", field), flux.Float(td.Value))
 	fn := flux.Function(flux.FunctionParams("r"), fnBody)
 
-	lvl := strings.ToLower(td.Level.String())
-
 	return flux.DefineVariable(lvl, fn)
 }
 
+func (td Greater) generateFluxASTRecoveryCalls(field string) []*ast.CallExpression {
+	if td.Recovery == nil {
+		return nil
+	}
+	return generateFluxASTRecoveryStates(
+		strings.ToLower(td.Level.String()),
+		flux.GreaterThanEqual(flux.Member("r", field), flux.Float(*td.Recovery)),
+		flux.LessThanEqual(flux.Member("r", field), flux.Float(td.Value)),
+	)
+}
+
 func (td Lesser) generateFluxASTThresholdFunction(field string) ast.Statement {
+	lvl := strings.ToLower(td.Level.String())
+	if td.Recovery != nil {
+		return generateFluxASTRecoveryFunction(lvl)
+	}
+
 	fnBody := flux.LessThan(flux.Member("r", field), flux.Float(td.Value))
 	fn := flux.Function(flux.FunctionParams("r"), fnBody)
 
-	lvl := strings.ToLower(td.Level.String())
-
 	return flux.DefineVariable(lvl, fn)
 }
 
+func (td Lesser) generateFluxASTRecoveryCalls(field string) []*ast.CallExpression {
+	if td.Recovery == nil {
+		return nil
+	}
+	return generateFluxASTRecoveryStates(
+		strings.ToLower(td.Level.String()),
+		flux.LessThanEqual(flux.Member("r", field), flux.Float(*td.Recovery)),
+		flux.GreaterThanEqual(flux.Member("r", field), flux.Float(td.Value)),
+	)
+}
+
+func (td Range) generateFluxASTRecoveryCalls(string) []*ast.CallExpression {
+	return nil
+}
+
+// generateFluxASTRecoveryStates counts, for each value, the consecutive values
+// which have not recovered and the consecutive values which have not triggered
+// the level. A level has triggered and not yet recovered when the first count
+// is the longest.
+func generateFluxASTRecoveryStates(lvl string, notRecovered, notTriggered ast.Expression) []*ast.CallExpression {
+	return []*ast.CallExpression{
+		flux.Call(flux.Identifier("stateCount"), flux.Object(
+			flux.Property("fn", flux.Function(flux.FunctionParams("r"), notRecovered)),
+			flux.Property("column", flux.String("_"+lvl+"_unrecovered")),
+		)),
+		flux.Call(flux.Identifier("stateCount"), flux.Object(
+			flux.Property("fn", flux.Function(flux.FunctionParams("r"), notTriggered)),
+			flux.Property("column", flux.String("_"+lvl+"_untriggered")),
+		)),
+	}
+}
+
+func generateFluxASTRecoveryFunction(lvl string) ast.Statement {
+	fnBody := flux.LessThan(flux.Member("r", "_"+lvl+"_untriggered"), flux.Member("r", "_"+lvl+"_unrecovered"))
+	return flux.DefineVariable(lvl, flux.Function(flux.FunctionParams("r"), fnBody))
+}
+
 func (td Range) generateFluxASTThresholdFunction(field string) ast.Statement {
 	var fnBody *ast.LogicalExpression
 	if !td.Within {
@@ -372,6 +499,7 @@ type ThresholdConfig interface {
 	Valid() error
 	Type() string
 	generateFluxASTThresholdFunction(string) ast.Statement
+	generateFluxASTRecoveryCalls(string) []*ast.CallExpression
 	GetLevel() notification.CheckLevel
 }
 
@@ -396,6 +524,21 @@ func (b ThresholdConfigBase) GetLevel() notification.CheckLevel {
 type Lesser struct {
 	ThresholdConfigBase
 	Value float64 `json:"value"`
+	// Recovery, if set, is the value above which the level recovers once it
+	// has been triggered, rather than as soon as the values are not lesser
+	// than Value.
+	Recovery *float64 `json:"recovery,omitempty"`
+}
+
+// Valid returns error if the recovery bound is lesser than the value.
+func (td Lesser) Valid() error {
+	if td.Recovery != nil && *td.Recovery < td.Value {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "lesser threshold recovery can't be lesser than value",
+		}
+	}
+	return nil
 }
 
 // Type of the threshold config.
@@ -420,6 +563,21 @@ func (td Lesser) MarshalJSON() ([]byte, error) {
 type Greater struct {
 	ThresholdConfigBase
 	Value float64 `json:"value"`
+	// Recovery, if set, is the value below which the level recovers once it
+	// has been triggered, rather than as soon as the values are not greater
+	// than Value.
+	Recovery *float64 `json:"recovery,omitempty"`
+}
+
+// Valid returns error if the recovery bound is greater than the value.
+func (td Greater) Valid() error {
+	if td.Recovery != nil && *td.Recovery > td.Value {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "greater threshold recovery can't be greater than value",
+		}
+	}
+	return nil
 }
 
 // Type of the threshold config.
@@ -22,6 +22,7 @@ func TestThreshold_GenerateFlux(t *testing.T) {
 
 	var l float64 = 10
 	var u float64 = 40
+	var recoverCrit float64 = 80
 
 	tests := []struct {
 		name  string
@@ -349,6 +350,78 @@ data
         warn: warn,
         crit: crit,
     )
+`,
+			},
+		},
+		{
+			name: "levels with recovery bounds",
+			args: args{
+				threshold: check.Threshold{
+					Base: check.Base{
+						ID:                    10,
+						Name:                  "moo",
+						Every:                 mustDuration("1m"),
+						StatusMessageTemplate: "whoa! {r[\"usage_user\"]}",
+						Query: influxdb.DashboardQuery{
+							Text: `from(bucket: "foo") |> range(start: -1d) |> filter(fn: (r) => r._field == "usage_user") |> aggregateWindow(every: 1m, fn: mean)`,
+						},
+					},
+					Thresholds: []check.ThresholdConfig{
+						check.Greater{
+							ThresholdConfigBase: check.ThresholdConfigBase{
+								Level: notification.Critical,
+							},
+							Value:    90,
+							Recovery: &recoverCrit,
+						},
+						check.Greater{
+							ThresholdConfigBase: check.ThresholdConfigBase{
+								Level: notification.Warn,
+							},
+							Value: 80,
+						},
+						check.Lesser{
+							ThresholdConfigBase: check.ThresholdConfigBase{
+								Level: notification.Info,
+							},
+							Value:    l,
+							Recovery: &u,
+						},
+					},
+				},
+			},
+			wants: wants{
+				script: `import "influxdata/influxdb/monitor"
+import "influxdata/influxdb/v1"
+
+data =
+    from(bucket: "foo")
+        |> range(start: -10m)
+        |> filter(fn: (r) => r._field == "usage_user")
+        |> aggregateWindow(every: 1m, fn: mean, createEmpty: false)
+
+option task = {name: "moo", every: 1m}
+
+check = {_check_id: "000000000000000a", _check_name: "moo", _type: "threshold", tags: {}}
+crit = (r) => r["_crit_untriggered"] < r["_crit_unrecovered"]
+warn = (r) => r["usage_user"] > 80.0
+info = (r) => r["_info_untriggered"] < r["_info_unrecovered"]
+messageFn = (r) => "whoa! {r[\"usage_user\"]}"
+
+data
+    |> v1["fieldsAsCols"]()
+    |> stateCount(fn: (r) => r["usage_user"] >= 80.0, column: "_crit_unrecovered")
+    |> stateCount(fn: (r) => r["usage_user"] <= 90.0, column: "_crit_untriggered")
+    |> stateCount(fn: (r) => r["usage_user"] <= 40.0, column: "_info_unrecovered")
+    |> stateCount(fn: (r) => r["usage_user"] >= 10.0, column: "_info_untriggered")
+    |> last(column: "_time")
+    |> monitor["check"](
+        data: check,
+        messageFn: messageFn,
+        crit: crit,
+        warn: warn,
+        info: info,
+    )
 `,
 			},
 		},
@@ -0,0 +1,222 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultRetryInterval is how often the Deliverer looks for the queued
+	// notifications which are due a retry.
+	DefaultRetryInterval = time.Second
+
+	// MaxQueuedNotifications is how many notifications wait for a retry at
+	// once. Failed notifications are dropped once the queue is full.
+	MaxQueuedNotifications = 10000
+
+	// maxBackoff caps the delay between two attempts at a notification.
+	maxBackoff = time.Hour
+)
+
+// AttemptRecorder records delivery attempts.
+type AttemptRecorder interface {
+	RecordAttempt(ctx context.Context, a Attempt) error
+}
+
+// Notification is a notification to an HTTP endpoint.
+type Notification struct {
+	EndpointID platform.ID
+	OrgID      platform.ID
+	RuleID     platform.ID
+	Method     string
+	URL        string
+	Header     http.Header
+	Body       []byte
+	// MaxAttempts is how many times the notification is attempted.
+	MaxAttempts int
+	// Backoff is the delay before the first retry, which doubles after every retry.
+	Backoff time.Duration
+
+	attempts int
+	next     time.Time
+}
+
+// Deliverer delivers notifications, and retries the failed deliveries in the
+// background. The queue of retries is kept in memory, and is lost on restart.
+type Deliverer struct {
+	log      *zap.Logger
+	client   *http.Client
+	recorder AttemptRecorder
+
+	mu    sync.Mutex
+	queue []*Notification
+
+	TimeGenerator influxdb.TimeGenerator
+}
+
+// NewDeliverer constructs a new Deliverer.
+func NewDeliverer(log *zap.Logger, recorder AttemptRecorder) *Deliverer {
+	return &Deliverer{
+		log:           log,
+		client:        &http.Client{Timeout: 30 * time.Second},
+		recorder:      recorder,
+		TimeGenerator: influxdb.RealTimeGenerator{},
+	}
+}
+
+// Deliver attempts to deliver n, and queues it for a retry when the attempt
+// fails and n has attempts left. It returns the attempt, and an error if n
+// failed and can't be retried.
+func (d *Deliverer) Deliver(ctx context.Context, n *Notification) (Attempt, error) {
+	a := d.attempt(ctx, n)
+	if a.Delivered() {
+		return a, nil
+	}
+	if n.attempts >= n.MaxAttempts {
+		return a, &errors.Error{
+			Code: errors.EUnavailable,
+			Msg:  "notification could not be delivered",
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.queue) >= MaxQueuedNotifications {
+		return a, &errors.Error{
+			Code: errors.ETooManyRequests,
+			Msg:  "notification could not be delivered, and too many notifications are waiting for a retry",
+		}
+	}
+	d.schedule(n)
+	d.queue = append(d.queue, n)
+	return a, nil
+}
+
+// Queued returns how many notifications wait for a retry.
+func (d *Deliverer) Queued() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.queue)
+}
+
+// Run retries the queued notifications every interval, until ctx is done.
+func (d *Deliverer) Run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			d.retry(ctx)
+		}
+	}
+}
+
+// retry attempts the notifications which are due, and queues the ones which
+// failed again and have attempts left.
+func (d *Deliverer) retry(ctx context.Context) {
+	now := d.TimeGenerator.Now()
+	var due []*Notification
+
+	d.mu.Lock()
+	queue := d.queue[:0]
+	for _, n := range d.queue {
+		if n.next.After(now) {
+			queue = append(queue, n)
+			continue
+		}
+		due = append(due, n)
+	}
+	d.queue = queue
+	d.mu.Unlock()
+
+	var again []*Notification
+	for _, n := range due {
+		if ctx.Err() != nil {
+			again = append(again, n)
+			continue
+		}
+		a := d.attempt(ctx, n)
+		if a.Delivered() {
+			continue
+		}
+		if n.attempts >= n.MaxAttempts {
+			d.log.Info("Dropping notification after its last delivery attempt failed",
+				zap.String("endpointID", n.EndpointID.String()),
+				zap.Int("attempts", n.attempts),
+			)
+			continue
+		}
+		d.schedule(n)
+		again = append(again, n)
+	}
+
+	d.mu.Lock()
+	d.queue = append(d.queue, again...)
+	d.mu.Unlock()
+}
+
+// schedule sets the time of the next attempt at n.
+func (d *Deliverer) schedule(n *Notification) {
+	backoff := n.Backoff
+	for i := 1; i < n.attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	n.next = d.TimeGenerator.Now().Add(backoff)
+}
+
+// attempt sends n once and records the attempt.
+func (d *Deliverer) attempt(ctx context.Context, n *Notification) Attempt {
+	n.attempts++
+	a := Attempt{
+		EndpointID: n.EndpointID,
+		OrgID:      n.OrgID,
+		RuleID:     n.RuleID,
+		Attempt:    n.attempts,
+		Time:       d.TimeGenerator.Now(),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, n.Method, n.URL, bytes.NewReader(n.Body))
+	if err == nil {
+		req.Header = n.Header.Clone()
+		start := time.Now()
+		var resp *http.Response
+		resp, err = d.client.Do(req)
+		a.Latency = time.Since(start)
+		if err == nil {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+			resp.Body.Close()
+			a.StatusCode = resp.StatusCode
+			a.Response = string(body)
+		}
+	}
+	if err != nil {
+		a.Err = err.Error()
+	}
+
+	if err := d.recorder.RecordAttempt(ctx, a); err != nil {
+		d.log.Warn("Failed to record notification delivery attempt", zap.String("endpointID", n.EndpointID.String()), zap.Error(err))
+	}
+	if !a.Delivered() {
+		d.log.Debug("Notification delivery attempt failed",
+			zap.String("endpointID", n.EndpointID.String()),
+			zap.Int("attempt", a.Attempt),
+			zap.Int("statusCode", a.StatusCode),
+			zap.String("error", a.Err),
+		)
+	}
+	return a
+}
@@ -0,0 +1,97 @@
+package delivery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type fakeRecorder struct {
+	mu       sync.Mutex
+	attempts []Attempt
+}
+
+func (r *fakeRecorder) RecordAttempt(ctx context.Context, a Attempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts = append(r.attempts, a)
+	return nil
+}
+
+func TestDeliverer_Retry(t *testing.T) {
+	failures := 2
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("try later"))
+		}
+	}))
+	defer srv.Close()
+
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	rec := &fakeRecorder{}
+	d := NewDeliverer(zaptest.NewLogger(t), rec)
+	d.TimeGenerator = mock.TimeGenerator{FakeValue: now}
+
+	n := &Notification{
+		EndpointID:  1,
+		OrgID:       2,
+		Method:      http.MethodPost,
+		URL:         srv.URL,
+		Header:      http.Header{"Content-Type": []string{"application/json"}},
+		Body:        []byte(`{}`),
+		MaxAttempts: 3,
+		Backoff:     time.Minute,
+	}
+	a, err := d.Deliver(context.Background(), n)
+	require.NoError(t, err)
+	require.False(t, a.Delivered())
+	require.Equal(t, "try later", a.Response)
+	require.Equal(t, 1, d.Queued())
+
+	// not due yet.
+	d.retry(context.Background())
+	require.Len(t, rec.attempts, 1)
+
+	d.TimeGenerator = mock.TimeGenerator{FakeValue: now.Add(time.Minute)}
+	d.retry(context.Background())
+	require.Len(t, rec.attempts, 2)
+	require.Equal(t, 1, d.Queued())
+	require.Equal(t, now.Add(3*time.Minute), n.next)
+
+	d.TimeGenerator = mock.TimeGenerator{FakeValue: now.Add(3 * time.Minute)}
+	d.retry(context.Background())
+	require.Len(t, rec.attempts, 3)
+	require.Equal(t, 0, d.Queued())
+	require.True(t, rec.attempts[2].Delivered())
+	require.Equal(t, 3, rec.attempts[2].Attempt)
+}
+
+func TestDeliverer_NoAttemptsLeft(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	rec := &fakeRecorder{}
+	d := NewDeliverer(zaptest.NewLogger(t), rec)
+
+	_, err := d.Deliver(context.Background(), &Notification{
+		Method:      http.MethodPost,
+		URL:         srv.URL,
+		MaxAttempts: 1,
+	})
+	require.Equal(t, errors.EUnavailable, errors.ErrorCode(err))
+	require.Equal(t, 0, d.Queued())
+	require.Len(t, rec.attempts, 1)
+	require.Equal(t, StatusFailed, rec.attempts[0].Status())
+}
@@ -0,0 +1,178 @@
+package delivery
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/notification/endpoint"
+	"github.com/influxdata/influxdb/v2/secret"
+	"go.uber.org/zap"
+)
+
+// maxNotificationSize is the largest notification the relay accepts.
+const maxNotificationSize = 1 << 20
+
+// forwardedHeaders are the headers of the notification rules which are
+// forwarded to the endpoint.
+var forwardedHeaders = []string{"Content-Type", "Authorization"}
+
+// RelayHandler delivers the notifications the tasks of notification rules
+// post to the HTTP endpoints with a retry policy. Its route is not
+// authenticated with a token: requests are authenticated by the relay secret
+// of the endpoint instead.
+type RelayHandler struct {
+	chi.Router
+
+	api *kithttp.API
+	log *zap.Logger
+
+	endpoints influxdb.NotificationEndpointService
+	secrets   influxdb.SecretService
+	deliverer *Deliverer
+}
+
+// NewRelayHandler returns a new instance of RelayHandler. The endpoints and
+// secrets services must not be authorized, the requests carry no authorizer.
+func NewRelayHandler(log *zap.Logger, endpoints influxdb.NotificationEndpointService, secrets influxdb.SecretService, deliverer *Deliverer) *RelayHandler {
+	h := &RelayHandler{
+		api:       kithttp.NewAPI(kithttp.WithLog(log)),
+		log:       log,
+		endpoints: endpoints,
+		secrets:   secrets,
+		deliverer: deliverer,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+	r.Post("/{id}", h.handleDeliver)
+	h.Router = r
+	return h
+}
+
+// Prefix returns the mounting prefix for the handler.
+func (h *RelayHandler) Prefix() string {
+	return endpoint.HTTPDeliverPath
+}
+
+// handleDeliver delivers a notification to the HTTP endpoint in the path. It
+// responds 200 when the notification is delivered, and 202 when it failed and
+// is queued for a retry.
+func (h *RelayHandler) handleDeliver(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	e, err := h.findEndpoint(r, *id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxNotificationSize))
+	if err != nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "unable to read notification",
+			Err:  err,
+		})
+		return
+	}
+
+	n := &Notification{
+		EndpointID:  *id,
+		OrgID:       e.GetOrgID(),
+		RuleID:      ruleID(body),
+		Method:      e.Method,
+		URL:         e.URL,
+		Header:      http.Header{},
+		Body:        body,
+		MaxAttempts: e.Retry.MaxAttempts,
+		Backoff:     e.Retry.BackoffDuration(),
+	}
+	for _, k := range forwardedHeaders {
+		if v := r.Header.Get(k); v != "" {
+			n.Header.Set(k, v)
+		}
+	}
+
+	a, err := h.deliverer.Deliver(ctx, n)
+	if err != nil {
+		h.log.Info("Failed to deliver notification", zap.String("endpointID", id.String()), zap.Error(err))
+		h.api.Err(w, r, err)
+		return
+	}
+	if !a.Delivered() {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// ruleID returns the notification rule of the body of a notification, which
+// carries the columns of the status it notifies.
+func ruleID(body []byte) platform.ID {
+	var n struct {
+		RuleID string `json:"_notification_rule_id"`
+	}
+	if err := json.Unmarshal(body, &n); err != nil {
+		return 0
+	}
+	id, err := platform.IDFromString(n.RuleID)
+	if err != nil {
+		return 0
+	}
+	return *id
+}
+
+// findEndpoint returns the HTTP endpoint of id, if it has a retry policy and
+// the request is authenticated by its relay secret.
+func (h *RelayHandler) findEndpoint(r *http.Request, id platform.ID) (*endpoint.HTTP, error) {
+	ctx := r.Context()
+	unauthorized := &errors.Error{
+		Code: errors.EUnauthorized,
+		Msg:  "unauthorized access",
+	}
+
+	found, err := h.endpoints.FindNotificationEndpointByID(ctx, id)
+	if errors.ErrorCode(err) == errors.ENotFound {
+		return nil, unauthorized
+	}
+	if err != nil {
+		return nil, err
+	}
+	e, ok := found.(*endpoint.HTTP)
+	if !ok || e.Retry == nil || e.RelaySecret.Key == "" {
+		return nil, unauthorized
+	}
+
+	// the previous relay secret is valid during the grace period of its rotation.
+	ok, err = secret.MatchSecret(ctx, h.secrets, e.GetOrgID(), e.RelaySecret.Key, r.Header.Get(endpoint.RelaySecretHeader))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, unauthorized
+	}
+
+	if e.Status != influxdb.Active {
+		return nil, &errors.Error{
+			Code: errors.EConflict,
+			Msg:  "notification endpoint is inactive",
+		}
+	}
+	return e, nil
+}
@@ -0,0 +1,107 @@
+package delivery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/notification/endpoint"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type fakeEndpointService struct {
+	influxdb.NotificationEndpointService
+	endpoints map[platform.ID]influxdb.NotificationEndpoint
+}
+
+func (s *fakeEndpointService) FindNotificationEndpointByID(ctx context.Context, id platform.ID) (influxdb.NotificationEndpoint, error) {
+	e, ok := s.endpoints[id]
+	if !ok {
+		return nil, &errors.Error{Code: errors.ENotFound, Msg: "notification endpoint not found"}
+	}
+	return e, nil
+}
+
+type fakeSecretService struct {
+	influxdb.SecretService
+	secrets map[string]string
+}
+
+func (s *fakeSecretService) LoadSecret(ctx context.Context, orgID platform.ID, k string) (string, error) {
+	v, ok := s.secrets[k]
+	if !ok {
+		return "", &errors.Error{Code: errors.ENotFound, Msg: "secret not found"}
+	}
+	return v, nil
+}
+
+func TestRelayHandler(t *testing.T) {
+	var delivered []*http.Request
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered = append(delivered, r)
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer target.Close()
+
+	id, downID, orgID := platform.ID(1), platform.ID(2), platform.ID(3)
+	newEndpoint := func(id *platform.ID, path string) *endpoint.HTTP {
+		return &endpoint.HTTP{
+			Base: endpoint.Base{
+				ID:     id,
+				Name:   "hook",
+				OrgID:  &orgID,
+				Status: influxdb.Active,
+			},
+			URL:         target.URL + path,
+			Method:      http.MethodPost,
+			AuthMethod:  "bearer",
+			Retry:       &endpoint.HTTPRetry{MaxAttempts: 3},
+			RelaySecret: influxdb.SecretField{Key: id.String() + "-relay-secret"},
+		}
+	}
+	endpoints := &fakeEndpointService{endpoints: map[platform.ID]influxdb.NotificationEndpoint{
+		id:     newEndpoint(&id, "/up"),
+		downID: newEndpoint(&downID, "/down"),
+	}}
+	secrets := &fakeSecretService{secrets: map[string]string{
+		id.String() + "-relay-secret":     "s3cr3t",
+		downID.String() + "-relay-secret": "s3cr3t",
+	}}
+	rec := &fakeRecorder{}
+	d := NewDeliverer(zaptest.NewLogger(t), rec)
+	h := NewRelayHandler(zaptest.NewLogger(t), endpoints, secrets, d)
+
+	post := func(id platform.ID, secret string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/"+id.String(), strings.NewReader(`{"_notification_rule_id": "0000000000000004", "_level": "crit"}`))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Authorization", "Bearer hook-token")
+		r.Header.Set(endpoint.RelaySecretHeader, secret)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := post(id, "wrong")
+	require.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+	require.Empty(t, delivered)
+
+	w = post(id, "s3cr3t")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Len(t, delivered, 1)
+	require.Equal(t, "Bearer hook-token", delivered[0].Header.Get("Authorization"))
+	require.Empty(t, delivered[0].Header.Get(endpoint.RelaySecretHeader))
+	require.Equal(t, platform.ID(4), rec.attempts[0].RuleID)
+
+	w = post(downID, "s3cr3t")
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	require.Equal(t, 1, d.Queued())
+	require.Equal(t, http.StatusBadGateway, rec.attempts[1].StatusCode)
+}
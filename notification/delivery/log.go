@@ -0,0 +1,119 @@
+// Package delivery delivers the notifications of HTTP endpoints with a retry
+// policy. The notification rules of such endpoints post their notifications
+// to influxd, which logs every delivery attempt in the monitoring bucket of
+// the organization, and retries the failed deliveries with backoff.
+//
+// The attempts are queried from the deliveries measurement of the bucket:
+//
+//	from(bucket: "_monitoring")
+//	    |> range(start: -1d)
+//	    |> filter(fn: (r) => r._measurement == "deliveries")
+package delivery
+
+import (
+	"context"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage"
+)
+
+// names of the measurement, tags and fields of delivery attempts.
+const (
+	deliveryMeasurement = "deliveries"
+	endpointIDTag       = "_notification_endpoint_id"
+	ruleIDTag           = "_notification_rule_id"
+	statusTag           = "_status"
+	attemptField        = "_attempt"
+	statusCodeField     = "_status_code"
+	responseField       = "_response"
+	errorField          = "_error"
+	latencyField        = "_latency_ms"
+)
+
+// statuses of delivery attempts.
+const (
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+// maxResponseSize is how much of the response of an endpoint is logged.
+const maxResponseSize = 1024
+
+// Attempt is an attempt at delivering a notification.
+type Attempt struct {
+	EndpointID platform.ID
+	OrgID      platform.ID
+	// RuleID is the notification rule which sent the notification, if it is known.
+	RuleID platform.ID
+	// Attempt counts the attempts at delivering the notification, from 1.
+	Attempt    int
+	StatusCode int
+	// Response is the start of the body of the response of the endpoint.
+	Response string
+	// Err is why the request failed, when there is no response.
+	Err     string
+	Latency time.Duration
+	Time    time.Time
+}
+
+// Delivered returns true when the endpoint accepted the notification.
+func (a Attempt) Delivered() bool {
+	return a.Err == "" && a.StatusCode >= 200 && a.StatusCode < 300
+}
+
+// Status returns the status of the attempt.
+func (a Attempt) Status() string {
+	if a.Delivered() {
+		return StatusDelivered
+	}
+	return StatusFailed
+}
+
+// AttemptLog writes delivery attempts to the monitoring bucket of their organization.
+type AttemptLog struct {
+	buckets influxdb.BucketService
+	pw      storage.PointsWriter
+}
+
+// NewAttemptLog constructs a new attempt log.
+func NewAttemptLog(buckets influxdb.BucketService, pw storage.PointsWriter) *AttemptLog {
+	return &AttemptLog{
+		buckets: buckets,
+		pw:      pw,
+	}
+}
+
+// RecordAttempt writes an attempt.
+func (l *AttemptLog) RecordAttempt(ctx context.Context, a Attempt) error {
+	b, err := l.buckets.FindBucketByName(ctx, a.OrgID, influxdb.MonitoringSystemBucketName)
+	if err != nil {
+		return err
+	}
+
+	tags := map[string]string{
+		endpointIDTag: a.EndpointID.String(),
+		statusTag:     a.Status(),
+	}
+	if a.RuleID.Valid() {
+		tags[ruleIDTag] = a.RuleID.String()
+	}
+	fields := map[string]interface{}{
+		attemptField:    int64(a.Attempt),
+		statusCodeField: int64(a.StatusCode),
+		latencyField:    a.Latency.Milliseconds(),
+	}
+	if a.Response != "" {
+		fields[responseField] = a.Response
+	}
+	if a.Err != "" {
+		fields[errorField] = a.Err
+	}
+	p, err := models.NewPoint(deliveryMeasurement, models.NewTags(tags), fields, a.Time)
+	if err != nil {
+		return err
+	}
+	return l.pw.WritePoints(ctx, a.OrgID, b.ID, models.Points{p})
+}
@@ -0,0 +1,245 @@
+package endpoint
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+var _ influxdb.NotificationEndpoint = &AWS{}
+
+// services an AWS endpoint publishes to.
+const (
+	AWSServiceSNS = "sns"
+	AWSServiceSQS = "sqs"
+)
+
+const (
+	// DefaultRelayURL is the URL of the influxd instance the notification
+	// rules of an endpoint relayed by influxd post to, when the endpoint
+	// does not set one.
+	DefaultRelayURL = "http://localhost:8086"
+	// DefaultAWSRelayURL is the URL of the influxd instance the notification
+	// rules of an AWS endpoint post to when the endpoint does not set one.
+	DefaultAWSRelayURL = DefaultRelayURL
+	// AWSPublishPath is the path of the influxd route that signs the messages of
+	// the notification rules of AWS endpoints and publishes them. Flux can not
+	// sign requests with AWS credentials itself.
+	AWSPublishPath = "/api/v2/notificationEndpoints/aws"
+
+	// MaxAWSMessageAttributes is the number of message attributes SNS and SQS
+	// accept per message.
+	MaxAWSMessageAttributes = 10
+)
+
+const (
+	awsAccessKeyIDSuffix     = "-access-key-id"
+	awsSecretAccessKeySuffix = "-secret-access-key"
+	awsSessionTokenSuffix    = "-session-token"
+	awsRelaySecretSuffix     = "-relay-secret"
+
+	// relaySecretLen is the number of random bytes of a generated relay secret.
+	relaySecretLen = 32
+)
+
+var awsAttributeName = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,256}$`)
+
+// AWS is the notification endpoint config of amazon SNS topics and SQS queues.
+type AWS struct {
+	Base
+	// Service is either sns or sqs.
+	Service string `json:"service"`
+	Region  string `json:"region"`
+	// TopicARN is the SNS topic messages are published to.
+	TopicARN string `json:"topicARN,omitempty"`
+	// QueueURL is the SQS queue messages are sent to.
+	QueueURL string `json:"queueURL,omitempty"`
+	// AccessKeyID and SecretAccessKey are the credentials of the IAM user or
+	// role the messages are published as. SessionToken is only set for
+	// temporary credentials.
+	AccessKeyID     influxdb.SecretField `json:"accessKeyID"`
+	SecretAccessKey influxdb.SecretField `json:"secretAccessKey"`
+	SessionToken    influxdb.SecretField `json:"sessionToken"`
+	// RelaySecret authenticates the messages the notification rules post to
+	// influxd, so the AWS credentials never leave influxd. It is generated
+	// when the endpoint is created, unless one is given.
+	RelaySecret influxdb.SecretField `json:"relaySecret"`
+	// MessageAttributes are added to every message published to the endpoint.
+	MessageAttributes map[string]string `json:"messageAttributes,omitempty"`
+	// RelayURL is the URL of the influxd instance running the tasks of the
+	// notification rules, which defaults to DefaultAWSRelayURL.
+	RelayURL string `json:"relayURL,omitempty"`
+}
+
+// PublishURL returns the URL the notification rules of the endpoint post their messages to.
+func (s AWS) PublishURL() string {
+	relay := s.RelayURL
+	if relay == "" {
+		relay = DefaultAWSRelayURL
+	}
+	return strings.TrimSuffix(relay, "/") + AWSPublishPath + "/" + s.idStr()
+}
+
+// BackfillSecretKeys fill back the secret field key during the unmarshalling
+// if value of that secret field is not nil.
+func (s *AWS) BackfillSecretKeys() {
+	if s.AccessKeyID.Key == "" && s.AccessKeyID.Value != nil {
+		s.AccessKeyID.Key = s.idStr() + awsAccessKeyIDSuffix
+	}
+	if s.SecretAccessKey.Key == "" && s.SecretAccessKey.Value != nil {
+		s.SecretAccessKey.Key = s.idStr() + awsSecretAccessKeySuffix
+	}
+	if s.SessionToken.Key == "" && s.SessionToken.Value != nil {
+		s.SessionToken.Key = s.idStr() + awsSessionTokenSuffix
+	}
+	if s.RelaySecret.Key == "" && s.RelaySecret.Value != nil {
+		s.RelaySecret.Key = s.idStr() + awsRelaySecretSuffix
+	}
+}
+
+// GenerateRelaySecret sets a new random relay secret on the endpoint, if it
+// has none.
+func (s *AWS) GenerateRelaySecret() error {
+	if s.RelaySecret.Key != "" || s.RelaySecret.Value != nil {
+		return nil
+	}
+	b := make([]byte, relaySecretLen)
+	if _, err := rand.Read(b); err != nil {
+		return &errors.Error{
+			Code: errors.EInternal,
+			Msg:  "failed to generate aws relay secret",
+			Err:  err,
+		}
+	}
+	v := base64.RawURLEncoding.EncodeToString(b)
+	s.RelaySecret.Value = &v
+	return nil
+}
+
+// SecretFields return available secret fields.
+func (s AWS) SecretFields() []influxdb.SecretField {
+	arr := []influxdb.SecretField{}
+	if s.AccessKeyID.Key != "" {
+		arr = append(arr, s.AccessKeyID)
+	}
+	if s.SecretAccessKey.Key != "" {
+		arr = append(arr, s.SecretAccessKey)
+	}
+	if s.SessionToken.Key != "" {
+		arr = append(arr, s.SessionToken)
+	}
+	if s.RelaySecret.Key != "" {
+		arr = append(arr, s.RelaySecret)
+	}
+	return arr
+}
+
+// Valid returns error if some configuration is invalid
+func (s AWS) Valid() error {
+	if err := s.Base.valid(); err != nil {
+		return err
+	}
+	if s.Region == "" {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "empty aws region",
+		}
+	}
+	switch s.Service {
+	case AWSServiceSNS:
+		if !strings.HasPrefix(s.TopicARN, "arn:") {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "aws sns endpoint requires a topic ARN",
+			}
+		}
+	case AWSServiceSQS:
+		if s.QueueURL == "" {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "aws sqs endpoint requires a queue URL",
+			}
+		}
+		if _, err := url.Parse(s.QueueURL); err != nil {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("aws sqs queue URL is invalid: %s", err.Error()),
+			}
+		}
+	default:
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("invalid aws service %q, must be %s or %s", s.Service, AWSServiceSNS, AWSServiceSQS),
+		}
+	}
+	if s.AccessKeyID.Key == "" || s.SecretAccessKey.Key == "" {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "aws endpoint requires an access key ID and a secret access key",
+		}
+	}
+	if s.RelaySecret.Key == "" {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "aws endpoint requires a relay secret",
+		}
+	}
+	if len(s.MessageAttributes) > MaxAWSMessageAttributes {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("aws endpoint has more than %d message attributes", MaxAWSMessageAttributes),
+		}
+	}
+	for name := range s.MessageAttributes {
+		if err := ValidAWSAttributeName(name); err != nil {
+			return err
+		}
+	}
+	if s.RelayURL != "" {
+		if _, err := url.Parse(s.RelayURL); err != nil {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("aws relay URL is invalid: %s", err.Error()),
+			}
+		}
+	}
+	return nil
+}
+
+// ValidAWSAttributeName returns an error if name can not be the name of an SNS or SQS message attribute.
+func ValidAWSAttributeName(name string) error {
+	lower := strings.ToLower(name)
+	if !awsAttributeName.MatchString(name) || strings.HasPrefix(lower, "aws.") || strings.HasPrefix(lower, "amazon.") ||
+		strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") || strings.Contains(name, "..") {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("invalid aws message attribute name %q", name),
+		}
+	}
+	return nil
+}
+
+// MarshalJSON implement json.Marshaler interface.
+func (s AWS) MarshalJSON() ([]byte, error) {
+	type awsAlias AWS
+	return json.Marshal(
+		struct {
+			awsAlias
+			Type string `json:"type"`
+		}{
+			awsAlias: awsAlias(s),
+			Type:     s.Type(),
+		})
+}
+
+// Type returns the type.
+func (s AWS) Type() string {
+	return AWSType
+}
@@ -0,0 +1,151 @@
+package aws
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/notification/endpoint"
+	"github.com/influxdata/influxdb/v2/secret"
+	"go.uber.org/zap"
+)
+
+// SecretHeader is the header the tasks of the notification rules authenticate
+// their messages with. It holds the relay secret of the endpoint, which the
+// tasks read from the secrets of their organization.
+const SecretHeader = endpoint.RelaySecretHeader
+
+// RelayHandler publishes the messages the tasks of notification rules post to
+// the AWS endpoints of the rules. Its route is not authenticated with a token:
+// requests are authenticated by the relay secret of the endpoint instead.
+type RelayHandler struct {
+	chi.Router
+
+	api *kithttp.API
+	log *zap.Logger
+
+	endpoints influxdb.NotificationEndpointService
+	secrets   influxdb.SecretService
+	publisher *Publisher
+}
+
+// NewRelayHandler returns a new instance of RelayHandler. The endpoints and
+// secrets services must not be authorized, the requests carry no authorizer.
+func NewRelayHandler(log *zap.Logger, endpoints influxdb.NotificationEndpointService, secrets influxdb.SecretService, publisher *Publisher) *RelayHandler {
+	h := &RelayHandler{
+		api:       kithttp.NewAPI(kithttp.WithLog(log)),
+		log:       log,
+		endpoints: endpoints,
+		secrets:   secrets,
+		publisher: publisher,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+	r.Post("/{id}", h.handlePublish)
+	h.Router = r
+	return h
+}
+
+// Prefix returns the mounting prefix for the handler.
+func (h *RelayHandler) Prefix() string {
+	return endpoint.AWSPublishPath
+}
+
+// handlePublish publishes a message to the AWS endpoint in the path.
+func (h *RelayHandler) handlePublish(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	e, creds, err := h.findEndpoint(r, *id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var m Message
+	if err := h.api.DecodeJSON(r.Body, &m); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if m.Message == "" {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "empty aws message",
+		})
+		return
+	}
+
+	if err := h.publisher.Publish(ctx, e, creds, m); err != nil {
+		h.log.Info("Failed to publish notification to aws", zap.String("endpointID", id.String()), zap.Error(err))
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Notification published to aws", zap.String("endpointID", id.String()), zap.String("service", e.Service))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// findEndpoint returns the AWS endpoint of id and its credentials, if the
+// request is authenticated by the relay secret of the endpoint.
+func (h *RelayHandler) findEndpoint(r *http.Request, id platform.ID) (*endpoint.AWS, Credentials, error) {
+	ctx := r.Context()
+	unauthorized := &errors.Error{
+		Code: errors.EUnauthorized,
+		Msg:  "unauthorized access",
+	}
+
+	found, err := h.endpoints.FindNotificationEndpointByID(ctx, id)
+	if errors.ErrorCode(err) == errors.ENotFound {
+		return nil, Credentials{}, unauthorized
+	}
+	if err != nil {
+		return nil, Credentials{}, err
+	}
+	e, ok := found.(*endpoint.AWS)
+	if !ok || e.RelaySecret.Key == "" {
+		return nil, Credentials{}, unauthorized
+	}
+
+	// the previous relay secret is valid during the grace period of its rotation.
+	orgID := e.GetOrgID()
+	ok, err = secret.MatchSecret(ctx, h.secrets, orgID, e.RelaySecret.Key, r.Header.Get(SecretHeader))
+	if err != nil {
+		return nil, Credentials{}, err
+	}
+	if !ok {
+		return nil, Credentials{}, unauthorized
+	}
+
+	if e.Status != influxdb.Active {
+		return nil, Credentials{}, &errors.Error{
+			Code: errors.EConflict,
+			Msg:  "notification endpoint is inactive",
+		}
+	}
+	var creds Credentials
+	if creds.AccessKeyID, err = h.secrets.LoadSecret(ctx, orgID, e.AccessKeyID.Key); err != nil {
+		return nil, Credentials{}, err
+	}
+	if creds.SecretAccessKey, err = h.secrets.LoadSecret(ctx, orgID, e.SecretAccessKey.Key); err != nil {
+		return nil, Credentials{}, err
+	}
+	if e.SessionToken.Key != "" {
+		if creds.SessionToken, err = h.secrets.LoadSecret(ctx, orgID, e.SessionToken.Key); err != nil {
+			return nil, Credentials{}, err
+		}
+	}
+	return e, creds, nil
+}
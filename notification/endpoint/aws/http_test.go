@@ -0,0 +1,165 @@
+package aws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/notification/endpoint"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type fakeEndpointService struct {
+	influxdb.NotificationEndpointService
+	endpoints map[platform.ID]influxdb.NotificationEndpoint
+}
+
+func (s *fakeEndpointService) FindNotificationEndpointByID(ctx context.Context, id platform.ID) (influxdb.NotificationEndpoint, error) {
+	e, ok := s.endpoints[id]
+	if !ok {
+		return nil, &errors.Error{Code: errors.ENotFound, Msg: "notification endpoint not found"}
+	}
+	return e, nil
+}
+
+type fakeSecretService struct {
+	influxdb.SecretService
+	secrets map[string]string
+}
+
+func (s *fakeSecretService) LoadSecret(ctx context.Context, orgID platform.ID, k string) (string, error) {
+	v, ok := s.secrets[k]
+	if !ok {
+		return "", &errors.Error{Code: errors.ENotFound, Msg: "secret not found"}
+	}
+	return v, nil
+}
+
+type fakeTime time.Time
+
+func (t fakeTime) Now() time.Time { return time.Time(t) }
+
+func TestRelayHandler(t *testing.T) {
+	var published []*http.Request
+	var forms []url.Values
+	aws := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		published = append(published, r)
+		forms = append(forms, r.PostForm)
+		if r.PostForm.Get("TopicArn") == "arn:aws:sns:us-east-1:123456789012:missing" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`<ErrorResponse><Error><Type>Sender</Type><Code>NotFound</Code><Message>Topic does not exist</Message></Error></ErrorResponse>`))
+		}
+	}))
+	defer aws.Close()
+
+	orgID := platform.ID(1)
+	snsID, sqsID, httpID := platform.ID(10), platform.ID(11), platform.ID(12)
+	endpoints := &fakeEndpointService{endpoints: map[platform.ID]influxdb.NotificationEndpoint{
+		snsID: &endpoint.AWS{
+			Base:              endpoint.Base{ID: &snsID, OrgID: &orgID, Name: "sns", Status: influxdb.Active},
+			Service:           endpoint.AWSServiceSNS,
+			Region:            "us-east-1",
+			TopicARN:          "arn:aws:sns:us-east-1:123456789012:alerts",
+			AccessKeyID:       influxdb.SecretField{Key: "sns-access-key-id"},
+			SecretAccessKey:   influxdb.SecretField{Key: "sns-secret-access-key"},
+			SessionToken:      influxdb.SecretField{Key: "sns-session-token"},
+			RelaySecret:       influxdb.SecretField{Key: "sns-relay-secret"},
+			MessageAttributes: map[string]string{"source": "influxdb", "env": "prod"},
+		},
+		sqsID: &endpoint.AWS{
+			Base:            endpoint.Base{ID: &sqsID, OrgID: &orgID, Name: "sqs", Status: influxdb.Active},
+			Service:         endpoint.AWSServiceSQS,
+			Region:          "eu-west-1",
+			QueueURL:        aws.URL + "/123456789012/alerts",
+			AccessKeyID:     influxdb.SecretField{Key: "sqs-access-key-id"},
+			SecretAccessKey: influxdb.SecretField{Key: "sqs-secret-access-key"},
+			RelaySecret:     influxdb.SecretField{Key: "sqs-relay-secret"},
+		},
+		httpID: &endpoint.HTTP{
+			Base: endpoint.Base{ID: &httpID, OrgID: &orgID, Name: "http", Status: influxdb.Active},
+		},
+	}}
+	secrets := &fakeSecretService{secrets: map[string]string{
+		"sns-access-key-id":     "AKIDSNS",
+		"sns-secret-access-key": "sns-secret",
+		"sns-session-token":     "sns-token",
+		"sqs-access-key-id":     "AKIDSQS",
+		"sqs-secret-access-key": "sqs-secret",
+		"sns-relay-secret":      "sns-relay",
+		"sqs-relay-secret":      "sqs-relay",
+	}}
+
+	publisher := NewPublisher()
+	publisher.TimeGenerator = fakeTime(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
+	publisher.snsURL = func(region string) string {
+		require.Equal(t, "us-east-1", region)
+		return aws.URL + "/"
+	}
+	srv := httptest.NewServer(NewRelayHandler(zaptest.NewLogger(t), endpoints, secrets, publisher))
+	defer srv.Close()
+
+	post := func(id platform.ID, secret, body string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/"+id.String(), strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(SecretHeader, secret)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	resp := post(snsID, "sns-relay", `{"message":"cpu is crit","subject":"cpu","attributes":{"level":"crit","env":"staging"}}`)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Len(t, forms, 1)
+	require.Equal(t, "Publish", forms[0].Get("Action"))
+	require.Equal(t, "arn:aws:sns:us-east-1:123456789012:alerts", forms[0].Get("TopicArn"))
+	require.Equal(t, "cpu is crit", forms[0].Get("Message"))
+	require.Equal(t, "cpu", forms[0].Get("Subject"))
+	require.Equal(t, "env", forms[0].Get("MessageAttributes.entry.1.Name"))
+	require.Equal(t, "staging", forms[0].Get("MessageAttributes.entry.1.Value.StringValue"), "message attributes override the endpoint's")
+	require.Equal(t, "level", forms[0].Get("MessageAttributes.entry.2.Name"))
+	require.Equal(t, "source", forms[0].Get("MessageAttributes.entry.3.Name"))
+	require.True(t, strings.HasPrefix(published[0].Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDSNS/20210101/us-east-1/sns/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-security-token, Signature="))
+	require.Equal(t, "sns-token", published[0].Header.Get("X-Amz-Security-Token"))
+
+	resp = post(sqsID, "sqs-relay", `{"message":"disk is warn"}`)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Len(t, forms, 2)
+	require.Equal(t, "/123456789012/alerts", published[1].URL.Path)
+	require.Equal(t, "SendMessage", forms[1].Get("Action"))
+	require.Equal(t, "disk is warn", forms[1].Get("MessageBody"))
+	require.Contains(t, published[1].Header.Get("Authorization"), "Credential=AKIDSQS/20210101/eu-west-1/sqs/aws4_request")
+
+	for _, c := range []struct {
+		id     platform.ID
+		secret string
+	}{
+		{id: snsID, secret: "sqs-relay"},
+		{id: snsID, secret: "sns-secret"},
+		{id: snsID, secret: ""},
+		{id: httpID, secret: ""},
+		{id: platform.ID(99), secret: "sns-relay"},
+	} {
+		resp := post(c.id, c.secret, `{"message":"blah"}`)
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode, c.id.String())
+	}
+	require.Len(t, forms, 2, "unauthorized messages are not published")
+
+	endpoints.endpoints[snsID].(*endpoint.AWS).TopicARN = "arn:aws:sns:us-east-1:123456789012:missing"
+	resp = post(snsID, "sns-relay", `{"message":"blah"}`)
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	endpoints.endpoints[snsID].(*endpoint.AWS).Status = influxdb.Inactive
+	resp = post(snsID, "sns-relay", `{"message":"blah"}`)
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+}
@@ -0,0 +1,188 @@
+// Package aws publishes the messages of notification rules to amazon SNS topics
+// and SQS queues. Flux can not sign requests with AWS credentials, so the tasks
+// of the rules post their messages to influxd, which signs and publishes them.
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/notification/endpoint"
+	"github.com/influxdata/influxdb/v2/pkg/sigv4"
+)
+
+const (
+	snsAPIVersion = "2010-03-31"
+	sqsAPIVersion = "2012-11-05"
+
+	// defaultPublishTimeout bounds the time a message takes to be published.
+	defaultPublishTimeout = 30 * time.Second
+)
+
+// Credentials are the AWS credentials messages are published with.
+type Credentials = sigv4.Credentials
+
+// Message is a message published to an AWS endpoint.
+type Message struct {
+	Message string `json:"message"`
+	// Subject is the subject of the emails SNS delivers the message with. It is not used by SQS.
+	Subject string `json:"subject,omitempty"`
+	// Attributes are added to the message attributes of the endpoint, overriding
+	// the ones with the same names.
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// Publisher publishes messages to SNS topics and SQS queues.
+type Publisher struct {
+	Client        *http.Client
+	TimeGenerator influxdb.TimeGenerator
+
+	// snsURL returns the URL of the SNS API of a region. It is replaced in tests.
+	snsURL func(region string) string
+}
+
+// NewPublisher returns a publisher sending its requests with a default http client.
+func NewPublisher() *Publisher {
+	return &Publisher{
+		Client:        &http.Client{Timeout: defaultPublishTimeout},
+		TimeGenerator: influxdb.RealTimeGenerator{},
+		snsURL: func(region string) string {
+			return fmt.Sprintf("https://sns.%s.amazonaws.com/", region)
+		},
+	}
+}
+
+// Publish publishes m to the SNS topic or the SQS queue of e, signed with creds.
+func (p *Publisher) Publish(ctx context.Context, e *endpoint.AWS, creds Credentials, m Message) error {
+	attrs, err := messageAttributes(e, m)
+	if err != nil {
+		return err
+	}
+
+	form := url.Values{}
+	var target string
+	switch e.Service {
+	case endpoint.AWSServiceSNS:
+		target = p.snsURL(e.Region)
+		form.Set("Action", "Publish")
+		form.Set("Version", snsAPIVersion)
+		form.Set("TopicArn", e.TopicARN)
+		form.Set("Message", m.Message)
+		if m.Subject != "" {
+			form.Set("Subject", m.Subject)
+		}
+		for i, name := range attrs.names {
+			prefix := "MessageAttributes.entry." + strconv.Itoa(i+1) + "."
+			form.Set(prefix+"Name", name)
+			form.Set(prefix+"Value.DataType", "String")
+			form.Set(prefix+"Value.StringValue", attrs.values[name])
+		}
+	case endpoint.AWSServiceSQS:
+		target = e.QueueURL
+		form.Set("Action", "SendMessage")
+		form.Set("Version", sqsAPIVersion)
+		form.Set("MessageBody", m.Message)
+		for i, name := range attrs.names {
+			prefix := "MessageAttribute." + strconv.Itoa(i+1) + "."
+			form.Set(prefix+"Name", name)
+			form.Set(prefix+"Value.DataType", "String")
+			form.Set(prefix+"Value.StringValue", attrs.values[name])
+		}
+	default:
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("invalid aws service %q", e.Service),
+		}
+	}
+
+	body := []byte(form.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "invalid aws endpoint URL",
+			Err:  err,
+		}
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	sigv4.Sign(req, body, creds, e.Region, e.Service, p.TimeGenerator.Now())
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.EUnavailable,
+			Msg:  fmt.Sprintf("failed to publish to aws %s", e.Service),
+			Err:  err,
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return &errors.Error{
+			Code: errors.EUnavailable,
+			Msg:  fmt.Sprintf("aws %s responded with %d: %s", e.Service, resp.StatusCode, responseError(resp.Body)),
+		}
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+type attributes struct {
+	names  []string
+	values map[string]string
+}
+
+// messageAttributes merges the message attributes of m into the ones of e.
+func messageAttributes(e *endpoint.AWS, m Message) (attributes, error) {
+	attrs := attributes{values: make(map[string]string, len(e.MessageAttributes)+len(m.Attributes))}
+	for k, v := range e.MessageAttributes {
+		attrs.values[k] = v
+	}
+	for k, v := range m.Attributes {
+		if err := endpoint.ValidAWSAttributeName(k); err != nil {
+			return attributes{}, err
+		}
+		attrs.values[k] = v
+	}
+	if len(attrs.values) > endpoint.MaxAWSMessageAttributes {
+		return attributes{}, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("aws messages can not have more than %d message attributes", endpoint.MaxAWSMessageAttributes),
+		}
+	}
+	for k, v := range attrs.values {
+		// empty string values are rejected by both services.
+		if v == "" {
+			delete(attrs.values, k)
+			continue
+		}
+		attrs.names = append(attrs.names, k)
+	}
+	sort.Strings(attrs.names)
+	return attrs, nil
+}
+
+// responseError returns the error code and the message of an error response
+// of the SNS or SQS query API.
+func responseError(r io.Reader) string {
+	var res struct {
+		Error struct {
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		} `xml:"Error"`
+	}
+	if err := xml.NewDecoder(io.LimitReader(r, 64*1024)).Decode(&res); err != nil || res.Error.Code == "" {
+		return "unknown error"
+	}
+	return res.Error.Code + ": " + res.Error.Message
+}
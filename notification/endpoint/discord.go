@@ -0,0 +1,87 @@
+package endpoint
+
+import (
+	"encoding/json"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+var _ influxdb.NotificationEndpoint = &Discord{}
+
+const discordWebhookTokenSuffix = "-webhook-token"
+
+// Discord is the notification endpoint config of discord.
+type Discord struct {
+	Base
+	// WebhookID is the ID of the discord webhook, the part of the webhook URL
+	// before its token, see https://discord.com/developers/docs/resources/webhook
+	WebhookID string `json:"webhookID"`
+	// WebhookToken is the token of the discord webhook.
+	WebhookToken influxdb.SecretField `json:"webhookToken"`
+	// Username overrides the default username of the webhook.
+	Username string `json:"username"`
+	// AvatarURL overrides the default avatar of the webhook.
+	AvatarURL string `json:"avatarURL,omitempty"`
+}
+
+// BackfillSecretKeys fill back the secret field key during the unmarshalling
+// if value of that secret field is not nil.
+func (s *Discord) BackfillSecretKeys() {
+	if s.WebhookToken.Key == "" && s.WebhookToken.Value != nil {
+		s.WebhookToken.Key = s.idStr() + discordWebhookTokenSuffix
+	}
+}
+
+// SecretFields return available secret fields.
+func (s Discord) SecretFields() []influxdb.SecretField {
+	arr := []influxdb.SecretField{}
+	if s.WebhookToken.Key != "" {
+		arr = append(arr, s.WebhookToken)
+	}
+	return arr
+}
+
+// Valid returns error if some configuration is invalid
+func (s Discord) Valid() error {
+	if err := s.Base.valid(); err != nil {
+		return err
+	}
+	if s.WebhookID == "" {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "empty discord webhook ID",
+		}
+	}
+	if s.WebhookToken.Key == "" {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "empty discord webhook token",
+		}
+	}
+	if s.Username == "" {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "empty discord username",
+		}
+	}
+	return nil
+}
+
+// MarshalJSON implement json.Marshaler interface.
+func (s Discord) MarshalJSON() ([]byte, error) {
+	type discordAlias Discord
+	return json.Marshal(
+		struct {
+			discordAlias
+			Type string `json:"type"`
+		}{
+			discordAlias: discordAlias(s),
+			Type:         s.Type(),
+		})
+}
+
+// Type returns the type.
+func (s Discord) Type() string {
+	return DiscordType
+}
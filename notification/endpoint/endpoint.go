@@ -15,6 +15,11 @@ const (
 	PagerDutyType = "pagerduty"
 	HTTPType      = "http"
 	TelegramType  = "telegram"
+	DiscordType   = "discord"
+	TeamsType     = "teams"
+	OpsGenieType  = "opsgenie"
+	VictorOpsType = "victorops"
+	AWSType       = "aws"
 )
 
 var typeToEndpoint = map[string]func() influxdb.NotificationEndpoint{
@@ -22,6 +27,11 @@ var typeToEndpoint = map[string]func() influxdb.NotificationEndpoint{
 	PagerDutyType: func() influxdb.NotificationEndpoint { return &PagerDuty{} },
 	HTTPType:      func() influxdb.NotificationEndpoint { return &HTTP{} },
 	TelegramType:  func() influxdb.NotificationEndpoint { return &Telegram{} },
+	DiscordType:   func() influxdb.NotificationEndpoint { return &Discord{} },
+	TeamsType:     func() influxdb.NotificationEndpoint { return &Teams{} },
+	OpsGenieType:  func() influxdb.NotificationEndpoint { return &OpsGenie{} },
+	VictorOpsType: func() influxdb.NotificationEndpoint { return &VictorOps{} },
+	AWSType:       func() influxdb.NotificationEndpoint { return &AWS{} },
 }
 
 // UnmarshalJSON will convert the bytes to notification endpoint.
@@ -167,6 +167,35 @@ func TestValidEndpoint(t *testing.T) {
 				Msg:  "invalid http username/password for basic auth",
 			},
 		},
+		{
+			name: "http retry without relay secret",
+			src: &endpoint.HTTP{
+				Base:       goodBase,
+				URL:        "localhost",
+				Method:     http.MethodPost,
+				AuthMethod: "none",
+				Retry:      &endpoint.HTTPRetry{MaxAttempts: 3},
+			},
+			err: &errors2.Error{
+				Code: errors2.EInvalid,
+				Msg:  "http endpoint with a retry policy requires a relay secret",
+			},
+		},
+		{
+			name: "http retry with too many attempts",
+			src: &endpoint.HTTP{
+				Base:        goodBase,
+				URL:         "localhost",
+				Method:      http.MethodPost,
+				AuthMethod:  "none",
+				Retry:       &endpoint.HTTPRetry{MaxAttempts: 20},
+				RelaySecret: influxdb.SecretField{Key: "relay"},
+			},
+			err: &errors2.Error{
+				Code: errors2.EInvalid,
+				Msg:  "http endpoint retry maxAttempts must be between 1 and 10",
+			},
+		},
 		{
 			name: "empty telegram token",
 			src: &endpoint.Telegram{
@@ -197,6 +226,173 @@ func TestValidEndpoint(t *testing.T) {
 			},
 			err: nil,
 		},
+		{
+			name: "empty discord webhook ID",
+			src: &endpoint.Discord{
+				Base:         goodBase,
+				WebhookToken: influxdb.SecretField{Key: id1.String() + "-webhook-token"},
+				Username:     "influxdb",
+			},
+			err: &errors2.Error{
+				Code: errors2.EInvalid,
+				Msg:  "empty discord webhook ID",
+			},
+		},
+		{
+			name: "empty discord webhook token",
+			src: &endpoint.Discord{
+				Base:      goodBase,
+				WebhookID: "123456789",
+				Username:  "influxdb",
+			},
+			err: &errors2.Error{
+				Code: errors2.EInvalid,
+				Msg:  "empty discord webhook token",
+			},
+		},
+		{
+			name: "valid discord",
+			src: &endpoint.Discord{
+				Base:         goodBase,
+				WebhookID:    "123456789",
+				WebhookToken: influxdb.SecretField{Key: id1.String() + "-webhook-token"},
+				Username:     "influxdb",
+			},
+			err: nil,
+		},
+		{
+			name: "empty teams url",
+			src: &endpoint.Teams{
+				Base: goodBase,
+			},
+			err: &errors2.Error{
+				Code: errors2.EInvalid,
+				Msg:  "empty teams webhook URL",
+			},
+		},
+		{
+			name: "empty opsgenie api key",
+			src: &endpoint.OpsGenie{
+				Base: goodBase,
+			},
+			err: &errors2.Error{
+				Code: errors2.EInvalid,
+				Msg:  "empty opsgenie API key",
+			},
+		},
+		{
+			name: "invalid opsgenie url",
+			src: &endpoint.OpsGenie{
+				Base:   goodBase,
+				URL:    "://example.com",
+				APIKey: influxdb.SecretField{Key: id1.String() + "-api-key"},
+			},
+			err: &errors2.Error{
+				Code: errors2.EInvalid,
+				Msg:  `opsgenie endpoint URL is invalid: parse "://example.com": missing protocol scheme`,
+			},
+		},
+		{
+			name: "valid opsgenie",
+			src: &endpoint.OpsGenie{
+				Base:   goodBase,
+				APIKey: influxdb.SecretField{Key: id1.String() + "-api-key"},
+			},
+			err: nil,
+		},
+		{
+			name: "invalid aws service",
+			src: &endpoint.AWS{
+				Base:    goodBase,
+				Service: "sms",
+				Region:  "us-east-1",
+			},
+			err: &errors2.Error{
+				Code: errors2.EInvalid,
+				Msg:  `invalid aws service "sms", must be sns or sqs`,
+			},
+		},
+		{
+			name: "aws sns without topic",
+			src: &endpoint.AWS{
+				Base:    goodBase,
+				Service: endpoint.AWSServiceSNS,
+				Region:  "us-east-1",
+			},
+			err: &errors2.Error{
+				Code: errors2.EInvalid,
+				Msg:  "aws sns endpoint requires a topic ARN",
+			},
+		},
+		{
+			name: "aws without credentials",
+			src: &endpoint.AWS{
+				Base:     goodBase,
+				Service:  endpoint.AWSServiceSQS,
+				Region:   "us-east-1",
+				QueueURL: "https://sqs.us-east-1.amazonaws.com/123456789012/alerts",
+			},
+			err: &errors2.Error{
+				Code: errors2.EInvalid,
+				Msg:  "aws endpoint requires an access key ID and a secret access key",
+			},
+		},
+		{
+			name: "aws without relay secret",
+			src: &endpoint.AWS{
+				Base:            goodBase,
+				Service:         endpoint.AWSServiceSNS,
+				Region:          "us-east-1",
+				TopicARN:        "arn:aws:sns:us-east-1:123456789012:alerts",
+				AccessKeyID:     influxdb.SecretField{Key: id1.String() + "-access-key-id"},
+				SecretAccessKey: influxdb.SecretField{Key: id1.String() + "-secret-access-key"},
+			},
+			err: &errors2.Error{
+				Code: errors2.EInvalid,
+				Msg:  "aws endpoint requires a relay secret",
+			},
+		},
+		{
+			name: "aws reserved attribute name",
+			src: &endpoint.AWS{
+				Base:              goodBase,
+				Service:           endpoint.AWSServiceSNS,
+				Region:            "us-east-1",
+				TopicARN:          "arn:aws:sns:us-east-1:123456789012:alerts",
+				AccessKeyID:       influxdb.SecretField{Key: id1.String() + "-access-key-id"},
+				SecretAccessKey:   influxdb.SecretField{Key: id1.String() + "-secret-access-key"},
+				RelaySecret:       influxdb.SecretField{Key: id1.String() + "-relay-secret"},
+				MessageAttributes: map[string]string{"Amazon.source": "influxdb"},
+			},
+			err: &errors2.Error{
+				Code: errors2.EInvalid,
+				Msg:  `invalid aws message attribute name "Amazon.source"`,
+			},
+		},
+		{
+			name: "valid aws",
+			src: &endpoint.AWS{
+				Base:              goodBase,
+				Service:           endpoint.AWSServiceSNS,
+				Region:            "us-east-1",
+				TopicARN:          "arn:aws:sns:us-east-1:123456789012:alerts",
+				AccessKeyID:       influxdb.SecretField{Key: id1.String() + "-access-key-id"},
+				SecretAccessKey:   influxdb.SecretField{Key: id1.String() + "-secret-access-key"},
+				RelaySecret:       influxdb.SecretField{Key: id1.String() + "-relay-secret"},
+				MessageAttributes: map[string]string{"source": "influxdb"},
+			},
+			err: nil,
+		},
+		{
+			name: "empty victorops url",
+			src: &endpoint.VictorOps{
+				Base: goodBase,
+			},
+			err: &errors2.Error{
+				Code: errors2.EInvalid,
+				Msg:  "empty victorops REST endpoint URL",
+			},
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
@@ -306,6 +502,100 @@ func TestJSON(t *testing.T) {
 				Token: influxdb.SecretField{Key: "token-key-1"},
 			},
 		},
+		{
+			name: "simple Discord",
+			src: &endpoint.Discord{
+				Base: endpoint.Base{
+					ID:     id1,
+					Name:   "nameDiscord",
+					OrgID:  id3,
+					Status: influxdb.Active,
+					CRUDLog: influxdb.CRUDLog{
+						CreatedAt: timeGen1.Now(),
+						UpdatedAt: timeGen2.Now(),
+					},
+				},
+				WebhookID:    "123456789",
+				WebhookToken: influxdb.SecretField{Key: "webhook-token-key"},
+				Username:     "influxdb",
+				AvatarURL:    "http://example.com/avatar.png",
+			},
+		},
+		{
+			name: "simple Teams",
+			src: &endpoint.Teams{
+				Base: endpoint.Base{
+					ID:     id1,
+					Name:   "nameTeams",
+					OrgID:  id3,
+					Status: influxdb.Active,
+					CRUDLog: influxdb.CRUDLog{
+						CreatedAt: timeGen1.Now(),
+						UpdatedAt: timeGen2.Now(),
+					},
+				},
+				URL: influxdb.SecretField{Key: "url-key"},
+			},
+		},
+		{
+			name: "simple OpsGenie",
+			src: &endpoint.OpsGenie{
+				Base: endpoint.Base{
+					ID:     id1,
+					Name:   "nameOpsGenie",
+					OrgID:  id3,
+					Status: influxdb.Active,
+					CRUDLog: influxdb.CRUDLog{
+						CreatedAt: timeGen1.Now(),
+						UpdatedAt: timeGen2.Now(),
+					},
+				},
+				URL:    "https://api.eu.opsgenie.com/v2/alerts",
+				APIKey: influxdb.SecretField{Key: "api-key-key"},
+				Entity: "checkout",
+			},
+		},
+		{
+			name: "simple VictorOps",
+			src: &endpoint.VictorOps{
+				Base: endpoint.Base{
+					ID:     id1,
+					Name:   "nameVictorOps",
+					OrgID:  id3,
+					Status: influxdb.Active,
+					CRUDLog: influxdb.CRUDLog{
+						CreatedAt: timeGen1.Now(),
+						UpdatedAt: timeGen2.Now(),
+					},
+				},
+				URL:            influxdb.SecretField{Key: "url-key"},
+				MonitoringTool: "influxdb-prod",
+			},
+		},
+		{
+			name: "simple AWS",
+			src: &endpoint.AWS{
+				Base: endpoint.Base{
+					ID:     id1,
+					Name:   "nameAWS",
+					OrgID:  id3,
+					Status: influxdb.Active,
+					CRUDLog: influxdb.CRUDLog{
+						CreatedAt: timeGen1.Now(),
+						UpdatedAt: timeGen2.Now(),
+					},
+				},
+				Service:           endpoint.AWSServiceSQS,
+				Region:            "eu-west-1",
+				QueueURL:          "https://sqs.eu-west-1.amazonaws.com/123456789012/alerts",
+				AccessKeyID:       influxdb.SecretField{Key: "access-key-id-key"},
+				SecretAccessKey:   influxdb.SecretField{Key: "secret-access-key-key"},
+				SessionToken:      influxdb.SecretField{Key: "session-token-key"},
+				RelaySecret:       influxdb.SecretField{Key: "relay-secret-key"},
+				MessageAttributes: map[string]string{"source": "influxdb"},
+				RelayURL:          "http://influxd:8086",
+			},
+		},
 	}
 	for _, c := range cases {
 		b, err := json.Marshal(c.src)
@@ -479,6 +769,76 @@ func TestBackFill(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "simple Discord",
+			src: &endpoint.Discord{
+				Base: endpoint.Base{
+					ID:     id1,
+					Name:   "name1",
+					OrgID:  id3,
+					Status: influxdb.Active,
+					CRUDLog: influxdb.CRUDLog{
+						CreatedAt: timeGen1.Now(),
+						UpdatedAt: timeGen2.Now(),
+					},
+				},
+				WebhookID: "123456789",
+				WebhookToken: influxdb.SecretField{
+					Value: strPtr("webhook-token-value"),
+				},
+			},
+			target: &endpoint.Discord{
+				Base: endpoint.Base{
+					ID:     id1,
+					Name:   "name1",
+					OrgID:  id3,
+					Status: influxdb.Active,
+					CRUDLog: influxdb.CRUDLog{
+						CreatedAt: timeGen1.Now(),
+						UpdatedAt: timeGen2.Now(),
+					},
+				},
+				WebhookID: "123456789",
+				WebhookToken: influxdb.SecretField{
+					Key:   id1.String() + "-webhook-token",
+					Value: strPtr("webhook-token-value"),
+				},
+			},
+		},
+		{
+			name: "simple OpsGenie",
+			src: &endpoint.OpsGenie{
+				Base: endpoint.Base{
+					ID:     id1,
+					Name:   "name1",
+					OrgID:  id3,
+					Status: influxdb.Active,
+					CRUDLog: influxdb.CRUDLog{
+						CreatedAt: timeGen1.Now(),
+						UpdatedAt: timeGen2.Now(),
+					},
+				},
+				APIKey: influxdb.SecretField{
+					Value: strPtr("api-key-value"),
+				},
+			},
+			target: &endpoint.OpsGenie{
+				Base: endpoint.Base{
+					ID:     id1,
+					Name:   "name1",
+					OrgID:  id3,
+					Status: influxdb.Active,
+					CRUDLog: influxdb.CRUDLog{
+						CreatedAt: timeGen1.Now(),
+						UpdatedAt: timeGen2.Now(),
+					},
+				},
+				APIKey: influxdb.SecretField{
+					Key:   id1.String() + "-api-key",
+					Value: strPtr("api-key-value"),
+				},
+			},
+		},
 	}
 	for _, c := range cases {
 		c.src.BackfillSecretKeys()
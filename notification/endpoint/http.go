@@ -6,6 +6,8 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
@@ -14,9 +16,26 @@ import (
 var _ influxdb.NotificationEndpoint = &HTTP{}
 
 const (
-	httpTokenSuffix    = "-token"
-	httpUsernameSuffix = "-username"
-	httpPasswordSuffix = "-password"
+	httpTokenSuffix       = "-token"
+	httpUsernameSuffix    = "-username"
+	httpPasswordSuffix    = "-password"
+	httpRelaySecretSuffix = "-relay-secret"
+)
+
+const (
+	// HTTPDeliverPath is the path of the influxd route that delivers the
+	// notifications of HTTP endpoints with a retry policy. It logs every
+	// delivery attempt and retries the failed ones.
+	HTTPDeliverPath = "/api/v2/notificationEndpoints/http"
+	// RelaySecretHeader is the header the tasks of the notification rules
+	// authenticate the notifications they post to influxd with.
+	RelaySecretHeader = "X-Influxdb-Endpoint-Secret"
+
+	// MaxHTTPRetryAttempts is the most times a notification is attempted.
+	MaxHTTPRetryAttempts = 10
+	// DefaultHTTPRetryBackoff is the delay before the first retry of a
+	// notification, when the retry policy does not set one.
+	DefaultHTTPRetryBackoff = 30 * time.Second
 )
 
 // HTTP is the notification endpoint config of http.
@@ -32,6 +51,73 @@ type HTTP struct {
 	AuthMethod      string               `json:"authMethod"`
 	Method          string               `json:"method"`
 	ContentTemplate string               `json:"contentTemplate"`
+	// Retry delivers the notifications of the endpoint through influxd,
+	// which retries the failed deliveries. The notification rules
+	// authenticate to influxd with RelaySecret.
+	Retry       *HTTPRetry           `json:"retry,omitempty"`
+	RelaySecret influxdb.SecretField `json:"relaySecret,omitempty"`
+}
+
+// HTTPRetry is the retry policy of an HTTP endpoint.
+type HTTPRetry struct {
+	// MaxAttempts is how many times a notification is attempted, counting
+	// the first attempt.
+	MaxAttempts int `json:"maxAttempts"`
+	// Backoff is the delay before the first retry, such as 30s, which doubles
+	// after every retry. It defaults to DefaultHTTPRetryBackoff.
+	Backoff string `json:"backoff,omitempty"`
+	// RelayURL is the URL of the influxd instance running the tasks of the
+	// notification rules, which defaults to DefaultRelayURL.
+	RelayURL string `json:"relayURL,omitempty"`
+}
+
+// BackoffDuration returns the delay before the first retry.
+func (r HTTPRetry) BackoffDuration() time.Duration {
+	d, err := time.ParseDuration(r.Backoff)
+	if err != nil || d <= 0 {
+		return DefaultHTTPRetryBackoff
+	}
+	return d
+}
+
+func (r HTTPRetry) valid() error {
+	if r.MaxAttempts < 1 || r.MaxAttempts > MaxHTTPRetryAttempts {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("http endpoint retry maxAttempts must be between 1 and %d", MaxHTTPRetryAttempts),
+		}
+	}
+	if r.Backoff != "" {
+		if d, err := time.ParseDuration(r.Backoff); err != nil || d < time.Second {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "http endpoint retry backoff must be a duration of at least 1s",
+			}
+		}
+	}
+	if r.RelayURL != "" {
+		if _, err := url.Parse(r.RelayURL); err != nil {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("http endpoint relay URL is invalid: %s", err.Error()),
+			}
+		}
+	}
+	return nil
+}
+
+// DeliverURL returns the URL the notification rules of the endpoint post
+// their notifications to. It is the URL of the endpoint, unless the endpoint
+// has a retry policy.
+func (s HTTP) DeliverURL() string {
+	if s.Retry == nil {
+		return s.URL
+	}
+	relay := s.Retry.RelayURL
+	if relay == "" {
+		relay = DefaultRelayURL
+	}
+	return strings.TrimSuffix(relay, "/") + HTTPDeliverPath + "/" + s.idStr()
 }
 
 // BackfillSecretKeys fill back fill the secret field key during the unmarshalling
@@ -46,6 +132,9 @@ func (s *HTTP) BackfillSecretKeys() {
 	if s.Password.Key == "" && s.Password.Value != nil {
 		s.Password.Key = s.idStr() + httpPasswordSuffix
 	}
+	if s.RelaySecret.Key == "" && s.RelaySecret.Value != nil {
+		s.RelaySecret.Key = s.idStr() + httpRelaySecretSuffix
+	}
 }
 
 // SecretFields return available secret fields.
@@ -60,6 +149,9 @@ func (s HTTP) SecretFields() []influxdb.SecretField {
 	if s.Password.Key != "" {
 		arr = append(arr, s.Password)
 	}
+	if s.RelaySecret.Key != "" {
+		arr = append(arr, s.RelaySecret)
+	}
 	return arr
 }
 
@@ -116,6 +208,17 @@ func (s HTTP) Valid() error {
 			Msg:  "invalid http token for bearer auth",
 		}
 	}
+	if s.Retry != nil {
+		if err := s.Retry.valid(); err != nil {
+			return err
+		}
+		if s.RelaySecret.Key == "" {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "http endpoint with a retry policy requires a relay secret",
+			}
+		}
+	}
 
 	return nil
 }
@@ -0,0 +1,83 @@
+package endpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+var _ influxdb.NotificationEndpoint = &OpsGenie{}
+
+const opsGenieAPIKeySuffix = "-api-key"
+
+// OpsGenie is the notification endpoint config of opsgenie.
+type OpsGenie struct {
+	Base
+	// URL is the alert API URL, which defaults to https://api.opsgenie.com/v2/alerts.
+	// Accounts in the EU region use https://api.eu.opsgenie.com/v2/alerts.
+	URL string `json:"url,omitempty"`
+	// APIKey is the key of an API integration, see https://docs.opsgenie.com/docs/api-integration
+	APIKey influxdb.SecretField `json:"apiKey"`
+	// Entity is the domain of the alerts, such as a server or an application.
+	Entity string `json:"entity,omitempty"`
+}
+
+// BackfillSecretKeys fill back the secret field key during the unmarshalling
+// if value of that secret field is not nil.
+func (s *OpsGenie) BackfillSecretKeys() {
+	if s.APIKey.Key == "" && s.APIKey.Value != nil {
+		s.APIKey.Key = s.idStr() + opsGenieAPIKeySuffix
+	}
+}
+
+// SecretFields return available secret fields.
+func (s OpsGenie) SecretFields() []influxdb.SecretField {
+	arr := []influxdb.SecretField{}
+	if s.APIKey.Key != "" {
+		arr = append(arr, s.APIKey)
+	}
+	return arr
+}
+
+// Valid returns error if some configuration is invalid
+func (s OpsGenie) Valid() error {
+	if err := s.Base.valid(); err != nil {
+		return err
+	}
+	if s.APIKey.Key == "" {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "empty opsgenie API key",
+		}
+	}
+	if s.URL != "" {
+		if _, err := url.Parse(s.URL); err != nil {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("opsgenie endpoint URL is invalid: %s", err.Error()),
+			}
+		}
+	}
+	return nil
+}
+
+// MarshalJSON implement json.Marshaler interface.
+func (s OpsGenie) MarshalJSON() ([]byte, error) {
+	type opsGenieAlias OpsGenie
+	return json.Marshal(
+		struct {
+			opsGenieAlias
+			Type string `json:"type"`
+		}{
+			opsGenieAlias: opsGenieAlias(s),
+			Type:          s.Type(),
+		})
+}
+
+// Type returns the type.
+func (s OpsGenie) Type() string {
+	return OpsGenieType
+}
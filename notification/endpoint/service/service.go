@@ -5,6 +5,7 @@ import (
 
 	influxdb "github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/notification/endpoint"
 )
 
 // Service provides all the notification endpoint service behavior.
@@ -36,6 +37,9 @@ func (s *Service) FindNotificationEndpoints(ctx context.Context, filter influxdb
 
 // CreateNotificationEndpoint creates a new notification endpoint and sets b.ID with the new identifier.
 func (s *Service) CreateNotificationEndpoint(ctx context.Context, edp influxdb.NotificationEndpoint, userID platform.ID) error {
+	if err := generateRelaySecret(edp); err != nil {
+		return err
+	}
 	err := s.endpointStore.CreateNotificationEndpoint(ctx, edp, userID)
 	if err != nil {
 		return err
@@ -57,6 +61,9 @@ func (s *Service) CreateNotificationEndpoint(ctx context.Context, edp influxdb.N
 // UpdateNotificationEndpoint updates a single notification endpoint.
 // Returns the new notification endpoint after update.
 func (s *Service) UpdateNotificationEndpoint(ctx context.Context, id platform.ID, nr influxdb.NotificationEndpoint, userID platform.ID) (influxdb.NotificationEndpoint, error) {
+	if err := generateRelaySecret(nr); err != nil {
+		return nil, err
+	}
 	nr.BackfillSecretKeys() // :sadpanda:
 	updatedEndpoint, err := s.endpointStore.UpdateNotificationEndpoint(ctx, id, nr, userID)
 	if err != nil {
@@ -91,3 +98,12 @@ func (s *Service) PatchNotificationEndpoint(ctx context.Context, id platform.ID,
 func (s *Service) DeleteNotificationEndpoint(ctx context.Context, id platform.ID) ([]influxdb.SecretField, platform.ID, error) {
 	return s.endpointStore.DeleteNotificationEndpoint(ctx, id)
 }
+
+// generateRelaySecret generates the relay secret of the endpoints whose
+// notifications are relayed through influxd, when none is given.
+func generateRelaySecret(edp influxdb.NotificationEndpoint) error {
+	if e, ok := edp.(*endpoint.AWS); ok {
+		return e.GenerateRelaySecret()
+	}
+	return nil
+}
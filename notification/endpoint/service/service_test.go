@@ -182,3 +182,39 @@ func TestEndpointService_cumulativeSecrets(t *testing.T) {
 		t.Errorf("secrets after deleting the 2nd endpoint = %v, want %v", len(secretKeys), 2)
 	}
 }
+
+// TestEndpointService_awsRelaySecret tests that AWS endpoints get a relay secret of their own,
+// which the notification rules authenticate with instead of the AWS credentials.
+func TestEndpointService_awsRelaySecret(t *testing.T) {
+	ctx := context.Background()
+	store := inmem.NewKVStore()
+	logger := zaptest.NewLogger(t)
+	require.NoError(t, all.Up(ctx, logger, store))
+
+	secretService := newSecretService(t, ctx, logger, store)
+	endpointService := service.New(service.NewStore(store), secretService)
+
+	e := &endpoint.AWS{
+		Base: endpoint.Base{
+			Name:   "sns",
+			OrgID:  orgID,
+			Status: influxdb.Active,
+		},
+		Service:         endpoint.AWSServiceSNS,
+		Region:          "us-east-1",
+		TopicARN:        "arn:aws:sns:us-east-1:123456789012:alerts",
+		AccessKeyID:     influxdb.SecretField{Value: pointer.String("AKID")},
+		SecretAccessKey: influxdb.SecretField{Value: pointer.String("aws-secret")},
+	}
+	require.NoError(t, endpointService.CreateNotificationEndpoint(ctx, e, *userID))
+	require.Equal(t, e.ID.String()+"-relay-secret", e.RelaySecret.Key)
+
+	relay, err := secretService.LoadSecret(ctx, *orgID, e.RelaySecret.Key)
+	require.NoError(t, err)
+	require.NotEmpty(t, relay)
+	require.NotEqual(t, "aws-secret", relay)
+
+	found, err := endpointService.FindNotificationEndpointByID(ctx, *e.ID)
+	require.NoError(t, err)
+	require.Equal(t, e.RelaySecret.Key, found.(*endpoint.AWS).RelaySecret.Key)
+}
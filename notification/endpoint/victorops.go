@@ -0,0 +1,71 @@
+package endpoint
+
+import (
+	"encoding/json"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+var _ influxdb.NotificationEndpoint = &VictorOps{}
+
+const victorOpsURLSuffix = "-url"
+
+// VictorOps is the notification endpoint config of victorops (splunk on-call).
+type VictorOps struct {
+	Base
+	// URL is the REST endpoint URL of the victorops integration, with the routing
+	// key appended. It embeds the API key of the integration, so it is kept as a secret.
+	URL influxdb.SecretField `json:"url"`
+	// MonitoringTool is the name alerts are reported as coming from, which defaults to InfluxDB.
+	MonitoringTool string `json:"monitoringTool,omitempty"`
+}
+
+// BackfillSecretKeys fill back the secret field key during the unmarshalling
+// if value of that secret field is not nil.
+func (s *VictorOps) BackfillSecretKeys() {
+	if s.URL.Key == "" && s.URL.Value != nil {
+		s.URL.Key = s.idStr() + victorOpsURLSuffix
+	}
+}
+
+// SecretFields return available secret fields.
+func (s VictorOps) SecretFields() []influxdb.SecretField {
+	arr := []influxdb.SecretField{}
+	if s.URL.Key != "" {
+		arr = append(arr, s.URL)
+	}
+	return arr
+}
+
+// Valid returns error if some configuration is invalid
+func (s VictorOps) Valid() error {
+	if err := s.Base.valid(); err != nil {
+		return err
+	}
+	if s.URL.Key == "" {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "empty victorops REST endpoint URL",
+		}
+	}
+	return nil
+}
+
+// MarshalJSON implement json.Marshaler interface.
+func (s VictorOps) MarshalJSON() ([]byte, error) {
+	type victorOpsAlias VictorOps
+	return json.Marshal(
+		struct {
+			victorOpsAlias
+			Type string `json:"type"`
+		}{
+			victorOpsAlias: victorOpsAlias(s),
+			Type:           s.Type(),
+		})
+}
+
+// Type returns the type.
+func (s VictorOps) Type() string {
+	return VictorOpsType
+}
@@ -0,0 +1,62 @@
+package notification
+
+import (
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// Escalation is the escalation policy of a notification rule. The statuses of
+// a check which stays crit are notified again every RepeatEvery, and once the
+// check has been crit for After, they are escalated to a second endpoint. The
+// statuses of a check whose alert is acknowledged are neither notified again
+// nor escalated.
+type Escalation struct {
+	// RepeatEvery is how often the statuses of a series are notified again,
+	// while they stay at a level. Crit statuses are notified again even if
+	// the status rules of the rule only match level changes.
+	RepeatEvery *Duration `json:"repeatEvery,omitempty"`
+	// After is how long a check must stay crit for its statuses to be
+	// escalated. Escalated statuses are notified again every RepeatEvery if
+	// it is set, or every After otherwise.
+	After *Duration `json:"after,omitempty"`
+	// EndpointID is the endpoint the statuses are escalated to.
+	EndpointID *platform.ID `json:"endpointID,omitempty"`
+}
+
+// Valid returns error for an empty escalation, or an escalation with invalid
+// durations or endpoint.
+func (e Escalation) Valid() error {
+	if e.RepeatEvery == nil && e.EndpointID == nil {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "escalation must have a repeatEvery or an endpointID",
+		}
+	}
+	if e.RepeatEvery != nil && e.RepeatEvery.TimeDuration() < time.Second {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "escalation repeatEvery must be at least 1s",
+		}
+	}
+	if (e.EndpointID == nil) != (e.After == nil) {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "escalation after and endpointID must be set together",
+		}
+	}
+	if e.EndpointID != nil && !e.EndpointID.Valid() {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "escalation endpointID is invalid",
+		}
+	}
+	if e.After != nil && e.After.TimeDuration() < time.Second {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "escalation after must be at least 1s",
+		}
+	}
+	return nil
+}
@@ -1,6 +1,10 @@
 package flux
 
-import "github.com/influxdata/flux/ast"
+import (
+	"time"
+
+	"github.com/influxdata/flux/ast"
+)
 
 // File creates a new *ast.File.
 func File(name string, imports []*ast.ImportDeclaration, body []ast.Statement) *ast.File {
@@ -20,6 +24,15 @@ func GreaterThan(lhs, rhs ast.Expression) *ast.BinaryExpression {
 	}
 }
 
+// GreaterThanEqual returns a greater than or equal to *ast.BinaryExpression.
+func GreaterThanEqual(lhs, rhs ast.Expression) *ast.BinaryExpression {
+	return &ast.BinaryExpression{
+		Operator: ast.GreaterThanEqualOperator,
+		Left:     lhs,
+		Right:    rhs,
+	}
+}
+
 // LessThan returns a less than *ast.BinaryExpression.
 func LessThan(lhs, rhs ast.Expression) *ast.BinaryExpression {
 	return &ast.BinaryExpression{
@@ -29,6 +42,15 @@ func LessThan(lhs, rhs ast.Expression) *ast.BinaryExpression {
 	}
 }
 
+// LessThanEqual returns a less than or equal to *ast.BinaryExpression.
+func LessThanEqual(lhs, rhs ast.Expression) *ast.BinaryExpression {
+	return &ast.BinaryExpression{
+		Operator: ast.LessThanEqualOperator,
+		Left:     lhs,
+		Right:    rhs,
+	}
+}
+
 // Equal returns an equal to *ast.BinaryExpression.
 func Equal(lhs, rhs ast.Expression) *ast.BinaryExpression {
 	return &ast.BinaryExpression{
@@ -38,6 +60,15 @@ func Equal(lhs, rhs ast.Expression) *ast.BinaryExpression {
 	}
 }
 
+// NotEqual returns a not equal to *ast.BinaryExpression.
+func NotEqual(lhs, rhs ast.Expression) *ast.BinaryExpression {
+	return &ast.BinaryExpression{
+		Operator: ast.NotEqualOperator,
+		Left:     lhs,
+		Right:    rhs,
+	}
+}
+
 // Subtract returns a subtraction *ast.BinaryExpression.
 func Subtract(lhs, rhs ast.Expression) *ast.BinaryExpression {
 	return &ast.BinaryExpression{
@@ -172,6 +203,13 @@ func Duration(m int64, u string) *ast.DurationLiteral {
 	}
 }
 
+// DateTime returns a *ast.DateTimeLiteral of t.
+func DateTime(t time.Time) *ast.DateTimeLiteral {
+	return &ast.DateTimeLiteral{
+		Value: t,
+	}
+}
+
 // Identifier returns an *ast.Identifier of i.
 func Identifier(i string) *ast.Identifier {
 	return &ast.Identifier{Name: i}
@@ -199,6 +237,22 @@ func Negative(e ast.Expression) *ast.UnaryExpression {
 	}
 }
 
+// Not returns *ast.UnaryExpression for not (e).
+func Not(e ast.Expression) *ast.UnaryExpression {
+	return &ast.UnaryExpression{
+		Operator: ast.NotOperator,
+		Argument: e,
+	}
+}
+
+// Exists returns an exists *ast.UnaryExpression.
+func Exists(e ast.Expression) *ast.UnaryExpression {
+	return &ast.UnaryExpression{
+		Operator: ast.ExistsOperator,
+		Argument: e,
+	}
+}
+
 // DefineVariable returns an *ast.VariableAssignment of id to the e. (e.g. id = <expression>)
 func DefineVariable(id string, e ast.Expression) *ast.VariableAssignment {
 	return &ast.VariableAssignment{
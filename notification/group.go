@@ -0,0 +1,59 @@
+package notification
+
+import (
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/notification/flux"
+)
+
+// StatusGroup groups the statuses notified by a notification rule by their
+// check and tags. A single notification is sent per group for each run of the
+// rule, for the latest status of the group.
+type StatusGroup struct {
+	// Tags are the tags the statuses are grouped by, in addition to their check.
+	Tags []string `json:"tags,omitempty"`
+}
+
+// Valid returns error for empty or duplicate tags.
+func (g StatusGroup) Valid() error {
+	seen := make(map[string]bool, len(g.Tags))
+	for _, t := range g.Tags {
+		if t == "" {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "status group tag can't be empty",
+			}
+		}
+		if t == "_check_id" || seen[t] {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "status group tag " + t + " is duplicated",
+			}
+		}
+		seen[t] = true
+	}
+	return nil
+}
+
+// GenerateFluxAST generates the calls grouping statuses and keeping the
+// latest status of each group.
+func (g StatusGroup) GenerateFluxAST() []*ast.CallExpression {
+	columns := []ast.Expression{flux.String("_check_id")}
+	for _, t := range g.Tags {
+		columns = append(columns, flux.String(t))
+	}
+	return []*ast.CallExpression{
+		flux.Call(
+			flux.Identifier("group"),
+			flux.Object(flux.Property("columns", flux.Array(columns...))),
+		),
+		flux.Call(
+			flux.Identifier("sort"),
+			flux.Object(flux.Property("columns", flux.Array(flux.String("_time")))),
+		),
+		flux.Call(
+			flux.Identifier("last"),
+			flux.Object(flux.Property("column", flux.String("_time"))),
+		),
+	}
+}
@@ -0,0 +1,66 @@
+package notification
+
+import (
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// Routing routes the statuses notified by a notification rule to an endpoint
+// depending on the value of one of their tags, such as a team tag routed to
+// the slack channel of each team. Statuses whose tag matches no route are
+// notified to the endpoint of the rule.
+type Routing struct {
+	// Tag is the tag the statuses are routed by.
+	Tag    string  `json:"tag"`
+	Routes []Route `json:"routes"`
+}
+
+// Route is a route of a routing table.
+type Route struct {
+	// Value is the value of the tag of the statuses routed to the endpoint.
+	Value      string      `json:"value"`
+	EndpointID platform.ID `json:"endpointID"`
+}
+
+// Valid returns error for an empty tag or routing table, and for invalid or
+// duplicate routes.
+func (r Routing) Valid() error {
+	if r.Tag == "" {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "routing tag can't be empty",
+		}
+	}
+	if len(r.Routes) == 0 {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "routing must have at least one route",
+		}
+	}
+	seen := make(map[string]bool, len(r.Routes))
+	for _, rt := range r.Routes {
+		if !rt.EndpointID.Valid() {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "route of " + r.Tag + " " + rt.Value + " has an invalid endpointID",
+			}
+		}
+		if seen[rt.Value] {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "route of " + r.Tag + " " + rt.Value + " is duplicated",
+			}
+		}
+		seen[rt.Value] = true
+	}
+	return nil
+}
+
+// EndpointIDs returns the IDs of the endpoints of the routes.
+func (r Routing) EndpointIDs() []platform.ID {
+	ids := make([]platform.ID, 0, len(r.Routes))
+	for _, rt := range r.Routes {
+		ids = append(ids, rt.EndpointID)
+	}
+	return ids
+}
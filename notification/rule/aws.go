@@ -0,0 +1,180 @@
+package rule
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/notification/endpoint"
+	"github.com/influxdata/influxdb/v2/notification/flux"
+)
+
+// AWS is the notification rule config of amazon SNS topics and SQS queues.
+type AWS struct {
+	Base
+	MessageTemplate string `json:"messageTemplate"`
+	// SubjectTemplate is the subject of the emails SNS delivers the message with.
+	SubjectTemplate string `json:"subjectTemplate,omitempty"`
+	// Attributes are the message attributes of the messages, in addition to the
+	// level of the status and the ones of the endpoint. Their values are templates.
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// GenerateFlux generates a flux script for the aws notification rule.
+func (s *AWS) GenerateFlux(e influxdb.NotificationEndpoint) (string, error) {
+	return s.generateRoutedFlux(e, func(e influxdb.NotificationEndpoint) (*ast.File, error) {
+		awsEndpoint, ok := e.(*endpoint.AWS)
+		if !ok {
+			return nil, fmt.Errorf("endpoint provided is a %s, not an AWS endpoint", e.Type())
+		}
+		return s.GenerateFluxAST(awsEndpoint), nil
+	})
+}
+
+// GenerateFluxAST generates a flux AST for the aws notification rule. The
+// messages are posted to influxd, which publishes them to the endpoint.
+func (s *AWS) GenerateFluxAST(e *endpoint.AWS) *ast.File {
+	return flux.File(
+		s.Name,
+		flux.Imports("influxdata/influxdb/monitor", "http", "json", "influxdata/influxdb/secrets", "experimental"),
+		s.generateFluxASTBody(e),
+	)
+}
+
+func (s *AWS) generateFluxASTBody(e *endpoint.AWS) []ast.Statement {
+	var statements []ast.Statement
+	statements = append(statements, s.generateTaskOption())
+	statements = append(statements, s.generateHeaders(e))
+	statements = append(statements, s.generateFluxASTEndpoint(e))
+	statements = append(statements, s.generateFluxASTNotificationDefinition(e))
+	statements = append(statements, s.generateFluxASTStatuses())
+	statements = append(statements, s.generateLevelChecks()...)
+	statements = append(statements, s.generateFluxASTNotifyPipe())
+
+	return statements
+}
+
+func (s *AWS) generateHeaders(e *endpoint.AWS) ast.Statement {
+	secret := flux.Call(
+		flux.Member("secrets", "get"),
+		flux.Object(
+			flux.Property("key", flux.String(e.RelaySecret.Key)),
+		),
+	)
+	props := []*ast.Property{
+		flux.Dictionary("Content-Type", flux.String("application/json")),
+		flux.Dictionary(endpoint.RelaySecretHeader, secret),
+	}
+	return flux.DefineVariable("headers", flux.Object(props...))
+}
+
+func (s *AWS) generateFluxASTEndpoint(e *endpoint.AWS) ast.Statement {
+	call := flux.Call(flux.Member("http", "endpoint"), flux.Object(flux.Property("url", flux.String(e.PublishURL()))))
+
+	return flux.DefineVariable("aws_endpoint", call)
+}
+
+func (s *AWS) generateFluxASTNotifyPipe() ast.Statement {
+	endpointBody := flux.Call(
+		flux.Member("json", "encode"),
+		flux.Object(flux.Property("v", flux.Identifier("body"))),
+	)
+	endpointProps := []*ast.Property{
+		flux.Property("headers", flux.Identifier("headers")),
+		flux.Property("data", endpointBody),
+	}
+	endpointFn := flux.FuncBlock(flux.FunctionParams("r"),
+		s.generateBody(),
+		&ast.ReturnStatement{
+			Argument: flux.Object(endpointProps...),
+		},
+	)
+
+	props := []*ast.Property{}
+	props = append(props, flux.Property("data", flux.Identifier("notification")))
+	props = append(props, flux.Property("endpoint",
+		flux.Call(flux.Identifier("aws_endpoint"), flux.Object(flux.Property("mapFn", endpointFn)))))
+
+	call := flux.Call(flux.Member("monitor", "notify"), flux.Object(props...))
+
+	return flux.ExpressionStatement(flux.Pipe(flux.Identifier("all_statuses"), call))
+}
+
+func (s *AWS) generateBody() ast.Statement {
+	names := make([]string, 0, len(s.Attributes))
+	for name := range s.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	attrs := []*ast.Property{
+		flux.Dictionary("level", flux.Member("r", "_level")),
+	}
+	for _, name := range names {
+		attrs = append(attrs, flux.Dictionary(name, flux.String(s.Attributes[name])))
+	}
+
+	props := []*ast.Property{
+		flux.Property("message", flux.String(s.MessageTemplate)),
+	}
+	if s.SubjectTemplate != "" {
+		props = append(props, flux.Property("subject", flux.String(s.SubjectTemplate)))
+	}
+	props = append(props, flux.Property("attributes", flux.Object(attrs...)))
+
+	return flux.DefineVariable("body", flux.Object(props...))
+}
+
+type awsAlias AWS
+
+// MarshalJSON implement json.Marshaler interface.
+func (s AWS) MarshalJSON() ([]byte, error) {
+	return json.Marshal(
+		struct {
+			awsAlias
+			Type string `json:"type"`
+		}{
+			awsAlias: awsAlias(s),
+			Type:     s.Type(),
+		})
+}
+
+// Valid returns where the config is valid.
+func (s AWS) Valid() error {
+	if err := s.Base.valid(); err != nil {
+		return err
+	}
+	if s.MessageTemplate == "" {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "AWS MessageTemplate is invalid",
+		}
+	}
+	// the level attribute is added to the ones of the rule.
+	if len(s.Attributes) >= endpoint.MaxAWSMessageAttributes {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("AWS rule can not have more than %d attributes", endpoint.MaxAWSMessageAttributes-1),
+		}
+	}
+	for name := range s.Attributes {
+		if name == "level" {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "AWS rule attribute level is set to the level of the status",
+			}
+		}
+		if err := endpoint.ValidAWSAttributeName(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Type returns the type of the rule config.
+func (s AWS) Type() string {
+	return "aws"
+}
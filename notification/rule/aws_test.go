@@ -0,0 +1,188 @@
+package rule_test
+
+import (
+	"testing"
+
+	"github.com/andreyvit/diff"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/notification"
+	"github.com/influxdata/influxdb/v2/notification/endpoint"
+	"github.com/influxdata/influxdb/v2/notification/rule"
+	influxTesting "github.com/influxdata/influxdb/v2/testing"
+)
+
+var _ influxdb.NotificationRule = &rule.AWS{}
+
+func TestAWS_GenerateFlux(t *testing.T) {
+	r := &rule.AWS{
+		MessageTemplate: "blah",
+		SubjectTemplate: "subject",
+		Attributes: map[string]string{
+			"host": "${r.host}",
+			"app":  "checkout",
+		},
+		Base: rule.Base{
+			ID:         1,
+			EndpointID: 3,
+			Name:       "foo",
+			Every:      mustDuration("1h"),
+			StatusRules: []notification.StatusRule{
+				{
+					CurrentLevel: notification.Critical,
+				},
+			},
+			TagRules: []notification.TagRule{
+				{
+					Tag: influxdb.Tag{
+						Key:   "foo",
+						Value: "bar",
+					},
+					Operator: influxdb.Equal,
+				},
+			},
+		},
+	}
+
+	_, err := r.GenerateFlux(&endpoint.Slack{
+		Base: endpoint.Base{
+			ID:   idPtr(3),
+			Name: "foo",
+		},
+		URL: "http://whatever",
+	})
+	if err == nil {
+		t.Fatal("expected an error generating flux for an incompatible endpoint")
+	}
+
+	script, err := r.GenerateFlux(&endpoint.AWS{
+		Base: endpoint.Base{
+			ID:   idPtr(3),
+			Name: "foo",
+		},
+		Service:         endpoint.AWSServiceSNS,
+		Region:          "us-east-1",
+		TopicARN:        "arn:aws:sns:us-east-1:123456789012:alerts",
+		AccessKeyID:     influxdb.SecretField{Key: "3-access-key-id"},
+		SecretAccessKey: influxdb.SecretField{Key: "3-secret-access-key"},
+		RelaySecret:     influxdb.SecretField{Key: "3-relay-secret"},
+		RelayURL:        "http://influxd:8086/",
+	})
+	if err != nil {
+		t.Fatalf("Failed to generate flux: %v", err)
+	}
+
+	want := `import "influxdata/influxdb/monitor"
+import "http"
+import "json"
+import "influxdata/influxdb/secrets"
+import "experimental"
+
+option task = {name: "foo", every: 1h}
+
+headers = {"Content-Type": "application/json", "X-Influxdb-Endpoint-Secret": secrets["get"](key: "3-relay-secret")}
+aws_endpoint = http["endpoint"](url: "http://influxd:8086/api/v2/notificationEndpoints/aws/0000000000000003")
+notification = {
+    _notification_rule_id: "0000000000000001",
+    _notification_rule_name: "foo",
+    _notification_endpoint_id: "0000000000000003",
+    _notification_endpoint_name: "foo",
+}
+statuses = monitor["from"](start: -2h, fn: (r) => r["foo"] == "bar")
+crit = statuses |> filter(fn: (r) => r["_level"] == "crit")
+all_statuses = crit |> filter(fn: (r) => r["_time"] >= experimental["subDuration"](from: now(), d: 1h))
+
+all_statuses
+    |> monitor["notify"](
+        data: notification,
+        endpoint:
+            aws_endpoint(
+                mapFn: (r) => {
+                    body = {
+                        message: "blah",
+                        subject: "subject",
+                        attributes: {"level": r["_level"], "app": "checkout", "host": "${r.host}"},
+                    }
+
+                    return {headers: headers, data: json["encode"](v: body)}
+                },
+            ),
+    )
+`
+	if got, want := script, influxTesting.FormatFluxString(t, want); got != want {
+		t.Errorf("\n\nStrings do not match:\n\n%s", diff.LineDiff(got, want))
+	}
+}
+
+func TestAWS_Valid(t *testing.T) {
+	base := rule.Base{
+		ID:         1,
+		EndpointID: 3,
+		OwnerID:    4,
+		OrgID:      5,
+		Name:       "foo",
+		Every:      mustDuration("1h"),
+		StatusRules: []notification.StatusRule{
+			{
+				CurrentLevel: notification.Critical,
+			},
+		},
+		TagRules: []notification.TagRule{},
+	}
+
+	cases := []struct {
+		name string
+		rule *rule.AWS
+		err  error
+	}{
+		{
+			name: "valid template",
+			rule: &rule.AWS{
+				MessageTemplate: "blah",
+				Attributes:      map[string]string{"host": "${r.host}"},
+				Base:            base,
+			},
+			err: nil,
+		},
+		{
+			name: "missing MessageTemplate",
+			rule: &rule.AWS{
+				Base: base,
+			},
+			err: &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "AWS MessageTemplate is invalid",
+			},
+		},
+		{
+			name: "level attribute",
+			rule: &rule.AWS{
+				MessageTemplate: "blah",
+				Attributes:      map[string]string{"level": "crit"},
+				Base:            base,
+			},
+			err: &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "AWS rule attribute level is set to the level of the status",
+			},
+		},
+		{
+			name: "invalid attribute name",
+			rule: &rule.AWS{
+				MessageTemplate: "blah",
+				Attributes:      map[string]string{"AWS.trace": "x"},
+				Base:            base,
+			},
+			err: &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  `invalid aws message attribute name "AWS.trace"`,
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := c.rule.Valid()
+			influxTesting.ErrorsEqual(t, got, c.err)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package rule
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/notification/endpoint"
+	"github.com/influxdata/influxdb/v2/notification/flux"
+)
+
+// Discord is the notification rule config of discord.
+type Discord struct {
+	Base
+	MessageTemplate string `json:"messageTemplate"`
+}
+
+// GenerateFlux generates a flux script for the discord notification rule.
+func (s *Discord) GenerateFlux(e influxdb.NotificationEndpoint) (string, error) {
+	return s.generateRoutedFlux(e, func(e influxdb.NotificationEndpoint) (*ast.File, error) {
+		discordEndpoint, ok := e.(*endpoint.Discord)
+		if !ok {
+			return nil, fmt.Errorf("endpoint provided is a %s, not a Discord endpoint", e.Type())
+		}
+		return s.GenerateFluxAST(discordEndpoint), nil
+	})
+}
+
+// GenerateFluxAST generates a flux AST for the discord notification rule.
+func (s *Discord) GenerateFluxAST(e *endpoint.Discord) *ast.File {
+	return flux.File(
+		s.Name,
+		flux.Imports("influxdata/influxdb/monitor", "contrib/chobbs/discord", "influxdata/influxdb/secrets", "experimental"),
+		s.generateFluxASTBody(e),
+	)
+}
+
+func (s *Discord) generateFluxASTBody(e *endpoint.Discord) []ast.Statement {
+	var statements []ast.Statement
+	statements = append(statements, s.generateTaskOption())
+	statements = append(statements, s.generateFluxASTSecrets(e))
+	statements = append(statements, s.generateFluxASTEndpoint(e))
+	statements = append(statements, s.generateFluxASTNotificationDefinition(e))
+	statements = append(statements, s.generateFluxASTStatuses())
+	statements = append(statements, s.generateLevelChecks()...)
+	statements = append(statements, s.generateFluxASTNotifyPipe())
+
+	return statements
+}
+
+func (s *Discord) generateFluxASTSecrets(e *endpoint.Discord) ast.Statement {
+	call := flux.Call(flux.Member("secrets", "get"), flux.Object(flux.Property("key", flux.String(e.WebhookToken.Key))))
+
+	return flux.DefineVariable("discord_secret", call)
+}
+
+func (s *Discord) generateFluxASTEndpoint(e *endpoint.Discord) ast.Statement {
+	props := []*ast.Property{}
+	props = append(props, flux.Property("webhookToken", flux.Identifier("discord_secret")))
+	props = append(props, flux.Property("webhookID", flux.String(e.WebhookID)))
+	props = append(props, flux.Property("username", flux.String(e.Username)))
+	if e.AvatarURL != "" {
+		props = append(props, flux.Property("avatar_url", flux.String(e.AvatarURL)))
+	}
+	call := flux.Call(flux.Member("discord", "endpoint"), flux.Object(props...))
+
+	return flux.DefineVariable("discord_endpoint", call)
+}
+
+func (s *Discord) generateFluxASTNotifyPipe() ast.Statement {
+	endpointProps := []*ast.Property{}
+	endpointProps = append(endpointProps, flux.Property("content", flux.String(s.MessageTemplate)))
+	endpointFn := flux.Function(flux.FunctionParams("r"), flux.Object(endpointProps...))
+
+	props := []*ast.Property{}
+	props = append(props, flux.Property("data", flux.Identifier("notification")))
+	props = append(props, flux.Property("endpoint",
+		flux.Call(flux.Identifier("discord_endpoint"), flux.Object(flux.Property("mapFn", endpointFn)))))
+
+	call := flux.Call(flux.Member("monitor", "notify"), flux.Object(props...))
+
+	return flux.ExpressionStatement(flux.Pipe(flux.Identifier("all_statuses"), call))
+}
+
+type discordAlias Discord
+
+// MarshalJSON implement json.Marshaler interface.
+func (s Discord) MarshalJSON() ([]byte, error) {
+	return json.Marshal(
+		struct {
+			discordAlias
+			Type string `json:"type"`
+		}{
+			discordAlias: discordAlias(s),
+			Type:         s.Type(),
+		})
+}
+
+// Valid returns where the config is valid.
+func (s Discord) Valid() error {
+	if err := s.Base.valid(); err != nil {
+		return err
+	}
+	if s.MessageTemplate == "" {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "Discord MessageTemplate is invalid",
+		}
+	}
+	return nil
+}
+
+// Type returns the type of the rule config.
+func (s Discord) Type() string {
+	return "discord"
+}
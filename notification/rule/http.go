@@ -5,7 +5,6 @@ import (
 	"fmt"
 
 	"github.com/influxdata/flux/ast"
-	"github.com/influxdata/flux/ast/astutil"
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/notification/endpoint"
 	"github.com/influxdata/influxdb/v2/notification/flux"
@@ -18,11 +17,13 @@ type HTTP struct {
 
 // GenerateFlux generates a flux script for the http notification rule.
 func (s *HTTP) GenerateFlux(e influxdb.NotificationEndpoint) (string, error) {
-	httpEndpoint, ok := e.(*endpoint.HTTP)
-	if !ok {
-		return "", fmt.Errorf("endpoint provided is a %s, not an HTTP endpoint", e.Type())
-	}
-	return astutil.Format(s.GenerateFluxAST(httpEndpoint))
+	return s.generateRoutedFlux(e, func(e influxdb.NotificationEndpoint) (*ast.File, error) {
+		httpEndpoint, ok := e.(*endpoint.HTTP)
+		if !ok {
+			return nil, fmt.Errorf("endpoint provided is a %s, not an HTTP endpoint", e.Type())
+		}
+		return s.GenerateFluxAST(httpEndpoint), nil
+	})
 }
 
 // GenerateFluxAST generates a flux AST for the http notification rule.
@@ -38,7 +39,7 @@ func (s *HTTP) imports(e *endpoint.HTTP) []*ast.ImportDeclaration {
 		"experimental",
 	}
 
-	if e.AuthMethod == "bearer" || e.AuthMethod == "basic" {
+	if e.AuthMethod == "bearer" || e.AuthMethod == "basic" || e.Retry != nil {
 		packages = append(packages, "influxdata/influxdb/secrets")
 	}
 
@@ -104,11 +105,21 @@ func (s *HTTP) generateHeaders(e *endpoint.HTTP) ast.Statement {
 		auth := flux.Dictionary("Authorization", basic)
 		props = append(props, auth)
 	}
+	if e.Retry != nil {
+		// influxd forwards the other headers to the endpoint.
+		secret := flux.Call(
+			flux.Member("secrets", "get"),
+			flux.Object(
+				flux.Property("key", flux.String(e.RelaySecret.Key)),
+			),
+		)
+		props = append(props, flux.Dictionary(endpoint.RelaySecretHeader, secret))
+	}
 	return flux.DefineVariable("headers", flux.Object(props...))
 }
 
 func (s *HTTP) generateFluxASTEndpoint(e *endpoint.HTTP) ast.Statement {
-	call := flux.Call(flux.Member("http", "endpoint"), flux.Object(flux.Property("url", flux.String(e.URL))))
+	call := flux.Call(flux.Member("http", "endpoint"), flux.Object(flux.Property("url", flux.String(e.DeliverURL()))))
 
 	return flux.DefineVariable("endpoint", call)
 }
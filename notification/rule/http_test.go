@@ -314,3 +314,79 @@ all_statuses
 	require.NoError(t, err)
 	assert.Equal(t, want, f)
 }
+
+func TestHTTP_GenerateFlux_retry(t *testing.T) {
+	want := itesting.FormatFluxString(t, `import "influxdata/influxdb/monitor"
+import "http"
+import "json"
+import "experimental"
+import "influxdata/influxdb/secrets"
+
+option task = {name: "foo", every: 1h, offset: 1s}
+
+headers = {
+    "Content-Type": "application/json",
+    "X-Influxdb-Endpoint-Secret": secrets["get"](key: "0000000000000002-relay-secret"),
+}
+endpoint = http["endpoint"](url: "http://influxd:8086/api/v2/notificationEndpoints/http/0000000000000002")
+notification = {
+    _notification_rule_id: "0000000000000001",
+    _notification_rule_name: "foo",
+    _notification_endpoint_id: "0000000000000002",
+    _notification_endpoint_name: "foo",
+}
+statuses = monitor["from"](start: -2h)
+crit = statuses |> filter(fn: (r) => r["_level"] == "crit")
+all_statuses = crit |> filter(fn: (r) => r["_time"] >= experimental["subDuration"](from: now(), d: 1h))
+
+all_statuses
+    |> monitor["notify"](
+        data: notification,
+        endpoint:
+            endpoint(
+                mapFn: (r) => {
+                    body = {r with _version: 1}
+
+                    return {headers: headers, data: json["encode"](v: body)}
+                },
+            ),
+    )
+`)
+
+	s := &rule.HTTP{
+		Base: rule.Base{
+			ID:         1,
+			Name:       "foo",
+			Every:      mustDuration("1h"),
+			Offset:     mustDuration("1s"),
+			EndpointID: 2,
+			TagRules:   []notification.TagRule{},
+			StatusRules: []notification.StatusRule{
+				{
+					CurrentLevel: notification.Critical,
+				},
+			},
+		},
+	}
+
+	id := platform.ID(2)
+	e := &endpoint.HTTP{
+		Base: endpoint.Base{
+			ID:   &id,
+			Name: "foo",
+		},
+		URL:        "http://localhost:7777",
+		AuthMethod: "none",
+		Retry: &endpoint.HTTPRetry{
+			MaxAttempts: 3,
+			RelayURL:    "http://influxd:8086",
+		},
+		RelaySecret: influxdb.SecretField{
+			Key: "0000000000000002-relay-secret",
+		},
+	}
+
+	f, err := s.GenerateFlux(e)
+	require.NoError(t, err)
+	assert.Equal(t, want, f)
+}
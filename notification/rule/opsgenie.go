@@ -0,0 +1,158 @@
+package rule
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/notification/endpoint"
+	"github.com/influxdata/influxdb/v2/notification/flux"
+)
+
+// OpsGenie is the notification rule config of opsgenie.
+type OpsGenie struct {
+	Base
+	MessageTemplate     string `json:"messageTemplate"`
+	DescriptionTemplate string `json:"descriptionTemplate,omitempty"`
+	// Responders are the teams, users, escalations or schedules the alert is
+	// routed to, such as "team:sre" or "user:jane@example.com".
+	Responders []string `json:"responders,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+}
+
+// GenerateFlux generates a flux script for the opsgenie notification rule.
+func (s *OpsGenie) GenerateFlux(e influxdb.NotificationEndpoint) (string, error) {
+	return s.generateRoutedFlux(e, func(e influxdb.NotificationEndpoint) (*ast.File, error) {
+		opsGenieEndpoint, ok := e.(*endpoint.OpsGenie)
+		if !ok {
+			return nil, fmt.Errorf("endpoint provided is a %s, not an OpsGenie endpoint", e.Type())
+		}
+		return s.GenerateFluxAST(opsGenieEndpoint), nil
+	})
+}
+
+// GenerateFluxAST generates a flux AST for the opsgenie notification rule.
+func (s *OpsGenie) GenerateFluxAST(e *endpoint.OpsGenie) *ast.File {
+	return flux.File(
+		s.Name,
+		flux.Imports("influxdata/influxdb/monitor", "contrib/sranka/opsgenie", "influxdata/influxdb/secrets", "experimental"),
+		s.generateFluxASTBody(e),
+	)
+}
+
+func (s *OpsGenie) generateFluxASTBody(e *endpoint.OpsGenie) []ast.Statement {
+	var statements []ast.Statement
+	statements = append(statements, s.generateTaskOption())
+	statements = append(statements, s.generateFluxASTSecrets(e))
+	statements = append(statements, s.generateFluxASTEndpoint(e))
+	statements = append(statements, s.generateFluxASTNotificationDefinition(e))
+	statements = append(statements, s.generateFluxASTStatuses())
+	statements = append(statements, s.generateLevelChecks()...)
+	statements = append(statements, s.generateFluxASTNotifyPipe())
+
+	return statements
+}
+
+func (s *OpsGenie) generateFluxASTSecrets(e *endpoint.OpsGenie) ast.Statement {
+	call := flux.Call(flux.Member("secrets", "get"), flux.Object(flux.Property("key", flux.String(e.APIKey.Key))))
+
+	return flux.DefineVariable("opsgenie_secret", call)
+}
+
+func (s *OpsGenie) generateFluxASTEndpoint(e *endpoint.OpsGenie) ast.Statement {
+	props := []*ast.Property{}
+	if e.URL != "" {
+		props = append(props, flux.Property("url", flux.String(e.URL)))
+	}
+	props = append(props, flux.Property("apiKey", flux.Identifier("opsgenie_secret")))
+	if e.Entity != "" {
+		props = append(props, flux.Property("entity", flux.String(e.Entity)))
+	}
+	call := flux.Call(flux.Member("opsgenie", "endpoint"), flux.Object(props...))
+
+	return flux.DefineVariable("opsgenie_endpoint", call)
+}
+
+func (s *OpsGenie) generateFluxASTNotifyPipe() ast.Statement {
+	endpointProps := []*ast.Property{}
+	endpointProps = append(endpointProps, flux.Property("message", flux.String(s.MessageTemplate)))
+	// alerts with the same alias are deduplicated by opsgenie, so that every
+	// status of a check notified by this rule updates the same alert.
+	endpointProps = append(endpointProps, flux.Property("alias",
+		flux.Add(flux.Add(flux.Member("r", "_notification_rule_id"), flux.String("-")), flux.Member("r", "_check_id"))))
+	endpointProps = append(endpointProps, flux.Property("description", flux.String(s.DescriptionTemplate)))
+	endpointProps = append(endpointProps, flux.Property("priority", s.generatePriority()))
+	endpointProps = append(endpointProps, flux.Property("responders", stringArray(s.Responders)))
+	endpointProps = append(endpointProps, flux.Property("tags", stringArray(s.Tags)))
+	endpointProps = append(endpointProps, flux.Property("actions", flux.Array()))
+	endpointProps = append(endpointProps, flux.Property("visibleTo", flux.Array()))
+	endpointProps = append(endpointProps, flux.Property("details", flux.String("{}")))
+	endpointFn := flux.Function(flux.FunctionParams("r"), flux.Object(endpointProps...))
+
+	props := []*ast.Property{}
+	props = append(props, flux.Property("data", flux.Identifier("notification")))
+	props = append(props, flux.Property("endpoint",
+		flux.Call(flux.Identifier("opsgenie_endpoint"), flux.Object(flux.Property("mapFn", endpointFn)))))
+
+	call := flux.Call(flux.Member("monitor", "notify"), flux.Object(props...))
+
+	return flux.ExpressionStatement(flux.Pipe(flux.Identifier("all_statuses"), call))
+}
+
+// generatePriority maps the level of the status to the opsgenie alert
+// priority, from P1 for crit down to P5 for info and ok.
+func (s *OpsGenie) generatePriority() ast.Expression {
+	level := flux.Member("r", "_level")
+	return flux.If(
+		flux.Equal(level, flux.String("crit")),
+		flux.String("P1"),
+		flux.If(
+			flux.Equal(level, flux.String("warn")),
+			flux.String("P3"),
+			flux.String("P5"),
+		),
+	)
+}
+
+func stringArray(ss []string) *ast.ArrayExpression {
+	es := make([]ast.Expression, 0, len(ss))
+	for _, s := range ss {
+		es = append(es, flux.String(s))
+	}
+	return flux.Array(es...)
+}
+
+type opsGenieAlias OpsGenie
+
+// MarshalJSON implement json.Marshaler interface.
+func (s OpsGenie) MarshalJSON() ([]byte, error) {
+	return json.Marshal(
+		struct {
+			opsGenieAlias
+			Type string `json:"type"`
+		}{
+			opsGenieAlias: opsGenieAlias(s),
+			Type:          s.Type(),
+		})
+}
+
+// Valid returns where the config is valid.
+func (s OpsGenie) Valid() error {
+	if err := s.Base.valid(); err != nil {
+		return err
+	}
+	if s.MessageTemplate == "" {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "OpsGenie MessageTemplate is invalid",
+		}
+	}
+	return nil
+}
+
+// Type returns the type of the rule config.
+func (s OpsGenie) Type() string {
+	return "opsgenie"
+}
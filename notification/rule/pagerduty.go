@@ -5,7 +5,6 @@ import (
 	"fmt"
 
 	"github.com/influxdata/flux/ast"
-	"github.com/influxdata/flux/ast/astutil"
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/notification/endpoint"
@@ -53,11 +52,13 @@ func (s PagerDuty) Type() string {
 
 // GenerateFlux generates a flux script for the pagerduty notification rule.
 func (s *PagerDuty) GenerateFlux(e influxdb.NotificationEndpoint) (string, error) {
-	pagerdutyEndpoint, ok := e.(*endpoint.PagerDuty)
-	if !ok {
-		return "", fmt.Errorf("endpoint provided is a %s, not an PagerDuty endpoint", e.Type())
-	}
-	return astutil.Format(s.GenerateFluxAST(pagerdutyEndpoint))
+	return s.generateRoutedFlux(e, func(e influxdb.NotificationEndpoint) (*ast.File, error) {
+		pagerdutyEndpoint, ok := e.(*endpoint.PagerDuty)
+		if !ok {
+			return nil, fmt.Errorf("endpoint provided is a %s, not an PagerDuty endpoint", e.Type())
+		}
+		return s.GenerateFluxAST(pagerdutyEndpoint), nil
+	})
 }
 
 // GenerateFluxAST generates a flux AST for the pagerduty notification rule.
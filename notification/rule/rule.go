@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/ast/astutil"
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
@@ -19,6 +20,11 @@ var typeToRule = map[string](func() influxdb.NotificationRule){
 	"pagerduty": func() influxdb.NotificationRule { return &PagerDuty{} },
 	"http":      func() influxdb.NotificationRule { return &HTTP{} },
 	"telegram":  func() influxdb.NotificationRule { return &Telegram{} },
+	"discord":   func() influxdb.NotificationRule { return &Discord{} },
+	"teams":     func() influxdb.NotificationRule { return &Teams{} },
+	"opsgenie":  func() influxdb.NotificationRule { return &OpsGenie{} },
+	"victorops": func() influxdb.NotificationRule { return &VictorOps{} },
+	"aws":       func() influxdb.NotificationRule { return &AWS{} },
 }
 
 // UnmarshalJSON will convert
@@ -60,6 +66,27 @@ type Base struct {
 	RunbookLink string                    `json:"runbookLink"`
 	TagRules    []notification.TagRule    `json:"tagRules,omitempty"`
 	StatusRules []notification.StatusRule `json:"statusRules,omitempty"`
+	// Group groups the notified statuses, to send a single notification per
+	// group and run.
+	Group *notification.StatusGroup `json:"group,omitempty"`
+	// MinStateDuration is how long a check must stay at a level for its
+	// statuses to be notified, so that a flapping check doesn't notify.
+	MinStateDuration *notification.Duration `json:"minStateDuration,omitempty"`
+	// Routing routes the statuses to the endpoints of its routes depending
+	// on the value of a tag, rather than to the endpoint of the rule.
+	Routing *notification.Routing `json:"routing,omitempty"`
+	// Escalation notifies the statuses of the checks which stay crit again,
+	// and escalates them to a second endpoint.
+	Escalation *notification.Escalation `json:"escalation,omitempty"`
+	// RouteEndpoints are the endpoints of the routes of Routing and of the
+	// escalation endpoint, by ID. They
+	// are set by the notification rule service to generate the flux of the
+	// task of the rule, and not stored with it.
+	RouteEndpoints map[platform.ID]influxdb.NotificationEndpoint `json:"-"`
+	// Silences are the silences of the organization the statuses of the rule
+	// are filtered by. They are set by the notification rule service to
+	// generate the flux of the task of the rule, and not stored with it.
+	Silences []*influxdb.Silence `json:"-"`
 	*influxdb.Limit
 	influxdb.CRUDLog
 }
@@ -106,6 +133,27 @@ func (b Base) valid() error {
 			return err
 		}
 	}
+	if b.Group != nil {
+		if err := b.Group.Valid(); err != nil {
+			return err
+		}
+	}
+	if b.Routing != nil {
+		if err := b.Routing.Valid(); err != nil {
+			return err
+		}
+	}
+	if b.Escalation != nil {
+		if err := b.Escalation.Valid(); err != nil {
+			return err
+		}
+	}
+	if b.MinStateDuration != nil && b.MinStateDuration.TimeDuration() < time.Second {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "if minStateDuration is set, it must be at least 1s",
+		}
+	}
 	if b.Limit != nil {
 		if b.Limit.Every <= 0 || b.Limit.Rate <= 0 {
 			return &errors.Error{
@@ -129,10 +177,21 @@ func (b *Base) generateFluxASTNotificationDefinition(e influxdb.NotificationEndp
 func (b *Base) generateLevelChecks() []ast.Statement {
 	stmts := []ast.Statement{}
 	tables := []ast.Expression{}
+	notifiesCrit := false
 	for _, r := range b.StatusRules {
 		stmt, table := b.generateLevelCheck(r)
 		tables = append(tables, table)
 		stmts = append(stmts, stmt)
+		if r.PreviousLevel == nil && (r.CurrentLevel == notification.Critical || r.CurrentLevel == notification.Any) {
+			notifiesCrit = true
+		}
+	}
+	repeatEvery := b.repeatEvery()
+	if repeatEvery != nil && !notifiesCrit {
+		// crit statuses are notified again while they stay crit.
+		stmt, table := b.generateLevelCheck(notification.StatusRule{CurrentLevel: notification.Critical})
+		tables = append(tables, table)
+		stmts = append(stmts, stmt)
 	}
 
 	now := flux.Call(flux.Identifier("now"), flux.Object())
@@ -185,11 +244,129 @@ func (b *Base) generateLevelChecks() []ast.Statement {
 		)
 	}
 
+	if b.Group != nil {
+		pipe = flux.Pipe(pipe, b.Group.GenerateFluxAST()...)
+	}
+
+	if repeatEvery != nil {
+		return append(stmts, b.generateRepeatedStatuses(pipe, repeatEvery)...)
+	}
 	stmts = append(stmts, flux.DefineVariable("all_statuses", pipe))
 
 	return stmts
 }
 
+func (b *Base) repeatEvery() *notification.Duration {
+	if b.Escalation == nil {
+		return nil
+	}
+	return b.Escalation.RepeatEvery
+}
+
+// generateRepeatedStatuses generates the statuses of the run whose series
+// have not been notified to the endpoint of the rule within repeatEvery. The
+// notifications sent by the rule are unioned with the statuses of the same
+// series, so that each status is given the time its series was last notified.
+// The statuses of checks whose alerts are acknowledged are left out.
+func (b *Base) generateRepeatedStatuses(statuses ast.Expression, repeatEvery *notification.Duration) []ast.Statement {
+	dur := (*ast.DurationLiteral)(repeatEvery)
+	notified := flux.Pipe(
+		flux.Call(flux.Member("monitor", "logs"), flux.Object(
+			flux.Property("start", flux.Negative(dur)),
+			flux.Property("fn", flux.Function(flux.FunctionParams("r"), flux.And(
+				flux.Equal(flux.Member("r", "_notification_rule_id"), flux.String(b.ID.String())),
+				flux.Equal(flux.Member("r", "_notification_endpoint_id"), flux.String(b.EndpointID.String())),
+			))),
+		)),
+		flux.Call(flux.Identifier("drop"), flux.Object(flux.Property("columns", flux.Array(
+			flux.String("_start"),
+			flux.String("_stop"),
+			flux.String("_measurement"),
+			flux.String("_notification_rule_id"),
+			flux.String("_notification_rule_name"),
+			flux.String("_notification_endpoint_id"),
+			flux.String("_notification_endpoint_name"),
+			flux.String("_sent"),
+		)))),
+		flux.Call(flux.Identifier("map"), flux.Object(flux.Property("fn", flux.Function(
+			flux.FunctionParams("r"),
+			flux.ObjectWith("r", flux.Property("_notified", flux.Member("r", "_time"))),
+		)))),
+	)
+
+	// the checks whose alerts are acknowledged, as recorded by the alert
+	// package, and which are neither notified again nor escalated.
+	acked := flux.Pipe(
+		flux.Call(flux.Identifier("from"), flux.Object(flux.Property("bucket", flux.String(influxdb.MonitoringSystemBucketName)))),
+		flux.Call(flux.Identifier("range"), flux.Object(
+			flux.Property("start", flux.Negative(flux.Duration(int64(influxdb.MaxAlertAckDuration/time.Hour), "h"))),
+		)),
+		flux.Call(flux.Identifier("filter"), flux.Object(flux.Property("fn", flux.Function(flux.FunctionParams("r"), flux.And(
+			flux.Equal(flux.Member("r", "_measurement"), flux.String("acks")),
+			flux.Equal(flux.Member("r", "_field"), flux.String("_expires")),
+		))))),
+		flux.Call(flux.Identifier("last"), flux.Object()),
+		flux.Call(flux.Identifier("filter"), flux.Object(flux.Property("fn", flux.Function(flux.FunctionParams("r"),
+			flux.GreaterThan(
+				flux.Member("r", "_value"),
+				flux.Call(flux.Identifier("int"), flux.Object(flux.Property("v", flux.Call(flux.Identifier("now"), flux.Object())))),
+			),
+		)))),
+		flux.Call(flux.Identifier("group"), flux.Object()),
+		flux.Call(flux.Identifier("findColumn"), flux.Object(
+			flux.Property("fn", flux.Function(flux.FunctionParams("key"), flux.Bool(true))),
+			flux.Property("column", flux.String("_check_id")),
+		)),
+	)
+
+	// notifications have a _status_timestamp, and statuses don't.
+	due := flux.Or(
+		flux.Not(flux.Exists(flux.Member("r", "_notified"))),
+		flux.GreaterThanEqual(
+			flux.Member("r", "_time"),
+			flux.Call(flux.Member("experimental", "addDuration"), flux.Object(
+				flux.Property("d", dur),
+				flux.Property("to", flux.Member("r", "_notified")),
+			)),
+		),
+	)
+	repeated := flux.Pipe(
+		flux.Call(flux.Identifier("union"), flux.Object(flux.Property("tables", flux.Array(
+			flux.Pipe(statuses, flux.Call(flux.Identifier("drop"), flux.Object(flux.Property("columns", flux.Array(
+				flux.String("_start"),
+				flux.String("_stop"),
+				flux.String("_measurement"),
+			))))),
+			flux.Identifier("notified"),
+		)))),
+		flux.Call(flux.Identifier("sort"), flux.Object(flux.Property("columns", flux.Array(flux.String("_time"))))),
+		flux.Call(flux.Identifier("fill"), flux.Object(
+			flux.Property("column", flux.String("_notified")),
+			flux.Property("usePrevious", flux.Bool(true)),
+		)),
+		flux.Call(flux.Identifier("filter"), flux.Object(flux.Property("fn", flux.Function(
+			flux.FunctionParams("r"),
+			flux.And(
+				flux.And(
+					flux.Not(flux.Exists(flux.Member("r", "_status_timestamp"))),
+					flux.Not(flux.Call(flux.Identifier("contains"), flux.Object(
+						flux.Property("value", flux.Member("r", "_check_id")),
+						flux.Property("set", flux.Identifier("acked")),
+					))),
+				),
+				due,
+			),
+		)))),
+		flux.Call(flux.Identifier("drop"), flux.Object(flux.Property("columns", flux.Array(flux.String("_notified"))))),
+	)
+
+	return []ast.Statement{
+		flux.DefineVariable("notified", notified),
+		flux.DefineVariable("acked", acked),
+		flux.DefineVariable("all_statuses", repeated),
+	}
+}
+
 func (b *Base) generateLevelCheck(r notification.StatusRule) (ast.Statement, *ast.Identifier) {
 	var name string
 	var pipe *ast.PipeExpression
@@ -292,21 +469,266 @@ func (b *Base) generateTaskOption() ast.Statement {
 func (b *Base) generateFluxASTStatuses() ast.Statement {
 	props := []*ast.Property{}
 
-	dur := (*ast.DurationLiteral)(b.Every)
-	props = append(props, flux.Property("start", flux.Negative(increaseDur(dur))))
+	dur := increaseDur((*ast.DurationLiteral)(b.Every))
+	if b.MinStateDuration != nil {
+		// query the statuses the levels of the statuses notified have been held for.
+		dur.Values = append(dur.Values, b.MinStateDuration.Values...)
+	}
+	props = append(props, flux.Property("start", flux.Negative(dur)))
 
-	if len(b.TagRules) > 0 {
-		r := b.TagRules[0]
-		var body ast.Expression = r.GenerateFluxAST()
-		for _, r := range b.TagRules[1:] {
-			body = flux.And(body, r.GenerateFluxAST())
+	var exprs []ast.Expression
+	for _, r := range b.TagRules {
+		exprs = append(exprs, r.GenerateFluxAST())
+	}
+	for _, s := range b.Silences {
+		exprs = append(exprs, flux.Not(generateSilence(s)))
+	}
+	if len(exprs) > 0 {
+		body := exprs[0]
+		for _, e := range exprs[1:] {
+			body = flux.And(body, e)
 		}
 		props = append(props, flux.Property("fn", flux.Function(flux.FunctionParams("r"), body)))
 	}
 
 	base := flux.Call(flux.Member("monitor", "from"), flux.Object(props...))
+	if b.MinStateDuration == nil {
+		return flux.DefineVariable("statuses", base)
+	}
+	return flux.DefineVariable("statuses", flux.Pipe(base, b.generateStableStatuses()...))
+}
+
+// statusLevels are the levels statuses can be at.
+var statusLevels = []string{"crit", "warn", "info", "ok"}
+
+// generateStableStatuses generates the calls keeping the statuses whose level
+// has been held for MinStateDuration. As monitor.stateChanges does, _level is
+// removed from the group key so that each series is a single table.
+func (b *Base) generateStableStatuses() []*ast.CallExpression {
+	calls := []*ast.CallExpression{
+		flux.Call(flux.Identifier("duplicate"), flux.Object(
+			flux.Property("column", flux.String("_level")),
+			flux.Property("as", flux.String("____temp_level____")),
+		)),
+		flux.Call(flux.Identifier("drop"), flux.Object(
+			flux.Property("columns", flux.Array(flux.String("_level"))),
+		)),
+		flux.Call(flux.Identifier("rename"), flux.Object(
+			flux.Property("columns", flux.Object(flux.Dictionary("____temp_level____", flux.String("_level")))),
+		)),
+		flux.Call(flux.Identifier("sort"), flux.Object(
+			flux.Property("columns", flux.Array(flux.String("_source_timestamp"), flux.String("_time"))),
+			flux.Property("desc", flux.Bool(false)),
+		)),
+	}
+
+	seconds := int64(b.MinStateDuration.TimeDuration() / time.Second)
+	var stable ast.Expression
+	var columns []ast.Expression
+	for _, level := range statusLevels {
+		column := "_" + level + "_duration"
+		calls = append(calls, flux.Call(flux.Identifier("stateDuration"), flux.Object(
+			flux.Property("fn", flux.Function(flux.FunctionParams("r"), flux.Equal(flux.Member("r", "_level"), flux.String(level)))),
+			flux.Property("column", flux.String(column)),
+			flux.Property("unit", flux.Duration(1, "s")),
+		)))
+
+		held := flux.GreaterThanEqual(flux.Member("r", column), flux.Integer(seconds))
+		if stable == nil {
+			stable = held
+		} else {
+			stable = flux.Or(stable, held)
+		}
+		columns = append(columns, flux.String(column))
+	}
+
+	return append(calls,
+		flux.Call(flux.Identifier("filter"), flux.Object(
+			flux.Property("fn", flux.Function(flux.FunctionParams("r"), stable)),
+		)),
+		flux.Call(flux.Identifier("drop"), flux.Object(
+			flux.Property("columns", flux.Array(columns...)),
+		)),
+		flux.Call(flux.Member("experimental", "group"), flux.Object(
+			flux.Property("mode", flux.String("extend")),
+			flux.Property("columns", flux.Array(flux.String("_level"))),
+		)),
+	)
+}
+
+// generateSilence returns the expression of the statuses muted by s.
+func generateSilence(s *influxdb.Silence) ast.Expression {
+	var body ast.Expression
+	if s.CheckID != nil {
+		body = flux.Equal(flux.Member("r", "_check_id"), flux.String(s.CheckID.String()))
+	}
+	for _, tr := range s.TagRules {
+		e := notification.TagRule(tr).GenerateFluxAST()
+		if body == nil {
+			body = e
+			continue
+		}
+		body = flux.And(body, e)
+	}
+
+	during := flux.And(
+		flux.GreaterThanEqual(flux.Member("r", "_time"), flux.DateTime(s.StartsAt)),
+		flux.LessThan(flux.Member("r", "_time"), flux.DateTime(s.EndsAt)),
+	)
+	if body == nil {
+		return during
+	}
+	return flux.And(body, during)
+}
+
+// SetSilences sets the silences the statuses of the rule are filtered by.
+func (b *Base) SetSilences(ss []*influxdb.Silence) {
+	b.Silences = ss
+}
+
+// RouteEndpointIDs returns the IDs of the endpoints the statuses of the rule
+// are notified to, other than the endpoint of the rule.
+func (b *Base) RouteEndpointIDs() []platform.ID {
+	var ids []platform.ID
+	if b.Routing != nil {
+		ids = append(ids, b.Routing.EndpointIDs()...)
+	}
+	if b.Escalation != nil && b.Escalation.EndpointID != nil {
+		ids = append(ids, *b.Escalation.EndpointID)
+	}
+	return ids
+}
+
+// SetRouteEndpoints sets the endpoints of the routes and escalation of the rule.
+func (b *Base) SetRouteEndpoints(es map[platform.ID]influxdb.NotificationEndpoint) {
+	b.RouteEndpoints = es
+}
+
+func (b *Base) routeEndpoint(id platform.ID) (influxdb.NotificationEndpoint, error) {
+	e, ok := b.RouteEndpoints[id]
+	if !ok {
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("notification endpoint %s of notification rule not found", id),
+		}
+	}
+	return e, nil
+}
+
+// generateRoutedFlux generates the flux of the rule with gen. If the rule has
+// a routing or an escalation endpoint, the flux generated for each route, with
+// the statuses of the route and its endpoint, for the statuses matching no
+// route, with the endpoint of the rule, and for the escalated statuses, with
+// the escalation endpoint, are each wrapped in a function called by the task.
+func (b *Base) generateRoutedFlux(e influxdb.NotificationEndpoint, gen func(influxdb.NotificationEndpoint) (*ast.File, error)) (string, error) {
+	if b.Routing == nil && (b.Escalation == nil || b.Escalation.EndpointID == nil) {
+		f, err := gen(e)
+		if err != nil {
+			return "", err
+		}
+		return astutil.Format(f)
+	}
+
+	base := *b
+	defer func() {
+		*b = base
+	}()
+
+	var (
+		imports []*ast.ImportDeclaration
+		seen    = map[string]bool{}
+		body    = []ast.Statement{b.generateTaskOption()}
+	)
+	block := func(name string, e influxdb.NotificationEndpoint) error {
+		f, err := gen(e)
+		if err != nil {
+			return err
+		}
+		for _, imp := range f.Imports {
+			if !seen[imp.Path.Value] {
+				seen[imp.Path.Value] = true
+				imports = append(imports, imp)
+			}
+		}
+
+		var stmts []ast.Statement
+		for _, stmt := range f.Body {
+			if _, ok := stmt.(*ast.OptionStatement); ok {
+				continue
+			}
+			stmts = append(stmts, stmt)
+		}
+		if last, ok := stmts[len(stmts)-1].(*ast.ExpressionStatement); ok {
+			stmts[len(stmts)-1] = &ast.ReturnStatement{Argument: last.Expression}
+		}
+
+		body = append(body,
+			flux.DefineVariable(name, flux.FuncBlock(nil, stmts...)),
+			flux.ExpressionStatement(flux.Pipe(
+				flux.Call(flux.Identifier(name), flux.Object()),
+				flux.Call(flux.Identifier("yield"), flux.Object(flux.Property("name", flux.String(name)))),
+			)),
+		)
+		return nil
+	}
+	withTagRule := func(trs []notification.TagRule, tr notification.TagRule) []notification.TagRule {
+		return append(append([]notification.TagRule{}, trs...), tr)
+	}
+
+	if b.Routing == nil {
+		if err := block("notify", e); err != nil {
+			return "", err
+		}
+	} else {
+		unrouted := base.TagRules
+		for i, rt := range base.Routing.Routes {
+			re, err := b.routeEndpoint(rt.EndpointID)
+			if err != nil {
+				return "", err
+			}
+			b.EndpointID = rt.EndpointID
+			b.TagRules = withTagRule(base.TagRules, notification.TagRule{
+				Tag:      influxdb.Tag{Key: base.Routing.Tag, Value: rt.Value},
+				Operator: influxdb.Equal,
+			})
+			if err := block(fmt.Sprintf("route_%d", i), re); err != nil {
+				return "", err
+			}
+
+			unrouted = withTagRule(unrouted, notification.TagRule{
+				Tag:      influxdb.Tag{Key: base.Routing.Tag, Value: rt.Value},
+				Operator: influxdb.NotEqual,
+			})
+		}
+
+		b.EndpointID, b.TagRules = base.EndpointID, unrouted
+		if err := block("route_default", e); err != nil {
+			return "", err
+		}
+	}
+
+	if esc := base.Escalation; esc != nil && esc.EndpointID != nil {
+		ee, err := b.routeEndpoint(*esc.EndpointID)
+		if err != nil {
+			return "", err
+		}
+		// the escalated statuses are the crit statuses held for After,
+		// notified again every RepeatEvery, or After.
+		repeatEvery := esc.RepeatEvery
+		if repeatEvery == nil {
+			repeatEvery = esc.After
+		}
+		b.EndpointID = *esc.EndpointID
+		b.TagRules = base.TagRules
+		b.StatusRules = []notification.StatusRule{{CurrentLevel: notification.Critical}}
+		b.MinStateDuration = esc.After
+		b.Escalation = &notification.Escalation{RepeatEvery: repeatEvery}
+		if err := block("escalation", ee); err != nil {
+			return "", err
+		}
+	}
 
-	return flux.DefineVariable("statuses", base)
+	return astutil.Format(flux.File(b.Name, imports, body))
 }
 
 // GetID implements influxdb.Getter interface.
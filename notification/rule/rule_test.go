@@ -120,6 +120,86 @@ func TestValidRule(t *testing.T) {
 				Msg:  "Offset should not be equal or greater than the interval",
 			},
 		},
+		{
+			name: "escalation without after",
+			src: &rule.Slack{
+				Base: rule.Base{
+					ID:         influxTesting.MustIDBase16(id1),
+					Name:       "name1",
+					OwnerID:    influxTesting.MustIDBase16(id2),
+					OrgID:      influxTesting.MustIDBase16(id3),
+					EndpointID: 1,
+					Every:      mustDuration("1m"),
+					Escalation: &notification.Escalation{
+						EndpointID: influxTesting.IDPtr(2),
+					},
+				},
+			},
+			err: &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "escalation after and endpointID must be set together",
+			},
+		},
+		{
+			name: "duplicate route",
+			src: &rule.Slack{
+				Base: rule.Base{
+					ID:         influxTesting.MustIDBase16(id1),
+					Name:       "name1",
+					OwnerID:    influxTesting.MustIDBase16(id2),
+					OrgID:      influxTesting.MustIDBase16(id3),
+					EndpointID: 1,
+					Every:      mustDuration("1m"),
+					Routing: &notification.Routing{
+						Tag: "team",
+						Routes: []notification.Route{
+							{Value: "db", EndpointID: 2},
+							{Value: "db", EndpointID: 3},
+						},
+					},
+				},
+			},
+			err: &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "route of team db is duplicated",
+			},
+		},
+		{
+			name: "duplicate group tag",
+			src: &rule.Slack{
+				Base: rule.Base{
+					ID:         influxTesting.MustIDBase16(id1),
+					Name:       "name1",
+					OwnerID:    influxTesting.MustIDBase16(id2),
+					OrgID:      influxTesting.MustIDBase16(id3),
+					EndpointID: 1,
+					Every:      mustDuration("1m"),
+					Group:      &notification.StatusGroup{Tags: []string{"host", "host"}},
+				},
+			},
+			err: &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "status group tag host is duplicated",
+			},
+		},
+		{
+			name: "min state duration shorter than a second",
+			src: &rule.Slack{
+				Base: rule.Base{
+					ID:               influxTesting.MustIDBase16(id1),
+					Name:             "name1",
+					OwnerID:          influxTesting.MustIDBase16(id2),
+					OrgID:            influxTesting.MustIDBase16(id3),
+					EndpointID:       1,
+					Every:            mustDuration("1m"),
+					MinStateDuration: mustDuration("10ms"),
+				},
+			},
+			err: &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "if minStateDuration is set, it must be at least 1s",
+			},
+		},
 		{
 			name: "empty slack message",
 			src: &rule.Slack{
@@ -43,6 +43,10 @@ type RuleService struct {
 	orgs      influxdb.OrganizationService
 	endpoints influxdb.NotificationEndpointService
 
+	// Silences are the silences the flux of the tasks of the notification
+	// rules filters statuses by, if it is set.
+	Silences influxdb.SilenceService
+
 	idGenerator   platform.IDGenerator
 	timeGenerator influxdb.TimeGenerator
 }
@@ -160,7 +164,7 @@ func (s *RuleService) createNotificationTask(ctx context.Context, r influxdb.Not
 		return nil, err
 	}
 
-	script, err := r.GenerateFlux(ep)
+	script, err := s.generateFlux(ctx, r.NotificationRule, ep)
 	if err != nil {
 		return nil, err
 	}
@@ -182,6 +186,72 @@ func (s *RuleService) createNotificationTask(ctx context.Context, r influxdb.Not
 	return t, nil
 }
 
+// silencedRule is a notification rule whose statuses can be silenced.
+type silencedRule interface {
+	SetSilences(ss []*influxdb.Silence)
+}
+
+// routedRule is a notification rule whose statuses can be notified to several endpoints.
+type routedRule interface {
+	RouteEndpointIDs() []platform.ID
+	SetRouteEndpoints(es map[platform.ID]influxdb.NotificationEndpoint)
+}
+
+// generateFlux generates the flux of the task of r, filtering out the
+// statuses muted by the current and upcoming silences of its organization,
+// and notifying the endpoints of its routes and escalation.
+func (s *RuleService) generateFlux(ctx context.Context, r influxdb.NotificationRule, ep influxdb.NotificationEndpoint) (string, error) {
+	if rr, ok := r.(routedRule); ok && len(rr.RouteEndpointIDs()) > 0 {
+		es := make(map[platform.ID]influxdb.NotificationEndpoint)
+		for _, id := range rr.RouteEndpointIDs() {
+			e, err := s.endpoints.FindNotificationEndpointByID(ctx, id)
+			if err != nil {
+				return "", err
+			}
+			if e.GetOrgID() != r.GetOrgID() {
+				return "", &errors.Error{
+					Code: errors.EInvalid,
+					Msg:  fmt.Sprintf("notification endpoint %s is not in the organization of the notification rule", id),
+				}
+			}
+			es[id] = e
+		}
+		rr.SetRouteEndpoints(es)
+	}
+	if sr, ok := r.(silencedRule); ok && s.Silences != nil {
+		orgID := r.GetOrgID()
+		ss, _, err := s.Silences.FindSilences(ctx, influxdb.SilenceFilter{OrganizationID: &orgID}, influxdb.FindOptions{})
+		if err != nil {
+			return "", err
+		}
+
+		now := s.timeGenerator.Now()
+		var silences []*influxdb.Silence
+		for _, sil := range ss {
+			if sil.EndsAt.After(now) {
+				silences = append(silences, sil)
+			}
+		}
+		sr.SetSilences(silences)
+	}
+	return r.GenerateFlux(ep)
+}
+
+// RefreshNotificationRuleTasks regenerates the flux of the tasks of the
+// notification rules of an organization, when its silences change.
+func (s *RuleService) RefreshNotificationRuleTasks(ctx context.Context, orgID platform.ID) error {
+	nrs, _, err := s.FindNotificationRules(ctx, influxdb.NotificationRuleFilter{OrgID: &orgID})
+	if err != nil {
+		return err
+	}
+	for _, nr := range nrs {
+		if _, err := s.updateNotificationTask(ctx, nr, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // UpdateNotificationRule updates a single notification rule.
 // Returns the new notification rule after update.
 func (s *RuleService) UpdateNotificationRule(ctx context.Context, id platform.ID, nr influxdb.NotificationRuleCreate, userID platform.ID) (influxdb.NotificationRule, error) {
@@ -206,7 +276,7 @@ func (s *RuleService) UpdateNotificationRule(ctx context.Context, id platform.ID
 		return nil, err
 	}
 
-	_, err = s.updateNotificationTask(ctx, nr, pointer.String(string(nr.Status)))
+	_, err = s.updateNotificationTask(ctx, nr.NotificationRule, pointer.String(string(nr.Status)))
 	if err != nil {
 		return nil, err
 	}
@@ -224,7 +294,7 @@ func (s *RuleService) updateNotificationTask(ctx context.Context, r influxdb.Not
 		return nil, err
 	}
 
-	script, err := r.GenerateFlux(ep)
+	script, err := s.generateFlux(ctx, r, ep)
 	if err != nil {
 		return nil, err
 	}
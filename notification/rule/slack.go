@@ -5,7 +5,6 @@ import (
 	"fmt"
 
 	"github.com/influxdata/flux/ast"
-	"github.com/influxdata/flux/ast/astutil"
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/notification/endpoint"
@@ -21,11 +20,13 @@ type Slack struct {
 
 // GenerateFlux generates a flux script for the slack notification rule.
 func (s *Slack) GenerateFlux(e influxdb.NotificationEndpoint) (string, error) {
-	slackEndpoint, ok := e.(*endpoint.Slack)
-	if !ok {
-		return "", fmt.Errorf("endpoint provided is a %s, not an Slack endpoint", e.Type())
-	}
-	return astutil.Format(s.GenerateFluxAST(slackEndpoint))
+	return s.generateRoutedFlux(e, func(e influxdb.NotificationEndpoint) (*ast.File, error) {
+		slackEndpoint, ok := e.(*endpoint.Slack)
+		if !ok {
+			return nil, fmt.Errorf("endpoint provided is a %s, not an Slack endpoint", e.Type())
+		}
+		return s.GenerateFluxAST(slackEndpoint), nil
+	})
 }
 
 // GenerateFluxAST generates a flux AST for the slack notification rule.
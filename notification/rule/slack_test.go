@@ -2,6 +2,7 @@ package rule_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/influxdata/flux/ast"
 	"github.com/influxdata/flux/parser"
@@ -117,6 +118,492 @@ all_statuses
 				URL: "http://localhost:7777",
 			},
 		},
+		{
+			name: "with silences",
+			want: `import "influxdata/influxdb/monitor"
+import "slack"
+import "influxdata/influxdb/secrets"
+import "experimental"
+
+option task = {name: "foo", every: 1h}
+
+slack_endpoint = slack["endpoint"](url: "http://localhost:7777")
+notification = {
+    _notification_rule_id: "0000000000000001",
+    _notification_rule_name: "foo",
+    _notification_endpoint_id: "0000000000000002",
+    _notification_endpoint_name: "foo",
+}
+statuses =
+    monitor["from"](
+        start: -2h,
+        fn: (r) =>
+            r["foo"] == "bar" and not (r["_check_id"] == "000000000000000a" and r["_time"] >= 2021-01-01T10:00:00Z and r["_time"] < 2021-01-01T12:00:00Z)
+                and
+                not (r["host"] == "db1" and r["region"] == "eu" and r["_time"] >= 2021-01-02T00:00:00Z and r["_time"] < 2021-01-02T01:00:00Z),
+    )
+any = statuses |> filter(fn: (r) => true)
+all_statuses = any |> filter(fn: (r) => r["_time"] >= experimental["subDuration"](from: now(), d: 1h))
+
+all_statuses
+    |> monitor["notify"](
+        data: notification,
+        endpoint:
+            slack_endpoint(
+                mapFn: (r) =>
+                    ({
+                        channel: "bar",
+                        text: "blah",
+                        color:
+                            if r["_level"] == "crit" then
+                                "danger"
+                            else if r["_level"] == "warn" then
+                                "warning"
+                            else
+                                "good",
+                    }),
+            ),
+    )
+`,
+			rule: &rule.Slack{
+				Channel:         "bar",
+				MessageTemplate: "blah",
+				Base: rule.Base{
+					ID:         1,
+					EndpointID: 2,
+					Name:       "foo",
+					Every:      mustDuration("1h"),
+					TagRules: []notification.TagRule{
+						{
+							Tag: influxdb.Tag{
+								Key:   "foo",
+								Value: "bar",
+							},
+							Operator: influxdb.Equal,
+						},
+					},
+					StatusRules: []notification.StatusRule{
+						{
+							CurrentLevel: notification.Any,
+						},
+					},
+					Silences: []*influxdb.Silence{
+						{
+							CheckID:  idPtr(10),
+							StartsAt: time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC),
+							EndsAt:   time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC),
+						},
+						{
+							TagRules: []influxdb.TagRule{
+								{Tag: influxdb.Tag{Key: "host", Value: "db1"}, Operator: influxdb.Equal},
+								{Tag: influxdb.Tag{Key: "region", Value: "eu"}, Operator: influxdb.Equal},
+							},
+							StartsAt: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC),
+							EndsAt:   time.Date(2021, 1, 2, 1, 0, 0, 0, time.UTC),
+						},
+					},
+				},
+			},
+			endpoint: &endpoint.Slack{
+				Base: endpoint.Base{
+					ID:   idPtr(2),
+					Name: "foo",
+				},
+				URL: "http://localhost:7777",
+			},
+		},
+		{
+			name: "with routing",
+			want: `import "influxdata/influxdb/monitor"
+import "slack"
+import "influxdata/influxdb/secrets"
+import "experimental"
+
+option task = {name: "foo", every: 1h}
+
+route_0 = () => {
+    slack_endpoint = slack["endpoint"](url: "http://localhost:7778")
+    notification = {
+        _notification_rule_id: "0000000000000001",
+        _notification_rule_name: "foo",
+        _notification_endpoint_id: "0000000000000003",
+        _notification_endpoint_name: "db",
+    }
+    statuses = monitor["from"](start: -2h, fn: (r) => r["foo"] == "bar" and r["team"] == "db")
+    any = statuses |> filter(fn: (r) => true)
+    all_statuses = any |> filter(fn: (r) => r["_time"] >= experimental["subDuration"](from: now(), d: 1h))
+
+    return
+        all_statuses
+            |> monitor["notify"](
+                data: notification,
+                endpoint:
+                    slack_endpoint(
+                        mapFn: (r) =>
+                            ({
+                                channel: "bar",
+                                text: "blah",
+                                color:
+                                    if r["_level"] == "crit" then
+                                        "danger"
+                                    else if r["_level"] == "warn" then
+                                        "warning"
+                                    else
+                                        "good",
+                            }),
+                    ),
+            )
+}
+
+route_0() |> yield(name: "route_0")
+route_default = () => {
+    slack_endpoint = slack["endpoint"](url: "http://localhost:7777")
+    notification = {
+        _notification_rule_id: "0000000000000001",
+        _notification_rule_name: "foo",
+        _notification_endpoint_id: "0000000000000002",
+        _notification_endpoint_name: "foo",
+    }
+    statuses = monitor["from"](start: -2h, fn: (r) => r["foo"] == "bar" and r["team"] != "db")
+    any = statuses |> filter(fn: (r) => true)
+    all_statuses = any |> filter(fn: (r) => r["_time"] >= experimental["subDuration"](from: now(), d: 1h))
+
+    return
+        all_statuses
+            |> monitor["notify"](
+                data: notification,
+                endpoint:
+                    slack_endpoint(
+                        mapFn: (r) =>
+                            ({
+                                channel: "bar",
+                                text: "blah",
+                                color:
+                                    if r["_level"] == "crit" then
+                                        "danger"
+                                    else if r["_level"] == "warn" then
+                                        "warning"
+                                    else
+                                        "good",
+                            }),
+                    ),
+            )
+}
+
+route_default() |> yield(name: "route_default")
+`,
+			rule: &rule.Slack{
+				Channel:         "bar",
+				MessageTemplate: "blah",
+				Base: rule.Base{
+					ID:         1,
+					EndpointID: 2,
+					Name:       "foo",
+					Every:      mustDuration("1h"),
+					TagRules: []notification.TagRule{
+						{
+							Tag: influxdb.Tag{
+								Key:   "foo",
+								Value: "bar",
+							},
+							Operator: influxdb.Equal,
+						},
+					},
+					StatusRules: []notification.StatusRule{
+						{
+							CurrentLevel: notification.Any,
+						},
+					},
+					Routing: &notification.Routing{
+						Tag:    "team",
+						Routes: []notification.Route{{Value: "db", EndpointID: 3}},
+					},
+					RouteEndpoints: map[platform.ID]influxdb.NotificationEndpoint{
+						3: &endpoint.Slack{
+							Base: endpoint.Base{
+								ID:   idPtr(3),
+								Name: "db",
+							},
+							URL: "http://localhost:7778",
+						},
+					},
+				},
+			},
+			endpoint: &endpoint.Slack{
+				Base: endpoint.Base{
+					ID:   idPtr(2),
+					Name: "foo",
+				},
+				URL: "http://localhost:7777",
+			},
+		},
+		{
+			name: "with escalation",
+			want: `import "influxdata/influxdb/monitor"
+import "slack"
+import "influxdata/influxdb/secrets"
+import "experimental"
+
+option task = {name: "foo", every: 1h}
+
+notify = () => {
+    slack_endpoint = slack["endpoint"](url: "http://localhost:7777")
+    notification = {
+        _notification_rule_id: "0000000000000001",
+        _notification_rule_name: "foo",
+        _notification_endpoint_id: "0000000000000002",
+        _notification_endpoint_name: "foo",
+    }
+    statuses = monitor["from"](start: -2h, fn: (r) => r["foo"] == "bar")
+    crit = statuses |> filter(fn: (r) => r["_level"] == "crit")
+    notified =
+        monitor["logs"](start: -30m, fn: (r) => r["_notification_rule_id"] == "0000000000000001" and r["_notification_endpoint_id"] == "0000000000000002")
+            |> drop(columns: ["_start", "_stop", "_measurement", "_notification_rule_id", "_notification_rule_name", "_notification_endpoint_id", "_notification_endpoint_name", "_sent"])
+            |> map(fn: (r) => ({r with _notified: r["_time"]}))
+    acked =
+        from(bucket: "_monitoring")
+            |> range(start: -24h)
+            |> filter(fn: (r) => r["_measurement"] == "acks" and r["_field"] == "_expires")
+            |> last()
+            |> filter(fn: (r) => r["_value"] > int(v: now()))
+            |> group()
+            |> findColumn(fn: (key) => true, column: "_check_id")
+    all_statuses =
+        union(tables: [crit |> filter(fn: (r) => r["_time"] >= experimental["subDuration"](from: now(), d: 1h)) |> drop(columns: ["_start", "_stop", "_measurement"]), notified])
+            |> sort(columns: ["_time"])
+            |> fill(column: "_notified", usePrevious: true)
+            |> filter(fn: (r) => not exists r["_status_timestamp"] and not contains(value: r["_check_id"], set: acked) and (not exists r["_notified"] or r["_time"] >= experimental["addDuration"](d: 30m, to: r["_notified"])))
+            |> drop(columns: ["_notified"])
+
+    return
+        all_statuses
+            |> monitor["notify"](
+                data: notification,
+                endpoint:
+                    slack_endpoint(
+                        mapFn: (r) =>
+                            ({
+                                channel: "bar",
+                                text: "blah",
+                                color:
+                                    if r["_level"] == "crit" then
+                                        "danger"
+                                    else if r["_level"] == "warn" then
+                                        "warning"
+                                    else
+                                        "good",
+                            }),
+                    ),
+            )
+}
+
+notify() |> yield(name: "notify")
+escalation = () => {
+    slack_endpoint = slack["endpoint"](url: "http://localhost:7778")
+    notification = {
+        _notification_rule_id: "0000000000000001",
+        _notification_rule_name: "foo",
+        _notification_endpoint_id: "0000000000000003",
+        _notification_endpoint_name: "oncall",
+    }
+    statuses = monitor["from"](start: -2h15m, fn: (r) => r["foo"] == "bar")
+        |> duplicate(column: "_level", as: "____temp_level____")
+        |> drop(columns: ["_level"])
+        |> rename(columns: {"____temp_level____": "_level"})
+        |> sort(columns: ["_source_timestamp", "_time"], desc: false)
+        |> stateDuration(fn: (r) => r["_level"] == "crit", column: "_crit_duration", unit: 1s)
+        |> stateDuration(fn: (r) => r["_level"] == "warn", column: "_warn_duration", unit: 1s)
+        |> stateDuration(fn: (r) => r["_level"] == "info", column: "_info_duration", unit: 1s)
+        |> stateDuration(fn: (r) => r["_level"] == "ok", column: "_ok_duration", unit: 1s)
+        |> filter(fn: (r) => r["_crit_duration"] >= 900 or r["_warn_duration"] >= 900 or r["_info_duration"] >= 900 or r["_ok_duration"] >= 900)
+        |> drop(columns: ["_crit_duration", "_warn_duration", "_info_duration", "_ok_duration"])
+        |> experimental["group"](mode: "extend", columns: ["_level"])
+    crit = statuses |> filter(fn: (r) => r["_level"] == "crit")
+    notified =
+        monitor["logs"](start: -30m, fn: (r) => r["_notification_rule_id"] == "0000000000000001" and r["_notification_endpoint_id"] == "0000000000000003")
+            |> drop(columns: ["_start", "_stop", "_measurement", "_notification_rule_id", "_notification_rule_name", "_notification_endpoint_id", "_notification_endpoint_name", "_sent"])
+            |> map(fn: (r) => ({r with _notified: r["_time"]}))
+    acked =
+        from(bucket: "_monitoring")
+            |> range(start: -24h)
+            |> filter(fn: (r) => r["_measurement"] == "acks" and r["_field"] == "_expires")
+            |> last()
+            |> filter(fn: (r) => r["_value"] > int(v: now()))
+            |> group()
+            |> findColumn(fn: (key) => true, column: "_check_id")
+    all_statuses =
+        union(tables: [crit |> filter(fn: (r) => r["_time"] >= experimental["subDuration"](from: now(), d: 1h)) |> drop(columns: ["_start", "_stop", "_measurement"]), notified])
+            |> sort(columns: ["_time"])
+            |> fill(column: "_notified", usePrevious: true)
+            |> filter(fn: (r) => not exists r["_status_timestamp"] and not contains(value: r["_check_id"], set: acked) and (not exists r["_notified"] or r["_time"] >= experimental["addDuration"](d: 30m, to: r["_notified"])))
+            |> drop(columns: ["_notified"])
+
+    return
+        all_statuses
+            |> monitor["notify"](
+                data: notification,
+                endpoint:
+                    slack_endpoint(
+                        mapFn: (r) =>
+                            ({
+                                channel: "bar",
+                                text: "blah",
+                                color:
+                                    if r["_level"] == "crit" then
+                                        "danger"
+                                    else if r["_level"] == "warn" then
+                                        "warning"
+                                    else
+                                        "good",
+                            }),
+                    ),
+            )
+}
+
+escalation() |> yield(name: "escalation")
+`,
+			rule: &rule.Slack{
+				Channel:         "bar",
+				MessageTemplate: "blah",
+				Base: rule.Base{
+					ID:         1,
+					EndpointID: 2,
+					Name:       "foo",
+					Every:      mustDuration("1h"),
+					TagRules: []notification.TagRule{
+						{
+							Tag: influxdb.Tag{
+								Key:   "foo",
+								Value: "bar",
+							},
+							Operator: influxdb.Equal,
+						},
+					},
+					StatusRules: []notification.StatusRule{
+						{
+							CurrentLevel: notification.Critical,
+						},
+					},
+					Escalation: &notification.Escalation{
+						RepeatEvery: mustDuration("30m"),
+						After:       mustDuration("15m"),
+						EndpointID:  idPtr(3),
+					},
+					RouteEndpoints: map[platform.ID]influxdb.NotificationEndpoint{
+						3: &endpoint.Slack{
+							Base: endpoint.Base{
+								ID:   idPtr(3),
+								Name: "oncall",
+							},
+							URL: "http://localhost:7778",
+						},
+					},
+				},
+			},
+			endpoint: &endpoint.Slack{
+				Base: endpoint.Base{
+					ID:   idPtr(2),
+					Name: "foo",
+				},
+				URL: "http://localhost:7777",
+			},
+		},
+		{
+			name: "with group and min state duration",
+			want: `import "influxdata/influxdb/monitor"
+import "slack"
+import "influxdata/influxdb/secrets"
+import "experimental"
+
+option task = {name: "foo", every: 1h}
+
+slack_endpoint = slack["endpoint"](url: "http://localhost:7777")
+notification = {
+    _notification_rule_id: "0000000000000001",
+    _notification_rule_name: "foo",
+    _notification_endpoint_id: "0000000000000002",
+    _notification_endpoint_name: "foo",
+}
+statuses =
+    monitor["from"](start: -2h5m, fn: (r) => r["foo"] == "bar")
+        |> duplicate(column: "_level", as: "____temp_level____")
+        |> drop(columns: ["_level"])
+        |> rename(columns: {"____temp_level____": "_level"})
+        |> sort(columns: ["_source_timestamp", "_time"], desc: false)
+        |> stateDuration(fn: (r) => r["_level"] == "crit", column: "_crit_duration", unit: 1s)
+        |> stateDuration(fn: (r) => r["_level"] == "warn", column: "_warn_duration", unit: 1s)
+        |> stateDuration(fn: (r) => r["_level"] == "info", column: "_info_duration", unit: 1s)
+        |> stateDuration(fn: (r) => r["_level"] == "ok", column: "_ok_duration", unit: 1s)
+        |> filter(
+            fn: (r) =>
+                r["_crit_duration"] >= 300 or r["_warn_duration"] >= 300 or r["_info_duration"] >= 300
+                    or
+                    r["_ok_duration"] >= 300,
+        )
+        |> drop(columns: ["_crit_duration", "_warn_duration", "_info_duration", "_ok_duration"])
+        |> experimental["group"](mode: "extend", columns: ["_level"])
+crit = statuses |> filter(fn: (r) => r["_level"] == "crit")
+all_statuses =
+    crit
+        |> filter(fn: (r) => r["_time"] >= experimental["subDuration"](from: now(), d: 1h))
+        |> group(columns: ["_check_id", "host"])
+        |> sort(columns: ["_time"])
+        |> last(column: "_time")
+
+all_statuses
+    |> monitor["notify"](
+        data: notification,
+        endpoint:
+            slack_endpoint(
+                mapFn: (r) =>
+                    ({
+                        channel: "bar",
+                        text: "blah",
+                        color:
+                            if r["_level"] == "crit" then
+                                "danger"
+                            else if r["_level"] == "warn" then
+                                "warning"
+                            else
+                                "good",
+                    }),
+            ),
+    )
+`,
+			rule: &rule.Slack{
+				Channel:         "bar",
+				MessageTemplate: "blah",
+				Base: rule.Base{
+					ID:         1,
+					EndpointID: 2,
+					Name:       "foo",
+					Every:      mustDuration("1h"),
+					TagRules: []notification.TagRule{
+						{
+							Tag: influxdb.Tag{
+								Key:   "foo",
+								Value: "bar",
+							},
+							Operator: influxdb.Equal,
+						},
+					},
+					StatusRules: []notification.StatusRule{
+						{
+							CurrentLevel: notification.Critical,
+						},
+					},
+					Group:            &notification.StatusGroup{Tags: []string{"host"}},
+					MinStateDuration: mustDuration("5m"),
+				},
+			},
+			endpoint: &endpoint.Slack{
+				Base: endpoint.Base{
+					ID:   idPtr(2),
+					Name: "foo",
+				},
+				URL: "http://localhost:7777",
+			},
+		},
 		{
 			name: "with url",
 			want: `import "influxdata/influxdb/monitor"
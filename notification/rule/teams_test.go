@@ -0,0 +1,148 @@
+package rule_test
+
+import (
+	"testing"
+
+	"github.com/andreyvit/diff"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/notification"
+	"github.com/influxdata/influxdb/v2/notification/endpoint"
+	"github.com/influxdata/influxdb/v2/notification/rule"
+	influxTesting "github.com/influxdata/influxdb/v2/testing"
+)
+
+var _ influxdb.NotificationRule = &rule.Teams{}
+
+func TestTeams_GenerateFlux(t *testing.T) {
+	r := &rule.Teams{
+		MessageTemplate: "blah",
+		Base: rule.Base{
+			ID:         1,
+			EndpointID: 3,
+			Name:       "foo",
+			Every:      mustDuration("1h"),
+			StatusRules: []notification.StatusRule{
+				{
+					CurrentLevel: notification.Critical,
+				},
+			},
+			TagRules: []notification.TagRule{
+				{
+					Tag: influxdb.Tag{
+						Key:   "foo",
+						Value: "bar",
+					},
+					Operator: influxdb.Equal,
+				},
+			},
+		},
+	}
+
+	_, err := r.GenerateFlux(&endpoint.Slack{
+		Base: endpoint.Base{
+			ID:   idPtr(3),
+			Name: "foo",
+		},
+		URL: "http://whatever",
+	})
+	if err == nil {
+		t.Fatal("expected an error generating flux for an incompatible endpoint")
+	}
+
+	script, err := r.GenerateFlux(&endpoint.Teams{
+		Base: endpoint.Base{
+			ID:   idPtr(3),
+			Name: "foo",
+		},
+		URL: influxdb.SecretField{Key: "3-key"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to generate flux: %v", err)
+	}
+
+	want := `import "influxdata/influxdb/monitor"
+import "contrib/sranka/teams"
+import "influxdata/influxdb/secrets"
+import "experimental"
+
+option task = {name: "foo", every: 1h}
+
+teams_secret = secrets["get"](key: "3-key")
+teams_endpoint = teams["endpoint"](url: teams_secret)
+notification = {
+    _notification_rule_id: "0000000000000001",
+    _notification_rule_name: "foo",
+    _notification_endpoint_id: "0000000000000003",
+    _notification_endpoint_name: "foo",
+}
+statuses = monitor["from"](start: -2h, fn: (r) => r["foo"] == "bar")
+crit = statuses |> filter(fn: (r) => r["_level"] == "crit")
+all_statuses = crit |> filter(fn: (r) => r["_time"] >= experimental["subDuration"](from: now(), d: 1h))
+
+all_statuses
+    |> monitor["notify"](
+        data: notification,
+        endpoint:
+            teams_endpoint(
+                mapFn: (r) =>
+                    ({
+                        title: r["_check_name"],
+                        text: "blah",
+                        summary: "blah",
+                    }),
+            ),
+    )
+`
+	if got, want := script, influxTesting.FormatFluxString(t, want); got != want {
+		t.Errorf("\n\nStrings do not match:\n\n%s", diff.LineDiff(got, want))
+	}
+}
+
+func TestTeams_Valid(t *testing.T) {
+	base := rule.Base{
+		ID:         1,
+		EndpointID: 3,
+		OwnerID:    4,
+		OrgID:      5,
+		Name:       "foo",
+		Every:      mustDuration("1h"),
+		StatusRules: []notification.StatusRule{
+			{
+				CurrentLevel: notification.Critical,
+			},
+		},
+		TagRules: []notification.TagRule{},
+	}
+
+	cases := []struct {
+		name string
+		rule *rule.Teams
+		err  error
+	}{
+		{
+			name: "valid template",
+			rule: &rule.Teams{
+				MessageTemplate: "blah",
+				Base:            base,
+			},
+			err: nil,
+		},
+		{
+			name: "missing MessageTemplate",
+			rule: &rule.Teams{
+				Base: base,
+			},
+			err: &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "Teams MessageTemplate is invalid",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := c.rule.Valid()
+			influxTesting.ErrorsEqual(t, got, c.err)
+		})
+	}
+}
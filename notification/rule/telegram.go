@@ -5,7 +5,6 @@ import (
 	"fmt"
 
 	"github.com/influxdata/flux/ast"
-	"github.com/influxdata/flux/ast/astutil"
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/notification/endpoint"
@@ -22,11 +21,13 @@ type Telegram struct {
 
 // GenerateFlux generates a flux script for the telegram notification rule.
 func (s *Telegram) GenerateFlux(e influxdb.NotificationEndpoint) (string, error) {
-	telegramEndpoint, ok := e.(*endpoint.Telegram)
-	if !ok {
-		return "", fmt.Errorf("endpoint provided is a %s, not a Telegram endpoint", e.Type())
-	}
-	return astutil.Format(s.GenerateFluxAST(telegramEndpoint))
+	return s.generateRoutedFlux(e, func(e influxdb.NotificationEndpoint) (*ast.File, error) {
+		telegramEndpoint, ok := e.(*endpoint.Telegram)
+		if !ok {
+			return nil, fmt.Errorf("endpoint provided is a %s, not a Telegram endpoint", e.Type())
+		}
+		return s.GenerateFluxAST(telegramEndpoint), nil
+	})
 }
 
 // GenerateFluxAST generates a flux AST for the telegram notification rule.
@@ -0,0 +1,139 @@
+package rule
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/notification/endpoint"
+	"github.com/influxdata/influxdb/v2/notification/flux"
+)
+
+// VictorOps is the notification rule config of victorops.
+type VictorOps struct {
+	Base
+	MessageTemplate string `json:"messageTemplate"`
+}
+
+// GenerateFlux generates a flux script for the victorops notification rule.
+func (s *VictorOps) GenerateFlux(e influxdb.NotificationEndpoint) (string, error) {
+	return s.generateRoutedFlux(e, func(e influxdb.NotificationEndpoint) (*ast.File, error) {
+		victorOpsEndpoint, ok := e.(*endpoint.VictorOps)
+		if !ok {
+			return nil, fmt.Errorf("endpoint provided is a %s, not a VictorOps endpoint", e.Type())
+		}
+		return s.GenerateFluxAST(victorOpsEndpoint), nil
+	})
+}
+
+// GenerateFluxAST generates a flux AST for the victorops notification rule.
+func (s *VictorOps) GenerateFluxAST(e *endpoint.VictorOps) *ast.File {
+	return flux.File(
+		s.Name,
+		flux.Imports("influxdata/influxdb/monitor", "contrib/bonitoo-io/victorops", "influxdata/influxdb/secrets", "experimental"),
+		s.generateFluxASTBody(e),
+	)
+}
+
+func (s *VictorOps) generateFluxASTBody(e *endpoint.VictorOps) []ast.Statement {
+	var statements []ast.Statement
+	statements = append(statements, s.generateTaskOption())
+	statements = append(statements, s.generateFluxASTSecrets(e))
+	statements = append(statements, s.generateFluxASTEndpoint(e))
+	statements = append(statements, s.generateFluxASTNotificationDefinition(e))
+	statements = append(statements, s.generateFluxASTStatuses())
+	statements = append(statements, s.generateLevelChecks()...)
+	statements = append(statements, s.generateFluxASTNotifyPipe())
+
+	return statements
+}
+
+func (s *VictorOps) generateFluxASTSecrets(e *endpoint.VictorOps) ast.Statement {
+	call := flux.Call(flux.Member("secrets", "get"), flux.Object(flux.Property("key", flux.String(e.URL.Key))))
+
+	return flux.DefineVariable("victorops_secret", call)
+}
+
+func (s *VictorOps) generateFluxASTEndpoint(e *endpoint.VictorOps) ast.Statement {
+	props := []*ast.Property{}
+	props = append(props, flux.Property("url", flux.Identifier("victorops_secret")))
+	if e.MonitoringTool != "" {
+		props = append(props, flux.Property("monitoringTool", flux.String(e.MonitoringTool)))
+	}
+	call := flux.Call(flux.Member("victorops", "endpoint"), flux.Object(props...))
+
+	return flux.DefineVariable("victorops_endpoint", call)
+}
+
+func (s *VictorOps) generateFluxASTNotifyPipe() ast.Statement {
+	endpointProps := []*ast.Property{}
+	endpointProps = append(endpointProps, flux.Property("messageType", s.generateMessageType()))
+	endpointProps = append(endpointProps, flux.Property("entityID", flux.Member("r", "_check_id")))
+	endpointProps = append(endpointProps, flux.Property("entityDisplayName", flux.Member("r", "_check_name")))
+	endpointProps = append(endpointProps, flux.Property("stateMessage", flux.String(s.MessageTemplate)))
+	endpointProps = append(endpointProps, flux.Property("timestamp", generateTime()))
+	endpointFn := flux.Function(flux.FunctionParams("r"), flux.Object(endpointProps...))
+
+	props := []*ast.Property{}
+	props = append(props, flux.Property("data", flux.Identifier("notification")))
+	props = append(props, flux.Property("endpoint",
+		flux.Call(flux.Identifier("victorops_endpoint"), flux.Object(flux.Property("mapFn", endpointFn)))))
+
+	call := flux.Call(flux.Member("monitor", "notify"), flux.Object(props...))
+
+	return flux.ExpressionStatement(flux.Pipe(flux.Identifier("all_statuses"), call))
+}
+
+// generateMessageType maps the level of the status to the victorops message
+// type. An ok status resolves the incident of the check.
+func (s *VictorOps) generateMessageType() ast.Expression {
+	level := flux.Member("r", "_level")
+	return flux.If(
+		flux.Equal(level, flux.String("crit")),
+		flux.String("CRITICAL"),
+		flux.If(
+			flux.Equal(level, flux.String("warn")),
+			flux.String("WARNING"),
+			flux.If(
+				flux.Equal(level, flux.String("info")),
+				flux.String("INFO"),
+				flux.String("RECOVERY"),
+			),
+		),
+	)
+}
+
+type victorOpsAlias VictorOps
+
+// MarshalJSON implement json.Marshaler interface.
+func (s VictorOps) MarshalJSON() ([]byte, error) {
+	return json.Marshal(
+		struct {
+			victorOpsAlias
+			Type string `json:"type"`
+		}{
+			victorOpsAlias: victorOpsAlias(s),
+			Type:           s.Type(),
+		})
+}
+
+// Valid returns where the config is valid.
+func (s VictorOps) Valid() error {
+	if err := s.Base.valid(); err != nil {
+		return err
+	}
+	if s.MessageTemplate == "" {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "VictorOps MessageTemplate is invalid",
+		}
+	}
+	return nil
+}
+
+// Type returns the type of the rule config.
+func (s VictorOps) Type() string {
+	return "victorops"
+}
@@ -0,0 +1,159 @@
+package rule_test
+
+import (
+	"testing"
+
+	"github.com/andreyvit/diff"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/notification"
+	"github.com/influxdata/influxdb/v2/notification/endpoint"
+	"github.com/influxdata/influxdb/v2/notification/rule"
+	influxTesting "github.com/influxdata/influxdb/v2/testing"
+)
+
+var _ influxdb.NotificationRule = &rule.VictorOps{}
+
+func TestVictorOps_GenerateFlux(t *testing.T) {
+	r := &rule.VictorOps{
+		MessageTemplate: "blah",
+		Base: rule.Base{
+			ID:         1,
+			EndpointID: 3,
+			Name:       "foo",
+			Every:      mustDuration("1h"),
+			StatusRules: []notification.StatusRule{
+				{
+					CurrentLevel: notification.Critical,
+				},
+			},
+			TagRules: []notification.TagRule{
+				{
+					Tag: influxdb.Tag{
+						Key:   "foo",
+						Value: "bar",
+					},
+					Operator: influxdb.Equal,
+				},
+			},
+		},
+	}
+
+	_, err := r.GenerateFlux(&endpoint.Slack{
+		Base: endpoint.Base{
+			ID:   idPtr(3),
+			Name: "foo",
+		},
+		URL: "http://whatever",
+	})
+	if err == nil {
+		t.Fatal("expected an error generating flux for an incompatible endpoint")
+	}
+
+	script, err := r.GenerateFlux(&endpoint.VictorOps{
+		Base: endpoint.Base{
+			ID:   idPtr(3),
+			Name: "foo",
+		},
+		URL:            influxdb.SecretField{Key: "3-key"},
+		MonitoringTool: "influxdb-prod",
+	})
+	if err != nil {
+		t.Fatalf("Failed to generate flux: %v", err)
+	}
+
+	want := `import "influxdata/influxdb/monitor"
+import "contrib/bonitoo-io/victorops"
+import "influxdata/influxdb/secrets"
+import "experimental"
+
+option task = {name: "foo", every: 1h}
+
+victorops_secret = secrets["get"](key: "3-key")
+victorops_endpoint = victorops["endpoint"](url: victorops_secret, monitoringTool: "influxdb-prod")
+notification = {
+    _notification_rule_id: "0000000000000001",
+    _notification_rule_name: "foo",
+    _notification_endpoint_id: "0000000000000003",
+    _notification_endpoint_name: "foo",
+}
+statuses = monitor["from"](start: -2h, fn: (r) => r["foo"] == "bar")
+crit = statuses |> filter(fn: (r) => r["_level"] == "crit")
+all_statuses = crit |> filter(fn: (r) => r["_time"] >= experimental["subDuration"](from: now(), d: 1h))
+
+all_statuses
+    |> monitor["notify"](
+        data: notification,
+        endpoint:
+            victorops_endpoint(
+                mapFn: (r) =>
+                    ({
+                        messageType:
+                            if r["_level"] == "crit" then
+                                "CRITICAL"
+                            else if r["_level"] == "warn" then
+                                "WARNING"
+                            else if r["_level"] == "info" then
+                                "INFO"
+                            else
+                                "RECOVERY",
+                        entityID: r["_check_id"],
+                        entityDisplayName: r["_check_name"],
+                        stateMessage: "blah",
+                        timestamp: time(v: r["_source_timestamp"]),
+                    }),
+            ),
+    )
+`
+	if got, want := script, influxTesting.FormatFluxString(t, want); got != want {
+		t.Errorf("\n\nStrings do not match:\n\n%s", diff.LineDiff(got, want))
+	}
+}
+
+func TestVictorOps_Valid(t *testing.T) {
+	base := rule.Base{
+		ID:         1,
+		EndpointID: 3,
+		OwnerID:    4,
+		OrgID:      5,
+		Name:       "foo",
+		Every:      mustDuration("1h"),
+		StatusRules: []notification.StatusRule{
+			{
+				CurrentLevel: notification.Critical,
+			},
+		},
+		TagRules: []notification.TagRule{},
+	}
+
+	cases := []struct {
+		name string
+		rule *rule.VictorOps
+		err  error
+	}{
+		{
+			name: "valid template",
+			rule: &rule.VictorOps{
+				MessageTemplate: "blah",
+				Base:            base,
+			},
+			err: nil,
+		},
+		{
+			name: "missing MessageTemplate",
+			rule: &rule.VictorOps{
+				Base: base,
+			},
+			err: &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "VictorOps MessageTemplate is invalid",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := c.rule.Valid()
+			influxTesting.ErrorsEqual(t, got, c.err)
+		})
+	}
+}
@@ -22,6 +22,8 @@ func (tr TagRule) GenerateFluxAST() ast.Expression {
 	switch tr.Operator {
 	case influxdb.Equal:
 		return flux.Equal(k, v)
+	case influxdb.NotEqual:
+		return flux.NotEqual(k, v)
 		// TODO(desa): have this work for all operator types
 	}
 
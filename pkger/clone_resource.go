@@ -63,6 +63,10 @@ var kindPriorities = map[Kind]int{
 	KindVariable:                      12,
 	KindDashboard:                     13,
 	KindTelegraf:                      14,
+	KindNotebook:                      15,
+	KindDBRP:                          16,
+	KindRemote:                        17,
+	KindReplication:                   18,
 }
 
 type exportKey struct {
@@ -87,8 +91,12 @@ type resourceExporter struct {
 	bucketSVC   influxdb.BucketService
 	checkSVC    influxdb.CheckService
 	dashSVC     influxdb.DashboardService
+	dbrpSVC     influxdb.DBRPMappingService
 	labelSVC    influxdb.LabelService
 	endpointSVC influxdb.NotificationEndpointService
+	notebookSVC influxdb.NotebookService
+	remoteSVC   RemoteConnectionService
+	replSVC     ReplicationService
 	ruleSVC     influxdb.NotificationRuleStore
 	taskSVC     taskmodel.TaskService
 	teleSVC     influxdb.TelegrafConfigStore
@@ -105,8 +113,12 @@ func newResourceExporter(svc *Service) *resourceExporter {
 		bucketSVC:       svc.bucketSVC,
 		checkSVC:        svc.checkSVC,
 		dashSVC:         svc.dashSVC,
+		dbrpSVC:         svc.dbrpSVC,
 		labelSVC:        svc.labelSVC,
 		endpointSVC:     svc.endpointSVC,
+		notebookSVC:     svc.notebookSVC,
+		remoteSVC:       svc.remoteSVC,
+		replSVC:         svc.replSVC,
 		ruleSVC:         svc.ruleSVC,
 		taskSVC:         svc.taskSVC,
 		teleSVC:         svc.teleSVC,
@@ -209,7 +221,10 @@ func (ex *resourceExporter) resourceCloneToKind(ctx context.Context, r ResourceT
 			Kind:       r.Kind,
 		}
 		for _, a := range ass {
-			stackResource.Associations = append(stackResource.Associations, StackResourceAssociation(a))
+			stackResource.Associations = append(stackResource.Associations, StackResourceAssociation{
+				Kind:     a.Kind,
+				MetaName: a.MetaName,
+			})
 		}
 
 		object.SetMetadataName(metaName)
@@ -392,6 +407,85 @@ func (ex *resourceExporter) resourceCloneToKind(ctx context.Context, r ResourceT
 
 			mapResource(rule.GetOrgID(), rule.GetID(), KindNotificationRule, NotificationRuleToObject(r.Name, endpointObjectName, rule))
 		}
+	case r.Kind.is(KindNotebook):
+		if ex.notebookSVC == nil {
+			return errors.New("notebooks are not supported")
+		}
+		if r.ID == platform.ID(0) {
+			return errors.New("notebooks can only be exported by id")
+		}
+		n, err := ex.notebookSVC.GetNotebook(ctx, r.ID)
+		if err != nil {
+			return err
+		}
+		mapResource(n.OrgID, n.ID, KindNotebook, NotebookToObject(r.Name, *n))
+	case r.Kind.is(KindDBRP):
+		if ex.dbrpSVC == nil {
+			return errors.New("dbrp mappings are not supported")
+		}
+		if r.ID == platform.ID(0) {
+			return errors.New("dbrp mappings can only be exported by id")
+		}
+		mappings, _, err := ex.dbrpSVC.FindMany(ctx, influxdb.DBRPMappingFilter{ID: &r.ID})
+		if err != nil {
+			return err
+		}
+		if len(mappings) == 0 {
+			return errors.New("no dbrp mappings found")
+		}
+		m := mappings[0]
+		bkt, err := ex.bucketSVC.FindBucketByID(ctx, m.BucketID)
+		if err != nil {
+			return err
+		}
+		mapResource(m.OrganizationID, m.ID, KindDBRP, DBRPToObject(r.Name, bkt.Name, *m))
+	case r.Kind.is(KindRemote):
+		if ex.remoteSVC == nil {
+			return errors.New("remote connections are not supported")
+		}
+		if r.ID == platform.ID(0) {
+			return errors.New("remote connections can only be exported by id")
+		}
+		rc, err := ex.remoteSVC.GetRemoteConnection(ctx, r.ID)
+		if err != nil {
+			return err
+		}
+		mapResource(rc.OrgID, uniqByNameResID, KindRemote, RemoteToObject(r.Name, *rc))
+	case r.Kind.is(KindReplication):
+		if ex.replSVC == nil || ex.remoteSVC == nil {
+			return errors.New("replications are not supported")
+		}
+		if r.ID == platform.ID(0) {
+			return errors.New("replications can only be exported by id")
+		}
+		rep, err := ex.replSVC.GetReplication(ctx, r.ID)
+		if err != nil {
+			return err
+		}
+		bkt, err := ex.bucketSVC.FindBucketByID(ctx, rep.LocalBucketID)
+		if err != nil {
+			return err
+		}
+
+		// the remote is exported along with the replication, the template
+		// must provide the remote a replication writes to.
+		rc, err := ex.remoteSVC.GetRemoteConnection(ctx, rep.RemoteID)
+		if err != nil {
+			return err
+		}
+		remoteKey := newExportKey(rc.OrgID, uniqByNameResID, KindRemote, rc.Name)
+		if _, ok := ex.mObjects[remoteKey]; !ok {
+			noAssociations := func(context.Context, ResourceToClone) ([]ObjectAssociation, bool, error) {
+				return nil, false, nil
+			}
+			err := ex.resourceCloneToKind(ctx, ResourceToClone{Kind: KindRemote, ID: rc.ID}, noAssociations)
+			if err != nil {
+				return err
+			}
+		}
+		remoteObjectName := ex.mObjects[remoteKey].Name()
+
+		mapResource(rep.OrgID, rep.ID, KindReplication, ReplicationToObject(r.Name, remoteObjectName, bkt.Name, *rep))
 	case r.Kind.is(KindTask):
 		switch {
 		case r.ID != platform.ID(0):
@@ -496,6 +590,11 @@ func (ex *resourceExporter) resourceCloneAssociationsGen(ctx context.Context, la
 			return nil, shouldSkip, nil
 		}
 
+		if r.Kind.is(KindNotebook, KindDBRP, KindRemote, KindReplication) {
+			// these resources have no labels, so are only exported when not filtering by label.
+			return nil, len(mLabelNames) > 0, nil
+		}
+
 		if len(r.Name) > 0 && r.ID == platform.ID(0) {
 			return nil, false, nil
 		}
@@ -508,10 +607,15 @@ func (ex *resourceExporter) resourceCloneAssociationsGen(ctx context.Context, la
 			return nil, false, ierrors.Wrap(err, "finding resource labels")
 		}
 
+		// resources labeled with a label descending from a filtered label match as well
+		matchesFilter := func(l *influxdb.Label) bool {
+			return mLabelNames[l.Name] || mLabelIDs[l.ID]
+		}
+
 		if len(mLabelNames) > 0 {
 			shouldSkip := true
 			for _, l := range labels {
-				if _, ok := mLabelNames[l.Name]; ok {
+				if matchesFilter(l) {
 					shouldSkip = false
 					break
 				}
@@ -523,10 +627,8 @@ func (ex *resourceExporter) resourceCloneAssociationsGen(ctx context.Context, la
 
 		var associations []ObjectAssociation
 		for _, l := range labels {
-			if len(mLabelNames) > 0 {
-				if _, ok := mLabelNames[l.Name]; !ok {
-					continue
-				}
+			if len(mLabelNames) > 0 && !matchesFilter(l) {
+				continue
 			}
 
 			labelObject := LabelToObject("", *l)
@@ -1356,6 +1458,98 @@ func NotificationRuleToObject(name, endpointPkgName string, iRule influxdb.Notif
 // regex used to rip out the hard coded task option stuffs
 var taskFluxRegex = regexp.MustCompile(`option task = {(.|\n)*?}`)
 
+// NotebookToObject converts an influxdb.Notebook into a pkger.Object. Buckets
+// referenced by the notebook's pipes keep only their names, so the notebook
+// can be applied to any org with buckets of the same name.
+func NotebookToObject(name string, n influxdb.Notebook) Object {
+	if name == "" {
+		name = n.Name
+	}
+
+	o := newObject(KindNotebook, name)
+	for k, v := range notebookSpecWithoutBucketIDs(n.Spec) {
+		if k == fieldName {
+			continue
+		}
+		o.Spec[k] = v
+	}
+	return o
+}
+
+// DBRPToObject converts an influxdb.DBRPMapping into a pkger.Object. The
+// mapping references its bucket by name.
+func DBRPToObject(name, bucketName string, m influxdb.DBRPMapping) Object {
+	if name == "" {
+		name = m.Database + "-" + m.RetentionPolicy
+	}
+
+	o := newObject(KindDBRP, name)
+	o.Spec[fieldDBRPDatabase] = m.Database
+	o.Spec[fieldDBRPRetentionPolicy] = m.RetentionPolicy
+	o.Spec[fieldDBRPBucketName] = bucketName
+	assignNonZeroBools(o.Spec, map[string]bool{
+		fieldDefault: m.Default,
+	})
+	return o
+}
+
+// RemoteToObject converts an influxdb.RemoteConnection into a pkger.Object.
+// The token of the remote is never exported, it is referenced as a secret
+// the template must be applied with.
+func RemoteToObject(name string, rc influxdb.RemoteConnection) Object {
+	if name == "" {
+		name = rc.Name
+	}
+
+	o := newObject(KindRemote, name)
+	o.Spec[fieldRemoteURL] = rc.RemoteURL
+	o.Spec[fieldRemoteToken] = Resource{
+		fieldReferencesSecret: Resource{
+			fieldKey: rc.Name + "-token",
+		},
+	}
+	if rc.Description != nil {
+		o.Spec[fieldDescription] = *rc.Description
+	}
+	if rc.RemoteOrgID != nil {
+		o.Spec[fieldRemoteOrgID] = rc.RemoteOrgID.String()
+	}
+	assignNonZeroBools(o.Spec, map[string]bool{
+		fieldRemoteAllowInsecureTLS: rc.AllowInsecureTLS,
+	})
+	return o
+}
+
+// ReplicationToObject converts an influxdb.Replication into a pkger.Object.
+// The replication references its remote by template name, and its local
+// bucket by name.
+func ReplicationToObject(name, remoteMetaName, bucketName string, r influxdb.Replication) Object {
+	if name == "" {
+		name = r.Name
+	}
+
+	o := newObject(KindReplication, name)
+	o.Spec[fieldReplicationRemoteName] = remoteMetaName
+	o.Spec[fieldReplicationBucketName] = bucketName
+	o.Spec[fieldReplicationMaxQueueSizeBytes] = int(r.MaxQueueSizeBytes)
+	if r.Description != nil {
+		o.Spec[fieldDescription] = *r.Description
+	}
+	assignNonZeroStrings(o.Spec, map[string]string{
+		fieldReplicationRemoteBucketName: r.RemoteBucketName,
+	})
+	if r.RemoteBucketID != nil && r.RemoteBucketID.Valid() {
+		o.Spec[fieldReplicationRemoteBucketID] = r.RemoteBucketID.String()
+	}
+	assignNonZeroInts(o.Spec, map[string]int{
+		fieldReplicationMaxAgeSeconds: int(r.MaxAgeSeconds),
+	})
+	assignNonZeroBools(o.Spec, map[string]bool{
+		fieldReplicationDropNonRetryableData: r.DropNonRetryableData,
+	})
+	return o
+}
+
 // TaskToObject converts an influxdb.Task into a pkger.Object.
 func TaskToObject(name string, t taskmodel.Task) Object {
 	if name == "" {
@@ -1372,6 +1566,11 @@ func TaskToObject(name string, t taskmodel.Task) Object {
 		fieldOffset:      durToStr(t.Offset),
 		fieldQuery:       strings.TrimSpace(query),
 	})
+	// the secrets of the task are declared rather than inlined, so that they
+	// are provided when the template is applied.
+	if secrets := fluxSecretKeys(query); len(secrets) > 0 {
+		o.Spec[fieldTaskSecretRefs] = secrets
+	}
 	return o
 }
 
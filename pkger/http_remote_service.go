@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"time"
 
 	ihttp "github.com/influxdata/influxdb/v2/http"
 	"github.com/influxdata/influxdb/v2/kit/platform"
@@ -52,6 +53,27 @@ func (s *HTTPRemoteService) UninstallStack(ctx context.Context, identifiers stru
 	return convertRespStackToStack(respBody)
 }
 
+func (s *HTTPRemoteService) RollbackStack(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (ImpactSummary, error) {
+	var resp RespApply
+	err := s.Client.
+		Post(httpc.BodyEmpty, RoutePrefixStacks, identifiers.StackID.String(), "/rollback").
+		QueryParams([2]string{"orgID", identifiers.OrgID.String()}).
+		DecodeJSON(&resp).
+		Do(ctx)
+	if err != nil {
+		return ImpactSummary{}, err
+	}
+
+	impact := ImpactSummary{
+		Sources: resp.Sources,
+		StackID: identifiers.StackID,
+		Diff:    resp.Diff,
+		Summary: resp.Summary,
+		Impacts: resp.Impacts,
+	}
+	return impact, nil
+}
+
 func (s *HTTPRemoteService) DeleteStack(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) error {
 	return s.Client.
 		Delete(RoutePrefixStacks, identifiers.StackID.String()).
@@ -114,6 +136,18 @@ func (s *HTTPRemoteService) UpdateStack(ctx context.Context, upd StackUpdate) (S
 			Kind:     r.Kind,
 		})
 	}
+	if upd.Sync != nil {
+		reqBody.Sync = &ReqStackSync{
+			URL:              upd.Sync.URL,
+			Branch:           upd.Sync.Branch,
+			Path:             upd.Sync.Path,
+			AutoApply:        upd.Sync.AutoApply,
+			WebhookSecretKey: upd.Sync.WebhookSecretKey,
+		}
+		if upd.Sync.Interval > 0 {
+			reqBody.Sync.Interval = upd.Sync.Interval.String()
+		}
+	}
 
 	var respBody RespStack
 	err := s.Client.
@@ -256,6 +290,7 @@ func (s *HTTPRemoteService) apply(ctx context.Context, orgID platform.ID, dryRun
 		Sources: resp.Sources,
 		Diff:    resp.Diff,
 		Summary: resp.Summary,
+		Impacts: resp.Impacts,
 	}
 
 	if stackID, err := platform.IDFromString(resp.StackID); err == nil {
@@ -309,6 +344,13 @@ func convertRespStackEvent(ev RespStackEvent) (StackEvent, error) {
 		eventType = StackEventUninstalled
 	case "update":
 		eventType = StackEventUpdate
+	case "rollback":
+		eventType = StackEventRollback
+	}
+
+	cfg, err := convertRespStackSync(ev.Sync)
+	if err != nil {
+		return StackEvent{}, err
 	}
 
 	return StackEvent{
@@ -318,10 +360,40 @@ func convertRespStackEvent(ev RespStackEvent) (StackEvent, error) {
 		Resources:    res,
 		Sources:      ev.Sources,
 		TemplateURLs: ev.URLs,
+		Sync:         cfg,
 		UpdatedAt:    ev.UpdatedAt,
 	}, nil
 }
 
+func convertRespStackSync(resp *RespStackSync) (*StackSync, error) {
+	if resp == nil {
+		return nil, nil
+	}
+
+	cfg := &StackSync{
+		URL:              resp.URL,
+		Branch:           resp.Branch,
+		Path:             resp.Path,
+		AutoApply:        resp.AutoApply,
+		WebhookSecretKey: resp.WebhookSecretKey,
+	}
+	if resp.Interval != "" {
+		interval, err := time.ParseDuration(resp.Interval)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Interval = interval
+	}
+	if resp.UserID != "" {
+		userID, err := platform.IDFromString(resp.UserID)
+		if err != nil {
+			return nil, err
+		}
+		cfg.UserID = *userID
+	}
+	return cfg, nil
+}
+
 func convertRespStackResources(resources []RespStackResource) ([]StackResource, error) {
 	out := make([]StackResource, 0, len(resources))
 	for _, r := range resources {
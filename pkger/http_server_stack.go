@@ -2,6 +2,7 @@ package pkger
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"path"
@@ -17,21 +18,38 @@ import (
 
 const RoutePrefixStacks = "/api/v2/stacks"
 
+// maxWebhookPingSize is the largest webhook ping of a repository the server accepts.
+const maxWebhookPingSize = 1 << 20
+
 // HTTPServerStacks is a server that manages the stacks HTTP transport.
 type HTTPServerStacks struct {
 	chi.Router
 	api    *kithttp.API
 	logger *zap.Logger
 	svc    SVC
+	syncer *Syncer
+}
+
+// HTTPServerStacksOptFn is a functional input for setting the http server options.
+type HTTPServerStacksOptFn func(*HTTPServerStacks)
+
+// WithStackSyncer serves the sync routes of stacks with the syncer.
+func WithStackSyncer(syncer *Syncer) HTTPServerStacksOptFn {
+	return func(s *HTTPServerStacks) {
+		s.syncer = syncer
+	}
 }
 
 // NewHTTPServerStacks constructs a new http server.
-func NewHTTPServerStacks(log *zap.Logger, svc SVC) *HTTPServerStacks {
+func NewHTTPServerStacks(log *zap.Logger, svc SVC, opts ...HTTPServerStacksOptFn) *HTTPServerStacks {
 	svr := &HTTPServerStacks{
 		api:    kithttp.NewAPI(kithttp.WithLog(log)),
 		logger: log,
 		svc:    svc,
 	}
+	for _, o := range opts {
+		o(svr)
+	}
 
 	r := chi.NewRouter()
 	{
@@ -43,6 +61,13 @@ func NewHTTPServerStacks(log *zap.Logger, svc SVC) *HTTPServerStacks {
 			r.Delete("/", svr.deleteStack)
 			r.Patch("/", svr.updateStack)
 			r.Post("/uninstall", svr.uninstallStack)
+			r.Post("/rollback", svr.rollbackStack)
+			if svr.syncer != nil {
+				r.Get("/sync", svr.readStackSync)
+				r.Post("/sync", svr.syncStack)
+				// webhook pings are authenticated by the webhook secret of the stack.
+				r.Post("/sync/webhook", svr.pingStackSync)
+			}
 		})
 	}
 
@@ -74,9 +99,22 @@ type (
 		Resources   []RespStackResource `json:"resources"`
 		Sources     []string            `json:"sources"`
 		URLs        []string            `json:"urls"`
+		Sync        *RespStackSync      `json:"sync,omitempty"`
 		UpdatedAt   time.Time           `json:"updatedAt"`
 	}
 
+	// RespStackSync is the response for the sync of a stack from a git repository.
+	RespStackSync struct {
+		URL              string `json:"url"`
+		Branch           string `json:"branch"`
+		Path             string `json:"path"`
+		Interval         string `json:"interval"`
+		AutoApply        bool   `json:"autoApply"`
+		WebhookSecretKey string `json:"webhookSecretKey,omitempty"`
+		UserID           string `json:"userID"`
+		TemplateURL      string `json:"templateURL"`
+	}
+
 	// RespStackResource is the response for a stack resource. This type exists
 	// to decouple the internal service implementation from the deprecates usage
 	// of templates in the API. We could add a custom UnmarshalJSON method, but
@@ -94,6 +132,9 @@ type (
 	RespStackResourceAssoc struct {
 		Kind     Kind   `json:"kind"`
 		MetaName string `json:"metaName"`
+		// StackID is the stack which owns the associated resource, when it
+		// is not the stack of the resource.
+		StackID platform.ID `json:"stackID,omitempty"`
 	}
 
 	RespStackResourceLinks struct {
@@ -291,6 +332,38 @@ func (s *HTTPServerStacks) uninstallStack(w http.ResponseWriter, r *http.Request
 	s.api.Respond(w, r, http.StatusOK, convertStackToRespStack(stack))
 }
 
+func (s *HTTPServerStacks) rollbackStack(w http.ResponseWriter, r *http.Request) {
+	orgID, err := getRequiredOrgIDFromQuery(r.URL.Query())
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	stackID, err := stackIDFromReq(r)
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	auth, err := pctx.GetAuthorizer(r.Context())
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	impact, err := s.svc.RollbackStack(r.Context(), struct{ OrgID, UserID, StackID platform.ID }{
+		OrgID:   orgID,
+		UserID:  auth.GetUserID(),
+		StackID: stackID,
+	})
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	s.api.Respond(w, r, http.StatusCreated, impactToRespApply(impact, nil))
+}
+
 func (s *HTTPServerStacks) readStack(w http.ResponseWriter, r *http.Request) {
 	stackID, err := stackIDFromReq(r)
 	if err != nil {
@@ -314,6 +387,9 @@ type (
 		Description         *string                  `json:"description"`
 		TemplateURLs        []string                 `json:"templateURLs"`
 		AdditionalResources []ReqUpdateStackResource `json:"additionalResources"`
+		// Sync configures the stack to be synced from a git repository, a
+		// sync without a url stops the syncing.
+		Sync *ReqStackSync `json:"sync"`
 
 		// Deprecating the urls field and replacing with templateURLs field.
 		// This is remaining here for backwards compatibility.
@@ -325,6 +401,17 @@ type (
 		Kind     Kind   `json:"kind"`
 		MetaName string `json:"templateMetaName"`
 	}
+
+	// ReqStackSync is the sync of a stack from a git repository.
+	ReqStackSync struct {
+		URL    string `json:"url"`
+		Branch string `json:"branch"`
+		Path   string `json:"path"`
+		// Interval is a duration, such as 10m.
+		Interval         string `json:"interval"`
+		AutoApply        bool   `json:"autoApply"`
+		WebhookSecretKey string `json:"webhookSecretKey"`
+	}
 )
 
 func (s *HTTPServerStacks) updateStack(w http.ResponseWriter, r *http.Request) {
@@ -360,6 +447,30 @@ func (s *HTTPServerStacks) updateStack(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
+	if req.Sync != nil {
+		auth, err := pctx.GetAuthorizer(r.Context())
+		if err != nil {
+			s.api.Err(w, r, err)
+			return
+		}
+		update.Sync = &StackSync{
+			URL:              req.Sync.URL,
+			Branch:           req.Sync.Branch,
+			Path:             req.Sync.Path,
+			AutoApply:        req.Sync.AutoApply,
+			WebhookSecretKey: req.Sync.WebhookSecretKey,
+			UserID:           auth.GetUserID(),
+		}
+		if req.Sync.Interval != "" {
+			interval, err := time.ParseDuration(req.Sync.Interval)
+			if err != nil {
+				s.api.Err(w, r, influxErr(errors.EInvalid, err, fmt.Sprintf("stack sync interval %q", req.Sync.Interval)))
+				return
+			}
+			update.Sync.Interval = interval
+		}
+	}
+
 	stack, err := s.svc.UpdateStack(r.Context(), update)
 	if err != nil {
 		s.api.Err(w, r, err)
@@ -369,6 +480,113 @@ func (s *HTTPServerStacks) updateStack(w http.ResponseWriter, r *http.Request) {
 	s.api.Respond(w, r, http.StatusOK, convertStackToRespStack(stack))
 }
 
+// RespStackSyncStatus is the response for the status of the latest sync of a stack.
+type RespStackSyncStatus struct {
+	StackID     string     `json:"stackID"`
+	State       SyncState  `json:"state"`
+	TemplateURL string     `json:"templateURL"`
+	Revision    string     `json:"revision,omitempty"`
+	Diff        *Diff      `json:"diff,omitempty"`
+	Err         string     `json:"error,omitempty"`
+	CheckedAt   time.Time  `json:"checkedAt"`
+	AppliedAt   *time.Time `json:"appliedAt,omitempty"`
+}
+
+func (s *HTTPServerStacks) readStackSync(w http.ResponseWriter, r *http.Request) {
+	stackID, err := stackIDFromReq(r)
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	// reading the stack authorizes the read of its sync status.
+	if _, err := s.svc.ReadStack(r.Context(), stackID); err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	status, ok := s.syncer.Status(stackID)
+	if !ok {
+		s.api.Err(w, r, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  "stack has not been synced",
+		})
+		return
+	}
+
+	s.api.Respond(w, r, http.StatusOK, convertSyncStatus(status))
+}
+
+func (s *HTTPServerStacks) syncStack(w http.ResponseWriter, r *http.Request) {
+	stackID, err := stackIDFromReq(r)
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	auth, err := pctx.GetAuthorizer(r.Context())
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	stack, err := s.svc.ReadStack(r.Context(), stackID)
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	status, err := s.syncer.SyncStack(r.Context(), stack, auth.GetUserID())
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	s.api.Respond(w, r, http.StatusOK, convertSyncStatus(status))
+}
+
+func (s *HTTPServerStacks) pingStackSync(w http.ResponseWriter, r *http.Request) {
+	stackID, err := stackIDFromReq(r)
+	if err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookPingSize))
+	if err != nil {
+		s.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "unable to read webhook ping",
+			Err:  err,
+		})
+		return
+	}
+
+	if err := s.syncer.Ping(r.Context(), stackID, r.Header, body); err != nil {
+		s.api.Err(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func convertSyncStatus(status StackSyncStatus) RespStackSyncStatus {
+	resp := RespStackSyncStatus{
+		StackID:     status.StackID.String(),
+		State:       status.State,
+		TemplateURL: status.TemplateURL,
+		Revision:    status.Revision,
+		Diff:        status.Diff,
+		Err:         status.Err,
+		CheckedAt:   status.CheckedAt,
+	}
+	if !status.AppliedAt.IsZero() {
+		appliedAt := status.AppliedAt
+		resp.AppliedAt = &appliedAt
+	}
+	return resp
+}
+
 func convertStackToRespStack(st Stack) RespStack {
 	events := make([]RespStackEvent, 0, len(st.Events))
 	for _, ev := range st.Events {
@@ -401,6 +619,20 @@ func convertStackEvent(ev StackEvent) RespStackEvent {
 		})
 	}
 
+	var cfg *RespStackSync
+	if ev.Sync != nil {
+		cfg = &RespStackSync{
+			URL:              ev.Sync.URL,
+			Branch:           ev.Sync.Branch,
+			Path:             ev.Sync.Path,
+			Interval:         ev.Sync.interval().String(),
+			AutoApply:        ev.Sync.AutoApply,
+			WebhookSecretKey: ev.Sync.WebhookSecretKey,
+			UserID:           ev.Sync.UserID.String(),
+			TemplateURL:      ev.Sync.TemplateURL(),
+		}
+	}
+
 	return RespStackEvent{
 		EventType:   ev.EventType.String(),
 		Name:        ev.Name,
@@ -408,12 +640,13 @@ func convertStackEvent(ev StackEvent) RespStackEvent {
 		Resources:   resources,
 		Sources:     append([]string{}, ev.Sources...),
 		URLs:        append([]string{}, ev.TemplateURLs...),
+		Sync:        cfg,
 		UpdatedAt:   ev.UpdatedAt,
 	}
 }
 
 func stackResLinks(r StackResource) RespStackResourceLinks {
-	var linkResource string
+	prefix, linkResource := "/api/v2", ""
 	switch r.Kind {
 	case KindBucket:
 		linkResource = "buckets"
@@ -430,6 +663,14 @@ func stackResLinks(r StackResource) RespStackResourceLinks {
 		linkResource = "notificationEndpoints"
 	case KindNotificationRule:
 		linkResource = "notificationRules"
+	case KindNotebook:
+		prefix, linkResource = "/api/v2private", "notebooks"
+	case KindDBRP:
+		linkResource = "dbrps"
+	case KindRemote:
+		linkResource = "remotes"
+	case KindReplication:
+		linkResource = "replications"
 	case KindTask:
 		linkResource = "tasks"
 	case KindTelegraf:
@@ -438,7 +679,7 @@ func stackResLinks(r StackResource) RespStackResourceLinks {
 		linkResource = "variables"
 	}
 	return RespStackResourceLinks{
-		Self: path.Join("/api/v2", linkResource, r.ID.String()),
+		Self: path.Join(prefix, linkResource, r.ID.String()),
 	}
 }
 
@@ -1566,6 +1566,10 @@ func (f *fakeSVC) UninstallStack(ctx context.Context, identifiers struct{ OrgID,
 	panic("not implemented")
 }
 
+func (f *fakeSVC) RollbackStack(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (pkger.ImpactSummary, error) {
+	panic("not implemented")
+}
+
 func (f *fakeSVC) DeleteStack(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) error {
 	panic("not implemented yet")
 }
@@ -314,6 +314,9 @@ type RespApply struct {
 	StackID string   `json:"stackID" yaml:"stackID"`
 	Diff    Diff     `json:"diff" yaml:"diff"`
 	Summary Summary  `json:"summary" yaml:"summary"`
+	// Impacts are the resources outside of the template a dry run finds the
+	// application would break.
+	Impacts []DownstreamImpact `json:"impacts" yaml:"impacts"`
 
 	Errors []ValidationErr `json:"errors,omitempty" yaml:"errors,omitempty"`
 }
@@ -469,6 +472,7 @@ func impactToRespApply(impact ImpactSummary, err error) RespApply {
 		StackID: impact.StackID.String(),
 		Diff:    impact.Diff,
 		Summary: impact.Summary,
+		Impacts: append([]DownstreamImpact{}, impact.Impacts...),
 	}
 	if err != nil {
 		out.Errors = convertParseErr(err)
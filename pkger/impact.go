@@ -0,0 +1,324 @@
+package pkger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/parser"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/task/taskmodel"
+)
+
+// DownstreamImpact is a resource which is not part of the template, that the
+// application of the template would break.
+type DownstreamImpact struct {
+	ResourceType influxdb.ResourceType `json:"resourceType"`
+	ID           SafeID                `json:"id"`
+	Name         string                `json:"name"`
+	// BucketMetaName is the template bucket whose change impacts the resource.
+	BucketMetaName string `json:"bucketTemplateMetaName"`
+	Reason         string `json:"reason"`
+}
+
+// bucketChange is a bucket which is renamed or removed by a template.
+type bucketChange struct {
+	id       platform.ID
+	metaName string
+	oldName  string
+	newName  string
+	removed  bool
+}
+
+func (c bucketChange) reason() string {
+	if c.removed {
+		return fmt.Sprintf("bucket %q is removed", c.oldName)
+	}
+	return fmt.Sprintf("bucket %q is renamed to %q", c.oldName, c.newName)
+}
+
+// downstreamImpact finds the resources outside of the template which reference
+// a bucket the template renames or removes, and the dbrp mappings the template
+// conflicts with. The tasks and dashboards of the template are not considered,
+// their queries are replaced by the template.
+func (s *Service) downstreamImpact(ctx context.Context, orgID platform.ID, state *stateCoordinator) ([]DownstreamImpact, error) {
+	var changes []bucketChange
+	for _, b := range state.mBuckets {
+		if b.existing == nil {
+			continue
+		}
+		c := bucketChange{
+			id:       b.existing.ID,
+			metaName: b.parserBkt.MetaName(),
+			oldName:  b.existing.Name,
+			newName:  b.parserBkt.Name(),
+			removed:  IsRemoval(b.stateStatus),
+		}
+		if c.removed || c.oldName != c.newName {
+			changes = append(changes, c)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].metaName < changes[j].metaName
+	})
+
+	var impacts []DownstreamImpact
+	if len(changes) > 0 {
+		taskImpacts, err := s.taskImpact(ctx, orgID, state, changes)
+		if err != nil {
+			return nil, err
+		}
+		impacts = append(impacts, taskImpacts...)
+
+		dashImpacts, err := s.dashboardImpact(ctx, orgID, state, changes)
+		if err != nil {
+			return nil, err
+		}
+		impacts = append(impacts, dashImpacts...)
+
+		authImpacts, err := s.authorizationImpact(ctx, orgID, changes)
+		if err != nil {
+			return nil, err
+		}
+		impacts = append(impacts, authImpacts...)
+	}
+
+	dbrpImpacts, err := s.dbrpImpact(ctx, orgID, state, changes)
+	if err != nil {
+		return nil, err
+	}
+	return append(impacts, dbrpImpacts...), nil
+}
+
+func (s *Service) taskImpact(ctx context.Context, orgID platform.ID, state *stateCoordinator, changes []bucketChange) ([]DownstreamImpact, error) {
+	if s.taskSVC == nil {
+		return nil, nil
+	}
+
+	managed := make(map[platform.ID]bool)
+	for _, t := range state.mTasks {
+		managed[t.ID()] = true
+	}
+
+	var (
+		impacts []DownstreamImpact
+		filter  = taskmodel.TaskFilter{OrganizationID: &orgID, Limit: taskmodel.TaskDefaultPageSize}
+	)
+	for {
+		tasks, _, err := s.taskSVC.FindTasks(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tasks {
+			if managed[t.ID] {
+				continue
+			}
+			names := fluxBucketNames(t.Flux)
+			for _, c := range changes {
+				if names[c.oldName] {
+					impacts = append(impacts, DownstreamImpact{
+						ResourceType:   influxdb.TasksResourceType,
+						ID:             SafeID(t.ID),
+						Name:           t.Name,
+						BucketMetaName: c.metaName,
+						Reason:         "task queries " + c.reason(),
+					})
+				}
+			}
+		}
+		if len(tasks) < taskmodel.TaskDefaultPageSize {
+			return impacts, nil
+		}
+		after := tasks[len(tasks)-1].ID
+		filter.After = &after
+	}
+}
+
+func (s *Service) dashboardImpact(ctx context.Context, orgID platform.ID, state *stateCoordinator, changes []bucketChange) ([]DownstreamImpact, error) {
+	if s.dashSVC == nil {
+		return nil, nil
+	}
+
+	managed := make(map[platform.ID]bool)
+	for _, d := range state.mDashboards {
+		managed[d.ID()] = true
+	}
+
+	dashes, _, err := s.dashSVC.FindDashboards(ctx, influxdb.DashboardFilter{OrganizationID: &orgID}, influxdb.DefaultDashboardFindOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var impacts []DownstreamImpact
+	for _, d := range dashes {
+		if managed[d.ID] {
+			continue
+		}
+		names := make(map[string]bool)
+		for _, cell := range d.Cells {
+			v, err := s.dashSVC.GetDashboardCellView(ctx, d.ID, cell.ID)
+			if err != nil {
+				continue
+			}
+			for name := range viewBucketNames(v) {
+				names[name] = true
+			}
+		}
+		for _, c := range changes {
+			if names[c.oldName] {
+				impacts = append(impacts, DownstreamImpact{
+					ResourceType:   influxdb.DashboardsResourceType,
+					ID:             SafeID(d.ID),
+					Name:           d.Name,
+					BucketMetaName: c.metaName,
+					Reason:         "dashboard cell queries " + c.reason(),
+				})
+			}
+		}
+	}
+	return impacts, nil
+}
+
+// authorizationImpact finds the tokens with permissions on removed buckets.
+// Permissions are granted by ID, which renames do not change.
+func (s *Service) authorizationImpact(ctx context.Context, orgID platform.ID, changes []bucketChange) ([]DownstreamImpact, error) {
+	if s.authSVC == nil {
+		return nil, nil
+	}
+
+	auths, _, err := s.authSVC.FindAuthorizations(ctx, influxdb.AuthorizationFilter{OrgID: &orgID})
+	if err != nil {
+		return nil, err
+	}
+
+	var impacts []DownstreamImpact
+	for _, a := range auths {
+		for _, c := range changes {
+			if !c.removed {
+				continue
+			}
+			for _, p := range a.Permissions {
+				if p.Resource.Type != influxdb.BucketsResourceType || p.Resource.ID == nil || *p.Resource.ID != c.id {
+					continue
+				}
+				impacts = append(impacts, DownstreamImpact{
+					ResourceType:   influxdb.AuthorizationsResourceType,
+					ID:             SafeID(a.ID),
+					Name:           a.Description,
+					BucketMetaName: c.metaName,
+					Reason:         fmt.Sprintf("token has %s permission on %s", p.Action, c.reason()),
+				})
+			}
+		}
+	}
+	return impacts, nil
+}
+
+// dbrpImpact finds the dbrp mappings of removed buckets, and the mappings
+// which take precedence over the database and retention policy the name of a
+// new or renamed bucket maps to.
+func (s *Service) dbrpImpact(ctx context.Context, orgID platform.ID, state *stateCoordinator, changes []bucketChange) ([]DownstreamImpact, error) {
+	if s.dbrpSVC == nil {
+		return nil, nil
+	}
+
+	virtual := false
+	mappings, _, err := s.dbrpSVC.FindMany(ctx, influxdb.DBRPMappingFilter{OrgID: &orgID, Virtual: &virtual})
+	if err != nil {
+		return nil, err
+	}
+
+	var impacts []DownstreamImpact
+	for _, m := range mappings {
+		name := m.Database + "/" + m.RetentionPolicy
+		for _, c := range changes {
+			if c.removed && m.BucketID == c.id {
+				impacts = append(impacts, DownstreamImpact{
+					ResourceType:   influxdb.DBRPResourceType,
+					ID:             SafeID(m.ID),
+					Name:           name,
+					BucketMetaName: c.metaName,
+					Reason:         "dbrp mapping points at " + c.reason(),
+				})
+			}
+		}
+
+		for _, b := range state.mBuckets {
+			if IsRemoval(b.stateStatus) || b.parserBkt.Name() != name || m.BucketID == b.ID() {
+				continue
+			}
+			if b.existing != nil && b.existing.Name == name {
+				continue
+			}
+			impacts = append(impacts, DownstreamImpact{
+				ResourceType:   influxdb.DBRPResourceType,
+				ID:             SafeID(m.ID),
+				Name:           name,
+				BucketMetaName: b.parserBkt.MetaName(),
+				Reason:         fmt.Sprintf("dbrp mapping of %q points at another bucket, v1 queries will not read bucket %q", name, name),
+			})
+		}
+	}
+	return impacts, nil
+}
+
+// viewBucketNames returns the names of the buckets the queries of a cell view read.
+func viewBucketNames(v *influxdb.View) map[string]bool {
+	names := make(map[string]bool)
+	if v == nil || v.Properties == nil {
+		return names
+	}
+
+	b, err := json.Marshal(v.Properties)
+	if err != nil {
+		return names
+	}
+	var props struct {
+		Queries []influxdb.DashboardQuery `json:"queries"`
+	}
+	if err := json.Unmarshal(b, &props); err != nil {
+		return names
+	}
+
+	for _, q := range props.Queries {
+		for _, name := range q.BuilderConfig.Buckets {
+			names[name] = true
+		}
+		for name := range fluxBucketNames(q.Text) {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// fluxBucketNames returns the buckets a flux script names in the bucket
+// parameter of its calls, such as from(bucket: "name").
+func fluxBucketNames(source string) map[string]bool {
+	names := make(map[string]bool)
+	if strings.TrimSpace(source) == "" {
+		return names
+	}
+	ast.Visit(parser.ParseSource(source), func(n ast.Node) {
+		call, ok := n.(*ast.CallExpression)
+		if !ok || len(call.Arguments) != 1 {
+			return
+		}
+		args, ok := call.Arguments[0].(*ast.ObjectExpression)
+		if !ok {
+			return
+		}
+		for _, p := range args.Properties {
+			if p.Key.Key() != "bucket" {
+				continue
+			}
+			if name, ok := p.Value.(*ast.StringLiteral); ok && name.Value != "" {
+				names[name.Value] = true
+			}
+		}
+	})
+	return names
+}
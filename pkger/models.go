@@ -21,13 +21,17 @@ const (
 	KindCheckDeadman                  Kind = "CheckDeadman"
 	KindCheckThreshold                Kind = "CheckThreshold"
 	KindDashboard                     Kind = "Dashboard"
+	KindDBRP                          Kind = "DBRP"
 	KindLabel                         Kind = "Label"
 	KindNotificationEndpoint          Kind = "NotificationEndpoint"
 	KindNotificationEndpointHTTP      Kind = "NotificationEndpointHTTP"
 	KindNotificationEndpointPagerDuty Kind = "NotificationEndpointPagerDuty"
 	KindNotificationEndpointSlack     Kind = "NotificationEndpointSlack"
 	KindNotificationRule              Kind = "NotificationRule"
+	KindNotebook                      Kind = "Notebook"
 	KindPackage                       Kind = "Package"
+	KindRemote                        Kind = "Remote"
+	KindReplication                   Kind = "Replication"
 	KindTask                          Kind = "Task"
 	KindTelegraf                      Kind = "Telegraf"
 	KindVariable                      Kind = "Variable"
@@ -48,12 +52,16 @@ var kinds = map[Kind]bool{
 	KindCheckDeadman:                  true,
 	KindCheckThreshold:                true,
 	KindDashboard:                     true,
+	KindDBRP:                          true,
 	KindLabel:                         true,
 	KindNotificationEndpoint:          true,
 	KindNotificationEndpointHTTP:      true,
 	KindNotificationEndpointPagerDuty: true,
 	KindNotificationEndpointSlack:     true,
 	KindNotificationRule:              true,
+	KindNotebook:                      true,
+	KindRemote:                        true,
+	KindReplication:                   true,
 	KindTask:                          true,
 	KindTelegraf:                      true,
 	KindVariable:                      true,
@@ -93,6 +101,8 @@ func (k Kind) ResourceType() influxdb.ResourceType {
 		return influxdb.ChecksResourceType
 	case KindDashboard:
 		return influxdb.DashboardsResourceType
+	case KindDBRP:
+		return influxdb.DBRPResourceType
 	case KindLabel:
 		return influxdb.LabelsResourceType
 	case KindNotificationEndpoint,
@@ -102,6 +112,12 @@ func (k Kind) ResourceType() influxdb.ResourceType {
 		return influxdb.NotificationEndpointResourceType
 	case KindNotificationRule:
 		return influxdb.NotificationRuleResourceType
+	case KindNotebook:
+		return influxdb.NotebooksResourceType
+	case KindRemote:
+		return influxdb.RemotesResourceType
+	case KindReplication:
+		return influxdb.ReplicationsResourceType
 	case KindTask:
 		return influxdb.TasksResourceType
 	case KindTelegraf:
@@ -158,10 +174,14 @@ type Diff struct {
 	Buckets               []DiffBucket               `json:"buckets"`
 	Checks                []DiffCheck                `json:"checks"`
 	Dashboards            []DiffDashboard            `json:"dashboards"`
+	DBRPs                 []DiffDBRP                 `json:"dbrps"`
 	Labels                []DiffLabel                `json:"labels"`
 	LabelMappings         []DiffLabelMapping         `json:"labelMappings"`
 	NotificationEndpoints []DiffNotificationEndpoint `json:"notificationEndpoints"`
 	NotificationRules     []DiffNotificationRule     `json:"notificationRules"`
+	Notebooks             []DiffNotebook             `json:"notebooks"`
+	Remotes               []DiffRemote               `json:"remotes"`
+	Replications          []DiffReplication          `json:"replications"`
 	Tasks                 []DiffTask                 `json:"tasks"`
 	Telegrafs             []DiffTelegraf             `json:"telegrafConfigs"`
 	Variables             []DiffVariable             `json:"variables"`
@@ -191,6 +211,85 @@ func (d Diff) HasConflicts() bool {
 	return false
 }
 
+// hasChanges returns true when applying the package would create or remove
+// resources or label mappings, or update buckets, dbrp mappings, labels,
+// remotes, replications or variables. The diffs of the other resources
+// compare the package to the platform resource in the form the platform stores
+// it, such as the flux of a task with its options, which can't tell updates
+// apart.
+func (d Diff) hasChanges() bool {
+	var ids []DiffIdentifier
+	for _, b := range d.Buckets {
+		if b.hasConflict() {
+			return true
+		}
+		ids = append(ids, b.DiffIdentifier)
+	}
+	for _, dbrp := range d.DBRPs {
+		if dbrp.Old != nil && *dbrp.Old != dbrp.New {
+			return true
+		}
+		ids = append(ids, dbrp.DiffIdentifier)
+	}
+	for _, l := range d.Labels {
+		if l.hasConflict() {
+			return true
+		}
+		ids = append(ids, l.DiffIdentifier)
+	}
+	for _, r := range d.Remotes {
+		if r.Old != nil && !reflect.DeepEqual(*r.Old, r.New) {
+			return true
+		}
+		ids = append(ids, r.DiffIdentifier)
+	}
+	for _, r := range d.Replications {
+		if r.Old != nil && !reflect.DeepEqual(*r.Old, r.New) {
+			return true
+		}
+		ids = append(ids, r.DiffIdentifier)
+	}
+	for _, v := range d.Variables {
+		if v.hasConflict() {
+			return true
+		}
+		ids = append(ids, v.DiffIdentifier)
+	}
+	for _, c := range d.Checks {
+		ids = append(ids, c.DiffIdentifier)
+	}
+	for _, dash := range d.Dashboards {
+		ids = append(ids, dash.DiffIdentifier)
+	}
+	for _, e := range d.NotificationEndpoints {
+		ids = append(ids, e.DiffIdentifier)
+	}
+	for _, r := range d.NotificationRules {
+		ids = append(ids, r.DiffIdentifier)
+	}
+	for _, n := range d.Notebooks {
+		ids = append(ids, n.DiffIdentifier)
+	}
+	for _, t := range d.Tasks {
+		ids = append(ids, t.DiffIdentifier)
+	}
+	for _, t := range d.Telegrafs {
+		ids = append(ids, t.DiffIdentifier)
+	}
+
+	for _, id := range ids {
+		if id.StateStatus != StateStatusExists {
+			return true
+		}
+	}
+	for _, m := range d.LabelMappings {
+		if m.StateStatus != StateStatusExists {
+			return true
+		}
+	}
+	return false
+}
+
 type (
 	// DiffBucket is a diff of an individual bucket.
 	DiffBucket struct {
@@ -385,6 +484,84 @@ type (
 	}
 )
 
+type (
+	// DiffNotebook is a diff of an individual notebook.
+	DiffNotebook struct {
+		DiffIdentifier
+
+		New DiffNotebookValues  `json:"new"`
+		Old *DiffNotebookValues `json:"old"`
+	}
+
+	// DiffNotebookValues are the varying values for a notebook. Bucket
+	// references in the spec are identified by name only.
+	DiffNotebookValues struct {
+		Name string                `json:"name"`
+		Spec influxdb.NotebookSpec `json:"spec"`
+	}
+)
+
+type (
+	// DiffDBRP is a diff of an individual dbrp mapping.
+	DiffDBRP struct {
+		DiffIdentifier
+
+		New DiffDBRPValues  `json:"new"`
+		Old *DiffDBRPValues `json:"old"`
+	}
+
+	// DiffDBRPValues are the varying values for a dbrp mapping.
+	DiffDBRPValues struct {
+		Database        string `json:"database"`
+		RetentionPolicy string `json:"retentionPolicy"`
+		Default         bool   `json:"default"`
+		BucketName      string `json:"bucketName"`
+	}
+)
+
+type (
+	// DiffRemote is a diff of an individual remote connection.
+	DiffRemote struct {
+		DiffIdentifier
+
+		New DiffRemoteValues  `json:"new"`
+		Old *DiffRemoteValues `json:"old"`
+	}
+
+	// DiffRemoteValues are the varying values for a remote connection. The
+	// token of the remote is never part of the diff.
+	DiffRemoteValues struct {
+		Name             string `json:"name"`
+		Description      string `json:"description"`
+		URL              string `json:"url"`
+		RemoteOrgID      string `json:"remoteOrgID"`
+		AllowInsecureTLS bool   `json:"allowInsecureTLS"`
+	}
+)
+
+type (
+	// DiffReplication is a diff of an individual replication.
+	DiffReplication struct {
+		DiffIdentifier
+
+		New DiffReplicationValues  `json:"new"`
+		Old *DiffReplicationValues `json:"old"`
+	}
+
+	// DiffReplicationValues are the varying values for a replication.
+	DiffReplicationValues struct {
+		Name                 string `json:"name"`
+		Description          string `json:"description"`
+		RemoteName           string `json:"remoteName"`
+		BucketName           string `json:"bucketName"`
+		RemoteBucketID       string `json:"remoteBucketID"`
+		RemoteBucketName     string `json:"remoteBucketName"`
+		MaxQueueSizeBytes    int64  `json:"maxQueueSizeBytes"`
+		MaxAgeSeconds        int64  `json:"maxAgeSeconds"`
+		DropNonRetryableData bool   `json:"dropNonRetryableData"`
+	}
+)
+
 type (
 	// DiffTask is a diff of an individual task.
 	DiffTask struct {
@@ -441,12 +618,16 @@ type Summary struct {
 	Buckets               []SummaryBucket               `json:"buckets"`
 	Checks                []SummaryCheck                `json:"checks"`
 	Dashboards            []SummaryDashboard            `json:"dashboards"`
+	DBRPs                 []SummaryDBRP                 `json:"dbrps"`
 	NotificationEndpoints []SummaryNotificationEndpoint `json:"notificationEndpoints"`
 	NotificationRules     []SummaryNotificationRule     `json:"notificationRules"`
 	Labels                []SummaryLabel                `json:"labels"`
 	LabelMappings         []SummaryLabelMapping         `json:"labelMappings"`
 	MissingEnvs           []string                      `json:"missingEnvRefs"`
 	MissingSecrets        []string                      `json:"missingSecrets"`
+	Notebooks             []SummaryNotebook             `json:"notebooks"`
+	Remotes               []SummaryRemote               `json:"remotes"`
+	Replications          []SummaryReplication          `json:"replications"`
 	Tasks                 []SummaryTask                 `json:"summaryTask"`
 	TelegrafConfigs       []SummaryTelegraf             `json:"telegrafConfigs"`
 	Variables             []SummaryVariable             `json:"variables"`
@@ -688,6 +869,54 @@ type SummaryTask struct {
 	LabelAssociations []SummaryLabel `json:"labelAssociations"`
 }
 
+// SummaryNotebook provides a summary of a pkg notebook.
+type SummaryNotebook struct {
+	SummaryIdentifier
+	ID    SafeID                `json:"id,omitempty"`
+	OrgID SafeID                `json:"orgID,omitempty"`
+	Name  string                `json:"name"`
+	Spec  influxdb.NotebookSpec `json:"spec"`
+}
+
+// SummaryDBRP provides a summary of a pkg dbrp mapping.
+type SummaryDBRP struct {
+	SummaryIdentifier
+	ID              SafeID `json:"id,omitempty"`
+	OrgID           SafeID `json:"orgID,omitempty"`
+	Database        string `json:"database"`
+	RetentionPolicy string `json:"retentionPolicy"`
+	Default         bool   `json:"default"`
+	BucketName      string `json:"bucketName"`
+}
+
+// SummaryRemote provides a summary of a pkg remote connection.
+type SummaryRemote struct {
+	SummaryIdentifier
+	ID               SafeID `json:"id,omitempty"`
+	OrgID            SafeID `json:"orgID,omitempty"`
+	Name             string `json:"name"`
+	Description      string `json:"description"`
+	URL              string `json:"url"`
+	RemoteOrgID      string `json:"remoteOrgID"`
+	AllowInsecureTLS bool   `json:"allowInsecureTLS"`
+}
+
+// SummaryReplication provides a summary of a pkg replication.
+type SummaryReplication struct {
+	SummaryIdentifier
+	ID                   SafeID `json:"id,omitempty"`
+	OrgID                SafeID `json:"orgID,omitempty"`
+	Name                 string `json:"name"`
+	Description          string `json:"description"`
+	RemoteMetaName       string `json:"remoteTemplateMetaName"`
+	BucketName           string `json:"bucketName"`
+	RemoteBucketID       string `json:"remoteBucketID"`
+	RemoteBucketName     string `json:"remoteBucketName"`
+	MaxQueueSizeBytes    int64  `json:"maxQueueSizeBytes"`
+	MaxAgeSeconds        int64  `json:"maxAgeSeconds"`
+	DropNonRetryableData bool   `json:"dropNonRetryableData"`
+}
+
 // SummaryTelegraf provides a summary of a pkg telegraf config.
 type SummaryTelegraf struct {
 	SummaryIdentifier
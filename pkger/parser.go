@@ -22,6 +22,7 @@ import (
 	"github.com/influxdata/flux/ast/edit"
 	fluxurl "github.com/influxdata/flux/dependencies/url"
 	"github.com/influxdata/flux/parser"
+	"github.com/influxdata/influxdb/v2/kit/platform"
 	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/pkg/jsonnet"
 	"github.com/influxdata/influxdb/v2/task/options"
@@ -384,11 +385,16 @@ type Template struct {
 	sources []string
 
 	mLabels                map[string]*label
+	mStackLabels           map[string]*label
 	mBuckets               map[string]*bucket
 	mChecks                map[string]*check
 	mDashboards            map[string]*dashboard
+	mDBRPs                 map[string]*dbrp
 	mNotificationEndpoints map[string]*notificationEndpoint
 	mNotificationRules     map[string]*notificationRule
+	mNotebooks             map[string]*notebook
+	mRemotes               map[string]*remote
+	mReplications          map[string]*replication
 	mTasks                 map[string]*task
 	mTelegrafs             map[string]*telegraf
 	mVariables             map[string]*variable
@@ -396,6 +402,9 @@ type Template struct {
 	mEnv     map[string]bool
 	mEnvVals map[string]interface{}
 	mSecrets map[string]bool
+	// mEnvSubs are the ${ENV_VAR} substitutions of the template, and if they
+	// are required, that is, declared without a default.
+	mEnvSubs map[string]bool
 
 	isParsed bool // indicates the pkg has been parsed and all resources graphed accordingly
 }
@@ -447,11 +456,15 @@ func (p *Template) Summary() Summary {
 		Buckets:               []SummaryBucket{},
 		Checks:                []SummaryCheck{},
 		Dashboards:            []SummaryDashboard{},
+		DBRPs:                 []SummaryDBRP{},
 		NotificationEndpoints: []SummaryNotificationEndpoint{},
 		NotificationRules:     []SummaryNotificationRule{},
 		Labels:                []SummaryLabel{},
 		MissingEnvs:           p.missingEnvRefs(),
 		MissingSecrets:        p.missingSecrets(),
+		Notebooks:             []SummaryNotebook{},
+		Remotes:               []SummaryRemote{},
+		Replications:          []SummaryReplication{},
 		Tasks:                 []SummaryTask{},
 		TelegrafConfigs:       []SummaryTelegraf{},
 		Variables:             []SummaryVariable{},
@@ -483,6 +496,22 @@ func (p *Template) Summary() Summary {
 		sum.NotificationRules = append(sum.NotificationRules, r.summarize())
 	}
 
+	for _, n := range p.notebooks() {
+		sum.Notebooks = append(sum.Notebooks, n.summarize())
+	}
+
+	for _, d := range p.dbrps() {
+		sum.DBRPs = append(sum.DBRPs, d.summarize())
+	}
+
+	for _, r := range p.remotes() {
+		sum.Remotes = append(sum.Remotes, r.summarize())
+	}
+
+	for _, r := range p.replications() {
+		sum.Replications = append(sum.Replications, r.summarize())
+	}
+
 	for _, t := range p.tasks() {
 		sum.Tasks = append(sum.Tasks, t.summarize())
 	}
@@ -542,6 +571,18 @@ func (p *Template) Contains(k Kind, pkgName string) bool {
 	case KindNotificationRule:
 		_, ok := p.mNotificationRules[pkgName]
 		return ok
+	case KindNotebook:
+		_, ok := p.mNotebooks[pkgName]
+		return ok
+	case KindDBRP:
+		_, ok := p.mDBRPs[pkgName]
+		return ok
+	case KindRemote:
+		_, ok := p.mRemotes[pkgName]
+		return ok
+	case KindReplication:
+		_, ok := p.mReplications[pkgName]
+		return ok
 	case KindTask:
 		_, ok := p.mTasks[pkgName]
 		return ok
@@ -671,6 +712,19 @@ func (p *Template) labels() []*label {
 	return labels
 }
 
+// stackLabels returns the labels of other stacks the resources of the template
+// are associated with.
+func (p *Template) stackLabels() []*label {
+	labels := make(sortedLabels, 0, len(p.mStackLabels))
+	for _, l := range p.mStackLabels {
+		labels = append(labels, l)
+	}
+
+	sort.Sort(labels)
+
+	return labels
+}
+
 func (p *Template) dashboards() []*dashboard {
 	dashes := make([]*dashboard, 0, len(p.mDashboards))
 	for _, d := range p.mDashboards {
@@ -715,6 +769,19 @@ func (p *Template) missingEnvRefs() []string {
 	return envRefs
 }
 
+// missingEnvSubstitutions returns the ${ENV_VAR} substitutions which have
+// no default and are not provided.
+func (p *Template) missingEnvSubstitutions() []string {
+	missing := make([]string, 0)
+	for name, required := range p.mEnvSubs {
+		if required && p.mEnvVals[name] == nil {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
 func (p *Template) missingSecrets() []string {
 	secrets := make([]string, 0, len(p.mSecrets))
 	for secret, foundInPlatform := range p.mSecrets {
@@ -737,6 +804,50 @@ func (p *Template) tasks() []*task {
 	return tasks
 }
 
+func (p *Template) notebooks() []*notebook {
+	notebooks := make([]*notebook, 0, len(p.mNotebooks))
+	for _, n := range p.mNotebooks {
+		notebooks = append(notebooks, n)
+	}
+
+	sort.Slice(notebooks, func(i, j int) bool { return notebooks[i].MetaName() < notebooks[j].MetaName() })
+
+	return notebooks
+}
+
+func (p *Template) dbrps() []*dbrp {
+	dbrps := make([]*dbrp, 0, len(p.mDBRPs))
+	for _, d := range p.mDBRPs {
+		dbrps = append(dbrps, d)
+	}
+
+	sort.Slice(dbrps, func(i, j int) bool { return dbrps[i].MetaName() < dbrps[j].MetaName() })
+
+	return dbrps
+}
+
+func (p *Template) remotes() []*remote {
+	remotes := make([]*remote, 0, len(p.mRemotes))
+	for _, r := range p.mRemotes {
+		remotes = append(remotes, r)
+	}
+
+	sort.Slice(remotes, func(i, j int) bool { return remotes[i].MetaName() < remotes[j].MetaName() })
+
+	return remotes
+}
+
+func (p *Template) replications() []*replication {
+	replications := make([]*replication, 0, len(p.mReplications))
+	for _, r := range p.mReplications {
+		replications = append(replications, r)
+	}
+
+	sort.Slice(replications, func(i, j int) bool { return replications[i].MetaName() < replications[j].MetaName() })
+
+	return replications
+}
+
 func (p *Template) telegrafs() []*telegraf {
 	teles := make([]*telegraf, 0, len(p.mTelegrafs))
 	for _, t := range p.mTelegrafs {
@@ -811,6 +922,7 @@ func (p *Template) validResources() error {
 
 func (p *Template) graphResources() error {
 	p.mEnv = make(map[string]bool)
+	p.mEnvSubs = make(map[string]bool)
 	p.mSecrets = make(map[string]bool)
 
 	graphFns := []func() *parseErr{
@@ -822,6 +934,11 @@ func (p *Template) graphResources() error {
 		p.graphDashboards,
 		p.graphNotificationEndpoints,
 		p.graphNotificationRules,
+		p.graphNotebooks,
+		p.graphDBRPs,
+		p.graphRemotes,
+		// replications are after remotes, to validate they write to a remote of the template
+		p.graphReplications,
 		p.graphTasks,
 		p.graphTelegrafs,
 	}
@@ -900,6 +1017,7 @@ func (p *Template) graphBuckets() *parseErr {
 
 func (p *Template) graphLabels() *parseErr {
 	p.mLabels = make(map[string]*label)
+	p.mStackLabels = make(map[string]*label)
 	tracker := p.trackNames(true)
 	return p.eachResource(KindLabel, func(o Object) []validationErr {
 		ident, errs := tracker(o)
@@ -1190,6 +1308,7 @@ func (p *Template) graphTasks() *parseErr {
 				Msg:   err.Error(),
 			})
 		}
+		t.secrets = taskSecrets(o.Spec.slcStr(fieldTaskSecretRefs), t.query.Query)
 
 		if o.APIVersion == APIVersion2 {
 			for _, ref := range t.query.task {
@@ -1234,6 +1353,134 @@ func (p *Template) graphTasks() *parseErr {
 	})
 }
 
+// taskSecrets returns the secrets declared by the secret references of a
+// task, and the secrets its query gets.
+func taskSecrets(declared []string, query string) []string {
+	mSecrets := make(map[string]bool)
+	for _, k := range declared {
+		if k != "" {
+			mSecrets[k] = true
+		}
+	}
+	for _, k := range fluxSecretKeys(query) {
+		mSecrets[k] = true
+	}
+
+	secrets := make([]string, 0, len(mSecrets))
+	for k := range mSecrets {
+		secrets = append(secrets, k)
+	}
+	sort.Strings(secrets)
+	return secrets
+}
+
+func (p *Template) graphNotebooks() *parseErr {
+	p.mNotebooks = make(map[string]*notebook)
+	tracker := p.trackNames(false)
+	return p.eachResource(KindNotebook, func(o Object) []validationErr {
+		ident, errs := tracker(o)
+		if len(errs) > 0 {
+			return errs
+		}
+
+		nb := &notebook{
+			identity: ident,
+			spec:     make(map[string]interface{}),
+		}
+		// everything in the spec beyond the name is the notebook's own spec,
+		// yaml maps are normalized so they can be stored as json.
+		for k, v := range o.Spec {
+			if k == fieldName || k == fieldAssociations {
+				continue
+			}
+			nb.spec[k] = normalizeNotebookValue(v)
+		}
+
+		p.mNotebooks[nb.MetaName()] = nb
+		p.setRefs(nb.name, nb.displayName)
+
+		return nb.valid()
+	})
+}
+
+func (p *Template) graphDBRPs() *parseErr {
+	p.mDBRPs = make(map[string]*dbrp)
+	tracker := p.trackNames(false)
+	return p.eachResource(KindDBRP, func(o Object) []validationErr {
+		ident, errs := tracker(o)
+		if len(errs) > 0 {
+			return errs
+		}
+
+		d := &dbrp{
+			identity:        ident,
+			database:        o.Spec.stringShort(fieldDBRPDatabase),
+			retentionPolicy: o.Spec.stringShort(fieldDBRPRetentionPolicy),
+			isDefault:       o.Spec.boolShort(fieldDefault),
+			bucketName:      o.Spec.stringShort(fieldDBRPBucketName),
+		}
+
+		p.mDBRPs[d.MetaName()] = d
+		p.setRefs(d.name, d.displayName)
+
+		return d.valid()
+	})
+}
+
+func (p *Template) graphRemotes() *parseErr {
+	p.mRemotes = make(map[string]*remote)
+	tracker := p.trackNames(true)
+	return p.eachResource(KindRemote, func(o Object) []validationErr {
+		ident, errs := tracker(o)
+		if len(errs) > 0 {
+			return errs
+		}
+
+		r := &remote{
+			identity:         ident,
+			description:      o.Spec.stringShort(fieldDescription),
+			url:              o.Spec.stringShort(fieldRemoteURL),
+			remoteOrgID:      o.Spec.stringShort(fieldRemoteOrgID),
+			token:            p.getRefWithKnownEnvs(o.Spec, fieldRemoteToken),
+			allowInsecureTLS: o.Spec.boolShort(fieldRemoteAllowInsecureTLS),
+		}
+
+		p.mRemotes[r.MetaName()] = r
+		p.setRefs(r.name, r.displayName, r.token)
+
+		return r.valid()
+	})
+}
+
+func (p *Template) graphReplications() *parseErr {
+	p.mReplications = make(map[string]*replication)
+	tracker := p.trackNames(true)
+	return p.eachResource(KindReplication, func(o Object) []validationErr {
+		ident, errs := tracker(o)
+		if len(errs) > 0 {
+			return errs
+		}
+
+		r := &replication{
+			identity:             ident,
+			description:          o.Spec.stringShort(fieldDescription),
+			remoteName:           p.getRefWithKnownEnvs(o.Spec, fieldReplicationRemoteName),
+			bucketName:           o.Spec.stringShort(fieldReplicationBucketName),
+			remoteBucketID:       o.Spec.stringShort(fieldReplicationRemoteBucketID),
+			remoteBucketName:     o.Spec.stringShort(fieldReplicationRemoteBucketName),
+			maxQueueSizeBytes:    int64(o.Spec.intShort(fieldReplicationMaxQueueSizeBytes)),
+			maxAgeSeconds:        int64(o.Spec.intShort(fieldReplicationMaxAgeSeconds)),
+			dropNonRetryableData: o.Spec.boolShort(fieldReplicationDropNonRetryableData),
+		}
+		r.associatedRemote = p.mRemotes[r.remoteName.String()]
+
+		p.mReplications[r.MetaName()] = r
+		p.setRefs(r.name, r.displayName, r.remoteName)
+
+		return r.valid()
+	})
+}
+
 func (p *Template) graphTelegrafs() *parseErr {
 	p.mTelegrafs = make(map[string]*telegraf)
 	tracker := p.trackNames(false)
@@ -1322,6 +1569,7 @@ func (p *Template) eachResource(resourceKind Kind, fn func(o Object) []validatio
 		if !k.Kind.is(resourceKind) {
 			continue
 		}
+		k = p.substituteEnvs(k)
 
 		if k.APIVersion != APIVersion && k.APIVersion != APIVersion2 {
 			pErr.append(resourceErr{
@@ -1419,6 +1667,28 @@ func (p *Template) parseNestedLabel(nr Resource, fn func(lb *label) error) *vali
 	}
 
 	nameRef := p.getRefWithKnownEnvs(nr, fieldName)
+	if rawStackID := nr.stringShort(fieldStackID); rawStackID != "" {
+		stackID, err := platform.IDFromString(rawStackID)
+		if err != nil {
+			return &validationErr{
+				Field: fieldAssociations,
+				Nested: []validationErr{
+					{
+						Field: fieldStackID,
+						Msg:   fmt.Sprintf("invalid stack ID %q", rawStackID),
+					},
+				},
+			}
+		}
+		if err := fn(p.stackLabel(*stackID, nameRef.String())); err != nil {
+			return &validationErr{
+				Field: fieldAssociations,
+				Msg:   err.Error(),
+			}
+		}
+		return nil
+	}
+
 	lb, found := p.mLabels[nameRef.String()]
 	if !found {
 		return &validationErr{
@@ -1436,6 +1706,21 @@ func (p *Template) parseNestedLabel(nr Resource, fn func(lb *label) error) *vali
 	return nil
 }
 
+// stackLabel returns the label of another stack, which the resources of the
+// template reference by the stack and the name of the label in its template.
+func (p *Template) stackLabel(stackID platform.ID, metaName string) *label {
+	key := stackLabelKey(stackID, metaName)
+	if l, ok := p.mStackLabels[key]; ok {
+		return l
+	}
+	l := &label{
+		identity: identity{name: &references{val: metaName}},
+		stackID:  stackID,
+	}
+	p.mStackLabels[key] = l
+	return l
+}
+
 func (p *Template) trackNames(resourceUniqueByName bool) func(Object) (identity, []validationErr) {
 	mPkgNames := make(map[string]bool)
 	uniqNames := make(map[string]bool)
@@ -1494,6 +1779,116 @@ func (p *Template) setRefs(refs ...*references) {
 	}
 }
 
+// envSubstitutionRegex matches the ${ENV_VAR} and ${ENV_VAR:-default}
+// substitutions of templates. Only upper case names are substituted, which
+// leaves the ${r._value} interpolations of flux strings alone. A $${ENV_VAR}
+// escapes the substitution.
+var envSubstitutionRegex = regexp.MustCompile(`\$?\$\{([A-Z_][A-Z0-9_]*)(:-([^}]*))?\}`)
+
+// substituteEnvs returns a copy of the object with the ${ENV_VAR}
+// substitutions of its metadata and spec replaced by the env refs the
+// template is applied with, or their defaults.
+func (p *Template) substituteEnvs(o Object) Object {
+	o.Metadata, _ = p.substituteEnvsIn(o.Metadata).(Resource)
+	o.Spec, _ = p.substituteEnvsIn(o.Spec).(Resource)
+	return o
+}
+
+func (p *Template) substituteEnvsIn(v interface{}) interface{} {
+	switch t := v.(type) {
+	case Resource:
+		if t == nil {
+			return t
+		}
+		out := make(Resource, len(t))
+		for k, val := range t {
+			out[k] = p.substituteEnvsIn(val)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			out[k] = p.substituteEnvsIn(val)
+		}
+		return out
+	case []Resource:
+		out := make([]Resource, len(t))
+		for i, val := range t {
+			out[i], _ = p.substituteEnvsIn(val).(Resource)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, val := range t {
+			out[i] = p.substituteEnvsIn(val)
+		}
+		return out
+	case string:
+		return p.substituteEnvsInString(t)
+	default:
+		return v
+	}
+}
+
+// substituteEnvsInString replaces the substitutions of s. A string which is
+// a single substitution takes the value of the env ref as is, so numbers and
+// booleans can be substituted into fields which are not strings.
+func (p *Template) substituteEnvsInString(s string) interface{} {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+
+	if m := envSubstitutionRegex.FindStringSubmatch(s); m != nil && m[0] == s && !strings.HasPrefix(s, "$$") {
+		v, ok := p.envSubstitution(m)
+		if !ok {
+			return s
+		}
+		if str, isStr := v.(string); isStr {
+			return literalToIface(str)
+		}
+		return v
+	}
+
+	return envSubstitutionRegex.ReplaceAllStringFunc(s, func(match string) string {
+		if strings.HasPrefix(match, "$$") {
+			return match[1:]
+		}
+		v, ok := p.envSubstitution(envSubstitutionRegex.FindStringSubmatch(match))
+		if !ok {
+			return match
+		}
+		return fmt.Sprint(v)
+	})
+}
+
+// envSubstitution returns the value of a matched substitution, and records
+// it on the template. It returns false when a required env ref is missing.
+func (p *Template) envSubstitution(m []string) (interface{}, bool) {
+	name, hasDefault, def := m[1], m[2] != "", m[3]
+	p.mEnvSubs[name] = p.mEnvSubs[name] || !hasDefault
+	if v := p.mEnvVals[name]; v != nil {
+		p.mEnv[name] = true
+		return v, true
+	}
+	if !hasDefault {
+		p.mEnv[name] = false
+		return nil, false
+	}
+	if _, ok := p.mEnv[name]; !ok {
+		p.mEnv[name] = true
+	}
+	return def, true
+}
+
+// literalToIface converts a substituted value to the integer it spells
+// exactly, and keeps any other value a string.
+func literalToIface(s string) interface{} {
+	if i, err := strconv.Atoi(s); err == nil && strconv.Itoa(i) == s {
+		return i
+	}
+	return s
+}
+
 func parseAxis(ra Resource, domain []float64) *axis {
 	return &axis{
 		Base:   ra.stringShort(fieldAxisBase),
@@ -14,6 +14,7 @@ import (
 	"github.com/influxdata/flux/ast/edit"
 	"github.com/influxdata/flux/parser"
 	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/notification"
 	icheck "github.com/influxdata/influxdb/v2/notification/check"
 	"github.com/influxdata/influxdb/v2/notification/endpoint"
@@ -74,6 +75,7 @@ const (
 	fieldQuery        = "query"
 	fieldSuffix       = "suffix"
 	fieldSpec         = "spec"
+	fieldStackID      = "stackID"
 	fieldStatus       = "status"
 	fieldType         = "type"
 	fieldValue        = "value"
@@ -1504,6 +1506,15 @@ type label struct {
 	Color       string
 	Description string
 	associationMapping
+
+	// stackID is the stack which owns the label, when the label is a
+	// dependency on another stack rather than a resource of the template.
+	stackID platform.ID
+}
+
+// stackLabelKey identifies the label of another stack.
+func stackLabelKey(stackID platform.ID, metaName string) string {
+	return stackID.String() + "/" + metaName
 }
 
 func (l *label) summarize() SummaryLabel {
@@ -2065,8 +2076,406 @@ func toSummaryTagRules(tagRules []struct{ k, v, op string }) []SummaryTagRule {
 }
 
 const (
-	fieldTaskCron = "cron"
-	fieldTask     = "task"
+	fieldNotebookPipes   = "pipes"
+	fieldNotebookBucket  = "bucket"
+	fieldNotebookBuckets = "buckets"
+	fieldNotebookID      = "id"
+)
+
+type notebook struct {
+	identity
+
+	spec map[string]interface{}
+}
+
+func (n *notebook) ResourceType() influxdb.ResourceType {
+	return KindNotebook.ResourceType()
+}
+
+func (n *notebook) summarize() SummaryNotebook {
+	return SummaryNotebook{
+		SummaryIdentifier: SummaryIdentifier{
+			Kind:          KindNotebook,
+			MetaName:      n.MetaName(),
+			EnvReferences: summarizeCommonReferences(n.identity, nil),
+		},
+		Name: n.Name(),
+		Spec: n.notebookSpec(),
+	}
+}
+
+// notebookSpec returns a copy of the notebook's spec, safe to be modified
+// when bucket references are resolved.
+func (n *notebook) notebookSpec() influxdb.NotebookSpec {
+	spec, _ := normalizeNotebookValue(n.spec).(map[string]interface{})
+	if spec == nil {
+		spec = make(map[string]interface{})
+	}
+	return spec
+}
+
+func (n *notebook) valid() []validationErr {
+	var vErrs []validationErr
+	if err, ok := isValidName(n.Name(), 1); !ok {
+		vErrs = append(vErrs, err)
+	}
+	if pipes, ok := n.spec[fieldNotebookPipes]; ok {
+		iPipes, ok := pipes.([]interface{})
+		if !ok {
+			vErrs = append(vErrs, validationErr{
+				Field: fieldNotebookPipes,
+				Msg:   "must be a list of pipes",
+			})
+		}
+		for i, p := range iPipes {
+			if _, ok := p.(map[string]interface{}); !ok {
+				vErrs = append(vErrs, validationErr{
+					Field: fieldNotebookPipes,
+					Index: intPtr(i),
+					Msg:   "pipe must be an object",
+				})
+			}
+		}
+	}
+
+	if len(vErrs) > 0 {
+		return []validationErr{
+			objectValidationErr(fieldSpec, vErrs...),
+		}
+	}
+
+	return nil
+}
+
+// normalizeNotebookValue returns a deep copy of v with every nested object
+// converted to a map[string]interface{}, so it may be encoded as json.
+func normalizeNotebookValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case []interface{}:
+		out := make([]interface{}, 0, len(val))
+		for _, vv := range val {
+			out = append(out, normalizeNotebookValue(vv))
+		}
+		return out
+	case []Resource:
+		out := make([]interface{}, 0, len(val))
+		for _, vv := range val {
+			out = append(out, normalizeNotebookValue(vv))
+		}
+		return out
+	case influxdb.NotebookSpec:
+		return normalizeNotebookValue(map[string]interface{}(val))
+	case map[string]interface{}, Resource, map[interface{}]interface{}:
+		res, _ := ifaceToResource(val)
+		out := make(map[string]interface{}, len(res))
+		for k, vv := range res {
+			out[k] = normalizeNotebookValue(vv)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// eachNotebookBucketRef calls fn for every bucket referenced by the pipes of a
+// notebook spec. Pipes reference buckets from their bucket or buckets fields,
+// by an object holding the bucket's name and id.
+func eachNotebookBucketRef(spec map[string]interface{}, fn func(ref map[string]interface{})) {
+	pipes, _ := spec[fieldNotebookPipes].([]interface{})
+	for _, p := range pipes {
+		pipe, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if ref, ok := pipe[fieldNotebookBucket].(map[string]interface{}); ok {
+			fn(ref)
+		}
+		refs, _ := pipe[fieldNotebookBuckets].([]interface{})
+		for _, r := range refs {
+			if ref, ok := r.(map[string]interface{}); ok {
+				fn(ref)
+			}
+		}
+	}
+}
+
+// notebookSpecWithoutBucketIDs returns a copy of the spec with the ids removed from
+// its bucket references, leaving them to be identified by name in any org.
+func notebookSpecWithoutBucketIDs(spec influxdb.NotebookSpec) influxdb.NotebookSpec {
+	out, _ := normalizeNotebookValue(spec).(map[string]interface{})
+	if out == nil {
+		return nil
+	}
+	eachNotebookBucketRef(out, func(ref map[string]interface{}) {
+		delete(ref, fieldNotebookID)
+	})
+	return out
+}
+
+const (
+	fieldDBRPBucketName      = "bucketName"
+	fieldDBRPDatabase        = "database"
+	fieldDBRPRetentionPolicy = "retentionPolicy"
+)
+
+type dbrp struct {
+	identity
+
+	database        string
+	retentionPolicy string
+	isDefault       bool
+	// bucketName is the template name of a bucket of the template, or the
+	// name of a bucket of the org.
+	bucketName string
+}
+
+func (d *dbrp) ResourceType() influxdb.ResourceType {
+	return KindDBRP.ResourceType()
+}
+
+func (d *dbrp) summarize() SummaryDBRP {
+	return SummaryDBRP{
+		SummaryIdentifier: SummaryIdentifier{
+			Kind:          KindDBRP,
+			MetaName:      d.MetaName(),
+			EnvReferences: summarizeCommonReferences(d.identity, nil),
+		},
+		Database:        d.database,
+		RetentionPolicy: d.retentionPolicy,
+		Default:         d.isDefault,
+		BucketName:      d.bucketName,
+	}
+}
+
+func (d *dbrp) valid() []validationErr {
+	var vErrs []validationErr
+	if d.database == "" {
+		vErrs = append(vErrs, validationErr{
+			Field: fieldDBRPDatabase,
+			Msg:   "must be provided",
+		})
+	}
+	if d.retentionPolicy == "" {
+		vErrs = append(vErrs, validationErr{
+			Field: fieldDBRPRetentionPolicy,
+			Msg:   "must be provided",
+		})
+	}
+	if d.bucketName == "" {
+		vErrs = append(vErrs, validationErr{
+			Field: fieldDBRPBucketName,
+			Msg:   "must be provided",
+		})
+	}
+
+	if len(vErrs) > 0 {
+		return []validationErr{
+			objectValidationErr(fieldSpec, vErrs...),
+		}
+	}
+
+	return nil
+}
+
+const (
+	fieldRemoteAllowInsecureTLS = "allowInsecureTLS"
+	fieldRemoteOrgID            = "remoteOrgID"
+	fieldRemoteToken            = "token"
+	fieldRemoteURL              = "url"
+)
+
+type remote struct {
+	identity
+
+	description      string
+	url              string
+	remoteOrgID      string
+	token            *references
+	allowInsecureTLS bool
+}
+
+func (r *remote) ResourceType() influxdb.ResourceType {
+	return KindRemote.ResourceType()
+}
+
+func (r *remote) summarize() SummaryRemote {
+	return SummaryRemote{
+		SummaryIdentifier: SummaryIdentifier{
+			Kind:          KindRemote,
+			MetaName:      r.MetaName(),
+			EnvReferences: summarizeCommonReferences(r.identity, nil),
+		},
+		Name:             r.Name(),
+		Description:      r.description,
+		URL:              r.url,
+		RemoteOrgID:      r.remoteOrgID,
+		AllowInsecureTLS: r.allowInsecureTLS,
+	}
+}
+
+func (r *remote) remoteOrg() *platform.ID {
+	id, err := platform.IDFromString(r.remoteOrgID)
+	if err != nil {
+		return nil
+	}
+	return id
+}
+
+func (r *remote) valid() []validationErr {
+	var vErrs []validationErr
+	if err, ok := isValidName(r.Name(), 1); !ok {
+		vErrs = append(vErrs, err)
+	}
+	if u, err := url.Parse(r.url); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		vErrs = append(vErrs, validationErr{
+			Field: fieldRemoteURL,
+			Msg:   "must be a valid http or https url",
+		})
+	}
+	if r.remoteOrgID != "" && r.remoteOrg() == nil {
+		vErrs = append(vErrs, validationErr{
+			Field: fieldRemoteOrgID,
+			Msg:   "must be a valid ID",
+		})
+	}
+	if r.token == nil || !r.token.hasValue() {
+		vErrs = append(vErrs, validationErr{
+			Field: fieldRemoteToken,
+			Msg:   "must be provided as a secretRef, envRef or value",
+		})
+	}
+
+	if len(vErrs) > 0 {
+		return []validationErr{
+			objectValidationErr(fieldSpec, vErrs...),
+		}
+	}
+
+	return nil
+}
+
+const (
+	fieldReplicationBucketName           = "bucketName"
+	fieldReplicationDropNonRetryableData = "dropNonRetryableData"
+	fieldReplicationMaxAgeSeconds        = "maxAgeSeconds"
+	fieldReplicationMaxQueueSizeBytes    = "maxQueueSizeBytes"
+	fieldReplicationRemoteBucketID       = "remoteBucketID"
+	fieldReplicationRemoteBucketName     = "remoteBucketName"
+	fieldReplicationRemoteName           = "remoteName"
+)
+
+type replication struct {
+	identity
+
+	description string
+	// remoteName is the template name of the remote of the template the
+	// replication writes to.
+	remoteName *references
+	// bucketName is the template name of a bucket of the template, or the
+	// name of a bucket of the org.
+	bucketName           string
+	remoteBucketID       string
+	remoteBucketName     string
+	maxQueueSizeBytes    int64
+	maxAgeSeconds        int64
+	dropNonRetryableData bool
+
+	associatedRemote *remote
+}
+
+func (r *replication) ResourceType() influxdb.ResourceType {
+	return KindReplication.ResourceType()
+}
+
+func (r *replication) summarize() SummaryReplication {
+	return SummaryReplication{
+		SummaryIdentifier: SummaryIdentifier{
+			Kind:          KindReplication,
+			MetaName:      r.MetaName(),
+			EnvReferences: summarizeCommonReferences(r.identity, nil),
+		},
+		Name:                 r.Name(),
+		Description:          r.description,
+		RemoteMetaName:       r.remoteName.String(),
+		BucketName:           r.bucketName,
+		RemoteBucketID:       r.remoteBucketID,
+		RemoteBucketName:     r.remoteBucketName,
+		MaxQueueSizeBytes:    r.maxQueueSize(),
+		MaxAgeSeconds:        r.maxAgeSeconds,
+		DropNonRetryableData: r.dropNonRetryableData,
+	}
+}
+
+func (r *replication) maxQueueSize() int64 {
+	if r.maxQueueSizeBytes == 0 {
+		return influxdb.DefaultReplicationMaxQueueSizeBytes
+	}
+	return r.maxQueueSizeBytes
+}
+
+func (r *replication) remoteBucket() platform.ID {
+	id, err := platform.IDFromString(r.remoteBucketID)
+	if err != nil {
+		return 0
+	}
+	return *id
+}
+
+func (r *replication) valid() []validationErr {
+	var vErrs []validationErr
+	if err, ok := isValidName(r.Name(), 1); !ok {
+		vErrs = append(vErrs, err)
+	}
+	if r.associatedRemote == nil {
+		vErrs = append(vErrs, validationErr{
+			Field: fieldReplicationRemoteName,
+			Msg:   fmt.Sprintf("remote %q does not exist in pkg", r.remoteName.String()),
+		})
+	}
+	if r.bucketName == "" {
+		vErrs = append(vErrs, validationErr{
+			Field: fieldReplicationBucketName,
+			Msg:   "must be provided",
+		})
+	}
+	switch {
+	case r.remoteBucketID == "" && r.remoteBucketName == "":
+		vErrs = append(vErrs, validationErr{
+			Field: fieldReplicationRemoteBucketName,
+			Msg:   "must provide one of remoteBucketID or remoteBucketName",
+		})
+	case r.remoteBucketID != "" && r.remoteBucket() == 0:
+		vErrs = append(vErrs, validationErr{
+			Field: fieldReplicationRemoteBucketID,
+			Msg:   "must be a valid ID",
+		})
+	}
+	if r.maxQueueSizeBytes != 0 && r.maxQueueSizeBytes < influxdb.MinReplicationMaxQueueSizeBytes {
+		vErrs = append(vErrs, validationErr{
+			Field: fieldReplicationMaxQueueSizeBytes,
+			Msg:   fmt.Sprintf("must be at least %d", influxdb.MinReplicationMaxQueueSizeBytes),
+		})
+	}
+	if r.maxAgeSeconds < 0 {
+		vErrs = append(vErrs, validationErr{
+			Field: fieldReplicationMaxAgeSeconds,
+			Msg:   "must not be negative",
+		})
+	}
+
+	if len(vErrs) > 0 {
+		return []validationErr{
+			objectValidationErr(fieldSpec, vErrs...),
+		}
+	}
+
+	return nil
+}
+
+const (
+	fieldTaskCron       = "cron"
+	fieldTask           = "task"
+	fieldTaskSecretRefs = "secretRefs"
 )
 
 type task struct {
@@ -2078,6 +2487,9 @@ type task struct {
 	offset      time.Duration
 	query       query
 	status      string
+	// secrets are the keys of the secrets the query gets, which must exist
+	// in the organization or be provided when the template is applied.
+	secrets []string
 
 	labels sortedLabels
 }
@@ -2109,7 +2521,51 @@ func (t *task) flux() string {
 }
 
 func (t *task) refs() []*references {
-	return append(t.query.params, t.name, t.displayName)
+	refs := make([]*references, 0, len(t.query.params)+2+len(t.secrets))
+	refs = append(refs, t.query.params...)
+	refs = append(refs, t.name, t.displayName)
+	for _, k := range t.secrets {
+		refs = append(refs, &references{Secret: k})
+	}
+	return refs
+}
+
+// fluxSecretKeys returns the keys of the secrets a flux script gets with
+// secrets.get, sorted.
+func fluxSecretKeys(source string) []string {
+	mKeys := make(map[string]bool)
+	ast.Visit(parser.ParseSource(source), func(n ast.Node) {
+		call, ok := n.(*ast.CallExpression)
+		if !ok || len(call.Arguments) != 1 {
+			return
+		}
+		callee, ok := call.Callee.(*ast.MemberExpression)
+		if !ok || callee.Property.Key() != "get" {
+			return
+		}
+		if obj, ok := callee.Object.(*ast.Identifier); !ok || obj.Name != "secrets" {
+			return
+		}
+		args, ok := call.Arguments[0].(*ast.ObjectExpression)
+		if !ok {
+			return
+		}
+		for _, p := range args.Properties {
+			if p.Key.Key() != "key" {
+				continue
+			}
+			if k, ok := p.Value.(*ast.StringLiteral); ok && k.Value != "" {
+				mKeys[k.Value] = true
+			}
+		}
+	})
+
+	keys := make([]string, 0, len(mKeys))
+	for k := range mKeys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 func (t *task) summarize() SummaryTask {
@@ -2,6 +2,7 @@ package pkger
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/url"
@@ -14,6 +15,7 @@ import (
 	"time"
 
 	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
 	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/notification"
 	icheck "github.com/influxdata/influxdb/v2/notification/check"
@@ -492,6 +494,25 @@ spec:
 			})
 		})
 
+		t.Run("with a label of another stack", func(t *testing.T) {
+			testfileRunner(t, "testdata/bucket_associates_stack_label.yml", func(t *testing.T, template *Template) {
+				labels := template.labels()
+				require.Len(t, labels, 1)
+				assert.Equal(t, "label-1", labels[0].MetaName())
+
+				stackLabels := template.stackLabels()
+				require.Len(t, stackLabels, 1)
+				assert.Equal(t, "base-label", stackLabels[0].MetaName())
+				assert.Equal(t, platform.ID(9), stackLabels[0].stackID)
+
+				bkts := template.buckets()
+				require.Len(t, bkts, 1)
+				require.Len(t, bkts[0].labels, 2)
+				assert.Equal(t, "base-label", bkts[0].labels[0].MetaName())
+				assert.Equal(t, "label-1", bkts[0].labels[1].MetaName())
+			})
+		})
+
 		t.Run("association doesn't exist then provides an error", func(t *testing.T) {
 			tests := []testTemplateResourceError{
 				{
@@ -527,6 +548,21 @@ spec:
       name: label-1
     - kind: Label
       name: NOT TO BE FOUND
+`,
+				},
+				{
+					name:    "invalid stack ID",
+					assErrs: 1,
+					assIdxs: []int{0},
+					templateStr: `apiVersion: influxdata.com/v2alpha1
+kind: Bucket
+metadata:
+  name: rucket-1
+spec:
+  associations:
+    - kind: Label
+      name: label-1
+      stackID: not-an-id
 `,
 				},
 				{
@@ -3954,6 +3990,243 @@ spec:
 		})
 	})
 
+	t.Run("template with notebooks", func(t *testing.T) {
+		t.Run("with pipes and bucket references should be successful", func(t *testing.T) {
+			testfileRunner(t, "testdata/notebook", func(t *testing.T, template *Template) {
+				sum := template.Summary()
+				require.Len(t, sum.Notebooks, 2)
+
+				actual := sum.Notebooks[0]
+				assert.Equal(t, KindNotebook, actual.Kind)
+				assert.Equal(t, "notebook-1", actual.MetaName)
+				assert.Equal(t, "display name", actual.Name)
+				assert.Equal(t, false, actual.Spec["readOnly"])
+				assert.NotContains(t, actual.Spec, fieldName)
+
+				pipes, ok := actual.Spec[fieldNotebookPipes].([]interface{})
+				require.True(t, ok)
+				require.Len(t, pipes, 3)
+
+				var bucketNames []string
+				eachNotebookBucketRef(actual.Spec, func(ref map[string]interface{}) {
+					bucketNames = append(bucketNames, ref[fieldName].(string))
+				})
+				assert.Equal(t, []string{"rucket-1", "rucket-1"}, bucketNames)
+
+				_, err := json.Marshal(actual.Spec)
+				require.NoError(t, err)
+
+				actual = sum.Notebooks[1]
+				assert.Equal(t, "notebook-2", actual.Name)
+				assert.Empty(t, actual.Spec)
+			})
+		})
+
+		t.Run("handles bad config", func(t *testing.T) {
+			tests := []testTemplateResourceError{
+				{
+					name:           "pipes not a list",
+					validationErrs: 1,
+					valFields:      []string{fieldSpec, fieldNotebookPipes},
+					templateStr: `apiVersion: influxdata.com/v2alpha1
+kind: Notebook
+metadata:
+  name: notebook-1
+spec:
+  pipes: not a list
+`,
+				},
+				{
+					name:           "pipe not an object",
+					validationErrs: 1,
+					valFields:      []string{fieldSpec, fieldNotebookPipes},
+					templateStr: `apiVersion: influxdata.com/v2alpha1
+kind: Notebook
+metadata:
+  name: notebook-1
+spec:
+  pipes:
+    - type: queryBuilder
+    - queryBuilder
+`,
+				},
+			}
+
+			for _, tt := range tests {
+				testTemplateErrors(t, KindNotebook, tt)
+			}
+		})
+	})
+
+	t.Run("template with dbrps, remotes and replications", func(t *testing.T) {
+		t.Run("should be successful", func(t *testing.T) {
+			testfileRunner(t, "testdata/edge", func(t *testing.T, template *Template) {
+				sum := template.Summary()
+
+				require.Len(t, sum.DBRPs, 1)
+				d := sum.DBRPs[0]
+				assert.Equal(t, KindDBRP, d.Kind)
+				assert.Equal(t, "dbrp-1", d.MetaName)
+				assert.Equal(t, "telegraf", d.Database)
+				assert.Equal(t, "autogen", d.RetentionPolicy)
+				assert.True(t, d.Default)
+				assert.Equal(t, "rucket-1", d.BucketName)
+
+				require.Len(t, sum.Remotes, 1)
+				r := sum.Remotes[0]
+				assert.Equal(t, KindRemote, r.Kind)
+				assert.Equal(t, "remote-1", r.MetaName)
+				assert.Equal(t, "cloud", r.Name)
+				assert.Equal(t, "remote desc", r.Description)
+				assert.Equal(t, "https://cloud.example.com", r.URL)
+				assert.Equal(t, "0000000000000001", r.RemoteOrgID)
+				assert.False(t, r.AllowInsecureTLS)
+				assert.Equal(t, []string{"cloud-token"}, sum.MissingSecrets)
+
+				require.Len(t, sum.Replications, 1)
+				rep := sum.Replications[0]
+				assert.Equal(t, KindReplication, rep.Kind)
+				assert.Equal(t, "replication-1", rep.MetaName)
+				assert.Equal(t, "edge-to-cloud", rep.Name)
+				assert.Equal(t, "remote-1", rep.RemoteMetaName)
+				assert.Equal(t, "rucket-1", rep.BucketName)
+				assert.Equal(t, "cloud-bucket", rep.RemoteBucketName)
+				assert.Equal(t, influxdb.DefaultReplicationMaxQueueSizeBytes, rep.MaxQueueSizeBytes)
+				assert.Equal(t, int64(3600), rep.MaxAgeSeconds)
+				assert.True(t, rep.DropNonRetryableData)
+			})
+		})
+
+		t.Run("handles bad config", func(t *testing.T) {
+			dbrpTests := []testTemplateResourceError{
+				{
+					name:           "missing database, retention policy and bucket",
+					validationErrs: 3,
+					valFields:      []string{fieldSpec, fieldDBRPDatabase},
+					templateStr: `apiVersion: influxdata.com/v2alpha1
+kind: DBRP
+metadata:
+  name: dbrp-1
+spec:
+  default: true
+`,
+				},
+			}
+			for _, tt := range dbrpTests {
+				testTemplateErrors(t, KindDBRP, tt)
+			}
+
+			remoteTests := []testTemplateResourceError{
+				{
+					name:           "invalid url",
+					validationErrs: 1,
+					valFields:      []string{fieldSpec, fieldRemoteURL},
+					templateStr: `apiVersion: influxdata.com/v2alpha1
+kind: Remote
+metadata:
+  name: remote-1
+spec:
+  url: not a url
+  token: tok
+`,
+				},
+				{
+					name:           "missing token",
+					validationErrs: 1,
+					valFields:      []string{fieldSpec, fieldRemoteToken},
+					templateStr: `apiVersion: influxdata.com/v2alpha1
+kind: Remote
+metadata:
+  name: remote-1
+spec:
+  url: https://cloud.example.com
+`,
+				},
+				{
+					name:           "invalid remote org id",
+					validationErrs: 1,
+					valFields:      []string{fieldSpec, fieldRemoteOrgID},
+					templateStr: `apiVersion: influxdata.com/v2alpha1
+kind: Remote
+metadata:
+  name: remote-1
+spec:
+  url: https://cloud.example.com
+  remoteOrgID: bad
+  token: tok
+`,
+				},
+			}
+			for _, tt := range remoteTests {
+				testTemplateErrors(t, KindRemote, tt)
+			}
+
+			replicationTests := []testTemplateResourceError{
+				{
+					name:           "remote not in template",
+					validationErrs: 1,
+					valFields:      []string{fieldSpec, fieldReplicationRemoteName},
+					templateStr: `apiVersion: influxdata.com/v2alpha1
+kind: Replication
+metadata:
+  name: replication-1
+spec:
+  remoteName: remote-1
+  bucketName: rucket-1
+  remoteBucketName: cloud-bucket
+`,
+				},
+				{
+					name:           "missing remote bucket",
+					validationErrs: 1,
+					valFields:      []string{fieldSpec, fieldReplicationRemoteBucketName},
+					templateStr: `apiVersion: influxdata.com/v2alpha1
+kind: Remote
+metadata:
+  name: remote-1
+spec:
+  url: https://cloud.example.com
+  token: tok
+---
+apiVersion: influxdata.com/v2alpha1
+kind: Replication
+metadata:
+  name: replication-1
+spec:
+  remoteName: remote-1
+  bucketName: rucket-1
+`,
+				},
+				{
+					name:           "max queue size too small",
+					validationErrs: 1,
+					valFields:      []string{fieldSpec, fieldReplicationMaxQueueSizeBytes},
+					templateStr: `apiVersion: influxdata.com/v2alpha1
+kind: Remote
+metadata:
+  name: remote-1
+spec:
+  url: https://cloud.example.com
+  token: tok
+---
+apiVersion: influxdata.com/v2alpha1
+kind: Replication
+metadata:
+  name: replication-1
+spec:
+  remoteName: remote-1
+  bucketName: rucket-1
+  remoteBucketName: cloud-bucket
+  maxQueueSizeBytes: 10
+`,
+				},
+			}
+			for _, tt := range replicationTests {
+				testTemplateErrors(t, KindReplication, tt)
+			}
+		})
+	})
+
 	t.Run("template with telegraf config", func(t *testing.T) {
 		t.Run("and associated labels should be successful", func(t *testing.T) {
 			testfileRunner(t, "testdata/telegraf", func(t *testing.T, template *Template) {
@@ -4371,6 +4644,12 @@ spec:
 
 			hasSecret(t, template.mSecrets, "routing-key")
 		})
+
+		testfileRunner(t, "testdata/task_secrets.yml", func(t *testing.T, template *Template) {
+			hasSecret(t, template.mSecrets, "declared-token")
+			hasSecret(t, template.mSecrets, "slack-token")
+			assert.Equal(t, []string{"declared-token", "slack-token"}, template.mTasks["task-secrets"].secrets)
+		})
 	})
 
 	t.Run("referencing env", func(t *testing.T) {
@@ -4445,6 +4724,47 @@ spec:
 		})
 	})
 
+	t.Run("substituting env variables", func(t *testing.T) {
+		testfileRunner(t, "testdata/env_substitution", func(t *testing.T, template *Template) {
+			sum := template.Summary()
+
+			assert.Equal(t, []string{"ENV"}, sum.MissingEnvs)
+			assert.Equal(t, []string{"ENV"}, template.missingEnvSubstitutions())
+
+			require.Len(t, sum.Buckets, 1)
+			assert.Equal(t, "${ENV}-bucket", sum.Buckets[0].Name)
+			assert.Equal(t, "edge bucket", sum.Buckets[0].Description)
+			assert.Equal(t, 7200*time.Second, sum.Buckets[0].RetentionPeriod)
+
+			require.Len(t, sum.Labels, 1)
+			assert.Equal(t, "costs ${PRICE}", sum.Labels[0].Properties.Description)
+
+			t.Log("applying env vars should substitute the variables")
+			{
+				err := template.applyEnvRefs(map[string]interface{}{
+					"ENV":               "prod",
+					"RETENTION_SECONDS": 86400,
+				})
+				require.NoError(t, err)
+
+				sum := template.Summary()
+
+				assert.Empty(t, sum.MissingEnvs)
+				assert.Empty(t, template.missingEnvSubstitutions())
+
+				require.Len(t, sum.Buckets, 1)
+				assert.Equal(t, "prod-bucket", sum.Buckets[0].Name)
+				assert.Equal(t, 86400*time.Second, sum.Buckets[0].RetentionPeriod)
+				require.Len(t, sum.Buckets[0].LabelAssociations, 1)
+				assert.Equal(t, "prod", sum.Buckets[0].LabelAssociations[0].Name)
+
+				require.Len(t, sum.Tasks, 1)
+				assert.Contains(t, sum.Tasks[0].Query, `from(bucket: "prod-bucket")`)
+				assert.Contains(t, sum.Tasks[0].Query, `${r._value}`)
+			}
+		})
+	})
+
 	t.Run("jsonnet support disabled by default", func(t *testing.T) {
 		template := validParsedTemplateFromFile(t, "testdata/bucket_associates_labels.jsonnet", EncodingJsonnet)
 		require.Equal(t, &Template{}, template)
@@ -1,6 +1,7 @@
 package pkger
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -52,6 +53,26 @@ func (s Stack) LatestEvent() StackEvent {
 	return s.Events[len(s.Events)-1]
 }
 
+// PreviousVersion returns the latest event which applied another template
+// version than the latest event. It returns false when no such application
+// recorded its template since the stack was last uninstalled.
+func (s Stack) PreviousVersion() (StackEvent, bool) {
+	latest := s.LatestEvent()
+	if latest.EventType == StackEventUninstalled {
+		return StackEvent{}, false
+	}
+	for i := len(s.Events) - 2; i >= 0; i-- {
+		ev := s.Events[i]
+		if ev.EventType == StackEventUninstalled {
+			break
+		}
+		if len(ev.Template) > 0 && !bytes.Equal(ev.Template, latest.Template) {
+			return ev, true
+		}
+	}
+	return StackEvent{}, false
+}
+
 type (
 	StackEvent struct {
 		EventType    StackEventType
@@ -60,7 +81,14 @@ type (
 		Sources      []string
 		TemplateURLs []string
 		Resources    []StackResource
-		UpdatedAt    time.Time `json:"updatedAt"`
+		// Template is the template applied by the event, encoded as JSON, and
+		// EnvRefs the env references it was applied with. A rollback of the
+		// stack re-applies them.
+		Template []byte
+		EnvRefs  map[string]interface{}
+		// Sync configures the stack to be synced from a git repository.
+		Sync      *StackSync
+		UpdatedAt time.Time `json:"updatedAt"`
 	}
 
 	StackCreate struct {
@@ -84,9 +112,11 @@ type (
 	}
 
 	// StackResourceAssociation associates a stack resource with another stack resource.
+	// StackID is set when the associated resource is owned by another stack.
 	StackResourceAssociation struct {
 		Kind     Kind
 		MetaName string
+		StackID  platform.ID
 	}
 
 	// StackUpdate provides a means to update an existing stack.
@@ -96,6 +126,9 @@ type (
 		Description         *string
 		TemplateURLs        []string
 		AdditionalResources []StackAdditionalResource
+		// Sync configures the stack to be synced from a git repository. A
+		// sync without a URL stops the syncing of the stack.
+		Sync *StackSync
 	}
 
 	StackAdditionalResource struct {
@@ -112,6 +145,7 @@ const (
 	StackEventCreate StackEventType = iota
 	StackEventUpdate
 	StackEventUninstalled
+	StackEventRollback
 )
 
 func (e StackEventType) String() string {
@@ -122,6 +156,8 @@ func (e StackEventType) String() string {
 		return "uninstall"
 	case StackEventUpdate:
 		return "update"
+	case StackEventRollback:
+		return "rollback"
 	default:
 		return "unknown"
 	}
@@ -133,6 +169,7 @@ const ResourceTypeStack influxdb.ResourceType = "stack"
 type SVC interface {
 	InitStack(ctx context.Context, userID platform.ID, stack StackCreate) (Stack, error)
 	UninstallStack(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (Stack, error)
+	RollbackStack(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (ImpactSummary, error)
 	DeleteStack(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) error
 	ListStacks(ctx context.Context, orgID platform.ID, filter ListFilter) ([]Stack, error)
 	ReadStack(ctx context.Context, id platform.ID) (Stack, error)
@@ -156,12 +193,17 @@ type serviceOpt struct {
 	timeGen       influxdb.TimeGenerator
 	store         Store
 
+	authSVC     influxdb.AuthorizationService
 	bucketSVC   influxdb.BucketService
 	checkSVC    influxdb.CheckService
 	dashSVC     influxdb.DashboardService
+	dbrpSVC     influxdb.DBRPMappingService
 	labelSVC    influxdb.LabelService
 	endpointSVC influxdb.NotificationEndpointService
+	notebookSVC influxdb.NotebookService
 	orgSVC      influxdb.OrganizationService
+	remoteSVC   RemoteConnectionService
+	replSVC     ReplicationService
 	ruleSVC     influxdb.NotificationRuleStore
 	secretSVC   influxdb.SecretService
 	taskSVC     taskmodel.TaskService
@@ -169,6 +211,26 @@ type serviceOpt struct {
 	varSVC      influxdb.VariableService
 }
 
+// RemoteConnectionService is the service the remote connections of templates
+// are applied with.
+type RemoteConnectionService interface {
+	ListRemoteConnections(context.Context, influxdb.RemoteConnectionListFilter) (*influxdb.RemoteConnections, error)
+	CreateRemoteConnection(context.Context, influxdb.CreateRemoteConnectionRequest) (*influxdb.RemoteConnection, error)
+	GetRemoteConnection(context.Context, platform.ID) (*influxdb.RemoteConnection, error)
+	UpdateRemoteConnection(context.Context, platform.ID, influxdb.UpdateRemoteConnectionRequest) (*influxdb.RemoteConnection, error)
+	DeleteRemoteConnection(context.Context, platform.ID) error
+}
+
+// ReplicationService is the service the replications of templates are
+// applied with.
+type ReplicationService interface {
+	ListReplications(context.Context, influxdb.ReplicationListFilter) (*influxdb.Replications, error)
+	CreateReplication(context.Context, influxdb.CreateReplicationRequest) (*influxdb.Replication, error)
+	GetReplication(context.Context, platform.ID) (*influxdb.Replication, error)
+	UpdateReplication(context.Context, platform.ID, influxdb.UpdateReplicationRequest) (*influxdb.Replication, error)
+	DeleteReplication(context.Context, platform.ID) error
+}
+
 // ServiceSetterFn is a means of setting dependencies on the Service type.
 type ServiceSetterFn func(opt *serviceOpt)
 
@@ -207,6 +269,14 @@ func WithStore(store Store) ServiceSetterFn {
 	}
 }
 
+// WithAuthorizationSVC sets the authorization service. It is used to find
+// the tokens a dry run impacts.
+func WithAuthorizationSVC(authSVC influxdb.AuthorizationService) ServiceSetterFn {
+	return func(opt *serviceOpt) {
+		opt.authSVC = authSVC
+	}
+}
+
 // WithBucketSVC sets the bucket service.
 func WithBucketSVC(bktSVC influxdb.BucketService) ServiceSetterFn {
 	return func(opt *serviceOpt) {
@@ -228,6 +298,14 @@ func WithDashboardSVC(dashSVC influxdb.DashboardService) ServiceSetterFn {
 	}
 }
 
+// WithDBRPSVC sets the dbrp mapping service. It is used to find the dbrp
+// mappings a dry run impacts.
+func WithDBRPSVC(dbrpSVC influxdb.DBRPMappingService) ServiceSetterFn {
+	return func(opt *serviceOpt) {
+		opt.dbrpSVC = dbrpSVC
+	}
+}
+
 // WithLabelSVC sets the label service.
 func WithLabelSVC(labelSVC influxdb.LabelService) ServiceSetterFn {
 	return func(opt *serviceOpt) {
@@ -235,6 +313,13 @@ func WithLabelSVC(labelSVC influxdb.LabelService) ServiceSetterFn {
 	}
 }
 
+// WithNotebookSVC sets the notebook service.
+func WithNotebookSVC(notebookSVC influxdb.NotebookService) ServiceSetterFn {
+	return func(opt *serviceOpt) {
+		opt.notebookSVC = notebookSVC
+	}
+}
+
 func withNameGen(nameGen NameGenerator) ServiceSetterFn {
 	return func(opt *serviceOpt) {
 		opt.nameGen = nameGen
@@ -262,6 +347,20 @@ func WithOrganizationService(orgSVC influxdb.OrganizationService) ServiceSetterF
 	}
 }
 
+// WithRemoteConnectionSVC sets the remote connection service.
+func WithRemoteConnectionSVC(remoteSVC RemoteConnectionService) ServiceSetterFn {
+	return func(opt *serviceOpt) {
+		opt.remoteSVC = remoteSVC
+	}
+}
+
+// WithReplicationSVC sets the replication service.
+func WithReplicationSVC(replSVC ReplicationService) ServiceSetterFn {
+	return func(opt *serviceOpt) {
+		opt.replSVC = replSVC
+	}
+}
+
 // WithSecretSVC sets the secret service.
 func WithSecretSVC(secretSVC influxdb.SecretService) ServiceSetterFn {
 	return func(opt *serviceOpt) {
@@ -313,12 +412,17 @@ type Service struct {
 	timeGen       influxdb.TimeGenerator
 
 	// external service dependencies
+	authSVC     influxdb.AuthorizationService
 	bucketSVC   influxdb.BucketService
 	checkSVC    influxdb.CheckService
 	dashSVC     influxdb.DashboardService
+	dbrpSVC     influxdb.DBRPMappingService
 	labelSVC    influxdb.LabelService
 	endpointSVC influxdb.NotificationEndpointService
+	notebookSVC influxdb.NotebookService
 	orgSVC      influxdb.OrganizationService
+	remoteSVC   RemoteConnectionService
+	replSVC     ReplicationService
 	ruleSVC     influxdb.NotificationRuleStore
 	secretSVC   influxdb.SecretService
 	taskSVC     taskmodel.TaskService
@@ -351,12 +455,17 @@ func NewService(opts ...ServiceSetterFn) *Service {
 		store:         opt.store,
 		timeGen:       opt.timeGen,
 
+		authSVC:     opt.authSVC,
 		bucketSVC:   opt.bucketSVC,
 		checkSVC:    opt.checkSVC,
 		labelSVC:    opt.labelSVC,
 		dashSVC:     opt.dashSVC,
+		dbrpSVC:     opt.dbrpSVC,
 		endpointSVC: opt.endpointSVC,
+		notebookSVC: opt.notebookSVC,
 		orgSVC:      opt.orgSVC,
+		remoteSVC:   opt.remoteSVC,
+		replSVC:     opt.replSVC,
 		ruleSVC:     opt.ruleSVC,
 		secretSVC:   opt.secretSVC,
 		taskSVC:     opt.taskSVC,
@@ -479,6 +588,43 @@ func (s *Service) uninstallStack(ctx context.Context, identifiers struct{ OrgID,
 	return stack, nil
 }
 
+// RollbackStack re-applies the template version the stack had before its latest
+// application. Resources of the latest version which are not in the previous
+// one are removed, and the removed ones are created again. Rolling back a
+// rollback re-applies the version which was rolled back.
+func (s *Service) RollbackStack(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (ImpactSummary, error) {
+	stack, err := s.store.ReadStackByID(ctx, identifiers.StackID)
+	if err != nil {
+		return ImpactSummary{}, err
+	}
+	if stack.OrgID != identifiers.OrgID {
+		return ImpactSummary{}, &errors2.Error{
+			Code: errors2.EConflict,
+			Msg:  "you do not have access to given stack ID",
+		}
+	}
+
+	prev, ok := stack.PreviousVersion()
+	if !ok {
+		return ImpactSummary{}, &errors2.Error{
+			Code: errors2.EConflict,
+			Msg:  "stack has no previous template version to roll back to",
+		}
+	}
+
+	template, err := Parse(EncodingJSON, FromReader(bytes.NewReader(prev.Template), prev.Sources...))
+	if err != nil {
+		return ImpactSummary{}, internalErr(err)
+	}
+
+	return s.Apply(ctx, identifiers.OrgID, identifiers.UserID,
+		ApplyWithTemplate(template),
+		ApplyWithEnvRefs(prev.EnvRefs),
+		ApplyWithStackID(identifiers.StackID),
+		applyAsRollback(),
+	)
+}
+
 // ListFilter are filter options for filtering stacks from being returned.
 type ListFilter struct {
 	StackIDs []platform.ID
@@ -517,6 +663,12 @@ func (s *Service) UpdateStack(ctx context.Context, upd StackUpdate) (Stack, erro
 		}
 	}
 
+	if upd.Sync != nil && upd.Sync.URL != "" {
+		if err := upd.Sync.valid(); err != nil {
+			return Stack{}, err
+		}
+	}
+
 	updatedStack := s.applyStackUpdate(existing, upd)
 	if err := s.store.UpdateStack(ctx, updatedStack); err != nil {
 		return Stack{}, err
@@ -538,6 +690,13 @@ func (s *Service) applyStackUpdate(existing Stack, upd StackUpdate) Stack {
 	if upd.TemplateURLs != nil {
 		ev.TemplateURLs = upd.TemplateURLs
 	}
+	if upd.Sync != nil {
+		ev.Sync = nil
+		if upd.Sync.URL != "" {
+			cfg := *upd.Sync
+			ev.Sync = &cfg
+		}
+	}
 
 	type key struct {
 		k  Kind
@@ -843,6 +1002,81 @@ func (s *Service) cloneOrgNotificationRules(ctx context.Context, orgID platform.
 	return resources, nil
 }
 
+func (s *Service) cloneOrgNotebooks(ctx context.Context, orgID platform.ID) ([]ResourceToClone, error) {
+	const limit = 100
+
+	var resources []ResourceToClone
+	for offset := 0; ; offset += limit {
+		notebooks, err := s.notebookSVC.ListNotebooks(ctx, influxdb.NotebookListFilter{
+			OrgID: orgID,
+			Page:  influxdb.Page{Offset: offset, Limit: limit},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range notebooks {
+			resources = append(resources, ResourceToClone{
+				Kind: KindNotebook,
+				ID:   n.ID,
+			})
+		}
+		if len(notebooks) < limit {
+			return resources, nil
+		}
+	}
+}
+
+func (s *Service) cloneOrgDBRPs(ctx context.Context, orgID platform.ID) ([]ResourceToClone, error) {
+	// virtual mappings follow the names of buckets, only the explicit
+	// mappings are resources of their own.
+	virtual := false
+	mappings, _, err := s.dbrpSVC.FindMany(ctx, influxdb.DBRPMappingFilter{OrgID: &orgID, Virtual: &virtual})
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]ResourceToClone, 0, len(mappings))
+	for _, m := range mappings {
+		resources = append(resources, ResourceToClone{
+			Kind: KindDBRP,
+			ID:   m.ID,
+		})
+	}
+	return resources, nil
+}
+
+func (s *Service) cloneOrgRemotes(ctx context.Context, orgID platform.ID) ([]ResourceToClone, error) {
+	remotes, err := s.remoteSVC.ListRemoteConnections(ctx, influxdb.RemoteConnectionListFilter{OrgID: orgID})
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]ResourceToClone, 0, len(remotes.Remotes))
+	for _, r := range remotes.Remotes {
+		resources = append(resources, ResourceToClone{
+			Kind: KindRemote,
+			ID:   r.ID,
+		})
+	}
+	return resources, nil
+}
+
+func (s *Service) cloneOrgReplications(ctx context.Context, orgID platform.ID) ([]ResourceToClone, error) {
+	replications, err := s.replSVC.ListReplications(ctx, influxdb.ReplicationListFilter{OrgID: orgID})
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]ResourceToClone, 0, len(replications.Replications))
+	for _, r := range replications.Replications {
+		resources = append(resources, ResourceToClone{
+			Kind: KindReplication,
+			ID:   r.ID,
+		})
+	}
+	return resources, nil
+}
+
 func (s *Service) cloneOrgTasks(ctx context.Context, orgID platform.ID) ([]ResourceToClone, error) {
 	tasks, err := s.getAllTasks(ctx, orgID)
 	if err != nil {
@@ -943,6 +1177,18 @@ func (s *Service) filterOrgResourceKinds(resourceKindFilters []Kind) []resClone
 		KindTelegraf:             s.cloneOrgTelegrafs,
 		KindVariable:             s.cloneOrgVariables,
 	}
+	if s.notebookSVC != nil {
+		mKinds[KindNotebook] = s.cloneOrgNotebooks
+	}
+	if s.dbrpSVC != nil {
+		mKinds[KindDBRP] = s.cloneOrgDBRPs
+	}
+	if s.remoteSVC != nil {
+		mKinds[KindRemote] = s.cloneOrgRemotes
+		if s.replSVC != nil {
+			mKinds[KindReplication] = s.cloneOrgReplications
+		}
+	}
 
 	newResGen := func(resType influxdb.ResourceType, cloneFn cloneResFn) resClone {
 		return resClone{
@@ -978,6 +1224,9 @@ type ImpactSummary struct {
 	StackID platform.ID
 	Diff    Diff
 	Summary Summary
+	// Impacts are the resources outside of the template that the application
+	// of the template would break. They are reported by DryRun.
+	Impacts []DownstreamImpact
 }
 
 var reCommunityTemplatesValidAddr = regexp.MustCompile(`(?:https://raw\.githubusercontent\.com/influxdata/community-templates/master/)(?P<name>\w+)(?:/.*)`)
@@ -1013,11 +1262,17 @@ func (s *Service) DryRun(ctx context.Context, orgID, userID platform.ID, opts ..
 		return ImpactSummary{}, err
 	}
 
+	impacts, err := s.downstreamImpact(ctx, orgID, state)
+	if err != nil {
+		return ImpactSummary{}, internalErr(err)
+	}
+
 	return ImpactSummary{
 		Sources: template.sources,
 		StackID: opt.StackID,
 		Diff:    state.diff(),
 		Summary: newSummaryFromStateTemplate(state, template),
+		Impacts: impacts,
 	}, nil
 }
 
@@ -1052,6 +1307,10 @@ func (s *Service) dryRun(ctx context.Context, orgID platform.ID, template *Templ
 		}
 	}
 
+	if err := s.dryRunStackDependencies(ctx, orgID, opt.StackID, state); err != nil {
+		return nil, err
+	}
+
 	if err := s.dryRunSecrets(ctx, orgID, template); err != nil {
 		return nil, err
 	}
@@ -1059,7 +1318,11 @@ func (s *Service) dryRun(ctx context.Context, orgID platform.ID, template *Templ
 	s.dryRunBuckets(ctx, orgID, state.mBuckets)
 	s.dryRunChecks(ctx, orgID, state.mChecks)
 	s.dryRunDashboards(ctx, orgID, state.mDashboards)
+	s.dryRunDBRPs(ctx, orgID, state)
 	s.dryRunLabels(ctx, orgID, state.mLabels)
+	s.dryRunNotebooks(ctx, orgID, state.mNotebooks)
+	s.dryRunRemotes(ctx, orgID, state.mRemotes)
+	s.dryRunReplications(ctx, orgID, state)
 	s.dryRunTasks(ctx, orgID, state.mTasks)
 	s.dryRunTelegrafConfigs(ctx, orgID, state.mTelegrafs)
 	s.dryRunVariables(ctx, orgID, state.mVariables)
@@ -1229,6 +1492,38 @@ func (s *Service) dryRunSecrets(ctx context.Context, orgID platform.ID, template
 	return nil
 }
 
+// validateSecrets returns an error if the template references secrets which
+// neither exist in the organization nor are provided with the apply.
+// validateEnvSubstitutions requires the ${ENV_VAR} substitutions without a
+// default to be provided by the env refs of the apply.
+func validateEnvSubstitutions(template *Template) error {
+	missing := template.missingEnvSubstitutions()
+	if len(missing) == 0 {
+		return nil
+	}
+	return &errors2.Error{
+		Code: errors2.EUnprocessableEntity,
+		Msg:  fmt.Sprintf("template references env variables that must be provided: %s", strings.Join(missing, ", ")),
+	}
+}
+
+func validateSecrets(template *Template, provided map[string]string) error {
+	var missing []string
+	for _, k := range template.missingSecrets() {
+		if _, ok := provided[k]; !ok {
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return &errors2.Error{
+		Code: errors2.EUnprocessableEntity,
+		Msg:  fmt.Sprintf("template references secrets that must be provided: %s", strings.Join(missing, ", ")),
+	}
+}
+
 func (s *Service) dryRunTasks(ctx context.Context, orgID platform.ID, tasks map[string]*stateTask) {
 	for _, stateTask := range tasks {
 		stateTask.orgID = orgID
@@ -1243,6 +1538,85 @@ func (s *Service) dryRunTasks(ctx context.Context, orgID platform.ID, tasks map[
 	}
 }
 
+func (s *Service) dryRunNotebooks(ctx context.Context, orgID platform.ID, notebooks map[string]*stateNotebook) {
+	for _, n := range notebooks {
+		n.orgID = orgID
+		if s.notebookSVC == nil || n.ID() == 0 {
+			continue
+		}
+		existing, _ := s.notebookSVC.GetNotebook(ctx, n.ID())
+		if IsNew(n.stateStatus) && existing != nil {
+			n.stateStatus = StateStatusExists
+		}
+		n.existing = existing
+	}
+}
+
+func (s *Service) dryRunDBRPs(ctx context.Context, orgID platform.ID, state *stateCoordinator) {
+	for _, d := range state.mDBRPs {
+		d.orgID = orgID
+		if d.parserDBRP.bucketName != "" {
+			d.bucketName = state.bucketName(d.parserDBRP.bucketName)
+		}
+		if s.dbrpSVC == nil || d.ID() == 0 {
+			continue
+		}
+		existing, _ := s.dbrpSVC.FindByID(ctx, orgID, d.ID())
+		if existing == nil {
+			continue
+		}
+		if IsNew(d.stateStatus) {
+			d.stateStatus = StateStatusExists
+		}
+		d.existing = existing
+		if bkt, err := s.bucketSVC.FindBucketByID(ctx, existing.BucketID); err == nil {
+			d.existingBucketName = bkt.Name
+		}
+	}
+}
+
+func (s *Service) dryRunRemotes(ctx context.Context, orgID platform.ID, remotes map[string]*stateRemote) {
+	for _, r := range remotes {
+		r.orgID = orgID
+		if s.remoteSVC == nil || r.ID() == 0 {
+			continue
+		}
+		existing, _ := s.remoteSVC.GetRemoteConnection(ctx, r.ID())
+		if IsNew(r.stateStatus) && existing != nil {
+			r.stateStatus = StateStatusExists
+		}
+		r.existing = existing
+	}
+}
+
+func (s *Service) dryRunReplications(ctx context.Context, orgID platform.ID, state *stateCoordinator) {
+	for _, r := range state.mReplications {
+		r.orgID = orgID
+		if r.parserReplication.bucketName != "" {
+			r.bucketName = state.bucketName(r.parserReplication.bucketName)
+		}
+		if s.replSVC == nil || r.ID() == 0 {
+			continue
+		}
+		existing, _ := s.replSVC.GetReplication(ctx, r.ID())
+		if existing == nil {
+			continue
+		}
+		if IsNew(r.stateStatus) {
+			r.stateStatus = StateStatusExists
+		}
+		r.existing = existing
+		if bkt, err := s.bucketSVC.FindBucketByID(ctx, existing.LocalBucketID); err == nil {
+			r.existingBucketName = bkt.Name
+		}
+		if s.remoteSVC != nil {
+			if rc, err := s.remoteSVC.GetRemoteConnection(ctx, existing.RemoteID); err == nil {
+				r.existingRemoteName = rc.Name
+			}
+		}
+	}
+}
+
 func (s *Service) dryRunTelegrafConfigs(ctx context.Context, orgID platform.ID, teleConfigs map[string]*stateTelegraf) {
 	for _, stateTele := range teleConfigs {
 		stateTele.orgID = orgID
@@ -1287,6 +1661,9 @@ func (s *Service) dryRunLabelMappings(ctx context.Context, state *stateCoordinat
 		}
 		stateLabelsByResName[l.parserLabel.Name()] = l
 	}
+	for _, l := range state.mStackLabels {
+		stateLabelsByResName[l.Name()] = l
+	}
 
 	var mappings []stateLabelMapping
 	for _, b := range state.mBuckets {
@@ -1426,6 +1803,9 @@ func (s *Service) dryRunResourceLabelMapping(ctx context.Context, state *stateCo
 	// now we add labels that do not exist
 	for _, l := range templateLabels {
 		stLabel, found := state.getLabelByMetaName(l.MetaName())
+		if l.parserLabel.stackID != 0 {
+			stLabel, found = l, true
+		}
 		if !found {
 			continue
 		}
@@ -1449,6 +1829,109 @@ func (s *Service) addStackState(ctx context.Context, stackID platform.ID, state
 	return nil
 }
 
+// dryRunStackDependencies finds the labels of other stacks the template
+// depends on, which must have been applied before the template. It errors when
+// the application removes labels of the stack which other stacks depend on.
+func (s *Service) dryRunStackDependencies(ctx context.Context, orgID, stackID platform.ID, state *stateCoordinator) error {
+	stacks := make(map[platform.ID]Stack)
+	findLabelID := func(depStackID platform.ID, metaName string) (platform.ID, error) {
+		st, ok := stacks[depStackID]
+		if !ok {
+			var err error
+			st, err = s.store.ReadStackByID(ctx, depStackID)
+			if err != nil && errors2.ErrorCode(err) != errors2.ENotFound {
+				return 0, err
+			}
+			if err != nil || st.OrgID != orgID {
+				return 0, &errors2.Error{
+					Code: errors2.EUnprocessableEntity,
+					Msg:  fmt.Sprintf("stack %s the template depends on does not exist", depStackID),
+				}
+			}
+			stacks[depStackID] = st
+		}
+		for _, r := range st.LatestEvent().Resources {
+			if r.Kind.is(KindLabel) && r.MetaName == metaName {
+				return r.ID, nil
+			}
+		}
+		return 0, &errors2.Error{
+			Code: errors2.EUnprocessableEntity,
+			Msg:  fmt.Sprintf("label %q is not a resource of stack %s, the stack must be applied first", metaName, depStackID),
+		}
+	}
+
+	for _, l := range state.mStackLabels {
+		depStackID := l.parserLabel.stackID
+		if depStackID == stackID {
+			return &errors2.Error{
+				Code: errors2.EUnprocessableEntity,
+				Msg:  fmt.Sprintf("label %q is a dependency on the stack being applied, associate a label of the template instead", l.MetaName()),
+			}
+		}
+		id, err := findLabelID(depStackID, l.MetaName())
+		if err != nil {
+			return err
+		}
+		existing, err := s.labelSVC.FindLabelByID(ctx, id)
+		if err != nil {
+			return ierrors.Wrap(err, fmt.Sprintf("failed to find label %q of stack %s", l.MetaName(), depStackID))
+		}
+		l.orgID = orgID
+		l.existing = existing
+	}
+
+	// the mappings of labels which are no longer resources of their stack
+	// were removed with the labels.
+	mappingsToRemove := state.labelMappingsToRemove[:0]
+	for _, m := range state.labelMappingsToRemove {
+		if m.LabelStackID != 0 {
+			id, err := findLabelID(m.LabelStackID, m.LabelMetaName)
+			if err != nil {
+				continue
+			}
+			m.LabelID = id
+		}
+		mappingsToRemove = append(mappingsToRemove, m)
+	}
+	state.labelMappingsToRemove = mappingsToRemove
+
+	if stackID == 0 {
+		return nil
+	}
+	removed := make(map[string]bool)
+	for _, l := range state.mLabels {
+		if IsRemoval(l.stateStatus) {
+			removed[l.MetaName()] = true
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	stacksInOrg, err := s.store.ListStacks(ctx, orgID, ListFilter{})
+	if err != nil {
+		return internalErr(err)
+	}
+	for _, st := range stacksInOrg {
+		ev := st.LatestEvent()
+		if st.ID == stackID || ev.EventType == StackEventUninstalled {
+			continue
+		}
+		for _, r := range ev.Resources {
+			for _, ass := range r.Associations {
+				if ass.StackID == stackID && ass.Kind.is(KindLabel) && removed[ass.MetaName] {
+					return &errors2.Error{
+						Code: errors2.EConflict,
+						Msg:  fmt.Sprintf("label %q is a dependency of stack %s and can't be removed", ass.MetaName, st.ID),
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
 type (
 	// ApplyOpt is an option for applying a package.
 	ApplyOpt struct {
@@ -1458,6 +1941,8 @@ type (
 		StackID         platform.ID
 		ResourcesToSkip map[ActionSkipResource]bool
 		KindsToSkip     map[Kind]bool
+
+		rollback bool
 	}
 
 	// ActionSkipResource provides an action from the consumer to use the template with
@@ -1541,6 +2026,14 @@ func ApplyWithStackID(stackID platform.ID) ApplyOptFn {
 	}
 }
 
+// applyAsRollback applies a previous template version of the stack, without
+// the remote templates of the stack, which the version already contains.
+func applyAsRollback() ApplyOptFn {
+	return func(o *ApplyOpt) {
+		o.rollback = true
+	}
+}
+
 func applyOptFromOptFns(opts ...ApplyOptFn) ApplyOpt {
 	var opt ApplyOpt
 	for _, o := range opts {
@@ -1573,6 +2066,14 @@ func (s *Service) Apply(ctx context.Context, orgID, userID platform.ID, opts ...
 		return ImpactSummary{}, err
 	}
 
+	if err := validateEnvSubstitutions(template); err != nil {
+		return ImpactSummary{}, err
+	}
+
+	if err := validateSecrets(template, opt.MissingSecrets); err != nil {
+		return ImpactSummary{}, err
+	}
+
 	stackID := opt.StackID
 	// if stackID is not provided, a stack will be provided for the application.
 	if stackID == 0 {
@@ -1584,17 +2085,17 @@ func (s *Service) Apply(ctx context.Context, orgID, userID platform.ID, opts ...
 	}
 
 	defer func(stackID platform.ID) {
-		updateStackFn := s.updateStackAfterSuccess
+		var err error
 		if e != nil {
-			updateStackFn = s.updateStackAfterRollback
 			if opt.StackID == 0 {
 				if err := s.store.DeleteStack(ctx, stackID); err != nil {
 					s.log.Error("failed to delete created stack", zap.Error(err))
 				}
 			}
+			err = s.updateStackAfterRollback(ctx, stackID, state, template.Sources())
+		} else {
+			err = s.updateStackAfterSuccess(ctx, stackID, state, template, opt)
 		}
-
-		err := updateStackFn(ctx, stackID, state, template.Sources())
 		if err != nil {
 			s.log.Error("failed to update stack", zap.Error(err))
 		}
@@ -1624,6 +2125,15 @@ func (s *Service) applyState(ctx context.Context, coordinator *rollbackCoordinat
 		return ierrors.Wrap(err, "failed to setup notification generator")
 	}
 
+	var remotes, removedRemotes []*stateRemote
+	for _, r := range state.remotes() {
+		if IsRemoval(r.stateStatus) {
+			removedRemotes = append(removedRemotes, r)
+			continue
+		}
+		remotes = append(remotes, r)
+	}
+
 	// each grouping here runs for its entirety, then returns an error that
 	// is indicative of running all appliers provided. For instance, the labels
 	// may have 1 variable fail and one of the buckets fails. The errors aggregate so
@@ -1652,6 +2162,7 @@ func (s *Service) applyState(ctx context.Context, coordinator *rollbackCoordinat
 			endpointApp,
 			s.applyTasks(ctx, state.tasks()),
 			s.applyTelegrafs(ctx, userID, state.telegrafConfigs()),
+			s.applyRemotes(ctx, remotes),
 		},
 	}
 
@@ -1672,11 +2183,23 @@ func (s *Service) applyState(ctx context.Context, coordinator *rollbackCoordinat
 	secondary := []applier{
 		s.applyLabelMappings(ctx, state.labelMappings),
 		s.removeLabelMappings(ctx, state.labelMappingsToRemove),
+		// notebooks reference buckets by name, these must exist
+		// before the notebooks can be applied.
+		s.applyNotebooks(ctx, state.notebooks()),
+		// dbrp mappings and replications reference the buckets, replications
+		// the remotes they write to.
+		s.applyDBRPs(ctx, state.dbrps(), state.mBuckets),
+		s.applyReplications(ctx, state.replications(), state.mRemotes, state.mBuckets),
 	}
 	if err := coordinator.runTilEnd(ctx, orgID, userID, secondary...); err != nil {
 		return internalErr(err)
 	}
 
+	// remotes are removed last, once the replications writing to them are removed.
+	if err := coordinator.runTilEnd(ctx, orgID, userID, s.applyRemotes(ctx, removedRemotes)); err != nil {
+		return internalErr(err)
+	}
+
 	return nil
 }
 
@@ -2694,30 +3217,32 @@ func (s *Service) rollbackTasks(ctx context.Context, tasks []*stateTask) error {
 	return nil
 }
 
-func (s *Service) applyTelegrafs(ctx context.Context, userID platform.ID, teles []*stateTelegraf) applier {
-	const resource = "telegrafs"
+func (s *Service) applyNotebooks(ctx context.Context, notebooks []*stateNotebook) applier {
+	const resource = "notebook"
 
 	mutex := new(doMutex)
-	rollbackTelegrafs := make([]*stateTelegraf, 0, len(teles))
+	rollbackNotebooks := make([]*stateNotebook, 0, len(notebooks))
 
 	createFn := func(ctx context.Context, i int, orgID, userID platform.ID) *applyErrBody {
-		var t *stateTelegraf
+		var n *stateNotebook
 		mutex.Do(func() {
-			teles[i].orgID = orgID
-			t = teles[i]
+			notebooks[i].orgID = orgID
+			n = notebooks[i]
 		})
 
-		existing, err := s.applyTelegrafConfig(ctx, userID, t)
+		influxNotebook, err := s.applyNotebook(ctx, n)
 		if err != nil {
 			return &applyErrBody{
-				name: t.parserTelegraf.MetaName(),
+				name: n.parserNotebook.MetaName(),
 				msg:  err.Error(),
 			}
 		}
 
 		mutex.Do(func() {
-			teles[i].id = existing.ID
-			rollbackTelegrafs = append(rollbackTelegrafs, teles[i])
+			if influxNotebook != nil {
+				notebooks[i].id = influxNotebook.ID
+			}
+			rollbackNotebooks = append(rollbackNotebooks, notebooks[i])
 		})
 
 		return nil
@@ -2725,31 +3250,659 @@ func (s *Service) applyTelegrafs(ctx context.Context, userID platform.ID, teles
 
 	return applier{
 		creater: creater{
-			entries: len(teles),
+			entries: len(notebooks),
 			fn:      createFn,
 		},
 		rollbacker: rollbacker{
 			resource: resource,
 			fn: func(_ platform.ID) error {
-				return s.rollbackTelegrafConfigs(ctx, userID, rollbackTelegrafs)
+				return s.rollbackNotebooks(ctx, rollbackNotebooks)
 			},
 		},
 	}
 }
 
-func (s *Service) applyTelegrafConfig(ctx context.Context, userID platform.ID, t *stateTelegraf) (influxdb.TelegrafConfig, error) {
-	switch {
-	case IsRemoval(t.stateStatus):
-		if err := s.teleSVC.DeleteTelegrafConfig(ctx, t.ID()); err != nil {
+func (s *Service) applyNotebook(ctx context.Context, n *stateNotebook) (*influxdb.Notebook, error) {
+	if s.notebookSVC == nil {
+		return nil, applyFailErr("apply", n.stateIdentity(), errors.New("notebooks are not supported"))
+	}
+
+	if IsRemoval(n.stateStatus) {
+		if err := s.notebookSVC.DeleteNotebook(ctx, n.ID()); err != nil {
 			if errors2.ErrorCode(err) == errors2.ENotFound {
-				return influxdb.TelegrafConfig{}, nil
+				return nil, nil
 			}
-			return influxdb.TelegrafConfig{}, applyFailErr("delete", t.stateIdentity(), err)
+			return nil, applyFailErr("delete", n.stateIdentity(), err)
 		}
-		return *t.existing, nil
-	case IsExisting(t.stateStatus) && t.existing != nil:
-		cfg := t.summarize().TelegrafConfig
-		updatedConfig, err := s.teleSVC.UpdateTelegrafConfig(ctx, t.ID(), &cfg, userID)
+		return n.existing, nil
+	}
+
+	spec, err := s.resolveNotebookBuckets(ctx, n.orgID, n.parserNotebook.notebookSpec())
+	if err != nil {
+		return nil, applyFailErr("resolve buckets for", n.stateIdentity(), err)
+	}
+	body := &influxdb.NotebookReqBody{
+		OrgID: n.orgID,
+		Name:  n.parserNotebook.Name(),
+		Spec:  spec,
+	}
+
+	if IsExisting(n.stateStatus) && n.existing != nil {
+		updated, err := s.notebookSVC.UpdateNotebook(ctx, n.ID(), body)
+		if err != nil {
+			return nil, applyFailErr("update", n.stateIdentity(), err)
+		}
+		return updated, nil
+	}
+
+	created, err := s.notebookSVC.CreateNotebook(ctx, body)
+	if err != nil {
+		return nil, applyFailErr("create", n.stateIdentity(), err)
+	}
+	return created, nil
+}
+
+// resolveNotebookBuckets sets the id of every bucket referenced by name in the
+// spec to the id of the bucket with that name in the org.
+func (s *Service) resolveNotebookBuckets(ctx context.Context, orgID platform.ID, spec influxdb.NotebookSpec) (influxdb.NotebookSpec, error) {
+	var errs []string
+	eachNotebookBucketRef(spec, func(ref map[string]interface{}) {
+		name, ok := ref[fieldName].(string)
+		if !ok || name == "" {
+			return
+		}
+		bkt, err := s.bucketSVC.FindBucketByName(ctx, orgID, name)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("bucket[%q]: %s", name, err))
+			return
+		}
+		ref[fieldNotebookID] = bkt.ID.String()
+	})
+	if len(errs) > 0 {
+		return nil, errors.New(strings.Join(errs, "; "))
+	}
+	return spec, nil
+}
+
+func (s *Service) rollbackNotebooks(ctx context.Context, notebooks []*stateNotebook) error {
+	rollbackFn := func(n *stateNotebook) error {
+		if !IsNew(n.stateStatus) && n.existing == nil {
+			return nil
+		}
+
+		var err error
+		switch n.stateStatus {
+		case StateStatusRemove:
+			var created *influxdb.Notebook
+			created, err = s.notebookSVC.CreateNotebook(ctx, &influxdb.NotebookReqBody{
+				OrgID: n.existing.OrgID,
+				Name:  n.existing.Name,
+				Spec:  n.existing.Spec,
+			})
+			if err == nil {
+				n.existing = created
+			}
+			err = ierrors.Wrap(err, "rolling back removed notebook")
+		case StateStatusExists:
+			_, err = s.notebookSVC.UpdateNotebook(ctx, n.ID(), &influxdb.NotebookReqBody{
+				OrgID: n.existing.OrgID,
+				Name:  n.existing.Name,
+				Spec:  n.existing.Spec,
+			})
+			err = ierrors.Wrap(err, "rolling back updated notebook")
+		default:
+			err = ierrors.Wrap(s.notebookSVC.DeleteNotebook(ctx, n.ID()), "rolling back created notebook")
+		}
+		return err
+	}
+
+	var errs []string
+	for _, n := range notebooks {
+		if err := rollbackFn(n); err != nil {
+			errs = append(errs, fmt.Sprintf("error for notebook[%q]: %s", n.ID(), err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// resolveBucketID returns the id of the bucket a resource references by the
+// template name of a bucket of the template, or by the name of a bucket of
+// the org. The buckets of the template must have been applied.
+func (s *Service) resolveBucketID(ctx context.Context, orgID platform.ID, buckets map[string]*stateBucket, ref string) (platform.ID, error) {
+	if b, ok := buckets[ref]; ok && !IsRemoval(b.stateStatus) && b.ID() != 0 {
+		return b.ID(), nil
+	}
+	bkt, err := s.bucketSVC.FindBucketByName(ctx, orgID, ref)
+	if err != nil {
+		return 0, ierrors.Wrap(err, fmt.Sprintf("bucket[%q]", ref))
+	}
+	return bkt.ID, nil
+}
+
+func (s *Service) applyDBRPs(ctx context.Context, dbrps []*stateDBRP, buckets map[string]*stateBucket) applier {
+	const resource = "dbrp"
+
+	mutex := new(doMutex)
+	rollbackDBRPs := make([]*stateDBRP, 0, len(dbrps))
+
+	createFn := func(ctx context.Context, i int, orgID, userID platform.ID) *applyErrBody {
+		var d *stateDBRP
+		mutex.Do(func() {
+			dbrps[i].orgID = orgID
+			d = dbrps[i]
+		})
+
+		influxDBRP, err := s.applyDBRP(ctx, d, buckets)
+		if err != nil {
+			return &applyErrBody{
+				name: d.parserDBRP.MetaName(),
+				msg:  err.Error(),
+			}
+		}
+
+		mutex.Do(func() {
+			if influxDBRP != nil {
+				dbrps[i].id = influxDBRP.ID
+			}
+			rollbackDBRPs = append(rollbackDBRPs, dbrps[i])
+		})
+
+		return nil
+	}
+
+	return applier{
+		creater: creater{
+			entries: len(dbrps),
+			fn:      createFn,
+		},
+		rollbacker: rollbacker{
+			resource: resource,
+			fn: func(_ platform.ID) error {
+				return s.rollbackDBRPs(ctx, rollbackDBRPs)
+			},
+		},
+	}
+}
+
+func (s *Service) applyDBRP(ctx context.Context, d *stateDBRP, buckets map[string]*stateBucket) (*influxdb.DBRPMapping, error) {
+	if s.dbrpSVC == nil {
+		return nil, applyFailErr("apply", d.stateIdentity(), errors.New("dbrp mappings are not supported"))
+	}
+
+	if IsRemoval(d.stateStatus) {
+		if err := s.dbrpSVC.Delete(ctx, d.orgID, d.ID()); err != nil {
+			if errors2.ErrorCode(err) == errors2.ENotFound {
+				return nil, nil
+			}
+			return nil, applyFailErr("delete", d.stateIdentity(), err)
+		}
+		return d.existing, nil
+	}
+
+	bucketID, err := s.resolveBucketID(ctx, d.orgID, buckets, d.parserDBRP.bucketName)
+	if err != nil {
+		return nil, applyFailErr("resolve bucket for", d.stateIdentity(), err)
+	}
+	m := &influxdb.DBRPMapping{
+		Database:        d.parserDBRP.database,
+		RetentionPolicy: d.parserDBRP.retentionPolicy,
+		Default:         d.parserDBRP.isDefault,
+		OrganizationID:  d.orgID,
+		BucketID:        bucketID,
+	}
+
+	if IsExisting(d.stateStatus) && d.existing != nil {
+		if d.existing.Database != m.Database || d.existing.BucketID != m.BucketID {
+			return nil, applyFailErr("update", d.stateIdentity(), errors.New("the database and bucket of a dbrp mapping can not be changed, replace the mapping with a new one"))
+		}
+		m.ID = d.existing.ID
+		if err := s.dbrpSVC.Update(ctx, m); err != nil {
+			return nil, applyFailErr("update", d.stateIdentity(), err)
+		}
+		return m, nil
+	}
+
+	if err := s.dbrpSVC.Create(ctx, m); err != nil {
+		return nil, applyFailErr("create", d.stateIdentity(), err)
+	}
+	return m, nil
+}
+
+func (s *Service) rollbackDBRPs(ctx context.Context, dbrps []*stateDBRP) error {
+	rollbackFn := func(d *stateDBRP) error {
+		if !IsNew(d.stateStatus) && d.existing == nil {
+			return nil
+		}
+
+		var err error
+		switch d.stateStatus {
+		case StateStatusRemove:
+			// recreated with the id of the removed mapping.
+			existing := *d.existing
+			err = ierrors.Wrap(s.dbrpSVC.Create(ctx, &existing), "rolling back removed dbrp mapping")
+		case StateStatusExists:
+			existing := *d.existing
+			err = ierrors.Wrap(s.dbrpSVC.Update(ctx, &existing), "rolling back updated dbrp mapping")
+		default:
+			err = ierrors.Wrap(s.dbrpSVC.Delete(ctx, d.orgID, d.ID()), "rolling back created dbrp mapping")
+		}
+		return err
+	}
+
+	var errs []string
+	for _, d := range dbrps {
+		if err := rollbackFn(d); err != nil {
+			errs = append(errs, fmt.Sprintf("error for dbrp[%q]: %s", d.ID(), err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func (s *Service) applyRemotes(ctx context.Context, remotes []*stateRemote) applier {
+	const resource = "remote"
+
+	mutex := new(doMutex)
+	rollbackRemotes := make([]*stateRemote, 0, len(remotes))
+
+	createFn := func(ctx context.Context, i int, orgID, userID platform.ID) *applyErrBody {
+		var r *stateRemote
+		mutex.Do(func() {
+			remotes[i].orgID = orgID
+			r = remotes[i]
+		})
+
+		influxRemote, err := s.applyRemote(ctx, r)
+		if err != nil {
+			return &applyErrBody{
+				name: r.parserRemote.MetaName(),
+				msg:  err.Error(),
+			}
+		}
+
+		mutex.Do(func() {
+			if influxRemote != nil {
+				remotes[i].id = influxRemote.ID
+			}
+			rollbackRemotes = append(rollbackRemotes, remotes[i])
+		})
+
+		return nil
+	}
+
+	return applier{
+		creater: creater{
+			entries: len(remotes),
+			fn:      createFn,
+		},
+		rollbacker: rollbacker{
+			resource: resource,
+			fn: func(_ platform.ID) error {
+				return s.rollbackRemotes(ctx, rollbackRemotes)
+			},
+		},
+	}
+}
+
+func (s *Service) applyRemote(ctx context.Context, r *stateRemote) (*influxdb.RemoteConnection, error) {
+	if s.remoteSVC == nil {
+		return nil, applyFailErr("apply", r.stateIdentity(), errors.New("remote connections are not supported"))
+	}
+
+	if IsRemoval(r.stateStatus) {
+		if err := s.remoteSVC.DeleteRemoteConnection(ctx, r.ID()); err != nil {
+			if errors2.ErrorCode(err) == errors2.ENotFound {
+				return nil, nil
+			}
+			return nil, applyFailErr("delete", r.stateIdentity(), err)
+		}
+		return r.existing, nil
+	}
+
+	token, err := s.remoteToken(ctx, r)
+	if err != nil {
+		return nil, applyFailErr("resolve token for", r.stateIdentity(), err)
+	}
+
+	p := r.parserRemote
+	if IsExisting(r.stateStatus) && r.existing != nil {
+		name, url := p.Name(), p.url
+		updated, err := s.remoteSVC.UpdateRemoteConnection(ctx, r.ID(), influxdb.UpdateRemoteConnectionRequest{
+			Name:             &name,
+			Description:      &p.description,
+			RemoteURL:        &url,
+			RemoteToken:      &token,
+			RemoteOrgID:      p.remoteOrg(),
+			AllowInsecureTLS: &p.allowInsecureTLS,
+		})
+		if err != nil {
+			return nil, applyFailErr("update", r.stateIdentity(), err)
+		}
+		return updated, nil
+	}
+
+	created, err := s.remoteSVC.CreateRemoteConnection(ctx, influxdb.CreateRemoteConnectionRequest{
+		OrgID:            r.orgID,
+		Name:             p.Name(),
+		Description:      &p.description,
+		RemoteURL:        p.url,
+		RemoteToken:      token,
+		RemoteOrgID:      p.remoteOrg(),
+		AllowInsecureTLS: p.allowInsecureTLS,
+	})
+	if err != nil {
+		return nil, applyFailErr("create", r.stateIdentity(), err)
+	}
+	return created, nil
+}
+
+// remoteToken returns the token of a remote, which the template provides
+// from a secret of the org, an env ref or a value.
+func (s *Service) remoteToken(ctx context.Context, r *stateRemote) (string, error) {
+	ref := r.parserRemote.token
+	if ref == nil {
+		return "", errors.New("token must be provided")
+	}
+	if ref.Secret != "" {
+		return s.secretSVC.LoadSecret(ctx, r.orgID, ref.Secret)
+	}
+	token := ref.StringVal()
+	if token == "" {
+		token, _ = ifaceToStr(ref.defaultVal)
+	}
+	if token == "" {
+		return "", errors.New("token must be provided")
+	}
+	return token, nil
+}
+
+// rollbackRemotes restores the remotes as they were. The tokens of remotes
+// can't be read back, so a removed remote can not be restored, and an
+// updated remote keeps the token it is updated with.
+func (s *Service) rollbackRemotes(ctx context.Context, remotes []*stateRemote) error {
+	rollbackFn := func(r *stateRemote) error {
+		if !IsNew(r.stateStatus) && r.existing == nil {
+			return nil
+		}
+
+		var err error
+		switch r.stateStatus {
+		case StateStatusRemove:
+			err = fmt.Errorf("rolling back removed remote: the token of remote %q is unknown, the remote must be recreated", r.existing.Name)
+		case StateStatusExists:
+			e := r.existing
+			description := ""
+			if e.Description != nil {
+				description = *e.Description
+			}
+			_, err = s.remoteSVC.UpdateRemoteConnection(ctx, r.ID(), influxdb.UpdateRemoteConnectionRequest{
+				Name:             &e.Name,
+				Description:      &description,
+				RemoteURL:        &e.RemoteURL,
+				RemoteOrgID:      e.RemoteOrgID,
+				AllowInsecureTLS: &e.AllowInsecureTLS,
+			})
+			err = ierrors.Wrap(err, "rolling back updated remote")
+		default:
+			err = ierrors.Wrap(s.remoteSVC.DeleteRemoteConnection(ctx, r.ID()), "rolling back created remote")
+		}
+		return err
+	}
+
+	var errs []string
+	for _, r := range remotes {
+		if err := rollbackFn(r); err != nil {
+			errs = append(errs, fmt.Sprintf("error for remote[%q]: %s", r.ID(), err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func (s *Service) applyReplications(ctx context.Context, replications []*stateReplication, remotes map[string]*stateRemote, buckets map[string]*stateBucket) applier {
+	const resource = "replication"
+
+	mutex := new(doMutex)
+	rollbackReplications := make([]*stateReplication, 0, len(replications))
+
+	createFn := func(ctx context.Context, i int, orgID, userID platform.ID) *applyErrBody {
+		var r *stateReplication
+		mutex.Do(func() {
+			replications[i].orgID = orgID
+			r = replications[i]
+		})
+
+		influxReplication, err := s.applyReplication(ctx, r, remotes, buckets)
+		if err != nil {
+			return &applyErrBody{
+				name: r.parserReplication.MetaName(),
+				msg:  err.Error(),
+			}
+		}
+
+		mutex.Do(func() {
+			if influxReplication != nil {
+				replications[i].id = influxReplication.ID
+			}
+			rollbackReplications = append(rollbackReplications, replications[i])
+		})
+
+		return nil
+	}
+
+	return applier{
+		creater: creater{
+			entries: len(replications),
+			fn:      createFn,
+		},
+		rollbacker: rollbacker{
+			resource: resource,
+			fn: func(_ platform.ID) error {
+				return s.rollbackReplications(ctx, rollbackReplications)
+			},
+		},
+	}
+}
+
+func (s *Service) applyReplication(ctx context.Context, r *stateReplication, remotes map[string]*stateRemote, buckets map[string]*stateBucket) (*influxdb.Replication, error) {
+	if s.replSVC == nil {
+		return nil, applyFailErr("apply", r.stateIdentity(), errors.New("replications are not supported"))
+	}
+
+	if IsRemoval(r.stateStatus) {
+		if err := s.replSVC.DeleteReplication(ctx, r.ID()); err != nil {
+			if errors2.ErrorCode(err) == errors2.ENotFound {
+				return nil, nil
+			}
+			return nil, applyFailErr("delete", r.stateIdentity(), err)
+		}
+		return r.existing, nil
+	}
+
+	p := r.parserReplication
+	rem, ok := remotes[p.remoteName.String()]
+	if !ok || rem.ID() == 0 {
+		return nil, applyFailErr("apply", r.stateIdentity(), fmt.Errorf("remote %q is not applied", p.remoteName.String()))
+	}
+	bucketID, err := s.resolveBucketID(ctx, r.orgID, buckets, p.bucketName)
+	if err != nil {
+		return nil, applyFailErr("resolve bucket for", r.stateIdentity(), err)
+	}
+
+	if IsExisting(r.stateStatus) && r.existing != nil {
+		if r.existing.LocalBucketID != bucketID {
+			return nil, applyFailErr("update", r.stateIdentity(), errors.New("the bucket of a replication can not be changed, replace the replication with a new one"))
+		}
+		name, remoteID, remoteBucketID, maxQueueSize := p.Name(), rem.ID(), p.remoteBucket(), p.maxQueueSize()
+		upd := influxdb.UpdateReplicationRequest{
+			Name:                 &name,
+			Description:          &p.description,
+			RemoteID:             &remoteID,
+			RemoteBucketName:     &p.remoteBucketName,
+			MaxQueueSizeBytes:    &maxQueueSize,
+			DropNonRetryableData: &p.dropNonRetryableData,
+			MaxAgeSeconds:        &p.maxAgeSeconds,
+		}
+		if remoteBucketID.Valid() {
+			upd.RemoteBucketID = &remoteBucketID
+		}
+		updated, err := s.replSVC.UpdateReplication(ctx, r.ID(), upd)
+		if err != nil {
+			return nil, applyFailErr("update", r.stateIdentity(), err)
+		}
+		return updated, nil
+	}
+
+	created, err := s.replSVC.CreateReplication(ctx, influxdb.CreateReplicationRequest{
+		OrgID:                r.orgID,
+		Name:                 p.Name(),
+		Description:          &p.description,
+		RemoteID:             rem.ID(),
+		LocalBucketID:        bucketID,
+		RemoteBucketID:       p.remoteBucket(),
+		RemoteBucketName:     p.remoteBucketName,
+		MaxQueueSizeBytes:    p.maxQueueSize(),
+		DropNonRetryableData: p.dropNonRetryableData,
+		MaxAgeSeconds:        p.maxAgeSeconds,
+	})
+	if err != nil {
+		return nil, applyFailErr("create", r.stateIdentity(), err)
+	}
+	return created, nil
+}
+
+func (s *Service) rollbackReplications(ctx context.Context, replications []*stateReplication) error {
+	rollbackFn := func(r *stateReplication) error {
+		if !IsNew(r.stateStatus) && r.existing == nil {
+			return nil
+		}
+
+		var err error
+		switch r.stateStatus {
+		case StateStatusRemove:
+			e := r.existing
+			req := influxdb.CreateReplicationRequest{
+				OrgID:                e.OrgID,
+				Name:                 e.Name,
+				Description:          e.Description,
+				RemoteID:             e.RemoteID,
+				LocalBucketID:        e.LocalBucketID,
+				RemoteBucketName:     e.RemoteBucketName,
+				MaxQueueSizeBytes:    e.MaxQueueSizeBytes,
+				DropNonRetryableData: e.DropNonRetryableData,
+				MaxAgeSeconds:        e.MaxAgeSeconds,
+			}
+			if e.RemoteBucketID != nil {
+				req.RemoteBucketID = *e.RemoteBucketID
+			}
+			var created *influxdb.Replication
+			created, err = s.replSVC.CreateReplication(ctx, req)
+			if err == nil {
+				r.existing = created
+			}
+			err = ierrors.Wrap(err, "rolling back removed replication")
+		case StateStatusExists:
+			e := r.existing
+			_, err = s.replSVC.UpdateReplication(ctx, r.ID(), influxdb.UpdateReplicationRequest{
+				Name:                 &e.Name,
+				Description:          e.Description,
+				RemoteID:             &e.RemoteID,
+				RemoteBucketID:       e.RemoteBucketID,
+				RemoteBucketName:     &e.RemoteBucketName,
+				MaxQueueSizeBytes:    &e.MaxQueueSizeBytes,
+				DropNonRetryableData: &e.DropNonRetryableData,
+				MaxAgeSeconds:        &e.MaxAgeSeconds,
+			})
+			err = ierrors.Wrap(err, "rolling back updated replication")
+		default:
+			err = ierrors.Wrap(s.replSVC.DeleteReplication(ctx, r.ID()), "rolling back created replication")
+		}
+		return err
+	}
+
+	var errs []string
+	for _, r := range replications {
+		if err := rollbackFn(r); err != nil {
+			errs = append(errs, fmt.Sprintf("error for replication[%q]: %s", r.ID(), err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func (s *Service) applyTelegrafs(ctx context.Context, userID platform.ID, teles []*stateTelegraf) applier {
+	const resource = "telegrafs"
+
+	mutex := new(doMutex)
+	rollbackTelegrafs := make([]*stateTelegraf, 0, len(teles))
+
+	createFn := func(ctx context.Context, i int, orgID, userID platform.ID) *applyErrBody {
+		var t *stateTelegraf
+		mutex.Do(func() {
+			teles[i].orgID = orgID
+			t = teles[i]
+		})
+
+		existing, err := s.applyTelegrafConfig(ctx, userID, t)
+		if err != nil {
+			return &applyErrBody{
+				name: t.parserTelegraf.MetaName(),
+				msg:  err.Error(),
+			}
+		}
+
+		mutex.Do(func() {
+			teles[i].id = existing.ID
+			rollbackTelegrafs = append(rollbackTelegrafs, teles[i])
+		})
+
+		return nil
+	}
+
+	return applier{
+		creater: creater{
+			entries: len(teles),
+			fn:      createFn,
+		},
+		rollbacker: rollbacker{
+			resource: resource,
+			fn: func(_ platform.ID) error {
+				return s.rollbackTelegrafConfigs(ctx, userID, rollbackTelegrafs)
+			},
+		},
+	}
+}
+
+func (s *Service) applyTelegrafConfig(ctx context.Context, userID platform.ID, t *stateTelegraf) (influxdb.TelegrafConfig, error) {
+	switch {
+	case IsRemoval(t.stateStatus):
+		if err := s.teleSVC.DeleteTelegrafConfig(ctx, t.ID()); err != nil {
+			if errors2.ErrorCode(err) == errors2.ENotFound {
+				return influxdb.TelegrafConfig{}, nil
+			}
+			return influxdb.TelegrafConfig{}, applyFailErr("delete", t.stateIdentity(), err)
+		}
+		return *t.existing, nil
+	case IsExisting(t.stateStatus) && t.existing != nil:
+		cfg := t.summarize().TelegrafConfig
+		updatedConfig, err := s.teleSVC.UpdateTelegrafConfig(ctx, t.ID(), &cfg, userID)
 		if err != nil {
 			return influxdb.TelegrafConfig{}, applyFailErr("update", t.stateIdentity(), err)
 		}
@@ -3060,7 +4213,7 @@ func (s *Service) rollbackLabelMappings(ctx context.Context, mappings []stateLab
 }
 
 func (s *Service) templateFromApplyOpts(ctx context.Context, opt ApplyOpt) (*Template, error) {
-	if opt.StackID != 0 {
+	if opt.StackID != 0 && !opt.rollback {
 		remotes, err := s.getStackRemoteTemplates(ctx, opt.StackID)
 		if err != nil {
 			return nil, err
@@ -3113,12 +4266,17 @@ func (s *Service) getStackRemoteTemplates(ctx context.Context, stackID platform.
 	return remotes, nil
 }
 
-func (s *Service) updateStackAfterSuccess(ctx context.Context, stackID platform.ID, state *stateCoordinator, sources []string) error {
+func (s *Service) updateStackAfterSuccess(ctx context.Context, stackID platform.ID, state *stateCoordinator, template *Template, opt ApplyOpt) error {
 	stack, err := s.store.ReadStackByID(ctx, stackID)
 	if err != nil {
 		return err
 	}
 
+	encodedTemplate, err := template.Encode(EncodingJSON)
+	if err != nil {
+		return err
+	}
+
 	var stackResources []StackResource
 	for _, b := range state.mBuckets {
 		if IsRemoval(b.stateStatus) || isSystemBucket(b.existing) {
@@ -3194,6 +4352,50 @@ func (s *Service) updateStackAfterSuccess(ctx context.Context, stackID platform.
 			),
 		})
 	}
+	for _, n := range state.mNotebooks {
+		if IsRemoval(n.stateStatus) {
+			continue
+		}
+		stackResources = append(stackResources, StackResource{
+			APIVersion: APIVersion,
+			ID:         n.ID(),
+			Kind:       KindNotebook,
+			MetaName:   n.parserNotebook.MetaName(),
+		})
+	}
+	for _, d := range state.mDBRPs {
+		if IsRemoval(d.stateStatus) {
+			continue
+		}
+		stackResources = append(stackResources, StackResource{
+			APIVersion: APIVersion,
+			ID:         d.ID(),
+			Kind:       KindDBRP,
+			MetaName:   d.parserDBRP.MetaName(),
+		})
+	}
+	for _, r := range state.mRemotes {
+		if IsRemoval(r.stateStatus) {
+			continue
+		}
+		stackResources = append(stackResources, StackResource{
+			APIVersion: APIVersion,
+			ID:         r.ID(),
+			Kind:       KindRemote,
+			MetaName:   r.parserRemote.MetaName(),
+		})
+	}
+	for _, r := range state.mReplications {
+		if IsRemoval(r.stateStatus) {
+			continue
+		}
+		stackResources = append(stackResources, StackResource{
+			APIVersion: APIVersion,
+			ID:         r.ID(),
+			Kind:       KindReplication,
+			MetaName:   r.parserReplication.MetaName(),
+		})
+	}
 	for _, t := range state.mTasks {
 		if IsRemoval(t.stateStatus) || isRestrictedTask(t.existing) {
 			continue
@@ -3232,8 +4434,13 @@ func (s *Service) updateStackAfterSuccess(ctx context.Context, stackID platform.
 	}
 	ev := stack.LatestEvent()
 	ev.EventType = StackEventUpdate
+	if opt.rollback {
+		ev.EventType = StackEventRollback
+	}
 	ev.Resources = stackResources
-	ev.Sources = sources
+	ev.Sources = template.Sources()
+	ev.Template = encodedTemplate
+	ev.EnvRefs = opt.EnvRefs
 	ev.UpdatedAt = s.timeGen.Now()
 	stack.Events = append(stack.Events, ev)
 	return s.store.UpdateStack(ctx, stack)
@@ -3327,6 +4534,20 @@ func (s *Service) updateStackAfterRollback(ctx context.Context, stackID platform
 				res.Associations = newAss
 			}
 		}
+		for _, n := range state.mNotebooks {
+			res, ok := existingResources[newKey(KindNotebook, n.parserNotebook.MetaName())]
+			if ok && n.existing != nil && res.ID != n.ID() {
+				hasChanges = true
+				res.ID = n.existing.ID
+			}
+		}
+		for _, r := range state.mReplications {
+			res, ok := existingResources[newKey(KindReplication, r.parserReplication.MetaName())]
+			if ok && r.existing != nil && res.ID != r.ID() {
+				hasChanges = true
+				res.ID = r.existing.ID
+			}
+		}
 		for _, t := range state.mTasks {
 			res, ok := existingResources[newKey(KindTask, t.parserTask.MetaName())]
 			if ok && res.ID != t.ID() {
@@ -3492,6 +4713,7 @@ func stateLabelsToStackAssociations(stateLabels []*stateLabel) []StackResourceAs
 		out = append(out, StackResourceAssociation{
 			Kind:     KindLabel,
 			MetaName: l.parserLabel.MetaName(),
+			StackID:  l.parserLabel.stackID,
 		})
 	}
 	return out
@@ -3506,6 +4728,8 @@ func applyFailErr(method string, ident stateIdentity, err error) error {
 	return ierrors.Wrap(err, msg)
 }
 
+// getLabelIDMap returns the IDs of the labels named, and of the labels descending
+// from them.
 func getLabelIDMap(ctx context.Context, labelSVC influxdb.LabelService, labelNames []string) (map[platform.ID]bool, error) {
 	mLabelIDs := make(map[platform.ID]bool)
 	for _, labelName := range labelNames {
@@ -3515,8 +4739,18 @@ func getLabelIDMap(ctx context.Context, labelSVC influxdb.LabelService, labelNam
 		if err != nil {
 			return nil, err
 		}
-		if len(iLabels) == 1 {
-			mLabelIDs[iLabels[0].ID] = true
+		if len(iLabels) != 1 {
+			continue
+		}
+
+		orgLabels, err := labelSVC.FindLabels(ctx, influxdb.LabelFilter{
+			OrgID: &iLabels[0].OrgID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for id := range influxdb.LabelDescendants(orgLabels, iLabels[0].ID) {
+			mLabelIDs[id] = true
 		}
 	}
 	return mLabelIDs, nil
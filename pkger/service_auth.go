@@ -45,6 +45,14 @@ func (s *authMW) UninstallStack(ctx context.Context, identifiers struct{ OrgID,
 	return s.next.UninstallStack(ctx, identifiers)
 }
 
+func (s *authMW) RollbackStack(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (ImpactSummary, error) {
+	err := s.authAgent.IsWritable(ctx, identifiers.OrgID, ResourceTypeStack)
+	if err != nil {
+		return ImpactSummary{}, err
+	}
+	return s.next.RollbackStack(ctx, identifiers)
+}
+
 func (s *authMW) DeleteStack(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) error {
 	err := s.authAgent.IsWritable(ctx, identifiers.OrgID, ResourceTypeStack)
 	if err != nil {
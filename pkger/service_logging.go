@@ -61,6 +61,24 @@ func (s *loggingMW) UninstallStack(ctx context.Context, identifiers struct{ OrgI
 	return s.next.UninstallStack(ctx, identifiers)
 }
 
+func (s *loggingMW) RollbackStack(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (_ ImpactSummary, err error) {
+	defer func(start time.Time) {
+		if err == nil {
+			return
+		}
+
+		s.logger.Error(
+			"failed to rollback stack",
+			zap.Error(err),
+			zap.Stringer("orgID", identifiers.OrgID),
+			zap.Stringer("userID", identifiers.UserID),
+			zap.Stringer("stackID", identifiers.StackID),
+			zap.Duration("took", time.Since(start)),
+		)
+	}(time.Now())
+	return s.next.RollbackStack(ctx, identifiers)
+}
+
 func (s *loggingMW) DeleteStack(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (err error) {
 	defer func(start time.Time) {
 		if err == nil {
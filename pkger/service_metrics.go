@@ -54,6 +54,12 @@ func (s *mwMetrics) UninstallStack(ctx context.Context, identifiers struct{ OrgI
 	return stack, rec(err)
 }
 
+func (s *mwMetrics) RollbackStack(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (ImpactSummary, error) {
+	rec := s.rec.Record("rollback_stack")
+	impact, err := s.next.RollbackStack(ctx, identifiers)
+	return impact, rec(err)
+}
+
 func (s *mwMetrics) DeleteStack(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) error {
 	rec := s.rec.Record("delete_stack")
 	return rec(s.next.DeleteStack(ctx, identifiers))
@@ -11,15 +11,23 @@ import (
 )
 
 type stateCoordinator struct {
-	mBuckets    map[string]*stateBucket
-	mChecks     map[string]*stateCheck
-	mDashboards map[string]*stateDashboard
-	mEndpoints  map[string]*stateEndpoint
-	mLabels     map[string]*stateLabel
-	mRules      map[string]*stateRule
-	mTasks      map[string]*stateTask
-	mTelegrafs  map[string]*stateTelegraf
-	mVariables  map[string]*stateVariable
+	mBuckets      map[string]*stateBucket
+	mChecks       map[string]*stateCheck
+	mDashboards   map[string]*stateDashboard
+	mDBRPs        map[string]*stateDBRP
+	mEndpoints    map[string]*stateEndpoint
+	mLabels       map[string]*stateLabel
+	mRules        map[string]*stateRule
+	mNotebooks    map[string]*stateNotebook
+	mRemotes      map[string]*stateRemote
+	mReplications map[string]*stateReplication
+	mTasks        map[string]*stateTask
+	mTelegrafs    map[string]*stateTelegraf
+	mVariables    map[string]*stateVariable
+
+	// mStackLabels are the labels of other stacks the resources are
+	// associated with, by stackLabelKey.
+	mStackLabels map[string]*stateLabel
 
 	labelMappings         []stateLabelMapping
 	labelMappingsToRemove []stateLabelMappingForRemoval
@@ -27,15 +35,21 @@ type stateCoordinator struct {
 
 func newStateCoordinator(template *Template, acts resourceActions) *stateCoordinator {
 	state := stateCoordinator{
-		mBuckets:    make(map[string]*stateBucket),
-		mChecks:     make(map[string]*stateCheck),
-		mDashboards: make(map[string]*stateDashboard),
-		mEndpoints:  make(map[string]*stateEndpoint),
-		mLabels:     make(map[string]*stateLabel),
-		mRules:      make(map[string]*stateRule),
-		mTasks:      make(map[string]*stateTask),
-		mTelegrafs:  make(map[string]*stateTelegraf),
-		mVariables:  make(map[string]*stateVariable),
+		mBuckets:      make(map[string]*stateBucket),
+		mChecks:       make(map[string]*stateCheck),
+		mDashboards:   make(map[string]*stateDashboard),
+		mDBRPs:        make(map[string]*stateDBRP),
+		mEndpoints:    make(map[string]*stateEndpoint),
+		mLabels:       make(map[string]*stateLabel),
+		mRules:        make(map[string]*stateRule),
+		mNotebooks:    make(map[string]*stateNotebook),
+		mRemotes:      make(map[string]*stateRemote),
+		mReplications: make(map[string]*stateReplication),
+		mTasks:        make(map[string]*stateTask),
+		mTelegrafs:    make(map[string]*stateTelegraf),
+		mVariables:    make(map[string]*stateVariable),
+
+		mStackLabels: make(map[string]*stateLabel),
 	}
 
 	// labels are done first to validate dependencies are accounted for.
@@ -51,6 +65,14 @@ func newStateCoordinator(template *Template, acts resourceActions) *stateCoordin
 			stateStatus: StateStatusNew,
 		}
 	}
+	// the labels of other stacks exist, they are found when the stacks
+	// they depend on are resolved.
+	for _, l := range template.stackLabels() {
+		state.mStackLabels[stackLabelKey(l.stackID, l.MetaName())] = &stateLabel{
+			parserLabel: l,
+			stateStatus: StateStatusExists,
+		}
+	}
 	for _, b := range template.buckets() {
 		if acts.skipResource(KindBucket, b.MetaName()) {
 			continue
@@ -101,6 +123,42 @@ func newStateCoordinator(template *Template, acts resourceActions) *stateCoordin
 			labelAssociations: state.templateToStateLabels(r.labels),
 		}
 	}
+	for _, n := range template.notebooks() {
+		if acts.skipResource(KindNotebook, n.MetaName()) {
+			continue
+		}
+		state.mNotebooks[n.MetaName()] = &stateNotebook{
+			parserNotebook: n,
+			stateStatus:    StateStatusNew,
+		}
+	}
+	for _, d := range template.dbrps() {
+		if acts.skipResource(KindDBRP, d.MetaName()) {
+			continue
+		}
+		state.mDBRPs[d.MetaName()] = &stateDBRP{
+			parserDBRP:  d,
+			stateStatus: StateStatusNew,
+		}
+	}
+	for _, r := range template.remotes() {
+		if acts.skipResource(KindRemote, r.MetaName()) {
+			continue
+		}
+		state.mRemotes[r.MetaName()] = &stateRemote{
+			parserRemote: r,
+			stateStatus:  StateStatusNew,
+		}
+	}
+	for _, r := range template.replications() {
+		if acts.skipResource(KindReplication, r.MetaName()) {
+			continue
+		}
+		state.mReplications[r.MetaName()] = &stateReplication{
+			parserReplication: r,
+			stateStatus:       StateStatusNew,
+		}
+	}
 	for _, task := range template.tasks() {
 		if acts.skipResource(KindTask, task.MetaName()) {
 			continue
@@ -183,6 +241,47 @@ func (s *stateCoordinator) rules() []*stateRule {
 	return out
 }
 
+func (s *stateCoordinator) notebooks() []*stateNotebook {
+	out := make([]*stateNotebook, 0, len(s.mNotebooks))
+	for _, n := range s.mNotebooks {
+		out = append(out, n)
+	}
+	return out
+}
+
+func (s *stateCoordinator) dbrps() []*stateDBRP {
+	out := make([]*stateDBRP, 0, len(s.mDBRPs))
+	for _, d := range s.mDBRPs {
+		out = append(out, d)
+	}
+	return out
+}
+
+func (s *stateCoordinator) remotes() []*stateRemote {
+	out := make([]*stateRemote, 0, len(s.mRemotes))
+	for _, r := range s.mRemotes {
+		out = append(out, r)
+	}
+	return out
+}
+
+func (s *stateCoordinator) replications() []*stateReplication {
+	out := make([]*stateReplication, 0, len(s.mReplications))
+	for _, r := range s.mReplications {
+		out = append(out, r)
+	}
+	return out
+}
+
+// bucketName returns the name of the bucket a resource references by the
+// template name of a bucket of the template, or by the name of the bucket.
+func (s *stateCoordinator) bucketName(ref string) string {
+	if b, ok := s.mBuckets[ref]; ok && !IsRemoval(b.stateStatus) {
+		return b.parserBkt.Name()
+	}
+	return ref
+}
+
 func (s *stateCoordinator) tasks() []*stateTask {
 	out := make([]*stateTask, 0, len(s.mTasks))
 	for _, t := range s.mTasks {
@@ -251,6 +350,34 @@ func (s *stateCoordinator) diff() Diff {
 		return diff.NotificationRules[i].MetaName < diff.NotificationRules[j].MetaName
 	})
 
+	for _, n := range s.mNotebooks {
+		diff.Notebooks = append(diff.Notebooks, n.diffNotebook())
+	}
+	sort.Slice(diff.Notebooks, func(i, j int) bool {
+		return diff.Notebooks[i].MetaName < diff.Notebooks[j].MetaName
+	})
+
+	for _, d := range s.mDBRPs {
+		diff.DBRPs = append(diff.DBRPs, d.diffDBRP())
+	}
+	sort.Slice(diff.DBRPs, func(i, j int) bool {
+		return diff.DBRPs[i].MetaName < diff.DBRPs[j].MetaName
+	})
+
+	for _, r := range s.mRemotes {
+		diff.Remotes = append(diff.Remotes, r.diffRemote())
+	}
+	sort.Slice(diff.Remotes, func(i, j int) bool {
+		return diff.Remotes[i].MetaName < diff.Remotes[j].MetaName
+	})
+
+	for _, r := range s.mReplications {
+		diff.Replications = append(diff.Replications, r.diffReplication())
+	}
+	sort.Slice(diff.Replications, func(i, j int) bool {
+		return diff.Replications[i].MetaName < diff.Replications[j].MetaName
+	})
+
 	for _, t := range s.mTasks {
 		diff.Tasks = append(diff.Tasks, t.diffTask())
 	}
@@ -361,6 +488,46 @@ func (s *stateCoordinator) summary() Summary {
 		return sum.NotificationRules[i].MetaName < sum.NotificationRules[j].MetaName
 	})
 
+	for _, n := range s.mNotebooks {
+		if IsRemoval(n.stateStatus) {
+			continue
+		}
+		sum.Notebooks = append(sum.Notebooks, n.summarize())
+	}
+	sort.Slice(sum.Notebooks, func(i, j int) bool {
+		return sum.Notebooks[i].MetaName < sum.Notebooks[j].MetaName
+	})
+
+	for _, d := range s.mDBRPs {
+		if IsRemoval(d.stateStatus) {
+			continue
+		}
+		sum.DBRPs = append(sum.DBRPs, d.summarize())
+	}
+	sort.Slice(sum.DBRPs, func(i, j int) bool {
+		return sum.DBRPs[i].MetaName < sum.DBRPs[j].MetaName
+	})
+
+	for _, r := range s.mRemotes {
+		if IsRemoval(r.stateStatus) {
+			continue
+		}
+		sum.Remotes = append(sum.Remotes, r.summarize())
+	}
+	sort.Slice(sum.Remotes, func(i, j int) bool {
+		return sum.Remotes[i].MetaName < sum.Remotes[j].MetaName
+	})
+
+	for _, r := range s.mReplications {
+		if IsRemoval(r.stateStatus) {
+			continue
+		}
+		sum.Replications = append(sum.Replications, r.summarize())
+	}
+	sort.Slice(sum.Replications, func(i, j int) bool {
+		return sum.Replications[i].MetaName < sum.Replications[j].MetaName
+	})
+
 	for _, t := range s.mTasks {
 		if IsRemoval(t.stateStatus) {
 			continue
@@ -417,6 +584,9 @@ func (s *stateCoordinator) templateToStateLabels(labels []*label) []*stateLabel
 	var out []*stateLabel
 	for _, l := range labels {
 		stLabel, found := s.getLabelByMetaName(l.MetaName())
+		if l.stackID != 0 {
+			stLabel, found = s.mStackLabels[stackLabelKey(l.stackID, l.MetaName())]
+		}
 		if !found {
 			continue
 		}
@@ -475,6 +645,7 @@ func (s *stateCoordinator) reconcileLabelMappings(stackResources []StackResource
 			delete(mStackAss, StackResourceAssociation{
 				Kind:     KindLabel,
 				MetaName: l.parserLabel.MetaName(),
+				StackID:  l.parserLabel.stackID,
 			})
 		}
 
@@ -484,6 +655,7 @@ func (s *stateCoordinator) reconcileLabelMappings(stackResources []StackResource
 			s.labelMappingsToRemove = append(s.labelMappingsToRemove, stateLabelMappingForRemoval{
 				LabelMetaName:    assForRemoval.MetaName,
 				LabelID:          mLabelMetaNameToID[assForRemoval.MetaName],
+				LabelStackID:     assForRemoval.StackID,
 				ResourceID:       r.ID,
 				ResourceMetaName: r.MetaName,
 				ResourceType:     r.Kind.ResourceType(),
@@ -528,6 +700,18 @@ func (s *stateCoordinator) get(k Kind, metaName string) (interface{}, bool) {
 	case KindNotificationRule:
 		v, ok := s.mRules[metaName]
 		return v, ok
+	case KindNotebook:
+		v, ok := s.mNotebooks[metaName]
+		return v, ok
+	case KindDBRP:
+		v, ok := s.mDBRPs[metaName]
+		return v, ok
+	case KindRemote:
+		v, ok := s.mRemotes[metaName]
+		return v, ok
+	case KindReplication:
+		v, ok := s.mReplications[metaName]
+		return v, ok
 	case KindTask:
 		v, ok := s.mTasks[metaName]
 		return v, ok
@@ -617,6 +801,30 @@ func (s *stateCoordinator) addObjectForRemoval(k Kind, metaName string, id platf
 			parserRule:  &notificationRule{identity: newIdentity},
 			stateStatus: StateStatusRemove,
 		}
+	case KindNotebook:
+		s.mNotebooks[metaName] = &stateNotebook{
+			id:             id,
+			parserNotebook: &notebook{identity: newIdentity},
+			stateStatus:    StateStatusRemove,
+		}
+	case KindDBRP:
+		s.mDBRPs[metaName] = &stateDBRP{
+			id:          id,
+			parserDBRP:  &dbrp{identity: newIdentity},
+			stateStatus: StateStatusRemove,
+		}
+	case KindRemote:
+		s.mRemotes[metaName] = &stateRemote{
+			id:           id,
+			parserRemote: &remote{identity: newIdentity},
+			stateStatus:  StateStatusRemove,
+		}
+	case KindReplication:
+		s.mReplications[metaName] = &stateReplication{
+			id:                id,
+			parserReplication: &replication{identity: newIdentity},
+			stateStatus:       StateStatusRemove,
+		}
 	case KindTask:
 		s.mTasks[metaName] = &stateTask{
 			id:          id,
@@ -679,6 +887,30 @@ func (s *stateCoordinator) getObjectIDSetter(k Kind, metaName string) (func(plat
 			r.id = id
 			r.stateStatus = StateStatusExists
 		}, ok
+	case KindNotebook:
+		r, ok := s.mNotebooks[metaName]
+		return func(id platform.ID) {
+			r.id = id
+			r.stateStatus = StateStatusExists
+		}, ok
+	case KindDBRP:
+		r, ok := s.mDBRPs[metaName]
+		return func(id platform.ID) {
+			r.id = id
+			r.stateStatus = StateStatusExists
+		}, ok
+	case KindRemote:
+		r, ok := s.mRemotes[metaName]
+		return func(id platform.ID) {
+			r.id = id
+			r.stateStatus = StateStatusExists
+		}, ok
+	case KindReplication:
+		r, ok := s.mReplications[metaName]
+		return func(id platform.ID) {
+			r.id = id
+			r.stateStatus = StateStatusExists
+		}, ok
 	case KindTask:
 		r, ok := s.mTasks[metaName]
 		return func(id platform.ID) {
@@ -995,6 +1227,11 @@ func (l *stateLabel) summarize() SummaryLabel {
 	sum := l.parserLabel.summarize()
 	sum.ID = SafeID(l.ID())
 	sum.OrgID = SafeID(l.orgID)
+	if l.parserLabel.stackID != 0 && l.existing != nil {
+		sum.Name = l.Name()
+		sum.Properties.Color = l.existing.Properties["color"]
+		sum.Properties.Description = l.existing.Properties["description"]
+	}
 	return sum
 }
 
@@ -1006,6 +1243,9 @@ func (l *stateLabel) ID() platform.ID {
 }
 
 func (l *stateLabel) Name() string {
+	if l.parserLabel.stackID != 0 && l.existing != nil {
+		return l.existing.Name
+	}
 	return l.parserLabel.Name()
 }
 
@@ -1085,8 +1325,11 @@ func stateLabelMappingToInfluxLabelMapping(mapping stateLabelMapping) influxdb.L
 }
 
 type stateLabelMappingForRemoval struct {
-	LabelID          platform.ID
-	LabelMetaName    string
+	LabelID       platform.ID
+	LabelMetaName string
+	// LabelStackID is the stack which owns the label, when it is the label
+	// of another stack. Its ID is found when the stack is resolved.
+	LabelStackID     platform.ID
 	ResourceID       platform.ID
 	ResourceMetaName string
 	ResourceType     influxdb.ResourceType
@@ -1418,6 +1661,291 @@ func (t *stateTask) summarize() SummaryTask {
 	return sum
 }
 
+type stateNotebook struct {
+	id, orgID   platform.ID
+	stateStatus StateStatus
+
+	parserNotebook *notebook
+	existing       *influxdb.Notebook
+}
+
+func (n *stateNotebook) ID() platform.ID {
+	if !IsNew(n.stateStatus) && n.existing != nil {
+		return n.existing.ID
+	}
+	return n.id
+}
+
+func (n *stateNotebook) diffNotebook() DiffNotebook {
+	diff := DiffNotebook{
+		DiffIdentifier: DiffIdentifier{
+			Kind:        KindNotebook,
+			ID:          SafeID(n.ID()),
+			StateStatus: n.stateStatus,
+			MetaName:    n.parserNotebook.MetaName(),
+		},
+		New: DiffNotebookValues{
+			Name: n.parserNotebook.Name(),
+			Spec: n.parserNotebook.notebookSpec(),
+		},
+	}
+	if e := n.existing; e != nil {
+		diff.Old = &DiffNotebookValues{
+			Name: e.Name,
+			Spec: notebookSpecWithoutBucketIDs(e.Spec),
+		}
+	}
+	return diff
+}
+
+func (n *stateNotebook) resourceType() influxdb.ResourceType {
+	return KindNotebook.ResourceType()
+}
+
+func (n *stateNotebook) stateIdentity() stateIdentity {
+	return stateIdentity{
+		id:           n.ID(),
+		name:         n.parserNotebook.Name(),
+		metaName:     n.parserNotebook.MetaName(),
+		resourceType: n.resourceType(),
+		stateStatus:  n.stateStatus,
+	}
+}
+
+func (n *stateNotebook) summarize() SummaryNotebook {
+	sum := n.parserNotebook.summarize()
+	sum.ID = SafeID(n.ID())
+	sum.OrgID = SafeID(n.orgID)
+	return sum
+}
+
+type stateDBRP struct {
+	id, orgID   platform.ID
+	stateStatus StateStatus
+
+	parserDBRP *dbrp
+	existing   *influxdb.DBRPMapping
+
+	// bucketName is the name of the bucket the dbrp mapping maps to, and
+	// existingBucketName the name of the bucket the existing mapping maps to.
+	bucketName         string
+	existingBucketName string
+}
+
+func (d *stateDBRP) ID() platform.ID {
+	if !IsNew(d.stateStatus) && d.existing != nil {
+		return d.existing.ID
+	}
+	return d.id
+}
+
+func (d *stateDBRP) diffDBRP() DiffDBRP {
+	diff := DiffDBRP{
+		DiffIdentifier: DiffIdentifier{
+			Kind:        KindDBRP,
+			ID:          SafeID(d.ID()),
+			StateStatus: d.stateStatus,
+			MetaName:    d.parserDBRP.MetaName(),
+		},
+		New: DiffDBRPValues{
+			Database:        d.parserDBRP.database,
+			RetentionPolicy: d.parserDBRP.retentionPolicy,
+			Default:         d.parserDBRP.isDefault,
+			BucketName:      d.bucketName,
+		},
+	}
+	if e := d.existing; e != nil {
+		diff.Old = &DiffDBRPValues{
+			Database:        e.Database,
+			RetentionPolicy: e.RetentionPolicy,
+			Default:         e.Default,
+			BucketName:      d.existingBucketName,
+		}
+	}
+	return diff
+}
+
+func (d *stateDBRP) resourceType() influxdb.ResourceType {
+	return KindDBRP.ResourceType()
+}
+
+func (d *stateDBRP) stateIdentity() stateIdentity {
+	return stateIdentity{
+		id:           d.ID(),
+		name:         d.parserDBRP.database + "/" + d.parserDBRP.retentionPolicy,
+		metaName:     d.parserDBRP.MetaName(),
+		resourceType: d.resourceType(),
+		stateStatus:  d.stateStatus,
+	}
+}
+
+func (d *stateDBRP) summarize() SummaryDBRP {
+	sum := d.parserDBRP.summarize()
+	sum.ID = SafeID(d.ID())
+	sum.OrgID = SafeID(d.orgID)
+	if d.bucketName != "" {
+		sum.BucketName = d.bucketName
+	}
+	return sum
+}
+
+type stateRemote struct {
+	id, orgID   platform.ID
+	stateStatus StateStatus
+
+	parserRemote *remote
+	existing     *influxdb.RemoteConnection
+}
+
+func (r *stateRemote) ID() platform.ID {
+	if !IsNew(r.stateStatus) && r.existing != nil {
+		return r.existing.ID
+	}
+	return r.id
+}
+
+func (r *stateRemote) diffRemote() DiffRemote {
+	diff := DiffRemote{
+		DiffIdentifier: DiffIdentifier{
+			Kind:        KindRemote,
+			ID:          SafeID(r.ID()),
+			StateStatus: r.stateStatus,
+			MetaName:    r.parserRemote.MetaName(),
+		},
+		New: DiffRemoteValues{
+			Name:             r.parserRemote.Name(),
+			Description:      r.parserRemote.description,
+			URL:              r.parserRemote.url,
+			RemoteOrgID:      r.parserRemote.remoteOrgID,
+			AllowInsecureTLS: r.parserRemote.allowInsecureTLS,
+		},
+	}
+	if e := r.existing; e != nil {
+		diff.Old = &DiffRemoteValues{
+			Name:             e.Name,
+			URL:              e.RemoteURL,
+			AllowInsecureTLS: e.AllowInsecureTLS,
+		}
+		if e.Description != nil {
+			diff.Old.Description = *e.Description
+		}
+		if e.RemoteOrgID != nil {
+			diff.Old.RemoteOrgID = e.RemoteOrgID.String()
+		}
+	}
+	return diff
+}
+
+func (r *stateRemote) resourceType() influxdb.ResourceType {
+	return KindRemote.ResourceType()
+}
+
+func (r *stateRemote) stateIdentity() stateIdentity {
+	return stateIdentity{
+		id:           r.ID(),
+		name:         r.parserRemote.Name(),
+		metaName:     r.parserRemote.MetaName(),
+		resourceType: r.resourceType(),
+		stateStatus:  r.stateStatus,
+	}
+}
+
+func (r *stateRemote) summarize() SummaryRemote {
+	sum := r.parserRemote.summarize()
+	sum.ID = SafeID(r.ID())
+	sum.OrgID = SafeID(r.orgID)
+	return sum
+}
+
+type stateReplication struct {
+	id, orgID   platform.ID
+	stateStatus StateStatus
+
+	parserReplication *replication
+	existing          *influxdb.Replication
+
+	// bucketName is the name of the local bucket the replication reads
+	// from, existingBucketName and existingRemoteName are the names of the
+	// local bucket and remote of the existing replication.
+	bucketName         string
+	existingBucketName string
+	existingRemoteName string
+}
+
+func (r *stateReplication) ID() platform.ID {
+	if !IsNew(r.stateStatus) && r.existing != nil {
+		return r.existing.ID
+	}
+	return r.id
+}
+
+func (r *stateReplication) diffReplication() DiffReplication {
+	p := r.parserReplication
+	diff := DiffReplication{
+		DiffIdentifier: DiffIdentifier{
+			Kind:        KindReplication,
+			ID:          SafeID(r.ID()),
+			StateStatus: r.stateStatus,
+			MetaName:    p.MetaName(),
+		},
+		New: DiffReplicationValues{
+			Name:                 p.Name(),
+			Description:          p.description,
+			BucketName:           r.bucketName,
+			RemoteBucketID:       p.remoteBucketID,
+			RemoteBucketName:     p.remoteBucketName,
+			MaxQueueSizeBytes:    p.maxQueueSize(),
+			MaxAgeSeconds:        p.maxAgeSeconds,
+			DropNonRetryableData: p.dropNonRetryableData,
+		},
+	}
+	if p.associatedRemote != nil {
+		diff.New.RemoteName = p.associatedRemote.Name()
+	}
+	if e := r.existing; e != nil {
+		diff.Old = &DiffReplicationValues{
+			Name:                 e.Name,
+			RemoteName:           r.existingRemoteName,
+			BucketName:           r.existingBucketName,
+			RemoteBucketName:     e.RemoteBucketName,
+			MaxQueueSizeBytes:    e.MaxQueueSizeBytes,
+			MaxAgeSeconds:        e.MaxAgeSeconds,
+			DropNonRetryableData: e.DropNonRetryableData,
+		}
+		if e.Description != nil {
+			diff.Old.Description = *e.Description
+		}
+		if e.RemoteBucketID != nil {
+			diff.Old.RemoteBucketID = e.RemoteBucketID.String()
+		}
+	}
+	return diff
+}
+
+func (r *stateReplication) resourceType() influxdb.ResourceType {
+	return KindReplication.ResourceType()
+}
+
+func (r *stateReplication) stateIdentity() stateIdentity {
+	return stateIdentity{
+		id:           r.ID(),
+		name:         r.parserReplication.Name(),
+		metaName:     r.parserReplication.MetaName(),
+		resourceType: r.resourceType(),
+		stateStatus:  r.stateStatus,
+	}
+}
+
+func (r *stateReplication) summarize() SummaryReplication {
+	sum := r.parserReplication.summarize()
+	sum.ID = SafeID(r.ID())
+	sum.OrgID = SafeID(r.orgID)
+	if r.bucketName != "" {
+		sum.BucketName = r.bucketName
+	}
+	return sum
+}
+
 type stateTelegraf struct {
 	id, orgID         platform.ID
 	stateStatus       StateStatus
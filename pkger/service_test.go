@@ -35,6 +35,7 @@ func TestService(t *testing.T) {
 			dashSVC:     mock.NewDashboardService(),
 			labelSVC:    mock.NewLabelService(),
 			endpointSVC: mock.NewNotificationEndpointService(),
+			notebookSVC: &fakeNotebookSVC{},
 			orgSVC:      mock.NewOrganizationService(),
 			ruleSVC:     mock.NewNotificationRuleStore(),
 			store: &fakeStore{
@@ -61,12 +62,17 @@ func TestService(t *testing.T) {
 
 		applyOpts := []ServiceSetterFn{
 			WithStore(opt.store),
+			WithAuthorizationSVC(opt.authSVC),
 			WithBucketSVC(opt.bucketSVC),
 			WithCheckSVC(opt.checkSVC),
 			WithDashboardSVC(opt.dashSVC),
+			WithDBRPSVC(opt.dbrpSVC),
 			WithLabelSVC(opt.labelSVC),
 			WithNotificationEndpointSVC(opt.endpointSVC),
 			WithNotificationRuleSVC(opt.ruleSVC),
+			WithNotebookSVC(opt.notebookSVC),
+			WithRemoteConnectionSVC(opt.remoteSVC),
+			WithReplicationSVC(opt.replSVC),
 			WithOrganizationService(opt.orgSVC),
 			WithSecretSVC(opt.secretSVC),
 			WithTaskSVC(opt.taskSVC),
@@ -244,6 +250,229 @@ func TestService(t *testing.T) {
 					},
 				})
 			})
+
+			t.Run("reports downstream impact of renamed and removed buckets", func(t *testing.T) {
+				testfileRunner(t, "testdata/bucket.yml", func(t *testing.T, template *Template) {
+					fakeBktSVC := mock.NewBucketService()
+					fakeBktSVC.FindBucketByIDFn = func(_ context.Context, id platform.ID) (*influxdb.Bucket, error) {
+						switch id {
+						case 1:
+							return &influxdb.Bucket{ID: id, OrgID: 100, Name: "old-name"}, nil
+						case 9:
+							return &influxdb.Bucket{ID: id, OrgID: 100, Name: "sunset"}, nil
+						}
+						return nil, errors.New("not found")
+					}
+					fakeBktSVC.FindBucketByNameFn = func(_ context.Context, orgID platform.ID, name string) (*influxdb.Bucket, error) {
+						return nil, errors.New("not found")
+					}
+
+					fakeTaskSVC := mock.NewTaskService()
+					fakeTaskSVC.FindTasksFn = func(ctx context.Context, f taskmodel.TaskFilter) ([]*taskmodel.Task, int, error) {
+						return []*taskmodel.Task{
+							{ID: 11, Name: "downsample", Flux: `from(bucket: "sunset") |> range(start: -1h) |> to(bucket: "other")`},
+							{ID: 12, Name: "unrelated", Flux: `from(bucket: "other") |> range(start: -1h)`},
+						}, 2, nil
+					}
+
+					fakeDashSVC := mock.NewDashboardService()
+					fakeDashSVC.FindDashboardsF = func(context.Context, influxdb.DashboardFilter, influxdb.FindOptions) ([]*influxdb.Dashboard, int, error) {
+						return []*influxdb.Dashboard{
+							{ID: 21, Name: "overview", Cells: []*influxdb.Cell{{ID: 22}}},
+						}, 1, nil
+					}
+					fakeDashSVC.GetDashboardCellViewF = func(ctx context.Context, dashboardID, cellID platform.ID) (*influxdb.View, error) {
+						return &influxdb.View{
+							Properties: influxdb.XYViewProperties{
+								Type: influxdb.ViewPropertyTypeXY,
+								Queries: []influxdb.DashboardQuery{
+									{Text: `from(bucket: "old-name") |> range(start: -1h)`},
+								},
+							},
+						}, nil
+					}
+
+					fakeAuthSVC := mock.NewAuthorizationService()
+					fakeAuthSVC.FindAuthorizationsFn = func(context.Context, influxdb.AuthorizationFilter, ...influxdb.FindOptions) ([]*influxdb.Authorization, int, error) {
+						sunsetID, otherID := platform.ID(9), platform.ID(10)
+						return []*influxdb.Authorization{
+							{
+								ID:          31,
+								Description: "writer",
+								Permissions: []influxdb.Permission{
+									{Action: influxdb.WriteAction, Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, ID: &sunsetID}},
+									{Action: influxdb.WriteAction, Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, ID: &otherID}},
+								},
+							},
+						}, 1, nil
+					}
+
+					fakeDBRPSVC := &mock.DBRPMappingService{
+						FindManyFn: func(ctx context.Context, f influxdb.DBRPMappingFilter, opts ...influxdb.FindOptions) ([]*influxdb.DBRPMapping, int, error) {
+							return []*influxdb.DBRPMapping{
+								{ID: 41, Database: "sunset", RetentionPolicy: "autogen", BucketID: 9},
+							}, 1, nil
+						},
+					}
+
+					svc := newTestService(
+						WithBucketSVC(fakeBktSVC),
+						WithTaskSVC(fakeTaskSVC),
+						WithDashboardSVC(fakeDashSVC),
+						WithAuthorizationSVC(fakeAuthSVC),
+						WithDBRPSVC(fakeDBRPSVC),
+						WithStore(&fakeStore{
+							readFn: func(ctx context.Context, id platform.ID) (Stack, error) {
+								return Stack{
+									ID: id,
+									Events: []StackEvent{{
+										Resources: []StackResource{
+											{APIVersion: APIVersion, ID: 1, Kind: KindBucket, MetaName: "rucket-11"},
+											{APIVersion: APIVersion, ID: 9, Kind: KindBucket, MetaName: "sunset-bucket"},
+										},
+									}},
+								}, nil
+							},
+						}),
+					)
+
+					impact, err := svc.DryRun(context.TODO(), platform.ID(100), 0, ApplyWithTemplate(template), ApplyWithStackID(3))
+					require.NoError(t, err)
+
+					expected := []DownstreamImpact{
+						{
+							ResourceType:   influxdb.TasksResourceType,
+							ID:             11,
+							Name:           "downsample",
+							BucketMetaName: "sunset-bucket",
+							Reason:         `task queries bucket "sunset" is removed`,
+						},
+						{
+							ResourceType:   influxdb.DashboardsResourceType,
+							ID:             21,
+							Name:           "overview",
+							BucketMetaName: "rucket-11",
+							Reason:         `dashboard cell queries bucket "old-name" is renamed to "rucket-11"`,
+						},
+						{
+							ResourceType:   influxdb.AuthorizationsResourceType,
+							ID:             31,
+							Name:           "writer",
+							BucketMetaName: "sunset-bucket",
+							Reason:         `token has write permission on bucket "sunset" is removed`,
+						},
+						{
+							ResourceType:   influxdb.DBRPResourceType,
+							ID:             41,
+							Name:           "sunset/autogen",
+							BucketMetaName: "sunset-bucket",
+							Reason:         `dbrp mapping points at bucket "sunset" is removed`,
+						},
+					}
+					assert.Equal(t, expected, impact.Impacts)
+				})
+			})
+		})
+
+		t.Run("stack dependencies", func(t *testing.T) {
+			baseStack := Stack{
+				ID:    9,
+				OrgID: 100,
+				Events: []StackEvent{{
+					EventType: StackEventUpdate,
+					Resources: []StackResource{
+						{APIVersion: APIVersion, ID: 77, Kind: KindLabel, MetaName: "base-label"},
+					},
+				}},
+			}
+
+			t.Run("associates the label of another stack", func(t *testing.T) {
+				testfileRunner(t, "testdata/bucket_associates_stack_label.yml", func(t *testing.T, template *Template) {
+					fakeLabelSVC := mock.NewLabelService()
+					fakeLabelSVC.FindLabelByIDFn = func(ctx context.Context, id platform.ID) (*influxdb.Label, error) {
+						if id != 77 {
+							return nil, errors.New("not found")
+						}
+						return &influxdb.Label{ID: id, OrgID: 100, Name: "team"}, nil
+					}
+					fakeBktSVC := mock.NewBucketService()
+					fakeBktSVC.FindBucketByNameFn = func(_ context.Context, orgID platform.ID, name string) (*influxdb.Bucket, error) {
+						return nil, errors.New("not found")
+					}
+					svc := newTestService(
+						WithBucketSVC(fakeBktSVC),
+						WithLabelSVC(fakeLabelSVC),
+						WithStore(&fakeStore{
+							readFn: func(ctx context.Context, id platform.ID) (Stack, error) {
+								return baseStack, nil
+							},
+						}),
+					)
+
+					impact, err := svc.DryRun(context.TODO(), platform.ID(100), 0, ApplyWithTemplate(template))
+					require.NoError(t, err)
+
+					require.Len(t, impact.Diff.Labels, 1)
+					assert.Equal(t, "label-1", impact.Diff.Labels[0].MetaName)
+
+					assert.Contains(t, impact.Diff.LabelMappings, DiffLabelMapping{
+						StateStatus:   StateStatusNew,
+						ResType:       influxdb.BucketsResourceType,
+						ResName:       "rucket-1",
+						ResMetaName:   "rucket-1",
+						LabelID:       77,
+						LabelName:     "team",
+						LabelMetaName: "base-label",
+					})
+				})
+			})
+
+			t.Run("errors when the stack does not own the label", func(t *testing.T) {
+				testfileRunner(t, "testdata/bucket_associates_stack_label.yml", func(t *testing.T, template *Template) {
+					svc := newTestService(WithStore(&fakeStore{
+						readFn: func(ctx context.Context, id platform.ID) (Stack, error) {
+							return Stack{ID: id, OrgID: 100}, nil
+						},
+					}))
+
+					_, err := svc.DryRun(context.TODO(), platform.ID(100), 0, ApplyWithTemplate(template))
+					require.Error(t, err)
+					assert.Equal(t, errors2.EUnprocessableEntity, errors2.ErrorCode(err))
+				})
+			})
+
+			t.Run("errors when removing a label another stack depends on", func(t *testing.T) {
+				svc := newTestService(WithStore(&fakeStore{
+					readFn: func(ctx context.Context, id platform.ID) (Stack, error) {
+						return baseStack, nil
+					},
+					listFn: func(ctx context.Context, orgID platform.ID, f ListFilter) ([]Stack, error) {
+						return []Stack{
+							baseStack,
+							{
+								ID:    10,
+								OrgID: 100,
+								Events: []StackEvent{{
+									EventType: StackEventUpdate,
+									Resources: []StackResource{{
+										APIVersion: APIVersion,
+										ID:         88,
+										Kind:       KindBucket,
+										MetaName:   "app-bucket",
+										Associations: []StackResourceAssociation{
+											{Kind: KindLabel, MetaName: "base-label", StackID: 9},
+										},
+									}},
+								}},
+							},
+						}, nil
+					},
+				}))
+
+				_, err := svc.DryRun(context.TODO(), platform.ID(100), 0, ApplyWithTemplate(new(Template)), ApplyWithStackID(9))
+				require.Error(t, err)
+				assert.Equal(t, errors2.EConflict, errors2.ErrorCode(err))
+			})
 		})
 
 		t.Run("checks", func(t *testing.T) {
@@ -1583,6 +1812,36 @@ func TestService(t *testing.T) {
 				})
 			})
 
+			t.Run("requires the secrets of tasks", func(t *testing.T) {
+				testfileRunner(t, "testdata/task_secrets.yml", func(t *testing.T, template *Template) {
+					fakeSecretSVC := mock.NewSecretService()
+					fakeSecretSVC.GetSecretKeysFn = func(ctx context.Context, orgID platform.ID) ([]string, error) {
+						return []string{"declared-token"}, nil
+					}
+					fakeSecretSVC.PutSecretsFn = func(ctx context.Context, orgID platform.ID, m map[string]string) error {
+						return nil
+					}
+					fakeTaskSVC := mock.NewTaskService()
+					fakeTaskSVC.CreateTaskFn = func(ctx context.Context, tc taskmodel.TaskCreate) (*taskmodel.Task, error) {
+						return &taskmodel.Task{ID: 1, OrganizationID: tc.OrganizationID, Flux: tc.Flux}, nil
+					}
+
+					svc := newTestService(WithSecretSVC(fakeSecretSVC), WithTaskSVC(fakeTaskSVC))
+
+					_, err := svc.Apply(context.TODO(), platform.ID(9000), 0, ApplyWithTemplate(template))
+					require.Error(t, err)
+					assert.Equal(t, errors2.EUnprocessableEntity, errors2.ErrorCode(err))
+					assert.Contains(t, err.Error(), "slack-token")
+					assert.Zero(t, fakeTaskSVC.CreateTaskCalls.Count())
+
+					_, err = svc.Apply(context.TODO(), platform.ID(9000), 0,
+						ApplyWithTemplate(template),
+						ApplyWithSecrets(map[string]string{"slack-token": "xoxb"}),
+					)
+					require.NoError(t, err)
+				})
+			})
+
 			t.Run("rolls back all created tasks on an error", func(t *testing.T) {
 				testfileRunner(t, "testdata/tasks.yml", func(t *testing.T, template *Template) {
 					fakeTaskSVC := mock.NewTaskService()
@@ -1607,6 +1866,240 @@ func TestService(t *testing.T) {
 			})
 		})
 
+		t.Run("notebooks", func(t *testing.T) {
+			t.Run("successfully creates with bucket references resolved by name", func(t *testing.T) {
+				testfileRunner(t, "testdata/notebook.yml", func(t *testing.T, template *Template) {
+					orgID := platform.ID(9000)
+
+					fakeBktSVC := mock.NewBucketService()
+					fakeBktSVC.CreateBucketFn = func(_ context.Context, b *influxdb.Bucket) error {
+						b.ID = 33
+						return nil
+					}
+					fakeBktSVC.FindBucketByNameFn = func(_ context.Context, id platform.ID, name string) (*influxdb.Bucket, error) {
+						if id != orgID || name != "rucket-1" {
+							return nil, errors.New("bucket not found")
+						}
+						return &influxdb.Bucket{ID: 33, OrgID: orgID, Name: name}, nil
+					}
+
+					var created []*influxdb.NotebookReqBody
+					fakeNotebookSVC := &fakeNotebookSVC{
+						createFn: func(_ context.Context, create *influxdb.NotebookReqBody) (*influxdb.Notebook, error) {
+							created = append(created, create)
+							return &influxdb.Notebook{ID: platform.ID(len(created)), OrgID: create.OrgID, Name: create.Name, Spec: create.Spec}, nil
+						},
+					}
+
+					svc := newTestService(WithBucketSVC(fakeBktSVC), WithNotebookSVC(fakeNotebookSVC))
+
+					impact, err := svc.Apply(context.TODO(), orgID, 0, ApplyWithTemplate(template))
+					require.NoError(t, err)
+
+					sum := impact.Summary
+					require.Len(t, sum.Notebooks, 2)
+					assert.Equal(t, "display name", sum.Notebooks[0].Name)
+					assert.NotZero(t, sum.Notebooks[0].ID)
+					assert.Equal(t, SafeID(orgID), sum.Notebooks[0].OrgID)
+
+					require.Len(t, created, 2)
+					sort.Slice(created, func(i, j int) bool { return created[i].Name < created[j].Name })
+					assert.Equal(t, orgID, created[0].OrgID)
+					assert.NotNil(t, created[1].Spec)
+
+					var bucketIDs []interface{}
+					eachNotebookBucketRef(created[0].Spec, func(ref map[string]interface{}) {
+						bucketIDs = append(bucketIDs, ref[fieldNotebookID])
+					})
+					assert.Equal(t, []interface{}{"0000000000000021", "0000000000000021"}, bucketIDs)
+				})
+			})
+
+			t.Run("rolls back all created notebooks on an error", func(t *testing.T) {
+				testfileRunner(t, "testdata/notebook.yml", func(t *testing.T, template *Template) {
+					fakeBktSVC := mock.NewBucketService()
+					fakeBktSVC.FindBucketByNameFn = func(_ context.Context, id platform.ID, name string) (*influxdb.Bucket, error) {
+						return &influxdb.Bucket{ID: 33, OrgID: id, Name: name}, nil
+					}
+
+					var createCalls, deleteCalls int
+					fakeNotebookSVC := &fakeNotebookSVC{
+						createFn: func(_ context.Context, create *influxdb.NotebookReqBody) (*influxdb.Notebook, error) {
+							createCalls++
+							if createCalls == 1 {
+								return nil, errors.New("limit hit")
+							}
+							return &influxdb.Notebook{ID: 1, OrgID: create.OrgID, Name: create.Name, Spec: create.Spec}, nil
+						},
+						deleteFn: func(_ context.Context, id platform.ID) error {
+							if id != 1 {
+								return errors.New("wrong id here")
+							}
+							deleteCalls++
+							return nil
+						},
+					}
+
+					svc := newTestService(WithBucketSVC(fakeBktSVC), WithNotebookSVC(fakeNotebookSVC))
+
+					_, err := svc.Apply(context.TODO(), platform.ID(9000), 0, ApplyWithTemplate(template))
+					require.Error(t, err)
+
+					assert.Equal(t, 1, deleteCalls)
+				})
+			})
+		})
+
+		t.Run("dbrps, remotes and replications", func(t *testing.T) {
+			t.Run("successfully creates with bucket and remote references resolved", func(t *testing.T) {
+				testfileRunner(t, "testdata/edge", func(t *testing.T, template *Template) {
+					orgID := platform.ID(9000)
+
+					fakeBktSVC := mock.NewBucketService()
+					fakeBktSVC.CreateBucketFn = func(_ context.Context, b *influxdb.Bucket) error {
+						b.ID = 33
+						return nil
+					}
+
+					fakeSecretSVC := mock.NewSecretService()
+					fakeSecretSVC.GetSecretKeysFn = func(_ context.Context, _ platform.ID) ([]string, error) {
+						return []string{"cloud-token"}, nil
+					}
+					fakeSecretSVC.LoadSecretFn = func(_ context.Context, id platform.ID, k string) (string, error) {
+						if id != orgID || k != "cloud-token" {
+							return "", errors.New("secret not found")
+						}
+						return "tok", nil
+					}
+
+					var createdDBRP []*influxdb.DBRPMapping
+					fakeDBRPSVC := &mock.DBRPMappingService{
+						FindManyFn: func(_ context.Context, _ influxdb.DBRPMappingFilter, _ ...influxdb.FindOptions) ([]*influxdb.DBRPMapping, int, error) {
+							return nil, 0, nil
+						},
+						CreateFn: func(_ context.Context, m *influxdb.DBRPMapping) error {
+							m.ID = 1
+							createdDBRP = append(createdDBRP, m)
+							return nil
+						},
+					}
+
+					var createdRemotes []influxdb.CreateRemoteConnectionRequest
+					fakeRemoteSVC := &fakeRemoteSVC{
+						createFn: func(_ context.Context, req influxdb.CreateRemoteConnectionRequest) (*influxdb.RemoteConnection, error) {
+							createdRemotes = append(createdRemotes, req)
+							return &influxdb.RemoteConnection{ID: 5, OrgID: req.OrgID, Name: req.Name, RemoteURL: req.RemoteURL, RemoteOrgID: req.RemoteOrgID}, nil
+						},
+					}
+
+					var createdRepls []influxdb.CreateReplicationRequest
+					fakeReplSVC := &fakeReplicationSVC{
+						createFn: func(_ context.Context, req influxdb.CreateReplicationRequest) (*influxdb.Replication, error) {
+							createdRepls = append(createdRepls, req)
+							return &influxdb.Replication{ID: 7, OrgID: req.OrgID, Name: req.Name, RemoteID: req.RemoteID, LocalBucketID: req.LocalBucketID}, nil
+						},
+					}
+
+					svc := newTestService(
+						WithBucketSVC(fakeBktSVC),
+						WithDBRPSVC(fakeDBRPSVC),
+						WithRemoteConnectionSVC(fakeRemoteSVC),
+						WithReplicationSVC(fakeReplSVC),
+						WithSecretSVC(fakeSecretSVC),
+					)
+
+					impact, err := svc.Apply(context.TODO(), orgID, 0, ApplyWithTemplate(template))
+					require.NoError(t, err)
+
+					sum := impact.Summary
+					require.Len(t, sum.DBRPs, 1)
+					assert.Equal(t, SafeID(1), sum.DBRPs[0].ID)
+					require.Len(t, sum.Remotes, 1)
+					assert.Equal(t, SafeID(5), sum.Remotes[0].ID)
+					require.Len(t, sum.Replications, 1)
+					assert.Equal(t, SafeID(7), sum.Replications[0].ID)
+
+					require.Len(t, createdDBRP, 1)
+					assert.Equal(t, platform.ID(33), createdDBRP[0].BucketID)
+					assert.Equal(t, "telegraf", createdDBRP[0].Database)
+					assert.Equal(t, "autogen", createdDBRP[0].RetentionPolicy)
+
+					require.Len(t, createdRemotes, 1)
+					assert.Equal(t, "tok", createdRemotes[0].RemoteToken)
+					assert.Equal(t, "https://cloud.example.com", createdRemotes[0].RemoteURL)
+
+					require.Len(t, createdRepls, 1)
+					assert.Equal(t, platform.ID(5), createdRepls[0].RemoteID)
+					assert.Equal(t, platform.ID(33), createdRepls[0].LocalBucketID)
+					assert.Equal(t, int64(3600), createdRepls[0].MaxAgeSeconds)
+					assert.True(t, createdRepls[0].DropNonRetryableData)
+				})
+			})
+
+			t.Run("rolls back the created remote when a replication fails", func(t *testing.T) {
+				testfileRunner(t, "testdata/edge", func(t *testing.T, template *Template) {
+					fakeBktSVC := mock.NewBucketService()
+					fakeBktSVC.CreateBucketFn = func(_ context.Context, b *influxdb.Bucket) error {
+						b.ID = 33
+						return nil
+					}
+					fakeBktSVC.DeleteBucketFn = func(_ context.Context, _ platform.ID) error {
+						return nil
+					}
+
+					fakeSecretSVC := mock.NewSecretService()
+					fakeSecretSVC.GetSecretKeysFn = func(_ context.Context, _ platform.ID) ([]string, error) {
+						return []string{"cloud-token"}, nil
+					}
+					fakeSecretSVC.LoadSecretFn = func(_ context.Context, _ platform.ID, _ string) (string, error) {
+						return "tok", nil
+					}
+
+					fakeDBRPSVC := &mock.DBRPMappingService{
+						FindManyFn: func(_ context.Context, _ influxdb.DBRPMappingFilter, _ ...influxdb.FindOptions) ([]*influxdb.DBRPMapping, int, error) {
+							return nil, 0, nil
+						},
+						CreateFn: func(_ context.Context, m *influxdb.DBRPMapping) error {
+							m.ID = 1
+							return nil
+						},
+						DeleteFn: func(_ context.Context, _, _ platform.ID) error {
+							return nil
+						},
+					}
+
+					var deletedRemotes []platform.ID
+					fakeRemoteSVC := &fakeRemoteSVC{
+						createFn: func(_ context.Context, req influxdb.CreateRemoteConnectionRequest) (*influxdb.RemoteConnection, error) {
+							return &influxdb.RemoteConnection{ID: 5, OrgID: req.OrgID, Name: req.Name}, nil
+						},
+						deleteFn: func(_ context.Context, id platform.ID) error {
+							deletedRemotes = append(deletedRemotes, id)
+							return nil
+						},
+					}
+					fakeReplSVC := &fakeReplicationSVC{
+						createFn: func(_ context.Context, _ influxdb.CreateReplicationRequest) (*influxdb.Replication, error) {
+							return nil, errors.New("remote unreachable")
+						},
+					}
+
+					svc := newTestService(
+						WithBucketSVC(fakeBktSVC),
+						WithDBRPSVC(fakeDBRPSVC),
+						WithRemoteConnectionSVC(fakeRemoteSVC),
+						WithReplicationSVC(fakeReplSVC),
+						WithSecretSVC(fakeSecretSVC),
+					)
+
+					_, err := svc.Apply(context.TODO(), platform.ID(9000), 0, ApplyWithTemplate(template))
+					require.Error(t, err)
+
+					assert.Equal(t, []platform.ID{5}, deletedRemotes)
+				})
+			})
+		})
+
 		t.Run("telegrafs", func(t *testing.T) {
 			t.Run("successfuly creates", func(t *testing.T) {
 				testfileRunner(t, "testdata/telegraf.yml", func(t *testing.T, template *Template) {
@@ -1766,6 +2259,24 @@ func TestService(t *testing.T) {
 				})
 			})
 		})
+
+		t.Run("requires the env variables substituted without a default", func(t *testing.T) {
+			testfileRunner(t, "testdata/env_substitution", func(t *testing.T, template *Template) {
+				fakeBktSVC := mock.NewBucketService()
+				fakeBktSVC.CreateBucketFn = func(_ context.Context, b *influxdb.Bucket) error {
+					b.ID = 33
+					return nil
+				}
+
+				svc := newTestService(WithBucketSVC(fakeBktSVC))
+
+				_, err := svc.Apply(context.TODO(), platform.ID(9000), 0, ApplyWithTemplate(template))
+				require.Error(t, err)
+				assert.Equal(t, errors2.EUnprocessableEntity, errors2.ErrorCode(err))
+				assert.Contains(t, err.Error(), "ENV")
+				assert.Zero(t, fakeBktSVC.CreateBucketCalls.Count())
+			})
+		})
 	})
 
 	t.Run("Export", func(t *testing.T) {
@@ -4032,6 +4543,34 @@ func TestService(t *testing.T) {
 					}
 				})
 
+				t.Run("declares the secrets of the task", func(t *testing.T) {
+					taskSVC := mock.NewTaskService()
+					taskSVC.FindTaskByIDFn = func(ctx context.Context, id platform.ID) (*taskmodel.Task, error) {
+						return &taskmodel.Task{
+							ID:    id,
+							Type:  taskmodel.TaskSystemType,
+							Name:  "secret",
+							Every: "5m0s",
+							Flux: `import "influxdata/influxdb/secrets"
+option task = { name: "secret", every: 5m }
+token = secrets.get(key: "slack-token")
+from(bucket: "foo")`,
+						}, nil
+					}
+
+					svc := newTestService(WithTaskSVC(taskSVC))
+
+					template, err := svc.Export(context.TODO(), ExportWithExistingResources(ResourceToClone{Kind: KindTask, ID: 1}))
+					require.NoError(t, err)
+
+					objects := template.Objects
+					require.Len(t, objects, 1)
+					assert.Equal(t, []string{"slack-token"}, objects[0].Spec[fieldTaskSecretRefs])
+
+					newTemplate := encodeAndDecode(t, template)
+					assert.Equal(t, []string{"slack-token"}, newTemplate.missingSecrets())
+				})
+
 				t.Run("handles multiple tasks of same name", func(t *testing.T) {
 					taskSVC := mock.NewTaskService()
 					taskSVC.FindTaskByIDFn = func(ctx context.Context, id platform.ID) (*taskmodel.Task, error) {
@@ -4206,6 +4745,49 @@ func TestService(t *testing.T) {
 				}
 			})
 
+			t.Run("notebook", func(t *testing.T) {
+				t.Run("exports bucket references by name", func(t *testing.T) {
+					fakeNotebookSVC := &fakeNotebookSVC{
+						getFn: func(_ context.Context, id platform.ID) (*influxdb.Notebook, error) {
+							return &influxdb.Notebook{
+								ID:    id,
+								OrgID: 9000,
+								Name:  "nb",
+								Spec: influxdb.NotebookSpec{
+									"name": "nb",
+									"pipes": []interface{}{
+										map[string]interface{}{
+											"type":    "queryBuilder",
+											"buckets": []interface{}{map[string]interface{}{"id": "0000000000000021", "name": "rucket-1"}},
+										},
+									},
+								},
+							}, nil
+						},
+					}
+
+					svc := newTestService(WithNotebookSVC(fakeNotebookSVC))
+
+					template, err := svc.Export(context.TODO(), ExportWithExistingResources(ResourceToClone{
+						Kind: KindNotebook,
+						ID:   1,
+					}))
+					require.NoError(t, err)
+
+					newTemplate := encodeAndDecode(t, template)
+
+					notebooks := newTemplate.Summary().Notebooks
+					require.Len(t, notebooks, 1)
+					assert.Equal(t, "nb", notebooks[0].Name)
+
+					var refs []map[string]interface{}
+					eachNotebookBucketRef(notebooks[0].Spec, func(ref map[string]interface{}) {
+						refs = append(refs, ref)
+					})
+					assert.Equal(t, []map[string]interface{}{{"name": "rucket-1"}}, refs)
+				})
+			})
+
 			t.Run("telegraf configs", func(t *testing.T) {
 				t.Run("allows for duplicate telegraf names to be exported", func(t *testing.T) {
 					tConfig := &influxdb.TelegrafConfig{
@@ -5419,6 +6001,159 @@ func TestService(t *testing.T) {
 			}
 		})
 	})
+
+	t.Run("RollbackStack", func(t *testing.T) {
+		bucketTemplate := func(metaName, name string) []byte {
+			return []byte(fmt.Sprintf(`[{"apiVersion":%q,"kind":"Bucket","metadata":{"name":%q},"spec":{"name":%q}}]`, APIVersion, metaName, name))
+		}
+		now := time.Time{}.Add(10 * 24 * time.Hour)
+
+		newStack := func(events ...StackEvent) Stack {
+			return Stack{ID: 3333, OrgID: 3, Events: events}
+		}
+
+		t.Run("re-applies the previous template version", func(t *testing.T) {
+			stack := newStack(
+				StackEvent{
+					EventType: StackEventUpdate,
+					Template:  bucketTemplate("bkt-old", "rucket-old"),
+					Resources: []StackResource{{APIVersion: APIVersion, ID: 5, Kind: KindBucket, MetaName: "bkt-old"}},
+					UpdatedAt: now.Add(-2 * time.Hour),
+				},
+				StackEvent{
+					EventType: StackEventUpdate,
+					Template:  bucketTemplate("bkt-new", "rucket-new"),
+					Resources: []StackResource{{APIVersion: APIVersion, ID: 6, Kind: KindBucket, MetaName: "bkt-new"}},
+					UpdatedAt: now.Add(-time.Hour),
+				},
+			)
+
+			fakeBktSVC := mock.NewBucketService()
+			fakeBktSVC.FindBucketByIDFn = func(ctx context.Context, id platform.ID) (*influxdb.Bucket, error) {
+				if id != 6 {
+					return nil, errors.New("not found")
+				}
+				return &influxdb.Bucket{ID: id, OrgID: 3, Name: "rucket-new"}, nil
+			}
+			fakeBktSVC.FindBucketByNameFn = func(ctx context.Context, orgID platform.ID, name string) (*influxdb.Bucket, error) {
+				return nil, errors.New("not found")
+			}
+			var created []string
+			fakeBktSVC.CreateBucketFn = func(ctx context.Context, b *influxdb.Bucket) error {
+				b.ID = 7
+				created = append(created, b.Name)
+				return nil
+			}
+			var deleted []platform.ID
+			fakeBktSVC.DeleteBucketFn = func(ctx context.Context, id platform.ID) error {
+				deleted = append(deleted, id)
+				return nil
+			}
+
+			var updated Stack
+			svc := newTestService(
+				WithBucketSVC(fakeBktSVC),
+				WithTimeGenerator(newTimeGen(now)),
+				WithStore(&fakeStore{
+					readFn: func(ctx context.Context, id platform.ID) (Stack, error) {
+						return stack, nil
+					},
+					updateFn: func(ctx context.Context, stack Stack) error {
+						updated = stack
+						return nil
+					},
+				}),
+			)
+
+			impact, err := svc.RollbackStack(context.Background(), struct{ OrgID, UserID, StackID platform.ID }{
+				OrgID:   3,
+				UserID:  9000,
+				StackID: 3333,
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, platform.ID(3333), impact.StackID)
+			assert.Equal(t, []string{"rucket-old"}, created)
+			assert.Equal(t, []platform.ID{6}, deleted)
+
+			latest := updated.LatestEvent()
+			assert.Equal(t, StackEventRollback, latest.EventType)
+			assert.Contains(t, string(latest.Template), "rucket-old")
+			require.Len(t, latest.Resources, 1)
+			assert.Equal(t, "bkt-old", latest.Resources[0].MetaName)
+			assert.Equal(t, platform.ID(7), latest.Resources[0].ID)
+		})
+
+		t.Run("errors when there is no previous template version", func(t *testing.T) {
+			tests := []struct {
+				name  string
+				stack Stack
+			}{
+				{
+					name: "single version",
+					stack: newStack(StackEvent{
+						EventType: StackEventUpdate,
+						Template:  bucketTemplate("bkt", "rucket"),
+						UpdatedAt: now,
+					}),
+				},
+				{
+					name: "updated without a new version",
+					stack: newStack(
+						StackEvent{
+							EventType: StackEventUpdate,
+							Template:  bucketTemplate("bkt", "rucket"),
+							UpdatedAt: now.Add(-time.Hour),
+						},
+						StackEvent{
+							EventType: StackEventUpdate,
+							Name:      "renamed",
+							Template:  bucketTemplate("bkt", "rucket"),
+							UpdatedAt: now,
+						},
+					),
+				},
+				{
+					name: "uninstalled",
+					stack: newStack(
+						StackEvent{
+							EventType: StackEventUpdate,
+							Template:  bucketTemplate("bkt-old", "rucket-old"),
+							UpdatedAt: now.Add(-2 * time.Hour),
+						},
+						StackEvent{
+							EventType: StackEventUpdate,
+							Template:  bucketTemplate("bkt-new", "rucket-new"),
+							UpdatedAt: now.Add(-time.Hour),
+						},
+						StackEvent{
+							EventType: StackEventUninstalled,
+							UpdatedAt: now,
+						},
+					),
+				},
+			}
+
+			for _, tt := range tests {
+				fn := func(t *testing.T) {
+					svc := newTestService(WithStore(&fakeStore{
+						readFn: func(ctx context.Context, id platform.ID) (Stack, error) {
+							return tt.stack, nil
+						},
+					}))
+
+					_, err := svc.RollbackStack(context.Background(), struct{ OrgID, UserID, StackID platform.ID }{
+						OrgID:   3,
+						UserID:  9000,
+						StackID: 3333,
+					})
+					require.Error(t, err)
+					assert.Equal(t, errors2.EConflict, errors2.ErrorCode(err))
+				}
+				t.Run(tt.name, fn)
+			}
+		})
+	})
 }
 
 func Test_normalizeRemoteSources(t *testing.T) {
@@ -5487,6 +6222,7 @@ func levelPtr(l notification.CheckLevel) *notification.CheckLevel {
 type fakeStore struct {
 	createFn func(ctx context.Context, stack Stack) error
 	deleteFn func(ctx context.Context, id platform.ID) error
+	listFn   func(ctx context.Context, orgID platform.ID, f ListFilter) ([]Stack, error)
 	readFn   func(ctx context.Context, id platform.ID) (Stack, error)
 	updateFn func(ctx context.Context, stack Stack) error
 }
@@ -5501,7 +6237,10 @@ func (s *fakeStore) CreateStack(ctx context.Context, stack Stack) error {
 }
 
 func (s *fakeStore) ListStacks(ctx context.Context, orgID platform.ID, f ListFilter) ([]Stack, error) {
-	panic("not implemented")
+	if s.listFn != nil {
+		return s.listFn(ctx, orgID, f)
+	}
+	return nil, nil
 }
 
 func (s *fakeStore) ReadStackByID(ctx context.Context, id platform.ID) (Stack, error) {
@@ -5525,6 +6264,129 @@ func (s *fakeStore) DeleteStack(ctx context.Context, id platform.ID) error {
 	panic("not implemented")
 }
 
+type fakeNotebookSVC struct {
+	getFn    func(ctx context.Context, id platform.ID) (*influxdb.Notebook, error)
+	createFn func(ctx context.Context, create *influxdb.NotebookReqBody) (*influxdb.Notebook, error)
+	updateFn func(ctx context.Context, id platform.ID, update *influxdb.NotebookReqBody) (*influxdb.Notebook, error)
+	deleteFn func(ctx context.Context, id platform.ID) error
+}
+
+var _ influxdb.NotebookService = (*fakeNotebookSVC)(nil)
+
+func (s *fakeNotebookSVC) GetNotebook(ctx context.Context, id platform.ID) (*influxdb.Notebook, error) {
+	if s.getFn != nil {
+		return s.getFn(ctx, id)
+	}
+	return nil, influxdb.ErrNotebookNotFound
+}
+
+func (s *fakeNotebookSVC) CreateNotebook(ctx context.Context, create *influxdb.NotebookReqBody) (*influxdb.Notebook, error) {
+	if s.createFn != nil {
+		return s.createFn(ctx, create)
+	}
+	panic("not implemented")
+}
+
+func (s *fakeNotebookSVC) UpdateNotebook(ctx context.Context, id platform.ID, update *influxdb.NotebookReqBody) (*influxdb.Notebook, error) {
+	if s.updateFn != nil {
+		return s.updateFn(ctx, id, update)
+	}
+	panic("not implemented")
+}
+
+func (s *fakeNotebookSVC) DeleteNotebook(ctx context.Context, id platform.ID) error {
+	if s.deleteFn != nil {
+		return s.deleteFn(ctx, id)
+	}
+	panic("not implemented")
+}
+
+func (s *fakeNotebookSVC) ListNotebooks(ctx context.Context, filter influxdb.NotebookListFilter) ([]*influxdb.Notebook, error) {
+	return nil, nil
+}
+
+type fakeRemoteSVC struct {
+	getFn    func(ctx context.Context, id platform.ID) (*influxdb.RemoteConnection, error)
+	createFn func(ctx context.Context, req influxdb.CreateRemoteConnectionRequest) (*influxdb.RemoteConnection, error)
+	updateFn func(ctx context.Context, id platform.ID, req influxdb.UpdateRemoteConnectionRequest) (*influxdb.RemoteConnection, error)
+	deleteFn func(ctx context.Context, id platform.ID) error
+}
+
+var _ RemoteConnectionService = (*fakeRemoteSVC)(nil)
+
+func (s *fakeRemoteSVC) ListRemoteConnections(ctx context.Context, filter influxdb.RemoteConnectionListFilter) (*influxdb.RemoteConnections, error) {
+	return &influxdb.RemoteConnections{}, nil
+}
+
+func (s *fakeRemoteSVC) CreateRemoteConnection(ctx context.Context, req influxdb.CreateRemoteConnectionRequest) (*influxdb.RemoteConnection, error) {
+	if s.createFn != nil {
+		return s.createFn(ctx, req)
+	}
+	panic("not implemented")
+}
+
+func (s *fakeRemoteSVC) GetRemoteConnection(ctx context.Context, id platform.ID) (*influxdb.RemoteConnection, error) {
+	if s.getFn != nil {
+		return s.getFn(ctx, id)
+	}
+	return nil, errors.New("remote not found")
+}
+
+func (s *fakeRemoteSVC) UpdateRemoteConnection(ctx context.Context, id platform.ID, req influxdb.UpdateRemoteConnectionRequest) (*influxdb.RemoteConnection, error) {
+	if s.updateFn != nil {
+		return s.updateFn(ctx, id, req)
+	}
+	panic("not implemented")
+}
+
+func (s *fakeRemoteSVC) DeleteRemoteConnection(ctx context.Context, id platform.ID) error {
+	if s.deleteFn != nil {
+		return s.deleteFn(ctx, id)
+	}
+	panic("not implemented")
+}
+
+type fakeReplicationSVC struct {
+	getFn    func(ctx context.Context, id platform.ID) (*influxdb.Replication, error)
+	createFn func(ctx context.Context, req influxdb.CreateReplicationRequest) (*influxdb.Replication, error)
+	updateFn func(ctx context.Context, id platform.ID, req influxdb.UpdateReplicationRequest) (*influxdb.Replication, error)
+	deleteFn func(ctx context.Context, id platform.ID) error
+}
+
+var _ ReplicationService = (*fakeReplicationSVC)(nil)
+
+func (s *fakeReplicationSVC) ListReplications(ctx context.Context, filter influxdb.ReplicationListFilter) (*influxdb.Replications, error) {
+	return &influxdb.Replications{}, nil
+}
+
+func (s *fakeReplicationSVC) CreateReplication(ctx context.Context, req influxdb.CreateReplicationRequest) (*influxdb.Replication, error) {
+	if s.createFn != nil {
+		return s.createFn(ctx, req)
+	}
+	panic("not implemented")
+}
+
+func (s *fakeReplicationSVC) GetReplication(ctx context.Context, id platform.ID) (*influxdb.Replication, error) {
+	if s.getFn != nil {
+		return s.getFn(ctx, id)
+	}
+	return nil, errors.New("replication not found")
+}
+
+func (s *fakeReplicationSVC) UpdateReplication(ctx context.Context, id platform.ID, req influxdb.UpdateReplicationRequest) (*influxdb.Replication, error) {
+	if s.updateFn != nil {
+		return s.updateFn(ctx, id, req)
+	}
+	panic("not implemented")
+}
+
+func (s *fakeReplicationSVC) DeleteReplication(ctx context.Context, id platform.ID) error {
+	if s.deleteFn != nil {
+		return s.deleteFn(ctx, id)
+	}
+	panic("not implemented")
+}
+
 type fakeIDGen func() platform.ID
 
 func newFakeIDGen(id platform.ID) fakeIDGen {
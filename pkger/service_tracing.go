@@ -33,6 +33,12 @@ func (s *traceMW) UninstallStack(ctx context.Context, identifiers struct{ OrgID,
 	return s.next.UninstallStack(ctx, identifiers)
 }
 
+func (s *traceMW) RollbackStack(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) (ImpactSummary, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+	return s.next.RollbackStack(ctx, identifiers)
+}
+
 func (s *traceMW) DeleteStack(ctx context.Context, identifiers struct{ OrgID, UserID, StackID platform.ID }) error {
 	span, ctx := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
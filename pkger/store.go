@@ -27,13 +27,26 @@ type (
 	}
 
 	entStackEvent struct {
-		EventType   StackEventType     `json:"eventType"`
-		Name        string             `json:"name"`
-		Description string             `json:"description"`
-		Sources     []string           `json:"sources,omitempty"`
-		URLs        []string           `json:"urls,omitempty"`
-		Resources   []entStackResource `json:"resources,omitempty"`
-		UpdatedAt   time.Time          `json:"updatedAt"`
+		EventType   StackEventType         `json:"eventType"`
+		Name        string                 `json:"name"`
+		Description string                 `json:"description"`
+		Sources     []string               `json:"sources,omitempty"`
+		URLs        []string               `json:"urls,omitempty"`
+		Resources   []entStackResource     `json:"resources,omitempty"`
+		Template    json.RawMessage        `json:"template,omitempty"`
+		EnvRefs     map[string]interface{} `json:"envRefs,omitempty"`
+		Sync        *entStackSync          `json:"sync,omitempty"`
+		UpdatedAt   time.Time              `json:"updatedAt"`
+	}
+
+	entStackSync struct {
+		URL              string        `json:"url"`
+		Branch           string        `json:"branch,omitempty"`
+		Path             string        `json:"path"`
+		Interval         time.Duration `json:"interval,omitempty"`
+		AutoApply        bool          `json:"autoApply,omitempty"`
+		WebhookSecretKey string        `json:"webhookSecretKey,omitempty"`
+		UserID           string        `json:"userID,omitempty"`
 	}
 
 	entStackResource struct {
@@ -45,8 +58,9 @@ type (
 	}
 
 	entStackAssociation struct {
-		Kind string `json:"kind"`
-		Name string `json:"name"`
+		Kind    string `json:"kind"`
+		Name    string `json:"name"`
+		StackID string `json:"stackID,omitempty"`
 	}
 )
 
@@ -293,10 +307,14 @@ func convertStackToEnt(stack Stack) (kv.Entity, error) {
 		for _, res := range ev.Resources {
 			var associations []entStackAssociation
 			for _, ass := range res.Associations {
-				associations = append(associations, entStackAssociation{
+				ent := entStackAssociation{
 					Kind: ass.Kind.String(),
 					Name: ass.MetaName,
-				})
+				}
+				if ass.StackID != 0 {
+					ent.StackID = ass.StackID.String()
+				}
+				associations = append(associations, ent)
 			}
 			resources = append(resources, entStackResource{
 				APIVersion:   res.APIVersion,
@@ -313,6 +331,9 @@ func convertStackToEnt(stack Stack) (kv.Entity, error) {
 			Sources:     ev.Sources,
 			URLs:        ev.TemplateURLs,
 			Resources:   resources,
+			Template:    ev.Template,
+			EnvRefs:     ev.EnvRefs,
+			Sync:        convertStackSyncToEnt(ev.Sync),
 			UpdatedAt:   ev.UpdatedAt,
 		})
 	}
@@ -363,6 +384,8 @@ func convertEntStackEvent(ent entStackEvent) (StackEvent, error) {
 		Description:  ent.Description,
 		Sources:      ent.Sources,
 		TemplateURLs: ent.URLs,
+		Template:     ent.Template,
+		EnvRefs:      ent.EnvRefs,
 		UpdatedAt:    ent.UpdatedAt,
 	}
 	out, err := convertStackEntResources(ent.Resources)
@@ -370,9 +393,44 @@ func convertEntStackEvent(ent entStackEvent) (StackEvent, error) {
 		return StackEvent{}, err
 	}
 	ev.Resources = out
+
+	if ent.Sync != nil {
+		cfg := StackSync{
+			URL:              ent.Sync.URL,
+			Branch:           ent.Sync.Branch,
+			Path:             ent.Sync.Path,
+			Interval:         ent.Sync.Interval,
+			AutoApply:        ent.Sync.AutoApply,
+			WebhookSecretKey: ent.Sync.WebhookSecretKey,
+		}
+		if ent.Sync.UserID != "" {
+			if err := cfg.UserID.DecodeFromString(ent.Sync.UserID); err != nil {
+				return StackEvent{}, err
+			}
+		}
+		ev.Sync = &cfg
+	}
 	return ev, nil
 }
 
+func convertStackSyncToEnt(cfg *StackSync) *entStackSync {
+	if cfg == nil {
+		return nil
+	}
+	ent := &entStackSync{
+		URL:              cfg.URL,
+		Branch:           cfg.Branch,
+		Path:             cfg.Path,
+		Interval:         cfg.Interval,
+		AutoApply:        cfg.AutoApply,
+		WebhookSecretKey: cfg.WebhookSecretKey,
+	}
+	if cfg.UserID.Valid() {
+		ent.UserID = cfg.UserID.String()
+	}
+	return ent
+}
+
 func convertStackEntResources(entResources []entStackResource) ([]StackResource, error) {
 	var out []StackResource
 	for _, res := range entResources {
@@ -386,10 +444,16 @@ func convertStackEntResources(entResources []entStackResource) ([]StackResource,
 		}
 
 		for _, ass := range res.Associations {
-			stackRes.Associations = append(stackRes.Associations, StackResourceAssociation{
+			stackAss := StackResourceAssociation{
 				Kind:     Kind(ass.Kind),
 				MetaName: ass.Name,
-			})
+			}
+			if ass.StackID != "" {
+				if err := stackAss.StackID.DecodeFromString(ass.StackID); err != nil {
+					return nil, err
+				}
+			}
+			stackRes.Associations = append(stackRes.Associations, stackAss)
 		}
 		out = append(out, stackRes)
 	}
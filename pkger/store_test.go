@@ -41,6 +41,17 @@ func TestStoreKV(t *testing.T) {
 					UpdatedAt:    now.Add(time.Hour),
 					Sources:      urls,
 					TemplateURLs: urls,
+					Template:     []byte(`[{"apiVersion":"influxdata.com/v2alpha1","kind":"Bucket","metadata":{"name":"beyond"}}]`),
+					EnvRefs:      map[string]interface{}{"bkt-name": "beyond"},
+					Sync: &pkger.StackSync{
+						URL:              "https://github.com/influxdata/community-templates",
+						Branch:           "master",
+						Path:             "docker/docker.yml",
+						Interval:         10 * time.Minute,
+						AutoApply:        true,
+						WebhookSecretKey: "webhook-secret",
+						UserID:           3,
+					},
 					Resources: []pkger.StackResource{
 						{
 							APIVersion: pkger.APIVersion,
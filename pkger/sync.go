@@ -0,0 +1,463 @@
+package pkger
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/secret"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultSyncInterval is how often the repository of a synced stack is
+	// polled when the stack does not set an interval.
+	DefaultSyncInterval = 5 * time.Minute
+
+	// MinSyncInterval is the shortest interval a stack can be polled at.
+	MinSyncInterval = time.Minute
+
+	// DefaultSyncCheckInterval is how often the Syncer looks for the stacks
+	// which are due a sync.
+	DefaultSyncCheckInterval = 30 * time.Second
+
+	defaultSyncBranch = "main"
+
+	// maxSyncedTemplateSize is the largest template a stack is synced from.
+	maxSyncedTemplateSize = 10 << 20
+)
+
+// headers of the webhook pings of git hosts.
+const (
+	githubSignatureHeader = "X-Hub-Signature-256"
+	gitlabTokenHeader     = "X-Gitlab-Token"
+)
+
+// StackSync configures a stack to be synced from a template in a git
+// repository.
+type StackSync struct {
+	// URL is the http(s) URL of the repository, such as
+	// https://github.com/influxdata/community-templates.
+	URL string
+	// Branch defaults to main.
+	Branch string
+	// Path is the path of the template in the repository.
+	Path string
+	// Interval is how often the repository is polled, it defaults to
+	// DefaultSyncInterval.
+	Interval time.Duration
+	// AutoApply applies the changes of the template, and reverts the changes
+	// made to the resources of the stack outside of the template. The
+	// changes are only reported when it is false.
+	AutoApply bool
+	// WebhookSecretKey is the key of the organization secret the webhook
+	// pings of the repository are authenticated with. Webhooks are disabled
+	// when it is empty.
+	WebhookSecretKey string
+	// UserID is the user the stack is synced as.
+	UserID platform.ID
+}
+
+func (s StackSync) valid() error {
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return influxErr(errors2.EInvalid, fmt.Sprintf("stack sync url[%q] must be an http(s) url of a git repository", s.URL))
+	}
+	if strings.Trim(s.Path, "/") == "" {
+		return influxErr(errors2.EInvalid, "stack sync must provide the path of the template in the repository")
+	}
+	if strings.HasSuffix(strings.ToLower(s.Path), "jsonnet") {
+		return influxErr(errors2.EUnprocessableEntity, fmt.Sprintf("stack sync path[%q] had an issue: %s", s.Path, ErrInvalidEncoding.Error()))
+	}
+	if s.Interval != 0 && s.Interval < MinSyncInterval {
+		return influxErr(errors2.EInvalid, fmt.Sprintf("stack sync interval must be at least %s", MinSyncInterval))
+	}
+	return nil
+}
+
+func (s StackSync) interval() time.Duration {
+	if s.Interval == 0 {
+		return DefaultSyncInterval
+	}
+	return s.Interval
+}
+
+// TemplateURL returns the URL of the raw content of the template in the
+// repository. GitHub and GitLab repositories are supported, other hosts are
+// expected to serve raw content like Gitea does.
+func (s StackSync) TemplateURL() string {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return s.URL
+	}
+
+	branch := s.Branch
+	if branch == "" {
+		branch = defaultSyncBranch
+	}
+	repo := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	file := strings.TrimPrefix(s.Path, "/")
+
+	switch {
+	case u.Host == githubHost:
+		u.Host = githubRawContentHost
+		u.Path = path.Join("/", repo, branch, file)
+	case strings.HasPrefix(u.Host, "gitlab."):
+		u.Path = path.Join("/", repo, "-/raw", branch, file)
+	default:
+		u.Path = path.Join("/", repo, "raw/branch", branch, file)
+	}
+	return u.String()
+}
+
+// SyncState is the state of a synced stack.
+type SyncState string
+
+// states of synced stacks.
+const (
+	// SyncStateSynced is a stack which matches its template.
+	SyncStateSynced SyncState = "synced"
+	// SyncStateOutOfSync is a stack whose template changed since it was last applied.
+	SyncStateOutOfSync SyncState = "outOfSync"
+	// SyncStateDrifted is a stack whose resources were changed outside of
+	// its template, which did not change since it was last applied.
+	SyncStateDrifted SyncState = "drifted"
+	// SyncStateFailed is a stack whose template could not be fetched, dry
+	// run or applied.
+	SyncStateFailed SyncState = "failed"
+)
+
+// StackSyncStatus is the result of the latest sync of a stack.
+type StackSyncStatus struct {
+	StackID     platform.ID
+	State       SyncState
+	TemplateURL string
+	// Revision is the sha256 of the template the stack was last found in
+	// sync with.
+	Revision string
+	// Diff are the changes of the template to the stack, when it is out of
+	// sync or drifted.
+	Diff      *Diff
+	Err       string
+	CheckedAt time.Time
+	AppliedAt time.Time
+}
+
+// PermissionService provides the permissions of the users stacks are synced as.
+type PermissionService interface {
+	FindPermissionForUser(ctx context.Context, userID platform.ID) (influxdb.PermissionSet, error)
+}
+
+// Syncer syncs the stacks configured with a git repository. It polls the
+// repositories, and accepts the webhook pings of their hosts. The statuses of
+// the syncs are kept in memory, and are lost on restart.
+type Syncer struct {
+	log       *zap.Logger
+	svc       SVC
+	store     Store
+	orgSVC    influxdb.OrganizationService
+	permSVC   PermissionService
+	secretSVC influxdb.SecretService
+	client    *http.Client
+
+	mu       sync.Mutex
+	statuses map[platform.ID]StackSyncStatus
+	pings    chan platform.ID
+
+	TimeGenerator influxdb.TimeGenerator
+}
+
+// NewSyncer constructs a new Syncer. The stacks are read from the store, and
+// synced through svc as the user of their sync. The store, organization,
+// permission and secret services must not be authorized, the background syncs
+// carry no authorizer.
+func NewSyncer(log *zap.Logger, svc SVC, store Store, orgSVC influxdb.OrganizationService, permSVC PermissionService, secretSVC influxdb.SecretService, client *http.Client) *Syncer {
+	return &Syncer{
+		log:           log,
+		svc:           svc,
+		store:         store,
+		orgSVC:        orgSVC,
+		permSVC:       permSVC,
+		secretSVC:     secretSVC,
+		client:        client,
+		statuses:      make(map[platform.ID]StackSyncStatus),
+		pings:         make(chan platform.ID, 100),
+		TimeGenerator: influxdb.RealTimeGenerator{},
+	}
+}
+
+// Run syncs the stacks which are due every interval, and the stacks pinged by
+// their webhook, until ctx is done.
+func (s *Syncer) Run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			s.syncDue(ctx)
+		case id := <-s.pings:
+			stack, err := s.store.ReadStackByID(ctx, id)
+			if err != nil {
+				s.log.Info("Failed to read pinged stack", zap.String("stackID", id.String()), zap.Error(err))
+				continue
+			}
+			s.syncAsUser(ctx, stack)
+		}
+	}
+}
+
+// Status returns the status of the latest sync of a stack.
+func (s *Syncer) Status(stackID platform.ID) (StackSyncStatus, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status, ok := s.statuses[stackID]
+	return status, ok
+}
+
+// SyncStack fetches the template of the stack from its repository, and dry
+// runs it to find the changes to the stack. The changes are applied when the
+// stack is synced with auto apply.
+func (s *Syncer) SyncStack(ctx context.Context, stack Stack, userID platform.ID) (StackSyncStatus, error) {
+	ev := stack.LatestEvent()
+	if ev.Sync == nil {
+		return StackSyncStatus{}, &errors2.Error{
+			Code: errors2.ENotFound,
+			Msg:  "stack is not synced from a git repository",
+		}
+	}
+
+	status := StackSyncStatus{
+		StackID:     stack.ID,
+		TemplateURL: ev.Sync.TemplateURL(),
+		CheckedAt:   s.TimeGenerator.Now(),
+	}
+	if prev, ok := s.Status(stack.ID); ok {
+		status.Revision, status.AppliedAt = prev.Revision, prev.AppliedAt
+	}
+
+	err := s.syncStack(ctx, stack, userID, &status)
+	if err != nil {
+		status.State = SyncStateFailed
+		status.Err = err.Error()
+	}
+
+	s.mu.Lock()
+	s.statuses[stack.ID] = status
+	s.mu.Unlock()
+	return status, err
+}
+
+func (s *Syncer) syncStack(ctx context.Context, stack Stack, userID platform.ID, status *StackSyncStatus) error {
+	ev := stack.LatestEvent()
+
+	raw, err := s.fetch(ctx, status.TemplateURL)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(raw)
+	revision := hex.EncodeToString(sum[:])
+
+	template, err := Parse(EncodingSource, FromReader(bytes.NewReader(raw), status.TemplateURL))
+	if err != nil {
+		return err
+	}
+	encoded, err := template.Encode(EncodingJSON)
+	if err != nil {
+		return internalErr(err)
+	}
+	// the stack records the template it was last applied with, the stack
+	// drifted when its template is the same and there are changes to apply.
+	templateChanged := !bytes.Equal(encoded, ev.Template)
+
+	opts := []ApplyOptFn{
+		ApplyWithTemplate(template),
+		ApplyWithStackID(stack.ID),
+		ApplyWithEnvRefs(ev.EnvRefs),
+	}
+	impact, err := s.svc.DryRun(ctx, stack.OrgID, userID, opts...)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !impact.Diff.hasChanges():
+		status.State = SyncStateSynced
+		status.Revision = revision
+		return nil
+	case templateChanged:
+		status.State = SyncStateOutOfSync
+	default:
+		status.State = SyncStateDrifted
+	}
+	status.Diff = &impact.Diff
+
+	if !ev.Sync.AutoApply {
+		return nil
+	}
+	if status.State == SyncStateDrifted {
+		s.log.Info("Reverting changes made outside of the template of synced stack", zap.String("stackID", stack.ID.String()))
+	}
+
+	if _, err := s.svc.Apply(ctx, stack.OrgID, userID, opts...); err != nil {
+		return err
+	}
+	status.State = SyncStateSynced
+	status.Revision = revision
+	status.Diff = nil
+	status.AppliedAt = s.TimeGenerator.Now()
+	return nil
+}
+
+func (s *Syncer) fetch(ctx context.Context, addr string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, addr, nil)
+	if err != nil {
+		return nil, influxErr(errors2.EInvalid, err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, influxErr(errors2.EUnavailable, fmt.Sprintf("failed to fetch template from url[%q]", addr), err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxSyncedTemplateSize))
+	if err != nil {
+		return nil, influxErr(errors2.EUnavailable, fmt.Sprintf("failed to read template from url[%q]", addr), err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, influxErr(errors2.EUnavailable, fmt.Sprintf("failed to fetch template from url[%q]: status_code=%d", addr, resp.StatusCode))
+	}
+	return b, nil
+}
+
+// syncDue syncs the stacks which were not synced for their interval.
+func (s *Syncer) syncDue(ctx context.Context) {
+	orgs, _, err := s.orgSVC.FindOrganizations(ctx, influxdb.OrganizationFilter{})
+	if err != nil {
+		s.log.Error("Failed to list organizations of synced stacks", zap.Error(err))
+		return
+	}
+
+	now := s.TimeGenerator.Now()
+	for _, org := range orgs {
+		stacks, err := s.store.ListStacks(ctx, org.ID, ListFilter{})
+		if err != nil {
+			s.log.Error("Failed to list stacks", zap.String("orgID", org.ID.String()), zap.Error(err))
+			continue
+		}
+		for _, stack := range stacks {
+			ev := stack.LatestEvent()
+			if ev.Sync == nil {
+				continue
+			}
+			if status, ok := s.Status(stack.ID); ok && now.Sub(status.CheckedAt) < ev.Sync.interval() {
+				continue
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			s.syncAsUser(ctx, stack)
+		}
+	}
+}
+
+// syncAsUser syncs a stack with the permissions of the user of its sync.
+// Uninstalled stacks are not synced.
+func (s *Syncer) syncAsUser(ctx context.Context, stack Stack) {
+	ev := stack.LatestEvent()
+	cfg := ev.Sync
+	if cfg == nil || ev.EventType == StackEventUninstalled {
+		return
+	}
+
+	log := s.log.With(zap.String("stackID", stack.ID.String()))
+	perms, err := s.permSVC.FindPermissionForUser(ctx, cfg.UserID)
+	if err != nil {
+		log.Info("Failed to find the permissions of the user of synced stack", zap.Error(err))
+		return
+	}
+	ctx = icontext.SetAuthorizer(ctx, &influxdb.Authorization{
+		Status:      influxdb.Active,
+		ID:          platform.ID(1),
+		UserID:      cfg.UserID,
+		OrgID:       stack.OrgID,
+		Permissions: perms,
+	})
+
+	if _, err := s.SyncStack(ctx, stack, cfg.UserID); err != nil {
+		log.Info("Failed to sync stack", zap.Error(err))
+	}
+}
+
+// Ping authenticates a webhook ping of the repository of a stack, and queues
+// the stack to be synced.
+func (s *Syncer) Ping(ctx context.Context, stackID platform.ID, header http.Header, body []byte) error {
+	unauthorized := &errors2.Error{
+		Code: errors2.EUnauthorized,
+		Msg:  "unauthorized access",
+	}
+
+	stack, err := s.store.ReadStackByID(ctx, stackID)
+	if errors2.ErrorCode(err) == errors2.ENotFound {
+		return unauthorized
+	}
+	if err != nil {
+		return err
+	}
+	cfg := stack.LatestEvent().Sync
+	if cfg == nil || cfg.WebhookSecretKey == "" {
+		return unauthorized
+	}
+
+	// the previous webhook secret is valid during the grace period of its rotation.
+	secrets, err := secret.ValidSecrets(ctx, s.secretSVC, stack.OrgID, cfg.WebhookSecretKey)
+	if err != nil {
+		return err
+	}
+	valid := false
+	for _, v := range secrets {
+		valid = valid || (v != "" && validWebhookPing(v, header, body))
+	}
+	if !valid {
+		return unauthorized
+	}
+
+	select {
+	case s.pings <- stackID:
+		return nil
+	default:
+		return &errors2.Error{
+			Code: errors2.ETooManyRequests,
+			Msg:  "too many stacks are waiting to be synced",
+		}
+	}
+}
+
+// validWebhookPing verifies the signature of a GitHub ping, or the token of a
+// GitLab ping.
+func validWebhookPing(secret string, header http.Header, body []byte) bool {
+	if sig := header.Get(githubSignatureHeader); sig != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(sig), []byte(expected))
+	}
+	token := header.Get(gitlabTokenHeader)
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+}
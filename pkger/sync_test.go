@@ -0,0 +1,217 @@
+package pkger
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestStackSync(t *testing.T) {
+	t.Run("TemplateURL", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			sync     StackSync
+			expected string
+		}{
+			{
+				name: "github",
+				sync: StackSync{
+					URL:    "https://github.com/influxdata/community-templates.git",
+					Branch: "master",
+					Path:   "/docker/docker.yml",
+				},
+				expected: "https://raw.githubusercontent.com/influxdata/community-templates/master/docker/docker.yml",
+			},
+			{
+				name: "gitlab with default branch",
+				sync: StackSync{
+					URL:  "https://gitlab.com/group/templates",
+					Path: "docker.yml",
+				},
+				expected: "https://gitlab.com/group/templates/-/raw/main/docker.yml",
+			},
+			{
+				name: "other hosts",
+				sync: StackSync{
+					URL:    "http://git.example.com/group/templates/",
+					Branch: "prod",
+					Path:   "templates/docker.json",
+				},
+				expected: "http://git.example.com/group/templates/raw/branch/prod/templates/docker.json",
+			},
+		}
+
+		for _, tt := range tests {
+			fn := func(t *testing.T) {
+				assert.Equal(t, tt.expected, tt.sync.TemplateURL())
+			}
+			t.Run(tt.name, fn)
+		}
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		tests := []struct {
+			name string
+			sync StackSync
+		}{
+			{
+				name: "url without http scheme",
+				sync: StackSync{URL: "git@github.com:influxdata/community-templates.git", Path: "docker/docker.yml"},
+			},
+			{
+				name: "missing path",
+				sync: StackSync{URL: "https://github.com/influxdata/community-templates", Path: "/"},
+			},
+			{
+				name: "jsonnet template",
+				sync: StackSync{URL: "https://github.com/influxdata/community-templates", Path: "docker/docker.jsonnet"},
+			},
+			{
+				name: "interval too short",
+				sync: StackSync{URL: "https://github.com/influxdata/community-templates", Path: "docker/docker.yml", Interval: time.Second},
+			},
+		}
+
+		for _, tt := range tests {
+			fn := func(t *testing.T) {
+				require.Error(t, tt.sync.valid())
+			}
+			t.Run(tt.name, fn)
+		}
+	})
+}
+
+func TestSyncer(t *testing.T) {
+	t.Run("Ping", func(t *testing.T) {
+		const secret = "webhook-secret"
+		body := []byte(`{"ref":"refs/heads/main"}`)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+		newSyncer := func(webhookSecretKey string) *Syncer {
+			store := &fakeStore{
+				readFn: func(ctx context.Context, id platform.ID) (Stack, error) {
+					if id != 1 {
+						return Stack{}, &errors.Error{Code: errors.ENotFound}
+					}
+					return Stack{
+						ID:    id,
+						OrgID: 2,
+						Events: []StackEvent{{
+							Sync: &StackSync{
+								URL:              "https://github.com/influxdata/community-templates",
+								Path:             "docker/docker.yml",
+								WebhookSecretKey: webhookSecretKey,
+							},
+						}},
+					}, nil
+				},
+			}
+			secretSVC := mock.NewSecretService()
+			secretSVC.LoadSecretFn = func(ctx context.Context, orgID platform.ID, k string) (string, error) {
+				if orgID != 2 || k != "hook" {
+					return "", &errors.Error{Code: errors.ENotFound}
+				}
+				return secret, nil
+			}
+			return NewSyncer(zaptest.NewLogger(t), nil, store, nil, nil, secretSVC, http.DefaultClient)
+		}
+
+		t.Run("queues the stack for a github signature", func(t *testing.T) {
+			syncer := newSyncer("hook")
+
+			header := http.Header{}
+			header.Set(githubSignatureHeader, signature)
+			require.NoError(t, syncer.Ping(context.Background(), 1, header, body))
+
+			assert.Equal(t, platform.ID(1), <-syncer.pings)
+		})
+
+		t.Run("queues the stack for a gitlab token", func(t *testing.T) {
+			syncer := newSyncer("hook")
+
+			header := http.Header{}
+			header.Set(gitlabTokenHeader, secret)
+			require.NoError(t, syncer.Ping(context.Background(), 1, header, body))
+
+			assert.Equal(t, platform.ID(1), <-syncer.pings)
+		})
+
+		t.Run("rejects unauthenticated pings", func(t *testing.T) {
+			tests := []struct {
+				name             string
+				stackID          platform.ID
+				webhookSecretKey string
+				header           string
+				value            string
+			}{
+				{
+					name:             "invalid signature",
+					stackID:          1,
+					webhookSecretKey: "hook",
+					header:           githubSignatureHeader,
+					value:            "sha256=00",
+				},
+				{
+					name:             "invalid token",
+					stackID:          1,
+					webhookSecretKey: "hook",
+					header:           gitlabTokenHeader,
+					value:            "rando",
+				},
+				{
+					name:    "webhook disabled",
+					stackID: 1,
+					header:  githubSignatureHeader,
+					value:   signature,
+				},
+				{
+					name:             "stack does not exist",
+					stackID:          3,
+					webhookSecretKey: "hook",
+					header:           githubSignatureHeader,
+					value:            signature,
+				},
+			}
+
+			for _, tt := range tests {
+				fn := func(t *testing.T) {
+					syncer := newSyncer(tt.webhookSecretKey)
+
+					header := http.Header{}
+					header.Set(tt.header, tt.value)
+					err := syncer.Ping(context.Background(), tt.stackID, header, body)
+					require.Error(t, err)
+					assert.Equal(t, errors.EUnauthorized, errors.ErrorCode(err))
+					assert.Empty(t, syncer.pings)
+				}
+				t.Run(tt.name, fn)
+			}
+		})
+	})
+
+	t.Run("SyncStack", func(t *testing.T) {
+		t.Run("stack without a sync errors", func(t *testing.T) {
+			syncer := NewSyncer(zaptest.NewLogger(t), nil, &fakeStore{}, nil, nil, nil, http.DefaultClient)
+
+			_, err := syncer.SyncStack(context.Background(), Stack{ID: 1, Events: []StackEvent{{}}}, 3)
+			require.Error(t, err)
+			assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+
+			_, ok := syncer.Status(1)
+			assert.False(t, ok)
+		})
+	})
+}
@@ -0,0 +1,149 @@
+package pprof
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/pprof"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+// BundleSources provides the server state included in a debug bundle.
+type BundleSources struct {
+	// ProfilingEnabled controls whether profiles are included.
+	ProfilingEnabled bool
+	// Gatherer provides the server's metrics, including storage engine statistics.
+	Gatherer prometheus.Gatherer
+	// Logs provides the server's recent log entries.
+	Logs io.WriterTo
+	// Info is included as-is, e.g. to describe the build and configuration.
+	Info map[string]interface{}
+}
+
+// BundleOptions configures the profiles collected for a debug bundle.
+type BundleOptions struct {
+	CPUDuration   time.Duration
+	TraceDuration time.Duration
+}
+
+// WriteBundle writes a gzipped tarball to w, containing:
+//   - info.json: build and runtime information
+//   - profiles/: the profiles described by collectAllProfiles, if profiling is enabled
+//   - goroutines.txt: the stack of every goroutine
+//   - expvar.json: all published expvars
+//   - metrics.txt: all metrics in the Prometheus text format
+//   - logs.txt: recent log entries
+//
+// The bundle is built in memory before anything is written to w, so that an
+// error can still be reported to the requester.
+func WriteBundle(ctx context.Context, w io.Writer, src BundleSources, opts BundleOptions) error {
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+
+	writeFile := func(name string, fn func(w io.Writer) error) error {
+		var buf bytes.Buffer
+		if err := fn(&buf); err != nil {
+			return fmt.Errorf("failed to collect %s: %w", name, err)
+		}
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    name,
+			Mode:    0600,
+			Size:    int64(buf.Len()),
+			ModTime: now,
+		}); err != nil {
+			return err
+		}
+		_, err := tw.Write(buf.Bytes())
+		return err
+	}
+
+	if err := writeFile("info.json", func(w io.Writer) error {
+		info := map[string]interface{}{
+			"collected_at":  now.UTC().Format(time.RFC3339),
+			"go_version":    runtime.Version(),
+			"go_os":         runtime.GOOS,
+			"go_arch":       runtime.GOARCH,
+			"num_cpu":       runtime.NumCPU(),
+			"gomaxprocs":    runtime.GOMAXPROCS(0),
+			"num_goroutine": runtime.NumGoroutine(),
+		}
+		for k, v := range src.Info {
+			info[k] = v
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	}); err != nil {
+		return err
+	}
+
+	if src.ProfilingEnabled {
+		if err := WriteProfiles(ctx, tw, "profiles", opts.TraceDuration, opts.CPUDuration); err != nil {
+			return fmt.Errorf("failed to collect profiles: %w", err)
+		}
+	}
+
+	if err := writeFile("goroutines.txt", func(w io.Writer) error {
+		return pprof.Lookup("goroutine").WriteTo(w, 2)
+	}); err != nil {
+		return err
+	}
+
+	if err := writeFile("expvar.json", func(w io.Writer) error {
+		vars := make(map[string]json.RawMessage)
+		expvar.Do(func(kv expvar.KeyValue) {
+			vars[kv.Key] = json.RawMessage(kv.Value.String())
+		})
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(vars)
+	}); err != nil {
+		return err
+	}
+
+	if src.Gatherer != nil {
+		if err := writeFile("metrics.txt", func(w io.Writer) error {
+			mfs, err := src.Gatherer.Gather()
+			if err != nil {
+				return err
+			}
+			for _, mf := range mfs {
+				if _, err := expfmt.MetricFamilyToText(w, mf); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	if src.Logs != nil {
+		if err := writeFile("logs.txt", func(w io.Writer) error {
+			_, err := src.Logs.WriteTo(w)
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	_, err := io.Copy(w, &archive)
+	return err
+}
@@ -0,0 +1,125 @@
+package pprof
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type stringWriterTo string
+
+func (s stringWriterTo) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, string(s))
+	return int64(n), err
+}
+
+func readBundle(t *testing.T, r io.Reader) map[string][]byte {
+	t.Helper()
+
+	gz, err := gzip.NewReader(r)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	files := make(map[string][]byte)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		b, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		files[hdr.Name] = b
+	}
+	return files
+}
+
+func testSources() BundleSources {
+	reg := prometheus.NewRegistry()
+	c := prometheus.NewCounter(prometheus.CounterOpts{Name: "bundle_test_total", Help: "test counter"})
+	reg.MustRegister(c)
+	c.Inc()
+
+	return BundleSources{
+		Gatherer: reg,
+		Logs:     stringWriterTo("lvl=info msg=hello\n"),
+		Info:     map[string]interface{}{"version": "test"},
+	}
+}
+
+func TestWriteBundle(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteBundle(context.Background(), &buf, testSources(), BundleOptions{}))
+
+	files := readBundle(t, &buf)
+	for _, name := range []string{"info.json", "goroutines.txt", "expvar.json", "metrics.txt", "logs.txt"} {
+		require.Contains(t, files, name)
+	}
+	for name := range files {
+		require.False(t, strings.HasPrefix(name, "profiles/"), "profiles included while profiling is disabled: %s", name)
+	}
+
+	var info map[string]interface{}
+	require.NoError(t, json.Unmarshal(files["info.json"], &info))
+	require.Equal(t, "test", info["version"])
+	require.Contains(t, string(files["metrics.txt"]), "bundle_test_total 1")
+	require.Equal(t, "lvl=info msg=hello\n", string(files["logs.txt"]))
+}
+
+func TestBundleHandler(t *testing.T) {
+	h := NewBundleHandler(zaptest.NewLogger(t), testSources())
+	router := chi.NewRouter()
+	router.Mount(h.Prefix(), h)
+
+	operator := &influxdb.Authorization{
+		Status:      influxdb.Active,
+		Permissions: influxdb.OperPermissions(),
+	}
+	reader := &influxdb.Authorization{
+		Status: influxdb.Active,
+		Permissions: []influxdb.Permission{{
+			Action:   influxdb.ReadAction,
+			Resource: influxdb.Resource{Type: influxdb.BucketsResourceType},
+		}},
+	}
+
+	tests := []struct {
+		name     string
+		auth     influxdb.Authorizer
+		query    string
+		wantCode int
+	}{
+		{name: "operator", auth: operator, query: "?cpu=0s", wantCode: http.StatusOK},
+		{name: "not an operator", auth: reader, query: "?cpu=0s", wantCode: http.StatusUnauthorized},
+		{name: "invalid duration", auth: operator, query: "?cpu=soon", wantCode: http.StatusBadRequest},
+		{name: "trace too long", auth: operator, query: "?cpu=0s&trace=1h", wantCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, prefixBundle+tt.query, nil)
+			r = r.WithContext(icontext.SetAuthorizer(r.Context(), tt.auth))
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, r)
+
+			require.Equal(t, tt.wantCode, w.Code, w.Body.String())
+			if tt.wantCode == http.StatusOK {
+				require.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+				require.Contains(t, readBundle(t, w.Body), "logs.txt")
+			}
+		})
+	}
+}
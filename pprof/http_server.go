@@ -1,6 +1,7 @@
 package pprof
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"net/http"
@@ -9,8 +10,12 @@ import (
 	"time"
 
 	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	ihttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
 )
 
 type Handler struct {
@@ -169,3 +174,109 @@ func archiveProfilesHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	_, _ = io.Copy(w, tarstream)
 }
+
+const prefixBundle = "/api/v2/debug/bundle"
+
+// Limits on the profiles collected for a debug bundle, so a request can't tie up
+// the server indefinitely.
+const (
+	defaultBundleCPUDuration = 10 * time.Second
+	maxBundleCPUDuration     = 5 * time.Minute
+	maxBundleTraceDuration   = 45 * time.Second
+)
+
+// BundleHandler serves debug bundles to operators.
+type BundleHandler struct {
+	chi.Router
+
+	log     *zap.Logger
+	api     *ihttp.API
+	sources BundleSources
+}
+
+// NewBundleHandler returns a handler serving a debug bundle built from sources.
+func NewBundleHandler(log *zap.Logger, sources BundleSources) *BundleHandler {
+	h := &BundleHandler{
+		log:     log,
+		api:     ihttp.NewAPI(ihttp.WithLog(log)),
+		sources: sources,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+		h.mwAuthorize,
+	)
+	r.Get("/", h.handleGetBundle)
+	h.Router = r
+	return h
+}
+
+func (h *BundleHandler) Prefix() string {
+	return prefixBundle
+}
+
+// handleGetBundle collects a debug bundle. Requests may set the durations of
+// the CPU profile and trace to collect, i.e. ?cpu=30s&trace=5s. A zero
+// duration skips that profile.
+func (h *BundleHandler) handleGetBundle(w http.ResponseWriter, r *http.Request) {
+	opts := BundleOptions{CPUDuration: defaultBundleCPUDuration}
+	for _, p := range []struct {
+		name string
+		dest *time.Duration
+		max  time.Duration
+	}{
+		{name: "cpu", dest: &opts.CPUDuration, max: maxBundleCPUDuration},
+		{name: "trace", dest: &opts.TraceDuration, max: maxBundleTraceDuration},
+	} {
+		val := r.URL.Query().Get(p.name)
+		if val == "" {
+			continue
+		}
+		d, err := time.ParseDuration(val)
+		if err != nil || d < 0 {
+			h.api.Err(w, r, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("could not parse supplied duration for %s %q", p.name, val),
+			})
+			return
+		}
+		if d > p.max {
+			h.api.Err(w, r, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("cannot collect %s for longer than %s", p.name, p.max),
+			})
+			return
+		}
+		*p.dest = d
+	}
+
+	var buf bytes.Buffer
+	if err := WriteBundle(r.Context(), &buf, h.sources, opts); err != nil {
+		h.log.Error("Failed to collect debug bundle", zap.Error(err))
+		h.api.Err(w, r, err)
+		return
+	}
+
+	filename := fmt.Sprintf("influxd-debug-bundle-%s.tar.gz", time.Now().UTC().Format("20060102T150405Z"))
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(w, &buf)
+}
+
+func (h *BundleHandler) mwAuthorize(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if err := authorizer.IsAllowedAll(r.Context(), influxdb.OperPermissions()); err != nil {
+			h.api.Err(w, r, &errors.Error{
+				Code: errors.EUnauthorized,
+				Msg:  fmt.Sprintf("access to %s requires operator permissions", h.Prefix()),
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
@@ -39,6 +39,23 @@ func SetGlobalProfiling(enabled bool) {
 // returned to the requester as an archive file. Where profiles support debug
 // parameters, the profile is collected with debug=1.
 func collectAllProfiles(ctx context.Context, traceDuration time.Duration, cpuDuration time.Duration) (io.Reader, error) {
+	tarball := &bytes.Buffer{}
+	tw := tar.NewWriter(tarball)
+	if err := WriteProfiles(ctx, tw, "profiles", traceDuration, cpuDuration); err != nil {
+		return nil, err
+	}
+
+	// Close the tar writer.
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+
+	return tarball, nil
+}
+
+// WriteProfiles writes the profiles described by collectAllProfiles to tw,
+// in the directory dir.
+func WriteProfiles(ctx context.Context, tw *tar.Writer, dir string, traceDuration time.Duration, cpuDuration time.Duration) error {
 	// prof describes a profile name and a debug value, or in the case of a CPU
 	// profile, the number of seconds to collect the profile for.
 	type prof struct {
@@ -62,23 +79,21 @@ func collectAllProfiles(ctx context.Context, traceDuration time.Duration, cpuDur
 		profiles = append([]prof{{"cpu", cpuDuration}}, profiles...)
 	}
 
-	tarball := &bytes.Buffer{}
 	buf := &bytes.Buffer{} // Temporary buffer for each profile/query result.
 
-	tw := tar.NewWriter(tarball)
 	// Collect and write out profiles.
 	for _, profile := range profiles {
 		switch profile.Name {
 		case "cpu":
 			if err := pprof.StartCPUProfile(buf); err != nil {
-				return nil, err
+				return err
 			}
 			sleep(ctx, profile.Duration)
 			pprof.StopCPUProfile()
 
 		case "trace":
 			if err := trace.Start(buf); err != nil {
-				return nil, err
+				return err
 			}
 			sleep(ctx, profile.Duration)
 			trace.Stop()
@@ -86,38 +101,33 @@ func collectAllProfiles(ctx context.Context, traceDuration time.Duration, cpuDur
 		default:
 			prof := pprof.Lookup(profile.Name)
 			if prof == nil {
-				return nil, fmt.Errorf("unable to find profile %q", profile.Name)
+				return fmt.Errorf("unable to find profile %q", profile.Name)
 			}
 
 			if err := prof.WriteTo(buf, 0); err != nil {
-				return nil, err
+				return err
 			}
 		}
 
 		// Write the profile file's header.
 		if err := tw.WriteHeader(&tar.Header{
-			Name: path.Join("profiles", profile.Name+".pb.gz"),
+			Name: path.Join(dir, profile.Name+".pb.gz"),
 			Mode: 0600,
 			Size: int64(buf.Len()),
 		}); err != nil {
-			return nil, err
+			return err
 		}
 
 		// Write the profile file's data.
 		if _, err := tw.Write(buf.Bytes()); err != nil {
-			return nil, err
+			return err
 		}
 
 		// Reset the buffer for the next profile.
 		buf.Reset()
 	}
 
-	// Close the tar writer.
-	if err := tw.Close(); err != nil {
-		return nil, err
-	}
-
-	return tarball, nil
+	return nil
 }
 
 // Adapted from net/http/pprof/pprof.go
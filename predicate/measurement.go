@@ -0,0 +1,65 @@
+package predicate
+
+import (
+	"fmt"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxql"
+)
+
+// MeasurementExpr returns the part of a delete predicate which restricts the
+// measurement, which is nil if the predicate does not. Deleting by field is
+// not supported, so it is an error for the predicate to restrict the field.
+func MeasurementExpr(pred string) (influxql.Expr, error) {
+	if pred == "" {
+		return nil, nil
+	}
+
+	expr, err := influxql.ParseExpr(pred)
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "invalid request; error parsing predicate",
+			Err:  err,
+		}
+	}
+	measurementExpr, _, err := influxql.PartitionExpr(influxql.CloneExpr(expr), func(e influxql.Expr) (bool, error) {
+		switch e := e.(type) {
+		case *influxql.BinaryExpr:
+			switch e.Op {
+			case influxql.EQ, influxql.NEQ, influxql.EQREGEX, influxql.NEQREGEX:
+				tag, ok := e.LHS.(*influxql.VarRef)
+				if ok && tag.Val == "_measurement" {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	})
+
+	var walkError error
+	influxql.WalkFunc(expr, func(e influxql.Node) {
+		if v, ok := e.(*influxql.BinaryExpr); ok {
+			if vv, ok := v.LHS.(*influxql.VarRef); ok && v.Op == influxql.EQ {
+				if vv.Val == "_field" {
+					walkError = &errors.Error{
+						Code: errors.ENotImplemented,
+						Msg:  "",
+						Err:  fmt.Errorf("delete by field is not supported"),
+					}
+				}
+			}
+		}
+	})
+	if walkError != nil {
+		return nil, walkError
+	}
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "invalid request; error partitioning predicate",
+			Err:  err,
+		}
+	}
+	return measurementExpr, nil
+}
@@ -34,10 +34,12 @@ import (
 	"github.com/influxdata/flux/memory"
 	"github.com/influxdata/flux/runtime"
 	errors3 "github.com/influxdata/influxdb/v2/kit/errors"
+	"github.com/influxdata/influxdb/v2/kit/platform"
 	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kit/prom"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
 	influxlogger "github.com/influxdata/influxdb/v2/logger"
+	"github.com/influxdata/influxdb/v2/membudget"
 	"github.com/influxdata/influxdb/v2/query"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
@@ -62,6 +64,13 @@ type Controller struct {
 	abort      chan struct{}
 	memory     *memoryManager
 
+	// limitsMu protects the limits of the config, the queryQueue and the
+	// workers, which UpdateLimits replaces while queries run.
+	limitsMu sync.RWMutex
+	// workers holds a channel per goroutine processing the queue, closed to
+	// stop it.
+	workers []chan struct{}
+
 	metrics   *controllerMetrics
 	labelKeys []string
 
@@ -119,10 +128,24 @@ type Config struct {
 
 	ExecutorDependencies []flux.Dependency
 
+	// OrgMemory, if set, charges the memory of queries to the budgets of their
+	// organizations.
+	OrgMemory *membudget.Accountant
+
+	// Packages, if set, resolves the imports of the Flux packages of the
+	// organizations of queries.
+	Packages PackageResolver
+
 	// FluxLogEnabled logs any in-progress queries that get cancelled due to the server being shut down.
 	FluxLogEnabled bool
 }
 
+// PackageResolver rewrites the compilers of the queries of organizations to
+// define the Flux packages they import.
+type PackageResolver interface {
+	ResolvePackages(ctx context.Context, orgID platform.ID, compiler flux.Compiler) (flux.Compiler, error)
+}
+
 // complete will fill in the defaults, validate the configuration, and
 // return the new Config.
 func (c *Config) complete(log *zap.Logger) (Config, error) {
@@ -145,6 +168,13 @@ func (c *Config) complete(log *zap.Logger) (Config, error) {
 	return config, nil
 }
 
+// Validate fills in defaults and reports whether the resulting configuration
+// can be used to construct a Controller.
+func (c *Config) Validate(log *zap.Logger) error {
+	_, err := c.complete(log)
+	return err
+}
+
 func (c *Config) validate() error {
 	if c.ConcurrencyQuota < 0 {
 		return errors.New("ConcurrencyQuota must not be negative")
@@ -200,6 +230,7 @@ func New(config Config, logger *zap.Logger) (*Controller, error) {
 	mm := &memoryManager{
 		initialBytesQuotaPerQuery: c.InitialMemoryBytesQuotaPerQuery,
 		memoryBytesQuotaPerQuery:  c.MemoryBytesQuotaPerQuery,
+		orgMemory:                 c.OrgMemory,
 	}
 	if c.MaxMemoryBytes > 0 {
 		mm.unusedMemoryBytes = c.MaxMemoryBytes - (int64(c.ConcurrencyQuota) * c.InitialMemoryBytesQuotaPerQuery)
@@ -223,17 +254,96 @@ func New(config Config, logger *zap.Logger) (*Controller, error) {
 		dependencies:   c.ExecutorDependencies,
 		fluxLogEnabled: config.FluxLogEnabled,
 	}
-	if c.ConcurrencyQuota != 0 {
-		quota := int(c.ConcurrencyQuota)
-		ctrl.wg.Add(quota)
-		for i := 0; i < quota; i++ {
-			go func() {
-				defer ctrl.wg.Done()
-				ctrl.processQueryQueue()
-			}()
+	ctrl.setWorkers(int(c.ConcurrencyQuota))
+	return ctrl, nil
+}
+
+// UpdateLimits applies the concurrency, queue size and memory limits of config
+// to the running Controller; its other fields are ignored. Queries already
+// running keep the memory they were given. Whether the concurrency and the
+// memory of queries are limited at all cannot change.
+func (c *Controller) UpdateLimits(config Config) error {
+	next, err := config.complete(c.log)
+	if err != nil {
+		return errors3.Wrap(err, "invalid controller config")
+	}
+
+	c.queriesMu.RLock()
+	defer c.queriesMu.RUnlock()
+	if c.shutdown {
+		return errors.New("controller is shutting down")
+	}
+
+	c.limitsMu.Lock()
+	defer c.limitsMu.Unlock()
+	cur := c.config
+	if (cur.ConcurrencyQuota == 0) != (next.ConcurrencyQuota == 0) {
+		return errors.New("cannot change whether ConcurrencyQuota is unlimited while running")
+	}
+	if (cur.MaxMemoryBytes == 0) != (next.MaxMemoryBytes == 0) {
+		return errors.New("cannot change whether MaxMemoryBytes is unlimited while running")
+	}
+
+	if !c.memory.unlimited {
+		// The initial memory of the queries which may run at once is set aside
+		// from the memory the queries request beyond it.
+		reserved := func(cfg Config) int64 {
+			return cfg.MaxMemoryBytes - int64(cfg.ConcurrencyQuota)*cfg.InitialMemoryBytesQuotaPerQuery
 		}
+		c.memory.addUnusedMemoryBytes(reserved(next) - reserved(cur))
+	}
+	atomic.StoreInt64(&c.memory.initialBytesQuotaPerQuery, next.InitialMemoryBytesQuotaPerQuery)
+	atomic.StoreInt64(&c.memory.memoryBytesQuotaPerQuery, next.MemoryBytesQuotaPerQuery)
+
+	if c.queryQueue != nil && int(next.QueueSize) > cap(c.queryQueue) {
+		// The queued queries move to a larger queue. A smaller queue size is
+		// enforced as queries are enqueued, and the queue keeps its capacity.
+		prev := c.queryQueue
+		c.queryQueue = make(chan *Query, next.QueueSize)
+		for moved := false; !moved; {
+			select {
+			case q := <-prev:
+				c.queryQueue <- q
+			default:
+				moved = true
+			}
+		}
+		close(prev)
+	}
+	c.setWorkers(int(next.ConcurrencyQuota))
+
+	c.config.ConcurrencyQuota = next.ConcurrencyQuota
+	c.config.InitialMemoryBytesQuotaPerQuery = next.InitialMemoryBytesQuotaPerQuery
+	c.config.MemoryBytesQuotaPerQuery = next.MemoryBytesQuotaPerQuery
+	c.config.MaxMemoryBytes = next.MaxMemoryBytes
+	c.config.QueueSize = next.QueueSize
+	c.log.Info("Updated query controller limits",
+		zap.Int32("concurrency_quota", next.ConcurrencyQuota),
+		zap.Int64("initial_memory_bytes_quota_per_query", next.InitialMemoryBytesQuotaPerQuery),
+		zap.Int64("memory_bytes_quota_per_query", next.MemoryBytesQuotaPerQuery),
+		zap.Int64("max_memory_bytes", next.MaxMemoryBytes),
+		zap.Int32("queue_size", next.QueueSize))
+	return nil
+}
+
+// setWorkers starts or stops the goroutines processing the queue, so that n
+// of them run. Stopped goroutines finish the query they are executing first.
+// The caller holds limitsMu, or has not shared the Controller yet.
+func (c *Controller) setWorkers(n int) {
+	for len(c.workers) < n {
+		quit := make(chan struct{})
+		c.workers = append(c.workers, quit)
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.processQueryQueue(quit)
+		}()
+	}
+	for len(c.workers) > n {
+		last := len(c.workers) - 1
+		close(c.workers[last])
+		c.workers = c.workers[:last]
 	}
-	return ctrl, nil
 }
 
 // Query satisfies the AsyncQueryService while ensuring the request is propagated on the context.
@@ -247,7 +357,15 @@ func (c *Controller) Query(ctx context.Context, req *query.Request) (flux.Query,
 	ctx = context.WithValue(ctx, orgLabel, req.OrganizationID.String()) //lint:ignore SA1029 this is a temporary ignore until we have time to create an appropriate type
 	// The controller injects the dependencies for each incoming request.
 	ctx, deps := dependency.Inject(ctx, c.dependencies...)
-	q, err := c.query(ctx, req.Compiler, deps)
+	compiler := req.Compiler
+	if c.config.Packages != nil {
+		var err error
+		if compiler, err = c.config.Packages.ResolvePackages(ctx, req.OrganizationID, compiler); err != nil {
+			deps.Finish()
+			return nil, err
+		}
+	}
+	q, err := c.query(ctx, compiler, deps)
 	if err != nil {
 		deps.Finish()
 		return q, err
@@ -406,7 +524,10 @@ func (c *Controller) enqueueQuery(q *Query) error {
 		}
 	}
 
-	if c.queryQueue == nil {
+	c.limitsMu.Lock()
+	queue := c.queryQueue
+	if queue == nil {
+		c.limitsMu.Unlock()
 		// unlimited queries case
 		c.queriesMu.RLock()
 		defer c.queriesMu.RUnlock()
@@ -425,8 +546,18 @@ func (c *Controller) enqueueQuery(q *Query) error {
 			c.executeQuery(q)
 		}()
 	} else {
+		// The queue may hold more queries than the queue size, when it was
+		// made smaller while they waited. UpdateLimits does not replace the
+		// queue while limitsMu is held.
+		defer c.limitsMu.Unlock()
+		if len(queue) >= int(c.config.QueueSize) {
+			return &flux.Error{
+				Code: codes.ResourceExhausted,
+				Msg:  "queue length exceeded",
+			}
+		}
 		select {
-		case c.queryQueue <- q:
+		case queue <- q:
 		default:
 			return &flux.Error{
 				Code: codes.ResourceExhausted,
@@ -438,12 +569,22 @@ func (c *Controller) enqueueQuery(q *Query) error {
 	return nil
 }
 
-func (c *Controller) processQueryQueue() {
+func (c *Controller) processQueryQueue(quit <-chan struct{}) {
 	for {
+		c.limitsMu.RLock()
+		queue := c.queryQueue
+		c.limitsMu.RUnlock()
+
 		select {
 		case <-c.done:
 			return
-		case q := <-c.queryQueue:
+		case <-quit:
+			return
+		case q, ok := <-queue:
+			if !ok {
+				// the queries moved to the queue which replaced it.
+				continue
+			}
 			c.executeQuery(q)
 		}
 	}
@@ -483,7 +624,10 @@ func (c *Controller) executeQuery(q *Query) {
 		return
 	}
 
-	q.c.createAllocator(q)
+	if err := q.c.createAllocator(ctx, q); err != nil {
+		q.setErr(err)
+		return
+	}
 	// Record unused memory before start.
 	q.recordUnusedMemory()
 	exec, err := q.program.Start(ctx, q.alloc)
@@ -597,7 +741,10 @@ func (c *Controller) GetUnusedMemoryBytes() int64 {
 }
 
 func (c *Controller) GetUsedMemoryBytes() int64 {
-	return c.config.MaxMemoryBytes - c.GetUnusedMemoryBytes()
+	c.limitsMu.RLock()
+	maxMemoryBytes := c.config.MaxMemoryBytes
+	c.limitsMu.RUnlock()
+	return maxMemoryBytes - c.GetUnusedMemoryBytes()
 }
 
 // Query represents a single request.
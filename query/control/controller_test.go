@@ -875,6 +875,98 @@ func TestController_QueueSize(t *testing.T) {
 	}
 }
 
+func TestController_UpdateLimits(t *testing.T) {
+	config := config
+	config.ConcurrencyQuota = 1
+	config.QueueSize = 1
+	config.InitialMemoryBytesQuotaPerQuery = 1024
+	config.MemoryBytesQuotaPerQuery = 4096
+	config.MaxMemoryBytes = 8192
+	ctrl, err := control.New(config, zaptest.NewLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown(t, ctrl)
+	reg := setupPromRegistry(ctrl)
+
+	done := make(chan struct{})
+	defer close(done)
+
+	executing := make(chan struct{}, 4)
+	compiler := &mock.Compiler{
+		CompileFn: func(ctx context.Context) (flux.Program, error) {
+			return &mock.Program{
+				ExecuteFn: func(ctx context.Context, q *mock.Query, alloc memory.Allocator) {
+					executing <- struct{}{}
+					<-done
+				},
+			}, nil
+		},
+	}
+	run := func() error {
+		q, err := ctrl.Query(context.Background(), makeRequest(compiler))
+		if err != nil {
+			return err
+		}
+		go func() {
+			for range q.Results() {
+				// discard the results
+			}
+			q.Done()
+		}()
+		return nil
+	}
+
+	// One query runs and another waits in the queue, which is then full.
+	if err := run(); err != nil {
+		t.Fatal(err)
+	}
+	<-executing
+	if err := run(); err != nil {
+		t.Fatal(err)
+	}
+	if err := run(); err == nil {
+		t.Fatal("expected an error about queue length exceeded")
+	}
+
+	config.ConcurrencyQuota = 2
+	config.QueueSize = 2
+	config.MaxMemoryBytes = 16384
+	if err := ctrl.UpdateLimits(config); err != nil {
+		t.Fatal(err)
+	}
+
+	// The queued query runs alongside the first, and the larger queue holds
+	// two more queries.
+	<-executing
+	validateUnusedMemory(t, reg, config)
+	for i := 0; i < 2; i++ {
+		if err := run(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := run(); err == nil {
+		t.Fatal("expected an error about queue length exceeded")
+	}
+
+	// A smaller queue rejects the queries beyond its size.
+	config.QueueSize = 1
+	if err := ctrl.UpdateLimits(config); err != nil {
+		t.Fatal(err)
+	}
+	if err := run(); err == nil {
+		t.Fatal("expected an error about queue length exceeded")
+	}
+
+	unlimited := config
+	unlimited.ConcurrencyQuota = 0
+	unlimited.QueueSize = 0
+	unlimited.MaxMemoryBytes = 0
+	if err := ctrl.UpdateLimits(unlimited); err == nil {
+		t.Fatal("expected an error about the concurrency becoming unlimited")
+	}
+}
+
 // Test that rapidly starting and canceling the query and then calling done will correctly
 // cancel the query and not result in a race condition.
 func TestController_CancelDone_Unlimited(t *testing.T) {
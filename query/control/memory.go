@@ -1,13 +1,23 @@
 package control
 
 import (
+	"context"
 	"errors"
 	"math"
 	"sync/atomic"
 
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
 	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/influxdb/v2/membudget"
+	"github.com/influxdata/influxdb/v2/query"
 )
 
+// orgMemoryInitialBytes is the most memory initially given to the queries
+// charged to the budgets of their organizations, so that they request the
+// memory they use beyond it.
+const orgMemoryInitialBytes = 1 << 20
+
 type memoryManager struct {
 	// initialBytesQuotaPerQuery is the initial amount of memory
 	// allocated for each query. It does not count against the
@@ -26,6 +36,20 @@ type memoryManager struct {
 	// unlimited indicates that the memory manager should indicate
 	// there is an unlimited amount of free memory available.
 	unlimited bool
+
+	// orgMemory, if set, is charged the memory of queries against
+	// the budgets of their organizations.
+	orgMemory *membudget.Accountant
+}
+
+// getInitialBytesQuotaPerQuery and getMemoryBytesQuotaPerQuery load the
+// quotas, which the Controller updates while queries run.
+func (m *memoryManager) getInitialBytesQuotaPerQuery() int64 {
+	return atomic.LoadInt64(&m.initialBytesQuotaPerQuery)
+}
+
+func (m *memoryManager) getMemoryBytesQuotaPerQuery() int64 {
+	return atomic.LoadInt64(&m.memoryBytesQuotaPerQuery)
 }
 
 func (m *memoryManager) getUnusedMemoryBytes() int64 {
@@ -41,17 +65,34 @@ func (m *memoryManager) addUnusedMemoryBytes(amount int64) int64 {
 }
 
 // createAllocator will construct an allocator and memory manager
-// for the given query.
-func (c *Controller) createAllocator(q *Query) {
+// for the given query. When the memory of queries is charged to
+// the budgets of their organizations, it waits for the initial
+// memory of the query to fit in the budget of its organization.
+func (c *Controller) createAllocator(ctx context.Context, q *Query) error {
 	q.memoryManager = &queryMemoryManager{
 		m:     c.memory,
-		limit: c.memory.initialBytesQuotaPerQuery,
+		limit: c.memory.getInitialBytesQuotaPerQuery(),
+	}
+	if req := query.RequestFromContext(ctx); req != nil && c.memory.orgMemory != nil {
+		if q.memoryManager.limit > orgMemoryInitialBytes {
+			q.memoryManager.limit = orgMemoryInitialBytes
+		}
+		org, err := c.memory.orgMemory.Reserve(ctx, req.OrganizationID, q.memoryManager.limit)
+		if err != nil {
+			return &flux.Error{
+				Code: codes.ResourceExhausted,
+				Msg:  "query exceeds the memory budget of its organization",
+				Err:  err,
+			}
+		}
+		q.memoryManager.org = org
 	}
 	q.alloc = &memory.ResourceAllocator{
 		// Use an anonymous function to ensure the value is copied.
 		Limit:   func(v int64) *int64 { return &v }(q.memoryManager.limit),
 		Manager: q.memoryManager,
 	}
+	return nil
 }
 
 // queryMemoryManager is a memory manager for a specific query.
@@ -59,6 +100,10 @@ type queryMemoryManager struct {
 	m     *memoryManager
 	limit int64
 	given int64
+
+	// org is the memory of the query charged to the budget
+	// of its organization, if any.
+	org *membudget.Reservation
 }
 
 // RequestMemory will determine if the query can be given more memory
@@ -73,7 +118,7 @@ type queryMemoryManager struct {
 func (q *queryMemoryManager) RequestMemory(want int64) (got int64, err error) {
 	// It can be determined statically if we are going to violate
 	// the memoryBytesQuotaPerQuery.
-	if q.limit+want > q.m.memoryBytesQuotaPerQuery {
+	if q.limit+want > q.m.getMemoryBytesQuotaPerQuery() {
 		return 0, errors.New("query hit hard limit")
 	}
 
@@ -103,6 +148,23 @@ func (q *queryMemoryManager) RequestMemory(want int64) (got int64, err error) {
 			}
 		}
 
+		// Charge the memory to the organization, settling for what
+		// the query wants when more does not fit in its budget.
+		if q.org != nil {
+			if err := q.org.Grow(given); err != nil {
+				if given == want || q.org.Grow(want) != nil {
+					if !q.m.unlimited {
+						q.m.addUnusedMemoryBytes(given)
+					}
+					return 0, err
+				}
+				if !q.m.unlimited {
+					q.m.addUnusedMemoryBytes(given - want)
+				}
+				given = want
+			}
+		}
+
 		// Successfully reserved the memory so update our own internal
 		// counter for the limit.
 		q.limit += given
@@ -118,12 +180,12 @@ func (q *queryMemoryManager) RequestMemory(want int64) (got int64, err error) {
 func (q *queryMemoryManager) giveMemory(want, unused int64) int64 {
 	// If we can safely double the limit, then just do that.
 	if q.limit > want && q.limit < unused {
-		if q.limit*2 <= q.m.memoryBytesQuotaPerQuery {
+		if q.limit*2 <= q.m.getMemoryBytesQuotaPerQuery() {
 			return q.limit
 		}
 		// Doubling the limit sends us over the quota.
 		// Determine what would be our maximum amount.
-		max := q.m.memoryBytesQuotaPerQuery - q.limit
+		max := q.m.getMemoryBytesQuotaPerQuery() - q.limit
 		if max > want {
 			return max
 		}
@@ -152,6 +214,7 @@ func (q *queryMemoryManager) Release() {
 	if !q.m.unlimited {
 		q.m.addUnusedMemoryBytes(q.given)
 	}
-	q.limit = q.m.initialBytesQuotaPerQuery
+	q.org.Release()
+	q.limit = q.m.getInitialBytesQuotaPerQuery()
 	q.given = 0
 }
@@ -9,6 +9,12 @@ import (
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 )
 
+// NewAuthCheckingService returns a RemoteConnectionService which authorizes every call
+// before it is passed on to underlying.
+func NewAuthCheckingService(underlying RemoteConnectionService) RemoteConnectionService {
+	return newAuthCheckingService(underlying)
+}
+
 func newAuthCheckingService(underlying RemoteConnectionService) *authCheckingService {
 	return &authCheckingService{underlying}
 }
@@ -0,0 +1,90 @@
+package replica
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const prefixReplica = "/api/v2/replica"
+
+// Handler is the handler of the replication status of the replica.
+type Handler struct {
+	chi.Router
+
+	api     *kithttp.API
+	log     *zap.Logger
+	replica *Replica
+}
+
+// NewHandler returns a new instance of Handler.
+func NewHandler(log *zap.Logger, replica *Replica) *Handler {
+	h := &Handler{
+		api:     kithttp.NewAPI(kithttp.WithLog(log)),
+		log:     log,
+		replica: replica,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+	r.Get("/", h.handleGetStatus)
+	h.Router = r
+	return h
+}
+
+// Prefix returns the mounting prefix for the handler.
+func (h *Handler) Prefix() string {
+	return prefixReplica
+}
+
+// handleGetStatus returns the replication status of the replica.
+func (h *Handler) handleGetStatus(w http.ResponseWriter, r *http.Request) {
+	h.api.Respond(w, r, http.StatusOK, h.replica.Status())
+}
+
+// writablePaths are the paths which may be posted to on a replica: the writes
+// of the replication streams, which the points writer of the replica only
+// accepts from its writer user, the queries, which only read, and the sign in
+// and setup of the users of the replica.
+var writablePaths = []string{
+	"/api/v2/write",
+	"/write",
+	"/api/v2/query",
+	"/query",
+	"/api/v2/signin",
+	"/api/v2/signout",
+	"/api/v2/setup",
+}
+
+// ReadOnly returns next rejecting the requests which would change the replica,
+// as the replica only serves the data and metadata of the primary.
+func ReadOnly(next http.Handler) http.Handler {
+	api := kithttp.NewAPI()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		case http.MethodPost:
+			for _, p := range writablePaths {
+				if r.URL.Path == p || strings.HasPrefix(r.URL.Path, p+"/") {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+		}
+		api.Err(w, r, &errors.Error{
+			Code: errors.EMethodNotAllowed,
+			Msg:  "the server is a read-only replica",
+		})
+	})
+}
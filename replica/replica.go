@@ -0,0 +1,273 @@
+// Package replica runs a server as a read-only replica of a primary server.
+//
+// The data of the replica is written by the replication streams of the
+// primary, configured on the primary with the replica as their remote. The
+// organizations and buckets of the primary are synced by the replica from
+// snapshots of the primary's metadata, taken every sync interval, so that the
+// buckets the streams write to exist on the replica with the IDs they have on
+// the primary. Only the writer user of the replica, which the replication
+// streams write with, can write to it; everything else the replica serves
+// read-only.
+package replica
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// Config configures the replica mode of a server.
+type Config struct {
+	// PrimaryURL is the URL of the primary server. The server is a replica when
+	// it is set.
+	PrimaryURL string
+	// PrimaryToken is the token the metadata of the primary is read with. It
+	// must be allowed to read all the organizations and buckets of the primary.
+	PrimaryToken string
+	// InsecureSkipVerify skips the verification of the TLS certificate of the
+	// primary.
+	InsecureSkipVerify bool
+	// SyncInterval is how often the metadata of the primary is synced.
+	SyncInterval time.Duration
+	// WriterUser is the name of the user of the replica whose tokens the
+	// replication streams of the primary write with. The writes of every other
+	// user are rejected, and every write is rejected when it is not set.
+	WriterUser string
+}
+
+// Enabled reports whether the server is a replica.
+func (c Config) Enabled() bool {
+	return c.PrimaryURL != ""
+}
+
+// PointsWriter writes points to storage.
+type PointsWriter interface {
+	WritePoints(ctx context.Context, orgID platform.ID, bucketID platform.ID, points []models.Point) error
+}
+
+// EngineSchema creates, updates and deletes the buckets of the storage engine.
+type EngineSchema interface {
+	CreateBucket(context.Context, *influxdb.Bucket) error
+	UpdateBucketRetentionPolicy(context.Context, platform.ID, *influxdb.BucketUpdate) error
+	DeleteBucket(context.Context, platform.ID, platform.ID) error
+}
+
+// Status is the replication status of a replica.
+type Status struct {
+	Primary string `json:"primary"`
+	// LastWrite is when the replica last received data from the primary.
+	LastWrite *time.Time `json:"lastWrite,omitempty"`
+	// LastSync is when the metadata of the primary was last synced.
+	LastSync *time.Time `json:"lastSync,omitempty"`
+	// DataLagSeconds and MetadataLagSeconds are the seconds since the last
+	// write and the last sync, -1 before the first.
+	DataLagSeconds     float64 `json:"dataLagSeconds"`
+	MetadataLagSeconds float64 `json:"metadataLagSeconds"`
+	// SyncError is the error of the last sync, if it failed.
+	SyncError string `json:"syncError,omitempty"`
+}
+
+// Replica syncs the metadata of the primary and tracks the replication of its
+// data.
+type Replica struct {
+	log     *zap.Logger
+	primary string
+	every   time.Duration
+	writer  string
+
+	primaryOrgs    influxdb.OrganizationService
+	primaryBuckets influxdb.BucketService
+
+	kvStore kv.Store
+	store   *tenant.Store
+	ids     *presetIDs
+	engine  EngineSchema
+
+	mu        sync.Mutex
+	lastWrite time.Time
+	lastSync  time.Time
+	syncErr   error
+
+	dataLag     prometheus.GaugeFunc
+	metadataLag prometheus.GaugeFunc
+	syncs       *prometheus.CounterVec
+
+	now func() time.Time
+}
+
+// New returns a Replica of the primary configured by cfg, whose organizations
+// and buckets are read with primaryOrgs and primaryBuckets. They are synced to
+// the tenant metadata in kvStore and the schema of the engine.
+func New(log *zap.Logger, cfg Config, primaryOrgs influxdb.OrganizationService, primaryBuckets influxdb.BucketService, kvStore kv.Store, engine EngineSchema) *Replica {
+	// The orgs and buckets are created with the IDs they have on the primary.
+	ids := &presetIDs{}
+	store := tenant.NewStore(kvStore)
+	store.OrgIDGen, store.BucketIDGen = ids, ids
+
+	r := &Replica{
+		log:            log,
+		primary:        cfg.PrimaryURL,
+		every:          cfg.SyncInterval,
+		writer:         cfg.WriterUser,
+		primaryOrgs:    primaryOrgs,
+		primaryBuckets: primaryBuckets,
+		kvStore:        kvStore,
+		store:          store,
+		ids:            ids,
+		engine:         engine,
+		now:            time.Now,
+	}
+	r.dataLag = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "replica",
+		Name:      "data_lag_seconds",
+		Help:      "Seconds since the replica last received data from the primary, -1 before the first write",
+	}, func() float64 { return r.Status().DataLagSeconds })
+	r.metadataLag = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "replica",
+		Name:      "metadata_lag_seconds",
+		Help:      "Seconds since the metadata of the primary was last synced, -1 before the first sync",
+	}, func() float64 { return r.Status().MetadataLagSeconds })
+	r.syncs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "replica",
+		Name:      "syncs_total",
+		Help:      "Number of syncs of the metadata of the primary, by result",
+	}, []string{"result"})
+	return r
+}
+
+// PrometheusCollectors returns the metrics of the replication to the replica.
+func (r *Replica) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{r.dataLag, r.metadataLag, r.syncs}
+}
+
+// Status returns the replication status of the replica.
+func (r *Replica) Status() Status {
+	now := r.now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := Status{Primary: r.primary, DataLagSeconds: -1, MetadataLagSeconds: -1}
+	if !r.lastWrite.IsZero() {
+		t := r.lastWrite
+		s.LastWrite = &t
+		s.DataLagSeconds = now.Sub(t).Seconds()
+	}
+	if !r.lastSync.IsZero() {
+		t := r.lastSync
+		s.LastSync = &t
+		s.MetadataLagSeconds = now.Sub(t).Seconds()
+	}
+	if r.syncErr != nil {
+		s.SyncError = r.syncErr.Error()
+	}
+	return s
+}
+
+// PointsWriter returns pw recording the writes of the replication streams,
+// and rejecting the writes of anyone else.
+func (r *Replica) PointsWriter(pw PointsWriter) PointsWriter {
+	return &pointsWriter{replica: r, next: pw}
+}
+
+type pointsWriter struct {
+	replica *Replica
+	next    PointsWriter
+}
+
+func (w *pointsWriter) WritePoints(ctx context.Context, orgID platform.ID, bucketID platform.ID, points []models.Point) error {
+	if err := w.replica.authorizeWrite(ctx); err != nil {
+		return err
+	}
+	if err := w.next.WritePoints(ctx, orgID, bucketID, points); err != nil {
+		return err
+	}
+	now := w.replica.now()
+	w.replica.mu.Lock()
+	w.replica.lastWrite = now
+	w.replica.mu.Unlock()
+	return nil
+}
+
+// authorizeWrite returns an error unless the write of ctx is made by the
+// writer user of the replica.
+func (r *Replica) authorizeWrite(ctx context.Context) error {
+	forbidden := &errors.Error{
+		Code: errors.EForbidden,
+		Msg:  "the server is a read-only replica, only the replication streams of the primary write to it",
+	}
+	if r.writer == "" {
+		return forbidden
+	}
+	a, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		return forbidden
+	}
+
+	var writer *influxdb.User
+	if err := r.kvStore.View(ctx, func(tx kv.Tx) (err error) {
+		writer, err = r.store.GetUserByName(ctx, tx, r.writer)
+		return err
+	}); err != nil {
+		if errors.ErrorCode(err) == errors.ENotFound {
+			return forbidden
+		}
+		return err
+	}
+	if a.GetUserID() != writer.ID {
+		return forbidden
+	}
+	return nil
+}
+
+// Run syncs the metadata of the primary every sync interval, until ctx is done.
+func (r *Replica) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.every)
+	defer ticker.Stop()
+
+	for {
+		r.syncOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Replica) syncOnce(ctx context.Context) {
+	err := r.Sync(ctx)
+	if ctx.Err() != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.syncErr = err
+	if err != nil {
+		r.syncs.WithLabelValues("error").Inc()
+		r.log.Error("Failed to sync the metadata of the primary", zap.String("primary", r.primary), zap.Error(err))
+		return
+	}
+	r.syncs.WithLabelValues("success").Inc()
+	r.lastSync = r.now()
+}
+
+// presetIDs generates the IDs the orgs and buckets are synced with.
+type presetIDs struct {
+	id platform.ID
+}
+
+func (g *presetIDs) ID() platform.ID {
+	return g.id
+}
@@ -0,0 +1,207 @@
+package replica_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/replica"
+	"github.com/influxdata/influxdb/v2/tenant"
+	itesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// engine records the buckets of the engine.
+type engine struct {
+	buckets map[platform.ID]time.Duration
+}
+
+func (e *engine) CreateBucket(ctx context.Context, b *influxdb.Bucket) error {
+	e.buckets[b.ID] = b.RetentionPeriod
+	return nil
+}
+
+func (e *engine) UpdateBucketRetentionPolicy(ctx context.Context, id platform.ID, upd *influxdb.BucketUpdate) error {
+	e.buckets[id] = *upd.RetentionPeriod
+	return nil
+}
+
+func (e *engine) DeleteBucket(ctx context.Context, orgID, id platform.ID) error {
+	delete(e.buckets, id)
+	return nil
+}
+
+func TestReplica_Sync(t *testing.T) {
+	ctx := context.Background()
+	org := &influxdb.Organization{ID: 0x1000000000000001, Name: "primary"}
+	buckets := []*influxdb.Bucket{
+		{ID: 0x2000000000000001, OrgID: org.ID, Name: "metrics", RetentionPeriod: time.Hour},
+		{ID: 0x2000000000000002, OrgID: org.ID, Name: "_monitoring", Type: influxdb.BucketTypeSystem, RetentionPeriod: 7 * 24 * time.Hour},
+	}
+
+	orgs := mock.NewOrganizationService()
+	orgs.FindOrganizationsF = func(ctx context.Context, f influxdb.OrganizationFilter, opts ...influxdb.FindOptions) ([]*influxdb.Organization, int, error) {
+		return []*influxdb.Organization{org}, 1, nil
+	}
+	bs := mock.NewBucketService()
+	bs.FindBucketsFn = func(ctx context.Context, f influxdb.BucketFilter, opts ...influxdb.FindOptions) ([]*influxdb.Bucket, int, error) {
+		require.Equal(t, org.ID, *f.OrganizationID)
+		return buckets, len(buckets), nil
+	}
+	kvStore := itesting.NewTestInmemStore(t)
+	e := &engine{buckets: map[platform.ID]time.Duration{}}
+	r := replica.New(zaptest.NewLogger(t), replica.Config{PrimaryURL: "http://primary:8086"}, orgs, bs, kvStore, e)
+
+	localBuckets := func() []*influxdb.Bucket {
+		var local []*influxdb.Bucket
+		require.NoError(t, kvStore.View(ctx, func(tx kv.Tx) (err error) {
+			local, err = tenant.NewStore(kvStore).ListBuckets(ctx, tx, tenant.BucketFilter{OrganizationID: &org.ID})
+			return err
+		}))
+		return local
+	}
+
+	// The org and buckets are created with the IDs of the primary.
+	require.NoError(t, r.Sync(ctx))
+	require.NoError(t, kvStore.View(ctx, func(tx kv.Tx) error {
+		o, err := tenant.NewStore(kvStore).GetOrg(ctx, tx, org.ID)
+		require.NoError(t, err)
+		require.Equal(t, "primary", o.Name)
+		return nil
+	}))
+	local := localBuckets()
+	require.Len(t, local, 2)
+	require.Equal(t, map[platform.ID]time.Duration{buckets[0].ID: time.Hour, buckets[1].ID: 7 * 24 * time.Hour}, e.buckets)
+
+	// Syncing again changes nothing.
+	require.NoError(t, r.Sync(ctx))
+	require.Len(t, localBuckets(), 2)
+
+	// The changed buckets are updated and the deleted ones deleted.
+	buckets = []*influxdb.Bucket{
+		{ID: 0x2000000000000001, OrgID: org.ID, Name: "metrics", RetentionPeriod: 2 * time.Hour},
+	}
+	require.NoError(t, r.Sync(ctx))
+	local = localBuckets()
+	require.Len(t, local, 1)
+	require.Equal(t, 2*time.Hour, local[0].RetentionPeriod)
+	require.Equal(t, map[platform.ID]time.Duration{buckets[0].ID: 2 * time.Hour}, e.buckets)
+}
+
+func TestReplica_SyncOrgConflict(t *testing.T) {
+	ctx := context.Background()
+	taken := &influxdb.Organization{ID: 0x1000000000000001, Name: "ops"}
+	broken := &influxdb.Organization{ID: 0x1000000000000002, Name: "broken"}
+	other := &influxdb.Organization{ID: 0x1000000000000003, Name: "other"}
+
+	orgs := mock.NewOrganizationService()
+	orgs.FindOrganizationsF = func(ctx context.Context, f influxdb.OrganizationFilter, opts ...influxdb.FindOptions) ([]*influxdb.Organization, int, error) {
+		return []*influxdb.Organization{taken, broken, other}, 3, nil
+	}
+	bs := mock.NewBucketService()
+	bs.FindBucketsFn = func(ctx context.Context, f influxdb.BucketFilter, opts ...influxdb.FindOptions) ([]*influxdb.Bucket, int, error) {
+		if *f.OrganizationID == broken.ID {
+			return nil, 0, fmt.Errorf("unavailable")
+		}
+		return []*influxdb.Bucket{{ID: *f.OrganizationID + 0x1000000000000000, OrgID: *f.OrganizationID, Name: "metrics"}}, 1, nil
+	}
+	kvStore := itesting.NewTestInmemStore(t)
+	store := tenant.NewStore(kvStore)
+	// The replica was set up with an org of the same name as one of the primary.
+	require.NoError(t, kvStore.Update(ctx, func(tx kv.Tx) error {
+		return store.CreateOrg(ctx, tx, &influxdb.Organization{Name: "ops"})
+	}))
+	e := &engine{buckets: map[platform.ID]time.Duration{}}
+	r := replica.New(zaptest.NewLogger(t), replica.Config{PrimaryURL: "http://primary:8086"}, orgs, bs, kvStore, e)
+
+	err := r.Sync(ctx)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), broken.ID.String())
+	require.NoError(t, kvStore.View(ctx, func(tx kv.Tx) error {
+		o, err := store.GetOrg(ctx, tx, taken.ID)
+		require.NoError(t, err)
+		require.Equal(t, "ops-"+taken.ID.String(), o.Name)
+		o, err = store.GetOrg(ctx, tx, other.ID)
+		require.NoError(t, err)
+		require.Equal(t, "other", o.Name)
+		return nil
+	}))
+	require.Len(t, e.buckets, 2, "the buckets of the other orgs are synced")
+
+	// Syncing again leaves the orgs as they are.
+	require.Error(t, r.Sync(ctx))
+	require.Len(t, e.buckets, 2)
+}
+
+func TestReplica_Status(t *testing.T) {
+	r := replica.New(zaptest.NewLogger(t), replica.Config{PrimaryURL: "http://primary:8086"}, nil, nil, nil, nil)
+	s := r.Status()
+	require.Equal(t, "http://primary:8086", s.Primary)
+	require.Nil(t, s.LastWrite)
+	require.Equal(t, float64(-1), s.DataLagSeconds)
+	require.Equal(t, float64(-1), s.MetadataLagSeconds)
+
+	pw := r.PointsWriter(&mock.PointsWriter{})
+	require.Error(t, pw.WritePoints(context.Background(), 1, 2, nil), "every write is rejected without a writer user")
+	require.Nil(t, r.Status().LastWrite)
+}
+
+func TestReplica_PointsWriter(t *testing.T) {
+	ctx := context.Background()
+	kvStore := itesting.NewTestInmemStore(t)
+	store := tenant.NewStore(kvStore)
+	writer := &influxdb.User{Name: "replication"}
+	operator := &influxdb.User{Name: "operator"}
+	require.NoError(t, kvStore.Update(ctx, func(tx kv.Tx) error {
+		if err := store.CreateUser(ctx, tx, writer); err != nil {
+			return err
+		}
+		return store.CreateUser(ctx, tx, operator)
+	}))
+	r := replica.New(zaptest.NewLogger(t), replica.Config{PrimaryURL: "http://primary:8086", WriterUser: "replication"}, nil, nil, kvStore, nil)
+	pw := r.PointsWriter(&mock.PointsWriter{})
+
+	err := pw.WritePoints(ctx, 1, 2, nil)
+	require.Equal(t, errors.EForbidden, errors.ErrorCode(err))
+	err = pw.WritePoints(icontext.SetAuthorizer(ctx, &influxdb.Authorization{UserID: operator.ID}), 1, 2, nil)
+	require.Equal(t, errors.EForbidden, errors.ErrorCode(err))
+	require.Nil(t, r.Status().LastWrite)
+
+	require.NoError(t, pw.WritePoints(icontext.SetAuthorizer(ctx, &influxdb.Authorization{UserID: writer.ID}), 1, 2, nil))
+	s := r.Status()
+	require.NotNil(t, s.LastWrite)
+	require.GreaterOrEqual(t, s.DataLagSeconds, float64(0))
+}
+
+func TestReadOnly(t *testing.T) {
+	h := replica.ReadOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	for _, tc := range []struct {
+		method, path string
+		status       int
+	}{
+		{method: http.MethodGet, path: "/api/v2/buckets", status: http.StatusNoContent},
+		{method: http.MethodPost, path: "/api/v2/write", status: http.StatusNoContent},
+		{method: http.MethodPost, path: "/api/v2/query/ast", status: http.StatusNoContent},
+		{method: http.MethodPost, path: "/query", status: http.StatusNoContent},
+		{method: http.MethodPost, path: "/api/v2/buckets", status: http.StatusMethodNotAllowed},
+		{method: http.MethodPost, path: "/api/v2/writes", status: http.StatusMethodNotAllowed},
+		{method: http.MethodDelete, path: "/api/v2/buckets/0000000000000001", status: http.StatusMethodNotAllowed},
+		{method: http.MethodPatch, path: "/api/v2/orgs/0000000000000001", status: http.StatusMethodNotAllowed},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		require.Equal(t, tc.status, w.Code, "%s %s", tc.method, tc.path)
+	}
+}
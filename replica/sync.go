@@ -0,0 +1,221 @@
+package replica
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/tenant"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+// Sync syncs the orgs and buckets of the primary to the replica. The orgs and
+// buckets missing on the replica are created, and those which changed are
+// updated. The buckets of the synced orgs which were deleted on the primary
+// are deleted with their data, but orgs are never deleted, as the replica has
+// orgs of its own. An org which fails to sync does not keep the other orgs
+// from syncing; the errors of all of them are returned.
+func (r *Replica) Sync(ctx context.Context) error {
+	orgs, err := r.primaryOrgList(ctx)
+	if err != nil {
+		return fmt.Errorf("listing the orgs of the primary: %w", err)
+	}
+	var errs error
+	for _, o := range orgs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		errs = multierr.Append(errs, r.syncOrgAndBuckets(ctx, o))
+	}
+	return errs
+}
+
+func (r *Replica) syncOrgAndBuckets(ctx context.Context, o *influxdb.Organization) error {
+	if err := r.syncOrg(ctx, o); err != nil {
+		return fmt.Errorf("syncing org %s: %w", o.ID, err)
+	}
+	buckets, err := r.primaryBucketList(ctx, o.ID)
+	if err != nil {
+		return fmt.Errorf("listing the buckets of org %s of the primary: %w", o.ID, err)
+	}
+	if err := r.syncBuckets(ctx, o.ID, buckets); err != nil {
+		return fmt.Errorf("syncing the buckets of org %s: %w", o.ID, err)
+	}
+	return nil
+}
+
+func (r *Replica) primaryOrgList(ctx context.Context) ([]*influxdb.Organization, error) {
+	var orgs []*influxdb.Organization
+	for {
+		page, _, err := r.primaryOrgs.FindOrganizations(ctx, influxdb.OrganizationFilter{}, influxdb.FindOptions{
+			Limit:  influxdb.MaxPageSize,
+			Offset: len(orgs),
+		})
+		if err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, page...)
+		if len(page) < influxdb.MaxPageSize {
+			return orgs, nil
+		}
+	}
+}
+
+func (r *Replica) primaryBucketList(ctx context.Context, orgID platform.ID) ([]*influxdb.Bucket, error) {
+	var buckets []*influxdb.Bucket
+	for {
+		page, _, err := r.primaryBuckets.FindBuckets(ctx, influxdb.BucketFilter{OrganizationID: &orgID}, influxdb.FindOptions{
+			Limit:  influxdb.MaxPageSize,
+			Offset: len(buckets),
+		})
+		if err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, page...)
+		if len(page) < influxdb.MaxPageSize {
+			return buckets, nil
+		}
+	}
+}
+
+// syncOrg creates or updates the org of the primary on the replica. The orgs
+// are matched by ID.
+func (r *Replica) syncOrg(ctx context.Context, o *influxdb.Organization) error {
+	return r.kvStore.Update(ctx, func(tx kv.Tx) error {
+		name, err := r.orgName(ctx, tx, o)
+		if err != nil {
+			return err
+		}
+		local, err := r.store.GetOrg(ctx, tx, o.ID)
+		if errors.ErrorCode(err) == errors.ENotFound {
+			r.ids.id = o.ID
+			r.log.Info("Creating the org of the primary", zap.Stringer("org_id", o.ID), zap.String("org", o.Name), zap.String("name", name))
+			return r.store.CreateOrg(ctx, tx, &influxdb.Organization{Name: name, Description: o.Description})
+		}
+		if err != nil {
+			return err
+		}
+		if local.Name == name && local.Description == o.Description {
+			return nil
+		}
+		if name != o.Name {
+			r.log.Warn("Syncing the org of the primary under another name, its name is taken by an org of the replica",
+				zap.Stringer("org_id", o.ID), zap.String("org", o.Name), zap.String("name", name))
+		}
+		_, err = r.store.UpdateOrg(ctx, tx, o.ID, influxdb.OrganizationUpdate{Name: &name, Description: &o.Description})
+		return err
+	})
+}
+
+// orgName returns the name the org of the primary is synced with. Org names
+// are unique, so when an org of the replica's own, such as the one it was set
+// up with, already has the name, the org is synced with its ID appended to it.
+func (r *Replica) orgName(ctx context.Context, tx kv.Tx, o *influxdb.Organization) (string, error) {
+	other, err := r.store.GetOrgByName(ctx, tx, o.Name)
+	if errors.ErrorCode(err) == errors.ENotFound {
+		return o.Name, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if other.ID == o.ID {
+		return o.Name, nil
+	}
+	return fmt.Sprintf("%s-%s", o.Name, o.ID), nil
+}
+
+// syncBuckets syncs the buckets of an org of the primary. Like the storage
+// bucket service, the buckets are changed in the tenant metadata first, and
+// then in the engine.
+func (r *Replica) syncBuckets(ctx context.Context, orgID platform.ID, buckets []*influxdb.Bucket) error {
+	var local []*influxdb.Bucket
+	if err := r.kvStore.View(ctx, func(tx kv.Tx) (err error) {
+		local, err = r.store.ListBuckets(ctx, tx, tenant.BucketFilter{OrganizationID: &orgID})
+		return err
+	}); err != nil {
+		return err
+	}
+	deleted := make(map[platform.ID]*influxdb.Bucket, len(local))
+	for _, b := range local {
+		deleted[b.ID] = b
+	}
+
+	for _, b := range buckets {
+		l, ok := deleted[b.ID]
+		delete(deleted, b.ID)
+		switch {
+		case !ok:
+			if err := r.createBucket(ctx, b); err != nil {
+				return fmt.Errorf("creating bucket %s: %w", b.ID, err)
+			}
+		case l.Name != b.Name || l.Description != b.Description || l.RetentionPeriod != b.RetentionPeriod || l.ShardGroupDuration != b.ShardGroupDuration:
+			if err := r.updateBucket(ctx, b); err != nil {
+				return fmt.Errorf("updating bucket %s: %w", b.ID, err)
+			}
+		}
+	}
+
+	for _, b := range deleted {
+		r.log.Info("Deleting the bucket deleted on the primary", zap.Stringer("bucket_id", b.ID), zap.String("bucket", b.Name))
+		if err := r.kvStore.Update(ctx, func(tx kv.Tx) error {
+			return r.store.DeleteBucket(ctx, tx, b.ID)
+		}); err != nil {
+			return fmt.Errorf("deleting bucket %s: %w", b.ID, err)
+		}
+		if err := r.engine.DeleteBucket(ctx, orgID, b.ID); err != nil {
+			return fmt.Errorf("deleting the data of bucket %s: %w", b.ID, err)
+		}
+	}
+	return nil
+}
+
+func (r *Replica) createBucket(ctx context.Context, b *influxdb.Bucket) error {
+	r.log.Info("Creating the bucket of the primary", zap.Stringer("bucket_id", b.ID), zap.String("bucket", b.Name))
+	bucket := &influxdb.Bucket{
+		Type:                b.Type,
+		OrgID:               b.OrgID,
+		Name:                b.Name,
+		Description:         b.Description,
+		RetentionPolicyName: b.RetentionPolicyName,
+		RetentionPeriod:     b.RetentionPeriod,
+		ShardGroupDuration:  b.ShardGroupDuration,
+	}
+	if err := r.kvStore.Update(ctx, func(tx kv.Tx) error {
+		r.ids.id = b.ID
+		return r.store.CreateBucket(ctx, tx, bucket)
+	}); err != nil {
+		return err
+	}
+
+	if err := r.engine.CreateBucket(ctx, bucket); err != nil {
+		// The bucket is created again by the next sync.
+		if derr := r.kvStore.Update(ctx, func(tx kv.Tx) error {
+			return r.store.DeleteBucket(ctx, tx, bucket.ID)
+		}); derr != nil {
+			r.log.Error("Failed to remove the bucket which could not be created in the engine", zap.Stringer("bucket_id", bucket.ID), zap.Error(derr))
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *Replica) updateBucket(ctx context.Context, b *influxdb.Bucket) error {
+	r.log.Info("Updating the bucket of the primary", zap.Stringer("bucket_id", b.ID), zap.String("bucket", b.Name))
+	upd := influxdb.BucketUpdate{
+		Name:               &b.Name,
+		Description:        &b.Description,
+		RetentionPeriod:    &b.RetentionPeriod,
+		ShardGroupDuration: &b.ShardGroupDuration,
+	}
+	if err := r.kvStore.Update(ctx, func(tx kv.Tx) error {
+		_, err := r.store.UpdateBucket(ctx, tx, b.ID, upd)
+		return err
+	}); err != nil {
+		return err
+	}
+	return r.engine.UpdateBucketRetentionPolicy(ctx, b.ID, &upd)
+}
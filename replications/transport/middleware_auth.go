@@ -9,6 +9,12 @@ import (
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 )
 
+// NewAuthCheckingService returns a ReplicationService which authorizes every call
+// before it is passed on to underlying.
+func NewAuthCheckingService(underlying ReplicationService) ReplicationService {
+	return newAuthCheckingService(underlying)
+}
+
 func newAuthCheckingService(underlying ReplicationService) *authCheckingService {
 	return &authCheckingService{underlying}
 }
@@ -0,0 +1,219 @@
+package influxdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// ErrReportNotFound is the error msg for a missing report.
+const ErrReportNotFound = "report not found"
+
+// ops for report service.
+const (
+	OpFindReportByID = "FindReportByID"
+	OpFindReports    = "FindReports"
+	OpCreateReport   = "CreateReport"
+	OpUpdateReport   = "UpdateReport"
+	OpDeleteReport   = "DeleteReport"
+	OpRenderReport   = "RenderReport"
+	OpRunReport      = "RunReport"
+)
+
+// Report formats.
+const (
+	ReportFormatPNG = "png"
+	ReportFormatPDF = "pdf"
+)
+
+// Report run statuses.
+const (
+	ReportRunSuccess = "success"
+	ReportRunFailed  = "failed"
+)
+
+// MinReportEvery is the shortest interval a report can be scheduled at.
+const MinReportEvery = time.Minute
+
+// DefaultReportRange is the time range covered by a report that does not set one.
+const DefaultReportRange = 24 * time.Hour
+
+// ReportService manages the reports scheduled for dashboards.
+type ReportService interface {
+	// FindReportByID returns a single report by ID.
+	FindReportByID(ctx context.Context, id platform.ID) (*Report, error)
+
+	// FindReports returns the reports matching filter and the total count of matching reports.
+	FindReports(ctx context.Context, filter ReportFilter, opts FindOptions) ([]*Report, int, error)
+
+	// CreateReport creates a new report and sets r.ID with the new identifier.
+	CreateReport(ctx context.Context, r *Report) error
+
+	// UpdateReport updates a single report with changeset.
+	UpdateReport(ctx context.Context, id platform.ID, upd ReportUpdate) (*Report, error)
+
+	// DeleteReport removes a report by ID.
+	DeleteReport(ctx context.Context, id platform.ID) error
+}
+
+// ReportRunService renders reports and delivers them to their notification endpoint.
+type ReportRunService interface {
+	// RenderReport renders the dashboard of a report over the time range ending now,
+	// in the format of the report unless format is set.
+	RenderReport(ctx context.Context, id platform.ID, format string) (*RenderedReport, error)
+
+	// RunReport renders a report and delivers it, outside of its schedule.
+	RunReport(ctx context.Context, id platform.ID) (*Report, error)
+}
+
+// Report is a dashboard rendered on a schedule and delivered to a notification endpoint.
+type Report struct {
+	ID             platform.ID `json:"id,omitempty"`
+	OrganizationID platform.ID `json:"orgID"`
+	DashboardID    platform.ID `json:"dashboardID"`
+	EndpointID     platform.ID `json:"endpointID"`
+	OwnerID        platform.ID `json:"ownerID,omitempty"`
+	Name           string      `json:"name"`
+	Description    string      `json:"description"`
+	Format         string      `json:"format"`
+	Status         Status      `json:"status"`
+	// Every is the interval the report is delivered at.
+	Every Duration `json:"every"`
+	// Range is the time range, ending when the report runs, its queries cover.
+	Range     Duration   `json:"range"`
+	NextRunAt time.Time  `json:"nextRunAt"`
+	LatestRun *ReportRun `json:"latestRun,omitempty"`
+	CRUDLog
+}
+
+// ReportRun is the outcome of a run of a report.
+type ReportRun struct {
+	StartedAt time.Time `json:"startedAt"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// RenderedReport is a dashboard rendered as a document.
+type RenderedReport struct {
+	ContentType string
+	Data        []byte
+}
+
+// ReportContentType returns the content type of a report format.
+func ReportContentType(format string) string {
+	if format == ReportFormatPDF {
+		return "application/pdf"
+	}
+	return "image/png"
+}
+
+// ValidReportFormat returns an error if format is not a supported report format.
+func ValidReportFormat(format string) error {
+	switch format {
+	case ReportFormatPNG, ReportFormatPDF:
+		return nil
+	}
+	return &errors.Error{
+		Code: errors.EInvalid,
+		Msg:  fmt.Sprintf("invalid report format %q: must be %s or %s", format, ReportFormatPNG, ReportFormatPDF),
+	}
+}
+
+// Valid returns an error if the report is invalid.
+func (r *Report) Valid() error {
+	if !r.OrganizationID.Valid() {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "report orgID is required",
+		}
+	}
+	if r.Name == "" {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "report name is required",
+		}
+	}
+	if !r.DashboardID.Valid() {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "report dashboardID is required",
+		}
+	}
+	if !r.EndpointID.Valid() {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "report endpointID is required",
+		}
+	}
+	if err := ValidReportFormat(r.Format); err != nil {
+		return err
+	}
+	if err := r.Status.Valid(); err != nil {
+		return err
+	}
+	if r.Every.Duration < MinReportEvery {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("report every must be at least %s", MinReportEvery),
+		}
+	}
+	if r.Range.Duration <= 0 {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "report range must be positive",
+		}
+	}
+	return nil
+}
+
+// NextRun returns the first time the report is due after t. Runs are aligned to
+// multiples of the report's interval.
+func (r *Report) NextRun(t time.Time) time.Time {
+	return t.Truncate(r.Every.Duration).Add(r.Every.Duration)
+}
+
+// ReportFilter represents a set of filters that restrict the returned reports.
+type ReportFilter struct {
+	OrganizationID *platform.ID
+	DashboardID    *platform.ID
+}
+
+// ReportUpdate is the patch structure for a report.
+type ReportUpdate struct {
+	Name        *string      `json:"name"`
+	Description *string      `json:"description"`
+	EndpointID  *platform.ID `json:"endpointID"`
+	Format      *string      `json:"format"`
+	Status      *Status      `json:"status"`
+	Every       *Duration    `json:"every"`
+	Range       *Duration    `json:"range"`
+}
+
+// Apply applies an update to a report.
+func (u ReportUpdate) Apply(r *Report) error {
+	if u.Name != nil {
+		r.Name = *u.Name
+	}
+	if u.Description != nil {
+		r.Description = *u.Description
+	}
+	if u.EndpointID != nil {
+		r.EndpointID = *u.EndpointID
+	}
+	if u.Format != nil {
+		r.Format = *u.Format
+	}
+	if u.Status != nil {
+		r.Status = *u.Status
+	}
+	if u.Every != nil {
+		r.Every = *u.Every
+	}
+	if u.Range != nil {
+		r.Range = *u.Range
+	}
+	return r.Valid()
+}
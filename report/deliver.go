@@ -0,0 +1,103 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	influxdb "github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/notification/endpoint"
+)
+
+// deliver sends a rendered report to the report's notification endpoint. Only
+// HTTP endpoints are supported: the document is sent as the request body, with
+// the endpoint's headers and authentication.
+func (r *Runner) deliver(ctx context.Context, rep *influxdb.Report, doc *influxdb.RenderedReport) error {
+	ne, err := r.endpoints.FindNotificationEndpointByID(ctx, rep.EndpointID)
+	if err != nil {
+		return err
+	}
+	if ne.GetOrgID() != rep.OrganizationID {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "report endpoint belongs to a different organization than the report",
+		}
+	}
+	if ne.GetStatus() != influxdb.Active {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("notification endpoint %q is inactive", ne.GetName()),
+		}
+	}
+	h, ok := ne.(*endpoint.HTTP)
+	if !ok {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("reports cannot be delivered to %s notification endpoints", ne.Type()),
+		}
+	}
+
+	// A document cannot be sent with GET.
+	method := h.Method
+	if method == "" || method == http.MethodGet {
+		method = http.MethodPost
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, h.URL, bytes.NewReader(doc.Data))
+	if err != nil {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "invalid notification endpoint url",
+			Err:  err,
+		}
+	}
+	for k, v := range h.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", doc.ContentType)
+	req.Header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("%s.%s", rep.Name, rep.Format)))
+	req.Header.Set("X-Influxdb-Report-ID", rep.ID.String())
+
+	switch h.AuthMethod {
+	case "basic":
+		username, err := r.secrets.LoadSecret(ctx, rep.OrganizationID, h.Username.Key)
+		if err != nil {
+			return err
+		}
+		password, err := r.secrets.LoadSecret(ctx, rep.OrganizationID, h.Password.Key)
+		if err != nil {
+			return err
+		}
+		req.SetBasicAuth(username, password)
+	case "bearer":
+		token, err := r.secrets.LoadSecret(ctx, rep.OrganizationID, h.Token.Key)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.EUnavailable,
+			Msg:  "failed to deliver report",
+			Err:  err,
+		}
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &errors.Error{
+			Code: errors.EUnavailable,
+			Msg:  fmt.Sprintf("notification endpoint responded with status %d", resp.StatusCode),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,426 @@
+// Package render draws dashboards as images, without a browser, for reports.
+//
+// A page is laid out on the dashboard grid: each cell occupies the columns and
+// rows of its position. Graphs are drawn as line charts, single stats as their
+// latest value and notes as their text.
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Sizes, in pixels, of the page layout.
+const (
+	// ColumnWidth and RowHeight are the size of a unit of the dashboard grid.
+	ColumnWidth = 100
+	RowHeight   = 70
+
+	// MinColumns is the number of grid columns a page is at least as wide as.
+	MinColumns = 12
+
+	headerHeight = 48
+	margin       = 8
+	padding      = 8
+	axisWidth    = 60
+	axisHeight   = 16
+)
+
+// PanelKind is the way a panel presents its data.
+type PanelKind int
+
+// Panel kinds.
+const (
+	// Chart draws every series as a line.
+	Chart PanelKind = iota
+	// Stat shows the latest value of the first series.
+	Stat
+	// Text shows the panel's text.
+	Text
+)
+
+var (
+	backgroundColor = color.RGBA{R: 0xf4, G: 0xf5, B: 0xf7, A: 0xff}
+	panelColor      = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	borderColor     = color.RGBA{R: 0xd0, G: 0xd4, B: 0xdc, A: 0xff}
+	gridColor       = color.RGBA{R: 0xe6, G: 0xe8, B: 0xec, A: 0xff}
+	textColor       = color.RGBA{R: 0x20, G: 0x24, B: 0x2c, A: 0xff}
+	mutedColor      = color.RGBA{R: 0x80, G: 0x86, B: 0x92, A: 0xff}
+	errorColor      = color.RGBA{R: 0xc8, G: 0x28, B: 0x28, A: 0xff}
+
+	// seriesColors are cycled through for the series of a chart.
+	seriesColors = []color.RGBA{
+		{R: 0x22, G: 0xad, B: 0xf6, A: 0xff},
+		{R: 0x7a, G: 0x65, B: 0xf2, A: 0xff},
+		{R: 0x4e, G: 0xd8, B: 0xa0, A: 0xff},
+		{R: 0xff, G: 0xa9, B: 0x4b, A: 0xff},
+		{R: 0xdc, G: 0x4e, B: 0x58, A: 0xff},
+		{R: 0x51, G: 0x3c, B: 0xc6, A: 0xff},
+	}
+)
+
+// Page is a dashboard to be rendered.
+type Page struct {
+	Title string
+	// Subtitle is shown under the title, typically the time range of the data.
+	Subtitle string
+	Panels   []Panel
+}
+
+// Panel is a cell of a dashboard along with its data.
+type Panel struct {
+	Title string
+	// X, Y, W and H are the position and size of the panel on the dashboard grid.
+	X, Y, W, H int32
+	Kind       PanelKind
+	Series     []Series
+	// Text is the text of a Text panel.
+	Text string
+	// Prefix and Suffix surround the value of a Stat panel.
+	Prefix, Suffix string
+	// Err is shown instead of the data when the data of the panel could not be retrieved.
+	Err string
+}
+
+// Series is a sequence of points, such as a table of a query result.
+type Series struct {
+	Name   string
+	Points []Point
+}
+
+// Point is a value at a time.
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Render draws a page.
+func Render(p Page) *image.RGBA {
+	cols, rows := int32(MinColumns), int32(0)
+	for _, pn := range p.Panels {
+		if c := pn.X + pn.W; c > cols {
+			cols = c
+		}
+		if r := pn.Y + pn.H; r > rows {
+			rows = r
+		}
+	}
+
+	width := int(cols) * ColumnWidth
+	height := headerHeight + int(rows)*RowHeight + margin
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fill(img, img.Bounds(), backgroundColor)
+
+	drawText(img, margin, margin, p.Title, 2, textColor)
+	drawText(img, margin, margin+2*lineHeight+2, p.Subtitle, 1, mutedColor)
+
+	for _, pn := range p.Panels {
+		if pn.W <= 0 || pn.H <= 0 {
+			continue
+		}
+		r := image.Rect(
+			int(pn.X)*ColumnWidth+margin/2,
+			headerHeight+int(pn.Y)*RowHeight+margin/2,
+			int(pn.X+pn.W)*ColumnWidth-margin/2,
+			headerHeight+int(pn.Y+pn.H)*RowHeight-margin/2,
+		)
+		drawPanel(img, r, pn)
+	}
+	return img
+}
+
+func drawPanel(img *image.RGBA, r image.Rectangle, p Panel) {
+	fill(img, r, panelColor)
+	strokeRect(img, r, borderColor)
+
+	inner := r.Inset(padding)
+	if p.Title != "" {
+		drawText(img, inner.Min.X, inner.Min.Y, truncate(p.Title, inner.Dx()/glyphAdvance), 1, textColor)
+		inner.Min.Y += lineHeight + padding/2
+	}
+	if inner.Empty() {
+		return
+	}
+
+	switch {
+	case p.Err != "":
+		drawWrapped(img, inner, "ERROR: "+p.Err, 1, errorColor)
+	case p.Kind == Text:
+		drawWrapped(img, inner, p.Text, 1, textColor)
+	case p.Kind == Stat:
+		drawStat(img, inner, p)
+	default:
+		drawChart(img, inner, p.Series)
+	}
+}
+
+func drawStat(img *image.RGBA, r image.Rectangle, p Panel) {
+	var last *Point
+	for _, s := range p.Series {
+		for i := range s.Points {
+			if last == nil || !s.Points[i].Time.Before(last.Time) {
+				last = &s.Points[i]
+			}
+		}
+		if last != nil {
+			break
+		}
+	}
+	if last == nil {
+		drawCentered(img, r, "NO DATA", 1, mutedColor)
+		return
+	}
+
+	text := p.Prefix + formatValue(last.Value) + p.Suffix
+	scale := 4
+	for scale > 1 && len(text)*glyphAdvance*scale > r.Dx() {
+		scale--
+	}
+	drawCentered(img, r, text, scale, textColor)
+}
+
+func drawChart(img *image.RGBA, r image.Rectangle, series []Series) {
+	var (
+		minT, maxT time.Time
+		minV       = math.Inf(1)
+		maxV       = math.Inf(-1)
+		points     int
+	)
+	for _, s := range series {
+		for _, pt := range s.Points {
+			if math.IsNaN(pt.Value) || math.IsInf(pt.Value, 0) {
+				continue
+			}
+			if points == 0 || pt.Time.Before(minT) {
+				minT = pt.Time
+			}
+			if points == 0 || pt.Time.After(maxT) {
+				maxT = pt.Time
+			}
+			minV = math.Min(minV, pt.Value)
+			maxV = math.Max(maxV, pt.Value)
+			points++
+		}
+	}
+	if points == 0 {
+		drawCentered(img, r, "NO DATA", 1, mutedColor)
+		return
+	}
+	if minV == maxV {
+		minV, maxV = minV-1, maxV+1
+	}
+
+	plot := image.Rect(r.Min.X+axisWidth, r.Min.Y, r.Max.X, r.Max.Y-axisHeight)
+	if plot.Dx() < 2 || plot.Dy() < 2 {
+		return
+	}
+
+	const gridLines = 4
+	for i := 0; i <= gridLines; i++ {
+		y := plot.Max.Y - 1 - i*(plot.Dy()-1)/gridLines
+		line(img, plot.Min.X, y, plot.Max.X-1, y, gridColor)
+		v := minV + float64(i)*(maxV-minV)/gridLines
+		label := truncate(formatValue(v), (axisWidth-padding)/glyphAdvance)
+		drawText(img, plot.Min.X-padding-len(label)*glyphAdvance, y-glyphHeight/2, label, 1, mutedColor)
+	}
+	line(img, plot.Min.X, plot.Min.Y, plot.Min.X, plot.Max.Y-1, borderColor)
+
+	layout := "15:04"
+	if maxT.Sub(minT) >= 24*time.Hour {
+		layout = "01-02 15:04"
+	}
+	labelY := plot.Max.Y + (axisHeight-glyphHeight)/2
+	drawText(img, plot.Min.X, labelY, minT.UTC().Format(layout), 1, mutedColor)
+	stop := maxT.UTC().Format(layout)
+	drawText(img, plot.Max.X-len(stop)*glyphAdvance, labelY, stop, 1, mutedColor)
+
+	span := maxT.Sub(minT)
+	x := func(t time.Time) int {
+		if span <= 0 {
+			return plot.Min.X + plot.Dx()/2
+		}
+		return plot.Min.X + int(float64(plot.Dx()-1)*float64(t.Sub(minT))/float64(span))
+	}
+	y := func(v float64) int {
+		return plot.Max.Y - 1 - int(float64(plot.Dy()-1)*(v-minV)/(maxV-minV))
+	}
+
+	for i, s := range series {
+		c := seriesColors[i%len(seriesColors)]
+		pts := make([]Point, 0, len(s.Points))
+		for _, pt := range s.Points {
+			if !math.IsNaN(pt.Value) && !math.IsInf(pt.Value, 0) {
+				pts = append(pts, pt)
+			}
+		}
+		sort.SliceStable(pts, func(i, j int) bool { return pts[i].Time.Before(pts[j].Time) })
+
+		for j, pt := range pts {
+			x1, y1 := x(pt.Time), y(pt.Value)
+			if len(pts) == 1 {
+				fill(img, image.Rect(x1-1, y1-1, x1+2, y1+2), c)
+				break
+			}
+			if j == 0 {
+				continue
+			}
+			x0, y0 := x(pts[j-1].Time), y(pts[j-1].Value)
+			line(img, x0, y0, x1, y1, c)
+			line(img, x0, y0+1, x1, y1+1, c)
+		}
+	}
+}
+
+// formatValue formats a value with up to 5 significant digits.
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', 5, 64)
+}
+
+// truncate shortens s to at most n characters, marking the cut with "..".
+func truncate(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	rs := []rune(s)
+	if len(rs) <= n {
+		return s
+	}
+	if n <= 2 {
+		return string(rs[:n])
+	}
+	return string(rs[:n-2]) + ".."
+}
+
+// wrap splits text into lines of at most n characters, breaking at spaces where
+// possible. Leading markdown heading and emphasis markers are dropped.
+func wrap(text string, n int) []string {
+	if n <= 0 {
+		return nil
+	}
+	var lines []string
+	for _, para := range strings.Split(text, "\n") {
+		para = strings.TrimLeft(strings.TrimSpace(para), "#> ")
+		para = strings.NewReplacer("**", "", "__", "", "`", "").Replace(para)
+
+		var cur []rune
+		for _, word := range strings.Fields(para) {
+			w := []rune(word)
+			for len(w) > n {
+				if len(cur) > 0 {
+					lines = append(lines, string(cur))
+					cur = nil
+				}
+				lines = append(lines, string(w[:n]))
+				w = w[n:]
+			}
+			switch {
+			case len(cur) == 0:
+				cur = w
+			case len(cur)+1+len(w) <= n:
+				cur = append(append(cur, ' '), w...)
+			default:
+				lines = append(lines, string(cur))
+				cur = w
+			}
+		}
+		lines = append(lines, string(cur))
+	}
+	return lines
+}
+
+func drawWrapped(img *image.RGBA, r image.Rectangle, text string, scale int, c color.Color) {
+	y := r.Min.Y
+	for _, l := range wrap(text, r.Dx()/(glyphAdvance*scale)) {
+		if y+glyphHeight*scale > r.Max.Y {
+			return
+		}
+		drawText(img, r.Min.X, y, l, scale, c)
+		y += lineHeight * scale
+	}
+}
+
+func drawCentered(img *image.RGBA, r image.Rectangle, text string, scale int, c color.Color) {
+	text = truncate(text, r.Dx()/(glyphAdvance*scale))
+	w := len([]rune(text))*glyphAdvance*scale - scale
+	x := r.Min.X + (r.Dx()-w)/2
+	y := r.Min.Y + (r.Dy()-glyphHeight*scale)/2
+	drawText(img, x, y, text, scale, c)
+}
+
+// drawText draws text with its top left corner at x, y, using the built-in font
+// magnified scale times. Characters the font does not have are drawn as '?'.
+func drawText(img *image.RGBA, x, y int, text string, scale int, c color.Color) {
+	for _, ch := range text {
+		g, ok := glyphs[unicode.ToUpper(ch)]
+		if !ok && ch != ' ' {
+			g = glyphs['?']
+		}
+		for row := 0; row < glyphHeight; row++ {
+			for col := 0; col < glyphWidth; col++ {
+				if g[row]&(1<<(glyphWidth-1-col)) == 0 {
+					continue
+				}
+				px, py := x+col*scale, y+row*scale
+				fill(img, image.Rect(px, py, px+scale, py+scale), c)
+			}
+		}
+		x += glyphAdvance * scale
+	}
+}
+
+func fill(img *image.RGBA, r image.Rectangle, c color.Color) {
+	r = r.Intersect(img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.Set(x, y, c)
+		}
+	}
+}
+
+func strokeRect(img *image.RGBA, r image.Rectangle, c color.Color) {
+	line(img, r.Min.X, r.Min.Y, r.Max.X-1, r.Min.Y, c)
+	line(img, r.Min.X, r.Max.Y-1, r.Max.X-1, r.Max.Y-1, c)
+	line(img, r.Min.X, r.Min.Y, r.Min.X, r.Max.Y-1, c)
+	line(img, r.Max.X-1, r.Min.Y, r.Max.X-1, r.Max.Y-1, c)
+}
+
+// line draws a line from x0, y0 to x1, y1, both included, with Bresenham's algorithm.
+func line(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.Set(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,108 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender_Layout(t *testing.T) {
+	img := Render(Page{
+		Title: "Daily",
+		Panels: []Panel{
+			{Title: "a", X: 0, Y: 0, W: 4, H: 3},
+			{Title: "b", X: 10, Y: 2, W: 6, H: 2},
+		},
+	})
+	assert.Equal(t, 16*ColumnWidth, img.Bounds().Dx())
+	assert.Equal(t, headerHeight+4*RowHeight+margin, img.Bounds().Dy())
+
+	// Below its title, the empty chart leaves the panel white.
+	assert.Equal(t, panelColor, img.RGBAAt(2*ColumnWidth, headerHeight+2*RowHeight))
+	// Nothing is drawn where there is no panel.
+	assert.Equal(t, backgroundColor, img.RGBAAt(6*ColumnWidth, headerHeight+RowHeight))
+}
+
+func TestRender_Chart(t *testing.T) {
+	now := time.Date(2021, 3, 4, 12, 0, 0, 0, time.UTC)
+	var pts []Point
+	for i := 0; i < 10; i++ {
+		pts = append(pts, Point{Time: now.Add(time.Duration(i) * time.Minute), Value: float64(i % 3)})
+	}
+	img := Render(Page{Panels: []Panel{{W: 6, H: 4, Series: []Series{{Name: "cpu", Points: pts}}}}})
+
+	var drawn int
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if img.RGBAAt(x, y) == seriesColors[0] {
+				drawn++
+			}
+		}
+	}
+	assert.Greater(t, drawn, 100, "expected the series to be drawn")
+}
+
+func TestRender_Stat(t *testing.T) {
+	now := time.Now()
+	p := Panel{W: 3, H: 2, Kind: Stat, Series: []Series{{Points: []Point{
+		{Time: now.Add(time.Minute), Value: 42},
+		{Time: now, Value: 7},
+	}}}}
+	withData := Render(Page{Panels: []Panel{p}})
+	p.Series = nil
+	noData := Render(Page{Panels: []Panel{p}})
+	assert.NotEqual(t, withData.Pix, noData.Pix)
+}
+
+func TestWrap(t *testing.T) {
+	assert.Equal(t, []string{"world"}, wrap("world", 10))
+	assert.Equal(t, []string{"HELLO", "the quick", "brown fox", ""}, wrap("# HELLO\nthe **quick** brown fox\n", 10))
+	assert.Equal(t, []string{"abcdef", "gh ij"}, wrap("abcdefgh ij", 6))
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "abc", truncate("abc", 3))
+	assert.Equal(t, "ab..", truncate("abcdef", 4))
+	assert.Equal(t, "", truncate("abc", 0))
+}
+
+func TestPNG(t *testing.T) {
+	img := Render(Page{Title: "t"})
+	b, err := PNG(img)
+	require.NoError(t, err)
+	decoded, err := png.Decode(bytes.NewReader(b))
+	require.NoError(t, err)
+	assert.Equal(t, img.Bounds(), decoded.Bounds())
+}
+
+func TestPDF(t *testing.T) {
+	img := Render(Page{Title: "t"})
+	b, err := PDF(img)
+	require.NoError(t, err)
+
+	require.True(t, bytes.HasPrefix(b, []byte("%PDF-1.4\n")))
+	require.True(t, bytes.HasSuffix(b, []byte("%%EOF\n")))
+	assert.Contains(t, string(b), fmt.Sprintf("/Width %d /Height %d", img.Bounds().Dx(), img.Bounds().Dy()))
+
+	// Every offset of the cross-reference table points at its object.
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(b)
+	require.NotNil(t, m)
+	xref, err := strconv.Atoi(string(m[1]))
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(b[xref:], []byte("xref\n0 6\n")))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(b[xref:], -1)
+	require.Len(t, entries, 5)
+	for i, e := range entries {
+		off, err := strconv.Atoi(string(e[1]))
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(b[off:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))), "object %d", i+1)
+	}
+}
@@ -0,0 +1,389 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/csv"
+	"github.com/influxdata/flux/lang"
+	influxdb "github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/dashboards"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/query"
+	"github.com/influxdata/influxdb/v2/report/render"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultSchedulerInterval is how often the scheduler checks for reports due.
+	DefaultSchedulerInterval = 30 * time.Second
+
+	// windowPoints is the number of windows the time range of a report is divided
+	// into, which sets v.windowPeriod for the queries of its cells.
+	windowPoints = 360
+
+	// deliveryTimeout bounds the delivery of a report to its endpoint.
+	deliveryTimeout = time.Minute
+)
+
+var _ influxdb.ReportRunService = (*Runner)(nil)
+
+// Runner renders reports and delivers them to their notification endpoint,
+// either on demand or when they are due.
+type Runner struct {
+	log *zap.Logger
+
+	reports     *Service
+	dashboards  influxdb.DashboardService
+	queries     query.ProxyQueryService
+	endpoints   influxdb.NotificationEndpointService
+	secrets     influxdb.SecretService
+	permissions dashboards.PermissionService
+
+	// Client is the http client reports are delivered with.
+	Client        *http.Client
+	TimeGenerator influxdb.TimeGenerator
+}
+
+// NewRunner constructs a report runner. The services given are used without
+// any authorization: the cell queries of a report are run with the bucket read
+// permissions its owner currently holds in the report's organization.
+func NewRunner(
+	log *zap.Logger,
+	reports *Service,
+	dashboards influxdb.DashboardService,
+	queries query.ProxyQueryService,
+	endpoints influxdb.NotificationEndpointService,
+	secrets influxdb.SecretService,
+	permissions dashboards.PermissionService,
+) *Runner {
+	return &Runner{
+		log:           log,
+		reports:       reports,
+		dashboards:    dashboards,
+		queries:       queries,
+		endpoints:     endpoints,
+		secrets:       secrets,
+		permissions:   permissions,
+		Client:        &http.Client{Timeout: deliveryTimeout},
+		TimeGenerator: influxdb.RealTimeGenerator{},
+	}
+}
+
+// RenderReport renders the dashboard of a report over the time range ending now.
+func (r *Runner) RenderReport(ctx context.Context, id platform.ID, format string) (*influxdb.RenderedReport, error) {
+	rep, err := r.reports.FindReportByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if format == "" {
+		format = rep.Format
+	}
+	if err := influxdb.ValidReportFormat(format); err != nil {
+		return nil, err
+	}
+	return r.render(ctx, rep, format, r.TimeGenerator.Now())
+}
+
+// RunReport renders a report and delivers it, leaving its schedule as it is.
+func (r *Runner) RunReport(ctx context.Context, id platform.ID) (*influxdb.Report, error) {
+	rep, err := r.reports.FindReportByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return r.run(ctx, rep, time.Time{})
+}
+
+// Run runs the reports which are due every interval until ctx is done.
+func (r *Runner) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.RunDue(ctx)
+		}
+	}
+}
+
+// RunDue runs every active report whose next run is due, and schedules its
+// following run. A failed run is recorded on the report and retried at the
+// following run, rather than right away.
+func (r *Runner) RunDue(ctx context.Context) {
+	reports, _, err := r.reports.FindReports(ctx, influxdb.ReportFilter{}, influxdb.FindOptions{})
+	if err != nil {
+		r.log.Warn("Failed to find reports", zap.Error(err))
+		return
+	}
+
+	now := r.TimeGenerator.Now()
+	for _, rep := range reports {
+		if ctx.Err() != nil {
+			return
+		}
+		if rep.Status != influxdb.Active || rep.NextRunAt.After(now) {
+			continue
+		}
+		if _, err := r.run(ctx, rep, rep.NextRun(now)); err != nil {
+			r.log.Warn("Failed to record report run", zap.Stringer("reportID", rep.ID), zap.Error(err))
+		}
+	}
+}
+
+// run renders and delivers a report, and records the outcome along with the
+// time of its next run, if next is set.
+func (r *Runner) run(ctx context.Context, rep *influxdb.Report, next time.Time) (*influxdb.Report, error) {
+	started := r.TimeGenerator.Now()
+	run := influxdb.ReportRun{
+		StartedAt: started,
+		Status:    influxdb.ReportRunSuccess,
+	}
+
+	doc, err := r.render(ctx, rep, rep.Format, started)
+	if err == nil {
+		err = r.deliver(ctx, rep, doc)
+	}
+	if err != nil {
+		run.Status = influxdb.ReportRunFailed
+		run.Error = err.Error()
+		r.log.Info("Report run failed", zap.Stringer("reportID", rep.ID), zap.Error(err))
+	} else {
+		r.log.Debug("Report delivered", zap.Stringer("reportID", rep.ID), zap.Int("bytes", len(doc.Data)))
+	}
+	return r.reports.RecordReportRun(ctx, rep.ID, run, next)
+}
+
+func (r *Runner) render(ctx context.Context, rep *influxdb.Report, format string, now time.Time) (*influxdb.RenderedReport, error) {
+	page, err := r.page(ctx, rep, now)
+	if err != nil {
+		return nil, err
+	}
+
+	img := render.Render(page)
+	var data []byte
+	if format == influxdb.ReportFormatPDF {
+		data, err = render.PDF(img)
+	} else {
+		data, err = render.PNG(img)
+	}
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.EInternal,
+			Msg:  "failed to encode report",
+			Err:  err,
+		}
+	}
+	return &influxdb.RenderedReport{
+		ContentType: influxdb.ReportContentType(format),
+		Data:        data,
+	}, nil
+}
+
+// page queries the data of each cell of the report's dashboard. A cell whose
+// queries fail is rendered with the error, so one broken cell does not keep the
+// rest of the dashboard from being reported.
+func (r *Runner) page(ctx context.Context, rep *influxdb.Report, now time.Time) (render.Page, error) {
+	d, err := r.dashboards.FindDashboardByID(ctx, rep.DashboardID)
+	if err != nil {
+		return render.Page{}, err
+	}
+	if d.OrganizationID != rep.OrganizationID {
+		return render.Page{}, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "report dashboard belongs to a different organization than the report",
+		}
+	}
+	// A report is never run once its owner can no longer read the data it reports.
+	auth, err := dashboards.ReadAuthorization(ctx, r.permissions, rep.ID, rep.OrganizationID, rep.OwnerID)
+	if err != nil {
+		return render.Page{}, err
+	}
+
+	stop := now
+	start := stop.Add(-rep.Range.Duration)
+	page := render.Page{
+		Title:    d.Name,
+		Subtitle: fmt.Sprintf("%s - %s UTC", start.UTC().Format("2006-01-02 15:04"), stop.UTC().Format("2006-01-02 15:04")),
+	}
+
+	cells := append([]*influxdb.Cell(nil), d.Cells...)
+	sort.SliceStable(cells, func(i, j int) bool {
+		if cells[i].Y != cells[j].Y {
+			return cells[i].Y < cells[j].Y
+		}
+		return cells[i].X < cells[j].X
+	})
+	for _, c := range cells {
+		p := render.Panel{X: c.X, Y: c.Y, W: c.W, H: c.H}
+		view, err := r.dashboards.GetDashboardCellView(ctx, d.ID, c.ID)
+		if err != nil {
+			p.Err = err.Error()
+			page.Panels = append(page.Panels, p)
+			continue
+		}
+		p.Title = view.Name
+
+		switch props := view.Properties.(type) {
+		case influxdb.MarkdownViewProperties:
+			p.Kind, p.Text = render.Text, props.Note
+			page.Panels = append(page.Panels, p)
+			continue
+		case influxdb.SingleStatViewProperties:
+			p.Kind, p.Prefix, p.Suffix = render.Stat, props.Prefix, props.Suffix
+		case influxdb.GaugeViewProperties:
+			p.Kind, p.Prefix, p.Suffix = render.Stat, props.Prefix, props.Suffix
+		}
+
+		queries, err := dashboards.ViewQueries(view)
+		if err == nil {
+			p.Series, err = r.querySeries(ctx, auth, queries, start, stop)
+		}
+		if err != nil {
+			p.Err = err.Error()
+		}
+		page.Panels = append(page.Panels, p)
+	}
+	return page, nil
+}
+
+// querySeries runs queries over the time range and returns the tables of their results.
+func (r *Runner) querySeries(ctx context.Context, auth *influxdb.Authorization, queries []string, start, stop time.Time) ([]render.Series, error) {
+	extern, err := dashboards.QueryExtern(start, stop, stop.Sub(start)/windowPoints)
+	if err != nil {
+		return nil, err
+	}
+	ctx = icontext.SetAuthorizer(ctx, auth)
+
+	var series []render.Series
+	for _, text := range queries {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		var buf bytes.Buffer
+		pr := &query.ProxyRequest{
+			Request: query.Request{
+				Authorization:  auth,
+				OrganizationID: auth.OrgID,
+				Compiler: lang.FluxCompiler{
+					Now:    stop,
+					Extern: extern,
+					Query:  text,
+				},
+				Source: "reports",
+			},
+			Dialect: &csv.Dialect{ResultEncoderConfig: csv.DefaultEncoderConfig()},
+		}
+		if _, err := r.queries.Query(ctx, &buf, pr); err != nil {
+			return nil, err
+		}
+		s, err := decodeSeries(&buf)
+		if err != nil {
+			return nil, err
+		}
+		series = append(series, s...)
+	}
+	return series, nil
+}
+
+// decodeSeries decodes annotated CSV query results. Each table with a _time and
+// a numeric _value column becomes a series named after its group key; other
+// tables are skipped.
+func decodeSeries(r io.Reader) ([]render.Series, error) {
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, r); err != nil {
+		return nil, err
+	}
+	if buf.Len() == 0 {
+		return nil, nil
+	}
+
+	results, err := csv.NewMultiResultDecoder(csv.ResultDecoderConfig{}).Decode(io.NopCloser(&buf))
+	if err != nil {
+		return nil, err
+	}
+	defer results.Release()
+
+	var series []render.Series
+	for results.More() {
+		err := results.Next().Tables().Do(func(tbl flux.Table) error {
+			s := render.Series{Name: seriesName(tbl.Key())}
+			err := tbl.Do(func(cr flux.ColReader) error {
+				timeCol, valueCol := -1, -1
+				for j, c := range cr.Cols() {
+					switch {
+					case c.Label == "_time" && c.Type == flux.TTime:
+						timeCol = j
+					case c.Label == "_value":
+						valueCol = j
+					}
+				}
+				if timeCol < 0 || valueCol < 0 {
+					return nil
+				}
+
+				times := cr.Times(timeCol)
+				for i := 0; i < cr.Len(); i++ {
+					if times.IsNull(i) {
+						continue
+					}
+					v, ok := numericValue(cr, valueCol, i)
+					if !ok {
+						continue
+					}
+					s.Points = append(s.Points, render.Point{Time: time.Unix(0, times.Value(i)), Value: v})
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if len(s.Points) > 0 {
+				series = append(series, s)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return series, results.Err()
+}
+
+func numericValue(cr flux.ColReader, j, i int) (float64, bool) {
+	switch cr.Cols()[j].Type {
+	case flux.TFloat:
+		vs := cr.Floats(j)
+		return vs.Value(i), vs.IsValid(i)
+	case flux.TInt:
+		vs := cr.Ints(j)
+		return float64(vs.Value(i)), vs.IsValid(i)
+	case flux.TUInt:
+		vs := cr.UInts(j)
+		return float64(vs.Value(i)), vs.IsValid(i)
+	}
+	return 0, false
+}
+
+// seriesName joins the string values of a group key, leaving out the bounds
+// every table of a query shares.
+func seriesName(key flux.GroupKey) string {
+	var parts []string
+	for j, c := range key.Cols() {
+		if c.Type != flux.TString || c.Label == "_start" || c.Label == "_stop" || key.IsNull(j) {
+			continue
+		}
+		parts = append(parts, key.ValueString(j))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,189 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/notification/endpoint"
+	"github.com/influxdata/influxdb/v2/query"
+	querymock "github.com/influxdata/influxdb/v2/query/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+const testCSV = `#datatype,string,long,dateTime:RFC3339,dateTime:RFC3339,dateTime:RFC3339,double,string,string
+#group,false,false,true,true,false,false,true,true
+#default,_result,,,,,,,
+,result,table,_start,_stop,_time,_value,_field,host
+,,0,2021-01-01T00:00:00Z,2021-01-02T00:00:00Z,2021-01-01T10:00:00Z,1.5,usage,a
+,,0,2021-01-01T00:00:00Z,2021-01-02T00:00:00Z,2021-01-01T11:00:00Z,2.5,usage,a
+,,1,2021-01-01T00:00:00Z,2021-01-02T00:00:00Z,2021-01-01T10:00:00Z,3,usage,b
+
+`
+
+func TestDecodeSeries(t *testing.T) {
+	series, err := decodeSeries(strings.NewReader(testCSV))
+	require.NoError(t, err)
+	require.Len(t, series, 2)
+	require.Equal(t, "usage a", series[0].Name)
+	require.Len(t, series[0].Points, 2)
+	require.Equal(t, 2.5, series[0].Points[1].Value)
+	require.Equal(t, time.Date(2021, 1, 1, 11, 0, 0, 0, time.UTC), series[0].Points[1].Time.UTC())
+	require.Equal(t, "usage b", series[1].Name)
+
+	series, err = decodeSeries(strings.NewReader(""))
+	require.NoError(t, err)
+	require.Empty(t, series)
+}
+
+func TestRunner_RunDue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2021, 1, 2, 0, 0, 30, 0, time.UTC)
+	svc := newTestService(t, now.Add(-time.Hour))
+
+	var delivered struct {
+		contentType, auth string
+		body              []byte
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered.contentType = r.Header.Get("Content-Type")
+		delivered.auth = r.Header.Get("Authorization")
+		delivered.body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	dashboards := mock.NewDashboardService()
+	dashboards.FindDashboardByIDF = func(ctx context.Context, id platform.ID) (*influxdb.Dashboard, error) {
+		return &influxdb.Dashboard{
+			ID:             id,
+			OrganizationID: 1,
+			Name:           "ops",
+			Cells: []*influxdb.Cell{
+				{ID: 100, CellProperty: influxdb.CellProperty{W: 6, H: 4}},
+				{ID: 101, CellProperty: influxdb.CellProperty{X: 6, W: 6, H: 4}},
+			},
+		}, nil
+	}
+	dashboards.GetDashboardCellViewF = func(ctx context.Context, dashboardID, cellID platform.ID) (*influxdb.View, error) {
+		if cellID == 101 {
+			return &influxdb.View{
+				ViewContents: influxdb.ViewContents{Name: "notes"},
+				Properties:   influxdb.MarkdownViewProperties{Type: influxdb.ViewPropertyTypeMarkdown, Note: "hello"},
+			}, nil
+		}
+		return &influxdb.View{
+			ViewContents: influxdb.ViewContents{Name: "cpu"},
+			Properties: influxdb.XYViewProperties{
+				Type:    influxdb.ViewPropertyTypeXY,
+				Queries: []influxdb.DashboardQuery{{Text: `from(bucket: "b") |> range(start: v.timeRangeStart)`}},
+			},
+		}, nil
+	}
+
+	var queries []*query.ProxyRequest
+	queryService := &querymock.ProxyQueryService{
+		QueryF: func(ctx context.Context, w io.Writer, req *query.ProxyRequest) (flux.Statistics, error) {
+			queries = append(queries, req)
+			_, err := io.WriteString(w, testCSV)
+			return flux.Statistics{}, err
+		},
+	}
+
+	endpoints := mock.NewNotificationEndpointService()
+	endpoints.FindNotificationEndpointByIDF = func(ctx context.Context, id platform.ID) (influxdb.NotificationEndpoint, error) {
+		return &endpoint.HTTP{
+			Base: endpoint.Base{
+				ID:     &id,
+				OrgID:  idPtr(1),
+				Name:   "mail relay",
+				Status: influxdb.Active,
+			},
+			URL:        srv.URL,
+			Method:     http.MethodPost,
+			AuthMethod: "bearer",
+			Token:      influxdb.SecretField{Key: "token-key"},
+		}, nil
+	}
+	secrets := mock.NewSecretService()
+	secrets.LoadSecretFn = func(ctx context.Context, orgID platform.ID, k string) (string, error) {
+		return "secret-" + k, nil
+	}
+
+	ownerID := platform.ID(5)
+	ownerPerms := influxdb.MemberPermissions(1)
+	users := mock.NewUserService()
+	users.FindPermissionForUserFn = func(ctx context.Context, id platform.ID) (influxdb.PermissionSet, error) {
+		require.Equal(t, ownerID, id)
+		return ownerPerms, nil
+	}
+
+	runner := NewRunner(zaptest.NewLogger(t), svc, dashboards, queryService, endpoints, secrets, users)
+	runner.TimeGenerator = mock.TimeGenerator{FakeValue: now}
+
+	ctx = icontext.SetAuthorizer(ctx, &influxdb.Authorization{UserID: ownerID})
+	due := &influxdb.Report{OrganizationID: 1, DashboardID: 10, EndpointID: 20, Name: "due", Every: influxdb.Duration{Duration: 24 * time.Hour}}
+	require.NoError(t, svc.CreateReport(ctx, due))
+	later := &influxdb.Report{OrganizationID: 1, DashboardID: 10, EndpointID: 20, Name: "later", Every: influxdb.Duration{Duration: 48 * time.Hour}}
+	require.NoError(t, svc.CreateReport(ctx, later))
+
+	runner.RunDue(ctx)
+
+	require.Len(t, queries, 1, "only the due report's graph cell is queried")
+	require.Equal(t, platform.ID(1), queries[0].Request.OrganizationID)
+	require.Equal(t, ownerID, queries[0].Request.Authorization.UserID)
+	require.Equal(t, []influxdb.Permission{{
+		Action:   influxdb.ReadAction,
+		Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, OrgID: idPtr(1)},
+	}}, queries[0].Request.Authorization.Permissions)
+	require.Equal(t, "image/png", delivered.contentType)
+	require.Equal(t, "Bearer secret-token-key", delivered.auth)
+	require.True(t, bytes.HasPrefix(delivered.body, []byte("\x89PNG")))
+
+	ran, err := svc.FindReportByID(ctx, due.ID)
+	require.NoError(t, err)
+	require.NotNil(t, ran.LatestRun)
+	require.Equal(t, influxdb.ReportRunSuccess, ran.LatestRun.Status)
+	require.Equal(t, time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC), ran.NextRunAt)
+
+	notRun, err := svc.FindReportByID(ctx, later.ID)
+	require.NoError(t, err)
+	require.Nil(t, notRun.LatestRun)
+
+	// Failed deliveries are recorded on the report.
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	failed, err := runner.RunReport(ctx, due.ID)
+	require.NoError(t, err)
+	require.Equal(t, influxdb.ReportRunFailed, failed.LatestRun.Status)
+	require.Contains(t, failed.LatestRun.Error, "502")
+	require.Equal(t, ran.NextRunAt, failed.NextRunAt, "running a report on demand leaves its schedule as it is")
+
+	// Reports of owners who lost access to the data are not delivered.
+	ownerPerms = influxdb.MePermissions(ownerID)
+	delivered.body = nil
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered.body, _ = io.ReadAll(r.Body)
+	})
+	failed, err = runner.RunReport(ctx, due.ID)
+	require.NoError(t, err)
+	require.Equal(t, influxdb.ReportRunFailed, failed.LatestRun.Status)
+	require.Contains(t, failed.LatestRun.Error, "can no longer read")
+	require.Nil(t, delivered.body)
+	require.Len(t, queries, 2)
+}
+
+func idPtr(id platform.ID) *platform.ID {
+	return &id
+}
@@ -0,0 +1,344 @@
+// Package report renders dashboards on a schedule and delivers them to
+// notification endpoints.
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	influxdb "github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/snowflake"
+)
+
+var (
+	reportBucket   = []byte("reportsv1")
+	orgReportIndex = []byte("orgsreportsv1")
+)
+
+var _ influxdb.ReportService = (*Service)(nil)
+
+// Service is a kv backed report service.
+type Service struct {
+	kv kv.Store
+
+	IDGenerator   platform.IDGenerator
+	TimeGenerator influxdb.TimeGenerator
+}
+
+// NewService constructs and configures a new report service.
+func NewService(store kv.Store) *Service {
+	return &Service{
+		kv:            store,
+		IDGenerator:   snowflake.NewIDGenerator(),
+		TimeGenerator: influxdb.RealTimeGenerator{},
+	}
+}
+
+// FindReportByID returns a single report by ID.
+func (s *Service) FindReportByID(ctx context.Context, id platform.ID) (*influxdb.Report, error) {
+	var r *influxdb.Report
+	err := s.kv.View(ctx, func(tx kv.Tx) error {
+		rr, err := findReportByID(tx, id)
+		if err != nil {
+			return err
+		}
+		r = rr
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+		}
+	}
+	return r, nil
+}
+
+// FindReports returns the reports matching filter, sorted by ID.
+func (s *Service) FindReports(ctx context.Context, filter influxdb.ReportFilter, opts influxdb.FindOptions) ([]*influxdb.Report, int, error) {
+	var rs []*influxdb.Report
+	err := s.kv.View(ctx, func(tx kv.Tx) error {
+		ids, err := reportIDs(tx, filter)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			r, err := findReportByID(tx, id)
+			if err != nil {
+				return err
+			}
+			if filter.DashboardID != nil && r.DashboardID != *filter.DashboardID {
+				continue
+			}
+			rs = append(rs, r)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, &errors.Error{
+			Err: err,
+		}
+	}
+
+	total := len(rs)
+	if opts.Descending {
+		for i, j := 0, len(rs)-1; i < j; i, j = i+1, j-1 {
+			rs[i], rs[j] = rs[j], rs[i]
+		}
+	}
+	if opts.Offset > 0 {
+		if opts.Offset >= len(rs) {
+			rs = nil
+		} else {
+			rs = rs[opts.Offset:]
+		}
+	}
+	if opts.Limit > 0 && opts.Limit < len(rs) {
+		rs = rs[:opts.Limit]
+	}
+	return rs, total, nil
+}
+
+// CreateReport creates a new report and sets r.ID with the new identifier. The
+// format, status and range of the report are defaulted when they are not set.
+func (s *Service) CreateReport(ctx context.Context, r *influxdb.Report) error {
+	if r.Format == "" {
+		r.Format = influxdb.ReportFormatPNG
+	}
+	if r.Status == "" {
+		r.Status = influxdb.Active
+	}
+	if r.Range.Duration == 0 {
+		r.Range = influxdb.Duration{Duration: influxdb.DefaultReportRange}
+	}
+	if err := r.Valid(); err != nil {
+		return err
+	}
+
+	err := s.kv.Update(ctx, func(tx kv.Tx) error {
+		now := s.TimeGenerator.Now()
+		r.ID = s.IDGenerator.ID()
+		r.SetCreatedAt(now)
+		r.SetUpdatedAt(now)
+		r.NextRunAt = r.NextRun(now)
+		r.LatestRun = nil
+		// The queries of the report are run on behalf of the user creating it.
+		if a, err := icontext.GetAuthorizer(ctx); err == nil {
+			r.OwnerID = a.GetUserID()
+		}
+		if err := putReport(tx, r); err != nil {
+			return err
+		}
+		k, err := orgReportKey(r)
+		if err != nil {
+			return err
+		}
+		idx, err := tx.Bucket(orgReportIndex)
+		if err != nil {
+			return err
+		}
+		return idx.Put(k, nil)
+	})
+	if err != nil {
+		return &errors.Error{
+			Err: err,
+		}
+	}
+	return nil
+}
+
+// UpdateReport updates a report. The next run of the report is rescheduled when
+// its interval changes or it is activated.
+func (s *Service) UpdateReport(ctx context.Context, id platform.ID, upd influxdb.ReportUpdate) (*influxdb.Report, error) {
+	var r *influxdb.Report
+	err := s.kv.Update(ctx, func(tx kv.Tx) error {
+		rr, err := findReportByID(tx, id)
+		if err != nil {
+			return err
+		}
+		every, status := rr.Every, rr.Status
+		if err := upd.Apply(rr); err != nil {
+			return err
+		}
+
+		now := s.TimeGenerator.Now()
+		if rr.Every != every || (rr.Status == influxdb.Active && status != influxdb.Active) {
+			rr.NextRunAt = rr.NextRun(now)
+		}
+		rr.SetUpdatedAt(now)
+		if err := putReport(tx, rr); err != nil {
+			return err
+		}
+		r = rr
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+		}
+	}
+	return r, nil
+}
+
+// DeleteReport removes a report by ID.
+func (s *Service) DeleteReport(ctx context.Context, id platform.ID) error {
+	err := s.kv.Update(ctx, func(tx kv.Tx) error {
+		r, err := findReportByID(tx, id)
+		if err != nil {
+			return err
+		}
+
+		k, err := orgReportKey(r)
+		if err != nil {
+			return err
+		}
+		idx, err := tx.Bucket(orgReportIndex)
+		if err != nil {
+			return err
+		}
+		if err := idx.Delete(k); err != nil {
+			return err
+		}
+
+		encodedID, err := id.Encode()
+		if err != nil {
+			return err
+		}
+		b, err := tx.Bucket(reportBucket)
+		if err != nil {
+			return err
+		}
+		return b.Delete(encodedID)
+	})
+	if err != nil {
+		return &errors.Error{
+			Err: err,
+		}
+	}
+	return nil
+}
+
+// RecordReportRun sets the latest run of a report and the time of its next run.
+func (s *Service) RecordReportRun(ctx context.Context, id platform.ID, run influxdb.ReportRun, next time.Time) (*influxdb.Report, error) {
+	var r *influxdb.Report
+	err := s.kv.Update(ctx, func(tx kv.Tx) error {
+		rr, err := findReportByID(tx, id)
+		if err != nil {
+			return err
+		}
+		rr.LatestRun = &run
+		if !next.IsZero() {
+			rr.NextRunAt = next
+		}
+		if err := putReport(tx, rr); err != nil {
+			return err
+		}
+		r = rr
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+		}
+	}
+	return r, nil
+}
+
+func findReportByID(tx kv.Tx, id platform.ID) (*influxdb.Report, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Err:  err,
+		}
+	}
+	b, err := tx.Bucket(reportBucket)
+	if err != nil {
+		return nil, err
+	}
+	v, err := b.Get(encodedID)
+	if kv.IsNotFound(err) {
+		return nil, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  influxdb.ErrReportNotFound,
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r := &influxdb.Report{}
+	if err := json.Unmarshal(v, r); err != nil {
+		return nil, errors.NewError(errors.WithErrorErr(err))
+	}
+	return r, nil
+}
+
+func putReport(tx kv.Tx, r *influxdb.Report) error {
+	v, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	encodedID, err := r.ID.Encode()
+	if err != nil {
+		return err
+	}
+	b, err := tx.Bucket(reportBucket)
+	if err != nil {
+		return err
+	}
+	return b.Put(encodedID, v)
+}
+
+func orgReportKey(r *influxdb.Report) ([]byte, error) {
+	orgID, err := r.OrganizationID.Encode()
+	if err != nil {
+		return nil, err
+	}
+	id, err := r.ID.Encode()
+	if err != nil {
+		return nil, err
+	}
+	return append(orgID, id...), nil
+}
+
+// reportIDs returns the IDs of the reports of the filter's organization, or of
+// every report, sorted by ID.
+func reportIDs(tx kv.Tx, filter influxdb.ReportFilter) ([]platform.ID, error) {
+	bucket, prefix := reportBucket, []byte(nil)
+	if filter.OrganizationID != nil {
+		p, err := filter.OrganizationID.Encode()
+		if err != nil {
+			return nil, err
+		}
+		bucket, prefix = orgReportIndex, p
+	}
+
+	b, err := tx.Bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+	var opts []kv.CursorOption
+	if prefix != nil {
+		opts = append(opts, kv.WithCursorPrefix(prefix))
+	}
+	cur, err := b.ForwardCursor(prefix, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close()
+
+	var ids []platform.ID
+	for k, _ := cur.Next(); k != nil; k, _ = cur.Next() {
+		var id platform.ID
+		if err := id.Decode(k[len(prefix):]); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, cur.Err()
+}
@@ -0,0 +1,115 @@
+package report
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mock"
+	itesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(t *testing.T, now time.Time) *Service {
+	s, closeBolt := itesting.NewTestBoltStore(t)
+	t.Cleanup(closeBolt)
+	svc := NewService(s)
+	svc.TimeGenerator = mock.TimeGenerator{FakeValue: now}
+	return svc
+}
+
+func TestService_Reports(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2021, 1, 1, 10, 20, 0, 0, time.UTC)
+	svc := newTestService(t, now)
+
+	r := &influxdb.Report{
+		OrganizationID: 1,
+		DashboardID:    10,
+		EndpointID:     20,
+		Name:           "daily",
+		Every:          influxdb.Duration{Duration: time.Hour},
+	}
+	require.NoError(t, svc.CreateReport(ctx, r))
+	require.True(t, r.ID.Valid())
+	require.Equal(t, influxdb.ReportFormatPNG, r.Format)
+	require.Equal(t, influxdb.Active, r.Status)
+	require.Equal(t, influxdb.DefaultReportRange, r.Range.Duration)
+	require.Equal(t, time.Date(2021, 1, 1, 11, 0, 0, 0, time.UTC), r.NextRunAt)
+
+	other := &influxdb.Report{
+		OrganizationID: 2,
+		DashboardID:    11,
+		EndpointID:     21,
+		Name:           "weekly",
+		Format:         influxdb.ReportFormatPDF,
+		Every:          influxdb.Duration{Duration: 7 * 24 * time.Hour},
+	}
+	require.NoError(t, svc.CreateReport(ctx, other))
+
+	found, err := svc.FindReportByID(ctx, r.ID)
+	require.NoError(t, err)
+	require.Equal(t, r, found)
+
+	orgID := platform.ID(1)
+	rs, n, err := svc.FindReports(ctx, influxdb.ReportFilter{OrganizationID: &orgID}, influxdb.FindOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, r.ID, rs[0].ID)
+
+	dashboardID := platform.ID(11)
+	rs, _, err = svc.FindReports(ctx, influxdb.ReportFilter{DashboardID: &dashboardID}, influxdb.FindOptions{})
+	require.NoError(t, err)
+	require.Len(t, rs, 1)
+	require.Equal(t, other.ID, rs[0].ID)
+
+	rs, n, err = svc.FindReports(ctx, influxdb.ReportFilter{}, influxdb.FindOptions{Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, []*influxdb.Report{other}, rs)
+
+	// Updating the interval reschedules the report.
+	every := influxdb.Duration{Duration: 30 * time.Minute}
+	name := "half hourly"
+	updated, err := svc.UpdateReport(ctx, r.ID, influxdb.ReportUpdate{Name: &name, Every: &every})
+	require.NoError(t, err)
+	require.Equal(t, name, updated.Name)
+	require.Equal(t, time.Date(2021, 1, 1, 10, 30, 0, 0, time.UTC), updated.NextRunAt)
+
+	format := "gif"
+	_, err = svc.UpdateReport(ctx, r.ID, influxdb.ReportUpdate{Format: &format})
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+
+	next := time.Date(2021, 1, 1, 11, 0, 0, 0, time.UTC)
+	run := influxdb.ReportRun{StartedAt: now, Status: influxdb.ReportRunFailed, Error: "boom"}
+	recorded, err := svc.RecordReportRun(ctx, r.ID, run, next)
+	require.NoError(t, err)
+	require.Equal(t, &run, recorded.LatestRun)
+	require.Equal(t, next, recorded.NextRunAt)
+
+	require.NoError(t, svc.DeleteReport(ctx, r.ID))
+	_, err = svc.FindReportByID(ctx, r.ID)
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+	rs, _, err = svc.FindReports(ctx, influxdb.ReportFilter{OrganizationID: &orgID}, influxdb.FindOptions{})
+	require.NoError(t, err)
+	require.Empty(t, rs)
+}
+
+func TestService_CreateReport_Invalid(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t, time.Now())
+
+	for _, r := range []*influxdb.Report{
+		{DashboardID: 10, EndpointID: 20, Name: "no org", Every: influxdb.Duration{Duration: time.Hour}},
+		{OrganizationID: 1, EndpointID: 20, Name: "no dashboard", Every: influxdb.Duration{Duration: time.Hour}},
+		{OrganizationID: 1, DashboardID: 10, EndpointID: 20, Every: influxdb.Duration{Duration: time.Hour}},
+		{OrganizationID: 1, DashboardID: 10, EndpointID: 20, Name: "too often", Every: influxdb.Duration{Duration: time.Second}},
+		{OrganizationID: 1, DashboardID: 10, Name: "no endpoint", Every: influxdb.Duration{Duration: time.Hour}},
+	} {
+		err := svc.CreateReport(ctx, r)
+		require.Equal(t, errors.EInvalid, errors.ErrorCode(err), r.Name)
+	}
+}
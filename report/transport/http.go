@@ -0,0 +1,277 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const prefixReports = "/api/v2/reports"
+
+// ReportHandler is the handler for the report service.
+type ReportHandler struct {
+	chi.Router
+
+	api *kithttp.API
+	log *zap.Logger
+
+	reportService influxdb.ReportService
+	runService    influxdb.ReportRunService
+}
+
+// NewReportHandler returns a new instance of ReportHandler.
+func NewReportHandler(log *zap.Logger, reportService influxdb.ReportService, runService influxdb.ReportRunService) *ReportHandler {
+	h := &ReportHandler{
+		log:           log,
+		api:           kithttp.NewAPI(kithttp.WithLog(log)),
+		reportService: reportService,
+		runService:    runService,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+	r.Route("/", func(r chi.Router) {
+		r.Get("/", h.handleGetReports)
+		r.Post("/", h.handlePostReport)
+
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", h.handleGetReport)
+			r.Patch("/", h.handlePatchReport)
+			r.Delete("/", h.handleDeleteReport)
+			r.Post("/run", h.handlePostReportRun)
+			r.Get("/render", h.handleGetReportRender)
+		})
+	})
+	h.Router = r
+	return h
+}
+
+// Prefix returns the mounting prefix for the handler.
+func (h *ReportHandler) Prefix() string {
+	return prefixReports
+}
+
+type reportResponse struct {
+	*influxdb.Report
+	Links map[string]string `json:"links"`
+}
+
+func newReportResponse(r *influxdb.Report) reportResponse {
+	return reportResponse{
+		Report: r,
+		Links: map[string]string{
+			"self":      fmt.Sprintf("%s/%s", prefixReports, r.ID),
+			"run":       fmt.Sprintf("%s/%s/run", prefixReports, r.ID),
+			"render":    fmt.Sprintf("%s/%s/render", prefixReports, r.ID),
+			"dashboard": fmt.Sprintf("/api/v2/dashboards/%s", r.DashboardID),
+			"endpoint":  fmt.Sprintf("/api/v2/notificationEndpoints/%s", r.EndpointID),
+			"org":       fmt.Sprintf("/api/v2/orgs/%s", r.OrganizationID),
+		},
+	}
+}
+
+type reportsResponse struct {
+	Reports []reportResponse  `json:"reports"`
+	Total   int               `json:"total"`
+	Links   map[string]string `json:"links"`
+}
+
+func decodeReportID(r *http.Request) (platform.ID, error) {
+	var id platform.ID
+	if err := id.DecodeFromString(chi.URLParam(r, "id")); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// handleGetReports lists the reports of an organization or a dashboard.
+func (h *ReportHandler) handleGetReports(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	opts, err := influxdb.DecodeFindOptions(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var filter influxdb.ReportFilter
+	q := r.URL.Query()
+	if orgID := q.Get("orgID"); orgID != "" {
+		id, err := platform.IDFromString(orgID)
+		if err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+		filter.OrganizationID = id
+	}
+	if dashboardID := q.Get("dashboardID"); dashboardID != "" {
+		id, err := platform.IDFromString(dashboardID)
+		if err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+		filter.DashboardID = id
+	}
+
+	rs, total, err := h.reportService.FindReports(ctx, filter, *opts)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Reports retrieved", zap.Int("count", len(rs)))
+
+	res := reportsResponse{
+		Reports: make([]reportResponse, 0, len(rs)),
+		Total:   total,
+		Links: map[string]string{
+			"self": prefixReports,
+		},
+	}
+	for _, rep := range rs {
+		res.Reports = append(res.Reports, newReportResponse(rep))
+	}
+	h.api.Respond(w, r, http.StatusOK, res)
+}
+
+// handlePostReport creates a report.
+func (h *ReportHandler) handlePostReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var rep influxdb.Report
+	if err := h.api.DecodeJSON(r.Body, &rep); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.reportService.CreateReport(ctx, &rep); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Report created", zap.String("report", fmt.Sprint(rep)))
+
+	h.api.Respond(w, r, http.StatusCreated, newReportResponse(&rep))
+}
+
+// handleGetReport retrieves a report by ID.
+func (h *ReportHandler) handleGetReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := decodeReportID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	rep, err := h.reportService.FindReportByID(ctx, id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Report retrieved", zap.String("report", fmt.Sprint(rep)))
+
+	h.api.Respond(w, r, http.StatusOK, newReportResponse(rep))
+}
+
+// handlePatchReport updates a report.
+func (h *ReportHandler) handlePatchReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := decodeReportID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var upd influxdb.ReportUpdate
+	if err := h.api.DecodeJSON(r.Body, &upd); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	rep, err := h.reportService.UpdateReport(ctx, id, upd)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Report updated", zap.String("report", fmt.Sprint(rep)))
+
+	h.api.Respond(w, r, http.StatusOK, newReportResponse(rep))
+}
+
+// handleDeleteReport deletes a report.
+func (h *ReportHandler) handleDeleteReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := decodeReportID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.reportService.DeleteReport(ctx, id); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Report deleted", zap.String("reportID", id.String()))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlePostReportRun renders a report and delivers it right away. The outcome
+// of the run is recorded on the report, which is responded with.
+func (h *ReportHandler) handlePostReportRun(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := decodeReportID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	rep, err := h.runService.RunReport(ctx, id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Report run", zap.String("reportID", id.String()))
+
+	h.api.Respond(w, r, http.StatusOK, newReportResponse(rep))
+}
+
+// handleGetReportRender responds with the report rendered as of now, to preview
+// it without delivering it.
+func (h *ReportHandler) handleGetReportRender(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := decodeReportID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" {
+		if err := influxdb.ValidReportFormat(format); err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+	}
+
+	doc, err := h.runService.RenderReport(ctx, id, format)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Report rendered", zap.String("reportID", id.String()), zap.Int("bytes", len(doc.Data)))
+
+	w.Header().Set("Content-Type", doc.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(doc.Data)))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(doc.Data); err != nil {
+		h.log.Info("Error writing rendered report to client", zap.String("reportID", id.String()), zap.Error(err))
+	}
+}
@@ -0,0 +1,94 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type fakeReportService struct {
+	influxdb.ReportService
+	reports map[platform.ID]*influxdb.Report
+}
+
+func (s *fakeReportService) FindReportByID(ctx context.Context, id platform.ID) (*influxdb.Report, error) {
+	r, ok := s.reports[id]
+	if !ok {
+		return nil, &errors.Error{Code: errors.ENotFound, Msg: influxdb.ErrReportNotFound}
+	}
+	return r, nil
+}
+
+func (s *fakeReportService) CreateReport(ctx context.Context, r *influxdb.Report) error {
+	r.ID = platform.ID(len(s.reports) + 1)
+	s.reports[r.ID] = r
+	return nil
+}
+
+type fakeReportRunService struct {
+	format string
+}
+
+func (s *fakeReportRunService) RenderReport(ctx context.Context, id platform.ID, format string) (*influxdb.RenderedReport, error) {
+	s.format = format
+	return &influxdb.RenderedReport{ContentType: influxdb.ReportContentType(format), Data: []byte("doc")}, nil
+}
+
+func (s *fakeReportRunService) RunReport(ctx context.Context, id platform.ID) (*influxdb.Report, error) {
+	return nil, &errors.Error{Code: errors.ENotFound, Msg: influxdb.ErrReportNotFound}
+}
+
+func TestReportHandler(t *testing.T) {
+	reports := &fakeReportService{reports: map[platform.ID]*influxdb.Report{}}
+	runs := &fakeReportRunService{}
+	h := NewReportHandler(zaptest.NewLogger(t), reports, runs)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	body := `{"orgID":"0000000000000001","dashboardID":"0000000000000002","endpointID":"0000000000000003","name":"daily","every":"24h"}`
+	resp, err := http.Post(srv.URL+"/", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created struct {
+		ID    platform.ID       `json:"id"`
+		Every influxdb.Duration `json:"every"`
+		Links map[string]string `json:"links"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	require.Equal(t, 24*time.Hour, created.Every.Duration)
+	require.Equal(t, "/api/v2/reports/0000000000000001/render", created.Links["render"])
+	require.Equal(t, platform.ID(2), reports.reports[created.ID].DashboardID)
+
+	resp, err = http.Get(srv.URL + "/" + created.ID.String() + "/render?format=pdf")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/pdf", resp.Header.Get("Content-Type"))
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "doc", string(b))
+	require.Equal(t, influxdb.ReportFormatPDF, runs.format)
+
+	resp, err = http.Get(srv.URL + "/" + created.ID.String() + "/render?format=gif")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Post(srv.URL+"/"+created.ID.String()+"/run", "application/json", nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,187 @@
+package cloud
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/pkg/sigv4"
+)
+
+const (
+	awsTargetPrefix  = "secretsmanager."
+	awsContentType   = "application/x-amz-json-1.1"
+	awsNotFoundError = "ResourceNotFoundException"
+
+	// defaultRequestTimeout bounds the time a request to a secret manager takes.
+	defaultRequestTimeout = 30 * time.Second
+
+	// maxResponseSize is the largest response of a secret manager which is read.
+	maxResponseSize = 1 << 20
+)
+
+// AWSConfig configures the AWS Secrets Manager secret service.
+type AWSConfig struct {
+	Region string
+	// Prefix prefixes the names of the secrets, it defaults to DefaultPrefix.
+	Prefix string
+	// Endpoint overrides the URL of the Secrets Manager API of the region.
+	Endpoint string
+	// Credentials default to the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY
+	// and AWS_SESSION_TOKEN environment variables.
+	Credentials sigv4.Credentials
+	// CacheTTL defaults to DefaultCacheTTL.
+	CacheTTL time.Duration
+}
+
+// NewAWSSecretService returns a secret service storing the secrets of each
+// organization in an AWS Secrets Manager secret.
+func NewAWSSecretService(cfg AWSConfig) (*SecretService, error) {
+	if cfg.Region == "" {
+		cfg.Region = os.Getenv("AWS_REGION")
+	}
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("aws secrets manager requires a region")
+	}
+	if cfg.Credentials.AccessKeyID == "" {
+		cfg.Credentials = sigv4.Credentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}
+	}
+	if cfg.Credentials.AccessKeyID == "" || cfg.Credentials.SecretAccessKey == "" {
+		return nil, fmt.Errorf("aws secrets manager requires an access key id and a secret access key")
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = DefaultPrefix
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com/", cfg.Region)
+	}
+
+	docs := &awsDocuments{
+		cfg:           cfg,
+		client:        &http.Client{Timeout: defaultRequestTimeout},
+		timeGenerator: influxdb.RealTimeGenerator{},
+	}
+	return newSecretService(docs, cfg.CacheTTL), nil
+}
+
+type awsDocuments struct {
+	cfg           AWSConfig
+	client        *http.Client
+	timeGenerator influxdb.TimeGenerator
+}
+
+func (d *awsDocuments) name(orgID platform.ID) string {
+	return d.cfg.Prefix + orgID.String()
+}
+
+func (d *awsDocuments) load(ctx context.Context, orgID platform.ID) (map[string]string, error) {
+	var resp struct {
+		SecretString string `json:"SecretString"`
+	}
+	err := d.do(ctx, "GetSecretValue", map[string]string{"SecretId": d.name(orgID)}, &resp)
+	if errors.ErrorCode(err) == errors.ENotFound {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeSecrets([]byte(resp.SecretString))
+}
+
+func (d *awsDocuments) store(ctx context.Context, orgID platform.ID, secrets map[string]string) error {
+	b, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+
+	err = d.do(ctx, "PutSecretValue", map[string]string{
+		"SecretId":     d.name(orgID),
+		"SecretString": string(b),
+	}, nil)
+	if errors.ErrorCode(err) != errors.ENotFound {
+		return err
+	}
+	return d.do(ctx, "CreateSecret", map[string]string{
+		"Name":         d.name(orgID),
+		"Description":  "secrets of influxdb organization " + orgID.String(),
+		"SecretString": string(b),
+	}, nil)
+}
+
+// do calls an action of the Secrets Manager API, and decodes its response into dest.
+func (d *awsDocuments) do(ctx context.Context, action string, input interface{}, dest interface{}) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", awsContentType)
+	req.Header.Set("X-Amz-Target", awsTargetPrefix+action)
+	sigv4.Sign(req, body, d.cfg.Credentials, d.cfg.Region, "secretsmanager", d.timeGenerator.Now())
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.EUnavailable,
+			Msg:  "failed to reach aws secrets manager",
+			Err:  err,
+		}
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		var awsErr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(b, &awsErr)
+		code := errors.EInternal
+		if awsErr.Type == awsNotFoundError {
+			code = errors.ENotFound
+		}
+		return &errors.Error{
+			Code: code,
+			Msg:  fmt.Sprintf("aws secrets manager %s failed: status_code=%d %s %s", action, resp.StatusCode, awsErr.Type, awsErr.Message),
+		}
+	}
+
+	if dest == nil {
+		return nil
+	}
+	return json.Unmarshal(b, dest)
+}
+
+// decodeSecrets decodes the JSON object of the secrets of an organization.
+func decodeSecrets(b []byte) (map[string]string, error) {
+	secrets := map[string]string{}
+	if len(b) == 0 {
+		return secrets, nil
+	}
+	if err := json.Unmarshal(b, &secrets); err != nil {
+		return nil, &errors.Error{
+			Code: errors.EInternal,
+			Msg:  "secret manager value is not a JSON object of secrets",
+			Err:  err,
+		}
+	}
+	return secrets, nil
+}
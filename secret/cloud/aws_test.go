@@ -0,0 +1,80 @@
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/pkg/sigv4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAWSSecretService(t *testing.T) {
+	secrets := map[string]string{}
+	var actions []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		action := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), awsTargetPrefix)
+		actions = append(actions, action)
+		assert.Equal(t, awsContentType, r.Header.Get("Content-Type"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+
+		var input map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&input))
+
+		notFound := func() {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`))
+		}
+		switch action {
+		case "GetSecretValue":
+			v, ok := secrets[input["SecretId"]]
+			if !ok {
+				notFound()
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"SecretString": v})
+		case "PutSecretValue":
+			if _, ok := secrets[input["SecretId"]]; !ok {
+				notFound()
+				return
+			}
+			secrets[input["SecretId"]] = input["SecretString"]
+		case "CreateSecret":
+			secrets[input["Name"]] = input["SecretString"]
+		}
+	}))
+	defer srv.Close()
+
+	svc, err := NewAWSSecretService(AWSConfig{
+		Region:      "us-east-1",
+		Prefix:      "influx/",
+		Endpoint:    srv.URL,
+		Credentials: sigv4.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"},
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	keys, err := svc.GetSecretKeys(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	require.NoError(t, svc.PutSecret(ctx, 1, "apiKey", "abc"))
+	assert.JSONEq(t, `{"apiKey":"abc"}`, secrets["influx/0000000000000001"])
+
+	require.NoError(t, svc.PatchSecrets(ctx, 1, map[string]string{"token": "def"}))
+	assert.JSONEq(t, `{"apiKey":"abc","token":"def"}`, secrets["influx/0000000000000001"])
+
+	assert.Equal(t, []string{
+		"GetSecretValue",
+		"GetSecretValue", "PutSecretValue", "CreateSecret",
+		"GetSecretValue", "PutSecretValue",
+	}, actions)
+
+	v, err := svc.LoadSecret(ctx, 1, "token")
+	require.NoError(t, err)
+	assert.Equal(t, "def", v)
+}
@@ -0,0 +1,195 @@
+package cloud
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	gcpDefaultEndpoint = "https://secretmanager.googleapis.com/v1/"
+	gcpScope           = "https://www.googleapis.com/auth/cloud-platform"
+)
+
+// gcpSecretID is the format of the IDs of GCP secrets.
+var gcpSecretID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,255}$`)
+
+// GCPConfig configures the GCP Secret Manager secret service.
+type GCPConfig struct {
+	Project string
+	// Prefix prefixes the IDs of the secrets, it defaults to DefaultPrefix.
+	Prefix string
+	// Endpoint overrides the URL of the Secret Manager API.
+	Endpoint string
+	// CredentialsFile is a service account key file. The application default
+	// credentials are used when it is empty.
+	CredentialsFile string
+	// CacheTTL defaults to DefaultCacheTTL.
+	CacheTTL time.Duration
+}
+
+// NewGCPSecretService returns a secret service storing the secrets of each
+// organization in a GCP Secret Manager secret. The versions of the secrets
+// are added on every write, and are expected to be pruned by the expiry
+// policy of the project.
+func NewGCPSecretService(ctx context.Context, cfg GCPConfig) (*SecretService, error) {
+	if cfg.Project == "" {
+		return nil, fmt.Errorf("gcp secret manager requires a project")
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = DefaultPrefix
+	}
+	if !gcpSecretID.MatchString(cfg.Prefix + platform.ID(1).String()) {
+		return nil, fmt.Errorf("gcp secret manager prefix %q may only contain letters, numbers, dashes and underscores", cfg.Prefix)
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = gcpDefaultEndpoint
+	}
+
+	var ts oauth2.TokenSource
+	if cfg.CredentialsFile != "" {
+		b, err := os.ReadFile(cfg.CredentialsFile)
+		if err != nil {
+			return nil, err
+		}
+		creds, err := google.CredentialsFromJSON(ctx, b, gcpScope)
+		if err != nil {
+			return nil, err
+		}
+		ts = creds.TokenSource
+	} else {
+		var err error
+		ts, err = google.DefaultTokenSource(ctx, gcpScope)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	client := oauth2.NewClient(ctx, ts)
+	client.Timeout = defaultRequestTimeout
+	return newSecretService(&gcpDocuments{cfg: cfg, client: client}, cfg.CacheTTL), nil
+}
+
+type gcpDocuments struct {
+	cfg    GCPConfig
+	client *http.Client
+}
+
+// secretPath is the path of the secret of an organization.
+func (d *gcpDocuments) secretPath(orgID platform.ID) string {
+	return fmt.Sprintf("projects/%s/secrets/%s%s", url.PathEscape(d.cfg.Project), d.cfg.Prefix, orgID)
+}
+
+func (d *gcpDocuments) load(ctx context.Context, orgID platform.ID) (map[string]string, error) {
+	var resp struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	err := d.do(ctx, http.MethodGet, d.secretPath(orgID)+"/versions/latest:access", nil, &resp)
+	if errors.ErrorCode(err) == errors.ENotFound {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSecrets(b)
+}
+
+func (d *gcpDocuments) store(ctx context.Context, orgID platform.ID, secrets map[string]string) error {
+	b, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	version := map[string]interface{}{
+		"payload": map[string]string{"data": base64.StdEncoding.EncodeToString(b)},
+	}
+
+	err = d.do(ctx, http.MethodPost, d.secretPath(orgID)+":addVersion", version, nil)
+	if errors.ErrorCode(err) != errors.ENotFound {
+		return err
+	}
+
+	secret := map[string]interface{}{
+		"replication": map[string]interface{}{"automatic": map[string]interface{}{}},
+		"labels":      map[string]string{"influxdb-org-id": orgID.String()},
+	}
+	create := fmt.Sprintf("projects/%s/secrets?secretId=%s", url.PathEscape(d.cfg.Project), url.QueryEscape(d.cfg.Prefix+orgID.String()))
+	if err := d.do(ctx, http.MethodPost, create, secret, nil); err != nil {
+		return err
+	}
+	return d.do(ctx, http.MethodPost, d.secretPath(orgID)+":addVersion", version, nil)
+}
+
+// do calls the Secret Manager API, and decodes its response into dest.
+func (d *gcpDocuments) do(ctx context.Context, method, path string, input interface{}, dest interface{}) error {
+	var body io.Reader
+	if input != nil {
+		b, err := json.Marshal(input)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, d.cfg.Endpoint+path, body)
+	if err != nil {
+		return err
+	}
+	if input != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.EUnavailable,
+			Msg:  "failed to reach gcp secret manager",
+			Err:  err,
+		}
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		var gcpErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.Unmarshal(b, &gcpErr)
+		code := errors.EInternal
+		if resp.StatusCode == http.StatusNotFound {
+			code = errors.ENotFound
+		}
+		return &errors.Error{
+			Code: code,
+			Msg:  fmt.Sprintf("gcp secret manager request failed: status_code=%d %s", resp.StatusCode, gcpErr.Error.Message),
+		}
+	}
+
+	if dest == nil {
+		return nil
+	}
+	return json.Unmarshal(b, dest)
+}
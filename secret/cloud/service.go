@@ -0,0 +1,183 @@
+// Package cloud implements influxdb.SecretService with the secret managers of
+// cloud providers. The secrets of an organization are stored as a JSON object
+// of keys to values in a single secret of the secret manager, named with a
+// prefix and the ID of the organization.
+package cloud
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// DefaultCacheTTL is how long the secrets of an organization are cached for.
+const DefaultCacheTTL = time.Minute
+
+// DefaultPrefix prefixes the names of the secrets of organizations.
+const DefaultPrefix = "influxdb-"
+
+// documentStore reads and writes the secrets of an organization in a secret manager.
+type documentStore interface {
+	// load returns the secrets of an organization, and an empty map when the
+	// organization has none.
+	load(ctx context.Context, orgID platform.ID) (map[string]string, error)
+	store(ctx context.Context, orgID platform.ID, secrets map[string]string) error
+}
+
+type cached struct {
+	secrets map[string]string
+	expires time.Time
+}
+
+var _ influxdb.SecretService = (*SecretService)(nil)
+
+// SecretService stores the secrets of organizations in a secret manager, and
+// caches them in memory. Writes are not atomic across influxd instances, the
+// secrets of an organization are read, changed and written back.
+type SecretService struct {
+	docs documentStore
+	ttl  time.Duration
+
+	// mu serializes the writes of the service, and guards the cache.
+	mu    sync.Mutex
+	cache map[platform.ID]cached
+	// writes counts the writes, the secrets read while a write happened are
+	// not cached.
+	writes uint64
+
+	TimeGenerator influxdb.TimeGenerator
+}
+
+func newSecretService(docs documentStore, ttl time.Duration) *SecretService {
+	if ttl == 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &SecretService{
+		docs:          docs,
+		ttl:           ttl,
+		cache:         make(map[platform.ID]cached),
+		TimeGenerator: influxdb.RealTimeGenerator{},
+	}
+}
+
+// LoadSecret retrieves the secret value v found at key k for organization orgID.
+func (s *SecretService) LoadSecret(ctx context.Context, orgID platform.ID, k string) (string, error) {
+	secrets, err := s.load(ctx, orgID)
+	if err != nil {
+		return "", err
+	}
+
+	v, ok := secrets[k]
+	if !ok {
+		return "", &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  influxdb.ErrSecretNotFound,
+		}
+	}
+	return v, nil
+}
+
+// GetSecretKeys retrieves all secret keys that are stored for the organization orgID.
+func (s *SecretService) GetSecretKeys(ctx context.Context, orgID platform.ID) ([]string, error) {
+	secrets, err := s.load(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(secrets))
+	for k := range secrets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// PutSecret stores the secret pair (k,v) for the organization orgID.
+func (s *SecretService) PutSecret(ctx context.Context, orgID platform.ID, k string, v string) error {
+	return s.PatchSecrets(ctx, orgID, map[string]string{k: v})
+}
+
+// PutSecrets puts all provided secrets and overwrites any previous values.
+func (s *SecretService) PutSecrets(ctx context.Context, orgID platform.ID, m map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secrets := make(map[string]string, len(m))
+	for k, v := range m {
+		secrets[k] = v
+	}
+	return s.store(ctx, orgID, secrets)
+}
+
+// PatchSecrets patches all provided secrets and updates any previous values.
+func (s *SecretService) PatchSecrets(ctx context.Context, orgID platform.ID, m map[string]string) error {
+	return s.update(ctx, orgID, func(secrets map[string]string) {
+		for k, v := range m {
+			secrets[k] = v
+		}
+	})
+}
+
+// DeleteSecret removes a single secret from the secret store.
+func (s *SecretService) DeleteSecret(ctx context.Context, orgID platform.ID, ks ...string) error {
+	return s.update(ctx, orgID, func(secrets map[string]string) {
+		for _, k := range ks {
+			delete(secrets, k)
+		}
+	})
+}
+
+// update reads the secrets of an organization from the secret manager,
+// bypassing the cache, and writes them back changed by fn.
+func (s *SecretService) update(ctx context.Context, orgID platform.ID, fn func(map[string]string)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secrets, err := s.docs.load(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	fn(secrets)
+	return s.store(ctx, orgID, secrets)
+}
+
+// store writes the secrets of an organization, and caches them. s.mu must be held.
+func (s *SecretService) store(ctx context.Context, orgID platform.ID, secrets map[string]string) error {
+	delete(s.cache, orgID)
+	s.writes++
+	if err := s.docs.store(ctx, orgID, secrets); err != nil {
+		return err
+	}
+	s.cache[orgID] = cached{secrets: secrets, expires: s.TimeGenerator.Now().Add(s.ttl)}
+	return nil
+}
+
+// load returns the cached secrets of an organization, and reads them from
+// the secret manager once they expire.
+func (s *SecretService) load(ctx context.Context, orgID platform.ID) (map[string]string, error) {
+	now := s.TimeGenerator.Now()
+	s.mu.Lock()
+	c, ok := s.cache[orgID]
+	writes := s.writes
+	s.mu.Unlock()
+	if ok && now.Before(c.expires) {
+		return c.secrets, nil
+	}
+
+	secrets, err := s.docs.load(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.writes == writes {
+		s.cache[orgID] = cached{secrets: secrets, expires: now.Add(s.ttl)}
+	}
+	s.mu.Unlock()
+	return secrets, nil
+}
@@ -0,0 +1,90 @@
+package cloud
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/mock"
+	influxdbtesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memDocuments is a secret manager in memory.
+type memDocuments struct {
+	mu    sync.Mutex
+	docs  map[platform.ID]map[string]string
+	loads int
+}
+
+func (d *memDocuments) load(ctx context.Context, orgID platform.ID) (map[string]string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.loads++
+	secrets := map[string]string{}
+	for k, v := range d.docs[orgID] {
+		secrets[k] = v
+	}
+	return secrets, nil
+}
+
+func (d *memDocuments) store(ctx context.Context, orgID platform.ID, secrets map[string]string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	doc := map[string]string{}
+	for k, v := range secrets {
+		doc[k] = v
+	}
+	d.docs[orgID] = doc
+	return nil
+}
+
+func TestSecretService(t *testing.T) {
+	influxdbtesting.SecretService(func(f influxdbtesting.SecretServiceFields, t *testing.T) (influxdb.SecretService, func()) {
+		svc := newSecretService(&memDocuments{docs: map[platform.ID]map[string]string{}}, 0)
+		for _, s := range f.Secrets {
+			if err := svc.PutSecrets(context.Background(), s.OrganizationID, s.Env); err != nil {
+				t.Fatalf("failed to populate secrets: %v", err)
+			}
+		}
+		return svc, func() {}
+	}, t)
+}
+
+func TestSecretService_Cache(t *testing.T) {
+	ctx := context.Background()
+	docs := &memDocuments{docs: map[platform.ID]map[string]string{
+		1: {"apiKey": "v1"},
+	}}
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	svc := newSecretService(docs, time.Minute)
+	svc.TimeGenerator = mock.TimeGenerator{FakeValue: now}
+
+	v, err := svc.LoadSecret(ctx, 1, "apiKey")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", v)
+
+	// changed outside of the service, the cached value is returned until it expires.
+	docs.docs[1] = map[string]string{"apiKey": "v2"}
+	v, err = svc.LoadSecret(ctx, 1, "apiKey")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", v)
+	assert.Equal(t, 1, docs.loads)
+
+	svc.TimeGenerator = mock.TimeGenerator{FakeValue: now.Add(time.Minute)}
+	v, err = svc.LoadSecret(ctx, 1, "apiKey")
+	require.NoError(t, err)
+	assert.Equal(t, "v2", v)
+	assert.Equal(t, 2, docs.loads)
+
+	// writes update the cache.
+	require.NoError(t, svc.PatchSecrets(ctx, 1, map[string]string{"token": "t1"}))
+	keys, err := svc.GetSecretKeys(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"apiKey", "token"}, keys)
+	assert.Equal(t, 3, docs.loads)
+}
@@ -0,0 +1,185 @@
+package secret
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/parser"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/task/taskmodel"
+)
+
+// Dependents finds the resources which use secrets with the finders
+// registered to it.
+type Dependents struct {
+	mu      sync.Mutex
+	finders []DependentFinder
+}
+
+// Register adds finders of the resources which use secrets. The finders must
+// not be authorized.
+func (d *Dependents) Register(finders ...DependentFinder) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.finders = append(d.finders, finders...)
+}
+
+// FindSecretDependents returns the resources of the organization which use the
+// secret. The resources of the finders which succeed are returned with the
+// error of the first finder which failed.
+func (d *Dependents) FindSecretDependents(ctx context.Context, orgID platform.ID, key string) ([]Dependent, error) {
+	d.mu.Lock()
+	finders := append([]DependentFinder{}, d.finders...)
+	d.mu.Unlock()
+
+	var (
+		deps     []Dependent
+		firstErr error
+	)
+	for _, f := range finders {
+		found, err := f.FindSecretDependents(ctx, orgID, key)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		deps = append(deps, found...)
+	}
+	return deps, firstErr
+}
+
+// TaskDependents finds the tasks which get a secret with secrets.get.
+type TaskDependents struct {
+	Tasks taskmodel.TaskService
+}
+
+// FindSecretDependents returns the tasks of the organization which get the secret.
+func (d TaskDependents) FindSecretDependents(ctx context.Context, orgID platform.ID, key string) ([]Dependent, error) {
+	var (
+		deps   []Dependent
+		filter = taskmodel.TaskFilter{OrganizationID: &orgID, Limit: taskmodel.TaskDefaultPageSize}
+	)
+	for {
+		tasks, _, err := d.Tasks.FindTasks(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tasks {
+			if fluxSecretKeys(t.Flux)[key] {
+				deps = append(deps, Dependent{
+					ResourceType: influxdb.TasksResourceType,
+					ID:           t.ID,
+					Name:         t.Name,
+				})
+			}
+		}
+		if len(tasks) < taskmodel.TaskDefaultPageSize {
+			return deps, nil
+		}
+		after := tasks[len(tasks)-1].ID
+		filter.After = &after
+	}
+}
+
+// EndpointDependents finds the notification endpoints which store a
+// credential in a secret.
+type EndpointDependents struct {
+	Endpoints influxdb.NotificationEndpointService
+}
+
+// FindSecretDependents returns the notification endpoints of the organization
+// which store a credential in the secret.
+func (d EndpointDependents) FindSecretDependents(ctx context.Context, orgID platform.ID, key string) ([]Dependent, error) {
+	endpoints, _, err := d.Endpoints.FindNotificationEndpoints(ctx, influxdb.NotificationEndpointFilter{OrgID: &orgID})
+	if err != nil {
+		return nil, err
+	}
+
+	var deps []Dependent
+	for _, e := range endpoints {
+		for _, f := range e.SecretFields() {
+			if f.Key == key {
+				deps = append(deps, Dependent{
+					ResourceType: influxdb.NotificationEndpointResourceType,
+					ID:           e.GetID(),
+					Name:         e.GetName(),
+				})
+				break
+			}
+		}
+	}
+	return deps, nil
+}
+
+// CheckDependents finds the checks whose tasks get a secret with secrets.get.
+type CheckDependents struct {
+	Checks influxdb.CheckService
+	Tasks  taskmodel.TaskService
+}
+
+// FindSecretDependents returns the checks of the organization whose tasks get
+// the secret.
+func (d CheckDependents) FindSecretDependents(ctx context.Context, orgID platform.ID, key string) ([]Dependent, error) {
+	checks, _, err := d.Checks.FindChecks(ctx, influxdb.CheckFilter{OrgID: &orgID})
+	if err != nil {
+		return nil, err
+	}
+
+	var deps []Dependent
+	for _, c := range checks {
+		if !c.GetTaskID().Valid() {
+			continue
+		}
+		t, err := d.Tasks.FindTaskByID(ctx, c.GetTaskID())
+		if err != nil {
+			continue
+		}
+		if fluxSecretKeys(t.Flux)[key] {
+			deps = append(deps, Dependent{
+				ResourceType: influxdb.ChecksResourceType,
+				ID:           c.GetID(),
+				Name:         c.GetName(),
+			})
+		}
+	}
+	return deps, nil
+}
+
+// fluxSecretKeys returns the keys of the secrets a flux script gets with
+// secrets.get(key: "key").
+func fluxSecretKeys(source string) map[string]bool {
+	keys := make(map[string]bool)
+	if strings.TrimSpace(source) == "" {
+		return keys
+	}
+	ast.Visit(parser.ParseSource(source), func(n ast.Node) {
+		call, ok := n.(*ast.CallExpression)
+		if !ok || len(call.Arguments) != 1 {
+			return
+		}
+		callee, ok := call.Callee.(*ast.MemberExpression)
+		if !ok || callee.Property.Key() != "get" {
+			return
+		}
+		if obj, ok := callee.Object.(*ast.Identifier); !ok || obj.Name != "secrets" {
+			return
+		}
+		args, ok := call.Arguments[0].(*ast.ObjectExpression)
+		if !ok {
+			return
+		}
+		for _, p := range args.Properties {
+			if p.Key.Key() != "key" {
+				continue
+			}
+			if k, ok := p.Value.(*ast.StringLiteral); ok {
+				keys[k.Value] = true
+			}
+		}
+	})
+	return keys
+}
@@ -0,0 +1,371 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+var secretDataKeyBucket = []byte("secretdatakeysv1")
+
+const (
+	// dataKeySize is the size of the AES-256 data keys secrets are encrypted with.
+	dataKeySize = 32
+
+	// encryptedPrefix prefixes the values of encrypted secrets, which are
+	// otherwise stored base64 encoded. The prefix is not valid base64, so the
+	// values stored before encryption was enabled are told apart.
+	encryptedPrefix = "enc:"
+)
+
+// KeyWrapper wraps the data keys secrets are encrypted with, with a key kept
+// outside of the metadata store, such as a KMS key.
+type KeyWrapper interface {
+	// KeyID identifies the key data keys are wrapped with. A new KeyID is
+	// a rotation of the key, the data keys are wrapped again with it.
+	KeyID() string
+	// WrapKey encrypts a data key with the current key.
+	WrapKey(ctx context.Context, key []byte) ([]byte, error)
+	// UnwrapKey decrypts a data key which was wrapped with the key of keyID.
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// dataKey is a data key as it is stored, wrapped by a KeyWrapper.
+type dataKey struct {
+	Version    uint64    `json:"version"`
+	KeyID      string    `json:"keyID"`
+	WrappedKey []byte    `json:"wrappedKey"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// envelope encrypts the values of secrets with data keys, which are stored
+// wrapped by a KeyWrapper. The unwrapped data keys are only kept in memory.
+type envelope struct {
+	kv      kv.Store
+	wrapper KeyWrapper
+
+	mu        sync.RWMutex
+	keys      map[uint64]cipher.AEAD
+	active    uint64
+	createdAt time.Time
+
+	timeGenerator influxdb.TimeGenerator
+}
+
+// open unwraps the stored data keys, and creates the first data key if there
+// are none. The data keys wrapped with a previous key of the wrapper are
+// wrapped again with its current key.
+func (e *envelope) open(ctx context.Context) error {
+	var stored []*dataKey
+	err := e.kv.View(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(secretDataKeyBucket)
+		if err != nil {
+			return err
+		}
+		cur, err := b.ForwardCursor(nil)
+		if err != nil {
+			return err
+		}
+		return kv.WalkCursor(ctx, cur, func(k, v []byte) (bool, error) {
+			dk := &dataKey{}
+			if err := json.Unmarshal(v, dk); err != nil {
+				return false, err
+			}
+			stored = append(stored, dk)
+			return true, nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(stored) == 0 {
+		return e.rotate(ctx)
+	}
+
+	var rewrapped []*dataKey
+	for _, dk := range stored {
+		key, err := e.wrapper.UnwrapKey(ctx, dk.KeyID, dk.WrappedKey)
+		if err != nil {
+			return &errors2.Error{
+				Code: errors2.EInternal,
+				Msg:  fmt.Sprintf("failed to unwrap secret data key %d wrapped with key %q", dk.Version, dk.KeyID),
+				Err:  err,
+			}
+		}
+		if dk.KeyID != e.wrapper.KeyID() {
+			wrapped, err := e.wrapper.WrapKey(ctx, key)
+			if err != nil {
+				return err
+			}
+			dk.KeyID, dk.WrappedKey = e.wrapper.KeyID(), wrapped
+			rewrapped = append(rewrapped, dk)
+		}
+		if err := e.addKey(dk, key); err != nil {
+			return err
+		}
+	}
+	return e.putDataKeys(ctx, rewrapped...)
+}
+
+// rotate creates a new data key, which the secrets are encrypted with from
+// then on. The previous data keys are kept to decrypt the secrets encrypted
+// with them.
+func (e *envelope) rotate(ctx context.Context) error {
+	key := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return err
+	}
+	wrapped, err := e.wrapper.WrapKey(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	e.mu.RLock()
+	version := e.active + 1
+	e.mu.RUnlock()
+
+	dk := &dataKey{
+		Version:    version,
+		KeyID:      e.wrapper.KeyID(),
+		WrappedKey: wrapped,
+		CreatedAt:  e.timeGenerator.Now().UTC(),
+	}
+	if err := e.putDataKeys(ctx, dk); err != nil {
+		return err
+	}
+	return e.addKey(dk, key)
+}
+
+// activeCreatedAt returns the time the data key secrets are encrypted with was created.
+func (e *envelope) activeCreatedAt() time.Time {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.createdAt
+}
+
+func (e *envelope) addKey(dk *dataKey, key []byte) error {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.keys[dk.Version] = aead
+	if dk.Version > e.active {
+		e.active, e.createdAt = dk.Version, dk.CreatedAt
+	}
+	return nil
+}
+
+func (e *envelope) putDataKeys(ctx context.Context, dks ...*dataKey) error {
+	if len(dks) == 0 {
+		return nil
+	}
+	return e.kv.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(secretDataKeyBucket)
+		if err != nil {
+			return err
+		}
+		for _, dk := range dks {
+			v, err := json.Marshal(dk)
+			if err != nil {
+				return err
+			}
+			if err := b.Put(encodeDataKeyVersion(dk.Version), v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// encrypt encrypts the value of the secret stored at key with the active
+// data key. The key is authenticated, so values can't be swapped between
+// secrets.
+func (e *envelope) encrypt(key []byte, v string) ([]byte, error) {
+	e.mu.RLock()
+	version, aead := e.active, e.keys[e.active]
+	e.mu.RUnlock()
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(v), key)
+
+	var buf bytes.Buffer
+	buf.WriteString(encryptedPrefix)
+	buf.WriteString(strconv.FormatUint(version, 10))
+	buf.WriteByte(':')
+	buf.WriteString(base64.StdEncoding.EncodeToString(sealed))
+	return buf.Bytes(), nil
+}
+
+// decrypt decrypts the value of the secret stored at key. current is false
+// when the value is not encrypted with the active data key.
+func (e *envelope) decrypt(key, val []byte) (v string, current bool, err error) {
+	version, sealed, err := splitEncryptedValue(val)
+	if err != nil {
+		return "", false, err
+	}
+
+	e.mu.RLock()
+	aead, ok := e.keys[version]
+	active := e.active
+	e.mu.RUnlock()
+	if !ok {
+		return "", false, &errors2.Error{
+			Code: errors2.EInternal,
+			Msg:  fmt.Sprintf("secret is encrypted with unknown data key %d", version),
+		}
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return "", false, &errors2.Error{
+			Code: errors2.EInternal,
+			Msg:  "encrypted secret is too short",
+		}
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, key)
+	if err != nil {
+		return "", false, &errors2.Error{
+			Code: errors2.EInternal,
+			Msg:  "failed to decrypt secret",
+			Err:  err,
+		}
+	}
+	return string(plaintext), version == active, nil
+}
+
+func isEncryptedValue(val []byte) bool {
+	return bytes.HasPrefix(val, []byte(encryptedPrefix))
+}
+
+func splitEncryptedValue(val []byte) (uint64, []byte, error) {
+	parts := bytes.SplitN(bytes.TrimPrefix(val, []byte(encryptedPrefix)), []byte(":"), 2)
+	if len(parts) != 2 {
+		return 0, nil, &errors2.Error{
+			Code: errors2.EInternal,
+			Msg:  "encrypted secret is malformed",
+		}
+	}
+	version, err := strconv.ParseUint(string(parts[0]), 10, 64)
+	if err != nil {
+		return 0, nil, &errors2.Error{
+			Code: errors2.EInternal,
+			Msg:  "encrypted secret has a malformed data key version",
+			Err:  err,
+		}
+	}
+	sealed, err := base64.StdEncoding.DecodeString(string(parts[1]))
+	if err != nil {
+		return 0, nil, err
+	}
+	return version, sealed, nil
+}
+
+func encodeDataKeyVersion(version uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, version)
+	return b
+}
+
+// NewEncryptedStore creates a new storage system, which encrypts the values of
+// secrets with data keys wrapped by wrapper. The secrets stored before are
+// encrypted as it is opened. The data key is rotated, and the secrets are
+// encrypted again, when it is older than maxAge, if maxAge is set.
+func NewEncryptedStore(ctx context.Context, s kv.Store, wrapper KeyWrapper, maxAge time.Duration) (*Storage, error) {
+	e := &envelope{
+		kv:            s,
+		wrapper:       wrapper,
+		keys:          make(map[uint64]cipher.AEAD),
+		timeGenerator: influxdb.RealTimeGenerator{},
+	}
+	if err := e.open(ctx); err != nil {
+		return nil, err
+	}
+
+	storage := &Storage{store: s, envelope: e}
+	if maxAge > 0 && e.timeGenerator.Now().Sub(e.activeCreatedAt()) > maxAge {
+		return storage, storage.RotateDataKey(ctx)
+	}
+	return storage, storage.reencrypt(ctx)
+}
+
+// RotateDataKey creates a new data key, and encrypts every secret again with
+// it. It fails when the storage does not encrypt secrets.
+func (s *Storage) RotateDataKey(ctx context.Context) error {
+	if s.envelope == nil {
+		return &errors2.Error{
+			Code: errors2.EMethodNotAllowed,
+			Msg:  "secret encryption is not enabled",
+		}
+	}
+	if err := s.envelope.rotate(ctx); err != nil {
+		return err
+	}
+	return s.reencrypt(ctx)
+}
+
+// reencrypt encrypts the secrets which are not encrypted with the active
+// data key.
+func (s *Storage) reencrypt(ctx context.Context) error {
+	return s.store.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(secretBucket)
+		if err != nil {
+			return err
+		}
+		cur, err := b.ForwardCursor(nil)
+		if err != nil {
+			return err
+		}
+
+		stale := map[string]string{}
+		err = kv.WalkCursor(ctx, cur, func(k, val []byte) (bool, error) {
+			v, current, err := s.decodeSecretValue(k, val)
+			if err != nil {
+				id, key, _ := decodeSecretKey(k)
+				return false, fmt.Errorf("secret %q of organization %s: %w", key, id, err)
+			}
+			if !current {
+				stale[string(k)] = v
+			}
+			return true, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for k, v := range stale {
+			val, err := s.encodeSecretValue([]byte(k), v)
+			if err != nil {
+				return err
+			}
+			if err := b.Put([]byte(k), val); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,146 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/inmem"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/kv/migration/all"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// xorWrapper wraps keys by xor-ing them with the byte of its key ID.
+type xorWrapper struct {
+	id byte
+}
+
+func (w xorWrapper) KeyID() string { return fmt.Sprintf("xor-%d", w.id) }
+
+func (w xorWrapper) WrapKey(_ context.Context, key []byte) ([]byte, error) {
+	return xor(key, w.id), nil
+}
+
+func (w xorWrapper) UnwrapKey(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	var id byte
+	if _, err := fmt.Sscanf(keyID, "xor-%d", &id); err != nil {
+		return nil, err
+	}
+	return xor(wrapped, id), nil
+}
+
+func xor(b []byte, k byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[i] = b[i] ^ k
+	}
+	return out
+}
+
+func storedSecret(t *testing.T, store kv.Store, orgID platform.ID, k string) []byte {
+	t.Helper()
+
+	key, err := encodeSecretKey(orgID, k)
+	require.NoError(t, err)
+	var val []byte
+	require.NoError(t, store.View(context.Background(), func(tx kv.Tx) error {
+		b, err := tx.Bucket(secretBucket)
+		if err != nil {
+			return err
+		}
+		val, err = b.Get(key)
+		return err
+	}))
+	return val
+}
+
+func TestEncryptedStore(t *testing.T) {
+	const orgID = platform.ID(1)
+	ctx := context.Background()
+
+	store := inmem.NewKVStore()
+	require.NoError(t, all.Up(ctx, zaptest.NewLogger(t), store))
+
+	plain, err := NewStore(store)
+	require.NoError(t, err)
+	require.NoError(t, NewService(plain).PutSecret(ctx, orgID, "before", "v0"))
+
+	t.Run("encrypts the secrets stored before", func(t *testing.T) {
+		storage, err := NewEncryptedStore(ctx, store, xorWrapper{id: 1}, 0)
+		require.NoError(t, err)
+		svc := NewService(storage)
+		require.NoError(t, svc.PutSecret(ctx, orgID, "token", "v1"))
+
+		for k, v := range map[string]string{"before": "v0", "token": "v1"} {
+			val := storedSecret(t, store, orgID, k)
+			assert.True(t, isEncryptedValue(val))
+			assert.False(t, bytes.Contains(val, encodeSecretValueForTest(v)))
+
+			got, err := svc.LoadSecret(ctx, orgID, k)
+			require.NoError(t, err)
+			assert.Equal(t, v, got)
+		}
+
+		// unencrypted storage can not read the secrets.
+		_, err = NewService(plain).LoadSecret(ctx, orgID, "token")
+		assert.Error(t, err)
+	})
+
+	t.Run("wraps the data keys again with a new key", func(t *testing.T) {
+		storage, err := NewEncryptedStore(ctx, store, xorWrapper{id: 2}, 0)
+		require.NoError(t, err)
+		v, err := NewService(storage).LoadSecret(ctx, orgID, "token")
+		require.NoError(t, err)
+		assert.Equal(t, "v1", v)
+
+		// the previous key is no longer needed.
+		storage, err = NewEncryptedStore(ctx, store, onlyKey{xorWrapper{id: 2}}, 0)
+		require.NoError(t, err)
+		v, err = NewService(storage).LoadSecret(ctx, orgID, "token")
+		require.NoError(t, err)
+		assert.Equal(t, "v1", v)
+	})
+
+	t.Run("rotates the data key", func(t *testing.T) {
+		storage, err := NewEncryptedStore(ctx, store, xorWrapper{id: 2}, 0)
+		require.NoError(t, err)
+		before := storedSecret(t, store, orgID, "token")
+		require.True(t, bytes.HasPrefix(before, []byte(encryptedPrefix+"1:")))
+
+		require.NoError(t, storage.RotateDataKey(ctx))
+		after := storedSecret(t, store, orgID, "token")
+		assert.True(t, bytes.HasPrefix(after, []byte(encryptedPrefix+"2:")))
+
+		v, err := NewService(storage).LoadSecret(ctx, orgID, "token")
+		require.NoError(t, err)
+		assert.Equal(t, "v1", v)
+
+		// the data key is rotated as the store is opened when it is too old.
+		storage, err = NewEncryptedStore(ctx, store, xorWrapper{id: 2}, time.Nanosecond)
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(storedSecret(t, store, orgID, "token"), []byte(encryptedPrefix+"3:")))
+	})
+}
+
+// onlyKey fails to unwrap keys wrapped with another key.
+type onlyKey struct {
+	xorWrapper
+}
+
+func (w onlyKey) UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	if keyID != w.KeyID() {
+		return nil, fmt.Errorf("unknown key %q", keyID)
+	}
+	return w.xorWrapper.UnwrapKey(ctx, keyID, wrapped)
+}
+
+func encodeSecretValueForTest(v string) []byte {
+	val, _ := (&Storage{}).encodeSecretValue(nil, v)
+	return val
+}
@@ -3,6 +3,7 @@ package secret
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/influxdata/influxdb/v2"
@@ -18,6 +19,7 @@ type handler struct {
 	api *kithttp.API
 
 	idLookupKey string
+	rotator     Rotator
 }
 
 // NewHandler creates a new handler for the secret service
@@ -36,6 +38,11 @@ func NewHandler(log *zap.Logger, idLookupKey string, svc influxdb.SecretService)
 	r.Patch("/", h.handlePatchSecrets)
 	r.Delete("/{secretID}", h.handleDeleteSecret)
 	r.Post("/delete", h.handleDeleteSecrets) // deprecated
+	if rotator, ok := svc.(Rotator); ok {
+		h.rotator = rotator
+		r.Post("/{secretID}/rotate", h.handleRotateSecret)
+		r.Get("/{secretID}/rotation", h.handleGetRotation)
+	}
 	return r
 }
 
@@ -150,3 +157,62 @@ func (h *handler) decodeOrgID(r *http.Request) (platform.ID, error) {
 	}
 	return *id, nil
 }
+
+type rotateSecretRequest struct {
+	Value string `json:"value"`
+	// GracePeriod is a duration, such as 1h, it defaults to DefaultRotationGracePeriod.
+	GracePeriod *string `json:"gracePeriod"`
+}
+
+// handleRotateSecret is the HTTP handler for the POST /api/v2/orgs/:id/secrets/:id/rotate route.
+func (h *handler) handleRotateSecret(w http.ResponseWriter, r *http.Request) {
+	orgID, err := h.decodeOrgID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var req rotateSecretRequest
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	grace := DefaultRotationGracePeriod
+	if req.GracePeriod != nil {
+		grace, err = time.ParseDuration(*req.GracePeriod)
+		if err != nil {
+			h.api.Err(w, r, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "invalid grace period",
+				Err:  err,
+			})
+			return
+		}
+	}
+
+	ev, err := h.rotator.RotateSecret(r.Context(), orgID, chi.URLParam(r, "secretID"), req.Value, grace)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusOK, ev)
+}
+
+// handleGetRotation is the HTTP handler for the GET /api/v2/orgs/:id/secrets/:id/rotation route.
+func (h *handler) handleGetRotation(w http.ResponseWriter, r *http.Request) {
+	orgID, err := h.decodeOrgID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	rot, err := h.rotator.FindRotation(r.Context(), orgID, chi.URLParam(r, "secretID"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusOK, rot)
+}
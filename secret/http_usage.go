@@ -0,0 +1,74 @@
+package secret
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const prefixSecrets = "/api/v2/secrets"
+
+// UsageHandler is the handler for the usage of secrets.
+type UsageHandler struct {
+	chi.Router
+
+	api *kithttp.API
+	log *zap.Logger
+
+	usageFinder UsageFinder
+}
+
+// NewUsageHandler returns a new instance of UsageHandler.
+func NewUsageHandler(log *zap.Logger, usageFinder UsageFinder) *UsageHandler {
+	h := &UsageHandler{
+		log:         log,
+		api:         kithttp.NewAPI(kithttp.WithLog(log)),
+		usageFinder: usageFinder,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+	r.Get("/{key}/usage", h.handleGetUsage)
+	h.Router = r
+	return h
+}
+
+// Prefix returns the mounting prefix for the handler.
+func (h *UsageHandler) Prefix() string {
+	return prefixSecrets
+}
+
+// handleGetUsage is the HTTP handler for the GET /api/v2/secrets/:key/usage route.
+func (h *UsageHandler) handleGetUsage(w http.ResponseWriter, r *http.Request) {
+	orgID := r.URL.Query().Get("orgID")
+	if orgID == "" {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "orgID is required",
+		})
+		return
+	}
+	id, err := platform.IDFromString(orgID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	u, err := h.usageFinder.FindSecretUsage(r.Context(), *id, chi.URLParam(r, "key"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Secret usage retrieved", zap.String("key", u.Key))
+
+	h.api.Respond(w, r, http.StatusOK, u)
+}
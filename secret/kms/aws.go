@@ -0,0 +1,140 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/pkg/sigv4"
+)
+
+const (
+	awsTargetPrefix = "TrentService."
+	awsContentType  = "application/x-amz-json-1.1"
+)
+
+// AWSConfig configures the AWS KMS key wrapper.
+type AWSConfig struct {
+	// KeyID is the ID, ARN or alias of the KMS key.
+	KeyID  string
+	Region string
+	// Endpoint overrides the URL of the KMS API of the region.
+	Endpoint string
+	// Credentials default to the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY
+	// and AWS_SESSION_TOKEN environment variables.
+	Credentials sigv4.Credentials
+}
+
+// AWSKeyWrapper wraps data keys with an AWS KMS key. The KMS key is rotated
+// by KMS within the same key ID, or by configuring a new key ID.
+type AWSKeyWrapper struct {
+	cfg           AWSConfig
+	client        *http.Client
+	timeGenerator influxdb.TimeGenerator
+}
+
+// NewAWSKeyWrapper returns a key wrapper using the AWS KMS key of cfg.
+func NewAWSKeyWrapper(cfg AWSConfig) (*AWSKeyWrapper, error) {
+	if cfg.KeyID == "" {
+		return nil, fmt.Errorf("aws kms secret encryption requires a key id")
+	}
+	if cfg.Region == "" {
+		cfg.Region = os.Getenv("AWS_REGION")
+	}
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("aws kms secret encryption requires a region")
+	}
+	if cfg.Credentials.AccessKeyID == "" {
+		cfg.Credentials = sigv4.Credentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}
+	}
+	if cfg.Credentials.AccessKeyID == "" || cfg.Credentials.SecretAccessKey == "" {
+		return nil, fmt.Errorf("aws kms secret encryption requires an access key id and a secret access key")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = fmt.Sprintf("https://kms.%s.amazonaws.com/", cfg.Region)
+	}
+	return &AWSKeyWrapper{
+		cfg:           cfg,
+		client:        &http.Client{Timeout: defaultRequestTimeout},
+		timeGenerator: influxdb.RealTimeGenerator{},
+	}, nil
+}
+
+// KeyID returns the configured KMS key ID.
+func (w *AWSKeyWrapper) KeyID() string {
+	return w.cfg.KeyID
+}
+
+// WrapKey encrypts key with the KMS key.
+func (w *AWSKeyWrapper) WrapKey(ctx context.Context, key []byte) ([]byte, error) {
+	var resp struct {
+		CiphertextBlob []byte `json:"CiphertextBlob"`
+	}
+	err := w.do(ctx, "Encrypt", map[string]interface{}{
+		"KeyId":     w.cfg.KeyID,
+		"Plaintext": key,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.CiphertextBlob, nil
+}
+
+// UnwrapKey decrypts a key wrapped with the KMS key of keyID.
+func (w *AWSKeyWrapper) UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	var resp struct {
+		Plaintext []byte `json:"Plaintext"`
+	}
+	err := w.do(ctx, "Decrypt", map[string]interface{}{
+		"KeyId":          keyID,
+		"CiphertextBlob": wrapped,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Plaintext, nil
+}
+
+// do calls an action of the KMS API, and decodes its response into dest.
+func (w *AWSKeyWrapper) do(ctx context.Context, action string, input interface{}, dest interface{}) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", awsContentType)
+	req.Header.Set("X-Amz-Target", awsTargetPrefix+action)
+	sigv4.Sign(req, body, w.cfg.Credentials, w.cfg.Region, "kms", w.timeGenerator.Now())
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach aws kms: %w", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		var awsErr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(b, &awsErr)
+		return fmt.Errorf("aws kms %s failed: status_code=%d %s %s", action, resp.StatusCode, awsErr.Type, awsErr.Message)
+	}
+	return json.Unmarshal(b, dest)
+}
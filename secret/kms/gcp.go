@@ -0,0 +1,134 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	gcpDefaultEndpoint = "https://cloudkms.googleapis.com/v1/"
+	gcpScope           = "https://www.googleapis.com/auth/cloudkms"
+)
+
+// GCPConfig configures the GCP Cloud KMS key wrapper.
+type GCPConfig struct {
+	// KeyName is the resource name of the crypto key, such as
+	// projects/p/locations/global/keyRings/r/cryptoKeys/k.
+	KeyName string
+	// Endpoint overrides the URL of the Cloud KMS API.
+	Endpoint string
+	// CredentialsFile is a service account key file. The application default
+	// credentials are used when it is empty.
+	CredentialsFile string
+}
+
+// GCPKeyWrapper wraps data keys with a GCP Cloud KMS crypto key. The versions
+// of the crypto key are rotated by Cloud KMS, a key is only wrapped again
+// when a new crypto key is configured.
+type GCPKeyWrapper struct {
+	cfg    GCPConfig
+	client *http.Client
+}
+
+// NewGCPKeyWrapper returns a key wrapper using the Cloud KMS key of cfg.
+func NewGCPKeyWrapper(ctx context.Context, cfg GCPConfig) (*GCPKeyWrapper, error) {
+	if !strings.HasPrefix(cfg.KeyName, "projects/") || !strings.Contains(cfg.KeyName, "/cryptoKeys/") {
+		return nil, fmt.Errorf("gcp kms secret encryption requires a crypto key name, such as projects/p/locations/l/keyRings/r/cryptoKeys/k")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = gcpDefaultEndpoint
+	}
+
+	var ts oauth2.TokenSource
+	if cfg.CredentialsFile != "" {
+		b, err := os.ReadFile(cfg.CredentialsFile)
+		if err != nil {
+			return nil, err
+		}
+		creds, err := google.CredentialsFromJSON(ctx, b, gcpScope)
+		if err != nil {
+			return nil, err
+		}
+		ts = creds.TokenSource
+	} else {
+		var err error
+		ts, err = google.DefaultTokenSource(ctx, gcpScope)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	client := oauth2.NewClient(ctx, ts)
+	client.Timeout = defaultRequestTimeout
+	return &GCPKeyWrapper{cfg: cfg, client: client}, nil
+}
+
+// KeyID returns the name of the crypto key.
+func (w *GCPKeyWrapper) KeyID() string {
+	return w.cfg.KeyName
+}
+
+// WrapKey encrypts key with the primary version of the crypto key.
+func (w *GCPKeyWrapper) WrapKey(ctx context.Context, key []byte) ([]byte, error) {
+	var resp struct {
+		Ciphertext []byte `json:"ciphertext"`
+	}
+	if err := w.do(ctx, w.cfg.KeyName+":encrypt", map[string][]byte{"plaintext": key}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Ciphertext, nil
+}
+
+// UnwrapKey decrypts a key wrapped with the crypto key named keyID.
+func (w *GCPKeyWrapper) UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	var resp struct {
+		Plaintext []byte `json:"plaintext"`
+	}
+	if err := w.do(ctx, keyID+":decrypt", map[string][]byte{"ciphertext": wrapped}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Plaintext, nil
+}
+
+// do calls the Cloud KMS API, and decodes its response into dest.
+func (w *GCPKeyWrapper) do(ctx context.Context, path string, input interface{}, dest interface{}) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.cfg.Endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach gcp kms: %w", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		var gcpErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.Unmarshal(b, &gcpErr)
+		return fmt.Errorf("gcp kms request failed: status_code=%d %s", resp.StatusCode, gcpErr.Error.Message)
+	}
+	return json.Unmarshal(b, dest)
+}
@@ -0,0 +1,104 @@
+package kms
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+// keySize is the size of the AES-256 keys of a keyring.
+const keySize = 32
+
+// Keyring wraps data keys with keys read from a file. The file has a base64
+// encoded 32 byte key per line, blank lines and lines starting with # are
+// ignored. The last key of the file is the current key, the key is rotated by
+// appending a new key. The previous keys must be kept until influxd has
+// started with the new key.
+type Keyring struct {
+	keys    map[string]cipher.AEAD
+	current string
+}
+
+// NewKeyring reads the keyring at path.
+func NewKeyring(path string) (*Keyring, error) {
+	if path == "" {
+		return nil, fmt.Errorf("local secret encryption requires a key file")
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &Keyring{keys: make(map[string]cipher.AEAD)}
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 || text[0] == '#' {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(string(text))
+		if err != nil || len(key) != keySize {
+			return nil, fmt.Errorf("line %d of key file %s is not a base64 encoded %d byte key", line, path, keySize)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		id := keyringKeyID(key)
+		k.keys[id] = aead
+		k.current = id
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if k.current == "" {
+		return nil, fmt.Errorf("key file %s has no keys", path)
+	}
+	return k, nil
+}
+
+// keyringKeyID identifies a key by its digest, so the key itself is not stored.
+func keyringKeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return "local:" + hex.EncodeToString(sum[:8])
+}
+
+// KeyID returns the ID of the last key of the keyring.
+func (k *Keyring) KeyID() string {
+	return k.current
+}
+
+// WrapKey encrypts key with the last key of the keyring.
+func (k *Keyring) WrapKey(_ context.Context, key []byte) ([]byte, error) {
+	aead := k.keys[k.current]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, key, nil), nil
+}
+
+// UnwrapKey decrypts a key wrapped with the key of keyID.
+func (k *Keyring) UnwrapKey(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	aead, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("key %q is not in the keyring", keyID)
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, fmt.Errorf("wrapped key is too short")
+	}
+	return aead.Open(nil, wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():], nil)
+}
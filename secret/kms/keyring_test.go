@@ -0,0 +1,61 @@
+package kms
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newKey(t *testing.T) string {
+	t.Helper()
+
+	key := make([]byte, keySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func TestKeyring(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keyring")
+	first := newKey(t)
+	require.NoError(t, os.WriteFile(path, []byte("# influxd secret keys\n"+first+"\n"), 0600))
+
+	k, err := NewKeyring(path)
+	require.NoError(t, err)
+	previousID := k.KeyID()
+	wrapped, err := k.WrapKey(ctx, []byte("data key"))
+	require.NoError(t, err)
+
+	// the key is rotated by appending a new key.
+	require.NoError(t, os.WriteFile(path, []byte(first+"\n\n"+newKey(t)+"\n"), 0600))
+	k, err = NewKeyring(path)
+	require.NoError(t, err)
+	assert.NotEqual(t, previousID, k.KeyID())
+
+	key, err := k.UnwrapKey(ctx, previousID, wrapped)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data key"), key)
+
+	_, err = k.UnwrapKey(ctx, "local:0000", wrapped)
+	assert.Error(t, err)
+
+	t.Run("invalid key files", func(t *testing.T) {
+		for name, content := range map[string]string{
+			"empty":      "# no keys\n",
+			"short key":  base64.StdEncoding.EncodeToString([]byte("short")) + "\n",
+			"not base64": "!!!\n",
+		} {
+			path := filepath.Join(t.TempDir(), "keyring")
+			require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+			_, err := NewKeyring(path)
+			assert.Error(t, err, name)
+		}
+	})
+}
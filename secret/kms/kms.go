@@ -0,0 +1,59 @@
+// Package kms implements secret.KeyWrapper with a local keyring and the key
+// management services of cloud providers, to encrypt the data keys of the
+// secrets stored in the metadata store.
+package kms
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/secret"
+)
+
+const (
+	// LocalProvider wraps data keys with the keys of a keyring file.
+	LocalProvider = "local"
+	// AWSProvider wraps data keys with an AWS KMS key.
+	AWSProvider = "aws"
+	// GCPProvider wraps data keys with a GCP Cloud KMS key.
+	GCPProvider = "gcp"
+
+	// defaultRequestTimeout bounds the time a request to a key management service takes.
+	defaultRequestTimeout = 30 * time.Second
+
+	// maxResponseSize is the largest response of a key management service which is read.
+	maxResponseSize = 1 << 20
+)
+
+// Config configures the encryption of stored secrets.
+type Config struct {
+	// Provider is local, aws or gcp. Secrets are not encrypted when it is empty.
+	Provider string
+	// KeyFile is the keyring of the local provider.
+	KeyFile string
+	AWS     AWSConfig
+	GCP     GCPConfig
+	// DataKeyMaxAge is how old the data key secrets are encrypted with gets
+	// before it is rotated, as influxd starts. It is never rotated when zero.
+	DataKeyMaxAge time.Duration
+}
+
+// Enabled returns true when stored secrets are encrypted.
+func (c Config) Enabled() bool {
+	return c.Provider != ""
+}
+
+// New returns the key wrapper of the provider of cfg.
+func New(ctx context.Context, cfg Config) (secret.KeyWrapper, error) {
+	switch cfg.Provider {
+	case LocalProvider:
+		return NewKeyring(cfg.KeyFile)
+	case AWSProvider:
+		return NewAWSKeyWrapper(cfg.AWS)
+	case GCPProvider:
+		return NewGCPKeyWrapper(ctx, cfg.GCP)
+	default:
+		return nil, fmt.Errorf("unknown secret encryption provider %q, expected %q, %q or %q", cfg.Provider, LocalProvider, AWSProvider, GCPProvider)
+	}
+}
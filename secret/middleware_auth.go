@@ -2,13 +2,18 @@ package secret
 
 import (
 	"context"
+	"time"
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/authorizer"
 	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 )
 
-var _ influxdb.SecretService = (*AuthedSvc)(nil)
+var (
+	_ influxdb.SecretService = (*AuthedSvc)(nil)
+	_ Rotator                = (*AuthedSvc)(nil)
+)
 
 // AuthedSvc wraps a influxdb.AuthedSvc and authorizes actions
 // against it appropriately.
@@ -99,3 +104,58 @@ func (s *AuthedSvc) DeleteSecret(ctx context.Context, orgID platform.ID, keys ..
 	}
 	return nil
 }
+
+// RotateSecret checks to see if the authorizer on context has write access to the secrets of orgID.
+func (s *AuthedSvc) RotateSecret(ctx context.Context, orgID platform.ID, key, value string, grace time.Duration) (*RotationEvent, error) {
+	r, err := s.rotator()
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizer.AuthorizeOrgWriteResource(ctx, influxdb.SecretsResourceType, orgID); err != nil {
+		return nil, err
+	}
+	return r.RotateSecret(ctx, orgID, key, value, grace)
+}
+
+// FindRotation checks to see if the authorizer on context has read access to the secrets of orgID.
+func (s *AuthedSvc) FindRotation(ctx context.Context, orgID platform.ID, key string) (*Rotation, error) {
+	r, err := s.rotator()
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizer.AuthorizeOrgReadResource(ctx, influxdb.SecretsResourceType, orgID); err != nil {
+		return nil, err
+	}
+	return r.FindRotation(ctx, orgID, key)
+}
+
+func (s *AuthedSvc) rotator() (Rotator, error) {
+	r, ok := s.s.(Rotator)
+	if !ok {
+		return nil, &errors.Error{
+			Code: errors.EMethodNotAllowed,
+			Msg:  "secret rotation is not enabled",
+		}
+	}
+	return r, nil
+}
+
+var _ UsageFinder = (*AuthedUsageSvc)(nil)
+
+// AuthedUsageSvc wraps a UsageFinder and authorizes the usage it finds.
+type AuthedUsageSvc struct {
+	s UsageFinder
+}
+
+// NewAuthedUsageService constructs an instance of an authorizing usage finder.
+func NewAuthedUsageService(s UsageFinder) *AuthedUsageSvc {
+	return &AuthedUsageSvc{s: s}
+}
+
+// FindSecretUsage checks to see if the authorizer on context has read access to the secrets of orgID.
+func (s *AuthedUsageSvc) FindSecretUsage(ctx context.Context, orgID platform.ID, key string) (*Usage, error) {
+	if _, _, err := authorizer.AuthorizeOrgReadResource(ctx, influxdb.SecretsResourceType, orgID); err != nil {
+		return nil, err
+	}
+	return s.s.FindSecretUsage(ctx, orgID, key)
+}
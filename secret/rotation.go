@@ -0,0 +1,440 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+	"go.uber.org/zap"
+)
+
+var secretRotationBucket = []byte("secretrotationsv1")
+
+const (
+	// DefaultRotationGracePeriod is how long the previous version of a
+	// rotated secret stays valid when no grace period is given.
+	DefaultRotationGracePeriod = 24 * time.Hour
+
+	// MaxRotationGracePeriod is the longest the previous version of a
+	// rotated secret stays valid.
+	MaxRotationGracePeriod = 30 * 24 * time.Hour
+
+	// previousSuffix suffixes the keys the previous versions of rotated
+	// secrets are stored at.
+	previousSuffix = "~previous"
+
+	rotationWebhookTimeout = 10 * time.Second
+)
+
+// Rotation is the version of a secret, and the time its previous version is
+// valid until.
+type Rotation struct {
+	OrgID   platform.ID `json:"orgID"`
+	Key     string      `json:"key"`
+	Version int         `json:"version"`
+	// RotatedAt is zero for a secret which was never rotated.
+	RotatedAt         time.Time `json:"rotatedAt,omitempty"`
+	PreviousExpiresAt time.Time `json:"previousExpiresAt,omitempty"`
+}
+
+// PreviousValid returns true if the previous version of the secret is valid at t.
+func (r Rotation) PreviousValid(t time.Time) bool {
+	return r.Version > 1 && t.Before(r.PreviousExpiresAt)
+}
+
+// Dependent is a resource which uses a secret.
+type Dependent struct {
+	ResourceType influxdb.ResourceType `json:"resourceType"`
+	ID           platform.ID           `json:"id"`
+	Name         string                `json:"name"`
+}
+
+// RotationEvent is sent to the rotation webhooks when a secret is rotated.
+type RotationEvent struct {
+	Rotation
+	Dependents []Dependent `json:"dependents"`
+}
+
+// DependentFinder finds the resources of an organization which use a secret.
+type DependentFinder interface {
+	FindSecretDependents(ctx context.Context, orgID platform.ID, key string) ([]Dependent, error)
+}
+
+// Rotator rotates secrets.
+type Rotator interface {
+	// RotateSecret replaces the value of a secret, and keeps its previous
+	// value valid for the grace period.
+	RotateSecret(ctx context.Context, orgID platform.ID, key, value string, grace time.Duration) (*RotationEvent, error)
+	// FindRotation returns the version of a secret.
+	FindRotation(ctx context.Context, orgID platform.ID, key string) (*Rotation, error)
+}
+
+// ValidSecretLoader loads the values of a secret which are valid, the
+// current value first.
+type ValidSecretLoader interface {
+	LoadValidSecrets(ctx context.Context, orgID platform.ID, key string) ([]string, error)
+}
+
+// ValidSecrets returns the values of a secret which are valid. The previous
+// value of a rotated secret is valid during its grace period, if svc keeps
+// the versions of secrets.
+func ValidSecrets(ctx context.Context, svc influxdb.SecretService, orgID platform.ID, key string) ([]string, error) {
+	if l, ok := svc.(ValidSecretLoader); ok {
+		return l.LoadValidSecrets(ctx, orgID, key)
+	}
+	v, err := svc.LoadSecret(ctx, orgID, key)
+	if err != nil {
+		return nil, err
+	}
+	return []string{v}, nil
+}
+
+// MatchSecret returns true if candidate is one of the valid values of the secret.
+func MatchSecret(ctx context.Context, svc influxdb.SecretService, orgID platform.ID, key, candidate string) (bool, error) {
+	values, err := ValidSecrets(ctx, svc, orgID, key)
+	if err != nil {
+		return false, err
+	}
+	for _, v := range values {
+		if v != "" && subtle.ConstantTimeCompare([]byte(candidate), []byte(v)) == 1 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+var (
+	_ influxdb.SecretService = (*RotationService)(nil)
+	_ Rotator                = (*RotationService)(nil)
+	_ ValidSecretLoader      = (*RotationService)(nil)
+)
+
+// RotationService keeps the versions of the secrets of a secret service. The
+// previous value of a rotated secret is stored in the secret service next to
+// its current value, and the versions are stored in the kv store. The
+// rotations of secrets are sent to the webhooks with the resources which use
+// the secrets.
+type RotationService struct {
+	log        *zap.Logger
+	s          influxdb.SecretService
+	kv         kv.Store
+	dependents DependentFinder
+	webhooks   []string
+	client     *http.Client
+
+	// mu serializes rotations.
+	mu sync.Mutex
+
+	TimeGenerator influxdb.TimeGenerator
+}
+
+// NewRotationService constructs a new RotationService. s must not be
+// authorized, the previous values of secrets are read in the background. The
+// rotations are sent with the resources dependents finds, if it is set.
+func NewRotationService(log *zap.Logger, s influxdb.SecretService, store kv.Store, dependents DependentFinder, webhooks ...string) *RotationService {
+	return &RotationService{
+		log:           log,
+		s:             s,
+		kv:            store,
+		dependents:    dependents,
+		webhooks:      webhooks,
+		client:        &http.Client{Timeout: rotationWebhookTimeout},
+		TimeGenerator: influxdb.RealTimeGenerator{},
+	}
+}
+
+// LoadSecret retrieves the secret value v found at key k for organization orgID.
+func (s *RotationService) LoadSecret(ctx context.Context, orgID platform.ID, k string) (string, error) {
+	return s.s.LoadSecret(ctx, orgID, k)
+}
+
+// GetSecretKeys retrieves all secret keys that are stored for the organization
+// orgID. The keys of previous versions are not returned.
+func (s *RotationService) GetSecretKeys(ctx context.Context, orgID platform.ID) ([]string, error) {
+	keys, err := s.s.GetSecretKeys(ctx, orgID)
+	if err != nil {
+		return keys, err
+	}
+
+	out := keys[:0]
+	for _, k := range keys {
+		if !strings.HasSuffix(k, previousSuffix) {
+			out = append(out, k)
+		}
+	}
+	return out, nil
+}
+
+// PutSecret stores the secret pair (k,v) for the organization orgID.
+func (s *RotationService) PutSecret(ctx context.Context, orgID platform.ID, k string, v string) error {
+	if err := validKey(k); err != nil {
+		return err
+	}
+	return s.s.PutSecret(ctx, orgID, k, v)
+}
+
+// PutSecrets puts all provided secrets and overwrites any previous values,
+// including the previous versions of rotated secrets.
+func (s *RotationService) PutSecrets(ctx context.Context, orgID platform.ID, m map[string]string) error {
+	for k := range m {
+		if err := validKey(k); err != nil {
+			return err
+		}
+	}
+	return s.s.PutSecrets(ctx, orgID, m)
+}
+
+// PatchSecrets patches all provided secrets and updates any previous values.
+func (s *RotationService) PatchSecrets(ctx context.Context, orgID platform.ID, m map[string]string) error {
+	for k := range m {
+		if err := validKey(k); err != nil {
+			return err
+		}
+	}
+	return s.s.PatchSecrets(ctx, orgID, m)
+}
+
+// DeleteSecret removes secrets, and their previous versions, from the secret store.
+func (s *RotationService) DeleteSecret(ctx context.Context, orgID platform.ID, ks ...string) error {
+	var previous []string
+	for _, k := range ks {
+		rot, err := s.findRotation(ctx, orgID, k)
+		if err != nil {
+			return err
+		}
+		if rot != nil {
+			previous = append(previous, k+previousSuffix)
+		}
+	}
+
+	if err := s.s.DeleteSecret(ctx, orgID, append(append([]string{}, ks...), previous...)...); err != nil {
+		return err
+	}
+	return s.kv.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(secretRotationBucket)
+		if err != nil {
+			return err
+		}
+		for _, k := range ks {
+			key, err := encodeSecretKey(orgID, k)
+			if err != nil {
+				return err
+			}
+			if err := b.Delete(key); err != nil && !kv.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// LoadValidSecrets returns the current value of a secret, and its previous
+// value during the grace period of its rotation.
+func (s *RotationService) LoadValidSecrets(ctx context.Context, orgID platform.ID, key string) ([]string, error) {
+	v, err := s.s.LoadSecret(ctx, orgID, key)
+	if err != nil {
+		return nil, err
+	}
+	values := []string{v}
+
+	rot, err := s.findRotation(ctx, orgID, key)
+	if err != nil {
+		return nil, err
+	}
+	if rot == nil || !rot.PreviousValid(s.TimeGenerator.Now()) {
+		return values, nil
+	}
+
+	prev, err := s.s.LoadSecret(ctx, orgID, key+previousSuffix)
+	if errors2.ErrorCode(err) == errors2.ENotFound {
+		return values, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(values, prev), nil
+}
+
+// FindRotation returns the version of a secret.
+func (s *RotationService) FindRotation(ctx context.Context, orgID platform.ID, key string) (*Rotation, error) {
+	if _, err := s.s.LoadSecret(ctx, orgID, key); err != nil {
+		return nil, err
+	}
+
+	rot, err := s.findRotation(ctx, orgID, key)
+	if err != nil {
+		return nil, err
+	}
+	if rot == nil {
+		return &Rotation{OrgID: orgID, Key: key, Version: 1}, nil
+	}
+	return rot, nil
+}
+
+// RotateSecret replaces the value of a secret, and keeps its previous value
+// valid for the grace period. The rotation is sent to the webhooks with the
+// resources which use the secret.
+func (s *RotationService) RotateSecret(ctx context.Context, orgID platform.ID, key, value string, grace time.Duration) (*RotationEvent, error) {
+	if value == "" {
+		return nil, &errors2.Error{
+			Code: errors2.EInvalid,
+			Msg:  "the rotated value of a secret must not be empty",
+		}
+	}
+	if grace < 0 || grace > MaxRotationGracePeriod {
+		return nil, &errors2.Error{
+			Code: errors2.EInvalid,
+			Msg:  "the grace period of a secret rotation must be between 0s and " + MaxRotationGracePeriod.String(),
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.s.LoadSecret(ctx, orgID, key)
+	if err != nil {
+		return nil, err
+	}
+	rot, err := s.FindRotation(ctx, orgID, key)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.TimeGenerator.Now()
+	rot.Version++
+	rot.RotatedAt = now
+	rot.PreviousExpiresAt = now.Add(grace)
+
+	secrets := map[string]string{key: value}
+	if grace > 0 {
+		secrets[key+previousSuffix] = current
+	}
+	if err := s.s.PatchSecrets(ctx, orgID, secrets); err != nil {
+		return nil, err
+	}
+	if grace == 0 {
+		if err := s.s.DeleteSecret(ctx, orgID, key+previousSuffix); err != nil && errors2.ErrorCode(err) != errors2.ENotFound {
+			return nil, err
+		}
+	}
+	if err := s.putRotation(ctx, rot); err != nil {
+		return nil, err
+	}
+
+	ev := &RotationEvent{Rotation: *rot, Dependents: []Dependent{}}
+	if s.dependents != nil {
+		deps, err := s.dependents.FindSecretDependents(ctx, orgID, key)
+		if err != nil {
+			s.log.Warn("Failed to find the dependents of rotated secret", zap.String("orgID", orgID.String()), zap.Error(err))
+		}
+		ev.Dependents = append(ev.Dependents, deps...)
+	}
+	s.notify(*ev)
+	return ev, nil
+}
+
+// notify logs the rotation, and posts it to the webhooks in the background.
+func (s *RotationService) notify(ev RotationEvent) {
+	s.log.Info("Secret rotated",
+		zap.String("orgID", ev.OrgID.String()),
+		zap.String("key", ev.Key),
+		zap.Int("version", ev.Version),
+		zap.Time("previousExpiresAt", ev.PreviousExpiresAt),
+		zap.Int("dependents", len(ev.Dependents)),
+	)
+	if len(s.webhooks) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(ev)
+	if err != nil {
+		s.log.Error("Failed to encode secret rotation", zap.Error(err))
+		return
+	}
+	for _, u := range s.webhooks {
+		go func(u string) {
+			ctx, cancel := context.WithTimeout(context.Background(), rotationWebhookTimeout)
+			defer cancel()
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+			if err != nil {
+				s.log.Warn("Failed to notify secret rotation webhook", zap.String("url", u), zap.Error(err))
+				return
+			}
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := s.client.Do(req)
+			if err != nil {
+				s.log.Warn("Failed to notify secret rotation webhook", zap.String("url", u), zap.Error(err))
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode/100 != 2 {
+				s.log.Warn("Secret rotation webhook failed", zap.String("url", u), zap.Int("statusCode", resp.StatusCode))
+			}
+		}(u)
+	}
+}
+
+func (s *RotationService) findRotation(ctx context.Context, orgID platform.ID, k string) (*Rotation, error) {
+	key, err := encodeSecretKey(orgID, k)
+	if err != nil {
+		return nil, err
+	}
+
+	var rot *Rotation
+	err = s.kv.View(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(secretRotationBucket)
+		if err != nil {
+			return err
+		}
+		v, err := b.Get(key)
+		if kv.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		rot = new(Rotation)
+		return json.Unmarshal(v, rot)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rot, nil
+}
+
+func (s *RotationService) putRotation(ctx context.Context, rot *Rotation) error {
+	key, err := encodeSecretKey(rot.OrgID, rot.Key)
+	if err != nil {
+		return err
+	}
+	v, err := json.Marshal(rot)
+	if err != nil {
+		return err
+	}
+	return s.kv.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(secretRotationBucket)
+		if err != nil {
+			return err
+		}
+		return b.Put(key, v)
+	})
+}
+
+func validKey(k string) error {
+	if strings.HasSuffix(k, previousSuffix) {
+		return &errors2.Error{
+			Code: errors2.EInvalid,
+			Msg:  "secret keys must not end with " + previousSuffix,
+		}
+	}
+	return nil
+}
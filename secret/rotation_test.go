@@ -0,0 +1,164 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/inmem"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv/migration/all"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type dependentsFn func(ctx context.Context, orgID platform.ID, key string) ([]Dependent, error)
+
+func (fn dependentsFn) FindSecretDependents(ctx context.Context, orgID platform.ID, key string) ([]Dependent, error) {
+	return fn(ctx, orgID, key)
+}
+
+func newTestRotationService(t *testing.T, dependents DependentFinder, webhooks ...string) *RotationService {
+	t.Helper()
+
+	store := inmem.NewKVStore()
+	require.NoError(t, all.Up(context.Background(), zaptest.NewLogger(t), store))
+	storage, err := NewStore(store)
+	require.NoError(t, err)
+
+	return NewRotationService(zaptest.NewLogger(t), NewService(storage), store, dependents, webhooks...)
+}
+
+func TestRotationService(t *testing.T) {
+	const orgID = platform.ID(1)
+	now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("keeps the previous value valid for the grace period", func(t *testing.T) {
+		ctx := context.Background()
+		svc := newTestRotationService(t, nil)
+		svc.TimeGenerator = mock.TimeGenerator{FakeValue: now}
+		require.NoError(t, svc.PutSecret(ctx, orgID, "token", "v1"))
+
+		ev, err := svc.RotateSecret(ctx, orgID, "token", "v2", time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, 2, ev.Version)
+		assert.Equal(t, now, ev.RotatedAt)
+		assert.Equal(t, now.Add(time.Hour), ev.PreviousExpiresAt)
+
+		v, err := svc.LoadSecret(ctx, orgID, "token")
+		require.NoError(t, err)
+		assert.Equal(t, "v2", v)
+
+		keys, err := svc.GetSecretKeys(ctx, orgID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"token"}, keys)
+
+		values, err := ValidSecrets(ctx, svc, orgID, "token")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v2", "v1"}, values)
+
+		ok, err := MatchSecret(ctx, svc, orgID, "token", "v1")
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		svc.TimeGenerator = mock.TimeGenerator{FakeValue: now.Add(time.Hour)}
+		ok, err = MatchSecret(ctx, svc, orgID, "token", "v1")
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		rot, err := svc.FindRotation(ctx, orgID, "token")
+		require.NoError(t, err)
+		assert.Equal(t, 2, rot.Version)
+	})
+
+	t.Run("without a grace period the previous value is invalid", func(t *testing.T) {
+		ctx := context.Background()
+		svc := newTestRotationService(t, nil)
+		svc.TimeGenerator = mock.TimeGenerator{FakeValue: now}
+		require.NoError(t, svc.PutSecret(ctx, orgID, "token", "v1"))
+
+		_, err := svc.RotateSecret(ctx, orgID, "token", "v2", 0)
+		require.NoError(t, err)
+
+		values, err := svc.LoadValidSecrets(ctx, orgID, "token")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v2"}, values)
+	})
+
+	t.Run("notifies the webhooks with the dependents of the secret", func(t *testing.T) {
+		events := make(chan RotationEvent, 1)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var ev RotationEvent
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&ev))
+			events <- ev
+		}))
+		defer srv.Close()
+
+		ctx := context.Background()
+		dependents := dependentsFn(func(ctx context.Context, id platform.ID, key string) ([]Dependent, error) {
+			assert.Equal(t, orgID, id)
+			assert.Equal(t, "token", key)
+			return []Dependent{{ResourceType: influxdb.TasksResourceType, ID: 3, Name: "task"}}, nil
+		})
+		svc := newTestRotationService(t, dependents, srv.URL)
+		svc.TimeGenerator = mock.TimeGenerator{FakeValue: now}
+		require.NoError(t, svc.PutSecret(ctx, orgID, "token", "v1"))
+
+		_, err := svc.RotateSecret(ctx, orgID, "token", "v2", time.Hour)
+		require.NoError(t, err)
+
+		select {
+		case ev := <-events:
+			assert.Equal(t, "token", ev.Key)
+			assert.Equal(t, 2, ev.Version)
+			assert.Equal(t, []Dependent{{ResourceType: influxdb.TasksResourceType, ID: 3, Name: "task"}}, ev.Dependents)
+		case <-time.After(5 * time.Second):
+			t.Fatal("rotation webhook was not notified")
+		}
+	})
+
+	t.Run("deleting a secret removes its previous value", func(t *testing.T) {
+		ctx := context.Background()
+		svc := newTestRotationService(t, nil)
+		svc.TimeGenerator = mock.TimeGenerator{FakeValue: now}
+		require.NoError(t, svc.PutSecret(ctx, orgID, "token", "v1"))
+		_, err := svc.RotateSecret(ctx, orgID, "token", "v2", time.Hour)
+		require.NoError(t, err)
+
+		require.NoError(t, svc.DeleteSecret(ctx, orgID, "token"))
+
+		_, err = svc.s.LoadSecret(ctx, orgID, "token"+previousSuffix)
+		assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		ctx := context.Background()
+		svc := newTestRotationService(t, nil)
+
+		_, err := svc.RotateSecret(ctx, orgID, "missing", "v2", time.Hour)
+		assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+
+		_, err = svc.RotateSecret(ctx, orgID, "token", "v2", MaxRotationGracePeriod+time.Hour)
+		assert.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+
+		err = svc.PatchSecrets(ctx, orgID, map[string]string{"token" + previousSuffix: "v0"})
+		assert.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+	})
+}
+
+func TestFluxSecretKeys(t *testing.T) {
+	keys := fluxSecretKeys(`
+import "influxdata/influxdb/secrets"
+
+token = secrets.get(key: "slack_token")
+from(bucket: "telegraf") |> range(start: -1m) |> yield(name: token)
+`)
+	assert.Equal(t, map[string]bool{"slack_token": true}, keys)
+}
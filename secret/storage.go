@@ -17,11 +17,13 @@ var secretBucket = []byte("secretsv1")
 // service layer.
 type Storage struct {
 	store kv.Store
+	// envelope encrypts the values of secrets, when it is set.
+	envelope *envelope
 }
 
 // NewStore creates a new storage system
 func NewStore(s kv.Store) (*Storage, error) {
-	return &Storage{s}, nil
+	return &Storage{store: s}, nil
 }
 
 func (s *Storage) View(ctx context.Context, fn func(kv.Tx) error) error {
@@ -56,7 +58,7 @@ func (s *Storage) GetSecret(ctx context.Context, tx kv.Tx, orgID platform.ID, k
 		return "", err
 	}
 
-	v, err := decodeSecretValue(val)
+	v, _, err := s.decodeSecretValue(key, val)
 	if err != nil {
 		return "", err
 	}
@@ -112,7 +114,10 @@ func (s *Storage) PutSecret(ctx context.Context, tx kv.Tx, orgID platform.ID, k,
 		return err
 	}
 
-	val := encodeSecretValue(v)
+	val, err := s.encodeSecretValue(key, v)
+	if err != nil {
+		return err
+	}
 
 	b, err := tx.Bucket(secretBucket)
 	if err != nil {
@@ -170,18 +175,34 @@ func decodeSecretKey(key []byte) (platform.ID, string, error) {
 	return id, k, nil
 }
 
-func decodeSecretValue(val []byte) (string, error) {
+// decodeSecretValue returns the value of the secret stored at key. current is
+// false when the value is not encrypted as it would be stored now.
+func (s *Storage) decodeSecretValue(key, val []byte) (v string, current bool, err error) {
+	if isEncryptedValue(val) {
+		if s.envelope == nil {
+			return "", false, &errors2.Error{
+				Code: errors2.EInternal,
+				Msg:  "secret is encrypted, but secret encryption is not enabled",
+			}
+		}
+		return s.envelope.decrypt(key, val)
+	}
+
 	// store the secret value base64 encoded so that it's marginally better than plaintext
-	v, err := base64.StdEncoding.DecodeString(string(val))
+	b, err := base64.StdEncoding.DecodeString(string(val))
 	if err != nil {
-		return "", err
+		return "", false, err
 	}
 
-	return string(v), nil
+	return string(b), s.envelope == nil, nil
 }
 
-func encodeSecretValue(v string) []byte {
+func (s *Storage) encodeSecretValue(key []byte, v string) ([]byte, error) {
+	if s.envelope != nil {
+		return s.envelope.encrypt(key, v)
+	}
+
 	val := make([]byte, base64.StdEncoding.EncodedLen(len(v)))
 	base64.StdEncoding.Encode(val, []byte(v))
-	return val
+	return val, nil
 }
@@ -0,0 +1,291 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+	"go.uber.org/zap"
+)
+
+var secretUsageBucket = []byte("secretusagev1")
+
+// DefaultUsageFlushInterval is how often the resolutions of secrets are
+// written to the store.
+const DefaultUsageFlushInterval = time.Minute
+
+// Usage is the resources which reference a secret, and the last time it was
+// resolved.
+type Usage struct {
+	OrgID platform.ID `json:"orgID"`
+	Key   string      `json:"key"`
+	// LastResolvedAt is nil for a secret which was never resolved.
+	LastResolvedAt *time.Time  `json:"lastResolvedAt,omitempty"`
+	References     []Dependent `json:"references"`
+	// Orphaned is true when no resource references the secret.
+	Orphaned bool `json:"orphaned"`
+}
+
+// UsageFinder finds the usage of secrets.
+type UsageFinder interface {
+	FindSecretUsage(ctx context.Context, orgID platform.ID, key string) (*Usage, error)
+}
+
+type usageKey struct {
+	orgID platform.ID
+	key   string
+}
+
+type usageRecord struct {
+	LastResolvedAt time.Time `json:"lastResolvedAt"`
+}
+
+// UsageTracker records the last time each secret of a secret service was
+// resolved. The resolutions are kept in memory, and written to the store
+// by Run.
+type UsageTracker struct {
+	log        *zap.Logger
+	s          influxdb.SecretService
+	kv         kv.Store
+	dependents DependentFinder
+
+	mu       sync.Mutex
+	resolved map[usageKey]time.Time
+
+	TimeGenerator influxdb.TimeGenerator
+}
+
+var _ influxdb.SecretService = (*UsageTracker)(nil)
+var _ UsageFinder = (*UsageTracker)(nil)
+
+// NewUsageTracker constructs a new UsageTracker. s must not be authorized.
+// The references of secrets are found with dependents, if it is set.
+func NewUsageTracker(log *zap.Logger, s influxdb.SecretService, store kv.Store, dependents DependentFinder) *UsageTracker {
+	return &UsageTracker{
+		log:           log,
+		s:             s,
+		kv:            store,
+		dependents:    dependents,
+		resolved:      make(map[usageKey]time.Time),
+		TimeGenerator: influxdb.RealTimeGenerator{},
+	}
+}
+
+// LoadSecret retrieves the secret value v found at key k for organization
+// orgID, and records its resolution.
+func (s *UsageTracker) LoadSecret(ctx context.Context, orgID platform.ID, k string) (string, error) {
+	v, err := s.s.LoadSecret(ctx, orgID, k)
+	if err != nil {
+		return "", err
+	}
+
+	// the previous versions of rotated secrets are resolutions of the secret.
+	key := usageKey{orgID: orgID, key: strings.TrimSuffix(k, previousSuffix)}
+	s.mu.Lock()
+	s.resolved[key] = s.TimeGenerator.Now()
+	s.mu.Unlock()
+	return v, nil
+}
+
+// GetSecretKeys retrieves all secret keys that are stored for the organization orgID.
+func (s *UsageTracker) GetSecretKeys(ctx context.Context, orgID platform.ID) ([]string, error) {
+	return s.s.GetSecretKeys(ctx, orgID)
+}
+
+// PutSecret stores the secret pair (k,v) for the organization orgID.
+func (s *UsageTracker) PutSecret(ctx context.Context, orgID platform.ID, k string, v string) error {
+	return s.s.PutSecret(ctx, orgID, k, v)
+}
+
+// PutSecrets puts all provided secrets and overwrites any previous values.
+func (s *UsageTracker) PutSecrets(ctx context.Context, orgID platform.ID, m map[string]string) error {
+	return s.s.PutSecrets(ctx, orgID, m)
+}
+
+// PatchSecrets patches all provided secrets and updates any previous values.
+func (s *UsageTracker) PatchSecrets(ctx context.Context, orgID platform.ID, m map[string]string) error {
+	return s.s.PatchSecrets(ctx, orgID, m)
+}
+
+// DeleteSecret removes secrets from the secret store, and forgets their resolutions.
+func (s *UsageTracker) DeleteSecret(ctx context.Context, orgID platform.ID, ks ...string) error {
+	if err := s.s.DeleteSecret(ctx, orgID, ks...); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	for _, k := range ks {
+		delete(s.resolved, usageKey{orgID: orgID, key: k})
+	}
+	s.mu.Unlock()
+
+	return s.kv.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(secretUsageBucket)
+		if err != nil {
+			return err
+		}
+		for _, k := range ks {
+			key, err := encodeSecretKey(orgID, k)
+			if err != nil {
+				return err
+			}
+			if err := b.Delete(key); err != nil && !kv.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FindSecretUsage returns the resources of the organization which reference
+// the secret, and the last time it was resolved.
+func (s *UsageTracker) FindSecretUsage(ctx context.Context, orgID platform.ID, key string) (*Usage, error) {
+	ks, err := s.s.GetSecretKeys(ctx, orgID)
+	if err != nil && errors2.ErrorCode(err) != errors2.ENotFound {
+		return nil, err
+	}
+	if !containsKey(ks, key) {
+		return nil, &errors2.Error{
+			Code: errors2.ENotFound,
+			Msg:  "secret not found",
+		}
+	}
+
+	u := &Usage{OrgID: orgID, Key: key, References: []Dependent{}}
+	if s.dependents != nil {
+		deps, err := s.dependents.FindSecretDependents(ctx, orgID, key)
+		if err != nil {
+			return nil, err
+		}
+		u.References = append(u.References, deps...)
+	}
+	u.Orphaned = len(u.References) == 0
+
+	s.mu.Lock()
+	t, ok := s.resolved[usageKey{orgID: orgID, key: key}]
+	s.mu.Unlock()
+	if ok {
+		u.LastResolvedAt = &t
+		return u, nil
+	}
+
+	rec, err := s.findRecord(ctx, orgID, key)
+	if err != nil {
+		return nil, err
+	}
+	if rec != nil {
+		u.LastResolvedAt = &rec.LastResolvedAt
+	}
+	return u, nil
+}
+
+// Run writes the resolutions of secrets to the store at every interval,
+// until ctx is done.
+func (s *UsageTracker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// the resolutions since the last flush are written on shutdown.
+			if err := s.Flush(context.Background()); err != nil {
+				s.log.Error("Failed to write the usage of secrets", zap.Error(err))
+			}
+			return
+		case <-ticker.C:
+			if err := s.Flush(ctx); err != nil {
+				s.log.Error("Failed to write the usage of secrets", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Flush writes the resolutions of secrets recorded since the last flush to
+// the store.
+func (s *UsageTracker) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	resolved := s.resolved
+	s.resolved = make(map[usageKey]time.Time)
+	s.mu.Unlock()
+
+	if len(resolved) == 0 {
+		return nil
+	}
+
+	err := s.kv.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(secretUsageBucket)
+		if err != nil {
+			return err
+		}
+		for k, t := range resolved {
+			key, err := encodeSecretKey(k.orgID, k.key)
+			if err != nil {
+				return err
+			}
+			v, err := json.Marshal(usageRecord{LastResolvedAt: t})
+			if err != nil {
+				return err
+			}
+			if err := b.Put(key, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// keep the resolutions which failed to be written, unless the
+		// secret was resolved again since.
+		s.mu.Lock()
+		for k, t := range resolved {
+			if _, ok := s.resolved[k]; !ok {
+				s.resolved[k] = t
+			}
+		}
+		s.mu.Unlock()
+	}
+	return err
+}
+
+func (s *UsageTracker) findRecord(ctx context.Context, orgID platform.ID, k string) (*usageRecord, error) {
+	key, err := encodeSecretKey(orgID, k)
+	if err != nil {
+		return nil, err
+	}
+
+	var rec *usageRecord
+	err = s.kv.View(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(secretUsageBucket)
+		if err != nil {
+			return err
+		}
+		v, err := b.Get(key)
+		if kv.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		rec = new(usageRecord)
+		return json.Unmarshal(v, rec)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func containsKey(ks []string, key string) bool {
+	for _, k := range ks {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
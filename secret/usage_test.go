@@ -0,0 +1,99 @@
+package secret
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/inmem"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv/migration/all"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func newTestUsageTracker(t *testing.T, dependents DependentFinder) *UsageTracker {
+	t.Helper()
+
+	store := inmem.NewKVStore()
+	require.NoError(t, all.Up(context.Background(), zaptest.NewLogger(t), store))
+	storage, err := NewStore(store)
+	require.NoError(t, err)
+
+	return NewUsageTracker(zaptest.NewLogger(t), NewService(storage), store, dependents)
+}
+
+func TestUsageTracker(t *testing.T) {
+	const orgID = platform.ID(1)
+	now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("records the last resolution of a secret", func(t *testing.T) {
+		ctx := context.Background()
+		svc := newTestUsageTracker(t, nil)
+		svc.TimeGenerator = mock.TimeGenerator{FakeValue: now}
+		require.NoError(t, svc.PutSecret(ctx, orgID, "token", "v1"))
+
+		u, err := svc.FindSecretUsage(ctx, orgID, "token")
+		require.NoError(t, err)
+		assert.Nil(t, u.LastResolvedAt)
+		assert.True(t, u.Orphaned)
+
+		_, err = svc.LoadSecret(ctx, orgID, "token")
+		require.NoError(t, err)
+
+		u, err = svc.FindSecretUsage(ctx, orgID, "token")
+		require.NoError(t, err)
+		require.NotNil(t, u.LastResolvedAt)
+		assert.Equal(t, now, *u.LastResolvedAt)
+
+		// the resolution is kept once it is written to the store.
+		require.NoError(t, svc.Flush(ctx))
+		u, err = svc.FindSecretUsage(ctx, orgID, "token")
+		require.NoError(t, err)
+		require.NotNil(t, u.LastResolvedAt)
+		assert.True(t, now.Equal(*u.LastResolvedAt))
+	})
+
+	t.Run("returns the references of a secret", func(t *testing.T) {
+		ctx := context.Background()
+		deps := &Dependents{}
+		deps.Register(dependentsFn(func(ctx context.Context, id platform.ID, key string) ([]Dependent, error) {
+			return []Dependent{{ResourceType: influxdb.ChecksResourceType, ID: 3, Name: "check"}}, nil
+		}))
+		svc := newTestUsageTracker(t, deps)
+		require.NoError(t, svc.PutSecret(ctx, orgID, "token", "v1"))
+
+		u, err := svc.FindSecretUsage(ctx, orgID, "token")
+		require.NoError(t, err)
+		assert.Equal(t, []Dependent{{ResourceType: influxdb.ChecksResourceType, ID: 3, Name: "check"}}, u.References)
+		assert.False(t, u.Orphaned)
+	})
+
+	t.Run("deleting a secret forgets its resolutions", func(t *testing.T) {
+		ctx := context.Background()
+		svc := newTestUsageTracker(t, nil)
+		svc.TimeGenerator = mock.TimeGenerator{FakeValue: now}
+		require.NoError(t, svc.PutSecret(ctx, orgID, "token", "v1"))
+		_, err := svc.LoadSecret(ctx, orgID, "token")
+		require.NoError(t, err)
+		require.NoError(t, svc.Flush(ctx))
+
+		require.NoError(t, svc.DeleteSecret(ctx, orgID, "token"))
+		require.NoError(t, svc.PutSecret(ctx, orgID, "token", "v2"))
+
+		u, err := svc.FindSecretUsage(ctx, orgID, "token")
+		require.NoError(t, err)
+		assert.Nil(t, u.LastResolvedAt)
+	})
+
+	t.Run("missing secret", func(t *testing.T) {
+		svc := newTestUsageTracker(t, nil)
+
+		_, err := svc.FindSecretUsage(context.Background(), orgID, "missing")
+		assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+	})
+}
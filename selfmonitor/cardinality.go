@@ -0,0 +1,269 @@
+package selfmonitor
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/notification/check"
+	"github.com/influxdata/influxdb/v2/task/taskmodel"
+	"go.uber.org/zap"
+)
+
+const (
+	// CardinalityCheckName is the name of the managed check of the series
+	// growth of buckets.
+	CardinalityCheckName = "Series cardinality growth"
+
+	// CardinalityMeasurement is the measurement the series of the buckets are
+	// written to in the system bucket.
+	CardinalityMeasurement = "bucket_series"
+
+	// cardinalityCheckTag tags the statuses of the managed check, so that
+	// notification rules can match them.
+	cardinalityCheckTag = "system"
+)
+
+// CardinalityConfig configures the managed check of the series growth of
+// buckets.
+type CardinalityConfig struct {
+	// Enabled enables measuring the series of the buckets, and the check.
+	Enabled bool
+	// Every is how often the series are measured and checked.
+	Every time.Duration
+	// Warn and Crit are the growth rates, in new series per hour, of a
+	// bucket above which its status is warn and crit. Zero disables a
+	// level.
+	Warn float64
+	Crit float64
+}
+
+// Valid returns an error if the config can't configure a check.
+func (c CardinalityConfig) Valid() error {
+	if c.Every <= 0 {
+		return fmt.Errorf("cardinality check interval must be positive")
+	}
+	if c.Warn < 0 || c.Crit < 0 {
+		return fmt.Errorf("cardinality check growth rates must not be negative")
+	}
+	if c.Warn == 0 && c.Crit == 0 {
+		return fmt.Errorf("cardinality check needs a warn or crit growth rate")
+	}
+	return nil
+}
+
+// SeriesCounter counts the series of buckets.
+type SeriesCounter interface {
+	SeriesCardinality(ctx context.Context, bucketID platform.ID) int64
+}
+
+// CardinalityCheck measures the series of every bucket with the engine, writes
+// them into the system bucket of a Monitor, and manages a check alerting on
+// the buckets whose series grow faster than configured. The check is a custom
+// check of the organization of the system bucket, so its statuses are routed
+// by the notification rules of that organization like any other.
+type CardinalityCheck struct {
+	log *zap.Logger
+	cfg CardinalityConfig
+
+	monitor *Monitor
+	series  SeriesCounter
+	checks  influxdb.CheckService
+	tasks   taskmodel.TaskService
+	urms    influxdb.UserResourceMappingService
+
+	mu     sync.Mutex
+	synced bool // whether the check is in sync with the config.
+}
+
+// NewCardinalityCheck constructs the managed check of the series growth of
+// buckets, which writes the series it measures into the system bucket of m.
+func NewCardinalityCheck(log *zap.Logger, cfg CardinalityConfig, m *Monitor, series SeriesCounter, checks influxdb.CheckService, tasks taskmodel.TaskService, urms influxdb.UserResourceMappingService) (*CardinalityCheck, error) {
+	if err := cfg.Valid(); err != nil {
+		return nil, err
+	}
+	return &CardinalityCheck{
+		log:     log,
+		cfg:     cfg,
+		monitor: m,
+		series:  series,
+		checks:  checks,
+		tasks:   tasks,
+		urms:    urms,
+	}, nil
+}
+
+// Run measures the series of the buckets every interval of the check until
+// ctx is done, and syncs the check once the system bucket exists.
+func (c *CardinalityCheck) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.Every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case t := <-ticker.C:
+			if err := c.Measure(ctx, t); err != nil && ctx.Err() == nil {
+				c.log.Warn("Failed to measure the series of the buckets", zap.Error(err))
+			}
+			if err := c.Sync(ctx); err != nil && ctx.Err() == nil {
+				c.log.Warn("Failed to sync the cardinality check", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Measure writes the series of every bucket, timestamped now, into the system
+// bucket. Nothing is written until the system bucket exists.
+func (c *CardinalityCheck) Measure(ctx context.Context, now time.Time) error {
+	sb, err := c.monitor.systemBucket(ctx)
+	if err != nil || sb == nil {
+		return err
+	}
+
+	buckets, _, err := c.monitor.buckets.FindBuckets(ctx, influxdb.BucketFilter{})
+	if err != nil {
+		return err
+	}
+	points := make([]models.Point, 0, len(buckets))
+	for _, b := range buckets {
+		if b.Type == influxdb.BucketTypeSystem {
+			continue
+		}
+		tags := models.NewTags(map[string]string{
+			"bucket":    b.Name,
+			"bucket_id": b.ID.String(),
+			"org_id":    b.OrgID.String(),
+		})
+		fields := models.Fields{"series": c.series.SeriesCardinality(ctx, b.ID)}
+		p, err := models.NewPoint(CardinalityMeasurement, tags, fields, now)
+		if err != nil {
+			return err
+		}
+		points = append(points, p)
+	}
+	if len(points) == 0 {
+		return nil
+	}
+	return c.monitor.writer.WritePoints(ctx, sb.OrgID, sb.ID, points)
+}
+
+// Sync creates the check in the organization of the system bucket, or updates
+// it with the config when it exists. The check is owned by an owner of the
+// organization, and an update keeps the status of the check, so a check which
+// was deactivated stays inactive. Nothing is done until the system bucket
+// exists and the organization has an owner.
+func (c *CardinalityCheck) Sync(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.synced {
+		return nil
+	}
+
+	sb, err := c.monitor.systemBucket(ctx)
+	if err != nil || sb == nil {
+		return err
+	}
+	urms, _, err := c.urms.FindUserResourceMappings(ctx, influxdb.UserResourceMappingFilter{
+		ResourceType: influxdb.OrgsResourceType,
+		ResourceID:   sb.OrgID,
+		UserType:     influxdb.Owner,
+	}, influxdb.FindOptions{Limit: 1})
+	if err != nil || len(urms) == 0 {
+		return err
+	}
+
+	chk := c.check(sb)
+	name := CardinalityCheckName
+	current, err := c.checks.FindCheck(ctx, influxdb.CheckFilter{Name: &name, OrgID: &sb.OrgID})
+	switch {
+	case err == nil:
+		task, err := c.tasks.FindTaskByID(ctx, current.GetTaskID())
+		if err != nil {
+			return err
+		}
+		if _, err := c.checks.UpdateCheck(ctx, current.GetID(), influxdb.CheckCreate{
+			Check:  chk,
+			Status: influxdb.Status(task.Status),
+		}); err != nil {
+			return err
+		}
+	case errors.ErrorCode(err) == errors.ENotFound:
+		if err := c.checks.CreateCheck(ctx, influxdb.CheckCreate{
+			Check:  chk,
+			Status: influxdb.Active,
+		}, urms[0].UserID); err != nil {
+			return err
+		}
+		c.log.Info("Created the cardinality check", zap.Stringer("org_id", sb.OrgID), zap.Stringer("check_id", chk.ID))
+	default:
+		return err
+	}
+	c.synced = true
+	return nil
+}
+
+func (c *CardinalityCheck) check(sb *influxdb.Bucket) *check.Custom {
+	return &check.Custom{
+		Name:        CardinalityCheckName,
+		Description: "Managed by influxd. Alerts on the buckets whose series grow faster than configured.",
+		OrgID:       sb.OrgID,
+		Query: influxdb.DashboardQuery{
+			Text:     c.flux(sb.Name),
+			EditMode: "advanced",
+		},
+	}
+}
+
+// flux returns the script of the check. The growth rate of a bucket is the
+// derivative of its series per hour between the last measurements, which is
+// given to the levels in the series column.
+func (c *CardinalityCheck) flux(bucket string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `import "influxdata/influxdb/monitor"
+import "influxdata/influxdb/v1"
+
+option task = {name: %q, every: %s, offset: 0s}
+
+check = {_check_id: "", _check_name: %q, _type: "custom", tags: {%s: "cardinality"}}
+`, CardinalityCheckName, c.cfg.Every, CardinalityCheckName, cardinalityCheckTag)
+
+	var levels []string
+	if c.cfg.Crit > 0 {
+		fmt.Fprintf(&sb, "crit = (r) => r.series > %s\n", fluxFloat(c.cfg.Crit))
+		levels = append(levels, "crit: crit")
+	}
+	if c.cfg.Warn > 0 {
+		fmt.Fprintf(&sb, "warn = (r) => r.series > %s\n", fluxFloat(c.cfg.Warn))
+		levels = append(levels, "warn: warn")
+	}
+
+	fmt.Fprintf(&sb, `messageFn = (r) => "Series of bucket ${r.bucket} (${r.bucket_id}) grew by ${string(v: int(v: r.series))} per hour"
+
+from(bucket: %q)
+	|> range(start: -%s)
+	|> filter(fn: (r) => r._measurement == %q and r._field == "series")
+	|> derivative(unit: 1h, nonNegative: true)
+	|> last()
+	|> v1.fieldsAsCols()
+	|> monitor.check(data: check, messageFn: messageFn, %s)
+`, bucket, 4*c.cfg.Every, CardinalityMeasurement, strings.Join(levels, ", "))
+	return sb.String()
+}
+
+// fluxFloat formats v as a flux float literal, which must have a fraction so
+// that it isn't an int.
+func fluxFloat(v float64) string {
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
@@ -0,0 +1,88 @@
+package selfmonitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type seriesCounter map[platform.ID]int64
+
+func (s seriesCounter) SeriesCardinality(_ context.Context, bucketID platform.ID) int64 {
+	return s[bucketID]
+}
+
+func TestCardinalityConfig_Valid(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		cfg  CardinalityConfig
+		ok   bool
+	}{
+		{name: "crit", cfg: CardinalityConfig{Every: time.Minute, Crit: 10}, ok: true},
+		{name: "warn", cfg: CardinalityConfig{Every: time.Minute, Warn: 10}, ok: true},
+		{name: "no levels", cfg: CardinalityConfig{Every: time.Minute}},
+		{name: "negative", cfg: CardinalityConfig{Every: time.Minute, Warn: -1, Crit: 10}},
+		{name: "no interval", cfg: CardinalityConfig{Crit: 10}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Valid()
+			if tt.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestCardinalityCheck_Measure(t *testing.T) {
+	ctx := context.Background()
+	m, ts, w := newTestMonitor(t, Config{})
+
+	series := seriesCounter{}
+	c, err := NewCardinalityCheck(zaptest.NewLogger(t), CardinalityConfig{Every: time.Minute, Crit: 100}, m, series, nil, nil, nil)
+	require.NoError(t, err)
+
+	// nothing is measured before the server has an organization.
+	require.NoError(t, c.Measure(ctx, time.Unix(1, 0)))
+	assert.Empty(t, w.writes)
+
+	org := &influxdb.Organization{Name: "influx"}
+	require.NoError(t, ts.CreateOrganization(ctx, org))
+	b := &influxdb.Bucket{OrgID: org.ID, Name: "telegraf"}
+	require.NoError(t, ts.CreateBucket(ctx, b))
+	series[b.ID] = 42
+
+	require.NoError(t, c.Measure(ctx, time.Unix(2, 0)))
+	require.Len(t, w.writes, 1)
+	sb, err := ts.FindBucketByName(ctx, org.ID, influxdb.InternalSystemBucketName)
+	require.NoError(t, err)
+	assert.Equal(t, sb.ID, w.writes[0].bucketID)
+
+	// the system buckets aren't measured.
+	require.Len(t, w.writes[0].points, 1)
+	p := w.writes[0].points[0]
+	assert.Equal(t, CardinalityMeasurement, string(p.Name()))
+	assert.Equal(t, "telegraf", string(p.Tags().Get([]byte("bucket"))))
+	assert.Equal(t, b.ID.String(), string(p.Tags().Get([]byte("bucket_id"))))
+	fields, err := p.Fields()
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), fields["series"])
+}
+
+func TestCardinalityCheck_Flux(t *testing.T) {
+	c := &CardinalityCheck{cfg: CardinalityConfig{Every: 10 * time.Minute, Crit: 1000}}
+	script := c.flux(influxdb.InternalSystemBucketName)
+
+	assert.Contains(t, script, `option task = {name: "Series cardinality growth", every: 10m0s, offset: 0s}`)
+	assert.Contains(t, script, `crit = (r) => r.series > 1000.0`)
+	assert.NotContains(t, script, `warn =`)
+	assert.Contains(t, script, `|> range(start: -40m0s)`)
+	assert.Contains(t, script, `|> monitor.check(data: check, messageFn: messageFn, crit: crit)`)
+}
@@ -0,0 +1,170 @@
+// Package selfmonitor writes the statistics of the server into a system bucket
+// of its own, so the server can be dashboarded with its own data.
+package selfmonitor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/gather"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// Config configures the self-monitoring of the server.
+type Config struct {
+	// Enabled enables writing the statistics of the server.
+	Enabled bool
+	// Interval is how often the statistics are written.
+	Interval time.Duration
+	// Retention is the retention period of the system bucket.
+	Retention time.Duration
+	// Org is the name of the organization of the system bucket. When empty,
+	// the first organization is used.
+	Org string
+	// Cardinality configures the managed check of the series growth of
+	// buckets.
+	Cardinality CardinalityConfig
+}
+
+// PointsWriter writes points to storage.
+type PointsWriter interface {
+	WritePoints(ctx context.Context, orgID platform.ID, bucketID platform.ID, points []models.Point) error
+}
+
+// Monitor writes the metrics of a prometheus gatherer into the
+// influxdb.InternalSystemBucketName system bucket every interval. The engine,
+// kv, task and HTTP statistics of the server are all gathered by its registry.
+type Monitor struct {
+	log *zap.Logger
+	cfg Config
+
+	gatherer prometheus.Gatherer
+	orgs     influxdb.OrganizationService
+	buckets  influxdb.BucketService
+	writer   PointsWriter
+
+	mu     sync.Mutex
+	bucket *influxdb.Bucket // the system bucket, once found or created.
+}
+
+// New constructs the self-monitoring of the server.
+func New(log *zap.Logger, cfg Config, gatherer prometheus.Gatherer, orgs influxdb.OrganizationService, buckets influxdb.BucketService, writer PointsWriter) (*Monitor, error) {
+	if cfg.Interval <= 0 {
+		return nil, fmt.Errorf("self-monitoring interval must be positive")
+	}
+	if cfg.Retention < 0 {
+		return nil, fmt.Errorf("self-monitoring retention must not be negative")
+	}
+	return &Monitor{
+		log:      log,
+		cfg:      cfg,
+		gatherer: gatherer,
+		orgs:     orgs,
+		buckets:  buckets,
+		writer:   writer,
+	}, nil
+}
+
+// Run writes the statistics every interval until ctx is done.
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case t := <-ticker.C:
+			if err := m.Write(ctx, t); err != nil && ctx.Err() == nil {
+				m.log.Warn("Failed to write the statistics of the server", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Write gathers the statistics and writes them, timestamped now, into the
+// system bucket. Nothing is written until the server has an organization.
+func (m *Monitor) Write(ctx context.Context, now time.Time) error {
+	b, err := m.systemBucket(ctx)
+	if err != nil || b == nil {
+		return err
+	}
+
+	families, err := m.gatherer.Gather()
+	if err != nil {
+		// the metrics which were gathered are still written.
+		m.log.Debug("Failed to gather some statistics of the server", zap.Error(err))
+	}
+	points, err := gather.FamilyMetrics(families, now).Points()
+	if err != nil {
+		return err
+	}
+	if len(points) == 0 {
+		return nil
+	}
+	return m.writer.WritePoints(ctx, b.OrgID, b.ID, points)
+}
+
+// systemBucket returns the system bucket, creating it in the configured
+// organization when it does not exist yet. A nil bucket is returned while
+// the organization does not exist.
+func (m *Monitor) systemBucket(ctx context.Context) (*influxdb.Bucket, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.bucket != nil {
+		return m.bucket, nil
+	}
+
+	org, err := m.findOrg(ctx)
+	if err != nil || org == nil {
+		return nil, err
+	}
+
+	b, err := m.buckets.FindBucketByName(ctx, org.ID, influxdb.InternalSystemBucketName)
+	switch {
+	case err == nil:
+		if b.RetentionPeriod != m.cfg.Retention {
+			if b, err = m.buckets.UpdateBucket(ctx, b.ID, influxdb.BucketUpdate{RetentionPeriod: &m.cfg.Retention}); err != nil {
+				return nil, err
+			}
+		}
+	case errors.ErrorCode(err) == errors.ENotFound:
+		b = &influxdb.Bucket{
+			OrgID:           org.ID,
+			Type:            influxdb.BucketTypeSystem,
+			Name:            influxdb.InternalSystemBucketName,
+			RetentionPeriod: m.cfg.Retention,
+			Description:     "System bucket for the statistics of the server",
+		}
+		if err := m.buckets.CreateBucket(ctx, b); err != nil {
+			return nil, err
+		}
+		m.log.Info("Created the self-monitoring system bucket", zap.Stringer("org_id", org.ID), zap.Stringer("bucket_id", b.ID))
+	default:
+		return nil, err
+	}
+	m.bucket = b
+	return b, nil
+}
+
+func (m *Monitor) findOrg(ctx context.Context) (*influxdb.Organization, error) {
+	if m.cfg.Org != "" {
+		org, err := m.orgs.FindOrganization(ctx, influxdb.OrganizationFilter{Name: &m.cfg.Org})
+		if errors.ErrorCode(err) == errors.ENotFound {
+			return nil, nil
+		}
+		return org, err
+	}
+
+	orgs, _, err := m.orgs.FindOrganizations(ctx, influxdb.OrganizationFilter{}, influxdb.FindOptions{Limit: 1})
+	if err != nil || len(orgs) == 0 {
+		return nil, err
+	}
+	return orgs[0], nil
+}
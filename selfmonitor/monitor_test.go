@@ -0,0 +1,111 @@
+package selfmonitor
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/inmem"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kv/migration/all"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type write struct {
+	orgID, bucketID platform.ID
+	points          []models.Point
+}
+
+type recordingWriter struct {
+	mu     sync.Mutex
+	writes []write
+}
+
+func (w *recordingWriter) WritePoints(_ context.Context, orgID, bucketID platform.ID, points []models.Point) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writes = append(w.writes, write{orgID: orgID, bucketID: bucketID, points: points})
+	return nil
+}
+
+func newTestMonitor(t *testing.T, cfg Config) (*Monitor, *tenant.Service, *recordingWriter) {
+	t.Helper()
+	store := inmem.NewKVStore()
+	require.NoError(t, all.Up(context.Background(), zaptest.NewLogger(t), store))
+	ts := tenant.NewService(tenant.NewStore(store))
+
+	reg := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "http",
+		Subsystem: "api",
+		Name:      "requests_total",
+	}, []string{"path"})
+	requests.WithLabelValues("/api/v2/write").Add(3)
+	reg.MustRegister(requests)
+
+	cfg.Interval = time.Minute
+	w := &recordingWriter{}
+	m, err := New(zaptest.NewLogger(t), cfg, reg, ts, ts, w)
+	require.NoError(t, err)
+	return m, ts, w
+}
+
+func TestMonitor_Write(t *testing.T) {
+	ctx := context.Background()
+	m, ts, w := newTestMonitor(t, Config{Retention: influxdb.InternalSystemBucketRetention})
+
+	// nothing is written before the server has an organization.
+	require.NoError(t, m.Write(ctx, time.Unix(1, 0)))
+	assert.Empty(t, w.writes)
+
+	org := &influxdb.Organization{Name: "influx"}
+	require.NoError(t, ts.CreateOrganization(ctx, org))
+
+	require.NoError(t, m.Write(ctx, time.Unix(2, 0)))
+	require.Len(t, w.writes, 1)
+	b, err := ts.FindBucketByName(ctx, org.ID, influxdb.InternalSystemBucketName)
+	require.NoError(t, err)
+	assert.Equal(t, influxdb.BucketTypeSystem, b.Type)
+	assert.Equal(t, influxdb.InternalSystemBucketRetention, b.RetentionPeriod)
+	assert.Equal(t, org.ID, w.writes[0].orgID)
+	assert.Equal(t, b.ID, w.writes[0].bucketID)
+
+	require.Len(t, w.writes[0].points, 1)
+	assert.Equal(t, "http_api_requests_total,path=/api/v2/write counter=3 2000000000", w.writes[0].points[0].String())
+}
+
+func TestMonitor_Org(t *testing.T) {
+	ctx := context.Background()
+	m, ts, w := newTestMonitor(t, Config{Org: "monitoring", Retention: time.Hour})
+
+	first := &influxdb.Organization{Name: "first"}
+	require.NoError(t, ts.CreateOrganization(ctx, first))
+	require.NoError(t, m.Write(ctx, time.Unix(1, 0)))
+	assert.Empty(t, w.writes)
+
+	org := &influxdb.Organization{Name: "monitoring"}
+	require.NoError(t, ts.CreateOrganization(ctx, org))
+	// the retention of an existing system bucket follows the configuration.
+	require.NoError(t, ts.CreateBucket(ctx, &influxdb.Bucket{
+		OrgID: org.ID,
+		Type:  influxdb.BucketTypeSystem,
+		Name:  influxdb.InternalSystemBucketName,
+	}))
+
+	require.NoError(t, m.Write(ctx, time.Unix(2, 0)))
+	require.Len(t, w.writes, 1)
+	assert.Equal(t, org.ID, w.writes[0].orgID)
+	b, err := ts.FindBucketByName(ctx, org.ID, influxdb.InternalSystemBucketName)
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, b.RetentionPeriod)
+
+	_, err = ts.FindBucketByName(ctx, first.ID, influxdb.InternalSystemBucketName)
+	assert.Error(t, err)
+}
@@ -43,6 +43,11 @@ type Session struct {
 	ExpiresAt   time.Time    `json:"expiresAt"`
 	UserID      platform.ID  `json:"userID,omitempty"`
 	Permissions []Permission `json:"permissions,omitempty"`
+	// LastActiveAt is the last time the session was used, to the minute.
+	LastActiveAt time.Time `json:"lastActiveAt"`
+	// IP and UserAgent are of the client which created the session.
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"userAgent,omitempty"`
 }
 
 // Expired returns an error if the session is expired.
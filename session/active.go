@@ -0,0 +1,97 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// activityResolution is how often the last activity of a session is written,
+// so requests don't write their session each time.
+const activityResolution = time.Minute
+
+// UserSessionService lists and revokes the active sessions of users.
+type UserSessionService interface {
+	// FindUserSessions returns the active sessions of a user, oldest first.
+	FindUserSessions(ctx context.Context, userID platform.ID) ([]*influxdb.Session, error)
+	// RevokeUserSession expires a session of a user.
+	RevokeUserSession(ctx context.Context, userID, id platform.ID) error
+	// RevokeUserSessions expires every session of a user.
+	RevokeUserSessions(ctx context.Context, userID platform.ID) error
+}
+
+var _ UserSessionService = (*Service)(nil)
+
+type clientContextKey struct{}
+
+type client struct {
+	ip        string
+	userAgent string
+}
+
+// withClient records the client the sessions created with ctx are for.
+func withClient(ctx context.Context, ip, userAgent string) context.Context {
+	return context.WithValue(ctx, clientContextKey{}, client{ip: ip, userAgent: userAgent})
+}
+
+func clientFromContext(ctx context.Context) client {
+	c, _ := ctx.Value(clientContextKey{}).(client)
+	return c
+}
+
+// FindUserSessions returns the active sessions of a user.
+func (s *Service) FindUserSessions(ctx context.Context, userID platform.ID) ([]*influxdb.Session, error) {
+	return s.store.FindSessionsByUserID(ctx, userID)
+}
+
+// RevokeUserSession expires a session of a user.
+func (s *Service) RevokeUserSession(ctx context.Context, userID, id platform.ID) error {
+	session, err := s.store.FindSessionByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID {
+		return &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  influxdb.ErrSessionNotFound,
+		}
+	}
+	return s.store.DeleteSession(ctx, id)
+}
+
+// RevokeUserSessions expires every session of a user.
+func (s *Service) RevokeUserSessions(ctx context.Context, userID platform.ID) error {
+	sessions, err := s.store.FindSessionsByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if err := s.store.DeleteSession(ctx, session.ID); err != nil && errors.ErrorCode(err) != errors.ENotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+// TouchSession records the activity of a session at, if it was last active
+// over a minute before.
+func (s *Storage) TouchSession(ctx context.Context, id platform.ID, at time.Time) error {
+	session, err := s.FindSessionByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if at.Sub(session.LastActiveAt) < activityResolution {
+		return nil
+	}
+
+	session.LastActiveAt = at
+	b, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return s.store.Set(sessionID(session.ID), string(b), session.ExpiresAt)
+}
@@ -0,0 +1,67 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_UserSessions(t *testing.T) {
+	now := time.Now().UTC().Round(0)
+	svc, _, u, _ := newTestPolicyService(t, now, Policy{Length: time.Hour})
+	ctx := withClient(context.Background(), "10.0.0.1", "curl/7.79.1")
+
+	s1, err := svc.CreateSession(ctx, "user")
+	require.NoError(t, err)
+	s2, err := svc.CreateSession(ctx, "user")
+	require.NoError(t, err)
+
+	t.Run("lists the sessions of the user with their clients", func(t *testing.T) {
+		sessions, err := svc.FindUserSessions(ctx, u.ID)
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+		assert.Equal(t, "10.0.0.1", sessions[0].IP)
+		assert.Equal(t, "curl/7.79.1", sessions[0].UserAgent)
+		assert.Equal(t, now, sessions[0].LastActiveAt)
+	})
+
+	t.Run("records the activity of sessions", func(t *testing.T) {
+		svc.now = func() time.Time { return now.Add(30 * time.Second) }
+		require.NoError(t, svc.RenewSession(ctx, s1, now.Add(time.Hour)))
+		found, err := svc.store.FindSessionByID(ctx, s1.ID)
+		require.NoError(t, err)
+		assert.Equal(t, now, found.LastActiveAt)
+
+		svc.now = func() time.Time { return now.Add(2 * time.Minute) }
+		require.NoError(t, svc.RenewSession(ctx, s1, now.Add(time.Hour)))
+		found, err = svc.store.FindSessionByID(ctx, s1.ID)
+		require.NoError(t, err)
+		assert.Equal(t, now.Add(2*time.Minute), found.LastActiveAt)
+		svc.now = func() time.Time { return now }
+	})
+
+	t.Run("revokes a session of the user", func(t *testing.T) {
+		err := svc.RevokeUserSession(ctx, u.ID+1, s1.ID)
+		assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+
+		require.NoError(t, svc.RevokeUserSession(ctx, u.ID, s1.ID))
+		_, err = svc.FindSession(ctx, s1.Key)
+		assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+		_, err = svc.FindSession(ctx, s2.Key)
+		require.NoError(t, err)
+	})
+
+	t.Run("revokes every session of the user", func(t *testing.T) {
+		_, err := svc.CreateSession(ctx, "user")
+		require.NoError(t, err)
+
+		require.NoError(t, svc.RevokeUserSessions(ctx, u.ID))
+		sessions, err := svc.FindUserSessions(ctx, u.ID)
+		require.NoError(t, err)
+		assert.Empty(t, sessions)
+	})
+}
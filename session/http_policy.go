@@ -0,0 +1,251 @@
+package session
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const prefixSessionPolicies = "/api/v2/session-policies"
+
+// PolicyHandler is the handler for the session policies of organizations.
+type PolicyHandler struct {
+	chi.Router
+
+	api *kithttp.API
+	log *zap.Logger
+
+	policySvc OrgPolicyService
+	limitSvc  UserLimitService
+}
+
+// NewPolicyHandler returns a new instance of PolicyHandler.
+func NewPolicyHandler(log *zap.Logger, policySvc OrgPolicyService, limitSvc UserLimitService) *PolicyHandler {
+	h := &PolicyHandler{
+		log:       log,
+		api:       kithttp.NewAPI(kithttp.WithLog(log)),
+		policySvc: policySvc,
+		limitSvc:  limitSvc,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+	r.Route("/users/{userID}", func(r chi.Router) {
+		r.Get("/", h.handleGetUserLimit)
+		r.Put("/", h.handlePutUserLimit)
+		r.Delete("/", h.handleDeleteUserLimit)
+	})
+	r.Route("/{orgID}", func(r chi.Router) {
+		r.Get("/", h.handleGetPolicy)
+		r.Put("/", h.handlePutPolicy)
+		r.Delete("/", h.handleDeletePolicy)
+	})
+	h.Router = r
+	return h
+}
+
+// Prefix returns the mounting prefix for the handler.
+func (h *PolicyHandler) Prefix() string {
+	return prefixSessionPolicies
+}
+
+// policyBody is a session policy with its durations as strings, such as 1h.
+type policyBody struct {
+	Length        string `json:"length,omitempty"`
+	IdleTimeout   string `json:"idleTimeout,omitempty"`
+	MaxLifetime   string `json:"maxLifetime,omitempty"`
+	RenewDisabled bool   `json:"renewDisabled"`
+	MaxSessions   int    `json:"maxSessions,omitempty"`
+}
+
+func newPolicyBody(p Policy) policyBody {
+	format := func(d time.Duration) string {
+		if d == 0 {
+			return ""
+		}
+		return d.String()
+	}
+	return policyBody{
+		Length:        format(p.Length),
+		IdleTimeout:   format(p.IdleTimeout),
+		MaxLifetime:   format(p.MaxLifetime),
+		RenewDisabled: p.RenewDisabled,
+		MaxSessions:   p.MaxSessions,
+	}
+}
+
+func (b policyBody) toPolicy() (Policy, error) {
+	p := Policy{RenewDisabled: b.RenewDisabled, MaxSessions: b.MaxSessions}
+	for _, d := range []struct {
+		name  string
+		value string
+		dest  *time.Duration
+	}{
+		{"length", b.Length, &p.Length},
+		{"idleTimeout", b.IdleTimeout, &p.IdleTimeout},
+		{"maxLifetime", b.MaxLifetime, &p.MaxLifetime},
+	} {
+		if d.value == "" {
+			continue
+		}
+		v, err := time.ParseDuration(d.value)
+		if err != nil {
+			return Policy{}, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "invalid " + d.name,
+				Err:  err,
+			}
+		}
+		*d.dest = v
+	}
+	return p, p.Valid()
+}
+
+func decodeIDParam(r *http.Request, param string) (platform.ID, error) {
+	var id platform.ID
+	if err := id.DecodeFromString(chi.URLParam(r, param)); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// handleGetPolicy is the HTTP handler for the GET /api/v2/session-policies/:orgID route.
+func (h *PolicyHandler) handleGetPolicy(w http.ResponseWriter, r *http.Request) {
+	orgID, err := decodeIDParam(r, "orgID")
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	p, err := h.policySvc.FindOrgPolicy(r.Context(), orgID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if p == nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  "organization has no session policy",
+		})
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusOK, newPolicyBody(*p))
+}
+
+// handlePutPolicy is the HTTP handler for the PUT /api/v2/session-policies/:orgID route.
+func (h *PolicyHandler) handlePutPolicy(w http.ResponseWriter, r *http.Request) {
+	orgID, err := decodeIDParam(r, "orgID")
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var body policyBody
+	if err := h.api.DecodeJSON(r.Body, &body); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	p, err := body.toPolicy()
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.policySvc.PutOrgPolicy(r.Context(), orgID, p); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Session policy updated", zap.String("orgID", orgID.String()))
+
+	h.api.Respond(w, r, http.StatusOK, newPolicyBody(p))
+}
+
+// handleDeletePolicy is the HTTP handler for the DELETE /api/v2/session-policies/:orgID route.
+func (h *PolicyHandler) handleDeletePolicy(w http.ResponseWriter, r *http.Request) {
+	orgID, err := decodeIDParam(r, "orgID")
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.policySvc.DeleteOrgPolicy(r.Context(), orgID); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusNoContent, nil)
+}
+
+// handleGetUserLimit is the HTTP handler for the GET /api/v2/session-policies/users/:userID route.
+func (h *PolicyHandler) handleGetUserLimit(w http.ResponseWriter, r *http.Request) {
+	userID, err := decodeIDParam(r, "userID")
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	l, err := h.limitSvc.FindUserLimit(r.Context(), userID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if l == nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  "user has no session limit override",
+		})
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusOK, l)
+}
+
+// handlePutUserLimit is the HTTP handler for the PUT /api/v2/session-policies/users/:userID route.
+func (h *PolicyHandler) handlePutUserLimit(w http.ResponseWriter, r *http.Request) {
+	userID, err := decodeIDParam(r, "userID")
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var l UserLimit
+	if err := h.api.DecodeJSON(r.Body, &l); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.limitSvc.PutUserLimit(r.Context(), userID, l); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Session limit of user overridden", zap.String("userID", userID.String()))
+
+	h.api.Respond(w, r, http.StatusOK, l)
+}
+
+// handleDeleteUserLimit is the HTTP handler for the DELETE /api/v2/session-policies/users/:userID route.
+func (h *PolicyHandler) handleDeleteUserLimit(w http.ResponseWriter, r *http.Request) {
+	userID, err := decodeIDParam(r, "userID")
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.limitSvc.DeleteUserLimit(r.Context(), userID); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusNoContent, nil)
+}
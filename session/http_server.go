@@ -27,6 +27,7 @@ type SessionHandler struct {
 	sessionSvc influxdb.SessionService
 	passSvc    influxdb.PasswordsService
 	userSvc    influxdb.UserService
+	refreshSvc RefreshTokenService
 }
 
 // NewSessionHandler returns a new instance of SessionHandler.
@@ -43,6 +44,12 @@ func NewSessionHandler(log *zap.Logger, sessionSvc influxdb.SessionService, user
 	return svr
 }
 
+// WithRefreshTokens issues a refresh token with each session created by sign
+// in, which is exchanged for a new session at /api/v2/signin/refresh.
+func (h *SessionHandler) WithRefreshTokens(svc RefreshTokenService) {
+	h.refreshSvc = svc
+}
+
 type resourceHandler struct {
 	prefix string
 	*SessionHandler
@@ -61,6 +68,8 @@ func (h SessionHandler) SignInResourceHandler() *resourceHandler {
 		middleware.RealIP,
 	)
 	h.Router.Post("/", h.handleSignin)
+	h.Router.Post("/refresh", h.handleRefresh)
+	h.Router.Delete("/refresh", h.handleRevokeRefresh)
 	return &resourceHandler{prefix: prefixSignIn, SessionHandler: &h}
 }
 
@@ -79,6 +88,7 @@ func (h SessionHandler) SignOutResourceHandler() *resourceHandler {
 
 // handleSignin is the HTTP handler for the POST /signin route.
 func (h *SessionHandler) handleSignin(w http.ResponseWriter, r *http.Request) {
+	r = ClientRequest(r)
 	ctx := r.Context()
 
 	req, decErr := decodeSigninRequest(ctx, r)
@@ -109,7 +119,82 @@ func (h *SessionHandler) handleSignin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	encodeCookieSession(w, s, (r != nil) && (r.TLS != nil))
+	if h.refreshSvc != nil {
+		t, err := h.refreshSvc.CreateRefreshToken(ctx, s)
+		if err != nil {
+			h.api.Err(w, r, ErrUnauthorized)
+			return
+		}
+		encodeCookieRefreshToken(w, t, (r != nil) && (r.TLS != nil))
+	}
+
+	EncodeCookieSession(w, s, (r != nil) && (r.TLS != nil))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRefresh is the HTTP handler for the POST /signin/refresh route. It
+// exchanges the refresh token of the request for a new session.
+func (h *SessionHandler) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	r = ClientRequest(r)
+	ctx := r.Context()
+	if h.refreshSvc == nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  "refresh tokens are not enabled",
+		})
+		return
+	}
+
+	key, err := decodeCookieRefreshToken(r)
+	if err != nil {
+		h.api.Err(w, r, ErrUnauthorized)
+		return
+	}
+
+	s, t, err := h.refreshSvc.RefreshSession(ctx, key)
+	if err != nil {
+		if errors.ErrorCode(err) != errors.EForbidden {
+			err = ErrUnauthorized
+		}
+		h.api.Err(w, r, err)
+		return
+	}
+
+	encodeCookieRefreshToken(w, t, r.TLS != nil)
+	EncodeCookieSession(w, s, r.TLS != nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRevokeRefresh is the HTTP handler for the DELETE /signin/refresh
+// route. It revokes the refresh token of the request, and expires its session.
+func (h *SessionHandler) handleRevokeRefresh(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if h.refreshSvc == nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  "refresh tokens are not enabled",
+		})
+		return
+	}
+
+	key, err := decodeCookieRefreshToken(r)
+	if err != nil {
+		h.api.Err(w, r, ErrUnauthorized)
+		return
+	}
+	if err := h.refreshSvc.RevokeRefreshToken(ctx, key); err != nil && errors.ErrorCode(err) != errors.ENotFound {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     cookieRefreshName,
+		Path:     prefixSignIn,
+		MaxAge:   -1,
+		SameSite: http.SameSiteStrictMode,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+	})
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -167,7 +252,8 @@ func decodeSignoutRequest(ctx context.Context, r *http.Request) (*signoutRequest
 
 const cookieSessionName = "influxdb-oss-session"
 
-func encodeCookieSession(w http.ResponseWriter, s *influxdb.Session, tlsEnabled bool) {
+// EncodeCookieSession sets the cookie of the session on the response.
+func EncodeCookieSession(w http.ResponseWriter, s *influxdb.Session, tlsEnabled bool) {
 	// We only need the session cookie for accesses to "/api/...", so limit
 	// it to that using "Path".
 	//
@@ -219,6 +305,35 @@ func encodeCookieSession(w http.ResponseWriter, s *influxdb.Session, tlsEnabled
 	http.SetCookie(w, c)
 }
 
+const cookieRefreshName = "influxdb-oss-refresh"
+
+func encodeCookieRefreshToken(w http.ResponseWriter, t *RefreshToken, tlsEnabled bool) {
+	// The refresh token is only sent to /api/v2/signin/..., where it is
+	// exchanged or revoked, so it has a smaller exposure than the session.
+	c := &http.Cookie{
+		Name:     cookieRefreshName,
+		Value:    t.Key,
+		Path:     prefixSignIn,
+		Expires:  t.ExpiresAt,
+		SameSite: http.SameSiteStrictMode,
+		HttpOnly: true,
+		Secure:   tlsEnabled,
+	}
+
+	http.SetCookie(w, c)
+}
+
+func decodeCookieRefreshToken(r *http.Request) (string, error) {
+	c, err := r.Cookie(cookieRefreshName)
+	if err != nil {
+		return "", &errors.Error{
+			Code: errors.EInvalid,
+			Err:  err,
+		}
+	}
+	return c.Value, nil
+}
+
 func DecodeCookieSession(ctx context.Context, r *http.Request) (string, error) {
 	c, err := r.Cookie(cookieSessionName)
 	if err != nil {
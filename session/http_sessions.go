@@ -0,0 +1,257 @@
+package session
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const (
+	prefixMeSessions   = "/api/v2/me/sessions"
+	prefixUserSessions = "/api/v2/sessions/users"
+)
+
+// UserSessionHandler is the handler for the active sessions of users.
+type UserSessionHandler struct {
+	chi.Router
+
+	api    *kithttp.API
+	log    *zap.Logger
+	prefix string
+
+	sessionSvc UserSessionService
+}
+
+// NewMeSessionHandler returns a handler for the active sessions of the user
+// of the request, mounted at /api/v2/me/sessions.
+func NewMeSessionHandler(log *zap.Logger, sessionSvc UserSessionService) *UserSessionHandler {
+	h := newUserSessionHandler(log, prefixMeSessions, sessionSvc)
+	h.Router.Get("/", h.handleGetMeSessions)
+	h.Router.Delete("/", h.handleDeleteMeSessions)
+	h.Router.Delete("/{id}", h.handleDeleteMeSession)
+	return h
+}
+
+// NewUserSessionHandler returns a handler for the active sessions of every
+// user, mounted at /api/v2/sessions/users.
+func NewUserSessionHandler(log *zap.Logger, sessionSvc UserSessionService) *UserSessionHandler {
+	h := newUserSessionHandler(log, prefixUserSessions, sessionSvc)
+	h.Router.Route("/{userID}", func(r chi.Router) {
+		r.Get("/", h.handleGetUserSessions)
+		r.Delete("/", h.handleDeleteUserSessions)
+		r.Delete("/{id}", h.handleDeleteUserSession)
+	})
+	return h
+}
+
+func newUserSessionHandler(log *zap.Logger, prefix string, sessionSvc UserSessionService) *UserSessionHandler {
+	h := &UserSessionHandler{
+		api:        kithttp.NewAPI(kithttp.WithLog(log)),
+		log:        log,
+		prefix:     prefix,
+		sessionSvc: sessionSvc,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+	h.Router = r
+	return h
+}
+
+// Prefix returns the mounting prefix for the handler.
+func (h *UserSessionHandler) Prefix() string {
+	return h.prefix
+}
+
+// sessionResponse is an active session, without its key.
+type sessionResponse struct {
+	ID           platform.ID `json:"id"`
+	UserID       platform.ID `json:"userID"`
+	CreatedAt    time.Time   `json:"createdAt"`
+	ExpiresAt    time.Time   `json:"expiresAt"`
+	LastActiveAt time.Time   `json:"lastActiveAt"`
+	IP           string      `json:"ip,omitempty"`
+	UserAgent    string      `json:"userAgent,omitempty"`
+	// Current is true for the session of the request.
+	Current bool `json:"current"`
+}
+
+type sessionsResponse struct {
+	Sessions []sessionResponse `json:"sessions"`
+}
+
+func newSessionsResponse(sessions []*influxdb.Session, current platform.ID) sessionsResponse {
+	res := sessionsResponse{Sessions: make([]sessionResponse, 0, len(sessions))}
+	for _, s := range sessions {
+		res.Sessions = append(res.Sessions, sessionResponse{
+			ID:           s.ID,
+			UserID:       s.UserID,
+			CreatedAt:    s.CreatedAt,
+			ExpiresAt:    s.ExpiresAt,
+			LastActiveAt: s.LastActiveAt,
+			IP:           s.IP,
+			UserAgent:    s.UserAgent,
+			Current:      current.Valid() && s.ID == current,
+		})
+	}
+	return res
+}
+
+// requestUser returns the user of the request, and the ID of its session when
+// it is authorized by one.
+func requestUser(r *http.Request) (userID, sessionID platform.ID, err error) {
+	a, err := icontext.GetAuthorizer(r.Context())
+	if err != nil {
+		return 0, 0, err
+	}
+	if a.Kind() == influxdb.SessionAuthorizationKind {
+		sessionID = a.Identifier()
+	}
+	return a.GetUserID(), sessionID, nil
+}
+
+// handleGetMeSessions is the HTTP handler for the GET /api/v2/me/sessions route.
+func (h *UserSessionHandler) handleGetMeSessions(w http.ResponseWriter, r *http.Request) {
+	userID, current, err := requestUser(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	sessions, err := h.sessionSvc.FindUserSessions(r.Context(), userID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, newSessionsResponse(sessions, current))
+}
+
+// handleDeleteMeSessions is the HTTP handler for the DELETE /api/v2/me/sessions
+// route. It revokes every session of the user but the session of the request.
+func (h *UserSessionHandler) handleDeleteMeSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, current, err := requestUser(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	sessions, err := h.sessionSvc.FindUserSessions(ctx, userID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	for _, s := range sessions {
+		if s.ID == current {
+			continue
+		}
+		if err := h.sessionSvc.RevokeUserSession(ctx, userID, s.ID); err != nil && errors.ErrorCode(err) != errors.ENotFound {
+			h.api.Err(w, r, err)
+			return
+		}
+	}
+	h.api.Respond(w, r, http.StatusNoContent, nil)
+}
+
+// handleDeleteMeSession is the HTTP handler for the DELETE /api/v2/me/sessions/:id route.
+func (h *UserSessionHandler) handleDeleteMeSession(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := requestUser(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	id, err := decodeIDParam(r, "id")
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.sessionSvc.RevokeUserSession(r.Context(), userID, id); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusNoContent, nil)
+}
+
+// handleGetUserSessions is the HTTP handler for the GET /api/v2/sessions/users/:userID route.
+func (h *UserSessionHandler) handleGetUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := decodeIDParam(r, "userID")
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	_, current, err := requestUser(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	sessions, err := h.sessionSvc.FindUserSessions(r.Context(), userID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, newSessionsResponse(sessions, current))
+}
+
+// handleDeleteUserSessions is the HTTP handler for the DELETE /api/v2/sessions/users/:userID route.
+func (h *UserSessionHandler) handleDeleteUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := decodeIDParam(r, "userID")
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.sessionSvc.RevokeUserSessions(r.Context(), userID); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Sessions revoked", zap.String("userID", userID.String()))
+
+	h.api.Respond(w, r, http.StatusNoContent, nil)
+}
+
+// handleDeleteUserSession is the HTTP handler for the DELETE /api/v2/sessions/users/:userID/:id route.
+func (h *UserSessionHandler) handleDeleteUserSession(w http.ResponseWriter, r *http.Request) {
+	userID, err := decodeIDParam(r, "userID")
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	id, err := decodeIDParam(r, "id")
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.sessionSvc.RevokeUserSession(r.Context(), userID, id); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Session revoked", zap.String("userID", userID.String()), zap.String("sessionID", id.String()))
+
+	h.api.Respond(w, r, http.StatusNoContent, nil)
+}
+
+// ClientRequest returns r with its client on its context, which is recorded
+// with the sessions it creates.
+func ClientRequest(r *http.Request) *http.Request {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	return r.WithContext(withClient(r.Context(), ip, r.UserAgent()))
+}
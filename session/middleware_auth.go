@@ -0,0 +1,133 @@
+package session
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+var (
+	_ OrgPolicyService = (*AuthedPolicyService)(nil)
+	_ UserLimitService = (*AuthedPolicyService)(nil)
+)
+
+// AuthedPolicyService wraps an OrgPolicyService and authorizes actions
+// against it appropriately.
+type AuthedPolicyService struct {
+	s  OrgPolicyService
+	ls UserLimitService
+}
+
+// NewAuthedPolicyService constructs an instance of an authorizing session policy service.
+func NewAuthedPolicyService(s OrgPolicyService, ls UserLimitService) *AuthedPolicyService {
+	return &AuthedPolicyService{s: s, ls: ls}
+}
+
+// FindOrgPolicy checks to see if the authorizer on context has read access to the organization.
+func (s *AuthedPolicyService) FindOrgPolicy(ctx context.Context, orgID platform.ID) (*Policy, error) {
+	if _, _, err := authorizer.AuthorizeReadOrg(ctx, orgID); err != nil {
+		return nil, err
+	}
+	return s.s.FindOrgPolicy(ctx, orgID)
+}
+
+// PutOrgPolicy checks to see if the authorizer on context has write access to the organization.
+func (s *AuthedPolicyService) PutOrgPolicy(ctx context.Context, orgID platform.ID, p Policy) error {
+	if _, _, err := authorizer.AuthorizeWriteOrg(ctx, orgID); err != nil {
+		return err
+	}
+	return s.s.PutOrgPolicy(ctx, orgID, p)
+}
+
+// DeleteOrgPolicy checks to see if the authorizer on context has write access to the organization.
+func (s *AuthedPolicyService) DeleteOrgPolicy(ctx context.Context, orgID platform.ID) error {
+	if _, _, err := authorizer.AuthorizeWriteOrg(ctx, orgID); err != nil {
+		return err
+	}
+	return s.s.DeleteOrgPolicy(ctx, orgID)
+}
+
+// FindUserLimit checks to see if the authorizer on context has read access to all users.
+func (s *AuthedPolicyService) FindUserLimit(ctx context.Context, userID platform.ID) (*UserLimit, error) {
+	if _, _, err := authorizer.AuthorizeReadGlobal(ctx, influxdb.UsersResourceType); err != nil {
+		return nil, err
+	}
+	return s.ls.FindUserLimit(ctx, userID)
+}
+
+// PutUserLimit checks to see if the authorizer on context has write access to
+// all users, the session limits of users are overridden by operators.
+func (s *AuthedPolicyService) PutUserLimit(ctx context.Context, userID platform.ID, l UserLimit) error {
+	if _, _, err := authorizer.AuthorizeWriteGlobal(ctx, influxdb.UsersResourceType); err != nil {
+		return err
+	}
+	return s.ls.PutUserLimit(ctx, userID, l)
+}
+
+// DeleteUserLimit checks to see if the authorizer on context has write access to all users.
+func (s *AuthedPolicyService) DeleteUserLimit(ctx context.Context, userID platform.ID) error {
+	if _, _, err := authorizer.AuthorizeWriteGlobal(ctx, influxdb.UsersResourceType); err != nil {
+		return err
+	}
+	return s.ls.DeleteUserLimit(ctx, userID)
+}
+
+var _ UserSessionService = (*AuthedUserSessionService)(nil)
+
+// AuthedUserSessionService wraps a UserSessionService and authorizes actions
+// against it appropriately. Users manage their own sessions, and operators
+// manage the sessions of every user.
+type AuthedUserSessionService struct {
+	s UserSessionService
+}
+
+// NewAuthedUserSessionService constructs an instance of an authorizing user session service.
+func NewAuthedUserSessionService(s UserSessionService) *AuthedUserSessionService {
+	return &AuthedUserSessionService{s: s}
+}
+
+// FindUserSessions checks to see if the authorizer on context is the user, or
+// has read access to all users.
+func (s *AuthedUserSessionService) FindUserSessions(ctx context.Context, userID platform.ID) ([]*influxdb.Session, error) {
+	if err := authorizeUserSessions(ctx, influxdb.ReadAction, userID); err != nil {
+		return nil, err
+	}
+	return s.s.FindUserSessions(ctx, userID)
+}
+
+// RevokeUserSession checks to see if the authorizer on context is the user, or
+// has write access to all users.
+func (s *AuthedUserSessionService) RevokeUserSession(ctx context.Context, userID, id platform.ID) error {
+	if err := authorizeUserSessions(ctx, influxdb.WriteAction, userID); err != nil {
+		return err
+	}
+	return s.s.RevokeUserSession(ctx, userID, id)
+}
+
+// RevokeUserSessions checks to see if the authorizer on context is the user,
+// or has write access to all users.
+func (s *AuthedUserSessionService) RevokeUserSessions(ctx context.Context, userID platform.ID) error {
+	if err := authorizeUserSessions(ctx, influxdb.WriteAction, userID); err != nil {
+		return err
+	}
+	return s.s.RevokeUserSessions(ctx, userID)
+}
+
+func authorizeUserSessions(ctx context.Context, action influxdb.Action, userID platform.ID) error {
+	a, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		return err
+	}
+	if a.GetUserID() == userID {
+		return nil
+	}
+	if action == influxdb.ReadAction {
+		_, _, err = authorizer.AuthorizeReadGlobal(ctx, influxdb.UsersResourceType)
+	} else {
+		_, _, err = authorizer.AuthorizeWriteGlobal(ctx, influxdb.UsersResourceType)
+	}
+	return err
+}
@@ -0,0 +1,341 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+var sessionPolicyBucket = []byte("sessionpoliciesv1")
+
+// Policy configures the lifetime of sessions.
+type Policy struct {
+	// Length is how long a new session is valid for.
+	Length time.Duration `json:"length,omitempty"`
+	// IdleTimeout is how far each request extends a session, when sessions
+	// are renewed.
+	IdleTimeout time.Duration `json:"idleTimeout,omitempty"`
+	// MaxLifetime is how long a session is valid for since it was created,
+	// however often it is renewed. Sessions are renewed indefinitely when it
+	// is zero.
+	MaxLifetime time.Duration `json:"maxLifetime,omitempty"`
+	// RenewDisabled disables extending sessions on requests.
+	RenewDisabled bool `json:"renewDisabled,omitempty"`
+	// MaxSessions is how many sessions a user has at once, the oldest
+	// sessions of the user are removed as new sessions are created. The
+	// sessions of users are not limited when it is zero.
+	MaxSessions int `json:"maxSessions,omitempty"`
+}
+
+// Valid returns an error if the policy has negative values.
+func (p Policy) Valid() error {
+	if p.Length < 0 || p.IdleTimeout < 0 || p.MaxLifetime < 0 {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "session policy durations must not be negative",
+		}
+	}
+	if p.MaxSessions < 0 {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "session policy max sessions must not be negative",
+		}
+	}
+	return nil
+}
+
+// Restrict returns the strictest combination of the policies. The zero
+// values of o do not restrict p.
+func (p Policy) Restrict(o Policy) Policy {
+	p.Length = minDuration(p.Length, o.Length)
+	p.IdleTimeout = minDuration(p.IdleTimeout, o.IdleTimeout)
+	p.MaxLifetime = minDuration(p.MaxLifetime, o.MaxLifetime)
+	p.RenewDisabled = p.RenewDisabled || o.RenewDisabled
+	if p.MaxSessions == 0 || (o.MaxSessions != 0 && o.MaxSessions < p.MaxSessions) {
+		p.MaxSessions = o.MaxSessions
+	}
+	return p
+}
+
+// expiresAt returns the expiration of a session created at createdAt, which
+// asks to be valid until requested at now.
+func (p Policy) expiresAt(createdAt, now, requested time.Time) time.Time {
+	if p.IdleTimeout > 0 && now.Add(p.IdleTimeout).Before(requested) {
+		requested = now.Add(p.IdleTimeout)
+	}
+	if p.MaxLifetime > 0 && createdAt.Add(p.MaxLifetime).Before(requested) {
+		requested = createdAt.Add(p.MaxLifetime)
+	}
+	return requested
+}
+
+// minDuration returns the smaller of the non-zero durations.
+func minDuration(a, b time.Duration) time.Duration {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// OrgPolicyService manages the session policies of organizations. The
+// sessions of a user are restricted by the policies of all organizations
+// the user is a member of.
+type OrgPolicyService interface {
+	// FindOrgPolicy returns the session policy of an organization, and nil
+	// when the organization has none.
+	FindOrgPolicy(ctx context.Context, orgID platform.ID) (*Policy, error)
+	PutOrgPolicy(ctx context.Context, orgID platform.ID, p Policy) error
+	DeleteOrgPolicy(ctx context.Context, orgID platform.ID) error
+}
+
+// UserLimit overrides the max sessions of the policies of a user.
+type UserLimit struct {
+	// MaxSessions is how many sessions the user has at once. The sessions of
+	// the user are not limited when it is zero.
+	MaxSessions int `json:"maxSessions"`
+}
+
+// UserLimitService manages the overrides of the session limits of users.
+type UserLimitService interface {
+	// FindUserLimit returns the override of the session limit of a user, and
+	// nil when the user has none.
+	FindUserLimit(ctx context.Context, userID platform.ID) (*UserLimit, error)
+	PutUserLimit(ctx context.Context, userID platform.ID, l UserLimit) error
+	DeleteUserLimit(ctx context.Context, userID platform.ID) error
+}
+
+var (
+	_ OrgPolicyService = (*PolicyStore)(nil)
+	_ UserLimitService = (*PolicyStore)(nil)
+)
+
+// PolicyStore stores the session policies of organizations.
+type PolicyStore struct {
+	kv kv.Store
+}
+
+// NewPolicyStore creates a new store of the session policies of organizations.
+func NewPolicyStore(s kv.Store) *PolicyStore {
+	return &PolicyStore{kv: s}
+}
+
+// FindOrgPolicy returns the session policy of an organization.
+func (s *PolicyStore) FindOrgPolicy(ctx context.Context, orgID platform.ID) (*Policy, error) {
+	key, err := orgID.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	var p *Policy
+	err = s.kv.View(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(sessionPolicyBucket)
+		if err != nil {
+			return err
+		}
+		v, err := b.Get(key)
+		if kv.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		p = new(Policy)
+		return json.Unmarshal(v, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// PutOrgPolicy sets the session policy of an organization.
+func (s *PolicyStore) PutOrgPolicy(ctx context.Context, orgID platform.ID, p Policy) error {
+	if err := p.Valid(); err != nil {
+		return err
+	}
+	key, err := orgID.Encode()
+	if err != nil {
+		return err
+	}
+	v, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return s.kv.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(sessionPolicyBucket)
+		if err != nil {
+			return err
+		}
+		return b.Put(key, v)
+	})
+}
+
+// DeleteOrgPolicy removes the session policy of an organization.
+func (s *PolicyStore) DeleteOrgPolicy(ctx context.Context, orgID platform.ID) error {
+	key, err := orgID.Encode()
+	if err != nil {
+		return err
+	}
+	return s.kv.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(sessionPolicyBucket)
+		if err != nil {
+			return err
+		}
+		if err := b.Delete(key); err != nil && !kv.IsNotFound(err) {
+			return err
+		}
+		return nil
+	})
+}
+
+// userLimitPrefix prefixes the keys of the limits of users, which are stored
+// with the policies of organizations.
+var userLimitPrefix = []byte("user/")
+
+func userLimitKey(userID platform.ID) ([]byte, error) {
+	id, err := userID.Encode()
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{}, userLimitPrefix...), id...), nil
+}
+
+// FindUserLimit returns the override of the session limit of a user.
+func (s *PolicyStore) FindUserLimit(ctx context.Context, userID platform.ID) (*UserLimit, error) {
+	key, err := userLimitKey(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var l *UserLimit
+	err = s.kv.View(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(sessionPolicyBucket)
+		if err != nil {
+			return err
+		}
+		v, err := b.Get(key)
+		if kv.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		l = new(UserLimit)
+		return json.Unmarshal(v, l)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// PutUserLimit overrides the session limit of a user.
+func (s *PolicyStore) PutUserLimit(ctx context.Context, userID platform.ID, l UserLimit) error {
+	if l.MaxSessions < 0 {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "max sessions must not be negative",
+		}
+	}
+	key, err := userLimitKey(userID)
+	if err != nil {
+		return err
+	}
+	v, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return s.kv.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(sessionPolicyBucket)
+		if err != nil {
+			return err
+		}
+		return b.Put(key, v)
+	})
+}
+
+// DeleteUserLimit removes the override of the session limit of a user.
+func (s *PolicyStore) DeleteUserLimit(ctx context.Context, userID platform.ID) error {
+	key, err := userLimitKey(userID)
+	if err != nil {
+		return err
+	}
+	return s.kv.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(sessionPolicyBucket)
+		if err != nil {
+			return err
+		}
+		if err := b.Delete(key); err != nil && !kv.IsNotFound(err) {
+			return err
+		}
+		return nil
+	})
+}
+
+// userPolicy returns the policy of the instance restricted by the policies of
+// the organizations the user is a member of.
+func (s *Service) userPolicy(ctx context.Context, userID platform.ID) (Policy, error) {
+	p := s.policy
+	if s.orgPolicies == nil {
+		return p, nil
+	}
+
+	mappings, _, err := s.urmService.FindUserResourceMappings(ctx, influxdb.UserResourceMappingFilter{
+		UserID:       userID,
+		ResourceType: influxdb.OrgsResourceType,
+	})
+	if err != nil {
+		return p, err
+	}
+	for _, m := range mappings {
+		op, err := s.orgPolicies.FindOrgPolicy(ctx, m.ResourceID)
+		if err != nil {
+			return p, err
+		}
+		if op != nil {
+			p = p.Restrict(*op)
+		}
+	}
+	return p, nil
+}
+
+// maxSessions returns how many sessions a user has at once, the override of
+// the user replaces the limit of the policy.
+func (s *Service) maxSessions(ctx context.Context, userID platform.ID, policy Policy) (int, error) {
+	if s.userLimits == nil {
+		return policy.MaxSessions, nil
+	}
+	l, err := s.userLimits.FindUserLimit(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if l != nil {
+		return l.MaxSessions, nil
+	}
+	return policy.MaxSessions, nil
+}
+
+// evictSessions removes the oldest sessions of a user over its session limit.
+func (s *Service) evictSessions(ctx context.Context, userID platform.ID, policy Policy) error {
+	max, err := s.maxSessions(ctx, userID, policy)
+	if err != nil || max == 0 {
+		return err
+	}
+
+	sessions, err := s.store.FindSessionsByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for len(sessions) > max {
+		if err := s.store.DeleteSession(ctx, sessions[0].ID); err != nil && errors.ErrorCode(err) != errors.ENotFound {
+			return err
+		}
+		sessions = sessions[1:]
+	}
+	return nil
+}
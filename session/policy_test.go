@@ -0,0 +1,190 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/inmem"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv/migration/all"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestPolicy_Restrict(t *testing.T) {
+	p := Policy{Length: time.Hour, IdleTimeout: 5 * time.Minute}.Restrict(Policy{
+		Length:        30 * time.Minute,
+		MaxLifetime:   8 * time.Hour,
+		RenewDisabled: true,
+	})
+	assert.Equal(t, Policy{
+		Length:        30 * time.Minute,
+		IdleTimeout:   5 * time.Minute,
+		MaxLifetime:   8 * time.Hour,
+		RenewDisabled: true,
+	}, p)
+}
+
+func newTestPolicyService(t *testing.T, now time.Time, policy Policy) (*Service, *PolicyStore, *influxdb.User, *influxdb.Organization) {
+	t.Helper()
+	ctx := context.Background()
+
+	kvStore := inmem.NewKVStore()
+	require.NoError(t, all.Up(ctx, zaptest.NewLogger(t), kvStore))
+	ten := tenant.NewService(tenant.NewStore(kvStore))
+
+	u := &influxdb.User{Name: "user"}
+	require.NoError(t, ten.CreateUser(ctx, u))
+	o := &influxdb.Organization{Name: "org"}
+	require.NoError(t, ten.CreateOrganization(ctx, o))
+	require.NoError(t, ten.CreateUserResourceMapping(ctx, &influxdb.UserResourceMapping{
+		UserID:       u.ID,
+		UserType:     influxdb.Member,
+		ResourceType: influxdb.OrgsResourceType,
+		ResourceID:   o.ID,
+	}))
+
+	policies := NewPolicyStore(kvStore)
+	svc := NewService(NewStorage(inmem.NewSessionStore()), ten, ten, &mock.AuthorizationService{
+		FindAuthorizationsFn: func(context.Context, influxdb.AuthorizationFilter, ...influxdb.FindOptions) ([]*influxdb.Authorization, int, error) {
+			return []*influxdb.Authorization{}, 0, nil
+		},
+	}, WithPolicy(policy), WithOrgPolicies(policies), WithUserLimits(policies))
+	svc.now = func() time.Time { return now }
+	return svc, policies, u, o
+}
+
+func TestService_OrgPolicies(t *testing.T) {
+	ctx := context.Background()
+	// the sessions expire with the real clock in the session store.
+	now := time.Now().UTC().Round(0)
+	svc, policies, _, o := newTestPolicyService(t, now, Policy{Length: time.Hour})
+
+	t.Run("the org policy restricts the length and lifetime of sessions", func(t *testing.T) {
+		require.NoError(t, policies.PutOrgPolicy(ctx, o.ID, Policy{MaxLifetime: 30 * time.Minute, IdleTimeout: 10 * time.Minute}))
+
+		s, err := svc.CreateSession(ctx, "user")
+		require.NoError(t, err)
+		assert.Equal(t, now.Add(30*time.Minute), s.ExpiresAt)
+
+		// renewals are limited by the idle timeout, then by the max lifetime.
+		svc.now = func() time.Time { return now.Add(5 * time.Minute) }
+		require.NoError(t, svc.RenewSession(ctx, s, now.Add(24*time.Hour)))
+		found, err := svc.FindSession(ctx, s.Key)
+		require.NoError(t, err)
+		assert.Equal(t, now.Add(30*time.Minute), found.ExpiresAt)
+
+		// tightening the policy expires the sessions created before.
+		require.NoError(t, policies.PutOrgPolicy(ctx, o.ID, Policy{MaxLifetime: time.Minute}))
+		_, err = svc.FindSession(ctx, s.Key)
+		assert.Equal(t, errors.EForbidden, errors.ErrorCode(err))
+		svc.now = func() time.Time { return now }
+	})
+
+	t.Run("the org policy disables renewal", func(t *testing.T) {
+		require.NoError(t, policies.PutOrgPolicy(ctx, o.ID, Policy{RenewDisabled: true}))
+
+		s, err := svc.CreateSession(ctx, "user")
+		require.NoError(t, err)
+		require.NoError(t, svc.RenewSession(ctx, s, now.Add(24*time.Hour)))
+		found, err := svc.FindSession(ctx, s.Key)
+		require.NoError(t, err)
+		assert.Equal(t, now.Add(time.Hour), found.ExpiresAt)
+	})
+
+	t.Run("without an org policy the instance policy applies", func(t *testing.T) {
+		require.NoError(t, policies.DeleteOrgPolicy(ctx, o.ID))
+
+		p, err := policies.FindOrgPolicy(ctx, o.ID)
+		require.NoError(t, err)
+		assert.Nil(t, p)
+
+		s, err := svc.CreateSession(ctx, "user")
+		require.NoError(t, err)
+		require.NoError(t, svc.RenewSession(ctx, s, now.Add(24*time.Hour)))
+		found, err := svc.FindSession(ctx, s.Key)
+		require.NoError(t, err)
+		assert.Equal(t, now.Add(24*time.Hour), found.ExpiresAt)
+	})
+
+	t.Run("negative durations are invalid", func(t *testing.T) {
+		err := policies.PutOrgPolicy(ctx, o.ID, Policy{Length: -time.Minute})
+		assert.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+	})
+}
+
+func TestService_SessionLimits(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC().Round(0)
+	svc, policies, u, o := newTestPolicyService(t, now, Policy{Length: time.Hour, MaxSessions: 3})
+
+	createSessions := func(t *testing.T, n int) []*influxdb.Session {
+		t.Helper()
+		var ss []*influxdb.Session
+		for i := 0; i < n; i++ {
+			svc.now = func() time.Time { return now.Add(time.Duration(i) * time.Second) }
+			s, err := svc.CreateSession(ctx, "user")
+			require.NoError(t, err)
+			ss = append(ss, s)
+		}
+		svc.now = func() time.Time { return now }
+		return ss
+	}
+	sessionIDs := func(t *testing.T) []platform.ID {
+		t.Helper()
+		ss, err := svc.store.FindSessionsByUserID(ctx, u.ID)
+		require.NoError(t, err)
+		var ids []platform.ID
+		for _, s := range ss {
+			ids = append(ids, s.ID)
+		}
+		return ids
+	}
+	expireAll := func(t *testing.T) {
+		t.Helper()
+		ss, err := svc.store.FindSessionsByUserID(ctx, u.ID)
+		require.NoError(t, err)
+		for _, s := range ss {
+			require.NoError(t, svc.ExpireSession(ctx, s.Key))
+		}
+	}
+
+	t.Run("evicts the oldest sessions of the user", func(t *testing.T) {
+		ss := createSessions(t, 5)
+		assert.Equal(t, []platform.ID{ss[2].ID, ss[3].ID, ss[4].ID}, sessionIDs(t))
+
+		_, err := svc.FindSession(ctx, ss[0].Key)
+		assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+		expireAll(t)
+	})
+
+	t.Run("the org policy restricts the limit", func(t *testing.T) {
+		require.NoError(t, policies.PutOrgPolicy(ctx, o.ID, Policy{MaxSessions: 1}))
+		defer policies.DeleteOrgPolicy(ctx, o.ID)
+
+		ss := createSessions(t, 2)
+		assert.Equal(t, []platform.ID{ss[1].ID}, sessionIDs(t))
+		expireAll(t)
+	})
+
+	t.Run("operators override the limit of a user", func(t *testing.T) {
+		require.NoError(t, policies.PutOrgPolicy(ctx, o.ID, Policy{MaxSessions: 1}))
+		defer policies.DeleteOrgPolicy(ctx, o.ID)
+		require.NoError(t, policies.PutUserLimit(ctx, u.ID, UserLimit{MaxSessions: 0}))
+
+		createSessions(t, 4)
+		assert.Len(t, sessionIDs(t), 4)
+		expireAll(t)
+
+		require.NoError(t, policies.DeleteUserLimit(ctx, u.ID))
+		l, err := policies.FindUserLimit(ctx, u.ID)
+		require.NoError(t, err)
+		assert.Nil(t, l)
+	})
+}
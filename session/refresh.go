@@ -0,0 +1,213 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+var storeRefreshPrefix = "sessionsrefreshv1/"
+var storeRefreshIndex = "sessionsrefreshindexv1/"
+
+// ErrRefreshTokenNotFound is the error message for a missing or revoked
+// refresh token.
+const ErrRefreshTokenNotFound = "refresh token not found"
+
+// RefreshToken is a token which replaces an expired session of a user with a
+// new one. Each refresh token is used once, a new refresh token is issued
+// with the new session.
+type RefreshToken struct {
+	ID        platform.ID `json:"id"`
+	Key       string      `json:"key"`
+	SessionID platform.ID `json:"sessionID"`
+	UserID    platform.ID `json:"userID"`
+	// CreatedAt is the time the first token of the refresh tokens issued for
+	// a sign in was created, it limits the tokens by the max lifetime of the
+	// policy of the user.
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// RefreshTokenService issues refresh tokens for sessions, and exchanges them
+// for new sessions.
+type RefreshTokenService interface {
+	// CreateRefreshToken issues a refresh token for a session.
+	CreateRefreshToken(ctx context.Context, session *influxdb.Session) (*RefreshToken, error)
+	// RefreshSession exchanges a refresh token for a new session and a new
+	// refresh token. The session the refresh token was issued for is expired.
+	RefreshSession(ctx context.Context, key string) (*influxdb.Session, *RefreshToken, error)
+	// RevokeRefreshToken revokes a refresh token, and expires the session it
+	// was issued for.
+	RevokeRefreshToken(ctx context.Context, key string) error
+}
+
+var _ RefreshTokenService = (*Service)(nil)
+
+// WithRefreshTokenLength enables refresh tokens, which are valid for length.
+// The sessions of users are meant to be short lived when refresh tokens are
+// enabled.
+func WithRefreshTokenLength(length time.Duration) ServiceOption {
+	return func(s *Service) {
+		s.refreshLength = length
+	}
+}
+
+// CreateRefreshToken issues a refresh token for a session.
+func (s *Service) CreateRefreshToken(ctx context.Context, session *influxdb.Session) (*RefreshToken, error) {
+	if s.refreshLength <= 0 {
+		return nil, &errors.Error{
+			Code: errors.EMethodNotAllowed,
+			Msg:  "refresh tokens are not enabled",
+		}
+	}
+	return s.createRefreshToken(ctx, session, session.CreatedAt)
+}
+
+func (s *Service) createRefreshToken(ctx context.Context, session *influxdb.Session, createdAt time.Time) (*RefreshToken, error) {
+	policy, err := s.userPolicy(ctx, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.tokenGen.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := s.now().Add(s.refreshLength)
+	if policy.MaxLifetime > 0 && createdAt.Add(policy.MaxLifetime).Before(expiresAt) {
+		expiresAt = createdAt.Add(policy.MaxLifetime)
+	}
+	t := &RefreshToken{
+		ID:        s.idGen.ID(),
+		Key:       token,
+		SessionID: session.ID,
+		UserID:    session.UserID,
+		CreatedAt: createdAt,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.store.CreateRefreshToken(ctx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// RefreshSession exchanges a refresh token for a new session and a new
+// refresh token.
+func (s *Service) RefreshSession(ctx context.Context, key string) (*influxdb.Session, *RefreshToken, error) {
+	// the refresh token is revoked with the session it was issued for as it
+	// is found, so it is used once.
+	t, err := s.revoke(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	u, err := s.userService.FindUserByID(ctx, t.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if u.Status == influxdb.Inactive {
+		return nil, nil, &errors.Error{
+			Code: errors.EForbidden,
+			Msg:  "user is inactive",
+		}
+	}
+
+	session, err := s.createSession(ctx, u)
+	if err != nil {
+		return nil, nil, err
+	}
+	next, err := s.createRefreshToken(ctx, session, t.CreatedAt)
+	if err != nil {
+		return nil, nil, err
+	}
+	return session, next, nil
+}
+
+// RevokeRefreshToken revokes a refresh token, and expires the session it was
+// issued for.
+func (s *Service) RevokeRefreshToken(ctx context.Context, key string) error {
+	_, err := s.revoke(ctx, key)
+	return err
+}
+
+// revoke takes the refresh token of a key from storage, and expires the
+// session it was issued for.
+func (s *Service) revoke(ctx context.Context, key string) (*RefreshToken, error) {
+	t, err := s.store.TakeRefreshToken(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.DeleteSession(ctx, t.SessionID); err != nil && errors.ErrorCode(err) != errors.ENotFound {
+		return nil, err
+	}
+	return t, nil
+}
+
+// CreateRefreshToken stores a refresh token, until it expires.
+func (s *Storage) CreateRefreshToken(ctx context.Context, t *RefreshToken) error {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	if err := s.store.Set(refreshTokenKey(t.Key), string(b), t.ExpiresAt); err != nil {
+		return err
+	}
+	// the refresh token is revoked with the session it was issued for.
+	return s.store.Set(refreshTokenIndexKey(t.SessionID), t.Key, t.ExpiresAt)
+}
+
+// TakeRefreshToken removes the refresh token of a key from storage and
+// returns it. Of concurrent calls for the same key, only one finds it.
+func (s *Storage) TakeRefreshToken(ctx context.Context, key string) (*RefreshToken, error) {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+
+	val, err := s.store.Get(refreshTokenKey(key))
+	if err != nil {
+		return nil, err
+	}
+	if val == "" {
+		return nil, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  ErrRefreshTokenNotFound,
+		}
+	}
+
+	t := &RefreshToken{}
+	if err := json.Unmarshal([]byte(val), t); err != nil {
+		return nil, err
+	}
+	if err := s.store.Delete(refreshTokenKey(t.Key)); err != nil {
+		return nil, err
+	}
+	return t, s.store.Delete(refreshTokenIndexKey(t.SessionID))
+}
+
+// deleteSessionRefreshToken removes the refresh token issued for a session,
+// if it has one.
+func (s *Storage) deleteSessionRefreshToken(ctx context.Context, sessionID platform.ID) error {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+
+	key, err := s.store.Get(refreshTokenIndexKey(sessionID))
+	if err != nil || key == "" {
+		return err
+	}
+	if err := s.store.Delete(refreshTokenKey(key)); err != nil {
+		return err
+	}
+	return s.store.Delete(refreshTokenIndexKey(sessionID))
+}
+
+func refreshTokenKey(key string) string {
+	return storeRefreshPrefix + key
+}
+
+func refreshTokenIndexKey(sessionID platform.ID) string {
+	return storeRefreshIndex + sessionID.String()
+}
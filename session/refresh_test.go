@@ -0,0 +1,115 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_RefreshTokens(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC().Round(0)
+	svc, policies, _, o := newTestPolicyService(t, now, Policy{Length: 5 * time.Minute})
+	WithRefreshTokenLength(24 * time.Hour)(svc)
+
+	t.Run("exchanges a refresh token for a new session", func(t *testing.T) {
+		s, err := svc.CreateSession(ctx, "user")
+		require.NoError(t, err)
+		rt, err := svc.CreateRefreshToken(ctx, s)
+		require.NoError(t, err)
+		assert.Equal(t, now.Add(24*time.Hour), rt.ExpiresAt)
+
+		next, nextRT, err := svc.RefreshSession(ctx, rt.Key)
+		require.NoError(t, err)
+		assert.NotEqual(t, s.Key, next.Key)
+		assert.NotEqual(t, rt.Key, nextRT.Key)
+		assert.Equal(t, next.ID, nextRT.SessionID)
+
+		// the previous session is expired, and the refresh token is used once.
+		_, err = svc.FindSession(ctx, s.Key)
+		assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+		_, _, err = svc.RefreshSession(ctx, rt.Key)
+		assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+
+		_, err = svc.FindSession(ctx, next.Key)
+		require.NoError(t, err)
+	})
+
+	t.Run("a refresh token is used once by concurrent refreshes", func(t *testing.T) {
+		s, err := svc.CreateSession(ctx, "user")
+		require.NoError(t, err)
+		rt, err := svc.CreateRefreshToken(ctx, s)
+		require.NoError(t, err)
+
+		const refreshes = 10
+		errs := make(chan error, refreshes)
+		var wg sync.WaitGroup
+		for i := 0; i < refreshes; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _, err := svc.RefreshSession(ctx, rt.Key)
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		var refreshed int
+		for err := range errs {
+			if err == nil {
+				refreshed++
+				continue
+			}
+			assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+		}
+		assert.Equal(t, 1, refreshed)
+	})
+
+	t.Run("revoking a refresh token expires its session", func(t *testing.T) {
+		s, err := svc.CreateSession(ctx, "user")
+		require.NoError(t, err)
+		rt, err := svc.CreateRefreshToken(ctx, s)
+		require.NoError(t, err)
+
+		require.NoError(t, svc.RevokeRefreshToken(ctx, rt.Key))
+		_, err = svc.FindSession(ctx, s.Key)
+		assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+		_, _, err = svc.RefreshSession(ctx, rt.Key)
+		assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+	})
+
+	t.Run("signing out revokes the refresh token", func(t *testing.T) {
+		s, err := svc.CreateSession(ctx, "user")
+		require.NoError(t, err)
+		rt, err := svc.CreateRefreshToken(ctx, s)
+		require.NoError(t, err)
+
+		require.NoError(t, svc.ExpireSession(ctx, s.Key))
+		_, _, err = svc.RefreshSession(ctx, rt.Key)
+		assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+	})
+
+	t.Run("refresh tokens are limited by the max lifetime", func(t *testing.T) {
+		require.NoError(t, policies.PutOrgPolicy(ctx, o.ID, Policy{MaxLifetime: time.Hour}))
+		defer policies.DeleteOrgPolicy(ctx, o.ID)
+
+		s, err := svc.CreateSession(ctx, "user")
+		require.NoError(t, err)
+		rt, err := svc.CreateRefreshToken(ctx, s)
+		require.NoError(t, err)
+		assert.Equal(t, now.Add(time.Hour), rt.ExpiresAt)
+
+		svc.now = func() time.Time { return now.Add(30 * time.Minute) }
+		defer func() { svc.now = func() time.Time { return now } }()
+		_, next, err := svc.RefreshSession(ctx, rt.Key)
+		require.NoError(t, err)
+		assert.Equal(t, now, next.CreatedAt)
+		assert.Equal(t, now.Add(time.Hour), next.ExpiresAt)
+	})
+}
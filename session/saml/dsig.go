@@ -0,0 +1,62 @@
+package saml
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/russellhaering/goxmldsig/etreeutils"
+)
+
+var errNotSigned = errors.New("element is not signed")
+
+// verifySignature verifies the enveloped signature of e with one of certs,
+// and returns the element which was signed. It returns errNotSigned when e
+// has no signature. The returned element is parsed from the canonical form
+// the signature was computed over, so callers reading it, rather than e,
+// never read what was not signed.
+func verifySignature(e *element, certs []*x509.Certificate, now time.Time) (*element, error) {
+	sigs := e.childElements(dsig.Namespace, dsig.SignatureTag)
+	if len(sigs) == 0 {
+		return nil, errNotSigned
+	}
+	if len(sigs) > 1 {
+		return nil, errors.New("element has more than one signature")
+	}
+
+	// the signature is verified on a copy of e, which declares the namespaces
+	// e inherits from the document.
+	ctx, err := etreeutils.NSBuildParentContext(e.Element)
+	if err != nil {
+		return nil, err
+	}
+	detached, err := etreeutils.NSDetatch(ctx, e.Element)
+	if err != nil {
+		return nil, err
+	}
+	// the certificates are the ones of the metadata of the identity
+	// provider, the key info of the signature is not signed.
+	for _, sig := range detached.ChildElements() {
+		if (&element{sig}).is(dsig.Namespace, dsig.SignatureTag) {
+			for _, ki := range (&element{sig}).childElements(dsig.Namespace, dsig.KeyInfoTag) {
+				sig.RemoveChild(ki.Element)
+			}
+		}
+	}
+
+	for _, cert := range certs {
+		vc := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{
+			Roots: []*x509.Certificate{cert},
+		})
+		// the certificate must be valid at the time the response is parsed.
+		vc.Clock = dsig.NewFakeClockAt(now)
+		signed, verr := vc.Validate(detached)
+		if verr == nil {
+			return &element{signed}, nil
+		}
+		err = verr
+	}
+	return nil, fmt.Errorf("signature is not valid for the certificates of the identity provider: %w", err)
+}
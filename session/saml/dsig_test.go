@@ -0,0 +1,215 @@
+package saml
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// idpFixture is an identity provider of testdata, which signed its responses
+// for a service provider at a time.
+type idpFixture struct {
+	name      string
+	acsURL    string
+	entityID  string
+	requestID string
+	now       string
+}
+
+var (
+	googleIDP = idpFixture{
+		name:      "google",
+		acsURL:    "https://29ee6d2e.ngrok.io/saml/acs",
+		entityID:  "https://29ee6d2e.ngrok.io/saml/metadata",
+		requestID: "id-fd419a5ab0472645427f8e07d87a3a5dd0b2e9a6",
+		now:       "2016-01-05T16:55:39Z",
+	}
+	oktaIDP = idpFixture{
+		name:      "okta",
+		acsURL:    "http://localhost:8000/saml/acs",
+		entityID:  "http://localhost:8000/saml/metadata",
+		requestID: "id-a7364d1e4432aa9085a7a8bd824ea2fa8fa8f684",
+		now:       "2020-03-03T19:24:28Z",
+	}
+	oneloginIDP = idpFixture{
+		name:      "onelogin",
+		acsURL:    "https://29ee6d2e.ngrok.io/saml/acs",
+		entityID:  "https://29ee6d2e.ngrok.io/saml/metadata",
+		requestID: "id-d40c15c104b52691eccf0a2a5c8a15595be75423",
+		now:       "2016-01-05T17:53:12Z",
+	}
+	secureworksIDP = idpFixture{
+		name:      "secureworks",
+		acsURL:    "https://preview.docrocket-ross.test.octolabs.io/saml/acs",
+		entityID:  "https://preview.docrocket-ross.test.octolabs.io/saml/metadata",
+		requestID: "id-3992f74e652d89c3cf1efd6c7e472abaac9bc917",
+		now:       "2017-04-21T13:12:51Z",
+	}
+	simplesamlphpIDP = idpFixture{
+		name:      "simplesamlphp",
+		acsURL:    "http://sp.example.com/demo1/index.php?acs",
+		entityID:  "http://sp.example.com/demo1/metadata.php",
+		requestID: "ONELOGIN_4fee3b046395c4e751011e97f8900b5273d56685",
+		now:       "2014-07-17T14:12:57Z",
+	}
+)
+
+// serviceProvider returns the service provider the identity provider signed
+// its responses for, which sent the request they answer.
+func (f idpFixture) serviceProvider(t *testing.T) *ServiceProvider {
+	t.Helper()
+	var ed entityDescriptor
+	require.NoError(t, xml.Unmarshal(readTestdata(t, f.name+"_metadata.xml"), &ed))
+	require.NotNil(t, ed.IDPSSODescriptor)
+
+	// the metadata of the fixtures is not always for the HTTP-Redirect
+	// binding, so it is not parsed as the metadata of influxd is.
+	var certs []*x509.Certificate
+	for _, kd := range ed.IDPSSODescriptor.KeyDescriptors {
+		for _, c := range kd.Certificates {
+			der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(c), ""))
+			require.NoError(t, err)
+			cert, err := x509.ParseCertificate(der)
+			require.NoError(t, err)
+			certs = append(certs, cert)
+		}
+	}
+
+	now, err := time.Parse(time.RFC3339, f.now)
+	require.NoError(t, err)
+	return &ServiceProvider{
+		entityID:    f.entityID,
+		acsURL:      f.acsURL,
+		idpEntityID: ed.EntityID,
+		idpCerts:    certs,
+		requests:    map[string]time.Time{f.requestID: now.Add(requestLifetime)},
+		consumed:    map[string]time.Time{},
+		now:         func() time.Time { return now },
+	}
+}
+
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	return b
+}
+
+func TestServiceProvider_IdentityProviders(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		idp      idpFixture
+		response string
+		nameID   string
+	}{
+		{name: "google signed response", idp: googleIDP, response: "google_response.xml", nameID: "ross@octolabs.io"},
+		{name: "onelogin signed response", idp: oneloginIDP, response: "onelogin_response.xml", nameID: "ross@kndr.org"},
+		{name: "secureworks signed assertion", idp: secureworksIDP, response: "secureworks_response.xml", nameID: "rkinder@secureworks.com"},
+		{name: "secureworks key value in key info", idp: secureworksIDP, response: "secureworks_rsakeyvalue_response.xml", nameID: "rkinder@secureworks.com"},
+		{name: "simplesamlphp signed assertion", idp: simplesamlphpIDP, response: "simplesamlphp_response.xml", nameID: "_ce3d2948b4cf20146dee0a0b3dd6f69b6cf86f62d7"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			sp := tt.idp.serviceProvider(t)
+			a, err := sp.ParseResponse(base64.StdEncoding.EncodeToString(readTestdata(t, tt.response)))
+			require.NoError(t, err)
+			assert.Equal(t, tt.nameID, a.NameID)
+		})
+	}
+
+	t.Run("okta signed response with an encrypted assertion", func(t *testing.T) {
+		// the signature of the response is verified before its assertion is
+		// refused.
+		sp := oktaIDP.serviceProvider(t)
+		_, err := sp.ParseResponse(base64.StdEncoding.EncodeToString(readTestdata(t, "okta_response.xml")))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "encrypted SAML assertions are not supported")
+	})
+
+	t.Run("certificates are checked at the time of the response", func(t *testing.T) {
+		sp := googleIDP.serviceProvider(t)
+		sp.now = time.Now
+		_, err := sp.ParseResponse(base64.StdEncoding.EncodeToString(readTestdata(t, "google_response.xml")))
+		assert.Error(t, err)
+	})
+}
+
+func TestServiceProvider_RejectsModifiedResponses(t *testing.T) {
+	const nameID = "<saml2:NameID>ross@octolabs.io</saml2:NameID>"
+	google := string(readTestdata(t, "google_response.xml"))
+	require.Contains(t, google, nameID)
+
+	parse := func(t *testing.T, resp string) (*Assertion, error) {
+		t.Helper()
+		return googleIDP.serviceProvider(t).ParseResponse(base64.StdEncoding.EncodeToString([]byte(resp)))
+	}
+	replace := func(old, new string) string {
+		return strings.Replace(google, old, new, 1)
+	}
+
+	t.Run("reads the NameID a comment splits as it was signed", func(t *testing.T) {
+		a, err := parse(t, replace(nameID, "<saml2:NameID>ross@<!-- comment -->octolabs.io</saml2:NameID>"))
+		require.NoError(t, err)
+		assert.Equal(t, "ross@octolabs.io", a.NameID)
+	})
+
+	t.Run("reads CDATA as the character data it is equivalent to", func(t *testing.T) {
+		a, err := parse(t, replace(nameID, "<saml2:NameID><![CDATA[ross@octolabs.io]]></saml2:NameID>"))
+		require.NoError(t, err)
+		assert.Equal(t, "ross@octolabs.io", a.NameID)
+
+		_, err = parse(t, replace(nameID, "<saml2:NameID><![CDATA[admin@octolabs.io]]></saml2:NameID>"))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects elements with duplicate ID attributes", func(t *testing.T) {
+		_, err := parse(t, replace(`ID="_fc141db284eb3098605351bde4d9be59"`, `ID="_fc141db284eb3098605351bde4d9be59" ID="evil"`))
+		assert.Error(t, err)
+		_, err = parse(t, replace(`ID="_9e764952e6a261e19409a3825581033d"`, `ID="_9e764952e6a261e19409a3825581033d" ID="evil"`))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects namespace redeclarations", func(t *testing.T) {
+		_, err := parse(t, replace(nameID, `<saml2:NameID xmlns:saml2="urn:evil">ross@octolabs.io</saml2:NameID>`))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects a second unsigned assertion", func(t *testing.T) {
+		start := strings.Index(google, "<saml2:Assertion")
+		end := strings.Index(google, "</saml2:Assertion>") + len("</saml2:Assertion>")
+		evil := strings.Replace(google[start:end], "ross@octolabs.io", "admin@octolabs.io", 1)
+		evil = strings.Replace(evil, `ID="_9e764952e6a261e19409a3825581033d"`, `ID="evil"`, 1)
+
+		_, err := parse(t, google[:end]+evil+google[end:])
+		assert.Error(t, err)
+		_, err = parse(t, google[:start]+evil+google[start:])
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects documents with a DTD", func(t *testing.T) {
+		_, err := parse(t, replace(`<?xml version="1.0" encoding="UTF-8" standalone="no"?>`,
+			`<?xml version="1.0" encoding="UTF-8" standalone="no"?><!DOCTYPE r [<!ENTITY e "admin">]>`))
+		assert.Error(t, err)
+	})
+}
+
+func TestServiceProvider_RejectsSignatureWrapping(t *testing.T) {
+	for i, idp := range []idpFixture{
+		oneloginIDP, oneloginIDP,
+		simplesamlphpIDP, simplesamlphpIDP, simplesamlphpIDP, simplesamlphpIDP, simplesamlphpIDP, simplesamlphpIDP, simplesamlphpIDP,
+	} {
+		name := "xsw" + string(rune('1'+i))
+		t.Run(name, func(t *testing.T) {
+			sp := idp.serviceProvider(t)
+			_, err := sp.ParseResponse(base64.StdEncoding.EncodeToString(readTestdata(t, name+"_response.xml")))
+			assert.Error(t, err)
+		})
+	}
+}
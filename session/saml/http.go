@@ -0,0 +1,137 @@
+package saml
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/session"
+	"go.uber.org/zap"
+)
+
+const prefixSAML = "/api/v2/saml"
+
+// maxResponseSize is the largest SAML response which is read.
+const maxResponseSize = 1 << 20
+
+var errUnauthorized = &errors.Error{
+	Code: errors.EUnauthorized,
+	Msg:  "SAML sign in failed",
+}
+
+// Handler is the HTTP handler of the SAML service provider. The routes are
+// not authenticated, users are signed in with them.
+type Handler struct {
+	chi.Router
+
+	api *kithttp.API
+	log *zap.Logger
+
+	sp          *ServiceProvider
+	provisioner *Provisioner
+	sessionSvc  influxdb.SessionService
+}
+
+// NewHandler returns a new instance of Handler. The sessions of users signed
+// in are created by sessionSvc.
+func NewHandler(log *zap.Logger, sp *ServiceProvider, provisioner *Provisioner, sessionSvc influxdb.SessionService) *Handler {
+	h := &Handler{
+		api:         kithttp.NewAPI(kithttp.WithLog(log)),
+		log:         log,
+		sp:          sp,
+		provisioner: provisioner,
+		sessionSvc:  sessionSvc,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+	r.Get("/metadata", h.handleMetadata)
+	r.Get("/login", h.handleLogin)
+	r.Post("/acs", h.handleACS)
+	h.Router = r
+	return h
+}
+
+// Prefix returns the mounting prefix for the handler.
+func (h *Handler) Prefix() string {
+	return prefixSAML
+}
+
+// handleMetadata is the HTTP handler for the GET /api/v2/saml/metadata route.
+func (h *Handler) handleMetadata(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/samlmetadata+xml")
+	h.api.Write(w, http.StatusOK, h.sp.Metadata())
+}
+
+// handleLogin is the HTTP handler for the GET /api/v2/saml/login route. It
+// redirects to the identity provider, which redirects back to the redirect
+// query parameter once the user is signed in.
+func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
+	u, err := h.sp.AuthnRequestURL(localRedirect(r.URL.Query().Get("redirect")))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	http.Redirect(w, r, u, http.StatusFound)
+}
+
+// handleACS is the HTTP handler for the POST /api/v2/saml/acs route, the
+// assertion consumer service the identity provider posts its responses to.
+func (h *Handler) handleACS(w http.ResponseWriter, r *http.Request) {
+	r = session.ClientRequest(r)
+	ctx := r.Context()
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxResponseSize)
+	if err := r.ParseForm(); err != nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "invalid SAML response form",
+			Err:  err,
+		})
+		return
+	}
+
+	a, err := h.sp.ParseResponse(r.PostForm.Get("SAMLResponse"))
+	if err != nil {
+		h.log.Info("Rejected SAML response", zap.Error(err))
+		h.api.Err(w, r, errUnauthorized)
+		return
+	}
+
+	u, err := h.provisioner.Provision(ctx, a)
+	if err != nil {
+		h.log.Info("Failed to provision SAML user", zap.String("nameID", a.NameID), zap.Error(err))
+		if errors.ErrorCode(err) != errors.EForbidden {
+			err = errUnauthorized
+		}
+		h.api.Err(w, r, err)
+		return
+	}
+
+	s, err := h.sessionSvc.CreateSession(ctx, u.Name)
+	if err != nil {
+		h.api.Err(w, r, errUnauthorized)
+		return
+	}
+
+	session.EncodeCookieSession(w, s, r.TLS != nil)
+	http.Redirect(w, r, localRedirect(r.PostForm.Get("RelayState")), http.StatusSeeOther)
+}
+
+// localRedirect returns the path users are redirected to after signing in,
+// which must be a path of influxd so the relay state can't redirect users
+// elsewhere.
+func localRedirect(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return "/"
+	}
+	return path
+}
@@ -0,0 +1,223 @@
+package saml
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"go.uber.org/zap"
+)
+
+// orgMapping maps a value of the org attribute of assertions to an
+// organization.
+type orgMapping struct {
+	value string
+	org   string
+	role  influxdb.UserType
+}
+
+// parseOrgMappings parses mappings of the form value=org or value=org:owner.
+func parseOrgMappings(mappings []string) ([]orgMapping, error) {
+	var ms []orgMapping
+	for _, m := range mappings {
+		value, org, ok := strings.Cut(m, "=")
+		if !ok || value == "" || org == "" {
+			return nil, fmt.Errorf("invalid SAML org mapping %q: expected value=org or value=org:owner", m)
+		}
+		role := influxdb.Member
+		if name, r, ok := strings.Cut(org, ":"); ok {
+			org, role = name, influxdb.UserType(r)
+			if role != influxdb.Owner && role != influxdb.Member {
+				return nil, fmt.Errorf("invalid SAML org mapping %q: role must be %s or %s", m, influxdb.Owner, influxdb.Member)
+			}
+		}
+		ms = append(ms, orgMapping{value: value, org: org, role: role})
+	}
+	return ms, nil
+}
+
+// subjectPrefix prefixes the subject of the assertion stored as the OAuthID
+// of the users created from SAML assertions, which marks them as such.
+const subjectPrefix = "saml:"
+
+// Provisioner creates the users of assertions, and keeps their memberships of
+// the mapped organizations in sync with the assertions. The memberships of
+// organizations which are not mapped are left as they are.
+type Provisioner struct {
+	log *zap.Logger
+
+	userSvc influxdb.UserService
+	orgSvc  influxdb.OrganizationService
+	urmSvc  influxdb.UserResourceMappingService
+
+	usernameAttribute string
+	orgAttribute      string
+	mappings          []orgMapping
+	linkExisting      bool
+}
+
+// NewProvisioner constructs a provisioner of the users of assertions with the
+// attributes and org mappings of cfg.
+func NewProvisioner(log *zap.Logger, cfg Config, userSvc influxdb.UserService, orgSvc influxdb.OrganizationService, urmSvc influxdb.UserResourceMappingService) (*Provisioner, error) {
+	mappings, err := parseOrgMappings(cfg.OrgMappings)
+	if err != nil {
+		return nil, err
+	}
+	if len(mappings) > 0 && cfg.OrgAttribute == "" {
+		return nil, fmt.Errorf("SAML org mappings require the attribute the organizations are mapped from")
+	}
+	return &Provisioner{
+		log:               log,
+		userSvc:           userSvc,
+		orgSvc:            orgSvc,
+		urmSvc:            urmSvc,
+		usernameAttribute: cfg.UsernameAttribute,
+		orgAttribute:      cfg.OrgAttribute,
+		mappings:          mappings,
+		linkExisting:      cfg.LinkExistingUsers,
+	}, nil
+}
+
+// Provision returns the user of an assertion, which is created if it does not
+// exist. An existing user of the same name is only the user of the assertion
+// if it was created from an assertion too, unless existing users are linked.
+func (p *Provisioner) Provision(ctx context.Context, a *Assertion) (*influxdb.User, error) {
+	name := a.Username(p.usernameAttribute)
+	if name == "" {
+		return nil, &errors.Error{
+			Code: errors.EForbidden,
+			Msg:  "SAML assertion has no username",
+		}
+	}
+
+	u, err := p.userSvc.FindUser(ctx, influxdb.UserFilter{Name: &name})
+	if errors.ErrorCode(err) == errors.ENotFound {
+		u = &influxdb.User{Name: name, OAuthID: subjectPrefix + a.NameID, Status: influxdb.Active}
+		if err := p.userSvc.CreateUser(ctx, u); err != nil {
+			return nil, err
+		}
+		p.log.Info("Created user from SAML assertion", zap.String("user", name))
+	} else if err != nil {
+		return nil, err
+	} else if err := p.canLink(ctx, u); err != nil {
+		p.log.Warn("SAML assertion refused for an existing user", zap.String("user", name), zap.Error(err))
+		return nil, err
+	}
+	if u.Status == influxdb.Inactive {
+		return nil, &errors.Error{
+			Code: errors.EForbidden,
+			Msg:  "user is inactive",
+		}
+	}
+
+	if len(p.mappings) == 0 {
+		return u, nil
+	}
+	if err := p.syncOrgs(ctx, u, a.Attributes[p.orgAttribute]); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// canLink returns an error unless an assertion may sign in as the existing
+// user u: a user created from an assertion, or any user but an operator when
+// existing users are linked.
+func (p *Provisioner) canLink(ctx context.Context, u *influxdb.User) error {
+	refused := &errors.Error{
+		Code: errors.EForbidden,
+		Msg:  "a local user of the same name exists, which can not sign in with SAML",
+	}
+	if !p.linkExisting && !strings.HasPrefix(u.OAuthID, subjectPrefix) {
+		return refused
+	}
+	// operator users have the instance resource mapped to them.
+	urms, _, err := p.urmSvc.FindUserResourceMappings(ctx, influxdb.UserResourceMappingFilter{
+		UserID:       u.ID,
+		ResourceType: influxdb.InstanceResourceType,
+	})
+	if err != nil {
+		return err
+	}
+	if len(urms) > 0 {
+		return refused
+	}
+	return nil
+}
+
+// syncOrgs grants the user the roles of the mapped organizations of values,
+// and removes it from the mapped organizations it is not mapped to anymore.
+func (p *Provisioner) syncOrgs(ctx context.Context, u *influxdb.User, values []string) error {
+	has := map[string]bool{}
+	for _, v := range values {
+		has[v] = true
+	}
+
+	// the roles of the user by organization, an owner mapping wins.
+	roles := map[string]influxdb.UserType{}
+	for _, m := range p.mappings {
+		if _, ok := roles[m.org]; !ok {
+			roles[m.org] = ""
+		}
+		if has[m.value] && roles[m.org] != influxdb.Owner {
+			roles[m.org] = m.role
+		}
+	}
+
+	granted := 0
+	for name, role := range roles {
+		org, err := p.orgSvc.FindOrganization(ctx, influxdb.OrganizationFilter{Name: &name})
+		if errors.ErrorCode(err) == errors.ENotFound {
+			p.log.Warn("SAML org mapping refers to a missing organization", zap.String("org", name))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := p.syncRole(ctx, u.ID, org.ID, role); err != nil {
+			return err
+		}
+		if role != "" {
+			granted++
+		}
+	}
+	if granted == 0 {
+		return &errors.Error{
+			Code: errors.EForbidden,
+			Msg:  "user is not mapped to an organization",
+		}
+	}
+	return nil
+}
+
+// syncRole sets the role of the user in the organization, the user is removed
+// from the organization when the role is empty.
+func (p *Provisioner) syncRole(ctx context.Context, userID, orgID platform.ID, role influxdb.UserType) error {
+	urms, _, err := p.urmSvc.FindUserResourceMappings(ctx, influxdb.UserResourceMappingFilter{
+		UserID:       userID,
+		ResourceID:   orgID,
+		ResourceType: influxdb.OrgsResourceType,
+	})
+	if err != nil {
+		return err
+	}
+	for _, urm := range urms {
+		if urm.UserType == role {
+			return nil
+		}
+		if err := p.urmSvc.DeleteUserResourceMapping(ctx, orgID, userID); err != nil {
+			return err
+		}
+	}
+	if role == "" {
+		return nil
+	}
+	return p.urmSvc.CreateUserResourceMapping(ctx, &influxdb.UserResourceMapping{
+		UserID:       userID,
+		UserType:     role,
+		ResourceType: influxdb.OrgsResourceType,
+		ResourceID:   orgID,
+	})
+}
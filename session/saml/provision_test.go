@@ -0,0 +1,57 @@
+package saml
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/tenant"
+	itesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestProvisioner_ExistingUsers(t *testing.T) {
+	ctx := context.Background()
+	ts := tenant.NewService(tenant.NewStore(itesting.NewTestInmemStore(t)))
+
+	local := &influxdb.User{Name: "alice", Status: influxdb.Active}
+	require.NoError(t, ts.CreateUser(ctx, local))
+	operator := &influxdb.User{Name: "admin", Status: influxdb.Active}
+	require.NoError(t, ts.CreateUser(ctx, operator))
+	require.NoError(t, ts.CreateUserResourceMapping(ctx, &influxdb.UserResourceMapping{
+		UserID:       operator.ID,
+		UserType:     influxdb.Owner,
+		MappingType:  influxdb.UserMappingType,
+		ResourceType: influxdb.InstanceResourceType,
+		ResourceID:   platform.ID(1),
+	}))
+
+	p, err := NewProvisioner(zaptest.NewLogger(t), Config{}, ts, ts, ts)
+	require.NoError(t, err)
+
+	// The users of assertions are created, and sign in again as the same user.
+	u, err := p.Provision(ctx, &Assertion{NameID: "bob"})
+	require.NoError(t, err)
+	require.Equal(t, "saml:bob", u.OAuthID)
+	again, err := p.Provision(ctx, &Assertion{NameID: "bob"})
+	require.NoError(t, err)
+	require.Equal(t, u.ID, again.ID)
+
+	// Local users are not signed in as from assertions of the same name.
+	_, err = p.Provision(ctx, &Assertion{NameID: "alice"})
+	require.Equal(t, errors.EForbidden, errors.ErrorCode(err))
+	_, err = p.Provision(ctx, &Assertion{NameID: "admin"})
+	require.Equal(t, errors.EForbidden, errors.ErrorCode(err))
+
+	// Unless existing users are linked, but never operators.
+	p, err = NewProvisioner(zaptest.NewLogger(t), Config{LinkExistingUsers: true}, ts, ts, ts)
+	require.NoError(t, err)
+	u, err = p.Provision(ctx, &Assertion{NameID: "alice"})
+	require.NoError(t, err)
+	require.Equal(t, local.ID, u.ID)
+	_, err = p.Provision(ctx, &Assertion{NameID: "admin"})
+	require.Equal(t, errors.EForbidden, errors.ErrorCode(err))
+}
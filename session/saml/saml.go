@@ -0,0 +1,506 @@
+// Package saml implements a SAML 2.0 service provider, which signs users in
+// with the assertions of an identity provider and creates sessions for them.
+//
+// Authentication requests are sent with the HTTP-Redirect binding, and
+// responses are received with the HTTP-POST binding. The response or the
+// assertion it contains must be signed by the identity provider, encrypted
+// assertions are not supported.
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	protocolNamespace  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	assertionNamespace = "urn:oasis:names:tc:SAML:2.0:assertion"
+	metadataNamespace  = "urn:oasis:names:tc:SAML:2.0:metadata"
+
+	redirectBinding = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+	postBinding     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+
+	statusSuccess      = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	bearerConfirmation = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+	unspecifiedNameID  = "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified"
+
+	// maxClockSkew is the difference between the clocks of the identity
+	// provider and influxd which is tolerated.
+	maxClockSkew = 3 * time.Minute
+
+	// requestLifetime is how long a user has to sign in with the identity
+	// provider.
+	requestLifetime = 10 * time.Minute
+
+	// maxRequests is the most authentication requests which are waiting for
+	// their responses. Requests are sent to users who are not signed in yet,
+	// so the oldest ones are forgotten beyond it.
+	maxRequests = 10000
+)
+
+// Config configures the SAML service provider.
+type Config struct {
+	// IDPMetadataFile is the metadata of the identity provider. SAML is not
+	// enabled when it is empty.
+	IDPMetadataFile string
+	// RootURL is the external URL of influxd, such as https://influxdb.example.com,
+	// the identity provider posts its assertions back to.
+	RootURL string
+	// EntityID identifies influxd to the identity provider. It defaults to
+	// the URL of the metadata of influxd.
+	EntityID string
+	// UsernameAttribute is the attribute of the assertions which is the name
+	// of the user. The NameID of the subject is the name when it is empty.
+	UsernameAttribute string
+	// OrgAttribute is the attribute of the assertions the organizations of
+	// the user are mapped from, such as groups.
+	OrgAttribute string
+	// OrgMappings map the values of OrgAttribute to organizations, as
+	// value=org or value=org:owner. Users are members of the organizations
+	// unless the owner role is mapped.
+	OrgMappings []string
+	// AllowIDPInitiated accepts assertions which were not requested by
+	// influxd, as when users sign in from the portal of the identity provider.
+	AllowIDPInitiated bool
+	// LinkExistingUsers signs the users of assertions in as the local users
+	// of the same name which were not created from SAML assertions, such as
+	// the users created before SAML was enabled. Operator users are never
+	// signed in from assertions.
+	LinkExistingUsers bool
+}
+
+// Enabled returns true when SAML single sign-on is configured.
+func (c Config) Enabled() bool {
+	return c.IDPMetadataFile != ""
+}
+
+// Assertion is the identity of a user asserted by the identity provider.
+type Assertion struct {
+	ID         string
+	NameID     string
+	Attributes map[string][]string
+}
+
+// Username returns the name of the user of the assertion, which is the value
+// of attribute unless it is empty.
+func (a *Assertion) Username(attribute string) string {
+	if attribute == "" {
+		return a.NameID
+	}
+	if vs := a.Attributes[attribute]; len(vs) > 0 {
+		return vs[0]
+	}
+	return ""
+}
+
+// ServiceProvider validates the assertions of an identity provider.
+type ServiceProvider struct {
+	entityID string
+	acsURL   string
+
+	idpEntityID string
+	idpSSOURL   string
+	idpCerts    []*x509.Certificate
+
+	allowIDPInitiated bool
+
+	mu sync.Mutex
+	// requests are the IDs of the authentication requests sent which were
+	// not answered yet, with the time they expire.
+	requests map[string]time.Time
+	// consumed are the IDs of the assertions which were used, until they
+	// expire, so assertions are not replayed.
+	consumed map[string]time.Time
+
+	now func() time.Time
+}
+
+// NewServiceProvider constructs a service provider for the identity provider
+// of the metadata of cfg.
+func NewServiceProvider(cfg Config) (*ServiceProvider, error) {
+	root, err := url.Parse(strings.TrimSuffix(cfg.RootURL, "/"))
+	if err != nil || root.Scheme == "" || root.Host == "" {
+		return nil, fmt.Errorf("invalid SAML root URL %q: it must be the external URL of influxd", cfg.RootURL)
+	}
+
+	b, err := os.ReadFile(cfg.IDPMetadataFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SAML identity provider metadata: %w", err)
+	}
+	idp, err := parseIDPMetadata(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SAML identity provider metadata %s: %w", cfg.IDPMetadataFile, err)
+	}
+
+	sp := &ServiceProvider{
+		entityID:          cfg.EntityID,
+		acsURL:            root.String() + prefixSAML + "/acs",
+		idpEntityID:       idp.entityID,
+		idpSSOURL:         idp.ssoURL,
+		idpCerts:          idp.certs,
+		allowIDPInitiated: cfg.AllowIDPInitiated,
+		requests:          map[string]time.Time{},
+		consumed:          map[string]time.Time{},
+		now:               time.Now,
+	}
+	if sp.entityID == "" {
+		sp.entityID = root.String() + prefixSAML + "/metadata"
+	}
+	return sp, nil
+}
+
+type idpMetadata struct {
+	entityID string
+	ssoURL   string
+	certs    []*x509.Certificate
+}
+
+type entityDescriptor struct {
+	XMLName          xml.Name `xml:"urn:oasis:names:tc:SAML:2.0:metadata EntityDescriptor"`
+	EntityID         string   `xml:"entityID,attr"`
+	IDPSSODescriptor *struct {
+		KeyDescriptors []struct {
+			Use          string   `xml:"use,attr"`
+			Certificates []string `xml:"http://www.w3.org/2000/09/xmldsig# KeyInfo>X509Data>X509Certificate"`
+		} `xml:"urn:oasis:names:tc:SAML:2.0:metadata KeyDescriptor"`
+		SingleSignOnServices []struct {
+			Binding  string `xml:"Binding,attr"`
+			Location string `xml:"Location,attr"`
+		} `xml:"urn:oasis:names:tc:SAML:2.0:metadata SingleSignOnService"`
+	} `xml:"urn:oasis:names:tc:SAML:2.0:metadata IDPSSODescriptor"`
+}
+
+func parseIDPMetadata(b []byte) (*idpMetadata, error) {
+	var ed entityDescriptor
+	if err := xml.Unmarshal(b, &ed); err != nil {
+		return nil, err
+	}
+	if ed.EntityID == "" || ed.IDPSSODescriptor == nil {
+		return nil, errors.New("metadata does not describe an identity provider")
+	}
+
+	md := &idpMetadata{entityID: ed.EntityID}
+	for _, sso := range ed.IDPSSODescriptor.SingleSignOnServices {
+		if sso.Binding == redirectBinding {
+			md.ssoURL = sso.Location
+		}
+	}
+	if md.ssoURL == "" {
+		return nil, errors.New("identity provider has no single sign-on service with the HTTP-Redirect binding")
+	}
+
+	for _, kd := range ed.IDPSSODescriptor.KeyDescriptors {
+		if kd.Use != "" && kd.Use != "signing" {
+			continue
+		}
+		for _, c := range kd.Certificates {
+			der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(c), ""))
+			if err != nil {
+				return nil, fmt.Errorf("invalid signing certificate: %w", err)
+			}
+			cert, err := x509.ParseCertificate(der)
+			if err != nil {
+				return nil, fmt.Errorf("invalid signing certificate: %w", err)
+			}
+			md.certs = append(md.certs, cert)
+		}
+	}
+	if len(md.certs) == 0 {
+		return nil, errors.New("identity provider has no signing certificate")
+	}
+	return md, nil
+}
+
+// Metadata returns the metadata of the service provider, which is registered
+// with the identity provider.
+func (sp *ServiceProvider) Metadata() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	fmt.Fprintf(&buf, `<md:EntityDescriptor xmlns:md="%s" entityID="%s">`, metadataNamespace, escapeAttr(sp.entityID))
+	fmt.Fprintf(&buf, `<md:SPSSODescriptor AuthnRequestsSigned="false" WantAssertionsSigned="true" protocolSupportEnumeration="%s">`, protocolNamespace)
+	fmt.Fprintf(&buf, `<md:NameIDFormat>%s</md:NameIDFormat>`, unspecifiedNameID)
+	fmt.Fprintf(&buf, `<md:AssertionConsumerService Binding="%s" Location="%s" index="1"/>`, postBinding, escapeAttr(sp.acsURL))
+	buf.WriteString(`</md:SPSSODescriptor></md:EntityDescriptor>`)
+	return buf.Bytes()
+}
+
+// AuthnRequestURL returns the URL of the identity provider users are
+// redirected to, to sign in. The identity provider posts relayState back
+// with its response.
+func (sp *ServiceProvider) AuthnRequestURL(relayState string) (string, error) {
+	id, err := newID()
+	if err != nil {
+		return "", err
+	}
+	now := sp.now().UTC()
+
+	var req bytes.Buffer
+	fmt.Fprintf(&req, `<samlp:AuthnRequest xmlns:samlp="%s" xmlns:saml="%s" ID="%s" Version="2.0" IssueInstant="%s" Destination="%s" AssertionConsumerServiceURL="%s" ProtocolBinding="%s">`,
+		protocolNamespace, assertionNamespace, id, now.Format(time.RFC3339), escapeAttr(sp.idpSSOURL), escapeAttr(sp.acsURL), postBinding)
+	fmt.Fprintf(&req, `<saml:Issuer>%s</saml:Issuer>`, escapeText(sp.entityID))
+	fmt.Fprintf(&req, `<samlp:NameIDPolicy Format="%s" AllowCreate="true"/>`, unspecifiedNameID)
+	req.WriteString(`</samlp:AuthnRequest>`)
+
+	// the HTTP-Redirect binding deflates the request.
+	var deflated bytes.Buffer
+	w, err := flate.NewWriter(&deflated, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(req.Bytes()); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(sp.idpSSOURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("SAMLRequest", base64.StdEncoding.EncodeToString(deflated.Bytes()))
+	if relayState != "" {
+		q.Set("RelayState", relayState)
+	}
+	u.RawQuery = q.Encode()
+
+	sp.mu.Lock()
+	sp.prune(now)
+	if len(sp.requests) >= maxRequests {
+		sp.forgetOldestRequest()
+	}
+	sp.requests[id] = now.Add(requestLifetime)
+	sp.mu.Unlock()
+	return u.String(), nil
+}
+
+// ParseResponse validates the base64 encoded response of the identity
+// provider, and returns the assertion it contains.
+func (sp *ServiceProvider) ParseResponse(encoded string) (*Assertion, error) {
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SAML response encoding: %w", err)
+	}
+	resp, err := parseDocument(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SAML response: %w", err)
+	}
+	if !resp.is(protocolNamespace, "Response") {
+		return nil, errors.New("SAML response is not a Response")
+	}
+	now := sp.now().UTC()
+
+	// either the response, or the assertion it contains, is signed. Only the
+	// elements which were signed are read.
+	signedResp, err := verifySignature(resp, sp.idpCerts, now)
+	if err != nil && err != errNotSigned {
+		return nil, fmt.Errorf("invalid SAML response signature: %w", err)
+	}
+	if signedResp != nil {
+		resp = signedResp
+	}
+
+	if dest := resp.attr("Destination"); dest != "" && dest != sp.acsURL {
+		return nil, fmt.Errorf("SAML response is for %q, not %q", dest, sp.acsURL)
+	}
+	status := resp.child(protocolNamespace, "Status")
+	if status == nil {
+		return nil, errors.New("SAML response has no status")
+	}
+	if code := status.child(protocolNamespace, "StatusCode"); code == nil || code.attr("Value") != statusSuccess {
+		value := ""
+		if code != nil {
+			value = code.attr("Value")
+		}
+		return nil, fmt.Errorf("identity provider did not authenticate the user: %s", value)
+	}
+
+	if len(resp.childElements(assertionNamespace, "EncryptedAssertion")) > 0 {
+		return nil, errors.New("encrypted SAML assertions are not supported")
+	}
+	assertions := resp.childElements(assertionNamespace, "Assertion")
+	if len(assertions) != 1 {
+		return nil, errors.New("SAML response must contain exactly one assertion")
+	}
+	assertion, err := verifySignature(assertions[0], sp.idpCerts, now)
+	switch {
+	case err == errNotSigned && signedResp != nil:
+		assertion = assertions[0]
+	case err == errNotSigned:
+		return nil, errors.New("SAML response is not signed")
+	case err != nil:
+		return nil, fmt.Errorf("invalid SAML assertion signature: %w", err)
+	}
+
+	return sp.validateAssertion(assertion, resp.attr("InResponseTo"), now)
+}
+
+func (sp *ServiceProvider) validateAssertion(e *element, inResponseTo string, now time.Time) (*Assertion, error) {
+	if issuer := e.child(assertionNamespace, "Issuer"); issuer == nil || issuer.text() != sp.idpEntityID {
+		return nil, errors.New("SAML assertion is not issued by the identity provider")
+	}
+	id := e.attr("ID")
+	if id == "" {
+		return nil, errors.New("SAML assertion has no ID")
+	}
+
+	notOnOrAfter := now.Add(requestLifetime)
+	if conditions := e.child(assertionNamespace, "Conditions"); conditions != nil {
+		if err := checkTimes(conditions, now); err != nil {
+			return nil, err
+		}
+		if t, err := parseTime(conditions.attr("NotOnOrAfter")); err == nil && !t.IsZero() {
+			notOnOrAfter = t
+		}
+		for _, ar := range conditions.childElements(assertionNamespace, "AudienceRestriction") {
+			ok := false
+			for _, aud := range ar.childElements(assertionNamespace, "Audience") {
+				if aud.text() == sp.entityID {
+					ok = true
+				}
+			}
+			if !ok {
+				return nil, errors.New("SAML assertion is not for this service provider")
+			}
+		}
+	}
+
+	subject := e.child(assertionNamespace, "Subject")
+	if subject == nil {
+		return nil, errors.New("SAML assertion has no subject")
+	}
+	nameID := subject.child(assertionNamespace, "NameID")
+	if nameID == nil || nameID.text() == "" {
+		return nil, errors.New("SAML assertion has no NameID")
+	}
+
+	confirmed := false
+	for _, sc := range subject.childElements(assertionNamespace, "SubjectConfirmation") {
+		if sc.attr("Method") != bearerConfirmation {
+			continue
+		}
+		data := sc.child(assertionNamespace, "SubjectConfirmationData")
+		if data == nil {
+			continue
+		}
+		if data.attr("Recipient") != sp.acsURL {
+			continue
+		}
+		if err := checkTimes(data, now); err != nil {
+			continue
+		}
+		if irt := data.attr("InResponseTo"); irt != "" {
+			inResponseTo = irt
+		}
+		confirmed = true
+	}
+	if !confirmed {
+		return nil, errors.New("SAML assertion has no valid bearer subject confirmation")
+	}
+
+	a := &Assertion{ID: id, NameID: nameID.text(), Attributes: map[string][]string{}}
+	for _, as := range e.childElements(assertionNamespace, "AttributeStatement") {
+		for _, attr := range as.childElements(assertionNamespace, "Attribute") {
+			name := attr.attr("Name")
+			for _, v := range attr.childElements(assertionNamespace, "AttributeValue") {
+				a.Attributes[name] = append(a.Attributes[name], v.text())
+			}
+		}
+	}
+
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	sp.prune(now)
+	if inResponseTo != "" {
+		if _, ok := sp.requests[inResponseTo]; !ok {
+			return nil, errors.New("SAML assertion answers an unknown or expired request")
+		}
+		delete(sp.requests, inResponseTo)
+	} else if !sp.allowIDPInitiated {
+		return nil, errors.New("SAML assertions which were not requested are not accepted")
+	}
+	if _, ok := sp.consumed[id]; ok {
+		return nil, errors.New("SAML assertion was already used")
+	}
+	sp.consumed[id] = notOnOrAfter.Add(maxClockSkew)
+	return a, nil
+}
+
+// prune forgets the requests and assertions which expired. sp.mu must be held.
+func (sp *ServiceProvider) prune(now time.Time) {
+	for id, exp := range sp.requests {
+		if now.After(exp) {
+			delete(sp.requests, id)
+		}
+	}
+	for id, exp := range sp.consumed {
+		if now.After(exp) {
+			delete(sp.consumed, id)
+		}
+	}
+}
+
+// forgetOldestRequest forgets the request which expires first. sp.mu must be
+// held.
+func (sp *ServiceProvider) forgetOldestRequest() {
+	var oldest string
+	var oldestExp time.Time
+	for id, exp := range sp.requests {
+		if oldest == "" || exp.Before(oldestExp) {
+			oldest, oldestExp = id, exp
+		}
+	}
+	delete(sp.requests, oldest)
+}
+
+// checkTimes checks the NotBefore and NotOnOrAfter attributes of e.
+func checkTimes(e *element, now time.Time) error {
+	notBefore, err := parseTime(e.attr("NotBefore"))
+	if err != nil {
+		return err
+	}
+	if !notBefore.IsZero() && now.Add(maxClockSkew).Before(notBefore) {
+		return errors.New("SAML assertion is not valid yet")
+	}
+	notOnOrAfter, err := parseTime(e.attr("NotOnOrAfter"))
+	if err != nil {
+		return err
+	}
+	if !notOnOrAfter.IsZero() && !now.Add(-maxClockSkew).Before(notOnOrAfter) {
+		return errors.New("SAML assertion has expired")
+	}
+	return nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SAML time %q: %w", s, err)
+	}
+	return t, nil
+}
+
+// newID returns a random ID, which starts with a letter as XML IDs do.
+func newID() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "id" + hex.EncodeToString(b), nil
+}
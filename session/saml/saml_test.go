@@ -0,0 +1,289 @@
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testIDPEntityID = "https://idp.example.com/metadata"
+	testRootURL     = "https://influxdb.example.com"
+	testACSURL      = testRootURL + "/api/v2/saml/acs"
+	testSPEntityID  = testRootURL + "/api/v2/saml/metadata"
+)
+
+type testIDP struct {
+	key  *rsa.PrivateKey
+	cert *x509.Certificate
+}
+
+func newTestIDP(t *testing.T) *testIDP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testIDP{key: key, cert: cert}
+}
+
+func (idp *testIDP) metadataFile(t *testing.T) string {
+	t.Helper()
+	md := fmt.Sprintf(`<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="%s">
+  <md:IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <md:KeyDescriptor use="signing">
+      <ds:KeyInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#"><ds:X509Data><ds:X509Certificate>%s</ds:X509Certificate></ds:X509Data></ds:KeyInfo>
+    </md:KeyDescriptor>
+    <md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="https://idp.example.com/sso"/>
+  </md:IDPSSODescriptor>
+</md:EntityDescriptor>`, testIDPEntityID, base64.StdEncoding.EncodeToString(idp.cert.Raw))
+	path := filepath.Join(t.TempDir(), "idp.xml")
+	require.NoError(t, os.WriteFile(path, []byte(md), 0600))
+	return path
+}
+
+type testAssertion struct {
+	id           string
+	inResponseTo string
+	nameID       string
+	audience     string
+	recipient    string
+	notOnOrAfter time.Time
+	groups       []string
+}
+
+func (a testAssertion) xml() string {
+	var attrs strings.Builder
+	for _, g := range a.groups {
+		fmt.Fprintf(&attrs, `<saml:AttributeValue>%s</saml:AttributeValue>`, g)
+	}
+	return fmt.Sprintf(`<saml:Assertion xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="%s" Version="2.0" IssueInstant="%s">`+
+		`<saml:Issuer>%s</saml:Issuer>`+
+		`<saml:Subject><saml:NameID>%s</saml:NameID><saml:SubjectConfirmation Method="urn:oasis:names:tc:SAML:2.0:cm:bearer">`+
+		`<saml:SubjectConfirmationData InResponseTo="%s" NotOnOrAfter="%s" Recipient="%s"/></saml:SubjectConfirmation></saml:Subject>`+
+		`<saml:Conditions NotOnOrAfter="%s"><saml:AudienceRestriction><saml:Audience>%s</saml:Audience></saml:AudienceRestriction></saml:Conditions>`+
+		`<saml:AttributeStatement><saml:Attribute Name="groups">%s</saml:Attribute></saml:AttributeStatement>`+
+		`</saml:Assertion>`,
+		a.id, time.Now().UTC().Format(time.RFC3339), testIDPEntityID, a.nameID,
+		a.inResponseTo, a.notOnOrAfter.Format(time.RFC3339), a.recipient,
+		a.notOnOrAfter.Format(time.RFC3339), a.audience, attrs.String())
+}
+
+// GetKeyPair returns the key of the identity provider, which signs with it.
+func (idp *testIDP) GetKeyPair() (*rsa.PrivateKey, []byte, error) {
+	return idp.key, idp.cert.Raw, nil
+}
+
+// sign inserts an enveloped signature of the root element of doc, which has
+// the id, into doc.
+func (idp *testIDP) sign(t *testing.T, doc, id string) string {
+	t.Helper()
+	d := etree.NewDocument()
+	require.NoError(t, d.ReadFromString(doc))
+	require.Equal(t, id, d.Root().SelectAttrValue("ID", ""))
+
+	ctx := dsig.NewDefaultSigningContext(idp)
+	ctx.Canonicalizer = dsig.MakeC14N10ExclusiveCanonicalizerWithPrefixList("")
+	signed, err := ctx.SignEnveloped(d.Root())
+	require.NoError(t, err)
+
+	// the signature follows the issuer of the signed element.
+	sig := signed.RemoveChildAt(len(signed.Child) - 1)
+	signed.InsertChildAt(1, sig)
+	d.SetRoot(signed)
+	out, err := d.WriteToString()
+	require.NoError(t, err)
+	return out
+}
+
+func response(inResponseTo, assertion string) string {
+	return fmt.Sprintf(`<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="resp1" Version="2.0" InResponseTo="%s" Destination="%s">`+
+		`<saml:Issuer>%s</saml:Issuer><samlp:Status><samlp:StatusCode Value="urn:oasis:names:tc:SAML:2.0:status:Success"/></samlp:Status>%s</samlp:Response>`,
+		inResponseTo, testACSURL, testIDPEntityID, assertion)
+}
+
+func encode(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+func TestServiceProvider(t *testing.T) {
+	idp := newTestIDP(t)
+	newSP := func(t *testing.T, allowIDPInitiated bool) *ServiceProvider {
+		sp, err := NewServiceProvider(Config{
+			IDPMetadataFile:   idp.metadataFile(t),
+			RootURL:           testRootURL,
+			AllowIDPInitiated: allowIDPInitiated,
+		})
+		require.NoError(t, err)
+		return sp
+	}
+	validAssertion := func(id string) testAssertion {
+		return testAssertion{
+			id:           id,
+			nameID:       "alice",
+			audience:     testSPEntityID,
+			recipient:    testACSURL,
+			notOnOrAfter: time.Now().Add(5 * time.Minute).UTC(),
+			groups:       []string{"engineering", "ops"},
+		}
+	}
+
+	t.Run("metadata describes the assertion consumer service", func(t *testing.T) {
+		md := string(newSP(t, false).Metadata())
+		assert.Contains(t, md, `entityID="`+testSPEntityID+`"`)
+		assert.Contains(t, md, `Location="`+testACSURL+`"`)
+	})
+
+	t.Run("accepts a signed assertion of a request", func(t *testing.T) {
+		sp := newSP(t, false)
+		u, err := sp.AuthnRequestURL("/orgs")
+		require.NoError(t, err)
+		requestID := authnRequestID(t, u)
+
+		a := validAssertion("a1")
+		a.inResponseTo = requestID
+		resp := response(requestID, idp.sign(t, a.xml(), "a1"))
+
+		got, err := sp.ParseResponse(encode(resp))
+		require.NoError(t, err)
+		assert.Equal(t, "alice", got.NameID)
+		assert.Equal(t, []string{"engineering", "ops"}, got.Attributes["groups"])
+
+		// the request is answered once.
+		a.id = "a2"
+		_, err = sp.ParseResponse(encode(response(requestID, idp.sign(t, a.xml(), "a2"))))
+		assert.Error(t, err)
+	})
+
+	t.Run("forgets the oldest requests beyond the limit", func(t *testing.T) {
+		sp := newSP(t, false)
+		now := sp.now().UTC()
+		for i := 0; i < maxRequests; i++ {
+			sp.requests[fmt.Sprintf("id%d", i)] = now.Add(requestLifetime + time.Duration(i)*time.Second)
+		}
+
+		u, err := sp.AuthnRequestURL("/orgs")
+		require.NoError(t, err)
+		assert.Len(t, sp.requests, maxRequests)
+		assert.Contains(t, sp.requests, authnRequestID(t, u))
+		assert.NotContains(t, sp.requests, "id0")
+		assert.Contains(t, sp.requests, "id1")
+	})
+
+	t.Run("accepts a signed response", func(t *testing.T) {
+		sp := newSP(t, true)
+		resp := idp.sign(t, response("", validAssertion("a1").xml()), "resp1")
+
+		got, err := sp.ParseResponse(encode(resp))
+		require.NoError(t, err)
+		assert.Equal(t, "alice", got.NameID)
+	})
+
+	t.Run("rejects assertions which were not requested", func(t *testing.T) {
+		sp := newSP(t, false)
+		_, err := sp.ParseResponse(encode(response("", idp.sign(t, validAssertion("a1").xml(), "a1"))))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects replayed assertions", func(t *testing.T) {
+		sp := newSP(t, true)
+		resp := encode(response("", idp.sign(t, validAssertion("a1").xml(), "a1")))
+		_, err := sp.ParseResponse(resp)
+		require.NoError(t, err)
+		_, err = sp.ParseResponse(resp)
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects tampered assertions", func(t *testing.T) {
+		sp := newSP(t, true)
+		signed := idp.sign(t, validAssertion("a1").xml(), "a1")
+		tampered := strings.Replace(signed, "<saml:NameID>alice<", "<saml:NameID>admin<", 1)
+		_, err := sp.ParseResponse(encode(response("", tampered)))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects unsigned assertions", func(t *testing.T) {
+		sp := newSP(t, true)
+		_, err := sp.ParseResponse(encode(response("", validAssertion("a1").xml())))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects assertions signed by another key", func(t *testing.T) {
+		sp := newSP(t, true)
+		other := newTestIDP(t)
+		_, err := sp.ParseResponse(encode(response("", other.sign(t, validAssertion("a1").xml(), "a1"))))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects the assertions of other audiences and recipients", func(t *testing.T) {
+		sp := newSP(t, true)
+		a := validAssertion("a1")
+		a.audience = "https://other.example.com"
+		_, err := sp.ParseResponse(encode(response("", idp.sign(t, a.xml(), "a1"))))
+		assert.Error(t, err)
+
+		a = validAssertion("a2")
+		a.recipient = "https://other.example.com/acs"
+		_, err = sp.ParseResponse(encode(response("", idp.sign(t, a.xml(), "a2"))))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects expired assertions", func(t *testing.T) {
+		sp := newSP(t, true)
+		a := validAssertion("a1")
+		a.notOnOrAfter = time.Now().Add(-time.Hour).UTC()
+		_, err := sp.ParseResponse(encode(response("", idp.sign(t, a.xml(), "a1"))))
+		assert.Error(t, err)
+	})
+}
+
+var requestIDPattern = regexp.MustCompile(`ID="([^"]+)"`)
+
+func authnRequestID(t *testing.T, u string) string {
+	t.Helper()
+	parsed, err := url.Parse(u)
+	require.NoError(t, err)
+	assert.Equal(t, "/orgs", parsed.Query().Get("RelayState"))
+
+	deflated, err := base64.StdEncoding.DecodeString(parsed.Query().Get("SAMLRequest"))
+	require.NoError(t, err)
+	req, err := io.ReadAll(flate.NewReader(bytes.NewReader(deflated)))
+	require.NoError(t, err)
+	m := requestIDPattern.FindSubmatch(req)
+	require.NotNil(t, m)
+	return string(m[1])
+}
+
+func TestLocalRedirect(t *testing.T) {
+	assert.Equal(t, "/orgs/1", localRedirect("/orgs/1"))
+	assert.Equal(t, "/", localRedirect(""))
+	assert.Equal(t, "/", localRedirect("https://evil.example.com"))
+	assert.Equal(t, "/", localRedirect("//evil.example.com"))
+}
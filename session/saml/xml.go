@@ -0,0 +1,113 @@
+package saml
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+// element is a parsed XML element, which keeps the prefixes and namespace
+// declarations of the document signatures are computed over.
+type element struct {
+	*etree.Element
+}
+
+// parseDocument parses the root element of an XML document. Documents with a
+// DTD are refused, so entities are not expanded, as are elements with
+// duplicate attributes, which readers of the document may not agree on.
+func parseDocument(b []byte) (*element, error) {
+	doc := etree.NewDocument()
+	doc.ReadSettings.PreserveDuplicateAttrs = true
+	if err := doc.ReadFromBytes(b); err != nil {
+		return nil, err
+	}
+	for _, t := range doc.Child {
+		if _, ok := t.(*etree.Directive); ok {
+			return nil, errors.New("documents with a DTD are not supported")
+		}
+	}
+	if len(doc.ChildElements()) != 1 {
+		return nil, errors.New("document must have exactly one root element")
+	}
+	root := doc.Root()
+	if err := checkAttrs(root); err != nil {
+		return nil, err
+	}
+	return &element{root}, nil
+}
+
+// checkAttrs refuses e, or one of its descendants, when it has the same
+// attribute more than once.
+func checkAttrs(e *etree.Element) error {
+	seen := map[string]struct{}{}
+	for _, a := range e.Attr {
+		key := a.FullKey()
+		if _, ok := seen[key]; ok {
+			return fmt.Errorf("element %s has more than one %s attribute", e.Tag, key)
+		}
+		seen[key] = struct{}{}
+	}
+	for _, c := range e.ChildElements() {
+		if err := checkAttrs(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// is returns true when the element is local in namespace space.
+func (e *element) is(space, local string) bool {
+	return e.Tag == local && e.NamespaceURI() == space
+}
+
+// attr returns the value of an attribute without a prefix.
+func (e *element) attr(local string) string {
+	for _, a := range e.Attr {
+		if a.Space == "" && a.Key == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// child returns the first child of the element which is local in space.
+func (e *element) child(space, local string) *element {
+	if els := e.childElements(space, local); len(els) > 0 {
+		return els[0]
+	}
+	return nil
+}
+
+// childElements returns the children of the element which are local in space.
+func (e *element) childElements(space, local string) []*element {
+	var els []*element
+	for _, c := range e.ChildElements() {
+		if el := (&element{c}); el.is(space, local) {
+			els = append(els, el)
+		}
+	}
+	return els
+}
+
+// text returns the character data of the element, without its children. The
+// character data split by comments is joined, as it is when the element is
+// canonicalized.
+func (e *element) text() string {
+	var sb strings.Builder
+	for _, c := range e.Child {
+		if cd, ok := c.(*etree.CharData); ok {
+			sb.WriteString(cd.Data)
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+)
+
+func escapeText(s string) string { return textEscaper.Replace(s) }
+func escapeAttr(s string) string { return attrEscaper.Replace(s) }
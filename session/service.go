@@ -14,16 +14,22 @@ import (
 // Service implements the influxdb.SessionService interface and
 // handles communication between session and the necessary user and urm services
 type Service struct {
-	store         *Storage
-	userService   influxdb.UserService
-	urmService    influxdb.UserResourceMappingService
-	authService   influxdb.AuthorizationService
-	sessionLength time.Duration
+	store       *Storage
+	userService influxdb.UserService
+	urmService  influxdb.UserResourceMappingService
+	authService influxdb.AuthorizationService
+	policy      Policy
+	orgPolicies OrgPolicyService
+	userLimits  UserLimitService
+
+	refreshLength time.Duration
 
 	idGen    platform.IDGenerator
 	tokenGen influxdb.TokenGenerator
 
 	disableAuthorizationsForMaxPermissions func(context.Context) bool
+
+	now func() time.Time
 }
 
 // ServiceOption is a functional option for configuring a *Service
@@ -33,7 +39,30 @@ type ServiceOption func(*Service)
 // duration when the resulting option is called on a *Service.
 func WithSessionLength(length time.Duration) ServiceOption {
 	return func(s *Service) {
-		s.sessionLength = length
+		s.policy.Length = length
+	}
+}
+
+// WithPolicy configures the lifetime of the sessions of the instance.
+func WithPolicy(p Policy) ServiceOption {
+	return func(s *Service) {
+		s.policy = p
+	}
+}
+
+// WithUserLimits overrides the session limits of the policies of users with
+// the limits set by operators.
+func WithUserLimits(ls UserLimitService) ServiceOption {
+	return func(s *Service) {
+		s.userLimits = ls
+	}
+}
+
+// WithOrgPolicies restricts the sessions of users with the session policies
+// of the organizations they are members of.
+func WithOrgPolicies(ps OrgPolicyService) ServiceOption {
+	return func(s *Service) {
+		s.orgPolicies = ps
 	}
 }
 
@@ -56,16 +85,17 @@ func WithTokenGenerator(gen influxdb.TokenGenerator) ServiceOption {
 // NewService creates a new session service
 func NewService(store *Storage, userService influxdb.UserService, urmService influxdb.UserResourceMappingService, authSvc influxdb.AuthorizationService, opts ...ServiceOption) *Service {
 	service := &Service{
-		store:         store,
-		userService:   userService,
-		urmService:    urmService,
-		authService:   authSvc,
-		sessionLength: time.Hour,
-		idGen:         snowflake.NewIDGenerator(),
-		tokenGen:      rand.NewTokenGenerator(64),
+		store:       store,
+		userService: userService,
+		urmService:  urmService,
+		authService: authSvc,
+		policy:      Policy{Length: influxdb.DefaultSessionLength},
+		idGen:       snowflake.NewIDGenerator(),
+		tokenGen:    rand.NewTokenGenerator(64),
 		disableAuthorizationsForMaxPermissions: func(context.Context) bool {
 			return false
 		},
+		now: time.Now,
 	}
 
 	for _, opt := range opts {
@@ -89,6 +119,21 @@ func (s *Service) FindSession(ctx context.Context, key string) (*influxdb.Sessio
 		return nil, err
 	}
 
+	// the policy may have been restricted since the session was created.
+	policy, err := s.userPolicy(ctx, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if policy.MaxLifetime > 0 && s.now().After(session.CreatedAt.Add(policy.MaxLifetime)) {
+		if err := s.store.DeleteSession(ctx, session.ID); err != nil {
+			return nil, err
+		}
+		return nil, &errors.Error{
+			Code: errors.EForbidden,
+			Msg:  influxdb.ErrSessionExpired,
+		}
+	}
+
 	// TODO: We want to be able to store permissions in the session
 	// but the contract provided by urm's doesn't give us enough information to quickly repopulate our
 	// session permissions on updates so we are required to pull the permissions every time we find the session.
@@ -118,34 +163,70 @@ func (s *Service) CreateSession(ctx context.Context, user string) (*influxdb.Ses
 	if err != nil {
 		return nil, err
 	}
+	return s.createSession(ctx, u)
+}
 
+func (s *Service) createSession(ctx context.Context, u *influxdb.User) (*influxdb.Session, error) {
 	token, err := s.tokenGen.Token()
 	if err != nil {
 		return nil, err
 	}
 
+	policy, err := s.userPolicy(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	// for now we are not storing the permissions because we need to pull them every time we find
 	// so we might as well keep the session stored small
-	now := time.Now()
+	now := s.now()
+	expiresAt := now.Add(policy.Length)
+	if policy.MaxLifetime > 0 && policy.MaxLifetime < policy.Length {
+		expiresAt = now.Add(policy.MaxLifetime)
+	}
+	c := clientFromContext(ctx)
 	session := &influxdb.Session{
-		ID:        s.idGen.ID(),
-		Key:       token,
-		CreatedAt: now,
-		ExpiresAt: now.Add(s.sessionLength),
-		UserID:    u.ID,
+		ID:           s.idGen.ID(),
+		Key:          token,
+		CreatedAt:    now,
+		ExpiresAt:    expiresAt,
+		UserID:       u.ID,
+		LastActiveAt: now,
+		IP:           c.ip,
+		UserAgent:    c.userAgent,
 	}
 
-	return session, s.store.CreateSession(ctx, session)
+	if err := s.store.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+	if err := s.evictSessions(ctx, u.ID, policy); err != nil {
+		return nil, err
+	}
+	return session, nil
 }
 
-// RenewSession update the sessions expiration time
+// RenewSession update the sessions expiration time. The expiration is
+// limited by the idle timeout and the max lifetime of the policy of the user
+// of the session, and it is not updated when the policy disables renewal.
 func (s *Service) RenewSession(ctx context.Context, session *influxdb.Session, newExpiration time.Time) error {
 	if session == nil {
 		return &errors.Error{
 			Msg: "session is nil",
 		}
 	}
-	return s.store.RefreshSession(ctx, session.ID, newExpiration)
+
+	if err := s.store.TouchSession(ctx, session.ID, s.now()); err != nil {
+		return err
+	}
+
+	policy, err := s.userPolicy(ctx, session.UserID)
+	if err != nil {
+		return err
+	}
+	if policy.RenewDisabled {
+		return nil
+	}
+	return s.store.RefreshSession(ctx, session.ID, policy.expiresAt(session.CreatedAt, s.now(), newExpiration))
 }
 
 func (s *Service) getPermissionSet(ctx context.Context, uid platform.ID) ([]influxdb.Permission, error) {
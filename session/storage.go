@@ -3,6 +3,8 @@ package session
 import (
 	"context"
 	"encoding/json"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/influxdata/influxdb/v2"
@@ -19,16 +21,23 @@ type Store interface {
 
 var storePrefix = "sessionsv2/"
 var storeIndex = "sessionsindexv2/"
+var storeUserIndex = "sessionsuserindexv2/"
 
 // Storage is a store translation layer between the data storage unit and the
 // service layer.
 type Storage struct {
 	store Store
+
+	// userIndexMu serializes the updates of the indexes of the sessions of users.
+	userIndexMu sync.Mutex
+	// refreshMu serializes the lookups and removals of refresh tokens, so
+	// that each refresh token is taken once.
+	refreshMu sync.Mutex
 }
 
 // NewStorage creates a new storage system
 func NewStorage(s Store) *Storage {
-	return &Storage{s}
+	return &Storage{store: s}
 }
 
 // FindSessionByKey use a given key to retrieve the stored session
@@ -89,7 +98,79 @@ func (s *Storage) CreateSession(ctx context.Context, session *influxdb.Session)
 		return err
 	}
 
-	return nil
+	if !session.UserID.Valid() {
+		return nil
+	}
+	return s.updateUserIndex(session.UserID, func(ids map[platform.ID]bool) {
+		ids[session.ID] = true
+	})
+}
+
+// FindSessionsByUserID returns the sessions of a user which have not expired,
+// oldest first.
+func (s *Storage) FindSessionsByUserID(ctx context.Context, userID platform.ID) ([]*influxdb.Session, error) {
+	var sessions []*influxdb.Session
+	err := s.updateUserIndex(userID, func(ids map[platform.ID]bool) {
+		for id := range ids {
+			session, err := s.FindSessionByID(ctx, id)
+			if err != nil {
+				// the session expired, or it was removed.
+				delete(ids, id)
+				continue
+			}
+			sessions = append(sessions, session)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].CreatedAt.Equal(sessions[j].CreatedAt) {
+			return sessions[i].ID < sessions[j].ID
+		}
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+	return sessions, nil
+}
+
+// updateUserIndex updates the IDs of the sessions of a user with fn.
+func (s *Storage) updateUserIndex(userID platform.ID, fn func(ids map[platform.ID]bool)) error {
+	s.userIndexMu.Lock()
+	defer s.userIndexMu.Unlock()
+
+	key := sessionUserIndexKey(userID)
+	val, err := s.store.Get(key)
+	if err != nil {
+		return err
+	}
+
+	ids := map[platform.ID]bool{}
+	if val != "" {
+		var list []platform.ID
+		if err := json.Unmarshal([]byte(val), &list); err != nil {
+			return err
+		}
+		for _, id := range list {
+			ids[id] = true
+		}
+	}
+
+	fn(ids)
+
+	if len(ids) == 0 {
+		return s.store.Delete(key)
+	}
+	list := make([]platform.ID, 0, len(ids))
+	for id := range ids {
+		list = append(list, id)
+	}
+	b, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	// the index is pruned of the sessions which expired as it is read.
+	return s.store.Set(key, string(b), time.Time{})
 }
 
 // RefreshSession updates the expiration time of a session.
@@ -126,7 +207,16 @@ func (s *Storage) DeleteSession(ctx context.Context, id platform.ID) error {
 		return err
 	}
 
-	return nil
+	if err := s.deleteSessionRefreshToken(ctx, session.ID); err != nil {
+		return err
+	}
+
+	if !session.UserID.Valid() {
+		return nil
+	}
+	return s.updateUserIndex(session.UserID, func(ids map[platform.ID]bool) {
+		delete(ids, session.ID)
+	})
 }
 
 func sessionID(id platform.ID) string {
@@ -136,3 +226,7 @@ func sessionID(id platform.ID) string {
 func sessionIndexKey(key string) string {
 	return storeIndex + key
 }
+
+func sessionUserIndexKey(userID platform.ID) string {
+	return storeUserIndex + userID.String()
+}
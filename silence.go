@@ -0,0 +1,142 @@
+package influxdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// ErrSilenceNotFound is the error msg for a missing silence.
+const ErrSilenceNotFound = "silence not found"
+
+// ops for silence service.
+const (
+	OpFindSilenceByID = "FindSilenceByID"
+	OpFindSilences    = "FindSilences"
+	OpCreateSilence   = "CreateSilence"
+	OpUpdateSilence   = "UpdateSilence"
+	OpDeleteSilence   = "DeleteSilence"
+)
+
+// SilenceService manages the silences muting the notification rules of organizations.
+type SilenceService interface {
+	// FindSilenceByID returns a single silence by ID.
+	FindSilenceByID(ctx context.Context, id platform.ID) (*Silence, error)
+
+	// FindSilences returns the silences matching filter and the total count of matching silences.
+	FindSilences(ctx context.Context, filter SilenceFilter, opts FindOptions) ([]*Silence, int, error)
+
+	// CreateSilence creates a new silence and sets s.ID with the new identifier.
+	CreateSilence(ctx context.Context, s *Silence) error
+
+	// UpdateSilence updates a single silence with changeset.
+	UpdateSilence(ctx context.Context, id platform.ID, upd SilenceUpdate) (*Silence, error)
+
+	// DeleteSilence removes a silence by ID.
+	DeleteSilence(ctx context.Context, id platform.ID) error
+}
+
+// Silence mutes the notifications of the statuses it matches between its start
+// and end time, such as during planned maintenance. A status is matched when it
+// comes from the check of the silence, if one is set, and has all of its tags.
+type Silence struct {
+	ID             platform.ID  `json:"id,omitempty"`
+	OrganizationID platform.ID  `json:"orgID"`
+	CheckID        *platform.ID `json:"checkID,omitempty"`
+	TagRules       []TagRule    `json:"tagRules,omitempty"`
+	StartsAt       time.Time    `json:"startsAt"`
+	EndsAt         time.Time    `json:"endsAt"`
+	// CreatedBy is the user who created the silence.
+	CreatedBy platform.ID `json:"createdBy,omitempty"`
+	Comment   string      `json:"comment"`
+	CRUDLog
+}
+
+// Active returns whether the silence mutes the statuses of t.
+func (s Silence) Active(t time.Time) bool {
+	return !t.Before(s.StartsAt) && t.Before(s.EndsAt)
+}
+
+// Valid returns an error if the silence is invalid.
+func (s Silence) Valid() error {
+	if !s.OrganizationID.Valid() {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "silence requires an orgID",
+		}
+	}
+	if s.CheckID != nil && !s.CheckID.Valid() {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "silence checkID is invalid",
+		}
+	}
+	if s.CheckID == nil && len(s.TagRules) == 0 {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "silence must match a check or tags",
+		}
+	}
+	for _, tr := range s.TagRules {
+		if err := tr.Valid(); err != nil {
+			return err
+		}
+		if tr.Operator != Equal {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "silence tag rules only support the equal operator",
+			}
+		}
+	}
+	if s.StartsAt.IsZero() || s.EndsAt.IsZero() {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "silence requires a start and an end time",
+		}
+	}
+	if !s.EndsAt.After(s.StartsAt) {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "silence must end after it starts",
+		}
+	}
+	return nil
+}
+
+// SilenceFilter represents a set of filters that restrict the returned silences.
+type SilenceFilter struct {
+	OrganizationID *platform.ID
+	// ActiveAt restricts the silences to the ones muting the statuses of its time.
+	ActiveAt *time.Time
+}
+
+// SilenceUpdate is the changeset of a silence.
+type SilenceUpdate struct {
+	CheckID  *platform.ID `json:"checkID,omitempty"`
+	TagRules *[]TagRule   `json:"tagRules,omitempty"`
+	StartsAt *time.Time   `json:"startsAt,omitempty"`
+	EndsAt   *time.Time   `json:"endsAt,omitempty"`
+	Comment  *string      `json:"comment,omitempty"`
+}
+
+// Apply applies the changeset to a silence and validates the result.
+func (u SilenceUpdate) Apply(s *Silence) error {
+	if u.CheckID != nil {
+		s.CheckID = u.CheckID
+	}
+	if u.TagRules != nil {
+		s.TagRules = *u.TagRules
+	}
+	if u.StartsAt != nil {
+		s.StartsAt = *u.StartsAt
+	}
+	if u.EndsAt != nil {
+		s.EndsAt = *u.EndsAt
+	}
+	if u.Comment != nil {
+		s.Comment = *u.Comment
+	}
+	return s.Valid()
+}
@@ -0,0 +1,329 @@
+// Package silence stores the silences muting the notification rules of
+// organizations, such as during planned maintenance.
+package silence
+
+import (
+	"context"
+	"encoding/json"
+
+	influxdb "github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/snowflake"
+)
+
+var (
+	silenceBucket   = []byte("silencesv1")
+	orgSilenceIndex = []byte("orgsilencesv1")
+)
+
+var _ influxdb.SilenceService = (*Service)(nil)
+
+// RuleTasks updates the tasks of notification rules. The silences of an
+// organization are compiled into the flux of the tasks of its notification
+// rules, which are updated whenever its silences change.
+type RuleTasks interface {
+	// RefreshNotificationRuleTasks regenerates the flux of the tasks of the
+	// notification rules of an organization.
+	RefreshNotificationRuleTasks(ctx context.Context, orgID platform.ID) error
+}
+
+// Service is a kv backed silence service.
+type Service struct {
+	kv    kv.Store
+	rules RuleTasks
+
+	IDGenerator   platform.IDGenerator
+	TimeGenerator influxdb.TimeGenerator
+}
+
+// NewService constructs and configures a new silence service. The tasks of the
+// notification rules are refreshed by rules when silences change, if it is set.
+func NewService(store kv.Store, rules RuleTasks) *Service {
+	return &Service{
+		kv:            store,
+		rules:         rules,
+		IDGenerator:   snowflake.NewIDGenerator(),
+		TimeGenerator: influxdb.RealTimeGenerator{},
+	}
+}
+
+// FindSilenceByID returns a single silence by ID.
+func (s *Service) FindSilenceByID(ctx context.Context, id platform.ID) (*influxdb.Silence, error) {
+	var sil *influxdb.Silence
+	err := s.kv.View(ctx, func(tx kv.Tx) error {
+		ss, err := findSilenceByID(tx, id)
+		if err != nil {
+			return err
+		}
+		sil = ss
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+		}
+	}
+	return sil, nil
+}
+
+// FindSilences returns the silences matching filter, sorted by ID.
+func (s *Service) FindSilences(ctx context.Context, filter influxdb.SilenceFilter, opts influxdb.FindOptions) ([]*influxdb.Silence, int, error) {
+	var ss []*influxdb.Silence
+	err := s.kv.View(ctx, func(tx kv.Tx) error {
+		ids, err := silenceIDs(tx, filter)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			sil, err := findSilenceByID(tx, id)
+			if err != nil {
+				return err
+			}
+			if filter.ActiveAt != nil && !sil.Active(*filter.ActiveAt) {
+				continue
+			}
+			ss = append(ss, sil)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, &errors.Error{
+			Err: err,
+		}
+	}
+
+	total := len(ss)
+	if opts.Descending {
+		for i, j := 0, len(ss)-1; i < j; i, j = i+1, j-1 {
+			ss[i], ss[j] = ss[j], ss[i]
+		}
+	}
+	if opts.Offset > 0 {
+		if opts.Offset >= len(ss) {
+			ss = nil
+		} else {
+			ss = ss[opts.Offset:]
+		}
+	}
+	if opts.Limit > 0 && opts.Limit < len(ss) {
+		ss = ss[:opts.Limit]
+	}
+	return ss, total, nil
+}
+
+// CreateSilence creates a new silence and sets sil.ID with the new identifier.
+// The creator of the silence is the user of the authorizer on ctx.
+func (s *Service) CreateSilence(ctx context.Context, sil *influxdb.Silence) error {
+	if err := sil.Valid(); err != nil {
+		return err
+	}
+
+	err := s.kv.Update(ctx, func(tx kv.Tx) error {
+		now := s.TimeGenerator.Now()
+		sil.ID = s.IDGenerator.ID()
+		sil.SetCreatedAt(now)
+		sil.SetUpdatedAt(now)
+		if a, err := icontext.GetAuthorizer(ctx); err == nil {
+			sil.CreatedBy = a.GetUserID()
+		}
+		if err := putSilence(tx, sil); err != nil {
+			return err
+		}
+		k, err := orgSilenceKey(sil)
+		if err != nil {
+			return err
+		}
+		idx, err := tx.Bucket(orgSilenceIndex)
+		if err != nil {
+			return err
+		}
+		return idx.Put(k, nil)
+	})
+	if err != nil {
+		return &errors.Error{
+			Err: err,
+		}
+	}
+	return s.refreshRules(ctx, sil.OrganizationID)
+}
+
+// UpdateSilence updates a silence.
+func (s *Service) UpdateSilence(ctx context.Context, id platform.ID, upd influxdb.SilenceUpdate) (*influxdb.Silence, error) {
+	var sil *influxdb.Silence
+	err := s.kv.Update(ctx, func(tx kv.Tx) error {
+		ss, err := findSilenceByID(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := upd.Apply(ss); err != nil {
+			return err
+		}
+		ss.SetUpdatedAt(s.TimeGenerator.Now())
+		if err := putSilence(tx, ss); err != nil {
+			return err
+		}
+		sil = ss
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+		}
+	}
+	if err := s.refreshRules(ctx, sil.OrganizationID); err != nil {
+		return nil, err
+	}
+	return sil, nil
+}
+
+// DeleteSilence removes a silence by ID.
+func (s *Service) DeleteSilence(ctx context.Context, id platform.ID) error {
+	var orgID platform.ID
+	err := s.kv.Update(ctx, func(tx kv.Tx) error {
+		sil, err := findSilenceByID(tx, id)
+		if err != nil {
+			return err
+		}
+		orgID = sil.OrganizationID
+
+		k, err := orgSilenceKey(sil)
+		if err != nil {
+			return err
+		}
+		idx, err := tx.Bucket(orgSilenceIndex)
+		if err != nil {
+			return err
+		}
+		if err := idx.Delete(k); err != nil {
+			return err
+		}
+
+		encodedID, err := id.Encode()
+		if err != nil {
+			return err
+		}
+		b, err := tx.Bucket(silenceBucket)
+		if err != nil {
+			return err
+		}
+		return b.Delete(encodedID)
+	})
+	if err != nil {
+		return &errors.Error{
+			Err: err,
+		}
+	}
+	return s.refreshRules(ctx, orgID)
+}
+
+func (s *Service) refreshRules(ctx context.Context, orgID platform.ID) error {
+	if s.rules == nil {
+		return nil
+	}
+	if err := s.rules.RefreshNotificationRuleTasks(ctx, orgID); err != nil {
+		return &errors.Error{
+			Code: errors.EInternal,
+			Msg:  "silences were saved but the notification rules of the organization could not be updated",
+			Err:  err,
+		}
+	}
+	return nil
+}
+
+func findSilenceByID(tx kv.Tx, id platform.ID) (*influxdb.Silence, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Err:  err,
+		}
+	}
+	b, err := tx.Bucket(silenceBucket)
+	if err != nil {
+		return nil, err
+	}
+	v, err := b.Get(encodedID)
+	if kv.IsNotFound(err) {
+		return nil, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  influxdb.ErrSilenceNotFound,
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sil := &influxdb.Silence{}
+	if err := json.Unmarshal(v, sil); err != nil {
+		return nil, errors.NewError(errors.WithErrorErr(err))
+	}
+	return sil, nil
+}
+
+func putSilence(tx kv.Tx, sil *influxdb.Silence) error {
+	v, err := json.Marshal(sil)
+	if err != nil {
+		return err
+	}
+	encodedID, err := sil.ID.Encode()
+	if err != nil {
+		return err
+	}
+	b, err := tx.Bucket(silenceBucket)
+	if err != nil {
+		return err
+	}
+	return b.Put(encodedID, v)
+}
+
+func orgSilenceKey(sil *influxdb.Silence) ([]byte, error) {
+	orgID, err := sil.OrganizationID.Encode()
+	if err != nil {
+		return nil, err
+	}
+	id, err := sil.ID.Encode()
+	if err != nil {
+		return nil, err
+	}
+	return append(orgID, id...), nil
+}
+
+// silenceIDs returns the IDs of the silences of the filter's organization, or
+// of every silence, sorted by ID.
+func silenceIDs(tx kv.Tx, filter influxdb.SilenceFilter) ([]platform.ID, error) {
+	bucket, prefix := silenceBucket, []byte(nil)
+	if filter.OrganizationID != nil {
+		p, err := filter.OrganizationID.Encode()
+		if err != nil {
+			return nil, err
+		}
+		bucket, prefix = orgSilenceIndex, p
+	}
+
+	b, err := tx.Bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+	var opts []kv.CursorOption
+	if prefix != nil {
+		opts = append(opts, kv.WithCursorPrefix(prefix))
+	}
+	cur, err := b.ForwardCursor(prefix, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close()
+
+	var ids []platform.ID
+	for k, _ := cur.Next(); k != nil; k, _ = cur.Next() {
+		var id platform.ID
+		if err := id.Decode(k[len(prefix):]); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, cur.Err()
+}
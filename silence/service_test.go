@@ -0,0 +1,112 @@
+package silence
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mock"
+	itesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRuleTasks struct {
+	refreshed []platform.ID
+}
+
+func (r *fakeRuleTasks) RefreshNotificationRuleTasks(ctx context.Context, orgID platform.ID) error {
+	r.refreshed = append(r.refreshed, orgID)
+	return nil
+}
+
+func TestService_Silences(t *testing.T) {
+	store, closeBolt := itesting.NewTestBoltStore(t)
+	t.Cleanup(closeBolt)
+	rules := &fakeRuleTasks{}
+	now := time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)
+	svc := NewService(store, rules)
+	svc.TimeGenerator = mock.TimeGenerator{FakeValue: now}
+
+	ctx := icontext.SetAuthorizer(context.Background(), &influxdb.Authorization{UserID: 5})
+	checkID := platform.ID(10)
+	maintenance := &influxdb.Silence{
+		OrganizationID: 1,
+		CheckID:        &checkID,
+		StartsAt:       now,
+		EndsAt:         now.Add(2 * time.Hour),
+		Comment:        "database upgrade",
+	}
+	require.NoError(t, svc.CreateSilence(ctx, maintenance))
+	require.True(t, maintenance.ID.Valid())
+	require.Equal(t, platform.ID(5), maintenance.CreatedBy)
+	require.Equal(t, now, maintenance.CreatedAt)
+
+	later := &influxdb.Silence{
+		OrganizationID: 1,
+		TagRules: []influxdb.TagRule{
+			{Tag: influxdb.Tag{Key: "host", Value: "db1"}, Operator: influxdb.Equal},
+		},
+		StartsAt: now.Add(24 * time.Hour),
+		EndsAt:   now.Add(25 * time.Hour),
+	}
+	require.NoError(t, svc.CreateSilence(ctx, later))
+
+	other := &influxdb.Silence{
+		OrganizationID: 2,
+		CheckID:        &checkID,
+		StartsAt:       now,
+		EndsAt:         now.Add(time.Hour),
+	}
+	require.NoError(t, svc.CreateSilence(ctx, other))
+	require.Equal(t, []platform.ID{1, 1, 2}, rules.refreshed)
+
+	found, err := svc.FindSilenceByID(ctx, maintenance.ID)
+	require.NoError(t, err)
+	require.Equal(t, maintenance, found)
+
+	orgID := platform.ID(1)
+	ss, n, err := svc.FindSilences(ctx, influxdb.SilenceFilter{OrganizationID: &orgID}, influxdb.FindOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, []*influxdb.Silence{maintenance, later}, ss)
+
+	activeAt := now.Add(time.Hour)
+	ss, n, err = svc.FindSilences(ctx, influxdb.SilenceFilter{OrganizationID: &orgID, ActiveAt: &activeAt}, influxdb.FindOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, maintenance.ID, ss[0].ID)
+
+	_, n, err = svc.FindSilences(ctx, influxdb.SilenceFilter{}, influxdb.FindOptions{Limit: 1})
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	endsAt := now.Add(30 * time.Minute)
+	comment := "database upgrade done early"
+	updated, err := svc.UpdateSilence(ctx, maintenance.ID, influxdb.SilenceUpdate{EndsAt: &endsAt, Comment: &comment})
+	require.NoError(t, err)
+	require.Equal(t, endsAt, updated.EndsAt)
+	require.Equal(t, comment, updated.Comment)
+	require.Equal(t, []platform.ID{1, 1, 2, 1}, rules.refreshed)
+
+	startsAt := now.Add(time.Hour)
+	_, err = svc.UpdateSilence(ctx, maintenance.ID, influxdb.SilenceUpdate{StartsAt: &startsAt})
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err), "the silence must end after it starts")
+
+	require.NoError(t, svc.DeleteSilence(ctx, maintenance.ID))
+	_, err = svc.FindSilenceByID(ctx, maintenance.ID)
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+	ss, _, err = svc.FindSilences(ctx, influxdb.SilenceFilter{OrganizationID: &orgID}, influxdb.FindOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []*influxdb.Silence{later}, ss)
+	require.Equal(t, []platform.ID{1, 1, 2, 1, 1}, rules.refreshed)
+
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(svc.CreateSilence(ctx, &influxdb.Silence{
+		OrganizationID: 1,
+		StartsAt:       now,
+		EndsAt:         now.Add(time.Hour),
+	})), "a silence must match a check or tags")
+}
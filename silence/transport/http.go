@@ -0,0 +1,232 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const prefixSilences = "/api/v2/silences"
+
+// SilenceHandler is the handler for the silence service.
+type SilenceHandler struct {
+	chi.Router
+
+	api *kithttp.API
+	log *zap.Logger
+
+	silenceService influxdb.SilenceService
+	now            func() time.Time
+}
+
+// NewSilenceHandler returns a new instance of SilenceHandler.
+func NewSilenceHandler(log *zap.Logger, silenceService influxdb.SilenceService) *SilenceHandler {
+	h := &SilenceHandler{
+		log:            log,
+		api:            kithttp.NewAPI(kithttp.WithLog(log)),
+		silenceService: silenceService,
+		now:            time.Now,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+	r.Route("/", func(r chi.Router) {
+		r.Get("/", h.handleGetSilences)
+		r.Post("/", h.handlePostSilence)
+
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", h.handleGetSilence)
+			r.Patch("/", h.handlePatchSilence)
+			r.Delete("/", h.handleDeleteSilence)
+		})
+	})
+	h.Router = r
+	return h
+}
+
+// Prefix returns the mounting prefix for the handler.
+func (h *SilenceHandler) Prefix() string {
+	return prefixSilences
+}
+
+type silenceResponse struct {
+	*influxdb.Silence
+	Links map[string]string `json:"links"`
+}
+
+func newSilenceResponse(s *influxdb.Silence) silenceResponse {
+	links := map[string]string{
+		"self": fmt.Sprintf("%s/%s", prefixSilences, s.ID),
+		"org":  fmt.Sprintf("/api/v2/orgs/%s", s.OrganizationID),
+	}
+	if s.CheckID != nil {
+		links["check"] = fmt.Sprintf("/api/v2/checks/%s", s.CheckID)
+	}
+	return silenceResponse{
+		Silence: s,
+		Links:   links,
+	}
+}
+
+type silencesResponse struct {
+	Silences []silenceResponse `json:"silences"`
+	Total    int               `json:"total"`
+	Links    map[string]string `json:"links"`
+}
+
+func decodeSilenceID(r *http.Request) (platform.ID, error) {
+	var id platform.ID
+	if err := id.DecodeFromString(chi.URLParam(r, "id")); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// handleGetSilences lists the silences of an organization. With active=true,
+// only the silences muting notifications right now are listed.
+func (h *SilenceHandler) handleGetSilences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	opts, err := influxdb.DecodeFindOptions(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var filter influxdb.SilenceFilter
+	q := r.URL.Query()
+	if orgID := q.Get("orgID"); orgID != "" {
+		id, err := platform.IDFromString(orgID)
+		if err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+		filter.OrganizationID = id
+	}
+	if active := q.Get("active"); active != "" {
+		b, err := strconv.ParseBool(active)
+		if err != nil {
+			h.api.Err(w, r, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "active must be a boolean",
+				Err:  err,
+			})
+			return
+		}
+		if b {
+			now := h.now()
+			filter.ActiveAt = &now
+		}
+	}
+
+	ss, total, err := h.silenceService.FindSilences(ctx, filter, *opts)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Silences retrieved", zap.Int("count", len(ss)))
+
+	res := silencesResponse{
+		Silences: make([]silenceResponse, 0, len(ss)),
+		Total:    total,
+		Links: map[string]string{
+			"self": prefixSilences,
+		},
+	}
+	for _, s := range ss {
+		res.Silences = append(res.Silences, newSilenceResponse(s))
+	}
+	h.api.Respond(w, r, http.StatusOK, res)
+}
+
+// handlePostSilence creates a silence.
+func (h *SilenceHandler) handlePostSilence(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var s influxdb.Silence
+	if err := h.api.DecodeJSON(r.Body, &s); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.silenceService.CreateSilence(ctx, &s); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Silence created", zap.String("silence", fmt.Sprint(s)))
+
+	h.api.Respond(w, r, http.StatusCreated, newSilenceResponse(&s))
+}
+
+// handleGetSilence retrieves a silence by ID.
+func (h *SilenceHandler) handleGetSilence(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := decodeSilenceID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	s, err := h.silenceService.FindSilenceByID(ctx, id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Silence retrieved", zap.String("silence", fmt.Sprint(s)))
+
+	h.api.Respond(w, r, http.StatusOK, newSilenceResponse(s))
+}
+
+// handlePatchSilence updates a silence, such as to end it early.
+func (h *SilenceHandler) handlePatchSilence(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := decodeSilenceID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var upd influxdb.SilenceUpdate
+	if err := h.api.DecodeJSON(r.Body, &upd); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	s, err := h.silenceService.UpdateSilence(ctx, id, upd)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Silence updated", zap.String("silence", fmt.Sprint(s)))
+
+	h.api.Respond(w, r, http.StatusOK, newSilenceResponse(s))
+}
+
+// handleDeleteSilence deletes a silence.
+func (h *SilenceHandler) handleDeleteSilence(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := decodeSilenceID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.silenceService.DeleteSilence(ctx, id); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Silence deleted", zap.String("silenceID", id.String()))
+
+	w.WriteHeader(http.StatusNoContent)
+}
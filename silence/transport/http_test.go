@@ -0,0 +1,126 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type fakeSilenceService struct {
+	influxdb.SilenceService
+	silences map[platform.ID]*influxdb.Silence
+	filter   influxdb.SilenceFilter
+}
+
+func (s *fakeSilenceService) FindSilenceByID(ctx context.Context, id platform.ID) (*influxdb.Silence, error) {
+	sil, ok := s.silences[id]
+	if !ok {
+		return nil, &errors.Error{Code: errors.ENotFound, Msg: influxdb.ErrSilenceNotFound}
+	}
+	return sil, nil
+}
+
+func (s *fakeSilenceService) FindSilences(ctx context.Context, filter influxdb.SilenceFilter, opts influxdb.FindOptions) ([]*influxdb.Silence, int, error) {
+	s.filter = filter
+	var ss []*influxdb.Silence
+	for _, sil := range s.silences {
+		if filter.ActiveAt == nil || sil.Active(*filter.ActiveAt) {
+			ss = append(ss, sil)
+		}
+	}
+	return ss, len(ss), nil
+}
+
+func (s *fakeSilenceService) CreateSilence(ctx context.Context, sil *influxdb.Silence) error {
+	if err := sil.Valid(); err != nil {
+		return err
+	}
+	sil.ID = platform.ID(len(s.silences) + 1)
+	s.silences[sil.ID] = sil
+	return nil
+}
+
+func (s *fakeSilenceService) DeleteSilence(ctx context.Context, id platform.ID) error {
+	if _, ok := s.silences[id]; !ok {
+		return &errors.Error{Code: errors.ENotFound, Msg: influxdb.ErrSilenceNotFound}
+	}
+	delete(s.silences, id)
+	return nil
+}
+
+func TestSilenceHandler(t *testing.T) {
+	silences := &fakeSilenceService{silences: map[platform.ID]*influxdb.Silence{}}
+	h := NewSilenceHandler(zaptest.NewLogger(t), silences)
+	now := time.Date(2021, 1, 1, 11, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	body := `{"orgID":"0000000000000001","checkID":"000000000000000a","startsAt":"2021-01-01T10:00:00Z","endsAt":"2021-01-01T12:00:00Z","comment":"database upgrade"}`
+	resp, err := http.Post(srv.URL+"/", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created struct {
+		ID    platform.ID       `json:"id"`
+		Links map[string]string `json:"links"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	require.Equal(t, "/api/v2/checks/000000000000000a", created.Links["check"])
+	require.Equal(t, "database upgrade", silences.silences[created.ID].Comment)
+
+	body = `{"orgID":"0000000000000001","tagRules":[{"key":"host","value":"db1","operator":"equal"}],"startsAt":"2021-01-02T10:00:00Z","endsAt":"2021-01-02T12:00:00Z"}`
+	resp, err = http.Post(srv.URL+"/", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, err = http.Get(srv.URL + "/?orgID=0000000000000001&active=true")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var listed struct {
+		Silences []struct {
+			ID platform.ID `json:"id"`
+		} `json:"silences"`
+		Total int `json:"total"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&listed))
+	require.Equal(t, 1, listed.Total, "only the silences active now are listed")
+	require.Equal(t, created.ID, listed.Silences[0].ID)
+	require.Equal(t, platform.ID(1), *silences.filter.OrganizationID)
+
+	resp, err = http.Get(srv.URL + "/?active=sometimes")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	body = `{"orgID":"0000000000000001","startsAt":"2021-01-01T10:00:00Z","endsAt":"2021-01-01T12:00:00Z"}`
+	resp, err = http.Post(srv.URL+"/", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode, "a silence must match a check or tags")
+
+	req, err := http.NewRequest(http.MethodDelete, srv.URL+"/"+created.ID.String(), nil)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = http.Get(srv.URL + "/" + created.ID.String())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
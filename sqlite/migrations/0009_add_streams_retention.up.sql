@@ -0,0 +1,2 @@
+-- Adds the retention of the annotations of a stream, in seconds. Zero keeps them forever.
+ALTER TABLE streams ADD COLUMN retention_seconds INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+DROP TABLE flux_packages;
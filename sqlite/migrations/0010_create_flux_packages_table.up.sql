@@ -0,0 +1,11 @@
+CREATE TABLE flux_packages (
+    id VARCHAR(16) NOT NULL PRIMARY KEY,
+    org_id VARCHAR(16) NOT NULL,
+    name TEXT NOT NULL,
+    version TEXT NOT NULL,
+    source TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL,
+
+    CONSTRAINT flux_packages_uniq_orgid_name_version UNIQUE (org_id, name, version)
+);
@@ -121,6 +121,34 @@ func (m *Migrator) UpUntil(ctx context.Context, untilMigration int, source embed
 	return nil
 }
 
+// Validate checks that every migration recorded in the SQL database has a matching
+// script in source. It does not modify the database, and should be used to verify
+// compatibility prior to calling UpUntil or Down.
+func (m *Migrator) Validate(ctx context.Context, source embed.FS) error {
+	knownMigrations, err := source.ReadDir(".")
+	if err != nil {
+		return err
+	}
+
+	// sort the list according to the version number so it lines up with the executed migrations
+	sort.Slice(knownMigrations, func(i, j int) bool {
+		return knownMigrations[i].Name() < knownMigrations[j].Name()
+	})
+
+	executedMigrations, err := m.store.allMigrationNames()
+	if err != nil {
+		return err
+	}
+
+	for idx := range executedMigrations {
+		if idx > len(knownMigrations)-1 || executedMigrations[idx] != dropExtension(knownMigrations[idx].Name()) {
+			return migration.ErrInvalidMigration(executedMigrations[idx])
+		}
+	}
+
+	return nil
+}
+
 // Down applies the "down" migrations until the SQL database has migrations only >= untilMigration. Use untilMigration = 0 to apply all
 // down migrations, which will delete all data from the database.
 func (m *Migrator) Down(ctx context.Context, untilMigration int, source embed.FS) error {
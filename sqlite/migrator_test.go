@@ -267,3 +267,27 @@ func migrationNamesMatch(t *testing.T, names []string, files []fs.DirEntry) {
 		require.Equal(t, dropExtension(files[idx].Name()), names[idx])
 	}
 }
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("known migrations", func(t *testing.T) {
+		store := NewTestStore(t)
+		ctx := context.Background()
+
+		migrator := NewMigrator(store, zaptest.NewLogger(t))
+		require.NoError(t, migrator.Validate(ctx, test_migrations.AllDown))
+		require.NoError(t, migrator.Up(ctx, test_migrations.AllUp))
+		require.NoError(t, migrator.Validate(ctx, test_migrations.AllDown))
+	})
+
+	t.Run("unknown migration exists", func(t *testing.T) {
+		store := NewTestStore(t)
+		ctx := context.Background()
+
+		migrator := NewMigrator(store, zaptest.NewLogger(t))
+		require.NoError(t, migrator.Up(ctx, test_migrations.FirstUp))
+		require.NoError(t, store.execTrans(ctx, `INSERT INTO migrations (name) VALUES ("0010_some_bad_migration")`))
+		require.Equal(t, migration.ErrInvalidMigration("0010_some_bad_migration"), migrator.Validate(ctx, test_migrations.AllDown))
+	})
+}
@@ -509,6 +509,44 @@ func (e *Engine) SeriesCardinality(ctx context.Context, bucketID platform.ID) in
 	return n
 }
 
+// BucketDiskSize returns the number of bytes the shards of a bucket use on disk.
+func (e *Engine) BucketDiskSize(ctx context.Context, bucketID platform.ID) int64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closing == nil {
+		return 0
+	}
+
+	db := e.metaClient.Database(bucketID.String())
+	if db == nil {
+		return 0
+	}
+	var ids []uint64
+	for _, rp := range db.RetentionPolicies {
+		for i := range rp.ShardGroups {
+			if rp.ShardGroups[i].Deleted() {
+				continue
+			}
+			for _, sh := range rp.ShardGroups[i].Shards {
+				ids = append(ids, sh.ID)
+			}
+		}
+	}
+
+	var size int64
+	for _, sh := range e.tsdbStore.Shards(ids) {
+		if sh == nil {
+			continue
+		}
+		n, err := sh.DiskSize()
+		if err != nil {
+			continue
+		}
+		size += n
+	}
+	return size
+}
+
 // Path returns the path of the engine's base directory.
 func (e *Engine) Path() string {
 	return e.path
@@ -11,12 +11,15 @@ import (
 	"github.com/influxdata/flux/memory"
 	"github.com/influxdata/flux/plan"
 	"github.com/influxdata/flux/values"
+	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
 	"github.com/influxdata/influxdb/v2/models"
 	"github.com/influxdata/influxdb/v2/query"
 	storage "github.com/influxdata/influxdb/v2/storage/reads"
 	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
 	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+	"github.com/opentracing/opentracing-go"
 	"google.golang.org/protobuf/types/known/anypb"
 )
 
@@ -131,6 +134,24 @@ func (r *storeReader) SupportReadSeriesCardinality(ctx context.Context) bool {
 
 func (r *storeReader) Close() {}
 
+// startReadSpan starts the span of a read of the series of a bucket.
+func startReadSpan(ctx context.Context, operationName string, orgID, bucketID platform.ID) (opentracing.Span, context.Context) {
+	span, ctx := tracing.StartSpanFromContextWithOperationName(ctx, operationName)
+	span.SetTag("org_id", orgID.String())
+	span.SetTag("bucket_id", bucketID.String())
+	return span, ctx
+}
+
+// finishReadSpan finishes the span of a read with the statistics of the read.
+func finishReadSpan(span opentracing.Span, stats cursors.CursorStats, err error) {
+	span.SetTag("scanned_values", stats.ScannedValues)
+	span.SetTag("scanned_bytes", stats.ScannedBytes)
+	if err != nil {
+		_ = tracing.LogError(span, err)
+	}
+	span.Finish()
+}
+
 type filterIterator struct {
 	ctx   context.Context
 	s     storage.Store
@@ -142,7 +163,10 @@ type filterIterator struct {
 
 func (fi *filterIterator) Statistics() cursors.CursorStats { return fi.stats }
 
-func (fi *filterIterator) Do(f func(flux.Table) error) error {
+func (fi *filterIterator) Do(f func(flux.Table) error) (err error) {
+	span, ctx := startReadSpan(fi.ctx, "storage.ReadFilter", fi.spec.OrganizationID, fi.spec.BucketID)
+	defer func() { finishReadSpan(span, fi.stats, err) }()
+
 	src := fi.s.GetSource(
 		uint64(fi.spec.OrganizationID),
 		uint64(fi.spec.BucketID),
@@ -162,7 +186,7 @@ func (fi *filterIterator) Do(f func(flux.Table) error) error {
 		End:   int64(fi.spec.Bounds.Stop),
 	}
 
-	rs, err := fi.s.ReadFilter(fi.ctx, &req)
+	rs, err := fi.s.ReadFilter(ctx, &req)
 	if err != nil {
 		return err
 	}
@@ -259,7 +283,10 @@ type groupIterator struct {
 
 func (gi *groupIterator) Statistics() cursors.CursorStats { return gi.stats }
 
-func (gi *groupIterator) Do(f func(flux.Table) error) error {
+func (gi *groupIterator) Do(f func(flux.Table) error) (err error) {
+	span, ctx := startReadSpan(gi.ctx, "storage.ReadGroup", gi.spec.OrganizationID, gi.spec.BucketID)
+	defer func() { finishReadSpan(span, gi.stats, err) }()
+
 	src := gi.s.GetSource(
 		uint64(gi.spec.OrganizationID),
 		uint64(gi.spec.BucketID),
@@ -294,7 +321,7 @@ func (gi *groupIterator) Do(f func(flux.Table) error) error {
 		req.Aggregate = &datatypes.Aggregate{Type: agg}
 	}
 
-	rs, err := gi.s.ReadGroup(gi.ctx, &req)
+	rs, err := gi.s.ReadGroup(ctx, &req)
 	if err != nil {
 		return err
 	}
@@ -634,7 +661,10 @@ type windowAggregateIterator struct {
 
 func (wai *windowAggregateIterator) Statistics() cursors.CursorStats { return wai.stats }
 
-func (wai *windowAggregateIterator) Do(f func(flux.Table) error) error {
+func (wai *windowAggregateIterator) Do(f func(flux.Table) error) (err error) {
+	span, ctx := startReadSpan(wai.ctx, "storage.WindowAggregate", wai.spec.OrganizationID, wai.spec.BucketID)
+	defer func() { finishReadSpan(span, wai.stats, err) }()
+
 	src := wai.s.GetSource(
 		uint64(wai.spec.OrganizationID),
 		uint64(wai.spec.BucketID),
@@ -677,7 +707,7 @@ func (wai *windowAggregateIterator) Do(f func(flux.Table) error) error {
 		}
 	}
 
-	rs, err := wai.s.WindowAggregate(wai.ctx, &req)
+	rs, err := wai.s.WindowAggregate(ctx, &req)
 	if err != nil {
 		return err
 	}
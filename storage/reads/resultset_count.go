@@ -0,0 +1,60 @@
+package reads
+
+import (
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// CountResultSet counts the series of rs with points, and their points. rs
+// is closed once counted.
+func CountResultSet(rs ResultSet) (series, points int64, err error) {
+	defer rs.Close()
+
+	for rs.Next() {
+		cur := rs.Cursor()
+		if cur == nil {
+			continue
+		}
+		n, err := countCursor(cur)
+		if err != nil {
+			return 0, 0, err
+		}
+		if n > 0 {
+			series++
+			points += n
+		}
+	}
+
+	return series, points, rs.Err()
+}
+
+func countCursor(cur cursors.Cursor) (int64, error) {
+	defer cur.Close()
+
+	var n int64
+	switch ccur := cur.(type) {
+	case cursors.IntegerArrayCursor:
+		for a := ccur.Next(); a.Len() > 0; a = ccur.Next() {
+			n += int64(a.Len())
+		}
+	case cursors.FloatArrayCursor:
+		for a := ccur.Next(); a.Len() > 0; a = ccur.Next() {
+			n += int64(a.Len())
+		}
+	case cursors.UnsignedArrayCursor:
+		for a := ccur.Next(); a.Len() > 0; a = ccur.Next() {
+			n += int64(a.Len())
+		}
+	case cursors.BooleanArrayCursor:
+		for a := ccur.Next(); a.Len() > 0; a = ccur.Next() {
+			n += int64(a.Len())
+		}
+	case cursors.StringArrayCursor:
+		for a := ccur.Next(); a.Len() > 0; a = ccur.Next() {
+			n += int64(a.Len())
+		}
+	default:
+		panic("unreachable")
+	}
+
+	return n, cur.Err()
+}
@@ -58,11 +58,19 @@ func MultiLimit(limits ...LimitFunc) LimitFunc {
 // LimitFunc is a function the executor will use to
 type LimitFunc func(*taskmodel.Task, *taskmodel.Run) error
 
+// Runner runs the tasks of a type natively, in place of querying their Flux.
+type Runner interface {
+	// Run runs a task for the time it is scheduled for. The context carries
+	// the authorization of the owner of the task.
+	Run(ctx context.Context, t *taskmodel.Task, scheduledFor time.Time) error
+}
+
 type executorConfig struct {
 	maxWorkers             int
 	systemBuildCompiler    CompilerBuilderFunc
 	nonSystemBuildCompiler CompilerBuilderFunc
 	flagger                feature.Flagger
+	runners                map[string]Runner
 }
 
 type executorOption func(*executorConfig)
@@ -119,6 +127,17 @@ func WithNonSystemCompilerBuilder(builder CompilerBuilderFunc) executorOption {
 	}
 }
 
+// WithRunner is an Executor option that runs the tasks of a type with r,
+// rather than by querying their Flux.
+func WithRunner(taskType string, r Runner) executorOption {
+	return func(o *executorConfig) {
+		if o.runners == nil {
+			o.runners = make(map[string]Runner)
+		}
+		o.runners[taskType] = r
+	}
+}
+
 // WithFlagger is an Executor option that allows us to use a feature flagger in the executor
 func WithFlagger(flagger feature.Flagger) executorOption {
 	return func(o *executorConfig) {
@@ -152,6 +171,7 @@ func NewExecutor(log *zap.Logger, qs query.QueryService, us PermissionService, t
 		systemBuildCompiler:    cfg.systemBuildCompiler,
 		nonSystemBuildCompiler: cfg.nonSystemBuildCompiler,
 		flagger:                cfg.flagger,
+		runners:                cfg.runners,
 	}
 
 	e.metrics = NewExecutorMetrics(e)
@@ -195,6 +215,7 @@ type Executor struct {
 	nonSystemBuildCompiler CompilerBuilderFunc
 	systemBuildCompiler    CompilerBuilderFunc
 	flagger                feature.Flagger
+	runners                map[string]Runner
 }
 
 func (e *Executor) LoadExistingScheduleRuns(ctx context.Context) error {
@@ -634,6 +655,15 @@ func (w *worker) executeQuery(p *promise) {
 
 	ctx = icontext.SetAuthorizer(ctx, p.auth)
 
+	if r, ok := w.e.runners[p.task.Type]; ok {
+		if err := r.Run(ctx, p.task, p.run.ScheduledFor); err != nil {
+			w.finish(p, taskmodel.RunFail, taskmodel.ErrRunExecutionError(err))
+			return
+		}
+		w.finish(p, taskmodel.RunSuccess, nil)
+		return
+	}
+
 	buildCompiler := w.systemBuildCompiler
 	if p.task.Type != taskmodel.TaskSystemType {
 		buildCompiler = w.nonSystemBuildCompiler
@@ -56,7 +56,7 @@ type tes struct {
 	tc      testCreds
 }
 
-func taskExecutorSystem(t *testing.T) tes {
+func taskExecutorSystem(t *testing.T, opts ...executorOption) tes {
 	var (
 		aqs = newFakeQueryService()
 		qs  = query.QueryServiceBridge{
@@ -87,7 +87,7 @@ func taskExecutorSystem(t *testing.T) tes {
 		})
 
 		tcs         = &taskControlService{TaskControlService: svc}
-		ex, metrics = NewExecutor(zaptest.NewLogger(t), qs, ps, svc, tcs)
+		ex, metrics = NewExecutor(zaptest.NewLogger(t), qs, ps, svc, tcs, opts...)
 	)
 	return tes{
 		svc:     aqs,
@@ -109,6 +109,7 @@ func TestTaskExecutor(t *testing.T) {
 	t.Run("Metrics", testMetrics)
 	t.Run("IteratorFailure", testIteratorFailure)
 	t.Run("ErrorHandling", testErrorHandling)
+	t.Run("Runner", testRunner)
 }
 
 func testQuerySuccess(t *testing.T) {
@@ -179,6 +180,46 @@ func testQuerySuccess(t *testing.T) {
 	}
 }
 
+type runnerFunc func(ctx context.Context, t *taskmodel.Task, scheduledFor time.Time) error
+
+func (f runnerFunc) Run(ctx context.Context, t *taskmodel.Task, scheduledFor time.Time) error {
+	return f(ctx, t, scheduledFor)
+}
+
+func testRunner(t *testing.T) {
+	t.Parallel()
+
+	var ran []time.Time
+	tes := taskExecutorSystem(t, WithRunner("native", runnerFunc(func(ctx context.Context, task *taskmodel.Task, scheduledFor time.Time) error {
+		if _, err := icontext.GetAuthorizer(ctx); err != nil {
+			return err
+		}
+		ran = append(ran, scheduledFor)
+		if len(ran) > 1 {
+			return errors.New("native failure")
+		}
+		return nil
+	})))
+
+	script := fmt.Sprintf(fmtTestScript, t.Name())
+	ctx := icontext.SetAuthorizer(context.Background(), tes.tc.Auth)
+	task, err := tes.i.CreateTask(ctx, taskmodel.TaskCreate{Type: "native", OrganizationID: tes.tc.OrgID, OwnerID: tes.tc.Auth.GetUserID(), Flux: script})
+	require.NoError(t, err)
+
+	promise, err := tes.ex.PromisedExecute(ctx, scheduler.ID(task.ID), time.Unix(123, 0), time.Unix(126, 0))
+	require.NoError(t, err)
+	<-promise.Done()
+	require.NoError(t, promise.Error())
+	require.Equal(t, []time.Time{time.Unix(123, 0).UTC()}, ran)
+
+	promise, err = tes.ex.PromisedExecute(ctx, scheduler.ID(task.ID), time.Unix(183, 0), time.Unix(186, 0))
+	require.NoError(t, err)
+	<-promise.Done()
+	require.Error(t, promise.Error())
+	require.Contains(t, promise.Error().Error(), "native failure")
+	require.Len(t, ran, 2)
+}
+
 func testQueryFailure(t *testing.T) {
 	t.Parallel()
 	tes := taskExecutorSystem(t)
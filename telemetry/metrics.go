@@ -1,56 +1,106 @@
 package telemetry
 
 import (
+	"fmt"
+
 	pr "github.com/influxdata/influxdb/v2/prometheus"
 )
 
-var telemetryMatcher = pr.NewMatcher().
-	/*
-	 *   Runtime stats
-	 */
-	Family("influxdb_info"). // includes version, os, etc.
-	Family("influxdb_uptime_seconds").
-	/*
-	 * Resource Counts
-	 */
-	Family("influxdb_organizations_total").
-	Family("influxdb_buckets_total").
-	Family("influxdb_users_total").
-	Family("influxdb_tokens_total").
-	Family("influxdb_dashboards_total").
-	Family("influxdb_scrapers_total").
-	Family("influxdb_telegrafs_total").
-	Family("influxdb_telegraf_plugins_count").
-	Family("influxdb_remotes_total").
-	Family("influxdb_replications_total").
-	Family("task_scheduler_claims_active"). // Count of currently active tasks
-	/*
-	 * Count of API requests including success and failure
-	 */
-	Family("http_api_requests_total").
-	/*
-	 * Count of writes and queries
-	 */
-	Family("storage_wal_writes_total").
-	Family("query_control_requests_total").
-	/*
-	 * Query analysis
-	 */
-	Family("query_control_functions_total").      // Count of functions in queries (e.g. mean, median)
-	Family("query_control_all_duration_seconds"). // Total query duration per org.
-	/*
-	 * Write analysis
-	 */
-	Family("http_api_request_duration_seconds_bucket",
-		pr.L("path", "/api/v2/write"), // Count only the durations of the /write endpoint.
-	).
-	/*
-	 * Storage cardinality
-	 */
-	Family("storage_tsi_index_series_total").
-	/*
-	 * Storage disk usage
-	 */
-	Family("storage_series_file_disk_bytes").    // All families need to be aggregated to
-	Family("storage_wal_current_segment_bytes"). // get a true idea of disk usage.
-	Family("storage_tsm_files_disk_bytes")
+// Category is a category of the metrics reported.
+type Category string
+
+// The categories of the metrics reported.
+const (
+	// CategoryRuntime is the version, os and uptime of the server.
+	CategoryRuntime Category = "runtime"
+	// CategoryResources is the number of each kind of resource, and of the
+	// active tasks.
+	CategoryResources Category = "resources"
+	// CategoryRequests is the number of API requests, writes and queries.
+	CategoryRequests Category = "requests"
+	// CategoryQueries is the functions used by queries and their duration.
+	CategoryQueries Category = "queries"
+	// CategoryWrites is the duration of writes.
+	CategoryWrites Category = "writes"
+	// CategoryCardinality is the series cardinality of the storage engine.
+	CategoryCardinality Category = "cardinality"
+	// CategoryDisk is the disk usage of the storage engine.
+	CategoryDisk Category = "disk"
+)
+
+// Categories are all the categories of metrics, which are all reported by
+// default.
+var Categories = []Category{
+	CategoryRuntime,
+	CategoryResources,
+	CategoryRequests,
+	CategoryQueries,
+	CategoryWrites,
+	CategoryCardinality,
+	CategoryDisk,
+}
+
+var categoryMatchers = map[Category]pr.Matcher{
+	CategoryRuntime: pr.NewMatcher().
+		Family("influxdb_info"). // includes version, os, etc.
+		Family("influxdb_uptime_seconds"),
+	CategoryResources: pr.NewMatcher().
+		Family("influxdb_organizations_total").
+		Family("influxdb_buckets_total").
+		Family("influxdb_users_total").
+		Family("influxdb_tokens_total").
+		Family("influxdb_dashboards_total").
+		Family("influxdb_scrapers_total").
+		Family("influxdb_telegrafs_total").
+		Family("influxdb_telegraf_plugins_count").
+		Family("influxdb_remotes_total").
+		Family("influxdb_replications_total").
+		Family("task_scheduler_claims_active"), // Count of currently active tasks
+	CategoryRequests: pr.NewMatcher().
+		/*
+		 * Count of API requests including success and failure
+		 */
+		Family("http_api_requests_total").
+		/*
+		 * Count of writes and queries
+		 */
+		Family("storage_wal_writes_total").
+		Family("query_control_requests_total"),
+	CategoryQueries: pr.NewMatcher().
+		Family("query_control_functions_total").      // Count of functions in queries (e.g. mean, median)
+		Family("query_control_all_duration_seconds"), // Total query duration per org.
+	CategoryWrites: pr.NewMatcher().
+		Family("http_api_request_duration_seconds_bucket",
+			pr.L("path", "/api/v2/write"), // Count only the durations of the /write endpoint.
+		),
+	CategoryCardinality: pr.NewMatcher().
+		Family("storage_tsi_index_series_total"),
+	CategoryDisk: pr.NewMatcher().
+		Family("storage_series_file_disk_bytes").    // All families need to be aggregated to
+		Family("storage_wal_current_segment_bytes"). // get a true idea of disk usage.
+		Family("storage_tsm_files_disk_bytes"),
+}
+
+// ParseCategories parses the names of categories.
+func ParseCategories(names []string) ([]Category, error) {
+	categories := make([]Category, 0, len(names))
+	for _, name := range names {
+		c := Category(name)
+		if _, ok := categoryMatchers[c]; !ok {
+			return nil, fmt.Errorf("unknown telemetry category %q", name)
+		}
+		categories = append(categories, c)
+	}
+	return categories, nil
+}
+
+// newMatcher returns a matcher of the metric families of the categories.
+func newMatcher(categories []Category) pr.Matcher {
+	m := pr.NewMatcher()
+	for _, c := range categories {
+		for name, labels := range categoryMatchers[c] {
+			m[name] = labels
+		}
+	}
+	return m
+}
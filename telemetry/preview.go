@@ -0,0 +1,128 @@
+package telemetry
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+const prefixPreview = "/api/v2/debug/telemetry"
+
+// Config selects the telemetry reported and where it is reported to.
+type Config struct {
+	// Categories are the names of the categories of metrics reported. All the
+	// Categories are reported when empty.
+	Categories []string
+	// URL is the push gateway the telemetry is reported to, such as an
+	// internal gateway rather than DefaultURL.
+	URL string
+}
+
+// Pusher returns a pusher of the telemetry selected by the config, and the
+// categories it pushes.
+func (c Config) Pusher(g prometheus.Gatherer) (*Pusher, []Category, error) {
+	categories, err := ParseCategories(c.Categories)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(categories) == 0 {
+		categories = Categories
+	}
+	if c.URL == "" {
+		return nil, nil, fmt.Errorf("telemetry url must not be empty")
+	}
+
+	p := NewPusher(g, categories...)
+	p.URL = c.URL
+	return p, categories, nil
+}
+
+// PreviewHandler serves to operators the payload the telemetry reporter sends,
+// so they can see exactly what is reported before opting in.
+type PreviewHandler struct {
+	chi.Router
+
+	api *kithttp.API
+	log *zap.Logger
+
+	pusher     *Pusher
+	categories []Category
+	reported   bool
+}
+
+// NewPreviewHandler returns a handler previewing the payload of p, which
+// pushes the categories. reported is whether the telemetry is reported.
+func NewPreviewHandler(log *zap.Logger, p *Pusher, categories []Category, reported bool) *PreviewHandler {
+	h := &PreviewHandler{
+		log:        log,
+		api:        kithttp.NewAPI(kithttp.WithLog(log)),
+		pusher:     p,
+		categories: categories,
+		reported:   reported,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+		h.mwAuthorize,
+	)
+	r.Get("/", h.handleGetPreview)
+	h.Router = r
+	return h
+}
+
+// Prefix returns the mounting prefix for the handler.
+func (h *PreviewHandler) Prefix() string {
+	return prefixPreview
+}
+
+type previewResponse struct {
+	Reported   bool       `json:"reported"`
+	URL        string     `json:"url"`
+	Categories []Category `json:"categories"`
+	Payload    string     `json:"payload"`
+}
+
+// handleGetPreview is the HTTP handler for the GET /api/v2/debug/telemetry route.
+// The payload is in the format it is pushed in.
+func (h *PreviewHandler) handleGetPreview(w http.ResponseWriter, r *http.Request) {
+	b, err := h.pusher.Preview()
+	if err != nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInternal,
+			Msg:  "failed to gather the telemetry",
+			Err:  err,
+		})
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, previewResponse{
+		Reported:   h.reported,
+		URL:        h.pusher.URL,
+		Categories: h.categories,
+		Payload:    string(b),
+	})
+}
+
+func (h *PreviewHandler) mwAuthorize(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if err := authorizer.IsAllowedAll(r.Context(), influxdb.OperPermissions()); err != nil {
+			h.api.Err(w, r, &errors.Error{
+				Code: errors.EUnauthorized,
+				Msg:  fmt.Sprintf("access to %s requires operator permissions", h.Prefix()),
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
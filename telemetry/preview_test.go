@@ -0,0 +1,89 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	influxdbcontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestConfig_Pusher(t *testing.T) {
+	gatherer := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return []*dto.MetricFamily{
+			NewCounter("influxdb_buckets_total", 1.0),
+			NewCounter("influxdb_uptime_seconds", 2.0),
+			NewCounter("storage_tsi_index_series_total", 3.0),
+			NewCounter("go_goroutines", 4.0),
+		}, nil
+	})
+
+	t.Run("selected categories", func(t *testing.T) {
+		p, categories, err := Config{Categories: []string{"resources", "cardinality"}, URL: "http://gateway.internal"}.Pusher(gatherer)
+		require.NoError(t, err)
+		assert.Equal(t, []Category{CategoryResources, CategoryCardinality}, categories)
+		assert.Equal(t, "http://gateway.internal", p.URL)
+
+		mfs, err := p.Gather.Gather()
+		require.NoError(t, err)
+		var names []string
+		for _, mf := range mfs {
+			names = append(names, mf.GetName())
+		}
+		assert.ElementsMatch(t, []string{"influxdb_buckets_total", "storage_tsi_index_series_total"}, names)
+	})
+
+	t.Run("all categories by default", func(t *testing.T) {
+		_, categories, err := Config{URL: DefaultURL}.Pusher(gatherer)
+		require.NoError(t, err)
+		assert.Equal(t, Categories, categories)
+	})
+
+	t.Run("unknown category", func(t *testing.T) {
+		_, _, err := Config{Categories: []string{"everything"}, URL: DefaultURL}.Pusher(gatherer)
+		assert.Error(t, err)
+	})
+
+	t.Run("no url", func(t *testing.T) {
+		_, _, err := Config{}.Pusher(gatherer)
+		assert.Error(t, err)
+	})
+}
+
+func TestPreviewHandler(t *testing.T) {
+	gatherer := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return []*dto.MetricFamily{NewCounter("influxdb_buckets_total", 1.0)}, nil
+	})
+	p, categories, err := Config{Categories: []string{"resources"}, URL: DefaultURL}.Pusher(gatherer)
+	require.NoError(t, err)
+	h := NewPreviewHandler(zaptest.NewLogger(t), p, categories, false)
+
+	do := func(perms []influxdb.Permission) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		ctx := influxdbcontext.SetAuthorizer(context.Background(), mock.NewMockAuthorizer(false, perms))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, r.WithContext(ctx))
+		return rr
+	}
+
+	rr := do(nil)
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	rr = do(influxdb.OperPermissions())
+	require.Equal(t, http.StatusOK, rr.Code)
+	var res previewResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&res))
+	assert.False(t, res.Reported)
+	assert.Equal(t, DefaultURL, res.URL)
+	assert.Equal(t, []Category{CategoryResources}, res.Categories)
+	assert.Contains(t, res.Payload, "influxdb_buckets_total 1")
+}
@@ -21,13 +21,20 @@ type Pusher struct {
 	PushFormat expfmt.Format
 }
 
-// NewPusher sends usage metrics to a prometheus push gateway.
-func NewPusher(g prometheus.Gatherer) *Pusher {
+// DefaultURL is the push gateway the telemetry is reported to by default.
+const DefaultURL = "https://telemetry.influxdata.com/metrics/job/influxdb"
+
+// NewPusher sends the usage metrics of the categories to a prometheus push
+// gateway. The metrics of all the Categories are sent when none are given.
+func NewPusher(g prometheus.Gatherer, categories ...Category) *Pusher {
+	if len(categories) == 0 {
+		categories = Categories
+	}
 	return &Pusher{
-		URL: "https://telemetry.influxdata.com/metrics/job/influxdb",
+		URL: DefaultURL,
 		Gather: &pr.Filter{
 			Gatherer: g,
-			Matcher:  telemetryMatcher,
+			Matcher:  newMatcher(categories),
 		},
 		Client: &http.Client{
 			Transport: http.DefaultTransport,
@@ -96,6 +103,16 @@ func (p *Pusher) push(ctx context.Context) error {
 }
 
 func (p *Pusher) encode() (io.Reader, error) {
+	b, err := p.Preview()
+	if err != nil || b == nil {
+		return nil, err
+	}
+	return bytes.NewBuffer(b), nil
+}
+
+// Preview returns the payload a push would send now, without sending it. The
+// payload is nil when there are no metrics to send.
+func (p *Pusher) Preview() ([]byte, error) {
 	mfs, err := p.Gather.Gather()
 	if err != nil {
 		return nil, err
@@ -105,10 +122,9 @@ func (p *Pusher) encode() (io.Reader, error) {
 		return nil, nil
 	}
 
-	b, err := pr.EncodeExpfmt(mfs, p.PushFormat)
-	if err != nil {
-		return nil, err
+	format := p.PushFormat
+	if format == "" {
+		format = expfmt.FmtText
 	}
-
-	return bytes.NewBuffer(b), nil
+	return pr.EncodeExpfmt(mfs, format)
 }
@@ -29,7 +29,7 @@ const (
 )
 
 // NewHTTPBucketHandler constructs a new http server.
-func NewHTTPBucketHandler(log *zap.Logger, bucketSvc influxdb.BucketService, labelSvc influxdb.LabelService, urmHandler, labelHandler http.Handler) *BucketHandler {
+func NewHTTPBucketHandler(log *zap.Logger, bucketSvc influxdb.BucketService, labelSvc influxdb.LabelService, urmHandler, labelHandler, statsHandler http.Handler) *BucketHandler {
 	svr := &BucketHandler{
 		api:       kithttp.NewAPI(kithttp.WithLog(log)),
 		log:       log,
@@ -59,6 +59,9 @@ func NewHTTPBucketHandler(log *zap.Logger, bucketSvc influxdb.BucketService, lab
 			mountableRouter.Mount("/members", urmHandler)
 			mountableRouter.Mount("/owners", urmHandler)
 			mountableRouter.Mount("/labels", labelHandler)
+			if statsHandler != nil {
+				mountableRouter.Mount("/stats", statsHandler)
+			}
 		})
 	})
 
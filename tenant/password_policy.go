@@ -0,0 +1,120 @@
+package tenant
+
+import (
+	eBase "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// strongPasswordCharClasses is the number of character classes strong
+// passwords contain.
+const strongPasswordCharClasses = 3
+
+// PasswordPolicy are the rules the passwords of users and v1 authorizations
+// follow.
+type PasswordPolicy struct {
+	// MinLength is the minimum length of passwords. Passwords are never longer
+	// than errors.MaxPasswordLen.
+	MinLength int
+	// MinCharClasses is the minimum number of uppercase, lowercase, number and
+	// special characters classes passwords contain.
+	MinCharClasses int
+	// Banned are the passwords which may not be used, compared case-insensitively.
+	Banned []string
+	// MaxAge is how long a password may be used before it must be changed.
+	// Zero never expires passwords.
+	MaxAge time.Duration
+}
+
+// DefaultPasswordPolicy returns the policy only checking the length of
+// passwords.
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{MinLength: errors.MinPasswordLen}
+}
+
+// StrongPasswordPolicy returns the policy of the strong-passwords option.
+func StrongPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:      errors.MinPasswordLen,
+		MinCharClasses: strongPasswordCharClasses,
+	}
+}
+
+// Valid returns an error if the rules of p contradict each other.
+func (p PasswordPolicy) Valid() error {
+	if p.MinLength < 1 || p.MinLength > errors.MaxPasswordLen {
+		return fmt.Errorf("minimum password length must be between 1 and %d", errors.MaxPasswordLen)
+	}
+	if p.MinCharClasses < 0 || p.MinCharClasses > len(classes) {
+		return fmt.Errorf("minimum password character classes must be between 0 and %d", len(classes))
+	}
+	if p.MaxAge < 0 {
+		return fmt.Errorf("maximum password age must not be negative")
+	}
+	return nil
+}
+
+// Check returns the errors of every rule password breaks, joined.
+func (p PasswordPolicy) Check(password string) error {
+	var errs []error
+	if l := len(password); l < p.MinLength || l > errors.MaxPasswordLen {
+		errs = append(errs, p.lengthError())
+	}
+	if p.MinCharClasses > 0 && len(password) > 0 && countCharClasses(password) < p.MinCharClasses {
+		errs = append(errs, p.charClassesError())
+	}
+	for _, banned := range p.Banned {
+		if strings.EqualFold(password, banned) {
+			errs = append(errs, errors.EPasswordBanned)
+			break
+		}
+	}
+	return eBase.Join(errs...)
+}
+
+// Expired reports whether a password set at setAt must be changed at now.
+func (p PasswordPolicy) Expired(setAt, now time.Time) bool {
+	return p.MaxAge > 0 && now.Sub(setAt) > p.MaxAge
+}
+
+func (p PasswordPolicy) lengthError() error {
+	if p.MinLength == errors.MinPasswordLen {
+		return errors.EPasswordLength
+	}
+	return &errors.Error{
+		Code: errors.EInvalid,
+		Msg:  fmt.Sprintf("passwords must be between %d and %d characters long", p.MinLength, errors.MaxPasswordLen),
+	}
+}
+
+func (p PasswordPolicy) charClassesError() error {
+	if p.MinCharClasses == strongPasswordCharClasses {
+		return errors.EPasswordChars
+	}
+	return &errors.Error{
+		Code: errors.EInvalid,
+		Msg: fmt.Sprintf(
+			"passwords must contain at least %d of the following character types: uppercase, lowercase, numbers, and special characters: %s",
+			p.MinCharClasses, errors.SpecialChars),
+	}
+}
+
+// countCharClasses returns the number of character classes in password,
+// walking a constant length copy of it for each class.
+func countCharClasses(password string) int {
+	constLenPassword := strings.Repeat(password, 1+(errors.MaxPasswordLen/len(password)))[:errors.MaxPasswordLen]
+	n := 0
+	for _, f := range classes {
+		found := false
+		for _, r := range constLenPassword {
+			found = f(r) || found
+		}
+		if found {
+			n++
+		}
+	}
+	return n
+}
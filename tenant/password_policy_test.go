@@ -0,0 +1,58 @@
+package tenant_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	influx_errors "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/tenant"
+	influxdbtesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	policy := tenant.PasswordPolicy{
+		MinLength:      12,
+		MinCharClasses: 2,
+		Banned:         []string{"Password1234"},
+	}
+	require.NoError(t, policy.Valid())
+
+	require.NoError(t, policy.Check("correct horse battery 1"))
+	require.EqualError(t, policy.Check("abc1"), "passwords must be between 12 and 72 characters long")
+	require.EqualError(t, policy.Check("abcdefghijklm"), "passwords must contain at least 2 of the following character types: uppercase, lowercase, numbers, and special characters: "+influx_errors.SpecialChars)
+	require.True(t, errors.Is(policy.Check("PASSWORD1234"), influx_errors.EPasswordBanned))
+
+	// the default bounds keep their errors
+	require.True(t, errors.Is(tenant.DefaultPasswordPolicy().Check("abc"), influx_errors.EPasswordLength))
+	require.True(t, errors.Is(tenant.StrongPasswordPolicy().Check("abcdefghij"), influx_errors.EPasswordChars))
+
+	require.Error(t, tenant.PasswordPolicy{MinLength: 0}.Valid())
+	require.Error(t, tenant.PasswordPolicy{MinLength: 8, MinCharClasses: 5}.Valid())
+	require.Error(t, tenant.PasswordPolicy{MinLength: 8, MaxAge: -time.Hour}.Valid())
+}
+
+func TestPasswordPolicy_MaxAge(t *testing.T) {
+	ctx := context.Background()
+	policy := tenant.DefaultPasswordPolicy()
+	policy.MaxAge = time.Millisecond
+	svc := tenant.NewService(tenant.NewStore(influxdbtesting.NewTestInmemStore(t)), tenant.WithPasswordPolicy(policy))
+
+	u := &influxdb.User{Name: "user"}
+	require.NoError(t, svc.CreateUser(ctx, u))
+	require.NoError(t, svc.SetPassword(ctx, u.ID, "password1"))
+	time.Sleep(2 * time.Millisecond)
+
+	err := svc.ComparePassword(ctx, u.ID, "password1")
+	require.True(t, errors.Is(err, influx_errors.EPasswordChangeRequired))
+	require.True(t, errors.Is(err, influx_errors.EPasswordExpired))
+
+	// a new password is valid again
+	require.NoError(t, svc.SetPassword(ctx, u.ID, "password2"))
+	svc.SetUserOptions(tenant.WithPasswordPolicy(tenant.DefaultPasswordPolicy()))
+	require.NoError(t, svc.ComparePassword(ctx, u.ID, "password2"))
+	require.Equal(t, influx_errors.EIncorrectPassword, svc.ComparePassword(ctx, u.ID, "password1"))
+}
@@ -2,6 +2,7 @@ package tenant
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/metric"
@@ -63,8 +64,8 @@ func (s *Service) SetUserOptions(opts ...func(*UserSvc)) {
 }
 
 // creates a new Service with logging and metrics middleware wrappers.
-func NewSystem(store *Store, log *zap.Logger, reg prometheus.Registerer, strongPasswords bool, metricOpts ...metric.ClientOptFn) *Service {
-	ts := NewService(store, WithPasswordChecking(strongPasswords))
+func NewSystem(store *Store, log *zap.Logger, reg prometheus.Registerer, passwordPolicy PasswordPolicy, metricOpts ...metric.ClientOptFn) *Service {
+	ts := NewService(store, WithPasswordPolicy(passwordPolicy))
 	ts.UserService = NewUserLogger(log, NewUserMetrics(reg, ts.UserService, metricOpts...))
 	ts.PasswordsService = NewPasswordLogger(log, NewPasswordMetrics(reg, ts.PasswordsService, metricOpts...))
 	ts.UserResourceMappingService = NewURMLogger(log, NewUrmMetrics(reg, ts.UserResourceMappingService, metricOpts...))
@@ -80,10 +81,10 @@ func (ts *Service) NewOrgHTTPHandler(log *zap.Logger, secretSvc influxdb.SecretS
 	return NewHTTPOrgHandler(log.With(zap.String("handler", "org")), NewAuthedOrgService(ts.OrganizationService), urmHandler, secretHandler)
 }
 
-func (ts *Service) NewBucketHTTPHandler(log *zap.Logger, labelSvc influxdb.LabelService) *BucketHandler {
+func (ts *Service) NewBucketHTTPHandler(log *zap.Logger, labelSvc influxdb.LabelService, statsHandler http.Handler) *BucketHandler {
 	urmHandler := NewURMHandler(log.With(zap.String("handler", "urm")), influxdb.BucketsResourceType, "id", ts.UserService, NewAuthedURMService(ts.OrganizationService, ts.UserResourceMappingService))
 	labelHandler := label.NewHTTPEmbeddedHandler(log.With(zap.String("handler", "label")), influxdb.BucketsResourceType, labelSvc)
-	return NewHTTPBucketHandler(log.With(zap.String("handler", "bucket")), NewAuthedBucketService(ts.BucketService), labelSvc, urmHandler, labelHandler)
+	return NewHTTPBucketHandler(log.With(zap.String("handler", "bucket")), NewAuthedBucketService(ts.BucketService), labelSvc, urmHandler, labelHandler, statsHandler)
 }
 
 func (ts *Service) NewUserHTTPHandler(log *zap.Logger) *UserHandler {
@@ -3,7 +3,7 @@ package tenant
 import (
 	"context"
 	eBase "errors"
-	"strings"
+	"time"
 	"unicode"
 
 	"github.com/influxdata/influxdb/v2"
@@ -14,16 +14,18 @@ import (
 )
 
 type UserSvc struct {
-	store           *Store
-	svc             *Service
-	strongPasswords bool
+	store          *Store
+	svc            *Service
+	passwordPolicy PasswordPolicy
+	now            func() time.Time
 }
 
 func NewUserSvc(st *Store, svc *Service, OptionFns ...func(*UserSvc)) *UserSvc {
 	userSvc := &UserSvc{
-		store:           st,
-		svc:             svc,
-		strongPasswords: false,
+		store:          st,
+		svc:            svc,
+		passwordPolicy: DefaultPasswordPolicy(),
+		now:            time.Now,
 	}
 	userSvc.SetOptions(OptionFns...)
 	return userSvc
@@ -35,9 +37,19 @@ func (s *UserSvc) SetOptions(opts ...func(*UserSvc)) {
 	}
 }
 
+// WithPasswordChecking sets the strong password policy if strong is set, and
+// the default one otherwise.
 func WithPasswordChecking(strong bool) func(*UserSvc) {
+	if strong {
+		return WithPasswordPolicy(StrongPasswordPolicy())
+	}
+	return WithPasswordPolicy(DefaultPasswordPolicy())
+}
+
+// WithPasswordPolicy sets the policy the passwords of users follow.
+func WithPasswordPolicy(policy PasswordPolicy) func(*UserSvc) {
 	return func(u *UserSvc) {
-		u.strongPasswords = strong
+		u.passwordPolicy = policy
 	}
 }
 
@@ -198,7 +210,7 @@ func (s *UserSvc) FindPermissionForUser(ctx context.Context, uid platform.ID) (i
 
 // SetPassword overrides the password of a known user.
 func (s *UserSvc) SetPassword(ctx context.Context, userID platform.ID, password string) error {
-	if err := IsPasswordStrong(password, s.strongPasswords); err != nil {
+	if err := s.passwordPolicy.Check(password); err != nil {
 		return err
 	}
 	passHash, err := encryptPassword(password)
@@ -211,18 +223,36 @@ func (s *UserSvc) SetPassword(ctx context.Context, userID platform.ID, password
 		if err != nil {
 			return errors.EIncorrectUser
 		}
-		return s.store.SetPassword(ctx, tx, userID, passHash)
+		if err := s.store.SetPassword(ctx, tx, userID, passHash); err != nil {
+			return err
+		}
+		return s.store.SetPasswordTime(ctx, tx, userID, s.now())
 	})
 }
 
 func (s *UserSvc) ComparePassword(ctx context.Context, userID platform.ID, password string) error {
 	err := s.comparePasswordNoStrengthCheck(ctx, userID, password)
-	// If a password matches, but is too weak, force user to change
-	if errStrength := IsPasswordStrong(password, s.strongPasswords); err == nil && errStrength != nil {
-		return eBase.Join(errors.EPasswordChangeRequired, errStrength)
-	} else {
+	if err != nil {
 		return err
 	}
+	// If a password matches, but is too weak or too old, force user to change
+	if errStrength := s.passwordPolicy.Check(password); errStrength != nil {
+		return eBase.Join(errors.EPasswordChangeRequired, errStrength)
+	}
+	if s.passwordPolicy.MaxAge > 0 {
+		var setAt time.Time
+		if err := s.store.View(ctx, func(tx kv.Tx) error {
+			var err error
+			setAt, err = s.store.GetPasswordTime(ctx, tx, userID)
+			return err
+		}); err != nil {
+			return err
+		}
+		if !setAt.IsZero() && s.passwordPolicy.Expired(setAt, s.now()) {
+			return eBase.Join(errors.EPasswordChangeRequired, errors.EPasswordExpired)
+		}
+	}
+	return nil
 }
 
 // comparePasswordNoStrengthCheck checks if the password matches the password recorded.
@@ -289,43 +319,13 @@ var classes []func(rune) bool = []func(rune) bool{
 	},
 }
 
-// IsPasswordStrong checks if a password is strong enough.
+// IsPasswordStrong checks a password against the strong password policy if
+// doCheck is set, and the default one otherwise.
 func IsPasswordStrong(password string, doCheck bool) error {
-	const numClassesRequired = 3
-	var eSlice []error = nil
-	var tslice []error = nil
-	l := len(password)
-	if l < errors.MinPasswordLen || l > errors.MaxPasswordLen {
-		eSlice = append(eSlice, errors.EPasswordLength)
-	} else {
-		tslice = append(tslice, errors.EPasswordLength)
-	}
 	if doCheck {
-		// make a password copy that is the length of the max password length
-		constLenPassword := strings.Repeat(password, 1+(errors.MaxPasswordLen/len(password)))[:errors.MaxPasswordLen]
-		n := 0
-		t := 0
-
-		// Walk the whole string for each class, for constant time operation
-		for _, f := range classes {
-			found := false
-			for _, r := range constLenPassword {
-				found = f(r) || found
-			}
-			if found {
-				n++
-			} else {
-				t++
-			}
-		}
-		if n < numClassesRequired {
-			eSlice = append(eSlice, errors.EPasswordChars)
-		} else {
-			tslice = append(tslice, errors.EPasswordChars)
-		}
+		return StrongPasswordPolicy().Check(password)
 	}
-	eBase.Join(tslice...)
-	return eBase.Join(eSlice...)
+	return DefaultPasswordPolicy().Check(password)
 }
 
 func permissionFromMapping(mappings []*influxdb.UserResourceMapping) ([]influxdb.Permission, error) {
@@ -3,6 +3,8 @@ package tenant
 import (
 	"context"
 	"encoding/json"
+	eBase "errors"
+	"time"
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/platform"
@@ -15,7 +17,8 @@ var (
 	userBucket = []byte("usersv1")
 	userIndex  = []byte("userindexv1")
 
-	userpasswordBucket = []byte("userspasswordv1")
+	userpasswordBucket     = []byte("userspasswordv1")
+	userpasswordTimeBucket = []byte("userspasswordtimev1")
 )
 
 func unmarshalUser(v []byte) (*influxdb.User, error) {
@@ -385,6 +388,71 @@ func (s *Store) DeletePassword(ctx context.Context, tx kv.Tx, id platform.ID) er
 		return errors.UnavailablePasswordServiceError(err)
 	}
 
-	return b.Delete(encodedID)
+	if err := b.Delete(encodedID); err != nil {
+		return err
+	}
+
+	tb, err := tx.Bucket(userpasswordTimeBucket)
+	if err != nil {
+		if eBase.Is(err, kv.ErrBucketNotFound) {
+			return nil
+		}
+		return errors.UnavailablePasswordServiceError(err)
+	}
+	if err := tb.Delete(encodedID); err != nil && !kv.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// GetPasswordTime returns when the password of a user was set, or the zero
+// time if it is unknown.
+func (s *Store) GetPasswordTime(ctx context.Context, tx kv.Tx, id platform.ID) (time.Time, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return time.Time{}, errors.InvalidUserIDError(err)
+	}
+
+	b, err := tx.Bucket(userpasswordTimeBucket)
+	if err != nil {
+		if eBase.Is(err, kv.ErrBucketNotFound) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, errors.UnavailablePasswordServiceError(err)
+	}
 
+	v, err := b.Get(encodedID)
+	if kv.IsNotFound(err) {
+		return time.Time{}, nil
+	} else if err != nil {
+		return time.Time{}, err
+	}
+	var t time.Time
+	if err := t.UnmarshalText(v); err != nil {
+		return time.Time{}, err
+	}
+	return t, nil
+}
+
+// SetPasswordTime records when the password of a user was set. Stores not
+// yet migrated, opened offline by recovery commands, do not record it.
+func (s *Store) SetPasswordTime(ctx context.Context, tx kv.Tx, id platform.ID, t time.Time) error {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return errors.InvalidUserIDError(err)
+	}
+
+	b, err := tx.Bucket(userpasswordTimeBucket)
+	if err != nil {
+		if eBase.Is(err, kv.ErrBucketNotFound) {
+			return nil
+		}
+		return errors.UnavailablePasswordServiceError(err)
+	}
+
+	v, err := t.UTC().MarshalText()
+	if err != nil {
+		return err
+	}
+	return b.Put(encodedID, v)
 }
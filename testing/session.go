@@ -20,7 +20,7 @@ const (
 	sessionTwoID = "020f755c3c082001"
 )
 
-var sessionCmpOptions = sessionCompareOptions("CreatedAt", "ExpiresAt", "Permissions")
+var sessionCmpOptions = sessionCompareOptions("CreatedAt", "ExpiresAt", "LastActiveAt", "Permissions")
 
 func sessionCompareOptions(ignore ...string) cmp.Options {
 	return cmp.Options{
@@ -425,7 +425,7 @@ func RenewSession(
 				t.Errorf("err in find session %v", err)
 			}
 
-			cmpOptions := sessionCompareOptions("CreatedAt", "Permissions")
+			cmpOptions := sessionCompareOptions("CreatedAt", "LastActiveAt", "Permissions")
 			if diff := cmp.Diff(session, tt.wants.session, cmpOptions...); diff != "" {
 				t.Errorf("session is different -got/+want\ndiff %s", diff)
 			}
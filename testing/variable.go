@@ -1033,6 +1033,55 @@ func UpdateVariable(init func(VariableFields, *testing.T) (influxdb.VariableServ
 				},
 			},
 		},
+		{
+			name: "updating fails when variable queries depend on each other",
+			fields: VariableFields{
+				TimeGenerator: fakeGenerator,
+				Variables: []*influxdb.Variable{
+					{
+						ID:             MustIDBase16(idA),
+						OrganizationID: platform.ID(7),
+						Name:           "region",
+						Arguments: &influxdb.VariableArguments{
+							Type:   "query",
+							Values: influxdb.VariableQueryValues{Query: `buckets()`, Language: "flux"},
+						},
+						CRUDLog: influxdb.CRUDLog{
+							CreatedAt: oldFakeDate,
+							UpdatedAt: fakeDate,
+						},
+					},
+					{
+						ID:             MustIDBase16(idB),
+						OrganizationID: platform.ID(7),
+						Name:           "host",
+						Arguments: &influxdb.VariableArguments{
+							Type:   "query",
+							Values: influxdb.VariableQueryValues{Query: `from(bucket: v.region)`, Language: "flux"},
+						},
+						CRUDLog: influxdb.CRUDLog{
+							CreatedAt: oldFakeDate,
+							UpdatedAt: fakeDate,
+						},
+					},
+				},
+			},
+			args: args{
+				id: MustIDBase16(idA),
+				update: &influxdb.VariableUpdate{
+					Arguments: &influxdb.VariableArguments{
+						Type:   "query",
+						Values: influxdb.VariableQueryValues{Query: `from(bucket: v.host)`, Language: "flux"},
+					},
+				},
+			},
+			wants: wants{
+				err: &errors.Error{
+					Code: errors.EInvalid,
+					Msg:  influxdb.ErrVariableDependencyCycle + ": region -> host -> region",
+				},
+			},
+		},
 		{
 			name: "trims the variable name but updating fails when variable name already exists",
 			fields: VariableFields{
@@ -0,0 +1,109 @@
+package influxdb
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+// TransferJobType is the type of a transfer job.
+type TransferJobType string
+
+const (
+	// TransferJobExport exports the data of a bucket.
+	TransferJobExport TransferJobType = "export"
+	// TransferJobImport imports exported data into a bucket.
+	TransferJobImport TransferJobType = "import"
+)
+
+// TransferJobStatus is the status of a transfer job.
+type TransferJobStatus string
+
+const (
+	TransferJobQueued    TransferJobStatus = "queued"
+	TransferJobRunning   TransferJobStatus = "running"
+	TransferJobSucceeded TransferJobStatus = "succeeded"
+	TransferJobFailed    TransferJobStatus = "failed"
+	TransferJobCanceled  TransferJobStatus = "canceled"
+)
+
+// Done returns whether a job with the status has finished running.
+func (s TransferJobStatus) Done() bool {
+	return s == TransferJobSucceeded || s == TransferJobFailed || s == TransferJobCanceled
+}
+
+// TransferJob is an export of the data of a bucket, as gzipped line protocol
+// kept by the server, or an import of such an export into a bucket, run in the
+// background.
+type TransferJob struct {
+	ID       platform.ID     `json:"id"`
+	Type     TransferJobType `json:"type"`
+	OrgID    platform.ID     `json:"orgID"`
+	BucketID platform.ID     `json:"bucketID"`
+	// Start, Stop and Predicate select the points of an export.
+	Start     *time.Time `json:"start,omitempty"`
+	Stop      *time.Time `json:"stop,omitempty"`
+	Predicate string     `json:"predicate,omitempty"`
+	// ExportID and URL are the export an import reads, either an export job
+	// of the server or the URL of the data of an export.
+	ExportID *platform.ID `json:"exportID,omitempty"`
+	URL      string       `json:"url,omitempty"`
+
+	Status TransferJobStatus `json:"status"`
+	// PercentComplete is the percentage of the time range exported, or of the
+	// export imported, so far.
+	PercentComplete float64 `json:"percentComplete"`
+	// Points are the points exported or imported so far, and Bytes the size
+	// of the gzipped line protocol written or read.
+	Points int64  `json:"points"`
+	Bytes  int64  `json:"bytes"`
+	Error  string `json:"error,omitempty"`
+
+	CreatedAt  time.Time  `json:"createdAt"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// ExportJobRequest is the export an export job is created to run.
+type ExportJobRequest struct {
+	OrgID    platform.ID
+	BucketID platform.ID
+	// Start and Stop are the inclusive time range of the export, in
+	// nanoseconds.
+	Start int64
+	Stop  int64
+	// Predicate is a predicate in the syntax of delete predicates the
+	// exported points match.
+	Predicate string
+}
+
+// ImportJobRequest is the import an import job is created to run.
+type ImportJobRequest struct {
+	OrgID    platform.ID
+	BucketID platform.ID
+	// ExportID is the export job of the server to import.
+	ExportID *platform.ID
+	// URL is the URL of the data of an export of another server to import,
+	// read with Token, when ExportID is not set.
+	URL   string
+	Token string
+}
+
+// TransferJobService runs the exports and imports of the data of buckets.
+type TransferJobService interface {
+	// CreateExportJob queues an export job.
+	CreateExportJob(ctx context.Context, req ExportJobRequest) (*TransferJob, error)
+	// CreateImportJob queues an import job.
+	CreateImportJob(ctx context.Context, req ImportJobRequest) (*TransferJob, error)
+	FindTransferJobByID(ctx context.Context, id platform.ID) (*TransferJob, error)
+	// FindTransferJobs returns the transfer jobs of an organization, most
+	// recent first.
+	FindTransferJobs(ctx context.Context, orgID platform.ID) ([]*TransferJob, error)
+	// CancelTransferJob cancels a queued or running transfer job. The points
+	// a running import has already written are not removed.
+	CancelTransferJob(ctx context.Context, id platform.ID) (*TransferJob, error)
+	// OpenExport returns the gzipped line protocol of a succeeded export job.
+	OpenExport(ctx context.Context, id platform.ID) (io.ReadCloser, error)
+}
@@ -0,0 +1,186 @@
+package transferjob
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strconv"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/pkg/escape"
+	"github.com/influxdata/influxdb/v2/predicate"
+	"github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// Exporter writes the points of a bucket as line protocol.
+type Exporter interface {
+	// Export writes the points in the inclusive time range [start, stop]
+	// matching the delete predicate pred to w, and returns their number.
+	Export(ctx context.Context, w io.Writer, orgID, bucketID platform.ID, start, stop int64, pred string) (points int64, err error)
+}
+
+var _ Exporter = (*StoreExporter)(nil)
+
+// StoreExporter exports the points read from a storage store.
+type StoreExporter struct {
+	store reads.Store
+}
+
+// NewStoreExporter constructs a StoreExporter.
+func NewStoreExporter(store reads.Store) *StoreExporter {
+	return &StoreExporter{store: store}
+}
+
+var (
+	measurementKey = []byte("_measurement")
+	fieldKey       = []byte("_field")
+)
+
+// Export reads the series matching the predicate and writes their points.
+func (e *StoreExporter) Export(ctx context.Context, w io.Writer, orgID, bucketID platform.ID, start, stop int64, pred string) (int64, error) {
+	src, err := anypb.New(e.store.GetSource(uint64(orgID), uint64(bucketID)))
+	if err != nil {
+		return 0, err
+	}
+
+	req := datatypes.ReadFilterRequest{
+		ReadSource: src,
+		// The range of a read is [start, end).
+		Range: &datatypes.TimestampRange{Start: start, End: stop + 1},
+	}
+	node, err := predicate.Parse(pred)
+	if err != nil {
+		return 0, err
+	}
+	if node != nil {
+		root, err := node.ToDataType()
+		if err != nil {
+			return 0, err
+		}
+		req.Predicate = &datatypes.Predicate{Root: root}
+	}
+
+	rs, err := e.store.ReadFilter(ctx, &req)
+	if err != nil {
+		return 0, err
+	}
+	if rs == nil {
+		return 0, nil
+	}
+	defer rs.Close()
+
+	var (
+		points int64
+		key    []byte
+	)
+	for rs.Next() {
+		cur := rs.Cursor()
+		if cur == nil {
+			continue
+		}
+		key = seriesLinePrefix(key[:0], rs.Tags())
+		n, err := writeCursor(w, key, cur)
+		points += n
+		if err != nil {
+			return points, err
+		}
+	}
+	return points, rs.Err()
+}
+
+// seriesLinePrefix appends the measurement, tags and field key of the series
+// with tags to dst, as the start of the lines of its points.
+func seriesLinePrefix(dst []byte, tags models.Tags) []byte {
+	var name, field []byte
+	series := make(models.Tags, 0, len(tags))
+	for _, t := range tags {
+		switch {
+		case bytes.Equal(t.Key, measurementKey):
+			name = t.Value
+		case bytes.Equal(t.Key, fieldKey):
+			field = t.Value
+		default:
+			series = append(series, t)
+		}
+	}
+	dst = models.AppendMakeKey(dst, name, series)
+	dst = append(dst, ' ')
+	dst = append(dst, escape.Bytes(field)...)
+	return append(dst, '=')
+}
+
+// writeCursor writes a line of the points of cur, each prefixed by key.
+func writeCursor(w io.Writer, key []byte, cur cursors.Cursor) (int64, error) {
+	defer cur.Close()
+
+	var (
+		n    int64
+		line []byte
+	)
+	write := func(ts int64) error {
+		line = append(line, ' ')
+		line = strconv.AppendInt(line, ts, 10)
+		line = append(line, '\n')
+		n++
+		_, err := w.Write(line)
+		return err
+	}
+	switch ccur := cur.(type) {
+	case cursors.IntegerArrayCursor:
+		for a := ccur.Next(); a.Len() > 0; a = ccur.Next() {
+			for i, ts := range a.Timestamps {
+				line = strconv.AppendInt(append(line[:0], key...), a.Values[i], 10)
+				line = append(line, 'i')
+				if err := write(ts); err != nil {
+					return n, err
+				}
+			}
+		}
+	case cursors.FloatArrayCursor:
+		for a := ccur.Next(); a.Len() > 0; a = ccur.Next() {
+			for i, ts := range a.Timestamps {
+				line = strconv.AppendFloat(append(line[:0], key...), a.Values[i], 'f', -1, 64)
+				if err := write(ts); err != nil {
+					return n, err
+				}
+			}
+		}
+	case cursors.UnsignedArrayCursor:
+		for a := ccur.Next(); a.Len() > 0; a = ccur.Next() {
+			for i, ts := range a.Timestamps {
+				line = strconv.AppendUint(append(line[:0], key...), a.Values[i], 10)
+				line = append(line, 'u')
+				if err := write(ts); err != nil {
+					return n, err
+				}
+			}
+		}
+	case cursors.BooleanArrayCursor:
+		for a := ccur.Next(); a.Len() > 0; a = ccur.Next() {
+			for i, ts := range a.Timestamps {
+				line = strconv.AppendBool(append(line[:0], key...), a.Values[i])
+				if err := write(ts); err != nil {
+					return n, err
+				}
+			}
+		}
+	case cursors.StringArrayCursor:
+		for a := ccur.Next(); a.Len() > 0; a = ccur.Next() {
+			for i, ts := range a.Timestamps {
+				line = append(append(line[:0], key...), '"')
+				line = append(line, models.EscapeStringField(a.Values[i])...)
+				line = append(line, '"')
+				if err := write(ts); err != nil {
+					return n, err
+				}
+			}
+		}
+	default:
+		panic("unreachable")
+	}
+	return n, cur.Err()
+}
@@ -0,0 +1,547 @@
+// Package transferjob runs the exports of the data of buckets, kept by the
+// server as gzipped line protocol, and the imports of such exports into other
+// buckets, of the server or of another one, in the background, as jobs whose
+// progress is tracked and which may be canceled. The data is moved from server
+// to server, without going through the machine of the client.
+package transferjob
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/snowflake"
+	"go.uber.org/zap"
+)
+
+const (
+	// slices is the number of slices the time range of an export is exported
+	// in. The progress of an export is the slices exported, and an export is
+	// canceled between slices.
+	slices = 100
+
+	// importBatchSize is the number of lines of an import written at a time.
+	importBatchSize = 5000
+
+	// jobRetention is how long finished jobs, and the data of exports, are
+	// kept.
+	jobRetention = 24 * time.Hour
+)
+
+// ErrTransferJobNotFound is returned when a transfer job does not exist.
+var ErrTransferJobNotFound = &errors.Error{
+	Code: errors.ENotFound,
+	Msg:  "transfer job not found",
+}
+
+var _ influxdb.TransferJobService = (*Service)(nil)
+
+// PointsWriter writes points to storage.
+type PointsWriter interface {
+	WritePoints(ctx context.Context, orgID platform.ID, bucketID platform.ID, points []models.Point) error
+}
+
+// URLValidator validates the URLs of the exports imported from other servers.
+type URLValidator interface {
+	Validate(*url.URL) error
+}
+
+type job struct {
+	influxdb.TransferJob
+	export influxdb.ExportJobRequest
+	imp    influxdb.ImportJobRequest
+	// cancel cancels the job while it runs.
+	cancel context.CancelFunc
+}
+
+// Service runs transfer jobs one at a time. The jobs are kept in memory, so
+// the jobs queued or running when the process stops are lost, and the data of
+// the exports which finished is removed when the process starts.
+type Service struct {
+	log       *zap.Logger
+	dir       string
+	exporter  Exporter
+	points    PointsWriter
+	validator URLValidator
+	client    *http.Client
+
+	IDGenerator   platform.IDGenerator
+	TimeGenerator influxdb.TimeGenerator
+
+	mu     sync.Mutex
+	jobs   map[platform.ID]*job
+	queue  []*job
+	notify chan struct{}
+}
+
+// NewService constructs a Service keeping the data of exports in dir. The
+// points of exports are read with exporter, those of imports written with
+// points, and the URLs of the exports of other servers checked by validator.
+func NewService(log *zap.Logger, dir string, exporter Exporter, points PointsWriter, validator URLValidator) *Service {
+	return &Service{
+		log:           log,
+		dir:           dir,
+		exporter:      exporter,
+		points:        points,
+		validator:     validator,
+		client:        &http.Client{},
+		IDGenerator:   snowflake.NewIDGenerator(),
+		TimeGenerator: influxdb.RealTimeGenerator{},
+		jobs:          make(map[platform.ID]*job),
+		notify:        make(chan struct{}, 1),
+	}
+}
+
+// Open removes the data of the exports of a previous process.
+func (s *Service) Open(ctx context.Context) error {
+	if err := os.RemoveAll(s.dir); err != nil {
+		return err
+	}
+	return os.MkdirAll(s.dir, 0700)
+}
+
+// CreateExportJob queues an export job.
+func (s *Service) CreateExportJob(ctx context.Context, req influxdb.ExportJobRequest) (*influxdb.TransferJob, error) {
+	if req.Start > req.Stop {
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "invalid time range, start time must not be after stop time",
+		}
+	}
+
+	start, stop := time.Unix(0, req.Start).UTC(), time.Unix(0, req.Stop).UTC()
+	return s.queueJob(&job{
+		TransferJob: influxdb.TransferJob{
+			Type:      influxdb.TransferJobExport,
+			OrgID:     req.OrgID,
+			BucketID:  req.BucketID,
+			Start:     &start,
+			Stop:      &stop,
+			Predicate: req.Predicate,
+		},
+		export: req,
+	}), nil
+}
+
+// CreateImportJob queues an import job.
+func (s *Service) CreateImportJob(ctx context.Context, req influxdb.ImportJobRequest) (*influxdb.TransferJob, error) {
+	switch {
+	case (req.ExportID == nil) == (req.URL == ""):
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "an import must have either an export ID or the URL of an export",
+		}
+	case req.ExportID != nil:
+		if _, err := s.FindTransferJobByID(ctx, *req.ExportID); err != nil {
+			return nil, err
+		}
+	default:
+		u, err := url.Parse(req.URL)
+		if err != nil {
+			return nil, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "invalid export URL",
+				Err:  err,
+			}
+		}
+		if err := s.validator.Validate(u); err != nil {
+			return nil, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "export URL is not allowed",
+				Err:  err,
+			}
+		}
+	}
+
+	return s.queueJob(&job{
+		TransferJob: influxdb.TransferJob{
+			Type:     influxdb.TransferJobImport,
+			OrgID:    req.OrgID,
+			BucketID: req.BucketID,
+			ExportID: req.ExportID,
+			URL:      req.URL,
+		},
+		imp: req,
+	}), nil
+}
+
+func (s *Service) queueJob(j *job) *influxdb.TransferJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.TimeGenerator.Now()
+	s.prune(now)
+
+	j.ID = s.IDGenerator.ID()
+	j.Status = influxdb.TransferJobQueued
+	j.CreatedAt = now
+	s.jobs[j.ID] = j
+	s.queue = append(s.queue, j)
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+
+	res := j.TransferJob
+	return &res
+}
+
+// FindTransferJobByID returns a transfer job.
+func (s *Service) FindTransferJobByID(ctx context.Context, id platform.ID) (*influxdb.TransferJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrTransferJobNotFound
+	}
+	res := j.TransferJob
+	return &res, nil
+}
+
+// FindTransferJobs returns the transfer jobs of an organization, most recent
+// first.
+func (s *Service) FindTransferJobs(ctx context.Context, orgID platform.ID) ([]*influxdb.TransferJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := []*influxdb.TransferJob{}
+	for _, j := range s.jobs {
+		if j.OrgID == orgID {
+			res := j.TransferJob
+			jobs = append(jobs, &res)
+		}
+	}
+	sort.Slice(jobs, func(i, k int) bool {
+		return jobs[i].CreatedAt.After(jobs[k].CreatedAt)
+	})
+	return jobs, nil
+}
+
+// CancelTransferJob cancels a queued or running transfer job. A running export
+// stops once the slice being exported is, and a running import once the batch
+// being written is.
+func (s *Service) CancelTransferJob(ctx context.Context, id platform.ID) (*influxdb.TransferJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrTransferJobNotFound
+	}
+	if j.Status.Done() {
+		return nil, &errors.Error{
+			Code: errors.EConflict,
+			Msg:  "transfer job has already finished",
+		}
+	}
+
+	if j.Status == influxdb.TransferJobQueued {
+		now := s.TimeGenerator.Now()
+		j.FinishedAt = &now
+	} else {
+		j.cancel()
+	}
+	j.Status = influxdb.TransferJobCanceled
+
+	res := j.TransferJob
+	return &res, nil
+}
+
+// OpenExport returns the gzipped line protocol of a succeeded export job.
+func (s *Service) OpenExport(ctx context.Context, id platform.ID) (io.ReadCloser, error) {
+	s.mu.Lock()
+	j, ok := s.jobs[id]
+	if !ok || j.Type != influxdb.TransferJobExport {
+		s.mu.Unlock()
+		return nil, ErrTransferJobNotFound
+	}
+	status := j.Status
+	s.mu.Unlock()
+
+	if status != influxdb.TransferJobSucceeded {
+		return nil, &errors.Error{
+			Code: errors.EConflict,
+			Msg:  fmt.Sprintf("export job is %s, not %s", status, influxdb.TransferJobSucceeded),
+		}
+	}
+	return os.Open(s.exportPath(id))
+}
+
+func (s *Service) exportPath(id platform.ID) string {
+	return filepath.Join(s.dir, id.String()+".lp.gz")
+}
+
+// Run runs the queued jobs until ctx is done.
+func (s *Service) Run(ctx context.Context) {
+	for {
+		s.mu.Lock()
+		var next *job
+		if len(s.queue) > 0 {
+			next, s.queue = s.queue[0], s.queue[1:]
+		}
+		s.mu.Unlock()
+
+		if next != nil {
+			s.run(ctx, next)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.notify:
+		}
+	}
+}
+
+func (s *Service) run(ctx context.Context, j *job) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s.mu.Lock()
+	if j.Status != influxdb.TransferJobQueued {
+		// The job was canceled while queued.
+		s.mu.Unlock()
+		return
+	}
+	now := s.TimeGenerator.Now()
+	j.Status = influxdb.TransferJobRunning
+	j.StartedAt = &now
+	j.cancel = cancel
+	s.mu.Unlock()
+
+	var err error
+	if j.Type == influxdb.TransferJobExport {
+		if err = s.exportData(ctx, j); err != nil {
+			os.Remove(s.exportPath(j.ID))
+		}
+	} else {
+		err = s.importData(ctx, j)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now = s.TimeGenerator.Now()
+	j.FinishedAt = &now
+	switch {
+	case j.Status == influxdb.TransferJobCanceled:
+		if j.Type == influxdb.TransferJobExport {
+			os.Remove(s.exportPath(j.ID))
+		}
+	case err != nil:
+		j.Status = influxdb.TransferJobFailed
+		j.Error = err.Error()
+	default:
+		j.Status = influxdb.TransferJobSucceeded
+	}
+	s.log.Info("Transfer job finished",
+		zap.Stringer("job_id", j.ID),
+		zap.String("type", string(j.Type)),
+		zap.Stringer("org_id", j.OrgID),
+		zap.Stringer("bucket_id", j.BucketID),
+		zap.String("status", string(j.Status)),
+		zap.Int64("points", j.Points),
+		zap.Error(err))
+}
+
+// exportData exports the time range of a job slice by slice.
+func (s *Service) exportData(ctx context.Context, j *job) error {
+	f, err := os.OpenFile(s.exportPath(j.ID), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	cw := &countingWriter{w: f}
+	gw := gzip.NewWriter(cw)
+
+	// The offsets are unsigned as the time range may be wider than an int64.
+	span := uint64(j.export.Stop) - uint64(j.export.Start)
+	step := span/slices + 1
+	for off := uint64(0); ; off += step {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		end := off + step - 1
+		if end > span || end < off {
+			end = span
+		}
+		start, stop := int64(uint64(j.export.Start)+off), int64(uint64(j.export.Start)+end)
+
+		points, err := s.exporter.Export(ctx, gw, j.OrgID, j.BucketID, start, stop, j.export.Predicate)
+		if err != nil {
+			return err
+		}
+
+		s.mu.Lock()
+		j.Points += points
+		j.Bytes = cw.n
+		j.PercentComplete = 100 * (float64(end) + 1) / (float64(span) + 1)
+		s.mu.Unlock()
+
+		if end == span {
+			break
+		}
+	}
+
+	if err := gw.Close(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	j.Bytes = cw.n
+	s.mu.Unlock()
+	return f.Sync()
+}
+
+// importData writes the lines of the export of a job in batches.
+func (s *Service) importData(ctx context.Context, j *job) error {
+	rc, size, err := s.openSource(ctx, j)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	cr := &countingReader{r: rc}
+	gr, err := gzip.NewReader(cr)
+	if err != nil {
+		return err
+	}
+	br := bufio.NewReader(gr)
+
+	var (
+		batch []byte
+		lines int
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		points, err := models.ParsePointsWithPrecision(batch, s.TimeGenerator.Now(), "ns")
+		if err != nil {
+			return err
+		}
+		if err := s.points.WritePoints(ctx, j.OrgID, j.BucketID, points); err != nil {
+			return err
+		}
+
+		s.mu.Lock()
+		j.Points += int64(len(points))
+		j.Bytes = cr.n
+		if size > 0 {
+			j.PercentComplete = 100 * float64(cr.n) / float64(size)
+		}
+		s.mu.Unlock()
+
+		batch, lines = batch[:0], 0
+		return ctx.Err()
+	}
+	for {
+		line, err := br.ReadSlice('\n')
+		batch = append(batch, line...)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if lines++; lines == importBatchSize || err == io.EOF {
+			if ferr := flush(); ferr != nil {
+				return ferr
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+
+	s.mu.Lock()
+	j.PercentComplete = 100
+	s.mu.Unlock()
+	return nil
+}
+
+// openSource opens the export an import job reads, and returns its size, or 0
+// when unknown.
+func (s *Service) openSource(ctx context.Context, j *job) (io.ReadCloser, int64, error) {
+	if j.imp.ExportID != nil {
+		rc, err := s.OpenExport(ctx, *j.imp.ExportID)
+		if err != nil {
+			return nil, 0, err
+		}
+		fi, err := rc.(*os.File).Stat()
+		if err != nil {
+			rc.Close()
+			return nil, 0, err
+		}
+		return rc, fi.Size(), nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.imp.URL, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if j.imp.Token != "" {
+		req.Header.Set("Authorization", "Token "+j.imp.Token)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, 0, fmt.Errorf("reading the export: %s", res.Status)
+	}
+	size := res.ContentLength
+	if size < 0 {
+		size = 0
+	}
+	return res.Body, size, nil
+}
+
+// prune removes the jobs which finished longer ago than the retention, with
+// the data of exports.
+func (s *Service) prune(now time.Time) {
+	for id, j := range s.jobs {
+		if j.FinishedAt != nil && now.Sub(*j.FinishedAt) > jobRetention {
+			delete(s.jobs, id)
+			if j.Type == influxdb.TransferJobExport {
+				os.Remove(s.exportPath(id))
+			}
+		}
+	}
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
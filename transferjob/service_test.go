@@ -0,0 +1,214 @@
+package transferjob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type exporterFunc func(w io.Writer, start, stop int64) (int64, error)
+
+func (f exporterFunc) Export(ctx context.Context, w io.Writer, orgID, bucketID platform.ID, start, stop int64, pred string) (int64, error) {
+	return f(w, start, stop)
+}
+
+// pointsExporter exports a point at the start of each slice.
+var pointsExporter = exporterFunc(func(w io.Writer, start, stop int64) (int64, error) {
+	_, err := fmt.Fprintf(w, "cpu,host=a value=%d %d\n", start, start)
+	return 1, err
+})
+
+// written records the points written.
+type written struct {
+	mu     sync.Mutex
+	points map[platform.ID][]string
+}
+
+func (w *written) WritePoints(ctx context.Context, orgID platform.ID, bucketID platform.ID, points []models.Point) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, p := range points {
+		w.points[bucketID] = append(w.points[bucketID], p.String())
+	}
+	return nil
+}
+
+type validatorFunc func(*url.URL) error
+
+func (f validatorFunc) Validate(u *url.URL) error {
+	return f(u)
+}
+
+func newTestService(t *testing.T, exporter Exporter, points PointsWriter) *Service {
+	svc := NewService(zaptest.NewLogger(t), t.TempDir(), exporter, points, validatorFunc(func(u *url.URL) error {
+		if u.Hostname() == "forbidden" {
+			return errors.New("forbidden")
+		}
+		return nil
+	}))
+	require.NoError(t, svc.Open(context.Background()))
+	svc.IDGenerator = mock.NewIncrementingIDGenerator(1)
+	svc.TimeGenerator = mock.TimeGenerator{FakeValue: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	return svc
+}
+
+func waitDone(t *testing.T, svc *Service, id platform.ID) *influxdb.TransferJob {
+	t.Helper()
+	var job *influxdb.TransferJob
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = svc.FindTransferJobByID(context.Background(), id)
+		require.NoError(t, err)
+		return job.Status.Done()
+	}, 5*time.Second, time.Millisecond)
+	return job
+}
+
+func TestService_ExportImport(t *testing.T) {
+	w := &written{points: map[platform.ID][]string{}}
+	svc := newTestService(t, pointsExporter, w)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go svc.Run(ctx)
+
+	export, err := svc.CreateExportJob(ctx, influxdb.ExportJobRequest{OrgID: 1, BucketID: 2, Start: 0, Stop: 9})
+	require.NoError(t, err)
+	require.Equal(t, influxdb.TransferJobQueued, export.Status)
+	export = waitDone(t, svc, export.ID)
+	require.Equal(t, influxdb.TransferJobSucceeded, export.Status, export.Error)
+	require.Equal(t, float64(100), export.PercentComplete)
+	require.Equal(t, int64(10), export.Points)
+	require.Positive(t, export.Bytes)
+
+	// The export is imported into another bucket of the server.
+	imp, err := svc.CreateImportJob(ctx, influxdb.ImportJobRequest{OrgID: 1, BucketID: 3, ExportID: &export.ID})
+	require.NoError(t, err)
+	imp = waitDone(t, svc, imp.ID)
+	require.Equal(t, influxdb.TransferJobSucceeded, imp.Status, imp.Error)
+	require.Equal(t, int64(10), imp.Points)
+	require.Equal(t, float64(100), imp.PercentComplete)
+	require.Len(t, w.points[3], 10)
+	require.Equal(t, "cpu,host=a value=0 0", w.points[3][0])
+
+	// And into a bucket of another server, from the URL of its data.
+	primary := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Token secret" {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		rc, err := svc.OpenExport(r.Context(), export.ID)
+		require.NoError(t, err)
+		defer rc.Close()
+		io.Copy(rw, rc)
+	}))
+	defer primary.Close()
+
+	imp, err = svc.CreateImportJob(ctx, influxdb.ImportJobRequest{OrgID: 1, BucketID: 4, URL: primary.URL, Token: "secret"})
+	require.NoError(t, err)
+	imp = waitDone(t, svc, imp.ID)
+	require.Equal(t, influxdb.TransferJobSucceeded, imp.Status, imp.Error)
+	require.Len(t, w.points[4], 10)
+
+	imp, err = svc.CreateImportJob(ctx, influxdb.ImportJobRequest{OrgID: 1, BucketID: 4, URL: primary.URL})
+	require.NoError(t, err)
+	imp = waitDone(t, svc, imp.ID)
+	require.Equal(t, influxdb.TransferJobFailed, imp.Status)
+	require.Contains(t, imp.Error, "401")
+}
+
+func TestService_CreateImportJob(t *testing.T) {
+	svc := newTestService(t, pointsExporter, &written{})
+	ctx := context.Background()
+
+	_, err := svc.CreateImportJob(ctx, influxdb.ImportJobRequest{OrgID: 1, BucketID: 2})
+	require.Error(t, err)
+	id := platform.ID(100)
+	_, err = svc.CreateImportJob(ctx, influxdb.ImportJobRequest{OrgID: 1, BucketID: 2, ExportID: &id, URL: "http://primary"})
+	require.Error(t, err)
+	_, err = svc.CreateImportJob(ctx, influxdb.ImportJobRequest{OrgID: 1, BucketID: 2, ExportID: &id})
+	require.Equal(t, ErrTransferJobNotFound, err)
+	_, err = svc.CreateImportJob(ctx, influxdb.ImportJobRequest{OrgID: 1, BucketID: 2, URL: "http://forbidden/data"})
+	require.Error(t, err)
+}
+
+func TestService_Failed(t *testing.T) {
+	svc := newTestService(t, exporterFunc(func(w io.Writer, start, stop int64) (int64, error) {
+		return 0, errors.New("disk full")
+	}), &written{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go svc.Run(ctx)
+
+	job, err := svc.CreateExportJob(ctx, influxdb.ExportJobRequest{OrgID: 1, BucketID: 2, Start: 0, Stop: 1000})
+	require.NoError(t, err)
+	job = waitDone(t, svc, job.ID)
+	require.Equal(t, influxdb.TransferJobFailed, job.Status)
+	require.Equal(t, "disk full", job.Error)
+
+	_, err = svc.OpenExport(ctx, job.ID)
+	require.Error(t, err)
+}
+
+func TestService_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	var slices int
+	svc := newTestService(t, exporterFunc(func(w io.Writer, start, stop int64) (int64, error) {
+		once.Do(func() { close(started) })
+		<-release
+		slices++
+		return pointsExporter(w, start, stop)
+	}), &written{})
+
+	running, err := svc.CreateExportJob(ctx, influxdb.ExportJobRequest{OrgID: 1, BucketID: 2, Start: 0, Stop: 1000})
+	require.NoError(t, err)
+	queued, err := svc.CreateExportJob(ctx, influxdb.ExportJobRequest{OrgID: 1, BucketID: 2, Start: 0, Stop: 1000})
+	require.NoError(t, err)
+
+	go svc.Run(ctx)
+	<-started
+
+	job, err := svc.CancelTransferJob(ctx, queued.ID)
+	require.NoError(t, err)
+	require.Equal(t, influxdb.TransferJobCanceled, job.Status)
+	require.NotNil(t, job.FinishedAt)
+
+	job, err = svc.CancelTransferJob(ctx, running.ID)
+	require.NoError(t, err)
+	require.Equal(t, influxdb.TransferJobCanceled, job.Status)
+	close(release)
+
+	job = waitDone(t, svc, running.ID)
+	require.Equal(t, influxdb.TransferJobCanceled, job.Status)
+	// The slice being exported when canceled is the last one.
+	require.Equal(t, 1, slices)
+	_, err = svc.OpenExport(ctx, running.ID)
+	require.Error(t, err)
+
+	_, err = svc.CancelTransferJob(ctx, running.ID)
+	require.Error(t, err)
+
+	jobs, err := svc.FindTransferJobs(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	jobs, err = svc.FindTransferJobs(ctx, 3)
+	require.NoError(t, err)
+	require.Empty(t, jobs)
+}
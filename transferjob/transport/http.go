@@ -0,0 +1,297 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/predicate"
+	"go.uber.org/zap"
+)
+
+const prefixTransfers = "/api/v2/transfers"
+
+// TransferJobHandler is the handler for the transfer job service.
+type TransferJobHandler struct {
+	chi.Router
+
+	api *kithttp.API
+	log *zap.Logger
+
+	transferJobService influxdb.TransferJobService
+	bucketService      influxdb.BucketService
+}
+
+// NewTransferJobHandler returns a new instance of TransferJobHandler.
+func NewTransferJobHandler(log *zap.Logger, transferJobService influxdb.TransferJobService, bucketService influxdb.BucketService) *TransferJobHandler {
+	h := &TransferJobHandler{
+		log:                log,
+		api:                kithttp.NewAPI(kithttp.WithLog(log)),
+		transferJobService: transferJobService,
+		bucketService:      bucketService,
+	}
+
+	r := chi.NewRouter()
+	r.Use(
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+	r.Route("/", func(r chi.Router) {
+		r.Get("/", h.handleGetTransferJobs)
+		r.Post("/export", h.handlePostExportJob)
+		r.Post("/import", h.handlePostImportJob)
+
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", h.handleGetTransferJob)
+			r.Post("/cancel", h.handleCancelTransferJob)
+			r.Get("/data", h.handleGetExportData)
+		})
+	})
+	h.Router = r
+	return h
+}
+
+// Prefix returns the mounting prefix for the handler.
+func (h *TransferJobHandler) Prefix() string {
+	return prefixTransfers
+}
+
+type transferJobsResponse struct {
+	Jobs []*influxdb.TransferJob `json:"jobs"`
+}
+
+type exportJobRequest struct {
+	BucketID  platform.ID `json:"bucketID"`
+	Start     time.Time   `json:"start"`
+	Stop      time.Time   `json:"stop"`
+	Predicate string      `json:"predicate"`
+}
+
+type importJobRequest struct {
+	BucketID platform.ID  `json:"bucketID"`
+	ExportID *platform.ID `json:"exportID"`
+	URL      string       `json:"url"`
+	Token    string       `json:"token"`
+}
+
+func decodeTransferJobID(r *http.Request) (platform.ID, error) {
+	var id platform.ID
+	if err := id.DecodeFromString(chi.URLParam(r, "id")); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// authorizeJob checks that the caller may see a job, by reading its bucket.
+func authorizeJob(ctx context.Context, j *influxdb.TransferJob) error {
+	_, _, err := authorizer.AuthorizeRead(ctx, influxdb.BucketsResourceType, j.BucketID, j.OrgID)
+	return err
+}
+
+// handleGetTransferJobs lists the transfer jobs of an organization whose
+// bucket the caller may read.
+func (h *TransferJobHandler) handleGetTransferJobs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	orgID, err := platform.IDFromString(r.URL.Query().Get("orgID"))
+	if err != nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "orgID is required",
+			Err:  err,
+		})
+		return
+	}
+
+	jobs, err := h.transferJobService.FindTransferJobs(ctx, *orgID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	res := transferJobsResponse{Jobs: make([]*influxdb.TransferJob, 0, len(jobs))}
+	for _, j := range jobs {
+		if authorizeJob(ctx, j) == nil {
+			res.Jobs = append(res.Jobs, j)
+		}
+	}
+	h.api.Respond(w, r, http.StatusOK, res)
+}
+
+// handlePostExportJob queues the export of the points of a bucket.
+func (h *TransferJobHandler) handlePostExportJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req exportJobRequest
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if req.Start.IsZero() || req.Stop.IsZero() {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "start and stop are required",
+		})
+		return
+	}
+	if _, err := predicate.Parse(req.Predicate); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	b, err := h.bucketService.FindBucketByID(ctx, req.BucketID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if _, _, err := authorizer.AuthorizeReadBucket(ctx, b.Type, b.ID, b.OrgID); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	j, err := h.transferJobService.CreateExportJob(ctx, influxdb.ExportJobRequest{
+		OrgID:     b.OrgID,
+		BucketID:  b.ID,
+		Start:     req.Start.UnixNano(),
+		Stop:      req.Stop.UnixNano(),
+		Predicate: req.Predicate,
+	})
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Export job queued", zap.Stringer("jobID", j.ID), zap.Stringer("bucketID", j.BucketID))
+	h.api.Respond(w, r, http.StatusAccepted, j)
+}
+
+// handlePostImportJob queues the import of an export into a bucket.
+func (h *TransferJobHandler) handlePostImportJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req importJobRequest
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	b, err := h.bucketService.FindBucketByID(ctx, req.BucketID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if _, _, err := authorizer.AuthorizeWrite(ctx, influxdb.BucketsResourceType, b.ID, b.OrgID); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	// The caller must be allowed to read the data of an export of the server.
+	if req.ExportID != nil {
+		export, err := h.transferJobService.FindTransferJobByID(ctx, *req.ExportID)
+		if err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+		if err := authorizeJob(ctx, export); err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+	}
+
+	j, err := h.transferJobService.CreateImportJob(ctx, influxdb.ImportJobRequest{
+		OrgID:    b.OrgID,
+		BucketID: b.ID,
+		ExportID: req.ExportID,
+		URL:      req.URL,
+		Token:    req.Token,
+	})
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Import job queued", zap.Stringer("jobID", j.ID), zap.Stringer("bucketID", j.BucketID))
+	h.api.Respond(w, r, http.StatusAccepted, j)
+}
+
+func (h *TransferJobHandler) findTransferJob(w http.ResponseWriter, r *http.Request) *influxdb.TransferJob {
+	id, err := decodeTransferJobID(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return nil
+	}
+	j, err := h.transferJobService.FindTransferJobByID(r.Context(), id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return nil
+	}
+	if err := authorizeJob(r.Context(), j); err != nil {
+		h.api.Err(w, r, err)
+		return nil
+	}
+	return j
+}
+
+// handleGetTransferJob returns a transfer job.
+func (h *TransferJobHandler) handleGetTransferJob(w http.ResponseWriter, r *http.Request) {
+	j := h.findTransferJob(w, r)
+	if j == nil {
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, j)
+}
+
+// handleCancelTransferJob cancels a queued or running transfer job. Canceling
+// an import requires writing to its bucket.
+func (h *TransferJobHandler) handleCancelTransferJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	j := h.findTransferJob(w, r)
+	if j == nil {
+		return
+	}
+	if j.Type == influxdb.TransferJobImport {
+		if _, _, err := authorizer.AuthorizeWrite(ctx, influxdb.BucketsResourceType, j.BucketID, j.OrgID); err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+	}
+
+	j, err := h.transferJobService.CancelTransferJob(ctx, j.ID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.api.Respond(w, r, http.StatusOK, j)
+}
+
+// handleGetExportData streams the gzipped line protocol of a succeeded export.
+func (h *TransferJobHandler) handleGetExportData(w http.ResponseWriter, r *http.Request) {
+	j := h.findTransferJob(w, r)
+	if j == nil {
+		return
+	}
+	rc, err := h.transferJobService.OpenExport(r.Context(), j.ID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	defer rc.Close()
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+j.ID.String()+`.lp.gz"`)
+	// The size lets the imports of other servers report their progress.
+	if f, ok := rc.(*os.File); ok {
+		if fi, err := f.Stat(); err == nil {
+			w.Header().Set("Content-Length", strconv.FormatInt(fi.Size(), 10))
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, rc); err != nil {
+		h.log.Debug("Failed to stream the data of an export", zap.Stringer("jobID", j.ID), zap.Error(err))
+	}
+}
@@ -0,0 +1,290 @@
+// Package usage exposes the usage of organizations as prometheus metrics, so
+// the usage of tenants can be graphed from /metrics.
+package usage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/metric"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/prom"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+const (
+	namespace = "influxdb"
+	subsystem = "org"
+
+	orgLabel = "org_id"
+
+	// OtherOrgs is the org_id label of the usage of the organizations which do
+	// not have a label of their own.
+	OtherOrgs = "other"
+)
+
+// Config configures the usage metrics of organizations.
+type Config struct {
+	// Enabled enables the usage metrics.
+	Enabled bool
+	// MaxOrgs bounds the number of organizations with a label of their own,
+	// which are the first organizations to be seen. Zero is no bound.
+	MaxOrgs int
+	// Orgs, when not empty, are the IDs of the only organizations with a label
+	// of their own, regardless of MaxOrgs.
+	Orgs []string
+	// Interval is how often the storage usage of organizations is measured.
+	Interval time.Duration
+}
+
+// PointsWriter writes points to storage.
+type PointsWriter interface {
+	WritePoints(ctx context.Context, orgID platform.ID, bucketID platform.ID, points []models.Point) error
+}
+
+// BucketFinder finds the buckets the storage usage is measured for.
+type BucketFinder interface {
+	FindBuckets(context.Context, influxdb.BucketFilter, ...influxdb.FindOptions) ([]*influxdb.Bucket, int, error)
+}
+
+// Storage measures the storage usage of buckets.
+type Storage interface {
+	SeriesCardinality(ctx context.Context, bucketID platform.ID) int64
+	BucketDiskSize(ctx context.Context, bucketID platform.ID) int64
+}
+
+var _ prom.PrometheusCollector = (*OrgMetrics)(nil)
+
+// OrgMetrics records the usage of organizations. Writes are recorded by the
+// points writer it wraps, queries by the query event recorder it wraps, and
+// the storage usage is measured every interval by Run.
+type OrgMetrics struct {
+	log *zap.Logger
+
+	buckets BucketFinder
+	storage Storage
+
+	labels *orgLabels
+
+	bytesWritten  *prometheus.CounterVec
+	pointsWritten *prometheus.CounterVec
+	queries       *prometheus.CounterVec
+	storageBytes  *prometheus.GaugeVec
+	cardinality   *prometheus.GaugeVec
+
+	mu       sync.Mutex
+	measured map[string]bool // the labels of the last measurement of the storage usage.
+}
+
+// NewOrgMetrics constructs the usage metrics of organizations.
+func NewOrgMetrics(log *zap.Logger, cfg Config, buckets BucketFinder, storage Storage) (*OrgMetrics, error) {
+	if cfg.Interval <= 0 {
+		return nil, fmt.Errorf("org metrics interval must be positive")
+	}
+	labels, err := newOrgLabels(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OrgMetrics{
+		log:     log,
+		buckets: buckets,
+		storage: storage,
+		labels:  labels,
+		bytesWritten: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "written_bytes_total",
+			Help:      "Bytes of line protocol written by organization",
+		}, []string{orgLabel}),
+		pointsWritten: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "written_points_total",
+			Help:      "Points written by organization",
+		}, []string{orgLabel}),
+		queries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "queries_total",
+			Help:      "Queries requested by organization",
+		}, []string{orgLabel}),
+		storageBytes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "storage_bytes",
+			Help:      "Bytes of the buckets of the organization on disk",
+		}, []string{orgLabel}),
+		cardinality: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "series_cardinality",
+			Help:      "Series of the buckets of the organization",
+		}, []string{orgLabel}),
+		measured: map[string]bool{},
+	}, nil
+}
+
+// PrometheusCollectors satisfies the prom.PrometheusCollector interface.
+func (m *OrgMetrics) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.bytesWritten,
+		m.pointsWritten,
+		m.queries,
+		m.storageBytes,
+		m.cardinality,
+	}
+}
+
+// PointsWriter returns pw recording the points it writes.
+func (m *OrgMetrics) PointsWriter(pw PointsWriter) PointsWriter {
+	return &pointsWriter{metrics: m, next: pw}
+}
+
+// QueryEventRecorder returns r recording the queries of its events.
+func (m *OrgMetrics) QueryEventRecorder(r metric.EventRecorder) metric.EventRecorder {
+	return &queryEventRecorder{metrics: m, next: r}
+}
+
+// Run measures the storage usage every interval until ctx is done.
+func (m *OrgMetrics) Run(ctx context.Context, interval time.Duration) {
+	m.Measure(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Measure(ctx)
+		}
+	}
+}
+
+// Measure measures the storage usage of the buckets of every organization.
+func (m *OrgMetrics) Measure(ctx context.Context) {
+	buckets, _, err := m.buckets.FindBuckets(ctx, influxdb.BucketFilter{})
+	if err != nil {
+		m.log.Warn("Failed to find buckets to measure the usage of", zap.Error(err))
+		return
+	}
+
+	bytes := map[string]float64{}
+	series := map[string]float64{}
+	for _, b := range buckets {
+		if ctx.Err() != nil {
+			return
+		}
+		l := m.labels.label(b.OrgID)
+		bytes[l] += float64(m.storage.BucketDiskSize(ctx, b.ID))
+		series[l] += float64(m.storage.SeriesCardinality(ctx, b.ID))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for l := range m.measured {
+		if _, ok := bytes[l]; !ok {
+			m.storageBytes.DeleteLabelValues(l)
+			m.cardinality.DeleteLabelValues(l)
+		}
+	}
+	m.measured = map[string]bool{}
+	for l, n := range bytes {
+		m.storageBytes.WithLabelValues(l).Set(n)
+		m.cardinality.WithLabelValues(l).Set(series[l])
+		m.measured[l] = true
+	}
+}
+
+type pointsWriter struct {
+	metrics *OrgMetrics
+	next    PointsWriter
+}
+
+func (w *pointsWriter) WritePoints(ctx context.Context, orgID platform.ID, bucketID platform.ID, points []models.Point) error {
+	if err := w.next.WritePoints(ctx, orgID, bucketID, points); err != nil {
+		return err
+	}
+
+	var n int
+	for _, p := range points {
+		n += p.StringSize()
+	}
+	l := w.metrics.labels.label(orgID)
+	w.metrics.bytesWritten.WithLabelValues(l).Add(float64(n))
+	w.metrics.pointsWritten.WithLabelValues(l).Add(float64(len(points)))
+	return nil
+}
+
+type queryEventRecorder struct {
+	metrics *OrgMetrics
+	next    metric.EventRecorder
+}
+
+func (r *queryEventRecorder) Record(ctx context.Context, e metric.Event) {
+	r.metrics.queries.WithLabelValues(r.metrics.labels.label(e.OrgID)).Inc()
+	r.next.Record(ctx, e)
+}
+
+// PrometheusCollectors returns the collectors of the wrapped recorder, so they
+// remain registered.
+func (r *queryEventRecorder) PrometheusCollectors() []prometheus.Collector {
+	if pc, ok := r.next.(prom.PrometheusCollector); ok {
+		return pc.PrometheusCollectors()
+	}
+	return nil
+}
+
+// orgLabels bounds the org_id label values of the metrics. The organizations
+// without a label of their own share the OtherOrgs label.
+type orgLabels struct {
+	mu      sync.Mutex
+	allowed map[platform.ID]bool
+	max     int
+	seen    map[platform.ID]string
+}
+
+func newOrgLabels(cfg Config) (*orgLabels, error) {
+	l := &orgLabels{
+		max:  cfg.MaxOrgs,
+		seen: map[platform.ID]string{},
+	}
+	if len(cfg.Orgs) > 0 {
+		l.allowed = map[platform.ID]bool{}
+		for _, s := range cfg.Orgs {
+			id, err := platform.IDFromString(s)
+			if err != nil {
+				return nil, err
+			}
+			l.allowed[*id] = true
+		}
+	}
+	return l, nil
+}
+
+func (l *orgLabels) label(id platform.ID) string {
+	if l.allowed != nil {
+		if l.allowed[id] {
+			return id.String()
+		}
+		return OtherOrgs
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if s, ok := l.seen[id]; ok {
+		return s
+	}
+	if l.max > 0 && len(l.seen) >= l.max {
+		return OtherOrgs
+	}
+	s := id.String()
+	l.seen[id] = s
+	return s
+}
@@ -0,0 +1,135 @@
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/metric"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/prom"
+	"github.com/influxdata/influxdb/v2/kit/prom/promtest"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+const (
+	org1 platform.ID = 1
+	org2 platform.ID = 2
+	org3 platform.ID = 3
+)
+
+type fakeBuckets []*influxdb.Bucket
+
+func (b fakeBuckets) FindBuckets(context.Context, influxdb.BucketFilter, ...influxdb.FindOptions) ([]*influxdb.Bucket, int, error) {
+	return b, len(b), nil
+}
+
+type fakeStorage map[platform.ID]int64
+
+func (s fakeStorage) SeriesCardinality(_ context.Context, id platform.ID) int64 { return s[id] }
+func (s fakeStorage) BucketDiskSize(_ context.Context, id platform.ID) int64    { return 10 * s[id] }
+
+type nopPointsWriter struct{}
+
+func (nopPointsWriter) WritePoints(context.Context, platform.ID, platform.ID, []models.Point) error {
+	return nil
+}
+
+func newTestOrgMetrics(t *testing.T, cfg Config, buckets fakeBuckets, storage fakeStorage) (*OrgMetrics, *prom.Registry) {
+	t.Helper()
+	cfg.Interval = time.Minute
+	m, err := NewOrgMetrics(zaptest.NewLogger(t), cfg, buckets, storage)
+	require.NoError(t, err)
+	reg := prom.NewRegistry(zaptest.NewLogger(t))
+	reg.MustRegister(m.PrometheusCollectors()...)
+	return m, reg
+}
+
+func value(t *testing.T, reg *prom.Registry, name string, org string) float64 {
+	t.Helper()
+	mfs := promtest.MustGather(t, reg)
+	m := promtest.MustFindMetric(t, mfs, name, map[string]string{orgLabel: org})
+	if m.Counter != nil {
+		return m.Counter.GetValue()
+	}
+	return m.Gauge.GetValue()
+}
+
+func TestOrgMetrics_Writes(t *testing.T) {
+	m, reg := newTestOrgMetrics(t, Config{}, nil, nil)
+	pw := m.PointsWriter(nopPointsWriter{})
+
+	points, err := models.ParsePointsString("cpu,host=a value=1 1\ncpu,host=b value=2 1")
+	require.NoError(t, err)
+	require.NoError(t, pw.WritePoints(context.Background(), org1, 10, points))
+
+	assert.Equal(t, float64(2), value(t, reg, "influxdb_org_written_points_total", org1.String()))
+	assert.Equal(t, float64(points[0].StringSize()+points[1].StringSize()), value(t, reg, "influxdb_org_written_bytes_total", org1.String()))
+}
+
+func TestOrgMetrics_Queries(t *testing.T) {
+	m, reg := newTestOrgMetrics(t, Config{}, nil, nil)
+	r := m.QueryEventRecorder(&metric.NopEventRecorder{})
+
+	r.Record(context.Background(), metric.Event{OrgID: org1})
+	r.Record(context.Background(), metric.Event{OrgID: org1})
+	r.Record(context.Background(), metric.Event{OrgID: org2})
+
+	assert.Equal(t, float64(2), value(t, reg, "influxdb_org_queries_total", org1.String()))
+	assert.Equal(t, float64(1), value(t, reg, "influxdb_org_queries_total", org2.String()))
+}
+
+func TestOrgMetrics_Measure(t *testing.T) {
+	buckets := fakeBuckets{
+		{ID: 10, OrgID: org1},
+		{ID: 11, OrgID: org1},
+		{ID: 20, OrgID: org2},
+	}
+	m, reg := newTestOrgMetrics(t, Config{}, buckets, fakeStorage{10: 1, 11: 2, 20: 5})
+
+	m.Measure(context.Background())
+	assert.Equal(t, float64(3), value(t, reg, "influxdb_org_series_cardinality", org1.String()))
+	assert.Equal(t, float64(30), value(t, reg, "influxdb_org_storage_bytes", org1.String()))
+	assert.Equal(t, float64(5), value(t, reg, "influxdb_org_series_cardinality", org2.String()))
+
+	// the usage of organizations without buckets is not reported anymore.
+	m.buckets = buckets[:2]
+	m.Measure(context.Background())
+	mfs := promtest.MustGather(t, reg)
+	assert.Nil(t, promtest.FindMetric(mfs, "influxdb_org_series_cardinality", map[string]string{orgLabel: org2.String()}))
+}
+
+func TestOrgMetrics_BoundedLabels(t *testing.T) {
+	t.Run("max orgs", func(t *testing.T) {
+		m, reg := newTestOrgMetrics(t, Config{MaxOrgs: 2}, nil, nil)
+		r := m.QueryEventRecorder(&metric.NopEventRecorder{})
+		for _, id := range []platform.ID{org1, org2, org3, 4, org1} {
+			r.Record(context.Background(), metric.Event{OrgID: id})
+		}
+
+		assert.Equal(t, float64(2), value(t, reg, "influxdb_org_queries_total", org1.String()))
+		assert.Equal(t, float64(1), value(t, reg, "influxdb_org_queries_total", org2.String()))
+		assert.Equal(t, float64(2), value(t, reg, "influxdb_org_queries_total", OtherOrgs))
+	})
+
+	t.Run("allowed orgs", func(t *testing.T) {
+		m, reg := newTestOrgMetrics(t, Config{MaxOrgs: 1, Orgs: []string{org2.String(), org3.String()}}, nil, nil)
+		r := m.QueryEventRecorder(&metric.NopEventRecorder{})
+		for _, id := range []platform.ID{org1, org2, org3} {
+			r.Record(context.Background(), metric.Event{OrgID: id})
+		}
+
+		assert.Equal(t, float64(1), value(t, reg, "influxdb_org_queries_total", org2.String()))
+		assert.Equal(t, float64(1), value(t, reg, "influxdb_org_queries_total", org3.String()))
+		assert.Equal(t, float64(1), value(t, reg, "influxdb_org_queries_total", OtherOrgs))
+	})
+
+	t.Run("invalid allowed org", func(t *testing.T) {
+		_, err := NewOrgMetrics(zaptest.NewLogger(t), Config{Interval: time.Minute, Orgs: []string{"nope"}}, nil, nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,169 @@
+package authorization
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+// AuditAction is the kind of change an audit record describes.
+type AuditAction string
+
+const (
+	AuditCreate      AuditAction = "create"
+	AuditUpdate      AuditAction = "update"
+	AuditDelete      AuditAction = "delete"
+	AuditRotateToken AuditAction = "rotate_token"
+	AuditSetPassword AuditAction = "set_password"
+	AuditRevoke      AuditAction = "revoke"
+)
+
+// AuditRecord describes a change of an authorization or of its password.
+// Each record holds the hash of the previous one, so that changing or
+// removing a record breaks the chain checked by VerifyAuditLog.
+type AuditRecord struct {
+	Seq    uint64      `json:"seq"`
+	Time   time.Time   `json:"time"`
+	Action AuditAction `json:"action"`
+	AuthID platform.ID `json:"authID"`
+	// ActorID is the user who made the change, if it was made on the
+	// behalf of a user.
+	ActorID *platform.ID `json:"actorID,omitempty"`
+	// Before and After are the authorization before and after the change,
+	// without their token.
+	Before *influxdb.Authorization `json:"before,omitempty"`
+	After  *influxdb.Authorization `json:"after,omitempty"`
+
+	PrevHash string `json:"prevHash"`
+	Hash     string `json:"hash"`
+}
+
+func (r *AuditRecord) computeHash() (string, error) {
+	c := *r
+	c.Hash = ""
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", &errors.Error{
+			Code: errors.EInternal,
+			Err:  err,
+		}
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// AuditFilter represents a set of filters that restrict the returned audit records.
+type AuditFilter struct {
+	AuthID  *platform.ID
+	ActorID *platform.ID
+	Since   *time.Time
+}
+
+func (f AuditFilter) match(r *AuditRecord) bool {
+	if f.AuthID != nil && *f.AuthID != r.AuthID {
+		return false
+	}
+	if f.ActorID != nil && (r.ActorID == nil || *f.ActorID != *r.ActorID) {
+		return false
+	}
+	if f.Since != nil && r.Time.Before(*f.Since) {
+		return false
+	}
+	return true
+}
+
+// auditSnapshot returns a copy of an authorization fit for the audit log.
+func auditSnapshot(a *influxdb.Authorization) *influxdb.Authorization {
+	if a == nil {
+		return nil
+	}
+	c := *a
+	c.Token = ""
+	return &c
+}
+
+// audit appends a record of a change to the audit log within the
+// transaction of the change.
+func (s *Service) audit(ctx context.Context, tx kv.Tx, action AuditAction, authID platform.ID, before, after *influxdb.Authorization) error {
+	r := &AuditRecord{
+		Time:   time.Now().UTC(),
+		Action: action,
+		AuthID: authID,
+		Before: auditSnapshot(before),
+		After:  auditSnapshot(after),
+	}
+	if userID, err := icontext.GetUserID(ctx); err == nil && userID.Valid() {
+		r.ActorID = &userID
+	}
+	return s.store.AppendAuditRecord(ctx, tx, r)
+}
+
+// FindAuditRecords returns the audit records matching a filter, oldest
+// first, paged by the offset, limit and descending of the first FindOptions
+// if any.
+func (s *Service) FindAuditRecords(ctx context.Context, filter AuditFilter, opt ...influxdb.FindOptions) ([]*AuditRecord, error) {
+	var o influxdb.FindOptions
+	if len(opt) > 0 {
+		o = opt[0]
+	}
+
+	rs := []*AuditRecord{}
+	err := s.store.View(ctx, func(tx kv.Tx) error {
+		offset := o.Offset
+		return s.store.forEachAuditRecord(ctx, tx, o.Descending, func(r *AuditRecord) bool {
+			if !filter.match(r) {
+				return true
+			}
+			if offset > 0 {
+				offset--
+				return true
+			}
+			rs = append(rs, r)
+			return o.Limit <= 0 || len(rs) < o.Limit
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// VerifyAuditLog returns an error if a record of the audit log was changed
+// or removed since it was appended.
+func (s *Service) VerifyAuditLog(ctx context.Context) error {
+	return s.store.View(ctx, func(tx kv.Tx) error {
+		var (
+			seq      uint64
+			prevHash string
+			err      error
+		)
+		iterErr := s.store.forEachAuditRecord(ctx, tx, false, func(r *AuditRecord) bool {
+			var hash string
+			if hash, err = r.computeHash(); err != nil {
+				return false
+			}
+			if r.Seq != seq+1 || r.PrevHash != prevHash || r.Hash != hash {
+				err = &errors.Error{
+					Code: errors.EInternal,
+					Msg:  fmt.Sprintf("audit log does not match its hash chain at record %d", seq+1),
+				}
+				return false
+			}
+			seq, prevHash = r.Seq, r.Hash
+			return true
+		})
+		if iterErr != nil {
+			return iterErr
+		}
+		return err
+	})
+}
@@ -0,0 +1,165 @@
+package authorization
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultAuthCacheTTL is the default time an authorization stays in the cache
+// of the tokens found by a Service.
+const DefaultAuthCacheTTL = time.Minute
+
+// WithAuthCache caches up to size of the authorizations found by token for
+// ttl, evicting the least recently used ones. The authorizations are dropped
+// from the cache when they change. A size of zero caches nothing.
+func WithAuthCache(size int, ttl time.Duration) func(*Service) {
+	return func(s *Service) {
+		if size <= 0 {
+			s.cache = nil
+			return
+		}
+		s.cache = newAuthCache(size, ttl)
+	}
+}
+
+type authCacheEntry struct {
+	token   string
+	auth    *influxdb.Authorization
+	expires time.Time
+}
+
+// authCache is a read-through LRU cache of the authorizations found by token.
+type authCache struct {
+	size int
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	lru     *list.List
+	byToken map[string]*list.Element
+	byID    map[platform.ID]*list.Element
+	// gen counts the removals, so that an authorization read before a
+	// change is not cached after it.
+	gen uint64
+
+	hits   prometheus.Counter
+	misses prometheus.Counter
+}
+
+func newAuthCache(size int, ttl time.Duration) *authCache {
+	return &authCache{
+		size:    size,
+		ttl:     ttl,
+		lru:     list.New(),
+		byToken: make(map[string]*list.Element),
+		byID:    make(map[platform.ID]*list.Element),
+		hits: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "v1_authorization",
+			Subsystem: "cache",
+			Name:      "hits_total",
+			Help:      "Number of v1 tokens found in the authorization cache",
+		}),
+		misses: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "v1_authorization",
+			Subsystem: "cache",
+			Name:      "misses_total",
+			Help:      "Number of v1 tokens looked up in the store rather than the authorization cache",
+		}),
+	}
+}
+
+// get returns a copy of the authorization of token cached and not expired
+// by now. On a miss, it returns the generation to add the authorization read
+// from the store with.
+func (c *authCache) get(token string, now time.Time) (*influxdb.Authorization, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.byToken[token]
+	if ok && now.After(e.Value.(*authCacheEntry).expires) {
+		c.removeElement(e)
+		ok = false
+	}
+	if !ok {
+		c.misses.Inc()
+		return nil, c.gen, false
+	}
+	c.hits.Inc()
+	c.lru.MoveToFront(e)
+	return copyAuthorization(e.Value.(*authCacheEntry).auth), c.gen, true
+}
+
+// add caches a copy of the authorization of token, evicting the least
+// recently used authorization once the cache is full. Nothing is cached if
+// an authorization was removed since the generation gen.
+func (c *authCache) add(token string, a *influxdb.Authorization, now time.Time, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.gen {
+		return
+	}
+
+	if e, ok := c.byID[a.ID]; ok {
+		c.removeElement(e)
+	}
+	if e, ok := c.byToken[token]; ok {
+		c.removeElement(e)
+	}
+	c.byID[a.ID] = c.lru.PushFront(&authCacheEntry{token: token, auth: copyAuthorization(a), expires: now.Add(c.ttl)})
+	c.byToken[token] = c.byID[a.ID]
+	for c.lru.Len() > c.size {
+		c.removeElement(c.lru.Back())
+	}
+}
+
+// remove drops the authorization id from the cache.
+func (c *authCache) remove(id platform.ID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	if e, ok := c.byID[id]; ok {
+		c.removeElement(e)
+	}
+}
+
+func (c *authCache) removeElement(e *list.Element) {
+	entry := c.lru.Remove(e).(*authCacheEntry)
+	delete(c.byToken, entry.token)
+	delete(c.byID, entry.auth.ID)
+}
+
+// copyAuthorization copies an authorization, so that the cached one is not
+// changed by the callers.
+func copyAuthorization(a *influxdb.Authorization) *influxdb.Authorization {
+	c := *a
+	c.Permissions = append([]influxdb.Permission(nil), a.Permissions...)
+	if a.Labels != nil {
+		c.Labels = make(map[string]string, len(a.Labels))
+		for k, v := range a.Labels {
+			c.Labels[k] = v
+		}
+	}
+	return &c
+}
+
+// invalidate drops an authorization which changed from the cache.
+func (s *Service) invalidate(id platform.ID) {
+	if s.cache != nil {
+		s.cache.remove(id)
+	}
+}
+
+// PrometheusCollectors returns the metrics of the authorization cache, if
+// the Service has one.
+func (s *Service) PrometheusCollectors() []prometheus.Collector {
+	if s.cache == nil {
+		return nil
+	}
+	return []prometheus.Collector{s.cache.hits, s.cache.misses}
+}
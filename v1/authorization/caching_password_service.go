@@ -57,6 +57,10 @@ func (c *CachingPasswordsService) ComparePassword(ctx context.Context, id platfo
 
 	err := c.inner.ComparePassword(ctx, id, password)
 	if err != nil {
+		// a failed attempt may lock the password, which the cache must not bypass
+		c.mu.Lock()
+		delete(c.authCache, id)
+		c.mu.Unlock()
 		return err
 	}
 
@@ -79,6 +83,15 @@ func (c *CachingPasswordsService) CompareAndSetPassword(ctx context.Context, id
 	return err
 }
 
+// Invalidate drops the cached password of an authorization, so that the next
+// comparison is made by the inner service. It is meant to be subscribed to
+// Service.OnRevoke.
+func (c *CachingPasswordsService) Invalidate(id platform.ID) {
+	c.mu.Lock()
+	delete(c.authCache, id)
+	c.mu.Unlock()
+}
+
 // NOTE(sgc): This caching implementation was lifted from the 1.x source
 //   https://github.com/influxdata/influxdb/blob/c1e11e732e145fc1a356535ddf3dcb9fb732a22b/services/meta/client.go#L390-L406
 
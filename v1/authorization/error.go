@@ -25,6 +25,25 @@ var (
 		Msg:  "ID already exists",
 	}
 
+	// ErrAuthExpired is used when the authorization of a token has expired
+	ErrAuthExpired = &errors.Error{
+		Code: errors.EForbidden,
+		Msg:  "authorization has expired",
+	}
+
+	// ErrAuthRevoked is used when the authorization of a token has been revoked
+	ErrAuthRevoked = &errors.Error{
+		Code: errors.EForbidden,
+		Msg:  "authorization has been revoked",
+	}
+
+	// ErrPasswordLocked is used when the password of an authorization is locked
+	// after too many failed attempts
+	ErrPasswordLocked = &errors.Error{
+		Code: errors.ETooManyRequests,
+		Msg:  "too many failed password attempts, try again later",
+	}
+
 	// ErrFailureGeneratingID occurs ony when the random number generator
 	// cannot generate an ID in MaxIDGenerationN times.
 	ErrFailureGeneratingID = &errors.Error{
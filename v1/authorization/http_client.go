@@ -3,6 +3,7 @@ package authorization
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/platform"
@@ -54,6 +55,12 @@ func (s *Client) FindAuthorizations(ctx context.Context, filter influxdb.Authori
 	if filter.Org != nil {
 		params = append(params, [2]string{"org", *filter.Org})
 	}
+	if filter.UsedBefore != nil {
+		params = append(params, [2]string{"usedBefore", filter.UsedBefore.Format(time.RFC3339)})
+	}
+	for _, l := range formatLabels(filter.Labels) {
+		params = append(params, [2]string{"label", l})
+	}
 
 	var as authsResponse
 	err := s.Client.
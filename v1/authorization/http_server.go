@@ -24,28 +24,43 @@ type TenantService interface {
 	FindUserByID(ctx context.Context, id platform.ID) (*influxdb.User, error)
 	FindUser(ctx context.Context, filter influxdb.UserFilter) (*influxdb.User, error)
 	FindBucketByID(ctx context.Context, id platform.ID) (*influxdb.Bucket, error)
+	FindBucket(ctx context.Context, filter influxdb.BucketFilter) (*influxdb.Bucket, error)
 }
 
 type PasswordService interface {
 	SetPassword(ctx context.Context, id platform.ID, password string) error
 }
 
+// TokenRotator replaces the token of an authorization.
+type TokenRotator interface {
+	RotateAuthorizationToken(ctx context.Context, id platform.ID) (*influxdb.Authorization, error)
+}
+
+// AuditLog finds the records of the audit log of authorizations.
+type AuditLog interface {
+	FindAuditRecords(ctx context.Context, filter AuditFilter, opt ...influxdb.FindOptions) ([]*AuditRecord, error)
+}
+
 type AuthHandler struct {
 	chi.Router
 	api           *kithttp.API
 	log           *zap.Logger
 	authSvc       influxdb.AuthorizationService
 	passwordSvc   PasswordService
+	tokenRotator  TokenRotator
+	auditLog      AuditLog
 	tenantService TenantService
 }
 
 // NewHTTPAuthHandler constructs a new http server.
-func NewHTTPAuthHandler(log *zap.Logger, authService influxdb.AuthorizationService, passwordService PasswordService, tenantService TenantService) *AuthHandler {
+func NewHTTPAuthHandler(log *zap.Logger, authService influxdb.AuthorizationService, passwordService PasswordService, tokenRotator TokenRotator, auditLog AuditLog, tenantService TenantService) *AuthHandler {
 	h := &AuthHandler{
 		api:           kithttp.NewAPI(kithttp.WithLog(log)),
 		log:           log,
 		authSvc:       authService,
 		passwordSvc:   passwordService,
+		tokenRotator:  tokenRotator,
+		auditLog:      auditLog,
 		tenantService: tenantService,
 	}
 
@@ -59,12 +74,14 @@ func NewHTTPAuthHandler(log *zap.Logger, authService influxdb.AuthorizationServi
 	r.Route("/", func(r chi.Router) {
 		r.Post("/", h.handlePostAuthorization)
 		r.Get("/", h.handleGetAuthorizations)
+		r.Get("/audit", h.handleGetAuditRecords)
 
 		r.Route("/{id}", func(r chi.Router) {
 			r.Get("/", h.handleGetAuthorization)
 			r.Patch("/", h.handleUpdateAuthorization)
 			r.Delete("/", h.handleDeleteAuthorization)
 			r.Post("/password", h.handlePostUserPassword)
+			r.Post("/rotate", h.handleRotateAuthorizationToken)
 		})
 	})
 
@@ -140,6 +157,8 @@ type postAuthorizationRequest struct {
 	UserID      *platform.ID          `json:"userID,omitempty"`
 	Description string                `json:"description"`
 	Permissions []influxdb.Permission `json:"permissions"`
+	ExpiresAt   *time.Time            `json:"expiresAt,omitempty"`
+	Labels      map[string]string     `json:"labels,omitempty"`
 }
 
 type authResponse struct {
@@ -153,6 +172,9 @@ type authResponse struct {
 	User        string               `json:"user"`
 	Permissions []permissionResponse `json:"permissions"`
 	Links       map[string]string    `json:"links"`
+	ExpiresAt   *time.Time           `json:"expiresAt,omitempty"`
+	LastUsedAt  *time.Time           `json:"lastUsedAt,omitempty"`
+	Labels      map[string]string    `json:"labels,omitempty"`
 	CreatedAt   time.Time            `json:"createdAt"`
 	UpdatedAt   time.Time            `json:"updatedAt"`
 }
@@ -185,8 +207,11 @@ func (h *AuthHandler) newAuthResponse(ctx context.Context, a *influxdb.Authoriza
 			"self": fmt.Sprintf(prefixAuthorization+"/%s", a.ID),
 			"user": fmt.Sprintf("/api/v2/users/%s", a.UserID),
 		},
-		CreatedAt: a.CreatedAt,
-		UpdatedAt: a.UpdatedAt,
+		ExpiresAt:  a.ExpiresAt,
+		LastUsedAt: a.LastUsedAt,
+		Labels:     a.Labels,
+		CreatedAt:  a.CreatedAt,
+		UpdatedAt:  a.UpdatedAt,
 	}
 
 	return res, nil
@@ -200,6 +225,8 @@ func (p *postAuthorizationRequest) toInfluxdb(userID platform.ID) *influxdb.Auth
 		Description: p.Description,
 		Permissions: p.Permissions,
 		UserID:      userID,
+		ExpiresAt:   p.ExpiresAt,
+		Labels:      p.Labels,
 	}
 
 	return t
@@ -213,6 +240,9 @@ func (a *authResponse) toInfluxdb() *influxdb.Authorization {
 		Description: a.Description,
 		OrgID:       a.OrgID,
 		UserID:      a.UserID,
+		ExpiresAt:   a.ExpiresAt,
+		LastUsedAt:  a.LastUsedAt,
+		Labels:      a.Labels,
 		CRUDLog: influxdb.CRUDLog{
 			CreatedAt: a.CreatedAt,
 			UpdatedAt: a.UpdatedAt,
@@ -246,6 +276,8 @@ func newPostAuthorizationRequest(a *influxdb.Authorization) (*postAuthorizationR
 		Permissions: a.Permissions,
 		Token:       a.Token,
 		Status:      a.Status,
+		ExpiresAt:   a.ExpiresAt,
+		Labels:      a.Labels,
 	}
 
 	if a.UserID.Valid() {
@@ -509,6 +541,25 @@ func decodeGetAuthorizationsRequest(ctx context.Context, r *http.Request) (*getA
 		req.filter.Token = &token
 	}
 
+	usedBefore := qp.Get("usedBefore")
+	if usedBefore != "" {
+		t, err := time.Parse(time.RFC3339, usedBefore)
+		if err != nil {
+			return nil, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "usedBefore must be an RFC3339 time",
+				Err:  err,
+			}
+		}
+		req.filter.UsedBefore = &t
+	}
+
+	labels, err := ParseLabels(qp["label"])
+	if err != nil {
+		return nil, err
+	}
+	req.filter.Labels = labels
+
 	return req, nil
 }
 
@@ -626,6 +677,104 @@ func (h *AuthHandler) handleDeleteAuthorization(w http.ResponseWriter, r *http.R
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// handleRotateAuthorizationToken is the HTTP handler for the POST prefixAuthorization/:id/rotate route.
+// The response holds the new token of the authorization.
+func (h *AuthHandler) handleRotateAuthorizationToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.log.Info("Failed to decode request", zap.String("handler", "rotateAuthorizationToken"), zap.Error(err))
+		h.api.Err(w, r, err)
+		return
+	}
+
+	a, err := h.tokenRotator.RotateAuthorizationToken(ctx, *id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	ps, err := h.newPermissionsResponse(ctx, a.Permissions)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Auth token rotated", zap.String("authID", fmt.Sprint(id)))
+
+	resp, err := h.newAuthResponse(ctx, a, ps)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusOK, resp)
+}
+
+// handleGetAuditRecords is the HTTP handler for the GET prefixAuthorization/audit route.
+func (h *AuthHandler) handleGetAuditRecords(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	filter, err := decodeGetAuditRecordsRequest(r)
+	if err != nil {
+		h.log.Info("Failed to decode request", zap.String("handler", "getAuditRecords"), zap.Error(err))
+		h.api.Err(w, r, err)
+		return
+	}
+
+	opts, err := influxdb.DecodeFindOptions(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	rs, err := h.auditLog.FindAuditRecords(ctx, filter, *opts)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusOK, getAuditRecordsResponse{Records: rs})
+}
+
+type getAuditRecordsResponse struct {
+	Records []*AuditRecord `json:"records"`
+}
+
+func decodeGetAuditRecordsRequest(r *http.Request) (AuditFilter, error) {
+	qp := r.URL.Query()
+
+	var filter AuditFilter
+	if authID := qp.Get("authID"); authID != "" {
+		id, err := platform.IDFromString(authID)
+		if err != nil {
+			return filter, err
+		}
+		filter.AuthID = id
+	}
+
+	if actorID := qp.Get("actorID"); actorID != "" {
+		id, err := platform.IDFromString(actorID)
+		if err != nil {
+			return filter, err
+		}
+		filter.ActorID = id
+	}
+
+	if since := qp.Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return filter, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "since must be an RFC3339 time",
+				Err:  err,
+			}
+		}
+		filter.Since = &t
+	}
+
+	return filter, nil
+}
+
 // password APIs
 
 type passwordSetRequest struct {
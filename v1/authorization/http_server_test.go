@@ -21,6 +21,7 @@ import (
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/mock"
 	itesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap/zaptest"
 )
 
@@ -193,7 +194,7 @@ func TestService_handlePostAuthorization(t *testing.T) {
 
 			svc := NewService(storage, tt.fields.TenantService)
 
-			handler := NewHTTPAuthHandler(zaptest.NewLogger(t), svc, nil, tt.fields.TenantService)
+			handler := NewHTTPAuthHandler(zaptest.NewLogger(t), svc, nil, nil, nil, tt.fields.TenantService)
 			router := chi.NewRouter()
 			router.Mount(handler.Prefix(), handler)
 
@@ -398,7 +399,7 @@ func TestService_handleGetAuthorization(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Helper()
 
-			handler := NewHTTPAuthHandler(zaptest.NewLogger(t), tt.fields.AuthorizationService, nil, tt.fields.TenantService)
+			handler := NewHTTPAuthHandler(zaptest.NewLogger(t), tt.fields.AuthorizationService, nil, nil, nil, tt.fields.TenantService)
 			router := chi.NewRouter()
 			router.Mount(handler.Prefix(), handler)
 
@@ -733,7 +734,7 @@ func TestService_handleGetAuthorizations(t *testing.T) {
 
 			svc := NewService(storage, tt.fields.TenantService)
 
-			handler := NewHTTPAuthHandler(zaptest.NewLogger(t), svc, nil, tt.fields.TenantService)
+			handler := NewHTTPAuthHandler(zaptest.NewLogger(t), svc, nil, nil, nil, tt.fields.TenantService)
 			router := chi.NewRouter()
 			router.Mount(handler.Prefix(), handler)
 
@@ -839,7 +840,7 @@ func TestService_handleDeleteAuthorization(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Helper()
 
-			handler := NewHTTPAuthHandler(zaptest.NewLogger(t), tt.fields.AuthorizationService, nil, tt.fields.TenantService)
+			handler := NewHTTPAuthHandler(zaptest.NewLogger(t), tt.fields.AuthorizationService, nil, nil, nil, tt.fields.TenantService)
 			router := chi.NewRouter()
 			router.Mount(handler.Prefix(), handler)
 
@@ -945,3 +946,49 @@ func MustMarshal(o interface{}) []byte {
 	b, _ := json.Marshal(o)
 	return b
 }
+
+func TestService_handleGetAuditRecords(t *testing.T) {
+	ctx := icontext.SetAuthorizer(context.Background(), &influxdb.Authorization{UserID: testUserID})
+	svc := newTestService(t)
+
+	a := newTestAuthorization("token")
+	require.NoError(t, svc.CreateAuthorization(ctx, a))
+	desc := "updated"
+	_, err := svc.UpdateAuthorization(ctx, a.ID, &influxdb.AuthorizationUpdate{Description: &desc})
+	require.NoError(t, err)
+	require.NoError(t, svc.CreateAuthorization(ctx, newTestAuthorization("other")))
+
+	handler := NewHTTPAuthHandler(zaptest.NewLogger(t), svc, nil, nil, NewAuthedAuditLog(svc), &tenantService{})
+	get := func(t *testing.T, a influxdb.Authorizer, query string) *http.Response {
+		t.Helper()
+		r := httptest.NewRequest("GET", "http://any.url/audit?"+query, nil)
+		r = r.WithContext(icontext.SetAuthorizer(r.Context(), a))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Result()
+	}
+	operator := &influxdb.Authorization{Status: influxdb.Active, Permissions: influxdb.OperPermissions()}
+
+	t.Run("operators find the records of an authorization", func(t *testing.T) {
+		res := get(t, operator, "authID="+a.ID.String()+"&descending=true&limit=1")
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var body getAuditRecordsResponse
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+		require.Len(t, body.Records, 1)
+		require.Equal(t, AuditUpdate, body.Records[0].Action)
+		require.Equal(t, a.ID, body.Records[0].AuthID)
+		require.Equal(t, testUserID, *body.Records[0].ActorID)
+		require.Empty(t, body.Records[0].After.Token)
+	})
+
+	t.Run("other users are refused", func(t *testing.T) {
+		res := get(t, newTestAuthorization("token"), "")
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("since must be an RFC3339 time", func(t *testing.T) {
+		res := get(t, operator, "since=yesterday")
+		require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+}
@@ -0,0 +1,40 @@
+package index
+
+import (
+	"encoding/json"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+// ByUserIDIndexMapping is the mapping description of an index
+// between a user and its v1 authorizations
+var ByUserIDIndexMapping = kv.NewIndexMapping(
+	[]byte("legacy/authorizationsv1"),
+	[]byte("legacy/authorizationsbyuserindexv1"),
+	func(v []byte) ([]byte, error) {
+		var a influxdb.Authorization
+		if err := json.Unmarshal(v, &a); err != nil {
+			return nil, err
+		}
+
+		id, _ := a.UserID.Encode()
+		return id, nil
+	},
+)
+
+// ByOrgIDIndexMapping is the mapping description of an index
+// between an organization and its v1 authorizations
+var ByOrgIDIndexMapping = kv.NewIndexMapping(
+	[]byte("legacy/authorizationsv1"),
+	[]byte("legacy/authorizationsbyorgindexv1"),
+	func(v []byte) ([]byte, error) {
+		var a influxdb.Authorization
+		if err := json.Unmarshal(v, &a); err != nil {
+			return nil, err
+		}
+
+		id, _ := a.OrgID.Encode()
+		return id, nil
+	},
+)
@@ -0,0 +1,54 @@
+package authorization
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// validateLabelKey returns an error if k cannot be the key of a label, which
+// must be written as key=value in filters.
+func validateLabelKey(k string) error {
+	if k == "" || strings.Contains(k, "=") {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("invalid authorization label key %q: must be non-empty and must not contain '='", k),
+		}
+	}
+	return nil
+}
+
+// ParseLabels parses labels written as key=value into a filter of
+// authorizations by label.
+func ParseLabels(ss []string) (map[string]string, error) {
+	if len(ss) == 0 {
+		return nil, nil
+	}
+	labels := make(map[string]string, len(ss))
+	for _, s := range ss {
+		k, v, ok := strings.Cut(s, "=")
+		if !ok {
+			return nil, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("invalid authorization label %q: must be key=value", s),
+			}
+		}
+		if err := validateLabelKey(k); err != nil {
+			return nil, err
+		}
+		labels[k] = v
+	}
+	return labels, nil
+}
+
+// formatLabels writes labels as sorted key=value, the inverse of ParseLabels.
+func formatLabels(labels map[string]string) []string {
+	ss := make([]string, 0, len(labels))
+	for k, v := range labels {
+		ss = append(ss, k+"="+v)
+	}
+	sort.Strings(ss)
+	return ss
+}
@@ -0,0 +1,111 @@
+package authorization
+
+import (
+	"context"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+	"go.uber.org/zap"
+)
+
+// DefaultLastUsedFlushInterval is the default interval of a LastUsedFlusher.
+const DefaultLastUsedFlushInterval = 10 * time.Second
+
+// markUsed records that the token of an authorization was found at t. Uses
+// are only stored by FlushLastUsed, rather than with a write per request.
+func (s *Service) markUsed(id platform.ID, t time.Time) {
+	s.lastUsedMu.Lock()
+	defer s.lastUsedMu.Unlock()
+	if prev, ok := s.lastUsed[id]; !ok || t.After(prev) {
+		s.lastUsed[id] = t
+	}
+}
+
+// FlushLastUsed stores the LastUsedAt of the authorizations used since the
+// last flush. The uses of authorizations deleted meanwhile are dropped.
+func (s *Service) FlushLastUsed(ctx context.Context) error {
+	s.lastUsedMu.Lock()
+	used := s.lastUsed
+	s.lastUsed = make(map[platform.ID]time.Time)
+	s.lastUsedMu.Unlock()
+
+	if len(used) == 0 {
+		return nil
+	}
+
+	var flushed []platform.ID
+	err := s.store.Update(ctx, func(tx kv.Tx) error {
+		flushed = flushed[:0]
+		for id, t := range used {
+			a, err := s.store.GetAuthorizationByID(ctx, tx, id)
+			if errors.ErrorCode(err) == errors.ENotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if a.LastUsedAt != nil && !t.After(*a.LastUsedAt) {
+				continue
+			}
+			a.LastUsedAt = &t
+			if _, err := s.store.UpdateAuthorization(ctx, tx, id, a); err != nil {
+				return err
+			}
+			flushed = append(flushed, id)
+		}
+		return nil
+	})
+	if err != nil {
+		// keep the uses to store them with the next flush
+		for id, t := range used {
+			s.markUsed(id, t)
+		}
+		return err
+	}
+	for _, id := range flushed {
+		s.invalidate(id)
+	}
+	return nil
+}
+
+// LastUsedFlusher periodically stores the LastUsedAt of the authorizations
+// of a Service.
+type LastUsedFlusher struct {
+	svc      *Service
+	log      *zap.Logger
+	interval time.Duration
+}
+
+// NewLastUsedFlusher constructs a LastUsedFlusher flushing svc every
+// interval.
+func NewLastUsedFlusher(log *zap.Logger, svc *Service, interval time.Duration) *LastUsedFlusher {
+	return &LastUsedFlusher{
+		svc:      svc,
+		log:      log,
+		interval: interval,
+	}
+}
+
+// Run flushes the uses of authorizations until ctx is done. The uses since
+// the last flush are left to the FlushLastUsed of shutdown.
+func (f *LastUsedFlusher) Run(ctx context.Context) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			f.flush(ctx)
+		}
+	}
+}
+
+func (f *LastUsedFlusher) flush(ctx context.Context) {
+	if err := f.svc.FlushLastUsed(ctx); err != nil {
+		f.log.Error("Failed to store the last use of authorizations", zap.Error(err))
+	}
+}
@@ -0,0 +1,81 @@
+package authorization
+
+import (
+	"context"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+// DefaultLockoutWindow is the default time an authorization is locked for
+// after too many failed password attempts.
+const DefaultLockoutWindow = 15 * time.Minute
+
+// WithLockout locks the password of an authorization for window after
+// threshold consecutive failed attempts to compare it. A threshold of zero
+// never locks passwords.
+func WithLockout(threshold int, window time.Duration) func(*Service) {
+	return func(s *Service) {
+		s.lockoutThreshold = threshold
+		s.lockoutWindow = window
+	}
+}
+
+// comparePasswordWithLockout compares the password of an authorization,
+// unless it is locked, and counts the consecutive failures to lock it. Each
+// attempt is counted as a failure before the password is compared, so that
+// concurrent attempts cannot get past the threshold, and the failures are
+// cleared when it matches. The comparison runs outside of any transaction.
+func (s *Service) comparePasswordWithLockout(ctx context.Context, authID platform.ID, password string) error {
+	if s.lockoutThreshold <= 0 {
+		return s.comparePasswordNoStrengthCheck(ctx, authID, password)
+	}
+
+	now := time.Now()
+	var hash []byte
+	if err := s.store.Update(ctx, func(tx kv.Tx) error {
+		l, err := s.store.GetLockout(ctx, tx, authID)
+		if err != nil {
+			return err
+		}
+		if now.Before(l.LockedUntil) {
+			return ErrPasswordLocked
+		}
+
+		hash, err = s.passwordHash(ctx, tx, authID)
+		if err != nil && err != errors.EIncorrectPassword {
+			return err
+		}
+		l.Failures++
+		if l.Failures >= s.lockoutThreshold {
+			l.Failures, l.LockedUntil = 0, now.Add(s.lockoutWindow)
+		}
+		return s.store.PutLockout(ctx, tx, authID, l)
+	}); err != nil {
+		return err
+	}
+
+	if hash == nil {
+		// the authorization has no password.
+		return errors.EIncorrectPassword
+	}
+	if err := comparePasswordHash(hash, password); err != nil {
+		return err
+	}
+	return s.store.Update(ctx, func(tx kv.Tx) error {
+		return s.store.DeleteLockout(ctx, tx, authID)
+	})
+}
+
+// UnlockPassword clears the failed password attempts of an authorization,
+// unlocking it.
+func (s *Service) UnlockPassword(ctx context.Context, authID platform.ID) error {
+	return s.store.Update(ctx, func(tx kv.Tx) error {
+		if _, err := s.store.GetAuthorizationByID(ctx, tx, authID); err != nil {
+			return ErrAuthNotFound
+		}
+		return s.store.DeleteLockout(ctx, tx, authID)
+	})
+}
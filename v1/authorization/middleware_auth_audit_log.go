@@ -0,0 +1,27 @@
+package authorization
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+)
+
+// AuthedAuditLog is middleware for authorizing requests to the inner AuditLog.
+type AuthedAuditLog struct {
+	inner AuditLog
+}
+
+// NewAuthedAuditLog wraps an existing AuditLog with authorization middleware.
+func NewAuthedAuditLog(inner AuditLog) *AuthedAuditLog {
+	return &AuthedAuditLog{inner: inner}
+}
+
+// FindAuditRecords returns the audit records matching a filter, when the
+// caller is an operator.
+func (s *AuthedAuditLog) FindAuditRecords(ctx context.Context, filter AuditFilter, opt ...influxdb.FindOptions) ([]*AuditRecord, error) {
+	if err := authorizer.IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return nil, err
+	}
+	return s.inner.FindAuditRecords(ctx, filter, opt...)
+}
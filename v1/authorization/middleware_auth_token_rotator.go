@@ -0,0 +1,35 @@
+package authorization
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+// AuthedTokenRotator is middleware for authorizing requests to the inner TokenRotator.
+type AuthedTokenRotator struct {
+	auth  AuthFinder
+	inner TokenRotator
+}
+
+// NewAuthedTokenRotator wraps an existing TokenRotator with authorization middleware.
+func NewAuthedTokenRotator(auth AuthFinder, inner TokenRotator) *AuthedTokenRotator {
+	return &AuthedTokenRotator{auth: auth, inner: inner}
+}
+
+// RotateAuthorizationToken replaces the token of an authorization the caller may write.
+func (s *AuthedTokenRotator) RotateAuthorizationToken(ctx context.Context, id platform.ID) (*influxdb.Authorization, error) {
+	a, err := s.auth.FindAuthorizationByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizer.AuthorizeWrite(ctx, influxdb.AuthorizationsResourceType, a.ID, a.OrgID); err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizer.AuthorizeWriteResource(ctx, influxdb.UsersResourceType, a.UserID); err != nil {
+		return nil, err
+	}
+	return s.inner.RotateAuthorizationToken(ctx, id)
+}
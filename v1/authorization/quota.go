@@ -0,0 +1,98 @@
+package authorization
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+// TokenQuota caps the number of v1 authorizations of an organization.
+type TokenQuota struct {
+	// MaxTokens is the maximum number of authorizations. 0 is unlimited.
+	MaxTokens int `json:"maxTokens"`
+}
+
+// Valid returns an error if the quota is invalid.
+func (q TokenQuota) Valid() error {
+	if q.MaxTokens < 0 {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "token quota must not be negative",
+		}
+	}
+	return nil
+}
+
+// ErrTokenQuotaExceeded is used when creating authorizations would exceed the
+// token quota of their organization.
+func ErrTokenQuotaExceeded(orgID platform.ID, max int) *errors.Error {
+	return &errors.Error{
+		Code: errors.EForbidden,
+		Msg:  fmt.Sprintf("organization %s has reached its quota of %d tokens", orgID, max),
+	}
+}
+
+// WithTokenQuota caps the number of authorizations of the organizations
+// without a quota of their own to max. A max of zero is unlimited.
+func WithTokenQuota(max int) func(*Service) {
+	return func(s *Service) {
+		s.tokenQuota = TokenQuota{MaxTokens: max}
+	}
+}
+
+// checkTokenQuota returns an error if creating n authorizations in an
+// organization would exceed its quota, or else the default quota.
+func (s *Service) checkTokenQuota(ctx context.Context, tx kv.Tx, orgID platform.ID, n int) error {
+	q, err := s.store.GetTokenQuota(ctx, tx, orgID)
+	if err != nil {
+		return err
+	}
+	if q == nil {
+		q = &s.tokenQuota
+	}
+	if q.MaxTokens == 0 {
+		return nil
+	}
+
+	count, err := s.store.CountAuthorizationsByOrg(ctx, tx, orgID)
+	if err != nil {
+		return err
+	}
+	if count+n > q.MaxTokens {
+		return ErrTokenQuotaExceeded(orgID, q.MaxTokens)
+	}
+	return nil
+}
+
+// FindOrgTokenQuota returns the token quota of an organization, or nil if it
+// has none and the default quota applies.
+func (s *Service) FindOrgTokenQuota(ctx context.Context, orgID platform.ID) (*TokenQuota, error) {
+	var q *TokenQuota
+	err := s.store.View(ctx, func(tx kv.Tx) (err error) {
+		q, err = s.store.GetTokenQuota(ctx, tx, orgID)
+		return err
+	})
+	return q, err
+}
+
+// PutOrgTokenQuota sets the token quota of an organization, overriding the
+// default quota. Existing authorizations beyond the quota are kept.
+func (s *Service) PutOrgTokenQuota(ctx context.Context, orgID platform.ID, q TokenQuota) error {
+	if err := q.Valid(); err != nil {
+		return err
+	}
+	return s.store.Update(ctx, func(tx kv.Tx) error {
+		return s.store.PutTokenQuota(ctx, tx, orgID, q)
+	})
+}
+
+// DeleteOrgTokenQuota removes the token quota of an organization, so that the
+// default quota applies.
+func (s *Service) DeleteOrgTokenQuota(ctx context.Context, orgID platform.ID) error {
+	return s.store.Update(ctx, func(tx kv.Tx) error {
+		return s.store.DeleteTokenQuota(ctx, tx, orgID)
+	})
+}
@@ -0,0 +1,87 @@
+package authorization
+
+import (
+	"context"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+// Revocation is the tombstone of a revoked authorization. A revoked
+// authorization is inactive and can never be activated again.
+type Revocation struct {
+	AuthID    platform.ID `json:"authID"`
+	Reason    string      `json:"reason,omitempty"`
+	RevokedAt time.Time   `json:"revokedAt"`
+}
+
+// OnRevoke registers fn to be called with the ID of every authorization the
+// Service revokes, once the revocation is stored. Caches of authorizations
+// subscribe so that a revocation takes effect immediately.
+func (s *Service) OnRevoke(fn func(id platform.ID)) {
+	s.revokeMu.Lock()
+	defer s.revokeMu.Unlock()
+	s.onRevoke = append(s.onRevoke, fn)
+}
+
+func (s *Service) notifyRevoked(id platform.ID) {
+	s.revokeMu.RLock()
+	defer s.revokeMu.RUnlock()
+	for _, fn := range s.onRevoke {
+		fn(id)
+	}
+}
+
+// RevokeAuthorization revokes an authorization for reason: it is marked
+// inactive and a tombstone keeps its token from being found again. Revoking
+// an authorization twice keeps the first revocation.
+func (s *Service) RevokeAuthorization(ctx context.Context, id platform.ID, reason string) error {
+	err := s.store.Update(ctx, func(tx kv.Tx) error {
+		a, err := s.store.GetAuthorizationByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if r, err := s.store.GetRevocation(ctx, tx, id); err != nil {
+			return err
+		} else if r != nil {
+			return nil
+		}
+
+		now := time.Now()
+		if err := s.store.PutRevocation(ctx, tx, &Revocation{
+			AuthID:    id,
+			Reason:    reason,
+			RevokedAt: now.UTC(),
+		}); err != nil {
+			return err
+		}
+
+		before := *a
+		a.Status = influxdb.Inactive
+		a.SetUpdatedAt(now)
+		if _, err := s.store.UpdateAuthorization(ctx, tx, id, a); err != nil {
+			return err
+		}
+		return s.audit(ctx, tx, AuditRevoke, id, &before, a)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.invalidate(id)
+	s.notifyRevoked(id)
+	return nil
+}
+
+// FindRevocation returns the revocation of an authorization, or nil if it was
+// never revoked.
+func (s *Service) FindRevocation(ctx context.Context, id platform.ID) (*Revocation, error) {
+	var r *Revocation
+	err := s.store.View(ctx, func(tx kv.Tx) (err error) {
+		r, err = s.store.GetRevocation(ctx, tx, id)
+		return err
+	})
+	return r, err
+}
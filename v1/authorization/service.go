@@ -2,12 +2,16 @@ package authorization
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
 	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/rand"
+	"github.com/influxdata/influxdb/v2/tenant"
 )
 
 var (
@@ -16,16 +20,33 @@ var (
 )
 
 type Service struct {
-	store           *Store
-	tenantService   TenantService
-	strongPasswords bool
+	store          *Store
+	tenantService  TenantService
+	passwordPolicy tenant.PasswordPolicy
+	tokenGenerator influxdb.TokenGenerator
+	hashTokens     bool
+	cache          *authCache
+
+	lockoutThreshold int
+	lockoutWindow    time.Duration
+
+	tokenQuota TokenQuota
+
+	lastUsedMu sync.Mutex
+	lastUsed   map[platform.ID]time.Time
+
+	revokeMu sync.RWMutex
+	onRevoke []func(platform.ID)
 }
 
 // NewService constructs a new Service.
 func NewService(st *Store, ts TenantService, OptFns ...func(*Service)) *Service {
 	svc := &Service{
-		store:         st,
-		tenantService: ts,
+		store:          st,
+		tenantService:  ts,
+		passwordPolicy: tenant.DefaultPasswordPolicy(),
+		tokenGenerator: rand.NewTokenGenerator(64),
+		lastUsed:       make(map[platform.ID]time.Time),
 	}
 	for _, fn := range OptFns {
 		fn(svc)
@@ -33,13 +54,130 @@ func NewService(st *Store, ts TenantService, OptFns ...func(*Service)) *Service
 	return svc
 }
 
+// WithPasswordChecking sets the strong password policy if strong is set, and
+// the default one otherwise.
 func WithPasswordChecking(strong bool) func(*Service) {
+	if strong {
+		return WithPasswordPolicy(tenant.StrongPasswordPolicy())
+	}
+	return WithPasswordPolicy(tenant.DefaultPasswordPolicy())
+}
+
+// WithPasswordPolicy sets the policy the passwords of v1 authorizations follow.
+func WithPasswordPolicy(policy tenant.PasswordPolicy) func(*Service) {
 	return func(s *Service) {
-		s.strongPasswords = strong
+		s.passwordPolicy = policy
 	}
 }
 
 func (s *Service) CreateAuthorization(ctx context.Context, a *influxdb.Authorization) error {
+	now := time.Now()
+	if err := s.validateCreate(ctx, a, now); err != nil {
+		return err
+	}
+
+	err := s.store.View(ctx, func(tx kv.Tx) error {
+		return s.uniqueToken(ctx, tx, a.Token)
+	})
+	if err != nil {
+		return ErrTokenAlreadyExistsError
+	}
+
+	a.SetCreatedAt(now)
+	a.SetUpdatedAt(now)
+
+	stored := s.toStored(a)
+	err = s.store.Update(ctx, func(tx kv.Tx) error {
+		if err := s.checkTokenQuota(ctx, tx, stored.OrgID, 1); err != nil {
+			return err
+		}
+		if err := s.store.CreateAuthorization(ctx, tx, stored); err != nil {
+			return err
+		}
+		if err := s.keepUsername(ctx, tx, stored.ID, a.Token); err != nil {
+			return err
+		}
+		return s.audit(ctx, tx, AuditCreate, stored.ID, nil, stored)
+	})
+	a.ID = stored.ID
+	return err
+}
+
+// CreateAuthorizations creates authorizations in a single transaction, so
+// either all or none of them are created.
+func (s *Service) CreateAuthorizations(ctx context.Context, as []*influxdb.Authorization) error {
+	now := time.Now()
+	tokens := make(map[string]struct{}, len(as))
+	byOrg := make(map[platform.ID]int)
+	for _, a := range as {
+		if err := s.validateCreate(ctx, a, now); err != nil {
+			return err
+		}
+		if _, ok := tokens[a.Token]; ok {
+			return ErrTokenAlreadyExistsError
+		}
+		tokens[a.Token] = struct{}{}
+		byOrg[a.OrgID]++
+	}
+
+	for _, a := range as {
+		a.SetCreatedAt(now)
+		a.SetUpdatedAt(now)
+	}
+
+	return s.store.Update(ctx, func(tx kv.Tx) error {
+		for orgID, n := range byOrg {
+			if err := s.checkTokenQuota(ctx, tx, orgID, n); err != nil {
+				return err
+			}
+		}
+		for _, a := range as {
+			if err := s.uniqueToken(ctx, tx, a.Token); err != nil {
+				return err
+			}
+			stored := s.toStored(a)
+			if err := s.store.CreateAuthorization(ctx, tx, stored); err != nil {
+				return err
+			}
+			if err := s.keepUsername(ctx, tx, stored.ID, a.Token); err != nil {
+				return err
+			}
+			if err := s.audit(ctx, tx, AuditCreate, stored.ID, nil, stored); err != nil {
+				return err
+			}
+			a.ID = stored.ID
+		}
+		return nil
+	})
+}
+
+// CreateScopedAuthorization creates an authorization with the permissions of
+// scopes applied to its organization, in addition to its own permissions.
+func (s *Service) CreateScopedAuthorization(ctx context.Context, a *influxdb.Authorization, scopes ...influxdb.AuthorizationScope) error {
+	if len(scopes) == 0 {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "at least one authorization scope is required",
+		}
+	}
+
+	ps, err := influxdb.ScopePermissions(a.OrgID, scopes, func(name string) (platform.ID, error) {
+		b, err := s.tenantService.FindBucket(ctx, influxdb.BucketFilter{OrganizationID: &a.OrgID, Name: &name})
+		if err != nil {
+			return 0, err
+		}
+		return b.ID, nil
+	})
+	if err != nil {
+		return err
+	}
+	a.Permissions = append(a.Permissions, ps...)
+
+	return s.CreateAuthorization(ctx, a)
+}
+
+// validateCreate returns an error if the authorization cannot be created.
+func (s *Service) validateCreate(ctx context.Context, a *influxdb.Authorization, now time.Time) error {
 	if err := a.Valid(); err != nil {
 		return &errors.Error{
 			Err: err,
@@ -50,6 +188,19 @@ func (s *Service) CreateAuthorization(ctx context.Context, a *influxdb.Authoriza
 		return influxdb.ErrUnableToCreateToken
 	}
 
+	if a.IsExpired(now) {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "authorization expiration must be in the future",
+		}
+	}
+
+	for k := range a.Labels {
+		if err := validateLabelKey(k); err != nil {
+			return err
+		}
+	}
+
 	if _, err := s.tenantService.FindUserByID(ctx, a.UserID); err != nil {
 		return influxdb.ErrUnableToCreateToken
 	}
@@ -58,23 +209,7 @@ func (s *Service) CreateAuthorization(ctx context.Context, a *influxdb.Authoriza
 		return influxdb.ErrUnableToCreateToken
 	}
 
-	err := s.store.View(ctx, func(tx kv.Tx) error {
-		if err := s.store.uniqueAuthToken(ctx, tx, a); err != nil {
-			return err
-		}
-		return nil
-	})
-	if err != nil {
-		return ErrTokenAlreadyExistsError
-	}
-
-	now := time.Now()
-	a.SetCreatedAt(now)
-	a.SetUpdatedAt(now)
-
-	return s.store.Update(ctx, func(tx kv.Tx) error {
-		return s.store.CreateAuthorization(ctx, tx, a)
-	})
+	return nil
 }
 
 func (s *Service) FindAuthorizationByID(ctx context.Context, id platform.ID) (*influxdb.Authorization, error) {
@@ -97,13 +232,32 @@ func (s *Service) FindAuthorizationByID(ctx context.Context, id platform.ID) (*i
 }
 
 // FindAuthorizationByToken returns a authorization by token for a particular authorization.
+// ErrAuthExpired is returned if the authorization has expired, and
+// ErrAuthRevoked if it was revoked. The use of the
+// token is recorded in the LastUsedAt of the authorization by FlushLastUsed.
 func (s *Service) FindAuthorizationByToken(ctx context.Context, n string) (*influxdb.Authorization, error) {
-	var a *influxdb.Authorization
+	now := time.Now()
+	var (
+		a   *influxdb.Authorization
+		gen uint64
+	)
+	if s.cache != nil {
+		var ok bool
+		if a, gen, ok = s.cache.get(n, now); ok {
+			return s.checkFound(a, now)
+		}
+	}
+
 	err := s.store.View(ctx, func(tx kv.Tx) error {
-		auth, err := s.store.GetAuthorizationByToken(ctx, tx, n)
+		auth, err := s.getAuthorizationByToken(ctx, tx, n)
 		if err != nil {
 			return err
 		}
+		if r, err := s.store.GetRevocation(ctx, tx, auth.ID); err != nil {
+			return err
+		} else if r != nil {
+			return ErrAuthRevoked
+		}
 
 		a = auth
 
@@ -114,13 +268,32 @@ func (s *Service) FindAuthorizationByToken(ctx context.Context, n string) (*infl
 		return nil, err
 	}
 
+	if s.cache != nil {
+		s.cache.add(n, a, now, gen)
+	}
+	return s.checkFound(a, now)
+}
+
+// checkFound returns an authorization found by token unless it has expired
+// by now, recording its use.
+func (s *Service) checkFound(a *influxdb.Authorization, now time.Time) (*influxdb.Authorization, error) {
+	if a.IsExpired(now) {
+		return nil, ErrAuthExpired
+	}
+
+	s.markUsed(a.ID, now)
 	return a, nil
 }
 
 // FindAuthorizations retrives all authorizations that match an arbitrary authorization filter.
 // Filters using ID, or Token should be efficient.
-// Other filters will do a linear scan across all authorizations searching for a match.
+// Other filters will do a linear scan across all authorizations searching for a match, stopping
+// once a page of opt is found.
 func (s *Service) FindAuthorizations(ctx context.Context, filter influxdb.AuthorizationFilter, opt ...influxdb.FindOptions) ([]*influxdb.Authorization, int, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+	span.SetTag("filter", filterType(filter))
+
 	if filter.ID != nil {
 		var auth *influxdb.Authorization
 		err := s.store.View(ctx, func(tx kv.Tx) error {
@@ -143,7 +316,7 @@ func (s *Service) FindAuthorizations(ctx context.Context, filter influxdb.Author
 	if filter.Token != nil {
 		var auth *influxdb.Authorization
 		err := s.store.View(ctx, func(tx kv.Tx) error {
-			a, e := s.store.GetAuthorizationByToken(ctx, tx, *filter.Token)
+			a, e := s.getAuthorizationByToken(ctx, tx, *filter.Token)
 			if e != nil {
 				return e
 			}
@@ -161,7 +334,7 @@ func (s *Service) FindAuthorizations(ctx context.Context, filter influxdb.Author
 
 	as := []*influxdb.Authorization{}
 	err := s.store.View(ctx, func(tx kv.Tx) error {
-		auths, err := s.store.ListAuthorizations(ctx, tx, filter)
+		auths, err := s.store.ListAuthorizations(ctx, tx, filter, opt...)
 		if err != nil {
 			return err
 		}
@@ -178,47 +351,179 @@ func (s *Service) FindAuthorizations(ctx context.Context, filter influxdb.Author
 	return as, len(as), nil
 }
 
+// filterType names how the authorizations matching a filter are found, for
+// traces: by ID, by token, with the index of a user or an org, or by a scan.
+func filterType(f influxdb.AuthorizationFilter) string {
+	switch {
+	case f.ID != nil:
+		return "id"
+	case f.Token != nil:
+		return "token"
+	case f.UserID != nil:
+		return "user"
+	case f.OrgID != nil:
+		return "org"
+	default:
+		return "scan"
+	}
+}
+
 // UpdateAuthorization updates the status and description if available.
 func (s *Service) UpdateAuthorization(ctx context.Context, id platform.ID, upd *influxdb.AuthorizationUpdate) (*influxdb.Authorization, error) {
 	var auth *influxdb.Authorization
-	err := s.store.View(ctx, func(tx kv.Tx) error {
-		a, e := s.store.GetAuthorizationByID(ctx, tx, id)
-		if e != nil {
-			return e
+	err := s.store.Update(ctx, func(tx kv.Tx) error {
+		a, err := s.store.GetAuthorizationByID(ctx, tx, id)
+		if err != nil {
+			return &errors.Error{
+				Code: errors.ENotFound,
+				Err:  err,
+			}
 		}
-		auth = a
-		return nil
+
+		before := *a
+		if upd.Status != nil {
+			a.Status = *upd.Status
+		}
+		if upd.Description != nil {
+			a.Description = *upd.Description
+		}
+		a.SetUpdatedAt(time.Now())
+
+		if a.IsActive() {
+			if r, err := s.store.GetRevocation(ctx, tx, id); err != nil {
+				return err
+			} else if r != nil {
+				return ErrAuthRevoked
+			}
+		}
+		auth, err = s.store.UpdateAuthorization(ctx, tx, id, a)
+		if err != nil {
+			return err
+		}
+		return s.audit(ctx, tx, AuditUpdate, id, &before, auth)
 	})
+	s.invalidate(id)
+	return auth, err
+}
 
+func (s *Service) DeleteAuthorization(ctx context.Context, id platform.ID) error {
+	defer s.invalidate(id)
+	return s.store.Update(ctx, func(tx kv.Tx) (err error) {
+		a, err := s.store.GetAuthorizationByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.store.DeleteAuthorization(ctx, tx, id); err != nil {
+			return err
+		}
+		if err := s.store.DeleteUsername(ctx, tx, id); err != nil {
+			return err
+		}
+		return s.audit(ctx, tx, AuditDelete, id, a, nil)
+	})
+}
+
+// RotateAuthorizationToken replaces the token of an authorization with a newly
+// generated one, keeping its ID so that references to it remain valid. The
+// returned authorization holds the new token, which the old one no longer
+// finds. When tokens are hashed, this is the only time the token is returned.
+func (s *Service) RotateAuthorizationToken(ctx context.Context, id platform.ID) (*influxdb.Authorization, error) {
+	token, err := s.tokenGenerator.Token()
 	if err != nil {
 		return nil, &errors.Error{
-			Code: errors.ENotFound,
-			Err:  err,
+			Err: err,
 		}
 	}
 
-	if upd.Status != nil {
-		auth.Status = *upd.Status
-	}
-	if upd.Description != nil {
-		auth.Description = *upd.Description
+	var auth *influxdb.Authorization
+	err = s.store.Update(ctx, func(tx kv.Tx) error {
+		a, err := s.store.GetAuthorizationByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		before := *a
+		a.SetUpdatedAt(time.Now())
+		auth, err = s.store.UpdateAuthorizationToken(ctx, tx, a, s.storedToken(token))
+		if err != nil {
+			return err
+		}
+		// The generated token is not a username.
+		if err := s.store.DeleteUsername(ctx, tx, id); err != nil {
+			return err
+		}
+		return s.audit(ctx, tx, AuditRotateToken, id, &before, auth)
+	})
+	s.invalidate(id)
+	if err != nil {
+		return nil, err
 	}
+	auth.Token = token
+	return auth, nil
+}
 
-	auth.SetUpdatedAt(time.Now())
+// UpdatePermissions replaces the permissions of an authorization.
+func (s *Service) UpdatePermissions(ctx context.Context, id platform.ID, perms []influxdb.Permission) (*influxdb.Authorization, error) {
+	var auth *influxdb.Authorization
+	err := s.store.Update(ctx, func(tx kv.Tx) error {
+		a, err := s.store.GetAuthorizationByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
 
-	err = s.store.Update(ctx, func(tx kv.Tx) error {
-		a, e := s.store.UpdateAuthorization(ctx, tx, id, auth)
-		if e != nil {
-			return e
+		before := *a
+		a.Permissions = perms
+		if err := a.Valid(); err != nil {
+			return &errors.Error{
+				Err: err,
+			}
 		}
-		auth = a
-		return nil
+		a.SetUpdatedAt(time.Now())
+
+		auth, err = s.store.UpdateAuthorization(ctx, tx, id, a)
+		if err != nil {
+			return err
+		}
+		return s.audit(ctx, tx, AuditUpdate, id, &before, auth)
 	})
+	s.invalidate(id)
 	return auth, err
 }
 
-func (s *Service) DeleteAuthorization(ctx context.Context, id platform.ID) error {
-	return s.store.Update(ctx, func(tx kv.Tx) (err error) {
-		return s.store.DeleteAuthorization(ctx, tx, id)
+// DeactivateExpired marks the active authorizations which have expired by now
+// inactive, and returns them. They are kept so that they can be audited before
+// being deleted.
+func (s *Service) DeactivateExpired(ctx context.Context, now time.Time) ([]*influxdb.Authorization, error) {
+	var expired []*influxdb.Authorization
+	err := s.store.Update(ctx, func(tx kv.Tx) error {
+		err := s.store.forEachAuthorization(ctx, tx, nil, func(a *influxdb.Authorization) bool {
+			if a.IsActive() && a.IsExpired(now) {
+				expired = append(expired, a)
+			}
+			return true
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, a := range expired {
+			before := *a
+			a.Status = influxdb.Inactive
+			a.SetUpdatedAt(now)
+			if _, err := s.store.UpdateAuthorization(ctx, tx, a.ID, a); err != nil {
+				return err
+			}
+			if err := s.audit(ctx, tx, AuditUpdate, a.ID, &before, a); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range expired {
+		s.invalidate(a.ID)
+	}
+	return expired, nil
 }
@@ -3,11 +3,11 @@ package authorization
 import (
 	"context"
 	eBase "errors"
+	"time"
 
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kv"
-	"github.com/influxdata/influxdb/v2/tenant"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -31,13 +31,19 @@ func (s *Service) SetPasswordHash(ctx context.Context, authID platform.ID, passH
 		if err != nil {
 			return ErrAuthNotFound
 		}
-		return s.store.SetPassword(ctx, tx, authID, passHash)
+		if err := s.store.SetPassword(ctx, tx, authID, passHash); err != nil {
+			return err
+		}
+		if err := s.store.SetPasswordTime(ctx, tx, authID, time.Now()); err != nil {
+			return err
+		}
+		return s.audit(ctx, tx, AuditSetPassword, authID, nil, nil)
 	})
 }
 
 // SetPassword overrides the password of a known user.
 func (s *Service) SetPassword(ctx context.Context, authID platform.ID, password string) error {
-	if err := tenant.IsPasswordStrong(password, s.strongPasswords); err != nil {
+	if err := s.passwordPolicy.Check(password); err != nil {
 		return err
 	}
 	passHash, err := encryptPassword(password)
@@ -50,54 +56,84 @@ func (s *Service) SetPassword(ctx context.Context, authID platform.ID, password
 		if err != nil {
 			return ErrAuthNotFound
 		}
-		return s.store.SetPassword(ctx, tx, authID, passHash)
+		if err := s.store.SetPassword(ctx, tx, authID, passHash); err != nil {
+			return err
+		}
+		if err := s.store.SetPasswordTime(ctx, tx, authID, time.Now()); err != nil {
+			return err
+		}
+		return s.audit(ctx, tx, AuditSetPassword, authID, nil, nil)
 	})
 }
 
 // ComparePassword checks if the password matches the password recorded.
-// Passwords that do not match return errors, as do too weak passwords
+// Passwords that do not match return errors, as do passwords breaking the
+// password policy or older than its maximum age.
 func (s *Service) ComparePassword(ctx context.Context, authID platform.ID, password string) error {
-	err := s.comparePasswordNoStrengthCheck(ctx, authID, password)
-	// If a password matches, but is too weak, force user to change
-	if errStrength := tenant.IsPasswordStrong(password, s.strongPasswords); err == nil && errStrength != nil {
+	err := s.comparePasswordWithLockout(ctx, authID, password)
+	if err != nil {
+		return err
+	}
+	// If a password matches, but is too weak or too old, force user to change
+	if errStrength := s.passwordPolicy.Check(password); errStrength != nil {
 		return eBase.Join(errors.EPasswordChangeRequired, errStrength)
 	}
-	return err
+	if s.passwordPolicy.MaxAge > 0 {
+		var setAt time.Time
+		if err := s.store.View(ctx, func(tx kv.Tx) error {
+			var err error
+			setAt, err = s.store.GetPasswordTime(ctx, tx, authID)
+			return err
+		}); err != nil {
+			return err
+		}
+		if !setAt.IsZero() && s.passwordPolicy.Expired(setAt, time.Now()) {
+			return eBase.Join(errors.EPasswordChangeRequired, errors.EPasswordExpired)
+		}
+	}
+	return nil
 }
 
 func (s *Service) comparePasswordNoStrengthCheck(ctx context.Context, authID platform.ID, password string) error {
-	// get password
 	var hash []byte
-	err := s.store.View(ctx, func(tx kv.Tx) error {
-		_, err := s.store.GetAuthorizationByID(ctx, tx, authID)
-		if err != nil {
-			return ErrAuthNotFound
-		}
-		h, err := s.store.GetPassword(ctx, tx, authID)
-		if err != nil {
-			if err == kv.ErrKeyNotFound {
-				return errors.EIncorrectPassword
-			}
-			return err
-		}
-		hash = []byte(h)
-		return nil
-	})
-	if err != nil {
+	if err := s.store.View(ctx, func(tx kv.Tx) error {
+		var err error
+		hash, err = s.passwordHash(ctx, tx, authID)
+		return err
+	}); err != nil {
 		return err
 	}
-	// compare password
+	return comparePasswordHash(hash, password)
+}
+
+// passwordHash returns the hash of the password of an authorization.
+func (s *Service) passwordHash(ctx context.Context, tx kv.Tx, authID platform.ID) ([]byte, error) {
+	if _, err := s.store.GetAuthorizationByID(ctx, tx, authID); err != nil {
+		return nil, ErrAuthNotFound
+	}
+	h, err := s.store.GetPassword(ctx, tx, authID)
+	if err != nil {
+		if err == kv.ErrKeyNotFound {
+			return nil, errors.EIncorrectPassword
+		}
+		return nil, err
+	}
+	return []byte(h), nil
+}
+
+// comparePasswordHash compares a password with its hash. It is slow by
+// design, so it is never run within a transaction.
+func comparePasswordHash(hash []byte, password string) error {
 	if err := bcrypt.CompareHashAndPassword(hash, []byte(password)); err != nil {
 		return errors.EIncorrectPassword
 	}
-
 	return nil
 }
 
 // CompareAndSetPassword checks the password and if they match
 // updates to the new password.
 func (s *Service) CompareAndSetPassword(ctx context.Context, authID platform.ID, old, new string) error {
-	err := s.comparePasswordNoStrengthCheck(ctx, authID, old)
+	err := s.comparePasswordWithLockout(ctx, authID, old)
 	if err != nil {
 		return err
 	}
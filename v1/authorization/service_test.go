@@ -0,0 +1,745 @@
+package authorization
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kit/prom"
+	"github.com/influxdata/influxdb/v2/kit/prom/promtest"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/tenant"
+	itesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+var (
+	testOrgID  = platform.ID(10)
+	testUserID = platform.ID(20)
+)
+
+func newTestService(t *testing.T, opts ...func(*Service)) *Service {
+	t.Helper()
+
+	storage, err := NewStore(itesting.NewTestInmemStore(t))
+	require.NoError(t, err)
+
+	return NewService(storage, &tenantService{
+		FindUserByIDFn: func(ctx context.Context, id platform.ID) (*influxdb.User, error) {
+			return &influxdb.User{ID: id, Name: "user", Status: influxdb.Active}, nil
+		},
+		FindOrganizationByIDF: func(ctx context.Context, id platform.ID) (*influxdb.Organization, error) {
+			return &influxdb.Organization{ID: id, Name: "org"}, nil
+		},
+		FindBucketFn: func(ctx context.Context, filter influxdb.BucketFilter) (*influxdb.Bucket, error) {
+			return nil, &errors.Error{Code: errors.ENotFound, Msg: "bucket not found"}
+		},
+	}, opts...)
+}
+
+func newTestAuthorization(token string) *influxdb.Authorization {
+	return &influxdb.Authorization{
+		Token:  token,
+		OrgID:  testOrgID,
+		UserID: testUserID,
+		Permissions: []influxdb.Permission{{
+			Action:   influxdb.ReadAction,
+			Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, OrgID: &testOrgID},
+		}},
+	}
+}
+
+func TestService_Expiration(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t)
+
+	past := time.Now().Add(-time.Minute)
+	a := newTestAuthorization("expired")
+	a.ExpiresAt = &past
+	err := svc.CreateAuthorization(ctx, a)
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+
+	future := time.Now().Add(time.Hour)
+	a.ExpiresAt = &future
+	require.NoError(t, svc.CreateAuthorization(ctx, a))
+	forever := newTestAuthorization("forever")
+	require.NoError(t, svc.CreateAuthorization(ctx, forever))
+
+	got, err := svc.FindAuthorizationByToken(ctx, "expired")
+	require.NoError(t, err)
+	require.True(t, future.Equal(*got.ExpiresAt))
+
+	// Expire the authorization.
+	require.NoError(t, svc.store.Update(ctx, func(tx kv.Tx) error {
+		a.ExpiresAt = &past
+		_, err := svc.store.UpdateAuthorization(ctx, tx, a.ID, a)
+		return err
+	}))
+	_, err = svc.FindAuthorizationByToken(ctx, "expired")
+	require.Equal(t, ErrAuthExpired, err)
+
+	deactivated, err := svc.DeactivateExpired(ctx, time.Now())
+	require.NoError(t, err)
+	require.Len(t, deactivated, 1)
+	require.Equal(t, a.ID, deactivated[0].ID)
+
+	// Expired authorizations are kept, inactive.
+	got, err = svc.FindAuthorizationByID(ctx, a.ID)
+	require.NoError(t, err)
+	require.Equal(t, influxdb.Inactive, got.Status)
+	got, err = svc.FindAuthorizationByID(ctx, forever.ID)
+	require.NoError(t, err)
+	require.Equal(t, influxdb.Active, got.Status)
+
+	deactivated, err = svc.DeactivateExpired(ctx, time.Now())
+	require.NoError(t, err)
+	require.Empty(t, deactivated)
+}
+
+func TestService_CreateAuthorizations(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t)
+
+	as := []*influxdb.Authorization{
+		newTestAuthorization("token1"),
+		newTestAuthorization("token2"),
+		newTestAuthorization("token3"),
+	}
+	require.NoError(t, svc.CreateAuthorizations(ctx, as))
+	for _, a := range as {
+		require.True(t, a.ID.Valid())
+		got, err := svc.FindAuthorizationByToken(ctx, a.Token)
+		require.NoError(t, err)
+		require.Equal(t, a.ID, got.ID)
+	}
+
+	// None of the authorizations are created if one of them cannot be.
+	err := svc.CreateAuthorizations(ctx, []*influxdb.Authorization{
+		newTestAuthorization("token4"),
+		newTestAuthorization("token1"),
+	})
+	require.Error(t, err)
+	_, err = svc.FindAuthorizationByToken(ctx, "token4")
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+
+	err = svc.CreateAuthorizations(ctx, []*influxdb.Authorization{
+		newTestAuthorization("token5"),
+		newTestAuthorization("token5"),
+	})
+	require.Equal(t, ErrTokenAlreadyExistsError, err)
+
+	_, n, err := svc.FindAuthorizations(ctx, influxdb.AuthorizationFilter{})
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+}
+
+func TestService_FindAuthorizations_Paging(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t)
+
+	var ids []platform.ID
+	for i := 0; i < 5; i++ {
+		a := newTestAuthorization(fmt.Sprintf("token%d", i))
+		require.NoError(t, svc.CreateAuthorization(ctx, a))
+		ids = append(ids, a.ID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	idsOf := func(as []*influxdb.Authorization) []platform.ID {
+		var got []platform.ID
+		for _, a := range as {
+			got = append(got, a.ID)
+		}
+		return got
+	}
+
+	as, _, err := svc.FindAuthorizations(ctx, influxdb.AuthorizationFilter{}, influxdb.FindOptions{Offset: 1, Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, ids[1:3], idsOf(as))
+
+	as, _, err = svc.FindAuthorizations(ctx, influxdb.AuthorizationFilter{}, influxdb.FindOptions{Limit: 2, Descending: true})
+	require.NoError(t, err)
+	require.Equal(t, []platform.ID{ids[4], ids[3]}, idsOf(as))
+
+	as, _, err = svc.FindAuthorizations(ctx, influxdb.AuthorizationFilter{}, influxdb.FindOptions{After: &ids[2]})
+	require.NoError(t, err)
+	require.Equal(t, ids[3:], idsOf(as))
+
+	as, _, err = svc.FindAuthorizations(ctx, influxdb.AuthorizationFilter{}, influxdb.FindOptions{After: &ids[2], Descending: true})
+	require.NoError(t, err)
+	require.Equal(t, []platform.ID{ids[1], ids[0]}, idsOf(as))
+
+	orgID := testOrgID + 1
+	as, _, err = svc.FindAuthorizations(ctx, influxdb.AuthorizationFilter{OrgID: &orgID}, influxdb.FindOptions{Limit: 2})
+	require.NoError(t, err)
+	require.Empty(t, as)
+}
+
+func TestService_FindAuthorizations_Indexes(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t)
+
+	otherUserID, otherOrgID := testUserID+1, testOrgID+1
+	var mine []platform.ID
+	for i := 0; i < 4; i++ {
+		a := newTestAuthorization(fmt.Sprintf("token%d", i))
+		if i%2 == 1 {
+			a.UserID = otherUserID
+		}
+		if i == 3 {
+			a.OrgID = otherOrgID
+			a.Permissions[0].Resource.OrgID = &otherOrgID
+		}
+		require.NoError(t, svc.CreateAuthorization(ctx, a))
+		if i%2 == 0 {
+			mine = append(mine, a.ID)
+		}
+	}
+
+	as, n, err := svc.FindAuthorizations(ctx, influxdb.AuthorizationFilter{UserID: &testUserID})
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	for i, a := range as {
+		require.Equal(t, mine[i], a.ID)
+	}
+
+	_, n, err = svc.FindAuthorizations(ctx, influxdb.AuthorizationFilter{OrgID: &testOrgID})
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	as, n, err = svc.FindAuthorizations(ctx, influxdb.AuthorizationFilter{UserID: &otherUserID, OrgID: &otherOrgID})
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, "token3", as[0].Token)
+
+	as, _, err = svc.FindAuthorizations(ctx, influxdb.AuthorizationFilter{UserID: &testUserID}, influxdb.FindOptions{Descending: true, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, as, 1)
+	require.Equal(t, mine[1], as[0].ID)
+
+	// Deleted authorizations are removed from the indexes.
+	require.NoError(t, svc.DeleteAuthorization(ctx, mine[0]))
+	_, n, err = svc.FindAuthorizations(ctx, influxdb.AuthorizationFilter{UserID: &testUserID})
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	_, n, err = svc.FindAuthorizations(ctx, influxdb.AuthorizationFilter{OrgID: &testOrgID})
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+}
+
+func TestService_RotateAuthorizationToken(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t)
+
+	a := newTestAuthorization("token")
+	require.NoError(t, svc.CreateAuthorization(ctx, a))
+
+	rotated, err := svc.RotateAuthorizationToken(ctx, a.ID)
+	require.NoError(t, err)
+	require.Equal(t, a.ID, rotated.ID)
+	require.NotEmpty(t, rotated.Token)
+	require.NotEqual(t, "token", rotated.Token)
+
+	_, err = svc.FindAuthorizationByToken(ctx, "token")
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+	got, err := svc.FindAuthorizationByToken(ctx, rotated.Token)
+	require.NoError(t, err)
+	require.Equal(t, a.ID, got.ID)
+
+	_, err = svc.RotateAuthorizationToken(ctx, a.ID+1)
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+}
+
+// viewHookStore calls afterView once, after the next read transaction.
+type viewHookStore struct {
+	kv.Store
+	afterView func()
+}
+
+func (s *viewHookStore) View(ctx context.Context, fn func(kv.Tx) error) error {
+	err := s.Store.View(ctx, fn)
+	if hook := s.afterView; hook != nil {
+		s.afterView = nil
+		hook()
+	}
+	return err
+}
+
+func TestService_UpdateAuthorizationRotated(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t)
+	hooks := &viewHookStore{Store: svc.store.kvStore}
+	svc.store.kvStore = hooks
+
+	a := newTestAuthorization("token")
+	require.NoError(t, svc.CreateAuthorization(ctx, a))
+
+	// The token is rotated between any read of the update and its write.
+	var rotated *influxdb.Authorization
+	rotate := func() {
+		var err error
+		rotated, err = svc.RotateAuthorizationToken(ctx, a.ID)
+		require.NoError(t, err)
+	}
+	hooks.afterView = rotate
+	desc := "updated"
+	_, err := svc.UpdateAuthorization(ctx, a.ID, &influxdb.AuthorizationUpdate{Description: &desc})
+	require.NoError(t, err)
+	if rotated == nil {
+		hooks.afterView = nil
+		rotate()
+	}
+
+	// The rotated-out token stays rotated out.
+	_, err = svc.FindAuthorizationByToken(ctx, "token")
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+	got, err := svc.FindAuthorizationByToken(ctx, rotated.Token)
+	require.NoError(t, err)
+	require.Equal(t, "updated", got.Description)
+}
+
+func TestService_TokenHashing(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t)
+
+	plain := newTestAuthorization("plain")
+	require.NoError(t, svc.CreateAuthorization(ctx, plain))
+
+	// Authorizations stored in plain text are found once tokens are hashed.
+	svc.hashTokens = true
+	hashed := newTestAuthorization("hashed")
+	require.NoError(t, svc.CreateAuthorization(ctx, hashed))
+	require.Equal(t, "hashed", hashed.Token)
+	require.Equal(t, ErrTokenAlreadyExistsError, svc.CreateAuthorization(ctx, newTestAuthorization("plain")))
+	require.Equal(t, ErrTokenAlreadyExistsError, svc.CreateAuthorization(ctx, newTestAuthorization("hashed")))
+
+	stored, err := svc.FindAuthorizationByID(ctx, hashed.ID)
+	require.NoError(t, err)
+	require.Equal(t, hashToken("hashed"), stored.Token)
+
+	for _, a := range []*influxdb.Authorization{plain, hashed} {
+		got, err := svc.FindAuthorizationByToken(ctx, a.Token)
+		require.NoError(t, err)
+		require.Equal(t, a.ID, got.ID)
+		require.Equal(t, a.Token, got.Token)
+	}
+
+	// The tokens hashed are kept as the usernames of their authorizations.
+	name, err := svc.FindUsername(ctx, hashed.ID)
+	require.NoError(t, err)
+	require.Equal(t, "hashed", name)
+
+	n, err := svc.HashTokens(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	name, err = svc.FindUsername(ctx, plain.ID)
+	require.NoError(t, err)
+	require.Equal(t, "plain", name)
+	stored, err = svc.FindAuthorizationByID(ctx, plain.ID)
+	require.NoError(t, err)
+	require.Equal(t, hashToken("plain"), stored.Token)
+	got, err := svc.FindAuthorizationByToken(ctx, "plain")
+	require.NoError(t, err)
+	require.Equal(t, plain.ID, got.ID)
+	_, err = svc.FindAuthorizationByToken(ctx, stored.Token)
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+
+	n, err = svc.HashTokens(ctx)
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	// Rotated tokens are returned once, and stored hashed.
+	rotated, err := svc.RotateAuthorizationToken(ctx, hashed.ID)
+	require.NoError(t, err)
+	stored, err = svc.FindAuthorizationByID(ctx, hashed.ID)
+	require.NoError(t, err)
+	require.Equal(t, hashToken(rotated.Token), stored.Token)
+	_, err = svc.FindAuthorizationByToken(ctx, "hashed")
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+	name, err = svc.FindUsername(ctx, hashed.ID)
+	require.NoError(t, err)
+	require.Empty(t, name)
+}
+
+func TestService_Audit(t *testing.T) {
+	ctx := icontext.SetAuthorizer(context.Background(), &influxdb.Authorization{UserID: testUserID})
+	svc := newTestService(t)
+
+	a := newTestAuthorization("token")
+	require.NoError(t, svc.CreateAuthorization(ctx, a))
+	desc := "updated"
+	_, err := svc.UpdateAuthorization(ctx, a.ID, &influxdb.AuthorizationUpdate{Description: &desc})
+	require.NoError(t, err)
+	require.NoError(t, svc.SetPassword(ctx, a.ID, "password"))
+	require.NoError(t, svc.DeleteAuthorization(context.Background(), a.ID))
+
+	rs, err := svc.FindAuditRecords(ctx, AuditFilter{AuthID: &a.ID})
+	require.NoError(t, err)
+	require.Len(t, rs, 4)
+	var actions []AuditAction
+	for _, r := range rs {
+		actions = append(actions, r.Action)
+	}
+	require.Equal(t, []AuditAction{AuditCreate, AuditUpdate, AuditSetPassword, AuditDelete}, actions)
+
+	require.Nil(t, rs[0].Before)
+	require.Empty(t, rs[0].After.Token)
+	require.Equal(t, "", rs[1].Before.Description)
+	require.Equal(t, desc, rs[1].After.Description)
+	require.Equal(t, desc, rs[3].Before.Description)
+	require.Nil(t, rs[3].After)
+	require.Equal(t, testUserID, *rs[0].ActorID)
+	require.Nil(t, rs[3].ActorID)
+
+	rs, err = svc.FindAuditRecords(ctx, AuditFilter{ActorID: &testUserID}, influxdb.FindOptions{Limit: 1, Descending: true})
+	require.NoError(t, err)
+	require.Len(t, rs, 1)
+	require.Equal(t, AuditSetPassword, rs[0].Action)
+
+	require.NoError(t, svc.VerifyAuditLog(ctx))
+
+	// Changing a record breaks the hash chain.
+	require.NoError(t, svc.store.Update(ctx, func(tx kv.Tx) error {
+		b, err := tx.Bucket(auditBucket)
+		if err != nil {
+			return err
+		}
+		r := rs[0]
+		r.Action = AuditUpdate
+		v, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		return b.Put(auditKey(r.Seq), v)
+	}))
+	require.Error(t, svc.VerifyAuditLog(ctx))
+}
+
+func TestService_LastUsed(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t)
+
+	used, unused := newTestAuthorization("used"), newTestAuthorization("unused")
+	require.NoError(t, svc.CreateAuthorization(ctx, used))
+	require.NoError(t, svc.CreateAuthorization(ctx, unused))
+
+	_, err := svc.FindAuthorizationByToken(ctx, "used")
+	require.NoError(t, err)
+
+	// Uses are stored when flushed.
+	got, err := svc.FindAuthorizationByID(ctx, used.ID)
+	require.NoError(t, err)
+	require.Nil(t, got.LastUsedAt)
+	require.NoError(t, svc.FlushLastUsed(ctx))
+	got, err = svc.FindAuthorizationByID(ctx, used.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.LastUsedAt)
+
+	now := time.Now()
+	as, _, err := svc.FindAuthorizations(ctx, influxdb.AuthorizationFilter{UsedBefore: &now})
+	require.NoError(t, err)
+	require.Len(t, as, 2)
+
+	before := got.LastUsedAt.Add(-time.Second)
+	as, _, err = svc.FindAuthorizations(ctx, influxdb.AuthorizationFilter{UsedBefore: &before})
+	require.NoError(t, err)
+	require.Len(t, as, 1)
+	require.Equal(t, unused.ID, as[0].ID)
+
+	// The uses of deleted authorizations are dropped.
+	_, err = svc.FindAuthorizationByToken(ctx, "unused")
+	require.NoError(t, err)
+	require.NoError(t, svc.DeleteAuthorization(ctx, unused.ID))
+	require.NoError(t, svc.FlushLastUsed(ctx))
+}
+
+func TestService_CreateScopedAuthorization(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t)
+
+	a := &influxdb.Authorization{Token: "admin", OrgID: testOrgID, UserID: testUserID}
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(svc.CreateScopedAuthorization(ctx, a)))
+
+	require.NoError(t, svc.CreateScopedAuthorization(ctx, a, influxdb.AuthorizationScope{Template: influxdb.AdminOrgTemplate}))
+	got, err := svc.FindAuthorizationByToken(ctx, "admin")
+	require.NoError(t, err)
+	require.Equal(t, influxdb.AdminOrgPermissions(testOrgID), got.Permissions)
+
+	a = &influxdb.Authorization{Token: "writer", OrgID: testOrgID, UserID: testUserID}
+	err = svc.CreateScopedAuthorization(ctx, a, influxdb.AuthorizationScope{Template: influxdb.WriteBucketTemplate, Bucket: "missing"})
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+}
+
+func TestService_PasswordPolicy(t *testing.T) {
+	ctx := context.Background()
+	policy := tenant.DefaultPasswordPolicy()
+	policy.Banned = []string{"influxdb1"}
+	policy.MaxAge = time.Millisecond
+	svc := newTestService(t, WithPasswordPolicy(policy))
+
+	a := newTestAuthorization("token")
+	require.NoError(t, svc.CreateAuthorization(ctx, a))
+	require.ErrorIs(t, svc.SetPassword(ctx, a.ID, "InfluxDB1"), errors.EPasswordBanned)
+
+	require.NoError(t, svc.SetPassword(ctx, a.ID, "password1"))
+	time.Sleep(2 * time.Millisecond)
+	err := svc.ComparePassword(ctx, a.ID, "password1")
+	require.ErrorIs(t, err, errors.EPasswordChangeRequired)
+	require.ErrorIs(t, err, errors.EPasswordExpired)
+}
+
+func TestService_Lockout(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t, WithLockout(2, time.Hour))
+
+	a := newTestAuthorization("token")
+	require.NoError(t, svc.CreateAuthorization(ctx, a))
+	require.NoError(t, svc.SetPassword(ctx, a.ID, "password1"))
+
+	// a successful attempt resets the failures
+	require.Equal(t, errors.EIncorrectPassword, svc.ComparePassword(ctx, a.ID, "wrong"))
+	require.NoError(t, svc.ComparePassword(ctx, a.ID, "password1"))
+	require.Equal(t, errors.EIncorrectPassword, svc.ComparePassword(ctx, a.ID, "wrong"))
+	require.Equal(t, errors.EIncorrectPassword, svc.ComparePassword(ctx, a.ID, "wrong"))
+
+	// even the correct password is rejected while locked
+	require.Equal(t, ErrPasswordLocked, svc.ComparePassword(ctx, a.ID, "password1"))
+	require.Equal(t, ErrPasswordLocked, svc.CompareAndSetPassword(ctx, a.ID, "password1", "password2"))
+
+	require.NoError(t, svc.UnlockPassword(ctx, a.ID))
+	require.NoError(t, svc.ComparePassword(ctx, a.ID, "password1"))
+	require.Equal(t, ErrAuthNotFound, svc.UnlockPassword(ctx, platform.ID(1000)))
+}
+
+func TestService_LockoutConcurrentAttempts(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t, WithLockout(3, time.Hour))
+
+	a := newTestAuthorization("token")
+	require.NoError(t, svc.CreateAuthorization(ctx, a))
+	require.NoError(t, svc.SetPassword(ctx, a.ID, "password1"))
+
+	// no more attempts than the threshold are compared, however many run at once.
+	const attempts = 10
+	errs := make(chan error, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- svc.ComparePassword(ctx, a.ID, "wrong")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	var compared int
+	for err := range errs {
+		if err == errors.EIncorrectPassword {
+			compared++
+			continue
+		}
+		require.Equal(t, ErrPasswordLocked, err)
+	}
+	require.Equal(t, 3, compared)
+}
+
+func TestService_RevokeAuthorization(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t)
+
+	var revoked []platform.ID
+	svc.OnRevoke(func(id platform.ID) { revoked = append(revoked, id) })
+
+	a := newTestAuthorization("token")
+	require.NoError(t, svc.CreateAuthorization(ctx, a))
+	require.NoError(t, svc.RevokeAuthorization(ctx, a.ID, "leaked"))
+	require.Equal(t, []platform.ID{a.ID}, revoked)
+
+	_, err := svc.FindAuthorizationByToken(ctx, "token")
+	require.Equal(t, ErrAuthRevoked, err)
+
+	got, err := svc.FindAuthorizationByID(ctx, a.ID)
+	require.NoError(t, err)
+	require.Equal(t, influxdb.Inactive, got.Status)
+
+	r, err := svc.FindRevocation(ctx, a.ID)
+	require.NoError(t, err)
+	require.Equal(t, "leaked", r.Reason)
+
+	// the first revocation is kept
+	require.NoError(t, svc.RevokeAuthorization(ctx, a.ID, "again"))
+	r, err = svc.FindRevocation(ctx, a.ID)
+	require.NoError(t, err)
+	require.Equal(t, "leaked", r.Reason)
+
+	// a revoked authorization cannot be activated again
+	active := influxdb.Active
+	_, err = svc.UpdateAuthorization(ctx, a.ID, &influxdb.AuthorizationUpdate{Status: &active})
+	require.Equal(t, ErrAuthRevoked, err)
+
+	require.Equal(t, ErrAuthNotFound, svc.RevokeAuthorization(ctx, platform.ID(1000), ""))
+}
+
+func TestService_AuthCache(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t, WithAuthCache(1, time.Hour))
+	reg := prom.NewRegistry(zaptest.NewLogger(t))
+	reg.MustRegister(svc.PrometheusCollectors()...)
+	counter := func(name string) float64 {
+		mfs := promtest.MustGather(t, reg)
+		return promtest.MustFindMetric(t, mfs, name, nil).Counter.GetValue()
+	}
+
+	a := newTestAuthorization("token")
+	require.NoError(t, svc.CreateAuthorization(ctx, a))
+	b := newTestAuthorization("token2")
+	require.NoError(t, svc.CreateAuthorization(ctx, b))
+
+	got, err := svc.FindAuthorizationByToken(ctx, "token")
+	require.NoError(t, err)
+	got.Description = "changed by the caller"
+	got, err = svc.FindAuthorizationByToken(ctx, "token")
+	require.NoError(t, err)
+	require.Empty(t, got.Description)
+	require.Equal(t, float64(1), counter("v1_authorization_cache_hits_total"))
+	require.Equal(t, float64(1), counter("v1_authorization_cache_misses_total"))
+
+	// the least recently used authorization is evicted
+	_, err = svc.FindAuthorizationByToken(ctx, "token2")
+	require.NoError(t, err)
+	_, err = svc.FindAuthorizationByToken(ctx, "token")
+	require.NoError(t, err)
+	require.Equal(t, float64(3), counter("v1_authorization_cache_misses_total"))
+
+	// updates are not hidden by the cache
+	inactive := influxdb.Inactive
+	_, err = svc.UpdateAuthorization(ctx, a.ID, &influxdb.AuthorizationUpdate{Status: &inactive})
+	require.NoError(t, err)
+	got, err = svc.FindAuthorizationByToken(ctx, "token")
+	require.NoError(t, err)
+	require.Equal(t, influxdb.Inactive, got.Status)
+
+	// flushed uses are not hidden by the cache
+	require.Nil(t, got.LastUsedAt)
+	require.NoError(t, svc.FlushLastUsed(ctx))
+	got, err = svc.FindAuthorizationByToken(ctx, "token")
+	require.NoError(t, err)
+	require.NotNil(t, got.LastUsedAt)
+
+	require.NoError(t, svc.DeleteAuthorization(ctx, a.ID))
+	_, err = svc.FindAuthorizationByToken(ctx, "token")
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+}
+
+func TestService_TxSpans(t *testing.T) {
+	tracer := mocktracer.New()
+	oldTracer := opentracing.GlobalTracer()
+	opentracing.SetGlobalTracer(tracer)
+	defer opentracing.SetGlobalTracer(oldTracer)
+
+	ctx := context.Background()
+	svc := newTestService(t)
+	a := newTestAuthorization("token")
+	require.NoError(t, svc.CreateAuthorization(ctx, a))
+	tracer.Reset()
+
+	_, err := svc.FindAuthorizationByToken(ctx, "token")
+	require.NoError(t, err)
+	_, _, err = svc.FindAuthorizations(ctx, influxdb.AuthorizationFilter{UserID: &testUserID})
+	require.NoError(t, err)
+
+	spans := map[string]*mocktracer.MockSpan{}
+	for _, span := range tracer.FinishedSpans() {
+		spans[span.OperationName] = span
+	}
+	require.Equal(t, "view", spans["authorization.(*Service).FindAuthorizationByToken"].Tag("tx"))
+	require.Equal(t, "user", spans["authorization.(*Service).FindAuthorizations"].Tag("filter"))
+}
+
+func TestService_Labels(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t)
+
+	ingest := newTestAuthorization("token")
+	ingest.Labels = map[string]string{"team": "ingest", "env": "prod"}
+	require.NoError(t, svc.CreateAuthorization(ctx, ingest))
+	query := newTestAuthorization("token2")
+	query.Labels = map[string]string{"team": "query", "env": "prod"}
+	require.NoError(t, svc.CreateAuthorization(ctx, query))
+	require.NoError(t, svc.CreateAuthorization(ctx, newTestAuthorization("token3")))
+
+	invalid := newTestAuthorization("token4")
+	invalid.Labels = map[string]string{"a=b": "c"}
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(svc.CreateAuthorization(ctx, invalid)))
+
+	for _, filter := range []influxdb.AuthorizationFilter{{}, {UserID: &testUserID}, {OrgID: &testOrgID}} {
+		filter.Labels = map[string]string{"team": "ingest"}
+		as, _, err := svc.FindAuthorizations(ctx, filter)
+		require.NoError(t, err)
+		require.Len(t, as, 1)
+		require.Equal(t, ingest.ID, as[0].ID)
+
+		filter.Labels = map[string]string{"env": "prod"}
+		as, _, err = svc.FindAuthorizations(ctx, filter)
+		require.NoError(t, err)
+		require.Len(t, as, 2)
+	}
+
+	labels, err := ParseLabels([]string{"team=ingest", "env=prod"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"team": "ingest", "env": "prod"}, labels)
+	require.Equal(t, []string{"env=prod", "team=ingest"}, formatLabels(labels))
+	_, err = ParseLabels([]string{"team"})
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+}
+
+func TestService_TokenQuota(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t, WithTokenQuota(2))
+	otherOrgID := platform.ID(11)
+
+	require.NoError(t, svc.CreateAuthorization(ctx, newTestAuthorization("token")))
+	require.NoError(t, svc.CreateAuthorization(ctx, newTestAuthorization("token2")))
+	err := svc.CreateAuthorization(ctx, newTestAuthorization("token3"))
+	require.Equal(t, ErrTokenQuotaExceeded(testOrgID, 2), err)
+
+	// the quota of an org overrides the default one
+	require.NoError(t, svc.PutOrgTokenQuota(ctx, testOrgID, TokenQuota{MaxTokens: 3}))
+	require.Equal(t, errors.EForbidden, errors.ErrorCode(svc.CreateAuthorizations(ctx, []*influxdb.Authorization{
+		newTestAuthorization("token3"),
+		newTestAuthorization("token4"),
+	})))
+	require.NoError(t, svc.CreateAuthorization(ctx, newTestAuthorization("token3")))
+
+	q, err := svc.FindOrgTokenQuota(ctx, testOrgID)
+	require.NoError(t, err)
+	require.Equal(t, &TokenQuota{MaxTokens: 3}, q)
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(svc.PutOrgTokenQuota(ctx, testOrgID, TokenQuota{MaxTokens: -1})))
+
+	require.NoError(t, svc.PutOrgTokenQuota(ctx, testOrgID, TokenQuota{}))
+	require.NoError(t, svc.CreateAuthorization(ctx, newTestAuthorization("token4")))
+
+	require.NoError(t, svc.DeleteOrgTokenQuota(ctx, testOrgID))
+	q, err = svc.FindOrgTokenQuota(ctx, testOrgID)
+	require.NoError(t, err)
+	require.Nil(t, q)
+	require.Equal(t, errors.EForbidden, errors.ErrorCode(svc.CreateAuthorization(ctx, newTestAuthorization("token5"))))
+
+	// the quota is per org
+	other := newTestAuthorization("token6")
+	other.OrgID = otherOrgID
+	other.Permissions = nil
+	require.NoError(t, svc.CreateAuthorization(ctx, other))
+}
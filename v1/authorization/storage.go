@@ -2,12 +2,16 @@ package authorization
 
 import (
 	"context"
+	"runtime"
+	"strings"
 
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
 	"github.com/influxdata/influxdb/v2/kv"
 	"github.com/influxdata/influxdb/v2/snowflake"
+	"github.com/influxdata/influxdb/v2/v1/authorization/index"
+	"github.com/opentracing/opentracing-go"
 )
 
 const MaxIDGenerationN = 100
@@ -21,25 +25,53 @@ var (
 type Store struct {
 	kvStore kv.Store
 	IDGen   platform.IDGenerator
+
+	byUserIndex *kv.Index
+	byOrgIndex  *kv.Index
 }
 
 func NewStore(kvStore kv.Store) (*Store, error) {
 	st := &Store{
-		kvStore: kvStore,
-		IDGen:   snowflake.NewDefaultIDGenerator(),
+		kvStore:     kvStore,
+		IDGen:       snowflake.NewDefaultIDGenerator(),
+		byUserIndex: kv.NewIndex(index.ByUserIDIndexMapping, kv.WithIndexReadPathEnabled),
+		byOrgIndex:  kv.NewIndex(index.ByOrgIDIndexMapping, kv.WithIndexReadPathEnabled),
 	}
 	return st, st.setup()
 }
 
 // View opens up a transaction that will not write to any data. Implementing interfaces
 // should take care to ensure that all view transactions do not mutate any data.
+// The transaction is traced in a span named after the operation opening it.
 func (s *Store) View(ctx context.Context, fn func(kv.Tx) error) error {
-	return s.kvStore.View(ctx, fn)
+	span, ctx := startTxSpan(ctx, "view")
+	defer span.Finish()
+	return tracing.LogError(span, s.kvStore.View(ctx, fn))
 }
 
-// Update opens up a transaction that will mutate data.
+// Update opens up a transaction that will mutate data. The transaction is
+// traced in a span named after the operation opening it.
 func (s *Store) Update(ctx context.Context, fn func(kv.Tx) error) error {
-	return s.kvStore.Update(ctx, fn)
+	span, ctx := startTxSpan(ctx, "update")
+	defer span.Finish()
+	return tracing.LogError(span, s.kvStore.Update(ctx, fn))
+}
+
+// startTxSpan starts the span of a transaction of kind view or update, named
+// after the function which called View or Update.
+func startTxSpan(ctx context.Context, kind string) (opentracing.Span, context.Context) {
+	name := "unknown"
+	var pcs [1]uintptr
+	if n := runtime.Callers(3, pcs[:]); n > 0 {
+		frame, _ := runtime.CallersFrames(pcs[:n]).Next()
+		name = frame.Function
+		if lastSlash := strings.LastIndexByte(name, '/'); lastSlash >= 0 {
+			name = name[lastSlash+1:]
+		}
+	}
+	span, ctx := tracing.StartSpanFromContextWithOperationName(ctx, name)
+	span.SetTag("tx", kind)
+	return span, ctx
 }
 
 func (s *Store) setup() error {
@@ -0,0 +1,103 @@
+package authorization
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+var (
+	auditBucket = []byte("legacy/authorizationauditv1")
+)
+
+func auditKey(seq uint64) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, seq)
+	return k
+}
+
+func decodeAuditRecord(v []byte) (*AuditRecord, error) {
+	r := &AuditRecord{}
+	if err := json.Unmarshal(v, r); err != nil {
+		return nil, &errors.Error{
+			Code: errors.EInternal,
+			Err:  err,
+		}
+	}
+	return r, nil
+}
+
+// AppendAuditRecord appends a record to the audit log, chaining it to the
+// last record of the log.
+func (s *Store) AppendAuditRecord(ctx context.Context, tx kv.Tx, r *AuditRecord) error {
+	b, err := tx.Bucket(auditBucket)
+	if err != nil {
+		return err
+	}
+
+	cur, err := b.ForwardCursor(nil, kv.WithCursorDirection(kv.CursorDescending))
+	if err != nil {
+		return err
+	}
+	_, v := cur.Next()
+	if err := cur.Err(); err != nil {
+		return err
+	}
+	if err := cur.Close(); err != nil {
+		return err
+	}
+
+	r.Seq, r.PrevHash = 1, ""
+	if v != nil {
+		last, err := decodeAuditRecord(v)
+		if err != nil {
+			return err
+		}
+		r.Seq, r.PrevHash = last.Seq+1, last.Hash
+	}
+	if r.Hash, err = r.computeHash(); err != nil {
+		return err
+	}
+
+	v, err = json.Marshal(r)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.EInternal,
+			Err:  err,
+		}
+	}
+	return b.Put(auditKey(r.Seq), v)
+}
+
+// forEachAuditRecord calls fn with the records of the audit log in order, or
+// in reverse order if descending, until fn returns false.
+func (s *Store) forEachAuditRecord(ctx context.Context, tx kv.Tx, descending bool, fn func(*AuditRecord) bool) error {
+	b, err := tx.Bucket(auditBucket)
+	if err != nil {
+		return err
+	}
+
+	direction := kv.CursorAscending
+	if descending {
+		direction = kv.CursorDescending
+	}
+	cur, err := b.ForwardCursor(nil, kv.WithCursorDirection(direction))
+	if err != nil {
+		return err
+	}
+	defer cur.Close()
+
+	for k, v := cur.Next(); k != nil; k, v = cur.Next() {
+		r, err := decodeAuditRecord(v)
+		if err != nil {
+			return err
+		}
+		if !fn(r) {
+			break
+		}
+	}
+	return cur.Err()
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kv"
+	errors3 "github.com/influxdata/influxdb/v2/pkg/errors"
 	jsonp "github.com/influxdata/influxdb/v2/pkg/jsonparser"
 	"github.com/influxdata/influxdb/v2/tenant"
 )
@@ -110,6 +111,10 @@ func (s *Store) CreateAuthorization(ctx context.Context, tx kv.Tx, a *influxdb.A
 		}
 	}
 
+	if err := s.insertIndexes(tx, a, encodedID); err != nil {
+		return err
+	}
+
 	b, err := tx.Bucket(authBucket)
 	if err != nil {
 		return err
@@ -124,6 +129,36 @@ func (s *Store) CreateAuthorization(ctx context.Context, tx kv.Tx, a *influxdb.A
 	return nil
 }
 
+// insertIndexes indexes an authorization by its user and organization.
+func (s *Store) insertIndexes(tx kv.Tx, a *influxdb.Authorization, encodedID []byte) error {
+	if userID, err := a.UserID.Encode(); err == nil {
+		if err := s.byUserIndex.Insert(tx, userID, encodedID); err != nil {
+			return errors.ErrInternalServiceError(err)
+		}
+	}
+	if orgID, err := a.OrgID.Encode(); err == nil {
+		if err := s.byOrgIndex.Insert(tx, orgID, encodedID); err != nil {
+			return errors.ErrInternalServiceError(err)
+		}
+	}
+	return nil
+}
+
+// deleteIndexes removes an authorization from the indexes by its user and organization.
+func (s *Store) deleteIndexes(tx kv.Tx, a *influxdb.Authorization, encodedID []byte) error {
+	if userID, err := a.UserID.Encode(); err == nil {
+		if err := s.byUserIndex.Delete(tx, userID, encodedID); err != nil {
+			return errors.ErrInternalServiceError(err)
+		}
+	}
+	if orgID, err := a.OrgID.Encode(); err == nil {
+		if err := s.byOrgIndex.Delete(tx, orgID, encodedID); err != nil {
+			return errors.ErrInternalServiceError(err)
+		}
+	}
+	return nil
+}
+
 // GetAuthorization gets an authorization by its ID from the auth bucket in kv
 func (s *Store) GetAuthorizationByID(ctx context.Context, tx kv.Tx, id platform.ID) (*influxdb.Authorization, error) {
 	encodedID, err := id.Encode()
@@ -182,22 +217,128 @@ func (s *Store) GetAuthorizationByToken(ctx context.Context, tx kv.Tx, token str
 	return s.GetAuthorizationByID(ctx, tx, id)
 }
 
-// ListAuthorizations returns all the authorizations matching a set of FindOptions. This function is used for
+// ListAuthorizations returns the authorizations matching a filter, ordered by ID, and paged by
+// the offset, limit, after and descending of the first FindOptions if any. This function is used for
 // FindAuthorizationByID, FindAuthorizationByToken, and FindAuthorizations in the AuthorizationService implementation
-func (s *Store) ListAuthorizations(ctx context.Context, tx kv.Tx, f influxdb.AuthorizationFilter) ([]*influxdb.Authorization, error) {
-	var as []*influxdb.Authorization
-	pred := authorizationsPredicateFn(f)
+func (s *Store) ListAuthorizations(ctx context.Context, tx kv.Tx, f influxdb.AuthorizationFilter, opt ...influxdb.FindOptions) (as []*influxdb.Authorization, retErr error) {
+	var o influxdb.FindOptions
+	if len(opt) > 0 {
+		o = opt[0]
+	}
+
+	// a user or an org has few authorizations, found with the indexes
+	if f.ID == nil && f.Token == nil {
+		if f.UserID != nil {
+			return s.listAuthorizationsByIndex(ctx, tx, s.byUserIndex, *f.UserID, f, o)
+		}
+		if f.OrgID != nil {
+			return s.listAuthorizationsByIndex(ctx, tx, s.byOrgIndex, *f.OrgID, f, o)
+		}
+	}
+
+	b, err := tx.Bucket(authBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []kv.CursorOption{kv.WithCursorHints(kv.WithCursorHintPredicate(authorizationsPredicateFn(f)))}
+	if o.Descending {
+		opts = append(opts, kv.WithCursorDirection(kv.CursorDescending))
+	}
+
+	var seek []byte
+	if o.After != nil {
+		seek, err = o.After.Encode()
+		if err != nil {
+			return nil, ErrInvalidAuthID
+		}
+	}
+
+	cursor, err := b.ForwardCursor(seek, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer errors3.Capture(&retErr, cursor.Close)()
+
+	filterFn := filterAuthorizationsFn(f)
+	count := 0
+	for k, v := cursor.Next(); k != nil; k, v = cursor.Next() {
+		a := &influxdb.Authorization{}
+		if err := decodeAuthorization(v, a); err != nil {
+			return nil, err
+		}
+		// the seek of a descending cursor lands after the missing ID it seeks
+		if o.After != nil && (a.ID == *o.After || (o.Descending && a.ID > *o.After)) {
+			continue
+		}
+		if !filterFn(a) {
+			continue
+		}
+		if count < o.Offset {
+			count++
+			continue
+		}
+
+		as = append(as, a)
+		if o.Limit > 0 && len(as) >= o.Limit {
+			break
+		}
+	}
+
+	return as, cursor.Err()
+}
+
+// listAuthorizationsByIndex returns the page of the authorizations of the foreign key id of an
+// index which match a filter.
+func (s *Store) listAuthorizationsByIndex(ctx context.Context, tx kv.Tx, idx *kv.Index, id platform.ID, f influxdb.AuthorizationFilter, o influxdb.FindOptions) ([]*influxdb.Authorization, error) {
+	fk, err := id.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Err:  err,
+		}
+	}
+
+	// the index walks the authorizations in the order of their IDs
 	filterFn := filterAuthorizationsFn(f)
-	err := s.forEachAuthorization(ctx, tx, pred, func(a *influxdb.Authorization) bool {
+	var matches []*influxdb.Authorization
+	err = idx.Walk(ctx, tx, fk, func(k, v []byte) (bool, error) {
+		a := &influxdb.Authorization{}
+		if err := decodeAuthorization(v, a); err != nil {
+			return false, err
+		}
 		if filterFn(a) {
-			as = append(as, a)
+			matches = append(matches, a)
 		}
-		return true
+		return true, nil
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	if o.Descending {
+		for i, j := 0, len(matches)-1; i < j; i, j = i+1, j-1 {
+			matches[i], matches[j] = matches[j], matches[i]
+		}
+	}
+
+	var as []*influxdb.Authorization
+	count := 0
+	for _, a := range matches {
+		if o.After != nil && (a.ID == *o.After || (a.ID < *o.After) != o.Descending) {
+			continue
+		}
+		if count < o.Offset {
+			count++
+			continue
+		}
+
+		as = append(as, a)
+		if o.Limit > 0 && len(as) >= o.Limit {
+			break
+		}
+	}
+
 	return as, nil
 }
 
@@ -280,6 +421,30 @@ func (s *Store) UpdateAuthorization(ctx context.Context, tx kv.Tx, id platform.I
 
 }
 
+// UpdateAuthorizationToken replaces the token of an authorization, moving its
+// entry in the token index to the new token.
+func (s *Store) UpdateAuthorizationToken(ctx context.Context, tx kv.Tx, a *influxdb.Authorization, token string) (*influxdb.Authorization, error) {
+	err := unique(ctx, tx, authIndex, authIndexKey(token))
+	if err == kv.NotUniqueError {
+		return nil, ErrTokenAlreadyExistsError
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := authIndexBucket(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := idx.Delete(authIndexKey(a.Token)); err != nil {
+		return nil, errors.ErrInternalServiceError(err)
+	}
+
+	a.Token = token
+	return s.UpdateAuthorization(ctx, tx, a.ID, a)
+}
+
 // DeleteAuthorization removes an authorization from storage
 func (s *Store) DeleteAuthorization(ctx context.Context, tx kv.Tx, id platform.ID) error {
 	a, err := s.GetAuthorizationByID(ctx, tx, id)
@@ -306,6 +471,10 @@ func (s *Store) DeleteAuthorization(ctx context.Context, tx kv.Tx, id platform.I
 		return errors.ErrInternalServiceError(err)
 	}
 
+	if err := s.deleteIndexes(tx, a, encodedID); err != nil {
+		return err
+	}
+
 	if err := b.Delete(encodedID); err != nil {
 		return errors.ErrInternalServiceError(err)
 	}
@@ -460,6 +629,24 @@ func filterAuthorizationsFn(filter influxdb.AuthorizationFilter) predicateFunc {
 		}
 	}
 
+	if filter.UsedBefore != nil {
+		exp := *filter.UsedBefore
+		prevFn := pred
+		pred = func(a *influxdb.Authorization) bool {
+			prev := prevFn == nil || prevFn(a)
+			return prev && (a.LastUsedAt == nil || a.LastUsedAt.Before(exp))
+		}
+	}
+
+	if len(filter.Labels) > 0 {
+		exp := filter.Labels
+		prevFn := pred
+		pred = func(a *influxdb.Authorization) bool {
+			prev := prevFn == nil || prevFn(a)
+			return prev && a.HasLabels(exp)
+		}
+	}
+
 	if pred == nil {
 		pred = func(a *influxdb.Authorization) bool { return true }
 	}
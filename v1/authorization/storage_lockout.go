@@ -0,0 +1,98 @@
+package authorization
+
+import (
+	"context"
+	"encoding/json"
+	eBase "errors"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+var (
+	lockoutBucket = []byte("legacy/authorizationlockoutv1")
+)
+
+// passwordLockout are the consecutive failed password attempts of an
+// authorization, and until when it is locked.
+type passwordLockout struct {
+	Failures    int       `json:"failures"`
+	LockedUntil time.Time `json:"lockedUntil,omitempty"`
+}
+
+// GetLockout returns the lockout of an authorization, which is empty if it
+// has no failed password attempts.
+func (s *Store) GetLockout(ctx context.Context, tx kv.Tx, id platform.ID) (*passwordLockout, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return nil, ErrInvalidAuthIDError(err)
+	}
+
+	b, err := tx.Bucket(lockoutBucket)
+	if err != nil {
+		if eBase.Is(err, kv.ErrBucketNotFound) {
+			return &passwordLockout{}, nil
+		}
+		return nil, UnavailablePasswordServiceError(err)
+	}
+
+	v, err := b.Get(encodedID)
+	if kv.IsNotFound(err) {
+		return &passwordLockout{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	l := &passwordLockout{}
+	if err := json.Unmarshal(v, l); err != nil {
+		return nil, &errors.Error{
+			Code: errors.EInternal,
+			Err:  err,
+		}
+	}
+	return l, nil
+}
+
+// PutLockout stores the lockout of an authorization.
+func (s *Store) PutLockout(ctx context.Context, tx kv.Tx, id platform.ID, l *passwordLockout) error {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return ErrInvalidAuthIDError(err)
+	}
+
+	b, err := tx.Bucket(lockoutBucket)
+	if err != nil {
+		return UnavailablePasswordServiceError(err)
+	}
+
+	v, err := json.Marshal(l)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.EInternal,
+			Err:  err,
+		}
+	}
+	return b.Put(encodedID, v)
+}
+
+// DeleteLockout clears the failed password attempts of an authorization.
+func (s *Store) DeleteLockout(ctx context.Context, tx kv.Tx, id platform.ID) error {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return ErrInvalidAuthIDError(err)
+	}
+
+	b, err := tx.Bucket(lockoutBucket)
+	if err != nil {
+		if eBase.Is(err, kv.ErrBucketNotFound) {
+			return nil
+		}
+		return UnavailablePasswordServiceError(err)
+	}
+
+	if err := b.Delete(encodedID); err != nil && !kv.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
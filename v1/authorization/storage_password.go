@@ -2,6 +2,8 @@ package authorization
 
 import (
 	"context"
+	eBase "errors"
+	"time"
 
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
@@ -9,7 +11,8 @@ import (
 )
 
 var (
-	passwordBucket = []byte("legacy/authorizationPasswordv1")
+	passwordBucket     = []byte("legacy/authorizationPasswordv1")
+	passwordTimeBucket = []byte("legacy/authorizationPasswordTimev1")
 )
 
 // UnavailablePasswordServiceError is used if we aren't able to add the
@@ -64,6 +67,72 @@ func (s *Store) DeletePassword(ctx context.Context, tx kv.Tx, id platform.ID) er
 		return UnavailablePasswordServiceError(err)
 	}
 
-	return b.Delete(encodedID)
+	if err := b.Delete(encodedID); err != nil {
+		return err
+	}
+
+	tb, err := tx.Bucket(passwordTimeBucket)
+	if err != nil {
+		if eBase.Is(err, kv.ErrBucketNotFound) {
+			return nil
+		}
+		return UnavailablePasswordServiceError(err)
+	}
+	if err := tb.Delete(encodedID); err != nil && !kv.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
 
+// GetPasswordTime returns when the password of an authorization was set, or
+// the zero time if it is unknown.
+func (s *Store) GetPasswordTime(ctx context.Context, tx kv.Tx, id platform.ID) (time.Time, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return time.Time{}, ErrInvalidAuthIDError(err)
+	}
+
+	b, err := tx.Bucket(passwordTimeBucket)
+	if err != nil {
+		if eBase.Is(err, kv.ErrBucketNotFound) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, UnavailablePasswordServiceError(err)
+	}
+
+	v, err := b.Get(encodedID)
+	if kv.IsNotFound(err) {
+		return time.Time{}, nil
+	} else if err != nil {
+		return time.Time{}, err
+	}
+	var t time.Time
+	if err := t.UnmarshalText(v); err != nil {
+		return time.Time{}, err
+	}
+	return t, nil
+}
+
+// SetPasswordTime records when the password of an authorization was set.
+// Stores not yet migrated, opened offline by recovery commands, do not
+// record it.
+func (s *Store) SetPasswordTime(ctx context.Context, tx kv.Tx, id platform.ID, t time.Time) error {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return ErrInvalidAuthIDError(err)
+	}
+
+	b, err := tx.Bucket(passwordTimeBucket)
+	if err != nil {
+		if eBase.Is(err, kv.ErrBucketNotFound) {
+			return nil
+		}
+		return UnavailablePasswordServiceError(err)
+	}
+
+	v, err := t.UTC().MarshalText()
+	if err != nil {
+		return err
+	}
+	return b.Put(encodedID, v)
 }
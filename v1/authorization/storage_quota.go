@@ -0,0 +1,118 @@
+package authorization
+
+import (
+	"context"
+	"encoding/json"
+	eBase "errors"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+var (
+	quotaBucket = []byte("legacy/authorizationquotav1")
+)
+
+// GetTokenQuota returns the token quota of an organization, or nil if it has
+// none.
+func (s *Store) GetTokenQuota(ctx context.Context, tx kv.Tx, orgID platform.ID) (*TokenQuota, error) {
+	encodedID, err := orgID.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Err:  err,
+		}
+	}
+
+	b, err := tx.Bucket(quotaBucket)
+	if err != nil {
+		if eBase.Is(err, kv.ErrBucketNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	v, err := b.Get(encodedID)
+	if kv.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	q := &TokenQuota{}
+	if err := json.Unmarshal(v, q); err != nil {
+		return nil, &errors.Error{
+			Code: errors.EInternal,
+			Err:  err,
+		}
+	}
+	return q, nil
+}
+
+// PutTokenQuota sets the token quota of an organization.
+func (s *Store) PutTokenQuota(ctx context.Context, tx kv.Tx, orgID platform.ID, q TokenQuota) error {
+	encodedID, err := orgID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Err:  err,
+		}
+	}
+
+	b, err := tx.Bucket(quotaBucket)
+	if err != nil {
+		return err
+	}
+
+	v, err := json.Marshal(q)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.EInternal,
+			Err:  err,
+		}
+	}
+	return b.Put(encodedID, v)
+}
+
+// DeleteTokenQuota removes the token quota of an organization.
+func (s *Store) DeleteTokenQuota(ctx context.Context, tx kv.Tx, orgID platform.ID) error {
+	encodedID, err := orgID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Err:  err,
+		}
+	}
+
+	b, err := tx.Bucket(quotaBucket)
+	if err != nil {
+		if eBase.Is(err, kv.ErrBucketNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if err := b.Delete(encodedID); err != nil && !kv.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// CountAuthorizationsByOrg returns the number of authorizations of an
+// organization, found with its index.
+func (s *Store) CountAuthorizationsByOrg(ctx context.Context, tx kv.Tx, orgID platform.ID) (int, error) {
+	fk, err := orgID.Encode()
+	if err != nil {
+		return 0, &errors.Error{
+			Code: errors.EInvalid,
+			Err:  err,
+		}
+	}
+
+	n := 0
+	err = s.byOrgIndex.Walk(ctx, tx, fk, func(k, v []byte) (bool, error) {
+		n++
+		return true, nil
+	})
+	return n, err
+}
@@ -0,0 +1,78 @@
+package authorization
+
+import (
+	"context"
+	"encoding/json"
+	eBase "errors"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+var (
+	revocationBucket = []byte("legacy/authorizationrevocationv1")
+)
+
+// GetRevocation returns the revocation of an authorization, or nil if it
+// was never revoked.
+func (s *Store) GetRevocation(ctx context.Context, tx kv.Tx, id platform.ID) (*Revocation, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return nil, ErrInvalidAuthIDError(err)
+	}
+
+	b, err := tx.Bucket(revocationBucket)
+	if err != nil {
+		if eBase.Is(err, kv.ErrBucketNotFound) {
+			return nil, nil
+		}
+		return nil, &errors.Error{
+			Code: errors.EInternal,
+			Err:  err,
+		}
+	}
+
+	v, err := b.Get(encodedID)
+	if kv.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, &errors.Error{
+			Code: errors.EInternal,
+			Err:  err,
+		}
+	}
+	r := &Revocation{}
+	if err := json.Unmarshal(v, r); err != nil {
+		return nil, &errors.Error{
+			Code: errors.EInternal,
+			Err:  err,
+		}
+	}
+	return r, nil
+}
+
+// PutRevocation stores the tombstone of a revoked authorization.
+func (s *Store) PutRevocation(ctx context.Context, tx kv.Tx, r *Revocation) error {
+	encodedID, err := r.AuthID.Encode()
+	if err != nil {
+		return ErrInvalidAuthIDError(err)
+	}
+
+	b, err := tx.Bucket(revocationBucket)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.EInternal,
+			Err:  err,
+		}
+	}
+
+	v, err := json.Marshal(r)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.EInternal,
+			Err:  err,
+		}
+	}
+	return b.Put(encodedID, v)
+}
@@ -0,0 +1,82 @@
+package authorization
+
+import (
+	"context"
+	eBase "errors"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+var (
+	usernameBucket = []byte("legacy/authorizationusernamev1")
+)
+
+// GetUsername returns the username recorded for an authorization whose token
+// is stored hashed, or "" if none was recorded.
+func (s *Store) GetUsername(ctx context.Context, tx kv.Tx, id platform.ID) (string, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return "", ErrInvalidAuthIDError(err)
+	}
+
+	b, err := tx.Bucket(usernameBucket)
+	if err != nil {
+		if eBase.Is(err, kv.ErrBucketNotFound) {
+			return "", nil
+		}
+		return "", &errors.Error{
+			Code: errors.EInternal,
+			Err:  err,
+		}
+	}
+
+	v, err := b.Get(encodedID)
+	if kv.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", &errors.Error{
+			Code: errors.EInternal,
+			Err:  err,
+		}
+	}
+	return string(v), nil
+}
+
+// PutUsername records the username of an authorization.
+func (s *Store) PutUsername(ctx context.Context, tx kv.Tx, id platform.ID, username string) error {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return ErrInvalidAuthIDError(err)
+	}
+
+	b, err := tx.Bucket(usernameBucket)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.EInternal,
+			Err:  err,
+		}
+	}
+	return b.Put(encodedID, []byte(username))
+}
+
+// DeleteUsername forgets the username of an authorization.
+func (s *Store) DeleteUsername(ctx context.Context, tx kv.Tx, id platform.ID) error {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return ErrInvalidAuthIDError(err)
+	}
+
+	b, err := tx.Bucket(usernameBucket)
+	if err != nil {
+		if eBase.Is(err, kv.ErrBucketNotFound) {
+			return nil
+		}
+		return &errors.Error{
+			Code: errors.EInternal,
+			Err:  err,
+		}
+	}
+	return b.Delete(encodedID)
+}
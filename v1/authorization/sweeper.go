@@ -0,0 +1,60 @@
+package authorization
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultSweepInterval is the default interval of an ExpirationSweeper.
+const DefaultSweepInterval = time.Minute
+
+// ExpirationSweeper periodically marks the expired authorizations of a Service
+// inactive.
+type ExpirationSweeper struct {
+	svc      *Service
+	log      *zap.Logger
+	interval time.Duration
+}
+
+// NewExpirationSweeper constructs an ExpirationSweeper sweeping svc every
+// interval.
+func NewExpirationSweeper(log *zap.Logger, svc *Service, interval time.Duration) *ExpirationSweeper {
+	return &ExpirationSweeper{
+		svc:      svc,
+		log:      log,
+		interval: interval,
+	}
+}
+
+// Run sweeps the expired authorizations until ctx is done.
+func (s *ExpirationSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.sweep(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ExpirationSweeper) sweep(ctx context.Context) {
+	expired, err := s.svc.DeactivateExpired(ctx, time.Now())
+	if err != nil {
+		s.log.Error("Failed to deactivate expired authorizations", zap.Error(err))
+		return
+	}
+	for _, a := range expired {
+		s.log.Info("Deactivated expired authorization",
+			zap.Stringer("authID", a.ID),
+			zap.Stringer("orgID", a.OrgID),
+			zap.Stringer("userID", a.UserID),
+			zap.Timep("expiresAt", a.ExpiresAt))
+	}
+}
@@ -0,0 +1,141 @@
+package authorization
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+// hashedTokenPrefix marks the tokens stored as their SHA-256 hash.
+const hashedTokenPrefix = "sha256:"
+
+// WithTokenHashing stores only the SHA-256 hash of the tokens of the
+// authorizations created or rotated by the Service. Tokens stored in plain
+// text are still found, until HashTokens migrates them.
+func WithTokenHashing(hash bool) func(*Service) {
+	return func(s *Service) {
+		s.hashTokens = hash
+	}
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hashedTokenPrefix + hex.EncodeToString(sum[:])
+}
+
+func isHashedToken(token string) bool {
+	return strings.HasPrefix(token, hashedTokenPrefix)
+}
+
+// storedToken returns the value under which the Service stores a token.
+func (s *Service) storedToken(token string) string {
+	if s.hashTokens {
+		return hashToken(token)
+	}
+	return token
+}
+
+// toStored returns a copy of an authorization holding its stored token.
+func (s *Service) toStored(a *influxdb.Authorization) *influxdb.Authorization {
+	stored := *a
+	stored.Token = s.storedToken(a.Token)
+	return &stored
+}
+
+// keepUsername records the token an authorization is created with as its
+// username when the token is stored hashed: v1 clients send the token as the
+// name of their user, along with a password, so it is no secret and the users
+// are listed by it.
+func (s *Service) keepUsername(ctx context.Context, tx kv.Tx, id platform.ID, token string) error {
+	if !s.hashTokens {
+		return nil
+	}
+	return s.store.PutUsername(ctx, tx, id, token)
+}
+
+// FindUsername returns the username of an authorization: the username
+// recorded when its token was hashed, else its token if stored in plain text.
+// An authorization whose generated token is stored hashed has no username.
+func (s *Service) FindUsername(ctx context.Context, id platform.ID) (string, error) {
+	var name string
+	err := s.store.View(ctx, func(tx kv.Tx) error {
+		a, err := s.store.GetAuthorizationByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if !isHashedToken(a.Token) {
+			name = a.Token
+			return nil
+		}
+		name, err = s.store.GetUsername(ctx, tx, id)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// getAuthorizationByToken looks a token up by its hash, then in plain text.
+// The authorization found holds the token looked up rather than its hash.
+// A stored hash is not itself a token.
+func (s *Service) getAuthorizationByToken(ctx context.Context, tx kv.Tx, token string) (*influxdb.Authorization, error) {
+	a, err := s.store.GetAuthorizationByToken(ctx, tx, hashToken(token))
+	if errors.ErrorCode(err) == errors.ENotFound && !isHashedToken(token) {
+		a, err = s.store.GetAuthorizationByToken(ctx, tx, token)
+	}
+	if err != nil {
+		return nil, err
+	}
+	a.Token = token
+	return a, nil
+}
+
+// uniqueToken returns an error if a token is already stored, hashed or not.
+func (s *Service) uniqueToken(ctx context.Context, tx kv.Tx, token string) error {
+	for _, key := range []string{token, hashToken(token)} {
+		if err := unique(ctx, tx, authIndex, authIndexKey(key)); err != nil {
+			return ErrTokenAlreadyExistsError
+		}
+	}
+	return nil
+}
+
+// HashTokens replaces the tokens stored in plain text with their hash, keeping
+// them as the usernames of their authorizations, and returns the number of
+// tokens hashed. Hashed tokens are found whether or not the Service hashes
+// the tokens it stores.
+func (s *Service) HashTokens(ctx context.Context) (int, error) {
+	var plain []*influxdb.Authorization
+	err := s.store.Update(ctx, func(tx kv.Tx) error {
+		err := s.store.forEachAuthorization(ctx, tx, nil, func(a *influxdb.Authorization) bool {
+			if !isHashedToken(a.Token) {
+				plain = append(plain, a)
+			}
+			return true
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, a := range plain {
+			if err := s.store.PutUsername(ctx, tx, a.ID, a.Token); err != nil {
+				return err
+			}
+			if _, err := s.store.UpdateAuthorizationToken(ctx, tx, a, hashToken(a.Token)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(plain), nil
+}
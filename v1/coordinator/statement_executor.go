@@ -12,12 +12,16 @@ import (
 	"github.com/influxdata/influxdb/v2/authorizer"
 	iql "github.com/influxdata/influxdb/v2/influxql"
 	"github.com/influxdata/influxdb/v2/influxql/query"
+	"github.com/influxdata/influxdb/v2/kit/platform"
 	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/models"
 	"github.com/influxdata/influxdb/v2/pkg/tracing"
 	"github.com/influxdata/influxdb/v2/pkg/tracing/fields"
 	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/v1/services/continuousquery"
 	"github.com/influxdata/influxdb/v2/v1/services/meta"
+	"github.com/influxdata/influxdb/v2/v1/services/subscription"
+	"github.com/influxdata/influxdb/v2/v1/services/user"
 	"github.com/influxdata/influxql"
 )
 
@@ -37,6 +41,22 @@ type StatementExecutor struct {
 
 	DBRP influxdb.DBRPMappingService
 
+	// BucketService manages the buckets the retention policies are mapped to.
+	// The retention policy statements are not implemented when it is nil.
+	BucketService influxdb.BucketService
+
+	// ContinuousQueries manages the continuous queries. The continuous query
+	// statements are not implemented when it is nil.
+	ContinuousQueries ContinuousQueryService
+
+	// Subscriptions manages the subscriptions. The subscription statements
+	// are not implemented when it is nil.
+	Subscriptions SubscriptionService
+
+	// Users manages the v1 users and their privileges. The user statements
+	// are not implemented when it is nil.
+	Users UserService
+
 	// Select statement limits
 	MaxSelectPointN   int
 	MaxSelectSeriesN  int
@@ -55,21 +75,45 @@ func (e *StatementExecutor) ExecuteStatement(ctx context.Context, stmt influxql.
 	var err error
 	switch stmt := stmt.(type) {
 	case *influxql.AlterRetentionPolicyStatement:
-		err = iql.ErrNotImplemented("ALTER RETENTION POLICY")
+		if e.BucketService == nil {
+			err = iql.ErrNotImplemented("ALTER RETENTION POLICY")
+			break
+		}
+		err = e.executeAlterRetentionPolicyStatement(ctx, stmt, ectx)
 	case *influxql.CreateContinuousQueryStatement:
-		err = iql.ErrNotImplemented("CREATE CONTINUOUS QUERY")
+		if e.ContinuousQueries == nil {
+			err = iql.ErrNotImplemented("CREATE CONTINUOUS QUERY")
+			break
+		}
+		err = e.ContinuousQueries.CreateContinuousQuery(ctx, ectx.OrgID, stmt)
 	case *influxql.CreateDatabaseStatement:
 		err = iql.ErrNotImplemented("CREATE DATABASE")
 	case *influxql.CreateRetentionPolicyStatement:
-		err = iql.ErrNotImplemented("CREATE RETENTION POLICY")
+		if e.BucketService == nil {
+			err = iql.ErrNotImplemented("CREATE RETENTION POLICY")
+			break
+		}
+		err = e.executeCreateRetentionPolicyStatement(ctx, stmt, ectx)
 	case *influxql.CreateSubscriptionStatement:
-		err = iql.ErrNotImplemented("CREATE SUBSCRIPTION")
+		if e.Subscriptions == nil {
+			err = iql.ErrNotImplemented("CREATE SUBSCRIPTION")
+			break
+		}
+		err = e.Subscriptions.CreateSubscription(ctx, ectx.OrgID, stmt)
 	case *influxql.CreateUserStatement:
-		err = iql.ErrNotImplemented("CREATE USER")
+		if e.Users == nil {
+			err = iql.ErrNotImplemented("CREATE USER")
+			break
+		}
+		err = e.Users.CreateUser(ctx, ectx.OrgID, stmt.Name, stmt.Password, stmt.Admin)
 	case *influxql.DeleteSeriesStatement:
 		return e.executeDeleteSeriesStatement(ctx, stmt, ectx.Database, ectx)
 	case *influxql.DropContinuousQueryStatement:
-		err = iql.ErrNotImplemented("DROP CONTINUOUS QUERY")
+		if e.ContinuousQueries == nil {
+			err = iql.ErrNotImplemented("DROP CONTINUOUS QUERY")
+			break
+		}
+		err = e.ContinuousQueries.DropContinuousQuery(ctx, ectx.OrgID, stmt.Database, stmt.Name)
 	case *influxql.DropDatabaseStatement:
 		err = iql.ErrNotImplemented("DROP DATABASE")
 	case *influxql.DropMeasurementStatement:
@@ -77,13 +121,25 @@ func (e *StatementExecutor) ExecuteStatement(ctx context.Context, stmt influxql.
 	case *influxql.DropSeriesStatement:
 		err = iql.ErrNotImplemented("DROP SERIES")
 	case *influxql.DropRetentionPolicyStatement:
-		err = iql.ErrNotImplemented("DROP RETENTION POLICY")
+		if e.BucketService == nil {
+			err = iql.ErrNotImplemented("DROP RETENTION POLICY")
+			break
+		}
+		err = e.executeDropRetentionPolicyStatement(ctx, stmt, ectx)
 	case *influxql.DropShardStatement:
 		err = iql.ErrNotImplemented("DROP SHARD")
 	case *influxql.DropSubscriptionStatement:
-		err = iql.ErrNotImplemented("DROP SUBSCRIPTION")
+		if e.Subscriptions == nil {
+			err = iql.ErrNotImplemented("DROP SUBSCRIPTION")
+			break
+		}
+		err = e.Subscriptions.DropSubscription(ctx, ectx.OrgID, stmt.Database, stmt.RetentionPolicy, stmt.Name)
 	case *influxql.DropUserStatement:
-		err = iql.ErrNotImplemented("DROP USER")
+		if e.Users == nil {
+			err = iql.ErrNotImplemented("DROP USER")
+			break
+		}
+		err = e.Users.DropUser(ctx, ectx.OrgID, stmt.Name)
 	case *influxql.ExplainStatement:
 		if stmt.Analyze {
 			rows, err = e.executeExplainAnalyzeStatement(ctx, stmt, ectx)
@@ -91,21 +147,45 @@ func (e *StatementExecutor) ExecuteStatement(ctx context.Context, stmt influxql.
 			rows, err = e.executeExplainStatement(ctx, stmt, ectx)
 		}
 	case *influxql.GrantStatement:
-		err = iql.ErrNotImplemented("GRANT")
+		if e.Users == nil {
+			err = iql.ErrNotImplemented("GRANT")
+			break
+		}
+		err = e.Users.GrantPrivilege(ctx, ectx.OrgID, stmt.User, stmt.On, stmt.Privilege)
 	case *influxql.GrantAdminStatement:
-		err = iql.ErrNotImplemented("GRANT ALL")
+		if e.Users == nil {
+			err = iql.ErrNotImplemented("GRANT ALL")
+			break
+		}
+		err = e.Users.SetUserAdmin(ctx, ectx.OrgID, stmt.User, true)
 	case *influxql.RevokeStatement:
-		err = iql.ErrNotImplemented("REVOKE")
+		if e.Users == nil {
+			err = iql.ErrNotImplemented("REVOKE")
+			break
+		}
+		err = e.Users.RevokePrivilege(ctx, ectx.OrgID, stmt.User, stmt.On, stmt.Privilege)
 	case *influxql.RevokeAdminStatement:
-		err = iql.ErrNotImplemented("REVOKE ALL")
+		if e.Users == nil {
+			err = iql.ErrNotImplemented("REVOKE ALL")
+			break
+		}
+		err = e.Users.SetUserAdmin(ctx, ectx.OrgID, stmt.User, false)
 	case *influxql.ShowContinuousQueriesStatement:
-		rows, err = nil, iql.ErrNotImplemented("SHOW CONTINUOUS QUERIES")
+		if e.ContinuousQueries == nil {
+			rows, err = nil, iql.ErrNotImplemented("SHOW CONTINUOUS QUERIES")
+			break
+		}
+		rows, err = e.executeShowContinuousQueriesStatement(ctx, ectx)
 	case *influxql.ShowDatabasesStatement:
 		rows, err = e.executeShowDatabasesStatement(ctx, stmt, ectx)
 	case *influxql.ShowDiagnosticsStatement:
 		rows, err = nil, iql.ErrNotImplemented("SHOW DIAGNOSTICS")
 	case *influxql.ShowGrantsForUserStatement:
-		rows, err = nil, iql.ErrNotImplemented("SHOW GRANTS")
+		if e.Users == nil {
+			rows, err = nil, iql.ErrNotImplemented("SHOW GRANTS")
+			break
+		}
+		rows, err = e.executeShowGrantsForUserStatement(ctx, stmt, ectx)
 	case *influxql.ShowMeasurementsStatement:
 		return e.executeShowMeasurementsStatement(ctx, stmt, ectx)
 	case *influxql.ShowMeasurementCardinalityStatement:
@@ -121,15 +201,27 @@ func (e *StatementExecutor) ExecuteStatement(ctx context.Context, stmt influxql.
 	case *influxql.ShowStatsStatement:
 		rows, err = nil, iql.ErrNotImplemented("SHOW STATS")
 	case *influxql.ShowSubscriptionsStatement:
-		rows, err = nil, iql.ErrNotImplemented("SHOW SUBSCRIPTIONS")
+		if e.Subscriptions == nil {
+			rows, err = nil, iql.ErrNotImplemented("SHOW SUBSCRIPTIONS")
+			break
+		}
+		rows, err = e.executeShowSubscriptionsStatement(ctx, ectx)
 	case *influxql.ShowTagKeysStatement:
 		return e.executeShowTagKeys(ctx, stmt, ectx)
 	case *influxql.ShowTagValuesStatement:
 		return e.executeShowTagValues(ctx, stmt, ectx)
 	case *influxql.ShowUsersStatement:
-		rows, err = nil, iql.ErrNotImplemented("SHOW USERS")
+		if e.Users == nil {
+			rows, err = nil, iql.ErrNotImplemented("SHOW USERS")
+			break
+		}
+		rows, err = e.executeShowUsersStatement(ctx, ectx)
 	case *influxql.SetPasswordUserStatement:
-		err = iql.ErrNotImplemented("SET PASSWORD")
+		if e.Users == nil {
+			err = iql.ErrNotImplemented("SET PASSWORD")
+			break
+		}
+		err = e.Users.SetUserPassword(ctx, ectx.OrgID, stmt.Name, stmt.Password)
 	case *influxql.ShowQueriesStatement, *influxql.KillQueryStatement:
 		err = iql.ErrNotImplemented("SHOW QUERIES")
 	default:
@@ -524,6 +616,221 @@ func (e *StatementExecutor) executeShowMeasurementsStatement(ctx context.Context
 
 }
 
+func (e *StatementExecutor) executeShowContinuousQueriesStatement(ctx context.Context, ectx *query.ExecutionContext) (models.Rows, error) {
+	cqs, err := e.ContinuousQueries.FindContinuousQueries(ctx, ectx.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	// As in 1.x, there is a row for each database.
+	var rows models.Rows
+	for _, cq := range cqs {
+		if len(rows) == 0 || rows[len(rows)-1].Name != cq.Database {
+			rows = append(rows, &models.Row{Name: cq.Database, Columns: []string{"name", "query"}})
+		}
+		row := rows[len(rows)-1]
+		row.Values = append(row.Values, []interface{}{cq.Name, cq.Query})
+	}
+	return rows, nil
+}
+
+func (e *StatementExecutor) executeShowSubscriptionsStatement(ctx context.Context, ectx *query.ExecutionContext) (models.Rows, error) {
+	subs, err := e.Subscriptions.FindSubscriptions(ctx, ectx.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	// As in 1.x, there is a row for each database.
+	var rows models.Rows
+	for _, sub := range subs {
+		if len(rows) == 0 || rows[len(rows)-1].Name != sub.Database {
+			rows = append(rows, &models.Row{Name: sub.Database, Columns: []string{"retention_policy", "name", "mode", "destinations"}})
+		}
+		row := rows[len(rows)-1]
+		row.Values = append(row.Values, []interface{}{sub.RetentionPolicy, sub.Name, sub.Mode, sub.Destinations})
+	}
+	return rows, nil
+}
+
+func (e *StatementExecutor) executeShowUsersStatement(ctx context.Context, ectx *query.ExecutionContext) (models.Rows, error) {
+	users, err := e.Users.FindUsers(ctx, ectx.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	row := &models.Row{Columns: []string{"user", "admin"}}
+	for _, u := range users {
+		row.Values = append(row.Values, []interface{}{u.Name, u.Admin})
+	}
+	return []*models.Row{row}, nil
+}
+
+func (e *StatementExecutor) executeShowGrantsForUserStatement(ctx context.Context, q *influxql.ShowGrantsForUserStatement, ectx *query.ExecutionContext) (models.Rows, error) {
+	privileges, err := e.Users.FindUserPrivileges(ctx, ectx.OrgID, q.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	dbs := make([]string, 0, len(privileges))
+	for db := range privileges {
+		dbs = append(dbs, db)
+	}
+	sort.Strings(dbs)
+
+	row := &models.Row{Columns: []string{"database", "privilege"}}
+	for _, db := range dbs {
+		row.Values = append(row.Values, []interface{}{db, privileges[db].String()})
+	}
+	return []*models.Row{row}, nil
+}
+
+// findRetentionPolicy returns the mapping of a retention policy of a
+// database, or nil if the database has no such retention policy. An error is
+// returned if the database does not exist.
+func (e *StatementExecutor) findRetentionPolicy(ctx context.Context, database, rp string, ectx *query.ExecutionContext) (*influxdb.DBRPMapping, error) {
+	dbrps, _, err := e.DBRP.FindMany(ctx, influxdb.DBRPMappingFilter{
+		OrgID:    &ectx.OrgID,
+		Database: &database,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(dbrps) == 0 {
+		return nil, query.ErrDatabaseNotFound(database)
+	}
+	for _, dbrp := range dbrps {
+		if dbrp.RetentionPolicy == rp {
+			return dbrp, nil
+		}
+	}
+	return nil, nil
+}
+
+// executeCreateRetentionPolicyStatement creates the bucket of a retention
+// policy, named db/rp like the buckets of upgraded retention policies, and
+// maps the retention policy to it.
+func (e *StatementExecutor) executeCreateRetentionPolicyStatement(ctx context.Context, q *influxql.CreateRetentionPolicyStatement, ectx *query.ExecutionContext) error {
+	dbrp, err := e.findRetentionPolicy(ctx, q.Database, q.Name, ectx)
+	if err != nil {
+		return err
+	}
+	if dbrp != nil {
+		return meta.ErrRetentionPolicyExists
+	}
+	if q.Replication < 1 {
+		return meta.ErrReplicationFactorTooLow
+	}
+	if err := validateRetentionPolicyDurations(q.Duration, q.ShardGroupDuration); err != nil {
+		return err
+	}
+
+	b := &influxdb.Bucket{
+		OrgID:               ectx.OrgID,
+		Type:                influxdb.BucketTypeUser,
+		Name:                q.Database + "/" + q.Name,
+		RetentionPolicyName: q.Name,
+		RetentionPeriod:     q.Duration,
+		ShardGroupDuration:  q.ShardGroupDuration,
+	}
+	if err := e.BucketService.CreateBucket(ctx, b); err != nil {
+		return err
+	}
+
+	return e.DBRP.Create(ctx, &influxdb.DBRPMapping{
+		Database:        q.Database,
+		RetentionPolicy: q.Name,
+		Default:         q.Default,
+		OrganizationID:  ectx.OrgID,
+		BucketID:        b.ID,
+	})
+}
+
+// executeAlterRetentionPolicyStatement updates the durations of the bucket
+// of a retention policy, and makes it the default retention policy.
+func (e *StatementExecutor) executeAlterRetentionPolicyStatement(ctx context.Context, q *influxql.AlterRetentionPolicyStatement, ectx *query.ExecutionContext) error {
+	dbrp, err := e.findRetentionPolicy(ctx, q.Database, q.Name, ectx)
+	if err != nil {
+		return err
+	}
+	if dbrp == nil {
+		return meta.ErrRetentionPolicyNotFound
+	}
+
+	if q.Duration != nil || q.ShardGroupDuration != nil {
+		b, err := e.BucketService.FindBucketByID(ctx, dbrp.BucketID)
+		if err != nil {
+			return err
+		}
+		upd := influxdb.BucketUpdate{
+			RetentionPeriod:    q.Duration,
+			ShardGroupDuration: q.ShardGroupDuration,
+		}
+		if upd.RetentionPeriod == nil {
+			upd.RetentionPeriod = &b.RetentionPeriod
+		}
+		if upd.ShardGroupDuration == nil {
+			upd.ShardGroupDuration = &b.ShardGroupDuration
+		}
+		if err := validateRetentionPolicyDurations(*upd.RetentionPeriod, *upd.ShardGroupDuration); err != nil {
+			return err
+		}
+		if _, err := e.BucketService.UpdateBucket(ctx, dbrp.BucketID, upd); err != nil {
+			return err
+		}
+	}
+
+	if q.Default && !dbrp.Default {
+		dbrp.Default = true
+		return e.DBRP.Update(ctx, dbrp)
+	}
+	return nil
+}
+
+// executeDropRetentionPolicyStatement removes a retention policy. As in 1.x,
+// its data is deleted with its bucket, unless other retention policies are
+// mapped to the bucket.
+func (e *StatementExecutor) executeDropRetentionPolicyStatement(ctx context.Context, q *influxql.DropRetentionPolicyStatement, ectx *query.ExecutionContext) error {
+	dbrp, err := e.findRetentionPolicy(ctx, q.Database, q.Name, ectx)
+	if err != nil {
+		return err
+	}
+	if dbrp == nil {
+		// As in 1.x, dropping a retention policy which does not exist succeeds.
+		return nil
+	}
+
+	_, n, err := e.DBRP.FindMany(ctx, influxdb.DBRPMappingFilter{
+		OrgID:    &ectx.OrgID,
+		BucketID: &dbrp.BucketID,
+	})
+	if err != nil {
+		return err
+	}
+	if err := e.DBRP.Delete(ctx, ectx.OrgID, dbrp.ID); err != nil {
+		return err
+	}
+	if n > 1 {
+		return nil
+	}
+	return e.BucketService.DeleteBucket(ctx, dbrp.BucketID)
+}
+
+// validateRetentionPolicyDurations validates the durations of a retention
+// policy as 1.x did. A zero duration is infinite, and a zero shard duration is
+// chosen by the storage engine.
+func validateRetentionPolicyDurations(d, shard time.Duration) error {
+	if d == 0 {
+		return nil
+	}
+	if d < meta.MinRetentionPolicyDuration {
+		return meta.ErrRetentionPolicyDurationTooLow
+	}
+	if shard > d {
+		return meta.ErrIncompatibleDurations
+	}
+	return nil
+}
+
 func (e *StatementExecutor) executeShowRetentionPoliciesStatement(ctx context.Context, q *influxql.ShowRetentionPoliciesStatement, ectx *query.ExecutionContext) (models.Rows, error) {
 	if q.Database == "" {
 		return nil, ErrDatabaseNameRequired
@@ -750,6 +1057,10 @@ func (e *StatementExecutor) NormalizeStatement(ctx context.Context, stmt influxq
 			return
 		}
 		switch node := node.(type) {
+		case *influxql.CreateContinuousQueryStatement:
+			// The measurements of a continuous query default to its database
+			// and the default retention policy of it.
+			defaultDatabase, defaultRetentionPolicy = node.Database, ""
 		case *influxql.ShowRetentionPoliciesStatement:
 			if node.Database == "" {
 				node.Database = defaultDatabase
@@ -844,6 +1155,32 @@ func (m mappings) DefaultRetentionPolicy(db string) string {
 	return ""
 }
 
+// ContinuousQueryService manages the continuous queries of organizations.
+type ContinuousQueryService interface {
+	CreateContinuousQuery(ctx context.Context, orgID platform.ID, stmt *influxql.CreateContinuousQueryStatement) error
+	DropContinuousQuery(ctx context.Context, orgID platform.ID, database, name string) error
+	FindContinuousQueries(ctx context.Context, orgID platform.ID) ([]continuousquery.ContinuousQuery, error)
+}
+
+// SubscriptionService manages the subscriptions of organizations.
+type SubscriptionService interface {
+	CreateSubscription(ctx context.Context, orgID platform.ID, stmt *influxql.CreateSubscriptionStatement) error
+	DropSubscription(ctx context.Context, orgID platform.ID, database, retentionPolicy, name string) error
+	FindSubscriptions(ctx context.Context, orgID platform.ID) ([]subscription.Subscription, error)
+}
+
+// UserService manages the v1 users of organizations and their privileges.
+type UserService interface {
+	CreateUser(ctx context.Context, orgID platform.ID, name, password string, admin bool) error
+	DropUser(ctx context.Context, orgID platform.ID, name string) error
+	SetUserPassword(ctx context.Context, orgID platform.ID, name, password string) error
+	GrantPrivilege(ctx context.Context, orgID platform.ID, name, database string, p influxql.Privilege) error
+	RevokePrivilege(ctx context.Context, orgID platform.ID, name, database string, p influxql.Privilege) error
+	SetUserAdmin(ctx context.Context, orgID platform.ID, name string, admin bool) error
+	FindUsers(ctx context.Context, orgID platform.ID) ([]user.User, error)
+	FindUserPrivileges(ctx context.Context, orgID platform.ID, name string) (map[string]influxql.Privilege, error)
+}
+
 // TSDBStore is an interface for accessing the time series data store.
 type TSDBStore interface {
 	DeleteMeasurement(ctx context.Context, database, name string) error
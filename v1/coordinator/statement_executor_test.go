@@ -20,6 +20,7 @@ import (
 	"github.com/influxdata/influxdb/v2/influxql/query"
 	"github.com/influxdata/influxdb/v2/internal"
 	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/mock"
 	"github.com/influxdata/influxdb/v2/models"
 	itesting "github.com/influxdata/influxdb/v2/testing"
 	"github.com/influxdata/influxdb/v2/tsdb"
@@ -386,6 +387,106 @@ func TestQueryExecutor_ExecuteQuery_ShowDatabases(t *testing.T) {
 	}
 }
 
+func TestQueryExecutor_ExecuteQuery_RetentionPolicies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orgID := platform.ID(0xff00)
+	db := "db0"
+	existing := &influxdb.DBRPMapping{ID: 0xaa, Database: db, RetentionPolicy: "autogen", Default: true, OrganizationID: orgID, BucketID: 0xffe0}
+
+	dbrp := mocks.NewMockDBRPMappingService(ctrl)
+	dbrp.EXPECT().
+		FindMany(gomock.Any(), influxdb.DBRPMappingFilter{OrgID: &orgID, Database: &db}).
+		Return([]*influxdb.DBRPMapping{existing}, 1, nil).
+		AnyTimes()
+
+	var created *influxdb.Bucket
+	buckets := mock.NewBucketService()
+	buckets.CreateBucketFn = func(ctx context.Context, b *influxdb.Bucket) error {
+		b.ID = 0xffe1
+		created = b
+		return nil
+	}
+	var updated influxdb.BucketUpdate
+	buckets.FindBucketByIDFn = func(ctx context.Context, id platform.ID) (*influxdb.Bucket, error) {
+		return &influxdb.Bucket{ID: id, RetentionPeriod: 72 * time.Hour, ShardGroupDuration: 24 * time.Hour}, nil
+	}
+	buckets.UpdateBucketFn = func(ctx context.Context, id platform.ID, upd influxdb.BucketUpdate) (*influxdb.Bucket, error) {
+		updated = upd
+		return &influxdb.Bucket{ID: id}, nil
+	}
+	var deleted platform.ID
+	buckets.DeleteBucketFn = func(ctx context.Context, id platform.ID) error {
+		deleted = id
+		return nil
+	}
+
+	qe := query.NewExecutor(zaptest.NewLogger(t), control.NewControllerMetrics([]string{}))
+	qe.StatementExecutor = &coordinator.StatementExecutor{
+		DBRP:          dbrp,
+		BucketService: buckets,
+	}
+	opt := query.ExecutionOptions{OrgID: orgID}
+
+	exec := func(q string) error {
+		t.Helper()
+		stmt, err := influxql.ParseQuery(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results := ReadAllResults(qe.ExecuteQuery(context.Background(), stmt, opt))
+		if len(results) != 1 {
+			t.Fatalf("unexpected results: %s", spew.Sdump(results))
+		}
+		return results[0].Err
+	}
+
+	// create a retention policy
+	dbrp.EXPECT().
+		Create(gomock.Any(), &influxdb.DBRPMapping{Database: db, RetentionPolicy: "short", Default: true, OrganizationID: orgID, BucketID: 0xffe1}).
+		Return(nil)
+	if err := exec(`CREATE RETENTION POLICY short ON db0 DURATION 2h REPLICATION 1 SHARD DURATION 1h DEFAULT`); err != nil {
+		t.Fatal(err)
+	}
+	if created.Name != "db0/short" || created.RetentionPolicyName != "short" || created.RetentionPeriod != 2*time.Hour || created.ShardGroupDuration != time.Hour {
+		t.Fatalf("unexpected bucket: %s", spew.Sdump(created))
+	}
+
+	if err := exec(`CREATE RETENTION POLICY autogen ON db0 DURATION 2h REPLICATION 1`); err != meta.ErrRetentionPolicyExists {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := exec(`CREATE RETENTION POLICY tiny ON db0 DURATION 10m REPLICATION 1`); err != meta.ErrRetentionPolicyDurationTooLow {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// alter the durations of a retention policy, keeping its shard duration
+	if err := exec(`ALTER RETENTION POLICY autogen ON db0 DURATION 48h`); err != nil {
+		t.Fatal(err)
+	}
+	if *updated.RetentionPeriod != 48*time.Hour || *updated.ShardGroupDuration != 24*time.Hour {
+		t.Fatalf("unexpected update: %s", spew.Sdump(updated))
+	}
+	if err := exec(`ALTER RETENTION POLICY autogen ON db0 SHARD DURATION 96h`); err != meta.ErrIncompatibleDurations {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := exec(`ALTER RETENTION POLICY missing ON db0 DURATION 48h`); err != meta.ErrRetentionPolicyNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// drop a retention policy with its bucket
+	dbrp.EXPECT().
+		FindMany(gomock.Any(), influxdb.DBRPMappingFilter{OrgID: &orgID, BucketID: &existing.BucketID}).
+		Return([]*influxdb.DBRPMapping{existing}, 1, nil)
+	dbrp.EXPECT().Delete(gomock.Any(), orgID, existing.ID).Return(nil)
+	if err := exec(`DROP RETENTION POLICY autogen ON db0`); err != nil {
+		t.Fatal(err)
+	}
+	if deleted != existing.BucketID {
+		t.Fatalf("unexpected deleted bucket: %s", deleted)
+	}
+}
+
 func testExecDeleteSeriesOrDropMeasurement(t *testing.T, qType string) {
 	orgID := platform.ID(0xff00)
 	otherOrgID := platform.ID(0xff01)
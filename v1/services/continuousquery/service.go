@@ -0,0 +1,207 @@
+// Package continuousquery runs the continuous queries of the v1 query API as
+// managed tasks.
+package continuousquery
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/task/taskmodel"
+	"github.com/influxdata/influxql"
+	"go.uber.org/zap"
+)
+
+// The metadata of the tasks of continuous queries, which map them back to
+// the continuous queries.
+const (
+	MetadataName     = "continuousQuery"
+	MetadataDatabase = "continuousQueryDatabase"
+	MetadataQuery    = "continuousQueryStatement"
+)
+
+// ContinuousQuery is a continuous query of a database, and the task running it.
+type ContinuousQuery struct {
+	Database string
+	Name     string
+	// Query is the CREATE CONTINUOUS QUERY statement of the continuous query.
+	Query  string
+	TaskID platform.ID
+}
+
+// Service manages the continuous queries of organizations. Each continuous
+// query is translated into a task, whose metadata records the continuous
+// query it runs, so the tasks are the continuous queries and deleting the
+// task of a continuous query drops it.
+type Service struct {
+	log   *zap.Logger
+	tasks taskmodel.TaskService
+	dbrps influxdb.DBRPMappingService
+}
+
+// NewService constructs a Service. The tasks are created as the writer of the
+// continuous query, so their services should authorize the context.
+func NewService(log *zap.Logger, tasks taskmodel.TaskService, dbrps influxdb.DBRPMappingService) *Service {
+	return &Service{log: log, tasks: tasks, dbrps: dbrps}
+}
+
+// CreateContinuousQuery creates the task of a continuous query. The
+// measurements of the query must have been normalized, so their databases
+// and retention policies are set.
+func (s *Service) CreateContinuousQuery(ctx context.Context, orgID platform.ID, stmt *influxql.CreateContinuousQueryStatement) error {
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		return err
+	}
+
+	cq, err := s.find(ctx, orgID, stmt.Database, stmt.Name)
+	if err != nil {
+		return err
+	}
+	if cq != nil {
+		return &errors.Error{
+			Code: errors.EConflict,
+			Msg:  fmt.Sprintf("continuous query %q already exists on database %q", stmt.Name, stmt.Database),
+		}
+	}
+
+	if len(stmt.Source.Sources) != 1 || stmt.Source.Target == nil {
+		return errUnsupported("exactly one measurement must be selected from into a measurement")
+	}
+	m, ok := stmt.Source.Sources[0].(*influxql.Measurement)
+	if !ok {
+		return errUnsupported("only a measurement may be selected from")
+	}
+	source, err := s.bucketID(ctx, orgID, m)
+	if err != nil {
+		return err
+	}
+	target, err := s.bucketID(ctx, orgID, stmt.Source.Target.Measurement)
+	if err != nil {
+		return err
+	}
+
+	flux, err := Translate(stmt.Database+"."+stmt.Name, stmt, source, target)
+	if err != nil {
+		return err
+	}
+	t, err := s.tasks.CreateTask(ctx, taskmodel.TaskCreate{
+		Flux:           flux,
+		Description:    fmt.Sprintf("Continuous query %s on database %s", stmt.Name, stmt.Database),
+		Status:         taskmodel.TaskStatusActive,
+		OrganizationID: orgID,
+		OwnerID:        auth.GetUserID(),
+		Metadata: map[string]interface{}{
+			MetadataName:     stmt.Name,
+			MetadataDatabase: stmt.Database,
+			MetadataQuery:    stmt.String(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	s.log.Info("Created continuous query",
+		zap.Stringer("org_id", orgID),
+		zap.String("database", stmt.Database),
+		zap.String("name", stmt.Name),
+		zap.Stringer("task_id", t.ID))
+	return nil
+}
+
+// DropContinuousQuery deletes the task of a continuous query.
+func (s *Service) DropContinuousQuery(ctx context.Context, orgID platform.ID, database, name string) error {
+	cq, err := s.find(ctx, orgID, database, name)
+	if err != nil {
+		return err
+	}
+	if cq == nil {
+		return &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  fmt.Sprintf("continuous query %q not found on database %q", name, database),
+		}
+	}
+	return s.tasks.DeleteTask(ctx, cq.TaskID)
+}
+
+// FindContinuousQueries returns the continuous queries of an organization,
+// sorted by database and name.
+func (s *Service) FindContinuousQueries(ctx context.Context, orgID platform.ID) ([]ContinuousQuery, error) {
+	var cqs []ContinuousQuery
+	filter := taskmodel.TaskFilter{OrganizationID: &orgID, Limit: taskmodel.TaskMaxPageSize}
+	for {
+		tasks, _, err := s.tasks.FindTasks(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tasks {
+			if cq, ok := fromTask(t); ok {
+				cqs = append(cqs, cq)
+			}
+		}
+		if len(tasks) < filter.Limit {
+			break
+		}
+		filter.After = &tasks[len(tasks)-1].ID
+	}
+
+	sort.Slice(cqs, func(i, j int) bool {
+		if cqs[i].Database != cqs[j].Database {
+			return cqs[i].Database < cqs[j].Database
+		}
+		return cqs[i].Name < cqs[j].Name
+	})
+	return cqs, nil
+}
+
+func (s *Service) find(ctx context.Context, orgID platform.ID, database, name string) (*ContinuousQuery, error) {
+	cqs, err := s.FindContinuousQueries(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range cqs {
+		if cqs[i].Database == database && cqs[i].Name == name {
+			return &cqs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// bucketID returns the bucket mapped to the database and retention policy of m.
+func (s *Service) bucketID(ctx context.Context, orgID platform.ID, m *influxql.Measurement) (platform.ID, error) {
+	filter := influxdb.DBRPMappingFilter{
+		OrgID:           &orgID,
+		Database:        &m.Database,
+		RetentionPolicy: &m.RetentionPolicy,
+	}
+	mappings, _, err := s.dbrps.FindMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	if len(mappings) == 0 {
+		return 0, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  fmt.Sprintf("retention policy %q not found on database %q", m.RetentionPolicy, m.Database),
+		}
+	}
+	return mappings[0].BucketID, nil
+}
+
+// fromTask returns the continuous query a task runs, if it runs one.
+func fromTask(t *taskmodel.Task) (ContinuousQuery, bool) {
+	name, _ := t.Metadata[MetadataName].(string)
+	database, _ := t.Metadata[MetadataDatabase].(string)
+	query, _ := t.Metadata[MetadataQuery].(string)
+	if name == "" || database == "" {
+		return ContinuousQuery{}, false
+	}
+	return ContinuousQuery{
+		Database: database,
+		Name:     name,
+		Query:    query,
+		TaskID:   t.ID,
+	}, true
+}
@@ -0,0 +1,112 @@
+package continuousquery_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/task/taskmodel"
+	"github.com/influxdata/influxdb/v2/v1/services/continuousquery"
+	"github.com/influxdata/influxql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// newTaskService returns a task service keeping its tasks in memory.
+func newTaskService() *mock.TaskService {
+	var tasks []*taskmodel.Task
+	ts := mock.NewTaskService()
+	ts.CreateTaskFn = func(ctx context.Context, tc taskmodel.TaskCreate) (*taskmodel.Task, error) {
+		t := &taskmodel.Task{
+			ID:             platform.ID(len(tasks) + 1),
+			OrganizationID: tc.OrganizationID,
+			OwnerID:        tc.OwnerID,
+			Flux:           tc.Flux,
+			Status:         tc.Status,
+			Metadata:       tc.Metadata,
+		}
+		tasks = append(tasks, t)
+		return t, nil
+	}
+	ts.FindTasksFn = func(ctx context.Context, f taskmodel.TaskFilter) ([]*taskmodel.Task, int, error) {
+		var res []*taskmodel.Task
+		for _, t := range tasks {
+			if t.OrganizationID == *f.OrganizationID && (f.After == nil || t.ID > *f.After) {
+				res = append(res, t)
+			}
+		}
+		return res, len(res), nil
+	}
+	ts.DeleteTaskFn = func(ctx context.Context, id platform.ID) error {
+		for i, t := range tasks {
+			if t.ID == id {
+				tasks = append(tasks[:i], tasks[i+1:]...)
+				return nil
+			}
+		}
+		return &errors.Error{Code: errors.ENotFound, Msg: "task not found"}
+	}
+	return ts
+}
+
+func TestService(t *testing.T) {
+	orgID, userID := platform.ID(1), platform.ID(2)
+	ctx := icontext.SetAuthorizer(context.Background(), &mock.Authorizer{AllowAll: true, UserID: userID})
+
+	dbrps := &mock.DBRPMappingService{
+		FindManyFn: func(ctx context.Context, f influxdb.DBRPMappingFilter, opts ...influxdb.FindOptions) ([]*influxdb.DBRPMapping, int, error) {
+			if *f.Database != "db" {
+				return nil, 0, nil
+			}
+			ids := map[string]platform.ID{"autogen": 10, "downsampled": 20}
+			id, ok := ids[*f.RetentionPolicy]
+			if !ok {
+				return nil, 0, nil
+			}
+			return []*influxdb.DBRPMapping{{Database: "db", RetentionPolicy: *f.RetentionPolicy, BucketID: id}}, 1, nil
+		},
+	}
+	ts := newTaskService()
+	s := continuousquery.NewService(zaptest.NewLogger(t), ts, dbrps)
+
+	create := func(q string) error {
+		stmt, err := influxql.ParseStatement(q)
+		require.NoError(t, err)
+		return s.CreateContinuousQuery(ctx, orgID, stmt.(*influxql.CreateContinuousQueryStatement))
+	}
+	const q = `CREATE CONTINUOUS QUERY cq ON db BEGIN SELECT mean(usage) INTO db.downsampled.cpu FROM db.autogen.cpu GROUP BY time(1h) END`
+	require.NoError(t, create(q))
+
+	cqs, err := s.FindContinuousQueries(ctx, orgID)
+	require.NoError(t, err)
+	require.Len(t, cqs, 1)
+	assert.Equal(t, "db", cqs[0].Database)
+	assert.Equal(t, "cq", cqs[0].Name)
+	assert.Contains(t, cqs[0].Query, "CREATE CONTINUOUS QUERY cq ON db")
+
+	tasks, _, err := ts.FindTasks(ctx, taskmodel.TaskFilter{OrganizationID: &orgID})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, userID, tasks[0].OwnerID)
+	assert.Contains(t, tasks[0].Flux, `from(bucketID: "000000000000000a")`)
+	assert.Contains(t, tasks[0].Flux, `to(bucketID: "0000000000000014")`)
+
+	err = create(q)
+	assert.Equal(t, errors.EConflict, errors.ErrorCode(err))
+
+	err = create(`CREATE CONTINUOUS QUERY cq2 ON db BEGIN SELECT mean(usage) INTO db.missing.cpu FROM db.autogen.cpu GROUP BY time(1h) END`)
+	assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+
+	require.NoError(t, s.DropContinuousQuery(ctx, orgID, "db", "cq"))
+	cqs, err = s.FindContinuousQueries(ctx, orgID)
+	require.NoError(t, err)
+	assert.Empty(t, cqs)
+
+	err = s.DropContinuousQuery(ctx, orgID, "db", "cq")
+	assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+}
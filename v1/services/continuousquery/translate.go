@@ -0,0 +1,290 @@
+package continuousquery
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxql"
+)
+
+// aggregates maps the InfluxQL aggregates continuous queries may use to the
+// Flux functions aggregateWindow is called with.
+var aggregates = map[string]string{
+	"count":  "count",
+	"first":  "first",
+	"last":   "last",
+	"max":    "max",
+	"mean":   "mean",
+	"median": "median",
+	"min":    "min",
+	"spread": "spread",
+	"stddev": "stddev",
+	"sum":    "sum",
+}
+
+// floatAggregates are the aggregates which always produce floats.
+var floatAggregates = map[string]bool{
+	"mean":   true,
+	"median": true,
+	"stddev": true,
+}
+
+func errUnsupported(format string, args ...interface{}) error {
+	return &errors.Error{
+		Code: errors.EInvalid,
+		Msg:  "unsupported continuous query: " + fmt.Sprintf(format, args...),
+	}
+}
+
+// Translate translates a continuous query into the Flux of the task running
+// it, which reads the source bucket and writes the target bucket. The
+// measurements of the query must have been normalized.
+//
+// The queries translated are those 1.x agents commonly create: aggregates of
+// fields of one measurement, grouped by time and tags, with tag conditions.
+func Translate(name string, stmt *influxql.CreateContinuousQueryStatement, sourceID, targetID platform.ID) (string, error) {
+	s := stmt.Source
+	if s.Target == nil || s.Target.Measurement == nil {
+		return "", errUnsupported("an INTO clause is required")
+	}
+	if len(s.Sources) != 1 {
+		return "", errUnsupported("exactly one measurement must be selected from")
+	}
+	m, ok := s.Sources[0].(*influxql.Measurement)
+	if !ok || m.Name == "" || m.Regex != nil {
+		return "", errUnsupported("only a measurement named explicitly may be selected from")
+	}
+	into := s.Target.Measurement.Name
+	if into == "" {
+		into = m.Name
+	}
+
+	interval, err := s.GroupByInterval()
+	if err != nil {
+		return "", err
+	}
+	if interval <= 0 {
+		return "", errUnsupported("GROUP BY time() is required")
+	}
+	offset, err := s.GroupByOffset()
+	if err != nil {
+		return "", err
+	}
+	tags, allTags, err := groupByTags(s.Dimensions)
+	if err != nil {
+		return "", err
+	}
+	filter, err := tagFilter(s.Condition)
+	if err != nil {
+		return "", err
+	}
+	fill, err := fillCall(s)
+	if err != nil {
+		return "", err
+	}
+
+	// as in 1.x, the query runs every interval over the last interval, or as
+	// resampled.
+	every, period := interval, interval
+	if stmt.ResampleEvery > 0 {
+		every = stmt.ResampleEvery
+	}
+	if stmt.ResampleFor > 0 {
+		period = stmt.ResampleFor
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "option task = {name: %s, every: %s", strconv.Quote(name), fluxDuration(every))
+	if offset > 0 {
+		// the runs are delayed by the offset, and cover the windows ending then.
+		fmt.Fprintf(&b, ", offset: %s", fluxDuration(offset))
+	}
+	b.WriteString("}\n")
+
+	for _, f := range s.Fields {
+		call, ok := f.Expr.(*influxql.Call)
+		if !ok {
+			return "", errUnsupported("field %s is not an aggregate", f)
+		}
+		fn, ok := aggregates[call.Name]
+		if !ok {
+			return "", errUnsupported("aggregate %s is not supported", call.Name)
+		}
+		if len(call.Args) != 1 {
+			return "", errUnsupported("aggregate %s must have a single argument", call.Name)
+		}
+		ref, ok := call.Args[0].(*influxql.VarRef)
+		if !ok {
+			return "", errUnsupported("aggregate %s must be of a field named explicitly", call.Name)
+		}
+
+		b.WriteString("\n")
+		fmt.Fprintf(&b, "from(bucketID: %s)\n", strconv.Quote(sourceID.String()))
+		if offset > 0 {
+			fmt.Fprintf(&b, "\t|> range(start: %s, stop: %s)\n", fluxDuration(offset-period), fluxDuration(offset))
+		} else {
+			fmt.Fprintf(&b, "\t|> range(start: %s)\n", fluxDuration(-period))
+		}
+		fmt.Fprintf(&b, "\t|> filter(fn: (r) => r._measurement == %s and r._field == %s)\n", strconv.Quote(m.Name), strconv.Quote(ref.Val))
+		if filter != "" {
+			fmt.Fprintf(&b, "\t|> filter(fn: (r) => %s)\n", filter)
+		}
+		if !allTags {
+			cols := make([]string, len(tags))
+			for i, t := range tags {
+				cols[i] = strconv.Quote(t)
+			}
+			fmt.Fprintf(&b, "\t|> group(columns: [%s])\n", strings.Join(cols, ", "))
+		}
+		fmt.Fprintf(&b, "\t|> aggregateWindow(every: %s", fluxDuration(interval))
+		if offset > 0 {
+			fmt.Fprintf(&b, ", offset: %s", fluxDuration(offset))
+		}
+		fmt.Fprintf(&b, ", fn: %s, createEmpty: %t)\n", fn, fill != nil)
+		if fill != nil {
+			fmt.Fprintf(&b, "\t|> %s\n", fill(call.Name))
+		}
+		fmt.Fprintf(&b, "\t|> set(key: \"_measurement\", value: %s)\n", strconv.Quote(into))
+		fmt.Fprintf(&b, "\t|> set(key: \"_field\", value: %s)\n", strconv.Quote(f.Name()))
+		fmt.Fprintf(&b, "\t|> to(bucketID: %s)\n", strconv.Quote(targetID.String()))
+	}
+	return b.String(), nil
+}
+
+// groupByTags returns the tags grouped by, or whether all the tags are.
+func groupByTags(dims influxql.Dimensions) ([]string, bool, error) {
+	var tags []string
+	for _, d := range dims {
+		switch expr := d.Expr.(type) {
+		case *influxql.Call:
+			// the time dimension.
+		case *influxql.VarRef:
+			tags = append(tags, expr.Val)
+		case *influxql.Wildcard:
+			return nil, true, nil
+		default:
+			return nil, false, errUnsupported("GROUP BY %s is not supported", d)
+		}
+	}
+	return tags, false, nil
+}
+
+// tagFilter translates a condition on tags into the body of a Flux predicate.
+func tagFilter(cond influxql.Expr) (string, error) {
+	switch expr := cond.(type) {
+	case nil:
+		return "", nil
+	case *influxql.ParenExpr:
+		return tagFilter(expr.Expr)
+	case *influxql.BinaryExpr:
+		switch expr.Op {
+		case influxql.AND, influxql.OR:
+			lhs, err := tagFilter(expr.LHS)
+			if err != nil {
+				return "", err
+			}
+			rhs, err := tagFilter(expr.RHS)
+			if err != nil {
+				return "", err
+			}
+			op := "and"
+			if expr.Op == influxql.OR {
+				op = "or"
+			}
+			return fmt.Sprintf("(%s %s %s)", lhs, op, rhs), nil
+		case influxql.EQ, influxql.NEQ, influxql.EQREGEX, influxql.NEQREGEX:
+			ref, ok := expr.LHS.(*influxql.VarRef)
+			if !ok || ref.Val == "time" {
+				return "", errUnsupported("condition %s is not on a tag", expr)
+			}
+			col := "r[" + strconv.Quote(ref.Val) + "]"
+			switch rhs := expr.RHS.(type) {
+			case *influxql.StringLiteral:
+				switch expr.Op {
+				case influxql.EQ:
+					return fmt.Sprintf("%s == %s", col, strconv.Quote(rhs.Val)), nil
+				case influxql.NEQ:
+					return fmt.Sprintf("%s != %s", col, strconv.Quote(rhs.Val)), nil
+				}
+			case *influxql.RegexLiteral:
+				if expr.Op == influxql.EQREGEX || expr.Op == influxql.NEQREGEX {
+					re := strings.ReplaceAll(rhs.Val.String(), "/", `\/`)
+					return fmt.Sprintf("%s %s /%s/", col, expr.Op, re), nil
+				}
+			}
+			return "", errUnsupported("condition %s is not on a tag", expr)
+		}
+	}
+	return "", errUnsupported("condition %s is not supported", cond)
+}
+
+// fillCall returns the Flux call filling the empty windows of an aggregate,
+// or nil when the empty windows are not written.
+func fillCall(s *influxql.SelectStatement) (func(aggregate string) string, error) {
+	switch s.Fill {
+	case influxql.NullFill, influxql.NoFill:
+		// null values are not written, so the empty windows are not in 1.x.
+		return nil, nil
+	case influxql.PreviousFill:
+		return func(string) string { return "fill(usePrevious: true)" }, nil
+	case influxql.NumberFill:
+		var v float64
+		isFloat := false
+		switch n := s.FillValue.(type) {
+		case int64:
+			v = float64(n)
+		case float64:
+			v, isFloat = n, true
+		default:
+			return nil, errUnsupported("fill(%v) is not supported", s.FillValue)
+		}
+		return func(aggregate string) string {
+			// the value must be of the type of the aggregate.
+			if aggregate == "count" {
+				return fmt.Sprintf("fill(value: %d)", int64(v))
+			}
+			if isFloat || floatAggregates[aggregate] {
+				f := strconv.FormatFloat(v, 'f', -1, 64)
+				if !strings.Contains(f, ".") {
+					f += ".0"
+				}
+				return "fill(value: " + f + ")"
+			}
+			return fmt.Sprintf("fill(value: %d)", int64(v))
+		}, nil
+	}
+	return nil, errUnsupported("fill(%v) is not supported", s.Fill)
+}
+
+// fluxDuration formats d as a Flux duration literal.
+func fluxDuration(d time.Duration) string {
+	if d == 0 {
+		return "0s"
+	}
+	var b strings.Builder
+	if d < 0 {
+		b.WriteString("-")
+		d = -d
+	}
+	for _, u := range []struct {
+		unit string
+		d    time.Duration
+	}{
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+		{"ms", time.Millisecond},
+		{"us", time.Microsecond},
+		{"ns", time.Nanosecond},
+	} {
+		if n := d / u.d; n > 0 {
+			fmt.Fprintf(&b, "%d%s", n, u.unit)
+			d -= n * u.d
+		}
+	}
+	return b.String()
+}
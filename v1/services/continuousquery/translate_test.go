@@ -0,0 +1,90 @@
+package continuousquery
+
+import (
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseCQ(t *testing.T, q string) *influxql.CreateContinuousQueryStatement {
+	t.Helper()
+	stmt, err := influxql.ParseStatement(q)
+	require.NoError(t, err)
+	cq, ok := stmt.(*influxql.CreateContinuousQueryStatement)
+	require.True(t, ok)
+	return cq
+}
+
+func TestTranslate(t *testing.T) {
+	source, target := platform.ID(0x1000), platform.ID(0x2000)
+	for _, tt := range []struct {
+		name  string
+		query string
+		flux  string
+	}{
+		{
+			name:  "group by tags",
+			query: `CREATE CONTINUOUS QUERY cq ON db BEGIN SELECT mean(usage) AS usage_mean, max(usage) INTO db.rp.cpu_1h FROM db.autogen.cpu WHERE host =~ /^web/ AND region = 'us' GROUP BY time(1h), host END`,
+			flux: `option task = {name: "db.cq", every: 1h}
+
+from(bucketID: "0000000000001000")
+	|> range(start: -1h)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage")
+	|> filter(fn: (r) => (r["host"] =~ /^web/ and r["region"] == "us"))
+	|> group(columns: ["host"])
+	|> aggregateWindow(every: 1h, fn: mean, createEmpty: false)
+	|> set(key: "_measurement", value: "cpu_1h")
+	|> set(key: "_field", value: "usage_mean")
+	|> to(bucketID: "0000000000002000")
+
+from(bucketID: "0000000000001000")
+	|> range(start: -1h)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage")
+	|> filter(fn: (r) => (r["host"] =~ /^web/ and r["region"] == "us"))
+	|> group(columns: ["host"])
+	|> aggregateWindow(every: 1h, fn: max, createEmpty: false)
+	|> set(key: "_measurement", value: "cpu_1h")
+	|> set(key: "_field", value: "max")
+	|> to(bucketID: "0000000000002000")
+`,
+		},
+		{
+			name:  "resample with offset and fill",
+			query: `CREATE CONTINUOUS QUERY cq ON db RESAMPLE EVERY 30m FOR 2h BEGIN SELECT count(requests) INTO db.rp.:MEASUREMENT FROM db.autogen.http GROUP BY time(1h, 15m), * fill(0) END`,
+			flux: `option task = {name: "db.cq", every: 30m, offset: 15m}
+
+from(bucketID: "0000000000001000")
+	|> range(start: -1h45m, stop: 15m)
+	|> filter(fn: (r) => r._measurement == "http" and r._field == "requests")
+	|> aggregateWindow(every: 1h, offset: 15m, fn: count, createEmpty: true)
+	|> fill(value: 0)
+	|> set(key: "_measurement", value: "http")
+	|> set(key: "_field", value: "count")
+	|> to(bucketID: "0000000000002000")
+`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			flux, err := Translate("db.cq", parseCQ(t, tt.query), source, target)
+			require.NoError(t, err)
+			assert.Equal(t, tt.flux, flux)
+		})
+	}
+}
+
+func TestTranslate_Unsupported(t *testing.T) {
+	for _, q := range []string{
+		`CREATE CONTINUOUS QUERY cq ON db BEGIN SELECT mean(usage) * 2 INTO db.rp.m FROM db.rp.cpu GROUP BY time(1h) END`,
+		`CREATE CONTINUOUS QUERY cq ON db BEGIN SELECT percentile(usage, 95) INTO db.rp.m FROM db.rp.cpu GROUP BY time(1h) END`,
+		`CREATE CONTINUOUS QUERY cq ON db BEGIN SELECT mean(*) INTO db.rp.m FROM db.rp.cpu GROUP BY time(1h) END`,
+		`CREATE CONTINUOUS QUERY cq ON db BEGIN SELECT mean(usage) INTO db.rp.m FROM db.rp./c.*/ GROUP BY time(1h) END`,
+		`CREATE CONTINUOUS QUERY cq ON db BEGIN SELECT mean(usage) INTO db.rp.m FROM db.rp.cpu WHERE usage > 1 GROUP BY time(1h) END`,
+		`CREATE CONTINUOUS QUERY cq ON db BEGIN SELECT mean(usage) INTO db.rp.m FROM db.rp.cpu GROUP BY time(1h) fill(linear) END`,
+	} {
+		_, err := Translate("db.cq", parseCQ(t, q), 1, 2)
+		assert.Error(t, err, q)
+	}
+}
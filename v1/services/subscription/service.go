@@ -0,0 +1,294 @@
+// Package subscription forwards the writes to the databases of the v1 API to
+// the destinations of their subscriptions, as replications.
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxql"
+	"go.uber.org/zap"
+)
+
+// Subscription is a subscription of a retention policy of a database, and
+// the replications forwarding its writes.
+type Subscription struct {
+	Database        string
+	RetentionPolicy string
+	Name            string
+	Mode            string
+	Destinations    []string
+	ReplicationIDs  []platform.ID
+}
+
+// RemoteConnectionService manages the remote connections of the destinations.
+type RemoteConnectionService interface {
+	CreateRemoteConnection(ctx context.Context, request influxdb.CreateRemoteConnectionRequest) (*influxdb.RemoteConnection, error)
+	DeleteRemoteConnection(ctx context.Context, id platform.ID) error
+}
+
+// ReplicationService manages the replications of the subscriptions.
+type ReplicationService interface {
+	ListReplications(ctx context.Context, filter influxdb.ReplicationListFilter) (*influxdb.Replications, error)
+	CreateReplication(ctx context.Context, request influxdb.CreateReplicationRequest) (*influxdb.Replication, error)
+	DeleteReplication(ctx context.Context, id platform.ID) error
+}
+
+// Service manages the subscriptions of organizations. Each destination of a
+// subscription is a remote connection, and a replication of the bucket the
+// retention policy is mapped to into the database and retention policy of
+// the destination. The description of the replications is the CREATE
+// SUBSCRIPTION statement of the subscription, so the replications are the
+// subscriptions.
+type Service struct {
+	log          *zap.Logger
+	remotes      RemoteConnectionService
+	replications ReplicationService
+	dbrps        influxdb.DBRPMappingService
+}
+
+// NewService constructs a Service.
+func NewService(log *zap.Logger, remotes RemoteConnectionService, replications ReplicationService, dbrps influxdb.DBRPMappingService) *Service {
+	return &Service{log: log, remotes: remotes, replications: replications, dbrps: dbrps}
+}
+
+// CreateSubscription creates the replications of a subscription. Only HTTP
+// destinations are supported, and the writes to a subscription in ANY mode
+// may only be forwarded to a single destination.
+func (s *Service) CreateSubscription(ctx context.Context, orgID platform.ID, stmt *influxql.CreateSubscriptionStatement) error {
+	sub, err := s.find(ctx, orgID, stmt.Database, stmt.RetentionPolicy, stmt.Name)
+	if err != nil {
+		return err
+	}
+	if sub != nil {
+		return &errors.Error{
+			Code: errors.EConflict,
+			Msg:  fmt.Sprintf("subscription %q already exists on %s.%s", stmt.Name, stmt.Database, stmt.RetentionPolicy),
+		}
+	}
+
+	if len(stmt.Destinations) == 0 {
+		return errUnsupported("at least one destination is required")
+	}
+	if stmt.Mode == "ANY" && len(stmt.Destinations) > 1 {
+		return errUnsupported("writes may only be balanced across a single destination")
+	}
+	for _, d := range stmt.Destinations {
+		u, err := url.Parse(d)
+		if err != nil {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("invalid destination %q", d),
+				Err:  err,
+			}
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return errUnsupported("destination %q is not an HTTP destination", d)
+		}
+	}
+
+	bucketID, err := s.bucketID(ctx, orgID, stmt.Database, stmt.RetentionPolicy)
+	if err != nil {
+		return err
+	}
+
+	description := stmt.String()
+	var created []platform.ID
+	for _, d := range stmt.Destinations {
+		id, err := s.createReplication(ctx, orgID, stmt, d, bucketID, description)
+		if err != nil {
+			// the subscription is not created without all its destinations.
+			for _, id := range created {
+				if derr := s.dropReplication(ctx, orgID, id); derr != nil {
+					s.log.Warn("Failed to drop replication of subscription", zap.Stringer("replication_id", id), zap.Error(derr))
+				}
+			}
+			return err
+		}
+		created = append(created, id)
+	}
+
+	s.log.Info("Created subscription",
+		zap.Stringer("org_id", orgID),
+		zap.String("database", stmt.Database),
+		zap.String("retention_policy", stmt.RetentionPolicy),
+		zap.String("name", stmt.Name))
+	return nil
+}
+
+// DropSubscription deletes the replications of a subscription, and their
+// remote connections.
+func (s *Service) DropSubscription(ctx context.Context, orgID platform.ID, database, retentionPolicy, name string) error {
+	sub, err := s.find(ctx, orgID, database, retentionPolicy, name)
+	if err != nil {
+		return err
+	}
+	if sub == nil {
+		return &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  fmt.Sprintf("subscription %q not found on %s.%s", name, database, retentionPolicy),
+		}
+	}
+	for _, id := range sub.ReplicationIDs {
+		if err := s.dropReplication(ctx, orgID, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindSubscriptions returns the subscriptions of an organization, sorted by
+// database, retention policy and name.
+func (s *Service) FindSubscriptions(ctx context.Context, orgID platform.ID) ([]Subscription, error) {
+	rs, err := s.replications.ListReplications(ctx, influxdb.ReplicationListFilter{OrgID: orgID})
+	if err != nil {
+		return nil, err
+	}
+
+	var subs []Subscription
+	byName := map[string]int{}
+	for _, r := range rs.Replications {
+		stmt, ok := fromReplication(r)
+		if !ok {
+			continue
+		}
+		key := stmt.Database + "\x00" + stmt.RetentionPolicy + "\x00" + stmt.Name
+		i, ok := byName[key]
+		if !ok {
+			i = len(subs)
+			byName[key] = i
+			subs = append(subs, Subscription{
+				Database:        stmt.Database,
+				RetentionPolicy: stmt.RetentionPolicy,
+				Name:            stmt.Name,
+				Mode:            stmt.Mode,
+				Destinations:    stmt.Destinations,
+			})
+		}
+		subs[i].ReplicationIDs = append(subs[i].ReplicationIDs, r.ID)
+	}
+
+	sort.Slice(subs, func(i, j int) bool {
+		if subs[i].Database != subs[j].Database {
+			return subs[i].Database < subs[j].Database
+		}
+		if subs[i].RetentionPolicy != subs[j].RetentionPolicy {
+			return subs[i].RetentionPolicy < subs[j].RetentionPolicy
+		}
+		return subs[i].Name < subs[j].Name
+	})
+	return subs, nil
+}
+
+func (s *Service) find(ctx context.Context, orgID platform.ID, database, retentionPolicy, name string) (*Subscription, error) {
+	subs, err := s.FindSubscriptions(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range subs {
+		if subs[i].Database == database && subs[i].RetentionPolicy == retentionPolicy && subs[i].Name == name {
+			return &subs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// createReplication creates the remote connection of a destination, and the
+// replication of a bucket to it.
+func (s *Service) createReplication(ctx context.Context, orgID platform.ID, stmt *influxql.CreateSubscriptionStatement, destination string, bucketID platform.ID, description string) (platform.ID, error) {
+	name := fmt.Sprintf("v1 subscription %s on %s.%s to %s", stmt.Name, stmt.Database, stmt.RetentionPolicy, destination)
+	remote, err := s.remotes.CreateRemoteConnection(ctx, influxdb.CreateRemoteConnectionRequest{
+		OrgID:       orgID,
+		Name:        name,
+		Description: &description,
+		RemoteURL:   destination,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	r, err := s.replications.CreateReplication(ctx, influxdb.CreateReplicationRequest{
+		OrgID:         orgID,
+		Name:          name,
+		Description:   &description,
+		RemoteID:      remote.ID,
+		LocalBucketID: bucketID,
+		// 1.x destinations accept the writes of the v2 API to the bucket
+		// named after the database and retention policy.
+		RemoteBucketName:  stmt.Database + "/" + stmt.RetentionPolicy,
+		MaxQueueSizeBytes: influxdb.DefaultReplicationMaxQueueSizeBytes,
+		MaxAgeSeconds:     influxdb.DefaultReplicationMaxAge,
+	})
+	if err != nil {
+		if derr := s.remotes.DeleteRemoteConnection(ctx, remote.ID); derr != nil {
+			s.log.Warn("Failed to delete remote connection of subscription", zap.Stringer("remote_id", remote.ID), zap.Error(derr))
+		}
+		return 0, err
+	}
+	return r.ID, nil
+}
+
+// dropReplication deletes a replication of a subscription, and its remote
+// connection.
+func (s *Service) dropReplication(ctx context.Context, orgID platform.ID, id platform.ID) error {
+	rs, err := s.replications.ListReplications(ctx, influxdb.ReplicationListFilter{OrgID: orgID})
+	if err != nil {
+		return err
+	}
+	for _, r := range rs.Replications {
+		if r.ID != id {
+			continue
+		}
+		if err := s.replications.DeleteReplication(ctx, id); err != nil {
+			return err
+		}
+		return s.remotes.DeleteRemoteConnection(ctx, r.RemoteID)
+	}
+	return nil
+}
+
+// bucketID returns the bucket mapped to a database and retention policy.
+func (s *Service) bucketID(ctx context.Context, orgID platform.ID, database, retentionPolicy string) (platform.ID, error) {
+	filter := influxdb.DBRPMappingFilter{
+		OrgID:           &orgID,
+		Database:        &database,
+		RetentionPolicy: &retentionPolicy,
+	}
+	mappings, _, err := s.dbrps.FindMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	if len(mappings) == 0 {
+		return 0, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  fmt.Sprintf("retention policy %q not found on database %q", retentionPolicy, database),
+		}
+	}
+	return mappings[0].BucketID, nil
+}
+
+// fromReplication returns the subscription a replication forwards the writes
+// of, if it is one of a subscription.
+func fromReplication(r influxdb.Replication) (*influxql.CreateSubscriptionStatement, bool) {
+	if r.Description == nil {
+		return nil, false
+	}
+	stmt, err := influxql.ParseStatement(*r.Description)
+	if err != nil {
+		return nil, false
+	}
+	sub, ok := stmt.(*influxql.CreateSubscriptionStatement)
+	return sub, ok
+}
+
+func errUnsupported(format string, args ...interface{}) error {
+	return &errors.Error{
+		Code: errors.EInvalid,
+		Msg:  "unsupported subscription: " + fmt.Sprintf(format, args...),
+	}
+}
@@ -0,0 +1,129 @@
+package subscription_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/v1/services/subscription"
+	"github.com/influxdata/influxql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// replicationService keeps its remote connections and replications in memory.
+type replicationService struct {
+	nextID       platform.ID
+	remotes      map[platform.ID]influxdb.RemoteConnection
+	replications []influxdb.Replication
+}
+
+func (s *replicationService) CreateRemoteConnection(ctx context.Context, r influxdb.CreateRemoteConnectionRequest) (*influxdb.RemoteConnection, error) {
+	s.nextID++
+	rc := influxdb.RemoteConnection{ID: s.nextID, OrgID: r.OrgID, Name: r.Name, RemoteURL: r.RemoteURL}
+	s.remotes[rc.ID] = rc
+	return &rc, nil
+}
+
+func (s *replicationService) DeleteRemoteConnection(ctx context.Context, id platform.ID) error {
+	delete(s.remotes, id)
+	return nil
+}
+
+func (s *replicationService) ListReplications(ctx context.Context, f influxdb.ReplicationListFilter) (*influxdb.Replications, error) {
+	var rs influxdb.Replications
+	for _, r := range s.replications {
+		if r.OrgID == f.OrgID {
+			rs.Replications = append(rs.Replications, r)
+		}
+	}
+	return &rs, nil
+}
+
+func (s *replicationService) CreateReplication(ctx context.Context, r influxdb.CreateReplicationRequest) (*influxdb.Replication, error) {
+	s.nextID++
+	rep := influxdb.Replication{
+		ID:               s.nextID,
+		OrgID:            r.OrgID,
+		Name:             r.Name,
+		Description:      r.Description,
+		RemoteID:         r.RemoteID,
+		LocalBucketID:    r.LocalBucketID,
+		RemoteBucketName: r.RemoteBucketName,
+	}
+	s.replications = append(s.replications, rep)
+	return &rep, nil
+}
+
+func (s *replicationService) DeleteReplication(ctx context.Context, id platform.ID) error {
+	for i, r := range s.replications {
+		if r.ID == id {
+			s.replications = append(s.replications[:i], s.replications[i+1:]...)
+			return nil
+		}
+	}
+	return &errors.Error{Code: errors.ENotFound, Msg: "replication not found"}
+}
+
+func TestService(t *testing.T) {
+	orgID := platform.ID(1)
+	ctx := context.Background()
+
+	dbrps := &mock.DBRPMappingService{
+		FindManyFn: func(ctx context.Context, f influxdb.DBRPMappingFilter, opts ...influxdb.FindOptions) ([]*influxdb.DBRPMapping, int, error) {
+			if *f.Database != "db" || *f.RetentionPolicy != "autogen" {
+				return nil, 0, nil
+			}
+			return []*influxdb.DBRPMapping{{Database: "db", RetentionPolicy: "autogen", BucketID: 10}}, 1, nil
+		},
+	}
+	rs := &replicationService{remotes: map[platform.ID]influxdb.RemoteConnection{}}
+	s := subscription.NewService(zaptest.NewLogger(t), rs, rs, dbrps)
+
+	create := func(q string) error {
+		stmt, err := influxql.ParseStatement(q)
+		require.NoError(t, err)
+		return s.CreateSubscription(ctx, orgID, stmt.(*influxql.CreateSubscriptionStatement))
+	}
+	require.NoError(t, create(`CREATE SUBSCRIPTION sub ON db.autogen DESTINATIONS ALL 'http://a:9092', 'http://b:9092'`))
+
+	subs, err := s.FindSubscriptions(ctx, orgID)
+	require.NoError(t, err)
+	require.Len(t, subs, 1)
+	assert.Equal(t, "db", subs[0].Database)
+	assert.Equal(t, "autogen", subs[0].RetentionPolicy)
+	assert.Equal(t, "sub", subs[0].Name)
+	assert.Equal(t, "ALL", subs[0].Mode)
+	assert.Equal(t, []string{"http://a:9092", "http://b:9092"}, subs[0].Destinations)
+	assert.Len(t, subs[0].ReplicationIDs, 2)
+
+	require.Len(t, rs.replications, 2)
+	assert.Equal(t, platform.ID(10), rs.replications[0].LocalBucketID)
+	assert.Equal(t, "db/autogen", rs.replications[0].RemoteBucketName)
+	assert.Equal(t, "http://a:9092", rs.remotes[rs.replications[0].RemoteID].RemoteURL)
+
+	err = create(`CREATE SUBSCRIPTION sub ON db.autogen DESTINATIONS ALL 'http://c:9092'`)
+	assert.Equal(t, errors.EConflict, errors.ErrorCode(err))
+
+	err = create(`CREATE SUBSCRIPTION udp ON db.autogen DESTINATIONS ALL 'udp://a:9100'`)
+	assert.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+
+	err = create(`CREATE SUBSCRIPTION balanced ON db.autogen DESTINATIONS ANY 'http://a:9092', 'http://b:9092'`)
+	assert.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+
+	err = create(`CREATE SUBSCRIPTION missing ON db.missing DESTINATIONS ALL 'http://a:9092'`)
+	assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+
+	require.NoError(t, s.DropSubscription(ctx, orgID, "db", "autogen", "sub"))
+	subs, err = s.FindSubscriptions(ctx, orgID)
+	require.NoError(t, err)
+	assert.Empty(t, subs)
+	assert.Empty(t, rs.remotes)
+
+	err = s.DropSubscription(ctx, orgID, "db", "autogen", "sub")
+	assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+}
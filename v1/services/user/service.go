@@ -0,0 +1,379 @@
+// Package user manages the users of the v1 query API, and their privileges,
+// as v1 authorizations.
+package user
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxql"
+)
+
+// User is a user of the v1 API.
+type User struct {
+	Name string
+	// Admin is whether the user may read and write all the buckets of the
+	// organization.
+	Admin           bool
+	AuthorizationID platform.ID
+}
+
+// AuthorizationService is the service of the v1 authorizations of the users.
+type AuthorizationService interface {
+	CreateAuthorization(ctx context.Context, a *influxdb.Authorization) error
+	FindAuthorizationByToken(ctx context.Context, token string) (*influxdb.Authorization, error)
+	FindAuthorizations(ctx context.Context, filter influxdb.AuthorizationFilter, opt ...influxdb.FindOptions) ([]*influxdb.Authorization, int, error)
+	// FindUsername returns the name of the user of an authorization, which is
+	// its token, kept unhashed when the token is stored hashed, or "" if it has
+	// none.
+	FindUsername(ctx context.Context, id platform.ID) (string, error)
+	UpdatePermissions(ctx context.Context, id platform.ID, perms []influxdb.Permission) (*influxdb.Authorization, error)
+	DeleteAuthorization(ctx context.Context, id platform.ID) error
+}
+
+// PasswordService sets the passwords of the v1 authorizations.
+type PasswordService interface {
+	SetPassword(ctx context.Context, id platform.ID, password string) error
+}
+
+// Service manages the users of the v1 API of organizations, and their
+// privileges. A user is the v1 authorization whose token is the name of the
+// user, and the privileges of a user on a database are the permissions of
+// the authorization on the buckets the database is mapped to.
+type Service struct {
+	auths     AuthorizationService
+	passwords PasswordService
+	dbrps     influxdb.DBRPMappingService
+}
+
+// NewService constructs a Service. The users are owned by the user of the
+// context they are created in.
+func NewService(auths AuthorizationService, passwords PasswordService, dbrps influxdb.DBRPMappingService) *Service {
+	return &Service{auths: auths, passwords: passwords, dbrps: dbrps}
+}
+
+// CreateUser creates a user with a password. An admin user is granted all
+// the privileges on the databases of the organization.
+func (s *Service) CreateUser(ctx context.Context, orgID platform.ID, name, password string, admin bool) error {
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		return err
+	}
+	if _, _, err := authorizer.AuthorizeCreate(ctx, influxdb.AuthorizationsResourceType, orgID); err != nil {
+		return err
+	}
+	if _, _, err := authorizer.AuthorizeWriteResource(ctx, influxdb.UsersResourceType, auth.GetUserID()); err != nil {
+		return err
+	}
+
+	var perms []influxdb.Permission
+	if admin {
+		perms = adminPermissions(orgID)
+	}
+	if err := authorizer.VerifyPermissions(ctx, perms); err != nil {
+		return err
+	}
+
+	a := &influxdb.Authorization{
+		Token:       name,
+		Status:      influxdb.Active,
+		Description: fmt.Sprintf("v1 user %s", name),
+		OrgID:       orgID,
+		UserID:      auth.GetUserID(),
+		Permissions: perms,
+	}
+	if err := s.auths.CreateAuthorization(ctx, a); err != nil {
+		return err
+	}
+	if err := s.passwords.SetPassword(ctx, a.ID, password); err != nil {
+		// the user is not created without its password.
+		if derr := s.auths.DeleteAuthorization(ctx, a.ID); derr != nil {
+			return derr
+		}
+		return err
+	}
+	return nil
+}
+
+// DropUser deletes a user.
+func (s *Service) DropUser(ctx context.Context, orgID platform.ID, name string) error {
+	a, err := s.findWritable(ctx, orgID, name)
+	if err != nil {
+		return err
+	}
+	return s.auths.DeleteAuthorization(ctx, a.ID)
+}
+
+// SetUserPassword overrides the password of a user.
+func (s *Service) SetUserPassword(ctx context.Context, orgID platform.ID, name, password string) error {
+	a, err := s.findWritable(ctx, orgID, name)
+	if err != nil {
+		return err
+	}
+	return s.passwords.SetPassword(ctx, a.ID, password)
+}
+
+// GrantPrivilege grants a privilege on a database to a user.
+func (s *Service) GrantPrivilege(ctx context.Context, orgID platform.ID, name, database string, p influxql.Privilege) error {
+	a, err := s.findWritable(ctx, orgID, name)
+	if err != nil {
+		return err
+	}
+	granted, err := s.databasePermissions(ctx, orgID, database, p)
+	if err != nil {
+		return err
+	}
+	if err := authorizer.VerifyPermissions(ctx, granted); err != nil {
+		return err
+	}
+
+	perms := a.Permissions
+	for _, g := range granted {
+		if !hasPermission(perms, g) {
+			perms = append(perms, g)
+		}
+	}
+	_, err = s.auths.UpdatePermissions(ctx, a.ID, perms)
+	return err
+}
+
+// RevokePrivilege revokes a privilege on a database from a user.
+func (s *Service) RevokePrivilege(ctx context.Context, orgID platform.ID, name, database string, p influxql.Privilege) error {
+	a, err := s.findWritable(ctx, orgID, name)
+	if err != nil {
+		return err
+	}
+	revoked, err := s.databasePermissions(ctx, orgID, database, p)
+	if err != nil {
+		return err
+	}
+	_, err = s.auths.UpdatePermissions(ctx, a.ID, removePermissions(a.Permissions, revoked))
+	return err
+}
+
+// SetUserAdmin grants or revokes all the privileges on the databases of the
+// organization to or from a user.
+func (s *Service) SetUserAdmin(ctx context.Context, orgID platform.ID, name string, admin bool) error {
+	a, err := s.findWritable(ctx, orgID, name)
+	if err != nil {
+		return err
+	}
+
+	perms := removePermissions(a.Permissions, adminPermissions(orgID))
+	if admin {
+		if err := authorizer.VerifyPermissions(ctx, adminPermissions(orgID)); err != nil {
+			return err
+		}
+		perms = append(perms, adminPermissions(orgID)...)
+	}
+	_, err = s.auths.UpdatePermissions(ctx, a.ID, perms)
+	return err
+}
+
+// FindUsers returns the users of an organization, sorted by name.
+func (s *Service) FindUsers(ctx context.Context, orgID platform.ID) ([]User, error) {
+	if _, _, err := authorizer.AuthorizeOrgReadResource(ctx, influxdb.AuthorizationsResourceType, orgID); err != nil {
+		return nil, err
+	}
+	auths, _, err := s.auths.FindAuthorizations(ctx, influxdb.AuthorizationFilter{OrgID: &orgID})
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]User, 0, len(auths))
+	for _, a := range auths {
+		name, err := s.auths.FindUsername(ctx, a.ID)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			// the token of the authorization was generated, and is stored hashed.
+			continue
+		}
+		users = append(users, User{
+			Name:            name,
+			Admin:           isAdmin(a.Permissions, orgID),
+			AuthorizationID: a.ID,
+		})
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Name < users[j].Name
+	})
+	return users, nil
+}
+
+// FindUserPrivileges returns the privileges of a user on the databases of the
+// organization. A user has a privilege on a database when it has it on all
+// the buckets the database is mapped to.
+func (s *Service) FindUserPrivileges(ctx context.Context, orgID platform.ID, name string) (map[string]influxql.Privilege, error) {
+	a, err := s.find(ctx, orgID, name)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizer.AuthorizeRead(ctx, influxdb.AuthorizationsResourceType, a.ID, orgID); err != nil {
+		return nil, err
+	}
+
+	mappings, _, err := s.dbrps.FindMany(ctx, influxdb.DBRPMappingFilter{OrgID: &orgID})
+	if err != nil {
+		return nil, err
+	}
+
+	read, write := map[string]bool{}, map[string]bool{}
+	for _, m := range mappings {
+		if _, ok := read[m.Database]; !ok {
+			read[m.Database], write[m.Database] = true, true
+		}
+		r, w := bucketPermissions(orgID, m.BucketID)
+		read[m.Database] = read[m.Database] && hasPermission(a.Permissions, r)
+		write[m.Database] = write[m.Database] && hasPermission(a.Permissions, w)
+	}
+
+	privileges := map[string]influxql.Privilege{}
+	for db := range read {
+		switch {
+		case read[db] && write[db]:
+			privileges[db] = influxql.AllPrivileges
+		case read[db]:
+			privileges[db] = influxql.ReadPrivilege
+		case write[db]:
+			privileges[db] = influxql.WritePrivilege
+		}
+	}
+	return privileges, nil
+}
+
+// find returns the authorization of a user of an organization.
+func (s *Service) find(ctx context.Context, orgID platform.ID, name string) (*influxdb.Authorization, error) {
+	a, err := s.auths.FindAuthorizationByToken(ctx, name)
+	if err != nil {
+		if errors.ErrorCode(err) == errors.ENotFound {
+			return nil, errUserNotFound(name)
+		}
+		return nil, err
+	}
+	if a.OrgID != orgID {
+		return nil, errUserNotFound(name)
+	}
+	return a, nil
+}
+
+// findWritable returns the authorization of a user of an organization, if
+// it may be written.
+func (s *Service) findWritable(ctx context.Context, orgID platform.ID, name string) (*influxdb.Authorization, error) {
+	a, err := s.find(ctx, orgID, name)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizer.AuthorizeWrite(ctx, influxdb.AuthorizationsResourceType, a.ID, orgID); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// databasePermissions returns the permissions of a privilege on the buckets
+// a database is mapped to.
+func (s *Service) databasePermissions(ctx context.Context, orgID platform.ID, database string, p influxql.Privilege) ([]influxdb.Permission, error) {
+	mappings, _, err := s.dbrps.FindMany(ctx, influxdb.DBRPMappingFilter{OrgID: &orgID, Database: &database})
+	if err != nil {
+		return nil, err
+	}
+	if len(mappings) == 0 {
+		return nil, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  fmt.Sprintf("database not found: %s", database),
+		}
+	}
+
+	var perms []influxdb.Permission
+	for _, m := range mappings {
+		r, w := bucketPermissions(orgID, m.BucketID)
+		switch p {
+		case influxql.ReadPrivilege:
+			perms = append(perms, r)
+		case influxql.WritePrivilege:
+			perms = append(perms, w)
+		case influxql.AllPrivileges:
+			perms = append(perms, r, w)
+		}
+	}
+	return perms, nil
+}
+
+func errUserNotFound(name string) error {
+	return &errors.Error{
+		Code: errors.ENotFound,
+		Msg:  fmt.Sprintf("user not found: %s", name),
+	}
+}
+
+// bucketPermissions returns the read and write permissions of a bucket.
+func bucketPermissions(orgID, bucketID platform.ID) (influxdb.Permission, influxdb.Permission) {
+	id := bucketID
+	r := influxdb.Permission{
+		Action:   influxdb.ReadAction,
+		Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, OrgID: &orgID, ID: &id},
+	}
+	w := influxdb.Permission{
+		Action:   influxdb.WriteAction,
+		Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, OrgID: &orgID, ID: &id},
+	}
+	return r, w
+}
+
+// adminPermissions returns the permissions of admin users, which are those
+// on all the buckets of the organization.
+func adminPermissions(orgID platform.ID) []influxdb.Permission {
+	return []influxdb.Permission{
+		{Action: influxdb.ReadAction, Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, OrgID: &orgID}},
+		{Action: influxdb.WriteAction, Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, OrgID: &orgID}},
+	}
+}
+
+func isAdmin(perms []influxdb.Permission, orgID platform.ID) bool {
+	for _, p := range adminPermissions(orgID) {
+		if !hasPermission(perms, p) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasPermission(perms []influxdb.Permission, p influxdb.Permission) bool {
+	for _, q := range perms {
+		if permissionEqual(p, q) {
+			return true
+		}
+	}
+	return false
+}
+
+func removePermissions(perms, removed []influxdb.Permission) []influxdb.Permission {
+	res := make([]influxdb.Permission, 0, len(perms))
+	for _, p := range perms {
+		if !hasPermission(removed, p) {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// permissionEqual returns whether two permissions are of the same action on
+// the same resource.
+func permissionEqual(p, q influxdb.Permission) bool {
+	return p.Action == q.Action && p.Resource.Type == q.Resource.Type &&
+		idEqual(p.Resource.OrgID, q.Resource.OrgID) && idEqual(p.Resource.ID, q.Resource.ID)
+}
+
+func idEqual(a, b *platform.ID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
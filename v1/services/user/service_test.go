@@ -0,0 +1,193 @@
+package user_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/tenant"
+	itesting "github.com/influxdata/influxdb/v2/testing"
+	authv1 "github.com/influxdata/influxdb/v2/v1/authorization"
+	"github.com/influxdata/influxdb/v2/v1/services/user"
+	"github.com/influxdata/influxql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// authService keeps its authorizations and their passwords in memory.
+type authService struct {
+	auths     []*influxdb.Authorization
+	passwords map[platform.ID]string
+}
+
+func (s *authService) CreateAuthorization(ctx context.Context, a *influxdb.Authorization) error {
+	a.ID = platform.ID(len(s.auths) + 1)
+	s.auths = append(s.auths, a)
+	return nil
+}
+
+func (s *authService) FindAuthorizationByToken(ctx context.Context, token string) (*influxdb.Authorization, error) {
+	for _, a := range s.auths {
+		if a.Token == token {
+			return a, nil
+		}
+	}
+	return nil, &errors.Error{Code: errors.ENotFound, Msg: "authorization not found"}
+}
+
+func (s *authService) FindAuthorizations(ctx context.Context, f influxdb.AuthorizationFilter, opt ...influxdb.FindOptions) ([]*influxdb.Authorization, int, error) {
+	var res []*influxdb.Authorization
+	for _, a := range s.auths {
+		if a.OrgID == *f.OrgID {
+			res = append(res, a)
+		}
+	}
+	return res, len(res), nil
+}
+
+func (s *authService) FindUsername(ctx context.Context, id platform.ID) (string, error) {
+	for _, a := range s.auths {
+		if a.ID == id {
+			return a.Token, nil
+		}
+	}
+	return "", &errors.Error{Code: errors.ENotFound, Msg: "authorization not found"}
+}
+
+func (s *authService) UpdatePermissions(ctx context.Context, id platform.ID, perms []influxdb.Permission) (*influxdb.Authorization, error) {
+	for _, a := range s.auths {
+		if a.ID == id {
+			a.Permissions = perms
+			return a, nil
+		}
+	}
+	return nil, &errors.Error{Code: errors.ENotFound, Msg: "authorization not found"}
+}
+
+func (s *authService) DeleteAuthorization(ctx context.Context, id platform.ID) error {
+	for i, a := range s.auths {
+		if a.ID == id {
+			s.auths = append(s.auths[:i], s.auths[i+1:]...)
+			return nil
+		}
+	}
+	return &errors.Error{Code: errors.ENotFound, Msg: "authorization not found"}
+}
+
+func (s *authService) SetPassword(ctx context.Context, id platform.ID, password string) error {
+	s.passwords[id] = password
+	return nil
+}
+
+func TestService(t *testing.T) {
+	orgID, userID := platform.ID(1), platform.ID(2)
+	ctx := icontext.SetAuthorizer(context.Background(), &mock.Authorizer{AllowAll: true, UserID: userID})
+
+	dbrps := &mock.DBRPMappingService{
+		FindManyFn: func(ctx context.Context, f influxdb.DBRPMappingFilter, opts ...influxdb.FindOptions) ([]*influxdb.DBRPMapping, int, error) {
+			mappings := []*influxdb.DBRPMapping{
+				{Database: "db", RetentionPolicy: "autogen", BucketID: 10, OrganizationID: orgID},
+				{Database: "db", RetentionPolicy: "downsampled", BucketID: 20, OrganizationID: orgID},
+			}
+			if f.Database != nil && *f.Database != "db" {
+				return nil, 0, nil
+			}
+			return mappings, len(mappings), nil
+		},
+	}
+	auths := &authService{passwords: map[platform.ID]string{}}
+	s := user.NewService(auths, auths, dbrps)
+
+	require.NoError(t, s.CreateUser(ctx, orgID, "alice", "secret", false))
+	require.NoError(t, s.CreateUser(ctx, orgID, "bob", "secret", true))
+	require.Len(t, auths.auths, 2)
+	assert.Equal(t, userID, auths.auths[0].UserID)
+	assert.Equal(t, "secret", auths.passwords[auths.auths[0].ID])
+
+	users, err := s.FindUsers(ctx, orgID)
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "alice", users[0].Name)
+	assert.False(t, users[0].Admin)
+	assert.True(t, users[1].Admin)
+
+	require.NoError(t, s.GrantPrivilege(ctx, orgID, "alice", "db", influxql.ReadPrivilege))
+	privileges, err := s.FindUserPrivileges(ctx, orgID, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]influxql.Privilege{"db": influxql.ReadPrivilege}, privileges)
+
+	require.NoError(t, s.GrantPrivilege(ctx, orgID, "alice", "db", influxql.WritePrivilege))
+	privileges, err = s.FindUserPrivileges(ctx, orgID, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]influxql.Privilege{"db": influxql.AllPrivileges}, privileges)
+
+	require.NoError(t, s.RevokePrivilege(ctx, orgID, "alice", "db", influxql.ReadPrivilege))
+	privileges, err = s.FindUserPrivileges(ctx, orgID, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]influxql.Privilege{"db": influxql.WritePrivilege}, privileges)
+
+	err = s.GrantPrivilege(ctx, orgID, "alice", "missing", influxql.ReadPrivilege)
+	assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+
+	require.NoError(t, s.SetUserAdmin(ctx, orgID, "bob", false))
+	users, err = s.FindUsers(ctx, orgID)
+	require.NoError(t, err)
+	assert.False(t, users[1].Admin)
+
+	require.NoError(t, s.SetUserPassword(ctx, orgID, "alice", "changed"))
+	assert.Equal(t, "changed", auths.passwords[auths.auths[0].ID])
+
+	require.NoError(t, s.DropUser(ctx, orgID, "alice"))
+	err = s.DropUser(ctx, orgID, "alice")
+	assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+
+	err = s.DropUser(ctx, platform.ID(3), "bob")
+	assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+}
+
+func TestService_TokenHashing(t *testing.T) {
+	ctx := context.Background()
+	kvStore := itesting.NewTestInmemStore(t)
+	ts := tenant.NewService(tenant.NewStore(kvStore))
+	u := &influxdb.User{Name: "owner"}
+	require.NoError(t, ts.CreateUser(ctx, u))
+	o := &influxdb.Organization{Name: "org"}
+	require.NoError(t, ts.CreateOrganization(ctx, o))
+	ctx = icontext.SetAuthorizer(ctx, &mock.Authorizer{AllowAll: true, UserID: u.ID})
+
+	authStore, err := authv1.NewStore(kvStore)
+	require.NoError(t, err)
+	auths := authv1.NewService(authStore, ts, authv1.WithTokenHashing(true))
+	s := user.NewService(auths, auths, &mock.DBRPMappingService{
+		FindManyFn: func(ctx context.Context, f influxdb.DBRPMappingFilter, opts ...influxdb.FindOptions) ([]*influxdb.DBRPMapping, int, error) {
+			return nil, 0, nil
+		},
+	})
+
+	// The users are listed by name although their tokens are stored hashed.
+	require.NoError(t, s.CreateUser(ctx, o.ID, "alice", "secret-password", true))
+	users, err := s.FindUsers(ctx, o.ID)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "alice", users[0].Name)
+	assert.True(t, users[0].Admin)
+	stored, err := auths.FindAuthorizationByID(ctx, users[0].AuthorizationID)
+	require.NoError(t, err)
+	assert.NotEqual(t, "alice", stored.Token)
+
+	_, err = s.FindUserPrivileges(ctx, o.ID, "alice")
+	require.NoError(t, err)
+
+	// An authorization with a rotated token names no user anymore.
+	_, err = auths.RotateAuthorizationToken(ctx, users[0].AuthorizationID)
+	require.NoError(t, err)
+	users, err = s.FindUsers(ctx, o.ID)
+	require.NoError(t, err)
+	assert.Empty(t, users)
+	err = s.DropUser(ctx, o.ID, "alice")
+	assert.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+}
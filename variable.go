@@ -6,13 +6,20 @@ import (
 	"fmt"
 	"net/url"
 	"regexp"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 )
 
 // ErrVariableNotFound is the error msg for a missing variable.
 const ErrVariableNotFound = "variable not found"
 
+// ErrVariableDependencyCycle is the error msg for variables whose queries depend on each other.
+const ErrVariableDependencyCycle = "variable dependency cycle"
+
 // ops for variable error.
 const (
 	OpFindVariableByID = "FindVariableByID"
@@ -44,6 +51,21 @@ type VariableService interface {
 	DeleteVariable(ctx context.Context, id platform.ID) error
 }
 
+// VariableValuesService evaluates the values of variables.
+type VariableValuesService interface {
+	// FindVariableValues returns the values a variable can take. The values of query
+	// variables are cached until they are older than the variable's refresh interval,
+	// or queried again if refresh is set.
+	FindVariableValues(ctx context.Context, id platform.ID, refresh bool) (*VariableValues, error)
+}
+
+// VariableValues are the values a variable can take, as evaluated by the server.
+type VariableValues struct {
+	VariableID  platform.ID `json:"variableID"`
+	Values      []string    `json:"values"`
+	RefreshedAt time.Time   `json:"refreshedAt"`
+}
+
 // A Variable describes a keyword that can be expanded into several possible
 // values when used in an InfluxQL or Flux query
 type Variable struct {
@@ -104,6 +126,9 @@ type VariableArguments struct {
 type VariableQueryValues struct {
 	Query    string `json:"query"`
 	Language string `json:"language"` // "influxql" or "flux"
+	// RefreshSeconds is how long the values of the query are cached by the
+	// server. The server's default is used if it is zero.
+	RefreshSeconds int64 `json:"refreshSeconds,omitempty"`
 }
 
 // VariableConstantValues are the data for expanding a constants-based Variable
@@ -242,6 +267,14 @@ func (a *VariableArguments) UnmarshalJSON(data []byte) error {
 			return fmt.Errorf("expected \"language\" to be string but received %T", language)
 		}
 
+		if refresh, prs := values["refreshSeconds"]; prs {
+			seconds, ok := refresh.(float64)
+			if !ok || seconds < 0 {
+				return fmt.Errorf("expected \"refreshSeconds\" to be a non-negative number but received %v", refresh)
+			}
+			variableValues.RefreshSeconds = int64(seconds)
+		}
+
 		variableValues.Query = query.(string)
 		variableValues.Language = language.(string)
 		a.Values = variableValues
@@ -251,3 +284,131 @@ func (a *VariableArguments) UnmarshalJSON(data []byte) error {
 
 	return nil
 }
+
+// variableReferencePattern matches references to other variables in a Flux query,
+// which are made through the v record, as in dashboard cell queries.
+var variableReferencePattern = regexp.MustCompile(`\bv\.([a-zA-Z_][a-zA-Z0-9_]*)`)
+
+// References returns the names referenced as v.<name> by the variable's query,
+// sorted and without duplicates. Only Flux query variables can reference others.
+// Names which are not variables, such as v.timeRangeStart, are included too.
+func (m *Variable) References() []string {
+	if m.Arguments == nil || m.Arguments.Type != "query" {
+		return nil
+	}
+	q, ok := m.Arguments.Values.(VariableQueryValues)
+	if !ok || q.Language != "flux" {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, match := range variableReferencePattern.FindAllStringSubmatch(q.Query, -1) {
+		if name := match[1]; !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SelectedValue returns the value of the variable's current selection. The first
+// selection is used; a map variable's selection is a key, whose value is returned.
+// Constant and map variables without a selection use their first value.
+func (m *Variable) SelectedValue() (string, bool) {
+	var selected string
+	if len(m.Selected) > 0 {
+		selected = m.Selected[0]
+	}
+	if m.Arguments == nil {
+		return selected, selected != ""
+	}
+
+	switch values := m.Arguments.Values.(type) {
+	case VariableConstantValues:
+		if selected == "" && len(values) > 0 {
+			selected = values[0]
+		}
+	case VariableMapValues:
+		if selected == "" {
+			keys := make([]string, 0, len(values))
+			for k := range values {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			if len(keys) > 0 {
+				selected = keys[0]
+			}
+		}
+		v, ok := values[selected]
+		return v, ok
+	}
+	return selected, selected != ""
+}
+
+// ResolveVariableDependencies returns the variables m depends on, directly or
+// through other variables, ordered so every variable comes after those it depends
+// on. Dependencies are looked up by name in vars, which should hold every variable
+// of m's organization. An error is returned if the dependencies contain a cycle.
+func ResolveVariableDependencies(m *Variable, vars []*Variable) ([]*Variable, error) {
+	byName := make(map[string]*Variable, len(vars))
+	for _, v := range vars {
+		byName[v.Name] = v
+	}
+	// m may not have been stored yet, or may be an updated copy of a stored variable.
+	byName[m.Name] = m
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	var (
+		state = make(map[string]int)
+		path  []string
+		deps  []*Variable
+	)
+	var visit func(v *Variable) error
+	visit = func(v *Variable) error {
+		switch state[v.Name] {
+		case visited:
+			return nil
+		case visiting:
+			start := 0
+			for i, name := range path {
+				if name == v.Name {
+					start = i
+				}
+			}
+			cycle := append(path[start:], v.Name)
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("%s: %s", ErrVariableDependencyCycle, strings.Join(cycle, " -> ")),
+			}
+		}
+
+		state[v.Name] = visiting
+		path = append(path, v.Name)
+		for _, name := range v.References() {
+			dep, ok := byName[name]
+			if !ok {
+				continue
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[v.Name] = visited
+
+		if v != m {
+			deps = append(deps, v)
+		}
+		return nil
+	}
+
+	if err := visit(m); err != nil {
+		return nil, err
+	}
+	return deps, nil
+}
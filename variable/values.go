@@ -0,0 +1,289 @@
+// Package variable evaluates the values of variables on the server, caching the
+// values of query variables so they are not queried on every dashboard load.
+package variable
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/jsonweb"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/query"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultRefreshInterval is how long the values of query variables are cached when
+// the variable does not set its refresh interval.
+const DefaultRefreshInterval = 5 * time.Minute
+
+var _ influxdb.VariableValuesService = (*ValuesService)(nil)
+
+// ValuesService evaluates the values of variables. The values of Flux query
+// variables are the distinct strings of the _value column of their results, which
+// are cached per authorization, so users do not see values they may not query.
+type ValuesService struct {
+	log             *zap.Logger
+	variables       influxdb.VariableService
+	queries         query.QueryService
+	refreshInterval time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedValues
+	group singleflight.Group
+
+	TimeGenerator influxdb.TimeGenerator
+}
+
+type cachedValues struct {
+	values      []string
+	refreshedAt time.Time
+	expiresAt   time.Time
+}
+
+// NewValuesService constructs a ValuesService looking variables up in variables
+// and querying their values with queries. Query variables without a refresh
+// interval are cached for refreshInterval.
+func NewValuesService(log *zap.Logger, variables influxdb.VariableService, queries query.QueryService, refreshInterval time.Duration) *ValuesService {
+	return &ValuesService{
+		log:             log,
+		variables:       variables,
+		queries:         queries,
+		refreshInterval: refreshInterval,
+		cache:           make(map[string]cachedValues),
+		TimeGenerator:   influxdb.RealTimeGenerator{},
+	}
+}
+
+// FindVariableValues returns the values of a variable. The values of constant and
+// map variables are their constants and their keys.
+func (s *ValuesService) FindVariableValues(ctx context.Context, id platform.ID, refresh bool) (*influxdb.VariableValues, error) {
+	v, err := s.variables.FindVariableByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.Arguments == nil {
+		return nil, &errors.Error{
+			Code: errors.EInternal,
+			Msg:  fmt.Sprintf("variable %q has no arguments", v.Name),
+		}
+	}
+
+	var values []string
+	refreshedAt := s.TimeGenerator.Now()
+	switch args := v.Arguments.Values.(type) {
+	case influxdb.VariableConstantValues:
+		values = append(values, args...)
+	case influxdb.VariableMapValues:
+		for k := range args {
+			values = append(values, k)
+		}
+		sort.Strings(values)
+	case influxdb.VariableQueryValues:
+		values, refreshedAt, err = s.queryValues(ctx, v, args, refresh)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, &errors.Error{
+			Code: errors.EInternal,
+			Msg:  fmt.Sprintf("variable %q has unknown arguments %T", v.Name, args),
+		}
+	}
+
+	if values == nil {
+		values = []string{}
+	}
+	return &influxdb.VariableValues{
+		VariableID:  v.ID,
+		Values:      values,
+		RefreshedAt: refreshedAt,
+	}, nil
+}
+
+func (s *ValuesService) queryValues(ctx context.Context, v *influxdb.Variable, q influxdb.VariableQueryValues, refresh bool) ([]string, time.Time, error) {
+	if q.Language != "flux" {
+		return nil, time.Time{}, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("the values of %s query variables cannot be evaluated by the server", q.Language),
+		}
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	extern, err := s.extern(ctx, v)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	// The values depend on the query, on the selected values of the variables it
+	// depends on, and on what the authorization may read.
+	key := strings.Join([]string{auth.Identifier().String(), v.ID.String(), q.Query, string(extern)}, "\x00")
+	if !refresh {
+		s.mu.Lock()
+		c, ok := s.cache[key]
+		s.mu.Unlock()
+		if ok && s.TimeGenerator.Now().Before(c.expiresAt) {
+			return c.values, c.refreshedAt, nil
+		}
+	}
+
+	// Concurrent loads of the same values share a single query.
+	res, err, _ := s.group.Do(key, func() (interface{}, error) {
+		values, err := s.query(ctx, auth, v.OrganizationID, q.Query, extern)
+		if err != nil {
+			return nil, err
+		}
+
+		interval := s.refreshInterval
+		if q.RefreshSeconds > 0 {
+			interval = time.Duration(q.RefreshSeconds) * time.Second
+		}
+		now := s.TimeGenerator.Now()
+		c := cachedValues{values: values, refreshedAt: now, expiresAt: now.Add(interval)}
+		s.store(key, c, now)
+		return c, nil
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	c := res.(cachedValues)
+	s.log.Debug("Variable values queried", zap.Stringer("variable_id", v.ID), zap.Int("values", len(c.values)))
+	return c.values, c.refreshedAt, nil
+}
+
+// store caches values, dropping the cached values which expired.
+func (s *ValuesService) store(key string, c cachedValues, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, cached := range s.cache {
+		if !now.Before(cached.expiresAt) {
+			delete(s.cache, k)
+		}
+	}
+	s.cache[key] = c
+}
+
+// extern returns the extern defining the selected values of the variables v
+// depends on, in the v record.
+func (s *ValuesService) extern(ctx context.Context, v *influxdb.Variable) ([]byte, error) {
+	if len(v.References()) == 0 {
+		return nil, nil
+	}
+
+	orgID := v.OrganizationID
+	vars, err := s.variables.FindVariables(ctx, influxdb.VariableFilter{OrganizationID: &orgID})
+	if err != nil {
+		return nil, err
+	}
+	deps, err := influxdb.ResolveVariableDependencies(v, vars)
+	if err != nil {
+		return nil, err
+	}
+	if len(deps) == 0 {
+		return nil, nil
+	}
+
+	props := make([]*ast.Property, 0, len(deps))
+	for _, dep := range deps {
+		value, ok := dep.SelectedValue()
+		if !ok {
+			return nil, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("variable %q has no selected value", dep.Name),
+			}
+		}
+		props = append(props, &ast.Property{
+			Key:   &ast.Identifier{Name: dep.Name},
+			Value: &ast.StringLiteral{Value: value},
+		})
+	}
+	return json.Marshal(&ast.File{
+		Body: []ast.Statement{&ast.OptionStatement{
+			Assignment: &ast.VariableAssignment{
+				ID:   &ast.Identifier{Name: "v"},
+				Init: &ast.ObjectExpression{Properties: props},
+			},
+		}},
+	})
+}
+
+func (s *ValuesService) query(ctx context.Context, auth influxdb.Authorizer, orgID platform.ID, q string, extern []byte) ([]string, error) {
+	var token *influxdb.Authorization
+	switch a := auth.(type) {
+	case *influxdb.Authorization:
+		token = a
+	case *influxdb.Session:
+		token = a.EphemeralAuth(orgID)
+	case *jsonweb.Token:
+		token = a.EphemeralAuth(orgID)
+	default:
+		return nil, influxdb.ErrAuthorizerNotSupported
+	}
+
+	it, err := s.queries.Query(ctx, &query.Request{
+		Authorization:  token,
+		OrganizationID: orgID,
+		Compiler: lang.FluxCompiler{
+			Query:  q,
+			Extern: extern,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer it.Release()
+
+	r := &valuesReader{seen: make(map[string]bool)}
+	for it.More() {
+		if err := it.Next().Tables().Do(r.readTable); err != nil {
+			return nil, err
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return r.values, nil
+}
+
+// valuesReader reads the distinct strings of the _value column of tables.
+type valuesReader struct {
+	values []string
+	seen   map[string]bool
+}
+
+func (r *valuesReader) readTable(tbl flux.Table) error {
+	return tbl.Do(func(cr flux.ColReader) error {
+		for j, col := range cr.Cols() {
+			if col.Label != "_value" || col.Type != flux.TString {
+				continue
+			}
+			vs := cr.Strings(j)
+			for i := 0; i < cr.Len(); i++ {
+				if !vs.IsValid(i) {
+					continue
+				}
+				if value := vs.Value(i); !r.seen[value] {
+					r.seen[value] = true
+					r.values = append(r.values, value)
+				}
+			}
+		}
+		return nil
+	})
+}
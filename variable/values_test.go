@@ -0,0 +1,171 @@
+package variable_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/query"
+	querymock "github.com/influxdata/influxdb/v2/query/mock"
+	"github.com/influxdata/influxdb/v2/variable"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+var (
+	orgID = platform.ID(1)
+	now   = time.Date(2021, 1, 2, 3, 0, 0, 0, time.UTC)
+)
+
+func newVariableService(vars ...*influxdb.Variable) *mock.VariableService {
+	vs := mock.NewVariableService()
+	vs.FindVariableByIDF = func(ctx context.Context, id platform.ID) (*influxdb.Variable, error) {
+		for _, v := range vars {
+			if v.ID == id {
+				return v, nil
+			}
+		}
+		return nil, &errors.Error{Code: errors.ENotFound, Msg: influxdb.ErrVariableNotFound}
+	}
+	vs.FindVariablesF = func(ctx context.Context, f influxdb.VariableFilter, opts ...influxdb.FindOptions) ([]*influxdb.Variable, error) {
+		return vars, nil
+	}
+	return vs
+}
+
+// newQueryService returns a query service whose results have a single table of
+// the values, counting its queries.
+func newQueryService(queries *int, values ...string) *querymock.QueryService {
+	return &querymock.QueryService{
+		QueryF: func(ctx context.Context, req *query.Request) (flux.ResultIterator, error) {
+			*queries++
+			tbl := &executetest.Table{
+				ColMeta: []flux.ColMeta{{Label: "_value", Type: flux.TString}},
+			}
+			for _, v := range values {
+				tbl.Data = append(tbl.Data, []interface{}{v})
+			}
+			return flux.NewSliceResultIterator([]flux.Result{
+				&executetest.Result{Nm: "_result", Tbls: []*executetest.Table{tbl}},
+			}), nil
+		},
+	}
+}
+
+func TestValuesService_Static(t *testing.T) {
+	ctx := icontext.SetAuthorizer(context.Background(), &influxdb.Authorization{ID: 10, OrgID: orgID})
+	constant := &influxdb.Variable{
+		ID:             2,
+		OrganizationID: orgID,
+		Name:           "constant",
+		Arguments:      &influxdb.VariableArguments{Type: "constant", Values: influxdb.VariableConstantValues{"b", "a"}},
+	}
+	mapped := &influxdb.Variable{
+		ID:             3,
+		OrganizationID: orgID,
+		Name:           "map",
+		Arguments:      &influxdb.VariableArguments{Type: "map", Values: influxdb.VariableMapValues{"b": "B", "a": "A"}},
+	}
+	var queries int
+	svc := variable.NewValuesService(zaptest.NewLogger(t), newVariableService(constant, mapped), newQueryService(&queries), time.Minute)
+
+	values, err := svc.FindVariableValues(ctx, constant.ID, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"b", "a"}, values.Values)
+
+	values, err = svc.FindVariableValues(ctx, mapped.ID, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, values.Values)
+	require.Zero(t, queries)
+
+	_, err = svc.FindVariableValues(ctx, 4, false)
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(err))
+}
+
+func TestValuesService_Query(t *testing.T) {
+	ctx := icontext.SetAuthorizer(context.Background(), &influxdb.Authorization{ID: 10, OrgID: orgID})
+	host := &influxdb.Variable{
+		ID:             2,
+		OrganizationID: orgID,
+		Name:           "host",
+		Arguments: &influxdb.VariableArguments{
+			Type:   "query",
+			Values: influxdb.VariableQueryValues{Query: `from(bucket: "b") |> keep(columns: ["host"])`, Language: "flux", RefreshSeconds: 60},
+		},
+	}
+	var queries int
+	svc := variable.NewValuesService(zaptest.NewLogger(t), newVariableService(host), newQueryService(&queries, "a", "b", "a"), time.Minute)
+	svc.TimeGenerator = mock.TimeGenerator{FakeValue: now}
+
+	values, err := svc.FindVariableValues(ctx, host.ID, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, values.Values)
+	require.Equal(t, now, values.RefreshedAt)
+	require.Equal(t, 1, queries)
+
+	// The values are cached until they are refreshed.
+	svc.TimeGenerator = mock.TimeGenerator{FakeValue: now.Add(30 * time.Second)}
+	values, err = svc.FindVariableValues(ctx, host.ID, false)
+	require.NoError(t, err)
+	require.Equal(t, now, values.RefreshedAt)
+	require.Equal(t, 1, queries)
+
+	values, err = svc.FindVariableValues(ctx, host.ID, true)
+	require.NoError(t, err)
+	require.Equal(t, now.Add(30*time.Second), values.RefreshedAt)
+	require.Equal(t, 2, queries)
+
+	svc.TimeGenerator = mock.TimeGenerator{FakeValue: now.Add(2 * time.Minute)}
+	_, err = svc.FindVariableValues(ctx, host.ID, false)
+	require.NoError(t, err)
+	require.Equal(t, 3, queries)
+
+	// Values are not shared between authorizations.
+	other := icontext.SetAuthorizer(context.Background(), &influxdb.Authorization{ID: 11, OrgID: orgID})
+	_, err = svc.FindVariableValues(other, host.ID, false)
+	require.NoError(t, err)
+	require.Equal(t, 4, queries)
+}
+
+func TestValuesService_QueryDependencies(t *testing.T) {
+	ctx := icontext.SetAuthorizer(context.Background(), &influxdb.Authorization{ID: 10, OrgID: orgID})
+	bucket := &influxdb.Variable{
+		ID:             2,
+		OrganizationID: orgID,
+		Name:           "bucket",
+		Selected:       []string{"b"},
+		Arguments:      &influxdb.VariableArguments{Type: "constant", Values: influxdb.VariableConstantValues{"a", "b"}},
+	}
+	host := &influxdb.Variable{
+		ID:             3,
+		OrganizationID: orgID,
+		Name:           "host",
+		Arguments: &influxdb.VariableArguments{
+			Type:   "query",
+			Values: influxdb.VariableQueryValues{Query: `from(bucket: v.bucket) |> range(start: v.timeRangeStart)`, Language: "flux"},
+		},
+	}
+	queries := &querymock.QueryService{
+		QueryF: func(ctx context.Context, req *query.Request) (flux.ResultIterator, error) {
+			require.Equal(t, orgID, req.OrganizationID)
+			require.Equal(t, platform.ID(10), req.Authorization.ID)
+			compiler := req.Compiler.(lang.FluxCompiler)
+			require.Contains(t, string(compiler.Extern), `"bucket"`)
+			require.NotContains(t, string(compiler.Extern), `"timeRangeStart"`)
+			return flux.NewSliceResultIterator(nil), nil
+		},
+	}
+	svc := variable.NewValuesService(zaptest.NewLogger(t), newVariableService(bucket, host), queries, time.Minute)
+
+	values, err := svc.FindVariableValues(ctx, host.ID, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{}, values.Values)
+}
@@ -6,7 +6,9 @@ import (
 	"testing"
 
 	platform "github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	platformtesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -123,6 +125,37 @@ func TestVariable_UnmarshalJSON(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "with query arguments refreshed",
+			json: `
+{ 
+  "id": "debac1e0deadbeef",
+  "name": "howdy",
+  "selected": [],
+  "arguments": {
+    "type": "query",
+    "values": {
+      "query": "howdy",
+      "language": "flux",
+      "refreshSeconds": 60
+    }
+  }
+}
+`,
+			want: platform.Variable{
+				ID:       platformtesting.MustIDBase16(variableTestID),
+				Name:     "howdy",
+				Selected: make([]string, 0),
+				Arguments: &platform.VariableArguments{
+					Type: "query",
+					Values: platform.VariableQueryValues{
+						Query:          "howdy",
+						Language:       "flux",
+						RefreshSeconds: 60,
+					},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -140,3 +173,54 @@ func TestVariable_UnmarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func newQueryVariable(name, query string) *platform.Variable {
+	return &platform.Variable{
+		Name: name,
+		Arguments: &platform.VariableArguments{
+			Type:   "query",
+			Values: platform.VariableQueryValues{Query: query, Language: "flux"},
+		},
+	}
+}
+
+func TestVariable_References(t *testing.T) {
+	v := newQueryVariable("disk", `from(bucket: v.bucket) |> range(start: v.timeRangeStart) |> filter(fn: (r) => r.host == v.host and r.region == v.region and r.host != v.host)`)
+	require.Equal(t, []string{"bucket", "host", "region", "timeRangeStart"}, v.References())
+
+	v.Arguments.Values = platform.VariableQueryValues{Query: `SHOW TAG VALUES WITH KEY = "host" WHERE region = v.region`, Language: "influxql"}
+	require.Empty(t, v.References())
+}
+
+func TestResolveVariableDependencies(t *testing.T) {
+	region := &platform.Variable{
+		Name:     "region",
+		Selected: []string{"us-west"},
+		Arguments: &platform.VariableArguments{
+			Type:   "constant",
+			Values: platform.VariableConstantValues{"us-east", "us-west"},
+		},
+	}
+	host := newQueryVariable("host", `from(bucket: "telegraf") |> filter(fn: (r) => r.region == v.region)`)
+	disk := newQueryVariable("disk", `from(bucket: "telegraf") |> filter(fn: (r) => r.host == v.host and r.region == v.region)`)
+
+	deps, err := platform.ResolveVariableDependencies(disk, []*platform.Variable{disk, host, region})
+	require.NoError(t, err)
+	require.Equal(t, []*platform.Variable{region, host}, deps)
+
+	deps, err = platform.ResolveVariableDependencies(region, []*platform.Variable{disk, host, region})
+	require.NoError(t, err)
+	require.Empty(t, deps)
+
+	value, ok := region.SelectedValue()
+	require.True(t, ok)
+	require.Equal(t, "us-west", value)
+	_, ok = host.SelectedValue()
+	require.False(t, ok)
+
+	// Making region depend on disk closes a cycle.
+	cyclic := newQueryVariable("region", `from(bucket: "telegraf") |> filter(fn: (r) => r.disk == v.disk)`)
+	_, err = platform.ResolveVariableDependencies(cyclic, []*platform.Variable{disk, host, region})
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+	require.Contains(t, err.Error(), "region -> disk -> host -> region")
+}
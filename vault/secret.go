@@ -3,8 +3,11 @@ package vault
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"path"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/vault/api"
@@ -14,9 +17,23 @@ import (
 
 var _ platform.SecretService = (*SecretService)(nil)
 
+const (
+	// DefaultMountPath is the path of the KV v2 secrets engine the secrets
+	// are stored in.
+	DefaultMountPath = "secret"
+
+	// DefaultAppRoleMountPath is the path of the AppRole auth method.
+	DefaultAppRoleMountPath = "approle"
+)
+
 // SecretService is service for storing user secrets
 type SecretService struct {
 	Client *api.Client
+
+	mountPath string
+	appRole   AppRoleConfig
+	// auth is the token of the latest AppRole login.
+	auth *api.Secret
 }
 
 // Config may setup the vault client configuration. If any field is a zero
@@ -27,9 +44,23 @@ type Config struct {
 	ClientTimeout time.Duration
 	MaxRetries    int
 	Token         string
+	// MountPath is the path of the KV v2 secrets engine, it defaults to
+	// DefaultMountPath.
+	MountPath string
+	AppRoleConfig
 	TLSConfig
 }
 
+// AppRoleConfig is the configuration for the AppRole auth method. The
+// service logs in with the role when a RoleID is provided, instead of using
+// the token.
+type AppRoleConfig struct {
+	RoleID   string
+	SecretID string
+	// AppRoleMountPath defaults to DefaultAppRoleMountPath.
+	AppRoleMountPath string
+}
+
 // TLSConfig is the configuration for TLS.
 type TLSConfig struct {
 	CACert             string
@@ -120,11 +151,145 @@ func NewSecretService(cfgOpts ...ConfigOptFn) (*SecretService, error) {
 		c.SetToken(explicitConfig.Token)
 	}
 
-	return &SecretService{
-		Client: c,
+	s := &SecretService{
+		Client:    c,
+		mountPath: explicitConfig.MountPath,
+		appRole:   explicitConfig.AppRoleConfig,
+	}
+	if s.appRole.RoleID != "" {
+		if err := s.login(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// login logs in with the AppRole, and sets the token of the client.
+func (s *SecretService) login() error {
+	mount := s.appRole.AppRoleMountPath
+	if mount == "" {
+		mount = DefaultAppRoleMountPath
+	}
+
+	sec, err := s.Client.Logical().Write(path.Join("auth", mount, "login"), map[string]interface{}{
+		"role_id":   s.appRole.RoleID,
+		"secret_id": s.appRole.SecretID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to login with vault approle: %v", err)
+	}
+	if sec == nil || sec.Auth == nil || sec.Auth.ClientToken == "" {
+		return errors.New("vault approle login returned no token")
+	}
+
+	s.Client.SetToken(sec.Auth.ClientToken)
+	s.auth = sec
+	return nil
+}
+
+// RenewToken keeps the token of the service renewed until ctx is done. The
+// service logs in again with its AppRole when the token reaches its max TTL,
+// and returns an error when a token without a role can no longer be renewed.
+// Tokens which do not expire, like root tokens, are not renewed.
+func (s *SecretService) RenewToken(ctx context.Context) error {
+	auth := s.auth
+	for {
+		if auth == nil {
+			token, err := s.lookupToken()
+			if err != nil {
+				return err
+			}
+			if token == nil {
+				return nil
+			}
+			auth = token
+		}
+
+		if err := s.renew(ctx, auth); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if s.appRole.RoleID == "" {
+			return errors.New("vault token reached its max ttl and can no longer be renewed")
+		}
+		if err := s.login(); err != nil {
+			return err
+		}
+		auth = s.auth
+	}
+}
+
+// renew renews auth until it can no longer be renewed, or ctx is done. A
+// token which is not renewable is waited on until it is close to expiring.
+func (s *SecretService) renew(ctx context.Context, auth *api.Secret) error {
+	if !auth.Auth.Renewable {
+		lease := time.Duration(auth.Auth.LeaseDuration) * time.Second
+		select {
+		case <-ctx.Done():
+		case <-time.After(lease * 2 / 3):
+		}
+		return nil
+	}
+
+	renewer, err := s.Client.NewRenewer(&api.RenewerInput{Secret: auth})
+	if err != nil {
+		return err
+	}
+	go renewer.Renew()
+	defer renewer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-renewer.DoneCh():
+		if err != nil && s.appRole.RoleID == "" {
+			return fmt.Errorf("failed to renew vault token: %v", err)
+		}
+		return nil
+	}
+}
+
+// lookupToken returns the auth of the token of the client, or nil when the
+// token does not expire.
+func (s *SecretService) lookupToken() (*api.Secret, error) {
+	sec, err := s.Client.Auth().Token().LookupSelf()
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup vault token: %v", err)
+	}
+
+	ttl, err := sec.TokenTTL()
+	if err != nil {
+		return nil, err
+	}
+	if ttl == 0 {
+		return nil, nil
+	}
+	renewable, err := sec.TokenIsRenewable()
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.Secret{
+		Auth: &api.SecretAuth{
+			ClientToken:   s.Client.Token(),
+			Renewable:     renewable,
+			LeaseDuration: int(ttl.Seconds()),
+		},
 	}, nil
 }
 
+// dataPath is the path of the secrets of an organization in the KV v2
+// secrets engine.
+func (s *SecretService) dataPath(orgID platform2.ID) string {
+	mount := strings.Trim(s.mountPath, "/")
+	if mount == "" {
+		mount = DefaultMountPath
+	}
+	return fmt.Sprintf("/%s/data/%s", mount, orgID)
+}
+
 // LoadSecret retrieves the secret value v found at key k for organization orgID.
 func (s *SecretService) LoadSecret(ctx context.Context, orgID platform2.ID, k string) (string, error) {
 	data, _, err := s.loadSecrets(ctx, orgID)
@@ -142,8 +307,7 @@ func (s *SecretService) LoadSecret(ctx context.Context, orgID platform2.ID, k st
 // loadSecrets retrieves a map of secrets for an organization and the version of the secrets retrieved.
 // The version is used to ensure that concurrent updates will not overwrite one another.
 func (s *SecretService) loadSecrets(ctx context.Context, orgID platform2.ID) (map[string]string, int, error) {
-	// TODO(desa): update url construction
-	sec, err := s.Client.Logical().Read(fmt.Sprintf("/secret/data/%s", orgID))
+	sec, err := s.Client.Logical().Read(s.dataPath(orgID))
 	if err != nil {
 		return nil, -1, err
 	}
@@ -233,7 +397,7 @@ func (s *SecretService) putSecrets(ctx context.Context, orgID platform2.ID, data
 		m["options"] = map[string]interface{}{"cas": version}
 	}
 
-	if _, err := s.Client.Logical().Write(fmt.Sprintf("/secret/data/%s", orgID), m); err != nil {
+	if _, err := s.Client.Logical().Write(s.dataPath(orgID), m); err != nil {
 		return err
 	}
 